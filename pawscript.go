@@ -15,6 +15,8 @@
 package pawscript
 
 import (
+	"crypto/ed25519"
+	"io"
 	"time"
 
 	impl "github.com/phroun/pawscript/src"
@@ -93,6 +95,113 @@ const (
 // FileAccessConfig controls file system access permissions.
 type FileAccessConfig = impl.FileAccessConfig
 
+// PermissionProfile is a named set of command restrictions.
+type PermissionProfile = impl.PermissionProfile
+
+// ResourceQuotas caps the files, bytes written and processes scripts may use.
+type ResourceQuotas = impl.ResourceQuotas
+
+// QuotaError reports which resource quota a command would have exceeded.
+type QuotaError = impl.QuotaError
+
+// ErrQuotaExceeded is wrapped by every QuotaError.
+var ErrQuotaExceeded = impl.ErrQuotaExceeded
+
+// AuditAction is the kind of access a sandbox audit entry records.
+type AuditAction = impl.AuditAction
+
+// Audit actions.
+const (
+	AuditRead    = impl.AuditRead
+	AuditWrite   = impl.AuditWrite
+	AuditExec    = impl.AuditExec
+	AuditConnect = impl.AuditConnect
+	AuditCommand = impl.AuditCommand
+)
+
+// AuditEntry is one sandbox decision recorded in the audit log.
+type AuditEntry = impl.AuditEntry
+
+// PermissionDecision is the answer to a PermissionRequest.
+type PermissionDecision = impl.PermissionDecision
+
+// Permission decisions.
+const (
+	PermissionDeny        = impl.PermissionDeny
+	PermissionAllowOnce   = impl.PermissionAllowOnce
+	PermissionAllowAlways = impl.PermissionAllowAlways
+)
+
+// PermissionRequest describes a file access a script made outside its roots.
+type PermissionRequest = impl.PermissionRequest
+
+// PermissionPrompt answers a PermissionRequest, usually by asking the user.
+type PermissionPrompt = impl.PermissionPrompt
+
+// ErrDryRun is returned for accesses a dry run records but does not perform.
+var ErrDryRun = impl.ErrDryRun
+
+// DryRunReport lists what a script accessed during a dry run.
+type DryRunReport = impl.DryRunReport
+
+// ErrCancelled is returned for subprocesses not started because of Cancel.
+var ErrCancelled = impl.ErrCancelled
+
+// SignatureExt is appended to a script's filename to find its detached signature.
+const SignatureExt = impl.SignatureExt
+
+// ErrUnsigned is wrapped by errors for scripts without a signature file.
+var ErrUnsigned = impl.ErrUnsigned
+
+// ErrUntrustedSignature is wrapped by errors for signatures no trusted key made.
+var ErrUntrustedSignature = impl.ErrUntrustedSignature
+
+// DefaultEnvAllowlist holds environment variables that are safe to show sandboxed scripts.
+var DefaultEnvAllowlist = impl.DefaultEnvAllowlist
+
+// ExecRequest describes a subprocess exec is about to start.
+type ExecRequest = impl.ExecRequest
+
+// ExecHook inspects an exec before it runs, and may refuse or rewrite it.
+type ExecHook = impl.ExecHook
+
+// Debugger pauses scripts at breakpoints and steps through them.
+type Debugger = impl.Debugger
+
+// DebugAction is how a paused script goes on.
+type DebugAction = impl.DebugAction
+
+const (
+	DebugContinue = impl.DebugContinue
+	DebugStepIn   = impl.DebugStepIn
+	DebugStepOver = impl.DebugStepOver
+	DebugStepOut  = impl.DebugStepOut
+)
+
+// Breakpoint is a line of a script file to pause before.
+type Breakpoint = impl.Breakpoint
+
+// DebugFrame is a macro call on the stack of a paused script.
+type DebugFrame = impl.DebugFrame
+
+// DebugVariable is a variable in scope where a script paused.
+type DebugVariable = impl.DebugVariable
+
+// DebugPause is where a script paused.
+type DebugPause = impl.DebugPause
+
+// DialogHost shows native dialogs for the gui:: commands.
+type DialogHost = impl.DialogHost
+
+// FileDialogOptions describes a file dialog a script asked for.
+type FileDialogOptions = impl.FileDialogOptions
+
+// ListDelta changes one FileAccessConfig list.
+type ListDelta = impl.ListDelta
+
+// SandboxDelta describes changes to apply to a FileAccessConfig.
+type SandboxDelta = impl.SandboxDelta
+
 // DisplayColorConfig holds display color settings.
 type DisplayColorConfig = impl.DisplayColorConfig
 
@@ -115,6 +224,9 @@ type StoredChannel = impl.StoredChannel
 // StoredFile is an open file handle.
 type StoredFile = impl.StoredFile
 
+// StoredImage is a mutable RGBA image.
+type StoredImage = impl.StoredImage
+
 // StoredMacro is a macro stored as a reference-counted object.
 type StoredMacro = impl.StoredMacro
 
@@ -234,6 +346,34 @@ type PSLConfig = impl.PSLConfig
 // PSLList is a list for PSL serialization.
 type PSLList = impl.PSLList
 
+// PSLSchemaError is a problem found by ValidatePSL.
+type PSLSchemaError = impl.PSLSchemaError
+
+// PSLDocument is a PSL map that keeps its comments and layout.
+type PSLDocument = impl.PSLDocument
+
+// PSLColor is an RGB color read from a PSL value.
+type PSLColor = impl.PSLColor
+
+// PSLChange is a key-level difference found by DiffPSL.
+type PSLChange = impl.PSLChange
+
+// PSLChangeKind says whether a key was added, removed or modified.
+type PSLChangeKind = impl.PSLChangeKind
+
+// PSL change kinds.
+const (
+	PSLAdded    = impl.PSLAdded
+	PSLRemoved  = impl.PSLRemoved
+	PSLModified = impl.PSLModified
+)
+
+// PSLConflict is a key both sides of a MergePSL changed differently.
+type PSLConflict = impl.PSLConflict
+
+// PSLStreamParser reads PSL from a reader one item at a time.
+type PSLStreamParser = impl.PSLStreamParser
+
 // =============================================================================
 // ASYNC AND FIBER TYPES
 // =============================================================================
@@ -286,6 +426,11 @@ func New(config *Config) *PawScript {
 	return impl.New(config)
 }
 
+// NewDebugger creates a debugger with no breakpoints, to set as Config.Debugger.
+func NewDebugger(onPause func(pause *DebugPause)) *Debugger {
+	return impl.NewDebugger(onPause)
+}
+
 // DefaultConfig returns a new Config with default values.
 func DefaultConfig() *Config {
 	return impl.DefaultConfig()
@@ -296,6 +441,71 @@ func DefaultDisplayColors() DisplayColorConfig {
 	return impl.DefaultDisplayColors()
 }
 
+// ParseHostPatterns splits a comma-separated list of host patterns, checking each one.
+func ParseHostPatterns(list string) ([]string, error) {
+	return impl.ParseHostPatterns(list)
+}
+
+// RegisterPermissionProfile adds a profile that LookupPermissionProfile can find by name.
+func RegisterPermissionProfile(profile *PermissionProfile) {
+	impl.RegisterPermissionProfile(profile)
+}
+
+// LookupPermissionProfile returns a copy of the named permission profile.
+func LookupPermissionProfile(name string) (*PermissionProfile, bool) {
+	return impl.LookupPermissionProfile(name)
+}
+
+// PermissionProfileNames returns the names of the permission profiles, sorted.
+func PermissionProfileNames() []string {
+	return impl.PermissionProfileNames()
+}
+
+// DefaultTrustedKeysPath returns ~/.paw/trusted_keys.
+func DefaultTrustedKeysPath() string {
+	return impl.DefaultTrustedKeysPath()
+}
+
+// LoadTrustedKeys reads a trusted keys file; a missing file has no keys.
+func LoadTrustedKeys(path string) ([]ed25519.PublicKey, error) {
+	return impl.LoadTrustedKeys(path)
+}
+
+// EncodePublicKey formats a public key for a trusted keys file.
+func EncodePublicKey(key ed25519.PublicKey) string {
+	return impl.EncodePublicKey(key)
+}
+
+// DecodePublicKey parses a public key written by EncodePublicKey.
+func DecodePublicKey(text string) (ed25519.PublicKey, error) {
+	return impl.DecodePublicKey(text)
+}
+
+// EncodeSigningKey formats a private key for saving to a key file.
+func EncodeSigningKey(key ed25519.PrivateKey) string {
+	return impl.EncodeSigningKey(key)
+}
+
+// DecodeSigningKey parses a private key written by EncodeSigningKey.
+func DecodeSigningKey(text string) (ed25519.PrivateKey, error) {
+	return impl.DecodeSigningKey(text)
+}
+
+// SignScript returns the detached signature file contents for a script.
+func SignScript(content []byte, key ed25519.PrivateKey) []byte {
+	return impl.SignScript(content, key)
+}
+
+// DenyShellMetacharacters is an ExecHook that refuses arguments a shell would interpret.
+func DenyShellMetacharacters(request ExecRequest) (ExecRequest, error) {
+	return impl.DenyShellMetacharacters(request)
+}
+
+// VerifyScriptSignature checks a detached signature against the trusted keys.
+func VerifyScriptSignature(content, signature []byte, keys []ed25519.PublicKey) error {
+	return impl.VerifyScriptSignature(content, signature, keys)
+}
+
 // =============================================================================
 // EXECUTION STATE CONSTRUCTORS
 // =============================================================================
@@ -349,6 +559,11 @@ func NewStoredChannel(bufferSize int) *StoredChannel {
 	return impl.NewStoredChannel(bufferSize)
 }
 
+// NewStoredImage creates a transparent image of the given size.
+func NewStoredImage(width, height int) *StoredImage {
+	return impl.NewStoredImage(width, height)
+}
+
 // NewStoredCommand creates a new stored command.
 func NewStoredCommand(name string, handler Handler) StoredCommand {
 	return impl.NewStoredCommand(name, handler)
@@ -443,6 +658,147 @@ func ParsePSLList(input string) (PSLList, error) {
 	return impl.ParsePSLList(input)
 }
 
+// ParsePSLDocument parses a PSL map, keeping its comments and layout.
+func ParsePSLDocument(input string) (*PSLDocument, error) {
+	return impl.ParsePSLDocument(input)
+}
+
+// UpdatePSL rewrites PSL text to hold config, keeping comments and unchanged entries.
+func UpdatePSL(original string, config PSLMap) (string, error) {
+	return impl.UpdatePSL(original, config)
+}
+
+// DiffPSL lists the key-level differences between two PSL maps.
+func DiffPSL(oldMap, newMap PSLMap) []PSLChange {
+	return impl.DiffPSL(oldMap, newMap)
+}
+
+// MergePSL merges two sets of changes to the same base map, reporting conflicts.
+func MergePSL(base, ours, theirs PSLMap) (PSLMap, []PSLConflict) {
+	return impl.MergePSL(base, ours, theirs)
+}
+
+// ClonePSLValue deep-copies a PSL value.
+func ClonePSLValue(value interface{}) interface{} {
+	return impl.ClonePSLValue(value)
+}
+
+// ParsePSLDuration reads a duration such as "1m30s"; a plain number is seconds.
+func ParsePSLDuration(value interface{}) (time.Duration, error) {
+	return impl.ParsePSLDuration(value)
+}
+
+// ParsePSLColor reads a color written as "#RRGGBB" or "#RGB".
+func ParsePSLColor(value interface{}) (PSLColor, error) {
+	return impl.ParsePSLColor(value)
+}
+
+// ParsePSLSize reads a byte count such as "2.5MB"; a plain number is bytes.
+func ParsePSLSize(value interface{}) (int64, error) {
+	return impl.ParsePSLSize(value)
+}
+
+// EncryptPSL seals a section as an (@encrypted: ...) block, keyed from a SecretStore.
+// The block only opens when stored at path, the dotted key path such as "servers.prod".
+func EncryptPSL(path string, section PSLMap, store SecretStore) (PSLMap, error) {
+	return impl.EncryptPSL(path, section, store)
+}
+
+// DecryptPSL returns a copy of config with its encrypted blocks opened.
+func DecryptPSL(config PSLMap, store SecretStore) (PSLMap, error) {
+	return impl.DecryptPSL(config, store)
+}
+
+// IsEncryptedPSL reports whether a value is an encrypted block.
+func IsEncryptedPSL(value interface{}) bool {
+	return impl.IsEncryptedPSL(value)
+}
+
+// ParsePSLWithSecrets parses PSL and opens its encrypted blocks.
+func ParsePSLWithSecrets(input string, store SecretStore) (PSLMap, error) {
+	return impl.ParsePSLWithSecrets(input, store)
+}
+
+// PSLToJSON converts PSL text to JSON, keeping named and positional items.
+func PSLToJSON(input string) (string, error) {
+	return impl.PSLToJSON(input)
+}
+
+// JSONToPSL converts a JSON object or array to PSL text.
+func JSONToPSL(input string) (string, error) {
+	return impl.JSONToPSL(input)
+}
+
+// NewPSLStreamParser creates a stream parser reading from r.
+func NewPSLStreamParser(r io.Reader) *PSLStreamParser {
+	return impl.NewPSLStreamParser(r)
+}
+
+// ParsePSLStream calls onItem for each item of the PSL list read from r.
+func ParsePSLStream(r io.Reader, onItem func(key string, value interface{}) error) error {
+	return impl.ParsePSLStream(r, onItem)
+}
+
+// ValidatePSL checks a config against a PSL schema and returns the problems found.
+func ValidatePSL(config PSLMap, schema PSLMap) []PSLSchemaError {
+	return impl.ValidatePSL(config, schema)
+}
+
+// =============================================================================
+// LOCALIZATION
+// =============================================================================
+
+// Catalog is a message catalog loaded from PSL files.
+type Catalog = impl.Catalog
+
+// LocaleInfo describes number and date conventions for a locale.
+type LocaleInfo = impl.LocaleInfo
+
+// NewCatalog creates an empty message catalog for a locale.
+func NewCatalog(locale string) *Catalog {
+	return impl.NewCatalog(locale)
+}
+
+// DetectLocale returns the user's locale from the environment.
+func DetectLocale() string {
+	return impl.DetectLocale()
+}
+
+// NormalizeLocale converts a locale tag to the form "ll_CC".
+func NormalizeLocale(locale string) string {
+	return impl.NormalizeLocale(locale)
+}
+
+// GetLocaleInfo returns the formatting conventions for a locale.
+func GetLocaleInfo(locale string) LocaleInfo {
+	return impl.GetLocaleInfo(locale)
+}
+
+// FormatNumber formats a number with locale separators.
+func FormatNumber(n float64, decimals int, locale string) string {
+	return impl.FormatNumber(n, decimals, locale)
+}
+
+// FormatDate formats a time using locale conventions.
+func FormatDate(t time.Time, style, locale string) (string, error) {
+	return impl.FormatDate(t, style, locale)
+}
+
+// =============================================================================
+// SECRET STORAGE
+// =============================================================================
+
+// SecretStore is a backend for the secret_get/secret_set commands.
+type SecretStore = impl.SecretStore
+
+// ErrSecretNotFound is returned by a SecretStore when a key isn't stored.
+var ErrSecretNotFound = impl.ErrSecretNotFound
+
+// NewKeychainStore returns a SecretStore backed by the OS keychain.
+func NewKeychainStore() SecretStore {
+	return impl.NewKeychainStore()
+}
+
 // =============================================================================
 // REPL AND TERMINAL
 // =============================================================================
@@ -482,11 +838,116 @@ type IOChannelConfig = impl.IOChannelConfig
 // CHANNEL FUNCTIONS
 // =============================================================================
 
+// ChannelClose closes a channel. A send blocked waiting for room returns
+// ErrChannelClosed, as do sends after it.
+func ChannelClose(ch *StoredChannel) error {
+	return impl.ChannelClose(ch)
+}
+
 // ChannelRecv receives a message from a channel.
 func ChannelRecv(ch *StoredChannel) (int, interface{}, error) {
 	return impl.ChannelRecv(ch)
 }
 
+// ChannelRecvTimeout receives a message, waiting up to timeout for one to arrive.
+// A negative timeout waits forever; zero only checks once.
+func ChannelRecvTimeout(ch *StoredChannel, timeout time.Duration) (int, interface{}, error) {
+	return impl.ChannelRecvTimeout(ch, timeout)
+}
+
+// ChannelSelect waits until one of the channels has a message and receives it.
+// Returns the index of the channel read, the sender ID, and the value.
+// A negative timeout waits forever; zero only checks once.
+func ChannelSelect(chs []*StoredChannel, timeout time.Duration) (int, int, interface{}, error) {
+	return impl.ChannelSelect(chs, timeout)
+}
+
+// ErrChannelTimeout is returned by ChannelSelect when no channel became ready in time.
+var ErrChannelTimeout = impl.ErrChannelTimeout
+
+// ErrChannelsClosed is returned by ChannelSelect when every channel is closed.
+var ErrChannelsClosed = impl.ErrChannelsClosed
+
+// ErrChannelClosed is returned when sending to a closed channel, and by receives once a
+// closed channel has been drained.
+var ErrChannelClosed = impl.ErrChannelClosed
+
+// ChannelOverflow is the policy for sending to a bounded channel whose buffer is full.
+type ChannelOverflow = impl.ChannelOverflow
+
+// Channel overflow policies.
+const (
+	OverflowError      = impl.OverflowError
+	OverflowBlock      = impl.OverflowBlock
+	OverflowDropOldest = impl.OverflowDropOldest
+	OverflowDropNewest = impl.OverflowDropNewest
+)
+
+// ParseChannelOverflow converts a script policy name (error, block, drop_oldest, drop_newest).
+func ParseChannelOverflow(name string) (ChannelOverflow, bool) {
+	return impl.ParseChannelOverflow(name)
+}
+
+// TopicBus routes messages published on named topics to pattern subscriptions.
+type TopicBus = impl.TopicBus
+
+// NewTopicBus creates an empty topic bus.
+func NewTopicBus() *TopicBus {
+	return impl.NewTopicBus()
+}
+
+// MatchTopic reports whether a dot-separated topic matches a subscription pattern.
+func MatchTopic(pattern, topic string) bool {
+	return impl.MatchTopic(pattern, topic)
+}
+
+// QueueSend puts an item on the Go queue behind a native channel, applying the
+// channel's Overflow policy when the queue is full and counting drops in its Stats.
+// Call it from NativeSend, which runs with the channel lock held; under OverflowBlock
+// the lock is released while waiting for room, and closing the channel ends the wait.
+// Don't close queue while a script can still send to it; close the channel instead.
+func QueueSend[T any](ch *StoredChannel, queue chan T, item T) error {
+	return impl.QueueSend(ch, queue, item)
+}
+
+// NewChannelFromGo creates a native channel backed by Go channels.
+// Script sends are delivered on sendCh (subject to the channel's Overflow policy) and
+// receives read from recvCh; either may be nil for a one-way channel.
+func NewChannelFromGo(sendCh chan interface{}, recvCh <-chan interface{}, caps *TerminalCapabilities) *StoredChannel {
+	return impl.NewChannelFromGo(sendCh, recvCh, caps)
+}
+
+// ChannelToGo bridges a StoredChannel to Go channels: messages on ch arrive on the
+// returned receive channel, values sent on the returned send channel go to ch, and sends
+// ch refuses are reported on the returned error channel. Closing done stops the bridge.
+func ChannelToGo(ch *StoredChannel, buffer int, done <-chan struct{}) (<-chan interface{}, chan<- interface{}, <-chan error) {
+	return impl.ChannelToGo(ch, buffer, done)
+}
+
+// DetachableChannel returns a stand-in for ch that passes everything through to it until
+// detach is called, after which the stand-in reads as closed and ch is left untouched.
+func DetachableChannel(ch *StoredChannel) (*StoredChannel, func()) {
+	return impl.DetachableChannel(ch)
+}
+
+// ConsoleBytes converts a value sent to a console output channel into terminal bytes,
+// normalizing newlines to \r\n.
+func ConsoleBytes(v interface{}) []byte {
+	return impl.ConsoleBytes(v)
+}
+
+// ChannelCounters tracks the messages that have passed through a channel.
+type ChannelCounters = impl.ChannelCounters
+
+// ChannelStats is a snapshot of a channel's queue depth and message counters.
+type ChannelStats = impl.ChannelStats
+
+// ChannelGetStats returns a snapshot of a channel's queue depth and message counters.
+// Frontends can poll this for console channels to show an I/O health indicator.
+func ChannelGetStats(ch *StoredChannel) ChannelStats {
+	return impl.ChannelGetStats(ch)
+}
+
 // =============================================================================
 // FORMATTING FUNCTIONS
 // =============================================================================
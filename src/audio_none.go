@@ -0,0 +1,13 @@
+//go:build !audio
+
+package pawscript
+
+import "errors"
+
+// audioAvailable reports whether sound output was compiled in
+const audioAvailable = false
+
+// playPCM always fails: sound output needs the audio build tag
+func playPCM(pcm []byte) error {
+	return errors.New("audio support not compiled in (build with -tags audio)")
+}
@@ -0,0 +1,51 @@
+//go:build audio
+
+package pawscript
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	"github.com/ebitengine/oto/v3"
+)
+
+// audioAvailable reports whether sound output was compiled in
+const audioAvailable = true
+
+var (
+	otoContext     *oto.Context
+	otoContextErr  error
+	otoContextOnce sync.Once
+)
+
+// audioContext returns the process-wide oto context (oto allows only one)
+func audioContext() (*oto.Context, error) {
+	otoContextOnce.Do(func() {
+		var ready chan struct{}
+		otoContext, ready, otoContextErr = oto.NewContext(&oto.NewContextOptions{
+			SampleRate:   audioSampleRate,
+			ChannelCount: 2,
+			Format:       oto.FormatSignedInt16LE,
+		})
+		if otoContextErr == nil {
+			<-ready
+		}
+	})
+	return otoContext, otoContextErr
+}
+
+// playPCM plays 16-bit stereo PCM at audioSampleRate and blocks until it finishes
+func playPCM(pcm []byte) error {
+	ctx, err := audioContext()
+	if err != nil {
+		return err
+	}
+	player := ctx.NewPlayer(bytes.NewReader(pcm))
+	defer player.Close()
+	player.Play()
+	for player.IsPlaying() {
+		time.Sleep(5 * time.Millisecond)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package pawscript
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Sandbox audit log
+// With Config.AuditLimit set, every sandbox decision is recorded: each file a command
+// opens or inspects, each exec, each network connection checked with CheckHostAccess,
+// and each command a permission profile refuses. Hosts read the log with AuditLog to
+// show users what a script touched. Only the newest AuditLimit entries are kept.
+
+// AuditAction is the kind of access an audit entry records
+type AuditAction string
+
+const (
+	AuditRead    AuditAction = "read"    // File read or inspected
+	AuditWrite   AuditAction = "write"   // File written, created or removed
+	AuditExec    AuditAction = "exec"    // Subprocess started
+	AuditConnect AuditAction = "connect" // Network connection
+	AuditCommand AuditAction = "command" // Command run (recorded when a profile denies it)
+)
+
+// AuditEntry is one recorded sandbox decision
+type AuditEntry struct {
+	Time     time.Time
+	Command  string // Command that asked for access
+	Action   AuditAction
+	Target   string // Path, program, host or command name, as the script gave it
+	Allowed  bool
+	Reason   string          // Why access was denied ("" if allowed)
+	Position *SourcePosition // Where in the script the command was (nil if unknown)
+}
+
+// String formats the entry as one line, e.g. "script.paw:3:1 file write out.txt: allowed"
+func (e AuditEntry) String() string {
+	where := "?"
+	if e.Position != nil {
+		where = fmt.Sprintf("%s:%d:%d", e.Position.Filename, e.Position.Line, e.Position.Column)
+	}
+	decision := "allowed"
+	if !e.Allowed {
+		decision = "denied (" + e.Reason + ")"
+	}
+	return fmt.Sprintf("%s %s %s %s: %s", where, e.Command, e.Action, e.Target, decision)
+}
+
+// auditLog holds the newest audit entries, between the limit and twice the limit
+type auditLog struct {
+	mu      sync.Mutex
+	entries []AuditEntry
+}
+
+// audit records a sandbox decision made for a command; err is the denial, or nil
+func (ps *PawScript) audit(ctx *Context, command string, action AuditAction, target string, err error) {
+	limit := 0
+	if ps.config != nil {
+		limit = ps.config.AuditLimit
+	}
+	if limit <= 0 {
+		return
+	}
+	entry := AuditEntry{
+		Time:    time.Now(),
+		Command: command,
+		Action:  action,
+		Target:  target,
+		Allowed: err == nil,
+	}
+	if err != nil {
+		entry.Reason = err.Error()
+	}
+	if ctx != nil {
+		entry.Position = ctx.Position
+	}
+
+	ps.auditLog.mu.Lock()
+	defer ps.auditLog.mu.Unlock()
+	ps.auditLog.entries = append(ps.auditLog.entries, entry)
+	if len(ps.auditLog.entries) >= 2*limit {
+		// Drop the oldest entries in batches, copying so they can be freed
+		kept := ps.auditLog.entries[len(ps.auditLog.entries)-limit:]
+		ps.auditLog.entries = append(make([]AuditEntry, 0, 2*limit), kept...)
+	}
+}
+
+// checkPathAccess validates a path like validatePathAccess and records the decision
+// In a dry run the sandbox is skipped: the access is recorded, and writes return ErrDryRun.
+func (ps *PawScript) checkPathAccess(ctx *Context, command, path string, needsWrite bool) (string, error) {
+	validate := ps.validatePathAccess
+	if ps.dryRun() {
+		validate = ps.dryRunPath
+	}
+	absPath, err := validate(path, needsWrite)
+	action := AuditRead
+	if needsWrite {
+		action = AuditWrite
+	}
+	ps.audit(ctx, command, action, path, err)
+	return absPath, err
+}
+
+// CheckPathAccess reports whether a command may read (or write) path under the
+// sandbox's roots, returning its absolute path, and records the decision
+// Commands registered by a host that open files should call it first.
+func (ps *PawScript) CheckPathAccess(ctx *Context, command, path string, needsWrite bool) (string, error) {
+	return ps.checkPathAccess(ctx, command, path, needsWrite)
+}
+
+// CheckHostAccess reports whether a command may connect to address (a host or
+// host:port) under the sandbox's host lists, and records the decision
+// Network commands registered by a host should call it before connecting. In a dry run
+// it records the host and returns ErrDryRun, which such commands may treat as success.
+func (ps *PawScript) CheckHostAccess(ctx *Context, command, address string) error {
+	var err error
+	if ps.dryRun() {
+		ps.recordDryRun(AuditConnect, address)
+		err = ErrDryRun
+	} else if ps.config != nil {
+		err = ps.config.FileAccess.CheckHost(address)
+	}
+	ps.audit(ctx, command, AuditConnect, address, err)
+	return err
+}
+
+// AuditLog returns a copy of the recorded audit entries, oldest first
+func (ps *PawScript) AuditLog() []AuditEntry {
+	ps.auditLog.mu.Lock()
+	defer ps.auditLog.mu.Unlock()
+	entries, limit := ps.auditLog.entries, 0
+	if ps.config != nil {
+		limit = max(ps.config.AuditLimit, 0)
+	}
+	if len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return append([]AuditEntry(nil), entries...)
+}
+
+// ClearAuditLog discards the recorded audit entries
+func (ps *PawScript) ClearAuditLog() {
+	ps.auditLog.mu.Lock()
+	defer ps.auditLog.mu.Unlock()
+	ps.auditLog.entries = nil
+}
@@ -0,0 +1,148 @@
+package pawscript
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"sync"
+)
+
+// Cancellation
+// Cancel stops an interpreter's scripts from another goroutine, as a host's Stop
+// button does. Every command that would run afterwards fails without running, so
+// scripts unwind as if each command had returned false: loops and macros end,
+// and the script's Execute call returns. The subprocesses exec is waiting on are
+// killed, commands waiting on async operations (msleep, and loops waiting on
+// them) or on channels (select, for over a channel, channel_recv with timeout:)
+// are woken to fail, a script paused by the debugger is resumed to stop, and
+// what Shutdown releases is released. A command busy in the host, such as read
+// waiting for input, ends the script when it returns. Cancellation lasts: an
+// interpreter that was cancelled runs nothing more.
+
+// ErrCancelled is returned for subprocesses not started, and channel waits given
+// up, because of Cancel
+var ErrCancelled = errors.New("script cancelled")
+
+// cancelState holds what Cancel has to stop
+type cancelState struct {
+	mu        sync.Mutex
+	processes map[*os.Process]bool    // Subprocesses exec is waiting on
+	done      chan struct{}           // Closed by Cancel, for channel waits
+	streams   map[*StoredChannel]bool // Channels fed by readers, closed by Shutdown
+}
+
+// Cancel stops the interpreter's scripts, killing the subprocesses they started
+func (ps *PawScript) Cancel() {
+	ps.cancel.mu.Lock()
+	ps.executor.cancelled.Store(true)
+	processes := ps.cancel.processes
+	ps.cancel.processes = nil
+	if ps.cancel.done == nil {
+		ps.cancel.done = make(chan struct{})
+	}
+	select {
+	case <-ps.cancel.done:
+	default:
+		close(ps.cancel.done)
+	}
+	ps.cancel.mu.Unlock()
+
+	for process := range processes {
+		process.Kill()
+	}
+	ps.Shutdown()
+	if ps.config != nil && ps.config.Debugger != nil {
+		ps.config.Debugger.Resume(DebugContinue)
+	}
+	ps.executor.failActiveTokens()
+}
+
+// Cancelled reports whether Cancel has been called
+func (ps *PawScript) Cancelled() bool {
+	return ps.executor.cancelled.Load()
+}
+
+// cancelDone returns a channel Cancel closes, for waits to give up on
+func (ps *PawScript) cancelDone() <-chan struct{} {
+	ps.cancel.mu.Lock()
+	defer ps.cancel.mu.Unlock()
+	if ps.cancel.done == nil {
+		ps.cancel.done = make(chan struct{})
+	}
+	return ps.cancel.done
+}
+
+// trackStream records a channel a reader goroutine feeds, for Shutdown to close so
+// the reader stops even if the script stopped receiving
+func (ps *PawScript) trackStream(ch *StoredChannel) {
+	ps.cancel.mu.Lock()
+	defer ps.cancel.mu.Unlock()
+	if ps.cancel.streams == nil {
+		ps.cancel.streams = make(map[*StoredChannel]bool)
+	}
+	ps.cancel.streams[ch] = true
+}
+
+// untrackStream forgets a channel whose reader has finished
+func (ps *PawScript) untrackStream(ch *StoredChannel) {
+	ps.cancel.mu.Lock()
+	defer ps.cancel.mu.Unlock()
+	delete(ps.cancel.streams, ch)
+}
+
+// closeStreams closes the channels readers feed, ending the readers
+func (ps *PawScript) closeStreams() {
+	ps.cancel.mu.Lock()
+	streams := ps.cancel.streams
+	ps.cancel.streams = nil
+	ps.cancel.mu.Unlock()
+	for ch := range streams {
+		_ = ChannelClose(ch)
+	}
+}
+
+// runProcess runs a subprocess for exec, where Cancel can kill it
+func (ps *PawScript) runProcess(cmd *exec.Cmd) error {
+	ps.cancel.mu.Lock()
+	if ps.executor.cancelled.Load() {
+		ps.cancel.mu.Unlock()
+		return ErrCancelled
+	}
+	if err := cmd.Start(); err != nil {
+		ps.cancel.mu.Unlock()
+		return err
+	}
+	if ps.cancel.processes == nil {
+		ps.cancel.processes = make(map[*os.Process]bool)
+	}
+	ps.cancel.processes[cmd.Process] = true
+	ps.cancel.mu.Unlock()
+
+	err := cmd.Wait()
+
+	ps.cancel.mu.Lock()
+	delete(ps.cancel.processes, cmd.Process)
+	ps.cancel.mu.Unlock()
+	return err
+}
+
+// failActiveTokens resumes every async operation still waiting as failed, so the
+// commands waiting on them go on to fail
+func (e *Executor) failActiveTokens() {
+	e.mu.RLock()
+	tokens := make([]string, 0, len(e.activeTokens))
+	for tokenID := range e.activeTokens {
+		tokens = append(tokens, tokenID)
+	}
+	e.mu.RUnlock()
+
+	for _, tokenID := range tokens {
+		// Resuming one token may have finished others in its chain
+		e.mu.RLock()
+		_, exists := e.activeTokens[tokenID]
+		e.mu.RUnlock()
+		if exists {
+			e.PopAndResumeCommandSequence(tokenID, false)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package pawscript
+
+import (
+	"testing"
+	"time"
+)
+
+// executeThenCancel runs a script that waits, cancels it once it is waiting,
+// and reports whether Execute returned
+func executeThenCancel(t *testing.T, script string) {
+	t.Helper()
+	ps := New(nil)
+	ps.RegisterStandardLibrary([]string{})
+
+	done := make(chan Result, 1)
+	go func() {
+		done <- ps.Execute(script)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	ps.Cancel()
+
+	select {
+	case result := <-done:
+		if status, ok := result.(BoolStatus); ok && bool(status) {
+			t.Errorf("expected the cancelled script to fail, got %v", result)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Execute did not return after Cancel")
+	}
+}
+
+func TestCancelWakesWaits(t *testing.T) {
+	t.Run("msleep", func(t *testing.T) {
+		executeThenCancel(t, "msleep 60000")
+	})
+
+	t.Run("select", func(t *testing.T) {
+		executeThenCancel(t, "ch: {channel}; select ~ch, x, (echo ~x)")
+	})
+
+	t.Run("for over a channel", func(t *testing.T) {
+		executeThenCancel(t, "ch: {channel}; for ~ch, x, (echo ~x)")
+	})
+}
@@ -0,0 +1,372 @@
+package pawscript
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"sort"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Canvas drawing primitives and terminal renderers
+// A canvas is an ordinary StoredImage; these functions draw on its pixels while
+// the caller holds the image lock (see StoredImage.Edit)
+
+// canvasFont is the built-in bitmap font used by draw_text
+var canvasFont = basicfont.Face7x13
+
+// plotPixel draws one pixel, blending translucent colors over what is already there
+func plotPixel(img *image.NRGBA, x, y int, c color.NRGBA) {
+	if !(image.Point{x, y}.In(img.Bounds())) {
+		return
+	}
+	if c.A == 255 {
+		img.SetNRGBA(x, y, c)
+		return
+	}
+	dst := img.NRGBAAt(x, y)
+	sa := uint32(c.A)
+	da := uint32(dst.A) * (255 - sa) / 255
+	outA := sa + da
+	if outA == 0 {
+		return
+	}
+	blend := func(s, d uint8) uint8 {
+		return uint8((uint32(s)*sa + uint32(d)*da) / outA)
+	}
+	img.SetNRGBA(x, y, color.NRGBA{R: blend(c.R, dst.R), G: blend(c.G, dst.G), B: blend(c.B, dst.B), A: uint8(outA)})
+}
+
+// drawLine draws a line between two points using Bresenham's algorithm
+func drawLine(img *image.NRGBA, x0, y0, x1, y1 int, c color.NRGBA) {
+	dx, sx := x1-x0, 1
+	if dx < 0 {
+		dx, sx = -dx, -1
+	}
+	dy, sy := y1-y0, 1
+	if dy < 0 {
+		dy, sy = -dy, -1
+	}
+	err := dx - dy
+	for {
+		plotPixel(img, x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 > -dy {
+			err -= dy
+			x0 += sx
+		}
+		if e2 < dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+// drawRect draws a rectangle outline, or a solid rectangle when fill is set
+func drawRect(img *image.NRGBA, x, y, w, h int, c color.NRGBA, fill bool) {
+	if w <= 0 || h <= 0 {
+		return
+	}
+	// Only the part on the image is visited, however large the rectangle
+	visible := image.Rect(x, y, x+w, y+h).Intersect(img.Bounds())
+	for py := visible.Min.Y; py < visible.Max.Y; py++ {
+		if fill || py == y || py == y+h-1 {
+			for px := visible.Min.X; px < visible.Max.X; px++ {
+				plotPixel(img, px, py, c)
+			}
+			continue
+		}
+		if x >= visible.Min.X {
+			plotPixel(img, x, py, c)
+		}
+		if x+w-1 < visible.Max.X && w > 1 {
+			plotPixel(img, x+w-1, py, c)
+		}
+	}
+}
+
+// maxCircleRadius caps the radius draw_circle takes: the midpoint algorithm still
+// walks the whole curve when it crosses the image, so its cost grows with the radius
+const maxCircleRadius = 1 << 24
+
+// drawCircle draws a circle outline, or a solid disc when fill is set
+// Uses the midpoint algorithm; each pixel is plotted once so translucent colors blend evenly.
+// Only the rows and columns on the image are visited, so a huge circle costs no more
+// than the image it crosses.
+func drawCircle(img *image.NRGBA, cx, cy, r int, c color.NRGBA, fill bool) {
+	if r < 0 {
+		return
+	}
+	b := img.Bounds()
+	top, bottom := max(-r, b.Min.Y-cy), min(r, b.Max.Y-1-cy)
+	left, right := max(-r, b.Min.X-cx), min(r, b.Max.X-1-cx)
+	if top > bottom || left > right {
+		return
+	}
+
+	// An image entirely inside the circle is all disc and no outline
+	farX := math.Max(math.Abs(float64(b.Min.X-cx)), math.Abs(float64(b.Max.X-1-cx)))
+	farY := math.Max(math.Abs(float64(b.Min.Y-cy)), math.Abs(float64(b.Max.Y-1-cy)))
+	if math.Hypot(farX, farY) < float64(r)-2 {
+		if fill {
+			drawRect(img, b.Min.X, b.Min.Y, b.Dx(), b.Dy(), c, true)
+		}
+		return
+	}
+
+	// The distances from cy of the rows on the image, and one more for the outline
+	low, high := min(absInt(top), absInt(bottom)), max(absInt(top), absInt(bottom))
+	if top <= 0 && bottom >= 0 {
+		low = 0
+	}
+	high = min(high+1, r)
+
+	// span[row-low] is the half-width of the circle on row cy+row (and cy-row)
+	span := make([]int, high-low+1)
+	for i := range span {
+		span[i] = -1
+	}
+	setSpan := func(row, half int) {
+		if row >= low && row <= high {
+			span[row-low] = max(span[row-low], half)
+		}
+	}
+	x, y, d := r, 0, 1-r
+	for x >= y && (y <= high || x >= low) {
+		setSpan(y, x)
+		setSpan(x, y)
+		y++
+		if d < 0 {
+			d += 2*y + 1
+		} else {
+			x--
+			d += 2*(y-x) + 1
+		}
+	}
+
+	for dy := top; dy <= bottom; dy++ {
+		row := absInt(dy)
+		half := span[row-low]
+		if fill {
+			for dx := max(-half, left); dx <= min(half, right); dx++ {
+				plotPixel(img, cx+dx, cy+dy, c)
+			}
+			continue
+		}
+		// The outline covers every column between this row's edge and the next row's,
+		// so flat parts of the curve have no gaps; the top and bottom rows are solid caps
+		inner := 0
+		if row < r && span[row+1-low] < half {
+			inner = span[row+1-low] + 1
+		} else if row < r {
+			inner = half
+		}
+		for dx := max(inner, min(left, -right)); dx <= min(half, max(right, -left)); dx++ {
+			if dx >= left && dx <= right {
+				plotPixel(img, cx+dx, cy+dy, c)
+			}
+			if dx != 0 && -dx >= left && -dx <= right {
+				plotPixel(img, cx-dx, cy+dy, c)
+			}
+		}
+	}
+}
+
+// drawText draws text with its top-left corner at (x, y) and returns its width in pixels
+func drawText(img *image.NRGBA, x, y int, text string, c color.NRGBA) int {
+	d := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(c),
+		Face: canvasFont,
+		Dot:  fixed.P(x, y+canvasFont.Ascent),
+	}
+	d.DrawString(text)
+	return (d.Dot.X - fixed.I(x)).Round()
+}
+
+// ansiColor returns the SGR parameters for a foreground or background color
+// Uses 24-bit color when trueColor is set, otherwise the nearest xterm-256 color
+func ansiColor(c color.NRGBA, background, trueColor bool) string {
+	base := 38
+	if background {
+		base = 48
+	}
+	if trueColor {
+		return fmt.Sprintf("%d;2;%d;%d;%d", base, c.R, c.G, c.B)
+	}
+	return fmt.Sprintf("%d;5;%d", base, nearestXterm256(c))
+}
+
+// nearestXterm256 maps a color to the closest entry of the xterm 6x6x6 cube or gray ramp
+func nearestXterm256(c color.NRGBA) int {
+	levels := []int{0, 95, 135, 175, 215, 255}
+	nearestLevel := func(v uint8) int {
+		best := 0
+		for i, l := range levels {
+			if absInt(int(v)-l) < absInt(int(v)-levels[best]) {
+				best = i
+			}
+		}
+		return best
+	}
+	ri, gi, bi := nearestLevel(c.R), nearestLevel(c.G), nearestLevel(c.B)
+	cubeDist := sqDist(c, levels[ri], levels[gi], levels[bi])
+
+	avg := (int(c.R) + int(c.G) + int(c.B)) / 3
+	grayIndex := min(max((avg-3)/10, 0), 23)
+	gray := 8 + 10*grayIndex
+	if sqDist(c, gray, gray, gray) < cubeDist {
+		return 232 + grayIndex
+	}
+	return 16 + 36*ri + 6*gi + bi
+}
+
+// sqDist returns the squared distance between a color and an RGB triple
+func sqDist(c color.NRGBA, r, g, b int) int {
+	dr, dg, db := int(c.R)-r, int(c.G)-g, int(c.B)-b
+	return dr*dr + dg*dg + db*db
+}
+
+// absInt returns the absolute value of n
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// renderHalfBlocks renders an image as text using the upper half block character
+// Each character cell shows two pixels: the top as foreground, the bottom as background
+// Mostly transparent pixels (alpha < 128) show the terminal's own background
+func renderHalfBlocks(img *image.NRGBA, trueColor bool) string {
+	b := img.Bounds()
+	opaque := func(x, y int) (color.NRGBA, bool) {
+		if y >= b.Max.Y {
+			return color.NRGBA{}, false
+		}
+		c := img.NRGBAAt(x, y)
+		return c, c.A >= 128
+	}
+
+	var sb strings.Builder
+	for y := b.Min.Y; y < b.Max.Y; y += 2 {
+		lastSGR := ""
+		for x := b.Min.X; x < b.Max.X; x++ {
+			top, hasTop := opaque(x, y)
+			bottom, hasBottom := opaque(x, y+1)
+
+			var sgr, ch string
+			switch {
+			case hasTop && hasBottom:
+				sgr, ch = ansiColor(top, false, trueColor)+";"+ansiColor(bottom, true, trueColor), "▀"
+			case hasTop:
+				sgr, ch = ansiColor(top, false, trueColor), "▀"
+			case hasBottom:
+				sgr, ch = ansiColor(bottom, false, trueColor), "▄"
+			default:
+				sgr, ch = "", " "
+			}
+			if sgr != lastSGR {
+				sb.WriteString("\x1b[0")
+				if sgr != "" {
+					sb.WriteString(";" + sgr)
+				}
+				sb.WriteString("m")
+				lastSGR = sgr
+			}
+			sb.WriteString(ch)
+		}
+		if lastSGR != "" {
+			sb.WriteString("\x1b[0m")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// renderSixel encodes an image as a sixel graphics sequence
+// Colors are quantized to the xterm 6x6x6 cube; transparent pixels are left unpainted
+func renderSixel(img *image.NRGBA) string {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	levels := []int{0, 20, 40, 60, 80, 100} // cube levels as sixel percentages
+
+	// Palette index for each pixel, or -1 when transparent
+	index := make([]int, w*h)
+	used := make(map[int]bool)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := img.NRGBAAt(b.Min.X+x, b.Min.Y+y)
+			if c.A < 128 {
+				index[y*w+x] = -1
+				continue
+			}
+			q := func(v uint8) int { return (int(v)*5 + 127) / 255 }
+			idx := 36*q(c.R) + 6*q(c.G) + q(c.B)
+			index[y*w+x] = idx
+			used[idx] = true
+		}
+	}
+
+	var sb strings.Builder
+	// P2=1 keeps unpainted pixels transparent; raster attributes give 1:1 aspect and size
+	sb.WriteString(fmt.Sprintf("\x1bP0;1;0q\"1;1;%d;%d", w, h))
+
+	colors := make([]int, 0, len(used))
+	for idx := range used {
+		colors = append(colors, idx)
+	}
+	sort.Ints(colors)
+	for _, idx := range colors {
+		sb.WriteString(fmt.Sprintf("#%d;2;%d;%d;%d", idx, levels[idx/36], levels[idx/6%6], levels[idx%6]))
+	}
+
+	// Each band is six pixel rows; each color in the band is one pass over the columns
+	for band := 0; band < h; band += 6 {
+		first := true
+		for _, idx := range colors {
+			bits := make([]byte, w)
+			present := false
+			for x := 0; x < w; x++ {
+				for dy := 0; dy < 6 && band+dy < h; dy++ {
+					if index[(band+dy)*w+x] == idx {
+						bits[x] |= 1 << dy
+						present = true
+					}
+				}
+			}
+			if !present {
+				continue
+			}
+			if !first {
+				sb.WriteString("$")
+			}
+			first = false
+			sb.WriteString(fmt.Sprintf("#%d", idx))
+			for x := 0; x < w; {
+				run := 1
+				for x+run < w && bits[x+run] == bits[x] {
+					run++
+				}
+				ch := string(rune(63 + bits[x]))
+				if run > 3 {
+					sb.WriteString(fmt.Sprintf("!%d%s", run, ch))
+				} else {
+					sb.WriteString(strings.Repeat(ch, run))
+				}
+				x += run
+			}
+		}
+		sb.WriteString("-")
+	}
+	sb.WriteString("\x1b\\")
+	return sb.String()
+}
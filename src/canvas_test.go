@@ -0,0 +1,105 @@
+package pawscript
+
+import (
+	"image"
+	"image/color"
+	"testing"
+	"time"
+)
+
+// referenceRect and referenceCircle draw shapes as drawRect and drawCircle did before
+// they were clipped, plotting every pixel and leaving plotPixel to drop those off the image
+func referenceRect(img *image.NRGBA, x, y, w, h int, c color.NRGBA, fill bool) {
+	for py := y; py < y+h; py++ {
+		for px := x; px < x+w; px++ {
+			if fill || py == y || py == y+h-1 || px == x || px == x+w-1 {
+				plotPixel(img, px, py, c)
+			}
+		}
+	}
+}
+
+func referenceCircle(img *image.NRGBA, cx, cy, r int, c color.NRGBA, fill bool) {
+	span := make([]int, r+1)
+	for i := range span {
+		span[i] = -1
+	}
+	x, y, d := r, 0, 1-r
+	for x >= y {
+		span[y] = max(span[y], x)
+		span[x] = max(span[x], y)
+		y++
+		if d < 0 {
+			d += 2*y + 1
+		} else {
+			x--
+			d += 2*(y-x) + 1
+		}
+	}
+	for dy := -r; dy <= r; dy++ {
+		row := absInt(dy)
+		half := span[row]
+		if fill {
+			for dx := -half; dx <= half; dx++ {
+				plotPixel(img, cx+dx, cy+dy, c)
+			}
+			continue
+		}
+		inner := 0
+		if row < r && span[row+1] < half {
+			inner = span[row+1] + 1
+		} else if row < r {
+			inner = half
+		}
+		for dx := inner; dx <= half; dx++ {
+			plotPixel(img, cx+dx, cy+dy, c)
+			if dx != 0 {
+				plotPixel(img, cx-dx, cy+dy, c)
+			}
+		}
+	}
+}
+
+func TestClippedShapesMatch(t *testing.T) {
+	// Translucent, so a pixel plotted twice shows
+	c := color.NRGBA{R: 200, G: 100, B: 50, A: 128}
+	newImage := func() *image.NRGBA { return image.NewNRGBA(image.Rect(0, 0, 23, 17)) }
+	same := func(a, b *image.NRGBA) bool { return string(a.Pix) == string(b.Pix) }
+
+	for _, fill := range []bool{false, true} {
+		for cx := -30; cx <= 50; cx += 4 {
+			for cy := -30; cy <= 45; cy += 5 {
+				for r := 0; r <= 60; r += 3 {
+					got, want := newImage(), newImage()
+					drawCircle(got, cx, cy, r, c, fill)
+					referenceCircle(want, cx, cy, r, c, fill)
+					if !same(got, want) {
+						t.Fatalf("circle at (%d, %d) radius %d fill %v differs", cx, cy, r, fill)
+					}
+				}
+				for _, size := range [][2]int{{1, 1}, {1, 9}, {9, 1}, {2, 2}, {40, 3}, {60, 60}} {
+					got, want := newImage(), newImage()
+					drawRect(got, cx, cy, size[0], size[1], c, fill)
+					referenceRect(want, cx, cy, size[0], size[1], c, fill)
+					if !same(got, want) {
+						t.Fatalf("rect at (%d, %d) size %v fill %v differs", cx, cy, size, fill)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestHugeShapesAreClipped(t *testing.T) {
+	c := color.NRGBA{A: 255}
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	start := time.Now()
+	for _, fill := range []bool{false, true} {
+		drawRect(img, -1<<40, -1<<40, 1<<41, 1<<41, c, fill)
+		drawCircle(img, 32, 32, 1<<40, c, fill)
+		drawCircle(img, -maxCircleRadius, 32, maxCircleRadius, c, fill)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("drawing took %v", elapsed)
+	}
+}
@@ -1,9 +1,257 @@
 package pawscript
 
 import (
+	"errors"
 	"fmt"
+	"sync"
+	"time"
 )
 
+// ChannelOverflow is the policy for sending to a bounded channel whose buffer is full
+type ChannelOverflow int
+
+const (
+	OverflowError      ChannelOverflow = iota // fail the send (the default)
+	OverflowBlock                             // wait until a receiver makes room
+	OverflowDropOldest                        // discard the oldest buffered message
+	OverflowDropNewest                        // discard the message being sent
+)
+
+// String returns the script name of the policy
+func (o ChannelOverflow) String() string {
+	switch o {
+	case OverflowBlock:
+		return "block"
+	case OverflowDropOldest:
+		return "drop_oldest"
+	case OverflowDropNewest:
+		return "drop_newest"
+	default:
+		return "error"
+	}
+}
+
+// ParseChannelOverflow converts a script policy name to a ChannelOverflow
+func ParseChannelOverflow(name string) (ChannelOverflow, bool) {
+	switch name {
+	case "error":
+		return OverflowError, true
+	case "block":
+		return OverflowBlock, true
+	case "drop_oldest":
+		return OverflowDropOldest, true
+	case "drop_newest":
+		return OverflowDropNewest, true
+	}
+	return OverflowError, false
+}
+
+// QueueSend puts an item on the Go queue behind a native channel, applying the channel's
+// Overflow policy when the queue is full and counting drops in its Stats
+// Call it from NativeSend, which ChannelSend runs with the channel lock held; under
+// OverflowBlock the lock is released while waiting for room, as it is for script channels,
+// and the wait ends with ErrChannelClosed if the channel closes, or ErrCancelled if the send
+// came from ChannelSendUntil and its done closes. The Go side must not close queue while a
+// script can still send to the channel; close the channel (ChannelClose) instead.
+// A flush sentinel (chan struct{}) dropped from the queue is closed so its waiter doesn't hang.
+func QueueSend[T any](ch *StoredChannel, queue chan T, item T) error {
+	select {
+	case queue <- item:
+		return nil
+	default:
+	}
+
+	switch ch.Overflow {
+	case OverflowBlock:
+		// Wait for room without holding the lock, so stats, receives and close go on;
+		// closing the channel or cancelling the send ends the wait
+		done := ch.sendDone
+		for {
+			wake := make(chan struct{})
+			channelWaitMu.Lock()
+			channelWaiters[ch] = append(channelWaiters[ch], wake)
+			channelWaitMu.Unlock()
+			ch.mu.Unlock()
+			select {
+			case queue <- item:
+				unregisterChannelWaiter([]*StoredChannel{ch}, wake)
+				ch.mu.Lock()
+				return nil
+			case <-wake:
+				ch.mu.Lock()
+			case <-done:
+				unregisterChannelWaiter([]*StoredChannel{ch}, wake)
+				ch.mu.Lock()
+				return ErrCancelled
+			}
+			if ch.IsClosed {
+				return ErrChannelClosed
+			}
+		}
+	case OverflowDropNewest:
+		ch.Stats.DroppedNewest++
+		return nil
+	case OverflowDropOldest:
+		for {
+			select {
+			case queue <- item:
+				return nil
+			default:
+			}
+			select {
+			case dropped := <-queue:
+				if sentinel, ok := any(dropped).(chan struct{}); ok {
+					close(sentinel)
+				} else {
+					ch.Stats.DroppedOldest++
+				}
+			default:
+			}
+		}
+	default:
+		ch.Stats.Rejected++
+		return fmt.Errorf("channel buffer full")
+	}
+}
+
+// ChannelCounters tracks the messages that have passed through a channel
+// Subscribers share their parent's buffer, so their traffic is counted on the parent.
+type ChannelCounters struct {
+	Sent          int64 // Messages accepted into the channel
+	Received      int64 // Messages read from the channel (by any endpoint)
+	DroppedOldest int64 // Buffered messages discarded to make room (drop_oldest)
+	DroppedNewest int64 // Sends discarded because the buffer was full (drop_newest)
+	Rejected      int64 // Sends that failed because the buffer was full (error)
+}
+
+// ChannelStats is a snapshot of a channel's queue and traffic
+type ChannelStats struct {
+	ChannelCounters
+	Depth       int             // Unread messages waiting for this endpoint
+	Capacity    int             // Buffer size (0 = unbounded)
+	Subscribers int             // Number of subscriber endpoints
+	Overflow    ChannelOverflow // Policy applied when the buffer is full
+	Closed      bool
+}
+
+// ChannelGetStats returns a snapshot of a channel's queue depth and message counters
+func ChannelGetStats(ch *StoredChannel) ChannelStats {
+	if ch == nil {
+		return ChannelStats{}
+	}
+	depth := ChannelLen(ch)
+
+	mainCh := mainChannel(ch)
+	mainCh.mu.RLock()
+	defer mainCh.mu.RUnlock()
+	return ChannelStats{
+		ChannelCounters: mainCh.Stats,
+		Depth:           depth,
+		Capacity:        mainCh.BufferSize,
+		Subscribers:     len(mainCh.Subscribers),
+		Overflow:        mainCh.Overflow,
+		Closed:          mainCh.IsClosed || ch.IsClosed,
+	}
+}
+
+// ErrChannelTimeout is returned by ChannelSelect when no channel became ready in time
+var ErrChannelTimeout = errors.New("timed out waiting for channel")
+
+// ErrChannelsClosed is returned by ChannelSelect when every channel is closed and drained
+var ErrChannelsClosed = errors.New("all channels are closed")
+
+// ErrChannelClosed is returned when sending to a closed channel, and by receives once a
+// closed channel has been drained (end of channel)
+var ErrChannelClosed = errors.New("channel is closed")
+
+// channelWaitPoll is how often ChannelSelect re-checks native channels,
+// whose Go-side producers don't signal waiters
+const channelWaitPoll = 10 * time.Millisecond
+
+// Waiters blocked in ChannelSelect, keyed by main channel
+// A waiter's chan is closed (and removed) when its channel gets a message or is closed
+var (
+	channelWaitMu  sync.Mutex
+	channelWaiters = make(map[*StoredChannel][]chan struct{})
+)
+
+// nativeRecvCall is a NativeRecv running in the background
+// done is closed once value and err are set
+type nativeRecvCall struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// In-flight NativeRecv calls left behind by timed-out receives, keyed by channel
+// The next receive on the channel collects the result instead of losing it
+var (
+	nativeRecvMu      sync.Mutex
+	nativeRecvPending = make(map[*StoredChannel]*nativeRecvCall)
+)
+
+// startNativeRecv returns the in-flight NativeRecv for a channel, starting one if needed
+func startNativeRecv(ch *StoredChannel, recv func() (interface{}, error)) *nativeRecvCall {
+	nativeRecvMu.Lock()
+	defer nativeRecvMu.Unlock()
+	if call, ok := nativeRecvPending[ch]; ok {
+		return call
+	}
+	call := &nativeRecvCall{done: make(chan struct{})}
+	nativeRecvPending[ch] = call
+	go func() {
+		call.value, call.err = recv()
+		close(call.done)
+		notifyChannelWaiters(ch) // a select waiting on the channel can now take the result
+	}()
+	return call
+}
+
+// takeNativeRecv waits for an in-flight NativeRecv and clears it so the value is delivered once
+func takeNativeRecv(ch *StoredChannel, call *nativeRecvCall) (interface{}, error) {
+	<-call.done
+	nativeRecvMu.Lock()
+	defer nativeRecvMu.Unlock()
+	if nativeRecvPending[ch] != call {
+		return nil, fmt.Errorf("no messages available")
+	}
+	delete(nativeRecvPending, ch)
+	return call.value, call.err
+}
+
+// countNativeRecv records a completed NativeRecv in the channel's counters
+func countNativeRecv(ch *StoredChannel, err error) {
+	if err != nil {
+		return
+	}
+	ch.mu.Lock()
+	ch.Stats.Received++
+	ch.mu.Unlock()
+}
+
+// mainChannel returns the channel that holds the message buffer for an endpoint
+func mainChannel(ch *StoredChannel) *StoredChannel {
+	if ch.IsSubscriber && ch.ParentChannel != nil {
+		return ch.ParentChannel
+	}
+	return ch
+}
+
+// notifyChannelWaiters wakes everything waiting on a main channel
+func notifyChannelWaiters(mainCh *StoredChannel) {
+	channelWaitMu.Lock()
+	defer channelWaitMu.Unlock()
+	for _, w := range channelWaiters[mainCh] {
+		// A waiter on several channels may already have been woken by another one
+		select {
+		case <-w:
+		default:
+			close(w)
+		}
+	}
+	delete(channelWaiters, mainCh)
+}
+
 // ChannelSubscribe creates a new subscriber endpoint for a channel
 func ChannelSubscribe(ch *StoredChannel) (*StoredChannel, error) {
 	if ch == nil {
@@ -19,7 +267,7 @@ func ChannelSubscribe(ch *StoredChannel) (*StoredChannel, error) {
 	defer ch.mu.Unlock()
 
 	if ch.IsClosed {
-		return nil, fmt.Errorf("channel is closed")
+		return nil, ErrChannelClosed
 	}
 
 	// Create new subscriber with unique ID
@@ -36,6 +284,12 @@ func ChannelSubscribe(ch *StoredChannel) (*StoredChannel, error) {
 // If sender is the main channel (ID 0), broadcasts to all subscribers
 // If sender is a subscriber, sends only to main channel
 func ChannelSend(ch *StoredChannel, value interface{}) error {
+	return ChannelSendUntil(ch, value, nil)
+}
+
+// ChannelSendUntil is ChannelSend that also stops waiting for room under OverflowBlock once
+// done is closed, returning ErrCancelled; a nil done never closes
+func ChannelSendUntil(ch *StoredChannel, value interface{}, done <-chan struct{}) error {
 	if ch == nil {
 		return fmt.Errorf("channel is nil")
 	}
@@ -44,12 +298,19 @@ func ChannelSend(ch *StoredChannel, value interface{}) error {
 	defer ch.mu.Unlock()
 
 	if ch.IsClosed {
-		return fmt.Errorf("channel is closed")
+		return ErrChannelClosed
 	}
 
 	// Check for native send handler first
 	if ch.NativeSend != nil {
-		return ch.NativeSend(value)
+		ch.sendDone = done // read by QueueSend before it releases the lock
+		dropped := ch.Stats.DroppedNewest
+		err := ch.NativeSend(value)
+		ch.sendDone = nil
+		if err == nil && ch.Stats.DroppedNewest == dropped {
+			ch.Stats.Sent++
+		}
+		return err
 	}
 
 	// Get the main channel
@@ -60,9 +321,48 @@ func ChannelSend(ch *StoredChannel, value interface{}) error {
 		senderID = ch.SubscriberID
 	}
 
-	// Check buffer capacity
-	if mainCh.BufferSize > 0 && len(mainCh.Messages) >= mainCh.BufferSize {
-		return fmt.Errorf("channel buffer full")
+	// Apply the overflow policy while the buffer is full
+	// Subscribers share their parent's buffer, so the parent's policy applies
+	for mainCh.BufferSize > 0 && len(mainCh.Messages) >= mainCh.BufferSize {
+		switch mainCh.Overflow {
+		case OverflowDropNewest:
+			mainCh.Stats.DroppedNewest++
+			return nil
+		case OverflowDropOldest:
+			mainCh.Messages = mainCh.Messages[1:]
+			mainCh.Stats.DroppedOldest++
+			if mainCh.journal != nil {
+				mainCh.journal.recordAck(1, len(mainCh.Messages))
+			}
+		case OverflowBlock:
+			// Wait for a receive (or close) without holding the lock
+			wake := make(chan struct{})
+			channelWaitMu.Lock()
+			channelWaiters[mainCh] = append(channelWaiters[mainCh], wake)
+			channelWaitMu.Unlock()
+			ch.mu.Unlock()
+			select {
+			case <-wake:
+			case <-done:
+				unregisterChannelWaiter([]*StoredChannel{mainCh}, wake)
+				ch.mu.Lock()
+				return ErrCancelled
+			}
+			ch.mu.Lock()
+			if ch.IsClosed || mainCh.IsClosed {
+				return ErrChannelClosed
+			}
+		default:
+			mainCh.Stats.Rejected++
+			return fmt.Errorf("channel buffer full")
+		}
+	}
+
+	// Durable channels journal the message before queuing it, so a failed write fails the send
+	if mainCh.journal != nil {
+		if err := mainCh.journal.recordSend(value); err != nil {
+			return fmt.Errorf("journal: %w", err)
+		}
 	}
 
 	// Create message with consumed tracking
@@ -106,6 +406,8 @@ func ChannelSend(ch *StoredChannel, value interface{}) error {
 	}
 
 	mainCh.Messages = append(mainCh.Messages, msg)
+	mainCh.Stats.Sent++
+	notifyChannelWaiters(mainCh)
 
 	return nil
 }
@@ -113,6 +415,8 @@ func ChannelSend(ch *StoredChannel, value interface{}) error {
 // ChannelRecv receives a message from a channel
 // Returns (senderID, value, error)
 // Advances read pointer and cleans up fully-consumed messages
+// A closed channel still delivers the messages buffered for it before closing;
+// once those are drained it returns ErrChannelClosed.
 func ChannelRecv(ch *StoredChannel) (int, interface{}, error) {
 	if ch == nil {
 		return 0, nil, fmt.Errorf("channel is nil")
@@ -120,9 +424,10 @@ func ChannelRecv(ch *StoredChannel) (int, interface{}, error) {
 
 	ch.mu.Lock()
 
-	if ch.IsClosed {
+	// Native channels buffer on the Go side, so closing them ends delivery at once
+	if ch.IsClosed && ch.NativeRecv != nil {
 		ch.mu.Unlock()
-		return 0, nil, fmt.Errorf("channel is closed")
+		return 0, nil, ErrChannelClosed
 	}
 
 	// Check for native receive handler first
@@ -130,7 +435,18 @@ func ChannelRecv(ch *StoredChannel) (int, interface{}, error) {
 	if ch.NativeRecv != nil {
 		nativeRecv := ch.NativeRecv
 		ch.mu.Unlock()
-		value, err := nativeRecv()
+		// Collect a read started by an earlier receive that timed out
+		nativeRecvMu.Lock()
+		call, ok := nativeRecvPending[ch]
+		nativeRecvMu.Unlock()
+		var value interface{}
+		var err error
+		if ok {
+			value, err = takeNativeRecv(ch, call)
+		} else {
+			value, err = nativeRecv()
+		}
+		countNativeRecv(ch, err)
 		return 0, value, err
 	}
 
@@ -166,37 +482,68 @@ func ChannelRecv(ch *StoredChannel) (int, interface{}, error) {
 			}
 		}
 
+		senderID, value := msg.SenderID, msg.Value
+
 		// If all consumed, remove messages from front of buffer
 		if allConsumed {
-			// Clean up all fully-consumed messages from the front
-			cleanupCount := 0
-			for j := 0; j < len(mainCh.Messages); j++ {
-				allConsumedJ := true
-				for _, consumed := range mainCh.Messages[j].ConsumedBy {
-					if !consumed {
-						allConsumedJ = false
-						break
-					}
-				}
-				if allConsumedJ {
-					cleanupCount++
-				} else {
-					break
-				}
-			}
-			if cleanupCount > 0 {
-				mainCh.Messages = mainCh.Messages[cleanupCount:]
-			}
+			removeConsumedMessages(mainCh)
 		}
 
-		return msg.SenderID, msg.Value, nil
+		mainCh.Stats.Received++
+		return senderID, value, nil
+	}
+
+	// A closed endpoint with nothing left has reached the end of the channel
+	if ch.IsClosed || mainCh.IsClosed {
+		return 0, nil, ErrChannelClosed
 	}
 
 	// No messages available
 	return 0, nil, fmt.Errorf("no messages available")
 }
 
+// removeConsumedMessages drops the fully-consumed messages from the front of a main channel's buffer
+// The caller holds the lock of the endpoint being received on.
+func removeConsumedMessages(mainCh *StoredChannel) {
+	cleanupCount := 0
+	for j := 0; j < len(mainCh.Messages); j++ {
+		allConsumed := true
+		for _, consumed := range mainCh.Messages[j].ConsumedBy {
+			if !consumed {
+				allConsumed = false
+				break
+			}
+		}
+		if !allConsumed {
+			break
+		}
+		cleanupCount++
+	}
+	if cleanupCount > 0 {
+		mainCh.Messages = mainCh.Messages[cleanupCount:]
+		if mainCh.journal != nil {
+			mainCh.journal.recordAck(cleanupCount, len(mainCh.Messages))
+		}
+		// Wake senders blocked on a full buffer
+		notifyChannelWaiters(mainCh)
+	}
+}
+
+// releaseSubscriberMessages marks everything still pending for a departing subscriber as
+// consumed, so its unread messages don't hold the shared buffer forever
+func releaseSubscriberMessages(mainCh *StoredChannel, subscriberID int) {
+	for i := range mainCh.Messages {
+		if consumed, exists := mainCh.Messages[i].ConsumedBy[subscriberID]; exists && !consumed {
+			mainCh.Messages[i].ConsumedBy[subscriberID] = true
+		}
+	}
+	removeConsumedMessages(mainCh)
+}
+
 // ChannelClose closes a channel or subscriber
+// Closing a main channel stops all sends to it and its subscribers, but every endpoint
+// can still receive what was buffered for it; receives return ErrChannelClosed once
+// that is drained. A subscriber that closes itself gives up its unread messages.
 func ChannelClose(ch *StoredChannel) error {
 	if ch == nil {
 		return fmt.Errorf("channel is nil")
@@ -213,6 +560,7 @@ func ChannelClose(ch *StoredChannel) error {
 	if ch.NativeClose != nil {
 		err := ch.NativeClose()
 		ch.IsClosed = true
+		notifyChannelWaiters(ch)
 		return err
 	}
 
@@ -220,14 +568,24 @@ func ChannelClose(ch *StoredChannel) error {
 		// Disconnect subscriber from parent
 		if ch.ParentChannel != nil {
 			delete(ch.ParentChannel.Subscribers, ch.SubscriberID)
+			releaseSubscriberMessages(ch.ParentChannel, ch.SubscriberID)
 		}
 	} else {
 		// Close main channel - disconnect all subscribers
 		for _, sub := range ch.Subscribers {
-			sub.IsClosed = true
+			sub.setClosed()
 		}
 		ch.Subscribers = make(map[int]*StoredChannel)
 
+		// Unread messages stay in the journal for the next run, including any
+		// drained after closing: those are delivered again rather than lost
+		if ch.journal != nil {
+			ch.journal.close()
+		}
+
+		// Other processes can no longer connect to it
+		unexportChannels(func(export *channelExport) bool { return export.ch == ch })
+
 		// Call custom close handler if present
 		// nolint:staticcheck // TODO: Execute custom close macro when implemented
 		if ch.CustomClose != nil {
@@ -236,9 +594,18 @@ func ChannelClose(ch *StoredChannel) error {
 	}
 
 	ch.IsClosed = true
+	notifyChannelWaiters(mainChannel(ch))
 	return nil
 }
 
+// setClosed marks a subscriber closed from its main channel, whose lock the caller
+// holds; readers of the subscriber hold its own lock
+func (ch *StoredChannel) setClosed() {
+	ch.mu.Lock()
+	ch.IsClosed = true
+	ch.mu.Unlock()
+}
+
 // ChannelDisconnect disconnects a specific subscriber from a channel
 func ChannelDisconnect(ch *StoredChannel, subscriberID int) error {
 	if ch == nil {
@@ -253,7 +620,7 @@ func ChannelDisconnect(ch *StoredChannel, subscriberID int) error {
 	defer ch.mu.Unlock()
 
 	if ch.IsClosed {
-		return fmt.Errorf("channel is closed")
+		return ErrChannelClosed
 	}
 
 	sub, exists := ch.Subscribers[subscriberID]
@@ -262,8 +629,10 @@ func ChannelDisconnect(ch *StoredChannel, subscriberID int) error {
 	}
 
 	// Mark subscriber as closed
-	sub.IsClosed = true
+	sub.setClosed()
 	delete(ch.Subscribers, subscriberID)
+	releaseSubscriberMessages(ch, subscriberID)
+	notifyChannelWaiters(ch)
 
 	return nil
 }
@@ -280,6 +649,14 @@ func ChannelIsOpened(ch *StoredChannel) bool {
 	return !ch.IsClosed
 }
 
+// channelDrained reports whether an endpoint is closed with nothing left to receive
+func channelDrained(ch *StoredChannel) bool {
+	ch.mu.RLock()
+	closed, native := ch.IsClosed, ch.NativeRecv != nil
+	ch.mu.RUnlock()
+	return closed && (native || ChannelLen(ch) == 0)
+}
+
 // ChannelLen returns the number of unread messages for a channel/subscriber
 func ChannelLen(ch *StoredChannel) int {
 	if ch == nil {
@@ -313,3 +690,192 @@ func ChannelLen(ch *StoredChannel) int {
 
 	return count
 }
+
+// ChannelRecvTimeout receives a message, waiting up to timeout for one to arrive
+// A negative timeout waits forever; a zero timeout only checks once.
+// Returns ErrChannelTimeout if nothing arrived in time.
+func ChannelRecvTimeout(ch *StoredChannel, timeout time.Duration) (int, interface{}, error) {
+	return ChannelRecvUntil(ch, timeout, nil)
+}
+
+// ChannelRecvUntil is ChannelRecvTimeout that also gives up once done is closed,
+// returning ErrCancelled; a nil done never closes
+func ChannelRecvUntil(ch *StoredChannel, timeout time.Duration, done <-chan struct{}) (int, interface{}, error) {
+	if ch == nil {
+		return 0, nil, fmt.Errorf("channel is nil")
+	}
+
+	if channelDrained(ch) {
+		return 0, nil, ErrChannelClosed
+	}
+	ch.mu.RLock()
+	nativeRecv, nativeLen := ch.NativeRecv, ch.NativeLen
+	ch.mu.RUnlock()
+
+	// A blocking native channel that can't report its length is read in the background;
+	// if the read outlasts the timeout, the next receive picks up its result
+	if nativeRecv != nil && nativeLen == nil {
+		call := startNativeRecv(ch, nativeRecv)
+		var deadline <-chan time.Time
+		if timeout >= 0 {
+			timer := time.NewTimer(timeout)
+			defer timer.Stop()
+			deadline = timer.C
+		}
+		select {
+		case <-call.done:
+			value, err := takeNativeRecv(ch, call)
+			countNativeRecv(ch, err)
+			return 0, value, err
+		case <-deadline:
+			return 0, nil, ErrChannelTimeout
+		case <-done:
+			return 0, nil, ErrCancelled
+		}
+	}
+
+	_, sender, value, err := ChannelSelectUntil([]*StoredChannel{ch}, timeout, done)
+	if err == ErrChannelsClosed {
+		err = ErrChannelClosed
+	}
+	return sender, value, err
+}
+
+// ChannelSelect waits until one of the channels has a message and receives it
+// Returns the index of the channel that was read along with the sender ID and value
+// A negative timeout waits forever; a zero timeout only checks once.
+// Closed channels are still received from until drained, then skipped;
+// ErrChannelsClosed is returned once all of them are closed and drained.
+func ChannelSelect(chs []*StoredChannel, timeout time.Duration) (int, int, interface{}, error) {
+	return ChannelSelectUntil(chs, timeout, nil)
+}
+
+// ChannelSelectUntil is ChannelSelect that also gives up once done is closed,
+// returning ErrCancelled; a nil done never closes
+func ChannelSelectUntil(chs []*StoredChannel, timeout time.Duration, done <-chan struct{}) (int, int, interface{}, error) {
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	// Native channels with a length can't signal new data, so poll them; those without
+	// one are read in the background, and the read finishing wakes the select
+	var poll <-chan time.Time
+	for _, ch := range chs {
+		if ch != nil && ch.NativeLen != nil {
+			ticker := time.NewTicker(channelWaitPoll)
+			defer ticker.Stop()
+			poll = ticker.C
+			break
+		}
+	}
+
+	for {
+		// Register before checking so a send between the check and the wait isn't missed
+		wake := make(chan struct{})
+		channelWaitMu.Lock()
+		for _, ch := range chs {
+			if ch != nil {
+				main := mainChannel(ch)
+				channelWaiters[main] = append(channelWaiters[main], wake)
+			}
+		}
+		channelWaitMu.Unlock()
+
+		index, sender, value, err := channelSelectReady(chs)
+		if err != nil || index >= 0 || timeout == 0 {
+			unregisterChannelWaiter(chs, wake)
+			if err == nil && index < 0 {
+				err = ErrChannelTimeout
+			}
+			return index, sender, value, err
+		}
+
+		select {
+		case <-wake:
+			unregisterChannelWaiter(chs, wake)
+		case <-poll:
+			unregisterChannelWaiter(chs, wake)
+		case <-deadline:
+			unregisterChannelWaiter(chs, wake)
+			return -1, 0, nil, ErrChannelTimeout
+		case <-done:
+			unregisterChannelWaiter(chs, wake)
+			return -1, 0, nil, ErrCancelled
+		}
+	}
+}
+
+// channelSelectReady receives from the first channel that has a message
+// Returns index -1 when none is ready
+func channelSelectReady(chs []*StoredChannel) (int, int, interface{}, error) {
+	open := 0
+	for i, ch := range chs {
+		if ch == nil || channelDrained(ch) {
+			continue
+		}
+		// A native channel that can't report its length is read in the background, and
+		// ready once the read completes; a read that reports the channel closed is left
+		// pending, so the channel counts as closed without being read again
+		ch.mu.RLock()
+		nativeRecv, nativeLen := ch.NativeRecv, ch.NativeLen
+		ch.mu.RUnlock()
+		if nativeRecv != nil && nativeLen == nil {
+			call := startNativeRecv(ch, nativeRecv)
+			select {
+			case <-call.done:
+			default:
+				open++
+				continue
+			}
+			if errors.Is(call.err, ErrChannelClosed) {
+				continue
+			}
+			value, err := takeNativeRecv(ch, call)
+			if err != nil && call.err == nil {
+				open++
+				continue // Another receive took the result
+			}
+			countNativeRecv(ch, err)
+			return i, 0, value, err
+		}
+		open++
+		if ChannelLen(ch) == 0 {
+			continue
+		}
+		// Another reader may have taken the message since ChannelLen; keep looking
+		if sender, value, err := ChannelRecv(ch); err == nil {
+			return i, sender, value, nil
+		}
+	}
+	if open == 0 {
+		return -1, 0, nil, ErrChannelsClosed
+	}
+	return -1, 0, nil, nil
+}
+
+// unregisterChannelWaiter removes a waiter that wasn't woken
+func unregisterChannelWaiter(chs []*StoredChannel, wake chan struct{}) {
+	channelWaitMu.Lock()
+	defer channelWaitMu.Unlock()
+	for _, ch := range chs {
+		if ch == nil {
+			continue
+		}
+		main := mainChannel(ch)
+		waiters := channelWaiters[main]
+		for i, w := range waiters {
+			if w == wake {
+				waiters = append(waiters[:i], waiters[i+1:]...)
+				break
+			}
+		}
+		if len(waiters) == 0 {
+			delete(channelWaiters, main)
+		} else {
+			channelWaiters[main] = waiters
+		}
+	}
+}
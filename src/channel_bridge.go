@@ -0,0 +1,193 @@
+package pawscript
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Bridges between StoredChannel and Go channels, for embedders building frontends
+
+// NewChannelFromGo creates a native channel backed by Go channels
+// Values scripts send are delivered on sendCh, applying the channel's Overflow policy when
+// it is full (set Overflow on the result; the default fails the send). Receives read from
+// recvCh, and a closed recvCh reads as a closed channel. Either may be nil for a one-way
+// channel. The Go side owns both channels: closing the script channel closes neither.
+// caps describes the terminal behind the channel; nil falls back to the system terminal.
+func NewChannelFromGo(sendCh chan interface{}, recvCh <-chan interface{}, caps *TerminalCapabilities) *StoredChannel {
+	ch := NewStoredChannel(0)
+	ch.Terminal = caps
+	ch.NativeSend = func(v interface{}) error {
+		if sendCh == nil {
+			return fmt.Errorf("cannot send to a receive-only channel")
+		}
+		// The sender may reuse its buffer once the send returns
+		if b, ok := v.([]byte); ok {
+			v = append([]byte(nil), b...)
+		}
+		return QueueSend(ch, sendCh, v)
+	}
+	ch.NativeRecv = func() (interface{}, error) {
+		if recvCh == nil {
+			return nil, fmt.Errorf("cannot receive from a send-only channel")
+		}
+		v, ok := <-recvCh
+		if !ok {
+			return nil, ErrChannelClosed
+		}
+		return v, nil
+	}
+	if recvCh != nil {
+		ch.NativeLen = func() int {
+			return len(recvCh)
+		}
+	}
+	return ch
+}
+
+// ChannelToGo bridges a StoredChannel to Go channels, the reverse of NewChannelFromGo
+// Messages received from ch are forwarded to the returned receive channel, which is closed
+// once ch closes. Values sent on the returned send channel are sent to ch; close it when
+// done. A send ch refuses is reported on the returned error channel, which is closed once
+// the send channel is; read it, or failed sends hold up the ones after them. Closing done
+// stops both directions, even with messages waiting to be forwarded, and closes the
+// receive and error channels; a nil done never closes. The forwarding goroutine receives
+// from ch as soon as messages arrive, so script receivers on the same endpoint compete
+// with it - subscribe first to share a channel.
+func ChannelToGo(ch *StoredChannel, buffer int, done <-chan struct{}) (<-chan interface{}, chan<- interface{}, <-chan error) {
+	out := make(chan interface{}, buffer)
+	in := make(chan interface{}, buffer)
+	errs := make(chan error, buffer)
+	go func() {
+		defer close(out)
+		for {
+			_, value, err := ChannelRecvUntil(ch, -1, done)
+			if err != nil {
+				return
+			}
+			select {
+			case out <- value:
+			case <-done:
+				return
+			}
+		}
+	}()
+	go func() {
+		defer close(errs)
+		for {
+			select {
+			case value, ok := <-in:
+				if !ok {
+					return
+				}
+				if err := ChannelSend(ch, value); err != nil {
+					select {
+					case errs <- err:
+					case <-done:
+						return
+					}
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return out, in, errs
+}
+
+// DetachableChannel returns a stand-in for ch that passes sends, receives and flushes
+// through to it until detach is called. From then on the stand-in reads as closed, and
+// sends and receives waiting on ch give up without taking anything from it, so ch is left
+// as it was for its next user. Closing the stand-in detaches it; ch itself is never closed.
+func DetachableChannel(ch *StoredChannel) (*StoredChannel, func()) {
+	proxy := NewStoredChannel(0)
+	proxy.Terminal = ch.Terminal
+	detached := make(chan struct{})
+	var once sync.Once
+	detachOnce := func() { once.Do(func() { close(detached) }) }
+
+	proxy.NativeSend = func(v interface{}) error {
+		done := detached
+		if sendDone := proxy.sendDone; sendDone != nil {
+			// Give up on whichever closes first: the caller's done or the detach
+			either := make(chan struct{})
+			stop := make(chan struct{})
+			defer close(stop)
+			go func() {
+				select {
+				case <-sendDone:
+				case <-detached:
+				case <-stop:
+					return
+				}
+				close(either)
+			}()
+			done = either
+		}
+		err := ChannelSendUntil(ch, v, done)
+		if err == ErrCancelled {
+			select {
+			case <-detached:
+				return ErrChannelClosed
+			default:
+			}
+		}
+		return err
+	}
+	proxy.NativeRecv = func() (interface{}, error) {
+		_, value, err := ChannelRecvUntil(ch, -1, detached)
+		if err == ErrCancelled {
+			err = ErrChannelClosed
+		}
+		return value, err
+	}
+	// Only channels whose length is known report one; the rest are read in the background
+	ch.mu.RLock()
+	knownLen := ch.NativeLen != nil || ch.NativeRecv == nil
+	ch.mu.RUnlock()
+	if knownLen {
+		proxy.NativeLen = func() int {
+			select {
+			case <-detached:
+				return 0
+			default:
+				return ChannelLen(ch)
+			}
+		}
+	}
+	proxy.NativeFlush = func() error {
+		select {
+		case <-detached:
+			return nil
+		default:
+			return ch.Flush()
+		}
+	}
+	proxy.NativeClose = func() error {
+		detachOnce()
+		return nil
+	}
+
+	return proxy, func() {
+		// Wake waiting sends first: ChannelClose needs the lock they hold
+		detachOnce()
+		_ = ChannelClose(proxy)
+	}
+}
+
+// ConsoleBytes converts a value sent to a console output channel into terminal bytes
+// Newlines become \r\n, since a terminal in raw mode doesn't return the carriage on \n.
+func ConsoleBytes(v interface{}) []byte {
+	var text string
+	switch d := v.(type) {
+	case []byte:
+		text = string(d)
+	case string:
+		text = d
+	default:
+		text = fmt.Sprintf("%v", v)
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\n", "\r\n")
+	return []byte(text)
+}
@@ -0,0 +1,126 @@
+package pawscript
+
+import (
+	"os"
+	"strings"
+)
+
+// channelJournal persists the queue of a durable channel so it survives a restart
+// Each line of the journal is a PSL list: ("send", <value>) appends a message and
+// ("ack", <n>) removes n messages from the front of the queue. Replaying the lines
+// rebuilds the queue. The file is rewritten compactly when opened and truncated
+// whenever the queue drains, so it only grows with the backlog.
+// A failed ack write can only cause a message to be delivered again, never lost.
+// Everything written to the journal is charged to the interpreter's bytes-written
+// quota, like writes to any other file.
+type channelJournal struct {
+	file     *os.File
+	executor *Executor
+	quota    *quotaTracker // Quotas charged for journal writes (nil = none)
+}
+
+// openChannelJournal attaches a journal file to a new main channel
+// Messages left unconsumed by a previous run are queued on the channel again, as if
+// newly sent. Returns the number of messages replayed.
+func openChannelJournal(ch *StoredChannel, path string, executor *Executor, quota *quotaTracker) (int, error) {
+	j := &channelJournal{executor: executor, quota: quota}
+
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+	var pending []interface{}
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		op, value, ok := parseChannelRecord(line)
+		if !ok {
+			continue // Skip damaged records, such as a write torn by a crash
+		}
+		switch op {
+		case "send":
+			pending = append(pending, value)
+		case "ack":
+			if n, ok := value.(int64); ok {
+				pending = pending[min(int(n), len(pending)):]
+			}
+		}
+	}
+
+	// Rewrite the journal with just the pending messages, then append from there
+	var sb strings.Builder
+	for _, value := range pending {
+		sb.WriteString(channelRecord("send", value))
+	}
+	if err := j.quota.write(int64(sb.Len())); err != nil {
+		return 0, err
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(sb.String()), 0644); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return 0, err
+	}
+	j.file, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return 0, err
+	}
+
+	messages := make([]ChannelMessage, len(pending))
+	for i, value := range pending {
+		messages[i] = ChannelMessage{Value: decodeChannelValue(value, j.executor), ConsumedBy: map[int]bool{0: false}}
+	}
+
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	ch.Messages = append(ch.Messages, messages...)
+	ch.Stats.Sent += int64(len(messages))
+	ch.journal = j
+	return len(pending), nil
+}
+
+// recordSend journals a message before it is queued
+func (j *channelJournal) recordSend(value interface{}) error {
+	encoded, err := encodeChannelValue(value, j.executor)
+	if err != nil {
+		return err
+	}
+	return j.write(channelRecord("send", encoded))
+}
+
+// recordAck journals the removal of n messages from the front of the queue
+// When the queue is empty the whole journal is cleared instead.
+func (j *channelJournal) recordAck(n, remaining int) {
+	if remaining == 0 {
+		_ = j.file.Truncate(0)
+		return
+	}
+	_ = j.write(channelRecord("ack", int64(n)))
+}
+
+// write appends a record to the journal, within the bytes-written quota
+func (j *channelJournal) write(record string) error {
+	if err := j.quota.write(int64(len(record))); err != nil {
+		return err
+	}
+	_, err := j.file.WriteString(record)
+	return err
+}
+
+// close releases the journal file; its contents are kept for the next run
+// Later writes fail, so a closed durable channel can't lose messages silently.
+func (j *channelJournal) close() {
+	_ = j.file.Close()
+}
+
+// closeJournal closes a durable channel's journal, if it has one
+// The journal is set once when the channel is created, so no channel lock is needed;
+// this matters because it is called while the executor lock is held.
+func (ch *StoredChannel) closeJournal() {
+	if ch.journal != nil {
+		ch.journal.close()
+	}
+}
@@ -0,0 +1,95 @@
+package pawscript
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Channel records carry channel messages outside the process, one PSL list per line
+// Durable channel journals and remote channel connections both use them.
+
+// channelRecordEscaper keeps each record on one line; the PSL parser reads the escapes back
+var channelRecordEscaper = strings.NewReplacer("\n", `\n`, "\r", `\r`)
+
+// channelRecord formats a record as one line: (<op>, <value>)
+func channelRecord(op string, value interface{}) string {
+	return channelRecordEscaper.Replace(SerializePSLList(PSLList{op, value})) + "\n"
+}
+
+// parseChannelRecord parses a line written by channelRecord
+func parseChannelRecord(line string) (string, interface{}, bool) {
+	record, err := ParsePSLList(line)
+	if err != nil || len(record) != 2 {
+		return "", nil, false
+	}
+	op, ok := record[0].(string)
+	return op, record[1], ok
+}
+
+// encodeChannelValue converts a channel message value to PSL data
+// Only plain data can be encoded: nil, booleans, numbers, strings, and lists of them.
+func encodeChannelValue(value interface{}, executor *Executor) (interface{}, error) {
+	value = executor.resolveValue(value)
+	switch v := value.(type) {
+	case nil, bool, int64, float64:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case string:
+		return v, nil
+	case QuotedString:
+		return string(v), nil
+	case Symbol:
+		return string(v), nil
+	case StoredString:
+		return string(v), nil
+	case StoredList:
+		named := v.NamedArgs()
+		if len(named) > 0 {
+			if v.Len() > 0 {
+				return nil, fmt.Errorf("cannot encode a list with both items and named keys")
+			}
+			m := PSLMap{}
+			for key, item := range named {
+				encoded, err := encodeChannelValue(item, executor)
+				if err != nil {
+					return nil, err
+				}
+				m[key] = encoded
+			}
+			return m, nil
+		}
+		list := PSLList{}
+		for _, item := range v.Items() {
+			encoded, err := encodeChannelValue(item, executor)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, encoded)
+		}
+		return list, nil
+	}
+	return nil, fmt.Errorf("cannot encode a %s value", getTypeName(value))
+}
+
+// decodeChannelValue converts PSL data back to a channel message value
+// Lists are registered with the executor, so the result can be queued on a channel.
+func decodeChannelValue(value interface{}, executor *Executor) interface{} {
+	switch v := value.(type) {
+	case string:
+		return QuotedString(v)
+	case PSLList:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = decodeChannelValue(item, executor)
+		}
+		return executor.RegisterObject(NewStoredListWithoutRefs(items), ObjList)
+	case PSLMap:
+		named := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			named[key] = decodeChannelValue(item, executor)
+		}
+		return executor.RegisterObject(NewStoredListWithNamed(nil, named), ObjList)
+	}
+	return value
+}
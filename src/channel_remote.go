@@ -0,0 +1,352 @@
+package pawscript
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Remote channels let separate PawScript processes exchange messages
+// A script exports a channel under a name and other processes connect to it by name.
+// Connections use a unix domain socket in a per-user directory, and a client must
+// present the token from the export's token file before anything else is accepted.
+// Each connection is a subscriber of the exported channel, so the exporting script
+// receives what connected processes send, and everything the exporting script sends
+// reaches every connected process. Messages travel as channel records (see
+// channel_records.go), so only plain data can be sent. An export belongs to the
+// interpreter that made it and ends when its channel is closed or the interpreter
+// shuts down (see PawScript.Shutdown), taking its socket and token file with it.
+
+const (
+	remoteAuthTimeout = 5 * time.Second // Time allowed for the auth handshake
+	remoteDialTimeout = 2 * time.Second
+	remoteRecvBuffer  = 256  // Messages a connection buffers before the sender waits
+	remoteAuthMaxLine = 4096 // Longest auth record read before the client is dropped
+)
+
+var remoteChannelNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// channelExport is a channel being served to other processes
+type channelExport struct {
+	owner      *PawScript // Only the owner can withdraw it
+	ch         *StoredChannel
+	listener   net.Listener
+	socketPath string
+	tokenPath  string
+	ownsToken  bool // The token file was created by the export and is removed with it
+}
+
+// Exports by name, so they can be withdrawn by name
+// Names are shared by the whole process, as the sockets are by the user's processes.
+var (
+	channelExportsMu sync.Mutex
+	channelExports   = make(map[string]*channelExport)
+)
+
+// remoteChannelPaths returns the socket path and default token file for an export name
+// Sockets live in $XDG_RUNTIME_DIR/pawscript, or a per-user directory under the temp dir.
+func remoteChannelPaths(name string) (string, string, error) {
+	if !remoteChannelNamePattern.MatchString(name) {
+		return "", "", fmt.Errorf("invalid channel name %q (use letters, digits, '.', '_' and '-')", name)
+	}
+	base, dirName := os.Getenv("XDG_RUNTIME_DIR"), "pawscript"
+	if base == "" {
+		base = os.TempDir()
+		if uid := os.Getuid(); uid >= 0 {
+			dirName = fmt.Sprintf("pawscript-%d", uid)
+		}
+	}
+	dir := filepath.Join(base, dirName)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", "", err
+	}
+	// MkdirAll keeps whatever is already there, which under a shared temp dir may not be ours
+	if err := checkRemoteChannelDir(dir); err != nil {
+		return "", "", fmt.Errorf("unsafe remote channel directory: %w", err)
+	}
+	return filepath.Join(dir, name+".sock"), filepath.Join(dir, name+".token"), nil
+}
+
+// readRemoteToken reads an access token from a token file
+func readRemoteToken(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("token file %s is empty", path)
+	}
+	return token, nil
+}
+
+// exportChannel serves a main channel to other processes under a name
+// tokenPath is the token file clients must read; empty uses the default next to the socket.
+// If the token file doesn't exist a random token is written to it (readable only by its owner).
+// Returns the token file path.
+func exportChannel(ps *PawScript, ch *StoredChannel, name, tokenPath string) (string, error) {
+	if ch.IsSubscriber || ch.NativeSend != nil || ch.NativeRecv != nil {
+		return "", fmt.Errorf("only script channels can be exported")
+	}
+	socketPath, defaultToken, err := remoteChannelPaths(name)
+	if err != nil {
+		return "", err
+	}
+	if tokenPath == "" {
+		tokenPath = defaultToken
+	}
+
+	channelExportsMu.Lock()
+	defer channelExportsMu.Unlock()
+	if _, exists := channelExports[name]; exists {
+		return "", fmt.Errorf("channel %q is already exported", name)
+	}
+
+	// A socket file left by a process that has exited is removed; a live one is an error
+	if _, err := os.Stat(socketPath); err == nil {
+		if conn, err := net.DialTimeout("unix", socketPath, remoteDialTimeout); err == nil {
+			_ = conn.Close()
+			return "", fmt.Errorf("channel %q is already exported by another process", name)
+		}
+		_ = os.Remove(socketPath)
+	}
+
+	ownsToken := false
+	token, err := readRemoteToken(tokenPath)
+	if os.IsNotExist(err) {
+		raw := make([]byte, 32)
+		if _, err := rand.Read(raw); err != nil {
+			return "", err
+		}
+		token = hex.EncodeToString(raw)
+		if err := os.WriteFile(tokenPath, []byte(token+"\n"), 0600); err != nil {
+			return "", err
+		}
+		ownsToken = true
+	} else if err != nil {
+		return "", err
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		if ownsToken {
+			_ = os.Remove(tokenPath)
+		}
+		return "", err
+	}
+	_ = os.Chmod(socketPath, 0600)
+
+	channelExports[name] = &channelExport{
+		owner:      ps,
+		ch:         ch,
+		listener:   listener,
+		socketPath: socketPath,
+		tokenPath:  tokenPath,
+		ownsToken:  ownsToken,
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return // Listener closed when the export ended
+			}
+			go serveRemoteChannel(conn, ch, token, ps.executor)
+		}
+	}()
+	return tokenPath, nil
+}
+
+// unexportChannel stops serving a channel the interpreter exported
+// Existing connections stay open until either side closes them.
+func unexportChannel(ps *PawScript, name string) error {
+	channelExportsMu.Lock()
+	export, exists := channelExports[name]
+	if exists && export.owner != ps {
+		channelExportsMu.Unlock()
+		return fmt.Errorf("channel %q is exported by another script", name)
+	}
+	delete(channelExports, name)
+	channelExportsMu.Unlock()
+	if !exists {
+		return fmt.Errorf("channel %q is not exported", name)
+	}
+	return export.close()
+}
+
+// unexportChannels stops serving every export for which match is true
+func unexportChannels(match func(*channelExport) bool) {
+	channelExportsMu.Lock()
+	var ended []*channelExport
+	for name, export := range channelExports {
+		if match(export) {
+			ended = append(ended, export)
+			delete(channelExports, name)
+		}
+	}
+	channelExportsMu.Unlock()
+	for _, export := range ended {
+		_ = export.close()
+	}
+}
+
+// close stops the listener and removes the socket, and the token file if the
+// export created it
+func (e *channelExport) close() error {
+	err := e.listener.Close()
+	_ = os.Remove(e.socketPath)
+	if e.ownsToken {
+		_ = os.Remove(e.tokenPath)
+	}
+	return err
+}
+
+// serveRemoteChannel handles one connection to an exported channel
+func serveRemoteChannel(conn net.Conn, ch *StoredChannel, token string, executor *Executor) {
+	defer conn.Close()
+	reader := bufio.NewReaderSize(conn, remoteAuthMaxLine)
+
+	// Until it authenticates, a client gets no more than one buffer for its auth line
+	_ = conn.SetReadDeadline(time.Now().Add(remoteAuthTimeout))
+	line, err := reader.ReadSlice('\n')
+	if err != nil {
+		return
+	}
+	op, value, ok := parseChannelRecord(string(line))
+	given, _ := value.(string)
+	if !ok || op != "auth" || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+		return
+	}
+	_ = conn.SetReadDeadline(time.Time{})
+
+	// Subscribe before confirming, so nothing sent after the client connects is missed
+	sub, err := ChannelSubscribe(ch)
+	if err != nil {
+		return
+	}
+	defer func() { _ = ChannelClose(sub) }()
+	if _, err := io.WriteString(conn, channelRecord("ok", nil)); err != nil {
+		return
+	}
+
+	// Forward messages for this subscriber until it or the connection closes
+	go func() {
+		defer conn.Close()
+		for {
+			_, _, value, err := ChannelSelect([]*StoredChannel{sub}, -1)
+			if err != nil {
+				return
+			}
+			encoded, err := encodeChannelValue(value, executor)
+			if err != nil {
+				continue // Not plain data; it can't leave the process
+			}
+			if _, err := io.WriteString(conn, channelRecord("send", encoded)); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		if op, value, ok := parseChannelRecord(line); ok && op == "send" {
+			_ = ChannelSend(sub, decodeChannelValue(value, executor))
+		}
+	}
+}
+
+// connectRemoteChannel connects to a channel exported by another process
+// tokenPath is the token file to authenticate with; empty uses the export's default.
+// The returned channel sends to the exported channel and receives what it sends.
+func connectRemoteChannel(name, tokenPath string, executor *Executor) (*StoredChannel, error) {
+	socketPath, defaultToken, err := remoteChannelPaths(name)
+	if err != nil {
+		return nil, err
+	}
+	usingDefault := tokenPath == ""
+	if usingDefault {
+		tokenPath = defaultToken
+	}
+	token, err := readRemoteToken(tokenPath)
+	if usingDefault && os.IsNotExist(err) {
+		return nil, fmt.Errorf("channel %q is not exported", name)
+	} else if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.DialTimeout("unix", socketPath, remoteDialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("channel %q is not exported", name)
+	}
+	reader := bufio.NewReader(conn)
+	_ = conn.SetDeadline(time.Now().Add(remoteAuthTimeout))
+	if _, err := io.WriteString(conn, channelRecord("auth", token)); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	line, err := reader.ReadString('\n')
+	if op, _, ok := parseChannelRecord(line); err != nil || !ok || op != "ok" {
+		_ = conn.Close()
+		return nil, fmt.Errorf("authentication failed for channel %q", name)
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	incoming := make(chan interface{}, remoteRecvBuffer)
+	var disconnected atomic.Bool
+	go func() {
+		defer func() {
+			disconnected.Store(true)
+			close(incoming)
+		}()
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if op, value, ok := parseChannelRecord(line); ok && op == "send" {
+				incoming <- decodeChannelValue(value, executor)
+			}
+		}
+	}()
+
+	ch := NewStoredChannel(0)
+	ch.NativeSend = func(v interface{}) error {
+		encoded, err := encodeChannelValue(v, executor)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(conn, channelRecord("send", encoded))
+		return err
+	}
+	ch.NativeRecv = func() (interface{}, error) {
+		v, ok := <-incoming
+		if !ok {
+			return nil, fmt.Errorf("remote channel %q disconnected", name)
+		}
+		return v, nil
+	}
+	ch.NativeLen = func() int {
+		// Once disconnected, report a pending receive so waiters wake up and see the error
+		if n := len(incoming); n > 0 || !disconnected.Load() {
+			return n
+		}
+		return 1
+	}
+	ch.NativeClose = func() error {
+		return conn.Close()
+	}
+	return ch, nil
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package pawscript
+
+// checkRemoteChannelDir has nothing to check here: the directory is under the user's own
+// temp dir, whose access is controlled by ACLs rather than unix modes
+func checkRemoteChannelDir(dir string) error {
+	return nil
+}
@@ -0,0 +1,105 @@
+package pawscript
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChannelExportOwnership(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	owner, other := New(nil), New(nil)
+
+	ch := NewStoredChannel(0)
+	tokenPath, err := exportChannel(owner, ch, "owned", "")
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if err := unexportChannel(other, "owned"); err == nil {
+		t.Error("another interpreter withdrew the export")
+	}
+
+	// Closing the channel ends the export, so the name can be exported again
+	if err := ChannelClose(ch); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if _, err := os.Stat(tokenPath); !os.IsNotExist(err) {
+		t.Errorf("token file left behind after close: %v", err)
+	}
+	if _, err := exportChannel(other, NewStoredChannel(0), "owned", ""); err != nil {
+		t.Fatalf("re-export after close: %v", err)
+	}
+
+	// Shutting the owner down ends its exports
+	other.Shutdown()
+	if err := unexportChannel(other, "owned"); err == nil {
+		t.Error("export outlived Shutdown")
+	}
+	if _, err := exportChannel(owner, NewStoredChannel(0), "owned", ""); err != nil {
+		t.Fatalf("re-export after Shutdown: %v", err)
+	}
+	owner.Shutdown()
+}
+
+func TestRemoteChannelDirChecked(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix modes and ownership aren't checked on windows")
+	}
+	base := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", base)
+	dir := filepath.Join(base, "pawscript")
+
+	if _, _, err := remoteChannelPaths("fresh"); err != nil {
+		t.Fatalf("new directory: %v", err)
+	}
+
+	if err := os.Chmod(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := remoteChannelPaths("open"); err == nil {
+		t.Error("accepted a directory others can read")
+	}
+
+	// A symlink to a directory we own is refused too, as it could have been planted
+	if err := os.Remove(dir); err != nil {
+		t.Fatal(err)
+	}
+	target := t.TempDir()
+	if err := os.Chmod(target, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := remoteChannelPaths("linked"); err == nil {
+		t.Error("accepted a symlinked directory")
+	}
+}
+
+func TestRemoteChannelAuthLineCapped(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+	done := make(chan struct{})
+	go func() {
+		serveRemoteChannel(server, NewStoredChannel(0), "token", nil)
+		close(done)
+	}()
+
+	// An auth line that never ends is cut off at the cap rather than buffered
+	go func() {
+		_, _ = client.Write([]byte(strings.Repeat("x", remoteAuthMaxLine*4)))
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("server kept reading an oversized auth line")
+	}
+	if _, err := client.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("connection still open: %v", err)
+	}
+}
@@ -0,0 +1,28 @@
+//go:build unix
+
+package pawscript
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// checkRemoteChannelDir refuses a socket directory another user could have planted or
+// can reach: it must be a real directory (not a symlink), owned by us, with mode 0700
+func checkRemoteChannelDir(dir string) error {
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); !ok || int(stat.Uid) != os.Getuid() {
+		return fmt.Errorf("%s is not owned by the current user", dir)
+	}
+	if info.Mode().Perm() != 0700 {
+		return fmt.Errorf("%s has mode %#o, want 0700", dir, info.Mode().Perm())
+	}
+	return nil
+}
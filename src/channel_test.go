@@ -0,0 +1,368 @@
+package pawscript
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// selectReturns reports whether ChannelSelect on chs returns once closeAll has run
+func selectReturns(t *testing.T, chs []*StoredChannel, closeAll func()) {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() {
+		_, _, _, err := ChannelSelect(chs, -1)
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	closeAll()
+	select {
+	case err := <-done:
+		if err != ErrChannelsClosed {
+			t.Errorf("got %v, want ErrChannelsClosed", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("select still waiting after its channels closed")
+	}
+}
+
+func TestChannelCloseWakesSelect(t *testing.T) {
+	t.Run("native channel", func(t *testing.T) {
+		ch := NewStoredChannel(0)
+		blocked := make(chan struct{})
+		ch.NativeRecv = func() (interface{}, error) {
+			<-blocked
+			return nil, ErrChannelClosed
+		}
+		ch.NativeClose = func() error {
+			close(blocked)
+			return nil
+		}
+		selectReturns(t, []*StoredChannel{ch}, func() { _ = ChannelClose(ch) })
+	})
+
+	t.Run("script channel", func(t *testing.T) {
+		ch := NewStoredChannel(0)
+		selectReturns(t, []*StoredChannel{ch}, func() { _ = ChannelClose(ch) })
+	})
+	t.Run("disconnected subscriber", func(t *testing.T) {
+		ch := NewStoredChannel(0)
+		sub, err := ChannelSubscribe(ch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		selectReturns(t, []*StoredChannel{sub}, func() { _ = ChannelDisconnect(ch, sub.SubscriberID) })
+	})
+}
+
+func TestQueueSendBlockReleasesLock(t *testing.T) {
+	queue := make(chan interface{}, 1)
+	ch := NewChannelFromGo(queue, nil, nil)
+	ch.Overflow = OverflowBlock
+	if err := ChannelSend(ch, "first"); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := make(chan error, 1)
+	go func() {
+		sent <- ChannelSend(ch, "second")
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	// The blocked send doesn't hold up others using the channel
+	stats := make(chan ChannelStats, 1)
+	go func() {
+		stats <- ChannelGetStats(ch)
+	}()
+	select {
+	case <-stats:
+	case <-time.After(2 * time.Second):
+		t.Fatal("ChannelGetStats blocked behind a send waiting for room")
+	}
+
+	if v := <-queue; v != "first" {
+		t.Errorf("got %v, want first", v)
+	}
+	if err := <-sent; err != nil {
+		t.Fatal(err)
+	}
+	if v := <-queue; v != "second" {
+		t.Errorf("got %v, want second", v)
+	}
+	if got := ChannelGetStats(ch).Sent; got != 2 {
+		t.Errorf("Sent = %d, want 2", got)
+	}
+}
+
+func TestBlockedSendEnds(t *testing.T) {
+	// sendReturns runs send, which should block until end is called
+	sendReturns := func(t *testing.T, send func() error, end func(), want error) {
+		t.Helper()
+		sent := make(chan error, 1)
+		go func() {
+			sent <- send()
+		}()
+		time.Sleep(20 * time.Millisecond)
+		select {
+		case err := <-sent:
+			t.Fatalf("send returned %v before it was ended", err)
+		default:
+		}
+		end()
+		select {
+		case err := <-sent:
+			if !errors.Is(err, want) {
+				t.Errorf("got %v, want %v", err, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("send still blocked")
+		}
+	}
+	fullQueue := func() *StoredChannel {
+		ch := NewChannelFromGo(make(chan interface{}, 1), nil, nil)
+		ch.Overflow = OverflowBlock
+		_ = ChannelSend(ch, "first")
+		return ch
+	}
+	fullChannel := func() *StoredChannel {
+		ch := NewStoredChannel(1)
+		ch.Overflow = OverflowBlock
+		_ = ChannelSend(ch, "first")
+		return ch
+	}
+
+	t.Run("native close", func(t *testing.T) {
+		ch := fullQueue()
+		sendReturns(t, func() error { return ChannelSend(ch, "second") },
+			func() { _ = ChannelClose(ch) }, ErrChannelClosed)
+		if err := ChannelSend(ch, "third"); !errors.Is(err, ErrChannelClosed) {
+			t.Errorf("send after close: got %v, want ErrChannelClosed", err)
+		}
+	})
+	t.Run("native cancel", func(t *testing.T) {
+		ch := fullQueue()
+		done := make(chan struct{})
+		sendReturns(t, func() error { return ChannelSendUntil(ch, "second", done) },
+			func() { close(done) }, ErrCancelled)
+	})
+	t.Run("script cancel", func(t *testing.T) {
+		ch := fullChannel()
+		done := make(chan struct{})
+		sendReturns(t, func() error { return ChannelSendUntil(ch, "second", done) },
+			func() { close(done) }, ErrCancelled)
+		if n := ChannelLen(ch); n != 1 {
+			t.Errorf("len = %d, want 1", n)
+		}
+	})
+}
+
+func TestSelectNativeWithoutLen(t *testing.T) {
+	// A native channel that can't report its length, like a pipe reader
+	native := func(values chan interface{}) *StoredChannel {
+		ch := NewStoredChannel(0)
+		ch.NativeRecv = func() (interface{}, error) {
+			v, ok := <-values
+			if !ok {
+				return nil, ErrChannelClosed
+			}
+			return v, nil
+		}
+		return ch
+	}
+	values := make(chan interface{})
+	pipe, script := native(values), NewStoredChannel(0)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		values <- "from pipe"
+	}()
+	index, _, value, err := ChannelSelect([]*StoredChannel{script, pipe}, 2*time.Second)
+	if err != nil || index != 1 || value != "from pipe" {
+		t.Fatalf("got %d, %v, %v; want 1, from pipe", index, value, err)
+	}
+
+	// Once its source closes it counts as closed, leaving the other channel to decide
+	close(values)
+	if _, _, _, err := ChannelSelect([]*StoredChannel{script, pipe}, 50*time.Millisecond); err != ErrChannelTimeout {
+		t.Errorf("got %v, want ErrChannelTimeout", err)
+	}
+	_ = ChannelClose(script)
+	if _, _, _, err := ChannelSelect([]*StoredChannel{script, pipe}, 2*time.Second); err != ErrChannelsClosed {
+		t.Errorf("got %v, want ErrChannelsClosed", err)
+	}
+}
+
+func TestChannelJournalChargesWriteQuota(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.journal")
+	ps := New(&Config{})
+	quota := newQuotaTracker(&ResourceQuotas{MaxBytesWritten: 100})
+	ch := NewStoredChannel(0)
+	if _, err := openChannelJournal(ch, path, ps.executor, quota); err != nil {
+		t.Fatal(err)
+	}
+	defer ch.closeJournal()
+
+	var err error
+	sent := 0
+	for ; sent < 100 && err == nil; sent++ {
+		err = ChannelSend(ch, int64(sent))
+	}
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("after %d sends: got %v, want ErrQuotaExceeded", sent, err)
+	}
+	info, statErr := os.Stat(path)
+	if statErr != nil {
+		t.Fatal(statErr)
+	}
+	if info.Size() > 100 || quota.usage.MaxBytesWritten != info.Size() {
+		t.Errorf("journal is %d bytes, %d charged, limit 100", info.Size(), quota.usage.MaxBytesWritten)
+	}
+}
+
+func TestChannelToGo(t *testing.T) {
+	t.Run("send errors reported", func(t *testing.T) {
+		ch := NewStoredChannel(0)
+		_ = ChannelClose(ch)
+		_, in, errs := ChannelToGo(ch, 0, nil)
+		in <- "hello"
+		select {
+		case err := <-errs:
+			if err == nil {
+				t.Error("got a nil error")
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("send to a closed channel not reported")
+		}
+		close(in)
+		if _, ok := <-errs; ok {
+			t.Error("error channel open after the send channel closed")
+		}
+	})
+
+	t.Run("done stops an unread receive", func(t *testing.T) {
+		ch := NewStoredChannel(0)
+		done := make(chan struct{})
+		out, _, errs := ChannelToGo(ch, 0, done)
+		// Nobody reads out, so the forwarder is left holding this message
+		if err := ChannelSend(ch, "unread"); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(20 * time.Millisecond)
+		close(done)
+		deadline := time.After(2 * time.Second)
+		for _, closed := range []func() bool{
+			func() bool { _, ok := <-errs; return !ok },
+			func() bool {
+				for range out {
+				}
+				return true
+			},
+		} {
+			result := make(chan bool, 1)
+			go func() { result <- closed() }()
+			select {
+			case ok := <-result:
+				if !ok {
+					t.Error("got a value, want the channel closed")
+				}
+			case <-deadline:
+				t.Fatal("bridge still running after done closed")
+			}
+		}
+	})
+}
+
+func TestDetachableChannel(t *testing.T) {
+	t.Run("passes through until detached", func(t *testing.T) {
+		outQueue := make(chan interface{}, 4)
+		inQueue := make(chan interface{}, 4)
+		ch := NewChannelFromGo(outQueue, inQueue, nil)
+		proxy, detach := DetachableChannel(ch)
+
+		if err := ChannelSend(proxy, "out"); err != nil {
+			t.Fatal(err)
+		}
+		if got := <-outQueue; got != "out" {
+			t.Errorf("sent %v, want out", got)
+		}
+		inQueue <- "in"
+		if _, got, err := ChannelRecvTimeout(proxy, time.Second); err != nil || got != "in" {
+			t.Errorf("received %v, %v, want in", got, err)
+		}
+
+		detach()
+		if err := ChannelSend(proxy, "late"); err != ErrChannelClosed {
+			t.Errorf("send after detach: %v, want ErrChannelClosed", err)
+		}
+		if _, _, err := ChannelRecv(proxy); err != ErrChannelClosed {
+			t.Errorf("receive after detach: %v, want ErrChannelClosed", err)
+		}
+		if ch.IsClosed {
+			t.Error("detaching closed the channel behind it")
+		}
+	})
+
+	t.Run("waiting receive gives up without taking input", func(t *testing.T) {
+		inQueue := make(chan interface{}, 4)
+		ch := NewChannelFromGo(nil, inQueue, nil)
+		proxy, detach := DetachableChannel(ch)
+
+		result := make(chan error, 1)
+		go func() {
+			_, err := proxy.NativeRecv()
+			result <- err
+		}()
+		time.Sleep(20 * time.Millisecond)
+		detach()
+		select {
+		case err := <-result:
+			if err != ErrChannelClosed {
+				t.Errorf("got %v, want ErrChannelClosed", err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("receive still waiting after detach")
+		}
+
+		// The next reader of the channel gets what arrives
+		inQueue <- "next"
+		if _, got, err := ChannelRecvTimeout(ch, time.Second); err != nil || got != "next" {
+			t.Errorf("received %v, %v, want next", got, err)
+		}
+	})
+
+	t.Run("blocked send gives up", func(t *testing.T) {
+		outQueue := make(chan interface{})
+		ch := NewChannelFromGo(outQueue, nil, nil)
+		ch.Overflow = OverflowBlock
+		proxy, detach := DetachableChannel(ch)
+
+		result := make(chan error, 1)
+		go func() { result <- ChannelSend(proxy, "stuck") }()
+		time.Sleep(20 * time.Millisecond)
+		detached := make(chan struct{})
+		go func() {
+			detach()
+			close(detached)
+		}()
+		select {
+		case err := <-result:
+			if err != ErrChannelClosed {
+				t.Errorf("got %v, want ErrChannelClosed", err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("send still blocked after detach")
+		}
+		select {
+		case <-detached:
+		case <-time.After(2 * time.Second):
+			t.Fatal("detach didn't return")
+		}
+		select {
+		case v := <-outQueue:
+			t.Errorf("%v reached the channel after detach", v)
+		default:
+		}
+	})
+}
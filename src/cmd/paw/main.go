@@ -1,6 +1,7 @@
 package main
 
 import (
+	"crypto/ed25519"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -307,11 +308,17 @@ func main() {
 	flag.BoolVar(verboseFlag, "v", false, "Enable verbose output (short, alias for -debug)")
 
 	// File access control flags
-	unrestrictedFlag := flag.Bool("unrestricted", false, "Disable all file/exec access restrictions")
+	unrestrictedFlag := flag.Bool("unrestricted", false, "Disable all file/exec/network access restrictions")
 	readRootsFlag := flag.String("read-roots", "", "Additional directories for file reading")
 	writeRootsFlag := flag.String("write-roots", "", "Additional directories for file writing")
 	execRootsFlag := flag.String("exec-roots", "", "Additional directories for exec command")
 	sandboxFlag := flag.String("sandbox", "", "Restrict all access to this directory only")
+	allowHostsFlag := flag.String("allow-hosts", "", "Hosts scripts may connect to (names, *.domain, CIDR)")
+	denyHostsFlag := flag.String("deny-hosts", "", "Hosts scripts may never connect to")
+	allowEnvFlag := flag.String("allow-env", "", "Extra environment variables scripts may see (NAME or PREFIX*)")
+	dryRunFlag := flag.Bool("dry-run", false, "Record file/exec/network access instead of performing it")
+	requireSignatureFlag := flag.Bool("require-signature", false, "Only run scripts signed by a key in ~/.paw/trusted_keys")
+	signFlag := flag.String("sign", "", "Sign the script with this key file (created if missing) instead of running it")
 
 	// Optimization level flag
 	optLevelFlag := flag.Int("O", 1, "Optimization level (0=no caching, 1=cache macro/loop bodies)")
@@ -363,6 +370,11 @@ func main() {
 	stdinInfo, _ := os.Stdin.Stat()
 	isStdinRedirected := (stdinInfo.Mode() & os.ModeCharDevice) == 0
 
+	if *signFlag != "" && len(fileArgs) == 0 {
+		errorPrintf("Error: --sign requires a script file\n")
+		os.Exit(1)
+	}
+
 	if len(fileArgs) > 0 {
 		// Filename provided
 		requestedFile := fileArgs[0]
@@ -385,6 +397,14 @@ func main() {
 		}
 		scriptContent = string(content)
 
+		if *signFlag != "" {
+			if err := signScript(scriptFile, content, *signFlag); err != nil {
+				errorPrintf("Error signing script: %v\n", err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		}
+
 		// Remaining fileArgs become script arguments (if no separator was used)
 		if separatorIndex == -1 && len(fileArgs) > 1 {
 			scriptArgs = fileArgs[1:]
@@ -392,6 +412,10 @@ func main() {
 
 	} else if isStdinRedirected {
 		// No filename, but stdin is redirected - read from stdin
+		if *requireSignatureFlag {
+			errorPrintf("Error: --require-signature needs a script file to find its signature\n")
+			os.Exit(1)
+		}
 		content, err := io.ReadAll(os.Stdin)
 		if err != nil {
 			errorPrintf("Error reading from stdin: %v\n", err)
@@ -401,6 +425,11 @@ func main() {
 
 	} else {
 		// No filename and stdin is not redirected - run REPL
+		// Typed lines have no signature, so the REPL can't honor --require-signature
+		if *requireSignatureFlag {
+			errorPrintf("Error: --require-signature needs a script file to find its signature\n")
+			os.Exit(1)
+		}
 		runREPL(debug, *unrestrictedFlag, *optLevelFlag)
 		os.Exit(0)
 	}
@@ -408,13 +437,16 @@ func main() {
 	// Build file access configuration
 	// Default: sandboxed to safe paths. Use --unrestricted to disable.
 	var fileAccess *pawscript.FileAccessConfig
+	var envAllowlist []string
 
 	// Determine script directory (used for sandbox paths and relative path resolution)
-	var scriptDir string
+	// The absolute script path also namespaces the script's keychain secrets
+	var scriptDir, secretNamespace string
 	if scriptFile != "" {
 		absScript, err := filepath.Abs(scriptFile)
 		if err == nil {
 			scriptDir = filepath.Dir(absScript)
+			secretNamespace = absScript
 		}
 	}
 
@@ -526,8 +558,32 @@ func main() {
 				fileAccess.ExecRoots = append(fileAccess.ExecRoots, parseRoots(*execRootsFlag)...)
 			}
 		}
+
+		// Network access: none unless --allow-hosts names the endpoints
+		var err error
+		fileAccess.AllowedHosts = []string{}
+		if *allowHostsFlag != "" {
+			if fileAccess.AllowedHosts, err = pawscript.ParseHostPatterns(*allowHostsFlag); err != nil {
+				errorPrintf("Error in --allow-hosts: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if *denyHostsFlag != "" {
+			if fileAccess.DeniedHosts, err = pawscript.ParseHostPatterns(*denyHostsFlag); err != nil {
+				errorPrintf("Error in --deny-hosts: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		// Environment: the safe defaults plus any --allow-env names
+		envAllowlist = append([]string{}, pawscript.DefaultEnvAllowlist...)
+		for _, name := range strings.Split(*allowEnvFlag, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				envAllowlist = append(envAllowlist, name)
+			}
+		}
 	}
-	// If --unrestricted, fileAccess remains nil (no restrictions)
+	// If --unrestricted, fileAccess and envAllowlist remain nil (no restrictions)
 
 	// Create PawScript interpreter
 	ps := pawscript.New(&pawscript.Config{
@@ -538,9 +594,22 @@ func main() {
 		ContextLines:         2,
 		FileAccess:           fileAccess,
 		ScriptDir:            scriptDir,
+		SecretNamespace:      secretNamespace,
 		OptLevel:             pawscript.OptimizationLevel(*optLevelFlag),
+		DryRun:               *dryRunFlag,
+		RequireSignature:     *requireSignatureFlag,
+		EnvAllowlist:         envAllowlist,
 	})
 
+	// In a dry run, report what the script accessed as it exits
+	exit := func(code int) {
+		ps.Shutdown()
+		if *dryRunFlag {
+			fmt.Fprintln(os.Stderr, pawscript.SerializePSLPretty(ps.DryRunReport().PSL()))
+		}
+		os.Exit(code)
+	}
+
 	// Register standard library commands
 	ps.RegisterStandardLibrary(scriptArgs)
 
@@ -555,9 +624,9 @@ func main() {
 	// Exit with appropriate code
 	if boolStatus, ok := result.(pawscript.BoolStatus); ok {
 		if bool(boolStatus) {
-			os.Exit(0)
+			exit(0)
 		} else {
-			os.Exit(1)
+			exit(1)
 		}
 	}
 
@@ -574,21 +643,50 @@ func main() {
 			select {
 			case <-timeout:
 				errorPrintf("Timeout waiting for async operations to complete\n")
-				os.Exit(1)
+				exit(1)
 			case <-ticker.C:
 				// Check if there are still active tokens
 				status := ps.GetTokenStatus()
 				activeCount, _ := status["activeCount"].(int)
 				if activeCount == 0 {
 					// All tokens completed
-					os.Exit(0)
+					exit(0)
 				}
 			}
 		}
 	}
 
 	// Unknown result type, exit successfully
-	os.Exit(0)
+	exit(0)
+}
+
+// signScript writes the detached signature for a script, generating the key file
+// if it doesn't exist, and prints the public key to add to trusted_keys
+func signScript(scriptFile string, content []byte, keyFile string) error {
+	var key ed25519.PrivateKey
+	keyText, err := os.ReadFile(keyFile)
+	if os.IsNotExist(err) {
+		_, key, err = ed25519.GenerateKey(nil)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(keyFile, []byte(pawscript.EncodeSigningKey(key)+"\n"), 0600); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Created signing key %s\n", keyFile)
+	} else if err != nil {
+		return err
+	} else if key, err = pawscript.DecodeSigningKey(string(keyText)); err != nil {
+		return fmt.Errorf("%s: %v", keyFile, err)
+	}
+
+	sigFile := scriptFile + pawscript.SignatureExt
+	if err := os.WriteFile(sigFile, pawscript.SignScript(content, key), 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s\nPublic key (for ~/.paw/trusted_keys):\n", sigFile)
+	fmt.Println(pawscript.EncodePublicKey(key.Public().(ed25519.PublicKey)))
+	return nil
 }
 
 func findScriptFile(filename string) string {
@@ -659,11 +757,21 @@ Options:
   -d, --debug         Enable debug output
   -v, --verbose       Enable verbose output (same as --debug)
   -O N                Set optimization level (0=no caching, 1=cache macro/loop bodies, default: 1)
-  --unrestricted      Disable all file/exec access restrictions
+  --unrestricted      Disable all file/exec/network access restrictions
   --sandbox DIR       Restrict all access to DIR only
   --read-roots DIRS   Additional directories for reading
   --write-roots DIRS  Additional directories for writing
   --exec-roots DIRS   Additional directories for exec command
+  --allow-hosts HOSTS Hosts scripts may connect to (default: none)
+  --deny-hosts HOSTS  Hosts scripts may never connect to
+  --allow-env NAMES   Extra environment variables scripts may see (NAME or PREFIX*)
+  --dry-run           Stub out file writes, exec and network access, and print
+                      what the script accessed (with a minimal sandbox) to stderr
+  --require-signature Only run scripts with a SCRIPT.sig signature made by a
+                      key listed in ~/.paw/trusted_keys (refuses stdin and
+                      the REPL)
+  --sign KEYFILE      Write SCRIPT.sig signed with KEYFILE instead of running
+                      the script (KEYFILE is created if missing)
 
 Arguments:
   script.paw          Script file to execute (adds .paw extension if needed)
@@ -673,6 +781,9 @@ Default Security Sandbox:
   Read:   SCRIPT_DIR, CWD, /tmp
   Write:  SCRIPT_DIR/saves, SCRIPT_DIR/output, CWD/saves, CWD/output, /tmp
   Exec:   SCRIPT_DIR/helpers, SCRIPT_DIR/bin
+  Net:    none (HOSTS are comma-separated names, *.domain wildcards or
+          CIDR ranges, each optionally with :port)
+  Env:    HOME, USER, PATH, LANG, LC_*, TERM, TZ, TMPDIR and similar basics
 
 Environment Variables (use SCRIPT_DIR as placeholder):
   PAW_READ_ROOTS      Override default read roots
@@ -684,6 +795,7 @@ Examples:
   paw --unrestricted hello.paw     # No file/exec restrictions
   paw --sandbox /myapp test.paw    # Restrict all to /myapp
   paw --exec-roots /usr/bin test.paw  # Add /usr/bin to exec roots
+  paw --allow-hosts "*.example.com:443" test.paw  # HTTPS to example.com subdomains
 
   # Environment variable with SCRIPT_DIR placeholder:
   export PAW_WRITE_ROOTS="SCRIPT_DIR/data,/tmp"
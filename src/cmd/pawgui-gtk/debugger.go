@@ -0,0 +1,152 @@
+package main
+
+import (
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/gotk3/gotk3/pango"
+	"github.com/phroun/pawscript"
+	"github.com/phroun/pawscript/src/pkg/pawgui"
+)
+
+// Debugger panel
+// Scripts run from the launcher stop at the breakpoints set by clicking the script
+// editor's gutter. The panel beside the terminal then shows where the script is,
+// its call stack and the variables in scope, with buttons to go on or step. It
+// stays up until the script finishes.
+
+// debuggerPanel is the launcher's debugger pane
+type debuggerPanel struct {
+	box       *gtk.Box
+	status    *gtk.Label
+	buttons   []*gtk.Button // Sensitive while a script is paused
+	stack     *gtk.TextBuffer
+	variables *gtk.TextBuffer
+}
+
+var (
+	launcherDebugger   *pawscript.Debugger
+	launcherDebugPanel *debuggerPanel
+	launcherDebugPaned *gtk.Paned // Splits the debugger beside the launcher terminal
+)
+
+// newDebuggerPanel creates the debugger pane, hidden until a script pauses
+func newDebuggerPanel() *debuggerPanel {
+	p := &debuggerPanel{}
+
+	p.box, _ = gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 4)
+	p.box.SetMarginStart(4)
+	p.box.SetMarginEnd(4)
+	p.box.SetMarginBottom(4)
+
+	p.status, _ = gtk.LabelNew(tr("Running"))
+	p.status.SetEllipsize(pango.ELLIPSIZE_END)
+	p.status.SetXAlign(0)
+	p.box.PackStart(p.status, false, false, 0)
+
+	buttonRow, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 4)
+	for _, b := range []struct {
+		label  string
+		action pawscript.DebugAction
+	}{
+		{"Continue", pawscript.DebugContinue},
+		{"Step In", pawscript.DebugStepIn},
+		{"Step Over", pawscript.DebugStepOver},
+		{"Step Out", pawscript.DebugStepOut},
+	} {
+		action := b.action
+		button, _ := gtk.ButtonNewWithLabel(b.label)
+		button.Connect("clicked", func() { p.resume(action) })
+		button.SetSensitive(false)
+		buttonRow.PackStart(button, false, false, 0)
+		p.buttons = append(p.buttons, button)
+	}
+	p.box.PackStart(buttonRow, false, false, 0)
+
+	p.stack = p.addSection("Call Stack", false)
+	p.variables = p.addSection("Variables", true)
+
+	// Shown when a script pauses, not with the rest of the window
+	p.box.ShowAll()
+	p.box.Hide()
+	p.box.SetNoShowAll(true)
+	return p
+}
+
+// addSection adds a heading and a read-only text area under it, returning its buffer
+func (p *debuggerPanel) addSection(title string, expand bool) *gtk.TextBuffer {
+	heading, _ := gtk.LabelNew("")
+	heading.SetMarkup("<b>" + title + "</b>")
+	heading.SetXAlign(0)
+	p.box.PackStart(heading, false, false, 0)
+
+	view, _ := gtk.TextViewNew()
+	view.SetMonospace(true)
+	view.SetEditable(false)
+	view.SetCursorVisible(false)
+	view.SetLeftMargin(4)
+	scroll, _ := gtk.ScrolledWindowNew(nil, nil)
+	scroll.SetPolicy(gtk.POLICY_AUTOMATIC, gtk.POLICY_AUTOMATIC)
+	scroll.SetSizeRequest(-1, 80)
+	scroll.Add(view)
+	p.box.PackStart(scroll, expand, true, 0)
+
+	buffer, _ := view.GetBuffer()
+	return buffer
+}
+
+// newLauncherDebugger creates the debugger that launcher scripts run under
+func newLauncherDebugger() *pawscript.Debugger {
+	return pawscript.NewDebugger(func(pause *pawscript.DebugPause) {
+		// Called on the script's goroutine
+		glib.IdleAdd(func() {
+			if launcherDebugPanel != nil {
+				launcherDebugPanel.showPause(pause)
+			}
+		})
+	})
+}
+
+// showPause shows where a script paused, opening the panel if it was hidden
+func (p *debuggerPanel) showPause(pause *pawscript.DebugPause) {
+	if !p.box.GetVisible() {
+		p.box.Show()
+		width := launcherDebugPaned.GetAllocatedWidth()
+		launcherDebugPaned.SetPosition(max(width-320, width/2))
+	}
+	p.status.SetText(pawgui.DebugPauseStatus(pause))
+	p.status.SetTooltipText(pause.Command)
+	p.stack.SetText(pawgui.DebugStackText(pause))
+	p.variables.SetText(pawgui.DebugVariablesText(pause))
+	for _, button := range p.buttons {
+		button.SetSensitive(true)
+	}
+	if launcherEditor != nil {
+		launcherEditor.showPaused(pause.File, pause.Line)
+	}
+}
+
+// resume lets the paused script go on
+func (p *debuggerPanel) resume(action pawscript.DebugAction) {
+	p.status.SetText("Running")
+	for _, button := range p.buttons {
+		button.SetSensitive(false)
+	}
+	if launcherEditor != nil {
+		launcherEditor.showPaused("", 0)
+	}
+	launcherDebugger.Resume(action)
+}
+
+// scriptFinished closes the panel once the script it was stepping through ends
+func (p *debuggerPanel) scriptFinished() {
+	p.status.SetText("Running")
+	p.stack.SetText("")
+	p.variables.SetText("")
+	for _, button := range p.buttons {
+		button.SetSensitive(false)
+	}
+	p.box.Hide()
+	if launcherEditor != nil {
+		launcherEditor.showPaused("", 0)
+	}
+}
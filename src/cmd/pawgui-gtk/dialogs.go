@@ -0,0 +1,158 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/phroun/pawscript"
+	"github.com/sqweek/dialog"
+)
+
+// Script dialogs
+// Scripts that IMPORT gui ask the user things with gui_alert, gui_confirm,
+// gui_prompt, gui_file_open and gui_file_save. The interpreter calls guiDialogs
+// from the script's goroutine; each dialog runs on the main thread, over the
+// window the user is in, while the script waits for the answer.
+
+// guiDialogs shows the dialogs scripts ask for (Config.Dialogs)
+type guiDialogs struct{}
+
+// onMainThread runs fn on the GTK main thread and waits for it to finish
+func onMainThread(fn func()) {
+	done := make(chan struct{})
+	glib.IdleAdd(func() bool {
+		fn()
+		close(done)
+		return false
+	})
+	<-done
+}
+
+// dialogParent returns the window a script's dialog should appear over
+func dialogParent() gtk.IWindow {
+	if app != nil {
+		if win := app.GetActiveWindow(); win != nil {
+			return win
+		}
+	}
+	if mainWindow != nil {
+		return mainWindow
+	}
+	return nil
+}
+
+// Alert shows a message until the user dismisses it
+func (guiDialogs) Alert(title, message string) {
+	onMainThread(func() {
+		msg := gtk.MessageDialogNew(dialogParent(), gtk.DIALOG_MODAL|gtk.DIALOG_DESTROY_WITH_PARENT,
+			gtk.MESSAGE_INFO, gtk.BUTTONS_OK, "%s", message)
+		msg.SetTitle(title)
+		msg.Run()
+		msg.Destroy()
+	})
+}
+
+// Confirm asks a question, returning whether the user chose OK
+func (guiDialogs) Confirm(title, message string) bool {
+	var confirmed bool
+	onMainThread(func() {
+		msg := gtk.MessageDialogNew(dialogParent(), gtk.DIALOG_MODAL|gtk.DIALOG_DESTROY_WITH_PARENT,
+			gtk.MESSAGE_QUESTION, gtk.BUTTONS_OK_CANCEL, "%s", message)
+		msg.SetTitle(title)
+		msg.SetDefaultResponse(gtk.RESPONSE_OK)
+		confirmed = msg.Run() == gtk.RESPONSE_OK
+		msg.Destroy()
+	})
+	return confirmed
+}
+
+// Prompt asks for a line of text, starting with initial
+func (guiDialogs) Prompt(title, message, initial string) (string, bool) {
+	var text string
+	var ok bool
+	onMainThread(func() {
+		text, ok = promptText(dialogParent(), title, message, initial)
+	})
+	return text, ok
+}
+
+// promptText asks for a line of text over parent, starting with initial. It must
+// be called on the main thread.
+func promptText(parent gtk.IWindow, title, message, initial string) (string, bool) {
+	dlg, err := gtk.DialogNewWithButtons(title, parent, gtk.DIALOG_MODAL|gtk.DIALOG_DESTROY_WITH_PARENT,
+		[]interface{}{tr("Cancel"), gtk.RESPONSE_CANCEL}, []interface{}{tr("OK"), gtk.RESPONSE_OK})
+	if err != nil {
+		return "", false
+	}
+	dlg.SetDefaultResponse(gtk.RESPONSE_OK)
+
+	contentArea, _ := dlg.GetContentArea()
+	contentArea.SetSpacing(8)
+	contentArea.SetMarginStart(12)
+	contentArea.SetMarginEnd(12)
+	contentArea.SetMarginTop(12)
+	contentArea.SetMarginBottom(12)
+
+	label, _ := gtk.LabelNew(message)
+	label.SetXAlign(0)
+	label.SetLineWrap(true)
+	contentArea.PackStart(label, false, false, 0)
+
+	entry, _ := gtk.EntryNew()
+	entry.SetText(initial)
+	entry.SetActivatesDefault(true)
+	entry.SetWidthChars(40)
+	contentArea.PackStart(entry, false, false, 0)
+
+	dlg.ShowAll()
+	var text string
+	ok := dlg.Run() == gtk.RESPONSE_OK
+	if ok {
+		text, _ = entry.GetText()
+	}
+	dlg.Destroy()
+	return text, ok
+}
+
+// FileOpen asks for an existing file
+func (guiDialogs) FileOpen(options pawscript.FileDialogOptions) (string, bool) {
+	var path string
+	onMainThread(func() {
+		path, _ = fileDialog(options).Load()
+	})
+	return path, path != ""
+}
+
+// FileSave asks where to save a file
+func (guiDialogs) FileSave(options pawscript.FileDialogOptions) (string, bool) {
+	var path string
+	onMainThread(func() {
+		path, _ = fileDialog(options).Save()
+	})
+	return path, path != ""
+}
+
+// fileDialog sets up a native file dialog from a script's options
+// Patterns of the form "*.ext" become a filter; others can't be expressed and
+// show all files.
+func fileDialog(options pawscript.FileDialogOptions) *dialog.FileBuilder {
+	builder := dialog.File().Title(options.Title)
+	var extensions []string
+	for _, pattern := range options.Patterns {
+		if ext, found := strings.CutPrefix(pattern, "*."); found && ext != "" {
+			extensions = append(extensions, ext)
+		}
+	}
+	if len(extensions) > 0 {
+		builder = builder.Filter(strings.Join(options.Patterns, " "), extensions...)
+	}
+	builder = builder.Filter("All files", "*")
+	if options.Dir != "" {
+		builder = builder.SetStartDir(options.Dir)
+	}
+	if options.Name != "" {
+		builder = builder.SetStartFile(options.Name)
+	}
+	return builder
+}
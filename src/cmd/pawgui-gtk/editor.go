@@ -0,0 +1,364 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/gotk3/gotk3/pango"
+	"github.com/phroun/pawscript/src/pkg/pawgui"
+	"github.com/sqweek/dialog"
+)
+
+// Script editor pane
+// The launcher can show an editor above its terminal, so a quick edit and run
+// doesn't need an external editor. It colors PawScript as it's typed (see
+// pawgui.HighlightLine), numbers the lines in a gutter that scrolls with the text,
+// and its Run button saves the buffer and runs it as the file browser's Run does.
+// Clicking a line's number sets or clears a breakpoint on it for the debugger.
+
+// scriptEditor is the launcher's editor pane
+type scriptEditor struct {
+	box        *gtk.Box
+	view       *gtk.TextView
+	buffer     *gtk.TextBuffer
+	gutter     *gtk.TextBuffer
+	nameLabel  *gtk.Label
+	tags       map[pawgui.HighlightKind]*gtk.TextTag
+	path       string // "" until the buffer is first saved
+	lines      int    // Lines numbered in the gutter
+	pausedLine int    // Line the debugger is paused on, or 0
+}
+
+var (
+	launcherEditor      *scriptEditor
+	launcherEditorPaned *gtk.Paned // Splits the editor above the launcher terminal
+)
+
+// getLauncherEditorShown returns whether the launcher shows the script editor
+func getLauncherEditorShown() bool {
+	return appConfig.GetBool("launcher_editor", false)
+}
+
+// saveLauncherEditorShown saves whether the launcher shows the script editor
+func saveLauncherEditorShown(shown bool) {
+	appConfig.Set("launcher_editor", shown)
+	saveConfig(appConfig)
+}
+
+// newScriptEditor creates the editor pane, hidden until it's toggled on
+func newScriptEditor() *scriptEditor {
+	e := &scriptEditor{tags: make(map[pawgui.HighlightKind]*gtk.TextTag)}
+
+	e.box, _ = gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 4)
+	e.box.SetMarginStart(8)
+	e.box.SetMarginBottom(4)
+
+	// Top row: file name, then the buttons
+	topRow, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 4)
+	e.nameLabel, _ = gtk.LabelNew("")
+	e.nameLabel.SetEllipsize(pango.ELLIPSIZE_START)
+	e.nameLabel.SetXAlign(0)
+	topRow.PackStart(e.nameLabel, true, true, 0)
+	for _, b := range []struct {
+		label   string
+		clicked func()
+	}{
+		{"New", e.newScript},
+		{"Open...", e.open},
+		{"Save", func() { e.save() }},
+		{"Run", e.run},
+	} {
+		button, _ := gtk.ButtonNewWithLabel(b.label)
+		button.Connect("clicked", b.clicked)
+		topRow.PackStart(button, false, false, 0)
+	}
+	e.box.PackStart(topRow, false, false, 0)
+
+	// The text, with the line number gutter scrolling on the text's adjustment
+	e.view, _ = gtk.TextViewNew()
+	e.view.SetMonospace(true)
+	e.view.SetLeftMargin(4)
+	e.buffer, _ = e.view.GetBuffer()
+	scroll, _ := gtk.ScrolledWindowNew(nil, nil)
+	scroll.SetPolicy(gtk.POLICY_AUTOMATIC, gtk.POLICY_AUTOMATIC)
+	scroll.Add(e.view)
+
+	gutterView, _ := gtk.TextViewNew()
+	gutterView.SetMonospace(true)
+	gutterView.SetEditable(false)
+	gutterView.SetCursorVisible(false)
+	gutterView.SetCanFocus(false)
+	gutterView.SetJustification(gtk.JUSTIFY_RIGHT)
+	gutterView.SetLeftMargin(4)
+	gutterView.SetRightMargin(4)
+	if styleCtx, err := gutterView.GetStyleContext(); err == nil {
+		styleCtx.AddClass("dim-label") // Greyed, as line numbers are
+	}
+	gutterView.Connect("button-press-event", func(view *gtk.TextView, ev *gdk.Event) bool {
+		btn := gdk.EventButtonNewFromEvent(ev)
+		if btn.Button() != 1 {
+			return false
+		}
+		_, y := view.WindowToBufferCoords(gtk.TEXT_WINDOW_WIDGET, int(btn.X()), int(btn.Y()))
+		iter, _ := view.GetLineAtY(y)
+		e.toggleBreakpoint(iter.GetLine() + 1)
+		return true
+	})
+	e.gutter, _ = gutterView.GetBuffer()
+	gutterScroll, _ := gtk.ScrolledWindowNew(nil, scroll.GetVAdjustment())
+	gutterScroll.SetPolicy(gtk.POLICY_NEVER, gtk.POLICY_EXTERNAL)
+	gutterScroll.Add(gutterView)
+
+	textRow, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 0)
+	textRow.PackStart(gutterScroll, false, false, 0)
+	textRow.PackStart(scroll, true, true, 0)
+	e.box.PackStart(textRow, true, true, 0)
+
+	for _, kind := range []pawgui.HighlightKind{
+		pawgui.HighlightComment, pawgui.HighlightString, pawgui.HighlightNumber,
+		pawgui.HighlightCommand, pawgui.HighlightVariable, pawgui.HighlightBracket,
+	} {
+		e.tags[kind] = e.buffer.CreateTag("paw-"+strconv.Itoa(int(kind)), nil)
+	}
+	e.updateColors()
+
+	e.buffer.Connect("changed", func() {
+		e.highlight()
+		e.updateGutter()
+	})
+	e.buffer.Connect("modified-changed", e.updateTitle)
+	e.updateGutter()
+	e.updateTitle()
+
+	// Shown through toggling, not with the rest of the window
+	e.box.ShowAll()
+	e.box.Hide()
+	e.box.SetNoShowAll(true)
+	return e
+}
+
+// shown returns whether the editor is showing
+func (e *scriptEditor) shown() bool {
+	return e.box.GetVisible()
+}
+
+// setShown shows or hides the editor, giving it half the height when it appears
+func (e *scriptEditor) setShown(shown bool) {
+	if shown == e.shown() {
+		return
+	}
+	if shown {
+		e.box.Show()
+		height := launcherEditorPaned.GetAllocatedHeight()
+		if height <= 1 {
+			// Not laid out yet, as when the launcher opens with the editor showing
+			_, height = mainWindow.GetSize()
+		}
+		launcherEditorPaned.SetPosition(height / 2)
+		e.view.GrabFocus()
+	} else {
+		e.box.Hide()
+	}
+	saveLauncherEditorShown(shown)
+}
+
+// updateColors sets the highlighting colors for the current theme
+func (e *scriptEditor) updateColors() {
+	for kind, tag := range e.tags {
+		tag.SetProperty("foreground", pawgui.HighlightColor(kind, appliedThemeIsDark))
+	}
+}
+
+// highlight recolors the whole buffer
+func (e *scriptEditor) highlight() {
+	start, end := e.buffer.GetBounds()
+	e.buffer.RemoveAllTags(start, end)
+	var state pawgui.HighlightState
+	offset := 0
+	for _, line := range strings.Split(e.text(), "\n") {
+		var spans []pawgui.HighlightSpan
+		spans, state = pawgui.HighlightLine(line, state)
+		for _, span := range spans {
+			if tag := e.tags[span.Kind]; tag != nil {
+				e.buffer.ApplyTag(tag, e.buffer.GetIterAtOffset(offset+span.Start), e.buffer.GetIterAtOffset(offset+span.End))
+			}
+		}
+		offset += utf8.RuneCountInString(line) + 1
+	}
+}
+
+// updateGutter numbers the buffer's lines when their count changes
+func (e *scriptEditor) updateGutter() {
+	lines := e.buffer.GetLineCount()
+	if lines == e.lines {
+		return
+	}
+	e.lines = lines
+	var numbers strings.Builder
+	for i := 1; i <= lines; i++ {
+		if i > 1 {
+			numbers.WriteByte('\n')
+		}
+		breakpoint := e.path != "" && launcherDebugger.HasBreakpoint(e.path, i)
+		if mark := pawgui.DebugGutterMark(breakpoint, i == e.pausedLine); mark != "" {
+			numbers.WriteString(mark + " ")
+		}
+		numbers.WriteString(strconv.Itoa(i))
+	}
+	e.gutter.SetText(numbers.String())
+}
+
+// updateMarks redraws the gutter after a breakpoint or the paused line changes
+func (e *scriptEditor) updateMarks() {
+	e.lines = 0
+	e.updateGutter()
+}
+
+// toggleBreakpoint sets or clears the breakpoint on a line, once the script has
+// a file for it to be in
+func (e *scriptEditor) toggleBreakpoint(line int) {
+	if e.path == "" || line < 1 {
+		return
+	}
+	launcherDebugger.SetBreakpoint(e.path, line, !launcherDebugger.HasBreakpoint(e.path, line))
+	e.updateMarks()
+}
+
+// showPaused marks the line the debugger paused on when it's in this script, and
+// scrolls to it; a line of 0 clears the mark
+func (e *scriptEditor) showPaused(file string, line int) {
+	if e.path == "" || !sameFile(file, e.path) {
+		line = 0
+	}
+	if line == e.pausedLine {
+		return
+	}
+	e.pausedLine = line
+	e.updateMarks()
+	if line > 0 {
+		e.view.ScrollToIter(e.buffer.GetIterAtLine(line-1), 0.1, false, 0, 0)
+	}
+}
+
+// sameFile reports whether two names are for the same file
+func sameFile(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
+
+// updateTitle shows the file's name, marked while it has unsaved changes
+func (e *scriptEditor) updateTitle() {
+	name := "Untitled"
+	if e.path != "" {
+		name = e.path
+	}
+	if e.buffer.GetModified() {
+		name = "* " + name
+	}
+	e.nameLabel.SetText(name)
+}
+
+// text returns the buffer's text
+func (e *scriptEditor) text() string {
+	start, end := e.buffer.GetBounds()
+	text, _ := e.buffer.GetText(start, end, true)
+	return text
+}
+
+// confirmDiscard asks before unsaved changes are thrown away
+func (e *scriptEditor) confirmDiscard() bool {
+	if !e.buffer.GetModified() {
+		return true
+	}
+	return dialog.Message("%s", tr("The script has unsaved changes. Discard them?")).Title(tr("Script Editor")).YesNo()
+}
+
+// newScript empties the editor for a new script
+func (e *scriptEditor) newScript() {
+	if !e.confirmDiscard() {
+		return
+	}
+	e.path = ""
+	e.pausedLine = 0
+	e.buffer.SetText("")
+	e.buffer.SetModified(false)
+	e.updateTitle()
+	e.updateMarks()
+}
+
+// open asks for a script and loads it
+func (e *scriptEditor) open() {
+	if !e.confirmDiscard() {
+		return
+	}
+	file, err := dialog.File().
+		Title(tr("Open PawScript File")).
+		Filter("PawScript files", "paw").
+		Filter("All files", "*").
+		SetStartDir(currentDir).
+		Load()
+	if err != nil || file == "" {
+		return
+	}
+	if err := e.load(file); err != nil {
+		dialog.Message(tr("Failed to open file: %v"), err).Title(tr("Error")).Error()
+	}
+}
+
+// load replaces the buffer with a script's text
+func (e *scriptEditor) load(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	e.path = path
+	e.pausedLine = 0
+	e.buffer.SetText(string(content))
+	e.buffer.SetModified(false)
+	e.updateTitle()
+	e.updateMarks() // Breakpoints are per file
+	e.buffer.PlaceCursor(e.buffer.GetStartIter())
+	return nil
+}
+
+// save writes the buffer to its file, asking where first if it has none. It
+// returns whether the script was saved.
+func (e *scriptEditor) save() bool {
+	if e.path == "" {
+		file, err := dialog.File().
+			Title(tr("Save PawScript File")).
+			Filter("PawScript files", "paw").
+			Filter("All files", "*").
+			SetStartDir(currentDir).
+			SetStartFile("untitled.paw").
+			Save()
+		if err != nil || file == "" {
+			return false
+		}
+		e.path = file
+	}
+	if err := os.WriteFile(e.path, []byte(e.text()), 0644); err != nil {
+		dialog.Message(tr("Failed to save file: %v"), err).Title(tr("Error")).Error()
+		return false
+	}
+	e.buffer.SetModified(false)
+	e.updateTitle()
+	if filepath.Dir(e.path) == currentDir {
+		refreshFileList()
+	}
+	return true
+}
+
+// run saves the script and runs it in the launcher
+func (e *scriptEditor) run() {
+	if !e.save() {
+		return
+	}
+	runScript(e.path)
+}
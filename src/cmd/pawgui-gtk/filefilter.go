@@ -0,0 +1,67 @@
+package main
+
+import (
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/gotk3/gotk3/pango"
+	"github.com/phroun/pawscript/src/pkg/pawgui"
+)
+
+// File list filter and details
+// The filter box above the file list hides the entries whose names and
+// descriptions don't have the words typed in it (see pawgui/scriptinfo.go); the
+// parent directory entry always shows. Each script's row has a second line with
+// its description, size and modification time.
+
+var (
+	fileFilterEntry      *gtk.SearchEntry
+	fileListDescriptions = make(map[string]string) // Script descriptions by name, for the filter
+)
+
+// createFileFilter creates the filter box for the file list
+func createFileFilter() *gtk.SearchEntry {
+	fileFilterEntry, _ = gtk.SearchEntryNew()
+	fileFilterEntry.SetPlaceholderText(tr("Filter"))
+	fileFilterEntry.Connect("search-changed", func() {
+		if fileList != nil {
+			fileList.InvalidateFilter()
+		}
+	})
+	// Escape clears the filter
+	fileFilterEntry.Connect("stop-search", func() {
+		fileFilterEntry.SetText("")
+	})
+	return fileFilterEntry
+}
+
+// filterFileRow reports whether a file list row passes the filter
+func filterFileRow(row *gtk.ListBoxRow) bool {
+	if fileFilterEntry == nil {
+		return true
+	}
+	filter, _ := fileFilterEntry.GetText()
+	name, _ := row.GetName()
+	return name == ".." || pawgui.FileListMatches(filter, name, fileListDescriptions[name])
+}
+
+// addFileDetails adds the line with a script's details under its name in the
+// box holding its name label
+func addFileDetails(row *gtk.ListBoxRow, nameBox *gtk.Box, path, name string) {
+	info, err := pawgui.ReadScriptInfo(path)
+	if err != nil {
+		return
+	}
+	fileListDescriptions[name] = info.Description
+	if info.Description != "" {
+		row.SetTooltipText(info.Description)
+	}
+
+	details, _ := gtk.LabelNew("")
+	details.SetMarkup("<small>" + glib.MarkupEscapeText(info.Summary()) + "</small>")
+	details.SetXAlign(0)
+	details.SetEllipsize(pango.ELLIPSIZE_END)
+	if style, err := details.GetStyleContext(); err == nil {
+		style.AddClass("dim-label")
+	}
+	nameBox.PackStart(details, false, false, 0)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/phroun/pawscript/src/pkg/pawgui"
+	"github.com/phroun/pawscript/src/pkg/purfecterm"
+)
+
+// Launcher drops
+// Dropping .paw files on the launcher window runs them and dropping a folder
+// opens it in the file list (see pawgui/launcherdrop.go). The launcher's terminal
+// offers its drops to handleLauncherDrop first; the rest of the window is a drop
+// target of its own.
+
+// handleLauncherDrop runs the scripts or opens the folder dropped on the
+// launcher, returning false for drops it doesn't take
+func handleLauncherDrop(paths []string) bool {
+	scripts, folder, ok := pawgui.LauncherDrop(paths)
+	if !ok {
+		return false
+	}
+	if folder != "" {
+		currentDir = folder
+		refreshFileList()
+		updatePathMenu()
+		saveBrowseDir(currentDir)
+		return true
+	}
+	// runScript opens a console window for a script when one is running
+	for _, script := range scripts {
+		runScript(script)
+	}
+	return true
+}
+
+// setupLauncherDrop makes the launcher window a drop target for scripts and
+// folders
+func setupLauncherDrop(win *gtk.ApplicationWindow) {
+	entry, err := gtk.TargetEntryNew("text/uri-list", gtk.TARGET_OTHER_APP, 0)
+	if err != nil {
+		return
+	}
+	win.DragDestSet(gtk.DEST_DEFAULT_ALL, []gtk.TargetEntry{*entry}, gdk.ACTION_COPY)
+	win.Connect("drag-data-received", func(win *gtk.ApplicationWindow, ctx *gdk.DragContext, x, y int, data *gtk.SelectionData, info, time uint) {
+		var paths []string
+		for _, uri := range data.GetURIs() {
+			path, ok := purfecterm.FileURIPath(uri)
+			if !ok {
+				return
+			}
+			paths = append(paths, path)
+		}
+		handleLauncherDrop(paths)
+	})
+}
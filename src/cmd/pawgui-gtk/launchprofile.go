@@ -0,0 +1,252 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/phroun/pawscript/src/pkg/pawgui"
+)
+
+// Launch profiles
+// Right-clicking a script in the file list offers its Launch Profile, the
+// arguments, optimization level, roots, window size and auto-restart it's run
+// with (see pawgui/launchprofile.go). runScript and openScriptTabWith apply it.
+
+var (
+	fileListMenu       *gtk.Menu // Context menu of the file list's scripts
+	fileListMenuScript string    // The script fileListMenu was opened for
+)
+
+// setupFileListMenu gives the scripts in the file list a context menu
+func setupFileListMenu() {
+	fileListMenu, _ = gtk.MenuNew()
+	fileListMenu.Append(createMenuItemWithGutter(tr("Run"), func() {
+		runScript(fileListMenuScript)
+	}))
+	fileListMenu.Append(createMenuItemWithGutter(tr("Launch Profile..."), func() {
+		showLaunchProfileDialog(fileListMenuScript)
+	}))
+	addProjectFileListItems(fileListMenu)
+	fileListMenu.ShowAll()
+
+	fileList.Connect("button-press-event", func(list *gtk.ListBox, ev *gdk.Event) bool {
+		btn := gdk.EventButtonNewFromEvent(ev)
+		if btn.Button() != 3 { // Right mouse button
+			return false
+		}
+		row := list.GetRowAtY(int(btn.Y()))
+		if row == nil {
+			return false
+		}
+		name, _ := row.GetName()
+		path := fileRowPath(name)
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			return false
+		}
+		list.SelectRow(row)
+		fileListMenuScript = path
+		showProjectFileListItems(path)
+		fileListMenu.PopupAtPointer(ev)
+		return true
+	})
+}
+
+// restartScript waits to run a script again when its launch profile says to,
+// after a run that ended ok or not, returning the script to run (nil if it isn't
+// run again). The profile is read again after the wait, so changing it stops the
+// restarts, as do stopping the script and closing its tab (closed, if not nil).
+// A run configuration's profile (runConfig, if not nil) is used as it is.
+func restartScript(filePath string, runConfig *pawgui.LaunchProfile, ok bool, feed func(string), closed func() bool) []byte {
+	restarts := func() bool {
+		if runConfig != nil {
+			return runConfig.Restarts(ok)
+		}
+		profile, _ := configHelper.GetLaunchProfile(filePath)
+		return profile.Restarts(ok)
+	}
+	if !restarts() || (closed != nil && closed()) {
+		return nil
+	}
+	feed(fmt.Sprintf("--- Restarting in %v ---\r\n", pawgui.RestartDelay))
+	time.Sleep(pawgui.RestartDelay)
+	if closed != nil && closed() {
+		return nil
+	}
+	if !restarts() {
+		return nil
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		feed(fmt.Sprintf(tr("Error reading script file: %v\r\n"), err))
+		return nil
+	}
+	feed(fmt.Sprintf("\r\n--- Running: %s ---\r\n\r\n", filepath.Base(filePath)))
+	return content
+}
+
+// joinRoots writes roots as a list separated as PATH is
+func joinRoots(roots []string) string {
+	return strings.Join(roots, string(os.PathListSeparator))
+}
+
+// splitRoots reads roots written by joinRoots
+func splitRoots(text string) []string {
+	var roots []string
+	for _, root := range filepath.SplitList(text) {
+		if root = strings.TrimSpace(root); root != "" {
+			roots = append(roots, root)
+		}
+	}
+	return roots
+}
+
+// showLaunchProfileDialog edits the launch profile of a script, saving it in
+// the config
+func showLaunchProfileDialog(script string) {
+	profile, err := configHelper.GetLaunchProfile(script)
+
+	dlg, _ := gtk.DialogNew()
+	dlg.SetTitle(tr("Launch Profile - ") + filepath.Base(script))
+	dlg.SetModal(true)
+	if win, ok := dialogParent().(*gtk.Window); ok {
+		dlg.SetTransientFor(win)
+	} else if appWin, ok := dialogParent().(*gtk.ApplicationWindow); ok {
+		dlg.SetTransientFor(&appWin.Window)
+	}
+	dlg.SetDefaultSize(460, -1)
+
+	contentArea, _ := dlg.GetContentArea()
+	contentArea.SetMarginStart(12)
+	contentArea.SetMarginEnd(12)
+	contentArea.SetMarginTop(12)
+	contentArea.SetMarginBottom(12)
+	contentArea.SetSpacing(8)
+
+	grid, _ := gtk.GridNew()
+	grid.SetRowSpacing(8)
+	grid.SetColumnSpacing(12)
+	contentArea.PackStart(grid, true, true, 0)
+	addRow := func(row int, title string, field gtk.IWidget) {
+		label, _ := gtk.LabelNew(title)
+		label.SetHAlign(gtk.ALIGN_START)
+		grid.Attach(label, 0, row, 1, 1)
+		grid.Attach(field, 1, row, 1, 1)
+	}
+
+	argsEntry, _ := gtk.EntryNew()
+	argsEntry.SetText(pawgui.JoinArgs(profile.Args))
+	argsEntry.SetHExpand(true)
+	argsEntry.SetTooltipText(tr("Separated by spaces; quote an argument with spaces in it"))
+	addRow(0, "Arguments:", argsEntry)
+
+	optCombo, _ := gtk.ComboBoxTextNew()
+	optCombo.AppendText("Default")
+	optCombo.AppendText("0 - No caching")
+	optCombo.AppendText("1 - Cache macro and loop bodies")
+	optCombo.SetActive(profile.OptLevel + 1)
+	addRow(1, "Optimization:", optCombo)
+
+	rootsTip := fmt.Sprintf(tr("Directories beyond the usual ones, separated by %q;\nrelative ones are in the script's directory"),
+		string(os.PathListSeparator))
+	rootEntry := func(row int, title string, roots []string) *gtk.Entry {
+		entry, _ := gtk.EntryNew()
+		entry.SetText(joinRoots(roots))
+		entry.SetTooltipText(rootsTip)
+		addRow(row, title, entry)
+		return entry
+	}
+	readEntry := rootEntry(2, "Read Roots:", profile.ReadRoots)
+	writeEntry := rootEntry(3, "Write Roots:", profile.WriteRoots)
+	execEntry := rootEntry(4, "Exec Roots:", profile.ExecRoots)
+
+	sizeRow, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 6)
+	widthSpin, _ := gtk.SpinButtonNewWithRange(0, 10000, 10)
+	widthSpin.SetValue(float64(profile.Width))
+	heightSpin, _ := gtk.SpinButtonNewWithRange(0, 10000, 10)
+	heightSpin.SetValue(float64(profile.Height))
+	byLabel, _ := gtk.LabelNew("x")
+	sizeRow.PackStart(widthSpin, false, false, 0)
+	sizeRow.PackStart(byLabel, false, false, 0)
+	sizeRow.PackStart(heightSpin, false, false, 0)
+	sizeRow.SetTooltipText(tr("Runs the script in a console window of its own of this size;\n0 to run it where scripts usually run"))
+	addRow(5, "Window Size:", sizeRow)
+
+	restarts := []string{pawgui.RestartNever, pawgui.RestartOnFailure, pawgui.RestartAlways}
+	restartCombo, _ := gtk.ComboBoxTextNew()
+	restartCombo.AppendText("Never")
+	restartCombo.AppendText("When It Fails")
+	restartCombo.AppendText("Whenever It Ends")
+	restartCombo.SetActive(0)
+	for i, restart := range restarts {
+		if restart == profile.AutoRestart {
+			restartCombo.SetActive(i)
+		}
+	}
+	addRow(6, "Auto-Restart:", restartCombo)
+
+	// Where the settings came from
+	var notes []string
+	if err != nil {
+		notes = append(notes, err.Error())
+	}
+	if _, statErr := os.Stat(pawgui.LaunchProfileSidecar(script)); statErr == nil {
+		notes = append(notes, fmt.Sprintf(tr("Settings saved here override those in %s."),
+			filepath.Base(pawgui.LaunchProfileSidecar(script))))
+	}
+	if len(notes) > 0 {
+		note, _ := gtk.LabelNew(strings.Join(notes, "\n"))
+		note.SetHAlign(gtk.ALIGN_START)
+		note.SetLineWrap(true)
+		note.SetMaxWidthChars(60)
+		contentArea.PackStart(note, false, false, 0)
+	}
+
+	if configHelper.HasSavedLaunchProfile(script) {
+		dlg.AddButton(tr("Remove"), gtk.RESPONSE_REJECT)
+	}
+	dlg.AddButton(tr("Cancel"), gtk.RESPONSE_CANCEL)
+	dlg.AddButton(tr("OK"), gtk.RESPONSE_OK)
+	dlg.SetDefaultResponse(gtk.RESPONSE_OK)
+	dlg.ShowAll()
+	defer dlg.Destroy()
+
+	for {
+		switch dlg.Run() {
+		case gtk.RESPONSE_REJECT:
+			configHelper.SetLaunchProfile(script, nil)
+			saveConfig(appConfig)
+			return
+		case gtk.RESPONSE_OK:
+		default:
+			return
+		}
+
+		argsText, _ := argsEntry.GetText()
+		args, err := pawgui.SplitArgs(argsText)
+		if err != nil {
+			msg := gtk.MessageDialogNew(dlg, gtk.DIALOG_MODAL, gtk.MESSAGE_ERROR, gtk.BUTTONS_OK, "%s", err.Error())
+			msg.Run()
+			msg.Destroy()
+			continue
+		}
+		edited := pawgui.DefaultLaunchProfile()
+		edited.Args = args
+		edited.OptLevel = optCombo.GetActive() - 1
+		readText, _ := readEntry.GetText()
+		writeText, _ := writeEntry.GetText()
+		execText, _ := execEntry.GetText()
+		edited.ReadRoots, edited.WriteRoots, edited.ExecRoots = splitRoots(readText), splitRoots(writeText), splitRoots(execText)
+		edited.Width, edited.Height = widthSpin.GetValueAsInt(), heightSpin.GetValueAsInt()
+		if i := restartCombo.GetActive(); i >= 0 {
+			edited.AutoRestart = restarts[i]
+		}
+		configHelper.SetLaunchProfile(script, &edited)
+		saveConfig(appConfig)
+		return
+	}
+}
@@ -3,6 +3,7 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"io"
@@ -16,6 +17,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/gotk3/gotk3/cairo"
 	"github.com/gotk3/gotk3/gdk"
 	"github.com/gotk3/gotk3/glib"
 	"github.com/gotk3/gotk3/gtk"
@@ -92,6 +94,7 @@ var (
 	clearInputFunc func()
 	flushFunc      func() // Flush pending output
 	scriptRunning  bool
+	launcherRun    *pawgui.ScriptRun // The script running in the launcher, for Stop Script
 	scriptMu       sync.Mutex
 
 	// REPL for interactive mode when no script is running
@@ -100,6 +103,7 @@ var (
 	// Configuration loaded at startup
 	appConfig    pawscript.PSLConfig
 	configHelper *pawgui.ConfigHelper
+	savedConfig  pawscript.PSLConfig // Config as last loaded or saved, the base for merging
 
 	// Track actual applied theme (resolved from Auto if needed)
 	appliedThemeIsDark bool
@@ -194,18 +198,19 @@ func safeRemoveChildren(container interface {
 	runtime.GC()
 }
 
-// WindowToolbarData holds per-window toolbar state for dummy_button command
+// WindowToolbarData holds per-window toolbar state for the toolbar_button command
 type WindowToolbarData struct {
 	strip          *gtk.Box                // The narrow strip container
 	menuButton     *gtk.Button             // The hamburger menu button
 	registeredBtns []*ToolbarButton        // Additional registered buttons
+	scriptButtons  *pawgui.ToolbarButtons  // Buttons scripts added, drawn as registeredBtns
 	terminal       *purfectermgtk.Terminal // Terminal for Feed() calls
 	updateFunc     func()                  // Function to update the strip's buttons
 }
 
 // ToolbarButton represents a registered toolbar button
 type ToolbarButton struct {
-	Icon    string      // Icon name or path
+	Icon    string      // Built-in icon name or SVG markup (see toolbarIconSVG)
 	Tooltip string      // Tooltip text
 	OnClick func()      // Click handler
 	Menu    *gtk.Menu   // Optional dropdown menu (if nil, OnClick is used)
@@ -257,9 +262,16 @@ func loadConfig() pawscript.PSLConfig {
 
 	config, err := pawscript.ParsePSL(string(data))
 	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v (using default settings)\n", configPath, err)
 		return pawscript.PSLConfig{}
 	}
 
+	// Settings that don't fit the schema are dropped so their defaults apply
+	for _, problem := range pawgui.ValidateConfig(config) {
+		fmt.Fprintf(os.Stderr, "%s: ignoring invalid setting %v\n", configPath, problem)
+	}
+
+	savedConfig = pawscript.ClonePSLValue(config).(pawscript.PSLConfig)
 	return config
 }
 
@@ -277,8 +289,61 @@ func saveConfig(config pawscript.PSLConfig) {
 		return
 	}
 
-	data := pawscript.SerializePSLPretty(config)
-	_ = os.WriteFile(configPath, []byte(data+"\n"), 0644)
+	data := pawscript.SerializePSLPretty(config) + "\n"
+	if existing, err := os.ReadFile(configPath); err == nil {
+		// Another instance may have saved since this one loaded; keep its changes
+		// unless this one changed the same settings
+		if onDisk, err := pawscript.ParsePSL(string(existing)); err == nil && savedConfig != nil {
+			merged, _ := pawscript.MergePSL(savedConfig, config, onDisk)
+			for key := range config {
+				delete(config, key)
+			}
+			for key, value := range merged {
+				config[key] = value
+			}
+		}
+		// Keep the comments and layout of a hand-edited file
+		if updated, err := pawscript.UpdatePSL(string(existing), config); err == nil {
+			data = updated
+		}
+	}
+	if os.WriteFile(configPath, []byte(data), 0644) == nil {
+		savedConfig = pawscript.ClonePSLValue(config).(pawscript.PSLConfig)
+	}
+}
+
+// reloadConfig applies settings from a config file edited outside the launcher
+// Settings changed here but not yet saved are kept unless the file changed them too.
+func reloadConfig(config pawscript.PSLConfig) {
+	for _, problem := range pawgui.ValidateConfig(config) {
+		fmt.Fprintf(os.Stderr, "%s: ignoring invalid setting %v\n", getConfigPath(), problem)
+	}
+	if savedConfig != nil && len(pawscript.DiffPSL(savedConfig, config)) == 0 {
+		return // Our own save
+	}
+	base := savedConfig
+	if base == nil {
+		base = pawscript.PSLConfig{}
+	}
+	merged, _ := pawscript.MergePSL(base, appConfig, config)
+	savedConfig = pawscript.ClonePSLValue(config).(pawscript.PSLConfig)
+
+	origUIScale := getUIScale()
+	// Update appConfig in place, since the config helper shares it
+	for key := range appConfig {
+		delete(appConfig, key)
+	}
+	for key, value := range merged {
+		appConfig[key] = value
+	}
+	configHelper.PopulateDefaults()
+
+	applyWindowTheme()
+	applyConsoleTheme()
+	if getUIScale() != origUIScale {
+		applyUIScale()
+	}
+	applyFontSettings()
 }
 
 // saveBrowseDir saves the current browse directory to config
@@ -298,6 +363,7 @@ func getTerminalBackground() purfecterm.Color    { return configHelper.GetTermin
 func getTerminalForeground() purfecterm.Color    { return configHelper.GetTerminalForeground() }
 func getColorPalette() []purfecterm.Color        { return configHelper.GetColorPalette() }
 func getBlinkMode() purfecterm.BlinkMode         { return configHelper.GetBlinkMode() }
+func getScrollbackLines() int                    { return configHelper.GetScrollbackLines() }
 func getQuitShortcut() string                    { return configHelper.GetQuitShortcut() }
 func getDefaultQuitShortcut() string             { return pawgui.GetDefaultQuitShortcut() }
 func getCloseShortcut() string                   { return configHelper.GetCloseShortcut() }
@@ -305,6 +371,14 @@ func getDefaultCloseShortcut() string            { return pawgui.GetDefaultClose
 func getPSLColors() pawscript.DisplayColorConfig { return configHelper.GetPSLColors() }
 func isTermThemeDark() bool                      { return configHelper.IsTermThemeDark() }
 
+func getLauncherPermissions(script string) *pawscript.PermissionProfile {
+	return configHelper.GetLauncherPermissions(script, getExamplesDir())
+}
+
+func getLauncherQuotas() *pawscript.ResourceQuotas {
+	return configHelper.GetLauncherQuotas()
+}
+
 func getColorSchemeForTheme(isDark bool) purfecterm.ColorScheme {
 	// Returns a dual-palette ColorScheme (isDark is now ignored)
 	return configHelper.GetDualColorScheme()
@@ -331,10 +405,8 @@ func saveLauncherWidth(width int) {
 
 // getLauncherPosition returns the saved launcher window position (x, y)
 func getLauncherPosition() (int, int) {
-	if items := appConfig.GetItems("launcher_position"); len(items) >= 2 {
-		x := pslToInt(items[0])
-		y := pslToInt(items[1])
-		return x, y
+	if xy, ok := appConfig.GetIntList("launcher_position"); ok && len(xy) >= 2 {
+		return xy[0], xy[1]
 	}
 	return -1, -1 // -1 means not set (let window manager decide)
 }
@@ -347,11 +419,9 @@ func saveLauncherPosition(x, y int) {
 
 // getLauncherSize returns the saved launcher window size (width, height)
 func getLauncherSize() (int, int) {
-	if items := appConfig.GetItems("launcher_size"); len(items) >= 2 {
-		w := pslToInt(items[0])
-		h := pslToInt(items[1])
-		if w > 0 && h > 0 {
-			return w, h
+	if wh, ok := appConfig.GetIntList("launcher_size"); ok && len(wh) >= 2 {
+		if wh[0] > 0 && wh[1] > 0 {
+			return wh[0], wh[1]
 		}
 	}
 	return 1100, 700 // Default size
@@ -363,19 +433,6 @@ func saveLauncherSize(width, height int) {
 	saveConfig(appConfig)
 }
 
-// pslToInt converts a PSL list item to int
-func pslToInt(v interface{}) int {
-	switch n := v.(type) {
-	case int:
-		return n
-	case int64:
-		return int(n)
-	case float64:
-		return int(n)
-	}
-	return 0
-}
-
 // getHomeDir returns the user's home directory
 func getHomeDir() string {
 	if home, err := os.UserHomeDir(); err == nil {
@@ -467,6 +524,47 @@ func clearRecentPaths() {
 	saveConfig(appConfig)
 }
 
+// promptPermission asks the user whether a launcher script may access a path outside its roots
+// Called from the script's goroutine, which waits while the dialog runs on the main thread.
+// "Always Allow" is saved in the config, so later runs get the directory as a root.
+func promptPermission(request pawscript.PermissionRequest) pawscript.PermissionDecision {
+	answer := make(chan pawscript.PermissionDecision, 1)
+	glib.IdleAdd(func() bool {
+		var parent gtk.IWindow
+		if mainWindow != nil {
+			parent = mainWindow
+		}
+		dialog := gtk.MessageDialogNew(
+			parent,
+			gtk.DIALOG_MODAL|gtk.DIALOG_DESTROY_WITH_PARENT,
+			gtk.MESSAGE_QUESTION,
+			gtk.BUTTONS_NONE,
+			"%s", pawgui.PermissionPromptText(request),
+		)
+		dialog.SetTitle(tr("Script Permission"))
+		dialog.AddButton(tr("Deny"), gtk.RESPONSE_REJECT)
+		dialog.AddButton(tr("Allow Once"), gtk.RESPONSE_ACCEPT)
+		dialog.AddButton(tr("Always Allow"), gtk.RESPONSE_YES)
+		dialog.SetDefaultResponse(gtk.RESPONSE_REJECT)
+		response := dialog.Run()
+		dialog.Destroy()
+
+		switch response {
+		case gtk.RESPONSE_ACCEPT:
+			answer <- pawscript.PermissionAllowOnce
+		case gtk.RESPONSE_YES:
+			if configHelper.AddGrantedRoot(request.Action == pawscript.AuditWrite, request.Root) {
+				saveConfig(appConfig)
+			}
+			answer <- pawscript.PermissionAllowAlways
+		default:
+			answer <- pawscript.PermissionDeny
+		}
+		return false
+	})
+	return <-answer
+}
+
 // --- Toolbar Strip and Hamburger Menu ---
 
 // showAboutDialog displays the About PawScript dialog
@@ -483,7 +581,7 @@ func showAboutDialog(parent gtk.IWindow) {
 		gtk.BUTTONS_OK,
 		"",
 	)
-	dialog.SetTitle("About PawScript")
+	dialog.SetTitle(tr("About PawScript"))
 
 	// Build about text
 	aboutText := fmt.Sprintf(`<b>PawScript</b>
@@ -541,10 +639,20 @@ func showSettingsDialog(parent gtk.IWindow) {
 	// Save original values for reverting on Cancel
 	origWindowTheme := appConfig.GetString("theme", "auto")
 	origTermTheme := appConfig.GetString("term_theme", "auto")
+	origClipboardAccess := appConfig.GetString("clipboard_access", "write")
 	origUIScale := appConfig.GetFloat("ui_scale", 1.0)
 	origFontFamily := appConfig.GetString("font_family", "")
 	origFontSize := appConfig.GetInt("font_size", pawgui.DefaultFontSize)
 	origFontFamilyUnicode := appConfig.GetString("font_family_unicode", "")
+	origBackgroundOpacity := appConfig.GetFloat("background_opacity", 1.0)
+	origBackgroundBlur := appConfig.GetBool("background_blur", false)
+	origBackgroundImage := appConfig.GetString("background_image", "")
+	origBackgroundDim := appConfig.GetFloat("background_dim", 0.5)
+	origDefaultBlink := appConfig.GetString("default_blink", "bounce")
+	origCursorShape := appConfig.GetString("cursor_shape", "block")
+	origCursorBlink := appConfig.GetString("cursor_blink", "off")
+	origScrollbackLines := configHelper.GetScrollbackLines()
+	origLanguage := configHelper.GetLanguage()
 
 	// Save original palette sections for reverting on Cancel
 	origTermColors := copyPSLConfig(appConfig["term_colors"])
@@ -559,7 +667,7 @@ func showSettingsDialog(parent gtk.IWindow) {
 
 	// Create dialog
 	dlg, _ := gtk.DialogNew()
-	dlg.SetTitle("Settings")
+	dlg.SetTitle(tr("Settings"))
 	dlg.SetModal(true)
 	dlg.SetDefaultSize(400, 300)
 	if parent != nil {
@@ -590,7 +698,7 @@ func showSettingsDialog(parent gtk.IWindow) {
 
 	// Window Theme row
 	windowThemeRow, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 12)
-	windowThemeLabel, _ := gtk.LabelNew("Window Theme:")
+	windowThemeLabel, _ := gtk.LabelNew(tr("Window Theme:"))
 	windowThemeLabel.SetHAlign(gtk.ALIGN_START)
 	windowThemeLabel.SetWidthChars(15)
 	windowThemeRow.PackStart(windowThemeLabel, false, false, 0)
@@ -609,7 +717,7 @@ func showSettingsDialog(parent gtk.IWindow) {
 	// Declare both combos so they can reference each other for icon refresh
 	var windowThemeCombo, consoleThemeCombo *SettingsComboMenu
 
-	windowThemeCombo = createSettingsComboMenu([]string{"Auto", "Light", "Dark"}, windowThemeSelected, func(idx int) {
+	windowThemeCombo = createSettingsComboMenu([]string{tr("Auto"), tr("Light"), tr("Dark")}, windowThemeSelected, func(idx int) {
 		switch idx {
 		case 1:
 			appConfig.Set("theme", "light")
@@ -631,7 +739,7 @@ func showSettingsDialog(parent gtk.IWindow) {
 
 	// Window Scale row
 	windowScaleRow, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 12)
-	windowScaleLabel, _ := gtk.LabelNew("Window Scale:")
+	windowScaleLabel, _ := gtk.LabelNew(tr("Window Scale:"))
 	windowScaleLabel.SetHAlign(gtk.ALIGN_START)
 	windowScaleLabel.SetWidthChars(15)
 	windowScaleRow.PackStart(windowScaleLabel, false, false, 0)
@@ -673,9 +781,37 @@ func showSettingsDialog(parent gtk.IWindow) {
 	windowScaleRow.PackStart(windowScaleSlider, true, true, 0)
 	appearanceBox.PackStart(windowScaleRow, false, false, 0)
 
+	// Language row - Automatic follows the OS; windows opened afterwards use it
+	languages := pawgui.Languages()
+	languageOptions := []string{tr("Automatic")}
+	languageSelected := 0
+	for i, language := range languages {
+		languageOptions = append(languageOptions, language.Name)
+		if language.Code == origLanguage {
+			languageSelected = i + 1
+		}
+	}
+	languageRow, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 12)
+	languageLabel, _ := gtk.LabelNew(tr("Language:"))
+	languageLabel.SetHAlign(gtk.ALIGN_START)
+	languageLabel.SetWidthChars(15)
+	languageRow.PackStart(languageLabel, false, false, 0)
+	languageCombo := createSettingsComboMenu(languageOptions, languageSelected, func(idx int) {
+		if idx == 0 {
+			appConfig.Set("language", "auto")
+		} else {
+			appConfig.Set("language", languages[idx-1].Code)
+		}
+		configHelper = pawgui.NewConfigHelper(appConfig)
+		applyLanguage()
+	})
+	languageCombo.Button.SetTooltipText(tr("Windows opened afterwards use it; the launcher, once restarted"))
+	languageRow.PackStart(languageCombo.Button, true, true, 0)
+	appearanceBox.PackStart(languageRow, false, false, 0)
+
 	// Console Theme row
 	consoleThemeRow, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 12)
-	consoleThemeLabel, _ := gtk.LabelNew("Console Theme:")
+	consoleThemeLabel, _ := gtk.LabelNew(tr("Console Theme:"))
 	consoleThemeLabel.SetHAlign(gtk.ALIGN_START)
 	consoleThemeLabel.SetWidthChars(15)
 	consoleThemeRow.PackStart(consoleThemeLabel, false, false, 0)
@@ -692,7 +828,7 @@ func showSettingsDialog(parent gtk.IWindow) {
 		consoleThemeSelected = 0 // Auto
 	}
 
-	consoleThemeCombo = createSettingsComboMenu([]string{"Auto", "Light", "Dark"}, consoleThemeSelected, func(idx int) {
+	consoleThemeCombo = createSettingsComboMenu([]string{tr("Auto"), tr("Light"), tr("Dark")}, consoleThemeSelected, func(idx int) {
 		switch idx {
 		case 1:
 			appConfig.Set("term_theme", "light")
@@ -707,9 +843,88 @@ func showSettingsDialog(parent gtk.IWindow) {
 	consoleThemeRow.PackStart(consoleThemeCombo.Button, true, true, 0)
 	appearanceBox.PackStart(consoleThemeRow, false, false, 0)
 
+	// Clipboard row - what programs may do with the clipboard through OSC 52
+	// (reading it lets a program see whatever was last copied anywhere)
+	clipboardSelected := int(configHelper.GetClipboardAccess())
+	clipboardRow, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 12)
+	clipboardLabel, _ := gtk.LabelNew(tr("Clipboard:"))
+	clipboardLabel.SetHAlign(gtk.ALIGN_START)
+	clipboardLabel.SetWidthChars(15)
+	clipboardRow.PackStart(clipboardLabel, false, false, 0)
+	clipboardCombo := createSettingsComboMenu([]string{tr("Programs Can't Use"), tr("Programs Can Copy"), tr("Programs Can Copy & Paste")}, clipboardSelected, func(idx int) {
+		switch idx {
+		case 1:
+			appConfig.Set("clipboard_access", "write")
+		case 2:
+			appConfig.Set("clipboard_access", "read-write")
+		default:
+			appConfig.Set("clipboard_access", "off")
+		}
+		configHelper = pawgui.NewConfigHelper(appConfig)
+		applyConsoleTheme()
+	})
+	clipboardRow.PackStart(clipboardCombo.Button, true, true, 0)
+	appearanceBox.PackStart(clipboardRow, false, false, 0)
+
+	// Blinking Text row - how text with the blink attribute is shown
+	blinkRow, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 12)
+	blinkLabel, _ := gtk.LabelNew(tr("Blinking Text:"))
+	blinkLabel.SetHAlign(gtk.ALIGN_START)
+	blinkLabel.SetWidthChars(15)
+	blinkRow.PackStart(blinkLabel, false, false, 0)
+	blinkSelected := pawgui.ValueIndex(pawgui.BlinkModeValues, origDefaultBlink)
+	blinkCombo := createSettingsComboMenu([]string{tr("Bounce"), tr("Blink"), tr("Bright Background")}, blinkSelected, func(idx int) {
+		appConfig.Set("default_blink", pawgui.BlinkModeValues[idx])
+		configHelper = pawgui.NewConfigHelper(appConfig)
+		applyConsoleTheme()
+	})
+	blinkRow.PackStart(blinkCombo.Button, true, true, 0)
+	appearanceBox.PackStart(blinkRow, false, false, 0)
+
+	// Cursor row - the console cursor's shape and whether it blinks
+	cursorRow, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 12)
+	cursorLabel, _ := gtk.LabelNew(tr("Cursor:"))
+	cursorLabel.SetHAlign(gtk.ALIGN_START)
+	cursorLabel.SetWidthChars(15)
+	cursorRow.PackStart(cursorLabel, false, false, 0)
+	cursorShapeSelected := pawgui.ValueIndex(pawgui.CursorShapeValues, origCursorShape)
+	cursorShapeCombo := createSettingsComboMenu([]string{tr("Block"), tr("Underline"), tr("Bar")}, cursorShapeSelected, func(idx int) {
+		appConfig.Set("cursor_shape", pawgui.CursorShapeValues[idx])
+		configHelper = pawgui.NewConfigHelper(appConfig)
+		applyConsoleTheme()
+	})
+	cursorRow.PackStart(cursorShapeCombo.Button, true, true, 0)
+	cursorBlinkSelected := pawgui.ValueIndex(pawgui.CursorBlinkValues, origCursorBlink)
+	cursorBlinkCombo := createSettingsComboMenu([]string{tr("Steady"), tr("Slow Blink"), tr("Fast Blink")}, cursorBlinkSelected, func(idx int) {
+		appConfig.Set("cursor_blink", pawgui.CursorBlinkValues[idx])
+		configHelper = pawgui.NewConfigHelper(appConfig)
+		applyConsoleTheme()
+	})
+	cursorRow.PackStart(cursorBlinkCombo.Button, true, true, 0)
+	appearanceBox.PackStart(cursorRow, false, false, 0)
+
+	// Scrollback row - lines kept once they scroll off, for consoles opened afterwards
+	scrollbackRow, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 12)
+	scrollbackLabel, _ := gtk.LabelNew(tr("Scrollback:"))
+	scrollbackLabel.SetHAlign(gtk.ALIGN_START)
+	scrollbackLabel.SetWidthChars(15)
+	scrollbackRow.PackStart(scrollbackLabel, false, false, 0)
+	scrollbackSpin, _ := gtk.SpinButtonNewWithRange(100, 10000000, 1000)
+	scrollbackSpin.SetValue(float64(origScrollbackLines))
+	scrollbackSpin.SetHExpand(true)
+	scrollbackSpin.SetTooltipText(tr("Lines each console keeps; consoles opened afterwards use it"))
+	scrollbackSpin.Connect("value-changed", func() {
+		appConfig.Set("scrollback_lines", scrollbackSpin.GetValueAsInt())
+		configHelper = pawgui.NewConfigHelper(appConfig)
+	})
+	scrollbackRow.PackStart(scrollbackSpin, true, true, 0)
+	scrollbackLinesLabel, _ := gtk.LabelNew(tr("lines"))
+	scrollbackRow.PackStart(scrollbackLinesLabel, false, false, 0)
+	appearanceBox.PackStart(scrollbackRow, false, false, 0)
+
 	// Console Font row - uses persistent font chooser dialog to avoid gotk3 finalizer crashes
 	consoleFontRow, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 12)
-	consoleFontLabel, _ := gtk.LabelNew("Console Font:")
+	consoleFontLabel, _ := gtk.LabelNew(tr("Console Font:"))
 	consoleFontLabel.SetHAlign(gtk.ALIGN_START)
 	consoleFontLabel.SetWidthChars(15)
 	consoleFontRow.PackStart(consoleFontLabel, false, false, 0)
@@ -729,7 +944,7 @@ func showSettingsDialog(parent gtk.IWindow) {
 	consoleFontButton.Connect("clicked", func() {
 		// Initialize persistent font chooser if needed
 		if consoleFontChooser == nil {
-			consoleFontChooser, _ = gtk.FontChooserDialogNew("Select Console Font", nil)
+			consoleFontChooser, _ = gtk.FontChooserDialogNew(tr("Select Console Font"), nil)
 		}
 		// Set current font and show dialog
 		currentDesc := fmt.Sprintf("%s %d", firstFont, configHelper.GetFontSize())
@@ -767,7 +982,7 @@ func showSettingsDialog(parent gtk.IWindow) {
 
 	// CJK Font row - uses persistent font chooser dialog
 	cjkFontRow, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 12)
-	cjkFontLabel, _ := gtk.LabelNew("CJK Font:")
+	cjkFontLabel, _ := gtk.LabelNew(tr("CJK Font:"))
 	cjkFontLabel.SetHAlign(gtk.ALIGN_START)
 	cjkFontLabel.SetWidthChars(15)
 	cjkFontRow.PackStart(cjkFontLabel, false, false, 0)
@@ -788,7 +1003,7 @@ func showSettingsDialog(parent gtk.IWindow) {
 	cjkFontButton.Connect("clicked", func() {
 		// Initialize persistent font chooser if needed
 		if cjkFontChooser == nil {
-			cjkFontChooser, _ = gtk.FontChooserDialogNew("Select CJK Font", nil)
+			cjkFontChooser, _ = gtk.FontChooserDialogNew(tr("Select CJK Font"), nil)
 		}
 		// Set current font and show dialog
 		cjkFontChooser.SetFont(fmt.Sprintf("%s %d", firstCJKFont, currentFontSize))
@@ -819,8 +1034,93 @@ func showSettingsDialog(parent gtk.IWindow) {
 	cjkFontRow.PackStart(cjkFontButton, true, true, 0)
 	appearanceBox.PackStart(cjkFontRow, false, false, 0)
 
+	// Opacity row - how much the desktop shows through the console background, and
+	// whether it is blurred (where the window manager can)
+	opacityRow, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 12)
+	opacityLabel, _ := gtk.LabelNew(tr("Opacity:"))
+	opacityLabel.SetHAlign(gtk.ALIGN_START)
+	opacityLabel.SetWidthChars(15)
+	opacityRow.PackStart(opacityLabel, false, false, 0)
+	opacitySlider, _ := gtk.ScaleNewWithRange(gtk.ORIENTATION_HORIZONTAL, purfecterm.MinBackgroundOpacity, 1.0, 0.05)
+	opacitySlider.SetValue(configHelper.GetBackgroundOpacity())
+	opacitySlider.SetDrawValue(true)
+	opacitySlider.SetHExpand(true)
+	opacitySlider.Connect("value-changed", func() {
+		appConfig.Set("background_opacity", opacitySlider.GetValue())
+		configHelper = pawgui.NewConfigHelper(appConfig)
+		applyConsoleTheme()
+	})
+	opacityRow.PackStart(opacitySlider, true, true, 0)
+	blurCheck, _ := gtk.CheckButtonNewWithLabel(tr("Blur"))
+	blurCheck.SetActive(configHelper.GetBackgroundBlur())
+	blurCheck.Connect("toggled", func() {
+		appConfig.Set("background_blur", blurCheck.GetActive())
+		configHelper = pawgui.NewConfigHelper(appConfig)
+		applyConsoleTheme()
+	})
+	opacityRow.PackStart(blurCheck, false, false, 0)
+	appearanceBox.PackStart(opacityRow, false, false, 0)
+
+	// Background row - an image drawn under the console text
+	backgroundRow, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 12)
+	backgroundLabel, _ := gtk.LabelNew(tr("Background:"))
+	backgroundLabel.SetHAlign(gtk.ALIGN_START)
+	backgroundLabel.SetWidthChars(15)
+	backgroundRow.PackStart(backgroundLabel, false, false, 0)
+	backgroundButton, _ := gtk.ButtonNew()
+	backgroundButtonLabel := func(path string) string {
+		if path == "" {
+			return "No Image"
+		}
+		return filepath.Base(path)
+	}
+	backgroundButton.SetLabel(backgroundButtonLabel(configHelper.GetBackgroundImage()))
+	backgroundButton.Connect("clicked", func() {
+		// Use sqweek/dialog for native file open dialog
+		filename, err := dialog.File().
+			Title(tr("Select Background Image")).
+			Filter("Images", "png", "jpg", "jpeg", "gif", "bmp", "webp").
+			Filter("All files", "*").
+			Load()
+		if err != nil || filename == "" {
+			return
+		}
+		appConfig.Set("background_image", filename)
+		configHelper = pawgui.NewConfigHelper(appConfig)
+		backgroundButton.SetLabel(backgroundButtonLabel(filename))
+		applyConsoleTheme()
+	})
+	backgroundRow.PackStart(backgroundButton, true, true, 0)
+	backgroundClearButton, _ := gtk.ButtonNewWithLabel(tr("Clear"))
+	backgroundClearButton.Connect("clicked", func() {
+		appConfig.Set("background_image", "")
+		configHelper = pawgui.NewConfigHelper(appConfig)
+		backgroundButton.SetLabel(backgroundButtonLabel(""))
+		applyConsoleTheme()
+	})
+	backgroundRow.PackStart(backgroundClearButton, false, false, 0)
+	appearanceBox.PackStart(backgroundRow, false, false, 0)
+
+	// Image Dimming row - how far the image is faded toward the background color
+	dimRow, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 12)
+	dimLabel, _ := gtk.LabelNew(tr("Image Dimming:"))
+	dimLabel.SetHAlign(gtk.ALIGN_START)
+	dimLabel.SetWidthChars(15)
+	dimRow.PackStart(dimLabel, false, false, 0)
+	dimSlider, _ := gtk.ScaleNewWithRange(gtk.ORIENTATION_HORIZONTAL, 0, 1.0, 0.05)
+	dimSlider.SetValue(configHelper.GetBackgroundDim())
+	dimSlider.SetDrawValue(true)
+	dimSlider.SetHExpand(true)
+	dimSlider.Connect("value-changed", func() {
+		appConfig.Set("background_dim", dimSlider.GetValue())
+		configHelper = pawgui.NewConfigHelper(appConfig)
+		applyConsoleTheme()
+	})
+	dimRow.PackStart(dimSlider, true, true, 0)
+	appearanceBox.PackStart(dimRow, false, false, 0)
+
 	// Add appearance tab to notebook
-	appearanceLabel, _ := gtk.LabelNew("Appearance")
+	appearanceLabel, _ := gtk.LabelNew(tr("Appearance"))
 	notebook.AppendPage(appearanceBox, appearanceLabel)
 
 	// --- Palette Tab ---
@@ -910,7 +1210,7 @@ func showSettingsDialog(parent gtk.IWindow) {
 	// Layout: Label | Space | Space | LightSwatch | Space | DarkSwatch
 	bgRow, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, rowSpacing)
 
-	bgLabel, _ := gtk.LabelNew("Background")
+	bgLabel, _ := gtk.LabelNew(tr("Background"))
 	bgLabel.SetXAlign(0)
 	bgLabel.SetSizeRequest(labelWidth, -1)
 	bgRow.PackStart(bgLabel, false, false, 0)
@@ -952,7 +1252,7 @@ func showSettingsDialog(parent gtk.IWindow) {
 	// Layout: Label | Space | Space | LightSwatch | Space | DarkSwatch
 	fgRow, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, rowSpacing)
 
-	fgLabel, _ := gtk.LabelNew("Foreground")
+	fgLabel, _ := gtk.LabelNew(tr("Foreground"))
 	fgLabel.SetXAlign(0)
 	fgLabel.SetSizeRequest(labelWidth, -1)
 	fgRow.PackStart(fgLabel, false, false, 0)
@@ -1138,22 +1438,37 @@ func showSettingsDialog(parent gtk.IWindow) {
 	_ = fgDarkSwatch
 
 	// Add palette tab to notebook
-	paletteLabel, _ := gtk.LabelNew("Palette")
+	paletteLabel, _ := gtk.LabelNew(tr("Palette"))
 	notebook.AppendPage(paletteBox, paletteLabel)
 
+	// --- Shortcuts Tab ---
+	shortcuts := newShortcutsTab()
+	shortcutsLabel, _ := gtk.LabelNew(tr("Shortcuts"))
+	shortcutsPage := notebook.AppendPage(shortcuts.box, shortcutsLabel)
+
 	// --- Button Box ---
 	buttonBox, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 8)
 	buttonBox.SetHAlign(gtk.ALIGN_END)
 	buttonBox.SetMarginTop(12)
 
-	cancelBtn, _ := gtk.ButtonNewWithLabel("Cancel")
+	cancelBtn, _ := gtk.ButtonNewWithLabel(tr("Cancel"))
 	cancelBtn.Connect("clicked", func() {
 		dlg.Response(gtk.RESPONSE_CANCEL)
 	})
 	buttonBox.PackStart(cancelBtn, false, false, 0)
 
-	saveBtn, _ := gtk.ButtonNewWithLabel("Save")
+	saveBtn, _ := gtk.ButtonNewWithLabel(tr("Save"))
 	saveBtn.Connect("clicked", func() {
+		// Clashing shortcuts must be sorted out first (the Shortcuts tab lists them)
+		if problems := shortcuts.check(); len(problems) > 0 {
+			notebook.SetCurrentPage(shortcutsPage)
+			msg := gtk.MessageDialogNew(dlg, gtk.DIALOG_MODAL|gtk.DIALOG_DESTROY_WITH_PARENT,
+				gtk.MESSAGE_ERROR, gtk.BUTTONS_OK, "%s", strings.Join(problems, "\n"))
+			msg.SetTitle(tr("Shortcuts"))
+			msg.Run()
+			msg.Destroy()
+			return
+		}
 		dlg.Response(gtk.RESPONSE_OK)
 	})
 	buttonBox.PackStart(saveBtn, false, false, 0)
@@ -1185,12 +1500,14 @@ func showSettingsDialog(parent gtk.IWindow) {
 	response := dlg.Run()
 	if response == gtk.RESPONSE_OK {
 		// Save config to file (settings already applied via change handlers)
+		shortcuts.save()
 		saveConfig(appConfig)
 	} else {
 		// Revert to original values on Cancel
 		currentUIScale := getUIScale() // Get current scale before reverting
 		appConfig.Set("theme", origWindowTheme)
 		appConfig.Set("term_theme", origTermTheme)
+		appConfig.Set("clipboard_access", origClipboardAccess)
 		appConfig.Set("ui_scale", origUIScale)
 		if origFontFamily != "" {
 			appConfig.Set("font_family", origFontFamily)
@@ -1199,6 +1516,15 @@ func showSettingsDialog(parent gtk.IWindow) {
 		if origFontFamilyUnicode != "" {
 			appConfig.Set("font_family_unicode", origFontFamilyUnicode)
 		}
+		appConfig.Set("background_opacity", origBackgroundOpacity)
+		appConfig.Set("background_blur", origBackgroundBlur)
+		appConfig.Set("background_image", origBackgroundImage)
+		appConfig.Set("background_dim", origBackgroundDim)
+		appConfig.Set("default_blink", origDefaultBlink)
+		appConfig.Set("cursor_shape", origCursorShape)
+		appConfig.Set("cursor_blink", origCursorBlink)
+		appConfig.Set("scrollback_lines", origScrollbackLines)
+		appConfig.Set("language", origLanguage)
 		// Revert palette sections
 		if len(origTermColors) > 0 {
 			appConfig.Set("term_colors", origTermColors)
@@ -1216,6 +1542,7 @@ func showSettingsDialog(parent gtk.IWindow) {
 			delete(appConfig, "term_colors_light")
 		}
 		configHelper = pawgui.NewConfigHelper(appConfig)
+		applyLanguage()
 		applyWindowTheme()
 		applyConsoleTheme()
 		// Only apply UI scale if it actually changed (avoids unnecessary file list rebuild)
@@ -1238,6 +1565,10 @@ func showSettingsDialog(parent gtk.IWindow) {
 func applyWindowTheme() {
 	applyTheme(configHelper.GetTheme())
 	updateToolbarIcons()
+	// Recolor the script editor's highlighting
+	if launcherEditor != nil {
+		launcherEditor.updateColors()
+	}
 	// Refresh path menu to update icon colors
 	updatePathMenu()
 }
@@ -1341,27 +1672,34 @@ func updateAllTerminalScrollbars() {
 func createLauncherContextMenu() *gtk.Menu {
 	menu, _ := gtk.MenuNew()
 
-	copyItem := createMenuItemWithGutter("Copy", func() {
+	copyItem := createMenuItemWithGutter(tr("Copy"), func() {
 		if terminal != nil {
 			terminal.CopySelection()
 		}
 	})
 	menu.Append(copyItem)
 
-	pasteItem := createMenuItemWithGutter("Paste", func() {
+	pasteItem := createMenuItemWithGutter(tr("Paste"), func() {
 		if terminal != nil {
 			terminal.PasteClipboard()
 		}
 	})
 	menu.Append(pasteItem)
 
-	selectAllItem := createMenuItemWithGutter("Select All", func() {
+	selectAllItem := createMenuItemWithGutter(tr("Select All"), func() {
 		if terminal != nil {
 			terminal.SelectAll()
 		}
 	})
 	menu.Append(selectAllItem)
 
+	findItem := createMenuItemWithGutter(tr("Find..."), func() {
+		if terminal != nil {
+			terminal.ShowFindBar()
+		}
+	})
+	menu.Append(findItem)
+
 	menu.ShowAll()
 	return menu
 }
@@ -1424,7 +1762,7 @@ func updateToolbarIcons() {
 	// Update all registered buttons in launcher toolbar
 	for _, btn := range launcherRegisteredBtns {
 		if btn.widget != nil {
-			svgData := getSVGIcon(starIconSVG)
+			svgData := getSVGIcon(toolbarIconSVG(btn.Icon))
 			if img := createImageFromSVG(svgData, scaledToolbarIconSize()); img != nil {
 				btn.widget.SetImage(img)
 			}
@@ -1445,7 +1783,7 @@ func updateToolbarIcons() {
 		// Update registered buttons
 		for _, btn := range data.registeredBtns {
 			if btn.widget != nil {
-				svgData := getSVGIcon(starIconSVG)
+				svgData := getSVGIcon(toolbarIconSVG(btn.Icon))
 				if img := createImageFromSVG(svgData, scaledToolbarIconSize()); img != nil {
 					btn.widget.SetImage(img)
 				}
@@ -1465,7 +1803,7 @@ func updateToolbarIcons() {
 		// Update registered buttons
 		for _, btn := range data.registeredBtns {
 			if btn.widget != nil {
-				svgData := getSVGIcon(starIconSVG)
+				svgData := getSVGIcon(toolbarIconSVG(btn.Icon))
 				if img := createImageFromSVG(svgData, scaledToolbarIconSize()); img != nil {
 					btn.widget.SetImage(img)
 				}
@@ -1554,9 +1892,9 @@ func refreshFileListIcons() {
 			svgData = getSVGIcon(svgTemplate)
 		}
 
-		// Update the existing image with new pixbuf
-		if pixbuf := createPixbufFromSVG(svgData, scaledFileListIconSize()); pixbuf != nil {
-			img.SetFromPixbuf(pixbuf)
+		// Update the existing image with the new icon
+		if surface := createSurfaceFromSVG(svgData, scaledFileListIconSize()); surface != nil {
+			img.SetFromSurface(surface)
 		}
 	}
 }
@@ -1604,10 +1942,13 @@ type MenuContext struct {
 	Terminal         *purfectermgtk.Terminal
 	IsScriptRunning  func() bool
 	StopScript       func()
-	IsFileListWide   func() bool   // Launcher only: returns true if wide panel visible
-	ToggleFileList   func()        // Launcher only: toggles wide/narrow mode
-	CloseWindow      func()        // Closes this window
-	FileListMenuItem *gtk.MenuItem // Reference to File List toggle item
+	IsFileListWide   func() bool         // Launcher only: returns true if wide panel visible
+	ToggleFileList   func()              // Launcher only: toggles wide/narrow mode
+	IsEditorShown    func() bool         // Launcher only: returns true if the script editor is showing
+	ToggleEditor     func()              // Launcher only: shows or hides the script editor
+	CloseWindow      func()              // Closes this window
+	FileListMenuItem *gtk.MenuItem       // Reference to File List toggle item
+	ScriptMenus      *pawgui.ScriptMenus // Entries scripts added with menu_register (nil = none)
 }
 
 // createHamburgerMenu creates the hamburger dropdown menu
@@ -1616,17 +1957,39 @@ func createHamburgerMenu(ctx *MenuContext) *gtk.Menu {
 	menu, _ := gtk.MenuNew()
 
 	// About option (both)
-	aboutItem := createMenuItemWithGutter("About PawScript...", func() {
+	aboutItem := createMenuItemWithGutter(tr("About PawScript..."), func() {
 		showAboutDialog(ctx.Parent)
 	})
 	menu.Append(aboutItem)
 
 	// Settings option (both)
-	settingsItem := createMenuItemWithGutter("Settings...", func() {
+	settingsItem := createMenuItemWithGutter(tr("Settings..."), func() {
 		showSettingsDialog(ctx.Parent)
 	})
 	menu.Append(settingsItem)
 
+	// Scheduled Tasks option (both)
+	scheduledTasksItem := createMenuItemWithGutter(tr("Scheduled Tasks..."), func() {
+		showScheduledTasksDialog(ctx.Parent)
+	})
+	menu.Append(scheduledTasksItem)
+
+	// Settings bundles and profiles (both)
+	exportSettingsItem := createMenuItemWithGutter(tr("Export Settings..."), func() {
+		exportSettingsDialog()
+	})
+	menu.Append(exportSettingsItem)
+	importSettingsItem := createMenuItemWithGutter(tr("Import Settings..."), func() {
+		importSettingsDialog(ctx.Parent)
+	})
+	menu.Append(importSettingsItem)
+	settingsProfilesItem := createMenuItemWithGutter(tr("Settings Profiles"), nil)
+	menu.Append(settingsProfilesItem)
+	menu.Connect("show", func() {
+		// Profiles are saved and deleted by other windows and instances too
+		settingsProfilesItem.SetSubmenu(createSettingsProfilesMenu(ctx.Parent))
+	})
+
 	// Separator after About/Settings
 	sepAbout, _ := gtk.SeparatorMenuItemNew()
 	menu.Append(sepAbout)
@@ -1645,7 +2008,7 @@ func createHamburgerMenu(ctx *MenuContext) *gtk.Menu {
 		} else {
 			iconSVG = uncheckedIconSVG
 		}
-		fileListItem := createMenuItemWithIcon(iconSVG, "File List", func() {
+		fileListItem := createMenuItemWithIcon(iconSVG, tr("File List"), func() {
 			if ctx.ToggleFileList != nil {
 				ctx.ToggleFileList()
 			}
@@ -1654,26 +2017,70 @@ func createHamburgerMenu(ctx *MenuContext) *gtk.Menu {
 		menu.Append(fileListItem)
 	}
 
+	// Script Editor toggle (launcher only) - same checked/unchecked icons as File List
+	var localEditorItem *gtk.MenuItem
+	if !ctx.IsScriptWindow && ctx.ToggleEditor != nil {
+		iconSVG := uncheckedIconSVG
+		if ctx.IsEditorShown != nil && ctx.IsEditorShown() {
+			iconSVG = checkedIconSVG
+		}
+		localEditorItem = createMenuItemWithIcon(iconSVG, tr("Script Editor"), ctx.ToggleEditor)
+		menu.Append(localEditorItem)
+	}
+
 	// Show Launcher (console windows only)
 	if ctx.IsScriptWindow {
-		showLauncherItem := createMenuItemWithGutter("Show Launcher", func() {
+		showLauncherItem := createMenuItemWithGutter(tr("Show Launcher"), func() {
 			showOrCreateLauncher()
 		})
 		menu.Append(showLauncherItem)
 	}
 
-	// New Window (both - creates a blank console window)
-	newWindowItem := createMenuItemWithGutter("New Window", func() {
-		createBlankConsoleWindow()
+	// New Tab (both - opens a blank console tab in the last focused tab window)
+	newTabItem := createMenuItemWithGutter(tr("New Tab"), func() {
+		createBlankConsoleTab(false)
+	})
+	menu.Append(newTabItem)
+
+	// New Window (both - opens a blank console tab in a window of its own)
+	newWindowItem := createMenuItemWithGutter(tr("New Window"), func() {
+		createBlankConsoleTab(true)
 	})
 	menu.Append(newWindowItem)
 
+	// Open System Shell Here (launcher only - a shell in the directory being browsed)
+	if !ctx.IsScriptWindow {
+		shellItem := createMenuItemWithGutter(tr("Open System Shell Here"), func() {
+			openShellTab(currentDir)
+		})
+		menu.Append(shellItem)
+	}
+
+	// Open Project... and the open project's submenu (launcher only)
+	if !ctx.IsScriptWindow {
+		openProjectItem := createMenuItemWithGutter(tr("Open Project..."), func() {
+			openProjectDialog()
+		})
+		menu.Append(openProjectItem)
+		projectItem := createMenuItemWithGutter(tr("Project"), nil)
+		menu.Append(projectItem)
+		menu.Connect("show", func() {
+			// Run configurations are added by the file list's menu
+			if currentProject == nil {
+				projectItem.Hide()
+				return
+			}
+			projectItem.SetSubmenu(createProjectMenu(ctx.Parent))
+			projectItem.Show()
+		})
+	}
+
 	// Separator
 	sep1, _ := gtk.SeparatorMenuItemNew()
 	menu.Append(sep1)
 
 	// Stop Script (both) - disabled when no script running
-	stopScriptItem := createMenuItemWithGutter("Stop Script", func() {
+	stopScriptItem := createMenuItemWithGutter(tr("Stop Script"), func() {
 		if ctx.StopScript != nil {
 			ctx.StopScript()
 		}
@@ -1682,7 +2089,7 @@ func createHamburgerMenu(ctx *MenuContext) *gtk.Menu {
 	menu.Append(stopScriptItem)
 
 	// Reset Terminal (both) - directly under Stop Script
-	resetTerminalItem := createMenuItemWithGutter("Reset Terminal", func() {
+	resetTerminalItem := createMenuItemWithGutter(tr("Reset Terminal"), func() {
 		if ctx.Terminal != nil {
 			ctx.Terminal.Reset()
 		}
@@ -1698,6 +2105,9 @@ func createHamburgerMenu(ctx *MenuContext) *gtk.Menu {
 		if localFileListItem != nil && ctx.IsFileListWide != nil {
 			updateFileListMenuIcon(localFileListItem, ctx.IsFileListWide())
 		}
+		if localEditorItem != nil && ctx.IsEditorShown != nil {
+			updateFileListMenuIcon(localEditorItem, ctx.IsEditorShown())
+		}
 	})
 
 	// Separator
@@ -1705,7 +2115,7 @@ func createHamburgerMenu(ctx *MenuContext) *gtk.Menu {
 	menu.Append(sep2)
 
 	// Save Scrollback ANSI (both)
-	saveScrollbackANSIItem := createMenuItemWithGutter("Save Scrollback ANSI...", func() {
+	saveScrollbackANSIItem := createMenuItemWithGutter(tr("Save Scrollback ANSI..."), func() {
 		if ctx.Parent != nil && ctx.Terminal != nil {
 			saveScrollbackANSIDialog(ctx.Parent, ctx.Terminal)
 		}
@@ -1713,15 +2123,48 @@ func createHamburgerMenu(ctx *MenuContext) *gtk.Menu {
 	menu.Append(saveScrollbackANSIItem)
 
 	// Save Scrollback Text (both)
-	saveScrollbackTextItem := createMenuItemWithGutter("Save Scrollback Text...", func() {
+	saveScrollbackTextItem := createMenuItemWithGutter(tr("Save Scrollback Text..."), func() {
 		if ctx.Parent != nil && ctx.Terminal != nil {
 			saveScrollbackTextDialog(ctx.Parent, ctx.Terminal)
 		}
 	})
 	menu.Append(saveScrollbackTextItem)
 
+	// Save Terminal State (both)
+	saveTerminalStateItem := createMenuItemWithGutter(tr("Save Terminal State..."), func() {
+		if ctx.Parent != nil && ctx.Terminal != nil {
+			saveTerminalStateDialog(ctx.Parent, ctx.Terminal)
+		}
+	})
+	menu.Append(saveTerminalStateItem)
+
+	// Start Recording / Stop Recording (both) - only one is enabled at a time
+	startRecordingItem := createMenuItemWithGutter(tr("Start Recording..."), func() {
+		if ctx.Parent != nil && ctx.Terminal != nil {
+			startRecordingDialog(ctx.Parent, ctx.Terminal)
+		}
+	})
+	menu.Append(startRecordingItem)
+	stopRecordingItem := createMenuItemWithGutter(tr("Stop Recording"), func() {
+		if ctx.Terminal != nil {
+			stopRecording(ctx.Terminal)
+		}
+	})
+	menu.Append(stopRecordingItem)
+	menu.Connect("show", func() {
+		recording := ctx.Terminal != nil && ctx.Terminal.IsRecording()
+		startRecordingItem.SetSensitive(!recording)
+		stopRecordingItem.SetSensitive(recording)
+	})
+
+	// Open Log Folder (both) - where run logs are written
+	openLogFolderItem := createMenuItemWithGutter(tr("Open Log Folder"), func() {
+		openRunLogFolder()
+	})
+	menu.Append(openLogFolderItem)
+
 	// Restore Buffer (both)
-	restoreBufferItem := createMenuItemWithGutter("Restore Buffer...", func() {
+	restoreBufferItem := createMenuItemWithGutter(tr("Restore Buffer..."), func() {
 		if ctx.Parent != nil && ctx.Terminal != nil {
 			restoreBufferDialog(ctx.Parent, ctx.Terminal)
 		}
@@ -1729,7 +2172,7 @@ func createHamburgerMenu(ctx *MenuContext) *gtk.Menu {
 	menu.Append(restoreBufferItem)
 
 	// Clear Scrollback (both)
-	clearScrollbackItem := createMenuItemWithGutter("Clear Scrollback", func() {
+	clearScrollbackItem := createMenuItemWithGutter(tr("Clear Scrollback"), func() {
 		if ctx.Terminal != nil {
 			ctx.Terminal.ClearScrollback()
 		}
@@ -1741,7 +2184,7 @@ func createHamburgerMenu(ctx *MenuContext) *gtk.Menu {
 	menu.Append(sep3)
 
 	// Close (both) - show shortcut if configured
-	closeItem := createMenuItemWithShortcut("Close", getCloseShortcut(), func() {
+	closeItem := createMenuItemWithShortcut(tr("Close"), getCloseShortcut(), func() {
 		if ctx.CloseWindow != nil {
 			ctx.CloseWindow()
 		}
@@ -1749,18 +2192,75 @@ func createHamburgerMenu(ctx *MenuContext) *gtk.Menu {
 	menu.Append(closeItem)
 
 	// Quit PawScript (both) - show shortcut if configured
-	quitItem := createMenuItemWithShortcut("Quit PawScript", getQuitShortcut(), func() {
+	quitItem := createMenuItemWithShortcut(tr("Quit PawScript"), getQuitShortcut(), func() {
 		quitApplication(ctx.Parent)
 	})
 	menu.Append(quitItem)
 
+	// Entries scripts added, at the top
+	if ctx.ScriptMenus != nil {
+		addScriptMenus(menu, ctx.ScriptMenus)
+	}
+
 	menu.ShowAll()
 	return menu
 }
 
+// addScriptMenus shows the entries scripts added with menu_register at the top of
+// a hamburger menu, rebuilding them as the menu opens after they changed
+func addScriptMenus(menu *gtk.Menu, menus *pawgui.ScriptMenus) {
+	var shown []*gtk.MenuItem
+	generation := 0
+	menu.Connect("show", func() {
+		items, current := menus.Items()
+		if current == generation {
+			return
+		}
+		generation = current
+
+		for _, item := range shown {
+			menu.Remove(item)
+		}
+		// Clear references and force GC to prevent finalizer crash
+		shown = nil
+		runtime.GC()
+
+		for _, item := range items {
+			shown = append(shown, createScriptMenuItem(item))
+		}
+		if len(shown) > 0 {
+			sep, _ := gtk.SeparatorMenuItemNew()
+			shown = append(shown, &sep.MenuItem)
+		}
+		for i, item := range shown {
+			menu.Insert(item, i)
+			item.ShowAll()
+		}
+	})
+}
+
+// createScriptMenuItem creates the menu item for an entry a script added, with its
+// submenu if it has one
+func createScriptMenuItem(entry *pawgui.ScriptMenuItem) *gtk.MenuItem {
+	if entry.Separator {
+		sep, _ := gtk.SeparatorMenuItemNew()
+		return &sep.MenuItem
+	}
+	item := createMenuItemWithGutter(entry.Label, entry.Activate)
+	if entry.Activate == nil {
+		submenu, _ := gtk.MenuNew()
+		for _, child := range entry.Submenu {
+			submenu.Append(createScriptMenuItem(child))
+		}
+		item.SetSubmenu(submenu)
+	}
+	return item
+}
+
 // showOrCreateLauncher brings the launcher window to front, or creates one if hidden/closed
 func showOrCreateLauncher() {
 	if mainWindow != nil {
+		mainWindow.Deiconify()
 		mainWindow.Present()
 	} else if app != nil {
 		// Launcher was closed, create a new one
@@ -1801,9 +2301,9 @@ func quitApplication(parent gtk.IWindow) {
 			gtk.DIALOG_MODAL|gtk.DIALOG_DESTROY_WITH_PARENT,
 			gtk.MESSAGE_QUESTION,
 			gtk.BUTTONS_YES_NO,
-			"This will stop all scripts. Are you sure?",
+			"%s", tr("This will stop all scripts. Are you sure?"),
 		)
-		dialog.SetTitle("Quit PawScript")
+		dialog.SetTitle(tr("Quit PawScript"))
 		response := dialog.Run()
 		dialog.Destroy()
 
@@ -1812,8 +2312,9 @@ func quitApplication(parent gtk.IWindow) {
 		}
 	}
 
-	// Quit the application
+	// Quit the application, saving what's open for the next launch
 	if app != nil {
+		saveSession()
 		app.Quit()
 	}
 }
@@ -1830,7 +2331,7 @@ func saveScrollbackANSIDialog(parent gtk.IWindow, term *purfectermgtk.Terminal)
 
 	// Use sqweek/dialog for native file save dialog
 	filename, err := dialog.File().
-		Title("Save Scrollback ANSI").
+		Title(tr("Save Scrollback ANSI")).
 		Filter("ANSI files", "ans").
 		Filter("All files", "*").
 		SetStartFile("scrollback.ans").
@@ -1847,7 +2348,80 @@ func saveScrollbackANSIDialog(parent gtk.IWindow, term *purfectermgtk.Terminal)
 
 	// Write to file
 	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
-		dialog.Message("Failed to save file: %v", err).Title("Error").Error()
+		dialog.Message(tr("Failed to save file: %v"), err).Title(tr("Error")).Error()
+	}
+}
+
+// saveTerminalStateDialog shows a file dialog to save a snapshot of the terminal's
+// complete state, which Restore Buffer resumes exactly
+func saveTerminalStateDialog(parent gtk.IWindow, term *purfectermgtk.Terminal) {
+	// Use global terminal as fallback if term is nil
+	if term == nil {
+		term = terminal
+	}
+	if term == nil {
+		return
+	}
+
+	// Use sqweek/dialog for native file save dialog
+	filename, err := dialog.File().
+		Title(tr("Save Terminal State")).
+		Filter("Terminal snapshots", "ptsnap").
+		Filter("All files", "*").
+		SetStartFile("terminal.ptsnap").
+		Save()
+	if err != nil || filename == "" {
+		return
+	}
+
+	var content bytes.Buffer
+	if err := term.SaveSnapshot(&content); err != nil {
+		dialog.Message(tr("Failed to save terminal state: %v"), err).Title(tr("Error")).Error()
+		return
+	}
+	if err := os.WriteFile(filename, content.Bytes(), 0644); err != nil {
+		dialog.Message(tr("Failed to save file: %v"), err).Title(tr("Error")).Error()
+	}
+}
+
+// startRecordingDialog shows a file dialog and starts recording the terminal's
+// output to it as an asciinema cast
+func startRecordingDialog(parent gtk.IWindow, term *purfectermgtk.Terminal) {
+	// Use global terminal as fallback if term is nil
+	if term == nil {
+		term = terminal
+	}
+	if term == nil {
+		return
+	}
+
+	// Use sqweek/dialog for native file save dialog
+	filename, err := dialog.File().
+		Title(tr("Start Recording")).
+		Filter("Asciinema casts", "cast").
+		Filter("All files", "*").
+		SetStartFile("recording.cast").
+		Save()
+	if err != nil || filename == "" {
+		return
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		dialog.Message(tr("Failed to create file: %v"), err).Title(tr("Error")).Error()
+		return
+	}
+	title := fmt.Sprintf("PawScript %s (GTK; %s; %s)", version, runtime.GOOS, runtime.GOARCH)
+	if err := term.StartRecording(f, title); err != nil {
+		f.Close()
+		dialog.Message(tr("Failed to start recording: %v"), err).Title(tr("Error")).Error()
+	}
+}
+
+// stopRecording stops recording the terminal's output, reporting any write error
+func stopRecording(term *purfectermgtk.Terminal) {
+	if err := term.StopRecording(); err != nil {
+		dialog.Message(tr("Failed to save recording: %v"), err).Title(tr("Error")).Error()
 	}
 }
 
@@ -1863,7 +2437,7 @@ func saveScrollbackTextDialog(parent gtk.IWindow, term *purfectermgtk.Terminal)
 
 	// Use sqweek/dialog for native file save dialog
 	filename, err := dialog.File().
-		Title("Save Scrollback Text").
+		Title(tr("Save Scrollback Text")).
 		Filter("Text files", "txt").
 		Filter("All files", "*").
 		SetStartFile("scrollback.txt").
@@ -1880,7 +2454,7 @@ func saveScrollbackTextDialog(parent gtk.IWindow, term *purfectermgtk.Terminal)
 
 	// Write to file
 	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
-		dialog.Message("Failed to save file: %v", err).Title("Error").Error()
+		dialog.Message(tr("Failed to save file: %v"), err).Title(tr("Error")).Error()
 	}
 }
 
@@ -1896,8 +2470,9 @@ func restoreBufferDialog(parent gtk.IWindow, term *purfectermgtk.Terminal) {
 
 	// Use sqweek/dialog for native file open dialog
 	filename, err := dialog.File().
-		Title("Restore Buffer").
+		Title(tr("Restore Buffer")).
 		Filter("ANSI files", "ans").
+		Filter("Terminal snapshots", "ptsnap").
 		Filter("Text files", "txt").
 		Filter("All files", "*").
 		Load()
@@ -1908,7 +2483,15 @@ func restoreBufferDialog(parent gtk.IWindow, term *purfectermgtk.Terminal) {
 	// Read file content
 	content, err := os.ReadFile(filename)
 	if err != nil {
-		dialog.Message("Failed to read file: %v", err).Title("Error").Error()
+		dialog.Message(tr("Failed to read file: %v"), err).Title(tr("Error")).Error()
+		return
+	}
+
+	// A snapshot restores the terminal exactly, replacing what it shows
+	if purfecterm.IsSnapshot(content) {
+		if err := term.RestoreSnapshot(bytes.NewReader(content)); err != nil {
+			dialog.Message(tr("Failed to restore terminal state: %v"), err).Title(tr("Error")).Error()
+		}
 		return
 	}
 
@@ -1921,62 +2504,222 @@ func restoreBufferDialog(parent gtk.IWindow, term *purfectermgtk.Terminal) {
 	term.Feed(contentStr)
 }
 
-// createBlankConsoleWindow creates a new blank terminal window with REPL
-// This creates the same environment as the Run button, but without running a script
-func createBlankConsoleWindow() {
-	if app == nil {
-		return
+// Console tabs
+// The consoles, shells and scripts the launcher opens share a window, each in a
+// tab with its own terminal, toolbar strip, REPL and script state. Tabs are dragged
+// to reorder them and closed with their close buttons, the Close menu item or the
+// close shortcut; closing the last one closes the window. New tabs open in the tab
+// window last focused, and New Window starts another.
+
+// tabWindow is a window holding console tabs
+type tabWindow struct {
+	win      *gtk.ApplicationWindow
+	notebook *gtk.Notebook
+	session  map[uintptr]*sessionTab // The tabs open, by page, for the session
+
+	background bool // Tabs open without raising it, for scheduled tasks
+}
+
+// currentTabWindow is where new tabs open: the tab window last focused, or nil
+// when none is open
+var currentTabWindow *tabWindow
+
+// getTabWindow returns the tab window to open a tab in, opening a window if there
+// is none or newWindow is set. A new window is shown when its first tab is added.
+func getTabWindow(newWindow bool) (*tabWindow, error) {
+	if currentTabWindow != nil && !newWindow {
+		return currentTabWindow, nil
 	}
 
-	// Create new window
 	win, err := gtk.ApplicationWindowNew(app)
 	if err != nil {
-		return
+		return nil, err
 	}
-	win.SetTitle("PawScript - Console")
-	win.SetDefaultSize(900, 600)
-
-	// Set up quit shortcut for this window
-	setupQuitShortcutForWindow(win)
-
-	// Create terminal for this window
-	winTerminal, err := purfectermgtk.New(purfectermgtk.Options{
-		Cols:           100,
-		Rows:           30,
-		ScrollbackSize: 10000,
-		FontFamily:     getFontFamily(),
-		FontSize:       getFontSize(),
-		Scheme:         getDualColorScheme(),
-	})
+	notebook, err := gtk.NotebookNew()
 	if err != nil {
 		win.Destroy()
-		return
+		return nil, err
 	}
+	tw := &tabWindow{win: win, notebook: notebook, session: make(map[uintptr]*sessionTab)}
 
-	// Set font fallbacks for Unicode/CJK characters
-	winTerminal.SetFontFallbacks(getFontFamilyUnicode(), getFontFamilyCJK())
+	// Let the background opacity show through (see purfectermgtk.UseRGBAVisual)
+	purfectermgtk.UseRGBAVisual(&win.Window)
+	win.SetTitle(tr("PawScript - Console"))
+	win.SetDefaultSize(900, 600)
 
-	// Set up terminal theme from config
-	prefersDark := isTermThemeDark()
-	winTerminal.Buffer().SetPreferredDarkTheme(prefersDark)
-	winTerminal.Buffer().SetDarkTheme(prefersDark)
+	// The close shortcut closes the tab shown rather than the window
+	setupShortcuts(win, tw.closeCurrentTab)
 
-	// Set up theme change callback (for CSI ? 5 h/l escape sequences)
-	winTerminal.Buffer().SetThemeChangeCallback(func(isDark bool) {
-		glib.IdleAdd(func() {
-			winTerminal.SetColorScheme(getColorSchemeForTheme(isDark))
+	notebook.SetScrollable(true)
+	notebook.SetShowBorder(false)
+	notebook.PopupEnable() // Right-click on the tabs lists them
+
+	// New tab button after the tabs
+	if newTabBtn, err := gtk.ButtonNewFromIconName("list-add-symbolic", gtk.ICON_SIZE_MENU); err == nil {
+		newTabBtn.SetRelief(gtk.RELIEF_NONE)
+		newTabBtn.SetTooltipText(tr("New Tab"))
+		newTabBtn.Connect("clicked", func() {
+			currentTabWindow = tw
+			createBlankConsoleTab(false)
 		})
+		newTabBtn.Show()
+		notebook.SetActionWidget(newTabBtn, gtk.PACK_END)
+	}
+
+	notebook.Connect("notify::page", func() {
+		tw.updateTitle()
+	})
+	notebook.Connect("page-removed", func() {
+		if notebook.GetNPages() == 0 {
+			win.Destroy()
+		}
+	})
+	win.Connect("focus-in-event", func() bool {
+		if !tw.background {
+			currentTabWindow = tw
+		}
+		return false
+	})
+	win.Connect("delete-event", saveSessionIfLast)
+	win.Connect("destroy", func() {
+		if currentTabWindow == tw {
+			currentTabWindow = nil
+		}
+		for i, open := range tabWindows {
+			if open == tw {
+				tabWindows = append(tabWindows[:i], tabWindows[i+1:]...)
+				break
+			}
+		}
 	})
 
-	// Create main layout with collapsible toolbar strip
-	paned, _ := gtk.PanedNew(gtk.ORIENTATION_HORIZONTAL)
+	win.Add(notebook)
+	currentTabWindow = tw
+	tabWindows = append(tabWindows, tw)
+	return tw, nil
+}
 
-	// Track script running state for this window (starts with no script)
-	var winScriptRunning bool
-	var winScriptMu sync.Mutex
+// addTab adds page as a tab titled title after the others and shows it, returning
+// a function that closes the tab
+func (tw *tabWindow) addTab(page gtk.IWidget, title string) (closeTab func()) {
+	closeTab = func() {
+		page.ToWidget().Destroy()
+	}
+
+	// The tab shows the title and a close button
+	tabLabel, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 4)
+	label, _ := gtk.LabelNew(title)
+	tabLabel.PackStart(label, true, true, 0)
+	if closeBtn, err := gtk.ButtonNewFromIconName("window-close-symbolic", gtk.ICON_SIZE_MENU); err == nil {
+		closeBtn.SetRelief(gtk.RELIEF_NONE)
+		closeBtn.SetFocusOnClick(false)
+		closeBtn.SetTooltipText(tr("Close Tab"))
+		closeBtn.Connect("clicked", closeTab)
+		tabLabel.PackStart(closeBtn, false, false, 0)
+	}
+	tabLabel.ShowAll()
+
+	n := tw.notebook.AppendPage(page, tabLabel)
+	tw.notebook.SetMenuLabelText(page, title)
+	tw.notebook.SetTabReorderable(page, true)
+	page.ToWidget().ShowAll()
+	if tw.background && !tw.win.GetVisible() {
+		tw.win.Iconify() // Background windows open minimized
+	}
+	tw.win.ShowAll()
+	tw.notebook.SetCurrentPage(n)
+	tw.updateTitle()
+	if !tw.background {
+		tw.win.Present()
+	}
+	return closeTab
+}
 
-	// Create MenuContext for this console window
-	consoleMenuCtx := &MenuContext{
+// closeCurrentTab closes the tab shown
+func (tw *tabWindow) closeCurrentTab() {
+	if page, err := tw.notebook.GetNthPage(tw.notebook.GetCurrentPage()); err == nil && page != nil {
+		page.ToWidget().Destroy()
+	}
+}
+
+// closeIfEmpty closes the window if it has no tabs, when opening its first failed
+func (tw *tabWindow) closeIfEmpty() {
+	if tw.notebook.GetNPages() == 0 {
+		tw.win.Destroy()
+	}
+}
+
+// updateTitle gives the window the title of the tab shown
+func (tw *tabWindow) updateTitle() {
+	page, err := tw.notebook.GetNthPage(tw.notebook.GetCurrentPage())
+	if err != nil || page == nil {
+		return
+	}
+	if title, err := tw.notebook.GetMenuLabelText(page); err == nil {
+		tw.win.SetTitle(tr("PawScript - ") + title)
+	}
+}
+
+// createBlankConsoleTab opens a console tab with a REPL, in a new tab window if
+// newWindow is set. This creates the same environment as the Run button, but
+// without running a script
+func createBlankConsoleTab(newWindow bool) {
+	if app == nil {
+		return
+	}
+
+	tabs, err := getTabWindow(newWindow)
+	if err != nil {
+		return
+	}
+	win := tabs.win
+
+	// Create terminal for this tab
+	winTerminal, err := purfectermgtk.New(purfectermgtk.Options{
+		Cols:           100,
+		Rows:           30,
+		ScrollbackSize: getScrollbackLines(),
+		FontFamily:     getFontFamily(),
+		FontSize:       getFontSize(),
+		Scheme:         getDualColorScheme(),
+	})
+	if err != nil {
+		tabs.closeIfEmpty()
+		return
+	}
+
+	// Set font fallbacks for Unicode/CJK characters
+	winTerminal.SetFontFallbacks(getFontFamilyUnicode(), getFontFamilyCJK())
+
+	// Key macros from the config (scripts can add more with key_macro)
+	setupKeyMacros(winTerminal)
+	setupWindowZoom(winTerminal, "console")
+
+	// Flash the window in the taskbar when a background script rings the bell
+	setupBellUrgency(win, winTerminal)
+
+	// Set up terminal theme from config
+	prefersDark := isTermThemeDark()
+	winTerminal.Buffer().SetPreferredDarkTheme(prefersDark)
+	winTerminal.Buffer().SetDarkTheme(prefersDark)
+
+	// Set up theme change callback (for CSI ? 5 h/l escape sequences)
+	winTerminal.Buffer().SetThemeChangeCallback(func(isDark bool) {
+		glib.IdleAdd(func() {
+			winTerminal.SetColorScheme(getColorSchemeForTheme(isDark))
+		})
+	})
+
+	// Create main layout with collapsible toolbar strip
+	paned, _ := gtk.PanedNew(gtk.ORIENTATION_HORIZONTAL)
+
+	// Track script running state for this tab (starts with no script)
+	var winScriptRunning bool
+	var winScriptMu sync.Mutex
+	var closeTab func()
+
+	// Create MenuContext for this console window
+	consoleMenuCtx := &MenuContext{
 		Parent:         win,
 		IsScriptWindow: true,
 		Terminal:       winTerminal,
@@ -1986,8 +2729,9 @@ func createBlankConsoleWindow() {
 			return winScriptRunning
 		},
 		CloseWindow: func() {
-			win.Close()
+			closeTab()
 		},
+		ScriptMenus: pawgui.NewScriptMenus(),
 	}
 
 	// Narrow strip for console window (always starts visible, collapsible)
@@ -2001,7 +2745,13 @@ func createBlankConsoleWindow() {
 	termWidget.SetVExpand(true)
 	termWidget.SetHExpand(true)
 	termWidget.SetMarginStart(8)
-	paned.Pack2(termWidget, true, false)
+
+	// Status bar under the terminal, set by scripts with status_set
+	winStatus := newStatusBar()
+	termBox, _ := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 0)
+	termBox.PackStart(termWidget, true, true, 0)
+	termBox.PackStart(winStatus.box, false, false, 0)
+	paned.Pack2(termBox, true, false)
 
 	// Set initial strip width and collapse behavior
 	consoleStripWidth := scaledMinNarrowStripWidth() + 4 + narrowOnlyExtraPadding
@@ -2073,27 +2823,30 @@ func createBlankConsoleWindow() {
 		return true
 	})
 
-	win.Add(paned)
-
 	// Create context menu for this console window
 	winContextMenu, _ := gtk.MenuNew()
 
-	winCopyItem := createMenuItemWithGutter("Copy", func() {
+	winCopyItem := createMenuItemWithGutter(tr("Copy"), func() {
 		winTerminal.CopySelection()
 	})
 	winContextMenu.Append(winCopyItem)
 
-	winPasteItem := createMenuItemWithGutter("Paste", func() {
+	winPasteItem := createMenuItemWithGutter(tr("Paste"), func() {
 		winTerminal.PasteClipboard()
 	})
 	winContextMenu.Append(winPasteItem)
 
-	winSelectAllItem := createMenuItemWithGutter("Select All", func() {
+	winSelectAllItem := createMenuItemWithGutter(tr("Select All"), func() {
 		winTerminal.SelectAll()
 	})
 	winContextMenu.Append(winSelectAllItem)
 
-	winClearItem := createMenuItemWithGutter("Clear", func() {
+	winFindItem := createMenuItemWithGutter(tr("Find..."), func() {
+		winTerminal.ShowFindBar()
+	})
+	winContextMenu.Append(winFindItem)
+
+	winClearItem := createMenuItemWithGutter(tr("Clear"), func() {
 		winTerminal.Clear()
 	})
 	winContextMenu.Append(winClearItem)
@@ -2103,6 +2856,7 @@ func createBlankConsoleWindow() {
 	termWidget.Connect("button-press-event", func(widget *gtk.Box, ev *gdk.Event) bool {
 		btn := gdk.EventButtonNewFromEvent(ev)
 		if btn.Button() == 3 {
+			winTerminal.PrepareContextMenu(winContextMenu, createMenuItemWithGutter)
 			winContextMenu.PopupAtPointer(ev)
 			return true
 		}
@@ -2117,70 +2871,49 @@ func createBlankConsoleWindow() {
 	termCaps := winTerminal.GetTerminalCapabilities()
 
 	// Non-blocking output queue
+	// The queue is never closed, as a script may still be sending to it; closing the
+	// channel ends the script's sends and closing outputQueueDone stops the writer
 	outputQueue := make(chan interface{}, 256)
+	outputQueueDone := make(chan struct{})
 	go func() {
-		for item := range outputQueue {
-			switch v := item.(type) {
-			case []byte:
-				stdoutWriter.Write(v)
-			case chan struct{}:
-				close(v)
+		for {
+			select {
+			case item := <-outputQueue:
+				switch v := item.(type) {
+				case chan struct{}:
+					close(v)
+				default:
+					stdoutWriter.Write(pawscript.ConsoleBytes(v))
+				}
+			case <-outputQueueDone:
+				return
 			}
 		}
 	}()
 
-	winOutCh := &pawscript.StoredChannel{
-		BufferSize:       0,
-		Messages:         make([]pawscript.ChannelMessage, 0),
-		Subscribers:      make(map[int]*pawscript.StoredChannel),
-		NextSubscriberID: 1,
-		IsClosed:         false,
-		Timestamp:        time.Now(),
-		Terminal:         termCaps,
-		NativeSend: func(v interface{}) error {
-			var text string
-			switch d := v.(type) {
-			case []byte:
-				text = string(d)
-			case string:
-				text = d
-			default:
-				text = fmt.Sprintf("%v", v)
-			}
-			text = strings.ReplaceAll(text, "\r\n", "\n")
-			text = strings.ReplaceAll(text, "\n", "\r\n")
-			data := []byte(text)
-			select {
-			case outputQueue <- data:
-			default:
-			}
-			return nil
-		},
-		NativeRecv: func() (interface{}, error) {
-			return nil, fmt.Errorf("cannot receive from console_out")
-		},
-		NativeFlush: func() error {
-			writerDone := make(chan struct{})
-			select {
-			case outputQueue <- writerDone:
-				<-writerDone
-			default:
-			}
-			glibDone := make(chan struct{})
-			glib.IdleAdd(func() bool {
-				close(glibDone)
-				return false
-			})
-			select {
-			case <-glibDone:
-			case <-time.After(100 * time.Millisecond):
-			}
-			return nil
-		},
+	winOutCh := pawscript.NewChannelFromGo(outputQueue, nil, termCaps)
+	winOutCh.Overflow = pawscript.OverflowDropNewest // a stalled GUI drops output rather than blocking the script
+	winOutCh.NativeFlush = func() error {
+		writerDone := make(chan struct{})
+		select {
+		case outputQueue <- writerDone:
+			<-writerDone
+		default:
+		}
+		glibDone := make(chan struct{})
+		glib.IdleAdd(func() bool {
+			close(glibDone)
+			return false
+		})
+		select {
+		case <-glibDone:
+		case <-time.After(100 * time.Millisecond):
+		}
+		return nil
 	}
 
 	// Non-blocking input queue
-	inputQueue := make(chan byte, 256)
+	inputQueue := make(chan interface{}, 256)
 	go func() {
 		buf := make([]byte, 1)
 		for {
@@ -2190,39 +2923,21 @@ func createBlankConsoleWindow() {
 				return
 			}
 			select {
-			case inputQueue <- buf[0]:
+			case inputQueue <- []byte{buf[0]}:
 			default:
 				select {
 				case <-inputQueue:
 				default:
 				}
 				select {
-				case inputQueue <- buf[0]:
+				case inputQueue <- []byte{buf[0]}:
 				default:
 				}
 			}
 		}
 	}()
 
-	winInCh := &pawscript.StoredChannel{
-		BufferSize:       0,
-		Messages:         make([]pawscript.ChannelMessage, 0),
-		Subscribers:      make(map[int]*pawscript.StoredChannel),
-		NextSubscriberID: 1,
-		IsClosed:         false,
-		Timestamp:        time.Now(),
-		Terminal:         termCaps,
-		NativeRecv: func() (interface{}, error) {
-			b, ok := <-inputQueue
-			if !ok {
-				return nil, fmt.Errorf("input closed")
-			}
-			return []byte{b}, nil
-		},
-		NativeSend: func(v interface{}) error {
-			return fmt.Errorf("cannot send to console_in")
-		},
-	}
+	winInCh := pawscript.NewChannelFromGo(nil, inputQueue, termCaps)
 
 	// Read from stdout pipe and feed to terminal
 	go func() {
@@ -2246,6 +2961,9 @@ func createBlankConsoleWindow() {
 	// REPL for interactive mode
 	var winREPL *pawscript.REPL
 
+	// Ctrl+click on a hyperlink opens it
+	winTerminal.SetLinkClickCallback(openHyperlink)
+
 	// Wire keyboard input
 	winTerminal.SetInputCallback(func(data []byte) {
 		winScriptMu.Lock()
@@ -2265,17 +2983,19 @@ func createBlankConsoleWindow() {
 		}
 	})
 
-	// Handle window close - clean up resources
-	win.Connect("destroy", func() {
+	// Handle tab close - clean up resources
+	paned.Connect("destroy", func() {
 		winContextMenu.Destroy()
 		stdinWriter.Close()
 		stdoutWriter.Close()
 		stdinReader.Close()
 		stdoutReader.Close()
-		close(outputQueue)
+		_ = pawscript.ChannelClose(winOutCh)
+		close(outputQueueDone)
 	})
 
-	win.ShowAll()
+	closeTab = tabs.addTab(paned, "Console")
+	tabs.remember(paned, pawgui.SessionTab{Kind: pawgui.SessionConsole}, winTerminal, nil)
 
 	// Start REPL immediately (no script to run first)
 	go func() {
@@ -2289,6 +3009,7 @@ func createBlankConsoleWindow() {
 				Stdin:  winInCh,
 				Stderr: winOutCh,
 			},
+			Dialogs: guiDialogs{},
 		}, func(s string) {
 			glib.IdleAdd(func() bool {
 				winTerminal.Feed(s)
@@ -2307,7 +3028,7 @@ func createBlankConsoleWindow() {
 		winREPL.SetPSLColors(getPSLColors())
 		winREPL.Start()
 
-		// Register the dummy_button command with the window's REPL
+		// Register the toolbar_button command with the window's REPL
 		winToolbarData := &WindowToolbarData{
 			strip:      strip,
 			menuButton: stripMenuBtn,
@@ -2316,17 +3037,143 @@ func createBlankConsoleWindow() {
 		winToolbarData.updateFunc = func() {
 			updateWindowToolbarButtons(winToolbarData.strip, winToolbarData.registeredBtns)
 		}
-		registerDummyButtonCommand(winREPL.GetPawScript(), winToolbarData)
+		registerToolbarButtonCommand(winREPL.GetPawScript(), winToolbarData)
+		pawgui.RegisterKeyMacroCommand(winREPL.GetPawScript(), winToolbarData.terminal.KeyMap())
+		pawgui.RegisterMenuItemCommand(winREPL.GetPawScript(), winToolbarData.terminal.ContextMenuItems())
+		pawgui.RegisterMenuRegisterCommand(winREPL.GetPawScript(), consoleMenuCtx.ScriptMenus)
+		pawgui.RegisterWidgetCommands(winREPL.GetPawScript(), scriptWidgets)
+		pawgui.RegisterNotifyCommand(winREPL.GetPawScript())
+		pawgui.RegisterTraySetCommand(winREPL.GetPawScript(), launcherTray)
+		pawgui.RegisterStatusSetCommand(winREPL.GetPawScript(), winStatus.status)
+		pawgui.RegisterProgressCommand(winREPL.GetPawScript(), winStatus.status)
 	}()
 }
 
+// openShellTab opens a tab running the user's shell in dir, returning its
+// terminal (nil if it didn't open)
+func openShellTab(dir string) *purfectermgtk.Terminal {
+	if app == nil {
+		return nil
+	}
+
+	tabs, err := getTabWindow(false)
+	if err != nil {
+		return nil
+	}
+	win := tabs.win
+
+	winTerminal, err := purfectermgtk.New(purfectermgtk.Options{
+		Cols:           100,
+		Rows:           30,
+		ScrollbackSize: getScrollbackLines(),
+		FontFamily:     getFontFamily(),
+		FontSize:       getFontSize(),
+		Scheme:         getDualColorScheme(),
+		WorkingDir:     dir,
+	})
+	if err != nil {
+		tabs.closeIfEmpty()
+		return nil
+	}
+
+	// Set font fallbacks for Unicode/CJK characters
+	winTerminal.SetFontFallbacks(getFontFamilyUnicode(), getFontFamilyCJK())
+
+	// Key macros from the config (scripts can add more with key_macro)
+	setupKeyMacros(winTerminal)
+	setupWindowZoom(winTerminal, "shell")
+
+	// Flash the window in the taskbar when the shell rings the bell
+	setupBellUrgency(win, winTerminal)
+
+	// Set up terminal theme from config
+	prefersDark := isTermThemeDark()
+	winTerminal.Buffer().SetPreferredDarkTheme(prefersDark)
+	winTerminal.Buffer().SetDarkTheme(prefersDark)
+
+	// Set up theme change callback (for CSI ? 5 h/l escape sequences)
+	winTerminal.Buffer().SetThemeChangeCallback(func(isDark bool) {
+		glib.IdleAdd(func() {
+			winTerminal.SetColorScheme(getColorSchemeForTheme(isDark))
+		})
+	})
+
+	// Ctrl+click on a hyperlink opens it
+	winTerminal.SetLinkClickCallback(openHyperlink)
+
+	termWidget := winTerminal.Widget()
+	termWidget.SetVExpand(true)
+	termWidget.SetHExpand(true)
+
+	// Create context menu for this shell window
+	winContextMenu, _ := gtk.MenuNew()
+
+	winCopyItem := createMenuItemWithGutter(tr("Copy"), func() {
+		winTerminal.CopySelection()
+	})
+	winContextMenu.Append(winCopyItem)
+
+	winPasteItem := createMenuItemWithGutter(tr("Paste"), func() {
+		winTerminal.PasteClipboard()
+	})
+	winContextMenu.Append(winPasteItem)
+
+	winSelectAllItem := createMenuItemWithGutter(tr("Select All"), func() {
+		winTerminal.SelectAll()
+	})
+	winContextMenu.Append(winSelectAllItem)
+
+	winFindItem := createMenuItemWithGutter(tr("Find..."), func() {
+		winTerminal.ShowFindBar()
+	})
+	winContextMenu.Append(winFindItem)
+
+	winClearItem := createMenuItemWithGutter(tr("Clear"), func() {
+		winTerminal.Clear()
+	})
+	winContextMenu.Append(winClearItem)
+
+	winContextMenu.ShowAll()
+
+	termWidget.Connect("button-press-event", func(widget *gtk.Box, ev *gdk.Event) bool {
+		btn := gdk.EventButtonNewFromEvent(ev)
+		if btn.Button() == 3 {
+			winTerminal.PrepareContextMenu(winContextMenu, createMenuItemWithGutter)
+			winContextMenu.PopupAtPointer(ev)
+			return true
+		}
+		return false
+	})
+
+	// Leave the tab open when the shell exits, so its last output can be read
+	winTerminal.SetExitCallback(func(err error) {
+		glib.IdleAdd(func() {
+			winTerminal.Feed("\r\n[Process exited]\r\n")
+		})
+	})
+
+	// Handle tab close - kill the shell if it is still running
+	termWidget.Connect("destroy", func() {
+		winContextMenu.Destroy()
+		winTerminal.Close()
+	})
+
+	tabs.addTab(termWidget, "Shell")
+	tabs.remember(termWidget, pawgui.SessionTab{Kind: pawgui.SessionShell, Dir: dir}, winTerminal, nil)
+
+	if err := winTerminal.RunShell(); err != nil {
+		winTerminal.Feed(fmt.Sprintf(tr("Failed to start shell: %v\r\n"), err))
+	}
+	return winTerminal
+}
+
 // createHamburgerButton creates a hamburger menu button with SVG icon
 // forVerticalStrip: true for vertical toolbar strip, false for horizontal rows (file selector)
 // menuGetter: function that returns the menu to show (allows menu to be rebuilt dynamically)
 func createHamburgerButton(menuGetter func() *gtk.Menu, forVerticalStrip bool) *gtk.Button {
 	btn, _ := gtk.ButtonNew()
 	btn.SetSizeRequest(32, 32)
-	btn.SetTooltipText("Menu")
+	btn.SetTooltipText(tr("Menu"))
 	applyToolbarButtonStyle(btn, forVerticalStrip)
 
 	// Set SVG icon with appropriate color for current theme
@@ -2532,42 +3379,50 @@ func resizeSVG(svgData string, size int) string {
 	return svgTag + rest
 }
 
+// createImageFromSVG creates an image of SVG data at the specified size, sharp on
+// high DPI screens (see createSurfaceFromSVG)
 func createImageFromSVG(svgData string, size int) *gtk.Image {
-	// Resize SVG to target size before loading - this lets the vector renderer
-	// rasterize directly at the correct size, avoiding bitmap scaling artifacts
-	resizedSVG := resizeSVG(svgData, size)
-
-	// Create a PixbufLoader for SVG
-	loader, err := gdk.PixbufLoaderNew()
-	if err != nil {
+	surface := createSurfaceFromSVG(svgData, size)
+	if surface == nil {
 		return nil
 	}
-
-	// Write SVG data to loader
-	_, err = loader.Write([]byte(resizedSVG))
+	img, err := gtk.ImageNewFromSurface(surface)
 	if err != nil {
-		loader.Close()
 		return nil
 	}
+	return img
+}
 
-	err = loader.Close()
-	if err != nil {
+// createSurfaceFromSVG renders SVG data at the specified size times the screens'
+// scale factor, as a surface GTK draws at the specified size. Fractional scales
+// (125%, 150%) are drawn by GTK at the next whole one and scaled down by the
+// compositor, so the icons come out as sharp as the text.
+func createSurfaceFromSVG(svgData string, size int) *cairo.Surface {
+	scale := iconScale()
+	pixbuf := createPixbufFromSVG(svgData, size*scale)
+	if pixbuf == nil {
 		return nil
 	}
-
-	// Get the pixbuf (already at target size)
-	pixbuf, err := loader.GetPixbuf()
-	if err != nil || pixbuf == nil {
+	surface, err := gdk.CairoSurfaceCreateFromPixbuf(pixbuf, scale, nil)
+	if err != nil {
 		return nil
 	}
+	return surface
+}
 
-	// Create image from pixbuf
-	img, err := gtk.ImageNewFromPixbuf(pixbuf)
+// iconScale returns the largest scale factor of the screens
+func iconScale() int {
+	display, err := gdk.DisplayGetDefault()
 	if err != nil {
-		return nil
+		return 1
 	}
-
-	return img
+	scale := 1
+	for i := 0; i < display.GetNMonitors(); i++ {
+		if monitor, err := display.GetMonitor(i); err == nil {
+			scale = max(scale, monitor.GetScaleFactor())
+		}
+	}
+	return scale
 }
 
 // createPixbufFromSVG creates a GdkPixbuf from SVG data (for updating existing images)
@@ -2638,9 +3493,9 @@ func updateRowIcon(row *gtk.ListBoxRow, useDarkIcon bool) {
 		svgData = getSVGIcon(svgTemplate)
 	}
 
-	// Update the existing image with new pixbuf
-	if pixbuf := createPixbufFromSVG(svgData, scaledFileListIconSize()); pixbuf != nil {
-		img.SetFromPixbuf(pixbuf)
+	// Update the existing image with the new icon
+	if surface := createSurfaceFromSVG(svgData, scaledFileListIconSize()); surface != nil {
+		img.SetFromSurface(surface)
 	}
 }
 
@@ -2903,7 +3758,7 @@ func updateFileListMenuIcon(item *gtk.MenuItem, isChecked bool) {
 	}
 
 	// Recreate the label
-	label, err := gtk.LabelNew("File List")
+	label, err := gtk.LabelNew(tr("File List"))
 	if err == nil {
 		label.SetXAlign(0)
 		label.SetVAlign(gtk.ALIGN_CENTER)
@@ -3334,8 +4189,34 @@ func applyToolbarButtonStyle(btn *gtk.Button, forVerticalStrip bool) {
 	styleCtx.AddProvider(cssProvider, gtk.STYLE_PROVIDER_PRIORITY_USER)
 }
 
-// Random icons for dummy buttons
-var dummyIcons = []string{"★", "♦", "♠", "♣", "♥", "●", "■", "▲", "◆", "⬟", "⬢", "✦", "✧", "⚡", "☀", "☁", "☂", "☃", "✿", "❀"}
+// toolbarIconSVG returns the SVG for a script button's icon: one of the icons
+// named by pawgui.ToolbarIconNames, or markup the script read from a file
+func toolbarIconSVG(icon string) string {
+	switch icon {
+	case "star":
+		return starIconSVG
+	case "trash":
+		return trashIconSVG
+	case "folder":
+		return folderIconSVG
+	case "folder-up":
+		return folderUpIconSVG
+	case "home":
+		return homeIconSVG
+	case "file":
+		return unknownFileIconSVG
+	case "paw":
+		return pawFileIconSVG
+	case "checked":
+		return checkedIconSVG
+	case "unchecked":
+		return uncheckedIconSVG
+	}
+	if strings.HasPrefix(strings.TrimSpace(icon), "<") {
+		return icon
+	}
+	return starIconSVG
+}
 
 // updateLauncherToolbarButtons updates the launcher's narrow strip with the current registered buttons
 func updateLauncherToolbarButtons() {
@@ -3349,7 +4230,7 @@ func updateLauncherToolbarButtons() {
 	// Get the strip's layout box (first child is the layout container)
 	// Actually the strip IS the box, so we work with it directly
 
-	// Remove existing dummy buttons (but keep the hamburger menu button)
+	// Remove existing script buttons (but keep the hamburger menu button)
 	// Collect widgets to remove (skip first child which is hamburger button)
 	var toRemove []gtk.IWidget
 	i := 0
@@ -3368,20 +4249,20 @@ func updateLauncherToolbarButtons() {
 	toRemove = nil
 	runtime.GC()
 
-	// Add new dummy buttons
+	// Add the script buttons
 	for _, btn := range launcherRegisteredBtns {
 		button, _ := gtk.ButtonNew()
 		button.SetSizeRequest(32, 32)
 		button.SetTooltipText(btn.Tooltip)
 		applyToolbarButtonStyle(button, true) // true = vertical strip
 		// Set SVG icon with appropriate color for current theme
-		svgData := getSVGIcon(starIconSVG)
+		svgData := getSVGIcon(toolbarIconSVG(btn.Icon))
 		if img := createImageFromSVG(svgData, scaledToolbarIconSize()); img != nil {
 			button.SetImage(img)
 			button.SetAlwaysShowImage(true)
 		} else {
 			// Fallback to text if SVG loading fails
-			button.SetLabel(btn.Icon)
+			button.SetLabel("?")
 		}
 		if btn.OnClick != nil {
 			callback := btn.OnClick // Capture for closure
@@ -3435,7 +4316,7 @@ func updateWindowToolbarButtons(strip *gtk.Box, buttons []*ToolbarButton) {
 		return
 	}
 
-	// Remove existing dummy buttons (but keep the hamburger menu button as first child)
+	// Remove existing script buttons (but keep the hamburger menu button as first child)
 	var toRemove []gtk.IWidget
 	i := 0
 	strip.GetChildren().Foreach(func(item interface{}) {
@@ -3453,20 +4334,20 @@ func updateWindowToolbarButtons(strip *gtk.Box, buttons []*ToolbarButton) {
 	toRemove = nil
 	runtime.GC()
 
-	// Add new dummy buttons
+	// Add the script buttons
 	for _, btn := range buttons {
 		button, _ := gtk.ButtonNew()
 		button.SetSizeRequest(32, 32)
 		button.SetTooltipText(btn.Tooltip)
 		applyToolbarButtonStyle(button, true) // true = vertical strip
 		// Set SVG icon with appropriate color for current theme
-		svgData := getSVGIcon(starIconSVG)
+		svgData := getSVGIcon(toolbarIconSVG(btn.Icon))
 		if img := createImageFromSVG(svgData, scaledToolbarIconSize()); img != nil {
 			button.SetImage(img)
 			button.SetAlwaysShowImage(true)
 		} else {
 			// Fallback to text if SVG loading fails
-			button.SetLabel(btn.Icon)
+			button.SetLabel("?")
 		}
 		if btn.OnClick != nil {
 			callback := btn.OnClick // Capture for closure
@@ -3483,111 +4364,78 @@ func updateWindowToolbarButtons(strip *gtk.Box, buttons []*ToolbarButton) {
 	strip.Show()
 }
 
-// setDummyButtonsForWindow sets the number of dummy buttons for a specific window
-func setDummyButtonsForWindow(data *WindowToolbarData, count int) {
-	// Clear existing dummy buttons
-	data.registeredBtns = nil
-
-	// Add new dummy buttons
-	for i := 0; i < count; i++ {
-		icon := dummyIcons[i%len(dummyIcons)]
-		idx := i              // Capture for closure
-		term := data.terminal // Capture terminal for closure
-		btn := &ToolbarButton{
-			Icon:    icon,
-			Tooltip: fmt.Sprintf("Dummy Button %d", i+1),
-			OnClick: func() {
-				if term != nil {
-					term.Feed(fmt.Sprintf("\r\nDummy button %d clicked!\r\n", idx+1))
-				}
-			},
-		}
-		data.registeredBtns = append(data.registeredBtns, btn)
+// setScriptButtonsForWindow redraws a window's strip with the buttons its
+// scripts added
+func setScriptButtonsForWindow(data *WindowToolbarData) {
+	var registered []*ToolbarButton
+	for _, b := range data.scriptButtons.Buttons() {
+		registered = append(registered, &ToolbarButton{
+			Icon:    b.Icon,
+			Tooltip: b.Tooltip,
+			OnClick: b.Activate,
+		})
 	}
 
 	// Update the toolbar strip on GTK main thread
-	updateFunc := data.updateFunc
 	glib.IdleAdd(func() bool {
-		if updateFunc != nil {
-			updateFunc()
-		}
-		return false
-	})
-}
-
-// setDummyButtons sets the number of dummy buttons in the launcher toolbar strip (legacy)
-func setDummyButtons(count int) {
-	// Clear existing dummy buttons
-	launcherRegisteredBtns = nil
-
-	// Add new dummy buttons
-	for i := 0; i < count; i++ {
-		icon := dummyIcons[i%len(dummyIcons)]
-		idx := i // Capture for closure
-		btn := &ToolbarButton{
-			Icon:    icon,
-			Tooltip: fmt.Sprintf("Dummy Button %d", i+1),
-			OnClick: func() {
-				if terminal != nil {
-					terminal.Feed(fmt.Sprintf("\r\nDummy button %d clicked!\r\n", idx+1))
-				}
-			},
+		data.registeredBtns = registered
+		if data.updateFunc != nil {
+			data.updateFunc()
 		}
-		launcherRegisteredBtns = append(launcherRegisteredBtns, btn)
-	}
-
-	// Update the toolbar strip on GTK main thread
-	glib.IdleAdd(func() bool {
-		updateLauncherToolbarButtons()
 		return false
 	})
 }
 
-// registerDummyButtonCommand registers the dummy_button command with PawScript
+// registerToolbarButtonCommand registers the toolbar_button command with PawScript
 // using per-window toolbar data
-func registerDummyButtonCommand(ps *pawscript.PawScript, data *WindowToolbarData) {
+func registerToolbarButtonCommand(ps *pawscript.PawScript, data *WindowToolbarData) {
 	// Store the association
 	toolbarDataMu.Lock()
 	toolbarDataByPS[ps] = data
+	if data.scriptButtons == nil {
+		data.scriptButtons = pawgui.NewToolbarButtons(func() {
+			setScriptButtonsForWindow(data)
+		})
+	}
 	toolbarDataMu.Unlock()
 
-	ps.RegisterCommand("dummy_button", func(ctx *pawscript.Context) pawscript.Result {
-		if len(ctx.Args) < 1 {
-			ctx.LogError(pawscript.CatCommand, "dummy_button requires a count argument")
-			return pawscript.BoolStatus(false)
-		}
-
-		// Get the count argument
-		count := 0
-		switch v := ctx.Args[0].(type) {
-		case int:
-			count = v
-		case int64:
-			count = int(v)
-		case float64:
-			count = int(v)
-		default:
-			ctx.LogError(pawscript.CatCommand, "dummy_button requires a numeric argument")
-			return pawscript.BoolStatus(false)
-		}
+	pawgui.RegisterToolbarButtonCommand(ps, data.scriptButtons)
+}
+
+// hyperlinkSchemes are the URI schemes a Ctrl+clicked terminal link may open
+var hyperlinkSchemes = []string{"http://", "https://", "ftp://", "mailto:"}
 
-		if count < 0 {
-			count = 0
+// openHyperlink opens a terminal hyperlink with the system's default handler
+// Other schemes (file:, custom app handlers) are ignored, since scripts choose the URI.
+func openHyperlink(uri string) {
+	for _, scheme := range hyperlinkSchemes {
+		if !strings.HasPrefix(strings.ToLower(uri), scheme) {
+			continue
+		}
+		var cmd *exec.Cmd
+		switch runtime.GOOS {
+		case "darwin":
+			cmd = exec.Command("open", uri)
+		case "windows":
+			cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", uri)
+		default:
+			cmd = exec.Command("xdg-open", uri)
 		}
-		if count > 20 {
-			count = 20 // Cap at 20 buttons
+		if err := cmd.Start(); err == nil {
+			go cmd.Wait()
 		}
-
-		// Use the captured window data
-		setDummyButtonsForWindow(data, count)
-		ctx.SetResult(count)
-		return pawscript.BoolStatus(true)
-	})
+		return
+	}
 }
 
 // detectSystemDarkMode checks if the system is using a dark theme
 // Uses platform-specific detection methods for reliability
 func detectSystemDarkMode() bool {
+	// The OS's own setting, once followSystemTheme has read it
+	if dark, ok := pawgui.SystemDarkTheme(); ok {
+		return dark
+	}
+
 	// macOS: Use defaults command to read AppleInterfaceStyle
 	if runtime.GOOS == "darwin" {
 		cmd := exec.Command("defaults", "read", "-g", "AppleInterfaceStyle")
@@ -3795,101 +4643,19 @@ func applyMenuCSS(isDark bool) {
 	}
 }
 
-// parseShortcutGTK parses a shortcut string like "Cmd+Shift+Q" into GDK key and modifiers
-// Returns ok=false for empty strings (disabled shortcut)
-func parseShortcutGTK(shortcut string) (targetKey uint, targetMod gdk.ModifierType, ok bool) {
-	if shortcut == "" {
-		return 0, 0, false // Empty string = disabled
-	}
-	parts := strings.Split(shortcut, "+")
-	if len(parts) == 0 {
-		return 0, 0, false
-	}
-
-	// Last part is the key, everything before is modifiers
-	keyPart := parts[len(parts)-1]
-	modParts := parts[:len(parts)-1]
-
-	// Parse modifiers
-	for _, mod := range modParts {
-		switch strings.ToLower(strings.TrimSpace(mod)) {
-		case "cmd", "meta", "super":
-			targetMod |= gdk.META_MASK
-		case "ctrl", "control":
-			targetMod |= gdk.CONTROL_MASK
-		case "alt", "opt", "option":
-			targetMod |= gdk.MOD1_MASK
-		case "shift":
-			targetMod |= gdk.SHIFT_MASK
-		}
-	}
-
-	// Parse key
-	keyPart = strings.TrimSpace(keyPart)
-	switch strings.ToUpper(keyPart) {
-	case "F1":
-		targetKey = gdk.KEY_F1
-	case "F2":
-		targetKey = gdk.KEY_F2
-	case "F3":
-		targetKey = gdk.KEY_F3
-	case "F4":
-		targetKey = gdk.KEY_F4
-	case "F5":
-		targetKey = gdk.KEY_F5
-	case "F6":
-		targetKey = gdk.KEY_F6
-	case "F7":
-		targetKey = gdk.KEY_F7
-	case "F8":
-		targetKey = gdk.KEY_F8
-	case "F9":
-		targetKey = gdk.KEY_F9
-	case "F10":
-		targetKey = gdk.KEY_F10
-	case "F11":
-		targetKey = gdk.KEY_F11
-	case "F12":
-		targetKey = gdk.KEY_F12
-	case "ESCAPE", "ESC":
-		targetKey = gdk.KEY_Escape
-	case "TAB":
-		targetKey = gdk.KEY_Tab
-	case "RETURN", "ENTER":
-		targetKey = gdk.KEY_Return
-	case "SPACE":
-		targetKey = gdk.KEY_space
-	case "BACKSPACE":
-		targetKey = gdk.KEY_BackSpace
-	case "DELETE", "DEL":
-		targetKey = gdk.KEY_Delete
-	case "INSERT", "INS":
-		targetKey = gdk.KEY_Insert
-	case "HOME":
-		targetKey = gdk.KEY_Home
-	case "END":
-		targetKey = gdk.KEY_End
-	case "PAGEUP", "PGUP":
-		targetKey = gdk.KEY_Page_Up
-	case "PAGEDOWN", "PGDN":
-		targetKey = gdk.KEY_Page_Down
-	default:
-		// Single character key
-		if len(keyPart) == 1 {
-			targetKey = uint(gdk.KEY_a) + uint(strings.ToLower(keyPart)[0]-'a')
-		} else {
-			return 0, 0, false
-		}
-	}
-
-	return targetKey, targetMod, true
-}
-
 // setupShortcutsForWindow configures keyboard shortcuts (quit and close) for a window
 func setupShortcutsForWindow(win *gtk.ApplicationWindow) {
-	// Parse shortcuts
-	quitKey, quitMod, quitOk := parseShortcutGTK(getQuitShortcut())
-	closeKey, closeMod, closeOk := parseShortcutGTK(getCloseShortcut())
+	setupShortcuts(win, win.Close)
+}
+
+// setupShortcuts sets up the quit and close shortcuts for a window, the close
+// shortcut calling closeFn
+func setupShortcuts(win *gtk.ApplicationWindow, closeFn func()) {
+	// Shortcuts as shortcutFromKeyGTK writes them
+	quitShortcut, quitOk := pawgui.NormalizeShortcut(getQuitShortcut())
+	closeShortcut, closeOk := pawgui.NormalizeShortcut(getCloseShortcut())
+	quitOk = quitOk && quitShortcut != ""
+	closeOk = closeOk && closeShortcut != ""
 
 	if !quitOk && !closeOk {
 		return // No shortcuts configured
@@ -3904,26 +4670,18 @@ func setupShortcutsForWindow(win *gtk.ApplicationWindow) {
 		state := gdk.ModifierType(keyEvent.State())
 
 		// Mask out non-modifier bits (like num lock, caps lock)
-		state = state & (gdk.CONTROL_MASK | gdk.SHIFT_MASK | gdk.MOD1_MASK | gdk.META_MASK)
-
-		// Helper to check if a shortcut matches
-		matchesShortcut := func(targetKey uint, targetMod gdk.ModifierType) bool {
-			if targetKey >= uint(gdk.KEY_a) && targetKey <= uint(gdk.KEY_z) {
-				upperKey := targetKey - uint(gdk.KEY_a) + uint(gdk.KEY_A)
-				return (keyval == targetKey || keyval == upperKey) && state == targetMod
-			}
-			return keyval == targetKey && state == targetMod
-		}
+		state = state & (gdk.CONTROL_MASK | gdk.SHIFT_MASK | gdk.MOD1_MASK | gdk.META_MASK | gdk.SUPER_MASK)
+		shortcut := shortcutFromKeyGTK(keyval, state)
 
 		// Check quit shortcut
-		if quitOk && matchesShortcut(quitKey, quitMod) {
+		if quitOk && shortcut == quitShortcut {
 			win.Close()
 			return true
 		}
 
 		// Check close shortcut
-		if closeOk && matchesShortcut(closeKey, closeMod) {
-			win.Close()
+		if closeOk && shortcut == closeShortcut {
+			closeFn()
 			return true
 		}
 
@@ -3936,7 +4694,70 @@ func setupQuitShortcutForWindow(win *gtk.ApplicationWindow) {
 	setupShortcutsForWindow(win)
 }
 
-// setupQuitShortcut configures keyboard shortcuts for the main window
+// setupKeyMacros gives a terminal a key map holding the key macros and console
+// shortcuts from the config
+func setupKeyMacros(term *purfectermgtk.Terminal) {
+	keyMap, errs := configHelper.NewKeyMap()
+	errs = append(errs, configHelper.BindShortcuts(keyMap, consoleShortcuts(term))...)
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", getConfigPath(), err)
+	}
+	term.SetKeyMap(keyMap)
+}
+
+// setupWindowZoom gives a terminal the font zoom last set in windows of its type,
+// and saves the zoom the user sets in it for the next one
+func setupWindowZoom(term *purfectermgtk.Terminal, windowType string) {
+	term.SetZoom(configHelper.GetWindowZoom(windowType))
+	term.SetZoomCallback(func(zoom float64) {
+		if configHelper.SetWindowZoom(windowType, zoom) {
+			saveConfig(appConfig)
+		}
+	})
+}
+
+// setupRunSelection adds "Run Selection as PawScript" to the launcher terminal's
+// context menu, which gives the selected text to the console's REPL as a paste
+// followed by Enter, while the REPL is waiting for input
+func setupRunSelection(term *purfectermgtk.Terminal) {
+	replWaiting := func() bool {
+		scriptMu.Lock()
+		running := scriptRunning
+		scriptMu.Unlock()
+		return !running && consoleREPL != nil && consoleREPL.IsRunning() && !consoleREPL.IsBusy()
+	}
+	term.ContextMenuItems().Add(purfecterm.MenuItem{
+		Label: "Run Selection as PawScript",
+		Enabled: func() bool {
+			return term.GetSelectedText() != "" && replWaiting()
+		},
+		Activate: func() {
+			if text := term.GetSelectedText(); text != "" && replWaiting() {
+				// Bracketed, so a selection of several lines is one entry
+				consoleREPL.HandleInput([]byte("\x1b[200~" + text + "\x1b[201~\r"))
+			}
+		},
+	})
+}
+
+// setupBellUrgency sets the urgency hint on a window when its terminal rings the
+// bell while the window is in the background, so the taskbar flashes it, and clears
+// the hint when the window is focused
+func setupBellUrgency(win *gtk.ApplicationWindow, term *purfectermgtk.Terminal) {
+	term.SetBellCallback(func() {
+		glib.IdleAdd(func() {
+			if !win.IsActive() {
+				win.SetUrgencyHint(true)
+			}
+		})
+	})
+	win.Connect("focus-in-event", func() bool {
+		win.SetUrgencyHint(false)
+		return false
+	})
+}
+
+// setupQuitShortcut configures keyboard shortcuts for the main window
 func setupQuitShortcut() {
 	setupShortcutsForWindow(mainWindow)
 }
@@ -3992,14 +4813,17 @@ Options:
   -d, --debug         Enable debug output
   -v, --verbose       Enable verbose output (same as --debug)
   -O N                Set optimization level (0=no caching, 1=cache macro/loop bodies, default: 1)
-  --unrestricted      Disable all file/exec access restrictions
+  --unrestricted      Disable all file/exec/network access restrictions
   --sandbox DIR       Restrict all access to DIR only
   --read-roots DIRS   Additional directories for reading
   --write-roots DIRS  Additional directories for writing
   --exec-roots DIRS   Additional directories for exec command
+  --allow-hosts HOSTS Hosts scripts may connect to (default: none)
+  --deny-hosts HOSTS  Hosts scripts may never connect to
 
 GUI Options:
   --window            Create console window for stdout/stdin/stderr
+  --recent            Run the script the launcher ran last
 
 Arguments:
   script.paw          Script file to execute (adds .paw extension if needed)
@@ -4009,6 +4833,8 @@ Default Security Sandbox:
   Read:   SCRIPT_DIR, CWD, /tmp
   Write:  SCRIPT_DIR/saves, SCRIPT_DIR/output, CWD/saves, CWD/output, /tmp
   Exec:   SCRIPT_DIR/helpers, SCRIPT_DIR/bin
+  Net:    none (HOSTS are comma-separated names, *.domain wildcards or
+          CIDR ranges, each optionally with :port)
 
 Environment Variables (use SCRIPT_DIR as placeholder):
   PAW_READ_ROOTS      Override default read roots
@@ -4046,17 +4872,20 @@ func main() {
 	flag.BoolVar(verboseFlag, "v", false, "Enable verbose output (short, alias for -debug)")
 
 	// File access control flags
-	unrestrictedFlag := flag.Bool("unrestricted", false, "Disable all file/exec access restrictions")
+	unrestrictedFlag := flag.Bool("unrestricted", false, "Disable all file/exec/network access restrictions")
 	readRootsFlag := flag.String("read-roots", "", "Additional directories for file reading")
 	writeRootsFlag := flag.String("write-roots", "", "Additional directories for file writing")
 	execRootsFlag := flag.String("exec-roots", "", "Additional directories for exec command")
 	sandboxFlag := flag.String("sandbox", "", "Restrict all access to this directory only")
+	allowHostsFlag := flag.String("allow-hosts", "", "Hosts scripts may connect to (names, *.domain, CIDR)")
+	denyHostsFlag := flag.String("deny-hosts", "", "Hosts scripts may never connect to")
 
 	// Optimization level flag
 	optLevelFlag := flag.Int("O", 1, "Optimization level (0=no caching, 1=cache macro/loop bodies)")
 
 	// GUI-specific flags
 	windowFlag := flag.Bool("window", false, "Create console window for stdout/stdin/stderr")
+	recentFlag := flag.Bool("recent", false, "Run the script the launcher ran last")
 
 	// Custom usage function
 	flag.Usage = showUsage
@@ -4106,6 +4935,16 @@ func main() {
 	stdinInfo, _ := os.Stdin.Stat()
 	isStdinRedirected := (stdinInfo.Mode() & os.ModeCharDevice) == 0
 
+	// --recent runs the script the launcher ran last, with any arguments given
+	if *recentFlag {
+		recent := mostRecentScript()
+		if recent == "" {
+			fmt.Fprintln(os.Stderr, "Error: The launcher hasn't run any scripts yet")
+			os.Exit(1)
+		}
+		fileArgs = append([]string{recent}, fileArgs...)
+	}
+
 	if len(fileArgs) > 0 {
 		// Filename provided
 		requestedFile := fileArgs[0]
@@ -4146,7 +4985,7 @@ func main() {
 	// If we have script content (from file or stdin), run it
 	if scriptContent != "" {
 		runScriptFromCLI(scriptContent, scriptFile, scriptArgs, *windowFlag, *unrestrictedFlag,
-			*sandboxFlag, *readRootsFlag, *writeRootsFlag, *execRootsFlag, *optLevelFlag)
+			*sandboxFlag, *readRootsFlag, *writeRootsFlag, *execRootsFlag, *allowHostsFlag, *denyHostsFlag, *optLevelFlag)
 		return
 	}
 
@@ -4166,7 +5005,7 @@ func main() {
 
 // runScriptFromCLI executes a script with the given options (from command line)
 func runScriptFromCLI(scriptContent, scriptFile string, scriptArgs []string, windowFlag bool,
-	unrestricted bool, sandbox, readRoots, writeRoots, execRoots string, optLevel int) {
+	unrestricted bool, sandbox, readRoots, writeRoots, execRoots, allowHosts, denyHosts string, optLevel int) {
 
 	// Build file access configuration
 	var fileAccess *pawscript.FileAccessConfig
@@ -4282,6 +5121,22 @@ func runScriptFromCLI(scriptContent, scriptFile string, scriptArgs []string, win
 				fileAccess.ExecRoots = append(fileAccess.ExecRoots, parseRoots(execRoots)...)
 			}
 		}
+
+		// Network access: none unless --allow-hosts names the endpoints
+		var err error
+		fileAccess.AllowedHosts = []string{}
+		if allowHosts != "" {
+			if fileAccess.AllowedHosts, err = pawscript.ParseHostPatterns(allowHosts); err != nil {
+				fmt.Fprintf(os.Stderr, "Error in --allow-hosts: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if denyHosts != "" {
+			if fileAccess.DeniedHosts, err = pawscript.ParseHostPatterns(denyHosts); err != nil {
+				fmt.Fprintf(os.Stderr, "Error in --deny-hosts: %v\n", err)
+				os.Exit(1)
+			}
+		}
 	}
 
 	if !windowFlag {
@@ -4324,6 +5179,8 @@ func runScriptFromCLI(scriptContent, scriptFile string, scriptArgs []string, win
 		if configHelper.PopulateDefaults() {
 			saveConfig(appConfig)
 		}
+		applyLanguage()
+		followSystemTheme()
 		applyTheme(configHelper.GetTheme())
 
 		// Create console window and run script
@@ -4344,6 +5201,9 @@ func runScriptInWindow(gtkApp *gtk.Application, scriptContent, scriptFile string
 		return
 	}
 
+	// Let the background opacity show through (see purfectermgtk.UseRGBAVisual)
+	purfectermgtk.UseRGBAVisual(&win.Window)
+
 	title := "PawScript Console"
 	if scriptFile != "" {
 		title = filepath.Base(scriptFile) + " - PawScript"
@@ -4358,7 +5218,7 @@ func runScriptInWindow(gtkApp *gtk.Application, scriptContent, scriptFile string
 	winTerminal, err := purfectermgtk.New(purfectermgtk.Options{
 		Cols:           100,
 		Rows:           30,
-		ScrollbackSize: 10000,
+		ScrollbackSize: getScrollbackLines(),
 		FontFamily:     getFontFamily(),
 		FontSize:       getFontSize(),
 		Scheme:         getDualColorScheme(),
@@ -4372,6 +5232,13 @@ func runScriptInWindow(gtkApp *gtk.Application, scriptContent, scriptFile string
 	// Set font fallbacks
 	winTerminal.SetFontFallbacks(getFontFamilyUnicode(), getFontFamilyCJK())
 
+	// Key macros from the config (scripts can add more with key_macro)
+	setupKeyMacros(winTerminal)
+	setupWindowZoom(winTerminal, "script")
+
+	// Flash the window in the taskbar when a background script rings the bell
+	setupBellUrgency(win, winTerminal)
+
 	// Set up terminal theme from config
 	prefersDark := isTermThemeDark()
 	winTerminal.Buffer().SetPreferredDarkTheme(prefersDark)
@@ -4387,14 +5254,30 @@ func runScriptInWindow(gtkApp *gtk.Application, scriptContent, scriptFile string
 	// Create main layout with collapsible toolbar strip
 	paned, _ := gtk.PanedNew(gtk.ORIENTATION_HORIZONTAL)
 
+	// The running script, for Stop Script
+	var winRun *pawgui.ScriptRun
+	var winRunMu sync.Mutex
+
 	// Create MenuContext for this window
 	menuCtx := &MenuContext{
 		Parent:         win,
 		IsScriptWindow: true,
 		Terminal:       winTerminal,
+		IsScriptRunning: func() bool {
+			winRunMu.Lock()
+			defer winRunMu.Unlock()
+			return winRun != nil
+		},
+		StopScript: func() {
+			winRunMu.Lock()
+			run := winRun
+			winRunMu.Unlock()
+			stopScript(run)
+		},
 		CloseWindow: func() {
 			win.Close()
 		},
+		ScriptMenus: pawgui.NewScriptMenus(),
 	}
 
 	// Narrow strip for script window (always starts visible, collapsible)
@@ -4411,6 +5294,12 @@ func runScriptInWindow(gtkApp *gtk.Application, scriptContent, scriptFile string
 		menuButton: stripMenuBtn,
 		terminal:   winTerminal,
 	}
+	runScriptToolbarData.updateFunc = func() {
+		updateWindowToolbarButtons(runScriptToolbarData.strip, runScriptToolbarData.registeredBtns)
+	}
+	runScriptToolbarData.scriptButtons = pawgui.NewToolbarButtons(func() {
+		setScriptButtonsForWindow(runScriptToolbarData)
+	})
 	// Use a unique key (nil is fine since there's no PawScript instance)
 	// Use the window pointer as a unique identifier
 	toolbarDataByWindow[win] = runScriptToolbarData
@@ -4421,7 +5310,13 @@ func runScriptInWindow(gtkApp *gtk.Application, scriptContent, scriptFile string
 	termWidget.SetVExpand(true)
 	termWidget.SetHExpand(true)
 	termWidget.SetMarginStart(8) // Spacing from splitter
-	paned.Pack2(termWidget, true, false)
+
+	// Status bar under the terminal, set by scripts with status_set
+	winStatus := newStatusBar()
+	termBox, _ := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 0)
+	termBox.PackStart(termWidget, true, true, 0)
+	termBox.PackStart(winStatus.box, false, false, 0)
+	paned.Pack2(termBox, true, false)
 
 	// Set initial strip width and collapse behavior
 	// Script windows only have two positions: 0 (collapsed) or visible (with extra padding)
@@ -4516,66 +5411,37 @@ func runScriptInWindow(gtkApp *gtk.Application, scriptContent, scriptFile string
 	go func() {
 		for item := range outputQueue {
 			switch v := item.(type) {
-			case []byte:
-				stdoutWriter.Write(v)
 			case chan struct{}:
 				close(v)
+			default:
+				stdoutWriter.Write(pawscript.ConsoleBytes(v))
 			}
 		}
 	}()
 
-	winOutCh := &pawscript.StoredChannel{
-		BufferSize:       0,
-		Messages:         make([]pawscript.ChannelMessage, 0),
-		Subscribers:      make(map[int]*pawscript.StoredChannel),
-		NextSubscriberID: 1,
-		IsClosed:         false,
-		Timestamp:        time.Now(),
-		Terminal:         termCaps,
-		NativeSend: func(v interface{}) error {
-			var text string
-			switch d := v.(type) {
-			case []byte:
-				text = string(d)
-			case string:
-				text = d
-			default:
-				text = fmt.Sprintf("%v", v)
-			}
-			text = strings.ReplaceAll(text, "\r\n", "\n")
-			text = strings.ReplaceAll(text, "\n", "\r\n")
-			data := []byte(text)
-			select {
-			case outputQueue <- data:
-			default:
-			}
-			return nil
-		},
-		NativeRecv: func() (interface{}, error) {
-			return nil, fmt.Errorf("cannot receive from console_out")
-		},
-		NativeFlush: func() error {
-			writerDone := make(chan struct{})
-			select {
-			case outputQueue <- writerDone:
-				<-writerDone
-			default:
-			}
-			glibDone := make(chan struct{})
-			glib.IdleAdd(func() bool {
-				close(glibDone)
-				return false
-			})
-			select {
-			case <-glibDone:
-			case <-time.After(100 * time.Millisecond):
-			}
-			return nil
-		},
+	winOutCh := pawscript.NewChannelFromGo(outputQueue, nil, termCaps)
+	winOutCh.Overflow = pawscript.OverflowDropNewest // a stalled GUI drops output rather than blocking the script
+	winOutCh.NativeFlush = func() error {
+		writerDone := make(chan struct{})
+		select {
+		case outputQueue <- writerDone:
+			<-writerDone
+		default:
+		}
+		glibDone := make(chan struct{})
+		glib.IdleAdd(func() bool {
+			close(glibDone)
+			return false
+		})
+		select {
+		case <-glibDone:
+		case <-time.After(100 * time.Millisecond):
+		}
+		return nil
 	}
 
 	// Non-blocking input queue
-	inputQueue := make(chan byte, 256)
+	inputQueue := make(chan interface{}, 256)
 	go func() {
 		buf := make([]byte, 1)
 		for {
@@ -4585,39 +5451,21 @@ func runScriptInWindow(gtkApp *gtk.Application, scriptContent, scriptFile string
 				return
 			}
 			select {
-			case inputQueue <- buf[0]:
+			case inputQueue <- []byte{buf[0]}:
 			default:
 				select {
 				case <-inputQueue:
 				default:
 				}
 				select {
-				case inputQueue <- buf[0]:
+				case inputQueue <- []byte{buf[0]}:
 				default:
 				}
 			}
 		}
 	}()
 
-	winInCh := &pawscript.StoredChannel{
-		BufferSize:       0,
-		Messages:         make([]pawscript.ChannelMessage, 0),
-		Subscribers:      make(map[int]*pawscript.StoredChannel),
-		NextSubscriberID: 1,
-		IsClosed:         false,
-		Timestamp:        time.Now(),
-		Terminal:         termCaps,
-		NativeRecv: func() (interface{}, error) {
-			b, ok := <-inputQueue
-			if !ok {
-				return nil, fmt.Errorf("input closed")
-			}
-			return []byte{b}, nil
-		},
-		NativeSend: func(v interface{}) error {
-			return fmt.Errorf("cannot send to console_in")
-		},
-	}
+	winInCh := pawscript.NewChannelFromGo(nil, inputQueue, termCaps)
 
 	// Read from stdout pipe and feed to terminal
 	go func() {
@@ -4648,6 +5496,7 @@ func runScriptInWindow(gtkApp *gtk.Application, scriptContent, scriptFile string
 		FileAccess:           fileAccess,
 		OptLevel:             pawscript.OptimizationLevel(optLevel),
 		ScriptDir:            scriptDir,
+		Dialogs:              guiDialogs{},
 	})
 
 	// Register standard library with console channels
@@ -4657,6 +5506,18 @@ func runScriptInWindow(gtkApp *gtk.Application, scriptContent, scriptFile string
 		Stderr: winOutCh,
 	}
 	ps.RegisterStandardLibraryWithIO(scriptArgs, ioConfig)
+	pawgui.RegisterKeyMacroCommand(ps, winTerminal.KeyMap())
+	pawgui.RegisterMenuItemCommand(ps, winTerminal.ContextMenuItems())
+	pawgui.RegisterToolbarButtonCommand(ps, runScriptToolbarData.scriptButtons)
+	pawgui.RegisterMenuRegisterCommand(ps, menuCtx.ScriptMenus)
+	pawgui.RegisterWidgetCommands(ps, scriptWidgets)
+	pawgui.RegisterNotifyCommand(ps)
+	pawgui.RegisterTraySetCommand(ps, launcherTray)
+	pawgui.RegisterStatusSetCommand(ps, winStatus.status)
+	pawgui.RegisterProgressCommand(ps, winStatus.status)
+
+	// Ctrl+click on a hyperlink opens it
+	winTerminal.SetLinkClickCallback(openHyperlink)
 
 	// Handle terminal input
 	winTerminal.SetInputCallback(func(data []byte) {
@@ -4673,6 +5534,28 @@ func runScriptInWindow(gtkApp *gtk.Application, scriptContent, scriptFile string
 		gtkApp.Quit()
 	})
 
+	endRunLog := startRunLog(winTerminal, scriptFile)
+
+	// Stop Script cancels the script, killing it if it won't stop
+	scriptEnded := func(message string) {
+		if winOutCh.NativeFlush != nil {
+			winOutCh.NativeFlush()
+		}
+		winTerminal.Feed(message)
+		endRunLog()
+		menuCtx.ScriptMenus.Clear() // The script's menu entries and status end with it
+		winStatus.status.Clear()
+		winRunMu.Lock()
+		winRun = nil
+		winRunMu.Unlock()
+	}
+	run := pawgui.NewScriptRun(ps, func() {
+		scriptEnded("\r\n[Script killed]\r\n")
+	})
+	winRunMu.Lock()
+	winRun = run
+	winRunMu.Unlock()
+
 	// Run script in goroutine
 	go func() {
 		time.Sleep(100 * time.Millisecond) // Let window initialize
@@ -4684,14 +5567,15 @@ func runScriptInWindow(gtkApp *gtk.Application, scriptContent, scriptFile string
 			result = ps.Execute(scriptContent)
 		}
 
-		if winOutCh.NativeFlush != nil {
-			winOutCh.NativeFlush()
+		if !run.End() {
+			return // Killed, and cleaned up after
 		}
-
-		if result == pawscript.BoolStatus(false) {
-			winTerminal.Feed("\r\n[Script execution failed]\r\n")
+		if run.Stopped() {
+			scriptEnded("\r\n[Script stopped]\r\n")
+		} else if result == pawscript.BoolStatus(false) {
+			scriptEnded("\r\n[Script execution failed]\r\n")
 		} else {
-			winTerminal.Feed("\r\n[Script completed]\r\n")
+			scriptEnded("\r\n[Script completed]\r\n")
 		}
 
 		// Don't auto-close - let user see output and close manually
@@ -4711,9 +5595,26 @@ func activate(application *gtk.Application) {
 		saveConfig(appConfig)
 	}
 
-	// Apply theme setting
+	// Follow edits made to the config file while the launcher runs
+	if configPath := getConfigPath(); configPath != "" {
+		_, err := appConfig.Watch(configPath, func(config pawscript.PSLConfig) {
+			glib.IdleAdd(func() { reloadConfig(config) })
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: not watching for changes: %v\n", configPath, err)
+		}
+	}
+
+	// Show text in the language the settings pick
+	applyLanguage()
+
+	// Apply theme setting, following the OS's where it's auto
+	followSystemTheme()
 	applyTheme(configHelper.GetTheme())
 
+	// Reopen the project that was open when the launcher quit
+	restoreProject()
+
 	// Create main window
 	var err error
 	mainWindow, err = gtk.ApplicationWindowNew(app)
@@ -4721,6 +5622,9 @@ func activate(application *gtk.Application) {
 		fmt.Fprintf(os.Stderr, "Failed to create window: %v\n", err)
 		return
 	}
+
+	// Let the background opacity show through (see purfectermgtk.UseRGBAVisual)
+	purfectermgtk.UseRGBAVisual(&mainWindow.Window)
 	mainWindow.SetTitle(appName)
 
 	// Get screen dimensions for bounds checking
@@ -4789,6 +5693,15 @@ func activate(application *gtk.Application) {
 		return false // Continue event propagation
 	})
 
+	// Hide in the system tray when minimized, if minimize_to_tray is set
+	mainWindow.Connect("window-state-event", hideWhenMinimized)
+
+	// Save the session if closing the launcher ends it
+	mainWindow.Connect("delete-event", saveSessionIfLast)
+
+	// Dropped scripts run and dropped folders open (see launcherdrop.go)
+	setupLauncherDrop(mainWindow)
+
 	// Apply CSS for UI scaling (base size 10px, scaled by ui_scale config)
 	// GTK uses 0.8x the config scale to match visual appearance with Qt
 	uiScale := getUIScale() * 0.8
@@ -4833,11 +5746,18 @@ func activate(application *gtk.Application) {
 		Parent:         mainWindow,
 		IsScriptWindow: false,
 		Terminal:       terminal, // Main launcher terminal
+		ScriptMenus:    pawgui.NewScriptMenus(),
 		IsScriptRunning: func() bool {
 			scriptMu.Lock()
 			defer scriptMu.Unlock()
 			return scriptRunning
 		},
+		StopScript: func() {
+			scriptMu.Lock()
+			run := launcherRun
+			scriptMu.Unlock()
+			stopScript(run)
+		},
 		IsFileListWide: func() bool {
 			// Wide if position >= bothThreshold (file list panel visible)
 			return launcherPaned.GetPosition() >= scaledBothThreshold()
@@ -4881,6 +5801,14 @@ func activate(application *gtk.Application) {
 				}
 			}
 		},
+		IsEditorShown: func() bool {
+			return launcherEditor != nil && launcherEditor.shown()
+		},
+		ToggleEditor: func() {
+			if launcherEditor != nil {
+				launcherEditor.setShown(!launcherEditor.shown())
+			}
+		},
 		CloseWindow: func() {
 			mainWindow.Close()
 		},
@@ -4908,10 +5836,21 @@ func activate(application *gtk.Application) {
 	// Pack1(widget, resize, shrink): resize=false (fixed), shrink=true (can collapse)
 	launcherPaned.Pack1(leftContainer, false, true)
 
-	// Right panel: Terminal (with left margin for spacing from divider)
+	// Right panel: Terminal (with left margin for spacing from divider), under the
+	// script editor when that is shown, and beside the debugger while a script is
+	// paused
 	rightPanel := createTerminal()
 	rightPanel.SetMarginStart(8) // 8 pixel spacer from divider
-	launcherPaned.Pack2(rightPanel, true, false)
+	launcherDebugger = newLauncherDebugger()
+	launcherDebugPanel = newDebuggerPanel()
+	launcherDebugPaned, _ = gtk.PanedNew(gtk.ORIENTATION_HORIZONTAL)
+	launcherDebugPaned.Pack1(rightPanel, true, false)
+	launcherDebugPaned.Pack2(launcherDebugPanel.box, false, true)
+	launcherEditor = newScriptEditor()
+	launcherEditorPaned, _ = gtk.PanedNew(gtk.ORIENTATION_VERTICAL)
+	launcherEditorPaned.Pack1(launcherEditor.box, true, true)
+	launcherEditorPaned.Pack2(launcherDebugPaned, true, false)
+	launcherPaned.Pack2(launcherEditorPaned, true, false)
 
 	// Update launcher menu context with the terminal (needed for Save Scrollback/Restore Buffer)
 	if launcherMenuCtx != nil {
@@ -5132,8 +6071,8 @@ func activate(application *gtk.Application) {
 	terminal.Feed(fmt.Sprintf("pawgui-gtk, the PawScript GUI interpreter version %s (with GTK)\r\n", version))
 	terminal.Feed("Copyright (c) 2025 Jeffrey R. Day\r\n")
 	terminal.Feed("License: MIT\r\n\r\n")
-	terminal.Feed("Interactive mode. Type 'exit' or 'quit' to leave.\r\n")
-	terminal.Feed("Select a .paw file and click Run to execute.\r\n\r\n")
+	terminal.Feed(tr("Interactive mode. Type 'exit' or 'quit' to leave.") + "\r\n")
+	terminal.Feed(tr("Select a .paw file and click Run to execute.") + "\r\n\r\n")
 
 	mainWindow.ShowAll()
 
@@ -5177,6 +6116,20 @@ func activate(application *gtk.Application) {
 
 	// Focus the Run button
 	runButton.GrabFocus()
+
+	// Reopen the script editor if it was showing when the launcher last closed
+	if getLauncherEditorShown() {
+		launcherEditor.setShown(true)
+	}
+
+	// Put the launcher's icon in the system tray
+	startTray()
+
+	// Run the scheduled tasks as they fall due
+	startScheduler()
+
+	// Offer to open the windows of the last session again
+	offerSessionRestore()
 }
 
 func getDefaultDir() string {
@@ -5244,6 +6197,9 @@ func createFileBrowser() *gtk.Box {
 
 	box.PackStart(topRow, false, true, 0)
 
+	// Filter box (see filefilter.go)
+	box.PackStart(createFileFilter(), false, true, 0)
+
 	// Scrolled window for file list
 	scroll, _ := gtk.ScrolledWindowNew(nil, nil)
 	scroll.SetPolicy(gtk.POLICY_AUTOMATIC, gtk.POLICY_AUTOMATIC)
@@ -5255,18 +6211,20 @@ func createFileBrowser() *gtk.Box {
 	fileList.SetActivateOnSingleClick(false)
 	fileList.Connect("row-activated", onFileActivated)
 	fileList.Connect("row-selected", onRowSelected)
+	fileList.SetFilterFunc(filterFileRow)
+	setupFileListMenu()
 	scroll.Add(fileList)
 	box.PackStart(scroll, true, true, 0)
 
 	// Button box
 	buttonBox, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 5)
 
-	runButton, _ = gtk.ButtonNewWithLabel("Run")
+	runButton, _ = gtk.ButtonNewWithLabel(tr("Run"))
 	runButton.Connect("clicked", onRunClicked)
 	runButton.SetHExpand(true)
 	buttonBox.PackStart(runButton, true, true, 0)
 
-	browseButton, _ := gtk.ButtonNewWithLabel("Browse...")
+	browseButton, _ := gtk.ButtonNewWithLabel(tr("Browse..."))
 	browseButton.Connect("clicked", onBrowseClicked)
 	browseButton.SetHExpand(true)
 	buttonBox.PackStart(browseButton, true, true, 0)
@@ -5284,7 +6242,7 @@ func createTerminal() *gtk.Box {
 	terminal, err = purfectermgtk.New(purfectermgtk.Options{
 		Cols:           100,
 		Rows:           30,
-		ScrollbackSize: 10000,
+		ScrollbackSize: getScrollbackLines(),
 		FontFamily:     getFontFamily(),
 		FontSize:       getFontSize(),
 		Scheme:         getDualColorScheme(),
@@ -5292,7 +6250,7 @@ func createTerminal() *gtk.Box {
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to create terminal: %v\n", err)
 		// Create a placeholder label
-		errLabel, _ := gtk.LabelNew(fmt.Sprintf("Terminal creation failed: %v", err))
+		errLabel, _ := gtk.LabelNew(fmt.Sprintf(tr("Terminal creation failed: %v"), err))
 		box.PackStart(errLabel, true, true, 0)
 		return box
 	}
@@ -5300,6 +6258,11 @@ func createTerminal() *gtk.Box {
 	// Set font fallbacks for Unicode/CJK characters
 	terminal.SetFontFallbacks(getFontFamilyUnicode(), getFontFamilyCJK())
 
+	// Key macros from the config (scripts can add more with key_macro)
+	setupKeyMacros(terminal)
+	setupWindowZoom(terminal, "launcher")
+	setupRunSelection(terminal)
+
 	// Set up terminal theme from config
 	prefersDark := isTermThemeDark()
 	terminal.Buffer().SetPreferredDarkTheme(prefersDark)
@@ -5323,6 +6286,7 @@ func createTerminal() *gtk.Box {
 		btn := gdk.EventButtonNewFromEvent(ev)
 		if btn.Button() == 3 { // Right mouse button
 			if contextMenu != nil {
+				terminal.PrepareContextMenu(contextMenu, createMenuItemWithGutter)
 				contextMenu.PopupAtPointer(ev)
 			}
 			return true
@@ -5419,6 +6383,9 @@ func updatePathMenu() {
 		})
 	}
 
+	// Add recent scripts (see recentscripts.go)
+	appendRecentScripts(pathMenu)
+
 	pathMenu.ShowAll()
 }
 
@@ -5430,6 +6397,7 @@ func refreshFileList() {
 	// Clear icon type map and reset previous selected row
 	rowIconTypeMap = make(map[*gtk.ListBoxRow]gtkIconType)
 	previousSelectedRow = nil
+	fileListDescriptions = make(map[string]string)
 
 	// Safely remove all existing items
 	safeRemoveChildren(fileList)
@@ -5437,10 +6405,17 @@ func refreshFileList() {
 	// Read directory
 	entries, err := os.ReadDir(currentDir)
 	if err != nil {
-		terminal.Feed(fmt.Sprintf("Error reading directory: %v\r\n", err))
+		terminal.Feed(fmt.Sprintf(tr("Error reading directory: %v\r\n"), err))
 		return
 	}
 
+	// The project's pinned scripts head the list, wherever it is
+	if currentProject != nil {
+		for _, script := range currentProject.PinnedScripts() {
+			fileList.Add(createFileRow(script, false, false))
+		}
+	}
+
 	// Add parent directory entry
 	if currentDir != "/" {
 		row := createFileRow("..", true, true)
@@ -5498,11 +6473,17 @@ func createFileRow(name string, isDir bool, isParent bool) *gtk.ListBoxRow {
 		box.PackStart(icon, false, false, 0)
 	}
 
-	// Name label
-	label, _ := gtk.LabelNew(name)
+	// Name label, with the script's details under it
+	nameBox, _ := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 0)
+	nameBox.SetHExpand(true)
+	nameBox.SetVAlign(gtk.ALIGN_CENTER)
+	label, _ := gtk.LabelNew(fileRowLabel(name))
 	label.SetXAlign(0)
-	label.SetHExpand(true)
-	box.PackStart(label, true, true, 0)
+	nameBox.PackStart(label, false, false, 0)
+	if !isDir {
+		addFileDetails(row, nameBox, fileRowPath(name), name)
+	}
+	box.PackStart(nameBox, true, true, 0)
 
 	row.Add(box)
 	row.SetName(name)
@@ -5533,31 +6514,31 @@ func onRowSelected(listbox *gtk.ListBox, row *gtk.ListBoxRow) {
 		return
 	}
 	name, _ := row.GetName()
-	fullPath := filepath.Join(currentDir, name)
+	fullPath := fileRowPath(name)
 
 	// Check if it's a directory (including ".." parent)
 	if name == ".." {
-		runButton.SetLabel("Open")
+		runButton.SetLabel(tr("Open"))
 		return
 	}
 
 	info, err := os.Stat(fullPath)
 	if err != nil {
-		runButton.SetLabel("Run")
+		runButton.SetLabel(tr("Run"))
 		return
 	}
 
 	if info.IsDir() {
-		runButton.SetLabel("Open")
+		runButton.SetLabel(tr("Open"))
 	} else {
-		runButton.SetLabel("Run")
+		runButton.SetLabel(tr("Run"))
 	}
 }
 
 func onRunClicked() {
 	row := fileList.GetSelectedRow()
 	if row == nil {
-		terminal.Feed("No file selected.\r\n")
+		terminal.Feed(tr("No file selected.") + "\r\n")
 		return
 	}
 
@@ -5566,11 +6547,11 @@ func onRunClicked() {
 }
 
 func handleFileSelection(name string) {
-	fullPath := filepath.Join(currentDir, name)
+	fullPath := fileRowPath(name)
 
 	info, err := os.Stat(fullPath)
 	if err != nil {
-		terminal.Feed(fmt.Sprintf("Error: %v\r\n", err))
+		terminal.Feed(fmt.Sprintf(tr("Error: %v\r\n"), err))
 		return
 	}
 
@@ -5594,7 +6575,7 @@ func handleFileSelection(name string) {
 func onBrowseClicked() {
 	// Use sqweek/dialog for native file open dialog
 	file, err := dialog.File().
-		Title("Open PawScript File").
+		Title(tr("Open PawScript File")).
 		Filter("PawScript files", "paw").
 		Filter("All files", "*").
 		SetStartDir(currentDir).
@@ -5611,14 +6592,22 @@ func onBrowseClicked() {
 }
 
 func runScript(filePath string) {
+	// Its launch profile (see launchprofile.go) may give it a window of its own
+	profile, profileErr := configHelper.GetLaunchProfile(filePath)
+	if profile.OwnWindow() {
+		openScriptTab(filePath)
+		return
+	}
+
 	scriptMu.Lock()
 	if scriptRunning {
 		scriptMu.Unlock()
-		// Script already running in main window - spawn a new console window
-		createConsoleWindow(filePath)
+		// Script already running in main window - run it in a console tab
+		openScriptTab(filePath)
 		return
 	}
 	scriptRunning = true
+	launcherScript, _ = filepath.Abs(filePath)
 	scriptMu.Unlock()
 
 	// Stop the REPL while script runs
@@ -5626,7 +6615,11 @@ func runScript(filePath string) {
 		consoleREPL.Stop()
 	}
 
+	endRunLog := startRunLog(terminal, filePath)
 	terminal.Feed(fmt.Sprintf("\r\n--- Running: %s ---\r\n\r\n", filepath.Base(filePath)))
+	if profileErr != nil {
+		terminal.Feed(fmt.Sprintf("%v\r\n", profileErr))
+	}
 
 	// Clear any buffered input from previous script runs
 	if clearInputFunc != nil {
@@ -5636,7 +6629,8 @@ func runScript(filePath string) {
 	// Read script content
 	content, err := os.ReadFile(filePath)
 	if err != nil {
-		terminal.Feed(fmt.Sprintf("Error reading script file: %v\r\n", err))
+		terminal.Feed(fmt.Sprintf(tr("Error reading script file: %v\r\n"), err))
+		endRunLog()
 		scriptMu.Lock()
 		scriptRunning = false
 		scriptMu.Unlock()
@@ -5652,6 +6646,9 @@ func runScript(filePath string) {
 	// Add the script's directory to recent paths for the combo box
 	addRecentPath(scriptDir)
 
+	// Remember the script for the Recent Scripts menus
+	recordScriptRun(absScript)
+
 	// Create file access config
 	cwd, _ := os.Getwd()
 	tmpDir := os.TempDir()
@@ -5660,6 +6657,9 @@ func runScript(filePath string) {
 		WriteRoots: []string{filepath.Join(scriptDir, "saves"), filepath.Join(scriptDir, "output"), filepath.Join(cwd, "saves"), filepath.Join(cwd, "output"), tmpDir},
 		ExecRoots:  []string{filepath.Join(scriptDir, "helpers"), filepath.Join(scriptDir, "bin")},
 	}
+	configHelper.ApplyGrantedRoots(fileAccess)
+	profile.ApplyRoots(fileAccess, scriptDir)
+	applyProjectRoots(fileAccess, absScript)
 
 	// Create a new PawScript instance for this script
 	ps := pawscript.New(&pawscript.Config{
@@ -5669,116 +6669,226 @@ func runScript(filePath string) {
 		ShowErrorContext:     true,
 		ContextLines:         2,
 		FileAccess:           fileAccess,
+		Permissions:          getLauncherPermissions(absScript),
+		Quotas:               getLauncherQuotas(),
+		EnvAllowlist:         pawscript.DefaultEnvAllowlist,
+		PermissionPrompt:     promptPermission,
 		ScriptDir:            scriptDir,
-		OptLevel:             pawscript.OptimizationLevel(getOptimizationLevel()),
+		OptLevel:             pawscript.OptimizationLevel(profile.OptimizationLevel(getOptimizationLevel())),
+		Debugger:             launcherDebugger,
+		Dialogs:              guiDialogs{},
 	})
 
-	// Register standard library with the console IO
-	ioConfig := &pawscript.IOChannelConfig{
+	// Stop Script cancels it, killing it if it won't stop
+	run := pawgui.NewScriptRun(ps, func() {
+		if flushFunc != nil {
+			flushFunc()
+		}
+		terminal.Feed("\r\n--- Script killed ---\r\n")
+		endRunLog()
+		recordScriptEnd(absScript, pawgui.RunStopped)
+		launcherMenuCtx.ScriptMenus.Clear()
+		launcherScriptEnded()
+	})
+	scriptMu.Lock()
+	launcherRun = run
+	scriptMu.Unlock()
+
+	// Register standard library with the console IO, which a killed script gives up
+	ioConfig := run.IO(&pawscript.IOChannelConfig{
 		Stdout: consoleOutCh,
 		Stdin:  consoleInCh,
 		Stderr: consoleOutCh,
+	})
+	ps.RegisterStandardLibraryWithIO(profile.Args, ioConfig)
+	pawgui.RegisterKeyMacroCommand(ps, terminal.KeyMap())
+	pawgui.RegisterMenuItemCommand(ps, terminal.ContextMenuItems())
+	if launcherToolbarData != nil {
+		pawgui.RegisterToolbarButtonCommand(ps, launcherToolbarData.scriptButtons)
 	}
-	ps.RegisterStandardLibraryWithIO([]string{}, ioConfig)
+	pawgui.RegisterMenuRegisterCommand(ps, launcherMenuCtx.ScriptMenus)
+	pawgui.RegisterWidgetCommands(ps, scriptWidgets)
+	pawgui.RegisterNotifyCommand(ps)
+	pawgui.RegisterTraySetCommand(ps, launcherTray)
 
 	// Run script in goroutine so UI stays responsive
 	go func() {
-		// Create an isolated snapshot for execution
-		snapshot := ps.CreateRestrictedSnapshot()
+		ok := false
+		for {
+			// Create an isolated snapshot for execution
+			snapshot := ps.CreateRestrictedSnapshot()
 
-		// Run the script in the isolated environment
-		result := ps.ExecuteWithEnvironment(string(content), snapshot, filePath, 0, 0)
+			// Run the script in the isolated environment
+			result := ps.ExecuteWithEnvironment(string(content), snapshot, filePath, 0, 0)
+			ok = result != pawscript.BoolStatus(false)
 
-		// Flush any pending output before printing completion message
-		if flushFunc != nil {
-			flushFunc()
+			// Flush any pending output before printing completion message
+			if flushFunc != nil {
+				flushFunc()
+			}
+
+			if run.Stopped() {
+				terminal.Feed("\r\n--- Script stopped ---\r\n")
+			} else if !ok {
+				terminal.Feed("\r\n--- Script execution failed ---\r\n")
+			} else {
+				terminal.Feed("\r\n--- Script completed ---\r\n")
+			}
+			launcherMenuCtx.ScriptMenus.Clear() // The script's menu entries end with it
+
+			// Run it again if its launch profile says to
+			if content = restartScript(filePath, nil, ok, terminal.Feed, run.Stopped); content == nil {
+				break
+			}
+		}
+		if run.End() {
+			endRunLog()
+			recordScriptEnd(absScript, pawgui.RunStatus(ok, run.Stopped()))
+			launcherScriptEnded()
 		}
+	}()
+}
 
-		if result == pawscript.BoolStatus(false) {
-			terminal.Feed("\r\n--- Script execution failed ---\r\n")
-		} else {
-			terminal.Feed("\r\n--- Script completed ---\r\n")
+// stopScript stops a script for Stop Script, nil being none running
+func stopScript(run *pawgui.ScriptRun) {
+	if run != nil {
+		run.Stop(configHelper.GetStopTimeout())
+	}
+}
+
+// launcherScriptEnded puts the launcher back as it was before its script ran,
+// with a fresh REPL, once the script ends or is killed
+func launcherScriptEnded() {
+	glib.IdleAdd(func() {
+		if launcherDebugPanel != nil {
+			launcherDebugPanel.scriptFinished()
 		}
+	})
 
-		scriptMu.Lock()
-		scriptRunning = false
-		scriptMu.Unlock()
+	scriptMu.Lock()
+	scriptRunning = false
+	launcherRun = nil
+	scriptMu.Unlock()
 
-		// Restart the REPL
-		if consoleREPL != nil {
-			// Create a new REPL instance (fresh state)
-			consoleREPL = pawscript.NewREPL(pawscript.REPLConfig{
-				Debug:        false,
-				Unrestricted: false,
-				OptLevel:     getOptimizationLevel(),
-				ShowBanner:   false, // Don't show banner again
-				IOConfig: &pawscript.IOChannelConfig{
-					Stdout: consoleOutCh,
-					Stdin:  consoleInCh,
-					Stderr: consoleOutCh,
-				},
-			}, func(s string) {
-				glib.IdleAdd(func() bool {
-					terminal.Feed(s)
-					return false
-				})
-			})
-			// Set flush callback to ensure output appears before blocking execution
-			consoleREPL.SetFlush(func() {
-				// Process pending GTK events to ensure output is displayed
-				// We're on the main thread, so glib.IdleAdd won't work (we'd be waiting for ourselves)
-				// Instead, use MainIterationDo to process pending events synchronously
-				for i := 0; i < 10 && gtk.EventsPending(); i++ {
-					gtk.MainIterationDo(false)
-				}
+	// Restart the REPL
+	if consoleREPL != nil {
+		// Create a new REPL instance (fresh state)
+		consoleREPL = pawscript.NewREPL(pawscript.REPLConfig{
+			Debug:        false,
+			Unrestricted: false,
+			OptLevel:     getOptimizationLevel(),
+			ShowBanner:   false, // Don't show banner again
+			IOConfig: &pawscript.IOChannelConfig{
+				Stdout: consoleOutCh,
+				Stdin:  consoleInCh,
+				Stderr: consoleOutCh,
+			},
+			Dialogs: guiDialogs{},
+		}, func(s string) {
+			glib.IdleAdd(func() bool {
+				terminal.Feed(s)
+				return false
 			})
-			// Set background color for prompt color selection
-			bg := getTerminalBackground()
-			consoleREPL.SetBackgroundRGB(bg.R, bg.G, bg.B)
-			consoleREPL.SetPSLColors(getPSLColors())
-			consoleREPL.Start()
+		})
+		// Set flush callback to ensure output appears before blocking execution
+		consoleREPL.SetFlush(func() {
+			// Process pending GTK events to ensure output is displayed
+			// We're on the main thread, so glib.IdleAdd won't work (we'd be waiting for ourselves)
+			// Instead, use MainIterationDo to process pending events synchronously
+			for i := 0; i < 10 && gtk.EventsPending(); i++ {
+				gtk.MainIterationDo(false)
+			}
+		})
+		// Set background color for prompt color selection
+		bg := getTerminalBackground()
+		consoleREPL.SetBackgroundRGB(bg.R, bg.G, bg.B)
+		consoleREPL.SetPSLColors(getPSLColors())
+		consoleREPL.Start()
+
+		// Re-register the toolbar_button command with the new REPL instance
+		// Reuse the existing launcherToolbarData with the new terminal reference
+		launcherToolbarData.terminal = terminal
+		registerToolbarButtonCommand(consoleREPL.GetPawScript(), launcherToolbarData)
+		pawgui.RegisterKeyMacroCommand(consoleREPL.GetPawScript(), launcherToolbarData.terminal.KeyMap())
+		pawgui.RegisterMenuItemCommand(consoleREPL.GetPawScript(), launcherToolbarData.terminal.ContextMenuItems())
+		pawgui.RegisterMenuRegisterCommand(consoleREPL.GetPawScript(), launcherMenuCtx.ScriptMenus)
+		pawgui.RegisterWidgetCommands(consoleREPL.GetPawScript(), scriptWidgets)
+		pawgui.RegisterNotifyCommand(consoleREPL.GetPawScript())
+		pawgui.RegisterTraySetCommand(consoleREPL.GetPawScript(), launcherTray)
+	}
+}
 
-			// Re-register the dummy_button command with the new REPL instance
-			// Reuse the existing launcherToolbarData with the new terminal reference
-			launcherToolbarData.terminal = terminal
-			registerDummyButtonCommand(consoleREPL.GetPawScript(), launcherToolbarData)
-		}
-	}()
+// openScriptTab opens a tab with just a terminal (no launcher UI) for running a
+// script when the main window already has a script running
+func openScriptTab(filePath string) {
+	openScriptTabWith(filePath, scriptTabOptions{})
 }
 
-// createConsoleWindow creates a new window with just a terminal (no launcher UI)
-// for running a script when the main window already has a script running
-func createConsoleWindow(filePath string) {
-	// Create new window
-	win, err := gtk.ApplicationWindowNew(app)
-	if err != nil {
-		terminal.Feed(fmt.Sprintf("Failed to create console window: %v\r\n", err))
-		return
+// scriptTabOptions changes how openScriptTabWith runs a script, for scheduled
+// tasks and run configurations
+type scriptTabOptions struct {
+	window      *tabWindow                   // The tab window to open in, if not the current one
+	permissions *pawscript.PermissionProfile // In place of the launcher's profile, if set
+	unattended  bool                         // No one to ask for permissions, nor to restore it
+	onFinish    func(ok bool)                // Called when the script ends or fails to start
+	profile     *pawgui.LaunchProfile        // In place of the script's launch profile, for a run configuration
+}
+
+// finish calls onFinish, if set
+func (o scriptTabOptions) finish(ok bool) {
+	if o.onFinish != nil {
+		o.onFinish(ok)
 	}
-	win.SetTitle(fmt.Sprintf("PawScript - %s", filepath.Base(filePath)))
-	win.SetDefaultSize(900, 600)
+}
 
-	// Set up quit shortcut for this window
-	setupQuitShortcutForWindow(win)
+// openScriptTabWith opens a tab running a script, as opts says, returning a
+// function that closes the tab (nil if it didn't open)
+func openScriptTabWith(filePath string, opts scriptTabOptions) func() {
+	// Its launch profile (see launchprofile.go) may give it a window of its own
+	profile, profileErr := configHelper.GetLaunchProfile(filePath)
+	if opts.profile != nil {
+		profile, profileErr = *opts.profile, nil
+	}
+	tabs := opts.window
+	if tabs == nil {
+		var err error
+		if tabs, err = getTabWindow(profile.OwnWindow()); err != nil {
+			terminal.Feed(fmt.Sprintf(tr("Failed to create console window: %v\r\n"), err))
+			opts.finish(false)
+			return nil
+		}
+		if profile.OwnWindow() {
+			tabs.win.Resize(profile.Width, profile.Height)
+		}
+	}
+	win := tabs.win
 
-	// Create terminal for this window
+	// Create terminal for this tab
 	winTerminal, err := purfectermgtk.New(purfectermgtk.Options{
 		Cols:           100,
 		Rows:           30,
-		ScrollbackSize: 10000,
+		ScrollbackSize: getScrollbackLines(),
 		FontFamily:     getFontFamily(),
 		FontSize:       getFontSize(),
 		Scheme:         getDualColorScheme(),
 	})
 	if err != nil {
-		terminal.Feed(fmt.Sprintf("Failed to create terminal: %v\r\n", err))
-		win.Destroy()
-		return
+		terminal.Feed(fmt.Sprintf(tr("Failed to create terminal: %v\r\n"), err))
+		tabs.closeIfEmpty()
+		opts.finish(false)
+		return nil
 	}
 
 	// Set font fallbacks for Unicode/CJK characters
 	winTerminal.SetFontFallbacks(getFontFamilyUnicode(), getFontFamilyCJK())
 
+	// Key macros from the config (scripts can add more with key_macro)
+	setupKeyMacros(winTerminal)
+	setupWindowZoom(winTerminal, "console")
+
+	// Flash the window in the taskbar when a background script rings the bell
+	setupBellUrgency(win, winTerminal)
+
 	// Set up terminal theme from config
 	prefersDark := isTermThemeDark()
 	winTerminal.Buffer().SetPreferredDarkTheme(prefersDark)
@@ -5794,9 +6904,12 @@ func createConsoleWindow(filePath string) {
 	// Create main layout with collapsible toolbar strip
 	paned, _ := gtk.PanedNew(gtk.ORIENTATION_HORIZONTAL)
 
-	// Track script running state for this window
+	// Track script running state for this tab
 	var winScriptRunning bool
+	var winTabClosed bool        // Stops the script's restarts
+	var winRun *pawgui.ScriptRun // The running script, for Stop Script
 	var winScriptMu sync.Mutex
+	var closeTab func()
 
 	// Create MenuContext for this console window
 	consoleMenuCtx := &MenuContext{
@@ -5808,9 +6921,16 @@ func createConsoleWindow(filePath string) {
 			defer winScriptMu.Unlock()
 			return winScriptRunning
 		},
+		StopScript: func() {
+			winScriptMu.Lock()
+			run := winRun
+			winScriptMu.Unlock()
+			stopScript(run)
+		},
 		CloseWindow: func() {
-			win.Close()
+			closeTab()
 		},
+		ScriptMenus: pawgui.NewScriptMenus(),
 	}
 
 	// Narrow strip for script window (always starts visible, collapsible)
@@ -5825,7 +6945,13 @@ func createConsoleWindow(filePath string) {
 	termWidget.SetVExpand(true)
 	termWidget.SetHExpand(true)
 	termWidget.SetMarginStart(8) // Spacing from splitter
-	paned.Pack2(termWidget, true, false)
+
+	// Status bar under the terminal, set by scripts with status_set
+	winStatus := newStatusBar()
+	termBox, _ := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 0)
+	termBox.PackStart(termWidget, true, true, 0)
+	termBox.PackStart(winStatus.box, false, false, 0)
+	paned.Pack2(termBox, true, false)
 
 	// Set initial strip width and collapse behavior
 	// Script windows only have two positions: 0 (collapsed) or visible (with extra padding)
@@ -5905,27 +7031,30 @@ func createConsoleWindow(filePath string) {
 		return true
 	})
 
-	win.Add(paned)
-
 	// Create context menu for this console window
 	winContextMenu, _ := gtk.MenuNew()
 
-	winCopyItem := createMenuItemWithGutter("Copy", func() {
+	winCopyItem := createMenuItemWithGutter(tr("Copy"), func() {
 		winTerminal.CopySelection()
 	})
 	winContextMenu.Append(winCopyItem)
 
-	winPasteItem := createMenuItemWithGutter("Paste", func() {
+	winPasteItem := createMenuItemWithGutter(tr("Paste"), func() {
 		winTerminal.PasteClipboard()
 	})
 	winContextMenu.Append(winPasteItem)
 
-	winSelectAllItem := createMenuItemWithGutter("Select All", func() {
+	winSelectAllItem := createMenuItemWithGutter(tr("Select All"), func() {
 		winTerminal.SelectAll()
 	})
 	winContextMenu.Append(winSelectAllItem)
 
-	winClearItem := createMenuItemWithGutter("Clear", func() {
+	winFindItem := createMenuItemWithGutter(tr("Find..."), func() {
+		winTerminal.ShowFindBar()
+	})
+	winContextMenu.Append(winFindItem)
+
+	winClearItem := createMenuItemWithGutter(tr("Clear"), func() {
 		winTerminal.Clear()
 	})
 	winContextMenu.Append(winClearItem)
@@ -5950,70 +7079,49 @@ func createConsoleWindow(filePath string) {
 	termCaps := winTerminal.GetTerminalCapabilities()
 
 	// Non-blocking output queue
+	// The queue is never closed, as a script may still be sending to it; closing the
+	// channel ends the script's sends and closing outputQueueDone stops the writer
 	outputQueue := make(chan interface{}, 256)
+	outputQueueDone := make(chan struct{})
 	go func() {
-		for item := range outputQueue {
-			switch v := item.(type) {
-			case []byte:
-				stdoutWriter.Write(v)
-			case chan struct{}:
-				close(v)
+		for {
+			select {
+			case item := <-outputQueue:
+				switch v := item.(type) {
+				case chan struct{}:
+					close(v)
+				default:
+					stdoutWriter.Write(pawscript.ConsoleBytes(v))
+				}
+			case <-outputQueueDone:
+				return
 			}
 		}
 	}()
 
-	winOutCh := &pawscript.StoredChannel{
-		BufferSize:       0,
-		Messages:         make([]pawscript.ChannelMessage, 0),
-		Subscribers:      make(map[int]*pawscript.StoredChannel),
-		NextSubscriberID: 1,
-		IsClosed:         false,
-		Timestamp:        time.Now(),
-		Terminal:         termCaps,
-		NativeSend: func(v interface{}) error {
-			var text string
-			switch d := v.(type) {
-			case []byte:
-				text = string(d)
-			case string:
-				text = d
-			default:
-				text = fmt.Sprintf("%v", v)
-			}
-			text = strings.ReplaceAll(text, "\r\n", "\n")
-			text = strings.ReplaceAll(text, "\n", "\r\n")
-			data := []byte(text)
-			select {
-			case outputQueue <- data:
-			default:
-			}
-			return nil
-		},
-		NativeRecv: func() (interface{}, error) {
-			return nil, fmt.Errorf("cannot receive from console_out")
-		},
-		NativeFlush: func() error {
-			writerDone := make(chan struct{})
-			select {
-			case outputQueue <- writerDone:
-				<-writerDone
-			default:
-			}
-			glibDone := make(chan struct{})
-			glib.IdleAdd(func() bool {
-				close(glibDone)
-				return false
-			})
-			select {
-			case <-glibDone:
-			case <-time.After(100 * time.Millisecond):
-			}
-			return nil
-		},
+	winOutCh := pawscript.NewChannelFromGo(outputQueue, nil, termCaps)
+	winOutCh.Overflow = pawscript.OverflowDropNewest // a stalled GUI drops output rather than blocking the script
+	winOutCh.NativeFlush = func() error {
+		writerDone := make(chan struct{})
+		select {
+		case outputQueue <- writerDone:
+			<-writerDone
+		default:
+		}
+		glibDone := make(chan struct{})
+		glib.IdleAdd(func() bool {
+			close(glibDone)
+			return false
+		})
+		select {
+		case <-glibDone:
+		case <-time.After(100 * time.Millisecond):
+		}
+		return nil
 	}
 
 	// Non-blocking input queue
-	inputQueue := make(chan byte, 256)
+	inputQueue := make(chan interface{}, 256)
 	go func() {
 		buf := make([]byte, 1)
 		for {
@@ -6023,39 +7131,21 @@ func createConsoleWindow(filePath string) {
 				return
 			}
 			select {
-			case inputQueue <- buf[0]:
+			case inputQueue <- []byte{buf[0]}:
 			default:
 				select {
 				case <-inputQueue:
 				default:
 				}
 				select {
-				case inputQueue <- buf[0]:
+				case inputQueue <- []byte{buf[0]}:
 				default:
 				}
 			}
 		}
 	}()
 
-	winInCh := &pawscript.StoredChannel{
-		BufferSize:       0,
-		Messages:         make([]pawscript.ChannelMessage, 0),
-		Subscribers:      make(map[int]*pawscript.StoredChannel),
-		NextSubscriberID: 1,
-		IsClosed:         false,
-		Timestamp:        time.Now(),
-		Terminal:         termCaps,
-		NativeRecv: func() (interface{}, error) {
-			b, ok := <-inputQueue
-			if !ok {
-				return nil, fmt.Errorf("input closed")
-			}
-			return []byte{b}, nil
-		},
-		NativeSend: func(v interface{}) error {
-			return fmt.Errorf("cannot send to console_in")
-		},
-	}
+	winInCh := pawscript.NewChannelFromGo(nil, inputQueue, termCaps)
 
 	// Read from stdout pipe and feed to terminal
 	go func() {
@@ -6079,6 +7169,9 @@ func createConsoleWindow(filePath string) {
 	// REPL for interactive mode when no script is running
 	var winREPL *pawscript.REPL
 
+	// Ctrl+click on a hyperlink opens it
+	winTerminal.SetLinkClickCallback(openHyperlink)
+
 	// Wire keyboard input
 	winTerminal.SetInputCallback(func(data []byte) {
 		winScriptMu.Lock()
@@ -6098,15 +7191,25 @@ func createConsoleWindow(filePath string) {
 		}
 	})
 
-	win.ShowAll()
+	closeTab = tabs.addTab(paned, filepath.Base(filePath))
+	if !opts.unattended {
+		absPath, _ := filepath.Abs(filePath)
+		tabs.remember(paned, pawgui.SessionTab{Kind: pawgui.SessionScript, Script: absPath}, winTerminal, consoleMenuCtx.IsScriptRunning)
+	}
 
 	// Run the script
+	endRunLog := startRunLog(winTerminal, filePath)
 	winTerminal.Feed(fmt.Sprintf("--- Running: %s ---\r\n\r\n", filepath.Base(filePath)))
+	if profileErr != nil {
+		winTerminal.Feed(fmt.Sprintf("%v\r\n", profileErr))
+	}
 
 	content, err := os.ReadFile(filePath)
 	if err != nil {
-		winTerminal.Feed(fmt.Sprintf("Error reading script file: %v\r\n", err))
-		return
+		winTerminal.Feed(fmt.Sprintf(tr("Error reading script file: %v\r\n"), err))
+		endRunLog()
+		opts.finish(false)
+		return closeTab
 	}
 
 	scriptDir := filepath.Dir(filePath)
@@ -6117,6 +7220,9 @@ func createConsoleWindow(filePath string) {
 
 	// Add the script's directory to recent paths for the combo box
 	addRecentPath(scriptDir)
+	if !opts.unattended {
+		recordScriptRun(absScript)
+	}
 
 	cwd, _ := os.Getwd()
 	tmpDir := os.TempDir()
@@ -6125,6 +7231,18 @@ func createConsoleWindow(filePath string) {
 		WriteRoots: []string{filepath.Join(scriptDir, "saves"), filepath.Join(scriptDir, "output"), filepath.Join(cwd, "saves"), filepath.Join(cwd, "output"), tmpDir},
 		ExecRoots:  []string{filepath.Join(scriptDir, "helpers"), filepath.Join(scriptDir, "bin")},
 	}
+	configHelper.ApplyGrantedRoots(fileAccess)
+	profile.ApplyRoots(fileAccess, scriptDir)
+	applyProjectRoots(fileAccess, absScript)
+
+	permissions := getLauncherPermissions(absScript)
+	if opts.permissions != nil {
+		permissions = opts.permissions
+	}
+	var permissionPrompt pawscript.PermissionPrompt = promptPermission
+	if opts.unattended {
+		permissionPrompt = nil // Access outside the roots is refused
+	}
 
 	ps := pawscript.New(&pawscript.Config{
 		Debug:                false,
@@ -6133,23 +7251,25 @@ func createConsoleWindow(filePath string) {
 		ShowErrorContext:     true,
 		ContextLines:         2,
 		FileAccess:           fileAccess,
+		Permissions:          permissions,
+		Quotas:               getLauncherQuotas(),
+		EnvAllowlist:         pawscript.DefaultEnvAllowlist,
+		PermissionPrompt:     permissionPrompt,
 		ScriptDir:            scriptDir,
-		OptLevel:             pawscript.OptimizationLevel(getOptimizationLevel()),
+		OptLevel:             pawscript.OptimizationLevel(profile.OptimizationLevel(getOptimizationLevel())),
+		Dialogs:              guiDialogs{},
 	})
 
-	ioConfig := &pawscript.IOChannelConfig{
-		Stdout: winOutCh,
-		Stdin:  winInCh,
-		Stderr: winOutCh,
-	}
-	ps.RegisterStandardLibraryWithIO([]string{}, ioConfig)
-
 	winScriptMu.Lock()
 	winScriptRunning = true
 	winScriptMu.Unlock()
 
-	// Handle window close - clean up resources to prevent GC issues
-	win.Connect("destroy", func() {
+	// Handle tab close - clean up resources to prevent GC issues
+	paned.Connect("destroy", func() {
+		winScriptMu.Lock()
+		winTabClosed = true
+		winScriptMu.Unlock()
+		endRunLog()
 		// Destroy the context menu explicitly to prevent GC finalizer crash
 		winContextMenu.Destroy()
 		// Close pipes to stop goroutines
@@ -6157,27 +7277,25 @@ func createConsoleWindow(filePath string) {
 		stdoutWriter.Close()
 		stdinReader.Close()
 		stdoutReader.Close()
-		// Close output queue to stop its goroutine
-		close(outputQueue)
+		// Close the output channel and stop its writer
+		_ = pawscript.ChannelClose(winOutCh)
+		close(outputQueueDone)
 	})
 
-	go func() {
-		snapshot := ps.CreateRestrictedSnapshot()
-		result := ps.ExecuteWithEnvironment(string(content), snapshot, filePath, 0, 0)
-
-		if winOutCh.NativeFlush != nil {
-			winOutCh.NativeFlush()
-		}
-
-		if result == pawscript.BoolStatus(false) {
-			winTerminal.Feed("\r\n--- Script execution failed ---\r\n")
-		} else {
-			winTerminal.Feed("\r\n--- Script completed ---\r\n")
-		}
+	tabClosed := func() bool {
+		winScriptMu.Lock()
+		defer winScriptMu.Unlock()
+		return winTabClosed
+	}
 
+	// scriptEnded starts the tab's REPL once the script ends or is killed
+	scriptEnded := func(ok bool) {
+		endRunLog()
 		winScriptMu.Lock()
 		winScriptRunning = false
+		winRun = nil
 		winScriptMu.Unlock()
+		opts.finish(ok)
 
 		// Start REPL for this window
 		winREPL = pawscript.NewREPL(pawscript.REPLConfig{
@@ -6190,6 +7308,7 @@ func createConsoleWindow(filePath string) {
 				Stdin:  winInCh,
 				Stderr: winOutCh,
 			},
+			Dialogs: guiDialogs{},
 		}, func(s string) {
 			glib.IdleAdd(func() bool {
 				winTerminal.Feed(s)
@@ -6211,7 +7330,7 @@ func createConsoleWindow(filePath string) {
 		winREPL.SetPSLColors(getPSLColors())
 		winREPL.Start()
 
-		// Register the dummy_button command with the window's REPL
+		// Register the toolbar_button command with the window's REPL
 		// Create window-specific toolbar data
 		winToolbarData := &WindowToolbarData{
 			strip:      strip,
@@ -6221,8 +7340,89 @@ func createConsoleWindow(filePath string) {
 		winToolbarData.updateFunc = func() {
 			updateWindowToolbarButtons(winToolbarData.strip, winToolbarData.registeredBtns)
 		}
-		registerDummyButtonCommand(winREPL.GetPawScript(), winToolbarData)
+		registerToolbarButtonCommand(winREPL.GetPawScript(), winToolbarData)
+		pawgui.RegisterKeyMacroCommand(winREPL.GetPawScript(), winToolbarData.terminal.KeyMap())
+		pawgui.RegisterMenuItemCommand(winREPL.GetPawScript(), winToolbarData.terminal.ContextMenuItems())
+		pawgui.RegisterMenuRegisterCommand(winREPL.GetPawScript(), consoleMenuCtx.ScriptMenus)
+		pawgui.RegisterWidgetCommands(winREPL.GetPawScript(), scriptWidgets)
+		pawgui.RegisterNotifyCommand(winREPL.GetPawScript())
+		pawgui.RegisterTraySetCommand(winREPL.GetPawScript(), launcherTray)
+		pawgui.RegisterStatusSetCommand(winREPL.GetPawScript(), winStatus.status)
+		pawgui.RegisterProgressCommand(winREPL.GetPawScript(), winStatus.status)
+	}
+
+	// Stop Script cancels the script, killing it if it won't stop
+	run := pawgui.NewScriptRun(ps, func() {
+		if winOutCh.NativeFlush != nil {
+			winOutCh.NativeFlush()
+		}
+		winTerminal.Feed("\r\n--- Script killed ---\r\n")
+		consoleMenuCtx.ScriptMenus.Clear()
+		winStatus.status.Clear()
+		if !opts.unattended {
+			recordScriptEnd(absScript, pawgui.RunStopped)
+		}
+		scriptEnded(false)
+	})
+	winScriptMu.Lock()
+	winRun = run
+	winScriptMu.Unlock()
+
+	// The script gets the tab's channels until it ends or is killed
+	ioConfig := run.IO(&pawscript.IOChannelConfig{
+		Stdout: winOutCh,
+		Stdin:  winInCh,
+		Stderr: winOutCh,
+	})
+	ps.RegisterStandardLibraryWithIO(profile.Args, ioConfig)
+	pawgui.RegisterKeyMacroCommand(ps, winTerminal.KeyMap())
+	pawgui.RegisterMenuItemCommand(ps, winTerminal.ContextMenuItems())
+	pawgui.RegisterMenuRegisterCommand(ps, consoleMenuCtx.ScriptMenus)
+	pawgui.RegisterWidgetCommands(ps, scriptWidgets)
+	pawgui.RegisterNotifyCommand(ps)
+	pawgui.RegisterTraySetCommand(ps, launcherTray)
+	pawgui.RegisterStatusSetCommand(ps, winStatus.status)
+	pawgui.RegisterProgressCommand(ps, winStatus.status)
+
+	noRestart := func() bool {
+		return tabClosed() || run.Stopped()
+	}
+
+	go func() {
+		ok := false
+		for {
+			snapshot := ps.CreateRestrictedSnapshot()
+			result := ps.ExecuteWithEnvironment(string(content), snapshot, filePath, 0, 0)
+
+			if winOutCh.NativeFlush != nil {
+				winOutCh.NativeFlush()
+			}
+
+			ok = result != pawscript.BoolStatus(false)
+			if run.Stopped() {
+				winTerminal.Feed("\r\n--- Script stopped ---\r\n")
+			} else if !ok {
+				winTerminal.Feed("\r\n--- Script execution failed ---\r\n")
+			} else {
+				winTerminal.Feed("\r\n--- Script completed ---\r\n")
+			}
+			consoleMenuCtx.ScriptMenus.Clear() // The script's menu entries and status end with it
+			winStatus.status.Clear()
+
+			// Run it again if its launch profile says to
+			if content = restartScript(filePath, opts.profile, ok, winTerminal.Feed, noRestart); content == nil {
+				break
+			}
+		}
+
+		if run.End() {
+			if !opts.unattended {
+				recordScriptEnd(absScript, pawgui.RunStatus(ok, run.Stopped()))
+			}
+			scriptEnded(ok)
+		}
 	}()
+	return closeTab
 }
 
 // createConsoleChannels creates the I/O channels for PawScript console
@@ -6245,78 +7445,46 @@ func createConsoleChannels() {
 	go func() {
 		for item := range outputQueue {
 			switch v := item.(type) {
-			case []byte:
-				stdoutWriter.Write(v)
 			case chan struct{}:
 				// Flush sentinel - signal that queue has drained up to this point
 				close(v)
+			default:
+				stdoutWriter.Write(pawscript.ConsoleBytes(v))
 			}
 		}
 	}()
 
-	consoleOutCh = &pawscript.StoredChannel{
-		BufferSize:       0,
-		Messages:         make([]pawscript.ChannelMessage, 0),
-		Subscribers:      make(map[int]*pawscript.StoredChannel),
-		NextSubscriberID: 1,
-		IsClosed:         false,
-		Timestamp:        time.Now(),
-		Terminal:         termCaps,
-		NativeSend: func(v interface{}) error {
-			var text string
-			switch d := v.(type) {
-			case []byte:
-				text = string(d)
-			case string:
-				text = d
-			default:
-				text = fmt.Sprintf("%v", v)
-			}
-			// Normalize newlines: first collapse any existing \r\n to \n, then convert all \n to \r\n
-			// This ensures consistent terminal behavior whether in raw mode or not
-			text = strings.ReplaceAll(text, "\r\n", "\n")
-			text = strings.ReplaceAll(text, "\n", "\r\n")
-			data := []byte(text)
-			select {
-			case outputQueue <- data:
-			default:
-				// Queue full - drop to prevent deadlock
-			}
-			return nil
-		},
-		NativeRecv: func() (interface{}, error) {
-			return nil, fmt.Errorf("cannot receive from console_out")
-		},
-		NativeFlush: func() error {
-			// Step 1: Wait for outputQueue to drain and pipe to be read
-			// Since io.Pipe blocks until read, when this completes all prior data
-			// has been read from the pipe (though glib.IdleAdd may still be pending)
-			writerDone := make(chan struct{})
-			select {
-			case outputQueue <- writerDone:
-				<-writerDone // Wait for writer goroutine to process sentinel
-			default:
-				// Queue full - shouldn't happen with 256 buffer, but proceed anyway
-			}
+	consoleOutCh = pawscript.NewChannelFromGo(outputQueue, nil, termCaps)
+	consoleOutCh.Overflow = pawscript.OverflowDropNewest // a stalled GUI drops output rather than blocking the script
+	consoleOutCh.NativeFlush = func() error {
+		// Step 1: Wait for outputQueue to drain and pipe to be read
+		// Since io.Pipe blocks until read, when this completes all prior data
+		// has been read from the pipe (though glib.IdleAdd may still be pending)
+		writerDone := make(chan struct{})
+		select {
+		case outputQueue <- writerDone:
+			<-writerDone // Wait for writer goroutine to process sentinel
+		default:
+			// Queue full - shouldn't happen with 256 buffer, but proceed anyway
+		}
 
-			// Step 2: Wait for all pending glib.IdleAdd callbacks to complete
-			// This ensures all FeedBytes calls have finished before we return
-			// Use a timeout to avoid deadlock if GTK main loop isn't running yet
-			// (e.g., during startup before app.Run() is called)
-			glibDone := make(chan struct{})
-			glib.IdleAdd(func() bool {
-				close(glibDone)
-				return false
-			})
-			select {
-			case <-glibDone:
-				// Successfully waited for GTK main loop
-			case <-time.After(100 * time.Millisecond):
-				// GTK main loop not running yet, proceed anyway
-			}
+		// Step 2: Wait for all pending glib.IdleAdd callbacks to complete
+		// This ensures all FeedBytes calls have finished before we return
+		// Use a timeout to avoid deadlock if GTK main loop isn't running yet
+		// (e.g., during startup before app.Run() is called)
+		glibDone := make(chan struct{})
+		glib.IdleAdd(func() bool {
+			close(glibDone)
+			return false
+		})
+		select {
+		case <-glibDone:
+			// Successfully waited for GTK main loop
+		case <-time.After(100 * time.Millisecond):
+			// GTK main loop not running yet, proceed anyway
+		}
 
-			return nil
-		},
+		return nil
 	}
 
 	// Set up the global flushFunc
@@ -6327,7 +7495,7 @@ func createConsoleChannels() {
 	}
 
 	// Non-blocking input queue
-	inputQueue := make(chan byte, 256)
+	inputQueue := make(chan interface{}, 256)
 
 	// Reader goroutine: drains pipe and puts bytes into queue
 	go func() {
@@ -6339,7 +7507,7 @@ func createConsoleChannels() {
 				return
 			}
 			select {
-			case inputQueue <- buf[0]:
+			case inputQueue <- []byte{buf[0]}:
 			default:
 				// Drop oldest if full
 				select {
@@ -6347,32 +7515,14 @@ func createConsoleChannels() {
 				default:
 				}
 				select {
-				case inputQueue <- buf[0]:
+				case inputQueue <- []byte{buf[0]}:
 				default:
 				}
 			}
 		}
 	}()
 
-	consoleInCh = &pawscript.StoredChannel{
-		BufferSize:       0,
-		Messages:         make([]pawscript.ChannelMessage, 0),
-		Subscribers:      make(map[int]*pawscript.StoredChannel),
-		NextSubscriberID: 1,
-		IsClosed:         false,
-		Timestamp:        time.Now(),
-		Terminal:         termCaps,
-		NativeRecv: func() (interface{}, error) {
-			b, ok := <-inputQueue
-			if !ok {
-				return nil, fmt.Errorf("input closed")
-			}
-			return []byte{b}, nil
-		},
-		NativeSend: func(v interface{}) error {
-			return fmt.Errorf("cannot send to console_in")
-		},
-	}
+	consoleInCh = pawscript.NewChannelFromGo(nil, inputQueue, termCaps)
 
 	clearInputFunc = func() {
 		for {
@@ -6404,6 +7554,12 @@ func createConsoleChannels() {
 		}
 	}()
 
+	// Ctrl+click on a hyperlink opens it
+	terminal.SetLinkClickCallback(openHyperlink)
+
+	// Dropped scripts run and dropped folders open (see launcherdrop.go)
+	terminal.SetFileDropCallback(handleLauncherDrop)
+
 	// Wire keyboard input from terminal to stdin pipe or REPL
 	terminal.SetInputCallback(func(data []byte) {
 		scriptMu.Lock()
@@ -6441,6 +7597,7 @@ func createConsoleChannels() {
 			Stdin:  consoleInCh,
 			Stderr: consoleOutCh,
 		},
+		Dialogs: guiDialogs{},
 	}, func(s string) {
 		// Output to terminal on GTK main thread
 		glib.IdleAdd(func() bool {
@@ -6463,7 +7620,7 @@ func createConsoleChannels() {
 	consoleREPL.SetPSLColors(getPSLColors())
 	consoleREPL.Start()
 
-	// Register the dummy_button command with the REPL's PawScript instance
+	// Register the toolbar_button command with the REPL's PawScript instance
 	// Create launcher toolbar data that uses the global launcher strip
 	launcherToolbarData = &WindowToolbarData{
 		strip:    launcherNarrowStrip,
@@ -6474,5 +7631,11 @@ func createConsoleChannels() {
 			updateLauncherToolbarButtons()
 		},
 	}
-	registerDummyButtonCommand(consoleREPL.GetPawScript(), launcherToolbarData)
+	registerToolbarButtonCommand(consoleREPL.GetPawScript(), launcherToolbarData)
+	pawgui.RegisterKeyMacroCommand(consoleREPL.GetPawScript(), launcherToolbarData.terminal.KeyMap())
+	pawgui.RegisterMenuItemCommand(consoleREPL.GetPawScript(), launcherToolbarData.terminal.ContextMenuItems())
+	pawgui.RegisterMenuRegisterCommand(consoleREPL.GetPawScript(), launcherMenuCtx.ScriptMenus)
+	pawgui.RegisterWidgetCommands(consoleREPL.GetPawScript(), scriptWidgets)
+	pawgui.RegisterNotifyCommand(consoleREPL.GetPawScript())
+	pawgui.RegisterTraySetCommand(consoleREPL.GetPawScript(), launcherTray)
 }
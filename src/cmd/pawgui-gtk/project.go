@@ -0,0 +1,269 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/phroun/pawscript"
+	"github.com/phroun/pawscript/src/pkg/pawgui"
+	purfectermgtk "github.com/phroun/pawscript/src/pkg/purfecterm-gtk"
+	"github.com/sqweek/dialog"
+)
+
+// Projects
+// Open Project... in the launcher's menu opens a directory as a project (see
+// pawgui/project.go): its pinned scripts head the file list, its roots apply to
+// its scripts, and the Project submenu runs its run configurations and opens the
+// project terminal, a shell that changes to the project root when another
+// project is opened. The file list's menu pins scripts and saves their launch
+// profiles as run configurations.
+
+var (
+	currentProject  *pawgui.Project         // The project open in the launcher, nil for none
+	projectTerminal *purfectermgtk.Terminal // The shell Project Terminal opened last
+
+	// The file list menu's project items, shown for the project's scripts
+	pinItem, unpinItem, saveRunConfigItem *gtk.MenuItem
+)
+
+// restoreProject reopens the project open when the launcher last quit
+func restoreProject() {
+	root := configHelper.GetProject()
+	if root == "" {
+		return
+	}
+	project, err := pawgui.OpenProject(root)
+	if project == nil {
+		// Moved or deleted since
+		fmt.Fprintf(os.Stderr, "Project: %v\n", err)
+		configHelper.SetProject("")
+		saveConfig(appConfig)
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Project: %v\n", err)
+	}
+	currentProject = project
+}
+
+// openProjectDialog asks for a directory and opens it as the project
+func openProjectDialog() {
+	dir, err := dialog.Directory().
+		Title(tr("Open Project")).
+		SetStartDir(currentDir).
+		Browse()
+	if err != nil || dir == "" {
+		return
+	}
+	openProject(dir)
+}
+
+// openProject opens dir as the launcher's project and shows its root in the
+// file list
+func openProject(dir string) {
+	project, err := pawgui.OpenProject(dir)
+	if project == nil {
+		dialog.Message(tr("Failed to open project: %v"), err).Title(tr("Error")).Error()
+		return
+	}
+	if err != nil {
+		terminal.Feed(fmt.Sprintf("%v\r\n", err))
+	}
+	setProject(project)
+	currentDir = project.Root
+	refreshFileList()
+	updatePathMenu()
+	saveBrowseDir(currentDir)
+}
+
+// closeProject closes the launcher's project
+func closeProject() {
+	setProject(nil)
+	refreshFileList()
+}
+
+// setProject makes project the launcher's, nil for none, and has the project
+// terminal follow its root
+func setProject(project *pawgui.Project) {
+	currentProject = project
+	root := ""
+	if project != nil {
+		root = project.Root
+	}
+	configHelper.SetProject(root)
+	saveConfig(appConfig)
+	if project != nil && projectTerminal != nil && projectTerminal.IsRunning() {
+		projectTerminal.WriteString(pawgui.ShellCdCommand(project.Root))
+	}
+}
+
+// saveProject writes the project's settings, reporting failure
+func saveProject() bool {
+	if err := currentProject.Save(); err != nil {
+		dialog.Message(tr("Failed to save project: %v"), err).Title(tr("Error")).Error()
+		return false
+	}
+	return true
+}
+
+// applyProjectRoots adds the project's roots to the file access of a script in it
+func applyProjectRoots(fileAccess *pawscript.FileAccessConfig, script string) {
+	if currentProject != nil {
+		currentProject.ApplyRoots(fileAccess, script)
+	}
+}
+
+// fileRowPath returns the path of a file list row's file, given the row's name:
+// a pinned script's row is named for its absolute path
+func fileRowPath(name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(currentDir, name)
+}
+
+// fileRowLabel returns the text of a file list row given its name, a pinned
+// script showing its path in the project
+func fileRowLabel(name string) string {
+	if filepath.IsAbs(name) && currentProject != nil {
+		if rel, ok := currentProject.Rel(name); ok {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return name
+}
+
+// openProjectTerminal opens a shell in the project root that follows it
+func openProjectTerminal() {
+	if currentProject != nil {
+		projectTerminal = openShellTab(currentProject.Root)
+	}
+}
+
+// runRunConfig runs the project's run configuration with the name given, in a
+// console tab or the window of its own its profile gives it
+func runRunConfig(name string) {
+	if currentProject == nil {
+		return
+	}
+	config, ok := currentProject.RunConfig(name)
+	if !ok {
+		return
+	}
+	openScriptTabWith(currentProject.Path(config.Script), scriptTabOptions{profile: &config.Profile})
+}
+
+// saveRunConfigDialog asks for a name and saves a script's launch profile as
+// the project's run configuration with it
+func saveRunConfigDialog(parent gtk.IWindow, script string) {
+	rel, ok := currentProject.Rel(script)
+	if !ok {
+		return
+	}
+	name, ok := promptText(parent, tr("Save as Run Configuration"),
+		tr("Save the script's launch profile as the run configuration named:"),
+		strings.TrimSuffix(filepath.Base(script), filepath.Ext(script)))
+	if name = strings.TrimSpace(name); !ok || name == "" {
+		return
+	}
+	profile, _ := configHelper.GetLaunchProfile(script)
+	currentProject.SetRunConfig(pawgui.RunConfig{Name: name, Script: rel, Profile: profile})
+	saveProject()
+}
+
+// deleteRunConfig deletes the run configuration with the name given, once the
+// user confirms it
+func deleteRunConfig(parent gtk.IWindow, name string) {
+	msg := gtk.MessageDialogNew(parent, gtk.DIALOG_MODAL|gtk.DIALOG_DESTROY_WITH_PARENT,
+		gtk.MESSAGE_QUESTION, gtk.BUTTONS_OK_CANCEL, "%s",
+		fmt.Sprintf(tr("Delete the run configuration %q?"), name))
+	msg.SetTitle(tr("Delete Run Configuration"))
+	confirmed := msg.Run() == gtk.RESPONSE_OK
+	msg.Destroy()
+	if !confirmed || currentProject == nil {
+		return
+	}
+	currentProject.DeleteRunConfig(name)
+	saveProject()
+}
+
+// setPinned pins a script to the file list or unpins it
+func setPinned(script string, pinned bool) {
+	currentProject.SetPinned(script, pinned)
+	if saveProject() {
+		refreshFileList()
+	}
+}
+
+// createProjectMenu creates the Project submenu: the run configurations, and
+// the items for the project terminal, the project root and closing the project
+func createProjectMenu(parent gtk.IWindow) *gtk.Menu {
+	menu, _ := gtk.MenuNew()
+	configs := currentProject.RunConfigs
+	for _, config := range configs {
+		name := config.Name
+		menu.Append(createMenuItemWithGutter(name, func() {
+			runRunConfig(name)
+		}))
+	}
+	if len(configs) > 0 {
+		deleteItem := createMenuItemWithGutter(tr("Delete Run Configuration"), nil)
+		deleteMenu, _ := gtk.MenuNew()
+		for _, config := range configs {
+			name := config.Name
+			deleteMenu.Append(createMenuItemWithGutter(name+"...", func() {
+				deleteRunConfig(parent, name)
+			}))
+		}
+		deleteItem.SetSubmenu(deleteMenu)
+		menu.Append(deleteItem)
+		sep, _ := gtk.SeparatorMenuItemNew()
+		menu.Append(sep)
+	}
+
+	menu.Append(createMenuItemWithGutter(tr("Project Terminal"), func() {
+		openProjectTerminal()
+	}))
+	menu.Append(createMenuItemWithGutter(tr("Go to Project Root"), func() {
+		if currentProject != nil {
+			currentDir = currentProject.Root
+			refreshFileList()
+			updatePathMenu()
+			saveBrowseDir(currentDir)
+		}
+	}))
+	menu.Append(createMenuItemWithGutter(tr("Close Project"), func() {
+		closeProject()
+	}))
+	menu.ShowAll()
+	return menu
+}
+
+// addProjectFileListItems adds the project's items to the file list's menu
+func addProjectFileListItems(menu *gtk.Menu) {
+	pinItem = createMenuItemWithGutter(tr("Pin to Project"), func() {
+		setPinned(fileListMenuScript, true)
+	})
+	menu.Append(pinItem)
+	unpinItem = createMenuItemWithGutter(tr("Unpin from Project"), func() {
+		setPinned(fileListMenuScript, false)
+	})
+	menu.Append(unpinItem)
+	saveRunConfigItem = createMenuItemWithGutter(tr("Save as Run Configuration..."), func() {
+		saveRunConfigDialog(mainWindow, fileListMenuScript)
+	})
+	menu.Append(saveRunConfigItem)
+}
+
+// showProjectFileListItems shows the file list menu's project items that apply
+// to a script
+func showProjectFileListItems(script string) {
+	inProject := currentProject != nil && currentProject.Contains(script)
+	pinned := inProject && currentProject.IsPinned(script)
+	pinItem.SetVisible(inProject && !pinned)
+	unpinItem.SetVisible(pinned)
+	saveRunConfigItem.SetVisible(inProject)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/phroun/pawscript/src/pkg/pawgui"
+)
+
+// Recent scripts
+// The scripts run from the launcher are remembered with when they last ran and
+// how that run ended (see pawgui.RecentScript), and listed in the path menu and
+// the tray icon's Recent Scripts submenu, which run them again. --recent runs the
+// most recent one from the command line.
+
+// recordScriptRun remembers a script the launcher is running
+func recordScriptRun(script string) {
+	if configHelper.AddRecentScript(script) {
+		recentScriptsChanged()
+	}
+}
+
+// recordScriptEnd records how a run of a recent script ended (see
+// pawgui.RunStatus); it may be called from any goroutine
+func recordScriptEnd(script, status string) {
+	glib.IdleAdd(func() {
+		if configHelper.SetRecentScriptStatus(script, status) {
+			recentScriptsChanged()
+		}
+	})
+}
+
+// recentScriptsChanged saves the recent scripts and shows them in the menus
+func recentScriptsChanged() {
+	saveConfig(appConfig)
+	refreshTrayMenu()
+	updatePathMenu()
+}
+
+// appendRecentScripts adds the recent scripts to the path menu, after a
+// separator, to run them again
+func appendRecentScripts(menu *gtk.Menu) {
+	scripts := configHelper.GetRecentScriptRuns()
+	if len(scripts) == 0 {
+		return
+	}
+	sep, _ := gtk.SeparatorMenuItemNew()
+	menu.Append(sep)
+	for _, recent := range scripts {
+		script := recent.Path
+		item := createMenuItemWithIcon(pawFileIconSVG, recent.Label(), func() {
+			runScript(script)
+		})
+		item.SetTooltipText(script)
+		menu.Append(item)
+	}
+}
+
+// mostRecentScript returns the script the launcher ran last, "" if none
+func mostRecentScript() string {
+	config := loadConfig()
+	if scripts := pawgui.NewConfigHelper(config).GetRecentScripts(); len(scripts) > 0 {
+		return scripts[0]
+	}
+	return ""
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sync"
+
+	purfectermgtk "github.com/phroun/pawscript/src/pkg/purfecterm-gtk"
+)
+
+// Run logs
+// With run_log on, everything a script run shows in its terminal is also written
+// to a log file of its own (see pawgui/runlog.go). A run's log lasts through the
+// restarts its launch profile asks for, and ends when the script does.
+
+// startRunLog starts logging what term shows for a run of script, returning the
+// function that ends the log (which does nothing after the first call)
+func startRunLog(term *purfectermgtk.Terminal, script string) func() {
+	runLog, err := configHelper.OpenRunLog(script)
+	if err != nil {
+		term.Feed(fmt.Sprintf(tr("Can't log this run: %v\r\n"), err))
+	}
+	if runLog == nil {
+		return func() {}
+	}
+	term.SetOutputTee(runLog)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			term.SetOutputTee(nil)
+			runLog.Close()
+		})
+	}
+}
+
+// openRunLogFolder opens the directory run logs are written to with the system's
+// file manager
+func openRunLogFolder() {
+	dir := configHelper.GetRunLogDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return
+	}
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", dir)
+	case "windows":
+		cmd = exec.Command("explorer", dir)
+	default:
+		cmd = exec.Command("xdg-open", dir)
+	}
+	if err := cmd.Start(); err == nil {
+		go cmd.Wait()
+	}
+}
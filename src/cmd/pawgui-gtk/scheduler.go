@@ -0,0 +1,355 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/phroun/pawscript"
+	"github.com/phroun/pawscript/src/pkg/pawgui"
+	"github.com/sqweek/dialog"
+)
+
+// Scheduled tasks
+// The Scheduled Tasks dialog, from the hamburger menu, lists the scripts the
+// launcher runs on a schedule, with their last runs. Each run opens a tab in a
+// window of its own, which opens minimized and isn't raised by later runs; a
+// task's next run replaces its tab.
+
+var (
+	launcherScheduler *pawgui.Scheduler
+
+	taskWindow *tabWindow        // Where scheduled tasks run, nil until one does
+	taskTabs   map[string]func() // Closes the tab of each task's last run
+
+	// refreshTasksDialog updates the Scheduled Tasks dialog while it's open
+	refreshTasksDialog func()
+)
+
+// startScheduler starts running the scheduled tasks, once
+func startScheduler() {
+	if launcherScheduler != nil {
+		return
+	}
+	taskTabs = make(map[string]func())
+	launcherScheduler = pawgui.NewScheduler(func(task pawgui.ScheduledTask, done func(ok bool)) {
+		glib.IdleAdd(func() {
+			runScheduledTask(task, done)
+		})
+	})
+	launcherScheduler.OnChange = func() {
+		glib.IdleAdd(func() {
+			if refreshTasksDialog != nil {
+				refreshTasksDialog()
+			}
+		})
+	}
+	launcherScheduler.SetTasks(configHelper.GetScheduledTasks())
+	launcherScheduler.Start()
+}
+
+// runScheduledTask runs a task in the background tab window
+func runScheduledTask(task pawgui.ScheduledTask, done func(ok bool)) {
+	if closeTab := taskTabs[task.Name]; closeTab != nil {
+		closeTab()
+		delete(taskTabs, task.Name)
+	}
+	if taskWindow == nil {
+		previous := currentTabWindow
+		tw, err := getTabWindow(true)
+		if err != nil {
+			done(false)
+			return
+		}
+		tw.background = true
+		tw.win.Connect("destroy", func() {
+			if taskWindow == tw {
+				taskWindow = nil
+				taskTabs = make(map[string]func())
+			}
+		})
+		taskWindow = tw
+		currentTabWindow = previous // The user's new tabs open where they did
+	}
+	taskTabs[task.Name] = openScriptTabWith(task.Script, scriptTabOptions{
+		window:      taskWindow,
+		permissions: task.Permissions(),
+		unattended:  true,
+		onFinish:    done,
+	})
+}
+
+// saveScheduledTasks saves the tasks to the config and schedules them
+func saveScheduledTasks(tasks []pawgui.ScheduledTask) {
+	configHelper.SetScheduledTasks(tasks)
+	saveConfig(appConfig)
+	launcherScheduler.SetTasks(tasks)
+}
+
+// showScheduledTasksDialog shows the Scheduled Tasks manager
+func showScheduledTasksDialog(parent gtk.IWindow) {
+	if launcherScheduler == nil {
+		return
+	}
+	if parent == nil && mainWindow != nil {
+		parent = mainWindow
+	}
+
+	dlg, _ := gtk.DialogNew()
+	dlg.SetTitle(tr("Scheduled Tasks"))
+	dlg.SetModal(true)
+	dlg.SetDefaultSize(560, 480)
+	if parent != nil {
+		if win, ok := parent.(*gtk.Window); ok {
+			dlg.SetTransientFor(win)
+		} else if appWin, ok := parent.(*gtk.ApplicationWindow); ok {
+			dlg.SetTransientFor(&appWin.Window)
+		}
+	}
+
+	contentArea, _ := dlg.GetContentArea()
+	contentArea.SetMarginStart(12)
+	contentArea.SetMarginEnd(12)
+	contentArea.SetMarginTop(12)
+	contentArea.SetMarginBottom(12)
+	contentArea.SetSpacing(8)
+
+	// Tasks, with a check box to enable each
+	taskList, _ := gtk.ListBoxNew()
+	taskList.SetSelectionMode(gtk.SELECTION_SINGLE)
+	taskScroll, _ := gtk.ScrolledWindowNew(nil, nil)
+	taskScroll.SetPolicy(gtk.POLICY_NEVER, gtk.POLICY_AUTOMATIC)
+	taskScroll.SetShadowType(gtk.SHADOW_IN)
+	taskScroll.Add(taskList)
+	contentArea.PackStart(taskScroll, true, true, 0)
+
+	buttonRow, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 6)
+	addBtn, _ := gtk.ButtonNewWithLabel(tr("Add..."))
+	editBtn, _ := gtk.ButtonNewWithLabel(tr("Edit..."))
+	removeBtn, _ := gtk.ButtonNewWithLabel(tr("Remove"))
+	runNowBtn, _ := gtk.ButtonNewWithLabel(tr("Run Now"))
+	buttonRow.PackStart(addBtn, false, false, 0)
+	buttonRow.PackStart(editBtn, false, false, 0)
+	buttonRow.PackStart(removeBtn, false, false, 0)
+	buttonRow.PackEnd(runNowBtn, false, false, 0)
+	contentArea.PackStart(buttonRow, false, false, 0)
+
+	// Runs, newest first
+	historyLabel, _ := gtk.LabelNew(tr("History"))
+	historyLabel.SetHAlign(gtk.ALIGN_START)
+	contentArea.PackStart(historyLabel, false, false, 4)
+	historyList, _ := gtk.ListBoxNew()
+	historyList.SetSelectionMode(gtk.SELECTION_NONE)
+	historyScroll, _ := gtk.ScrolledWindowNew(nil, nil)
+	historyScroll.SetPolicy(gtk.POLICY_NEVER, gtk.POLICY_AUTOMATIC)
+	historyScroll.SetShadowType(gtk.SHADOW_IN)
+	historyScroll.Add(historyList)
+	contentArea.PackStart(historyScroll, true, true, 0)
+
+	dlg.AddButton(tr("Close"), gtk.RESPONSE_CLOSE)
+
+	tasks := launcherScheduler.Tasks()
+	selected := func() int {
+		if row := taskList.GetSelectedRow(); row != nil {
+			return row.GetIndex()
+		}
+		return -1
+	}
+
+	updateButtons := func() {
+		hasSelection := selected() >= 0
+		editBtn.SetSensitive(hasSelection)
+		removeBtn.SetSensitive(hasSelection)
+		runNowBtn.SetSensitive(hasSelection)
+	}
+
+	refresh := func() {
+		index := selected()
+		safeRemoveChildren(taskList)
+		for i, task := range tasks {
+			i := i
+			row, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 8)
+			row.SetMarginStart(6)
+			row.SetMarginEnd(6)
+			row.SetMarginTop(4)
+			row.SetMarginBottom(4)
+			enabled, _ := gtk.CheckButtonNew()
+			enabled.SetActive(task.Enabled)
+			enabled.SetTooltipText(tr("Enabled"))
+			enabled.Connect("toggled", func() {
+				tasks[i].Enabled = enabled.GetActive()
+				saveScheduledTasks(tasks)
+			})
+			row.PackStart(enabled, false, false, 0)
+			label, _ := gtk.LabelNew(fmt.Sprintf("%s  (%s, %s)\n%s  %s", task.Name, task.Schedule, task.Profile,
+				filepath.Base(task.Script), launcherScheduler.Status(task)))
+			label.SetHAlign(gtk.ALIGN_START)
+			label.SetTooltipText(task.Script)
+			row.PackStart(label, true, true, 0)
+			taskList.Add(row)
+		}
+		taskList.ShowAll()
+		if index >= 0 && index < len(tasks) {
+			taskList.SelectRow(taskList.GetRowAtIndex(index))
+		}
+
+		safeRemoveChildren(historyList)
+		for _, run := range launcherScheduler.History() {
+			label, _ := gtk.LabelNew(run.Describe())
+			label.SetHAlign(gtk.ALIGN_START)
+			label.SetTooltipText(run.Script)
+			historyList.Add(label)
+		}
+		historyList.ShowAll()
+		updateButtons()
+	}
+	taskList.Connect("row-selected", updateButtons)
+
+	addBtn.Connect("clicked", func() {
+		task := pawgui.ScheduledTask{Schedule: "every 1h", Profile: "untrusted", Enabled: true}
+		if editScheduledTask(dlg, &task, tasks) {
+			tasks = append(tasks, task)
+			saveScheduledTasks(tasks)
+			refresh()
+		}
+	})
+	editBtn.Connect("clicked", func() {
+		i := selected()
+		if i < 0 {
+			return
+		}
+		others := append(append([]pawgui.ScheduledTask(nil), tasks[:i]...), tasks[i+1:]...)
+		task := tasks[i]
+		if editScheduledTask(dlg, &task, others) {
+			tasks[i] = task
+			saveScheduledTasks(tasks)
+			refresh()
+		}
+	})
+	removeBtn.Connect("clicked", func() {
+		if i := selected(); i >= 0 {
+			tasks = append(tasks[:i], tasks[i+1:]...)
+			saveScheduledTasks(tasks)
+			refresh()
+		}
+	})
+	runNowBtn.Connect("clicked", func() {
+		if i := selected(); i >= 0 {
+			launcherScheduler.RunNow(tasks[i].Name)
+		}
+	})
+
+	refresh()
+	refreshTasksDialog = refresh
+	dlg.ShowAll()
+	dlg.Run()
+	refreshTasksDialog = nil
+	dlg.Destroy()
+}
+
+// editScheduledTask asks for a task's settings, returning false if canceled.
+// others are the tasks it must not share a name with.
+func editScheduledTask(parent *gtk.Dialog, task *pawgui.ScheduledTask, others []pawgui.ScheduledTask) bool {
+	dlg, _ := gtk.DialogNew()
+	dlg.SetTitle(tr("Scheduled Task"))
+	dlg.SetModal(true)
+	dlg.SetTransientFor(parent)
+	dlg.SetDefaultSize(420, -1)
+
+	contentArea, _ := dlg.GetContentArea()
+	contentArea.SetMarginStart(12)
+	contentArea.SetMarginEnd(12)
+	contentArea.SetMarginTop(12)
+	contentArea.SetMarginBottom(12)
+
+	grid, _ := gtk.GridNew()
+	grid.SetRowSpacing(8)
+	grid.SetColumnSpacing(12)
+	contentArea.PackStart(grid, true, true, 0)
+	addRow := func(row int, title string, field gtk.IWidget) {
+		label, _ := gtk.LabelNew(title)
+		label.SetHAlign(gtk.ALIGN_START)
+		grid.Attach(label, 0, row, 1, 1)
+		grid.Attach(field, 1, row, 1, 1)
+	}
+
+	nameEntry, _ := gtk.EntryNew()
+	nameEntry.SetText(task.Name)
+	nameEntry.SetHExpand(true)
+	addRow(0, "Name:", nameEntry)
+
+	scriptRow, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 6)
+	scriptEntry, _ := gtk.EntryNew()
+	scriptEntry.SetText(task.Script)
+	scriptRow.PackStart(scriptEntry, true, true, 0)
+	browseBtn, _ := gtk.ButtonNewWithLabel(tr("Browse..."))
+	browseBtn.Connect("clicked", func() {
+		startDir := currentDir
+		if script, _ := scriptEntry.GetText(); script != "" {
+			startDir = filepath.Dir(script)
+		}
+		file, err := dialog.File().
+			Title(tr("Choose Script")).
+			Filter("PawScript files", "paw").
+			Filter("All files", "*").
+			SetStartDir(startDir).
+			Load()
+		if err == nil && file != "" {
+			scriptEntry.SetText(file)
+			if name, _ := nameEntry.GetText(); name == "" {
+				nameEntry.SetText(filepath.Base(file))
+			}
+		}
+	})
+	scriptRow.PackStart(browseBtn, false, false, 0)
+	addRow(1, "Script:", scriptRow)
+
+	scheduleEntry, _ := gtk.EntryNew()
+	scheduleEntry.SetText(task.Schedule)
+	scheduleEntry.SetPlaceholderText(tr("every 30m, or cron fields: 0 9 * * 1-5"))
+	scheduleEntry.SetTooltipText(tr("\"every\" and an interval (30m, 2h), or five cron fields:\nminute, hour, day of month, month and day of week"))
+	addRow(2, "Schedule:", scheduleEntry)
+
+	profileCombo, _ := gtk.ComboBoxTextNew()
+	active := 0
+	for i, name := range pawscript.PermissionProfileNames() {
+		profileCombo.AppendText(name)
+		if name == task.Profile || (name == "untrusted" && active == 0) {
+			active = i
+		}
+	}
+	profileCombo.SetActive(active)
+	addRow(3, "Profile:", profileCombo)
+
+	enabledCheck, _ := gtk.CheckButtonNewWithLabel(tr("Enabled"))
+	enabledCheck.SetActive(task.Enabled)
+	grid.Attach(enabledCheck, 1, 4, 1, 1)
+
+	dlg.AddButton(tr("Cancel"), gtk.RESPONSE_CANCEL)
+	dlg.AddButton(tr("OK"), gtk.RESPONSE_OK)
+	dlg.SetDefaultResponse(gtk.RESPONSE_OK)
+	dlg.ShowAll()
+	defer dlg.Destroy()
+
+	for dlg.Run() == gtk.RESPONSE_OK {
+		edited := *task
+		edited.Name, _ = nameEntry.GetText()
+		edited.Script, _ = scriptEntry.GetText()
+		edited.Schedule, _ = scheduleEntry.GetText()
+		edited.Profile = profileCombo.GetActiveText()
+		edited.Enabled = enabledCheck.GetActive()
+		if abs, err := filepath.Abs(edited.Script); err == nil && edited.Script != "" {
+			edited.Script = abs
+		}
+		if err := edited.Validate(others); err != nil {
+			msg := gtk.MessageDialogNew(dlg, gtk.DIALOG_MODAL, gtk.MESSAGE_ERROR, gtk.BUTTONS_OK, "%s", err.Error())
+			msg.Run()
+			msg.Destroy()
+			continue
+		}
+		*task = edited
+		return true
+	}
+	return false
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/phroun/pawscript/src/pkg/pawgui"
+	"github.com/phroun/pawscript/src/pkg/purfecterm"
+	purfectermgtk "github.com/phroun/pawscript/src/pkg/purfecterm-gtk"
+)
+
+// Session restore
+// When the launcher quits, or its last window closes, it saves the tab windows
+// open, and on the next launch offers to open them again, as session_restore
+// says. Each tab is remembered as it opens, with what's needed to open it again.
+
+// sessionTab is what the session keeps of an open tab
+type sessionTab struct {
+	tab      pawgui.SessionTab
+	terminal *purfectermgtk.Terminal
+	running  func() bool // Whether its script still runs, for script tabs
+}
+
+var (
+	// tabWindows are the open tab windows, in the order they opened
+	tabWindows []*tabWindow
+
+	// launcherScript is the path of the script last run in the launcher
+	// (guarded by scriptMu)
+	launcherScript string
+
+	// restoringScrollback is the snapshot for the next tab to open while a
+	// session is restored
+	restoringScrollback []byte
+
+	sessionOffered bool // Whether restoring the last session was offered yet
+)
+
+// remember records a tab that opened for the session, restoring what its
+// terminal showed if it's being restored
+func (tw *tabWindow) remember(page gtk.IWidget, tab pawgui.SessionTab, term *purfectermgtk.Terminal, running func() bool) {
+	key := page.ToWidget().Native()
+	tw.session[key] = &sessionTab{tab: tab, terminal: term, running: running}
+	page.ToWidget().Connect("destroy", func() {
+		delete(tw.session, key)
+	})
+
+	if restoringScrollback != nil {
+		if purfecterm.IsSnapshot(restoringScrollback) {
+			term.RestoreSnapshot(bytes.NewReader(restoringScrollback))
+		}
+		restoringScrollback = nil
+	}
+}
+
+// currentSession returns the tab windows open and the script the launcher runs
+func currentSession() *pawgui.Session {
+	session := &pawgui.Session{}
+	scriptMu.Lock()
+	if scriptRunning {
+		session.LauncherScript = launcherScript
+	}
+	scriptMu.Unlock()
+
+	scrollback := configHelper.GetSessionScrollback()
+	for _, tw := range tabWindows {
+		x, y := tw.win.GetPosition()
+		width, height := tw.win.GetSize()
+		window := pawgui.SessionWindow{X: x, Y: y, Width: width, Height: height,
+			Current: tw.notebook.GetCurrentPage()}
+		for i := 0; i < tw.notebook.GetNPages(); i++ {
+			page, err := tw.notebook.GetNthPage(i)
+			if err != nil || page == nil {
+				continue
+			}
+			open := tw.session[page.ToWidget().Native()]
+			if open == nil {
+				continue
+			}
+			tab := open.tab
+			if tab.Kind == pawgui.SessionScript && (open.running == nil || !open.running()) {
+				// Its script finished, leaving a console
+				tab = pawgui.SessionTab{Kind: pawgui.SessionConsole}
+			}
+			if scrollback {
+				var snapshot bytes.Buffer
+				if open.terminal.SaveSnapshot(&snapshot) == nil {
+					tab.Scrollback = snapshot.Bytes()
+				}
+			}
+			window.Tabs = append(window.Tabs, tab)
+		}
+		session.Windows = append(session.Windows, window)
+	}
+	return session
+}
+
+// saveSession saves the session for the next launch
+func saveSession() {
+	if err := pawgui.SaveSession(currentSession()); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to save session: %v\n", err)
+	}
+}
+
+// saveSessionIfLast saves the session when the last window is closing, which
+// ends the launcher. Connected to each window's delete-event.
+func saveSessionIfLast() bool {
+	if app != nil && app.GetWindows().Length() <= 1 {
+		saveSession()
+	}
+	return false
+}
+
+// offerSessionRestore opens the tab windows of the last session, once, if
+// session_restore says to or the user agrees
+func offerSessionRestore() {
+	if sessionOffered {
+		return
+	}
+	sessionOffered = true
+
+	session, err := pawgui.LoadSession()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load session: %v\n", err)
+	}
+	if session.Empty() {
+		return
+	}
+	// Offered once: it's saved again when the launcher quits
+	pawgui.ClearSession()
+
+	switch configHelper.GetSessionRestore() {
+	case "never":
+		return
+	case "ask":
+		dialog := gtk.MessageDialogNew(
+			dialogParent(),
+			gtk.DIALOG_MODAL|gtk.DIALOG_DESTROY_WITH_PARENT,
+			gtk.MESSAGE_QUESTION,
+			gtk.BUTTONS_YES_NO,
+			tr("Restore the previous session? (%s)"),
+			session.Describe(),
+		)
+		dialog.SetTitle(tr("Restore Session"))
+		response := dialog.Run()
+		dialog.Destroy()
+		if response != gtk.RESPONSE_YES {
+			return
+		}
+	}
+	restoreSession(session)
+}
+
+// restoreSession opens the tab windows of a saved session where they were, and
+// runs the launcher's script again
+func restoreSession(session *pawgui.Session) {
+	for _, window := range session.Windows {
+		if len(window.Tabs) == 0 {
+			continue
+		}
+		currentTabWindow = nil // Each saved window opens a new one
+		for _, tab := range window.Tabs {
+			restoringScrollback = tab.Scrollback
+			switch {
+			case tab.Kind == pawgui.SessionShell:
+				openShellTab(tab.Dir)
+			case tab.Kind == pawgui.SessionScript && fileExists(tab.Script):
+				openScriptTab(tab.Script)
+			default:
+				createBlankConsoleTab(false)
+			}
+			restoringScrollback = nil
+		}
+
+		tw := currentTabWindow
+		if tw == nil {
+			continue
+		}
+		if window.Width > 0 && window.Height > 0 {
+			tw.win.Resize(window.Width, window.Height)
+		}
+		if window.X >= 0 && window.Y >= 0 {
+			tw.win.Move(window.X, window.Y)
+		}
+		if window.Current < tw.notebook.GetNPages() {
+			tw.notebook.SetCurrentPage(window.Current)
+		}
+	}
+
+	if session.LauncherScript != "" && fileExists(session.LauncherScript) {
+		runScript(session.LauncherScript)
+	}
+}
+
+// fileExists reports whether a script is still there to run again
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
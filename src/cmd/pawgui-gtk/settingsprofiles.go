@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/phroun/pawscript"
+	"github.com/phroun/pawscript/src/pkg/pawgui"
+	"github.com/sqweek/dialog"
+)
+
+// Settings bundles and profiles
+// The hamburger menu's Export Settings... and Import Settings... write and read
+// a bundle of the settings worth carrying between machines, and its Settings
+// Profiles submenu switches between bundles saved by name (see
+// pawgui/settingsbundle.go). Imported settings apply as those of a reloaded
+// config do; shortcuts, as when set in Settings, apply to windows opened
+// afterwards. Sandbox settings that would let launched scripts do more apply only
+// once the user confirms them.
+
+// exportSettingsDialog shows a file dialog and writes a settings bundle to it
+func exportSettingsDialog() {
+	filename, err := dialog.File().
+		Title(tr("Export Settings")).
+		Filter("PSL files", "psl").
+		Filter("All files", "*").
+		SetStartFile("pawgui-settings.psl").
+		Save()
+	if err != nil || filename == "" {
+		return
+	}
+	if err := pawgui.WriteSettingsBundle(filename, appConfig); err != nil {
+		dialog.Message(tr("Failed to export settings: %v"), err).Title(tr("Error")).Error()
+	}
+}
+
+// importSettingsDialog shows a file dialog and imports the settings bundle chosen
+func importSettingsDialog(parent gtk.IWindow) {
+	filename, err := dialog.File().
+		Title(tr("Import Settings")).
+		Filter("PSL files", "psl").
+		Filter("All files", "*").
+		Load()
+	if err != nil || filename == "" {
+		return
+	}
+	bundle, err := pawgui.ReadSettingsBundle(filename)
+	if err != nil {
+		dialog.Message(tr("Failed to import settings: %v"), err).Title(tr("Error")).Error()
+		return
+	}
+
+	if !confirmSandboxWidening(parent, pawgui.SandboxWidening(appConfig, bundle)) {
+		return
+	}
+
+	origUIScale := getUIScale()
+	problems, err := pawgui.ImportSettings(appConfig, bundle)
+	if err != nil {
+		dialog.Message(tr("Failed to import settings: %v"), err).Title(tr("Error")).Error()
+		return
+	}
+	// The settings are no longer those of the profile last used
+	delete(appConfig, "settings_profile")
+	settingsImported(parent, origUIScale, problems)
+}
+
+// settingsImported applies and saves the settings just imported, ui_scale having
+// been origUIScale, and reports those dropped for not fitting the schema
+func settingsImported(parent gtk.IWindow, origUIScale float64, problems []pawscript.PSLSchemaError) {
+	configHelper.PopulateDefaults()
+	applyWindowTheme()
+	applyConsoleTheme()
+	if getUIScale() != origUIScale {
+		applyUIScale()
+	}
+	applyFontSettings()
+	saveConfig(appConfig)
+
+	if len(problems) > 0 {
+		lines := make([]string, len(problems))
+		for i, problem := range problems {
+			lines[i] = problem.Error()
+		}
+		msg := gtk.MessageDialogNew(parent, gtk.DIALOG_MODAL|gtk.DIALOG_DESTROY_WITH_PARENT,
+			gtk.MESSAGE_WARNING, gtk.BUTTONS_OK, "%s",
+			tr("These settings were left out:\n\n")+strings.Join(lines, "\n"))
+		msg.SetTitle(tr("Import Settings"))
+		msg.Run()
+		msg.Destroy()
+	}
+}
+
+// useSettingsProfile switches to the settings profile with the name given
+func useSettingsProfile(parent gtk.IWindow, name string) {
+	bundle, err := pawgui.ReadSettingsProfile(name)
+	if err != nil {
+		dialog.Message(tr("Failed to switch to profile %q: %v"), name, err).Title(tr("Error")).Error()
+		return
+	}
+	if !confirmSandboxWidening(parent, pawgui.SandboxWidening(appConfig, bundle)) {
+		return
+	}
+
+	origUIScale := getUIScale()
+	problems, err := configHelper.UseSettingsProfileBundle(name, bundle)
+	if err != nil {
+		dialog.Message(tr("Failed to switch to profile %q: %v"), name, err).Title(tr("Error")).Error()
+		return
+	}
+	settingsImported(parent, origUIScale, problems)
+}
+
+// confirmSandboxWidening shows the ways settings about to apply would let
+// launched scripts do more and asks the user to confirm them, there being
+// nothing to confirm if there are none
+func confirmSandboxWidening(parent gtk.IWindow, changes []string) bool {
+	if len(changes) == 0 {
+		return true
+	}
+	msg := gtk.MessageDialogNew(parent, gtk.DIALOG_MODAL|gtk.DIALOG_DESTROY_WITH_PARENT,
+		gtk.MESSAGE_WARNING, gtk.BUTTONS_OK_CANCEL, "%s",
+		tr("These settings give scripts run from the launcher more access:\n\n")+strings.Join(changes, "\n"))
+	msg.SetTitle(tr("Confirm Sandbox Changes"))
+	confirmed := msg.Run() == gtk.RESPONSE_OK
+	msg.Destroy()
+	return confirmed
+}
+
+// saveSettingsProfileDialog asks for a name and saves the settings as the
+// settings profile with it
+func saveSettingsProfileDialog(parent gtk.IWindow) {
+	name, ok := promptText(parent, tr("Save Settings Profile"),
+		tr("Save the current settings as the profile named:"), configHelper.GetSettingsProfile())
+	if !ok || strings.TrimSpace(name) == "" {
+		return
+	}
+	if err := configHelper.SaveSettingsProfile(name); err != nil {
+		dialog.Message(tr("Failed to save profile: %v"), err).Title(tr("Error")).Error()
+		return
+	}
+	saveConfig(appConfig)
+}
+
+// deleteSettingsProfile deletes the settings profile with the name given, once
+// the user confirms it
+func deleteSettingsProfile(parent gtk.IWindow, name string) {
+	msg := gtk.MessageDialogNew(parent, gtk.DIALOG_MODAL|gtk.DIALOG_DESTROY_WITH_PARENT,
+		gtk.MESSAGE_QUESTION, gtk.BUTTONS_OK_CANCEL, "%s",
+		fmt.Sprintf(tr("Delete the settings profile %q? The current settings stay as they are."), name))
+	msg.SetTitle(tr("Delete Settings Profile"))
+	confirmed := msg.Run() == gtk.RESPONSE_OK
+	msg.Destroy()
+	if !confirmed {
+		return
+	}
+	if err := configHelper.DeleteSettingsProfile(name); err != nil {
+		dialog.Message(tr("Failed to delete profile: %v"), err).Title(tr("Error")).Error()
+		return
+	}
+	saveConfig(appConfig)
+}
+
+// createSettingsProfilesMenu creates the Settings Profiles submenu: the
+// profiles, the current one checked, and the items saving and deleting them
+func createSettingsProfilesMenu(parent gtk.IWindow) *gtk.Menu {
+	menu, _ := gtk.MenuNew()
+	current := configHelper.GetSettingsProfile()
+	profiles := pawgui.ListSettingsProfiles()
+	for _, name := range profiles {
+		name := name
+		if name == current {
+			menu.Append(createMenuItemWithIcon(checkedIconSVG, name, func() {
+				useSettingsProfile(parent, name)
+			}))
+		} else {
+			menu.Append(createMenuItemWithGutter(name, func() {
+				useSettingsProfile(parent, name)
+			}))
+		}
+	}
+	if len(profiles) > 0 {
+		sep, _ := gtk.SeparatorMenuItemNew()
+		menu.Append(sep)
+	}
+
+	menu.Append(createMenuItemWithGutter(tr("Save as Profile..."), func() {
+		saveSettingsProfileDialog(parent)
+	}))
+	if len(profiles) > 0 {
+		deleteItem := createMenuItemWithGutter(tr("Delete Profile"), nil)
+		deleteMenu, _ := gtk.MenuNew()
+		for _, name := range profiles {
+			name := name
+			deleteMenu.Append(createMenuItemWithGutter(name+"...", func() {
+				deleteSettingsProfile(parent, name)
+			}))
+		}
+		deleteItem.SetSubmenu(deleteMenu)
+		menu.Append(deleteItem)
+	}
+	menu.ShowAll()
+	return menu
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/phroun/pawscript/src/pkg/pawgui"
+	purfectermgtk "github.com/phroun/pawscript/src/pkg/purfecterm-gtk"
+)
+
+// Keyboard shortcuts
+// The Shortcuts tab of the settings dialog sets the shortcut of each action (see
+// pawgui/shortcuts.go): clicking one's button and pressing keys captures them,
+// Backspace removes it and Esc leaves it as it was. Shortcuts that clash are
+// listed under them, and must be sorted out before the settings are saved. The
+// shortcuts saved apply to windows opened afterwards.
+
+// shortcutFromKeyGTK returns the shortcut for a key press, "" for a key that
+// can't be one
+func shortcutFromKeyGTK(keyval uint, state gdk.ModifierType) string {
+	name := ""
+	switch keyval {
+	case gdk.KEY_Escape:
+		name = "Esc"
+	case gdk.KEY_Tab, gdk.KEY_ISO_Left_Tab:
+		name = "Tab"
+	case gdk.KEY_Return, gdk.KEY_KP_Enter:
+		name = "Enter"
+	case gdk.KEY_space:
+		name = "Space"
+	case gdk.KEY_BackSpace:
+		name = "Backspace"
+	case gdk.KEY_Delete, gdk.KEY_KP_Delete:
+		name = "Delete"
+	case gdk.KEY_Insert, gdk.KEY_KP_Insert:
+		name = "Insert"
+	case gdk.KEY_Home, gdk.KEY_KP_Home:
+		name = "Home"
+	case gdk.KEY_End, gdk.KEY_KP_End:
+		name = "End"
+	case gdk.KEY_Page_Up, gdk.KEY_KP_Page_Up:
+		name = "PageUp"
+	case gdk.KEY_Page_Down, gdk.KEY_KP_Page_Down:
+		name = "PageDown"
+	case gdk.KEY_Up, gdk.KEY_KP_Up:
+		name = "Up"
+	case gdk.KEY_Down, gdk.KEY_KP_Down:
+		name = "Down"
+	case gdk.KEY_Left, gdk.KEY_KP_Left:
+		name = "Left"
+	case gdk.KEY_Right, gdk.KEY_KP_Right:
+		name = "Right"
+	default:
+		if keyval >= gdk.KEY_F1 && keyval <= gdk.KEY_F12 {
+			name = fmt.Sprintf("F%d", keyval-gdk.KEY_F1+1)
+		} else if r := gdk.KeyvalToUnicode(keyval); r != 0 {
+			name = string(r)
+		}
+	}
+	if name == "" {
+		return ""
+	}
+	return pawgui.ShortcutString(name,
+		state&gdk.CONTROL_MASK != 0,
+		state&gdk.MOD1_MASK != 0,
+		state&gdk.SHIFT_MASK != 0,
+		state&(gdk.META_MASK|gdk.SUPER_MASK) != 0)
+}
+
+// isModifierKeyGTK returns whether a key is a modifier, which a shortcut holds
+// rather than ends with
+func isModifierKeyGTK(keyval uint) bool {
+	switch keyval {
+	case gdk.KEY_Shift_L, gdk.KEY_Shift_R, gdk.KEY_Control_L, gdk.KEY_Control_R,
+		gdk.KEY_Alt_L, gdk.KEY_Alt_R, gdk.KEY_Meta_L, gdk.KEY_Meta_R,
+		gdk.KEY_Super_L, gdk.KEY_Super_R, gdk.KEY_ISO_Level3_Shift, gdk.KEY_Caps_Lock:
+		return true
+	}
+	return false
+}
+
+// consoleShortcuts returns what the console shortcuts do in a terminal, for
+// its key map
+func consoleShortcuts(term *purfectermgtk.Terminal) map[string]func() {
+	onMain := func(fn func()) func() {
+		return func() {
+			glib.IdleAdd(fn)
+		}
+	}
+	return map[string]func(){
+		pawgui.ShortcutCopy:      onMain(term.CopySelection),
+		pawgui.ShortcutPaste:     onMain(term.PasteClipboard),
+		pawgui.ShortcutFind:      onMain(term.ShowFindBar),
+		pawgui.ShortcutZoomIn:    onMain(func() { term.ZoomBy(1) }),
+		pawgui.ShortcutZoomOut:   onMain(func() { term.ZoomBy(-1) }),
+		pawgui.ShortcutZoomReset: onMain(func() { term.ZoomBy(0) }),
+	}
+}
+
+// shortcutsTab is the Shortcuts tab of the settings dialog
+type shortcutsTab struct {
+	box       *gtk.Box
+	shortcuts map[string]string // Shortcut being set for each action
+	problems  *gtk.Label
+}
+
+// newShortcutsTab creates the Shortcuts tab, showing the configured shortcuts
+func newShortcutsTab() *shortcutsTab {
+	tab := &shortcutsTab{shortcuts: configHelper.GetShortcuts()}
+
+	tab.box, _ = gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 8)
+	tab.box.SetMarginStart(12)
+	tab.box.SetMarginEnd(12)
+	tab.box.SetMarginTop(12)
+	tab.box.SetMarginBottom(12)
+
+	for _, action := range pawgui.ShortcutActions {
+		tab.box.PackStart(tab.newRow(action), false, false, 0)
+	}
+
+	tab.problems, _ = gtk.LabelNew("")
+	tab.problems.SetHAlign(gtk.ALIGN_START)
+	tab.problems.SetLineWrap(true)
+	tab.box.PackStart(tab.problems, false, false, 4)
+
+	hint, _ := gtk.LabelNew(tr("Click a shortcut and press the keys for it: Backspace removes it, Esc leaves it. ") +
+		tr("Shortcuts apply to windows opened after saving."))
+	hint.SetHAlign(gtk.ALIGN_START)
+	hint.SetLineWrap(true)
+	hint.SetMaxWidthChars(50)
+	if ctx, err := hint.GetStyleContext(); err == nil {
+		ctx.AddClass("dim-label")
+	}
+	tab.box.PackEnd(hint, false, false, 0)
+
+	tab.update()
+	return tab
+}
+
+// newRow creates the row of an action: its label, the button capturing its
+// shortcut and a button restoring its default
+func (tab *shortcutsTab) newRow(action pawgui.ShortcutAction) *gtk.Box {
+	row, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 12)
+	label, _ := gtk.LabelNew(tr(action.Label) + ":")
+	label.SetHAlign(gtk.ALIGN_START)
+	label.SetWidthChars(20)
+	row.PackStart(label, false, false, 0)
+
+	button, _ := gtk.ButtonNew()
+	capturing := false
+	showShortcut := func() {
+		if capturing {
+			button.SetLabel(tr("Press a shortcut..."))
+		} else if shortcut := tab.shortcuts[action.Name]; shortcut != "" {
+			button.SetLabel(shortcut)
+		} else {
+			button.SetLabel(tr("None"))
+		}
+	}
+	setShortcut := func(shortcut string) {
+		capturing = false
+		tab.shortcuts[action.Name] = shortcut
+		showShortcut()
+		tab.update()
+	}
+	button.Connect("clicked", func() {
+		capturing = true
+		showShortcut()
+	})
+	button.Connect("key-press-event", func(b *gtk.Button, event *gdk.Event) bool {
+		if !capturing {
+			return false
+		}
+		keyEvent := gdk.EventKeyNewFromEvent(event)
+		keyval := keyEvent.KeyVal()
+		state := gdk.ModifierType(keyEvent.State()) &
+			(gdk.CONTROL_MASK | gdk.SHIFT_MASK | gdk.MOD1_MASK | gdk.META_MASK | gdk.SUPER_MASK)
+		switch {
+		case isModifierKeyGTK(keyval):
+			// Wait for the key the modifiers go with
+		case state == 0 && keyval == gdk.KEY_Escape:
+			capturing = false
+			showShortcut()
+		case state == 0 && keyval == gdk.KEY_BackSpace:
+			setShortcut("")
+		default:
+			if shortcut := shortcutFromKeyGTK(keyval, state); shortcut != "" {
+				setShortcut(shortcut)
+			}
+		}
+		return true
+	})
+	button.Connect("focus-out-event", func() bool {
+		if capturing {
+			capturing = false
+			showShortcut()
+		}
+		return false
+	})
+	showShortcut()
+	row.PackStart(button, true, true, 0)
+
+	defaultButton, _ := gtk.ButtonNewWithLabel(tr("Default"))
+	defaultButton.SetTooltipText(pawgui.GetDefaultShortcut(action.Name))
+	defaultButton.Connect("clicked", func() {
+		setShortcut(pawgui.GetDefaultShortcut(action.Name))
+	})
+	row.PackStart(defaultButton, false, false, 0)
+	return row
+}
+
+// update lists the problems with the shortcuts being set
+func (tab *shortcutsTab) update() {
+	if problems := tab.check(); len(problems) > 0 {
+		tab.problems.SetText(strings.Join(problems, "\n"))
+	} else {
+		tab.problems.SetText("No conflicts.")
+	}
+}
+
+// check returns the problems with the shortcuts being set
+func (tab *shortcutsTab) check() []string {
+	return pawgui.CheckShortcuts(tab.shortcuts, configHelper.GetKeyMacros())
+}
+
+// save sets the shortcuts in the config
+func (tab *shortcutsTab) save() {
+	for _, action := range pawgui.ShortcutActions {
+		configHelper.SetShortcut(action.Name, tab.shortcuts[action.Name])
+	}
+}
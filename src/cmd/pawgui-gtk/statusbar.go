@@ -0,0 +1,73 @@
+package main
+
+import (
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/gotk3/gotk3/pango"
+	"github.com/phroun/pawscript/src/pkg/pawgui"
+)
+
+// Status bar
+// Script and console windows show a thin bar under the terminal, with the left
+// and right segments scripts set with status_set, and the progress bar they show
+// with progress. It hides while it has nothing to show.
+
+// statusBar is a script or console window's status bar
+type statusBar struct {
+	box      *gtk.Box
+	left     *gtk.Label
+	right    *gtk.Label
+	progress *gtk.ProgressBar
+	status   *pawgui.StatusBar
+}
+
+// newStatusBar creates a status bar, hidden until a script sets it
+func newStatusBar() *statusBar {
+	b := &statusBar{}
+
+	b.box, _ = gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 8)
+	b.box.SetMarginStart(8)
+	b.box.SetMarginEnd(4)
+	b.box.SetMarginTop(2)
+	b.box.SetMarginBottom(2)
+
+	b.left, _ = gtk.LabelNew("")
+	b.left.SetEllipsize(pango.ELLIPSIZE_END)
+	b.left.SetXAlign(0)
+	b.box.PackStart(b.left, true, true, 0)
+
+	b.right, _ = gtk.LabelNew("")
+	b.right.SetXAlign(1)
+	b.box.PackEnd(b.right, false, false, 0)
+
+	b.progress, _ = gtk.ProgressBarNew()
+	b.progress.SetSizeRequest(120, -1)
+	b.progress.SetVAlign(gtk.ALIGN_CENTER)
+	b.box.PackEnd(b.progress, false, false, 0)
+
+	b.status = pawgui.NewStatusBar(func() {
+		// Called on the script's goroutine
+		glib.IdleAdd(b.update)
+	})
+
+	// Shown when a script sets it, not with the rest of the window
+	b.box.ShowAll()
+	b.box.Hide()
+	b.box.SetNoShowAll(true)
+	b.progress.Hide()
+	b.progress.SetNoShowAll(true)
+	return b
+}
+
+// update shows the status bar's text and progress, hiding it while there are
+// none
+func (b *statusBar) update() {
+	left, right := b.status.Text()
+	fraction, progress := b.status.Progress()
+	b.left.SetText(left)
+	b.left.SetTooltipText(left)
+	b.right.SetText(right)
+	b.progress.SetFraction(fraction)
+	b.progress.SetVisible(progress)
+	b.box.SetVisible(left != "" || right != "" || progress)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/gotk3/gotk3/glib"
+	"github.com/phroun/pawscript/src/pkg/pawgui"
+)
+
+// System theme
+// With theme or term_theme set to auto, windows, icons and console palettes
+// switch when the OS switches between dark and light (see pawgui/systemtheme.go).
+
+var followSystemThemeOnce sync.Once
+
+// followSystemTheme reads the OS's theme for detectSystemDarkMode and applies the
+// auto themes again whenever it changes. Where the OS can't be watched, the theme
+// is judged by GTK's colors as windows open.
+func followSystemTheme() {
+	followSystemThemeOnce.Do(func() {
+		pawgui.WatchSystemTheme(func(dark bool) {
+			glib.IdleAdd(systemThemeChanged)
+		})
+	})
+}
+
+// systemThemeChanged applies the auto themes after the OS's theme changes
+func systemThemeChanged() {
+	if configHelper.GetTheme() == pawgui.ThemeAuto {
+		applyWindowTheme()
+	}
+	if configHelper.IsTermThemeAuto() {
+		applyConsoleTheme()
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"runtime"
+	"sync"
+
+	"fyne.io/systray"
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/phroun/pawscript/src/pkg/pawgui"
+)
+
+// System tray
+// The launcher shows an icon in the system tray whose menu shows the launcher,
+// opens a console or runs a recent script, and hides there when it's minimized if
+// minimize_to_tray is set. GTK 3 has no tray icon apart from the deprecated
+// GtkStatusIcon, so it goes through systray, which uses StatusNotifierItem on
+// Linux and the native tray elsewhere. Scripts set its tooltip and badge with
+// tray_set.
+
+// trayIconSize is the size the tray icon is drawn at; the tray scales it down
+const trayIconSize = 64
+
+var (
+	trayOnce  sync.Once
+	trayShown bool                // Whether the tray icon was set up (main thread)
+	trayItems []*systray.MenuItem // Entries of the Recent Scripts submenu (main thread)
+	trayMenu  *systray.MenuItem   // The Recent Scripts submenu (main thread)
+
+	// launcherTray holds the tray icon's tooltip and badge
+	launcherTray *pawgui.Tray
+)
+
+// init creates launcherTray, whose updates refer back to it
+func init() {
+	launcherTray = pawgui.NewTray(func() {
+		// Called on the script's goroutine
+		glib.IdleAdd(updateTrayIcon)
+	})
+}
+
+// startTray puts the launcher's icon in the system tray
+func startTray() {
+	trayOnce.Do(func() {
+		start, _ := systray.RunWithExternalLoop(onTrayReady, nil)
+		start()
+	})
+}
+
+// onTrayReady builds the tray icon's menu, on a goroutine of systray's
+func onTrayReady() {
+	systray.SetOnTapped(func() {
+		glib.IdleAdd(showOrCreateLauncher)
+	})
+
+	onClick(systray.AddMenuItem("Show Launcher", ""), showOrCreateLauncher)
+	onClick(systray.AddMenuItem("New Console", ""), func() {
+		createBlankConsoleTab(true)
+	})
+	recentMenu := systray.AddMenuItem("Recent Scripts", "")
+	recentItems := make([]*systray.MenuItem, pawgui.MaxRecentScripts)
+	for i := range recentItems {
+		index := i
+		recentItems[i] = recentMenu.AddSubMenuItem("", "")
+		recentItems[i].Hide()
+		onClick(recentItems[i], func() {
+			if scripts := configHelper.GetRecentScripts(); index < len(scripts) {
+				showOrCreateLauncher()
+				runScript(scripts[index])
+			}
+		})
+	}
+	systray.AddSeparator()
+	onClick(systray.AddMenuItem("Quit", ""), func() {
+		quitApplication(dialogParent())
+	})
+
+	glib.IdleAdd(func() {
+		trayMenu, trayItems = recentMenu, recentItems
+		trayShown = true
+		refreshTrayMenu()
+		updateTrayIcon()
+	})
+}
+
+// onClick runs action on the main thread each time a tray menu entry is chosen
+func onClick(item *systray.MenuItem, action func()) {
+	go func() {
+		for range item.ClickedCh {
+			glib.IdleAdd(action)
+		}
+	}()
+}
+
+// refreshTrayMenu shows the scripts the launcher ran last in the Recent Scripts
+// submenu
+func refreshTrayMenu() {
+	if !trayShown {
+		return
+	}
+	scripts := configHelper.GetRecentScriptRuns()
+	for i, item := range trayItems {
+		if i < len(scripts) {
+			item.SetTitle(scripts[i].Label())
+			item.SetTooltip(scripts[i].Path)
+			item.Show()
+		} else {
+			item.Hide()
+		}
+	}
+	if len(scripts) > 0 {
+		trayMenu.Enable()
+	} else {
+		trayMenu.Disable()
+	}
+}
+
+// updateTrayIcon shows the tray icon's tooltip and badge
+func updateTrayIcon() {
+	if !trayShown {
+		return
+	}
+	systray.SetTooltip(launcherTray.Tooltip())
+
+	pixbuf := createPixbufFromSVG(pawFileIconSVG, trayIconSize)
+	if pixbuf == nil {
+		return
+	}
+	var icon bytes.Buffer
+	if err := pixbuf.WritePNG(&icon, 9); err != nil {
+		return
+	}
+	data, err := pawgui.TrayIconPNG(icon.Bytes(), launcherTray.Badge())
+	if err != nil {
+		return
+	}
+	if runtime.GOOS == "windows" {
+		data = pngToICO(data, trayIconSize)
+	}
+	systray.SetIcon(data)
+}
+
+// pngToICO wraps a square PNG image in an ICO file, which is what the Windows
+// tray loads icons from
+func pngToICO(data []byte, size int) []byte {
+	var ico bytes.Buffer
+	// ICONDIR: reserved, type 1 (icon), one image
+	binary.Write(&ico, binary.LittleEndian, [3]uint16{0, 1, 1})
+	// ICONDIRENTRY: width and height (0 means 256), colors, reserved, planes,
+	// bits per pixel, size of the image, and its offset after the 22 byte header
+	dimension := byte(size % 256)
+	ico.Write([]byte{dimension, dimension, 0, 0})
+	binary.Write(&ico, binary.LittleEndian, [2]uint16{1, 32})
+	binary.Write(&ico, binary.LittleEndian, [2]uint32{uint32(len(data)), 22})
+	ico.Write(data)
+	return ico.Bytes()
+}
+
+// hideWhenMinimized hides the launcher in the tray when it's minimized, if
+// minimize_to_tray is set
+func hideWhenMinimized(win *gtk.ApplicationWindow, event *gdk.Event) bool {
+	state := gdk.EventWindowStateNewFromEvent(event)
+	iconified := state.NewWindowState()&gdk.WINDOW_STATE_ICONIFIED != 0
+	if state.ChangedMask()&gdk.WINDOW_STATE_ICONIFIED != 0 && iconified &&
+		trayShown && configHelper.GetMinimizeToTray() {
+		win.Hide()
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"runtime"
+
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/phroun/pawscript/src/pkg/pawgui"
+)
+
+// Script windows
+// Scripts build windows of their own with window_create and add labels, buttons,
+// sliders, rows and columns to them (see pawgui.RegisterWidgetCommands). The
+// windows stay open after the script ends, until the user or window_close closes
+// them.
+
+// gtkWidgetHost draws script windows with GTK widgets
+type gtkWidgetHost struct {
+	// Used on the main thread only
+	windows map[*pawgui.WidgetWindow]*gtk.Window
+	widgets map[*pawgui.Widget]gtk.IWidget
+}
+
+// scriptWidgets holds the windows scripts created, in any window or tab
+var scriptWidgets = pawgui.NewScriptWidgets(&gtkWidgetHost{
+	windows: make(map[*pawgui.WidgetWindow]*gtk.Window),
+	widgets: make(map[*pawgui.Widget]gtk.IWidget),
+})
+
+// newWidgetBox creates the box of a script's row or column
+func newWidgetBox(orientation gtk.Orientation) *gtk.Box {
+	box, _ := gtk.BoxNew(orientation, 6)
+	return box
+}
+
+// OpenWindow shows a script's new, empty window
+func (h *gtkWidgetHost) OpenWindow(window *pawgui.WidgetWindow) {
+	glib.IdleAdd(func() {
+		win, err := gtk.WindowNew(gtk.WINDOW_TOPLEVEL)
+		if err != nil {
+			return
+		}
+		win.SetTitle(window.Title)
+		width, height := -1, -1
+		if window.Width > 0 {
+			width = window.Width
+		}
+		if window.Height > 0 {
+			height = window.Height
+		}
+		win.SetDefaultSize(width, height)
+
+		root := newWidgetBox(gtk.ORIENTATION_VERTICAL)
+		root.SetBorderWidth(12)
+		win.Add(root)
+		h.windows[window] = win
+		h.widgets[window.Root] = root
+
+		win.Connect("destroy", func() {
+			delete(h.windows, window)
+			for widget := range h.widgets {
+				if widget.Window == window {
+					delete(h.widgets, widget)
+				}
+			}
+			// Clear references and force GC to prevent finalizer crash
+			runtime.GC()
+			scriptWidgets.WindowClosed(window)
+		})
+		win.ShowAll()
+	})
+}
+
+// AddWidget adds a widget to a script window's row or column
+func (h *gtkWidgetHost) AddWidget(parent, widget *pawgui.Widget) {
+	glib.IdleAdd(func() {
+		box, ok := h.widgets[parent].(*gtk.Box)
+		if !ok {
+			return // The window was closed
+		}
+
+		var child gtk.IWidget
+		expand := false
+		switch widget.Kind {
+		case pawgui.WidgetColumn:
+			child = newWidgetBox(gtk.ORIENTATION_VERTICAL)
+			expand = true
+		case pawgui.WidgetRow:
+			child = newWidgetBox(gtk.ORIENTATION_HORIZONTAL)
+			expand = true
+		case pawgui.WidgetLabel:
+			label, _ := gtk.LabelNew(widget.Text())
+			label.SetXAlign(0)
+			child = label
+		case pawgui.WidgetButton:
+			button, _ := gtk.ButtonNewWithLabel(widget.Text())
+			button.Connect("clicked", widget.Clicked)
+			child = button
+		case pawgui.WidgetSlider:
+			scale, _ := gtk.ScaleNewWithRange(gtk.ORIENTATION_HORIZONTAL, float64(widget.Min), float64(widget.Max), 1)
+			scale.SetValue(float64(widget.Value()))
+			scale.SetSizeRequest(160, -1)
+			scale.Connect("value-changed", func() {
+				widget.Moved(int(scale.GetValue()))
+			})
+			child = scale
+			expand = true
+		default:
+			return
+		}
+		h.widgets[widget] = child
+		box.PackStart(child, expand, true, 0)
+		box.ShowAll()
+	})
+}
+
+// UpdateWidget shows a widget's new text or value
+func (h *gtkWidgetHost) UpdateWidget(widget *pawgui.Widget) {
+	glib.IdleAdd(func() {
+		switch w := h.widgets[widget].(type) {
+		case *gtk.Label:
+			w.SetText(widget.Text())
+		case *gtk.Button:
+			w.SetLabel(widget.Text())
+		case *gtk.Scale:
+			w.SetValue(float64(widget.Value()))
+		}
+	})
+}
+
+// CloseWindow closes a script's window
+func (h *gtkWidgetHost) CloseWindow(window *pawgui.WidgetWindow) {
+	glib.IdleAdd(func() {
+		if win, ok := h.windows[window]; ok {
+			win.Destroy()
+		}
+	})
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"github.com/mappu/miqt/qt"
+	"github.com/mappu/miqt/qt/mainthread"
+	"github.com/phroun/pawscript"
+	"github.com/phroun/pawscript/src/pkg/pawgui"
+)
+
+// Debugger panel
+// Scripts run from the launcher stop at the breakpoints set by clicking the script
+// editor's gutter. The panel beside the terminal then shows where the script is,
+// its call stack and the variables in scope, with buttons to go on or step. It
+// stays up until the script finishes.
+
+// debuggerPanel is the launcher's debugger pane
+type debuggerPanel struct {
+	widget    *qt.QWidget
+	status    *qt.QLabel
+	buttons   []*qt.QPushButton // Enabled while a script is paused
+	stack     *qt.QPlainTextEdit
+	variables *qt.QPlainTextEdit
+}
+
+var (
+	launcherDebugger      *pawscript.Debugger
+	launcherDebugPanel    *debuggerPanel
+	launcherDebugSplitter *qt.QSplitter // Splits the debugger beside the launcher terminal
+)
+
+// newDebuggerPanel creates the debugger pane, hidden until a script pauses
+func newDebuggerPanel() *debuggerPanel {
+	p := &debuggerPanel{}
+
+	p.widget = qt.NewQWidget2()
+	layout := qt.NewQVBoxLayout2()
+	layout.SetContentsMargins(4, 0, 4, 4)
+	layout.SetSpacing(4)
+	p.widget.SetLayout(layout.QLayout)
+
+	p.status = qt.NewQLabel3(tr("Running"))
+	p.status.SetSizePolicy2(qt.QSizePolicy__Ignored, qt.QSizePolicy__Preferred)
+	layout.AddWidget(p.status.QWidget)
+
+	buttonRow := qt.NewQHBoxLayout2()
+	buttonRow.SetSpacing(4)
+	for _, b := range []struct {
+		label  string
+		action pawscript.DebugAction
+	}{
+		{"Continue", pawscript.DebugContinue},
+		{"Step In", pawscript.DebugStepIn},
+		{"Step Over", pawscript.DebugStepOver},
+		{"Step Out", pawscript.DebugStepOut},
+	} {
+		action := b.action
+		button := qt.NewQPushButton3(b.label)
+		button.OnClicked(func() { p.resume(action) })
+		button.SetEnabled(false)
+		buttonRow.AddWidget(button.QWidget)
+		p.buttons = append(p.buttons, button)
+	}
+	buttonRow.AddStretch()
+	layout.AddLayout(buttonRow.QLayout)
+
+	p.stack = p.addSection(layout, "Call Stack", 0)
+	p.variables = p.addSection(layout, "Variables", 1)
+
+	p.widget.Hide()
+	return p
+}
+
+// addSection adds a heading and a read-only text area under it
+func (p *debuggerPanel) addSection(layout *qt.QVBoxLayout, title string, stretch int) *qt.QPlainTextEdit {
+	heading := qt.NewQLabel3("<b>" + title + "</b>")
+	layout.AddWidget(heading.QWidget)
+
+	text := qt.NewQPlainTextEdit2()
+	text.SetReadOnly(true)
+	text.SetFont(qt.QFontDatabase_SystemFont(qt.QFontDatabase__FixedFont))
+	text.SetLineWrapMode(qt.QPlainTextEdit__NoWrap)
+	text.SetMinimumHeight(80)
+	layout.AddWidget2(text.QWidget, stretch)
+	return text
+}
+
+// newLauncherDebugger creates the debugger that launcher scripts run under
+func newLauncherDebugger() *pawscript.Debugger {
+	return pawscript.NewDebugger(func(pause *pawscript.DebugPause) {
+		// Called on the script's goroutine
+		mainthread.Start(func() {
+			if launcherDebugPanel != nil {
+				launcherDebugPanel.showPause(pause)
+			}
+		})
+	})
+}
+
+// showPause shows where a script paused, opening the panel if it was hidden
+func (p *debuggerPanel) showPause(pause *pawscript.DebugPause) {
+	if !p.widget.IsVisible() {
+		p.widget.Show()
+		width := launcherDebugSplitter.Width()
+		panelWidth := min(320, width/2)
+		launcherDebugSplitter.SetSizes([]int{width - panelWidth, panelWidth})
+	}
+	p.status.SetText(pawgui.DebugPauseStatus(pause))
+	p.status.SetToolTip(pause.Command)
+	p.stack.SetPlainText(pawgui.DebugStackText(pause))
+	p.variables.SetPlainText(pawgui.DebugVariablesText(pause))
+	for _, button := range p.buttons {
+		button.SetEnabled(true)
+	}
+	if launcherEditor != nil {
+		launcherEditor.showPaused(pause.File, pause.Line)
+	}
+}
+
+// resume lets the paused script go on
+func (p *debuggerPanel) resume(action pawscript.DebugAction) {
+	p.status.SetText(tr("Running"))
+	for _, button := range p.buttons {
+		button.SetEnabled(false)
+	}
+	if launcherEditor != nil {
+		launcherEditor.showPaused("", 0)
+	}
+	launcherDebugger.Resume(action)
+}
+
+// scriptFinished closes the panel once the script it was stepping through ends
+func (p *debuggerPanel) scriptFinished() {
+	p.status.SetText(tr("Running"))
+	p.stack.SetPlainText("")
+	p.variables.SetPlainText("")
+	for _, button := range p.buttons {
+		button.SetEnabled(false)
+	}
+	p.widget.Hide()
+	if launcherEditor != nil {
+		launcherEditor.showPaused("", 0)
+	}
+}
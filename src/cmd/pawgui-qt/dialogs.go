@@ -0,0 +1,85 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/mappu/miqt/qt"
+	"github.com/mappu/miqt/qt/mainthread"
+	"github.com/phroun/pawscript"
+)
+
+// Script dialogs
+// Scripts that IMPORT gui ask the user things with gui_alert, gui_confirm,
+// gui_prompt, gui_file_open and gui_file_save. The interpreter calls guiDialogs
+// from the script's goroutine; each dialog runs on the main thread, over the
+// window the user is in, while the script waits for the answer.
+
+// guiDialogs shows the dialogs scripts ask for (Config.Dialogs)
+type guiDialogs struct{}
+
+// dialogParent returns the window a script's dialog should appear over
+func dialogParent() *qt.QWidget {
+	if win := qt.QApplication_ActiveWindow(); win != nil {
+		return win
+	}
+	if mainWindow != nil {
+		return mainWindow.QWidget
+	}
+	return nil
+}
+
+// Alert shows a message until the user dismisses it
+func (guiDialogs) Alert(title, message string) {
+	mainthread.Wait(func() {
+		qt.QMessageBox_Information(dialogParent(), title, message)
+	})
+}
+
+// Confirm asks a question, returning whether the user chose OK
+func (guiDialogs) Confirm(title, message string) bool {
+	return mainthread.Wait2(func() bool {
+		answer := qt.QMessageBox_Question6(dialogParent(), title, message,
+			qt.QMessageBox__Ok|qt.QMessageBox__Cancel, qt.QMessageBox__Ok)
+		return answer == qt.QMessageBox__Ok
+	})
+}
+
+// Prompt asks for a line of text, starting with initial
+func (guiDialogs) Prompt(title, message, initial string) (string, bool) {
+	var text string
+	var ok bool
+	mainthread.Wait(func() {
+		text = qt.QInputDialog_GetText4(dialogParent(), title, message, qt.QLineEdit__Normal, initial, &ok)
+	})
+	return text, ok
+}
+
+// FileOpen asks for an existing file
+func (guiDialogs) FileOpen(options pawscript.FileDialogOptions) (string, bool) {
+	path := mainthread.Wait2(func() string {
+		return qt.QFileDialog_GetOpenFileName4(dialogParent(), options.Title, options.Dir, fileDialogFilter(options))
+	})
+	return path, path != ""
+}
+
+// FileSave asks where to save a file
+func (guiDialogs) FileSave(options pawscript.FileDialogOptions) (string, bool) {
+	start := options.Dir
+	if options.Name != "" {
+		start = filepath.Join(options.Dir, options.Name)
+	}
+	path := mainthread.Wait2(func() string {
+		return qt.QFileDialog_GetSaveFileName4(dialogParent(), options.Title, start, fileDialogFilter(options))
+	})
+	return path, path != ""
+}
+
+// fileDialogFilter returns the name filter for a script's file dialog
+func fileDialogFilter(options pawscript.FileDialogOptions) string {
+	if len(options.Patterns) == 0 {
+		return "All files (*)"
+	}
+	patterns := strings.Join(options.Patterns, " ")
+	return patterns + " (" + patterns + ");;All files (*)"
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"unicode/utf16"
+
+	"github.com/mappu/miqt/qt"
+	"github.com/phroun/pawscript/src/pkg/pawgui"
+)
+
+// Script editor pane
+// The launcher can show an editor above its terminal, so a quick edit and run
+// doesn't need an external editor. It colors PawScript as it's typed (see
+// pawgui.HighlightLine), numbers the lines in a gutter beside the text, and its
+// Run button saves the buffer and runs it as the file browser's Run does.
+// Clicking a line's number sets or clears a breakpoint on it for the debugger.
+
+// scriptEditor is the launcher's editor pane
+type scriptEditor struct {
+	widget      *qt.QWidget
+	edit        *qt.QPlainTextEdit
+	gutter      *qt.QWidget
+	highlighter *qt.QSyntaxHighlighter // Kept so it lives as long as the editor
+	nameLabel   *qt.QLabel
+	formats     map[pawgui.HighlightKind]*qt.QTextCharFormat
+	path        string // "" until the buffer is first saved
+	pausedLine  int    // Line the debugger is paused on, or 0
+}
+
+var (
+	launcherEditor         *scriptEditor
+	launcherEditorSplitter *qt.QSplitter // Splits the editor above the launcher terminal
+)
+
+// getLauncherEditorShown returns whether the launcher shows the script editor
+func getLauncherEditorShown() bool {
+	return appConfig.GetBool("launcher_editor", false)
+}
+
+// saveLauncherEditorShown saves whether the launcher shows the script editor
+func saveLauncherEditorShown(shown bool) {
+	appConfig.Set("launcher_editor", shown)
+	saveConfig(appConfig)
+}
+
+// newScriptEditor creates the editor pane, hidden until it's toggled on
+func newScriptEditor() *scriptEditor {
+	e := &scriptEditor{formats: make(map[pawgui.HighlightKind]*qt.QTextCharFormat)}
+
+	e.widget = qt.NewQWidget2()
+	layout := qt.NewQVBoxLayout2()
+	layout.SetContentsMargins(0, 0, 0, 4)
+	layout.SetSpacing(4)
+	e.widget.SetLayout(layout.QLayout)
+
+	// Top row: file name, then the buttons
+	topRow := qt.NewQHBoxLayout2()
+	topRow.SetSpacing(4)
+	e.nameLabel = qt.NewQLabel3("")
+	e.nameLabel.SetSizePolicy2(qt.QSizePolicy__Ignored, qt.QSizePolicy__Preferred)
+	topRow.AddWidget2(e.nameLabel.QWidget, 1)
+	for _, b := range []struct {
+		label   string
+		clicked func()
+	}{
+		{"New", e.newScript},
+		{"Open...", e.open},
+		{"Save", func() { e.save() }},
+		{"Run", e.run},
+	} {
+		button := qt.NewQPushButton3(b.label)
+		button.OnClicked(b.clicked)
+		topRow.AddWidget(button.QWidget)
+	}
+	layout.AddLayout(topRow.QLayout)
+
+	// The text, with the line number gutter in its left margin
+	e.edit = qt.NewQPlainTextEdit2()
+	e.edit.SetFont(qt.QFontDatabase_SystemFont(qt.QFontDatabase__FixedFont))
+	e.edit.SetLineWrapMode(qt.QPlainTextEdit__NoWrap)
+	layout.AddWidget(e.edit.QWidget)
+
+	e.gutter = qt.NewQWidget(e.edit.QWidget)
+	e.gutter.OnPaintEvent(func(super func(event *qt.QPaintEvent), event *qt.QPaintEvent) {
+		e.paintGutter(event)
+	})
+	e.gutter.OnMousePressEvent(func(super func(event *qt.QMouseEvent), event *qt.QMouseEvent) {
+		if event.Button() != qt.LeftButton {
+			super(event)
+			return
+		}
+		// The gutter and the text's viewport share their top edge
+		cursor := e.edit.CursorForPosition(qt.NewQPoint2(0, event.Pos().Y()))
+		e.toggleBreakpoint(cursor.BlockNumber() + 1)
+	})
+	e.edit.OnResizeEvent(func(super func(event *qt.QResizeEvent), event *qt.QResizeEvent) {
+		super(event)
+		r := e.edit.ContentsRect()
+		e.gutter.SetGeometry(r.Left(), r.Top(), e.gutterWidth(), r.Height())
+	})
+	e.edit.OnBlockCountChanged(func(int) {
+		e.edit.SetViewportMargins(e.gutterWidth(), 0, 0, 0)
+	})
+	e.edit.OnUpdateRequest(func(rect *qt.QRect, dy int) {
+		if dy != 0 {
+			e.gutter.Scroll(0, dy)
+		} else {
+			e.gutter.Update2(0, rect.Top(), e.gutter.Width(), rect.Height())
+		}
+	})
+	e.edit.SetViewportMargins(e.gutterWidth(), 0, 0, 0)
+
+	for _, kind := range []pawgui.HighlightKind{
+		pawgui.HighlightComment, pawgui.HighlightString, pawgui.HighlightNumber,
+		pawgui.HighlightCommand, pawgui.HighlightVariable, pawgui.HighlightBracket,
+	} {
+		e.formats[kind] = qt.NewQTextCharFormat()
+	}
+	e.highlighter = qt.NewQSyntaxHighlighter2(e.edit.Document())
+	e.highlighter.OnHighlightBlock(e.highlightBlock)
+	e.updateColors()
+
+	e.edit.OnModificationChanged(func(bool) { e.updateTitle() })
+	e.updateTitle()
+
+	e.widget.Hide()
+	return e
+}
+
+// shown returns whether the editor is showing
+func (e *scriptEditor) shown() bool {
+	return e.widget.IsVisible()
+}
+
+// setShown shows or hides the editor, giving it half the height when it appears
+func (e *scriptEditor) setShown(shown bool) {
+	if shown == e.shown() {
+		return
+	}
+	if shown {
+		e.widget.Show()
+		height := launcherEditorSplitter.Height()
+		launcherEditorSplitter.SetSizes([]int{height / 2, height - height/2})
+		e.edit.SetFocus()
+	} else {
+		e.widget.Hide()
+	}
+	saveLauncherEditorShown(shown)
+}
+
+// toggleScriptEditor shows the launcher's script editor, or hides it
+func toggleScriptEditor() {
+	if launcherEditor != nil {
+		launcherEditor.setShown(!launcherEditor.shown())
+	}
+}
+
+// isScriptEditorShown returns whether the launcher's script editor is showing
+func isScriptEditorShown() bool {
+	return launcherEditor != nil && launcherEditor.shown()
+}
+
+// updateColors sets the highlighting colors for the current theme
+func (e *scriptEditor) updateColors() {
+	for kind, format := range e.formats {
+		color := qt.NewQColor6(pawgui.HighlightColor(kind, appliedThemeIsDark))
+		format.SetForeground(qt.NewQBrush3(color))
+	}
+	e.highlighter.Rehighlight()
+}
+
+// highlightBlock colors a line, carrying what it ends inside of to the next
+func (e *scriptEditor) highlightBlock(text string) {
+	state := pawgui.HighlightState(0)
+	if previous := e.highlighter.PreviousBlockState(); previous > 0 {
+		state = pawgui.HighlightState(previous)
+	}
+	spans, state := pawgui.HighlightLine(text, state)
+	e.highlighter.SetCurrentBlockState(int(state))
+
+	// Spans count runes; Qt counts UTF-16 units
+	offsets := make([]int, 0, len(text)+1)
+	offset := 0
+	for _, r := range text {
+		offsets = append(offsets, offset)
+		offset += utf16.RuneLen(r)
+	}
+	offsets = append(offsets, offset)
+	for _, span := range spans {
+		if format := e.formats[span.Kind]; format != nil {
+			e.highlighter.SetFormat(offsets[span.Start], offsets[span.End]-offsets[span.Start], format)
+		}
+	}
+}
+
+// gutterWidth is how wide the line numbers need to be, for the most lines there
+// are, with room for a breakpoint's mark before them
+func (e *scriptEditor) gutterWidth() int {
+	digits := len(strconv.Itoa(max(e.edit.BlockCount(), 1)))
+	metrics := e.edit.FontMetrics()
+	return 12 + metrics.HorizontalAdvance(pawgui.DebugGutterMark(true, false)) + metrics.HorizontalAdvance("9")*digits
+}
+
+// paintGutter numbers the lines showing beside them, marking breakpoints and the
+// line the debugger is paused on
+func (e *scriptEditor) paintGutter(event *qt.QPaintEvent) {
+	painter := qt.NewQPainter2(e.gutter.QPaintDevice)
+	defer painter.End()
+	painter.SetFont(e.edit.Font())
+	painter.SetPen(e.edit.Palette().ColorWithCr(qt.QPalette__PlaceholderText))
+
+	area := event.Rect()
+	offset := e.edit.ContentOffset()
+	lineHeight := e.edit.FontMetrics().Height()
+	width := e.gutter.Width() - 4
+	block := e.edit.FirstVisibleBlock()
+	for b := &block; b.IsValid(); b = b.Next() {
+		geometry := e.edit.BlockBoundingGeometry(b)
+		top := int(geometry.Top() + offset.Y())
+		if top > area.Bottom() {
+			break
+		}
+		if b.IsVisible() && top+lineHeight >= area.Top() {
+			line := b.BlockNumber() + 1
+			painter.DrawText7(0, top, width, lineHeight, int(qt.AlignRight), strconv.Itoa(line))
+			breakpoint := e.path != "" && launcherDebugger.HasBreakpoint(e.path, line)
+			if mark := pawgui.DebugGutterMark(breakpoint, line == e.pausedLine); mark != "" {
+				painter.DrawText7(4, top, width, lineHeight, int(qt.AlignLeft), mark)
+			}
+		}
+	}
+}
+
+// toggleBreakpoint sets or clears the breakpoint on a line, once the script has
+// a file for it to be in
+func (e *scriptEditor) toggleBreakpoint(line int) {
+	if e.path == "" || line < 1 {
+		return
+	}
+	launcherDebugger.SetBreakpoint(e.path, line, !launcherDebugger.HasBreakpoint(e.path, line))
+	e.gutter.Update()
+}
+
+// showPaused marks the line the debugger paused on when it's in this script, and
+// moves to it; a line of 0 clears the mark
+func (e *scriptEditor) showPaused(file string, line int) {
+	if e.path == "" || !sameFile(file, e.path) {
+		line = 0
+	}
+	if line == e.pausedLine {
+		return
+	}
+	e.pausedLine = line
+	e.gutter.Update()
+	if line > 0 {
+		e.edit.SetTextCursor(qt.NewQTextCursor4(e.edit.Document().FindBlockByNumber(line - 1)))
+		e.edit.CenterCursor()
+	}
+}
+
+// sameFile reports whether two names are for the same file
+func sameFile(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
+
+// updateTitle shows the file's name, marked while it has unsaved changes
+func (e *scriptEditor) updateTitle() {
+	name := "Untitled"
+	if e.path != "" {
+		name = e.path
+	}
+	if e.edit.Document().IsModified() {
+		name = "* " + name
+	}
+	e.nameLabel.SetText(name)
+	e.nameLabel.SetToolTip(name)
+}
+
+// confirmDiscard asks before unsaved changes are thrown away
+func (e *scriptEditor) confirmDiscard() bool {
+	if !e.edit.Document().IsModified() {
+		return true
+	}
+	result := qt.QMessageBox_Question6(
+		e.widget,
+		tr("Script Editor"),
+		tr("The script has unsaved changes. Discard them?"),
+		qt.QMessageBox__Yes|qt.QMessageBox__No,
+		qt.QMessageBox__No,
+	)
+	return result == qt.QMessageBox__Yes
+}
+
+// newScript empties the editor for a new script
+func (e *scriptEditor) newScript() {
+	if !e.confirmDiscard() {
+		return
+	}
+	e.path = ""
+	e.pausedLine = 0
+	e.edit.SetPlainText("")
+	e.updateTitle()
+}
+
+// open asks for a script and loads it
+func (e *scriptEditor) open() {
+	if !e.confirmDiscard() {
+		return
+	}
+	file := qt.QFileDialog_GetOpenFileName4(
+		e.widget,
+		"Open PawScript File",
+		currentDir,
+		"PawScript files (*.paw);;All files (*)",
+	)
+	if file == "" {
+		return
+	}
+	if err := e.load(file); err != nil {
+		qt.QMessageBox_Critical5(e.widget, tr("Error"), tr("Failed to open file: ")+err.Error(), qt.QMessageBox__Ok)
+	}
+}
+
+// load replaces the buffer with a script's text
+func (e *scriptEditor) load(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	e.path = path
+	e.pausedLine = 0
+	e.edit.SetPlainText(string(content))
+	e.updateTitle()
+	return nil
+}
+
+// save writes the buffer to its file, asking where first if it has none. It
+// returns whether the script was saved.
+func (e *scriptEditor) save() bool {
+	if e.path == "" {
+		file := qt.QFileDialog_GetSaveFileName4(
+			e.widget,
+			"Save PawScript File",
+			filepath.Join(currentDir, "untitled.paw"),
+			"PawScript files (*.paw);;All files (*)",
+		)
+		if file == "" {
+			return false
+		}
+		e.path = file
+	}
+	if err := os.WriteFile(e.path, []byte(e.edit.ToPlainText()), 0644); err != nil {
+		qt.QMessageBox_Critical5(e.widget, tr("Error"), tr("Failed to save file: ")+err.Error(), qt.QMessageBox__Ok)
+		return false
+	}
+	e.edit.Document().SetModifiedWithBool(false)
+	e.updateTitle()
+	if filepath.Dir(e.path) == currentDir {
+		loadDirectory(currentDir)
+	}
+	return true
+}
+
+// run saves the script and runs it in the launcher
+func (e *scriptEditor) run() {
+	if !e.save() {
+		return
+	}
+	runScript(e.path)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"path/filepath"
+
+	"github.com/mappu/miqt/qt"
+	"github.com/phroun/pawscript/src/pkg/pawgui"
+)
+
+// File list filter and details
+// The filter box above the file list hides the entries whose names and
+// descriptions don't have the words typed in it (see pawgui/scriptinfo.go); the
+// parent directory entry always shows. Each script's entry has a second line with
+// its description, size and modification time.
+
+var fileFilterEdit *qt.QLineEdit
+
+// createFileFilter creates the filter box for the file list
+func createFileFilter() *qt.QLineEdit {
+	fileFilterEdit = qt.NewQLineEdit2()
+	fileFilterEdit.SetPlaceholderText(tr("Filter"))
+	fileFilterEdit.SetClearButtonEnabled(true)
+	fileFilterEdit.OnTextChanged(func(string) {
+		applyFileFilter()
+	})
+	// Escape clears the filter
+	fileFilterEdit.OnKeyPressEvent(func(super func(event *qt.QKeyEvent), event *qt.QKeyEvent) {
+		if qt.Key(event.Key()) == qt.Key_Escape && fileFilterEdit.Text() != "" {
+			fileFilterEdit.Clear()
+			return
+		}
+		super(event)
+	})
+	return fileFilterEdit
+}
+
+// applyFileFilter hides the file list entries that don't pass the filter
+func applyFileFilter() {
+	if fileList == nil || fileFilterEdit == nil {
+		return
+	}
+	filter := fileFilterEdit.Text()
+	for i := 0; i < fileList.Count(); i++ {
+		item := fileList.Item(i)
+		if item == nil {
+			continue
+		}
+		fileItemDataMu.Lock()
+		data, ok := fileItemDataMap[item.UnsafePointer()]
+		fileItemDataMu.Unlock()
+		if !ok || data.iconType == iconTypeFolderUp {
+			continue
+		}
+		item.SetHidden(!pawgui.FileListMatches(filter, filepath.Base(data.path), data.description))
+	}
+}
+
+// addFileDetails adds the line with a script's details under its name, returning
+// its description
+func addFileDetails(item *qt.QListWidgetItem, path string) string {
+	info, err := pawgui.ReadScriptInfo(path)
+	if err != nil {
+		return ""
+	}
+	item.SetText(filepath.Base(path) + "\n" + info.Summary())
+	if info.Description != "" {
+		item.SetToolTip(info.Description)
+	}
+	return info.Description
+}
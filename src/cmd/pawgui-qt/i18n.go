@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/phroun/pawscript/src/pkg/pawgui"
+)
+
+// Localization
+// Text the launcher shows goes through tr, which translates it with the message
+// catalog of the language setting (see pawgui/i18n.go). Windows take their text
+// as they're made, so a change of language shows in windows opened afterwards.
+
+// tr returns text, written in English, in the launcher's language
+func tr(text string) string {
+	return pawgui.Tr(text)
+}
+
+// applyLanguage has tr use the language the settings pick
+func applyLanguage() {
+	if err := configHelper.ApplyLanguage(); err != nil {
+		fmt.Fprintf(os.Stderr, "Language: %v\n", err)
+	}
+}
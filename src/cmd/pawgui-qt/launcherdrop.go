@@ -0,0 +1,62 @@
+package main
+
+import (
+	"github.com/mappu/miqt/qt"
+	"github.com/phroun/pawscript/src/pkg/pawgui"
+)
+
+// Launcher drops
+// Dropping .paw files on the launcher window runs them and dropping a folder
+// opens it in the file list (see pawgui/launcherdrop.go). The launcher's terminal
+// offers its drops to handleLauncherDrop first; the rest of the window is a drop
+// target of its own.
+
+// handleLauncherDrop runs the scripts or opens the folder dropped on the
+// launcher, returning false for drops it doesn't take
+func handleLauncherDrop(paths []string) bool {
+	scripts, folder, ok := pawgui.LauncherDrop(paths)
+	if !ok {
+		return false
+	}
+	if folder != "" {
+		loadDirectory(folder)
+		return true
+	}
+	// runScript opens a console window for a script when one is running
+	for _, script := range scripts {
+		runScript(script)
+	}
+	return true
+}
+
+// droppedFiles returns the paths of the files being dragged, nil if anything but
+// local files is
+func droppedFiles(mime *qt.QMimeData) []string {
+	if mime == nil || !mime.HasUrls() {
+		return nil
+	}
+	var paths []string
+	for _, u := range mime.Urls() {
+		if !u.IsLocalFile() {
+			return nil
+		}
+		paths = append(paths, u.ToLocalFile())
+	}
+	return paths
+}
+
+// setupLauncherDrop makes the launcher window a drop target for scripts and
+// folders
+func setupLauncherDrop(win *qt.QMainWindow) {
+	win.SetAcceptDrops(true)
+	win.OnDragEnterEvent(func(super func(event *qt.QDragEnterEvent), event *qt.QDragEnterEvent) {
+		if _, _, ok := pawgui.LauncherDrop(droppedFiles(event.MimeData())); ok {
+			event.AcceptProposedAction()
+		}
+	})
+	win.OnDropEvent(func(super func(event *qt.QDropEvent), event *qt.QDropEvent) {
+		if handleLauncherDrop(droppedFiles(event.MimeData())) {
+			event.AcceptProposedAction()
+		}
+	})
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mappu/miqt/qt"
+	"github.com/phroun/pawscript/src/pkg/pawgui"
+)
+
+// Launch profiles
+// Right-clicking a script in the file list offers its Launch Profile, the
+// arguments, optimization level, roots, window size and auto-restart it's run
+// with (see pawgui/launchprofile.go). runScript and openScriptTabWith apply it.
+
+var (
+	fileListMenu       *qt.QMenu // Context menu of the file list's scripts
+	fileListMenuScript string    // The script fileListMenu was opened for
+)
+
+// setupFileListMenu gives the scripts in the file list a context menu
+func setupFileListMenu() {
+	fileListMenu = qt.NewQMenu2()
+	fileListMenu.AddAction(tr("Run")).OnTriggered(func() {
+		runScript(fileListMenuScript)
+	})
+	fileListMenu.AddAction(tr("Launch Profile...")).OnTriggered(func() {
+		showLaunchProfileDialog(fileListMenuScript)
+	})
+	addProjectFileListActions(fileListMenu)
+
+	fileList.SetContextMenuPolicy(qt.CustomContextMenu)
+	fileList.OnCustomContextMenuRequested(func(pos *qt.QPoint) {
+		item := fileList.ItemAt(pos)
+		if item == nil {
+			return
+		}
+		fileItemDataMu.Lock()
+		data, ok := fileItemDataMap[item.UnsafePointer()]
+		fileItemDataMu.Unlock()
+		if !ok || data.isDir {
+			return
+		}
+		fileList.SetCurrentItem(item)
+		fileListMenuScript = data.path
+		showProjectFileListActions(data.path)
+		fileListMenu.Popup(fileList.Viewport().MapToGlobal(pos))
+	})
+}
+
+// restartScript waits to run a script again when its launch profile says to,
+// after a run that ended ok or not, returning the script to run (nil if it isn't
+// run again). The profile is read again after the wait, so changing it stops the
+// restarts, as do stopping the script and closing its tab (closed, if not nil).
+// A run configuration's profile (runConfig, if not nil) is used as it is.
+func restartScript(filePath string, runConfig *pawgui.LaunchProfile, ok bool, feed func(string), closed func() bool) []byte {
+	restarts := func() bool {
+		if runConfig != nil {
+			return runConfig.Restarts(ok)
+		}
+		profile, _ := configHelper.GetLaunchProfile(filePath)
+		return profile.Restarts(ok)
+	}
+	if !restarts() || (closed != nil && closed()) {
+		return nil
+	}
+	feed(fmt.Sprintf("--- Restarting in %v ---\r\n", pawgui.RestartDelay))
+	time.Sleep(pawgui.RestartDelay)
+	if closed != nil && closed() {
+		return nil
+	}
+	if !restarts() {
+		return nil
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		feed(fmt.Sprintf(tr("Error reading script file: %v\r\n"), err))
+		return nil
+	}
+	feed(fmt.Sprintf("\r\n--- Running: %s ---\r\n\r\n", filepath.Base(filePath)))
+	return content
+}
+
+// joinRoots writes roots as a list separated as PATH is
+func joinRoots(roots []string) string {
+	return strings.Join(roots, string(os.PathListSeparator))
+}
+
+// splitRoots reads roots written by joinRoots
+func splitRoots(text string) []string {
+	var roots []string
+	for _, root := range filepath.SplitList(text) {
+		if root = strings.TrimSpace(root); root != "" {
+			roots = append(roots, root)
+		}
+	}
+	return roots
+}
+
+// showLaunchProfileDialog edits the launch profile of a script, saving it in
+// the config
+func showLaunchProfileDialog(script string) {
+	profile, profileErr := configHelper.GetLaunchProfile(script)
+
+	dialog := qt.NewQDialog(dialogParent())
+	dialog.SetWindowTitle(tr("Launch Profile - ") + filepath.Base(script))
+	dialog.SetMinimumWidth(460)
+	dialog.SetModal(true)
+
+	mainLayout := qt.NewQVBoxLayout2()
+	mainLayout.SetContentsMargins(12, 12, 12, 12)
+	mainLayout.SetSpacing(12)
+	dialog.SetLayout(mainLayout.QLayout)
+
+	form := qt.NewQFormLayout2()
+	form.SetSpacing(8)
+	mainLayout.AddLayout(form.QLayout)
+
+	argsEdit := qt.NewQLineEdit2()
+	argsEdit.SetText(pawgui.JoinArgs(profile.Args))
+	argsEdit.SetToolTip(tr("Separated by spaces; quote an argument with spaces in it"))
+	form.AddRow3(tr("Arguments:"), argsEdit.QWidget)
+
+	optCombo := qt.NewQComboBox2()
+	optCombo.AddItem(tr("Default"))
+	optCombo.AddItem(tr("0 - No caching"))
+	optCombo.AddItem(tr("1 - Cache macro and loop bodies"))
+	optCombo.SetCurrentIndex(profile.OptLevel + 1)
+	form.AddRow3(tr("Optimization:"), optCombo.QWidget)
+
+	rootsTip := fmt.Sprintf(tr("Directories beyond the usual ones, separated by %q;\nrelative ones are in the script's directory"),
+		string(os.PathListSeparator))
+	rootEdit := func(title string, roots []string) *qt.QLineEdit {
+		edit := qt.NewQLineEdit2()
+		edit.SetText(joinRoots(roots))
+		edit.SetToolTip(rootsTip)
+		form.AddRow3(title, edit.QWidget)
+		return edit
+	}
+	readEdit := rootEdit("Read Roots:", profile.ReadRoots)
+	writeEdit := rootEdit("Write Roots:", profile.WriteRoots)
+	execEdit := rootEdit("Exec Roots:", profile.ExecRoots)
+
+	sizeLayout := qt.NewQHBoxLayout2()
+	widthSpin := qt.NewQSpinBox2()
+	widthSpin.SetRange(0, 10000)
+	widthSpin.SetSingleStep(10)
+	widthSpin.SetValue(profile.Width)
+	heightSpin := qt.NewQSpinBox2()
+	heightSpin.SetRange(0, 10000)
+	heightSpin.SetSingleStep(10)
+	heightSpin.SetValue(profile.Height)
+	sizeTip := "Runs the script in a console window of its own of this size;\n0 to run it where scripts usually run"
+	widthSpin.SetToolTip(sizeTip)
+	heightSpin.SetToolTip(sizeTip)
+	sizeLayout.AddWidget(widthSpin.QWidget)
+	sizeLayout.AddWidget(qt.NewQLabel3("x").QWidget)
+	sizeLayout.AddWidget(heightSpin.QWidget)
+	sizeLayout.AddStretch()
+	form.AddRow4("Window Size:", sizeLayout.QLayout)
+
+	restarts := []string{pawgui.RestartNever, pawgui.RestartOnFailure, pawgui.RestartAlways}
+	restartCombo := qt.NewQComboBox2()
+	restartCombo.AddItem(tr("Never"))
+	restartCombo.AddItem(tr("When It Fails"))
+	restartCombo.AddItem(tr("Whenever It Ends"))
+	for i, restart := range restarts {
+		if restart == profile.AutoRestart {
+			restartCombo.SetCurrentIndex(i)
+		}
+	}
+	form.AddRow3(tr("Auto-Restart:"), restartCombo.QWidget)
+
+	// Where the settings came from
+	var notes []string
+	if profileErr != nil {
+		notes = append(notes, profileErr.Error())
+	}
+	if _, statErr := os.Stat(pawgui.LaunchProfileSidecar(script)); statErr == nil {
+		notes = append(notes, fmt.Sprintf(tr("Settings saved here override those in %s."),
+			filepath.Base(pawgui.LaunchProfileSidecar(script))))
+	}
+	if len(notes) > 0 {
+		note := qt.NewQLabel3(strings.Join(notes, "\n"))
+		note.SetWordWrap(true)
+		mainLayout.AddWidget(note.QWidget)
+	}
+
+	// Button row
+	buttonLayout := qt.NewQHBoxLayout2()
+
+	removed := false
+	if configHelper.HasSavedLaunchProfile(script) {
+		removeBtn := qt.NewQPushButton3(tr("Remove"))
+		removeBtn.SetAutoDefault(false)
+		removeBtn.OnClicked(func() {
+			removed = true
+			dialog.Accept()
+		})
+		buttonLayout.AddWidget(removeBtn.QWidget)
+	}
+	buttonLayout.AddStretch()
+
+	cancelBtn := qt.NewQPushButton3(tr("Cancel"))
+	cancelBtn.OnClicked(func() {
+		dialog.Reject()
+	})
+	buttonLayout.AddWidget(cancelBtn.QWidget)
+
+	edited := pawgui.DefaultLaunchProfile()
+	okBtn := qt.NewQPushButton3(tr("OK"))
+	okBtn.SetDefault(true)
+	okBtn.OnClicked(func() {
+		args, err := pawgui.SplitArgs(argsEdit.Text())
+		if err != nil {
+			qt.QMessageBox_Critical5(dialog.QWidget, tr("Launch Profile"), err.Error(), qt.QMessageBox__Ok)
+			return
+		}
+		edited.Args = args
+		edited.OptLevel = optCombo.CurrentIndex() - 1
+		edited.ReadRoots = splitRoots(readEdit.Text())
+		edited.WriteRoots = splitRoots(writeEdit.Text())
+		edited.ExecRoots = splitRoots(execEdit.Text())
+		edited.Width, edited.Height = widthSpin.Value(), heightSpin.Value()
+		if i := restartCombo.CurrentIndex(); i >= 0 {
+			edited.AutoRestart = restarts[i]
+		}
+		dialog.Accept()
+	})
+	buttonLayout.AddWidget(okBtn.QWidget)
+
+	mainLayout.AddLayout(buttonLayout.QLayout)
+
+	accepted := dialog.Exec() == 1
+	dialog.DeleteLater()
+	switch {
+	case !accepted:
+		return
+	case removed:
+		configHelper.SetLaunchProfile(script, nil)
+	default:
+		configHelper.SetLaunchProfile(script, &edited)
+	}
+	saveConfig(appConfig)
+}
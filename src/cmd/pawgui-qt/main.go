@@ -3,9 +3,11 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -18,6 +20,7 @@ import (
 	"unsafe"
 
 	"github.com/mappu/miqt/qt"
+	"github.com/mappu/miqt/qt/mainthread"
 	"github.com/phroun/pawscript"
 	"github.com/phroun/pawscript/src/pkg/pawgui"
 	"github.com/phroun/pawscript/src/pkg/purfecterm"
@@ -51,6 +54,7 @@ var (
 	clearInputFunc func()
 	flushFunc      func()
 	scriptRunning  bool
+	launcherRun    *pawgui.ScriptRun // The script running in the launcher, for Stop Script
 	scriptMu       sync.Mutex
 
 	// REPL for interactive mode
@@ -59,36 +63,47 @@ var (
 	// Configuration
 	appConfig    pawscript.PSLConfig
 	configHelper *pawgui.ConfigHelper
+	savedConfig  pawscript.PSLConfig // Config as last loaded or saved, the base for merging
+
+	// Config file edits waiting for the UI update timer
+	pendingConfigMu sync.Mutex
+	pendingConfig   pawscript.PSLConfig
+
+	// Permission prompts waiting for the UI update timer
+	pendingPromptsMu sync.Mutex
+	pendingPrompts   []permissionPrompt
 
 	// Track actual applied theme (resolved from Auto if needed)
 	appliedThemeIsDark bool
 
 	// Launcher narrow strip (for multiple toolbar buttons)
-	launcherNarrowStrip    *qt.QWidget        // The narrow strip container
-	launcherMenuButton     *IconButton        // Hamburger button in path selector (when strip hidden)
-	launcherStripMenuBtn   *IconButton        // Hamburger button in narrow strip (when strip visible)
-	launcherWidePanel      *qt.QWidget        // The wide panel (file browser)
-	launcherSplitter       *qt.QSplitter      // The main launcher splitter
-	launcherRegisteredBtns []*QtToolbarButton // Additional registered buttons for launcher
-	launcherMenu           *qt.QMenu          // Shared hamburger menu for launcher (used by both buttons)
-	pendingToolbarUpdate   bool               // Flag to signal main thread to update toolbar
-	splitterAdjusting      bool               // Flag to prevent recursive splitter callbacks
+	launcherNarrowStrip    *qt.QWidget         // The narrow strip container
+	launcherMenuButton     *IconButton         // Hamburger button in path selector (when strip hidden)
+	launcherStripMenuBtn   *IconButton         // Hamburger button in narrow strip (when strip visible)
+	launcherWidePanel      *qt.QWidget         // The wide panel (file browser)
+	launcherSplitter       *qt.QSplitter       // The main launcher splitter
+	launcherRegisteredBtns []*QtToolbarButton  // Additional registered buttons for launcher
+	launcherMenu           *qt.QMenu           // Shared hamburger menu for launcher (used by both buttons)
+	launcherScriptMenus    *pawgui.ScriptMenus // Entries launcher scripts added with menu_register
+	pendingToolbarUpdate   bool                // Flag to signal main thread to update toolbar
+	splitterAdjusting      bool                // Flag to prevent recursive splitter callbacks
 )
 
 // QtToolbarButton represents a registered toolbar button for Qt
 type QtToolbarButton struct {
-	Icon    string      // Icon name or path
+	Icon    string      // Built-in icon name or SVG markup (see toolbarIconSVG)
 	Tooltip string      // Tooltip text
 	OnClick func()      // Click handler
 	Menu    *qt.QMenu   // Optional dropdown menu (if nil, OnClick is used)
 	widget  *IconButton // The actual button widget
 }
 
-// QtWindowToolbarData holds per-window toolbar state for dummy_button command
+// QtWindowToolbarData holds per-window toolbar state for the toolbar_button command
 type QtWindowToolbarData struct {
 	strip          *qt.QWidget            // The narrow strip container
 	menuButton     *IconButton            // The hamburger menu button
 	registeredBtns []*QtToolbarButton     // Additional registered buttons
+	scriptButtons  *pawgui.ToolbarButtons // Buttons scripts added, drawn as registeredBtns
 	terminal       *purfectermqt.Terminal // Terminal for Feed() calls
 	updateFunc     func()                 // Function to update the strip's buttons
 }
@@ -96,7 +111,7 @@ type QtWindowToolbarData struct {
 // Per-window toolbar data (keyed by PawScript instance or window)
 var (
 	qtToolbarDataByPS     = make(map[*pawscript.PawScript]*QtWindowToolbarData)
-	qtToolbarDataByWindow = make(map[*qt.QMainWindow]*QtWindowToolbarData)
+	qtToolbarDataByWindow = make(map[*qt.QWidget]*QtWindowToolbarData) // Keyed by window, or tab page
 	qtToolbarDataMu       sync.Mutex
 	launcherToolbarData   *QtWindowToolbarData   // Toolbar data for the launcher window
 	pendingWindowUpdates  []*QtWindowToolbarData // Windows that need toolbar updates
@@ -243,16 +258,22 @@ func resizeSVG(svgData string, size int) string {
 	return svgTag + rest
 }
 
-// createPixmapFromSVG creates a QPixmap from SVG data at the specified size
+// createPixmapFromSVG creates a QPixmap from SVG data at the specified size, with
+// the screens' device pixel ratio so it stays sharp at 125%, 150% and the like
 func createPixmapFromSVG(svgData string, size int) *qt.QPixmap {
+	scale := 1.0
+	if qtApp != nil {
+		scale = purfecterm.DeviceScale(qtApp.DevicePixelRatio())
+	}
 	// Resize SVG to target size before loading - this lets the vector renderer
 	// rasterize directly at the correct size, avoiding bitmap scaling artifacts
-	resizedSVG := resizeSVG(svgData, size)
+	resizedSVG := resizeSVG(svgData, purfecterm.DevicePixels(size, scale))
 	pixmap := qt.NewQPixmap()
 	data := []byte(resizedSVG)
 	// Use LoadFromData4 with explicit "SVG" format to ensure Qt uses the SVG plugin
 	// rather than relying on auto-detection which can fail on some platforms
 	if pixmap.LoadFromData4(data, "SVG") {
+		pixmap.SetDevicePixelRatio(scale)
 		return pixmap
 	}
 	return nil
@@ -377,8 +398,9 @@ func (btn *IconButton) paintEvent(event *qt.QPaintEvent) {
 
 	// Draw the icon centered
 	if btn.pixmap != nil && !btn.pixmap.IsNull() {
-		iconW := btn.pixmap.Width()
-		iconH := btn.pixmap.Height()
+		// Sized in logical pixels; the pixmap has more at high DPI
+		iconW := int(math.Round(float64(btn.pixmap.Width()) / btn.pixmap.DevicePixelRatio()))
+		iconH := int(math.Round(float64(btn.pixmap.Height()) / btn.pixmap.DevicePixelRatio()))
 		x := (w - iconW) / 2
 		y := (h - iconH) / 2
 		painter.DrawPixmap9(x, y, btn.pixmap)
@@ -406,8 +428,34 @@ func (btn *IconButton) UpdateSize(buttonSize, iconSize int, svgData string) {
 	btn.QWidget.Update()
 }
 
-// Random icons for dummy buttons
-var dummyIcons = []string{"★", "♦", "♠", "♣", "♥", "●", "■", "▲", "◆", "⬟", "⬢", "✦", "✧", "⚡", "☀", "☁", "☂", "☃", "✿", "❀"}
+// toolbarIconSVG returns the SVG for a script button's icon: one of the icons
+// named by pawgui.ToolbarIconNames, or markup the script read from a file
+func toolbarIconSVG(icon string) string {
+	switch icon {
+	case "star":
+		return starIconSVG
+	case "trash":
+		return trashIconSVG
+	case "folder":
+		return folderIconSVG
+	case "folder-up":
+		return folderUpIconSVG
+	case "home":
+		return homeIconSVG
+	case "file":
+		return unknownFileIconSVG
+	case "paw":
+		return pawFileIconSVG
+	case "checked":
+		return checkedIconSVG
+	case "unchecked":
+		return uncheckedIconSVG
+	}
+	if strings.HasPrefix(strings.TrimSpace(icon), "<") {
+		return icon
+	}
+	return starIconSVG
+}
 
 // --- Configuration Management ---
 
@@ -440,9 +488,16 @@ func loadConfig() pawscript.PSLConfig {
 
 	config, err := pawscript.ParsePSL(string(data))
 	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v (using default settings)\n", configPath, err)
 		return pawscript.PSLConfig{}
 	}
 
+	// Settings that don't fit the schema are dropped so their defaults apply
+	for _, problem := range pawgui.ValidateConfig(config) {
+		fmt.Fprintf(os.Stderr, "%s: ignoring invalid setting %v\n", configPath, problem)
+	}
+
+	savedConfig = pawscript.ClonePSLValue(config).(pawscript.PSLConfig)
 	return config
 }
 
@@ -457,8 +512,61 @@ func saveConfig(config pawscript.PSLConfig) {
 		return
 	}
 
-	data := pawscript.SerializePSLPretty(config)
-	_ = os.WriteFile(configPath, []byte(data+"\n"), 0644)
+	data := pawscript.SerializePSLPretty(config) + "\n"
+	if existing, err := os.ReadFile(configPath); err == nil {
+		// Another instance may have saved since this one loaded; keep its changes
+		// unless this one changed the same settings
+		if onDisk, err := pawscript.ParsePSL(string(existing)); err == nil && savedConfig != nil {
+			merged, _ := pawscript.MergePSL(savedConfig, config, onDisk)
+			for key := range config {
+				delete(config, key)
+			}
+			for key, value := range merged {
+				config[key] = value
+			}
+		}
+		// Keep the comments and layout of a hand-edited file
+		if updated, err := pawscript.UpdatePSL(string(existing), config); err == nil {
+			data = updated
+		}
+	}
+	if os.WriteFile(configPath, []byte(data), 0644) == nil {
+		savedConfig = pawscript.ClonePSLValue(config).(pawscript.PSLConfig)
+	}
+}
+
+// reloadConfig applies settings from a config file edited outside the launcher
+// Settings changed here but not yet saved are kept unless the file changed them too.
+func reloadConfig(config pawscript.PSLConfig) {
+	for _, problem := range pawgui.ValidateConfig(config) {
+		fmt.Fprintf(os.Stderr, "%s: ignoring invalid setting %v\n", getConfigPath(), problem)
+	}
+	if savedConfig != nil && len(pawscript.DiffPSL(savedConfig, config)) == 0 {
+		return // Our own save
+	}
+	base := savedConfig
+	if base == nil {
+		base = pawscript.PSLConfig{}
+	}
+	merged, _ := pawscript.MergePSL(base, appConfig, config)
+	savedConfig = pawscript.ClonePSLValue(config).(pawscript.PSLConfig)
+
+	origUIScale := getUIScale()
+	// Update appConfig in place, since the config helper shares it
+	for key := range appConfig {
+		delete(appConfig, key)
+	}
+	for key, value := range merged {
+		appConfig[key] = value
+	}
+	configHelper.PopulateDefaults()
+
+	applyTheme(configHelper.GetTheme())
+	applyConsoleTheme()
+	if getUIScale() != origUIScale {
+		applyUIScaleFromConfig()
+	}
+	applyFontSettings()
 }
 
 func saveBrowseDir(dir string) {
@@ -477,6 +585,7 @@ func getTerminalBackground() purfecterm.Color    { return configHelper.GetTermin
 func getTerminalForeground() purfecterm.Color    { return configHelper.GetTerminalForeground() }
 func getColorPalette() []purfecterm.Color        { return configHelper.GetColorPalette() }
 func getBlinkMode() purfecterm.BlinkMode         { return configHelper.GetBlinkMode() }
+func getScrollbackLines() int                    { return configHelper.GetScrollbackLines() }
 func getQuitShortcut() string                    { return configHelper.GetQuitShortcut() }
 func getDefaultQuitShortcut() string             { return pawgui.GetDefaultQuitShortcut() }
 func getCloseShortcut() string                   { return configHelper.GetCloseShortcut() }
@@ -484,6 +593,14 @@ func getDefaultCloseShortcut() string            { return pawgui.GetDefaultClose
 func getPSLColors() pawscript.DisplayColorConfig { return configHelper.GetPSLColors() }
 func isTermThemeDark() bool                      { return configHelper.IsTermThemeDark() }
 
+func getLauncherPermissions(script string) *pawscript.PermissionProfile {
+	return configHelper.GetLauncherPermissions(script, getExamplesDir())
+}
+
+func getLauncherQuotas() *pawscript.ResourceQuotas {
+	return configHelper.GetLauncherQuotas()
+}
+
 func getColorSchemeForTheme(isDark bool) purfecterm.ColorScheme {
 	// Returns a dual-palette ColorScheme (isDark is now ignored)
 	return configHelper.GetDualColorScheme()
@@ -544,14 +661,17 @@ Options:
   -d, --debug         Enable debug output
   -v, --verbose       Enable verbose output (same as --debug)
   -O N                Set optimization level (0=no caching, 1=cache macro/loop bodies, default: 1)
-  --unrestricted      Disable all file/exec access restrictions
+  --unrestricted      Disable all file/exec/network access restrictions
   --sandbox DIR       Restrict all access to DIR only
   --read-roots DIRS   Additional directories for reading
   --write-roots DIRS  Additional directories for writing
   --exec-roots DIRS   Additional directories for exec command
+  --allow-hosts HOSTS Hosts scripts may connect to (default: none)
+  --deny-hosts HOSTS  Hosts scripts may never connect to
 
 GUI Options:
   --window            Create console window for stdout/stdin/stderr
+  --recent            Run the script the launcher ran last
 
 Arguments:
   script.paw          Script file to execute (adds .paw extension if needed)
@@ -561,6 +681,8 @@ Default Security Sandbox:
   Read:   SCRIPT_DIR, CWD, /tmp
   Write:  SCRIPT_DIR/saves, SCRIPT_DIR/output, CWD/saves, CWD/output, /tmp
   Exec:   SCRIPT_DIR/helpers, SCRIPT_DIR/bin
+  Net:    none (HOSTS are comma-separated names, *.domain wildcards or
+          CIDR ranges, each optionally with :port)
 
 Environment Variables (use SCRIPT_DIR as placeholder):
   PAW_READ_ROOTS      Override default read roots
@@ -605,10 +727,8 @@ func saveLauncherWidth(width int) {
 
 // getLauncherPosition returns the saved launcher window position (x, y)
 func getLauncherPosition() (int, int) {
-	if items := appConfig.GetItems("launcher_position"); len(items) >= 2 {
-		x := pslToInt(items[0])
-		y := pslToInt(items[1])
-		return x, y
+	if xy, ok := appConfig.GetIntList("launcher_position"); ok && len(xy) >= 2 {
+		return xy[0], xy[1]
 	}
 	return -1, -1 // -1 means not set (let window manager decide)
 }
@@ -621,11 +741,9 @@ func saveLauncherPosition(x, y int) {
 
 // getLauncherSize returns the saved launcher window size (width, height)
 func getLauncherSize() (int, int) {
-	if items := appConfig.GetItems("launcher_size"); len(items) >= 2 {
-		w := pslToInt(items[0])
-		h := pslToInt(items[1])
-		if w > 0 && h > 0 {
-			return w, h
+	if wh, ok := appConfig.GetIntList("launcher_size"); ok && len(wh) >= 2 {
+		if wh[0] > 0 && wh[1] > 0 {
+			return wh[0], wh[1]
 		}
 	}
 	return 1100, 700 // Default size
@@ -637,19 +755,6 @@ func saveLauncherSize(width, height int) {
 	saveConfig(appConfig)
 }
 
-// pslToInt converts a PSL list item to int
-func pslToInt(v interface{}) int {
-	switch n := v.(type) {
-	case int:
-		return n
-	case int64:
-		return int(n)
-	case float64:
-		return int(n)
-	}
-	return 0
-}
-
 // getHomeDir returns the user's home directory path
 func getHomeDir() string {
 	if home, err := os.UserHomeDir(); err == nil {
@@ -741,6 +846,52 @@ func clearRecentPaths() {
 	saveConfig(appConfig)
 }
 
+// permissionPrompt is a script's permission request waiting for the UI update timer
+type permissionPrompt struct {
+	request pawscript.PermissionRequest
+	answer  chan pawscript.PermissionDecision
+}
+
+// promptPermission asks the user whether a launcher script may access a path outside its roots
+// Called from the script's goroutine, which waits until the UI update timer shows the dialog.
+func promptPermission(request pawscript.PermissionRequest) pawscript.PermissionDecision {
+	prompt := permissionPrompt{request, make(chan pawscript.PermissionDecision, 1)}
+	pendingPromptsMu.Lock()
+	pendingPrompts = append(pendingPrompts, prompt)
+	pendingPromptsMu.Unlock()
+	return <-prompt.answer
+}
+
+// showPermissionPrompt shows a permission request and sends back the user's answer
+// "Always Allow" is saved in the config, so later runs get the directory as a root.
+func showPermissionPrompt(prompt permissionPrompt) {
+	var parent *qt.QWidget
+	if mainWindow != nil {
+		parent = mainWindow.QWidget
+	}
+	box := qt.NewQMessageBox6(qt.QMessageBox__Question, "Script Permission",
+		pawgui.PermissionPromptText(prompt.request), qt.QMessageBox__NoButton, parent)
+	deny := box.AddButton2("Deny", qt.QMessageBox__RejectRole)
+	once := box.AddButton2("Allow Once", qt.QMessageBox__AcceptRole)
+	always := box.AddButton2("Always Allow", qt.QMessageBox__YesRole)
+	box.SetDefaultButton(deny)
+	box.Exec()
+	clicked := box.ClickedButton().UnsafePointer()
+	box.Delete()
+
+	switch clicked {
+	case once.QAbstractButton.UnsafePointer():
+		prompt.answer <- pawscript.PermissionAllowOnce
+	case always.QAbstractButton.UnsafePointer():
+		if configHelper.AddGrantedRoot(prompt.request.Action == pawscript.AuditWrite, prompt.request.Root) {
+			saveConfig(appConfig)
+		}
+		prompt.answer <- pawscript.PermissionAllowAlways
+	default:
+		prompt.answer <- pawscript.PermissionDeny
+	}
+}
+
 // --- Toolbar Strip and Hamburger Menu ---
 
 // showAboutDialog displays the About PawScript dialog
@@ -750,7 +901,7 @@ func showAboutDialog(parent *qt.QWidget) {
 <p>Copyright © 2025 Jeffrey R. Day<br>
 License: MIT</p>`, version)
 
-	qt.QMessageBox_About(parent, "About PawScript", aboutText)
+	qt.QMessageBox_About(parent, tr("About PawScript"), aboutText)
 }
 
 // QtSettingsComboMenu represents a styled combo menu for settings dialogs using QPushButton + QMenu
@@ -1028,7 +1179,7 @@ func (s *QtColorSwatch) SetText(label, colorHex string) {
 // showColorPickerDialog shows a color picker dialog with editable hex and RGB fields
 func showColorPickerDialog(currentHex string) string {
 	dialog := qt.NewQDialog2()
-	dialog.SetWindowTitle("Choose Color")
+	dialog.SetWindowTitle(tr("Choose Color"))
 	dialog.SetMinimumSize2(320, 220)
 	dialog.SetModal(true)
 
@@ -1061,7 +1212,7 @@ func showColorPickerDialog(currentHex string) string {
 
 	// Hex input row
 	hexLayout := qt.NewQHBoxLayout2()
-	hexLabel := qt.NewQLabel3("Hex:")
+	hexLabel := qt.NewQLabel3(tr("Hex:"))
 	hexLabel.SetFixedWidth(30)
 	hexLayout.AddWidget(hexLabel.QWidget)
 
@@ -1210,13 +1361,13 @@ func showColorPickerDialog(currentHex string) string {
 	buttonLayout := qt.NewQHBoxLayout2()
 	buttonLayout.AddStretch()
 
-	cancelBtn := qt.NewQPushButton3("Cancel")
+	cancelBtn := qt.NewQPushButton3(tr("Cancel"))
 	cancelBtn.OnClicked(func() {
 		dialog.Reject()
 	})
 	buttonLayout.AddWidget(cancelBtn.QWidget)
 
-	okBtn := qt.NewQPushButton3("OK")
+	okBtn := qt.NewQPushButton3(tr("OK"))
 	okBtn.SetDefault(true)
 	okBtn.OnClicked(func() {
 		dialog.Accept()
@@ -1263,10 +1414,20 @@ func showSettingsDialog(parent *qt.QWidget) {
 	// Save original values for reverting on Cancel
 	origWindowTheme := appConfig.GetString("theme", "auto")
 	origTermTheme := appConfig.GetString("term_theme", "auto")
+	origClipboardAccess := appConfig.GetString("clipboard_access", "write")
 	origUIScale := appConfig.GetFloat("ui_scale", 1.0)
 	origFontFamily := appConfig.GetString("font_family", "")
 	origFontSize := appConfig.GetInt("font_size", pawgui.DefaultFontSize)
 	origFontFamilyUnicode := appConfig.GetString("font_family_unicode", "")
+	origBackgroundOpacity := appConfig.GetFloat("background_opacity", 1.0)
+	origBackgroundBlur := appConfig.GetBool("background_blur", false)
+	origBackgroundImage := appConfig.GetString("background_image", "")
+	origBackgroundDim := appConfig.GetFloat("background_dim", 0.5)
+	origDefaultBlink := appConfig.GetString("default_blink", "bounce")
+	origCursorShape := appConfig.GetString("cursor_shape", "block")
+	origCursorBlink := appConfig.GetString("cursor_blink", "off")
+	origScrollbackLines := configHelper.GetScrollbackLines()
+	origLanguage := configHelper.GetLanguage()
 
 	// Save original palette sections for reverting on Cancel
 	origTermColors := copyPSLConfig(appConfig["term_colors"])
@@ -1284,7 +1445,7 @@ func showSettingsDialog(parent *qt.QWidget) {
 
 	// Create dialog
 	dialog := qt.NewQDialog2()
-	dialog.SetWindowTitle("Settings")
+	dialog.SetWindowTitle(tr("Settings"))
 	dialog.SetMinimumSize2(400, 300)
 	dialog.SetModal(true)
 
@@ -1319,7 +1480,7 @@ func showSettingsDialog(parent *qt.QWidget) {
 	// Declare both combos so they can reference each other for icon refresh
 	var windowThemeCombo, consoleThemeCombo *QtSettingsComboMenu
 
-	windowThemeCombo = createQtSettingsComboMenu([]string{"Auto", "Light", "Dark"}, windowThemeSelected, func(idx int) {
+	windowThemeCombo = createQtSettingsComboMenu([]string{tr("Auto"), tr("Light"), tr("Dark")}, windowThemeSelected, func(idx int) {
 		switch idx {
 		case 1:
 			appConfig.Set("theme", "light")
@@ -1336,7 +1497,7 @@ func showSettingsDialog(parent *qt.QWidget) {
 			consoleThemeCombo.RefreshIcons()
 		}
 	})
-	appearanceLayout.AddRow3("Window Theme:", windowThemeCombo.Button.QWidget)
+	appearanceLayout.AddRow3(tr("Window Theme:"), windowThemeCombo.Button.QWidget)
 
 	// Window Scale - use QDoubleSpinBox for precise control
 	currentScale := configHelper.GetUIScale()
@@ -1394,7 +1555,29 @@ func showSettingsDialog(parent *qt.QWidget) {
 
 	scaleWidget := qt.NewQWidget2()
 	scaleWidget.SetLayout(scaleLayout.QLayout)
-	appearanceLayout.AddRow3("Window Scale:", scaleWidget)
+	appearanceLayout.AddRow3(tr("Window Scale:"), scaleWidget)
+
+	// Language combo - Automatic follows the OS; windows opened afterwards use it
+	languages := pawgui.Languages()
+	languageOptions := []string{tr("Automatic")}
+	languageSelected := 0
+	for i, language := range languages {
+		languageOptions = append(languageOptions, language.Name)
+		if language.Code == origLanguage {
+			languageSelected = i + 1
+		}
+	}
+	languageCombo := createQtSettingsComboMenu(languageOptions, languageSelected, func(idx int) {
+		if idx == 0 {
+			appConfig.Set("language", "auto")
+		} else {
+			appConfig.Set("language", languages[idx-1].Code)
+		}
+		configHelper = pawgui.NewConfigHelper(appConfig)
+		applyLanguage()
+	})
+	languageCombo.Button.SetToolTip(tr("Windows opened afterwards use it; the launcher, once restarted"))
+	appearanceLayout.AddRow3(tr("Language:"), languageCombo.Button.QWidget)
 
 	// Console Theme combo - determine initial selection
 	var consoleThemeSelected int
@@ -1408,7 +1591,7 @@ func showSettingsDialog(parent *qt.QWidget) {
 		consoleThemeSelected = 0 // Auto
 	}
 
-	consoleThemeCombo = createQtSettingsComboMenu([]string{"Auto", "Light", "Dark"}, consoleThemeSelected, func(idx int) {
+	consoleThemeCombo = createQtSettingsComboMenu([]string{tr("Auto"), tr("Light"), tr("Dark")}, consoleThemeSelected, func(idx int) {
 		switch idx {
 		case 1:
 			appConfig.Set("term_theme", "light")
@@ -1420,7 +1603,67 @@ func showSettingsDialog(parent *qt.QWidget) {
 		configHelper = pawgui.NewConfigHelper(appConfig)
 		applyConsoleTheme()
 	})
-	appearanceLayout.AddRow3("Console Theme:", consoleThemeCombo.Button.QWidget)
+	appearanceLayout.AddRow3(tr("Console Theme:"), consoleThemeCombo.Button.QWidget)
+
+	// Clipboard row - what programs may do with the clipboard through OSC 52
+	// (reading it lets a program see whatever was last copied anywhere)
+	clipboardSelected := int(configHelper.GetClipboardAccess())
+	clipboardCombo := createQtSettingsComboMenu([]string{tr("Programs Can't Use"), tr("Programs Can Copy"), tr("Programs Can Copy & Paste")}, clipboardSelected, func(idx int) {
+		switch idx {
+		case 1:
+			appConfig.Set("clipboard_access", "write")
+		case 2:
+			appConfig.Set("clipboard_access", "read-write")
+		default:
+			appConfig.Set("clipboard_access", "off")
+		}
+		configHelper = pawgui.NewConfigHelper(appConfig)
+		applyConsoleTheme()
+	})
+	appearanceLayout.AddRow3(tr("Clipboard:"), clipboardCombo.Button.QWidget)
+
+	// Blinking Text - how text with the blink attribute is shown
+	blinkSelected := pawgui.ValueIndex(pawgui.BlinkModeValues, origDefaultBlink)
+	blinkCombo := createQtSettingsComboMenu([]string{tr("Bounce"), tr("Blink"), tr("Bright Background")}, blinkSelected, func(idx int) {
+		appConfig.Set("default_blink", pawgui.BlinkModeValues[idx])
+		configHelper = pawgui.NewConfigHelper(appConfig)
+		applyConsoleTheme()
+	})
+	appearanceLayout.AddRow3(tr("Blinking Text:"), blinkCombo.Button.QWidget)
+
+	// Cursor - the console cursor's shape and whether it blinks
+	cursorLayout := qt.NewQHBoxLayout2()
+	cursorLayout.SetContentsMargins(0, 0, 0, 0)
+	cursorShapeSelected := pawgui.ValueIndex(pawgui.CursorShapeValues, origCursorShape)
+	cursorShapeCombo := createQtSettingsComboMenu([]string{tr("Block"), tr("Underline"), tr("Bar")}, cursorShapeSelected, func(idx int) {
+		appConfig.Set("cursor_shape", pawgui.CursorShapeValues[idx])
+		configHelper = pawgui.NewConfigHelper(appConfig)
+		applyConsoleTheme()
+	})
+	cursorBlinkSelected := pawgui.ValueIndex(pawgui.CursorBlinkValues, origCursorBlink)
+	cursorBlinkCombo := createQtSettingsComboMenu([]string{tr("Steady"), tr("Slow Blink"), tr("Fast Blink")}, cursorBlinkSelected, func(idx int) {
+		appConfig.Set("cursor_blink", pawgui.CursorBlinkValues[idx])
+		configHelper = pawgui.NewConfigHelper(appConfig)
+		applyConsoleTheme()
+	})
+	cursorLayout.AddWidget(cursorShapeCombo.Button.QWidget)
+	cursorLayout.AddWidget(cursorBlinkCombo.Button.QWidget)
+	cursorWidget := qt.NewQWidget2()
+	cursorWidget.SetLayout(cursorLayout.QLayout)
+	appearanceLayout.AddRow3(tr("Cursor:"), cursorWidget)
+
+	// Scrollback - lines kept once they scroll off, for consoles opened afterwards
+	scrollbackSpin := qt.NewQSpinBox2()
+	scrollbackSpin.SetRange(100, 10000000)
+	scrollbackSpin.SetSingleStep(1000)
+	scrollbackSpin.SetValue(origScrollbackLines)
+	scrollbackSpin.SetSuffix(" lines")
+	scrollbackSpin.SetToolTip(tr("Lines each console keeps; consoles opened afterwards use it"))
+	scrollbackSpin.OnValueChanged(func(value int) {
+		appConfig.Set("scrollback_lines", value)
+		configHelper = pawgui.NewConfigHelper(appConfig)
+	})
+	appearanceLayout.AddRow3(tr("Scrollback:"), scrollbackSpin.QWidget)
 
 	// Console Font - button that opens font dialog
 	currentFontFamily := configHelper.GetFontFamily()
@@ -1459,7 +1702,7 @@ func showSettingsDialog(parent *qt.QWidget) {
 			consoleFontButton.SetText(fmt.Sprintf("%s, %dpt", selectedFont.Family(), newSize))
 		}
 	})
-	appearanceLayout.AddRow3("Console Font:", consoleFontButton.QWidget)
+	appearanceLayout.AddRow3(tr("Console Font:"), consoleFontButton.QWidget)
 
 	// CJK Font - button that opens font dialog (size ignored)
 	currentCJKFamily := appConfig.GetString("font_family_unicode", "")
@@ -1496,9 +1739,108 @@ func showSettingsDialog(parent *qt.QWidget) {
 			cjkFontButton.SetText(selectedFont.Family())
 		}
 	})
-	appearanceLayout.AddRow3("CJK Font:", cjkFontButton.QWidget)
+	appearanceLayout.AddRow3(tr("CJK Font:"), cjkFontButton.QWidget)
+
+	// Opacity - how much the desktop shows through the console background (in
+	// windows opened while it is see-through), and whether it is blurred
+	opacityLayout := qt.NewQHBoxLayout2()
+	opacityLayout.SetContentsMargins(0, 0, 0, 0)
+
+	// QSlider uses integers, so use percent
+	currentOpacity := configHelper.GetBackgroundOpacity()
+	opacitySlider := qt.NewQSlider2()
+	opacitySlider.SetOrientation(qt.Horizontal)
+	opacitySlider.SetRange(int(purfecterm.MinBackgroundOpacity*100), 100)
+	opacitySlider.SetSingleStep(5)
+	opacitySlider.SetValue(int(math.Round(currentOpacity * 100)))
+	opacityValueLabel := qt.NewQLabel3(fmt.Sprintf("%d%%", int(math.Round(currentOpacity*100))))
+	opacityValueLabel.SetMinimumWidth(40)
+	opacitySlider.OnValueChanged(func(value int) {
+		appConfig.Set("background_opacity", float64(value)/100.0)
+		configHelper = pawgui.NewConfigHelper(appConfig)
+		opacityValueLabel.SetText(fmt.Sprintf("%d%%", value))
+		applyConsoleTheme()
+	})
+	blurCheck := qt.NewQCheckBox3(tr("Blur"))
+	blurCheck.SetChecked(configHelper.GetBackgroundBlur())
+	blurCheck.SetToolTip(tr("Blur what shows through, where the window manager can"))
+	blurCheck.OnToggled(func(checked bool) {
+		appConfig.Set("background_blur", checked)
+		configHelper = pawgui.NewConfigHelper(appConfig)
+		applyConsoleTheme()
+	})
+
+	opacityLayout.AddWidget(opacitySlider.QWidget)
+	opacityLayout.AddWidget(opacityValueLabel.QWidget)
+	opacityLayout.AddWidget(blurCheck.QWidget)
+	opacityWidget := qt.NewQWidget2()
+	opacityWidget.SetLayout(opacityLayout.QLayout)
+	appearanceLayout.AddRow3(tr("Opacity:"), opacityWidget)
+
+	// Background - an image drawn under the console text
+	backgroundLayout := qt.NewQHBoxLayout2()
+	backgroundLayout.SetContentsMargins(0, 0, 0, 0)
+	backgroundButtonText := func(path string) string {
+		if path == "" {
+			return "No Image"
+		}
+		return filepath.Base(path)
+	}
+	backgroundButton := qt.NewQPushButton3(backgroundButtonText(configHelper.GetBackgroundImage()))
+	backgroundButton.OnClicked(func() {
+		file := qt.QFileDialog_GetOpenFileName4(
+			dialog.QWidget,
+			"Select Background Image",
+			"",
+			"Images (*.png *.jpg *.jpeg *.gif *.bmp *.webp);;All Files (*)",
+		)
+		if file == "" {
+			return
+		}
+		appConfig.Set("background_image", file)
+		configHelper = pawgui.NewConfigHelper(appConfig)
+		backgroundButton.SetText(backgroundButtonText(file))
+		applyConsoleTheme()
+	})
+	backgroundClearButton := qt.NewQPushButton3(tr("Clear"))
+	backgroundClearButton.OnClicked(func() {
+		appConfig.Set("background_image", "")
+		configHelper = pawgui.NewConfigHelper(appConfig)
+		backgroundButton.SetText(backgroundButtonText(""))
+		applyConsoleTheme()
+	})
+
+	backgroundLayout.AddWidget(backgroundButton.QWidget)
+	backgroundLayout.AddWidget(backgroundClearButton.QWidget)
+	backgroundWidget := qt.NewQWidget2()
+	backgroundWidget.SetLayout(backgroundLayout.QLayout)
+	appearanceLayout.AddRow3(tr("Background:"), backgroundWidget)
+
+	// Image Dimming - how far the image is faded toward the background color
+	dimLayout := qt.NewQHBoxLayout2()
+	dimLayout.SetContentsMargins(0, 0, 0, 0)
+	currentDim := configHelper.GetBackgroundDim()
+	dimSlider := qt.NewQSlider2()
+	dimSlider.SetOrientation(qt.Horizontal)
+	dimSlider.SetRange(0, 100)
+	dimSlider.SetSingleStep(5)
+	dimSlider.SetValue(int(math.Round(currentDim * 100)))
+	dimValueLabel := qt.NewQLabel3(fmt.Sprintf("%d%%", int(math.Round(currentDim*100))))
+	dimValueLabel.SetMinimumWidth(40)
+	dimSlider.OnValueChanged(func(value int) {
+		appConfig.Set("background_dim", float64(value)/100.0)
+		configHelper = pawgui.NewConfigHelper(appConfig)
+		dimValueLabel.SetText(fmt.Sprintf("%d%%", value))
+		applyConsoleTheme()
+	})
 
-	tabWidget.AddTab(appearanceWidget, "Appearance")
+	dimLayout.AddWidget(dimSlider.QWidget)
+	dimLayout.AddWidget(dimValueLabel.QWidget)
+	dimWidget := qt.NewQWidget2()
+	dimWidget.SetLayout(dimLayout.QLayout)
+	appearanceLayout.AddRow3(tr("Image Dimming:"), dimWidget)
+
+	tabWidget.AddTab(appearanceWidget, tr("Appearance"))
 
 	// --- Palette Tab ---
 	paletteWidget := qt.NewQWidget2()
@@ -1597,7 +1939,7 @@ func showSettingsDialog(parent *qt.QWidget) {
 	bgRowWidget.SetLayout(bgRowLayout.QLayout)
 
 	// Label on the left
-	bgLabel := qt.NewQLabel3("Background")
+	bgLabel := qt.NewQLabel3(tr("Background"))
 	bgLabel.SetFixedWidth(labelWidth)
 	bgRowLayout.AddWidget(bgLabel.QWidget)
 
@@ -1643,7 +1985,7 @@ func showSettingsDialog(parent *qt.QWidget) {
 	fgRowWidget.SetLayout(fgRowLayout.QLayout)
 
 	// Label on the left
-	fgLabel := qt.NewQLabel3("Foreground")
+	fgLabel := qt.NewQLabel3(tr("Foreground"))
 	fgLabel.SetFixedWidth(labelWidth)
 	fgRowLayout.AddWidget(fgLabel.QWidget)
 
@@ -1832,21 +2174,31 @@ func showSettingsDialog(parent *qt.QWidget) {
 	leftColumnLayout.AddStretch()
 	rightColumnLayout.AddStretch()
 
-	tabWidget.AddTab(paletteWidget, "Palette")
+	tabWidget.AddTab(paletteWidget, tr("Palette"))
+
+	// --- Shortcuts Tab ---
+	shortcuts := newShortcutsTab()
+	shortcutsIndex := tabWidget.AddTab(shortcuts.widget, tr("Shortcuts"))
 
 	// --- Button Box ---
 	buttonLayout := qt.NewQHBoxLayout2()
 	buttonLayout.AddStretch()
 
-	cancelBtn := qt.NewQPushButton3("Cancel")
+	cancelBtn := qt.NewQPushButton3(tr("Cancel"))
 	cancelBtn.OnClicked(func() {
 		dialog.Reject()
 	})
 	buttonLayout.AddWidget(cancelBtn.QWidget)
 
-	saveBtn := qt.NewQPushButton3("Save")
+	saveBtn := qt.NewQPushButton3(tr("Save"))
 	saveBtn.SetDefault(true)
 	saveBtn.OnClicked(func() {
+		// Clashing shortcuts must be sorted out first (the Shortcuts tab lists them)
+		if problems := shortcuts.check(); len(problems) > 0 {
+			tabWidget.SetCurrentIndex(shortcutsIndex)
+			qt.QMessageBox_Critical5(dialog.QWidget, tr("Shortcuts"), strings.Join(problems, "\n"), qt.QMessageBox__Ok)
+			return
+		}
 		dialog.Accept()
 	})
 	buttonLayout.AddWidget(saveBtn.QWidget)
@@ -1856,12 +2208,14 @@ func showSettingsDialog(parent *qt.QWidget) {
 	// Show dialog and handle response
 	if dialog.Exec() == 1 { // QDialog::Accepted = 1
 		// Save config to file (settings already applied via change handlers)
+		shortcuts.save()
 		saveConfig(appConfig)
 	} else {
 		// Revert to original values on Cancel
 		currentUIScale := getUIScale() // Get current scale before reverting
 		appConfig.Set("theme", origWindowTheme)
 		appConfig.Set("term_theme", origTermTheme)
+		appConfig.Set("clipboard_access", origClipboardAccess)
 		appConfig.Set("ui_scale", origUIScale)
 		if origFontFamily != "" {
 			appConfig.Set("font_family", origFontFamily)
@@ -1870,6 +2224,15 @@ func showSettingsDialog(parent *qt.QWidget) {
 		if origFontFamilyUnicode != "" {
 			appConfig.Set("font_family_unicode", origFontFamilyUnicode)
 		}
+		appConfig.Set("background_opacity", origBackgroundOpacity)
+		appConfig.Set("background_blur", origBackgroundBlur)
+		appConfig.Set("background_image", origBackgroundImage)
+		appConfig.Set("background_dim", origBackgroundDim)
+		appConfig.Set("default_blink", origDefaultBlink)
+		appConfig.Set("cursor_shape", origCursorShape)
+		appConfig.Set("cursor_blink", origCursorBlink)
+		appConfig.Set("scrollback_lines", origScrollbackLines)
+		appConfig.Set("language", origLanguage)
 		// Revert palette sections
 		if len(origTermColors) > 0 {
 			appConfig.Set("term_colors", origTermColors)
@@ -1887,6 +2250,7 @@ func showSettingsDialog(parent *qt.QWidget) {
 			delete(appConfig, "term_colors_light")
 		}
 		configHelper = pawgui.NewConfigHelper(appConfig)
+		applyLanguage()
 		applyTheme(configHelper.GetTheme())
 		applyConsoleTheme()
 		// Only apply UI scale if it actually changed (avoids unnecessary refresh)
@@ -2018,8 +2382,9 @@ func createMenuActionWithShortcut(menu *qt.QMenu, label, shortcut string) *qt.QA
 // isScriptWindow: true for script windows (slightly different options)
 // term: terminal widget for this window (nil to use global terminal)
 // isScriptRunningFunc: returns true if a script is running in this window
+// stopScriptFunc: stops the script running in this window (nil if it can't)
 // closeWindowFunc: closes this window
-func createHamburgerMenu(parent *qt.QWidget, isScriptWindow bool, term *purfectermqt.Terminal, isScriptRunningFunc func() bool, closeWindowFunc func()) *qt.QMenu {
+func createHamburgerMenu(parent *qt.QWidget, isScriptWindow bool, term *purfectermqt.Terminal, isScriptRunningFunc func() bool, stopScriptFunc func(), closeWindowFunc func()) *qt.QMenu {
 	menu := qt.NewQMenu2()
 
 	// Helper to get the terminal (uses provided term or falls back to global)
@@ -2031,24 +2396,44 @@ func createHamburgerMenu(parent *qt.QWidget, isScriptWindow bool, term *purfecte
 	}
 
 	// About option (both)
-	aboutAction := menu.AddAction("About PawScript...")
+	aboutAction := menu.AddAction(tr("About PawScript..."))
 	aboutAction.OnTriggered(func() {
 		showAboutDialog(parent)
 	})
 
 	// Settings option (both)
-	settingsAction := menu.AddAction("Settings...")
+	settingsAction := menu.AddAction(tr("Settings..."))
 	settingsAction.OnTriggered(func() {
 		showSettingsDialog(parent)
 	})
 
+	// Scheduled Tasks option (both)
+	scheduledTasksAction := menu.AddAction(tr("Scheduled Tasks..."))
+	scheduledTasksAction.OnTriggered(func() {
+		showScheduledTasksDialog(parent)
+	})
+
+	// Settings bundles and profiles (both)
+	menu.AddAction(tr("Export Settings...")).OnTriggered(func() {
+		exportSettingsDialog(parent)
+	})
+	menu.AddAction(tr("Import Settings...")).OnTriggered(func() {
+		importSettingsDialog(parent)
+	})
+	settingsProfilesMenu := menu.AddMenuWithTitle(tr("Settings Profiles"))
+	settingsProfilesMenu.OnAboutToShow(func() {
+		// Rebuilt each time, as other windows and instances save and delete profiles
+		settingsProfilesMenu.Clear()
+		addSettingsProfiles(parent, settingsProfilesMenu)
+	})
+
 	// Separator after About/Settings
 	menu.AddSeparator()
 
 	// File List toggle with custom icon (launcher only)
 	var fileListAction *qt.QAction
 	if !isScriptWindow {
-		fileListAction = menu.AddAction("File List")
+		fileListAction = menu.AddAction(tr("File List"))
 		// Set initial icon based on current state
 		if isWideMode() {
 			if icon := createIconFromSVG(checkedIconSVG, scaledMenuIconSize()); icon != nil {
@@ -2064,28 +2449,80 @@ func createHamburgerMenu(parent *qt.QWidget, isScriptWindow bool, term *purfecte
 		})
 	}
 
+	// Script Editor toggle with the same icons as File List (launcher only)
+	var editorAction *qt.QAction
+	if !isScriptWindow {
+		editorAction = menu.AddAction(tr("Script Editor"))
+		iconSVG := uncheckedIconSVG
+		if isScriptEditorShown() {
+			iconSVG = checkedIconSVG
+		}
+		if icon := createIconFromSVG(iconSVG, scaledMenuIconSize()); icon != nil {
+			editorAction.SetIcon(icon)
+		}
+		editorAction.OnTriggered(func() {
+			toggleScriptEditor()
+		})
+	}
+
 	// Show Launcher (console windows only)
 	if isScriptWindow {
-		showLauncherAction := menu.AddAction("Show Launcher")
+		showLauncherAction := menu.AddAction(tr("Show Launcher"))
 		showLauncherAction.OnTriggered(func() {
 			showOrCreateLauncher()
 		})
 	}
 
-	// New Window (both - creates a blank console window)
-	newWindowAction := menu.AddAction("New Window")
+	// New Tab (both - opens a blank console tab in the last active tab window)
+	newTabAction := menu.AddAction(tr("New Tab"))
+	newTabAction.OnTriggered(func() {
+		createBlankConsoleTab(false)
+	})
+
+	// New Window (both - opens a blank console tab in a window of its own)
+	newWindowAction := menu.AddAction(tr("New Window"))
 	newWindowAction.OnTriggered(func() {
-		createBlankConsoleWindow()
+		createBlankConsoleTab(true)
 	})
 
+	// Open System Shell Here (launcher only - a shell in the directory being browsed)
+	if !isScriptWindow {
+		shellAction := menu.AddAction(tr("Open System Shell Here"))
+		shellAction.OnTriggered(func() {
+			openShellTab(currentDir)
+		})
+	}
+
+	// Open Project... and the open project's submenu (launcher only)
+	if !isScriptWindow {
+		menu.AddAction(tr("Open Project...")).OnTriggered(func() {
+			openProjectDialog(parent)
+		})
+		projectMenu := menu.AddMenuWithTitle(tr("Project"))
+		projectMenu.MenuAction().SetVisible(currentProject != nil)
+		projectMenu.OnAboutToShow(func() {
+			// Run configurations are added by the file list's menu
+			projectMenu.Clear()
+			addProjectActions(parent, projectMenu)
+		})
+		menu.OnAboutToShow(func() {
+			projectMenu.MenuAction().SetVisible(currentProject != nil)
+		})
+	}
+
 	menu.AddSeparator()
 
 	// Stop Script (both) - disabled when no script running
-	stopScriptAction := menu.AddAction("Stop Script")
+	stopScriptAction := menu.AddAction(tr("Stop Script"))
 	stopScriptAction.SetEnabled(false) // Initially disabled
+	stopScriptAction.OnTriggered(func() {
+		if stopScriptFunc != nil {
+			stopScriptFunc()
+		}
+	})
 
 	// Reset Terminal (both) - directly under Stop Script
-	resetTerminalAction := menu.AddAction("Reset Terminal")
+	resetTerminalAction := menu.AddAction(tr("Reset Terminal"))
 	resetTerminalAction.OnTriggered(func() {
 		if t := getTerminal(); t != nil {
 			t.Reset()
@@ -2106,6 +2543,16 @@ func createHamburgerMenu(parent *qt.QWidget, isScriptWindow bool, term *purfecte
 				}
 			}
 		}
+		// Update Script Editor icon to match current state
+		if editorAction != nil {
+			iconSVG := uncheckedIconSVG
+			if isScriptEditorShown() {
+				iconSVG = checkedIconSVG
+			}
+			if icon := createIconFromSVG(iconSVG, scaledMenuIconSize()); icon != nil {
+				editorAction.SetIcon(icon)
+			}
+		}
 		// Update Stop Script enabled state
 		if isScriptRunningFunc != nil {
 			stopScriptAction.SetEnabled(isScriptRunningFunc())
@@ -2115,25 +2562,53 @@ func createHamburgerMenu(parent *qt.QWidget, isScriptWindow bool, term *purfecte
 	menu.AddSeparator()
 
 	// Save Scrollback ANSI (both)
-	saveScrollbackANSIAction := menu.AddAction("Save Scrollback ANSI...")
+	saveScrollbackANSIAction := menu.AddAction(tr("Save Scrollback ANSI..."))
 	saveScrollbackANSIAction.OnTriggered(func() {
 		saveScrollbackANSIDialog(parent, getTerminal())
 	})
 
 	// Save Scrollback Text (both)
-	saveScrollbackTextAction := menu.AddAction("Save Scrollback Text...")
+	saveScrollbackTextAction := menu.AddAction(tr("Save Scrollback Text..."))
 	saveScrollbackTextAction.OnTriggered(func() {
 		saveScrollbackTextDialog(parent, getTerminal())
 	})
 
+	// Save Terminal State (both)
+	saveTerminalStateAction := menu.AddAction(tr("Save Terminal State..."))
+	saveTerminalStateAction.OnTriggered(func() {
+		saveTerminalStateDialog(parent, getTerminal())
+	})
+
+	// Start Recording / Stop Recording (both) - only one is enabled at a time
+	startRecordingAction := menu.AddAction(tr("Start Recording..."))
+	startRecordingAction.OnTriggered(func() {
+		startRecordingDialog(parent, getTerminal())
+	})
+	stopRecordingAction := menu.AddAction(tr("Stop Recording"))
+	stopRecordingAction.OnTriggered(func() {
+		stopRecording(parent, getTerminal())
+	})
+	menu.OnAboutToShow(func() {
+		t := getTerminal()
+		recording := t != nil && t.IsRecording()
+		startRecordingAction.SetEnabled(!recording)
+		stopRecordingAction.SetEnabled(recording)
+	})
+
+	// Open Log Folder (both) - where run logs are written
+	openLogFolderAction := menu.AddAction(tr("Open Log Folder"))
+	openLogFolderAction.OnTriggered(func() {
+		openRunLogFolder()
+	})
+
 	// Restore Buffer (both)
-	restoreBufferAction := menu.AddAction("Restore Buffer...")
+	restoreBufferAction := menu.AddAction(tr("Restore Buffer..."))
 	restoreBufferAction.OnTriggered(func() {
 		restoreBufferDialog(parent, getTerminal())
 	})
 
 	// Clear Scrollback (both)
-	clearScrollbackAction := menu.AddAction("Clear Scrollback")
+	clearScrollbackAction := menu.AddAction(tr("Clear Scrollback"))
 	clearScrollbackAction.OnTriggered(func() {
 		if t := getTerminal(); t != nil {
 			t.ClearScrollback()
@@ -2161,6 +2636,70 @@ func createHamburgerMenu(parent *qt.QWidget, isScriptWindow bool, term *purfecte
 	return menu
 }
 
+// addScriptMenus shows the entries scripts added with menu_register at the top of
+// a hamburger menu, rebuilding them as the menu opens after they changed
+func addScriptMenus(menu *qt.QMenu, menus *pawgui.ScriptMenus) {
+	actions := menu.Actions()
+	if len(actions) == 0 {
+		return
+	}
+	first := actions[0]
+	var shown []*qt.QAction
+	var owned []*qt.QObject // Deleting these deletes the shown actions
+	generation := 0
+	menu.OnAboutToShow(func() {
+		items, current := menus.Items()
+		if current == generation {
+			return
+		}
+		generation = current
+
+		for _, action := range shown {
+			menu.RemoveAction(action)
+		}
+		for _, object := range owned {
+			object.DeleteLater()
+		}
+		shown, owned = nil, nil
+
+		for _, item := range items {
+			action, object := createScriptMenuAction(menu.QWidget, item)
+			shown = append(shown, action)
+			owned = append(owned, object)
+		}
+		if len(shown) > 0 {
+			sep := qt.NewQAction4(menu.QObject)
+			sep.SetSeparator(true)
+			shown = append(shown, sep)
+			owned = append(owned, sep.QObject)
+		}
+		for _, action := range shown {
+			menu.InsertAction(first, action)
+		}
+	})
+}
+
+// createScriptMenuAction creates the action for an entry a script added, with its
+// submenu if it has one, returning it and the object that owns it
+func createScriptMenuAction(parent *qt.QWidget, entry *pawgui.ScriptMenuItem) (*qt.QAction, *qt.QObject) {
+	if entry.Separator {
+		sep := qt.NewQAction4(parent.QObject)
+		sep.SetSeparator(true)
+		return sep, sep.QObject
+	}
+	if entry.Activate == nil {
+		submenu := qt.NewQMenu4(entry.Label, parent)
+		for _, child := range entry.Submenu {
+			action, _ := createScriptMenuAction(submenu.QWidget, child)
+			submenu.QWidget.AddAction(action)
+		}
+		return submenu.MenuAction(), submenu.QObject
+	}
+	action := qt.NewQAction5(entry.Label, parent.QObject)
+	action.OnTriggered(entry.Activate)
+	return action, action.QObject
+}
+
 // isWideMode returns true if the file list panel is visible
 func isWideMode() bool {
 	if launcherSplitter == nil {
@@ -2223,6 +2762,7 @@ func toggleFileList() {
 // showOrCreateLauncher brings the launcher window to front, or creates one if needed
 func showOrCreateLauncher() {
 	if mainWindow != nil {
+		mainWindow.SetWindowState(mainWindow.WindowState() &^ qt.WindowMinimized)
 		mainWindow.Show()
 		mainWindow.Raise()
 		mainWindow.ActivateWindow()
@@ -2240,8 +2780,8 @@ func quitApplication(parent *qt.QWidget) {
 		// Show confirmation dialog
 		result := qt.QMessageBox_Question6(
 			parent,
-			"Quit PawScript",
-			"This will stop all scripts. Are you sure?",
+			tr("Quit PawScript"),
+			tr("This will stop all scripts. Are you sure?"),
 			qt.QMessageBox__Yes|qt.QMessageBox__No,
 			qt.QMessageBox__No,
 		)
@@ -2250,7 +2790,8 @@ func quitApplication(parent *qt.QWidget) {
 		}
 	}
 
-	// Quit the application
+	// Quit the application, saving what's open for the next launch
+	saveSession()
 	qt.QCoreApplication_Quit()
 }
 
@@ -2281,8 +2822,8 @@ func saveScrollbackANSIDialog(parent *qt.QWidget, term *purfectermqt.Terminal) {
 	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
 		qt.QMessageBox_Critical5(
 			parent,
-			"Error",
-			fmt.Sprintf("Failed to save file: %v", err),
+			tr("Error"),
+			fmt.Sprintf(tr("Failed to save file: %v"), err),
 			qt.QMessageBox__Ok,
 		)
 	}
@@ -2315,8 +2856,91 @@ func saveScrollbackTextDialog(parent *qt.QWidget, term *purfectermqt.Terminal) {
 	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
 		qt.QMessageBox_Critical5(
 			parent,
-			"Error",
-			fmt.Sprintf("Failed to save file: %v", err),
+			tr("Error"),
+			fmt.Sprintf(tr("Failed to save file: %v"), err),
+			qt.QMessageBox__Ok,
+		)
+	}
+}
+
+// saveTerminalStateDialog shows a file dialog to save a snapshot of the terminal's
+// complete state, which Restore Buffer resumes exactly
+func saveTerminalStateDialog(parent *qt.QWidget, term *purfectermqt.Terminal) {
+	if term == nil {
+		return
+	}
+
+	file := qt.QFileDialog_GetSaveFileName4(
+		parent,
+		"Save Terminal State",
+		"terminal.ptsnap",
+		"Terminal Snapshots (*.ptsnap);;All Files (*)",
+	)
+
+	if file == "" {
+		return
+	}
+
+	var content bytes.Buffer
+	err := term.SaveSnapshot(&content)
+	if err == nil {
+		err = os.WriteFile(file, content.Bytes(), 0644)
+	}
+	if err != nil {
+		qt.QMessageBox_Critical5(
+			parent,
+			tr("Error"),
+			fmt.Sprintf(tr("Failed to save terminal state: %v"), err),
+			qt.QMessageBox__Ok,
+		)
+	}
+}
+
+// startRecordingDialog shows a file dialog and starts recording the terminal's
+// output to it as an asciinema cast
+func startRecordingDialog(parent *qt.QWidget, term *purfectermqt.Terminal) {
+	if term == nil {
+		return
+	}
+
+	file := qt.QFileDialog_GetSaveFileName4(
+		parent,
+		"Start Recording",
+		"recording.cast",
+		"Asciinema Casts (*.cast);;All Files (*)",
+	)
+
+	if file == "" {
+		return
+	}
+
+	f, err := os.Create(file)
+	if err == nil {
+		title := fmt.Sprintf("PawScript %s (Qt; %s; %s)", version, runtime.GOOS, runtime.GOARCH)
+		if err = term.StartRecording(f, title); err != nil {
+			f.Close()
+		}
+	}
+	if err != nil {
+		qt.QMessageBox_Critical5(
+			parent,
+			tr("Error"),
+			fmt.Sprintf(tr("Failed to start recording: %v"), err),
+			qt.QMessageBox__Ok,
+		)
+	}
+}
+
+// stopRecording stops recording the terminal's output, reporting any write error
+func stopRecording(parent *qt.QWidget, term *purfectermqt.Terminal) {
+	if term == nil {
+		return
+	}
+	if err := term.StopRecording(); err != nil {
+		qt.QMessageBox_Critical5(
+			parent,
+			tr("Error"),
+			fmt.Sprintf(tr("Failed to save recording: %v"), err),
 			qt.QMessageBox__Ok,
 		)
 	}
@@ -2332,7 +2956,7 @@ func restoreBufferDialog(parent *qt.QWidget, term *purfectermqt.Terminal) {
 		parent,
 		"Restore Buffer",
 		"",
-		"ANSI Files (*.ans);;Text Files (*.txt);;All Files (*)",
+		"ANSI Files (*.ans);;Terminal Snapshots (*.ptsnap);;Text Files (*.txt);;All Files (*)",
 	)
 
 	if file == "" {
@@ -2344,13 +2968,26 @@ func restoreBufferDialog(parent *qt.QWidget, term *purfectermqt.Terminal) {
 	if err != nil {
 		qt.QMessageBox_Critical5(
 			parent,
-			"Error",
-			fmt.Sprintf("Failed to read file: %v", err),
+			tr("Error"),
+			fmt.Sprintf(tr("Failed to read file: %v"), err),
 			qt.QMessageBox__Ok,
 		)
 		return
 	}
 
+	// A snapshot restores the terminal exactly, replacing what it shows
+	if purfecterm.IsSnapshot(content) {
+		if err := term.RestoreSnapshot(bytes.NewReader(content)); err != nil {
+			qt.QMessageBox_Critical5(
+				parent,
+				tr("Error"),
+				fmt.Sprintf(tr("Failed to restore terminal state: %v"), err),
+				qt.QMessageBox__Ok,
+			)
+		}
+		return
+	}
+
 	// Convert LF to CR+LF for proper terminal display
 	// (LF alone moves down without returning to column 0)
 	contentStr := strings.ReplaceAll(string(content), "\r\n", "\n") // Normalize first
@@ -2360,33 +2997,178 @@ func restoreBufferDialog(parent *qt.QWidget, term *purfectermqt.Terminal) {
 	term.Feed(contentStr)
 }
 
-// createBlankConsoleWindow creates a new blank terminal window with REPL
-func createBlankConsoleWindow() {
-	// Create new window
+// Console tabs
+// The consoles, shells and scripts the launcher opens share a window, each in a
+// tab with its own terminal, toolbar strip, REPL and script state. Tabs are dragged
+// to reorder them and closed with their close buttons, the Close menu item or the
+// close shortcut; closing the last one closes the window. New tabs open in the tab
+// window last active, and New Window starts another.
+
+// tabWindow is a window holding console tabs
+type tabWindow struct {
+	win     *qt.QMainWindow
+	tabs    *qt.QTabWidget
+	onClose map[unsafe.Pointer]func()      // Cleanup of each tab, by its page
+	session map[unsafe.Pointer]*sessionTab // The tabs open, by page, for the session
+
+	background bool // Tabs open without raising it, for scheduled tasks
+}
+
+// currentTabWindow is where new tabs open: the tab window last active, or nil when
+// none is open
+var currentTabWindow *tabWindow
+
+// getTabWindow returns the tab window to open a tab in, opening a window if there
+// is none or newWindow is set. A new window is shown when its first tab is added.
+func getTabWindow(newWindow bool) *tabWindow {
+	if currentTabWindow != nil && !newWindow {
+		return currentTabWindow
+	}
+
 	win := qt.NewQMainWindow2()
-	win.SetWindowTitle("PawScript - Console")
+	win.SetWindowTitle(tr("PawScript - Console"))
 	win.SetMinimumSize2(900, 600)
+	tabs := qt.NewQTabWidget2()
+	tw := &tabWindow{win: win, tabs: tabs, onClose: make(map[unsafe.Pointer]func()),
+		session: make(map[unsafe.Pointer]*sessionTab)}
 
-	// Set up quit shortcut for this window
-	setupQuitShortcutForWindow(win)
+	// The close shortcut closes the tab shown rather than the window
+	setupShortcuts(win, func() {
+		tw.closeTab(tabs.CurrentIndex())
+	})
+
+	tabs.SetTabsClosable(true)
+	tabs.SetMovable(true)
+	tabs.SetDocumentMode(true)
+	tabs.OnTabCloseRequested(tw.closeTab)
+	tabs.OnCurrentChanged(func(index int) {
+		if index >= 0 {
+			win.SetWindowTitle(tr("PawScript - ") + tabs.TabText(index))
+		}
+	})
+
+	// New tab button after the tabs
+	newTabBtn := qt.NewQToolButton2()
+	newTabBtn.SetText("+")
+	newTabBtn.SetToolTip(tr("New Tab"))
+	newTabBtn.SetAutoRaise(true)
+	newTabBtn.OnClicked(func() {
+		currentTabWindow = tw
+		createBlankConsoleTab(false)
+	})
+	tabs.SetCornerWidget(newTabBtn.QWidget)
+
+	win.OnEvent(func(super func(event *qt.QEvent) bool, event *qt.QEvent) bool {
+		if event.Type() == qt.QEvent__WindowActivate && !tw.background {
+			currentTabWindow = tw
+		}
+		return super(event)
+	})
+	// Closing the window closes its tabs
+	win.OnCloseEvent(func(super func(event *qt.QCloseEvent), event *qt.QCloseEvent) {
+		saveSessionIfLast(win)
+		for tabs.Count() > 0 {
+			tw.removeTab(0)
+		}
+		if currentTabWindow == tw {
+			currentTabWindow = nil
+		}
+		for i, open := range tabWindows {
+			if open == tw {
+				tabWindows = append(tabWindows[:i], tabWindows[i+1:]...)
+				break
+			}
+		}
+		super(event)
+	})
+
+	win.SetCentralWidget(tabs.QWidget)
+	setupTranslucency(win)
+	currentTabWindow = tw
+	tabWindows = append(tabWindows, tw)
+	return tw
+}
+
+// addTab adds page as a tab titled title after the others and shows it. onClose
+// cleans up after the tab when it is closed. It returns a function that closes
+// the tab.
+func (tw *tabWindow) addTab(page *qt.QWidget, title string, onClose func()) (closeTab func()) {
+	tw.onClose[page.UnsafePointer()] = onClose
+	index := tw.tabs.AddTab(page, title)
+	tw.tabs.SetCurrentIndex(index)
+	tw.win.SetWindowTitle(tr("PawScript - ") + title)
+	if !tw.background {
+		tw.win.Show()
+		tw.win.Raise()
+		tw.win.ActivateWindow()
+	} else if !tw.win.IsVisible() {
+		tw.win.ShowMinimized() // Background windows open minimized
+	}
+	return func() {
+		tw.closeTab(tw.tabs.IndexOf(page))
+	}
+}
+
+// closeTab closes the tab at index, and the window with its last tab
+func (tw *tabWindow) closeTab(index int) {
+	if index < 0 || index >= tw.tabs.Count() {
+		return
+	}
+	tw.removeTab(index)
+	if tw.tabs.Count() == 0 {
+		tw.win.Close()
+	}
+}
+
+// removeTab takes the tab at index out of the window and cleans up after it. The
+// page is hidden rather than deleted, as its terminal may still be fed output.
+func (tw *tabWindow) removeTab(index int) {
+	page := tw.tabs.Widget(index)
+	tw.tabs.RemoveTab(index)
+	delete(tw.session, page.UnsafePointer())
+	if onClose := tw.onClose[page.UnsafePointer()]; onClose != nil {
+		delete(tw.onClose, page.UnsafePointer())
+		onClose()
+	}
+}
+
+// closeIfEmpty closes the window if it has no tabs, when opening its first failed
+func (tw *tabWindow) closeIfEmpty() {
+	if tw.tabs.Count() == 0 {
+		tw.win.Close()
+	}
+}
+
+// createBlankConsoleTab opens a console tab with a REPL, in a new tab window if
+// newWindow is set
+func createBlankConsoleTab(newWindow bool) {
+	tabs := getTabWindow(newWindow)
+	win := tabs.win
 
-	// Create terminal for this window with color scheme from config
+	// Create terminal for this tab with color scheme from config
 	winTerminal, err := purfectermqt.New(purfectermqt.Options{
 		Cols:           100,
 		Rows:           30,
-		ScrollbackSize: 10000,
+		ScrollbackSize: getScrollbackLines(),
 		FontFamily:     getFontFamily(),
 		FontSize:       getFontSize(),
 		Scheme:         getDualColorScheme(),
 	})
 	if err != nil {
-		win.Close()
+		tabs.closeIfEmpty()
 		return
 	}
 
 	// Set font fallbacks for Unicode/CJK characters
 	winTerminal.SetFontFallbacks(getFontFamilyUnicode(), getFontFamilyCJK())
 
+	// Key macros from the config (scripts can add more with key_macro)
+	setupKeyMacros(winTerminal)
+	setupWindowZoom(winTerminal, "console")
+
+	// Flash the window in the taskbar when a background script rings the bell
+	setupBellUrgency(win, winTerminal)
+
 	// Set up terminal theme from config
 	prefersDark := isTermThemeDark()
 	winTerminal.Buffer().SetPreferredDarkTheme(prefersDark)
@@ -2397,9 +3179,10 @@ func createBlankConsoleWindow() {
 		winTerminal.SetColorScheme(getColorSchemeForTheme(isDark))
 	})
 
-	// Track script running state for this window (starts with no script)
+	// Track script running state for this tab (starts with no script)
 	var winScriptRunning bool
 	var winScriptMu sync.Mutex
+	var closeTab func()
 
 	// Create splitter for toolbar strip + terminal
 	winSplitter := qt.NewQSplitter3(qt.Horizontal)
@@ -2409,8 +3192,8 @@ func createBlankConsoleWindow() {
 		winScriptMu.Lock()
 		defer winScriptMu.Unlock()
 		return winScriptRunning
-	}, func() {
-		win.Close()
+	}, nil, func() {
+		closeTab()
 	})
 	narrowWidth := scaledMinNarrowStripWidth()
 	winNarrowStrip.SetFixedWidth(narrowWidth)
@@ -2424,11 +3207,13 @@ func createBlankConsoleWindow() {
 		menuButton: winStripMenuBtn,
 		terminal:   winTerminal,
 	}
-	qtToolbarDataByWindow[win] = blankConsoleToolbarData
+	qtToolbarDataByWindow[winSplitter.QWidget] = blankConsoleToolbarData
 	qtToolbarDataMu.Unlock()
 
 	winSplitter.AddWidget(winNarrowStrip)
-	winSplitter.AddWidget(winTerminal.Widget())
+	// Status bar under the terminal, set by scripts with status_set
+	winStatus := newStatusBar()
+	winSplitter.AddWidget(withStatusBar(winTerminal.Widget(), winStatus))
 
 	winSplitter.SetStretchFactor(0, 0)
 	winSplitter.SetStretchFactor(1, 1)
@@ -2449,84 +3234,47 @@ func createBlankConsoleWindow() {
 		}
 	})
 
-	win.SetCentralWidget(winSplitter.QWidget)
-
 	// Create I/O channels for this window's console
 	winStdinReader, winStdinWriter := io.Pipe()
 
-	// Terminal capabilities for this window
-	winWidth, winHeight := 100, 30
-	winTermCaps := &pawscript.TerminalCapabilities{
-		TermType:      "gui-console",
-		IsTerminal:    true,
-		SupportsANSI:  true,
-		SupportsColor: true,
-		ColorDepth:    256,
-		Width:         winWidth,
-		Height:        winHeight,
-		SupportsInput: true,
-		EchoEnabled:   false,
-		LineMode:      false,
-		Metadata:      make(map[string]interface{}),
-	}
+	// Terminal capabilities for this window (auto-updated on resize and theme change)
+	winTermCaps := winTerminal.GetTerminalCapabilities()
 
 	// Non-blocking output queue
+	// The queue is never closed, as a script may still be sending to it; closing the
+	// channel ends the script's sends and closing winOutputQueueDone stops the writer
 	winOutputQueue := make(chan interface{}, 256)
+	winOutputQueueDone := make(chan struct{})
 	go func() {
-		for item := range winOutputQueue {
-			switch v := item.(type) {
-			case []byte:
-				winTerminal.Feed(string(v))
-			case string:
-				winTerminal.Feed(v)
-			case chan struct{}:
-				close(v)
+		for {
+			select {
+			case item := <-winOutputQueue:
+				switch v := item.(type) {
+				case chan struct{}:
+					close(v)
+				default:
+					winTerminal.Feed(string(pawscript.ConsoleBytes(v)))
+				}
+			case <-winOutputQueueDone:
+				return
 			}
 		}
 	}()
 
-	winOutCh := &pawscript.StoredChannel{
-		BufferSize:       0,
-		Messages:         make([]pawscript.ChannelMessage, 0),
-		Subscribers:      make(map[int]*pawscript.StoredChannel),
-		NextSubscriberID: 1,
-		IsClosed:         false,
-		Timestamp:        time.Now(),
-		Terminal:         winTermCaps,
-		NativeSend: func(v interface{}) error {
-			var text string
-			switch d := v.(type) {
-			case []byte:
-				text = string(d)
-			case string:
-				text = d
-			default:
-				text = fmt.Sprintf("%v", v)
-			}
-			text = strings.ReplaceAll(text, "\r\n", "\n")
-			text = strings.ReplaceAll(text, "\n", "\r\n")
-			select {
-			case winOutputQueue <- []byte(text):
-			default:
-			}
-			return nil
-		},
-		NativeRecv: func() (interface{}, error) {
-			return nil, fmt.Errorf("cannot receive from console_out")
-		},
-		NativeFlush: func() error {
-			writerDone := make(chan struct{})
-			select {
-			case winOutputQueue <- writerDone:
-				<-writerDone
-			default:
-			}
-			return nil
-		},
+	winOutCh := pawscript.NewChannelFromGo(winOutputQueue, nil, winTermCaps)
+	winOutCh.Overflow = pawscript.OverflowDropNewest // a stalled GUI drops output rather than blocking the script
+	winOutCh.NativeFlush = func() error {
+		writerDone := make(chan struct{})
+		select {
+		case winOutputQueue <- writerDone:
+			<-writerDone
+		default:
+		}
+		return nil
 	}
 
 	// Non-blocking input queue
-	winInputQueue := make(chan byte, 256)
+	winInputQueue := make(chan interface{}, 256)
 	go func() {
 		buf := make([]byte, 1)
 		for {
@@ -2536,42 +3284,27 @@ func createBlankConsoleWindow() {
 				return
 			}
 			select {
-			case winInputQueue <- buf[0]:
+			case winInputQueue <- []byte{buf[0]}:
 			default:
 				select {
 				case <-winInputQueue:
 				default:
 				}
 				select {
-				case winInputQueue <- buf[0]:
+				case winInputQueue <- []byte{buf[0]}:
 				default:
 				}
 			}
 		}
 	}()
 
-	winInCh := &pawscript.StoredChannel{
-		BufferSize:       0,
-		Messages:         make([]pawscript.ChannelMessage, 0),
-		Subscribers:      make(map[int]*pawscript.StoredChannel),
-		NextSubscriberID: 1,
-		IsClosed:         false,
-		Timestamp:        time.Now(),
-		Terminal:         winTermCaps,
-		NativeRecv: func() (interface{}, error) {
-			b, ok := <-winInputQueue
-			if !ok {
-				return nil, fmt.Errorf("input closed")
-			}
-			return []byte{b}, nil
-		},
-		NativeSend: func(v interface{}) error {
-			return fmt.Errorf("cannot send to console_in")
-		},
-	}
+	winInCh := pawscript.NewChannelFromGo(nil, winInputQueue, winTermCaps)
 
 	var winREPL *pawscript.REPL
 
+	// Ctrl+click on a hyperlink opens it
+	winTerminal.SetLinkClickCallback(openHyperlink)
+
 	// Wire keyboard input
 	winTerminal.SetInputCallback(func(data []byte) {
 		winScriptMu.Lock()
@@ -2589,18 +3322,18 @@ func createBlankConsoleWindow() {
 		}
 	})
 
-	// Clean up on window close
-	win.OnDestroyed(func() {
+	// Clean up on tab close
+	closeTab = tabs.addTab(winSplitter.QWidget, "Console", func() {
 		// Clean up toolbar data
 		qtToolbarDataMu.Lock()
-		delete(qtToolbarDataByWindow, win)
+		delete(qtToolbarDataByWindow, winSplitter.QWidget)
 		qtToolbarDataMu.Unlock()
 		winStdinWriter.Close()
 		winStdinReader.Close()
-		close(winOutputQueue)
+		_ = pawscript.ChannelClose(winOutCh)
+		close(winOutputQueueDone)
 	})
-
-	win.Show()
+	tabs.remember(winSplitter.QWidget, pawgui.SessionTab{Kind: pawgui.SessionConsole}, winTerminal, nil)
 
 	// Start REPL immediately (no script to run first)
 	go func() {
@@ -2614,6 +3347,7 @@ func createBlankConsoleWindow() {
 				Stdin:  winInCh,
 				Stderr: winOutCh,
 			},
+			Dialogs: guiDialogs{},
 		}, func(s string) {
 			winTerminal.Feed(s)
 		})
@@ -2624,12 +3358,77 @@ func createBlankConsoleWindow() {
 		winREPL.SetBackgroundRGB(bg.R, bg.G, bg.B)
 		winREPL.SetPSLColors(getPSLColors())
 		winREPL.Start()
+		pawgui.RegisterStatusSetCommand(winREPL.GetPawScript(), winStatus.status)
+		pawgui.RegisterProgressCommand(winREPL.GetPawScript(), winStatus.status)
+		pawgui.RegisterWidgetCommands(winREPL.GetPawScript(), scriptWidgets)
+		pawgui.RegisterNotifyCommand(winREPL.GetPawScript())
+		pawgui.RegisterTraySetCommand(winREPL.GetPawScript(), launcherTray)
 	}()
 }
 
+// openShellTab opens a tab running the user's shell in dir, returning its
+// terminal (nil if it didn't open)
+func openShellTab(dir string) *purfectermqt.Terminal {
+	tabs := getTabWindow(false)
+	win := tabs.win
+
+	winTerminal, err := purfectermqt.New(purfectermqt.Options{
+		Cols:           100,
+		Rows:           30,
+		ScrollbackSize: getScrollbackLines(),
+		FontFamily:     getFontFamily(),
+		FontSize:       getFontSize(),
+		Scheme:         getDualColorScheme(),
+		WorkingDir:     dir,
+	})
+	if err != nil {
+		tabs.closeIfEmpty()
+		return nil
+	}
+
+	// Set font fallbacks for Unicode/CJK characters
+	winTerminal.SetFontFallbacks(getFontFamilyUnicode(), getFontFamilyCJK())
+
+	// Key macros from the config (scripts can add more with key_macro)
+	setupKeyMacros(winTerminal)
+	setupWindowZoom(winTerminal, "shell")
+
+	// Flash the window in the taskbar when the shell rings the bell
+	setupBellUrgency(win, winTerminal)
+
+	// Set up terminal theme from config
+	prefersDark := isTermThemeDark()
+	winTerminal.Buffer().SetPreferredDarkTheme(prefersDark)
+	winTerminal.Buffer().SetDarkTheme(prefersDark)
+
+	// Set up theme change callback (for CSI ? 5 h/l escape sequences)
+	winTerminal.Buffer().SetThemeChangeCallback(func(isDark bool) {
+		winTerminal.SetColorScheme(getColorSchemeForTheme(isDark))
+	})
+
+	// Ctrl+click on a hyperlink opens it
+	winTerminal.SetLinkClickCallback(openHyperlink)
+
+	// Leave the tab open when the shell exits, so its last output can be read
+	winTerminal.SetExitCallback(func(err error) {
+		winTerminal.Feed("\r\n[Process exited]\r\n")
+	})
+
+	// Clean up on tab close - kill the shell if it is still running
+	tabs.addTab(winTerminal.Widget(), "Shell", func() {
+		winTerminal.Close()
+	})
+	tabs.remember(winTerminal.Widget(), pawgui.SessionTab{Kind: pawgui.SessionShell, Dir: dir}, winTerminal, nil)
+
+	if err := winTerminal.RunShell(); err != nil {
+		winTerminal.Feed(fmt.Sprintf(tr("Failed to start shell: %v\r\n"), err))
+	}
+	return winTerminal
+}
+
 // createToolbarStripForWindow creates a vertical strip of toolbar buttons for a specific window
-func createToolbarStripForWindow(parent *qt.QWidget, isScriptWindow bool, term *purfectermqt.Terminal, isScriptRunningFunc func() bool, closeWindowFunc func()) (*qt.QWidget, *IconButton, *qt.QMenu) {
-	menu := createHamburgerMenu(parent, isScriptWindow, term, isScriptRunningFunc, closeWindowFunc)
+func createToolbarStripForWindow(parent *qt.QWidget, isScriptWindow bool, term *purfectermqt.Terminal, isScriptRunningFunc func() bool, stopScriptFunc func(), closeWindowFunc func()) (*qt.QWidget, *IconButton, *qt.QMenu) {
+	menu := createHamburgerMenu(parent, isScriptWindow, term, isScriptRunningFunc, stopScriptFunc, closeWindowFunc)
 	return createToolbarStripWithMenu(menu)
 }
 
@@ -2680,7 +3479,7 @@ func scaledMenuIconSize() int {
 func createHamburgerButton(menu *qt.QMenu) *IconButton {
 	svgData := getSVGIcon(hamburgerIconSVG)
 	btn := NewIconButton(scaledToolbarButtonSize(), scaledToolbarIconSize(), svgData)
-	btn.SetToolTip("Menu")
+	btn.SetToolTip(tr("Menu"))
 
 	// Show menu at the button's position when clicked
 	btn.SetOnClick(func() {
@@ -2703,7 +3502,7 @@ func createToolbarStrip(parent *qt.QWidget, isScriptWindow bool) (*qt.QWidget, *
 			mainWindow.Close()
 		}
 	}
-	return createToolbarStripForWindow(parent, isScriptWindow, nil, isScriptRunningFunc, closeWindowFunc)
+	return createToolbarStripForWindow(parent, isScriptWindow, nil, isScriptRunningFunc, stopLauncherScript, closeWindowFunc)
 }
 
 // updateLauncherToolbarButtons updates the launcher's narrow strip with the current registered buttons
@@ -2722,7 +3521,7 @@ func updateLauncherToolbarButtons() {
 	}
 	vbox := qt.UnsafeNewQVBoxLayout(layout.UnsafePointer())
 
-	// Remove existing dummy buttons (but keep the hamburger menu button and stretch at the end)
+	// Remove existing script buttons (but keep the hamburger menu button and stretch at the end)
 	// We skip index 0 (hamburger) and the stretch item at the end
 	for vbox.Count() > 2 {
 		item := vbox.TakeAt(1)
@@ -2731,9 +3530,9 @@ func updateLauncherToolbarButtons() {
 		}
 	}
 
-	// Add new dummy buttons (insert after hamburger button, before stretch)
+	// Add the script buttons (insert after hamburger button, before stretch)
 	for _, btn := range launcherRegisteredBtns {
-		svgData := getSVGIcon(starIconSVG)
+		svgData := getSVGIcon(toolbarIconSVG(btn.Icon))
 		button := NewIconButton(scaledToolbarButtonSize(), scaledToolbarIconSize(), svgData)
 		button.SetToolTip(btn.Tooltip)
 		if btn.OnClick != nil {
@@ -2815,7 +3614,7 @@ func updateWindowToolbarButtons(strip *qt.QWidget, buttons []*QtToolbarButton) {
 	}
 	vbox := qt.UnsafeNewQVBoxLayout(layout.UnsafePointer())
 
-	// Remove existing dummy buttons (but keep the hamburger menu button and stretch at the end)
+	// Remove existing script buttons (but keep the hamburger menu button and stretch at the end)
 	// We skip index 0 (hamburger) and the stretch item at the end
 	for vbox.Count() > 2 {
 		item := vbox.TakeAt(1)
@@ -2824,9 +3623,9 @@ func updateWindowToolbarButtons(strip *qt.QWidget, buttons []*QtToolbarButton) {
 		}
 	}
 
-	// Add new dummy buttons (insert after hamburger button, before stretch)
+	// Add the script buttons (insert after hamburger button, before stretch)
 	for _, btn := range buttons {
-		svgData := getSVGIcon(starIconSVG)
+		svgData := getSVGIcon(toolbarIconSVG(btn.Icon))
 		button := NewIconButton(scaledToolbarButtonSize(), scaledToolbarIconSize(), svgData)
 		button.SetToolTip(btn.Tooltip)
 		if btn.OnClick != nil {
@@ -2843,27 +3642,18 @@ func updateWindowToolbarButtons(strip *qt.QWidget, buttons []*QtToolbarButton) {
 	strip.Show()
 }
 
-// setDummyButtonsForWindow sets the number of dummy buttons for a specific window
-func setDummyButtonsForWindow(data *QtWindowToolbarData, count int) {
-	// Clear existing dummy buttons
-	data.registeredBtns = nil
-
-	// Add new dummy buttons
-	for i := 0; i < count; i++ {
-		icon := dummyIcons[i%len(dummyIcons)]
-		idx := i              // Capture for closure
-		term := data.terminal // Capture terminal for closure
-		btn := &QtToolbarButton{
-			Icon:    icon,
-			Tooltip: fmt.Sprintf("Dummy Button %d", i+1),
-			OnClick: func() {
-				if term != nil {
-					term.Feed(fmt.Sprintf("\r\nDummy button %d clicked!\r\n", idx+1))
-				}
-			},
-		}
-		data.registeredBtns = append(data.registeredBtns, btn)
+// setScriptButtonsForWindow redraws a window's strip with the buttons its
+// scripts added
+func setScriptButtonsForWindow(data *QtWindowToolbarData) {
+	var registered []*QtToolbarButton
+	for _, b := range data.scriptButtons.Buttons() {
+		registered = append(registered, &QtToolbarButton{
+			Icon:    b.Icon,
+			Tooltip: b.Tooltip,
+			OnClick: b.Activate,
+		})
 	}
+	data.registeredBtns = registered
 
 	// Queue this window for update on the main thread
 	if data.updateFunc != nil {
@@ -2873,76 +3663,43 @@ func setDummyButtonsForWindow(data *QtWindowToolbarData, count int) {
 	}
 }
 
-// setDummyButtons sets the number of dummy buttons in the launcher toolbar strip (legacy)
-func setDummyButtons(count int) {
-	// Clear existing dummy buttons
-	launcherRegisteredBtns = nil
-
-	// Add new dummy buttons
-	for i := 0; i < count; i++ {
-		icon := dummyIcons[i%len(dummyIcons)]
-		idx := i // Capture for closure
-		btn := &QtToolbarButton{
-			Icon:    icon,
-			Tooltip: fmt.Sprintf("Dummy Button %d", i+1),
-			OnClick: func() {
-				if terminal != nil {
-					terminal.Feed(fmt.Sprintf("\r\nDummy button %d clicked!\r\n", idx+1))
-				}
-			},
-		}
-		launcherRegisteredBtns = append(launcherRegisteredBtns, btn)
-	}
-
-	// Signal the main thread to update the toolbar strip
-	// The uiUpdateTimer will check this flag and call updateLauncherToolbarButtons()
-	pendingToolbarUpdate = true
-}
-
-// registerDummyButtonCommand registers the dummy_button command with PawScript
+// registerToolbarButtonCommand registers the toolbar_button command with PawScript
 // using per-window toolbar data
-func registerDummyButtonCommand(ps *pawscript.PawScript, data *QtWindowToolbarData) {
+func registerToolbarButtonCommand(ps *pawscript.PawScript, data *QtWindowToolbarData) {
 	// Store the association
 	qtToolbarDataMu.Lock()
 	qtToolbarDataByPS[ps] = data
+	if data.scriptButtons == nil {
+		data.scriptButtons = pawgui.NewToolbarButtons(func() {
+			setScriptButtonsForWindow(data)
+		})
+	}
 	qtToolbarDataMu.Unlock()
 
-	ps.RegisterCommand("dummy_button", func(ctx *pawscript.Context) pawscript.Result {
-		if len(ctx.Args) < 1 {
-			ctx.LogError(pawscript.CatCommand, "dummy_button requires a count argument")
-			return pawscript.BoolStatus(false)
-		}
-
-		// Get the count argument
-		count := 0
-		switch v := ctx.Args[0].(type) {
-		case int:
-			count = v
-		case int64:
-			count = int(v)
-		case float64:
-			count = int(v)
-		default:
-			ctx.LogError(pawscript.CatCommand, "dummy_button requires a numeric argument")
-			return pawscript.BoolStatus(false)
-		}
+	pawgui.RegisterToolbarButtonCommand(ps, data.scriptButtons)
+}
 
-		if count < 0 {
-			count = 0
-		}
-		if count > 20 {
-			count = 20 // Cap at 20 buttons
-		}
+// hyperlinkSchemes are the URI schemes a Ctrl+clicked terminal link may open
+var hyperlinkSchemes = []string{"http://", "https://", "ftp://", "mailto:"}
 
-		// Use the captured window data
-		setDummyButtonsForWindow(data, count)
-		ctx.SetResult(count)
-		return pawscript.BoolStatus(true)
-	})
+// openHyperlink opens a terminal hyperlink with the system's default handler
+// Other schemes (file:, custom app handlers) are ignored, since scripts choose the URI.
+func openHyperlink(uri string) {
+	for _, scheme := range hyperlinkSchemes {
+		if strings.HasPrefix(strings.ToLower(uri), scheme) {
+			qt.QDesktopServices_OpenUrl(qt.NewQUrl3(uri))
+			return
+		}
+	}
 }
 
 // isSystemDarkMode detects if the OS is currently using dark mode
 func isSystemDarkMode() bool {
+	// The OS's own setting, once followSystemTheme has read it
+	if dark, ok := pawgui.SystemDarkTheme(); ok {
+		return dark
+	}
+
 	// On macOS, check AppleInterfaceStyle preference
 	if runtime.GOOS == "darwin" {
 		// Try to read macOS dark mode setting
@@ -2966,6 +3723,16 @@ func isSystemDarkMode() bool {
 	return luminance < 128
 }
 
+// enableHighDPI has Qt scale windows by each screen's scale factor, passing
+// fractional ones (125%, 150%) through rather than rounding them, so text, the
+// terminal and icons are drawn at the screen's resolution. It must be called
+// before the application is created.
+func enableHighDPI() {
+	qt.QCoreApplication_SetAttribute(qt.AA_EnableHighDpiScaling)
+	qt.QCoreApplication_SetAttribute(qt.AA_UseHighDpiPixmaps)
+	qt.QGuiApplication_SetHighDpiScaleFactorRoundingPolicy(qt.PassThrough)
+}
+
 // applyTheme sets the Qt application palette based on the configuration.
 // "auto" = detect OS preference, "dark" = force dark palette, "light" = force light palette
 func applyTheme(theme pawgui.ThemeMode) {
@@ -3273,6 +4040,11 @@ func applyTheme(theme pawgui.ThemeMode) {
 
 	// Update toolbar icons to match new theme colors
 	updateToolbarIcons()
+
+	// Recolor the script editor's highlighting
+	if launcherEditor != nil {
+		launcherEditor.updateColors()
+	}
 }
 
 // updateToolbarIcons regenerates all toolbar icons with the current theme's colors
@@ -3288,7 +4060,7 @@ func updateToolbarIcons() {
 	// Update all registered buttons in launcher toolbar
 	for _, btn := range launcherRegisteredBtns {
 		if btn.widget != nil {
-			btn.widget.UpdateSize(scaledToolbarButtonSize(), scaledToolbarIconSize(), getSVGIcon(starIconSVG))
+			btn.widget.UpdateSize(scaledToolbarButtonSize(), scaledToolbarIconSize(), getSVGIcon(toolbarIconSVG(btn.Icon)))
 		}
 	}
 
@@ -3302,7 +4074,7 @@ func updateToolbarIcons() {
 		// Update registered buttons
 		for _, btn := range data.registeredBtns {
 			if btn.widget != nil {
-				btn.widget.UpdateSize(scaledToolbarButtonSize(), scaledToolbarIconSize(), getSVGIcon(starIconSVG))
+				btn.widget.UpdateSize(scaledToolbarButtonSize(), scaledToolbarIconSize(), getSVGIcon(toolbarIconSVG(btn.Icon)))
 			}
 		}
 	}
@@ -3316,7 +4088,7 @@ func updateToolbarIcons() {
 		// Update registered buttons
 		for _, btn := range data.registeredBtns {
 			if btn.widget != nil {
-				btn.widget.UpdateSize(scaledToolbarButtonSize(), scaledToolbarIconSize(), getSVGIcon(starIconSVG))
+				btn.widget.UpdateSize(scaledToolbarButtonSize(), scaledToolbarIconSize(), getSVGIcon(toolbarIconSVG(btn.Icon)))
 			}
 		}
 	}
@@ -3453,17 +4225,20 @@ func main() {
 	flag.BoolVar(verboseFlag, "v", false, "Enable verbose output (short, alias for -debug)")
 
 	// File access control flags
-	unrestrictedFlag := flag.Bool("unrestricted", false, "Disable all file/exec access restrictions")
+	unrestrictedFlag := flag.Bool("unrestricted", false, "Disable all file/exec/network access restrictions")
 	readRootsFlag := flag.String("read-roots", "", "Additional directories for file reading")
 	writeRootsFlag := flag.String("write-roots", "", "Additional directories for file writing")
 	execRootsFlag := flag.String("exec-roots", "", "Additional directories for exec command")
 	sandboxFlag := flag.String("sandbox", "", "Restrict all access to this directory only")
+	allowHostsFlag := flag.String("allow-hosts", "", "Hosts scripts may connect to (names, *.domain, CIDR)")
+	denyHostsFlag := flag.String("deny-hosts", "", "Hosts scripts may never connect to")
 
 	// Optimization level flag
 	optLevelFlag := flag.Int("O", 1, "Optimization level (0=no caching, 1=cache macro/loop bodies)")
 
 	// GUI-specific flags
 	windowFlag := flag.Bool("window", false, "Create console window for stdout/stdin/stderr")
+	recentFlag := flag.Bool("recent", false, "Run the script the launcher ran last")
 
 	// Custom usage function
 	flag.Usage = showUsage
@@ -3513,6 +4288,16 @@ func main() {
 	stdinInfo, _ := os.Stdin.Stat()
 	isStdinRedirected := (stdinInfo.Mode() & os.ModeCharDevice) == 0
 
+	// --recent runs the script the launcher ran last, with any arguments given
+	if *recentFlag {
+		recent := mostRecentScript()
+		if recent == "" {
+			fmt.Fprintln(os.Stderr, "Error: The launcher hasn't run any scripts yet")
+			os.Exit(1)
+		}
+		fileArgs = append([]string{recent}, fileArgs...)
+	}
+
 	if len(fileArgs) > 0 {
 		// Filename provided
 		requestedFile := fileArgs[0]
@@ -3553,7 +4338,7 @@ func main() {
 	// If we have script content (from file or stdin), run it
 	if scriptContent != "" {
 		runScriptFromCLI(scriptContent, scriptFile, scriptArgs, *windowFlag, *unrestrictedFlag,
-			*sandboxFlag, *readRootsFlag, *writeRootsFlag, *execRootsFlag, *optLevelFlag)
+			*sandboxFlag, *readRootsFlag, *writeRootsFlag, *execRootsFlag, *allowHostsFlag, *denyHostsFlag, *optLevelFlag)
 		return
 	}
 
@@ -3572,6 +4357,19 @@ func launchGUIMode() {
 		saveConfig(appConfig)
 	}
 
+	// Follow edits made to the config file while the launcher runs;
+	// the UI update timer applies them on the main thread
+	if configPath := getConfigPath(); configPath != "" {
+		_, err := appConfig.Watch(configPath, func(config pawscript.PSLConfig) {
+			pendingConfigMu.Lock()
+			pendingConfig = config
+			pendingConfigMu.Unlock()
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: not watching for changes: %v\n", configPath, err)
+		}
+	}
+
 	// Get initial directory
 	currentDir = appConfig.GetString("last_browse_dir", "")
 	if currentDir == "" {
@@ -3579,11 +4377,19 @@ func launchGUIMode() {
 	}
 
 	// Initialize Qt application
+	enableHighDPI()
 	qtApp = qt.NewQApplication(os.Args)
 
-	// Apply theme setting
+	// Show text in the language the settings pick
+	applyLanguage()
+
+	// Apply theme setting, following the OS's where it's auto
+	followSystemTheme()
 	applyTheme(configHelper.GetTheme())
 
+	// Reopen the project that was open when the launcher quit
+	restoreProject()
+
 	// Apply UI scaling via stylesheet (affects everything except terminal)
 	applyUIScale(getUIScale())
 
@@ -3591,6 +4397,18 @@ func launchGUIMode() {
 	mainWindow = qt.NewQMainWindow2()
 	mainWindow.SetWindowTitle(appName)
 
+	// Hide in the system tray when minimized, if minimize_to_tray is set
+	mainWindow.OnChangeEvent(hideWhenMinimized)
+
+	// Save the session if closing the launcher ends it
+	mainWindow.OnCloseEvent(func(super func(event *qt.QCloseEvent), event *qt.QCloseEvent) {
+		saveSessionIfLast(mainWindow)
+		super(event)
+	})
+
+	// Dropped scripts run and dropped folders open (see launcherdrop.go)
+	setupLauncherDrop(mainWindow)
+
 	// Get screen dimensions for bounds checking
 	screen := qt.QGuiApplication_PrimaryScreen()
 	screenGeom := screen.AvailableGeometry()
@@ -3676,11 +4494,13 @@ func launchGUIMode() {
 		scriptMu.Lock()
 		defer scriptMu.Unlock()
 		return scriptRunning
-	}, func() {
+	}, stopLauncherScript, func() {
 		if mainWindow != nil {
 			mainWindow.Close()
 		}
 	})
+	launcherScriptMenus = pawgui.NewScriptMenus()
+	addScriptMenus(launcherMenu, launcherScriptMenus)
 
 	// Wide panel (file browser) - uses shared launcherMenu
 	widePanel := createFilePanel()
@@ -3698,9 +4518,20 @@ func launchGUIMode() {
 
 	launcherSplitter.AddWidget(leftContainer)
 
-	// Right panel (terminal)
+	// Right panel (terminal), under the script editor when that is shown, and
+	// beside the debugger while a script is paused
 	rightPanel := createTerminalPanel()
-	launcherSplitter.AddWidget(rightPanel)
+	launcherDebugger = newLauncherDebugger()
+	launcherDebugPanel = newDebuggerPanel()
+	launcherDebugSplitter = qt.NewQSplitter3(qt.Horizontal)
+	launcherDebugSplitter.AddWidget(rightPanel)
+	launcherDebugSplitter.AddWidget(launcherDebugPanel.widget)
+	launcherDebugSplitter.SetStretchFactor(0, 1)
+	launcherEditor = newScriptEditor()
+	launcherEditorSplitter = qt.NewQSplitter3(qt.Vertical)
+	launcherEditorSplitter.AddWidget(launcherEditor.widget)
+	launcherEditorSplitter.AddWidget(launcherDebugSplitter.QWidget)
+	launcherSplitter.AddWidget(launcherEditorSplitter.QWidget)
 
 	// Set initial splitter sizes using saved launcher width
 	// Note: panelWidth represents only the wide panel width (not including strip)
@@ -3789,6 +4620,7 @@ func launchGUIMode() {
 
 	mainLayout.AddWidget(launcherSplitter.QWidget)
 	mainWindow.SetCentralWidget(centralWidget)
+	setupTranslucency(mainWindow)
 
 	// Set up console I/O
 	setupConsoleIO()
@@ -3797,8 +4629,8 @@ func launchGUIMode() {
 	terminal.Feed(fmt.Sprintf("pawgui-qt, the PawScript GUI interpreter version %s (with Qt)\r\n", version))
 	terminal.Feed("Copyright (c) 2025 Jeffrey R. Day\r\n")
 	terminal.Feed("License: MIT\r\n\r\n")
-	terminal.Feed("Interactive mode. Type 'exit' or 'quit' to leave.\r\n")
-	terminal.Feed("Select a .paw file and click Run to execute.\r\n\r\n")
+	terminal.Feed(tr("Interactive mode. Type 'exit' or 'quit' to leave.") + "\r\n")
+	terminal.Feed(tr("Select a .paw file and click Run to execute.") + "\r\n\r\n")
 
 	// Start REPL (prompt will appear after welcome message)
 	startREPL()
@@ -3825,6 +4657,22 @@ func launchGUIMode() {
 				data.updateFunc()
 			}
 		}
+		// Apply a config file edited outside the launcher
+		pendingConfigMu.Lock()
+		config := pendingConfig
+		pendingConfig = nil
+		pendingConfigMu.Unlock()
+		if config != nil {
+			reloadConfig(config)
+		}
+		// Ask about script file access outside the sandbox roots
+		pendingPromptsMu.Lock()
+		prompts := pendingPrompts
+		pendingPrompts = nil
+		pendingPromptsMu.Unlock()
+		for _, prompt := range prompts {
+			showPermissionPrompt(prompt)
+		}
 	})
 	uiUpdateTimer.Start(250)
 
@@ -3843,13 +4691,27 @@ func launchGUIMode() {
 	// Focus the Run button by default
 	runButton.SetFocus()
 
+	// Reopen the script editor if it was showing when the launcher last closed
+	if getLauncherEditorShown() {
+		launcherEditor.setShown(true)
+	}
+
+	// Put the launcher's icon in the system tray
+	startTray()
+
+	// Run the scheduled tasks as they fall due
+	startScheduler()
+
+	// Offer to open the windows of the last session again
+	offerSessionRestore()
+
 	// Run application
 	qt.QApplication_Exec()
 }
 
 // runScriptFromCLI executes a script provided via command line
 func runScriptFromCLI(scriptContent, scriptFile string, scriptArgs []string, windowFlag bool,
-	unrestricted bool, sandbox, readRoots, writeRoots, execRoots string, optLevel int) {
+	unrestricted bool, sandbox, readRoots, writeRoots, execRoots, allowHosts, denyHosts string, optLevel int) {
 
 	// Build file access configuration
 	var fileAccess *pawscript.FileAccessConfig
@@ -3965,6 +4827,22 @@ func runScriptFromCLI(scriptContent, scriptFile string, scriptArgs []string, win
 				fileAccess.ExecRoots = append(fileAccess.ExecRoots, parseRoots(execRoots)...)
 			}
 		}
+
+		// Network access: none unless --allow-hosts names the endpoints
+		var err error
+		fileAccess.AllowedHosts = []string{}
+		if allowHosts != "" {
+			if fileAccess.AllowedHosts, err = pawscript.ParseHostPatterns(allowHosts); err != nil {
+				fmt.Fprintf(os.Stderr, "Error in --allow-hosts: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if denyHosts != "" {
+			if fileAccess.DeniedHosts, err = pawscript.ParseHostPatterns(denyHosts); err != nil {
+				fmt.Fprintf(os.Stderr, "Error in --deny-hosts: %v\n", err)
+				os.Exit(1)
+			}
+		}
 	}
 
 	if !windowFlag {
@@ -4009,7 +4887,10 @@ func runScriptInWindow(scriptContent, scriptFile string, scriptArgs []string,
 	}
 
 	// Initialize Qt application
+	enableHighDPI()
 	qtApp = qt.NewQApplication(os.Args)
+	applyLanguage()
+	followSystemTheme()
 	applyTheme(configHelper.GetTheme())
 
 	// Create console window
@@ -4028,7 +4909,7 @@ func runScriptInWindow(scriptContent, scriptFile string, scriptArgs []string,
 	winTerminal, err := purfectermqt.New(purfectermqt.Options{
 		Cols:           100,
 		Rows:           30,
-		ScrollbackSize: 10000,
+		ScrollbackSize: getScrollbackLines(),
 		FontFamily:     getFontFamily(),
 		FontSize:       getFontSize(),
 		Scheme:         getDualColorScheme(),
@@ -4041,6 +4922,13 @@ func runScriptInWindow(scriptContent, scriptFile string, scriptArgs []string,
 	// Set font fallbacks
 	winTerminal.SetFontFallbacks(getFontFamilyUnicode(), getFontFamilyCJK())
 
+	// Key macros from the config (scripts can add more with key_macro)
+	setupKeyMacros(winTerminal)
+	setupWindowZoom(winTerminal, "script")
+
+	// Flash the window in the taskbar when a background script rings the bell
+	setupBellUrgency(win, winTerminal)
+
 	// Set up terminal theme from config
 	prefersDark := isTermThemeDark()
 	winTerminal.Buffer().SetPreferredDarkTheme(prefersDark)
@@ -4051,18 +4939,28 @@ func runScriptInWindow(scriptContent, scriptFile string, scriptArgs []string,
 		winTerminal.SetColorScheme(getColorSchemeForTheme(isDark))
 	})
 
-	// In standalone script mode, script is always running
-	winScriptRunning := true
+	// The running script, for Stop Script
+	var winRun *pawgui.ScriptRun
+	var winRunMu sync.Mutex
 
 	// Create splitter for toolbar strip + terminal
 	winSplitter := qt.NewQSplitter3(qt.Horizontal)
 
 	// Create toolbar strip for this window (script windows only have narrow strip, no wide panel)
-	winNarrowStrip, winStripMenuBtn, _ := createToolbarStripForWindow(win.QWidget, true, winTerminal, func() bool {
-		return winScriptRunning
+	winNarrowStrip, winStripMenuBtn, winMenu := createToolbarStripForWindow(win.QWidget, true, winTerminal, func() bool {
+		winRunMu.Lock()
+		defer winRunMu.Unlock()
+		return winRun != nil
+	}, func() {
+		winRunMu.Lock()
+		run := winRun
+		winRunMu.Unlock()
+		stopScript(run)
 	}, func() {
 		win.Close()
 	})
+	winScriptMenus := pawgui.NewScriptMenus()
+	addScriptMenus(winMenu, winScriptMenus)
 	narrowWidth := scaledMinNarrowStripWidth()
 	winNarrowStrip.SetFixedWidth(narrowWidth)
 	// Start visible with hamburger menu
@@ -4076,11 +4974,19 @@ func runScriptInWindow(scriptContent, scriptFile string, scriptArgs []string,
 		menuButton: winStripMenuBtn,
 		terminal:   winTerminal,
 	}
-	qtToolbarDataByWindow[win] = runScriptToolbarData
+	runScriptToolbarData.updateFunc = func() {
+		updateWindowToolbarButtons(runScriptToolbarData.strip, runScriptToolbarData.registeredBtns)
+	}
+	runScriptToolbarData.scriptButtons = pawgui.NewToolbarButtons(func() {
+		setScriptButtonsForWindow(runScriptToolbarData)
+	})
+	qtToolbarDataByWindow[win.QWidget] = runScriptToolbarData
 	qtToolbarDataMu.Unlock()
 
 	winSplitter.AddWidget(winNarrowStrip)
-	winSplitter.AddWidget(winTerminal.Widget())
+	// Status bar under the terminal, set by scripts with status_set
+	winStatus := newStatusBar()
+	winSplitter.AddWidget(withStatusBar(winTerminal.Widget(), winStatus))
 
 	// Set stretch factors so strip is fixed and terminal is flexible
 	winSplitter.SetStretchFactor(0, 0)
@@ -4108,82 +5014,41 @@ func runScriptInWindow(scriptContent, scriptFile string, scriptArgs []string,
 	})
 
 	win.SetCentralWidget(winSplitter.QWidget)
+	setupTranslucency(win)
 
 	// Create I/O channels for this window
 	winStdinReader, winStdinWriter := io.Pipe()
 
-	width, height := 100, 30
-	winTermCaps := &pawscript.TerminalCapabilities{
-		TermType:      "gui-console",
-		IsTerminal:    true,
-		SupportsANSI:  true,
-		SupportsColor: true,
-		ColorDepth:    256,
-		Width:         width,
-		Height:        height,
-		SupportsInput: true,
-		EchoEnabled:   false,
-		LineMode:      false,
-		Metadata:      make(map[string]interface{}),
-	}
+	// Terminal capabilities for this window (auto-updated on resize and theme change)
+	winTermCaps := winTerminal.GetTerminalCapabilities()
 
 	// Non-blocking output queue
 	winOutputQueue := make(chan interface{}, 256)
 	go func() {
 		for item := range winOutputQueue {
 			switch v := item.(type) {
-			case []byte:
-				winTerminal.Feed(string(v))
-			case string:
-				winTerminal.Feed(v)
 			case chan struct{}:
 				close(v)
+			default:
+				winTerminal.Feed(string(pawscript.ConsoleBytes(v)))
 			}
 		}
 	}()
 
-	winOutCh := &pawscript.StoredChannel{
-		BufferSize:       0,
-		Messages:         make([]pawscript.ChannelMessage, 0),
-		Subscribers:      make(map[int]*pawscript.StoredChannel),
-		NextSubscriberID: 1,
-		IsClosed:         false,
-		Timestamp:        time.Now(),
-		Terminal:         winTermCaps,
-		NativeSend: func(v interface{}) error {
-			var text string
-			switch d := v.(type) {
-			case []byte:
-				text = string(d)
-			case string:
-				text = d
-			default:
-				text = fmt.Sprintf("%v", v)
-			}
-			text = strings.ReplaceAll(text, "\r\n", "\n")
-			text = strings.ReplaceAll(text, "\n", "\r\n")
-			select {
-			case winOutputQueue <- []byte(text):
-			default:
-			}
-			return nil
-		},
-		NativeRecv: func() (interface{}, error) {
-			return nil, fmt.Errorf("cannot receive from console_out")
-		},
-		NativeFlush: func() error {
-			writerDone := make(chan struct{})
-			select {
-			case winOutputQueue <- writerDone:
-				<-writerDone
-			default:
-			}
-			return nil
-		},
+	winOutCh := pawscript.NewChannelFromGo(winOutputQueue, nil, winTermCaps)
+	winOutCh.Overflow = pawscript.OverflowDropNewest // a stalled GUI drops output rather than blocking the script
+	winOutCh.NativeFlush = func() error {
+		writerDone := make(chan struct{})
+		select {
+		case winOutputQueue <- writerDone:
+			<-writerDone
+		default:
+		}
+		return nil
 	}
 
 	// Non-blocking input queue
-	winInputQueue := make(chan byte, 256)
+	winInputQueue := make(chan interface{}, 256)
 	go func() {
 		buf := make([]byte, 1)
 		for {
@@ -4193,39 +5058,24 @@ func runScriptInWindow(scriptContent, scriptFile string, scriptArgs []string,
 				return
 			}
 			select {
-			case winInputQueue <- buf[0]:
+			case winInputQueue <- []byte{buf[0]}:
 			default:
 				select {
 				case <-winInputQueue:
 				default:
 				}
 				select {
-				case winInputQueue <- buf[0]:
+				case winInputQueue <- []byte{buf[0]}:
 				default:
 				}
 			}
 		}
 	}()
 
-	winInCh := &pawscript.StoredChannel{
-		BufferSize:       0,
-		Messages:         make([]pawscript.ChannelMessage, 0),
-		Subscribers:      make(map[int]*pawscript.StoredChannel),
-		NextSubscriberID: 1,
-		IsClosed:         false,
-		Timestamp:        time.Now(),
-		Terminal:         winTermCaps,
-		NativeRecv: func() (interface{}, error) {
-			b, ok := <-winInputQueue
-			if !ok {
-				return nil, fmt.Errorf("input closed")
-			}
-			return []byte{b}, nil
-		},
-		NativeSend: func(v interface{}) error {
-			return fmt.Errorf("cannot send to console_in")
-		},
-	}
+	winInCh := pawscript.NewChannelFromGo(nil, winInputQueue, winTermCaps)
+
+	// Ctrl+click on a hyperlink opens it
+	winTerminal.SetLinkClickCallback(openHyperlink)
 
 	// Wire keyboard input
 	winTerminal.SetInputCallback(func(data []byte) {
@@ -4236,7 +5086,7 @@ func runScriptInWindow(scriptContent, scriptFile string, scriptArgs []string,
 	win.OnDestroyed(func() {
 		// Clean up toolbar data
 		qtToolbarDataMu.Lock()
-		delete(qtToolbarDataByWindow, win)
+		delete(qtToolbarDataByWindow, win.QWidget)
 		qtToolbarDataMu.Unlock()
 		winStdinWriter.Close()
 	})
@@ -4253,6 +5103,7 @@ func runScriptInWindow(scriptContent, scriptFile string, scriptArgs []string,
 		FileAccess:           fileAccess,
 		OptLevel:             pawscript.OptimizationLevel(optLevel),
 		ScriptDir:            scriptDir,
+		Dialogs:              guiDialogs{},
 	})
 
 	ioConfig := &pawscript.IOChannelConfig{
@@ -4261,6 +5112,37 @@ func runScriptInWindow(scriptContent, scriptFile string, scriptArgs []string,
 		Stderr: winOutCh,
 	}
 	ps.RegisterStandardLibraryWithIO(scriptArgs, ioConfig)
+	pawgui.RegisterKeyMacroCommand(ps, winTerminal.KeyMap())
+	pawgui.RegisterMenuItemCommand(ps, winTerminal.ContextMenuItems())
+	pawgui.RegisterToolbarButtonCommand(ps, runScriptToolbarData.scriptButtons)
+	pawgui.RegisterMenuRegisterCommand(ps, winScriptMenus)
+	pawgui.RegisterWidgetCommands(ps, scriptWidgets)
+	pawgui.RegisterNotifyCommand(ps)
+	pawgui.RegisterTraySetCommand(ps, launcherTray)
+	pawgui.RegisterStatusSetCommand(ps, winStatus.status)
+	pawgui.RegisterProgressCommand(ps, winStatus.status)
+
+	endRunLog := startRunLog(winTerminal, scriptFile)
+
+	// Stop Script cancels the script, killing it if it won't stop
+	scriptEnded := func(message string) {
+		if winOutCh.NativeFlush != nil {
+			winOutCh.NativeFlush()
+		}
+		winTerminal.Feed(message)
+		endRunLog()
+		winScriptMenus.Clear() // The script's menu entries and status end with it
+		winStatus.status.Clear()
+		winRunMu.Lock()
+		winRun = nil
+		winRunMu.Unlock()
+	}
+	run := pawgui.NewScriptRun(ps, func() {
+		scriptEnded("\r\n[Script killed]\r\n")
+	})
+	winRunMu.Lock()
+	winRun = run
+	winRunMu.Unlock()
 
 	// Run script in goroutine
 	go func() {
@@ -4273,14 +5155,15 @@ func runScriptInWindow(scriptContent, scriptFile string, scriptArgs []string,
 			result = ps.Execute(scriptContent)
 		}
 
-		if winOutCh.NativeFlush != nil {
-			winOutCh.NativeFlush()
+		if !run.End() {
+			return // Killed, and cleaned up after
 		}
-
-		if result == pawscript.BoolStatus(false) {
-			winTerminal.Feed("\r\n[Script execution failed]\r\n")
+		if run.Stopped() {
+			scriptEnded("\r\n[Script stopped]\r\n")
+		} else if result == pawscript.BoolStatus(false) {
+			scriptEnded("\r\n[Script execution failed]\r\n")
 		} else {
-			winTerminal.Feed("\r\n[Script completed]\r\n")
+			scriptEnded("\r\n[Script completed]\r\n")
 		}
 	}()
 
@@ -4387,6 +5270,14 @@ func convertShortcutForQt(shortcut string) string {
 
 // setupShortcutsForWindow configures keyboard shortcuts (quit and close) for a window
 func setupShortcutsForWindow(win *qt.QMainWindow) {
+	setupShortcuts(win, func() {
+		win.Close()
+	})
+}
+
+// setupShortcuts sets up the quit and close shortcuts for a window, the close
+// shortcut calling closeFn
+func setupShortcuts(win *qt.QMainWindow, closeFn func()) {
 	// Setup quit shortcut
 	if quitShortcut := getQuitShortcut(); quitShortcut != "" {
 		keySequence := convertShortcutForQt(quitShortcut)
@@ -4400,9 +5291,7 @@ func setupShortcutsForWindow(win *qt.QMainWindow) {
 	if closeShortcut := getCloseShortcut(); closeShortcut != "" {
 		keySequence := convertShortcutForQt(closeShortcut)
 		shortcut := qt.NewQShortcut2(qt.NewQKeySequence2(keySequence), win.QWidget)
-		shortcut.OnActivated(func() {
-			win.Close()
-		})
+		shortcut.OnActivated(closeFn)
 	}
 }
 
@@ -4411,6 +5300,75 @@ func setupQuitShortcutForWindow(win *qt.QMainWindow) {
 	setupShortcutsForWindow(win)
 }
 
+// setupKeyMacros gives a terminal a key map holding the key macros and console
+// shortcuts from the config
+func setupKeyMacros(term *purfectermqt.Terminal) {
+	keyMap, errs := configHelper.NewKeyMap()
+	errs = append(errs, configHelper.BindShortcuts(keyMap, consoleShortcuts(term))...)
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", getConfigPath(), err)
+	}
+	term.SetKeyMap(keyMap)
+}
+
+// setupTranslucency lets the desktop show through a window's console when the
+// console background is see-through (see purfectermqt.UseTranslucentWindow); call
+// it once the window has its central widget, before it is shown. Windows opened
+// while the background is opaque stay opaque.
+func setupTranslucency(win *qt.QMainWindow) {
+	if configHelper.GetBackgroundOpacity() < 1 {
+		purfectermqt.UseTranslucentWindow(win.QWidget, win.CentralWidget())
+	}
+}
+
+// setupWindowZoom gives a terminal the font zoom last set in windows of its type,
+// and saves the zoom the user sets in it for the next one
+func setupWindowZoom(term *purfectermqt.Terminal, windowType string) {
+	term.SetZoom(configHelper.GetWindowZoom(windowType))
+	term.SetZoomCallback(func(zoom float64) {
+		if configHelper.SetWindowZoom(windowType, zoom) {
+			saveConfig(appConfig)
+		}
+	})
+}
+
+// setupRunSelection adds "Run Selection as PawScript" to the launcher terminal's
+// context menu, which gives the selected text to the console's REPL as a paste
+// followed by Enter, while the REPL is waiting for input
+func setupRunSelection(term *purfectermqt.Terminal) {
+	replWaiting := func() bool {
+		scriptMu.Lock()
+		running := scriptRunning
+		scriptMu.Unlock()
+		return !running && consoleREPL != nil && consoleREPL.IsRunning() && !consoleREPL.IsBusy()
+	}
+	term.ContextMenuItems().Add(purfecterm.MenuItem{
+		Label: "Run Selection as PawScript",
+		Enabled: func() bool {
+			return term.GetSelectedText() != "" && replWaiting()
+		},
+		Activate: func() {
+			if text := term.GetSelectedText(); text != "" && replWaiting() {
+				// Bracketed, so a selection of several lines is one entry
+				consoleREPL.HandleInput([]byte("\x1b[200~" + text + "\x1b[201~\r"))
+			}
+		},
+	})
+}
+
+// setupBellUrgency alerts a window when its terminal rings the bell while the
+// window is in the background, so the taskbar flashes it (Qt stops the alert when
+// the window is activated)
+func setupBellUrgency(win *qt.QMainWindow, term *purfectermqt.Terminal) {
+	term.SetBellCallback(func() {
+		mainthread.Start(func() {
+			if !win.IsActiveWindow() {
+				qt.QApplication_Alert(win.QWidget)
+			}
+		})
+	})
+}
+
 // setupQuitShortcut configures keyboard shortcuts for the main window
 func setupQuitShortcut() {
 	setupShortcutsForWindow(mainWindow)
@@ -4451,6 +5409,9 @@ func createFilePanel() *qt.QWidget {
 
 	layout.AddWidget(topRow)
 
+	// Filter box (see filefilter.go)
+	layout.AddWidget(createFileFilter().QWidget)
+
 	// File list
 	fileList = qt.NewQListWidget2()
 	fileList.SetIconSize(qt.NewQSize2(scaledFileListIconSize(), scaledFileListIconSize()))
@@ -4460,16 +5421,17 @@ func createFilePanel() *qt.QWidget {
 	fileList.OnCurrentItemChanged(func(current *qt.QListWidgetItem, previous *qt.QListWidgetItem) {
 		onSelectionChanged(current, previous)
 	})
+	setupFileListMenu()
 	layout.AddWidget2(fileList.QWidget, 1)
 
 	// Run and Browse buttons
 	buttonLayout := qt.NewQHBoxLayout2()
 
-	runButton = qt.NewQPushButton3("Run")
+	runButton = qt.NewQPushButton3(tr("Run"))
 	runButton.OnClicked(func() { runSelectedFile() })
 	buttonLayout.AddWidget(runButton.QWidget)
 
-	browseButton = qt.NewQPushButton3("Browse...")
+	browseButton = qt.NewQPushButton3(tr("Browse..."))
 	browseButton.OnClicked(func() { browseFolder() })
 	buttonLayout.AddWidget(browseButton.QWidget)
 
@@ -4490,7 +5452,7 @@ func createTerminalPanel() *qt.QWidget {
 	terminal, err = purfectermqt.New(purfectermqt.Options{
 		Cols:           100,
 		Rows:           30,
-		ScrollbackSize: 10000,
+		ScrollbackSize: getScrollbackLines(),
 		FontFamily:     getFontFamily(),
 		FontSize:       getFontSize(),
 		Scheme:         getDualColorScheme(),
@@ -4503,6 +5465,11 @@ func createTerminalPanel() *qt.QWidget {
 	// Set font fallbacks for Unicode/CJK characters
 	terminal.SetFontFallbacks(getFontFamilyUnicode(), getFontFamilyCJK())
 
+	// Key macros from the config (scripts can add more with key_macro)
+	setupKeyMacros(terminal)
+	setupWindowZoom(terminal, "launcher")
+	setupRunSelection(terminal)
+
 	// Set up terminal theme from config
 	prefersDark := isTermThemeDark()
 	terminal.Buffer().SetPreferredDarkTheme(prefersDark)
@@ -4532,59 +5499,27 @@ func setupConsoleIO() {
 	go func() {
 		for v := range outputQueue {
 			switch d := v.(type) {
-			case []byte:
-				terminal.Feed(string(d))
-			case string:
-				terminal.Feed(d)
 			case chan struct{}:
 				// Sentinel for flush synchronization
 				close(d)
+			default:
+				terminal.Feed(string(pawscript.ConsoleBytes(d)))
 			}
 		}
 	}()
 
 	// Create console output channel
-	consoleOutCh = &pawscript.StoredChannel{
-		BufferSize:       0,
-		Messages:         make([]pawscript.ChannelMessage, 0),
-		Subscribers:      make(map[int]*pawscript.StoredChannel),
-		NextSubscriberID: 1,
-		IsClosed:         false,
-		Timestamp:        time.Now(),
-		Terminal:         termCaps,
-		NativeSend: func(v interface{}) error {
-			var text string
-			switch d := v.(type) {
-			case []byte:
-				text = string(d)
-			case string:
-				text = d
-			default:
-				text = fmt.Sprintf("%v", v)
-			}
-			// Normalize newlines for terminal
-			text = strings.ReplaceAll(text, "\r\n", "\n")
-			text = strings.ReplaceAll(text, "\n", "\r\n")
-			select {
-			case outputQueue <- []byte(text):
-			default:
-				// Queue full - drop to prevent deadlock
-			}
-			return nil
-		},
-		NativeRecv: func() (interface{}, error) {
-			return nil, fmt.Errorf("cannot receive from console_out")
-		},
-		NativeFlush: func() error {
-			// Wait for outputQueue to drain
-			writerDone := make(chan struct{})
-			select {
-			case outputQueue <- writerDone:
-				<-writerDone
-			default:
-			}
-			return nil
-		},
+	consoleOutCh = pawscript.NewChannelFromGo(outputQueue, nil, termCaps)
+	consoleOutCh.Overflow = pawscript.OverflowDropNewest // a stalled GUI drops output rather than blocking the script
+	consoleOutCh.NativeFlush = func() error {
+		// Wait for outputQueue to drain
+		writerDone := make(chan struct{})
+		select {
+		case outputQueue <- writerDone:
+			<-writerDone
+		default:
+		}
+		return nil
 	}
 
 	// Set up the global flushFunc
@@ -4595,7 +5530,7 @@ func setupConsoleIO() {
 	}
 
 	// Non-blocking input queue
-	inputQueue := make(chan byte, 256)
+	inputQueue := make(chan interface{}, 256)
 
 	// Reader goroutine: drains pipe and puts bytes into queue
 	go func() {
@@ -4607,7 +5542,7 @@ func setupConsoleIO() {
 				return
 			}
 			select {
-			case inputQueue <- buf[0]:
+			case inputQueue <- []byte{buf[0]}:
 			default:
 				// Drop oldest if full
 				select {
@@ -4615,7 +5550,7 @@ func setupConsoleIO() {
 				default:
 				}
 				select {
-				case inputQueue <- buf[0]:
+				case inputQueue <- []byte{buf[0]}:
 				default:
 				}
 			}
@@ -4623,25 +5558,7 @@ func setupConsoleIO() {
 	}()
 
 	// Create console input channel
-	consoleInCh = &pawscript.StoredChannel{
-		BufferSize:       0,
-		Messages:         make([]pawscript.ChannelMessage, 0),
-		Subscribers:      make(map[int]*pawscript.StoredChannel),
-		NextSubscriberID: 1,
-		IsClosed:         false,
-		Timestamp:        time.Now(),
-		Terminal:         termCaps,
-		NativeRecv: func() (interface{}, error) {
-			b, ok := <-inputQueue
-			if !ok {
-				return nil, fmt.Errorf("input closed")
-			}
-			return []byte{b}, nil
-		},
-		NativeSend: func(v interface{}) error {
-			return fmt.Errorf("cannot send to console_in")
-		},
-	}
+	consoleInCh = pawscript.NewChannelFromGo(nil, inputQueue, termCaps)
 
 	clearInputFunc = func() {
 		for {
@@ -4653,6 +5570,12 @@ func setupConsoleIO() {
 		}
 	}
 
+	// Ctrl+click on a hyperlink opens it
+	terminal.SetLinkClickCallback(openHyperlink)
+
+	// Dropped scripts run and dropped folders open (see launcherdrop.go)
+	terminal.SetFileDropCallback(handleLauncherDrop)
+
 	// Wire keyboard input from terminal to stdin pipe or REPL
 	terminal.SetInputCallback(func(data []byte) {
 		scriptMu.Lock()
@@ -4692,6 +5615,7 @@ func startREPL() {
 			Stdin:  consoleInCh,
 			Stderr: consoleOutCh,
 		},
+		Dialogs: guiDialogs{},
 	}, func(s string) {
 		// Output to terminal
 		terminal.Feed(s)
@@ -4707,7 +5631,7 @@ func startREPL() {
 	consoleREPL.SetPSLColors(getPSLColors())
 	consoleREPL.Start()
 
-	// Register the dummy_button command with the REPL's PawScript instance
+	// Register the toolbar_button command with the REPL's PawScript instance
 	// Create launcher toolbar data that uses the global launcher strip
 	launcherToolbarData = &QtWindowToolbarData{
 		strip:    launcherNarrowStrip,
@@ -4718,7 +5642,13 @@ func startREPL() {
 			updateLauncherToolbarButtons()
 		},
 	}
-	registerDummyButtonCommand(consoleREPL.GetPawScript(), launcherToolbarData)
+	registerToolbarButtonCommand(consoleREPL.GetPawScript(), launcherToolbarData)
+	pawgui.RegisterKeyMacroCommand(consoleREPL.GetPawScript(), launcherToolbarData.terminal.KeyMap())
+	pawgui.RegisterMenuItemCommand(consoleREPL.GetPawScript(), launcherToolbarData.terminal.ContextMenuItems())
+	pawgui.RegisterMenuRegisterCommand(consoleREPL.GetPawScript(), launcherScriptMenus)
+	pawgui.RegisterWidgetCommands(consoleREPL.GetPawScript(), scriptWidgets)
+	pawgui.RegisterNotifyCommand(consoleREPL.GetPawScript())
+	pawgui.RegisterTraySetCommand(consoleREPL.GetPawScript(), launcherTray)
 }
 
 // iconType represents the type of icon for a file list item
@@ -4732,9 +5662,10 @@ const (
 
 // fileItemData stores path and isDir for list items
 type fileItemData struct {
-	path     string
-	isDir    bool
-	iconType iconType
+	path        string
+	isDir       bool
+	iconType    iconType
+	description string // A script's description, for the filter
 }
 
 var fileItemDataMap = make(map[unsafe.Pointer]fileItemData)
@@ -4776,7 +5707,7 @@ func updatePathMenu() {
 
 	// Add Home directory
 	if home := getHomeDir(); home != "" {
-		homeAction := pathMenu.AddAction("Home")
+		homeAction := pathMenu.AddAction(tr("Home"))
 		if icon := createIconFromSVG(homeIconSVG, scaledMenuIconSize()); icon != nil {
 			homeAction.SetIcon(icon)
 		}
@@ -4789,7 +5720,7 @@ func updatePathMenu() {
 
 	// Add Examples directory
 	if examples := getExamplesDir(); examples != "" {
-		examplesAction := pathMenu.AddAction("Examples")
+		examplesAction := pathMenu.AddAction(tr("Examples"))
 		if icon := createIconFromSVG(folderIconSVG, scaledMenuIconSize()); icon != nil {
 			examplesAction.SetIcon(icon)
 		}
@@ -4818,7 +5749,7 @@ func updatePathMenu() {
 	// Add Clear Recent Paths option
 	if len(recentPaths) > 0 {
 		pathMenu.AddSeparator()
-		clearAction := pathMenu.AddAction("Clear Recent Paths")
+		clearAction := pathMenu.AddAction(tr("Clear Recent Paths"))
 		if icon := createIconFromSVG(trashIconSVG, scaledMenuIconSize()); icon != nil {
 			clearAction.SetIcon(icon)
 		}
@@ -4827,6 +5758,12 @@ func updatePathMenu() {
 			updatePathMenu()
 		})
 	}
+
+	// Add recent scripts (see recentscripts.go)
+	if len(configHelper.GetRecentScripts()) > 0 {
+		pathMenu.AddSeparator()
+		addRecentScripts(pathMenu)
+	}
 }
 
 func loadDirectory(dir string) {
@@ -4842,7 +5779,7 @@ func loadDirectory(dir string) {
 
 	entries, err := os.ReadDir(dir)
 	if err != nil {
-		terminal.Feed(fmt.Sprintf("Error reading directory: %v\r\n", err))
+		terminal.Feed(fmt.Sprintf(tr("Error reading directory: %v\r\n"), err))
 		return
 	}
 
@@ -4854,6 +5791,26 @@ func loadDirectory(dir string) {
 	// Reset previous selected item when directory changes
 	previousSelectedItem = nil
 
+	// The project's pinned scripts head the list, wherever it is
+	if currentProject != nil {
+		for _, path := range currentProject.PinnedScripts() {
+			rel, _ := currentProject.Rel(path)
+			item := qt.NewQListWidgetItem7(filepath.ToSlash(rel), fileList)
+			if fileIcon != nil {
+				item.SetIcon(fileIcon)
+			}
+			description := addFileDetails(item, path)
+			fileItemDataMu.Lock()
+			fileItemDataMap[item.UnsafePointer()] = fileItemData{
+				path:        path,
+				isDir:       false,
+				iconType:    iconTypePawFile,
+				description: description,
+			}
+			fileItemDataMu.Unlock()
+		}
+	}
+
 	// Add parent directory entry (except at root)
 	if dir != "/" && filepath.Dir(dir) != dir {
 		item := qt.NewQListWidgetItem7("..", fileList)
@@ -4894,16 +5851,20 @@ func loadDirectory(dir string) {
 			if fileIcon != nil {
 				item.SetIcon(fileIcon)
 			}
+			path := filepath.Join(dir, entry.Name())
+			description := addFileDetails(item, path)
 			// Store data using pointer map
 			fileItemDataMu.Lock()
 			fileItemDataMap[item.UnsafePointer()] = fileItemData{
-				path:     filepath.Join(dir, entry.Name()),
-				isDir:    false,
-				iconType: iconTypePawFile,
+				path:        path,
+				isDir:       false,
+				iconType:    iconTypePawFile,
+				description: description,
 			}
 			fileItemDataMu.Unlock()
 		}
 	}
+	applyFileFilter()
 
 	saveBrowseDir(dir)
 }
@@ -4962,7 +5923,7 @@ func onSelectionChanged(current *qt.QListWidgetItem, previous *qt.QListWidgetIte
 	fileItemDataMu.Unlock()
 
 	if !ok {
-		runButton.SetText("Run")
+		runButton.SetText(tr("Run"))
 		return
 	}
 
@@ -4981,9 +5942,9 @@ func onSelectionChanged(current *qt.QListWidgetItem, previous *qt.QListWidgetIte
 	}
 
 	if data.isDir {
-		runButton.SetText("Open")
+		runButton.SetText(tr("Open"))
 	} else {
-		runButton.SetText("Run")
+		runButton.SetText(tr("Run"))
 	}
 }
 
@@ -5006,7 +5967,7 @@ func browseFolder() {
 func runSelectedFile() {
 	items := fileList.SelectedItems()
 	if len(items) == 0 {
-		terminal.Feed("No file selected.\r\n")
+		terminal.Feed(tr("No file selected.") + "\r\n")
 		return
 	}
 
@@ -5027,14 +5988,22 @@ func runSelectedFile() {
 }
 
 func runScript(filePath string) {
+	// Its launch profile (see launchprofile.go) may give it a window of its own
+	profile, profileErr := configHelper.GetLaunchProfile(filePath)
+	if profile.OwnWindow() {
+		openScriptTab(filePath)
+		return
+	}
+
 	scriptMu.Lock()
 	if scriptRunning {
 		scriptMu.Unlock()
-		// Script already running in main window - spawn a new console window
-		createConsoleWindow(filePath)
+		// Script already running in main window - run it in a console tab
+		openScriptTab(filePath)
 		return
 	}
 	scriptRunning = true
+	launcherScript, _ = filepath.Abs(filePath)
 	scriptMu.Unlock()
 
 	// Stop the REPL while script runs
@@ -5042,7 +6011,11 @@ func runScript(filePath string) {
 		consoleREPL.Stop()
 	}
 
+	endRunLog := startRunLog(terminal, filePath)
 	terminal.Feed(fmt.Sprintf("\r\n--- Running: %s ---\r\n\r\n", filepath.Base(filePath)))
+	if profileErr != nil {
+		terminal.Feed(fmt.Sprintf("%v\r\n", profileErr))
+	}
 
 	// Clear any buffered input from previous script runs
 	if clearInputFunc != nil {
@@ -5052,7 +6025,8 @@ func runScript(filePath string) {
 	// Read script content
 	content, err := os.ReadFile(filePath)
 	if err != nil {
-		terminal.Feed(fmt.Sprintf("Error reading script file: %v\r\n", err))
+		terminal.Feed(fmt.Sprintf(tr("Error reading script file: %v\r\n"), err))
+		endRunLog()
 		scriptMu.Lock()
 		scriptRunning = false
 		scriptMu.Unlock()
@@ -5068,6 +6042,9 @@ func runScript(filePath string) {
 	// Add the script's directory to recent paths for the combo box
 	addRecentPath(scriptDir)
 
+	// Remember the script for the Recent Scripts menus
+	recordScriptRun(absScript)
+
 	// Create file access config
 	cwd, _ := os.Getwd()
 	tmpDir := os.TempDir()
@@ -5076,6 +6053,9 @@ func runScript(filePath string) {
 		WriteRoots: []string{filepath.Join(scriptDir, "saves"), filepath.Join(scriptDir, "output"), filepath.Join(cwd, "saves"), filepath.Join(cwd, "output"), tmpDir},
 		ExecRoots:  []string{filepath.Join(scriptDir, "helpers"), filepath.Join(scriptDir, "bin")},
 	}
+	configHelper.ApplyGrantedRoots(fileAccess)
+	profile.ApplyRoots(fileAccess, scriptDir)
+	applyProjectRoots(fileAccess, absScript)
 
 	// Create a new PawScript instance for this script
 	ps := pawscript.New(&pawscript.Config{
@@ -5085,105 +6065,222 @@ func runScript(filePath string) {
 		ShowErrorContext:     true,
 		ContextLines:         2,
 		FileAccess:           fileAccess,
+		Permissions:          getLauncherPermissions(absScript),
+		Quotas:               getLauncherQuotas(),
+		EnvAllowlist:         pawscript.DefaultEnvAllowlist,
+		PermissionPrompt:     promptPermission,
 		ScriptDir:            scriptDir,
-		OptLevel:             pawscript.OptimizationLevel(getOptimizationLevel()),
+		OptLevel:             pawscript.OptimizationLevel(profile.OptimizationLevel(getOptimizationLevel())),
+		Debugger:             launcherDebugger,
+		Dialogs:              guiDialogs{},
 	})
 
-	// Register standard library with the console IO
-	ioConfig := &pawscript.IOChannelConfig{
+	// Stop Script cancels it, killing it if it won't stop
+	run := pawgui.NewScriptRun(ps, func() {
+		if flushFunc != nil {
+			flushFunc()
+		}
+		terminal.Feed("\r\n--- Script killed ---\r\n")
+		endRunLog()
+		recordScriptEnd(absScript, pawgui.RunStopped)
+		launcherScriptMenus.Clear()
+		launcherScriptEnded()
+	})
+	scriptMu.Lock()
+	launcherRun = run
+	scriptMu.Unlock()
+
+	// Register standard library with the console IO, which a killed script gives up
+	ioConfig := run.IO(&pawscript.IOChannelConfig{
 		Stdout: consoleOutCh,
 		Stdin:  consoleInCh,
 		Stderr: consoleOutCh,
+	})
+	ps.RegisterStandardLibraryWithIO(profile.Args, ioConfig)
+	pawgui.RegisterKeyMacroCommand(ps, terminal.KeyMap())
+	pawgui.RegisterMenuItemCommand(ps, terminal.ContextMenuItems())
+	pawgui.RegisterMenuRegisterCommand(ps, launcherScriptMenus)
+	pawgui.RegisterWidgetCommands(ps, scriptWidgets)
+	pawgui.RegisterNotifyCommand(ps)
+	pawgui.RegisterTraySetCommand(ps, launcherTray)
+	if launcherToolbarData != nil {
+		pawgui.RegisterToolbarButtonCommand(ps, launcherToolbarData.scriptButtons)
 	}
-	ps.RegisterStandardLibraryWithIO([]string{}, ioConfig)
 
 	// Run script in goroutine so UI stays responsive
 	go func() {
-		// Create an isolated snapshot for execution
-		snapshot := ps.CreateRestrictedSnapshot()
+		ok := false
+		for {
+			// Create an isolated snapshot for execution
+			snapshot := ps.CreateRestrictedSnapshot()
 
-		// Run the script in the isolated environment
-		result := ps.ExecuteWithEnvironment(string(content), snapshot, filePath, 0, 0)
+			// Run the script in the isolated environment
+			result := ps.ExecuteWithEnvironment(string(content), snapshot, filePath, 0, 0)
+			ok = result != pawscript.BoolStatus(false)
 
-		// Flush any pending output before printing completion message
-		if flushFunc != nil {
-			flushFunc()
-		}
+			// Flush any pending output before printing completion message
+			if flushFunc != nil {
+				flushFunc()
+			}
 
-		if result == pawscript.BoolStatus(false) {
-			terminal.Feed("\r\n--- Script execution failed ---\r\n")
-		} else {
-			terminal.Feed("\r\n--- Script completed ---\r\n")
+			if run.Stopped() {
+				terminal.Feed("\r\n--- Script stopped ---\r\n")
+			} else if !ok {
+				terminal.Feed("\r\n--- Script execution failed ---\r\n")
+			} else {
+				terminal.Feed("\r\n--- Script completed ---\r\n")
+			}
+			launcherScriptMenus.Clear() // The script's menu entries end with it
+
+			// Run it again if its launch profile says to
+			if content = restartScript(filePath, nil, ok, terminal.Feed, run.Stopped); content == nil {
+				break
+			}
 		}
+		if run.End() {
+			endRunLog()
+			recordScriptEnd(absScript, pawgui.RunStatus(ok, run.Stopped()))
+			launcherScriptEnded()
+		}
+	}()
+}
 
-		scriptMu.Lock()
-		scriptRunning = false
-		scriptMu.Unlock()
+// stopScript stops a script for Stop Script, nil being none running
+func stopScript(run *pawgui.ScriptRun) {
+	if run != nil {
+		run.Stop(configHelper.GetStopTimeout())
+	}
+}
 
-		// Restart the REPL
-		if consoleREPL != nil {
-			// Create a new REPL instance (fresh state)
-			consoleREPL = pawscript.NewREPL(pawscript.REPLConfig{
-				Debug:        false,
-				Unrestricted: false,
-				OptLevel:     getOptimizationLevel(),
-				ShowBanner:   false, // Don't show banner again
-				IOConfig: &pawscript.IOChannelConfig{
-					Stdout: consoleOutCh,
-					Stdin:  consoleInCh,
-					Stderr: consoleOutCh,
-				},
-			}, func(s string) {
-				terminal.Feed(s)
-			})
-			// Set flush callback to ensure output appears before blocking execution
-			consoleREPL.SetFlush(func() {
-				// Force immediate repaint to display output before blocking operations
-				terminal.Flush()
-			})
-			// Set background color for prompt color selection
-			bg := getTerminalBackground()
-			consoleREPL.SetBackgroundRGB(bg.R, bg.G, bg.B)
-			consoleREPL.SetPSLColors(getPSLColors())
-			consoleREPL.Start()
+// stopLauncherScript stops the script running in the launcher, if any
+func stopLauncherScript() {
+	scriptMu.Lock()
+	run := launcherRun
+	scriptMu.Unlock()
+	stopScript(run)
+}
 
-			// Re-register the dummy_button command with the new REPL instance
-			// Reuse the existing launcherToolbarData with the new terminal reference
-			launcherToolbarData.terminal = terminal
-			registerDummyButtonCommand(consoleREPL.GetPawScript(), launcherToolbarData)
+// launcherScriptEnded puts the launcher back as it was before its script ran,
+// with a fresh REPL, once the script ends or is killed
+func launcherScriptEnded() {
+	mainthread.Start(func() {
+		if launcherDebugPanel != nil {
+			launcherDebugPanel.scriptFinished()
 		}
-	}()
+	})
+
+	scriptMu.Lock()
+	scriptRunning = false
+	launcherRun = nil
+	scriptMu.Unlock()
+
+	// Restart the REPL
+	if consoleREPL != nil {
+		// Create a new REPL instance (fresh state)
+		consoleREPL = pawscript.NewREPL(pawscript.REPLConfig{
+			Debug:        false,
+			Unrestricted: false,
+			OptLevel:     getOptimizationLevel(),
+			ShowBanner:   false, // Don't show banner again
+			IOConfig: &pawscript.IOChannelConfig{
+				Stdout: consoleOutCh,
+				Stdin:  consoleInCh,
+				Stderr: consoleOutCh,
+			},
+			Dialogs: guiDialogs{},
+		}, func(s string) {
+			terminal.Feed(s)
+		})
+		// Set flush callback to ensure output appears before blocking execution
+		consoleREPL.SetFlush(func() {
+			// Force immediate repaint to display output before blocking operations
+			terminal.Flush()
+		})
+		// Set background color for prompt color selection
+		bg := getTerminalBackground()
+		consoleREPL.SetBackgroundRGB(bg.R, bg.G, bg.B)
+		consoleREPL.SetPSLColors(getPSLColors())
+		consoleREPL.Start()
+
+		// Re-register the toolbar_button command with the new REPL instance
+		// Reuse the existing launcherToolbarData with the new terminal reference
+		launcherToolbarData.terminal = terminal
+		registerToolbarButtonCommand(consoleREPL.GetPawScript(), launcherToolbarData)
+		pawgui.RegisterKeyMacroCommand(consoleREPL.GetPawScript(), launcherToolbarData.terminal.KeyMap())
+		pawgui.RegisterMenuItemCommand(consoleREPL.GetPawScript(), launcherToolbarData.terminal.ContextMenuItems())
+		pawgui.RegisterMenuRegisterCommand(consoleREPL.GetPawScript(), launcherScriptMenus)
+		pawgui.RegisterWidgetCommands(consoleREPL.GetPawScript(), scriptWidgets)
+		pawgui.RegisterNotifyCommand(consoleREPL.GetPawScript())
+		pawgui.RegisterTraySetCommand(consoleREPL.GetPawScript(), launcherTray)
+	}
 }
 
-// createConsoleWindow creates a new window with just a terminal (no launcher UI)
-// for running a script when the main window already has a script running
-func createConsoleWindow(filePath string) {
-	// Create new window
-	win := qt.NewQMainWindow2()
-	win.SetWindowTitle(fmt.Sprintf("PawScript - %s", filepath.Base(filePath)))
-	win.SetMinimumSize2(900, 600)
+// openScriptTab opens a tab with just a terminal (no launcher UI) for running a
+// script when the main window already has a script running
+func openScriptTab(filePath string) {
+	openScriptTabWith(filePath, scriptTabOptions{})
+}
 
-	// Set up quit shortcut for this window
-	setupQuitShortcutForWindow(win)
+// scriptTabOptions changes how openScriptTabWith runs a script, for scheduled
+// tasks and run configurations
+type scriptTabOptions struct {
+	window      *tabWindow                   // The tab window to open in, if not the current one
+	permissions *pawscript.PermissionProfile // In place of the launcher's profile, if set
+	unattended  bool                         // No one to ask for permissions, nor to restore it
+	onFinish    func(ok bool)                // Called when the script ends or fails to start
+	profile     *pawgui.LaunchProfile        // In place of the script's launch profile, for a run configuration
+}
+
+// finish calls onFinish, if set
+func (o scriptTabOptions) finish(ok bool) {
+	if o.onFinish != nil {
+		o.onFinish(ok)
+	}
+}
+
+// openScriptTabWith opens a tab running a script, as opts says, returning a
+// function that closes the tab (nil if it didn't open)
+func openScriptTabWith(filePath string, opts scriptTabOptions) func() {
+	// Its launch profile (see launchprofile.go) may give it a window of its own
+	profile, profileErr := configHelper.GetLaunchProfile(filePath)
+	if opts.profile != nil {
+		profile, profileErr = *opts.profile, nil
+	}
+	tabs := opts.window
+	if tabs == nil {
+		tabs = getTabWindow(profile.OwnWindow())
+		if profile.OwnWindow() {
+			tabs.win.Resize(profile.Width, profile.Height)
+		}
+	}
+	win := tabs.win
 
-	// Create terminal for this window with color scheme from config
+	// Create terminal for this tab with color scheme from config
 	winTerminal, err := purfectermqt.New(purfectermqt.Options{
 		Cols:           100,
 		Rows:           30,
-		ScrollbackSize: 10000,
+		ScrollbackSize: getScrollbackLines(),
 		FontFamily:     getFontFamily(),
 		FontSize:       getFontSize(),
 		Scheme:         getDualColorScheme(),
 	})
 	if err != nil {
 		terminal.Feed(fmt.Sprintf("\r\nFailed to create console window: %v\r\n", err))
-		win.Close()
-		return
+		tabs.closeIfEmpty()
+		opts.finish(false)
+		return nil
 	}
 
 	// Set font fallbacks for Unicode/CJK characters
 	winTerminal.SetFontFallbacks(getFontFamilyUnicode(), getFontFamilyCJK())
 
+	// Key macros from the config (scripts can add more with key_macro)
+	setupKeyMacros(winTerminal)
+	setupWindowZoom(winTerminal, "console")
+
+	// Flash the window in the taskbar when a background script rings the bell
+	setupBellUrgency(win, winTerminal)
+
 	// Set up terminal theme from config
 	prefersDark := isTermThemeDark()
 	winTerminal.Buffer().SetPreferredDarkTheme(prefersDark)
@@ -5194,21 +6291,37 @@ func createConsoleWindow(filePath string) {
 		winTerminal.SetColorScheme(getColorSchemeForTheme(isDark))
 	})
 
-	// Track script running state for this window
+	// Track script running state for this tab
 	var winScriptRunning bool
+	var winTabClosed bool        // Stops the script's restarts
+	var winRun *pawgui.ScriptRun // The running script, for Stop Script
 	var winScriptMu sync.Mutex
+	var closeTab func()
+	isScriptRunning := func() bool {
+		winScriptMu.Lock()
+		defer winScriptMu.Unlock()
+		return winScriptRunning
+	}
+	tabClosed := func() bool {
+		winScriptMu.Lock()
+		defer winScriptMu.Unlock()
+		return winTabClosed
+	}
 
 	// Create splitter for toolbar strip + terminal
 	winSplitter := qt.NewQSplitter3(qt.Horizontal)
 
 	// Create toolbar strip for this window (script windows only have narrow strip, no wide panel)
-	winNarrowStrip, winStripMenuBtn, _ := createToolbarStripForWindow(win.QWidget, true, winTerminal, func() bool {
+	winNarrowStrip, winStripMenuBtn, winMenu := createToolbarStripForWindow(win.QWidget, true, winTerminal, isScriptRunning, func() {
 		winScriptMu.Lock()
-		defer winScriptMu.Unlock()
-		return winScriptRunning
+		run := winRun
+		winScriptMu.Unlock()
+		stopScript(run)
 	}, func() {
-		win.Close()
+		closeTab()
 	})
+	winScriptMenus := pawgui.NewScriptMenus()
+	addScriptMenus(winMenu, winScriptMenus)
 	narrowWidth := scaledMinNarrowStripWidth()
 	winNarrowStrip.SetFixedWidth(narrowWidth)
 	// Always show the strip (has hamburger menu)
@@ -5216,7 +6329,9 @@ func createConsoleWindow(filePath string) {
 	winStripMenuBtn.Show()
 
 	winSplitter.AddWidget(winNarrowStrip)
-	winSplitter.AddWidget(winTerminal.Widget())
+	// Status bar under the terminal, set by scripts with status_set
+	winStatus := newStatusBar()
+	winSplitter.AddWidget(withStatusBar(winTerminal.Widget(), winStatus))
 
 	// Set stretch factors so strip is fixed and terminal is flexible
 	winSplitter.SetStretchFactor(0, 0)
@@ -5243,84 +6358,40 @@ func createConsoleWindow(filePath string) {
 		}
 	})
 
-	win.SetCentralWidget(winSplitter.QWidget)
 
 	// Create I/O channels for this window's console
 	winStdinReader, winStdinWriter := io.Pipe()
 
-	// Terminal capabilities for this window
-	winWidth, winHeight := 100, 30
-	winTermCaps := &pawscript.TerminalCapabilities{
-		TermType:      "gui-console",
-		IsTerminal:    true,
-		SupportsANSI:  true,
-		SupportsColor: true,
-		ColorDepth:    256,
-		Width:         winWidth,
-		Height:        winHeight,
-		SupportsInput: true,
-		EchoEnabled:   false,
-		LineMode:      false,
-		Metadata:      make(map[string]interface{}),
-	}
+	// Terminal capabilities for this window (auto-updated on resize and theme change)
+	winTermCaps := winTerminal.GetTerminalCapabilities()
 
 	// Non-blocking output queue
 	winOutputQueue := make(chan interface{}, 256)
 	go func() {
 		for item := range winOutputQueue {
 			switch v := item.(type) {
-			case []byte:
-				winTerminal.Feed(string(v))
-			case string:
-				winTerminal.Feed(v)
 			case chan struct{}:
 				close(v)
+			default:
+				winTerminal.Feed(string(pawscript.ConsoleBytes(v)))
 			}
 		}
 	}()
 
-	winOutCh := &pawscript.StoredChannel{
-		BufferSize:       0,
-		Messages:         make([]pawscript.ChannelMessage, 0),
-		Subscribers:      make(map[int]*pawscript.StoredChannel),
-		NextSubscriberID: 1,
-		IsClosed:         false,
-		Timestamp:        time.Now(),
-		Terminal:         winTermCaps,
-		NativeSend: func(v interface{}) error {
-			var text string
-			switch d := v.(type) {
-			case []byte:
-				text = string(d)
-			case string:
-				text = d
-			default:
-				text = fmt.Sprintf("%v", v)
-			}
-			text = strings.ReplaceAll(text, "\r\n", "\n")
-			text = strings.ReplaceAll(text, "\n", "\r\n")
-			select {
-			case winOutputQueue <- []byte(text):
-			default:
-			}
-			return nil
-		},
-		NativeRecv: func() (interface{}, error) {
-			return nil, fmt.Errorf("cannot receive from console_out")
-		},
-		NativeFlush: func() error {
-			writerDone := make(chan struct{})
-			select {
-			case winOutputQueue <- writerDone:
-				<-writerDone
-			default:
-			}
-			return nil
-		},
+	winOutCh := pawscript.NewChannelFromGo(winOutputQueue, nil, winTermCaps)
+	winOutCh.Overflow = pawscript.OverflowDropNewest // a stalled GUI drops output rather than blocking the script
+	winOutCh.NativeFlush = func() error {
+		writerDone := make(chan struct{})
+		select {
+		case winOutputQueue <- writerDone:
+			<-writerDone
+		default:
+		}
+		return nil
 	}
 
 	// Non-blocking input queue
-	winInputQueue := make(chan byte, 256)
+	winInputQueue := make(chan interface{}, 256)
 	go func() {
 		buf := make([]byte, 1)
 		for {
@@ -5330,42 +6401,27 @@ func createConsoleWindow(filePath string) {
 				return
 			}
 			select {
-			case winInputQueue <- buf[0]:
+			case winInputQueue <- []byte{buf[0]}:
 			default:
 				select {
 				case <-winInputQueue:
 				default:
 				}
 				select {
-				case winInputQueue <- buf[0]:
+				case winInputQueue <- []byte{buf[0]}:
 				default:
 				}
 			}
 		}
 	}()
 
-	winInCh := &pawscript.StoredChannel{
-		BufferSize:       0,
-		Messages:         make([]pawscript.ChannelMessage, 0),
-		Subscribers:      make(map[int]*pawscript.StoredChannel),
-		NextSubscriberID: 1,
-		IsClosed:         false,
-		Timestamp:        time.Now(),
-		Terminal:         winTermCaps,
-		NativeRecv: func() (interface{}, error) {
-			b, ok := <-winInputQueue
-			if !ok {
-				return nil, fmt.Errorf("input closed")
-			}
-			return []byte{b}, nil
-		},
-		NativeSend: func(v interface{}) error {
-			return fmt.Errorf("cannot send to console_in")
-		},
-	}
+	winInCh := pawscript.NewChannelFromGo(nil, winInputQueue, winTermCaps)
 
 	var winREPL *pawscript.REPL
 
+	// Ctrl+click on a hyperlink opens it
+	winTerminal.SetLinkClickCallback(openHyperlink)
+
 	// Wire keyboard input
 	winTerminal.SetInputCallback(func(data []byte) {
 		winScriptMu.Lock()
@@ -5385,15 +6441,31 @@ func createConsoleWindow(filePath string) {
 		}
 	})
 
-	win.Show()
+	// The run's log ends with the script, or when its tab closes
+	endRunLog := startRunLog(winTerminal, filePath)
+	closeTab = tabs.addTab(winSplitter.QWidget, filepath.Base(filePath), func() {
+		winScriptMu.Lock()
+		winTabClosed = true
+		winScriptMu.Unlock()
+		endRunLog()
+	})
+	if !opts.unattended {
+		absPath, _ := filepath.Abs(filePath)
+		tabs.remember(winSplitter.QWidget, pawgui.SessionTab{Kind: pawgui.SessionScript, Script: absPath}, winTerminal, isScriptRunning)
+	}
 
 	// Run the script
 	winTerminal.Feed(fmt.Sprintf("--- Running: %s ---\r\n\r\n", filepath.Base(filePath)))
+	if profileErr != nil {
+		winTerminal.Feed(fmt.Sprintf("%v\r\n", profileErr))
+	}
 
 	content, err := os.ReadFile(filePath)
 	if err != nil {
-		winTerminal.Feed(fmt.Sprintf("Error reading script file: %v\r\n", err))
-		return
+		winTerminal.Feed(fmt.Sprintf(tr("Error reading script file: %v\r\n"), err))
+		endRunLog()
+		opts.finish(false)
+		return closeTab
 	}
 
 	scriptDir := filepath.Dir(filePath)
@@ -5404,6 +6476,9 @@ func createConsoleWindow(filePath string) {
 
 	// Add the script's directory to recent paths for the combo box
 	addRecentPath(scriptDir)
+	if !opts.unattended {
+		recordScriptRun(absScript)
+	}
 
 	cwd, _ := os.Getwd()
 	tmpDir := os.TempDir()
@@ -5412,6 +6487,18 @@ func createConsoleWindow(filePath string) {
 		WriteRoots: []string{filepath.Join(scriptDir, "saves"), filepath.Join(scriptDir, "output"), filepath.Join(cwd, "saves"), filepath.Join(cwd, "output"), tmpDir},
 		ExecRoots:  []string{filepath.Join(scriptDir, "helpers"), filepath.Join(scriptDir, "bin")},
 	}
+	configHelper.ApplyGrantedRoots(fileAccess)
+	profile.ApplyRoots(fileAccess, scriptDir)
+	applyProjectRoots(fileAccess, absScript)
+
+	permissions := getLauncherPermissions(absScript)
+	if opts.permissions != nil {
+		permissions = opts.permissions
+	}
+	var permissionPrompt pawscript.PermissionPrompt = promptPermission
+	if opts.unattended {
+		permissionPrompt = nil // Access outside the roots is refused
+	}
 
 	ps := pawscript.New(&pawscript.Config{
 		Debug:                false,
@@ -5420,38 +6507,27 @@ func createConsoleWindow(filePath string) {
 		ShowErrorContext:     true,
 		ContextLines:         2,
 		FileAccess:           fileAccess,
+		Permissions:          permissions,
+		Quotas:               getLauncherQuotas(),
+		EnvAllowlist:         pawscript.DefaultEnvAllowlist,
+		PermissionPrompt:     permissionPrompt,
 		ScriptDir:            scriptDir,
-		OptLevel:             pawscript.OptimizationLevel(getOptimizationLevel()),
+		OptLevel:             pawscript.OptimizationLevel(profile.OptimizationLevel(getOptimizationLevel())),
+		Dialogs:              guiDialogs{},
 	})
 
-	ioConfig := &pawscript.IOChannelConfig{
-		Stdout: winOutCh,
-		Stdin:  winInCh,
-		Stderr: winOutCh,
-	}
-	ps.RegisterStandardLibraryWithIO([]string{}, ioConfig)
-
 	winScriptMu.Lock()
 	winScriptRunning = true
 	winScriptMu.Unlock()
 
-	go func() {
-		snapshot := ps.CreateRestrictedSnapshot()
-		result := ps.ExecuteWithEnvironment(string(content), snapshot, filePath, 0, 0)
-
-		if winOutCh.NativeFlush != nil {
-			winOutCh.NativeFlush()
-		}
-
-		if result == pawscript.BoolStatus(false) {
-			winTerminal.Feed("\r\n--- Script execution failed ---\r\n")
-		} else {
-			winTerminal.Feed("\r\n--- Script completed ---\r\n")
-		}
-
+	// scriptEnded starts the tab's REPL once the script ends or is killed
+	scriptEnded := func(ok bool) {
+		endRunLog()
 		winScriptMu.Lock()
 		winScriptRunning = false
+		winRun = nil
 		winScriptMu.Unlock()
+		opts.finish(ok)
 
 		// Start REPL for this window
 		winREPL = pawscript.NewREPL(pawscript.REPLConfig{
@@ -5464,6 +6540,7 @@ func createConsoleWindow(filePath string) {
 				Stdin:  winInCh,
 				Stderr: winOutCh,
 			},
+			Dialogs: guiDialogs{},
 		}, func(s string) {
 			winTerminal.Feed(s)
 		})
@@ -5478,7 +6555,7 @@ func createConsoleWindow(filePath string) {
 		winREPL.SetPSLColors(getPSLColors())
 		winREPL.Start()
 
-		// Register the dummy_button command with the window's REPL
+		// Register the toolbar_button command with the window's REPL
 		// Create window-specific toolbar data
 		winToolbarData := &QtWindowToolbarData{
 			strip:      winNarrowStrip,
@@ -5488,6 +6565,87 @@ func createConsoleWindow(filePath string) {
 		winToolbarData.updateFunc = func() {
 			updateWindowToolbarButtons(winToolbarData.strip, winToolbarData.registeredBtns)
 		}
-		registerDummyButtonCommand(winREPL.GetPawScript(), winToolbarData)
+		registerToolbarButtonCommand(winREPL.GetPawScript(), winToolbarData)
+		pawgui.RegisterKeyMacroCommand(winREPL.GetPawScript(), winToolbarData.terminal.KeyMap())
+		pawgui.RegisterMenuItemCommand(winREPL.GetPawScript(), winToolbarData.terminal.ContextMenuItems())
+		pawgui.RegisterMenuRegisterCommand(winREPL.GetPawScript(), winScriptMenus)
+		pawgui.RegisterWidgetCommands(winREPL.GetPawScript(), scriptWidgets)
+		pawgui.RegisterNotifyCommand(winREPL.GetPawScript())
+		pawgui.RegisterTraySetCommand(winREPL.GetPawScript(), launcherTray)
+		pawgui.RegisterStatusSetCommand(winREPL.GetPawScript(), winStatus.status)
+		pawgui.RegisterProgressCommand(winREPL.GetPawScript(), winStatus.status)
+	}
+
+	// Stop Script cancels the script, killing it if it won't stop
+	run := pawgui.NewScriptRun(ps, func() {
+		if winOutCh.NativeFlush != nil {
+			winOutCh.NativeFlush()
+		}
+		winTerminal.Feed("\r\n--- Script killed ---\r\n")
+		winScriptMenus.Clear()
+		winStatus.status.Clear()
+		if !opts.unattended {
+			recordScriptEnd(absScript, pawgui.RunStopped)
+		}
+		scriptEnded(false)
+	})
+	winScriptMu.Lock()
+	winRun = run
+	winScriptMu.Unlock()
+
+	// The script gets the tab's channels until it ends or is killed
+	ioConfig := run.IO(&pawscript.IOChannelConfig{
+		Stdout: winOutCh,
+		Stdin:  winInCh,
+		Stderr: winOutCh,
+	})
+	ps.RegisterStandardLibraryWithIO(profile.Args, ioConfig)
+	pawgui.RegisterKeyMacroCommand(ps, winTerminal.KeyMap())
+	pawgui.RegisterMenuItemCommand(ps, winTerminal.ContextMenuItems())
+	pawgui.RegisterMenuRegisterCommand(ps, winScriptMenus)
+	pawgui.RegisterWidgetCommands(ps, scriptWidgets)
+	pawgui.RegisterNotifyCommand(ps)
+	pawgui.RegisterTraySetCommand(ps, launcherTray)
+	pawgui.RegisterStatusSetCommand(ps, winStatus.status)
+	pawgui.RegisterProgressCommand(ps, winStatus.status)
+
+	noRestart := func() bool {
+		return tabClosed() || run.Stopped()
+	}
+
+	go func() {
+		ok := false
+		for {
+			snapshot := ps.CreateRestrictedSnapshot()
+			result := ps.ExecuteWithEnvironment(string(content), snapshot, filePath, 0, 0)
+
+			if winOutCh.NativeFlush != nil {
+				winOutCh.NativeFlush()
+			}
+
+			ok = result != pawscript.BoolStatus(false)
+			if run.Stopped() {
+				winTerminal.Feed("\r\n--- Script stopped ---\r\n")
+			} else if !ok {
+				winTerminal.Feed("\r\n--- Script execution failed ---\r\n")
+			} else {
+				winTerminal.Feed("\r\n--- Script completed ---\r\n")
+			}
+			winScriptMenus.Clear() // The script's menu entries and status end with it
+			winStatus.status.Clear()
+
+			// Run it again if its launch profile says to
+			if content = restartScript(filePath, opts.profile, ok, winTerminal.Feed, noRestart); content == nil {
+				break
+			}
+		}
+
+		if run.End() {
+			if !opts.unattended {
+				recordScriptEnd(absScript, pawgui.RunStatus(ok, run.Stopped()))
+			}
+			scriptEnded(ok)
+		}
 	}()
+	return closeTab
 }
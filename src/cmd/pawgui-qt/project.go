@@ -0,0 +1,232 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mappu/miqt/qt"
+	"github.com/phroun/pawscript"
+	"github.com/phroun/pawscript/src/pkg/pawgui"
+	purfectermqt "github.com/phroun/pawscript/src/pkg/purfecterm-qt"
+)
+
+// Projects
+// Open Project... in the launcher's menu opens a directory as a project (see
+// pawgui/project.go): its pinned scripts head the file list, its roots apply to
+// its scripts, and the Project submenu runs its run configurations and opens the
+// project terminal, a shell that changes to the project root when another
+// project is opened. The file list's menu pins scripts and saves their launch
+// profiles as run configurations.
+
+var (
+	currentProject  *pawgui.Project        // The project open in the launcher, nil for none
+	projectTerminal *purfectermqt.Terminal // The shell Project Terminal opened last
+
+	// The file list menu's project actions, shown for the project's scripts
+	pinAction, unpinAction, saveRunConfigAction *qt.QAction
+)
+
+// restoreProject reopens the project open when the launcher last quit
+func restoreProject() {
+	root := configHelper.GetProject()
+	if root == "" {
+		return
+	}
+	project, err := pawgui.OpenProject(root)
+	if project == nil {
+		// Moved or deleted since
+		fmt.Fprintf(os.Stderr, "Project: %v\n", err)
+		configHelper.SetProject("")
+		saveConfig(appConfig)
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Project: %v\n", err)
+	}
+	currentProject = project
+}
+
+// openProjectDialog asks for a directory and opens it as the project
+func openProjectDialog(parent *qt.QWidget) {
+	dir := qt.QFileDialog_GetExistingDirectory3(parent, tr("Open Project"), currentDir)
+	if dir == "" {
+		return
+	}
+	openProject(parent, dir)
+}
+
+// openProject opens dir as the launcher's project and shows its root in the
+// file list
+func openProject(parent *qt.QWidget, dir string) {
+	project, err := pawgui.OpenProject(dir)
+	if project == nil {
+		qt.QMessageBox_Critical5(parent, tr("Error"), fmt.Sprintf(tr("Failed to open project: %v"), err), qt.QMessageBox__Ok)
+		return
+	}
+	if err != nil {
+		terminal.Feed(fmt.Sprintf("%v\r\n", err))
+	}
+	setProject(project)
+	loadDirectory(project.Root)
+}
+
+// closeProject closes the launcher's project
+func closeProject() {
+	setProject(nil)
+	loadDirectory(currentDir)
+}
+
+// setProject makes project the launcher's, nil for none, and has the project
+// terminal follow its root
+func setProject(project *pawgui.Project) {
+	currentProject = project
+	root := ""
+	if project != nil {
+		root = project.Root
+	}
+	configHelper.SetProject(root)
+	saveConfig(appConfig)
+	if project != nil && projectTerminal != nil && projectTerminal.IsRunning() {
+		projectTerminal.WriteString(pawgui.ShellCdCommand(project.Root))
+	}
+}
+
+// saveProject writes the project's settings, reporting failure
+func saveProject(parent *qt.QWidget) bool {
+	if err := currentProject.Save(); err != nil {
+		qt.QMessageBox_Critical5(parent, tr("Error"), fmt.Sprintf(tr("Failed to save project: %v"), err), qt.QMessageBox__Ok)
+		return false
+	}
+	return true
+}
+
+// applyProjectRoots adds the project's roots to the file access of a script in it
+func applyProjectRoots(fileAccess *pawscript.FileAccessConfig, script string) {
+	if currentProject != nil {
+		currentProject.ApplyRoots(fileAccess, script)
+	}
+}
+
+// openProjectTerminal opens a shell in the project root that follows it
+func openProjectTerminal() {
+	if currentProject != nil {
+		projectTerminal = openShellTab(currentProject.Root)
+	}
+}
+
+// runRunConfig runs the project's run configuration with the name given, in a
+// console tab or the window of its own its profile gives it
+func runRunConfig(name string) {
+	if currentProject == nil {
+		return
+	}
+	config, ok := currentProject.RunConfig(name)
+	if !ok {
+		return
+	}
+	openScriptTabWith(currentProject.Path(config.Script), scriptTabOptions{profile: &config.Profile})
+}
+
+// saveRunConfigDialog asks for a name and saves a script's launch profile as
+// the project's run configuration with it
+func saveRunConfigDialog(parent *qt.QWidget, script string) {
+	rel, ok := currentProject.Rel(script)
+	if !ok {
+		return
+	}
+	name := qt.QInputDialog_GetText4(parent, tr("Save as Run Configuration"),
+		tr("Save the script's launch profile as the run configuration named:"), qt.QLineEdit__Normal,
+		strings.TrimSuffix(filepath.Base(script), filepath.Ext(script)), &ok)
+	if name = strings.TrimSpace(name); !ok || name == "" {
+		return
+	}
+	profile, _ := configHelper.GetLaunchProfile(script)
+	currentProject.SetRunConfig(pawgui.RunConfig{Name: name, Script: rel, Profile: profile})
+	saveProject(parent)
+}
+
+// deleteRunConfig deletes the run configuration with the name given, once the
+// user confirms it
+func deleteRunConfig(parent *qt.QWidget, name string) {
+	answer := qt.QMessageBox_Question6(parent, tr("Delete Run Configuration"),
+		fmt.Sprintf(tr("Delete the run configuration %q?"), name),
+		qt.QMessageBox__Ok|qt.QMessageBox__Cancel, qt.QMessageBox__Cancel)
+	if answer != qt.QMessageBox__Ok || currentProject == nil {
+		return
+	}
+	currentProject.DeleteRunConfig(name)
+	saveProject(parent)
+}
+
+// setPinned pins a script to the file list or unpins it
+func setPinned(script string, pinned bool) {
+	currentProject.SetPinned(script, pinned)
+	if saveProject(dialogParent()) {
+		loadDirectory(currentDir)
+	}
+}
+
+// addProjectActions fills the Project submenu: the run configurations, and the
+// actions for the project terminal, the project root and closing the project
+func addProjectActions(parent *qt.QWidget, menu *qt.QMenu) {
+	if currentProject == nil {
+		return
+	}
+	configs := currentProject.RunConfigs
+	for _, config := range configs {
+		name := config.Name // Capture for closure
+		menu.AddAction(name).OnTriggered(func() {
+			runRunConfig(name)
+		})
+	}
+	if len(configs) > 0 {
+		deleteMenu := menu.AddMenuWithTitle(tr("Delete Run Configuration"))
+		for _, config := range configs {
+			name := config.Name // Capture for closure
+			deleteMenu.AddAction(name + "...").OnTriggered(func() {
+				deleteRunConfig(parent, name)
+			})
+		}
+		menu.AddSeparator()
+	}
+
+	menu.AddAction(tr("Project Terminal")).OnTriggered(func() {
+		openProjectTerminal()
+	})
+	menu.AddAction(tr("Go to Project Root")).OnTriggered(func() {
+		if currentProject != nil {
+			loadDirectory(currentProject.Root)
+		}
+	})
+	menu.AddAction(tr("Close Project")).OnTriggered(func() {
+		closeProject()
+	})
+}
+
+// addProjectFileListActions adds the project's actions to the file list's menu
+func addProjectFileListActions(menu *qt.QMenu) {
+	pinAction = menu.AddAction(tr("Pin to Project"))
+	pinAction.OnTriggered(func() {
+		setPinned(fileListMenuScript, true)
+	})
+	unpinAction = menu.AddAction(tr("Unpin from Project"))
+	unpinAction.OnTriggered(func() {
+		setPinned(fileListMenuScript, false)
+	})
+	saveRunConfigAction = menu.AddAction(tr("Save as Run Configuration..."))
+	saveRunConfigAction.OnTriggered(func() {
+		saveRunConfigDialog(dialogParent(), fileListMenuScript)
+	})
+}
+
+// showProjectFileListActions shows the file list menu's project actions that
+// apply to a script
+func showProjectFileListActions(script string) {
+	inProject := currentProject != nil && currentProject.Contains(script)
+	pinned := inProject && currentProject.IsPinned(script)
+	pinAction.SetVisible(inProject && !pinned)
+	unpinAction.SetVisible(pinned)
+	saveRunConfigAction.SetVisible(inProject)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"github.com/mappu/miqt/qt"
+	"github.com/mappu/miqt/qt/mainthread"
+	"github.com/phroun/pawscript/src/pkg/pawgui"
+)
+
+// Recent scripts
+// The scripts run from the launcher are remembered with when they last ran and
+// how that run ended (see pawgui.RecentScript), and listed in the path menu and
+// the tray icon's Recent Scripts submenu, which run them again. --recent runs the
+// most recent one from the command line.
+
+// recordScriptRun remembers a script the launcher is running
+func recordScriptRun(script string) {
+	if configHelper.AddRecentScript(script) {
+		recentScriptsChanged()
+	}
+}
+
+// recordScriptEnd records how a run of a recent script ended (see
+// pawgui.RunStatus); it may be called from any goroutine
+func recordScriptEnd(script, status string) {
+	mainthread.Start(func() {
+		if configHelper.SetRecentScriptStatus(script, status) {
+			recentScriptsChanged()
+		}
+	})
+}
+
+// recentScriptsChanged saves the recent scripts and shows them in the path menu
+// (the tray's submenu is built as it opens)
+func recentScriptsChanged() {
+	saveConfig(appConfig)
+	updatePathMenu()
+}
+
+// addRecentScripts adds the recent scripts to a menu, to run them again
+func addRecentScripts(menu *qt.QMenu) {
+	for _, recent := range configHelper.GetRecentScriptRuns() {
+		script := recent.Path // Capture for closure
+		action := menu.AddAction(recent.Label())
+		if icon := createIconFromSVG(pawFileIconSVG, scaledMenuIconSize()); icon != nil {
+			action.SetIcon(icon)
+		}
+		action.SetToolTip(script)
+		action.OnTriggered(func() {
+			showOrCreateLauncher()
+			runScript(script)
+		})
+	}
+}
+
+// mostRecentScript returns the script the launcher ran last, "" if none
+func mostRecentScript() string {
+	config := loadConfig()
+	if scripts := pawgui.NewConfigHelper(config).GetRecentScripts(); len(scripts) > 0 {
+		return scripts[0]
+	}
+	return ""
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/mappu/miqt/qt"
+	purfectermqt "github.com/phroun/pawscript/src/pkg/purfecterm-qt"
+)
+
+// Run logs
+// With run_log on, everything a script run shows in its terminal is also written
+// to a log file of its own (see pawgui/runlog.go). A run's log lasts through the
+// restarts its launch profile asks for, and ends when the script does.
+
+// startRunLog starts logging what term shows for a run of script, returning the
+// function that ends the log (which does nothing after the first call)
+func startRunLog(term *purfectermqt.Terminal, script string) func() {
+	runLog, err := configHelper.OpenRunLog(script)
+	if err != nil {
+		term.Feed(fmt.Sprintf(tr("Can't log this run: %v\r\n"), err))
+	}
+	if runLog == nil {
+		return func() {}
+	}
+	term.SetOutputTee(runLog)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			term.SetOutputTee(nil)
+			runLog.Close()
+		})
+	}
+}
+
+// openRunLogFolder opens the directory run logs are written to with the system's
+// file manager
+func openRunLogFolder() {
+	dir := configHelper.GetRunLogDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return
+	}
+	qt.QDesktopServices_OpenUrl(qt.QUrl_FromLocalFile(dir))
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/mappu/miqt/qt"
+	"github.com/mappu/miqt/qt/mainthread"
+	"github.com/phroun/pawscript"
+	"github.com/phroun/pawscript/src/pkg/pawgui"
+)
+
+// Scheduled tasks
+// The Scheduled Tasks dialog, from the hamburger menu, lists the scripts the
+// launcher runs on a schedule, with their last runs. Each run opens a tab in a
+// window of its own, which opens minimized and isn't raised by later runs; a
+// task's next run replaces its tab.
+
+var (
+	launcherScheduler *pawgui.Scheduler
+
+	taskWindow *tabWindow        // Where scheduled tasks run, nil until one does
+	taskTabs   map[string]func() // Closes the tab of each task's last run
+
+	// refreshTasksDialog updates the Scheduled Tasks dialog while it's open
+	refreshTasksDialog func()
+)
+
+// startScheduler starts running the scheduled tasks, once
+func startScheduler() {
+	if launcherScheduler != nil {
+		return
+	}
+	taskTabs = make(map[string]func())
+	launcherScheduler = pawgui.NewScheduler(func(task pawgui.ScheduledTask, done func(ok bool)) {
+		mainthread.Start(func() {
+			runScheduledTask(task, done)
+		})
+	})
+	launcherScheduler.OnChange = func() {
+		mainthread.Start(func() {
+			if refreshTasksDialog != nil {
+				refreshTasksDialog()
+			}
+		})
+	}
+	launcherScheduler.SetTasks(configHelper.GetScheduledTasks())
+	launcherScheduler.Start()
+}
+
+// runScheduledTask runs a task in the background tab window
+func runScheduledTask(task pawgui.ScheduledTask, done func(ok bool)) {
+	if closeTab := taskTabs[task.Name]; closeTab != nil {
+		closeTab()
+		delete(taskTabs, task.Name)
+	}
+	if taskWindow == nil || !taskWindow.win.IsVisible() {
+		previous := currentTabWindow
+		if previous == taskWindow {
+			previous = nil
+		}
+		taskWindow = getTabWindow(true)
+		taskWindow.background = true
+		taskTabs = make(map[string]func())
+		currentTabWindow = previous // The user's new tabs open where they did
+	}
+	taskTabs[task.Name] = openScriptTabWith(task.Script, scriptTabOptions{
+		window:      taskWindow,
+		permissions: task.Permissions(),
+		unattended:  true,
+		onFinish:    done,
+	})
+}
+
+// saveScheduledTasks saves the tasks to the config and schedules them
+func saveScheduledTasks(tasks []pawgui.ScheduledTask) {
+	configHelper.SetScheduledTasks(tasks)
+	saveConfig(appConfig)
+	launcherScheduler.SetTasks(tasks)
+}
+
+// showScheduledTasksDialog shows the Scheduled Tasks manager
+func showScheduledTasksDialog(parent *qt.QWidget) {
+	if launcherScheduler == nil {
+		return
+	}
+
+	dialog := qt.NewQDialog(parent)
+	dialog.SetWindowTitle(tr("Scheduled Tasks"))
+	dialog.SetMinimumSize2(560, 480)
+	dialog.SetModal(true)
+
+	mainLayout := qt.NewQVBoxLayout2()
+	mainLayout.SetContentsMargins(12, 12, 12, 12)
+	mainLayout.SetSpacing(8)
+	dialog.SetLayout(mainLayout.QLayout)
+
+	// Tasks, with a check box to enable each
+	taskList := qt.NewQListWidget2()
+	mainLayout.AddWidget(taskList.QWidget)
+
+	buttonLayout := qt.NewQHBoxLayout2()
+	addBtn := qt.NewQPushButton3(tr("Add..."))
+	editBtn := qt.NewQPushButton3(tr("Edit..."))
+	removeBtn := qt.NewQPushButton3(tr("Remove"))
+	runNowBtn := qt.NewQPushButton3(tr("Run Now"))
+	buttonLayout.AddWidget(addBtn.QWidget)
+	buttonLayout.AddWidget(editBtn.QWidget)
+	buttonLayout.AddWidget(removeBtn.QWidget)
+	buttonLayout.AddStretch()
+	buttonLayout.AddWidget(runNowBtn.QWidget)
+	mainLayout.AddLayout(buttonLayout.QLayout)
+
+	// Runs, newest first
+	mainLayout.AddWidget(qt.NewQLabel3(tr("History")).QWidget)
+	historyList := qt.NewQListWidget2()
+	mainLayout.AddWidget(historyList.QWidget)
+
+	closeLayout := qt.NewQHBoxLayout2()
+	closeLayout.AddStretch()
+	closeBtn := qt.NewQPushButton3(tr("Close"))
+	closeBtn.OnClicked(func() {
+		dialog.Accept()
+	})
+	closeLayout.AddWidget(closeBtn.QWidget)
+	mainLayout.AddLayout(closeLayout.QLayout)
+
+	tasks := launcherScheduler.Tasks()
+	updateButtons := func() {
+		hasSelection := taskList.CurrentRow() >= 0
+		editBtn.SetEnabled(hasSelection)
+		removeBtn.SetEnabled(hasSelection)
+		runNowBtn.SetEnabled(hasSelection)
+	}
+
+	refreshing := false // Items changing as the list is rebuilt aren't toggles
+	refresh := func() {
+		refreshing = true
+		index := taskList.CurrentRow()
+		taskList.Clear()
+		for _, task := range tasks {
+			item := qt.NewQListWidgetItem7(fmt.Sprintf("%s  (%s, %s)\n%s  %s", task.Name, task.Schedule, task.Profile,
+				filepath.Base(task.Script), launcherScheduler.Status(task)), taskList)
+			item.SetFlags(item.Flags() | qt.ItemIsUserCheckable)
+			if task.Enabled {
+				item.SetCheckState(qt.Checked)
+			} else {
+				item.SetCheckState(qt.Unchecked)
+			}
+			item.SetToolTip(task.Script)
+		}
+		if index < len(tasks) {
+			taskList.SetCurrentRow(index)
+		}
+
+		historyList.Clear()
+		for _, run := range launcherScheduler.History() {
+			qt.NewQListWidgetItem7(run.Describe(), historyList).SetToolTip(run.Script)
+		}
+		updateButtons()
+		refreshing = false
+	}
+	taskList.OnCurrentRowChanged(func(currentRow int) {
+		updateButtons()
+	})
+	taskList.OnItemChanged(func(item *qt.QListWidgetItem) {
+		i := taskList.Row(item)
+		if refreshing || i < 0 || i >= len(tasks) {
+			return
+		}
+		if enabled := item.CheckState() == qt.Checked; enabled != tasks[i].Enabled {
+			tasks[i].Enabled = enabled
+			saveScheduledTasks(tasks)
+		}
+	})
+
+	addBtn.OnClicked(func() {
+		task := pawgui.ScheduledTask{Schedule: "every 1h", Profile: "untrusted", Enabled: true}
+		if editScheduledTask(dialog.QWidget, &task, tasks) {
+			tasks = append(tasks, task)
+			saveScheduledTasks(tasks)
+			refresh()
+		}
+	})
+	editBtn.OnClicked(func() {
+		i := taskList.CurrentRow()
+		if i < 0 {
+			return
+		}
+		others := append(append([]pawgui.ScheduledTask(nil), tasks[:i]...), tasks[i+1:]...)
+		task := tasks[i]
+		if editScheduledTask(dialog.QWidget, &task, others) {
+			tasks[i] = task
+			saveScheduledTasks(tasks)
+			refresh()
+		}
+	})
+	removeBtn.OnClicked(func() {
+		if i := taskList.CurrentRow(); i >= 0 {
+			tasks = append(tasks[:i], tasks[i+1:]...)
+			saveScheduledTasks(tasks)
+			refresh()
+		}
+	})
+	runNowBtn.OnClicked(func() {
+		if i := taskList.CurrentRow(); i >= 0 {
+			launcherScheduler.RunNow(tasks[i].Name)
+		}
+	})
+
+	refresh()
+	refreshTasksDialog = refresh
+	dialog.Exec()
+	refreshTasksDialog = nil
+	dialog.DeleteLater()
+}
+
+// editScheduledTask asks for a task's settings, returning false if canceled.
+// others are the tasks it must not share a name with.
+func editScheduledTask(parent *qt.QWidget, task *pawgui.ScheduledTask, others []pawgui.ScheduledTask) bool {
+	dialog := qt.NewQDialog(parent)
+	dialog.SetWindowTitle(tr("Scheduled Task"))
+	dialog.SetMinimumWidth(420)
+	dialog.SetModal(true)
+
+	mainLayout := qt.NewQVBoxLayout2()
+	mainLayout.SetContentsMargins(12, 12, 12, 12)
+	mainLayout.SetSpacing(12)
+	dialog.SetLayout(mainLayout.QLayout)
+
+	form := qt.NewQFormLayout2()
+	form.SetSpacing(8)
+	mainLayout.AddLayout(form.QLayout)
+
+	nameEdit := qt.NewQLineEdit2()
+	nameEdit.SetText(task.Name)
+	form.AddRow3(tr("Name:"), nameEdit.QWidget)
+
+	scriptLayout := qt.NewQHBoxLayout2()
+	scriptEdit := qt.NewQLineEdit2()
+	scriptEdit.SetText(task.Script)
+	scriptLayout.AddWidget(scriptEdit.QWidget)
+	browseBtn := qt.NewQPushButton3(tr("Browse..."))
+	browseBtn.OnClicked(func() {
+		startDir := currentDir
+		if script := scriptEdit.Text(); script != "" {
+			startDir = filepath.Dir(script)
+		}
+		file := qt.QFileDialog_GetOpenFileName4(
+			dialog.QWidget,
+			"Choose Script",
+			startDir,
+			"PawScript files (*.paw);;All files (*)",
+		)
+		if file != "" {
+			scriptEdit.SetText(file)
+			if nameEdit.Text() == "" {
+				nameEdit.SetText(filepath.Base(file))
+			}
+		}
+	})
+	scriptLayout.AddWidget(browseBtn.QWidget)
+	form.AddRow4("Script:", scriptLayout.QLayout)
+
+	scheduleEdit := qt.NewQLineEdit2()
+	scheduleEdit.SetText(task.Schedule)
+	scheduleEdit.SetPlaceholderText(tr("every 30m, or cron fields: 0 9 * * 1-5"))
+	scheduleEdit.SetToolTip(tr("\"every\" and an interval (30m, 2h), or five cron fields:\nminute, hour, day of month, month and day of week"))
+	form.AddRow3(tr("Schedule:"), scheduleEdit.QWidget)
+
+	profileCombo := qt.NewQComboBox2()
+	active := 0
+	for i, name := range pawscript.PermissionProfileNames() {
+		profileCombo.AddItem(name)
+		if name == task.Profile || (name == "untrusted" && active == 0) {
+			active = i
+		}
+	}
+	profileCombo.SetCurrentIndex(active)
+	form.AddRow3(tr("Profile:"), profileCombo.QWidget)
+
+	enabledCheck := qt.NewQCheckBox3(tr("Enabled"))
+	enabledCheck.SetChecked(task.Enabled)
+	form.AddRow3("", enabledCheck.QWidget)
+
+	// Button row
+	buttonLayout := qt.NewQHBoxLayout2()
+	buttonLayout.AddStretch()
+
+	cancelBtn := qt.NewQPushButton3(tr("Cancel"))
+	cancelBtn.OnClicked(func() {
+		dialog.Reject()
+	})
+	buttonLayout.AddWidget(cancelBtn.QWidget)
+
+	var edited pawgui.ScheduledTask
+	okBtn := qt.NewQPushButton3(tr("OK"))
+	okBtn.SetDefault(true)
+	okBtn.OnClicked(func() {
+		edited = pawgui.ScheduledTask{
+			Name:     nameEdit.Text(),
+			Script:   scriptEdit.Text(),
+			Schedule: scheduleEdit.Text(),
+			Profile:  profileCombo.CurrentText(),
+			Enabled:  enabledCheck.IsChecked(),
+		}
+		if abs, err := filepath.Abs(edited.Script); err == nil && edited.Script != "" {
+			edited.Script = abs
+		}
+		if err := edited.Validate(others); err != nil {
+			qt.QMessageBox_Critical5(dialog.QWidget, tr("Scheduled Task"), err.Error(), qt.QMessageBox__Ok)
+			return
+		}
+		dialog.Accept()
+	})
+	buttonLayout.AddWidget(okBtn.QWidget)
+
+	mainLayout.AddLayout(buttonLayout.QLayout)
+
+	if dialog.Exec() == 1 { // Accepted
+		dialog.DeleteLater()
+		*task = edited
+		return true
+	}
+	dialog.DeleteLater()
+	return false
+}
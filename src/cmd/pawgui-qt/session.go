@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/mappu/miqt/qt"
+	"github.com/phroun/pawscript/src/pkg/pawgui"
+	"github.com/phroun/pawscript/src/pkg/purfecterm"
+	purfectermqt "github.com/phroun/pawscript/src/pkg/purfecterm-qt"
+)
+
+// Session restore
+// When the launcher quits, or its last window closes, it saves the tab windows
+// open, and on the next launch offers to open them again, as session_restore
+// says. Each tab is remembered as it opens, with what's needed to open it again.
+
+// sessionTab is what the session keeps of an open tab
+type sessionTab struct {
+	tab      pawgui.SessionTab
+	terminal *purfectermqt.Terminal
+	running  func() bool // Whether its script still runs, for script tabs
+}
+
+var (
+	// tabWindows are the open tab windows, in the order they opened
+	tabWindows []*tabWindow
+
+	// launcherScript is the path of the script last run in the launcher
+	// (guarded by scriptMu)
+	launcherScript string
+
+	// restoringScrollback is the snapshot for the next tab to open while a
+	// session is restored
+	restoringScrollback []byte
+)
+
+// remember records a tab that opened for the session, restoring what its
+// terminal showed if it's being restored
+func (tw *tabWindow) remember(page *qt.QWidget, tab pawgui.SessionTab, term *purfectermqt.Terminal, running func() bool) {
+	tw.session[page.UnsafePointer()] = &sessionTab{tab: tab, terminal: term, running: running}
+
+	if restoringScrollback != nil {
+		if purfecterm.IsSnapshot(restoringScrollback) {
+			term.RestoreSnapshot(bytes.NewReader(restoringScrollback))
+		}
+		restoringScrollback = nil
+	}
+}
+
+// currentSession returns the tab windows open and the script the launcher runs
+func currentSession() *pawgui.Session {
+	session := &pawgui.Session{}
+	scriptMu.Lock()
+	if scriptRunning {
+		session.LauncherScript = launcherScript
+	}
+	scriptMu.Unlock()
+
+	scrollback := configHelper.GetSessionScrollback()
+	for _, tw := range tabWindows {
+		pos, size := tw.win.Pos(), tw.win.Size()
+		window := pawgui.SessionWindow{X: pos.X(), Y: pos.Y(), Width: size.Width(), Height: size.Height(),
+			Current: tw.tabs.CurrentIndex()}
+		for i := 0; i < tw.tabs.Count(); i++ {
+			open := tw.session[tw.tabs.Widget(i).UnsafePointer()]
+			if open == nil {
+				continue
+			}
+			tab := open.tab
+			if tab.Kind == pawgui.SessionScript && (open.running == nil || !open.running()) {
+				// Its script finished, leaving a console
+				tab = pawgui.SessionTab{Kind: pawgui.SessionConsole}
+			}
+			if scrollback {
+				var snapshot bytes.Buffer
+				if open.terminal.SaveSnapshot(&snapshot) == nil {
+					tab.Scrollback = snapshot.Bytes()
+				}
+			}
+			window.Tabs = append(window.Tabs, tab)
+		}
+		session.Windows = append(session.Windows, window)
+	}
+	return session
+}
+
+// saveSession saves the session for the next launch
+func saveSession() {
+	if err := pawgui.SaveSession(currentSession()); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to save session: %v\n", err)
+	}
+}
+
+// saveSessionIfLast saves the session when closing win leaves no window shown,
+// which ends the launcher
+func saveSessionIfLast(win *qt.QMainWindow) {
+	for _, widget := range qt.QApplication_TopLevelWidgets() {
+		if widget.IsWindow() && widget.IsVisible() && widget.UnsafePointer() != win.QWidget.UnsafePointer() {
+			return
+		}
+	}
+	saveSession()
+}
+
+// offerSessionRestore opens the tab windows of the last session, if
+// session_restore says to or the user agrees
+func offerSessionRestore() {
+	session, err := pawgui.LoadSession()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load session: %v\n", err)
+	}
+	if session.Empty() {
+		return
+	}
+	// Offered once: it's saved again when the launcher quits
+	pawgui.ClearSession()
+
+	switch configHelper.GetSessionRestore() {
+	case "never":
+		return
+	case "ask":
+		result := qt.QMessageBox_Question6(
+			mainWindow.QWidget,
+			tr("Restore Session"),
+			fmt.Sprintf(tr("Restore the previous session? (%s)"), session.Describe()),
+			qt.QMessageBox__Yes|qt.QMessageBox__No,
+			qt.QMessageBox__Yes,
+		)
+		if result != qt.QMessageBox__Yes {
+			return
+		}
+	}
+	restoreSession(session)
+}
+
+// restoreSession opens the tab windows of a saved session where they were, and
+// runs the launcher's script again
+func restoreSession(session *pawgui.Session) {
+	for _, window := range session.Windows {
+		if len(window.Tabs) == 0 {
+			continue
+		}
+		currentTabWindow = nil // Each saved window opens a new one
+		for _, tab := range window.Tabs {
+			restoringScrollback = tab.Scrollback
+			switch {
+			case tab.Kind == pawgui.SessionShell:
+				openShellTab(tab.Dir)
+			case tab.Kind == pawgui.SessionScript && fileExists(tab.Script):
+				openScriptTab(tab.Script)
+			default:
+				createBlankConsoleTab(false)
+			}
+			restoringScrollback = nil
+		}
+
+		tw := currentTabWindow
+		if tw == nil {
+			continue
+		}
+		if window.Width > 0 && window.Height > 0 {
+			tw.win.Resize(window.Width, window.Height)
+		}
+		if window.X >= 0 && window.Y >= 0 {
+			tw.win.Move(window.X, window.Y)
+		}
+		if window.Current < tw.tabs.Count() {
+			tw.tabs.SetCurrentIndex(window.Current)
+		}
+	}
+
+	if session.LauncherScript != "" && fileExists(session.LauncherScript) {
+		runScript(session.LauncherScript)
+	}
+}
+
+// fileExists reports whether a script is still there to run again
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
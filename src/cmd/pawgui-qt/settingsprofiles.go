@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mappu/miqt/qt"
+	"github.com/phroun/pawscript"
+	"github.com/phroun/pawscript/src/pkg/pawgui"
+)
+
+// Settings bundles and profiles
+// The hamburger menu's Export Settings... and Import Settings... write and read
+// a bundle of the settings worth carrying between machines, and its Settings
+// Profiles submenu switches between bundles saved by name (see
+// pawgui/settingsbundle.go). Imported settings apply as those of a reloaded
+// config do; shortcuts, as when set in Settings, apply to windows opened
+// afterwards. Sandbox settings that would let launched scripts do more apply only
+// once the user confirms them.
+
+// exportSettingsDialog shows a file dialog and writes a settings bundle to it
+func exportSettingsDialog(parent *qt.QWidget) {
+	file := qt.QFileDialog_GetSaveFileName4(
+		parent,
+		"Export Settings",
+		"pawgui-settings.psl",
+		"PSL Files (*.psl);;All Files (*)",
+	)
+	if file == "" {
+		return
+	}
+	if err := pawgui.WriteSettingsBundle(file, appConfig); err != nil {
+		qt.QMessageBox_Critical5(parent, tr("Error"), fmt.Sprintf(tr("Failed to export settings: %v"), err), qt.QMessageBox__Ok)
+	}
+}
+
+// importSettingsDialog shows a file dialog and imports the settings bundle chosen
+func importSettingsDialog(parent *qt.QWidget) {
+	file := qt.QFileDialog_GetOpenFileName4(
+		parent,
+		"Import Settings",
+		"",
+		"PSL Files (*.psl);;All Files (*)",
+	)
+	if file == "" {
+		return
+	}
+	bundle, err := pawgui.ReadSettingsBundle(file)
+	if err != nil {
+		qt.QMessageBox_Critical5(parent, tr("Error"), fmt.Sprintf(tr("Failed to import settings: %v"), err), qt.QMessageBox__Ok)
+		return
+	}
+
+	if !confirmSandboxWidening(parent, pawgui.SandboxWidening(appConfig, bundle)) {
+		return
+	}
+
+	origUIScale := getUIScale()
+	problems, err := pawgui.ImportSettings(appConfig, bundle)
+	if err != nil {
+		qt.QMessageBox_Critical5(parent, tr("Error"), fmt.Sprintf(tr("Failed to import settings: %v"), err), qt.QMessageBox__Ok)
+		return
+	}
+	// The settings are no longer those of the profile last used
+	delete(appConfig, "settings_profile")
+	settingsImported(parent, origUIScale, problems)
+}
+
+// settingsImported applies and saves the settings just imported, ui_scale having
+// been origUIScale, and reports those dropped for not fitting the schema
+func settingsImported(parent *qt.QWidget, origUIScale float64, problems []pawscript.PSLSchemaError) {
+	configHelper.PopulateDefaults()
+	applyTheme(configHelper.GetTheme())
+	applyConsoleTheme()
+	if getUIScale() != origUIScale {
+		applyUIScaleFromConfig()
+	}
+	applyFontSettings()
+	saveConfig(appConfig)
+
+	if len(problems) > 0 {
+		lines := make([]string, len(problems))
+		for i, problem := range problems {
+			lines[i] = problem.Error()
+		}
+		qt.QMessageBox_Warning(parent, tr("Import Settings"),
+			tr("These settings were left out:\n\n")+strings.Join(lines, "\n"))
+	}
+}
+
+// useSettingsProfile switches to the settings profile with the name given
+func useSettingsProfile(parent *qt.QWidget, name string) {
+	bundle, err := pawgui.ReadSettingsProfile(name)
+	if err != nil {
+		qt.QMessageBox_Critical5(parent, tr("Error"), fmt.Sprintf(tr("Failed to switch to profile %q: %v"), name, err), qt.QMessageBox__Ok)
+		return
+	}
+	if !confirmSandboxWidening(parent, pawgui.SandboxWidening(appConfig, bundle)) {
+		return
+	}
+
+	origUIScale := getUIScale()
+	problems, err := configHelper.UseSettingsProfileBundle(name, bundle)
+	if err != nil {
+		qt.QMessageBox_Critical5(parent, tr("Error"), fmt.Sprintf(tr("Failed to switch to profile %q: %v"), name, err), qt.QMessageBox__Ok)
+		return
+	}
+	settingsImported(parent, origUIScale, problems)
+}
+
+// confirmSandboxWidening shows the ways settings about to apply would let
+// launched scripts do more and asks the user to confirm them, there being
+// nothing to confirm if there are none
+func confirmSandboxWidening(parent *qt.QWidget, changes []string) bool {
+	if len(changes) == 0 {
+		return true
+	}
+	answer := qt.QMessageBox_Question6(parent, tr("Confirm Sandbox Changes"),
+		tr("These settings give scripts run from the launcher more access:\n\n")+strings.Join(changes, "\n"),
+		qt.QMessageBox__Ok|qt.QMessageBox__Cancel, qt.QMessageBox__Cancel)
+	return answer == qt.QMessageBox__Ok
+}
+
+// saveSettingsProfileDialog asks for a name and saves the settings as the
+// settings profile with it
+func saveSettingsProfileDialog(parent *qt.QWidget) {
+	var ok bool
+	name := qt.QInputDialog_GetText4(parent, tr("Save Settings Profile"),
+		tr("Save the current settings as the profile named:"), qt.QLineEdit__Normal, configHelper.GetSettingsProfile(), &ok)
+	if !ok || strings.TrimSpace(name) == "" {
+		return
+	}
+	if err := configHelper.SaveSettingsProfile(name); err != nil {
+		qt.QMessageBox_Critical5(parent, tr("Error"), fmt.Sprintf(tr("Failed to save profile: %v"), err), qt.QMessageBox__Ok)
+		return
+	}
+	saveConfig(appConfig)
+}
+
+// deleteSettingsProfile deletes the settings profile with the name given, once
+// the user confirms it
+func deleteSettingsProfile(parent *qt.QWidget, name string) {
+	answer := qt.QMessageBox_Question6(parent, tr("Delete Settings Profile"),
+		fmt.Sprintf(tr("Delete the settings profile %q? The current settings stay as they are."), name),
+		qt.QMessageBox__Ok|qt.QMessageBox__Cancel, qt.QMessageBox__Cancel)
+	if answer != qt.QMessageBox__Ok {
+		return
+	}
+	if err := configHelper.DeleteSettingsProfile(name); err != nil {
+		qt.QMessageBox_Critical5(parent, tr("Error"), fmt.Sprintf(tr("Failed to delete profile: %v"), err), qt.QMessageBox__Ok)
+		return
+	}
+	saveConfig(appConfig)
+}
+
+// addSettingsProfiles fills the Settings Profiles submenu: the profiles, the
+// current one checked, and the actions saving and deleting them
+func addSettingsProfiles(parent *qt.QWidget, menu *qt.QMenu) {
+	current := configHelper.GetSettingsProfile()
+	profiles := pawgui.ListSettingsProfiles()
+	for _, name := range profiles {
+		name := name // Capture for closure
+		action := menu.AddAction(name)
+		action.SetCheckable(true)
+		action.SetChecked(name == current)
+		action.OnTriggered(func() {
+			useSettingsProfile(parent, name)
+		})
+	}
+	if len(profiles) > 0 {
+		menu.AddSeparator()
+	}
+
+	menu.AddAction(tr("Save as Profile...")).OnTriggered(func() {
+		saveSettingsProfileDialog(parent)
+	})
+	if len(profiles) > 0 {
+		deleteMenu := menu.AddMenuWithTitle(tr("Delete Profile"))
+		for _, name := range profiles {
+			name := name // Capture for closure
+			deleteMenu.AddAction(name + "...").OnTriggered(func() {
+				deleteSettingsProfile(parent, name)
+			})
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/mappu/miqt/qt"
+	"github.com/mappu/miqt/qt/mainthread"
+	"github.com/phroun/pawscript/src/pkg/pawgui"
+	purfectermqt "github.com/phroun/pawscript/src/pkg/purfecterm-qt"
+)
+
+// Keyboard shortcuts
+// The Shortcuts tab of the settings dialog sets the shortcut of each action (see
+// pawgui/shortcuts.go): clicking one's button and pressing keys captures them,
+// Backspace removes it and Esc leaves it as it was. Shortcuts that clash are
+// listed under them, and must be sorted out before the settings are saved. The
+// shortcuts saved apply to windows opened afterwards.
+
+// shortcutFromKeyQt returns the shortcut for a key press, "" for a key that
+// can't be one
+func shortcutFromKeyQt(event *qt.QKeyEvent) string {
+	key := qt.Key(event.Key())
+	name := ""
+	switch key {
+	case qt.Key_Escape:
+		name = "Esc"
+	case qt.Key_Tab, qt.Key_Backtab:
+		name = "Tab"
+	case qt.Key_Return, qt.Key_Enter:
+		name = "Enter"
+	case qt.Key_Space:
+		name = "Space"
+	case qt.Key_Backspace:
+		name = "Backspace"
+	case qt.Key_Delete:
+		name = "Delete"
+	case qt.Key_Insert:
+		name = "Insert"
+	case qt.Key_Home:
+		name = "Home"
+	case qt.Key_End:
+		name = "End"
+	case qt.Key_PageUp:
+		name = "PageUp"
+	case qt.Key_PageDown:
+		name = "PageDown"
+	case qt.Key_Up:
+		name = "Up"
+	case qt.Key_Down:
+		name = "Down"
+	case qt.Key_Left:
+		name = "Left"
+	case qt.Key_Right:
+		name = "Right"
+	default:
+		if key >= qt.Key_F1 && key <= qt.Key_F12 {
+			name = fmt.Sprintf("F%d", key-qt.Key_F1+1)
+		} else if key > qt.Key_Space && key <= qt.Key_AsciiTilde {
+			// Printable keys are their (uppercase) character
+			name = string(rune(key))
+		}
+	}
+	if name == "" {
+		return ""
+	}
+
+	modifiers := event.Modifiers()
+	hasCtrl := modifiers&qt.ControlModifier != 0
+	hasMeta := modifiers&qt.MetaModifier != 0
+	if runtime.GOOS == "darwin" {
+		// Qt swaps Control and Meta on macOS (see convertShortcutForQt)
+		hasCtrl, hasMeta = hasMeta, hasCtrl
+	}
+	return pawgui.ShortcutString(name, hasCtrl, modifiers&qt.AltModifier != 0,
+		modifiers&qt.ShiftModifier != 0, hasMeta)
+}
+
+// consoleShortcuts returns what the console shortcuts do in a terminal, for
+// its key map
+func consoleShortcuts(term *purfectermqt.Terminal) map[string]func() {
+	onMain := func(fn func()) func() {
+		return func() {
+			mainthread.Start(fn)
+		}
+	}
+	return map[string]func(){
+		pawgui.ShortcutCopy:      onMain(term.CopySelection),
+		pawgui.ShortcutPaste:     onMain(term.PasteClipboard),
+		pawgui.ShortcutFind:      onMain(term.ShowFindBar),
+		pawgui.ShortcutZoomIn:    onMain(func() { term.ZoomBy(1) }),
+		pawgui.ShortcutZoomOut:   onMain(func() { term.ZoomBy(-1) }),
+		pawgui.ShortcutZoomReset: onMain(func() { term.ZoomBy(0) }),
+	}
+}
+
+// shortcutsTab is the Shortcuts tab of the settings dialog
+type shortcutsTab struct {
+	widget    *qt.QWidget
+	shortcuts map[string]string // Shortcut being set for each action
+	problems  *qt.QLabel
+}
+
+// newShortcutsTab creates the Shortcuts tab, showing the configured shortcuts
+func newShortcutsTab() *shortcutsTab {
+	tab := &shortcutsTab{shortcuts: configHelper.GetShortcuts()}
+
+	tab.widget = qt.NewQWidget2()
+	layout := qt.NewQVBoxLayout2()
+	layout.SetContentsMargins(12, 12, 12, 12)
+	layout.SetSpacing(8)
+	tab.widget.SetLayout(layout.QLayout)
+
+	form := qt.NewQFormLayout2()
+	form.SetSpacing(8)
+	for _, action := range pawgui.ShortcutActions {
+		form.AddRow4(tr(action.Label)+":", tab.newRow(action).QLayout)
+	}
+	layout.AddLayout(form.QLayout)
+
+	tab.problems = qt.NewQLabel2()
+	tab.problems.SetWordWrap(true)
+	layout.AddWidget(tab.problems.QWidget)
+	layout.AddStretch()
+
+	hint := qt.NewQLabel3(tr("Click a shortcut and press the keys for it: Backspace removes it, Esc leaves it. ") +
+		tr("Shortcuts apply to windows opened after saving."))
+	hint.SetWordWrap(true)
+	hint.SetEnabled(false) // Dimmed
+	layout.AddWidget(hint.QWidget)
+
+	tab.update()
+	return tab
+}
+
+// newRow creates the row of an action: the button capturing its shortcut and a
+// button restoring its default
+func (tab *shortcutsTab) newRow(action pawgui.ShortcutAction) *qt.QHBoxLayout {
+	row := qt.NewQHBoxLayout2()
+
+	button := qt.NewQPushButton3("")
+	button.SetAutoDefault(false) // Enter is captured, not a click
+	capturing := false
+	showShortcut := func() {
+		if capturing {
+			button.SetText(tr("Press a shortcut..."))
+		} else if shortcut := tab.shortcuts[action.Name]; shortcut != "" {
+			button.SetText(shortcut)
+		} else {
+			button.SetText(tr("None"))
+		}
+	}
+	setShortcut := func(shortcut string) {
+		capturing = false
+		tab.shortcuts[action.Name] = shortcut
+		showShortcut()
+		tab.update()
+	}
+	button.OnClicked(func() {
+		capturing = true
+		showShortcut()
+	})
+	button.OnKeyPressEvent(func(super func(event *qt.QKeyEvent), event *qt.QKeyEvent) {
+		if !capturing {
+			super(event)
+			return
+		}
+		key := qt.Key(event.Key())
+		plain := event.Modifiers()&(qt.ControlModifier|qt.ShiftModifier|qt.AltModifier|qt.MetaModifier) == 0
+		switch {
+		case key == qt.Key_Shift || key == qt.Key_Control || key == qt.Key_Alt ||
+			key == qt.Key_Meta || key == qt.Key_AltGr || key == qt.Key_CapsLock:
+			// Wait for the key the modifiers go with
+		case plain && key == qt.Key_Escape:
+			capturing = false
+			showShortcut()
+		case plain && key == qt.Key_Backspace:
+			setShortcut("")
+		default:
+			if shortcut := shortcutFromKeyQt(event); shortcut != "" {
+				setShortcut(shortcut)
+			}
+		}
+	})
+	button.OnFocusOutEvent(func(super func(event *qt.QFocusEvent), event *qt.QFocusEvent) {
+		super(event)
+		if capturing {
+			capturing = false
+			showShortcut()
+		}
+	})
+	showShortcut()
+	row.AddWidget2(button.QWidget, 1)
+
+	defaultButton := qt.NewQPushButton3(tr("Default"))
+	defaultButton.SetAutoDefault(false)
+	defaultButton.SetToolTip(pawgui.GetDefaultShortcut(action.Name))
+	defaultButton.OnClicked(func() {
+		setShortcut(pawgui.GetDefaultShortcut(action.Name))
+	})
+	row.AddWidget(defaultButton.QWidget)
+	return row
+}
+
+// update lists the problems with the shortcuts being set
+func (tab *shortcutsTab) update() {
+	if problems := tab.check(); len(problems) > 0 {
+		tab.problems.SetText(strings.Join(problems, "\n"))
+	} else {
+		tab.problems.SetText(tr("No conflicts."))
+	}
+}
+
+// check returns the problems with the shortcuts being set
+func (tab *shortcutsTab) check() []string {
+	return pawgui.CheckShortcuts(tab.shortcuts, configHelper.GetKeyMacros())
+}
+
+// save sets the shortcuts in the config
+func (tab *shortcutsTab) save() {
+	for _, action := range pawgui.ShortcutActions {
+		configHelper.SetShortcut(action.Name, tab.shortcuts[action.Name])
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"github.com/mappu/miqt/qt"
+	"github.com/mappu/miqt/qt/mainthread"
+	"github.com/phroun/pawscript/src/pkg/pawgui"
+)
+
+// Status bar
+// Script and console windows show a thin bar under the terminal, with the left
+// and right segments scripts set with status_set, and the progress bar they show
+// with progress. It hides while it has nothing to show.
+
+// statusBar is a script or console window's status bar
+type statusBar struct {
+	widget   *qt.QWidget
+	left     *qt.QLabel
+	right    *qt.QLabel
+	progress *qt.QProgressBar
+	status   *pawgui.StatusBar
+}
+
+// newStatusBar creates a status bar, hidden until a script sets it
+func newStatusBar() *statusBar {
+	b := &statusBar{}
+
+	b.widget = qt.NewQWidget2()
+	layout := qt.NewQHBoxLayout2()
+	layout.SetContentsMargins(8, 2, 4, 2)
+	layout.SetSpacing(8)
+	b.widget.SetLayout(layout.QLayout)
+
+	b.left = qt.NewQLabel3("")
+	b.left.SetSizePolicy2(qt.QSizePolicy__Ignored, qt.QSizePolicy__Preferred)
+	layout.AddWidget2(b.left.QWidget, 1)
+
+	b.progress = qt.NewQProgressBar2()
+	b.progress.SetRange(0, progressSteps)
+	b.progress.SetTextVisible(false)
+	b.progress.SetFixedWidth(120)
+	b.progress.SetMaximumHeight(12)
+	b.progress.Hide()
+	layout.AddWidget(b.progress.QWidget)
+
+	b.right = qt.NewQLabel3("")
+	layout.AddWidget(b.right.QWidget)
+
+	b.status = pawgui.NewStatusBar(func() {
+		// Called on the script's goroutine
+		mainthread.Start(b.update)
+	})
+
+	b.widget.Hide()
+	return b
+}
+
+// progressSteps is the range of the progress bar, which shows a fraction
+const progressSteps = 1000
+
+// update shows the status bar's text and progress, hiding it while there are
+// none
+func (b *statusBar) update() {
+	left, right := b.status.Text()
+	fraction, progress := b.status.Progress()
+	b.left.SetText(left)
+	b.left.SetToolTip(left)
+	b.right.SetText(right)
+	b.progress.SetValue(int(fraction * progressSteps))
+	b.progress.SetVisible(progress)
+	b.widget.SetVisible(left != "" || right != "" || progress)
+}
+
+// withStatusBar puts a terminal and a status bar under it in one widget
+func withStatusBar(term *qt.QWidget, bar *statusBar) *qt.QWidget {
+	container := qt.NewQWidget2()
+	layout := qt.NewQVBoxLayout2()
+	layout.SetContentsMargins(0, 0, 0, 0)
+	layout.SetSpacing(0)
+	container.SetLayout(layout.QLayout)
+	layout.AddWidget2(term, 1)
+	layout.AddWidget(bar.widget)
+	return container
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/mappu/miqt/qt/mainthread"
+	"github.com/phroun/pawscript/src/pkg/pawgui"
+)
+
+// System theme
+// With theme or term_theme set to auto, windows, icons and console palettes
+// switch when the OS switches between dark and light (see pawgui/systemtheme.go).
+
+var followSystemThemeOnce sync.Once
+
+// followSystemTheme reads the OS's theme for isSystemDarkMode and applies the
+// auto themes again whenever it changes. Where the OS can't be watched, the theme
+// is judged by Qt's palette at startup.
+func followSystemTheme() {
+	followSystemThemeOnce.Do(func() {
+		pawgui.WatchSystemTheme(func(dark bool) {
+			mainthread.Start(systemThemeChanged)
+		})
+	})
+}
+
+// systemThemeChanged applies the auto themes after the OS's theme changes
+func systemThemeChanged() {
+	if configHelper.GetTheme() == pawgui.ThemeAuto {
+		applyTheme(pawgui.ThemeAuto)
+	}
+	if configHelper.IsTermThemeAuto() {
+		applyConsoleTheme()
+	}
+}
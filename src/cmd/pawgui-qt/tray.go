@@ -0,0 +1,106 @@
+package main
+
+import (
+
+	"github.com/mappu/miqt/qt"
+	"github.com/mappu/miqt/qt/mainthread"
+	"github.com/phroun/pawscript/src/pkg/pawgui"
+)
+
+// System tray
+// The launcher shows an icon in the system tray whose menu shows the launcher,
+// opens a console or runs a recent script, and hides there when it's minimized if
+// minimize_to_tray is set. Scripts set its tooltip and badge with tray_set.
+
+// trayIconSize is the size the tray icon is drawn at; the tray scales it down
+const trayIconSize = 64
+
+var (
+	trayIcon *qt.QSystemTrayIcon // nil until shown, or where there is no tray
+
+	// launcherTray holds the tray icon's tooltip and badge
+	launcherTray *pawgui.Tray
+)
+
+// init creates launcherTray, whose updates refer back to it
+func init() {
+	launcherTray = pawgui.NewTray(func() {
+		// Called on the script's goroutine
+		mainthread.Start(updateTrayIcon)
+	})
+}
+
+// startTray puts the launcher's icon in the system tray, where there is one
+func startTray() {
+	if trayIcon != nil || !qt.QSystemTrayIcon_IsSystemTrayAvailable() {
+		return
+	}
+
+	menu := qt.NewQMenu2()
+	menu.AddAction(tr("Show Launcher")).OnTriggered(showOrCreateLauncher)
+	menu.AddAction(tr("New Console")).OnTriggered(func() {
+		createBlankConsoleTab(true)
+	})
+	recentMenu := menu.AddMenuWithTitle(tr("Recent Scripts"))
+	menu.OnAboutToShow(func() {
+		recentMenu.MenuAction().SetEnabled(len(configHelper.GetRecentScripts()) > 0)
+	})
+	recentMenu.OnAboutToShow(func() {
+		// Rebuilt each time, for the scripts the launcher ran last
+		recentMenu.Clear()
+		addRecentScripts(recentMenu)
+	})
+	menu.AddSeparator()
+	menu.AddAction(tr("Quit")).OnTriggered(func() {
+		quitApplication(mainWindow.QWidget)
+	})
+
+	trayIcon = qt.NewQSystemTrayIcon()
+	trayIcon.SetContextMenu(menu)
+	trayIcon.OnActivated(func(reason qt.QSystemTrayIcon__ActivationReason) {
+		if reason == qt.QSystemTrayIcon__Trigger {
+			showOrCreateLauncher()
+		}
+	})
+	updateTrayIcon()
+	trayIcon.Show()
+}
+
+// updateTrayIcon shows the tray icon's tooltip and badge
+func updateTrayIcon() {
+	if trayIcon == nil {
+		return
+	}
+	trayIcon.SetToolTip(launcherTray.Tooltip())
+
+	pixmap := createPixmapFromSVG(pawFileIconSVG, trayIconSize)
+	if pixmap == nil {
+		return
+	}
+	if badge := launcherTray.Badge(); badge != "" {
+		buffer := qt.NewQBuffer()
+		buffer.Open(qt.QIODevice__WriteOnly)
+		pixmap.Save4(buffer.QIODevice, "PNG")
+		data, err := pawgui.TrayIconPNG(buffer.Data(), badge)
+		buffer.Close()
+		if err != nil {
+			return
+		}
+		pixmap = qt.NewQPixmap()
+		if !pixmap.LoadFromData4(data, "PNG") {
+			return
+		}
+	}
+	trayIcon.SetIcon(qt.NewQIcon2(pixmap))
+}
+
+// hideWhenMinimized hides the launcher in the tray when it's minimized, if
+// minimize_to_tray is set
+func hideWhenMinimized(super func(event *qt.QEvent), event *qt.QEvent) {
+	super(event)
+	if event.Type() == qt.QEvent__WindowStateChange && mainWindow.IsMinimized() &&
+		trayIcon != nil && configHelper.GetMinimizeToTray() {
+		// Hidden once Qt has finished minimizing it
+		mainthread.Start(mainWindow.Hide)
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"github.com/mappu/miqt/qt"
+	"github.com/mappu/miqt/qt/mainthread"
+	"github.com/phroun/pawscript/src/pkg/pawgui"
+)
+
+// Script windows
+// Scripts build windows of their own with window_create and add labels, buttons,
+// sliders, rows and columns to them (see pawgui.RegisterWidgetCommands). The
+// windows stay open after the script ends, until the user or window_close closes
+// them.
+
+// qtWidgetHost draws script windows with Qt widgets
+type qtWidgetHost struct {
+	// Used on the main thread only
+	windows map[*pawgui.WidgetWindow]*qt.QWidget
+	widgets map[*pawgui.Widget]*qt.QWidget
+	layouts map[*pawgui.Widget]*qt.QBoxLayout // Of the rows and columns
+}
+
+// scriptWidgets holds the windows scripts created, in any window or tab
+var scriptWidgets = pawgui.NewScriptWidgets(&qtWidgetHost{
+	windows: make(map[*pawgui.WidgetWindow]*qt.QWidget),
+	widgets: make(map[*pawgui.Widget]*qt.QWidget),
+	layouts: make(map[*pawgui.Widget]*qt.QBoxLayout),
+})
+
+// OpenWindow shows a script's new, empty window
+func (h *qtWidgetHost) OpenWindow(window *pawgui.WidgetWindow) {
+	mainthread.Start(func() {
+		win := qt.NewQWidget2()
+		win.SetWindowTitle(window.Title)
+		win.SetAttribute(qt.WA_DeleteOnClose)
+		layout := qt.NewQVBoxLayout2()
+		layout.SetContentsMargins(12, 12, 12, 12)
+		layout.SetSpacing(6)
+		win.SetLayout(layout.QLayout)
+		if window.Width > 0 || window.Height > 0 {
+			size := win.SizeHint()
+			width, height := size.Width(), size.Height()
+			if window.Width > 0 {
+				width = window.Width
+			}
+			if window.Height > 0 {
+				height = window.Height
+			}
+			win.Resize(width, height)
+		}
+		h.windows[window] = win
+		h.widgets[window.Root] = win
+		h.layouts[window.Root] = layout.QBoxLayout
+
+		win.OnDestroyed(func() {
+			delete(h.windows, window)
+			for widget := range h.widgets {
+				if widget.Window == window {
+					delete(h.widgets, widget)
+					delete(h.layouts, widget)
+				}
+			}
+			scriptWidgets.WindowClosed(window)
+		})
+		win.Show()
+	})
+}
+
+// AddWidget adds a widget to a script window's row or column
+func (h *qtWidgetHost) AddWidget(parent, widget *pawgui.Widget) {
+	mainthread.Start(func() {
+		layout, ok := h.layouts[parent]
+		if !ok {
+			return // The window was closed
+		}
+
+		var child *qt.QWidget
+		stretch := 0
+		switch widget.Kind {
+		case pawgui.WidgetColumn, pawgui.WidgetRow:
+			child = qt.NewQWidget2()
+			var box *qt.QBoxLayout
+			if widget.Kind == pawgui.WidgetColumn {
+				box = qt.NewQVBoxLayout2().QBoxLayout
+			} else {
+				box = qt.NewQHBoxLayout2().QBoxLayout
+			}
+			box.SetContentsMargins(0, 0, 0, 0)
+			box.SetSpacing(6)
+			child.SetLayout(box.QLayout)
+			h.layouts[widget] = box
+			stretch = 1
+		case pawgui.WidgetLabel:
+			child = qt.NewQLabel3(widget.Text()).QWidget
+		case pawgui.WidgetButton:
+			button := qt.NewQPushButton3(widget.Text())
+			button.OnClicked(widget.Clicked)
+			child = button.QWidget
+		case pawgui.WidgetSlider:
+			slider := qt.NewQSlider3(qt.Horizontal)
+			slider.SetRange(widget.Min, widget.Max)
+			slider.SetValue(widget.Value())
+			slider.SetMinimumWidth(160)
+			slider.OnValueChanged(widget.Moved)
+			child = slider.QWidget
+			stretch = 1
+		default:
+			return
+		}
+		h.widgets[widget] = child
+		layout.AddWidget2(child, stretch)
+	})
+}
+
+// UpdateWidget shows a widget's new text or value
+func (h *qtWidgetHost) UpdateWidget(widget *pawgui.Widget) {
+	mainthread.Start(func() {
+		child, ok := h.widgets[widget]
+		if !ok {
+			return
+		}
+		switch widget.Kind {
+		case pawgui.WidgetLabel:
+			qt.UnsafeNewQLabel(child.UnsafePointer()).SetText(widget.Text())
+		case pawgui.WidgetButton:
+			qt.UnsafeNewQPushButton(child.UnsafePointer()).SetText(widget.Text())
+		case pawgui.WidgetSlider:
+			qt.UnsafeNewQSlider(child.UnsafePointer()).SetValue(widget.Value())
+		}
+	})
+}
+
+// CloseWindow closes a script's window
+func (h *qtWidgetHost) CloseWindow(window *pawgui.WidgetWindow) {
+	mainthread.Start(func() {
+		if win, ok := h.windows[window]; ok {
+			win.Close()
+		}
+	})
+}
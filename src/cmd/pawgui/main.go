@@ -1012,8 +1012,14 @@ func saveConfig(config pawscript.PSLConfig) {
 		return
 	}
 
-	data := pawscript.SerializePSL(config)
-	_ = os.WriteFile(configPath, []byte(data+"\n"), 0644)
+	data := pawscript.SerializePSL(config) + "\n"
+	// Keep the comments and layout of a hand-edited file
+	if existing, err := os.ReadFile(configPath); err == nil {
+		if updated, err := pawscript.UpdatePSL(string(existing), config); err == nil {
+			data = updated
+		}
+	}
+	_ = os.WriteFile(configPath, []byte(data), 0644)
 }
 
 // getExamplesDir returns the path to the examples directory relative to the executable
@@ -2704,6 +2710,7 @@ func createConsoleChannels(stdinReader *io.PipeReader, stdoutWriter *io.PipeWrit
 		ColorDepth:    256,
 		Width:         width,
 		Height:        height,
+		DarkTheme:     true,
 		SupportsInput: true,
 		EchoEnabled:   false, // Echo handled by KeyInputManager
 		LineMode:      false, // Raw byte mode - line assembly in PawScript
@@ -2712,57 +2719,21 @@ func createConsoleChannels(stdinReader *io.PipeReader, stdoutWriter *io.PipeWrit
 
 	// Non-blocking output: large buffer absorbs bursts, never blocks PawScript
 	// This prevents deadlock between input and output on slow terminals
-	outputQueue := make(chan []byte, 256)
+	outputQueue := make(chan interface{}, 256)
 
 	// Writer goroutine: drains queue and writes to terminal pipe
 	go func() {
 		for data := range outputQueue {
-			stdoutWriter.Write(data)
+			stdoutWriter.Write(pawscript.ConsoleBytes(data))
 		}
 	}()
 
-	consoleOutCh := &pawscript.StoredChannel{
-		BufferSize:       0,
-		Messages:         make([]pawscript.ChannelMessage, 0),
-		Subscribers:      make(map[int]*pawscript.StoredChannel),
-		NextSubscriberID: 1,
-		IsClosed:         false,
-		Timestamp:        time.Now(),
-		Terminal:         termCaps,
-		NativeSend: func(v interface{}) error {
-			// Convert to bytes
-			var data []byte
-			switch d := v.(type) {
-			case []byte:
-				data = d
-			case string:
-				// Convert newlines for terminal display
-				text := strings.ReplaceAll(d, "\r\n", "\n")
-				text = strings.ReplaceAll(text, "\n", "\r\n")
-				data = []byte(text)
-			default:
-				text := fmt.Sprintf("%v", v)
-				text = strings.ReplaceAll(text, "\r\n", "\n")
-				text = strings.ReplaceAll(text, "\n", "\r\n")
-				data = []byte(text)
-			}
-			// Non-blocking send - if queue full, drop to prevent deadlock
-			select {
-			case outputQueue <- data:
-				// Sent
-			default:
-				// Queue full - output will be lost but we won't deadlock
-			}
-			return nil
-		},
-		NativeRecv: func() (interface{}, error) {
-			return nil, fmt.Errorf("cannot receive from console_out")
-		},
-	}
+	consoleOutCh := pawscript.NewChannelFromGo(outputQueue, nil, termCaps)
+	consoleOutCh.Overflow = pawscript.OverflowDropNewest // a stalled GUI drops output rather than blocking the script
 
 	// Non-blocking input: large buffer absorbs input when script isn't reading
 	// This prevents deadlock when script ends but terminal is still accepting input
-	inputQueue := make(chan byte, 256)
+	inputQueue := make(chan interface{}, 256)
 
 	// Reader goroutine: drains pipe and puts bytes into queue
 	// Drops oldest bytes if queue is full to prevent blocking
@@ -2776,7 +2747,7 @@ func createConsoleChannels(stdinReader *io.PipeReader, stdoutWriter *io.PipeWrit
 			}
 			// Non-blocking send with "drop oldest" policy
 			select {
-			case inputQueue <- buf[0]:
+			case inputQueue <- []byte{buf[0]}:
 				// Sent successfully
 			default:
 				// Queue full - drop oldest byte to make room
@@ -2788,7 +2759,7 @@ func createConsoleChannels(stdinReader *io.PipeReader, stdoutWriter *io.PipeWrit
 				}
 				// Try again - should succeed now
 				select {
-				case inputQueue <- buf[0]:
+				case inputQueue <- []byte{buf[0]}:
 				default:
 					// Still can't send, just drop this byte
 				}
@@ -2796,26 +2767,7 @@ func createConsoleChannels(stdinReader *io.PipeReader, stdoutWriter *io.PipeWrit
 		}
 	}()
 
-	consoleInCh := &pawscript.StoredChannel{
-		BufferSize:       0,
-		Messages:         make([]pawscript.ChannelMessage, 0),
-		Subscribers:      make(map[int]*pawscript.StoredChannel),
-		NextSubscriberID: 1,
-		IsClosed:         false,
-		Timestamp:        time.Now(),
-		Terminal:         termCaps,
-		NativeRecv: func() (interface{}, error) {
-			// Read from buffered queue instead of directly from pipe
-			b, ok := <-inputQueue
-			if !ok {
-				return nil, fmt.Errorf("input closed")
-			}
-			return []byte{b}, nil
-		},
-		NativeSend: func(v interface{}) error {
-			return fmt.Errorf("cannot send to console_in")
-		},
-	}
+	consoleInCh := pawscript.NewChannelFromGo(nil, inputQueue, termCaps)
 
 	// clearQueue drains all pending input from the queue
 	clearQueue := func() {
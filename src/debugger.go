@@ -0,0 +1,267 @@
+package pawscript
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Debugging
+// A Debugger set as Config.Debugger pauses a script before a command runs: at a
+// breakpoint on the command's file and line, after a step, or when the host asks
+// for a pause. While it is paused the script's goroutine waits, and the debugger's
+// OnPause is told where it stopped, with the macro call stack and the variables in
+// scope, until the host resumes it. Stepping goes a line at a time, so the
+// commands of a brace expression don't stop on the line they share.
+
+// DebugAction is how a paused script goes on
+type DebugAction int
+
+const (
+	DebugContinue DebugAction = iota // Run until a breakpoint or a pause
+	DebugStepIn                      // Stop at the next line, in a macro it calls too
+	DebugStepOver                    // Stop at the next line of this macro or its callers
+	DebugStepOut                     // Stop once this macro returns
+)
+
+// Breakpoint is a line of a script file to pause before
+type Breakpoint struct {
+	File string
+	Line int
+}
+
+// DebugFrame is a macro call on the stack of a paused script
+type DebugFrame struct {
+	Macro  string // Name of the macro, or "" for the script itself
+	File   string
+	Line   int
+	Column int
+}
+
+// DebugVariable is a variable in scope where a script paused, formatted as PSL
+type DebugVariable struct {
+	Name  string
+	Value string
+}
+
+// DebugPause is where a script paused
+type DebugPause struct {
+	File      string
+	Line      int
+	Column    int
+	Command   string          // The command about to run
+	Stack     []DebugFrame    // Innermost first, ending with the script itself
+	Variables []DebugVariable // In the current scope, by name
+}
+
+// Debugger holds breakpoints and pauses scripts at them
+type Debugger struct {
+	// OnPause is called on the script's goroutine each time it pauses; the script
+	// waits until Resume is called
+	OnPause func(pause *DebugPause)
+
+	mu          sync.Mutex
+	breakpoints map[Breakpoint]bool
+	pauseNext   bool        // Pause requested, or stepping from the last pause
+	step        DebugAction // How the last pause resumed
+	stepFrom    Breakpoint  // Line the last pause was on
+	stepDepth   int         // Macro depth of the last pause
+	lastLine    Breakpoint  // Line of the last command checked
+	paused      bool
+	resume      chan DebugAction
+	pauseMu     sync.Mutex // One script goroutine paused at a time
+}
+
+// NewDebugger creates a debugger with no breakpoints
+func NewDebugger(onPause func(pause *DebugPause)) *Debugger {
+	return &Debugger{
+		OnPause:     onPause,
+		breakpoints: make(map[Breakpoint]bool),
+		resume:      make(chan DebugAction),
+	}
+}
+
+// breakpointKey cleans a file name so breakpoints match the names scripts run as
+func breakpointKey(file string, line int) Breakpoint {
+	if file != "" {
+		if abs, err := filepath.Abs(file); err == nil {
+			file = abs
+		}
+	}
+	return Breakpoint{File: file, Line: line}
+}
+
+// SetBreakpoint sets or clears the breakpoint on a line
+func (d *Debugger) SetBreakpoint(file string, line int, set bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	key := breakpointKey(file, line)
+	if set {
+		d.breakpoints[key] = true
+	} else {
+		delete(d.breakpoints, key)
+	}
+}
+
+// HasBreakpoint reports whether there is a breakpoint on a line
+func (d *Debugger) HasBreakpoint(file string, line int) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.breakpoints[breakpointKey(file, line)]
+}
+
+// Breakpoints returns the breakpoints by file and line
+func (d *Debugger) Breakpoints() []Breakpoint {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	list := make([]Breakpoint, 0, len(d.breakpoints))
+	for bp := range d.breakpoints {
+		list = append(list, bp)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].File != list[j].File {
+			return list[i].File < list[j].File
+		}
+		return list[i].Line < list[j].Line
+	})
+	return list
+}
+
+// Pause pauses the script before the next command it runs
+func (d *Debugger) Pause() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pauseNext = true
+	d.step = DebugStepIn
+	d.stepFrom = Breakpoint{}
+}
+
+// Paused reports whether a script is waiting to be resumed
+func (d *Debugger) Paused() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.paused
+}
+
+// Resume lets a paused script go on; it does nothing when none is paused
+func (d *Debugger) Resume(action DebugAction) {
+	d.mu.Lock()
+	paused := d.paused
+	d.paused = false
+	d.mu.Unlock()
+	if paused {
+		d.resume <- action
+	}
+}
+
+// shouldPause decides whether to pause before a command at a line and macro depth
+func (d *Debugger) shouldPause(at Breakpoint, depth int) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	newLine := at != d.lastLine
+	d.lastLine = at
+	if d.pauseNext {
+		switch d.step {
+		case DebugStepIn:
+			if at != d.stepFrom || depth != d.stepDepth {
+				return true
+			}
+		case DebugStepOver:
+			if depth < d.stepDepth || (depth == d.stepDepth && at != d.stepFrom) {
+				return true
+			}
+		case DebugStepOut:
+			if depth < d.stepDepth {
+				return true
+			}
+		}
+	}
+	return newLine && d.breakpoints[at]
+}
+
+// check pauses before a command when it should, until the host resumes
+func (d *Debugger) check(ps *PawScript, cmd *ParsedCommand, state *ExecutionState) {
+	if cmd.Position == nil || state == nil {
+		return
+	}
+	at := breakpointKey(cmd.Position.Filename, cmd.Position.Line)
+	depth := 0
+	for mc := state.macroContext; mc != nil; mc = mc.ParentMacro {
+		depth++
+	}
+	if !d.shouldPause(at, depth) {
+		return
+	}
+
+	d.pauseMu.Lock()
+	defer d.pauseMu.Unlock()
+	d.mu.Lock()
+	d.paused = true
+	d.pauseNext = false
+	d.mu.Unlock()
+
+	if d.OnPause != nil {
+		d.OnPause(newDebugPause(ps, cmd, state))
+	}
+	action := <-d.resume
+
+	d.mu.Lock()
+	d.pauseNext = action != DebugContinue
+	d.step = action
+	d.stepFrom = at
+	d.stepDepth = depth
+	d.mu.Unlock()
+}
+
+// newDebugPause describes where a script is about to run a command
+func newDebugPause(ps *PawScript, cmd *ParsedCommand, state *ExecutionState) *DebugPause {
+	pause := &DebugPause{
+		File:    cmd.Position.Filename,
+		Line:    cmd.Position.Line,
+		Column:  cmd.Position.Column,
+		Command: cmd.Command,
+	}
+
+	// The innermost frame is where the script is; each macro's frame is where it
+	// was called from
+	pause.Stack = append(pause.Stack, DebugFrame{
+		Macro: debugFrameName(state.macroContext),
+		File:  pause.File, Line: pause.Line, Column: pause.Column,
+	})
+	for mc := state.macroContext; mc != nil; mc = mc.ParentMacro {
+		pause.Stack = append(pause.Stack, DebugFrame{
+			Macro: debugFrameName(mc.ParentMacro),
+			File:  mc.InvocationFile, Line: mc.InvocationLine, Column: mc.InvocationColumn,
+		})
+	}
+
+	state.mu.RLock()
+	variables := make(map[string]interface{}, len(state.variables))
+	for name, value := range state.variables {
+		variables[name] = value
+	}
+	state.mu.RUnlock()
+	for name, value := range variables {
+		pause.Variables = append(pause.Variables, DebugVariable{
+			Name:  name,
+			Value: FormatValueColored(value, false, DisplayColorConfig{}, ps),
+		})
+	}
+	sort.Slice(pause.Variables, func(i, j int) bool {
+		return pause.Variables[i].Name < pause.Variables[j].Name
+	})
+	return pause
+}
+
+// debugFrameName names a macro for the call stack
+func debugFrameName(mc *MacroContext) string {
+	switch {
+	case mc == nil:
+		return ""
+	case mc.MacroName != "":
+		return mc.MacroName
+	default:
+		return fmt.Sprintf("<macro at %s:%d>", mc.DefinitionFile, mc.DefinitionLine)
+	}
+}
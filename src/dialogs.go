@@ -0,0 +1,35 @@
+package pawscript
+
+// GUI dialogs
+// Hosts with a user interface set Config.Dialogs, and scripts that IMPORT gui can
+// then ask the user things with native dialogs: gui_alert, gui_confirm,
+// gui_prompt, gui_file_open and gui_file_save. Without it (the command line, a
+// server) those commands fail, and sys_info reports gui: false so a script can
+// fall back to the terminal.
+
+// DialogHost shows native dialogs for the gui:: commands
+// Its methods are called from the goroutine running the script and block until
+// the user answers; ok is false when the user cancels.
+type DialogHost interface {
+	Alert(title, message string)
+	Confirm(title, message string) bool
+	Prompt(title, message, initial string) (text string, ok bool)
+	FileOpen(options FileDialogOptions) (path string, ok bool)
+	FileSave(options FileDialogOptions) (path string, ok bool)
+}
+
+// FileDialogOptions describes a file dialog a script asked for
+type FileDialogOptions struct {
+	Title    string
+	Dir      string   // Directory to start in (the script's directory if empty)
+	Name     string   // File name to suggest, when saving
+	Patterns []string // Glob patterns of the files to show, e.g. "*.paw" (empty = all)
+}
+
+// dialogs returns the host's dialogs, or nil without a GUI
+func (ps *PawScript) dialogs() DialogHost {
+	if ps.config == nil {
+		return nil
+	}
+	return ps.config.Dialogs
+}
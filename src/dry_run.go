@@ -0,0 +1,145 @@
+package pawscript
+
+import (
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Dry-run access analysis
+// With Config.DryRun set, a script runs with its side effects stubbed out and every
+// access it attempts is recorded instead of checked against the sandbox:
+//   - files are read normally, so the script takes the path it usually would
+//   - files opened for writing write nowhere, and mkdir, rm, rmdir and image_save
+//     succeed without touching the disk
+//   - exec succeeds with empty output, without starting anything
+//   - CheckHostAccess fails with ErrDryRun, so network commands don't connect
+// Other commands that would write fail with ErrDryRun. Afterwards DryRunReport says
+// what the script touched, and its FileAccess method turns that into the smallest
+// sandbox that would have let the script run.
+
+// ErrDryRun is returned for accesses a dry run records but does not perform
+var ErrDryRun = errors.New("dry run: not performed")
+
+// DryRunReport lists what a script accessed during a dry run, each sorted and unique
+type DryRunReport struct {
+	Reads  []string // Absolute paths read or inspected
+	Writes []string // Absolute paths written, created or removed
+	Execs  []string // Programs run, as absolute paths when they could be found
+	Hosts  []string // Hosts (or host:port) connected to
+}
+
+// dryRunLog collects accesses for DryRunReport
+type dryRunLog struct {
+	mu       sync.Mutex
+	accesses map[AuditAction]map[string]bool
+}
+
+func (ps *PawScript) dryRun() bool {
+	return ps.config != nil && ps.config.DryRun
+}
+
+// recordDryRun notes an access made during a dry run
+func (ps *PawScript) recordDryRun(action AuditAction, target string) {
+	ps.dryRunLog.mu.Lock()
+	defer ps.dryRunLog.mu.Unlock()
+	if ps.dryRunLog.accesses == nil {
+		ps.dryRunLog.accesses = make(map[AuditAction]map[string]bool)
+	}
+	if ps.dryRunLog.accesses[action] == nil {
+		ps.dryRunLog.accesses[action] = make(map[string]bool)
+	}
+	ps.dryRunLog.accesses[action][target] = true
+}
+
+// dryRunPath records a file access during a dry run
+// Reads are allowed; writes return ErrDryRun for the command to stub out.
+func (ps *PawScript) dryRunPath(path string, needsWrite bool) (string, error) {
+	absPath, err := ps.scriptPath(path)
+	if err != nil {
+		return "", err
+	}
+	if needsWrite {
+		ps.recordDryRun(AuditWrite, absPath)
+		return absPath, ErrDryRun
+	}
+	ps.recordDryRun(AuditRead, absPath)
+	return absPath, nil
+}
+
+// dryRunExec records a program exec would have run, resolving it like exec does
+func (ps *PawScript) dryRunExec(resolvedCmd string) {
+	if !filepath.IsAbs(resolvedCmd) {
+		if found, err := exec.LookPath(resolvedCmd); err == nil {
+			resolvedCmd = found
+		}
+	}
+	if filepath.IsAbs(resolvedCmd) {
+		resolvedCmd = filepath.Clean(resolvedCmd)
+	}
+	ps.recordDryRun(AuditExec, resolvedCmd)
+}
+
+// DryRunReport returns what scripts have accessed so far in a dry run
+func (ps *PawScript) DryRunReport() DryRunReport {
+	ps.dryRunLog.mu.Lock()
+	defer ps.dryRunLog.mu.Unlock()
+	sorted := func(action AuditAction) []string {
+		targets := []string{}
+		for target := range ps.dryRunLog.accesses[action] {
+			targets = append(targets, target)
+		}
+		sort.Strings(targets)
+		return targets
+	}
+	return DryRunReport{
+		Reads:  sorted(AuditRead),
+		Writes: sorted(AuditWrite),
+		Execs:  sorted(AuditExec),
+		Hosts:  sorted(AuditConnect),
+	}
+}
+
+// FileAccess returns the smallest sandbox allowing everything in the report
+// Each path is its own root, so only the exact files and directories used are allowed.
+// Programs not found on PATH during the dry run are left out of ExecRoots.
+func (r DryRunReport) FileAccess() *FileAccessConfig {
+	fa := &FileAccessConfig{
+		ReadRoots:    append([]string{}, r.Reads...),
+		WriteRoots:   append([]string{}, r.Writes...),
+		ExecRoots:    []string{},
+		AllowedHosts: append([]string{}, r.Hosts...),
+	}
+	for _, program := range r.Execs {
+		if filepath.IsAbs(program) {
+			fa.ExecRoots = append(fa.ExecRoots, program)
+		}
+	}
+	return fa
+}
+
+// PSL returns the report as a PSL map, with the sandbox from FileAccess under "sandbox"
+func (r DryRunReport) PSL() PSLMap {
+	list := func(items []string) PSLList {
+		out := PSLList{}
+		for _, item := range items {
+			out = append(out, item)
+		}
+		return out
+	}
+	fa := r.FileAccess()
+	return PSLMap{
+		"reads":  list(r.Reads),
+		"writes": list(r.Writes),
+		"execs":  list(r.Execs),
+		"hosts":  list(r.Hosts),
+		"sandbox": PSLMap{
+			"read_roots":    list(fa.ReadRoots),
+			"write_roots":   list(fa.WriteRoots),
+			"exec_roots":    list(fa.ExecRoots),
+			"allowed_hosts": list(fa.AllowedHosts),
+		},
+	}
+}
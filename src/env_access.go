@@ -0,0 +1,75 @@
+package pawscript
+
+import (
+	"os"
+	"runtime"
+	"strings"
+)
+
+// Environment variable access
+// Config.EnvAllowlist controls which environment variables scripts can see, both
+// through env_get/env_list and in the environment exec passes to subprocesses.
+// Entries name a variable ("HOME") or a group by prefix ("LC_*"); nil allows every
+// variable and an empty list allows none. Names match case-insensitively on Windows.
+
+// DefaultEnvAllowlist holds variables that are safe to show sandboxed scripts
+// It covers locale, terminal and user basics, but nothing that tends to hold secrets.
+var DefaultEnvAllowlist = []string{
+	"HOME", "USER", "USERNAME", "LOGNAME", "SHELL", "PATH",
+	"LANG", "LANGUAGE", "LC_*", "TZ",
+	"TERM", "COLORTERM", "TERM_PROGRAM", "NO_COLOR",
+	"TMPDIR", "TEMP", "TMP",
+}
+
+// envAllowed reports whether an allowlist lets scripts see a variable
+func envAllowed(allowlist []string, name string) bool {
+	if allowlist == nil {
+		return true
+	}
+	equal, hasPrefix := func(a, b string) bool { return a == b }, strings.HasPrefix
+	if runtime.GOOS == "windows" {
+		equal = strings.EqualFold
+		hasPrefix = func(s, prefix string) bool {
+			return strings.HasPrefix(strings.ToUpper(s), strings.ToUpper(prefix))
+		}
+	}
+	for _, entry := range allowlist {
+		if prefix, wildcard := strings.CutSuffix(entry, "*"); wildcard {
+			if hasPrefix(name, prefix) {
+				return true
+			}
+		} else if equal(name, entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// envAllowlist returns the configured allowlist (nil = every variable)
+func (ps *PawScript) envAllowlist() []string {
+	if ps.config == nil {
+		return nil
+	}
+	return ps.config.EnvAllowlist
+}
+
+// scriptEnviron returns the "NAME=value" environment scripts may see, in os.Environ order
+func (ps *PawScript) scriptEnviron() []string {
+	allowlist := ps.envAllowlist()
+	env := []string{}
+	for _, entry := range os.Environ() {
+		name, _, _ := strings.Cut(entry, "=")
+		if name != "" && envAllowed(allowlist, name) {
+			env = append(env, entry)
+		}
+	}
+	return env
+}
+
+// lookupScriptEnv returns a variable's value if it is set and scripts may see it
+func (ps *PawScript) lookupScriptEnv(name string) (string, bool) {
+	if name == "" || !envAllowed(ps.envAllowlist(), name) {
+		return "", false
+	}
+	return os.LookupEnv(name)
+}
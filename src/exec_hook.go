@@ -0,0 +1,55 @@
+package pawscript
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Exec inspection
+// Config.ExecHook sees every subprocess exec is about to start, after the ExecRoots
+// checks pass, with the binary resolved and the arguments as strings. The hook can
+// refuse the exec by returning an error, or return a changed request to run instead.
+// A rewritten request is not checked against ExecRoots again.
+
+// ExecRequest describes a subprocess exec is about to start
+type ExecRequest struct {
+	Command string   // Program as the script named it
+	Path    string   // Binary to run: absolute if it was found, else as the script named it
+	Args    []string // Arguments, not including the program
+}
+
+// ExecHook inspects an exec before it runs
+// It returns the request to run (changed or not), or an error to refuse it; the error
+// is logged for the script and exec fails.
+type ExecHook func(request ExecRequest) (ExecRequest, error)
+
+// shellMetacharacters are the characters DenyShellMetacharacters refuses
+const shellMetacharacters = ";&|`$<>(){}*?[]!~\\\"'\n\r"
+
+// DenyShellMetacharacters is an ExecHook that refuses arguments a shell would
+// interpret, for hosts that let scripts pass text to "sh -c" style commands
+func DenyShellMetacharacters(request ExecRequest) (ExecRequest, error) {
+	for _, arg := range request.Args {
+		if i := strings.IndexAny(arg, shellMetacharacters); i >= 0 {
+			return request, fmt.Errorf("argument %q contains shell metacharacter %q", arg, arg[i])
+		}
+	}
+	return request, nil
+}
+
+// inspectExec builds the ExecRequest for an exec and passes it through the hook
+func (ps *PawScript) inspectExec(cmdName, resolvedCmd string, args []string) (ExecRequest, error) {
+	request := ExecRequest{Command: cmdName, Path: resolvedCmd, Args: args}
+	if ps.config == nil || ps.config.ExecHook == nil {
+		return request, nil
+	}
+	if !filepath.IsAbs(request.Path) {
+		if found, err := exec.LookPath(request.Path); err == nil {
+			request.Path = found
+		}
+	}
+	request.Args = append([]string(nil), args...)
+	return ps.config.ExecHook(request)
+}
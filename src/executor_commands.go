@@ -195,6 +195,16 @@ func (e *Executor) executeParsedCommand(parsedCmd *ParsedCommand, state *Executi
 	if substitutionCtx != nil {
 		substitutionCtx.CurrentParsedCommand = parsedCmd
 	}
+	// A cancelled script unwinds as ret would (see cancel.go)
+	if e.cancelled.Load() {
+		return EarlyReturn{Status: BoolStatus(false)}
+	}
+	if e.debugHook != nil {
+		e.debugHook(parsedCmd, state)
+		if e.cancelled.Load() {
+			return EarlyReturn{Status: BoolStatus(false)}
+		}
+	}
 	return e.executeSingleCommand(parsedCmd.Command, state, substitutionCtx, parsedCmd.Position)
 }
 
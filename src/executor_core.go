@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"regexp"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -40,6 +41,8 @@ type Executor struct {
 	maxIterations    int               // Maximum loop iterations (0 or negative = unlimited)
 	rootState        *ExecutionState   // Root execution state for routing errors when no specific state is available
 	fallbackHandler  func(cmdName string, args []interface{}, namedArgs map[string]interface{}, state *ExecutionState, position *SourcePosition) Result
+	debugHook        func(cmd *ParsedCommand, state *ExecutionState) // Called before each command when a Debugger is set
+	cancelled        atomic.Bool       // Set by PawScript.Cancel; commands fail without running
 }
 
 // NewExecutor creates a new command executor
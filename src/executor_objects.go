@@ -228,8 +228,10 @@ func (e *Executor) RefRelease(ref ObjectRef) {
 			e.logger.DebugCat(CatMemory, "Removed block %d from parse cache", ref.ID)
 
 		case ObjChannel:
-			// TODO: Handle channel cleanup if needed
-			_ = obj.Value // Silence unused warning
+			// Release the journal file of a durable channel (its contents are kept for replay)
+			if ch, ok := obj.Value.(*StoredChannel); ok {
+				ch.closeJournal()
+			}
 		}
 
 		// Clear the value to help GC
@@ -438,6 +440,11 @@ func (e *Executor) decrementObjectRefCount(objectID int) {
 				storedFile.Close()
 			}
 
+			// Release the journal file of a durable channel (its contents are kept for replay)
+			if ch, ok := obj.Value.(*StoredChannel); ok {
+				ch.closeJournal()
+			}
+
 			// Clean up cached parsed form for blocks
 			if _, ok := obj.Value.(StoredBlock); ok {
 				delete(e.blockCache, objectID)
@@ -393,7 +393,9 @@ func (e *Executor) PopAndResumeCommandSequence(tokenID string, status bool) bool
 	tokenData, exists := e.activeTokens[tokenID]
 	if !exists {
 		e.mu.Unlock()
-		e.logger.WarnCat(CatAsync,"Attempted to resume with invalid token: %s", tokenID)
+		if !e.cancelled.Load() { // Cancel fails tokens before their operations finish
+			e.logger.WarnCat(CatAsync,"Attempted to resume with invalid token: %s", tokenID)
+		}
 		return false
 	}
 
@@ -0,0 +1,123 @@
+package pawscript
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Network host access control
+// AllowedHosts and DeniedHosts in FileAccessConfig hold host patterns:
+//   - "api.example.com"    the host itself
+//   - "*.example.com"      any subdomain of example.com, but not example.com itself
+//   - "10.0.0.0/8"         any IP address in the range (CIDR notation)
+//   - "192.168.1.20"       the address itself; IPv6 addresses are written bare, as "::1"
+//   - "*"                  any host
+// Any pattern but a CIDR range may end in ":port" to match only that port.
+// Names are matched as written and are never resolved, so a CIDR range only matches
+// connections made to an IP address.
+
+// hostPattern is a parsed AllowedHosts or DeniedHosts entry
+type hostPattern struct {
+	host    string     // Lowercase name or IP, "*" for any, or "" for a CIDR range
+	network *net.IPNet // CIDR range, if given
+	port    string     // Required port, or "" for any
+}
+
+// parseHostPattern parses one host pattern
+func parseHostPattern(pattern string) (hostPattern, error) {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if pattern == "" {
+		return hostPattern{}, fmt.Errorf("empty host pattern")
+	}
+	if strings.Contains(pattern, "/") {
+		_, network, err := net.ParseCIDR(pattern)
+		if err != nil {
+			return hostPattern{}, fmt.Errorf("invalid CIDR range %q", pattern)
+		}
+		return hostPattern{network: network}, nil
+	}
+	p := hostPattern{host: pattern}
+	if host, port, err := net.SplitHostPort(pattern); err == nil {
+		p.host, p.port = host, port
+	}
+	p.host = strings.TrimSuffix(p.host, ".")
+	if strings.Contains(p.host, "*") && p.host != "*" &&
+		(!strings.HasPrefix(p.host, "*.") || strings.Contains(p.host[2:], "*")) {
+		return hostPattern{}, fmt.Errorf("invalid host pattern %q: a wildcard must be a leading \"*.\"", pattern)
+	}
+	return p, nil
+}
+
+// matches reports whether the pattern covers a host and port ("" if unknown)
+func (p hostPattern) matches(host, port string) bool {
+	if p.port != "" && p.port != port {
+		return false
+	}
+	ip := net.ParseIP(host)
+	switch {
+	case p.network != nil:
+		return ip != nil && p.network.Contains(ip)
+	case p.host == "*":
+		return true
+	case strings.HasPrefix(p.host, "*."):
+		return ip == nil && strings.HasSuffix(host, p.host[1:])
+	case ip != nil:
+		return ip.Equal(net.ParseIP(p.host))
+	}
+	return host == p.host
+}
+
+// ParseHostPatterns splits a comma-separated list of host patterns, checking each one
+// Used by frontends for --allow-hosts and --deny-hosts.
+func ParseHostPatterns(list string) ([]string, error) {
+	var patterns []string
+	for _, pattern := range strings.Split(list, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if _, err := parseHostPattern(pattern); err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// CheckHost reports whether scripts may connect to address, a host or host:port
+// A nil config allows every host. DeniedHosts is checked first; then nil AllowedHosts
+// allows any host and an empty AllowedHosts allows none.
+func (fa *FileAccessConfig) CheckHost(address string) error {
+	if fa == nil {
+		return nil
+	}
+	host, port := strings.ToLower(strings.TrimSpace(address)), ""
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		host, port = h, p
+	}
+	host = strings.TrimSuffix(strings.Trim(host, "[]"), ".")
+	if host == "" {
+		return fmt.Errorf("network access denied: no host given")
+	}
+
+	for _, entry := range fa.DeniedHosts {
+		if pattern, err := parseHostPattern(entry); err == nil && pattern.matches(host, port) {
+			return fmt.Errorf("network access denied: host %q is denied", host)
+		}
+	}
+	if fa.AllowedHosts == nil {
+		// nil means unrestricted
+		return nil
+	}
+	if len(fa.AllowedHosts) == 0 {
+		// Empty slice means no network access allowed
+		return fmt.Errorf("network access denied: no allowed hosts configured")
+	}
+	for _, entry := range fa.AllowedHosts {
+		if pattern, err := parseHostPattern(entry); err == nil && pattern.matches(host, port) {
+			return nil
+		}
+	}
+	return fmt.Errorf("network access denied: host %q not in allowed hosts", host)
+}
@@ -0,0 +1,269 @@
+package pawscript
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LocaleInfo describes the number and date conventions for a locale
+type LocaleInfo struct {
+	Decimal    string // Decimal separator
+	Group      string // Thousands group separator
+	DateFormat string // Go time layout for dates
+	TimeFormat string // Go time layout for times (without seconds)
+}
+
+// localeTable holds formatting conventions for known locales
+// Lookup tries the full tag first (e.g. "en_GB"), then the language alone ("en")
+var localeTable = map[string]LocaleInfo{
+	"en":    {Decimal: ".", Group: ",", DateFormat: "01/02/2006", TimeFormat: "3:04 PM"},
+	"en_GB": {Decimal: ".", Group: ",", DateFormat: "02/01/2006", TimeFormat: "15:04"},
+	"en_AU": {Decimal: ".", Group: ",", DateFormat: "02/01/2006", TimeFormat: "3:04 PM"},
+	"en_CA": {Decimal: ".", Group: ",", DateFormat: "2006-01-02", TimeFormat: "3:04 PM"},
+	"de":    {Decimal: ",", Group: ".", DateFormat: "02.01.2006", TimeFormat: "15:04"},
+	"de_CH": {Decimal: ".", Group: "'", DateFormat: "02.01.2006", TimeFormat: "15:04"},
+	"fr":    {Decimal: ",", Group: " ", DateFormat: "02/01/2006", TimeFormat: "15:04"},
+	"es":    {Decimal: ",", Group: ".", DateFormat: "02/01/2006", TimeFormat: "15:04"},
+	"it":    {Decimal: ",", Group: ".", DateFormat: "02/01/2006", TimeFormat: "15:04"},
+	"pt":    {Decimal: ",", Group: ".", DateFormat: "02/01/2006", TimeFormat: "15:04"},
+	"nl":    {Decimal: ",", Group: ".", DateFormat: "02-01-2006", TimeFormat: "15:04"},
+	"sv":    {Decimal: ",", Group: " ", DateFormat: "2006-01-02", TimeFormat: "15:04"},
+	"pl":    {Decimal: ",", Group: " ", DateFormat: "02.01.2006", TimeFormat: "15:04"},
+	"ru":    {Decimal: ",", Group: " ", DateFormat: "02.01.2006", TimeFormat: "15:04"},
+	"ja":    {Decimal: ".", Group: ",", DateFormat: "2006/01/02", TimeFormat: "15:04"},
+	"zh":    {Decimal: ".", Group: ",", DateFormat: "2006/01/02", TimeFormat: "15:04"},
+	"ko":    {Decimal: ".", Group: ",", DateFormat: "2006. 01. 02.", TimeFormat: "15:04"},
+	"he":    {Decimal: ".", Group: ",", DateFormat: "02.01.2006", TimeFormat: "15:04"},
+	"C":     {Decimal: ".", Group: "", DateFormat: "2006-01-02", TimeFormat: "15:04"},
+}
+
+// NormalizeLocale converts locale tags like "de-DE.UTF-8" or "de_de" to "de_DE"
+// Returns "C" for empty, "C", and "POSIX" locales
+func NormalizeLocale(locale string) string {
+	locale = strings.TrimSpace(locale)
+	// Strip encoding and modifier (e.g. ".UTF-8", "@euro")
+	if idx := strings.IndexAny(locale, ".@"); idx >= 0 {
+		locale = locale[:idx]
+	}
+	if locale == "" || locale == "C" || locale == "POSIX" {
+		return "C"
+	}
+	locale = strings.ReplaceAll(locale, "-", "_")
+	parts := strings.SplitN(locale, "_", 2)
+	lang := strings.ToLower(parts[0])
+	if len(parts) == 1 {
+		return lang
+	}
+	return lang + "_" + strings.ToUpper(parts[1])
+}
+
+// DetectLocale returns the user's locale from the environment
+// Checks LC_ALL, LC_MESSAGES, and LANG in that order, defaulting to "en_US"
+func DetectLocale() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(name); v != "" {
+			if norm := NormalizeLocale(v); norm != "C" {
+				return norm
+			}
+		}
+	}
+	return "en_US"
+}
+
+// GetLocaleInfo returns the formatting conventions for a locale
+// Falls back from the full tag to the language, then to "en"
+func GetLocaleInfo(locale string) LocaleInfo {
+	locale = NormalizeLocale(locale)
+	if info, ok := localeTable[locale]; ok {
+		return info
+	}
+	if idx := strings.Index(locale, "_"); idx > 0 {
+		if info, ok := localeTable[locale[:idx]]; ok {
+			return info
+		}
+	}
+	return localeTable["en"]
+}
+
+// FormatNumber formats a number with the locale's decimal and group separators
+// decimals < 0 means use the shortest representation that round-trips
+func FormatNumber(n float64, decimals int, locale string) string {
+	info := GetLocaleInfo(locale)
+	if math.IsNaN(n) || math.IsInf(n, 0) {
+		return strconv.FormatFloat(n, 'f', -1, 64)
+	}
+
+	str := strconv.FormatFloat(math.Abs(n), 'f', decimals, 64)
+	intPart, fracPart := str, ""
+	if idx := strings.Index(str, "."); idx >= 0 {
+		intPart, fracPart = str[:idx], str[idx+1:]
+	}
+
+	var sb strings.Builder
+	if n < 0 && strings.Trim(str, "0.") != "" {
+		sb.WriteString("-")
+	}
+	for i, r := range intPart {
+		if i > 0 && info.Group != "" && (len(intPart)-i)%3 == 0 {
+			sb.WriteString(info.Group)
+		}
+		sb.WriteRune(r)
+	}
+	if fracPart != "" {
+		sb.WriteString(info.Decimal)
+		sb.WriteString(fracPart)
+	}
+	return sb.String()
+}
+
+// FormatDate formats a time using the locale's conventions
+// style is "date", "time", or "datetime"
+func FormatDate(t time.Time, style, locale string) (string, error) {
+	info := GetLocaleInfo(locale)
+	switch style {
+	case "", "date":
+		return t.Format(info.DateFormat), nil
+	case "time":
+		return t.Format(info.TimeFormat), nil
+	case "datetime":
+		return t.Format(info.DateFormat + " " + info.TimeFormat), nil
+	}
+	return "", fmt.Errorf("unknown date style '%s' (expected date, time, or datetime)", style)
+}
+
+// Catalog is a message catalog mapping keys to localized strings
+// Catalogs are loaded from PSL files so scripts and host applications can share them
+// Nested sections are flattened with dots: (menu: (file: "Datei")) defines "menu.file"
+type Catalog struct {
+	mu       sync.RWMutex
+	locale   string
+	messages map[string]string
+}
+
+// NewCatalog creates an empty message catalog for a locale
+func NewCatalog(locale string) *Catalog {
+	return &Catalog{
+		locale:   NormalizeLocale(locale),
+		messages: make(map[string]string),
+	}
+}
+
+// Locale returns the catalog's locale
+func (c *Catalog) Locale() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.locale
+}
+
+// SetLocale changes the catalog's locale (used for number and date formatting)
+func (c *Catalog) SetLocale(locale string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.locale = NormalizeLocale(locale)
+}
+
+// LoadPSL merges messages from PSL content into the catalog
+// Later loads override earlier keys, so a base catalog can be layered with a locale catalog
+func (c *Catalog) LoadPSL(content string) error {
+	m, err := ParsePSL(content)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	flattenCatalog(c.messages, "", m)
+	return nil
+}
+
+// LoadFile merges messages from a PSL catalog file
+func (c *Catalog) LoadFile(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return c.LoadPSL(string(content))
+}
+
+// flattenCatalog copies PSL entries into dest, joining nested keys with dots
+func flattenCatalog(dest map[string]string, prefix string, m PSLMap) {
+	for key, value := range m {
+		fullKey := key
+		if prefix != "" {
+			fullKey = prefix + "." + key
+		}
+		if nested, ok := value.(PSLMap); ok {
+			flattenCatalog(dest, fullKey, nested)
+			continue
+		}
+		if value == nil {
+			continue
+		}
+		dest[fullKey] = fmt.Sprintf("%v", value)
+	}
+}
+
+// Lookup returns the message for a key and whether it was found
+func (c *Catalog) Lookup(key string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	msg, ok := c.messages[key]
+	return msg, ok
+}
+
+// Keys returns all message keys in sorted order
+func (c *Catalog) Keys() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	keys := make([]string, 0, len(c.messages))
+	for k := range c.messages {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Clear removes all messages from the catalog
+func (c *Catalog) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = make(map[string]string)
+}
+
+// Tr translates a key, substituting {1}, {2}, ... with positional args
+// and {name} with named args. Missing keys translate to the key itself.
+func (c *Catalog) Tr(key string, args []string, named map[string]string) string {
+	msg, ok := c.Lookup(key)
+	if !ok {
+		msg = key
+	}
+	if len(args) == 0 && len(named) == 0 {
+		return msg
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(msg); i++ {
+		if msg[i] == '{' {
+			if end := strings.IndexByte(msg[i+1:], '}'); end >= 0 {
+				name := msg[i+1 : i+1+end]
+				if n, err := strconv.Atoi(name); err == nil && n >= 1 && n <= len(args) {
+					sb.WriteString(args[n-1])
+					i += end + 1
+					continue
+				}
+				if v, ok := named[name]; ok {
+					sb.WriteString(v)
+					i += end + 1
+					continue
+				}
+			}
+		}
+		sb.WriteByte(msg[i])
+	}
+	return sb.String()
+}
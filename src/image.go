@@ -0,0 +1,103 @@
+package pawscript
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+)
+
+// StoredImage is a mutable RGBA image value
+// Like files and channels, images are passed by reference: pixel writes through
+// any reference are visible through all of them
+type StoredImage struct {
+	mu  sync.RWMutex
+	img *image.NRGBA
+}
+
+// MaxImageSize caps each dimension of an image scripts create, in pixels
+const MaxImageSize = 16384
+
+// checkImageSize returns an error unless width and height are positive and within
+// MaxImageSize
+func checkImageSize(width, height int64) error {
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("width and height must be positive")
+	}
+	if width > MaxImageSize || height > MaxImageSize {
+		return fmt.Errorf("width and height must be at most %d", MaxImageSize)
+	}
+	return nil
+}
+
+// NewStoredImage creates a transparent image of the given size
+func NewStoredImage(width, height int) *StoredImage {
+	return &StoredImage{img: image.NewNRGBA(image.Rect(0, 0, width, height))}
+}
+
+// NewStoredImageFrom copies any image into a new StoredImage
+func NewStoredImageFrom(src image.Image) *StoredImage {
+	b := src.Bounds()
+	img := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(img, img.Bounds(), src, b.Min, draw.Src)
+	return &StoredImage{img: img}
+}
+
+// Size returns the image dimensions
+func (si *StoredImage) Size() (width, height int) {
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+	b := si.img.Bounds()
+	return b.Dx(), b.Dy()
+}
+
+// Pixel returns the color at (x, y) and whether the point is inside the image
+func (si *StoredImage) Pixel(x, y int) (color.NRGBA, bool) {
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+	if !(image.Point{x, y}.In(si.img.Bounds())) {
+		return color.NRGBA{}, false
+	}
+	return si.img.NRGBAAt(x, y), true
+}
+
+// SetPixel sets the color at (x, y); points outside the image are ignored
+func (si *StoredImage) SetPixel(x, y int, c color.NRGBA) bool {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	if !(image.Point{x, y}.In(si.img.Bounds())) {
+		return false
+	}
+	si.img.SetNRGBA(x, y, c)
+	return true
+}
+
+// Fill sets every pixel to a color
+func (si *StoredImage) Fill(c color.NRGBA) {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	draw.Draw(si.img, si.img.Bounds(), &image.Uniform{C: c}, image.Point{}, draw.Src)
+}
+
+// Snapshot returns a copy of the image for encoding or rendering
+func (si *StoredImage) Snapshot() *image.NRGBA {
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+	img := image.NewNRGBA(si.img.Bounds())
+	copy(img.Pix, si.img.Pix)
+	return img
+}
+
+// String returns a display form that doesn't dump pixel data
+func (si *StoredImage) String() string {
+	w, h := si.Size()
+	return fmt.Sprintf("<image %dx%d>", w, h)
+}
+
+// Edit runs fn with exclusive access to the pixels, for drawing operations
+func (si *StoredImage) Edit(fn func(img *image.NRGBA)) {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	fn(si.img)
+}
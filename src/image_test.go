@@ -0,0 +1,24 @@
+package pawscript
+
+import "testing"
+
+func TestImageSizeCap(t *testing.T) {
+	ps := New(&Config{})
+	ps.RegisterStandardLibrary([]string{})
+	for _, tt := range []struct {
+		script string
+		want   bool
+	}{
+		{"IMPORT image; image_new 16, 16", true},
+		{"IMPORT image; image_new 16384, 1", true},
+		{"IMPORT image; image_new 16385, 1", false},
+		{"IMPORT image; image_new 1, 100000000", false},
+		{"IMPORT image; image_new 0, 1", false},
+		{"IMPORT image; image_resize {image_new 4, 4}, 100000, 100000", false},
+		{"IMPORT canvas; canvas 100000, 100000", false},
+	} {
+		if got := ps.Execute(tt.script); got != BoolStatus(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.script, got, tt.want)
+		}
+	}
+}
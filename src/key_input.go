@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"sync"
 	"time"
 	"unicode/utf8"
@@ -572,6 +573,10 @@ func (m *KeyInputManager) couldBeEscapePrefix(seq string) bool {
 			return true
 		}
 	}
+	// Legacy mouse reports are ESC [ M followed by three raw bytes
+	if len(seq) >= 3 && seq[:3] == "\x1b[M" {
+		return len(seq) < 6
+	}
 	// Also allow CSI sequences in progress: ESC [ ...
 	if len(seq) >= 2 && seq[0] == 0x1b && seq[1] == '[' {
 		// CSI sequence - wait for terminator
@@ -981,6 +986,26 @@ func (m *KeyInputManager) parseModifiedCSI(seq string) (string, bool) {
 		return "S-Tab", true
 	}
 
+	// Mouse reports: ESC [ M Cb Cx Cy (legacy) or ESC [ < Cb ; Cx ; Cy M/m (SGR)
+	if len(body) == 4 && body[0] == 'M' {
+		return formatMouseKey(int(body[1])-32, int(body[2])-32, int(body[3])-32, false)
+	}
+	if body[0] == '<' && len(body) > 1 && (body[len(body)-1] == 'M' || body[len(body)-1] == 'm') {
+		parts := splitCSIParams(body[1 : len(body)-1])
+		if len(parts) != 3 {
+			return "", false
+		}
+		nums := make([]int, 3)
+		for i, part := range parts {
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return "", false
+			}
+			nums[i] = n
+		}
+		return formatMouseKey(nums[0], nums[1], nums[2], body[len(body)-1] == 'm')
+	}
+
 	// Final byte determines the key type
 	finalByte := body[len(body)-1]
 	if finalByte < 0x40 || finalByte > 0x7E {
@@ -1013,6 +1038,46 @@ func (m *KeyInputManager) parseModifiedCSI(seq string) (string, bool) {
 	return "", false
 }
 
+// formatMouseKey names a mouse report as a key: "MouseLeft:10:5" for a press at
+// column 10, row 5 (1-based), with "Up" or "Drag" after the button name for releases
+// and motion while held, "MouseMove" for motion with no button, "WheelUp",
+// "WheelDown", "WheelLeft" and "WheelRight" for the wheel, and "MouseUp" for a
+// legacy release (which doesn't say which button). Modifiers prefix the name as
+// for keys, e.g. "C-MouseLeft:10:5".
+func formatMouseKey(code, col, row int, release bool) (string, bool) {
+	if col < 1 || row < 1 {
+		return "", false
+	}
+	buttons := [...]string{"Left", "Middle", "Right"}
+	button := code & 3
+	var name string
+	switch {
+	case code&64 != 0:
+		name = [...]string{"WheelUp", "WheelDown", "WheelLeft", "WheelRight"}[button]
+	case code&32 != 0 && button == 3:
+		name = "MouseMove"
+	case code&32 != 0:
+		name = "Mouse" + buttons[button] + "Drag"
+	case button == 3:
+		name = "MouseUp"
+	case release:
+		name = "Mouse" + buttons[button] + "Up"
+	default:
+		name = "Mouse" + buttons[button]
+	}
+	mod := 1
+	if code&4 != 0 { // Shift
+		mod += 1
+	}
+	if code&8 != 0 { // Alt
+		mod += 2
+	}
+	if code&16 != 0 { // Ctrl
+		mod += 4
+	}
+	return fmt.Sprintf("%s%s:%d:%d", modifierPrefix(mod), name, col, row), true
+}
+
 // parseKittyProtocol handles CSI keycode ; mod u format (kitty keyboard protocol)
 // This format encodes special keys with full modifier information
 func parseKittyProtocol(parts []string) (string, bool) {
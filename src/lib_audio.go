@@ -0,0 +1,292 @@
+package pawscript
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+)
+
+// audioSampleRate is the output sample rate for tones and resampled WAV files
+const audioSampleRate = 44100
+
+// maxToneMs caps the duration of a tone, so a long one can't allocate unbounded PCM
+const maxToneMs = 60000
+
+// generateTone returns a sine tone as 16-bit stereo PCM
+// A short fade in and out keeps the tone from clicking
+func generateTone(freq float64, ms int, volume float64) []byte {
+	samples := audioSampleRate * ms / 1000
+	fade := audioSampleRate / 200 // 5ms
+	if fade > samples/2 {
+		fade = samples / 2
+	}
+
+	pcm := make([]byte, samples*4)
+	for i := 0; i < samples; i++ {
+		amp := volume
+		if i < fade {
+			amp *= float64(i) / float64(fade)
+		} else if i >= samples-fade {
+			amp *= float64(samples-i) / float64(fade)
+		}
+		v := int16(math.Sin(2*math.Pi*freq*float64(i)/audioSampleRate) * amp * math.MaxInt16)
+		binary.LittleEndian.PutUint16(pcm[i*4:], uint16(v))
+		binary.LittleEndian.PutUint16(pcm[i*4+2:], uint16(v))
+	}
+	return pcm
+}
+
+// decodeWAV converts a WAV file to 16-bit stereo PCM at audioSampleRate
+// Supports integer PCM (8, 16, 24, 32-bit) and 32-bit float, mono or stereo
+func decodeWAV(data []byte, volume float64) ([]byte, error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("not a WAV file")
+	}
+
+	var format, channels, bits int
+	var rate int
+	var samples []byte
+	haveFmt := false
+	for pos := 12; pos+8 <= len(data); {
+		id := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		body := data[pos+8:]
+		if size > len(body) {
+			size = len(body)
+		}
+		body = body[:size]
+
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return nil, fmt.Errorf("invalid fmt chunk")
+			}
+			format = int(binary.LittleEndian.Uint16(body[0:2]))
+			channels = int(binary.LittleEndian.Uint16(body[2:4]))
+			rate = int(binary.LittleEndian.Uint32(body[4:8]))
+			bits = int(binary.LittleEndian.Uint16(body[14:16]))
+			// WAVE_FORMAT_EXTENSIBLE stores the real format in its subformat GUID
+			if format == 0xFFFE && size >= 26 {
+				format = int(binary.LittleEndian.Uint16(body[24:26]))
+			}
+			haveFmt = true
+		case "data":
+			samples = body
+		}
+		pos += 8 + size + size%2 // chunks are word-aligned
+	}
+
+	if !haveFmt || samples == nil {
+		return nil, fmt.Errorf("missing fmt or data chunk")
+	}
+	if channels < 1 || channels > 2 || rate <= 0 {
+		return nil, fmt.Errorf("unsupported WAV layout: %d channels at %d Hz", channels, rate)
+	}
+
+	// Decode one sample at a byte offset to the range [-1, 1]
+	var decode func(b []byte) float64
+	switch {
+	case format == 1 && bits == 8:
+		decode = func(b []byte) float64 { return (float64(b[0]) - 128) / 128 }
+	case format == 1 && bits == 16:
+		decode = func(b []byte) float64 { return float64(int16(binary.LittleEndian.Uint16(b))) / 32768 }
+	case format == 1 && bits == 24:
+		decode = func(b []byte) float64 {
+			return float64(int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24)>>8) / 8388608
+		}
+	case format == 1 && bits == 32:
+		decode = func(b []byte) float64 { return float64(int32(binary.LittleEndian.Uint32(b))) / 2147483648 }
+	case format == 3 && bits == 32:
+		decode = func(b []byte) float64 { return float64(math.Float32frombits(binary.LittleEndian.Uint32(b))) }
+	default:
+		return nil, fmt.Errorf("unsupported WAV encoding: format %d, %d bits", format, bits)
+	}
+
+	frameSize := channels * bits / 8
+	frames := len(samples) / frameSize
+	if frames == 0 {
+		return nil, nil
+	}
+	sampleAt := func(frame, channel int) float64 {
+		if frame >= frames {
+			frame = frames - 1
+		}
+		return decode(samples[frame*frameSize+(channel%channels)*bits/8:])
+	}
+
+	// Resample with linear interpolation to the output rate
+	outFrames := int(int64(frames) * audioSampleRate / int64(rate))
+	pcm := make([]byte, outFrames*4)
+	step := float64(rate) / audioSampleRate
+	for i := 0; i < outFrames; i++ {
+		src := float64(i) * step
+		frame := int(src)
+		frac := src - float64(frame)
+		for c := 0; c < 2; c++ {
+			v := sampleAt(frame, c)*(1-frac) + sampleAt(frame+1, c)*frac
+			v = math.Max(-1, math.Min(1, v*volume))
+			binary.LittleEndian.PutUint16(pcm[i*4+c*2:], uint16(int16(v*math.MaxInt16)))
+		}
+	}
+	return pcm, nil
+}
+
+// RegisterAudioLib registers sound commands
+// This library is NOT auto-imported - users must explicitly use IMPORT audio.
+// Sound output requires building with -tags audio; otherwise beep falls back to
+// the terminal bell and the other commands fail.
+// Module: audio
+func (ps *PawScript) RegisterAudioLib() {
+	var volumeMu sync.Mutex
+	volume := 1.0
+
+	// Helper to get the playback volume: volume: N (0-100) overrides the current setting
+	volumeFor := func(ctx *Context) (float64, bool) {
+		if volVal, exists := ctx.NamedArgs["volume"]; exists {
+			v, ok := toNumber(ctx.executor.resolveValue(volVal))
+			if !ok || v < 0 || v > 100 {
+				ctx.LogError(CatArgument, "volume must be a number from 0 to 100")
+				return 0, false
+			}
+			return v / 100, true
+		}
+		volumeMu.Lock()
+		defer volumeMu.Unlock()
+		return volume, true
+	}
+
+	// Helper to play PCM, either blocking or in the background
+	// With async: true, returns a channel that receives (event: "done") when playback
+	// finishes, or (event: "error", message: "...") if it fails
+	play := func(ctx *Context, name string, pcm []byte) Result {
+		if asyncVal, exists := ctx.NamedArgs["async"]; !exists || !toBool(asyncVal) {
+			if err := playPCM(pcm); err != nil {
+				ctx.LogError(CatIO, fmt.Sprintf("%s: %v", name, err))
+				return BoolStatus(false)
+			}
+			return BoolStatus(true)
+		}
+
+		ch := NewStoredChannel(1)
+		executor := ctx.executor
+		go func() {
+			event := map[string]interface{}{"event": QuotedString("done")}
+			if err := playPCM(pcm); err != nil {
+				event = map[string]interface{}{
+					"event":   QuotedString("error"),
+					"message": QuotedString(err.Error()),
+				}
+			}
+			ref := executor.RegisterObject(NewStoredListWithNamed(nil, event), ObjList)
+			if err := ChannelSend(ch, ref); err != nil {
+				ps.logger.DebugCat(CatIO, "%s: dropped completion event: %v", name, err)
+			}
+		}()
+
+		chRef := ctx.executor.RegisterObject(ch, ObjChannel)
+		ctx.state.SetResult(chRef)
+		return BoolStatus(true)
+	}
+
+	// ==================== audio:: module ====================
+
+	// volume - get or set the playback volume
+	// Usage: volume         -> returns current volume (0-100)
+	//        volume 50      -> sets volume to 50%
+	ps.RegisterCommandInModule("audio", "volume", func(ctx *Context) Result {
+		volumeMu.Lock()
+		defer volumeMu.Unlock()
+		if len(ctx.Args) > 0 {
+			v, ok := toNumber(ctx.executor.resolveValue(ctx.Args[0]))
+			if !ok || v < 0 || v > 100 {
+				ctx.LogError(CatArgument, "volume must be a number from 0 to 100")
+				ctx.SetResult(nil)
+				return BoolStatus(false)
+			}
+			volume = v / 100
+		}
+		ctx.SetResult(volume * 100)
+		return BoolStatus(true)
+	})
+
+	// beep - short alert sound
+	// Usage: beep [, volume: N] [, async: true]
+	// Without audio support, writes the terminal bell character to #out instead
+	ps.RegisterCommandInModule("audio", "beep", func(ctx *Context) Result {
+		if !audioAvailable {
+			if err := NewOutputContext(ctx.state, ctx.executor).WriteToOut("\a"); err != nil {
+				ctx.LogError(CatIO, fmt.Sprintf("beep: %v", err))
+				return BoolStatus(false)
+			}
+			return BoolStatus(true)
+		}
+		vol, ok := volumeFor(ctx)
+		if !ok {
+			return BoolStatus(false)
+		}
+		return play(ctx, "beep", generateTone(880, 120, vol))
+	})
+
+	// play_tone - play a sine tone
+	// Usage: play_tone <freq_hz>, <ms> [, volume: N] [, async: true]
+	// Durations over a minute are shortened to one
+	ps.RegisterCommandInModule("audio", "play_tone", func(ctx *Context) Result {
+		if len(ctx.Args) < 2 {
+			ctx.LogError(CatCommand, "Usage: play_tone <freq_hz>, <ms> [, volume: N] [, async: true]")
+			return BoolStatus(false)
+		}
+		freq, ok := toNumber(ctx.executor.resolveValue(ctx.Args[0]))
+		if !ok || freq <= 0 || freq > audioSampleRate/2 {
+			ctx.LogError(CatArgument, fmt.Sprintf("play_tone: invalid frequency: %v", ctx.Args[0]))
+			return BoolStatus(false)
+		}
+		ms, ok := toInt64(ctx.executor.resolveValue(ctx.Args[1]))
+		if !ok || ms < 0 {
+			ctx.LogError(CatArgument, fmt.Sprintf("play_tone: invalid duration: %v", ctx.Args[1]))
+			return BoolStatus(false)
+		}
+		vol, ok := volumeFor(ctx)
+		if !ok {
+			return BoolStatus(false)
+		}
+		if ms > maxToneMs {
+			ms = maxToneMs
+		}
+		return play(ctx, "play_tone", generateTone(freq, int(ms), vol))
+	})
+
+	// play_wav - play a WAV file
+	// Usage: play_wav <path> [, volume: N] [, async: true]
+	// Supports PCM (8/16/24/32-bit) and 32-bit float WAV files, mono or stereo
+	ps.RegisterCommandInModule("audio", "play_wav", func(ctx *Context) Result {
+		if len(ctx.Args) < 1 {
+			ctx.LogError(CatCommand, "Usage: play_wav <path> [, volume: N] [, async: true]")
+			return BoolStatus(false)
+		}
+
+		path := fmt.Sprintf("%v", ctx.executor.resolveValue(ctx.Args[0]))
+		absPath, err := ps.checkPathAccess(ctx, "play_wav", path, false)
+		if err != nil {
+			ctx.LogError(CatIO, fmt.Sprintf("play_wav: %v", err))
+			return BoolStatus(false)
+		}
+		data, err := os.ReadFile(absPath)
+		if err != nil {
+			ctx.LogError(CatIO, fmt.Sprintf("play_wav: %v", err))
+			return BoolStatus(false)
+		}
+
+		vol, ok := volumeFor(ctx)
+		if !ok {
+			return BoolStatus(false)
+		}
+		pcm, err := decodeWAV(data, vol)
+		if err != nil {
+			ctx.LogError(CatArgument, fmt.Sprintf("play_wav: %v", err))
+			return BoolStatus(false)
+		}
+		return play(ctx, "play_wav", pcm)
+	})
+}
@@ -0,0 +1,215 @@
+package pawscript
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// RegisterCanvasLib registers drawing commands and terminal rendering for images
+// This library is NOT auto-imported - users must explicitly use IMPORT canvas.
+// Canvases are ordinary image values, so image:: commands work on them too.
+// Module: canvas
+func (ps *PawScript) RegisterCanvasLib() {
+	// Helper function to set a StoredImage as result with proper reference counting
+	setImageResult := func(ctx *Context, img *StoredImage) {
+		ref := ctx.executor.RegisterObject(img, ObjImage)
+		ctx.state.SetResultWithoutClaim(ref)
+	}
+
+	// Helper to read the canvas, the integer arguments after it, and the color in the last argument
+	// Every drawing command has the form: <cmd> <canvas>, <n1>, ..., <nN>, [<text>,] <color>
+	drawArgs := func(ctx *Context, cmd, usage string, count, total int) (*StoredImage, []int, color.NRGBA, bool) {
+		if len(ctx.Args) < total {
+			ctx.LogError(CatCommand, "Usage: "+usage)
+			return nil, nil, color.NRGBA{}, false
+		}
+		img, ok := ctx.executor.resolveValue(ctx.Args[0]).(*StoredImage)
+		if !ok {
+			ctx.LogError(CatType, fmt.Sprintf("%s: first argument must be a canvas", cmd))
+			return nil, nil, color.NRGBA{}, false
+		}
+		values := make([]int, count)
+		for i := range values {
+			n, ok := toInt64(ctx.executor.resolveValue(ctx.Args[i+1]))
+			if !ok {
+				ctx.LogError(CatArgument, fmt.Sprintf("%s: expected integer, got %v", cmd, ctx.Args[i+1]))
+				return nil, nil, color.NRGBA{}, false
+			}
+			values[i] = int(n)
+		}
+		c, err := parseColorValue(ctx.Args[total-1], ctx.executor)
+		if err != nil {
+			ctx.LogError(CatArgument, fmt.Sprintf("%s: %v", cmd, err))
+			return nil, nil, color.NRGBA{}, false
+		}
+		return img, values, c, true
+	}
+
+	// Helper to check the fill: option
+	fillFor := func(ctx *Context) bool {
+		fillVal, exists := ctx.NamedArgs["fill"]
+		return exists && toBool(fillVal)
+	}
+
+	// Helper to render a canvas for the terminal behind #out
+	// mode: blocks (half-block characters), sixel, or auto (sixel when the terminal supports it)
+	render := func(ctx *Context, cmd string) (string, bool) {
+		if len(ctx.Args) < 1 {
+			ctx.LogError(CatCommand, fmt.Sprintf("Usage: %s <canvas> [, mode: auto|blocks|sixel]", cmd))
+			return "", false
+		}
+		img, ok := ctx.executor.resolveValue(ctx.Args[0]).(*StoredImage)
+		if !ok {
+			ctx.LogError(CatType, fmt.Sprintf("%s: first argument must be a canvas", cmd))
+			return "", false
+		}
+
+		caps := NewOutputContext(ctx.state, ctx.executor).ResolveChannel("#out").GetTerminalCapabilities()
+		caps.mu.RLock()
+		sixel, trueColor := caps.SupportsSixel, caps.ColorDepth == 24
+		caps.mu.RUnlock()
+
+		mode := "auto"
+		if modeVal, exists := ctx.NamedArgs["mode"]; exists {
+			mode = fmt.Sprintf("%v", ctx.executor.resolveValue(modeVal))
+		}
+		switch mode {
+		case "auto":
+		case "blocks":
+			sixel = false
+		case "sixel":
+			sixel = true
+		default:
+			ctx.LogError(CatArgument, fmt.Sprintf("%s: unknown mode: %s (use auto, blocks, or sixel)", cmd, mode))
+			return "", false
+		}
+
+		if sixel {
+			return renderSixel(img.Snapshot()), true
+		}
+		return renderHalfBlocks(img.Snapshot(), trueColor), true
+	}
+
+	// ==================== canvas:: module ====================
+
+	// canvas - create a canvas to draw on
+	// Usage: canvas <width>, <height> [, bg: <color>]
+	// Colors are "#rrggbb[aa]" or lists (r, g, b [, a]); the default background is transparent
+	ps.RegisterCommandInModule("canvas", "canvas", func(ctx *Context) Result {
+		if len(ctx.Args) < 2 {
+			ctx.LogError(CatCommand, "Usage: canvas <width>, <height> [, bg: <color>]")
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		w, okW := toInt64(ctx.executor.resolveValue(ctx.Args[0]))
+		h, okH := toInt64(ctx.executor.resolveValue(ctx.Args[1]))
+		if !okW || !okH {
+			ctx.LogError(CatArgument, "canvas: width and height must be integers")
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		if err := checkImageSize(w, h); err != nil {
+			ctx.LogError(CatArgument, fmt.Sprintf("canvas: %v", err))
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+
+		img := NewStoredImage(int(w), int(h))
+		if bgVal, exists := ctx.NamedArgs["bg"]; exists {
+			c, err := parseColorValue(bgVal, ctx.executor)
+			if err != nil {
+				ctx.LogError(CatArgument, fmt.Sprintf("canvas: %v", err))
+				ctx.SetResult(nil)
+				return BoolStatus(false)
+			}
+			img.Fill(c)
+		}
+		setImageResult(ctx, img)
+		return BoolStatus(true)
+	})
+
+	// draw_line - draw a line between two points
+	// Usage: draw_line <canvas>, <x0>, <y0>, <x1>, <y1>, <color>
+	ps.RegisterCommandInModule("canvas", "draw_line", func(ctx *Context) Result {
+		img, n, c, ok := drawArgs(ctx, "draw_line", "draw_line <canvas>, <x0>, <y0>, <x1>, <y1>, <color>", 4, 6)
+		if !ok {
+			return BoolStatus(false)
+		}
+		img.Edit(func(dst *image.NRGBA) { drawLine(dst, n[0], n[1], n[2], n[3], c) })
+		return BoolStatus(true)
+	})
+
+	// draw_rect - draw a rectangle
+	// Usage: draw_rect <canvas>, <x>, <y>, <width>, <height>, <color> [, fill: true]
+	ps.RegisterCommandInModule("canvas", "draw_rect", func(ctx *Context) Result {
+		img, n, c, ok := drawArgs(ctx, "draw_rect", "draw_rect <canvas>, <x>, <y>, <width>, <height>, <color> [, fill: true]", 4, 6)
+		if !ok {
+			return BoolStatus(false)
+		}
+		fill := fillFor(ctx)
+		img.Edit(func(dst *image.NRGBA) { drawRect(dst, n[0], n[1], n[2], n[3], c, fill) })
+		return BoolStatus(true)
+	})
+
+	// draw_circle - draw a circle around a center point
+	// Usage: draw_circle <canvas>, <cx>, <cy>, <radius>, <color> [, fill: true]
+	// The radius is at most 16777216
+	ps.RegisterCommandInModule("canvas", "draw_circle", func(ctx *Context) Result {
+		img, n, c, ok := drawArgs(ctx, "draw_circle", "draw_circle <canvas>, <cx>, <cy>, <radius>, <color> [, fill: true]", 3, 5)
+		if !ok {
+			return BoolStatus(false)
+		}
+		if n[2] > maxCircleRadius {
+			ctx.LogError(CatArgument, fmt.Sprintf("draw_circle: radius must be at most %d", maxCircleRadius))
+			return BoolStatus(false)
+		}
+		fill := fillFor(ctx)
+		img.Edit(func(dst *image.NRGBA) { drawCircle(dst, n[0], n[1], n[2], c, fill) })
+		return BoolStatus(true)
+	})
+
+	// draw_text - draw text in the built-in 7x13 pixel font
+	// Usage: draw_text <canvas>, <x>, <y>, <text>, <color>
+	// (x, y) is the top-left corner of the text; returns the text width in pixels
+	ps.RegisterCommandInModule("canvas", "draw_text", func(ctx *Context) Result {
+		img, n, c, ok := drawArgs(ctx, "draw_text", "draw_text <canvas>, <x>, <y>, <text>, <color>", 2, 5)
+		if !ok {
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		text := formatArgForDisplay(ctx.Args[3], ctx.executor)
+		var width int
+		img.Edit(func(dst *image.NRGBA) { width = drawText(dst, n[0], n[1], text, c) })
+		ctx.SetResult(int64(width))
+		return BoolStatus(true)
+	})
+
+	// canvas_render - render a canvas as terminal output without printing it
+	// Usage: canvas_render <canvas> [, mode: auto|blocks|sixel]
+	// blocks uses half-block characters (two pixels per cell) with 24-bit or 256 colors
+	ps.RegisterCommandInModule("canvas", "canvas_render", func(ctx *Context) Result {
+		out, ok := render(ctx, "canvas_render")
+		if !ok {
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		ctx.SetResult(out)
+		return BoolStatus(true)
+	})
+
+	// canvas_show - draw a canvas on the terminal
+	// Usage: canvas_show <canvas> [, mode: auto|blocks|sixel]
+	// auto uses sixel graphics when the terminal supports them, otherwise half-blocks
+	ps.RegisterCommandInModule("canvas", "canvas_show", func(ctx *Context) Result {
+		out, ok := render(ctx, "canvas_show")
+		if !ok {
+			return BoolStatus(false)
+		}
+		if err := NewOutputContext(ctx.state, ctx.executor).WriteToOut(out); err != nil {
+			ctx.LogError(CatIO, fmt.Sprintf("canvas_show: %v", err))
+			return BoolStatus(false)
+		}
+		return BoolStatus(true)
+	})
+}
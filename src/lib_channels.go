@@ -3,6 +3,7 @@ package pawscript
 import (
 	"fmt"
 	"strings"
+	"time"
 )
 
 // getChannelFromArg extracts a *StoredChannel from an argument
@@ -35,19 +36,51 @@ func getChannelFromArg(arg interface{}, executor *Executor) *StoredChannel {
 	return nil
 }
 
+// resolveChannelArg resolves a command argument to a channel
+// Accepts anything getChannelFromArg does after tilde resolution, plus #-names like #out
+func resolveChannelArg(ctx *Context, arg interface{}) *StoredChannel {
+	if ch := getChannelFromArg(ctx.executor.resolveValue(arg), ctx.executor); ch != nil {
+		return ch
+	}
+	if sym, ok := arg.(Symbol); ok && strings.HasPrefix(string(sym), "#") {
+		return NewOutputContext(ctx.state, ctx.executor).ResolveChannel(string(sym))
+	}
+	return nil
+}
+
+// parseTimeoutArg converts a timeout value to a duration
+// Numbers are milliseconds; strings may also use Go duration units ("500ms", "2s", "1m")
+func parseTimeoutArg(value interface{}) (time.Duration, bool) {
+	if n, ok := toNumber(value); ok {
+		if n < 0 {
+			return 0, false
+		}
+		return time.Duration(n * float64(time.Millisecond)), true
+	}
+	d, err := time.ParseDuration(fmt.Sprintf("%v", value))
+	if err != nil || d < 0 {
+		return 0, false
+	}
+	return d, true
+}
+
 // RegisterChannelsLib registers channel-related commands
 // Module: channels
 func (ps *PawScript) RegisterChannelsLib() {
 
 	// channel - create a native or custom channel
+	// Usage: channel [buffer_size] [, overflow: error|block|drop_oldest|drop_newest] [, journal: <path>]
+	// overflow: sets what sends do when a bounded buffer is full (default: error)
+	// journal: makes the channel durable - queued messages are logged to the file (which must
+	// be under a write root) and messages still unread when the script ended are queued again
 	ps.RegisterCommandInModule("channels", "channel", func(ctx *Context) Result {
 		bufferSize := 0
 		var customSend, customRecv, customClose *StoredMacro
 
 		// Check for buffer size as first positional argument
 		if len(ctx.Args) > 0 {
-			if size, ok := ctx.Args[0].(int); ok {
-				bufferSize = size
+			if size, ok := toInt64(ctx.executor.resolveValue(ctx.Args[0])); ok {
+				bufferSize = int(size)
 			} else if sizeStr, ok := ctx.Args[0].(string); ok {
 				_, _ = fmt.Sscanf(sizeStr, "%d", &bufferSize)
 			}
@@ -70,11 +103,36 @@ func (ps *PawScript) RegisterChannelsLib() {
 			}
 		}
 
+		overflow := OverflowError
+		if overflowVal, ok := ctx.NamedArgs["overflow"]; ok {
+			policy, valid := ParseChannelOverflow(fmt.Sprintf("%v", ctx.executor.resolveValue(overflowVal)))
+			if !valid {
+				ctx.LogError(CatArgument, fmt.Sprintf("channel: unknown overflow policy: %v (use error, block, drop_oldest, or drop_newest)", overflowVal))
+				return BoolStatus(false)
+			}
+			overflow = policy
+		}
+
 		ch := NewStoredChannel(bufferSize)
+		ch.Overflow = overflow
 		ch.CustomSend = customSend
 		ch.CustomRecv = customRecv
 		ch.CustomClose = customClose
 
+		if journalVal, ok := ctx.NamedArgs["journal"]; ok {
+			path, err := ps.checkPathAccess(ctx, "channel", fmt.Sprintf("%v", ctx.executor.resolveValue(journalVal)), true)
+			if err != nil {
+				ctx.LogError(CatIO, fmt.Sprintf("channel: %v", err))
+				return BoolStatus(false)
+			}
+			replayed, err := openChannelJournal(ch, path, ctx.executor, ps.quotas)
+			if err != nil {
+				ctx.LogError(CatIO, fmt.Sprintf("channel: cannot open journal: %v", err))
+				return BoolStatus(false)
+			}
+			ps.logger.DebugCat(CatAsync, "Replayed %d journaled messages from %s", replayed, path)
+		}
+
 		chRef := ctx.executor.RegisterObject(ch, ObjChannel)
 		ctx.state.SetResult(chRef)
 
@@ -200,7 +258,7 @@ func (ps *PawScript) RegisterChannelsLib() {
 			return BoolStatus(false)
 		}
 
-		err := ChannelSend(ch, ctx.Args[1])
+		err := ChannelSendUntil(ch, ctx.Args[1], ps.cancelDone())
 		if err != nil {
 			ps.logger.ErrorCat(CatAsync, "Failed to send: %v", err)
 			return BoolStatus(false)
@@ -209,6 +267,10 @@ func (ps *PawScript) RegisterChannelsLib() {
 		return BoolStatus(true)
 	})
 
+	// channel_recv - receive a message as a (sender_id, value) tuple
+	// Usage: channel_recv <channel> [, timeout: <ms|duration>] [, default: <value>]
+	// With timeout:, waits up to that long for a message. With default:, the result is
+	// that value when no message arrives. Either way status is false if nothing was received.
 	ps.RegisterCommandInModule("channels", "channel_recv", func(ctx *Context) Result {
 		if len(ctx.Args) < 1 {
 			ps.logger.ErrorCat(CatCommand, "Usage: channel_recv <channel>")
@@ -302,6 +364,44 @@ func (ps *PawScript) RegisterChannelsLib() {
 			return BoolStatus(false)
 		}
 
+		// timeout: waits for a message; default: is the result when none arrives
+		// (without timeout:, default: makes the receive non-blocking)
+		timeoutVal, hasTimeout := ctx.NamedArgs["timeout"]
+		defaultVal, hasDefault := ctx.NamedArgs["default"]
+		if hasTimeout || hasDefault {
+			timeout := time.Duration(0)
+			if hasTimeout {
+				d, ok := parseTimeoutArg(ctx.executor.resolveValue(timeoutVal))
+				if !ok {
+					ps.logger.ErrorCat(CatArgument, "channel_recv: invalid timeout: %v", timeoutVal)
+					return BoolStatus(false)
+				}
+				timeout = d
+			}
+
+			senderID, value, err := ChannelRecvUntil(ch, timeout, ps.cancelDone())
+			if err == ErrChannelTimeout {
+				if hasDefault {
+					ctx.state.SetResult(defaultVal)
+				} else {
+					ctx.state.SetResult(nil)
+				}
+				return BoolStatus(false)
+			}
+			if err == ErrCancelled {
+				return BoolStatus(false)
+			}
+			if err != nil {
+				ps.logger.ErrorCat(CatAsync, "Failed to receive: %v", err)
+				return BoolStatus(false)
+			}
+
+			tuple := NewStoredListWithoutRefs([]interface{}{senderID, value})
+			tupleRef := ctx.executor.RegisterObject(tuple, ObjList)
+			ctx.state.SetResult(tupleRef)
+			return BoolStatus(true)
+		}
+
 		senderID, value, err := ChannelRecv(ch)
 		if err != nil {
 			ps.logger.ErrorCat(CatAsync, "Failed to receive: %v", err)
@@ -381,4 +481,321 @@ func (ps *PawScript) RegisterChannelsLib() {
 
 		return BoolStatus(true)
 	})
+	// select - wait on several channels and run the branch for whichever has a message first
+	// Usage: select <channel>, <var>, (body) [, <channel>, <var>, (body) ...]
+	//               [, timeout: <ms|duration>] [, on_timeout: (body)]
+	// The received value is stored in <var> before its body runs; the result and status
+	// are the body's. Closed channels are skipped once their buffered messages are drained.
+	// timeout: 0 makes select non-blocking. On timeout, on_timeout runs if given;
+	// otherwise select returns false. If every channel is closed, select returns false.
+	ps.RegisterCommandInModule("channels", "select", func(ctx *Context) Result {
+		usage := "Usage: select <channel>, <var>, (body) [, ...] [, timeout: <ms>] [, on_timeout: (body)]"
+		if len(ctx.Args) < 3 || len(ctx.Args)%3 != 0 {
+			ctx.LogError(CatCommand, usage)
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+
+		type selectBranch struct {
+			varName string
+			body    string
+		}
+		chs := make([]*StoredChannel, 0, len(ctx.Args)/3)
+		branches := make([]selectBranch, 0, len(ctx.Args)/3)
+		for i := 0; i < len(ctx.Args); i += 3 {
+			ch := resolveChannelArg(ctx, ctx.Args[i])
+			if ch == nil {
+				ctx.LogError(CatArgument, fmt.Sprintf("select: argument %d must be a channel", i+1))
+				ctx.SetResult(nil)
+				return BoolStatus(false)
+			}
+			chs = append(chs, ch)
+			branches = append(branches, selectBranch{
+				varName: fmt.Sprintf("%v", ctx.Args[i+1]),
+				body:    fmt.Sprintf("%v", ctx.Args[i+2]),
+			})
+		}
+
+		timeout := time.Duration(-1)
+		if timeoutVal, exists := ctx.NamedArgs["timeout"]; exists {
+			d, ok := parseTimeoutArg(ctx.executor.resolveValue(timeoutVal))
+			if !ok {
+				ctx.LogError(CatArgument, fmt.Sprintf("select: invalid timeout: %v", timeoutVal))
+				ctx.SetResult(nil)
+				return BoolStatus(false)
+			}
+			timeout = d
+		}
+
+		index, _, value, err := ChannelSelectUntil(chs, timeout, ps.cancelDone())
+		if err == ErrChannelTimeout {
+			if onTimeout, exists := ctx.NamedArgs["on_timeout"]; exists {
+				return ctx.executor.ExecuteWithState(fmt.Sprintf("%v", onTimeout), ctx.state, nil, "", 0, 0)
+			}
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		if err != nil {
+			ps.logger.DebugCat(CatAsync, "select: %v", err)
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+
+		branch := branches[index]
+		ctx.state.SetVariable(branch.varName, value)
+		return ctx.executor.ExecuteWithState(branch.body, ctx.state, nil, "", 0, 0)
+	})
+	// channel_overflow - get or set a channel's overflow policy
+	// Usage: channel_overflow <channel>                 -> returns the current policy
+	//        channel_overflow <channel>, <policy>       -> sets it (error, block, drop_oldest, drop_newest)
+	// Host channels such as the GUI console's #out honor the policy for their output queues
+	ps.RegisterCommandInModule("channels", "channel_overflow", func(ctx *Context) Result {
+		if len(ctx.Args) < 1 {
+			ctx.LogError(CatCommand, "Usage: channel_overflow <channel> [, error|block|drop_oldest|drop_newest]")
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+
+		ch := resolveChannelArg(ctx, ctx.Args[0])
+		if ch == nil {
+			ctx.LogError(CatArgument, "channel_overflow: first argument must be a channel")
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		// Subscribers share their parent's buffer and policy
+		ch = mainChannel(ch)
+
+		ch.mu.Lock()
+		defer ch.mu.Unlock()
+		if len(ctx.Args) > 1 {
+			policy, valid := ParseChannelOverflow(fmt.Sprintf("%v", ctx.executor.resolveValue(ctx.Args[1])))
+			if !valid {
+				ctx.LogError(CatArgument, fmt.Sprintf("channel_overflow: unknown policy: %v (use error, block, drop_oldest, or drop_newest)", ctx.Args[1]))
+				ctx.SetResult(nil)
+				return BoolStatus(false)
+			}
+			ch.Overflow = policy
+		}
+		ctx.SetResult(ch.Overflow.String())
+		return BoolStatus(true)
+	})
+
+	// chan_stats - report a channel's queue depth and message counters
+	// Usage: chan_stats <channel>
+	// Returns (depth:, capacity:, subscribers:, sent:, received:, dropped_oldest:,
+	// dropped_newest:, rejected:, overflow:, closed:); subscribers share their parent's counters
+	ps.RegisterCommandInModule("channels", "chan_stats", func(ctx *Context) Result {
+		if len(ctx.Args) < 1 {
+			ctx.LogError(CatCommand, "Usage: chan_stats <channel>")
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		ch := resolveChannelArg(ctx, ctx.Args[0])
+		if ch == nil {
+			ctx.LogError(CatArgument, "chan_stats: argument must be a channel")
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+
+		stats := ChannelGetStats(ch)
+		result := NewStoredListWithNamed(nil, map[string]interface{}{
+			"depth":          int64(stats.Depth),
+			"capacity":       int64(stats.Capacity),
+			"subscribers":    int64(stats.Subscribers),
+			"sent":           stats.Sent,
+			"received":       stats.Received,
+			"dropped_oldest": stats.DroppedOldest,
+			"dropped_newest": stats.DroppedNewest,
+			"rejected":       stats.Rejected,
+			"overflow":       QuotedString(stats.Overflow.String()),
+			"closed":         stats.Closed,
+		})
+		ref := ctx.executor.RegisterObject(result, ObjList)
+		ctx.state.SetResultWithoutClaim(ref)
+		return BoolStatus(true)
+	})
+
+	// channel_export - let other PawScript processes connect to a channel by name
+	// Usage: channel_export <channel>, <name> [, token_file: <path>]
+	// Clients must present the token in the token file; by default a random token is
+	// written next to the socket, readable only by the current user. Each connected
+	// process acts as a subscriber. Returns the token file path.
+	ps.RegisterCommandInModule("channels", "channel_export", func(ctx *Context) Result {
+		if len(ctx.Args) < 2 {
+			ctx.LogError(CatCommand, "Usage: channel_export <channel>, <name> [, token_file: <path>]")
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		ch := resolveChannelArg(ctx, ctx.Args[0])
+		if ch == nil {
+			ctx.LogError(CatArgument, "channel_export: first argument must be a channel")
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		name := fmt.Sprintf("%v", ctx.executor.resolveValue(ctx.Args[1]))
+
+		tokenPath := ""
+		if tokenVal, ok := ctx.NamedArgs["token_file"]; ok {
+			path, err := ps.checkPathAccess(ctx, "channel_export", fmt.Sprintf("%v", ctx.executor.resolveValue(tokenVal)), true)
+			if err != nil {
+				ctx.LogError(CatIO, fmt.Sprintf("channel_export: %v", err))
+				ctx.SetResult(nil)
+				return BoolStatus(false)
+			}
+			tokenPath = path
+		}
+
+		tokenPath, err := exportChannel(ps, ch, name, tokenPath)
+		if err != nil {
+			ctx.LogError(CatIO, fmt.Sprintf("channel_export: %v", err))
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		ctx.SetResult(tokenPath)
+		return BoolStatus(true)
+	})
+
+	// channel_unexport - stop accepting connections to an exported channel
+	// Usage: channel_unexport <name>
+	// Processes that are already connected stay connected
+	ps.RegisterCommandInModule("channels", "channel_unexport", func(ctx *Context) Result {
+		if len(ctx.Args) < 1 {
+			ctx.LogError(CatCommand, "Usage: channel_unexport <name>")
+			return BoolStatus(false)
+		}
+		if err := unexportChannel(ps, fmt.Sprintf("%v", ctx.executor.resolveValue(ctx.Args[0]))); err != nil {
+			ctx.LogError(CatIO, fmt.Sprintf("channel_unexport: %v", err))
+			return BoolStatus(false)
+		}
+		return BoolStatus(true)
+	})
+
+	// channel_connect - connect to a channel exported by another PawScript process
+	// Usage: channel_connect <name> [, token_file: <path>]
+	// Sends go to the exported channel; receives get what the exporting script sends.
+	// Only plain data (strings, numbers, booleans, nil, and lists of them) can be sent.
+	ps.RegisterCommandInModule("channels", "channel_connect", func(ctx *Context) Result {
+		if len(ctx.Args) < 1 {
+			ctx.LogError(CatCommand, "Usage: channel_connect <name> [, token_file: <path>]")
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		name := fmt.Sprintf("%v", ctx.executor.resolveValue(ctx.Args[0]))
+
+		tokenPath := ""
+		if tokenVal, ok := ctx.NamedArgs["token_file"]; ok {
+			path, err := ps.checkPathAccess(ctx, "channel_connect", fmt.Sprintf("%v", ctx.executor.resolveValue(tokenVal)), false)
+			if err != nil {
+				ctx.LogError(CatIO, fmt.Sprintf("channel_connect: %v", err))
+				ctx.SetResult(nil)
+				return BoolStatus(false)
+			}
+			tokenPath = path
+		}
+
+		ch, err := connectRemoteChannel(name, tokenPath, ctx.executor)
+		if err != nil {
+			ctx.LogError(CatIO, fmt.Sprintf("channel_connect: %v", err))
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		chRef := ctx.executor.RegisterObject(ch, ObjChannel)
+		ctx.state.SetResult(chRef)
+		return BoolStatus(true)
+	})
+
+	// topic_subscribe - subscribe to named topics matching a pattern
+	// Usage: topic_subscribe <pattern> [, buffer: <size>] [, overflow: <policy>]
+	// Patterns are dot-separated: * matches one segment, a final ** matches the rest
+	// (events.ui.* gets events.ui.click but not events.ui.button.click; events.** gets both).
+	// Returns a channel that receives (topic: <name>, value: <value>) lists.
+	ps.RegisterCommandInModule("channels", "topic_subscribe", func(ctx *Context) Result {
+		if len(ctx.Args) < 1 {
+			ctx.LogError(CatCommand, "Usage: topic_subscribe <pattern> [, buffer: <size>] [, overflow: <policy>]")
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		pattern := fmt.Sprintf("%v", ctx.executor.resolveValue(ctx.Args[0]))
+
+		bufferSize := 0
+		if bufVal, ok := ctx.NamedArgs["buffer"]; ok {
+			size, valid := toInt64(ctx.executor.resolveValue(bufVal))
+			if !valid || size < 0 {
+				ctx.LogError(CatArgument, fmt.Sprintf("topic_subscribe: invalid buffer size: %v", bufVal))
+				ctx.SetResult(nil)
+				return BoolStatus(false)
+			}
+			bufferSize = int(size)
+		}
+		overflow := OverflowError
+		if overflowVal, ok := ctx.NamedArgs["overflow"]; ok {
+			policy, valid := ParseChannelOverflow(fmt.Sprintf("%v", ctx.executor.resolveValue(overflowVal)))
+			if !valid {
+				ctx.LogError(CatArgument, fmt.Sprintf("topic_subscribe: unknown overflow policy: %v (use error, block, drop_oldest, or drop_newest)", overflowVal))
+				ctx.SetResult(nil)
+				return BoolStatus(false)
+			}
+			overflow = policy
+		}
+
+		ch, err := ps.topics.Subscribe(pattern, bufferSize)
+		if err != nil {
+			ctx.LogError(CatArgument, fmt.Sprintf("topic_subscribe: %v", err))
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		ch.Overflow = overflow
+
+		chRef := ctx.executor.RegisterObject(ch, ObjChannel)
+		ctx.state.SetResult(chRef)
+		return BoolStatus(true)
+	})
+
+	// topic_publish - send a value to every subscription matching a topic
+	// Usage: topic_publish <topic>, <value>
+	// Returns the number of subscriptions that received it (0 is not an error)
+	ps.RegisterCommandInModule("channels", "topic_publish", func(ctx *Context) Result {
+		if len(ctx.Args) < 2 {
+			ctx.LogError(CatCommand, "Usage: topic_publish <topic>, <value>")
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		topic := fmt.Sprintf("%v", ctx.executor.resolveValue(ctx.Args[0]))
+
+		delivered, err := ps.topics.Publish(topic, ps.topicMessage(topic, ctx.Args[1]))
+		if err != nil {
+			ctx.LogError(CatArgument, fmt.Sprintf("topic_publish: %v", err))
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		ctx.SetResult(int64(delivered))
+		return BoolStatus(true)
+	})
+
+	// topic_unsubscribe - remove a topic subscription and close its channel
+	// Usage: topic_unsubscribe <channel>
+	ps.RegisterCommandInModule("channels", "topic_unsubscribe", func(ctx *Context) Result {
+		if len(ctx.Args) < 1 {
+			ctx.LogError(CatCommand, "Usage: topic_unsubscribe <channel>")
+			return BoolStatus(false)
+		}
+		ch := resolveChannelArg(ctx, ctx.Args[0])
+		if ch == nil || !ps.topics.Unsubscribe(ch) {
+			ctx.LogError(CatArgument, "topic_unsubscribe: argument must be a topic subscription")
+			return BoolStatus(false)
+		}
+		return BoolStatus(true)
+	})
+
+	// topic_list - list the patterns of the open topic subscriptions
+	// Usage: topic_list
+	ps.RegisterCommandInModule("channels", "topic_list", func(ctx *Context) Result {
+		var items []interface{}
+		for _, pattern := range ps.topics.Patterns() {
+			items = append(items, QuotedString(pattern))
+		}
+		ref := ctx.executor.RegisterObject(NewStoredListWithoutRefs(items), ObjList)
+		ctx.state.SetResultWithoutClaim(ref)
+		return BoolStatus(true)
+	})
 }
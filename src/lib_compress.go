@@ -0,0 +1,210 @@
+package pawscript
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Magic numbers used to auto-detect the format of compressed data
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// compressData compresses data with the named format ("gzip" or "zstd")
+// level < 0 selects the format's default level
+func compressData(data []byte, format string, level int) ([]byte, error) {
+	var buf bytes.Buffer
+	switch format {
+	case "gzip", "gz":
+		gzLevel := gzip.DefaultCompression
+		if level >= 0 {
+			gzLevel = level
+		}
+		w, err := gzip.NewWriterLevel(&buf, gzLevel)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	case "zstd", "zst":
+		opts := []zstd.EOption{}
+		if level >= 0 {
+			opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+		}
+		w, err := zstd.NewWriter(&buf, opts...)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown format '%s' (expected gzip or zstd)", format)
+	}
+	return buf.Bytes(), nil
+}
+
+// DefaultMaxDecompressedSize caps what decompress produces unless its max: says otherwise,
+// so a small archive can't expand to fill memory
+const DefaultMaxDecompressedSize = 64 << 20
+
+// decompressData decompresses data in the named format, failing if it comes to more
+// than limit bytes
+// An empty format detects gzip or zstd from the data's magic number
+func decompressData(data []byte, format string, limit int64) ([]byte, error) {
+	if format == "" || format == "auto" {
+		switch {
+		case bytes.HasPrefix(data, gzipMagic):
+			format = "gzip"
+		case bytes.HasPrefix(data, zstdMagic):
+			format = "zstd"
+		default:
+			return nil, fmt.Errorf("unrecognized compression format")
+		}
+	}
+
+	var r io.Reader
+	switch format {
+	case "gzip", "gz":
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	case "zstd", "zst":
+		zr, err := zstd.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	default:
+		return nil, fmt.Errorf("unknown format '%s' (expected gzip or zstd)", format)
+	}
+
+	// Read one byte past the limit to tell data that fills it from data that exceeds it
+	out, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(out)) > limit {
+		return nil, fmt.Errorf("decompressed data exceeds %d bytes (raise max: to allow more)", limit)
+	}
+	return out, nil
+}
+
+// RegisterCompressLib registers in-memory compression commands
+// This library is NOT auto-imported - users must explicitly use IMPORT compress.
+// Module: compress
+func (ps *PawScript) RegisterCompressLib() {
+	// Helper function to set a StoredBytes as result with proper reference counting
+	setBytesResult := func(ctx *Context, bytes StoredBytes) {
+		ref := ctx.executor.RegisterObject(bytes, ObjBytes)
+		ctx.state.SetResultWithoutClaim(ref)
+	}
+
+	// Helper to get the raw data of a bytes or string argument
+	dataOf := func(ctx *Context, arg interface{}) []byte {
+		switch v := ctx.executor.resolveValue(arg).(type) {
+		case StoredBytes:
+			return v.Data()
+		case []byte:
+			return v
+		default:
+			return []byte(formatArgForDisplay(v, ctx.executor))
+		}
+	}
+
+	// Helper to read the format: named argument
+	formatFor := func(ctx *Context, def string) string {
+		if fmtVal, exists := ctx.NamedArgs["format"]; exists {
+			return strings.ToLower(fmt.Sprintf("%v", ctx.executor.resolveValue(fmtVal)))
+		}
+		return def
+	}
+
+	// ==================== compress:: module ====================
+
+	// compress - compress a string or bytes value
+	// Usage: compress <data> [, format: gzip|zstd] [, level: N]
+	// Returns bytes; the default format is gzip
+	// Levels: gzip 0-9 (0 = store, 9 = best), zstd 1-22
+	ps.RegisterCommandInModule("compress", "compress", func(ctx *Context) Result {
+		if len(ctx.Args) < 1 {
+			ctx.LogError(CatCommand, "Usage: compress <data> [, format: gzip|zstd] [, level: N]")
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+
+		level := -1
+		if levelVal, exists := ctx.NamedArgs["level"]; exists {
+			l, ok := toInt64(ctx.executor.resolveValue(levelVal))
+			if !ok || l < 0 {
+				ctx.LogError(CatArgument, "level must be a non-negative integer")
+				ctx.SetResult(nil)
+				return BoolStatus(false)
+			}
+			level = int(l)
+		}
+
+		out, err := compressData(dataOf(ctx, ctx.Args[0]), formatFor(ctx, "gzip"), level)
+		if err != nil {
+			ctx.LogError(CatArgument, fmt.Sprintf("compress: %v", err))
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		setBytesResult(ctx, NewStoredBytes(out))
+		return BoolStatus(true)
+	})
+
+	// decompress - decompress gzip or zstd data
+	// Usage: decompress <bytes> [, format: auto|gzip|zstd] [, string: true] [, max: N]
+	// The format is detected from the data unless given; string: true returns a string
+	// Fails if the data decompresses to more than max bytes (default 64MB)
+	ps.RegisterCommandInModule("compress", "decompress", func(ctx *Context) Result {
+		if len(ctx.Args) < 1 {
+			ctx.LogError(CatCommand, "Usage: decompress <bytes> [, format: auto|gzip|zstd] [, string: true] [, max: N]")
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+
+		limit := int64(DefaultMaxDecompressedSize)
+		if maxVal, exists := ctx.NamedArgs["max"]; exists {
+			n, ok := toInt64(ctx.executor.resolveValue(maxVal))
+			if !ok || n < 0 {
+				ctx.LogError(CatArgument, "max must be a non-negative integer")
+				ctx.SetResult(nil)
+				return BoolStatus(false)
+			}
+			limit = n
+		}
+
+		out, err := decompressData(dataOf(ctx, ctx.Args[0]), formatFor(ctx, "auto"), limit)
+		if err != nil {
+			ctx.LogError(CatArgument, fmt.Sprintf("decompress: %v", err))
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+
+		if strVal, exists := ctx.NamedArgs["string"]; exists && toBool(strVal) {
+			ctx.SetResult(string(out))
+			return BoolStatus(true)
+		}
+		setBytesResult(ctx, NewStoredBytes(out))
+		return BoolStatus(true)
+	})
+}
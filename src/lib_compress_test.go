@@ -0,0 +1,22 @@
+package pawscript
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDecompressLimit(t *testing.T) {
+	data := bytes.Repeat([]byte("a"), 1000)
+	for _, format := range []string{"gzip", "zstd"} {
+		compressed, err := compressData(data, format, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out, err := decompressData(compressed, "auto", 1000); err != nil || !bytes.Equal(out, data) {
+			t.Errorf("%s at the limit: got %d bytes, %v", format, len(out), err)
+		}
+		if _, err := decompressData(compressed, "auto", 999); err == nil {
+			t.Errorf("%s past the limit: no error", format)
+		}
+	}
+}
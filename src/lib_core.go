@@ -2534,7 +2534,9 @@ func (ps *PawScript) RegisterCoreLib() {
 					resumeData := <-waitChan
 
 					if !resumeData.Status {
-						ctx.LogError(CatFlow, "Async operation in while loop failed")
+						if !ctx.executor.cancelled.Load() {
+							ctx.LogError(CatFlow, "Async operation in while loop failed")
+						}
 						return BoolStatus(false)
 					}
 					lastStatus = resumeData.Status
@@ -2570,6 +2572,7 @@ func (ps *PawScript) RegisterCoreLib() {
 	//   for ~<list>, <key>, <value>, (body)         - key/value pairs (named args)
 	//   for ~<struct>, <key>, <value>, (body)       - struct field names and values
 	//   for ~<list>, (<unpack vars>), (body)        - unpack each item
+	//   for ~<channel>, <var>, (body)               - receive until the channel is closed and drained
 	// Named args:
 	//   by: <step>        - step value for numeric ranges
 	//   order: ascending|descending - iteration order for lists
@@ -2682,6 +2685,65 @@ func (ps *PawScript) RegisterCoreLib() {
 			return StoredList{}, -1, false
 		}
 
+		// Helper to run one iteration of the body, for the forms that don't support yield
+		// Returns done with the result for to return on an early return, a break, or a
+		// continue that leaves this loop
+		runBody := func(bodyCommands []*ParsedCommand) (Result, bool) {
+			lastStatus := true
+			for _, cmd := range bodyCommands {
+				if strings.TrimSpace(cmd.Command) == "" {
+					continue
+				}
+				shouldExecute := true
+				switch cmd.Separator {
+				case "&":
+					shouldExecute = lastStatus
+				case "|":
+					shouldExecute = !lastStatus
+				}
+				if !shouldExecute {
+					continue
+				}
+
+				result := ctx.executor.executeParsedCommand(cmd, ctx.state, nil)
+
+				if earlyReturn, ok := result.(EarlyReturn); ok {
+					// Propagate early return up to calling context
+					return earlyReturn, true
+				}
+
+				// Check for break - exit this loop
+				if breakResult, ok := result.(BreakResult); ok {
+					if breakResult.Levels <= 1 {
+						return BoolStatus(true), true
+					}
+					return BreakResult{Levels: breakResult.Levels - 1}, true
+				}
+
+				// Check for continue - skip to next iteration
+				if continueResult, ok := result.(ContinueResult); ok {
+					if continueResult.Levels <= 1 {
+						break
+					}
+					return ContinueResult{Levels: continueResult.Levels - 1}, true
+				}
+
+				if asyncToken, isToken := result.(TokenResult); isToken {
+					tokenID := string(asyncToken)
+					waitChan := make(chan ResumeData, 1)
+					ctx.executor.attachWaitChan(tokenID, waitChan)
+					resumeData := <-waitChan
+					lastStatus = resumeData.Status
+					continue
+				}
+
+				if boolRes, ok := result.(BoolStatus); ok {
+					lastStatus = bool(boolRes)
+				}
+			}
+			return nil, false
+		}
+
 		// Helper to check if arg is a struct
 		isStruct := func(arg interface{}) (StoredStruct, bool) {
 			switch v := arg.(type) {
@@ -3024,57 +3086,8 @@ func (ps *PawScript) RegisterCoreLib() {
 						ctx.state.SetVariable(indexVar, int64(idx))
 					}
 
-					lastStatus := true
-					for _, cmd := range bodyCommands {
-						if strings.TrimSpace(cmd.Command) == "" {
-							continue
-						}
-						shouldExecute := true
-						switch cmd.Separator {
-						case "&":
-							shouldExecute = lastStatus
-						case "|":
-							shouldExecute = !lastStatus
-						}
-						if !shouldExecute {
-							continue
-						}
-
-						result := ctx.executor.executeParsedCommand(cmd, ctx.state, nil)
-
-						if earlyReturn, ok := result.(EarlyReturn); ok {
-							// Propagate early return up to calling context
-							return earlyReturn
-						}
-
-						// Check for break - exit this loop
-						if breakResult, ok := result.(BreakResult); ok {
-							if breakResult.Levels <= 1 {
-								return BoolStatus(true)
-							}
-							return BreakResult{Levels: breakResult.Levels - 1}
-						}
-
-						// Check for continue - skip to next iteration
-						if continueResult, ok := result.(ContinueResult); ok {
-							if continueResult.Levels <= 1 {
-								break
-							}
-							return ContinueResult{Levels: continueResult.Levels - 1}
-						}
-
-						if asyncToken, isToken := result.(TokenResult); isToken {
-							tokenID := string(asyncToken)
-							waitChan := make(chan ResumeData, 1)
-							ctx.executor.attachWaitChan(tokenID, waitChan)
-							resumeData := <-waitChan
-							lastStatus = resumeData.Status
-							continue
-						}
-
-						if boolRes, ok := result.(BoolStatus); ok {
-							lastStatus = bool(boolRes)
-						}
+					if result, done := runBody(bodyCommands); done {
+						return result
 					}
 					iterNum++
 				}
@@ -3141,57 +3154,8 @@ func (ps *PawScript) RegisterCoreLib() {
 					ctx.state.SetVariable(indexVar, int64(idx))
 				}
 
-				lastStatus := true
-				for _, cmd := range bodyCommands {
-					if strings.TrimSpace(cmd.Command) == "" {
-						continue
-					}
-					shouldExecute := true
-					switch cmd.Separator {
-					case "&":
-						shouldExecute = lastStatus
-					case "|":
-						shouldExecute = !lastStatus
-					}
-					if !shouldExecute {
-						continue
-					}
-
-					result := ctx.executor.executeParsedCommand(cmd, ctx.state, nil)
-
-					if earlyReturn, ok := result.(EarlyReturn); ok {
-						// Propagate early return up to calling context
-						return earlyReturn
-					}
-
-					// Check for break - exit this loop
-					if breakResult, ok := result.(BreakResult); ok {
-						if breakResult.Levels <= 1 {
-							return BoolStatus(true)
-						}
-						return BreakResult{Levels: breakResult.Levels - 1}
-					}
-
-					// Check for continue - skip to next iteration
-					if continueResult, ok := result.(ContinueResult); ok {
-						if continueResult.Levels <= 1 {
-							break
-						}
-						return ContinueResult{Levels: continueResult.Levels - 1}
-					}
-
-					if asyncToken, isToken := result.(TokenResult); isToken {
-						tokenID := string(asyncToken)
-						waitChan := make(chan ResumeData, 1)
-						ctx.executor.attachWaitChan(tokenID, waitChan)
-						resumeData := <-waitChan
-						lastStatus = resumeData.Status
-						continue
-					}
-
-					if boolRes, ok := result.(BoolStatus); ok {
-						lastStatus = bool(boolRes)
-					}
+				if result, done := runBody(bodyCommands); done {
+					return result
 				}
 				iterNum++
 			}
@@ -3233,57 +3197,8 @@ func (ps *PawScript) RegisterCoreLib() {
 						ctx.state.SetVariable(indexVar, int64(idx))
 					}
 
-					lastStatus := true
-					for _, cmd := range bodyCommands {
-						if strings.TrimSpace(cmd.Command) == "" {
-							continue
-						}
-						shouldExecute := true
-						switch cmd.Separator {
-						case "&":
-							shouldExecute = lastStatus
-						case "|":
-							shouldExecute = !lastStatus
-						}
-						if !shouldExecute {
-							continue
-						}
-
-						result := ctx.executor.executeParsedCommand(cmd, ctx.state, nil)
-
-						if earlyReturn, ok := result.(EarlyReturn); ok {
-							// Propagate early return up to calling context
-							return earlyReturn
-						}
-
-						// Check for break - exit this loop
-						if breakResult, ok := result.(BreakResult); ok {
-							if breakResult.Levels <= 1 {
-								return BoolStatus(true)
-							}
-							return BreakResult{Levels: breakResult.Levels - 1}
-						}
-
-						// Check for continue - skip to next iteration
-						if continueResult, ok := result.(ContinueResult); ok {
-							if continueResult.Levels <= 1 {
-								break
-							}
-							return ContinueResult{Levels: continueResult.Levels - 1}
-						}
-
-						if asyncToken, isToken := result.(TokenResult); isToken {
-							tokenID := string(asyncToken)
-							waitChan := make(chan ResumeData, 1)
-							ctx.executor.attachWaitChan(tokenID, waitChan)
-							resumeData := <-waitChan
-							lastStatus = resumeData.Status
-							continue
-						}
-
-						if boolRes, ok := result.(BoolStatus); ok {
-							lastStatus = bool(boolRes)
-						}
+					if result, done := runBody(bodyCommands); done {
+						return result
 					}
 					iterNum++
 				}
@@ -3331,57 +3246,47 @@ func (ps *PawScript) RegisterCoreLib() {
 					ctx.state.SetVariable(indexVar, int64(idx))
 				}
 
-				lastStatus := true
-				for _, cmd := range bodyCommands {
-					if strings.TrimSpace(cmd.Command) == "" {
-						continue
-					}
-					shouldExecute := true
-					switch cmd.Separator {
-					case "&":
-						shouldExecute = lastStatus
-					case "|":
-						shouldExecute = !lastStatus
-					}
-					if !shouldExecute {
-						continue
-					}
-
-					result := ctx.executor.executeParsedCommand(cmd, ctx.state, nil)
-
-					if earlyReturn, ok := result.(EarlyReturn); ok {
-						// Propagate early return up to calling context
-						return earlyReturn
-					}
+				if result, done := runBody(bodyCommands); done {
+					return result
+				}
+				iterNum++
+			}
+			return BoolStatus(true)
+		} else if ch := resolveChannelArg(ctx, firstArg); ch != nil {
+			// Channel form: receive until the channel is closed and drained
+			iterVarName = fmt.Sprintf("%v", ctx.Args[1])
+			bodyBlock = extractCode(ctx.Args[2])
 
-					// Check for break - exit this loop
-					if breakResult, ok := result.(BreakResult); ok {
-						if breakResult.Levels <= 1 {
-							return BoolStatus(true)
-						}
-						return BreakResult{Levels: breakResult.Levels - 1}
-					}
+			bodyCommands, parseErr := ctx.GetOrParseBlock(2, bodyBlock)
+			if parseErr != "" {
+				ctx.LogError(CatCommand, fmt.Sprintf("for: failed to parse body: %s", parseErr))
+				return BoolStatus(false)
+			}
 
-					// Check for continue - skip to next iteration
-					if continueResult, ok := result.(ContinueResult); ok {
-						if continueResult.Levels <= 1 {
-							break
-						}
-						return ContinueResult{Levels: continueResult.Levels - 1}
-					}
+			iterNum := 1
+			for {
+				_, value, err := ChannelRecvUntil(ch, -1, ps.cancelDone())
+				if err == ErrChannelClosed {
+					break
+				}
+				if err == ErrCancelled {
+					return BoolStatus(false)
+				}
+				if err != nil {
+					ctx.LogError(CatAsync, fmt.Sprintf("for: failed to receive: %v", err))
+					return BoolStatus(false)
+				}
+				ctx.state.SetVariable(iterVarName, value)
 
-					if asyncToken, isToken := result.(TokenResult); isToken {
-						tokenID := string(asyncToken)
-						waitChan := make(chan ResumeData, 1)
-						ctx.executor.attachWaitChan(tokenID, waitChan)
-						resumeData := <-waitChan
-						lastStatus = resumeData.Status
-						continue
-					}
+				if iterVar != "" {
+					ctx.state.SetVariable(iterVar, int64(iterNum))
+				}
+				if indexVar != "" {
+					ctx.state.SetVariable(indexVar, int64(iterNum-1))
+				}
 
-					if boolRes, ok := result.(BoolStatus); ok {
-						lastStatus = bool(boolRes)
-					}
+				if result, done := runBody(bodyCommands); done {
+					return result
 				}
 				iterNum++
 			}
@@ -3621,6 +3526,10 @@ func (ps *PawScript) RegisterCoreLib() {
 			ctx.LogError(CatIO, fmt.Sprintf("include: failed to read file %s: %v", filename, err))
 			return BoolStatus(false)
 		}
+		if err := ps.verifyScriptFile(string(content), filename); err != nil {
+			ctx.LogError(CatIO, fmt.Sprintf("include: %v", err))
+			return BoolStatus(false)
+		}
 
 		if isAdvancedForm {
 			restrictedEnv := NewMacroModuleEnvironment(ctx.state.moduleEnv)
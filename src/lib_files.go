@@ -1,6 +1,7 @@
 package pawscript
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -8,6 +9,8 @@ import (
 	"runtime"
 	"strings"
 	"time"
+
+	"github.com/fsnotify/fsnotify"
 )
 
 // pathHasPrefix checks if path starts with prefix, handling case sensitivity
@@ -29,97 +32,110 @@ func pathEquals(path1, path2 string) bool {
 	return path1 == path2
 }
 
-// RegisterFilesLib registers file system commands
-// Module: files
-func (ps *PawScript) RegisterFilesLib() {
-	// Helper to set a StoredList as result
-	// Note: RegisterObject now handles nested ref claiming for lists
-	setListResult := func(ctx *Context, list StoredList) {
-		// RegisterObject claims refs for all nested items automatically
-		ref := ctx.executor.RegisterObject(list, ObjList)
-		ctx.state.SetResultWithoutClaim(ref)
+// scriptPath returns the cleaned absolute path a script means by path
+// Relative paths are resolved from ScriptDir if available
+func (ps *PawScript) scriptPath(path string) (string, error) {
+	var absPath string
+	var err error
+	if !filepath.IsAbs(path) && ps.config != nil && ps.config.ScriptDir != "" {
+		// Resolve relative path from script directory
+		absPath = filepath.Join(ps.config.ScriptDir, path)
+	} else {
+		absPath, err = filepath.Abs(path)
+		if err != nil {
+			return "", fmt.Errorf("invalid path: %v", err)
+		}
 	}
+	return filepath.Clean(absPath), nil
+}
 
-	// Helper to validate path access against configured roots
-	// Returns cleaned absolute path and nil error if allowed
-	validatePathAccess := func(ctx *Context, path string, needsWrite bool) (string, error) {
-		// Get absolute path - resolve relative paths from ScriptDir if available
-		var absPath string
-		var err error
-		if !filepath.IsAbs(path) && ps.config != nil && ps.config.ScriptDir != "" {
-			// Resolve relative path from script directory
-			absPath = filepath.Join(ps.config.ScriptDir, path)
-		} else {
-			absPath, err = filepath.Abs(path)
-			if err != nil {
-				return "", fmt.Errorf("invalid path: %v", err)
-			}
-		}
-		absPath = filepath.Clean(absPath)
+// validatePathAccess validates path access against the configured read/write roots
+// Returns cleaned absolute path and nil error if allowed
+// Paths outside the roots are offered to Config.PermissionPrompt, if set
+func (ps *PawScript) validatePathAccess(path string, needsWrite bool) (string, error) {
+	absPath, err := ps.scriptPath(path)
+	if err != nil {
+		return "", err
+	}
 
-		// Get file access config from PawScript instance
-		if ps.config == nil || ps.config.FileAccess == nil {
-			// No restrictions configured
-			return absPath, nil
-		}
+	if needsWrite && ps.config != nil && ps.config.Permissions != nil && ps.config.Permissions.ReadOnly {
+		return "", fmt.Errorf("write access denied: the %q permission profile is read-only", ps.config.Permissions.Name)
+	}
 
-		fileAccess := ps.config.FileAccess
+	// Get file access config from PawScript instance
+	if ps.config == nil || ps.config.FileAccess == nil {
+		// No restrictions configured
+		return absPath, nil
+	}
 
-		// Check write roots if write access needed
-		if needsWrite {
-			if fileAccess.WriteRoots == nil {
-				// nil means unrestricted
-				return absPath, nil
-			}
-			if len(fileAccess.WriteRoots) == 0 {
-				// Empty slice means no write access allowed
-				return "", fmt.Errorf("write access denied: no write roots configured")
-			}
-			allowed := false
-			for _, root := range fileAccess.WriteRoots {
-				absRoot, err := filepath.Abs(root)
-				if err != nil {
-					continue
-				}
-				absRoot = filepath.Clean(absRoot)
-				// Use case-insensitive comparison on Windows/macOS
-				if pathHasPrefix(absPath, absRoot+string(filepath.Separator)) || pathEquals(absPath, absRoot) {
-					allowed = true
-					break
-				}
-			}
-			if !allowed {
-				return "", fmt.Errorf("write access denied: path outside allowed roots")
-			}
-		} else {
-			// Check read roots
-			if fileAccess.ReadRoots == nil {
-				// nil means unrestricted
-				return absPath, nil
+	fileAccess := ps.config.FileAccess
+
+	// Check write roots if write access needed
+	if needsWrite {
+		if fileAccess.WriteRoots == nil {
+			// nil means unrestricted
+			return absPath, nil
+		}
+		if len(fileAccess.WriteRoots) == 0 {
+			// Empty slice means no write access allowed
+			return ps.pathDenied(absPath, needsWrite, fmt.Errorf("write access denied: no write roots configured"))
+		}
+		allowed := false
+		for _, root := range fileAccess.WriteRoots {
+			absRoot, err := filepath.Abs(root)
+			if err != nil {
+				continue
 			}
-			if len(fileAccess.ReadRoots) == 0 {
-				// Empty slice means no read access allowed
-				return "", fmt.Errorf("read access denied: no read roots configured")
+			absRoot = filepath.Clean(absRoot)
+			// Use case-insensitive comparison on Windows/macOS
+			if pathHasPrefix(absPath, absRoot+string(filepath.Separator)) || pathEquals(absPath, absRoot) {
+				allowed = true
+				break
 			}
-			allowed := false
-			for _, root := range fileAccess.ReadRoots {
-				absRoot, err := filepath.Abs(root)
-				if err != nil {
-					continue
-				}
-				absRoot = filepath.Clean(absRoot)
-				// Use case-insensitive comparison on Windows/macOS
-				if pathHasPrefix(absPath, absRoot+string(filepath.Separator)) || pathEquals(absPath, absRoot) {
-					allowed = true
-					break
-				}
+		}
+		if !allowed {
+			return ps.pathDenied(absPath, needsWrite, fmt.Errorf("write access denied: path outside allowed roots"))
+		}
+	} else {
+		// Check read roots
+		if fileAccess.ReadRoots == nil {
+			// nil means unrestricted
+			return absPath, nil
+		}
+		if len(fileAccess.ReadRoots) == 0 {
+			// Empty slice means no read access allowed
+			return ps.pathDenied(absPath, needsWrite, fmt.Errorf("read access denied: no read roots configured"))
+		}
+		allowed := false
+		for _, root := range fileAccess.ReadRoots {
+			absRoot, err := filepath.Abs(root)
+			if err != nil {
+				continue
 			}
-			if !allowed {
-				return "", fmt.Errorf("read access denied: path outside allowed roots")
+			absRoot = filepath.Clean(absRoot)
+			// Use case-insensitive comparison on Windows/macOS
+			if pathHasPrefix(absPath, absRoot+string(filepath.Separator)) || pathEquals(absPath, absRoot) {
+				allowed = true
+				break
 			}
 		}
+		if !allowed {
+			return ps.pathDenied(absPath, needsWrite, fmt.Errorf("read access denied: path outside allowed roots"))
+		}
+	}
 
-		return absPath, nil
+	return absPath, nil
+}
+
+// RegisterFilesLib registers file system commands
+// Module: files
+func (ps *PawScript) RegisterFilesLib() {
+	// Helper to set a StoredList as result
+	// Note: RegisterObject now handles nested ref claiming for lists
+	setListResult := func(ctx *Context, list StoredList) {
+		// RegisterObject claims refs for all nested items automatically
+		ref := ctx.executor.RegisterObject(list, ObjList)
+		ctx.state.SetResultWithoutClaim(ref)
 	}
 
 	// Helper to resolve a file from an argument
@@ -192,7 +208,11 @@ func (ps *PawScript) RegisterFilesLib() {
 		needsWrite := mode == "w" || mode == "a" || mode == "rw"
 
 		// Validate path access
-		absPath, err := validatePathAccess(ctx, path, needsWrite)
+		absPath, err := ps.checkPathAccess(ctx, "file", path, needsWrite)
+		if errors.Is(err, ErrDryRun) {
+			// Dry run: writes go nowhere
+			absPath, err = os.DevNull, nil
+		}
 		if err != nil {
 			ctx.LogError(CatCommand, fmt.Sprintf("file: %v", err))
 			return BoolStatus(false)
@@ -223,15 +243,21 @@ func (ps *PawScript) RegisterFilesLib() {
 			return BoolStatus(false)
 		}
 
-		// Open the file
+		// Open the file, within the open files quota
+		if err := ps.quotas.openFile(); err != nil {
+			ctx.LogError(CatIO, fmt.Sprintf("file: %v", err))
+			return BoolStatus(false)
+		}
 		file, err := os.OpenFile(absPath, flags, 0644)
 		if err != nil {
+			ps.quotas.closeFile()
 			ctx.LogError(CatCommand, fmt.Sprintf("file: %v", err))
 			return BoolStatus(false)
 		}
 
 		// Create StoredFile and return
 		storedFile := NewStoredFile(file, path, mode)
+		storedFile.quota = ps.quotas
 		ctx.SetResult(storedFile)
 		return BoolStatus(true)
 	})
@@ -412,7 +438,7 @@ func (ps *PawScript) RegisterFilesLib() {
 		path := fmt.Sprintf("%v", ctx.Args[0])
 
 		// Validate read access
-		absPath, err := validatePathAccess(ctx, path, false)
+		absPath, err := ps.checkPathAccess(ctx, "file_exists", path, false)
 		if err != nil {
 			ctx.LogError(CatCommand, fmt.Sprintf("file_exists: %v", err))
 			return BoolStatus(false)
@@ -435,7 +461,7 @@ func (ps *PawScript) RegisterFilesLib() {
 		path := fmt.Sprintf("%v", ctx.Args[0])
 
 		// Validate read access
-		absPath, err := validatePathAccess(ctx, path, false)
+		absPath, err := ps.checkPathAccess(ctx, "file_info", path, false)
 		if err != nil {
 			ctx.LogError(CatCommand, fmt.Sprintf("file_info: %v", err))
 			return BoolStatus(false)
@@ -470,7 +496,7 @@ func (ps *PawScript) RegisterFilesLib() {
 		}
 
 		// Validate read access
-		absPath, err := validatePathAccess(ctx, path, false)
+		absPath, err := ps.checkPathAccess(ctx, "list_dir", path, false)
 		if err != nil {
 			ctx.LogError(CatCommand, fmt.Sprintf("list_dir: %v", err))
 			return BoolStatus(false)
@@ -511,7 +537,10 @@ func (ps *PawScript) RegisterFilesLib() {
 		}
 
 		// Validate write access
-		absPath, err := validatePathAccess(ctx, path, true)
+		absPath, err := ps.checkPathAccess(ctx, "mkdir", path, true)
+		if errors.Is(err, ErrDryRun) {
+			return BoolStatus(true)
+		}
 		if err != nil {
 			ctx.LogError(CatCommand, fmt.Sprintf("mkdir: %v", err))
 			return BoolStatus(false)
@@ -542,7 +571,10 @@ func (ps *PawScript) RegisterFilesLib() {
 		path := fmt.Sprintf("%v", ctx.Args[0])
 
 		// Validate write access
-		absPath, err := validatePathAccess(ctx, path, true)
+		absPath, err := ps.checkPathAccess(ctx, "rm", path, true)
+		if errors.Is(err, ErrDryRun) {
+			return BoolStatus(true)
+		}
 		if err != nil {
 			ctx.LogError(CatCommand, fmt.Sprintf("rm: %v", err))
 			return BoolStatus(false)
@@ -577,7 +609,10 @@ func (ps *PawScript) RegisterFilesLib() {
 		}
 
 		// Validate write access
-		absPath, err := validatePathAccess(ctx, path, true)
+		absPath, err := ps.checkPathAccess(ctx, "rmdir", path, true)
+		if errors.Is(err, ErrDryRun) {
+			return BoolStatus(true)
+		}
 		if err != nil {
 			ctx.LogError(CatCommand, fmt.Sprintf("rmdir: %v", err))
 			return BoolStatus(false)
@@ -597,6 +632,217 @@ func (ps *PawScript) RegisterFilesLib() {
 		return BoolStatus(true)
 	})
 
+	// ==================== File Watching ====================
+
+	// fswatch - Watch a file or directory for changes
+	// Usage: fswatch <path> [recursive: true] [buffer: N]
+	// Returns: a channel receiving (op: "create"|"modify"|"delete", path: "...") lists
+	// Renames are reported as a delete of the old path followed by a create of the new one
+	// Closing the channel with channel_close stops the watcher
+	ps.RegisterCommandInModule("files", "fswatch", func(ctx *Context) Result {
+		if len(ctx.Args) < 1 {
+			ctx.LogError(CatCommand, "fswatch: path required")
+			return BoolStatus(false)
+		}
+
+		path := fmt.Sprintf("%v", ctx.executor.resolveValue(ctx.Args[0]))
+
+		// Validate read access
+		absPath, err := ps.checkPathAccess(ctx, "fswatch", path, false)
+		if err != nil {
+			ctx.LogError(CatCommand, fmt.Sprintf("fswatch: %v", err))
+			return BoolStatus(false)
+		}
+
+		info, err := os.Stat(absPath)
+		if err != nil {
+			ctx.LogError(CatCommand, fmt.Sprintf("fswatch: %v", err))
+			return BoolStatus(false)
+		}
+
+		recursive := false
+		if recVal, exists := ctx.NamedArgs["recursive"]; exists {
+			recursive = toBool(recVal)
+		}
+		bufferSize := 0
+		if bufVal, exists := ctx.NamedArgs["buffer"]; exists {
+			if n, ok := toInt64(bufVal); ok && n > 0 {
+				bufferSize = int(n)
+			}
+		}
+
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			ctx.LogError(CatIO, fmt.Sprintf("fswatch: %v", err))
+			return BoolStatus(false)
+		}
+
+		// Helper to add a directory tree (or a single path) to the watcher
+		addTree := func(root string) error {
+			if !recursive {
+				return watcher.Add(root)
+			}
+			return filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+				if err != nil {
+					return nil // Skip unreadable entries
+				}
+				if d.IsDir() {
+					return watcher.Add(p)
+				}
+				return nil
+			})
+		}
+
+		if !info.IsDir() {
+			err = watcher.Add(absPath)
+		} else {
+			err = addTree(absPath)
+		}
+		if err != nil {
+			_ = watcher.Close()
+			ctx.LogError(CatIO, fmt.Sprintf("fswatch: %v", err))
+			return BoolStatus(false)
+		}
+
+		ch := NewStoredChannel(bufferSize)
+		done := make(chan struct{})
+		// Stop the forwarding goroutine without blocking: it owns the watcher and
+		// closes it on exit. Closing here directly could deadlock, since
+		// NativeClose runs with the channel lock held.
+		ch.NativeClose = func() error {
+			close(done)
+			return nil
+		}
+
+		executor := ctx.executor
+		go func() {
+			defer watcher.Close()
+			for {
+				select {
+				case <-done:
+					return
+				case event, ok := <-watcher.Events:
+					if !ok {
+						return
+					}
+					var op string
+					switch {
+					case event.Has(fsnotify.Create):
+						op = "create"
+						// Newly created directories join a recursive watch
+						if recursive {
+							if fi, err := os.Stat(event.Name); err == nil && fi.IsDir() {
+								_ = addTree(event.Name)
+							}
+						}
+					case event.Has(fsnotify.Write):
+						op = "modify"
+					case event.Has(fsnotify.Remove), event.Has(fsnotify.Rename):
+						op = "delete"
+					default:
+						continue // Ignore attribute-only changes
+					}
+					eventList := NewStoredListWithNamed(nil, map[string]interface{}{
+						"op":   QuotedString(op),
+						"path": QuotedString(event.Name),
+					})
+					ref := executor.RegisterObject(eventList, ObjList)
+					if err := ChannelSend(ch, ref); err != nil {
+						ps.logger.DebugCat(CatIO, "fswatch: dropped %s event for %s: %v", op, event.Name, err)
+					}
+				case err, ok := <-watcher.Errors:
+					if !ok {
+						return
+					}
+					ps.logger.WarnCat(CatIO, "fswatch: %v", err)
+				}
+			}
+		}()
+
+		chRef := ctx.executor.RegisterObject(ch, ObjChannel)
+		ctx.state.SetResult(chRef)
+		return BoolStatus(true)
+	})
+
+	// ==================== Streaming PSL ====================
+
+	// psl_stream - Read a PSL file one item at a time
+	// Usage: psl_stream <path> [records: true] [buffer: N]
+	// Returns: a channel receiving each item of the file's list, closed after the last one
+	// Positional items arrive as their values and named items as (name, value) lists
+	// With records: true the file holds one list after another (such as a log with one
+	// list per line) and each whole list arrives as an item
+	// Only buffer: items (default 64) are read ahead, so large files are never held in
+	// memory at once; closing the channel with channel_close, or the script ending,
+	// stops reading
+	ps.RegisterCommandInModule("files", "psl_stream", func(ctx *Context) Result {
+		if len(ctx.Args) < 1 {
+			ctx.LogError(CatCommand, "psl_stream: path required")
+			return BoolStatus(false)
+		}
+
+		path := fmt.Sprintf("%v", ctx.executor.resolveValue(ctx.Args[0]))
+		absPath, err := ps.checkPathAccess(ctx, "psl_stream", path, false)
+		if err != nil {
+			ctx.LogError(CatCommand, fmt.Sprintf("psl_stream: %v", err))
+			return BoolStatus(false)
+		}
+		if err := ps.quotas.openFile(); err != nil {
+			ctx.LogError(CatIO, fmt.Sprintf("psl_stream: %v", err))
+			return BoolStatus(false)
+		}
+		file, err := os.Open(absPath)
+		if err != nil {
+			ps.quotas.closeFile()
+			ctx.LogError(CatIO, fmt.Sprintf("psl_stream: %v", err))
+			return BoolStatus(false)
+		}
+
+		parser := NewPSLStreamParser(file)
+		if recVal, exists := ctx.NamedArgs["records"]; exists {
+			parser.Records = toBool(recVal)
+		}
+		bufferSize := 64
+		if bufVal, exists := ctx.NamedArgs["buffer"]; exists {
+			if n, ok := toInt64(bufVal); ok && n > 0 {
+				bufferSize = int(n)
+			}
+		}
+
+		// The reader waits for the script to catch up rather than dropping items
+		ch := NewStoredChannel(bufferSize)
+		ch.Overflow = OverflowBlock
+
+		executor := ctx.executor
+		ps.trackStream(ch)
+		go func() {
+			defer ps.quotas.closeFile()
+			defer file.Close()
+			defer ps.untrackStream(ch)
+			defer func() { _ = ChannelClose(ch) }()
+			for {
+				key, value, err := parser.Next()
+				if err == io.EOF {
+					return
+				}
+				if err != nil {
+					ps.logger.WarnCat(CatIO, "psl_stream: %s: %v", path, err)
+					return
+				}
+				if key != "" {
+					value = PSLList{key, value}
+				}
+				if err := ChannelSend(ch, decodeChannelValue(value, executor)); err != nil {
+					return // Closed by the script
+				}
+			}
+		}()
+
+		chRef := ctx.executor.RegisterObject(ch, ObjChannel)
+		ctx.state.SetResult(chRef)
+		return BoolStatus(true)
+	})
+
 	// ==================== Path Manipulation (pure, no filesystem access) ====================
 
 	// abs_path - Get absolute path
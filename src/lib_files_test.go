@@ -0,0 +1,42 @@
+package pawscript
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// openFiles returns the number of files the interpreter's quota counts as open
+func openFiles(ps *PawScript) int {
+	ps.quotas.mu.Lock()
+	defer ps.quotas.mu.Unlock()
+	return ps.quotas.usage.MaxOpenFiles
+}
+
+func TestPSLStreamClosedOnShutdown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "items.psl")
+	if err := os.WriteFile(path, []byte("("+strings.Repeat("1, ", 100)+"2)"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ps := New(&Config{Quotas: &ResourceQuotas{MaxOpenFiles: 4}})
+	ps.RegisterStandardLibrary([]string{})
+	// The script never reads, so the reader is left waiting for room
+	if result := ps.Execute(`IMPORT files; psl_stream "` + filepath.ToSlash(path) + `", buffer: 1`); result != BoolStatus(true) {
+		t.Fatalf("psl_stream: %v", result)
+	}
+	if n := openFiles(ps); n != 1 {
+		t.Fatalf("open files = %d, want 1", n)
+	}
+
+	ps.Shutdown()
+	deadline := time.Now().Add(2 * time.Second)
+	for openFiles(ps) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("psl_stream's file still open after Shutdown")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
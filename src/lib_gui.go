@@ -0,0 +1,158 @@
+package pawscript
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RegisterGUILib registers the native dialog commands
+// This library is NOT auto-imported - users must explicitly use IMPORT gui.
+// The dialogs come from the host (Config.Dialogs); without one every command
+// fails, and sys_info reports gui: false.
+// Module: gui
+func (ps *PawScript) RegisterGUILib() {
+
+	// ==================== gui:: module ====================
+
+	// Helper to get the host's dialogs, failing the command without a GUI
+	dialogsFor := func(ctx *Context, name string) DialogHost {
+		host := ps.dialogs()
+		if host == nil {
+			ctx.LogError(CatIO, name+": no GUI is available to show dialogs")
+		}
+		return host
+	}
+
+	// Helper to get a named string argument, or a default
+	namedString := func(ctx *Context, name, def string) string {
+		if val, exists := ctx.NamedArgs[name]; exists {
+			return fmt.Sprintf("%v", ctx.executor.resolveValue(val))
+		}
+		return def
+	}
+
+	// Helper to get a file dialog's options from title:, dir:, name: and filter:
+	// filter: is a list of glob patterns or a string of them separated by spaces
+	fileOptions := func(ctx *Context, title string) FileDialogOptions {
+		options := FileDialogOptions{
+			Title: namedString(ctx, "title", title),
+			Dir:   namedString(ctx, "dir", ""),
+			Name:  namedString(ctx, "name", ""),
+		}
+		if options.Dir == "" && ps.config != nil {
+			options.Dir = ps.config.ScriptDir
+		}
+		if val, exists := ctx.NamedArgs["filter"]; exists {
+			if list, ok := ctx.executor.resolveValue(val).(StoredList); ok {
+				for _, item := range list.Items() {
+					options.Patterns = append(options.Patterns, fmt.Sprintf("%v", ctx.executor.resolveValue(item)))
+				}
+			} else {
+				options.Patterns = strings.Fields(namedString(ctx, "filter", ""))
+			}
+		}
+		return options
+	}
+
+	// gui_alert - show a message until the user dismisses it
+	// Usage: gui_alert <message> [, title: "..."]
+	ps.RegisterCommandInModule("gui", "gui_alert", func(ctx *Context) Result {
+		if len(ctx.Args) < 1 {
+			ctx.LogError(CatCommand, "Usage: gui_alert <message> [, title: \"...\"]")
+			return BoolStatus(false)
+		}
+		host := dialogsFor(ctx, "gui_alert")
+		if host == nil {
+			return BoolStatus(false)
+		}
+		message := fmt.Sprintf("%v", ctx.executor.resolveValue(ctx.Args[0]))
+		host.Alert(namedString(ctx, "title", "PawScript"), message)
+		return BoolStatus(true)
+	})
+
+	// gui_confirm - ask a yes or no question
+	// Usage: gui_confirm <question> [, title: "..."]
+	// Returns true for OK, false for Cancel (the status matches, for use in conditions)
+	ps.RegisterCommandInModule("gui", "gui_confirm", func(ctx *Context) Result {
+		if len(ctx.Args) < 1 {
+			ctx.LogError(CatCommand, "Usage: gui_confirm <question> [, title: \"...\"]")
+			ctx.SetResult(false)
+			return BoolStatus(false)
+		}
+		host := dialogsFor(ctx, "gui_confirm")
+		if host == nil {
+			ctx.SetResult(false)
+			return BoolStatus(false)
+		}
+		message := fmt.Sprintf("%v", ctx.executor.resolveValue(ctx.Args[0]))
+		confirmed := host.Confirm(namedString(ctx, "title", "PawScript"), message)
+		ctx.SetResult(confirmed)
+		return BoolStatus(confirmed)
+	})
+
+	// gui_prompt - ask for a line of text
+	// Usage: gui_prompt <message> [, <default>] [, title: "..."]
+	// Returns the text entered, or nil with status false if the user cancels
+	ps.RegisterCommandInModule("gui", "gui_prompt", func(ctx *Context) Result {
+		if len(ctx.Args) < 1 {
+			ctx.LogError(CatCommand, "Usage: gui_prompt <message> [, <default>] [, title: \"...\"]")
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		host := dialogsFor(ctx, "gui_prompt")
+		if host == nil {
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		message := fmt.Sprintf("%v", ctx.executor.resolveValue(ctx.Args[0]))
+		initial := ""
+		if len(ctx.Args) > 1 {
+			initial = fmt.Sprintf("%v", ctx.executor.resolveValue(ctx.Args[1]))
+		}
+		text, ok := host.Prompt(namedString(ctx, "title", "PawScript"), message, initial)
+		if !ok {
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		ctx.SetResult(text)
+		return BoolStatus(true)
+	})
+
+	// gui_file_open - choose an existing file
+	// Usage: gui_file_open [title: "..."] [, dir: <path>] [, filter: "*.paw *.txt"]
+	// Returns the chosen path, or nil with status false if the user cancels
+	// The script still needs file access to the path to read it.
+	ps.RegisterCommandInModule("gui", "gui_file_open", func(ctx *Context) Result {
+		host := dialogsFor(ctx, "gui_file_open")
+		if host == nil {
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		path, ok := host.FileOpen(fileOptions(ctx, "Open File"))
+		if !ok {
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		ctx.SetResult(path)
+		return BoolStatus(true)
+	})
+
+	// gui_file_save - choose where to save a file
+	// Usage: gui_file_save [title: "..."] [, dir: <path>] [, name: <file>] [, filter: "*.txt"]
+	// Returns the chosen path, or nil with status false if the user cancels
+	// The script still needs file access to the path to write it.
+	ps.RegisterCommandInModule("gui", "gui_file_save", func(ctx *Context) Result {
+		host := dialogsFor(ctx, "gui_file_save")
+		if host == nil {
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		path, ok := host.FileSave(fileOptions(ctx, "Save File"))
+		if !ok {
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		ctx.SetResult(path)
+		return BoolStatus(true)
+	})
+}
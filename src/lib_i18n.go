@@ -0,0 +1,172 @@
+package pawscript
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// RegisterI18nLib registers locale-aware formatting and message catalog commands.
+// This library is NOT auto-imported - users must explicitly use IMPORT i18n.
+// Module: i18n
+func (ps *PawScript) RegisterI18nLib() {
+
+	// ==================== i18n:: module ====================
+
+	// Helper to get the locale for a command: explicit locale: arg, else the catalog locale
+	localeFor := func(ctx *Context) string {
+		if locVal, exists := ctx.NamedArgs["locale"]; exists {
+			return fmt.Sprintf("%v", ctx.executor.resolveValue(locVal))
+		}
+		return ps.catalog.Locale()
+	}
+
+	// locale - get or set the current locale
+	// Usage: locale            -> returns current locale, e.g. "de_DE"
+	//        locale "fr_FR"    -> sets locale (affects formatting defaults)
+	ps.RegisterCommandInModule("i18n", "locale", func(ctx *Context) Result {
+		if len(ctx.Args) > 0 {
+			ps.catalog.SetLocale(fmt.Sprintf("%v", ctx.executor.resolveValue(ctx.Args[0])))
+		}
+		ctx.SetResult(ps.catalog.Locale())
+		return BoolStatus(true)
+	})
+
+	// tr_load - load a PSL message catalog file into the current catalog
+	// Usage: tr_load <path> [, clear: true]
+	// Later loads override earlier keys; clear: true empties the catalog first
+	// Nested sections are flattened with dots: (menu: (file: "Datei")) defines "menu.file"
+	ps.RegisterCommandInModule("i18n", "tr_load", func(ctx *Context) Result {
+		if len(ctx.Args) < 1 {
+			ctx.LogError(CatCommand, "Usage: tr_load <path> [, clear: true]")
+			return BoolStatus(false)
+		}
+
+		path := fmt.Sprintf("%v", ctx.executor.resolveValue(ctx.Args[0]))
+		absPath, err := ps.checkPathAccess(ctx, "tr_load", path, false)
+		if err != nil {
+			ctx.LogError(CatIO, fmt.Sprintf("tr_load: %v", err))
+			return BoolStatus(false)
+		}
+
+		content, err := os.ReadFile(absPath)
+		if err != nil {
+			ctx.LogError(CatIO, fmt.Sprintf("tr_load: %v", err))
+			return BoolStatus(false)
+		}
+
+		if clearVal, exists := ctx.NamedArgs["clear"]; exists && toBool(clearVal) {
+			ps.catalog.Clear()
+		}
+		if err := ps.catalog.LoadPSL(string(content)); err != nil {
+			ctx.LogError(CatArgument, fmt.Sprintf("tr_load: %v", err))
+			return BoolStatus(false)
+		}
+		return BoolStatus(true)
+	})
+
+	// tr - translate a message key using the loaded catalog
+	// Usage: tr <key> [, arg1, arg2, ...] [, name: value ...]
+	// {1}, {2}, ... in the message are replaced by positional args, {name} by named args
+	// If the key is not in the catalog, the key itself is used as the message (status false)
+	ps.RegisterCommandInModule("i18n", "tr", func(ctx *Context) Result {
+		if len(ctx.Args) < 1 {
+			ctx.LogError(CatCommand, "Usage: tr <key> [, args...] [, name: value ...]")
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+
+		key := formatArgForDisplay(ctx.Args[0], ctx.executor)
+		args := make([]string, 0, len(ctx.Args)-1)
+		for _, arg := range ctx.Args[1:] {
+			args = append(args, formatArgForDisplay(arg, ctx.executor))
+		}
+		var named map[string]string
+		if len(ctx.NamedArgs) > 0 {
+			named = make(map[string]string, len(ctx.NamedArgs))
+			for k, v := range ctx.NamedArgs {
+				named[k] = formatArgForDisplay(v, ctx.executor)
+			}
+		}
+
+		_, found := ps.catalog.Lookup(key)
+		ctx.SetResult(ps.catalog.Tr(key, args, named))
+		return BoolStatus(found)
+	})
+
+	// format_number - format a number using locale separators
+	// Usage: format_number <value> [, decimals: N] [, locale: "de_DE"]
+	// Without decimals:, uses the shortest exact representation
+	ps.RegisterCommandInModule("i18n", "format_number", func(ctx *Context) Result {
+		if len(ctx.Args) < 1 {
+			ctx.LogError(CatCommand, "Usage: format_number <value> [, decimals: N] [, locale: <locale>]")
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		n, ok := toNumber(ctx.executor.resolveValue(ctx.Args[0]))
+		if !ok {
+			ctx.LogError(CatArgument, fmt.Sprintf("Invalid numeric argument: %v", ctx.Args[0]))
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+
+		decimals := -1
+		if decVal, exists := ctx.NamedArgs["decimals"]; exists {
+			d, ok := toInt64(ctx.executor.resolveValue(decVal))
+			if !ok || d < 0 {
+				ctx.LogError(CatArgument, "decimals must be a non-negative integer")
+				ctx.SetResult(nil)
+				return BoolStatus(false)
+			}
+			decimals = int(d)
+		}
+
+		ctx.SetResult(FormatNumber(n, decimals, localeFor(ctx)))
+		return BoolStatus(true)
+	})
+
+	// format_date - format a date/time using locale conventions
+	// Usage: format_date [<time>] [, style: date|time|datetime] [, locale: "de_DE"]
+	// <time> may be a Unix timestamp (seconds) or an ISO 8601 string as produced by datetime
+	// Without <time>, the current local time is used
+	ps.RegisterCommandInModule("i18n", "format_date", func(ctx *Context) Result {
+		t := time.Now()
+		if len(ctx.Args) > 0 {
+			resolved := ctx.executor.resolveValue(ctx.Args[0])
+			switch v := resolved.(type) {
+			case int64, int, float64:
+				secs, _ := toInt64(v)
+				t = time.Unix(secs, 0)
+			default:
+				s := fmt.Sprintf("%v", v)
+				parsed := false
+				for _, layout := range []string{time.RFC3339, "2006-01-02T15:04Z07:00", "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"} {
+					if pt, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+						t = pt
+						parsed = true
+						break
+					}
+				}
+				if !parsed {
+					ctx.LogError(CatArgument, fmt.Sprintf("format_date: unable to parse time: %s", s))
+					ctx.SetResult(nil)
+					return BoolStatus(false)
+				}
+			}
+		}
+
+		style := ""
+		if styleVal, exists := ctx.NamedArgs["style"]; exists {
+			style = fmt.Sprintf("%v", ctx.executor.resolveValue(styleVal))
+		}
+
+		result, err := FormatDate(t, style, localeFor(ctx))
+		if err != nil {
+			ctx.LogError(CatArgument, fmt.Sprintf("format_date: %v", err))
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		ctx.SetResult(result)
+		return BoolStatus(true)
+	})
+}
@@ -0,0 +1,351 @@
+package pawscript
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/bmp"
+	xdraw "golang.org/x/image/draw"
+)
+
+// parseColorValue converts a script color value to NRGBA
+// Accepts "#rgb", "#rrggbb", "#rrggbbaa", a list (r, g, b [, a]), or (r:, g:, b: [, a:])
+// Channel values are 0-255; alpha defaults to 255
+func parseColorValue(value interface{}, executor *Executor) (color.NRGBA, error) {
+	if executor != nil {
+		value = executor.resolveValue(value)
+	}
+
+	if group, ok := value.(ParenGroup); ok {
+		_, items, named := ParseCommand("dummy " + string(group))
+		value = NewStoredListWithNamed(items, named)
+	}
+	if list, ok := value.(StoredList); ok {
+		channels := []int64{0, 0, 0, 255}
+		named := list.NamedArgs()
+		for i, key := range []string{"r", "g", "b", "a"} {
+			var v interface{}
+			if i < list.Len() {
+				v = list.Get(i)
+			} else if nv, exists := named[key]; exists {
+				v = nv
+			} else if i < 3 {
+				return color.NRGBA{}, fmt.Errorf("color list needs r, g, and b")
+			} else {
+				continue
+			}
+			n, ok := toInt64(v)
+			if !ok || n < 0 || n > 255 {
+				return color.NRGBA{}, fmt.Errorf("color channel out of range 0-255: %v", v)
+			}
+			channels[i] = n
+		}
+		return color.NRGBA{R: uint8(channels[0]), G: uint8(channels[1]), B: uint8(channels[2]), A: uint8(channels[3])}, nil
+	}
+
+	s := strings.TrimPrefix(fmt.Sprintf("%v", value), "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) == 6 {
+		s += "ff"
+	}
+	n, err := strconv.ParseUint(s, 16, 32)
+	if len(s) != 8 || err != nil {
+		return color.NRGBA{}, fmt.Errorf("invalid color: %v", value)
+	}
+	return color.NRGBA{R: uint8(n >> 24), G: uint8(n >> 16), B: uint8(n >> 8), A: uint8(n)}, nil
+}
+
+// RegisterImageLib registers image loading and pixel manipulation commands
+// This library is NOT auto-imported - users must explicitly use IMPORT image.
+// Module: image
+func (ps *PawScript) RegisterImageLib() {
+	// Helper function to set a StoredImage as result with proper reference counting
+	setImageResult := func(ctx *Context, img *StoredImage) {
+		ref := ctx.executor.RegisterObject(img, ObjImage)
+		ctx.state.SetResultWithoutClaim(ref)
+	}
+
+	// Helper function to set a StoredList as result with proper reference counting
+	setListResult := func(ctx *Context, list StoredList) {
+		ref := ctx.executor.RegisterObject(list, ObjList)
+		ctx.state.SetResultWithoutClaim(ref)
+	}
+
+	// Helper to get the image in the first argument
+	imageArg := func(ctx *Context, cmd string) (*StoredImage, bool) {
+		if len(ctx.Args) > 0 {
+			if img, ok := ctx.executor.resolveValue(ctx.Args[0]).(*StoredImage); ok {
+				return img, true
+			}
+		}
+		ctx.LogError(CatType, fmt.Sprintf("%s: first argument must be an image", cmd))
+		return nil, false
+	}
+
+	// Helper to read integer arguments starting at index from
+	intArgs := func(ctx *Context, cmd string, from int, names ...string) ([]int, bool) {
+		values := make([]int, len(names))
+		for i, name := range names {
+			if from+i >= len(ctx.Args) {
+				ctx.LogError(CatCommand, fmt.Sprintf("%s: %s required", cmd, name))
+				return nil, false
+			}
+			n, ok := toInt64(ctx.executor.resolveValue(ctx.Args[from+i]))
+			if !ok {
+				ctx.LogError(CatArgument, fmt.Sprintf("%s: %s must be an integer", cmd, name))
+				return nil, false
+			}
+			values[i] = int(n)
+		}
+		return values, true
+	}
+
+	// ==================== image:: module ====================
+
+	// image_new - create a blank image
+	// Usage: image_new <width>, <height> [, fill: <color>]
+	// Colors are "#rrggbb[aa]" or lists (r, g, b [, a]); the default fill is transparent
+	ps.RegisterCommandInModule("image", "image_new", func(ctx *Context) Result {
+		size, ok := intArgs(ctx, "image_new", 0, "width", "height")
+		if !ok {
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		if err := checkImageSize(int64(size[0]), int64(size[1])); err != nil {
+			ctx.LogError(CatArgument, fmt.Sprintf("image_new: %v", err))
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+
+		img := NewStoredImage(size[0], size[1])
+		if fillVal, exists := ctx.NamedArgs["fill"]; exists {
+			c, err := parseColorValue(fillVal, ctx.executor)
+			if err != nil {
+				ctx.LogError(CatArgument, fmt.Sprintf("image_new: %v", err))
+				ctx.SetResult(nil)
+				return BoolStatus(false)
+			}
+			img.Fill(c)
+		}
+		setImageResult(ctx, img)
+		return BoolStatus(true)
+	})
+
+	// image_load - load a PNG, JPEG, GIF (first frame), or BMP file
+	// Usage: image_load <path>
+	ps.RegisterCommandInModule("image", "image_load", func(ctx *Context) Result {
+		if len(ctx.Args) < 1 {
+			ctx.LogError(CatCommand, "Usage: image_load <path>")
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+
+		path := fmt.Sprintf("%v", ctx.executor.resolveValue(ctx.Args[0]))
+		absPath, err := ps.checkPathAccess(ctx, "image_load", path, false)
+		if err != nil {
+			ctx.LogError(CatIO, fmt.Sprintf("image_load: %v", err))
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+
+		f, err := os.Open(absPath)
+		if err != nil {
+			ctx.LogError(CatIO, fmt.Sprintf("image_load: %v", err))
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		defer f.Close()
+
+		src, _, err := image.Decode(f)
+		if err != nil {
+			ctx.LogError(CatIO, fmt.Sprintf("image_load: %v", err))
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		setImageResult(ctx, NewStoredImageFrom(src))
+		return BoolStatus(true)
+	})
+
+	// image_save - save an image to a file
+	// Usage: image_save <image>, <path> [, format: png|jpeg|gif|bmp] [, quality: 1-100]
+	// The format defaults to the file extension (PNG if unknown); quality applies to JPEG
+	ps.RegisterCommandInModule("image", "image_save", func(ctx *Context) Result {
+		img, ok := imageArg(ctx, "image_save")
+		if !ok {
+			return BoolStatus(false)
+		}
+		if len(ctx.Args) < 2 {
+			ctx.LogError(CatCommand, "Usage: image_save <image>, <path> [, format: png|jpeg|gif|bmp] [, quality: N]")
+			return BoolStatus(false)
+		}
+
+		path := fmt.Sprintf("%v", ctx.executor.resolveValue(ctx.Args[1]))
+		absPath, err := ps.checkPathAccess(ctx, "image_save", path, true)
+		if errors.Is(err, ErrDryRun) {
+			// Dry run: encode the image, but write it nowhere
+			absPath, err = os.DevNull, nil
+		}
+		if err != nil {
+			ctx.LogError(CatIO, fmt.Sprintf("image_save: %v", err))
+			return BoolStatus(false)
+		}
+
+		format := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+		if fmtVal, exists := ctx.NamedArgs["format"]; exists {
+			format = strings.ToLower(fmt.Sprintf("%v", ctx.executor.resolveValue(fmtVal)))
+		}
+		quality := jpeg.DefaultQuality
+		if qVal, exists := ctx.NamedArgs["quality"]; exists {
+			q, ok := toInt64(ctx.executor.resolveValue(qVal))
+			if !ok || q < 1 || q > 100 {
+				ctx.LogError(CatArgument, "image_save: quality must be 1-100")
+				return BoolStatus(false)
+			}
+			quality = int(q)
+		}
+
+		if err := ps.quotas.openFile(); err != nil {
+			ctx.LogError(CatIO, fmt.Sprintf("image_save: %v", err))
+			return BoolStatus(false)
+		}
+		defer ps.quotas.closeFile()
+		f, err := os.Create(absPath)
+		if err != nil {
+			ctx.LogError(CatIO, fmt.Sprintf("image_save: %v", err))
+			return BoolStatus(false)
+		}
+
+		snapshot := img.Snapshot()
+		w := quotaWriter{f, ps.quotas}
+		switch format {
+		case "jpg", "jpeg":
+			err = jpeg.Encode(w, snapshot, &jpeg.Options{Quality: quality})
+		case "gif":
+			err = gif.Encode(w, snapshot, nil)
+		case "bmp":
+			err = bmp.Encode(w, snapshot)
+		default:
+			err = png.Encode(w, snapshot)
+		}
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			ctx.LogError(CatIO, fmt.Sprintf("image_save: %v", err))
+			return BoolStatus(false)
+		}
+		return BoolStatus(true)
+	})
+
+	// image_size - get image dimensions
+	// Usage: image_size <image>
+	// Returns: (width: W, height: H)
+	ps.RegisterCommandInModule("image", "image_size", func(ctx *Context) Result {
+		img, ok := imageArg(ctx, "image_size")
+		if !ok {
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		w, h := img.Size()
+		setListResult(ctx, NewStoredListWithNamed(nil, map[string]interface{}{
+			"width":  int64(w),
+			"height": int64(h),
+		}))
+		return BoolStatus(true)
+	})
+
+	// image_pixel - read a pixel
+	// Usage: image_pixel <image>, <x>, <y>
+	// Returns: (r: R, g: G, b: B, a: A), or nil with status false outside the image
+	ps.RegisterCommandInModule("image", "image_pixel", func(ctx *Context) Result {
+		img, ok := imageArg(ctx, "image_pixel")
+		if !ok {
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		pt, ok := intArgs(ctx, "image_pixel", 1, "x", "y")
+		if !ok {
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		c, inside := img.Pixel(pt[0], pt[1])
+		if !inside {
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		setListResult(ctx, NewStoredListWithNamed(nil, map[string]interface{}{
+			"r": int64(c.R),
+			"g": int64(c.G),
+			"b": int64(c.B),
+			"a": int64(c.A),
+		}))
+		return BoolStatus(true)
+	})
+
+	// image_set_pixel - write a pixel in place
+	// Usage: image_set_pixel <image>, <x>, <y>, <color>
+	// Returns status false (without error) for points outside the image
+	ps.RegisterCommandInModule("image", "image_set_pixel", func(ctx *Context) Result {
+		img, ok := imageArg(ctx, "image_set_pixel")
+		if !ok {
+			return BoolStatus(false)
+		}
+		pt, ok := intArgs(ctx, "image_set_pixel", 1, "x", "y")
+		if !ok {
+			return BoolStatus(false)
+		}
+		if len(ctx.Args) < 4 {
+			ctx.LogError(CatCommand, "Usage: image_set_pixel <image>, <x>, <y>, <color>")
+			return BoolStatus(false)
+		}
+		c, err := parseColorValue(ctx.Args[3], ctx.executor)
+		if err != nil {
+			ctx.LogError(CatArgument, fmt.Sprintf("image_set_pixel: %v", err))
+			return BoolStatus(false)
+		}
+		return BoolStatus(img.SetPixel(pt[0], pt[1], c))
+	})
+
+	// image_resize - scale an image to a new size
+	// Usage: image_resize <image>, <width>, <height> [, smooth: true]
+	// Returns a new image; smooth: false uses nearest-neighbor (crisp pixel art)
+	ps.RegisterCommandInModule("image", "image_resize", func(ctx *Context) Result {
+		img, ok := imageArg(ctx, "image_resize")
+		if !ok {
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		size, ok := intArgs(ctx, "image_resize", 1, "width", "height")
+		if !ok {
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		if err := checkImageSize(int64(size[0]), int64(size[1])); err != nil {
+			ctx.LogError(CatArgument, fmt.Sprintf("image_resize: %v", err))
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+
+		var scaler xdraw.Scaler = xdraw.CatmullRom
+		if smoothVal, exists := ctx.NamedArgs["smooth"]; exists && !toBool(smoothVal) {
+			scaler = xdraw.NearestNeighbor
+		}
+
+		src := img.Snapshot()
+		dst := image.NewNRGBA(image.Rect(0, 0, size[0], size[1]))
+		scaler.Scale(dst, dst.Bounds(), src, src.Bounds(), xdraw.Src, nil)
+		setImageResult(ctx, &StoredImage{img: dst})
+		return BoolStatus(true)
+	})
+}
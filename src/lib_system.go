@@ -8,11 +8,13 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 )
 
 // channelReader wraps a StoredChannel as an io.Reader
@@ -52,7 +54,8 @@ type channelWriter struct {
 
 func (w *channelWriter) Write(p []byte) (n int, err error) {
 	if w.ch.NativeSend != nil {
-		err = w.ch.NativeSend(p)
+		// Native channels take the bytes as they are
+		err = ChannelSend(w.ch, p)
 		if err != nil {
 			return 0, err
 		}
@@ -530,6 +533,204 @@ func (ps *PawScript) RegisterSystemLib(scriptArgs []string) {
 		return BoolStatus(true)
 	})
 
+	// sys_info - structured information about the host system
+	// Usage: sys_info
+	// Returns: (os:, arch:, cpus:, hostname:, pid:, go_version:,
+	//           memory: (total:, available:, process:), terminal: (type:, ansi:, color:, ...), gui:)
+	// Memory values are in bytes; total/available are 0 when the platform doesn't report them
+	// Terminal capabilities are those of the current #out channel
+	// gui: is true when the host can show the gui:: dialogs
+	ps.RegisterCommandInModule("os", "sys_info", func(ctx *Context) Result {
+		hostname, err := os.Hostname()
+		if err != nil {
+			hostname = ""
+		}
+
+		var memStats runtime.MemStats
+		runtime.ReadMemStats(&memStats)
+		total, available, _ := systemMemory()
+		memory := NewStoredListWithNamed(nil, map[string]interface{}{
+			"total":     int64(total),
+			"available": int64(available),
+			"process":   int64(memStats.Sys),
+		})
+		memRef := ctx.executor.RegisterObject(memory, ObjList)
+
+		terminalList := resolveChannel(ctx, "#out").GetTerminalCapabilities().toStoredList()
+		termRef := ctx.executor.RegisterObject(terminalList, ObjList)
+
+		info := NewStoredListWithNamed(nil, map[string]interface{}{
+			"os":         QuotedString(runtime.GOOS),
+			"arch":       QuotedString(runtime.GOARCH),
+			"cpus":       int64(runtime.NumCPU()),
+			"hostname":   QuotedString(hostname),
+			"pid":        int64(os.Getpid()),
+			"go_version": QuotedString(runtime.Version()),
+			"memory":     memRef,
+			"terminal":   termRef,
+			"gui":        ps.dialogs() != nil,
+		})
+		setListResult(ctx, info)
+		return BoolStatus(true)
+	})
+
+	// env_get - read an environment variable
+	// Usage: env_get <name> [, <default>]
+	// Only variables allowed by Config.EnvAllowlist are visible
+	// Returns the value, or the default (nil if none) with status false if unset or hidden
+	ps.RegisterCommandInModule("os", "env_get", func(ctx *Context) Result {
+		if len(ctx.Args) < 1 {
+			ctx.LogError(CatCommand, "Usage: env_get <name> [, <default>]")
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		name := fmt.Sprintf("%v", ctx.executor.resolveValue(ctx.Args[0]))
+		if value, ok := ps.lookupScriptEnv(name); ok {
+			ctx.SetResult(value)
+			return BoolStatus(true)
+		}
+		if len(ctx.Args) > 1 {
+			ctx.SetResult(ctx.Args[1])
+		} else {
+			ctx.SetResult(nil)
+		}
+		return BoolStatus(false)
+	})
+
+	// env_list - list the environment variables scripts may see
+	// Usage: env_list
+	// Returns: (NAME: "value", ...) for each variable allowed by Config.EnvAllowlist
+	ps.RegisterCommandInModule("os", "env_list", func(ctx *Context) Result {
+		vars := make(map[string]interface{})
+		for _, entry := range ps.scriptEnviron() {
+			name, value, _ := strings.Cut(entry, "=")
+			vars[name] = QuotedString(value)
+		}
+		setListResult(ctx, NewStoredListWithNamed(nil, vars))
+		return BoolStatus(true)
+	})
+
+	// secret_get - read a secret from the OS keychain
+	// Usage: secret_get <key>
+	// Secrets are namespaced per script (by script path), so scripts can't read each other's secrets
+	// Returns the secret string, or nil with status false if the key isn't stored
+	ps.RegisterCommandInModule("os", "secret_get", func(ctx *Context) Result {
+		if len(ctx.Args) < 1 {
+			ctx.LogError(CatCommand, "Usage: secret_get <key>")
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		key := fmt.Sprintf("%v", ctx.executor.resolveValue(ctx.Args[0]))
+
+		value, err := ps.secretStore().Get(ps.secretService(), key)
+		if err == ErrSecretNotFound {
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		if err != nil {
+			ctx.LogError(CatIO, fmt.Sprintf("secret_get: %v", err))
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		ctx.SetResult(value)
+		return BoolStatus(true)
+	})
+
+	// secret_set - store a secret in the OS keychain
+	// Usage: secret_set <key>, <value>
+	// Overwrites any existing secret for the key in this script's namespace
+	ps.RegisterCommandInModule("os", "secret_set", func(ctx *Context) Result {
+		if len(ctx.Args) < 2 {
+			ctx.LogError(CatCommand, "Usage: secret_set <key>, <value>")
+			return BoolStatus(false)
+		}
+		key := fmt.Sprintf("%v", ctx.executor.resolveValue(ctx.Args[0]))
+		value := formatArgForDisplay(ctx.Args[1], ctx.executor)
+
+		if err := ps.secretStore().Set(ps.secretService(), key, value); err != nil {
+			ctx.LogError(CatIO, fmt.Sprintf("secret_set: %v", err))
+			return BoolStatus(false)
+		}
+		return BoolStatus(true)
+	})
+
+	// secret_delete - remove a secret from the OS keychain
+	// Usage: secret_delete <key>
+	// Returns status false if the key wasn't stored
+	ps.RegisterCommandInModule("os", "secret_delete", func(ctx *Context) Result {
+		if len(ctx.Args) < 1 {
+			ctx.LogError(CatCommand, "Usage: secret_delete <key>")
+			return BoolStatus(false)
+		}
+		key := fmt.Sprintf("%v", ctx.executor.resolveValue(ctx.Args[0]))
+
+		err := ps.secretStore().Delete(ps.secretService(), key)
+		if err == ErrSecretNotFound {
+			return BoolStatus(false)
+		}
+		if err != nil {
+			ctx.LogError(CatIO, fmt.Sprintf("secret_delete: %v", err))
+			return BoolStatus(false)
+		}
+		return BoolStatus(true)
+	})
+
+	// secret_encrypt - seal a list of named values for storing in a PSL file
+	// Usage: secret_encrypt <key>, <list>
+	// Returns (@encrypted: "<data>") for storing under key (dotted for a nested key, as in
+	// servers.prod); secret_decrypt and hosts reading PSL with secrets open it there only
+	// The encryption key is kept in the OS keychain, shared by every script unlike secret_set keys
+	ps.RegisterCommandInModule("os", "secret_encrypt", func(ctx *Context) Result {
+		if len(ctx.Args) < 2 {
+			ctx.LogError(CatCommand, "Usage: secret_encrypt <key>, <list>")
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		path := fmt.Sprintf("%v", ctx.executor.resolveValue(ctx.Args[0]))
+		value, err := encodeChannelValue(ctx.Args[1], ctx.executor)
+		section, ok := value.(PSLMap)
+		if err != nil || !ok {
+			ctx.LogError(CatArgument, "secret_encrypt: expected a list of named values")
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		block, err := EncryptPSL(path, section, ps.secretStore())
+		if err != nil {
+			ctx.LogError(CatIO, fmt.Sprintf("secret_encrypt: %v", err))
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		ctx.state.SetResultWithoutClaim(decodeChannelValue(block, ctx.executor))
+		return BoolStatus(true)
+	})
+
+	// secret_decrypt - open the encrypted sections of a list read from a PSL file
+	// Usage: secret_decrypt <list>
+	// Returns a copy of the list with every (@encrypted: ...) section replaced by its contents
+	// Fails if any section can't be opened (no key, keychain access denied, or wrong key)
+	ps.RegisterCommandInModule("os", "secret_decrypt", func(ctx *Context) Result {
+		if len(ctx.Args) < 1 {
+			ctx.LogError(CatCommand, "Usage: secret_decrypt <list>")
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		value, err := encodeChannelValue(ctx.Args[0], ctx.executor)
+		config, ok := value.(PSLMap)
+		if err != nil || !ok {
+			ctx.LogError(CatArgument, "secret_decrypt: expected a list of named values")
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		opened, err := DecryptPSL(config, ps.secretStore())
+		if err != nil {
+			ctx.LogError(CatIO, fmt.Sprintf("secret_decrypt: %v", err))
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		ctx.state.SetResultWithoutClaim(decodeChannelValue(opened, ctx.executor))
+		return BoolStatus(true)
+	})
+
 	// exec - execute external command and capture output
 	ps.RegisterCommandInModule("os", "exec", func(ctx *Context) Result {
 		if len(ctx.Args) == 0 {
@@ -549,6 +750,14 @@ func (ps *PawScript) RegisterSystemLib(scriptArgs []string) {
 			}
 		}
 
+		// Dry run: record the program instead of running it
+		if ps.dryRun() {
+			ps.dryRunExec(resolvedCmd)
+			ps.audit(ctx, "exec", AuditExec, cmdName, ErrDryRun)
+			ctx.state.SetResultWithoutClaim("")
+			return BoolStatus(true)
+		}
+
 		// Validate exec access against ExecRoots if configured
 		if ps.config != nil && ps.config.FileAccess != nil {
 			fileAccess := ps.config.FileAccess
@@ -590,7 +799,9 @@ func (ps *PawScript) RegisterSystemLib(scriptArgs []string) {
 					}
 				}
 				if !allowed {
-					ctx.LogError(CatIO, "exec: access denied: command outside allowed roots")
+					err := fmt.Errorf("access denied: command outside allowed roots")
+					ps.audit(ctx, "exec", AuditExec, cmdName, err)
+					ctx.LogError(CatIO, fmt.Sprintf("exec: %v", err))
 					return BoolStatus(false)
 				}
 
@@ -605,7 +816,9 @@ func (ps *PawScript) RegisterSystemLib(scriptArgs []string) {
 						}
 						absWriteRoot = filepath.Clean(absWriteRoot)
 						if pathHasPrefix(cmdPath, absWriteRoot+string(filepath.Separator)) || pathEquals(cmdPath, absWriteRoot) {
-							ctx.LogError(CatIO, "exec: access denied: cannot execute from writable directory (security restriction)")
+							err := fmt.Errorf("access denied: cannot execute from writable directory (security restriction)")
+							ps.audit(ctx, "exec", AuditExec, cmdName, err)
+							ctx.LogError(CatIO, fmt.Sprintf("exec: %v", err))
 							return BoolStatus(false)
 						}
 					}
@@ -618,13 +831,32 @@ func (ps *PawScript) RegisterSystemLib(scriptArgs []string) {
 			cmdArgs = append(cmdArgs, fmt.Sprintf("%v", ctx.Args[i]))
 		}
 
-		cmd := exec.Command(resolvedCmd, cmdArgs...)
+		// Let the host inspect, refuse or rewrite the invocation
+		request, err := ps.inspectExec(cmdName, resolvedCmd, cmdArgs)
+		if err != nil {
+			err = fmt.Errorf("refused by host: %v", err)
+			ps.audit(ctx, "exec", AuditExec, cmdName, err)
+			ctx.LogError(CatIO, fmt.Sprintf("exec: %v", err))
+			return BoolStatus(false)
+		}
+
+		if err := ps.quotas.startProcess(); err != nil {
+			ps.audit(ctx, "exec", AuditExec, cmdName, err)
+			ctx.LogError(CatIO, fmt.Sprintf("exec: %v", err))
+			return BoolStatus(false)
+		}
+		ps.audit(ctx, "exec", AuditExec, cmdName, nil)
+		cmd := exec.Command(request.Path, request.Args...)
+		if ps.envAllowlist() != nil {
+			// Subprocesses only see the variables scripts may see
+			cmd.Env = ps.scriptEnviron()
+		}
 
 		var stdoutBuf, stderrBuf bytes.Buffer
 		cmd.Stdout = &stdoutBuf
 		cmd.Stderr = &stderrBuf
 
-		err := cmd.Run()
+		err = ps.runProcess(cmd)
 
 		stdout := stdoutBuf.String()
 		stderr := stderrBuf.String()
@@ -706,7 +938,7 @@ func (ps *PawScript) RegisterSystemLib(scriptArgs []string) {
 			text += formatArgForDisplay(arg, ctx.executor)
 		}
 
-		err := ChannelSend(ch, text)
+		err := ChannelSendUntil(ch, text, ps.cancelDone())
 		if err != nil {
 			ctx.LogError(CatIO, fmt.Sprintf("Failed to write: %v", err))
 			return BoolStatus(false)
@@ -786,7 +1018,7 @@ func (ps *PawScript) RegisterSystemLib(scriptArgs []string) {
 		}
 
 		ps.logger.DebugCat(CatIO,"outputLineCommand: calling ChannelSend with text=%q", text)
-		err := ChannelSend(ch, text+"\n")
+		err := ChannelSendUntil(ch, text+"\n", ps.cancelDone())
 		if err != nil {
 			ps.logger.DebugCat(CatIO,"outputLineCommand: ChannelSend returned error: %v", err)
 			ctx.LogError(CatIO, fmt.Sprintf("Failed to write: %v", err))
@@ -1529,6 +1761,186 @@ func (ps *PawScript) RegisterSystemLib(scriptArgs []string) {
 		return BoolStatus(true)
 	})
 
+	// Helper to get the terminal capabilities for the channel in the first argument (default #out)
+	// The system terminal's size is re-queried so scripts see resizes; GUI terminals update theirs live
+	getTerminalCaps := func(ctx *Context) *TerminalCapabilities {
+		outCh, _, _ := getOutputChannel(ctx, "#out")
+		caps := outCh.GetTerminalCapabilities()
+		caps.refreshSize()
+		return caps
+	}
+
+	// term_size - current terminal dimensions
+	// Usage: term_size [#channel]
+	// Returns: (width: cols, height: rows)
+	ps.RegisterCommandInModule("io", "term_size", func(ctx *Context) Result {
+		width, height := getTerminalCaps(ctx).GetSize()
+		setListResult(ctx, NewStoredListWithNamed(nil, map[string]interface{}{
+			"width":  int64(width),
+			"height": int64(height),
+		}))
+		return BoolStatus(true)
+	})
+
+	// term_colors - color depth of the terminal
+	// Usage: term_colors [#channel]
+	// Returns 0 (no color), 8, 16, 256, or 24 (truecolor)
+	ps.RegisterCommandInModule("io", "term_colors", func(ctx *Context) Result {
+		caps := getTerminalCaps(ctx)
+		caps.mu.RLock()
+		depth := caps.ColorDepth
+		if !caps.SupportsColor {
+			depth = 0
+		}
+		caps.mu.RUnlock()
+		ctx.SetResult(int64(depth))
+		return BoolStatus(true)
+	})
+
+	// term_is_dark - whether the terminal has a dark background
+	// Usage: term_is_dark [#channel]
+	// Returns true or false (also as status); assumed dark when the terminal doesn't report it
+	ps.RegisterCommandInModule("io", "term_is_dark", func(ctx *Context) Result {
+		dark := getTerminalCaps(ctx).IsDarkTheme()
+		ctx.SetResult(dark)
+		return BoolStatus(dark)
+	})
+
+	// search - search the terminal's scrollback (PurfecTerm OSC 7004)
+	// Usage: search <pattern> | search next: true | search prev: true | search clear: true
+	// A pattern (regular expression) highlights its matches and shows the newest one;
+	// next: shows the next older match and prev: the next newer one
+	// Returns true if the request was sent; false if the output isn't an ANSI terminal
+	ps.RegisterCommandInModule("io", "search", func(ctx *Context) Result {
+		outCh, _, found := getOutputChannel(ctx, "#out")
+
+		var sequence string
+		switch {
+		case len(ctx.Args) > 0:
+			pattern := fmt.Sprintf("%v", ctx.Args[0])
+			// A raw control character (BEL, ESC, ...) would end the sequence early and let
+			// the rest reach the terminal as escape codes; regexp escapes like \t still work
+			if strings.IndexFunc(pattern, unicode.IsControl) >= 0 {
+				ctx.LogError(CatArgument, "search: pattern can't contain control characters")
+				return BoolStatus(false)
+			}
+			if _, err := regexp.Compile(pattern); err != nil {
+				ctx.LogError(CatArgument, fmt.Sprintf("search: invalid pattern: %v", err))
+				return BoolStatus(false)
+			}
+			sequence = "\x1b]7004;s;" + pattern + "\x07"
+		case isTruthy(ctx.NamedArgs["next"]):
+			sequence = "\x1b]7004;n\x07"
+		case isTruthy(ctx.NamedArgs["prev"]):
+			sequence = "\x1b]7004;p\x07"
+		case isTruthy(ctx.NamedArgs["clear"]):
+			sequence = "\x1b]7004;c\x07"
+		default:
+			ctx.LogError(CatCommand, "Usage: search <pattern> | search next: true | search prev: true | search clear: true")
+			return BoolStatus(false)
+		}
+
+		if !ChannelIsTerminal(outCh) || !ChannelSupportsANSI(outCh) {
+			return BoolStatus(false)
+		}
+		if found && outCh != nil {
+			_ = ChannelSend(outCh, sequence)
+		} else {
+			fmt.Print(sequence)
+		}
+		return BoolStatus(true)
+	})
+
+	// pane - split the terminal into panes, each with a screen of its own (PurfecTerm OSC 7005)
+	// Usage: pane <id> | pane split: <id> [from: <id>] [below: true] [size: <percent>] |
+	//        pane resize: <id>, size: <percent> | pane focus: <id> | pane close: <id>
+	// pane <id> sends the output that follows to that pane (0 is the terminal's own screen);
+	// split: makes pane <id> to the right of pane from: (default 0), or below it, taking
+	// size: percent of its space (default 50); resize: sets a pane's share of its split
+	// Returns true if the request was sent; false if the output isn't an ANSI terminal
+	ps.RegisterCommandInModule("io", "pane", func(ctx *Context) Result {
+		outCh, _, found := getOutputChannel(ctx, "#out")
+
+		usage := "Usage: pane <id> | pane split: <id> [from: <id>] [below: true] [size: <percent>] | pane resize: <id>, size: <percent> | pane focus: <id> | pane close: <id>"
+		paneID := func(val interface{}) (int64, bool) {
+			id, ok := toInt64(val)
+			if !ok || id < 0 {
+				ctx.LogError(CatArgument, fmt.Sprintf("pane: invalid pane ID: %v", val))
+				return 0, false
+			}
+			return id, true
+		}
+		size := int64(50)
+		if val, ok := ctx.NamedArgs["size"]; ok {
+			n, ok := toInt64(val)
+			if !ok || n <= 0 || n >= 100 {
+				ctx.LogError(CatArgument, fmt.Sprintf("pane: size must be a percentage between 0 and 100: %v", val))
+				return BoolStatus(false)
+			}
+			size = n
+		}
+
+		var sequence string
+		if len(ctx.Args) > 0 {
+			id, ok := paneID(ctx.Args[0])
+			if !ok {
+				return BoolStatus(false)
+			}
+			sequence = fmt.Sprintf("\x1b]7005;w;%d\x07", id)
+		} else if val, ok := ctx.NamedArgs["split"]; ok {
+			id, ok := paneID(val)
+			if !ok {
+				return BoolStatus(false)
+			}
+			from := int64(0)
+			if val, ok := ctx.NamedArgs["from"]; ok {
+				if from, ok = paneID(val); !ok {
+					return BoolStatus(false)
+				}
+			}
+			dir := "h"
+			if isTruthy(ctx.NamedArgs["below"]) {
+				dir = "v"
+			}
+			sequence = fmt.Sprintf("\x1b]7005;s;%d;%d;%s;%d\x07", id, from, dir, size)
+		} else if val, ok := ctx.NamedArgs["resize"]; ok {
+			id, ok := paneID(val)
+			if !ok {
+				return BoolStatus(false)
+			}
+			if _, ok := ctx.NamedArgs["size"]; !ok {
+				ctx.LogError(CatCommand, usage)
+				return BoolStatus(false)
+			}
+			sequence = fmt.Sprintf("\x1b]7005;r;%d;%d\x07", id, size)
+		} else if val, ok := ctx.NamedArgs["focus"]; ok {
+			id, ok := paneID(val)
+			if !ok {
+				return BoolStatus(false)
+			}
+			sequence = fmt.Sprintf("\x1b]7005;f;%d\x07", id)
+		} else if val, ok := ctx.NamedArgs["close"]; ok {
+			id, ok := paneID(val)
+			if !ok {
+				return BoolStatus(false)
+			}
+			sequence = fmt.Sprintf("\x1b]7005;c;%d\x07", id)
+		} else {
+			ctx.LogError(CatCommand, usage)
+			return BoolStatus(false)
+		}
+
+		if !ChannelIsTerminal(outCh) || !ChannelSupportsANSI(outCh) {
+			return BoolStatus(false)
+		}
+		if found && outCh != nil {
+			_ = ChannelSend(outCh, sequence)
+		} else {
+			fmt.Print(sequence)
+		}
+		return BoolStatus(true)
+	})
+
 	// clear - clear terminal screen or specific regions
 	// With no args: clear screen (ANSI in terminal, separator if redirected)
 	// With arg: "eol", "bol", "line", "eos", "bos", "screen" for specific ANSI clear modes
@@ -0,0 +1,32 @@
+package pawscript
+
+import (
+	"io"
+	"testing"
+)
+
+func TestSearchRejectsControlCharacters(t *testing.T) {
+	out := make(chan interface{}, 16)
+	caps := &TerminalCapabilities{IsTerminal: true, SupportsANSI: true}
+	ps := New(&Config{Stderr: io.Discard})
+	ps.RegisterStandardLibraryWithIO([]string{}, &IOChannelConfig{
+		Stdout: NewChannelFromGo(out, nil, caps),
+		Stderr: NewChannelFromGo(make(chan interface{}, 16), nil, nil),
+	})
+
+	for _, pattern := range []string{"a\x07b", "a\x1b]0;pwned\x07", "a\u009cb"} {
+		if result := ps.Execute("IMPORT io; search \"" + pattern + "\""); result != BoolStatus(false) {
+			t.Errorf("search %q: got %v, want false", pattern, result)
+		}
+	}
+	if len(out) != 0 {
+		t.Errorf("%d writes reached the terminal, want none", len(out))
+	}
+
+	if result := ps.Execute(`IMPORT io; search "err\\d+"`); result != BoolStatus(true) {
+		t.Fatalf("search: got %v, want true", result)
+	}
+	if got := <-out; got != "\x1b]7004;s;err\\d+\x07" {
+		t.Errorf("sent %q", got)
+	}
+}
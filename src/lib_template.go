@@ -0,0 +1,246 @@
+package pawscript
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+)
+
+// templateNodeKind identifies the kind of a parsed template node
+type templateNodeKind int
+
+const (
+	templateText     templateNodeKind = iota // literal text
+	templateVar                              // {{name}} - escaped value
+	templateRaw                              // {{{name}}} or {{& name}} - unescaped value
+	templateSection                          // {{#name}}...{{/name}}
+	templateInverted                         // {{^name}}...{{/name}}
+)
+
+// templateNode is a single element of a parsed template
+type templateNode struct {
+	kind     templateNodeKind
+	text     string // literal text (templateText) or tag name
+	children []templateNode
+}
+
+// parseTemplate parses mustache-like template source into a node tree
+// open and close are the tag delimiters (normally "{{" and "}}")
+// Supported tags: {{name}}, {{{name}}}, {{& name}}, {{#name}}, {{^name}}, {{/name}}, {{! comment}}
+func parseTemplate(src, open, close string) ([]templateNode, error) {
+	type frame struct {
+		name  string
+		kind  templateNodeKind
+		nodes []templateNode
+	}
+	stack := []frame{{}}
+
+	pos := 0
+	for pos < len(src) {
+		start := strings.Index(src[pos:], open)
+		if start < 0 {
+			stack[len(stack)-1].nodes = append(stack[len(stack)-1].nodes, templateNode{kind: templateText, text: src[pos:]})
+			break
+		}
+		start += pos
+		if start > pos {
+			stack[len(stack)-1].nodes = append(stack[len(stack)-1].nodes, templateNode{kind: templateText, text: src[pos:start]})
+		}
+
+		tagStart := start + len(open)
+		closeDelim := close
+		triple := false
+		// Triple mustache {{{name}}} only applies with the default delimiters
+		if open == "{{" && close == "}}" && strings.HasPrefix(src[tagStart:], "{") {
+			triple = true
+			tagStart++
+			closeDelim = "}" + close
+		}
+
+		end := strings.Index(src[tagStart:], closeDelim)
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed tag at offset %d", start)
+		}
+		end += tagStart
+		tag := strings.TrimSpace(src[tagStart:end])
+		pos = end + len(closeDelim)
+
+		if triple {
+			stack[len(stack)-1].nodes = append(stack[len(stack)-1].nodes, templateNode{kind: templateRaw, text: tag})
+			continue
+		}
+
+		if tag == "" {
+			return nil, fmt.Errorf("empty tag at offset %d", start)
+		}
+
+		switch tag[0] {
+		case '!':
+			// Comment - produces no output
+		case '&':
+			stack[len(stack)-1].nodes = append(stack[len(stack)-1].nodes, templateNode{kind: templateRaw, text: strings.TrimSpace(tag[1:])})
+		case '#', '^':
+			kind := templateSection
+			if tag[0] == '^' {
+				kind = templateInverted
+			}
+			stack = append(stack, frame{name: strings.TrimSpace(tag[1:]), kind: kind})
+		case '/':
+			name := strings.TrimSpace(tag[1:])
+			if len(stack) < 2 {
+				return nil, fmt.Errorf("unexpected closing tag '%s' at offset %d", name, start)
+			}
+			top := stack[len(stack)-1]
+			if top.name != name {
+				return nil, fmt.Errorf("closing tag '%s' does not match open section '%s' at offset %d", name, top.name, start)
+			}
+			stack = stack[:len(stack)-1]
+			stack[len(stack)-1].nodes = append(stack[len(stack)-1].nodes, templateNode{kind: top.kind, text: top.name, children: top.nodes})
+		default:
+			stack[len(stack)-1].nodes = append(stack[len(stack)-1].nodes, templateNode{kind: templateVar, text: tag})
+		}
+	}
+
+	if len(stack) > 1 {
+		return nil, fmt.Errorf("unclosed section '%s'", stack[len(stack)-1].name)
+	}
+	return stack[0].nodes, nil
+}
+
+// templateEscaper returns the escaping function for the named escape mode
+// Modes: "html" (default), "url", "none"
+func templateEscaper(mode string) (func(string) string, bool) {
+	switch strings.ToLower(mode) {
+	case "", "html", "xml":
+		return html.EscapeString, true
+	case "url":
+		return url.QueryEscape, true
+	case "none", "raw":
+		return func(s string) string { return s }, true
+	}
+	return nil, false
+}
+
+// templateLookup resolves a (possibly dotted) name against the context stack
+// The innermost context that has the first name segment wins, as in mustache
+// "." refers to the current context value itself
+func templateLookup(name string, stack []interface{}, executor *Executor) (interface{}, bool) {
+	if name == "." {
+		if len(stack) == 0 {
+			return nil, false
+		}
+		return stack[len(stack)-1], true
+	}
+
+	parts := strings.Split(name, ".")
+	var value interface{}
+	found := false
+	for i := len(stack) - 1; i >= 0; i-- {
+		if v, ok := templateField(stack[i], parts[0], executor); ok {
+			value = v
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, false
+	}
+
+	for _, part := range parts[1:] {
+		v, ok := templateField(value, part, executor)
+		if !ok {
+			return nil, false
+		}
+		value = v
+	}
+	return value, true
+}
+
+// templateField looks up a single key in a context value
+// Lists are searched by named argument, or by index for numeric keys
+func templateField(ctxValue interface{}, key string, executor *Executor) (interface{}, bool) {
+	if executor != nil {
+		ctxValue = executor.resolveValue(ctxValue)
+	}
+	list, ok := ctxValue.(StoredList)
+	if !ok {
+		return nil, false
+	}
+	if named := list.NamedArgs(); named != nil {
+		if v, exists := named[key]; exists {
+			return v, true
+		}
+	}
+	var idx int
+	if _, err := fmt.Sscanf(key, "%d", &idx); err == nil && fmt.Sprint(idx) == key {
+		if idx >= 0 && idx < list.Len() {
+			return list.Get(idx), true
+		}
+	}
+	return nil, false
+}
+
+// renderTemplate renders parsed nodes against the context stack
+func renderTemplate(sb *strings.Builder, nodes []templateNode, stack []interface{}, escape func(string) string, executor *Executor) {
+	for _, node := range nodes {
+		switch node.kind {
+		case templateText:
+			sb.WriteString(node.text)
+		case templateVar, templateRaw:
+			value, ok := templateLookup(node.text, stack, executor)
+			if !ok || value == nil {
+				continue
+			}
+			if _, isUndef := value.(ActualUndefined); isUndef {
+				continue
+			}
+			text := formatArgForDisplay(value, executor)
+			if node.kind == templateVar {
+				text = escape(text)
+			}
+			sb.WriteString(text)
+		case templateSection, templateInverted:
+			value, _ := templateLookup(node.text, stack, executor)
+			if executor != nil {
+				value = executor.resolveValue(value)
+			}
+
+			// Determine truthiness: lists are truthy when non-empty
+			truthy := false
+			var items []interface{}
+			isLoop := false
+			switch v := value.(type) {
+			case StoredList:
+				if v.Len() > 0 {
+					truthy = true
+					isLoop = true
+					items = v.Items()
+				} else if len(v.NamedArgs()) > 0 {
+					truthy = true
+				}
+			case ActualUndefined:
+				truthy = false
+			default:
+				truthy = isTruthy(v)
+			}
+
+			if node.kind == templateInverted {
+				if !truthy {
+					renderTemplate(sb, node.children, stack, escape, executor)
+				}
+				continue
+			}
+			if !truthy {
+				continue
+			}
+			if isLoop {
+				for _, item := range items {
+					renderTemplate(sb, node.children, append(stack, item), escape, executor)
+				}
+			} else {
+				renderTemplate(sb, node.children, append(stack, value), escape, executor)
+			}
+		}
+	}
+}
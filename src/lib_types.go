@@ -2231,6 +2231,62 @@ func (ps *PawScript) RegisterTypesLib() {
 		return BoolStatus(true)
 	})
 
+	// template - render a mustache-like template against a list of named values
+	// Usage: template <template>, <data> [, escape: html|url|none] [, delims: "<% %>"]
+	// Tags: {{name}} escaped value, {{{name}}} or {{& name}} raw value,
+	//       {{#name}}...{{/name}} section (loops over positional items, enters named lists,
+	//       renders once for other truthy values), {{^name}}...{{/name}} inverted section,
+	//       {{! comment}}, {{.}} current item, {{a.b}} dotted lookup
+	// Returns the rendered string
+	ps.RegisterCommandInModule("strlist", "template", func(ctx *Context) Result {
+		if len(ctx.Args) < 1 {
+			ctx.LogError(CatCommand, "Usage: template <template>, <data> [, escape: html|url|none] [, delims: \"<% %>\"]")
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+
+		src, _ := extractStringContent(ctx.Args[0], ctx.executor)
+
+		escapeMode := ""
+		if escVal, hasEsc := ctx.NamedArgs["escape"]; hasEsc {
+			escapeMode = fmt.Sprintf("%v", ctx.executor.resolveValue(escVal))
+		}
+		escape, ok := templateEscaper(escapeMode)
+		if !ok {
+			ctx.LogError(CatArgument, fmt.Sprintf("template: unknown escape mode '%s' (expected html, url, or none)", escapeMode))
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+
+		open, close := "{{", "}}"
+		if delimVal, hasDelims := ctx.NamedArgs["delims"]; hasDelims {
+			parts := strings.Fields(fmt.Sprintf("%v", ctx.executor.resolveValue(delimVal)))
+			if len(parts) != 2 {
+				ctx.LogError(CatArgument, "template: delims must be two space-separated delimiters, e.g. \"<% %>\"")
+				ctx.SetResult(nil)
+				return BoolStatus(false)
+			}
+			open, close = parts[0], parts[1]
+		}
+
+		nodes, err := parseTemplate(src, open, close)
+		if err != nil {
+			ctx.LogError(CatArgument, fmt.Sprintf("template: %v", err))
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+
+		var stack []interface{}
+		if len(ctx.Args) > 1 {
+			stack = append(stack, ctx.executor.resolveValue(ctx.Args[1]))
+		}
+
+		var sb strings.Builder
+		renderTemplate(&sb, nodes, stack, escape, ctx.executor)
+		ctx.SetResult(sb.String())
+		return BoolStatus(true)
+	})
+
 	// string - convert any value to its string representation
 	// Usage: string 123      -> "123"
 	//        string 3.14     -> "3.14"
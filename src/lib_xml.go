@@ -0,0 +1,416 @@
+package pawscript
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// xmlElement is an element of a parsed XML document before conversion to lists
+type xmlElement struct {
+	tag      string
+	attrs    []xml.Attr
+	text     strings.Builder
+	children []*xmlElement
+}
+
+// parseXMLTree parses an XML document and returns its root element
+// Namespace prefixes are dropped: <atom:link> becomes tag "link"
+func parseXMLTree(src string, strict bool) (*xmlElement, error) {
+	decoder := xml.NewDecoder(strings.NewReader(src))
+	decoder.Strict = strict
+	if !strict {
+		decoder.AutoClose = xml.HTMLAutoClose
+		decoder.Entity = xml.HTMLEntity
+	}
+
+	var root *xmlElement
+	var stack []*xmlElement
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			elem := &xmlElement{tag: t.Name.Local}
+			for _, attr := range t.Attr {
+				if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+					continue
+				}
+				elem.attrs = append(elem.attrs, attr)
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, elem)
+			} else if root == nil {
+				root = elem
+			} else {
+				return nil, fmt.Errorf("multiple root elements")
+			}
+			stack = append(stack, elem)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, fmt.Errorf("no root element")
+	}
+	return root, nil
+}
+
+// toStoredList converts an element into a node list:
+// (child1, child2, ..., tag: "name", attrs: (key: "value", ...), text: "...")
+// Child elements are the positional items; text is the element's own trimmed character data
+func (el *xmlElement) toStoredList(executor *Executor) StoredList {
+	children := make([]interface{}, 0, len(el.children))
+	for _, child := range el.children {
+		children = append(children, executor.RegisterObject(child.toStoredList(executor), ObjList))
+	}
+
+	attrs := make(map[string]interface{}, len(el.attrs))
+	for _, attr := range el.attrs {
+		attrs[attr.Name.Local] = QuotedString(attr.Value)
+	}
+
+	return NewStoredListWithNamed(children, map[string]interface{}{
+		"tag":   QuotedString(el.tag),
+		"attrs": executor.RegisterObject(NewStoredListWithNamed(nil, attrs), ObjList),
+		"text":  QuotedString(strings.TrimSpace(el.text.String())),
+	})
+}
+
+// xmlStep is one step of an XPath-lite query
+type xmlStep struct {
+	descendant bool   // preceded by "//"
+	name       string // element name, "*", "@attr", or "text()"
+	index      int    // [n] predicate (1-based), 0 for none, -1 for last()
+	attr       string // [@attr] or [@attr='value'] predicate
+	attrValue  *string
+}
+
+// parseXMLPath parses an XPath-lite expression into steps
+// Supported: /abs/path, rel/path, //descendant, *, [n], [last()], [@attr], [@attr='v'], @attr, text()
+func parseXMLPath(path string) (steps []xmlStep, absolute bool, err error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, false, fmt.Errorf("empty path")
+	}
+	absolute = strings.HasPrefix(path, "/")
+
+	for path != "" {
+		step := xmlStep{}
+		if strings.HasPrefix(path, "//") {
+			step.descendant = true
+			path = path[2:]
+		} else if strings.HasPrefix(path, "/") {
+			path = path[1:]
+		}
+
+		// Find the end of this step, skipping slashes inside predicates
+		end, depth := len(path), 0
+		for i, c := range path {
+			if c == '[' {
+				depth++
+			} else if c == ']' {
+				depth--
+			} else if c == '/' && depth == 0 {
+				end = i
+				break
+			}
+		}
+		raw := path[:end]
+		path = path[end:]
+
+		name := raw
+		if idx := strings.Index(raw, "["); idx >= 0 {
+			name = raw[:idx]
+			preds := raw[idx:]
+			for preds != "" {
+				if !strings.HasPrefix(preds, "[") {
+					return nil, false, fmt.Errorf("invalid predicate in '%s'", raw)
+				}
+				close := strings.Index(preds, "]")
+				if close < 0 {
+					return nil, false, fmt.Errorf("unclosed predicate in '%s'", raw)
+				}
+				if err := step.addPredicate(strings.TrimSpace(preds[1:close])); err != nil {
+					return nil, false, err
+				}
+				preds = preds[close+1:]
+			}
+		}
+		if name == "" {
+			return nil, false, fmt.Errorf("empty step in path")
+		}
+		if (strings.HasPrefix(name, "@") || name == "text()") && path != "" {
+			return nil, false, fmt.Errorf("'%s' must be the last step", name)
+		}
+		step.name = name
+		steps = append(steps, step)
+	}
+	return steps, absolute, nil
+}
+
+// addPredicate parses a single [..] predicate into the step
+func (s *xmlStep) addPredicate(pred string) error {
+	if pred == "last()" {
+		s.index = -1
+		return nil
+	}
+	if n, err := strconv.Atoi(pred); err == nil {
+		if n < 1 {
+			return fmt.Errorf("index predicate must be >= 1: [%s]", pred)
+		}
+		s.index = n
+		return nil
+	}
+	if strings.HasPrefix(pred, "@") {
+		if eq := strings.Index(pred, "="); eq >= 0 {
+			s.attr = strings.TrimSpace(pred[1:eq])
+			value := strings.Trim(strings.TrimSpace(pred[eq+1:]), `'"`)
+			s.attrValue = &value
+		} else {
+			s.attr = strings.TrimSpace(pred[1:])
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported predicate [%s]", pred)
+}
+
+// xmlMatch pairs a node's original value (usually an ObjectRef) with its resolved list
+type xmlMatch struct {
+	value interface{}
+	list  StoredList
+}
+
+// xmlNodeChildren returns the element children of a node list
+func xmlNodeChildren(node StoredList, executor *Executor) []xmlMatch {
+	var children []xmlMatch
+	for _, item := range node.Items() {
+		if list, ok := executor.resolveValue(item).(StoredList); ok {
+			if _, isNode := list.NamedArgs()["tag"]; isNode {
+				children = append(children, xmlMatch{value: item, list: list})
+			}
+		}
+	}
+	return children
+}
+
+// xmlNodeDescendants returns all element descendants of a node in document order
+func xmlNodeDescendants(node StoredList, executor *Executor) []xmlMatch {
+	var result []xmlMatch
+	for _, child := range xmlNodeChildren(node, executor) {
+		result = append(result, child)
+		result = append(result, xmlNodeDescendants(child.list, executor)...)
+	}
+	return result
+}
+
+// xmlNamedString returns a named string field of a node list
+func xmlNamedString(list StoredList, key string, executor *Executor) (string, bool) {
+	v, ok := list.NamedArgs()[key]
+	if !ok {
+		return "", false
+	}
+	return formatArgForDisplay(executor.resolveValue(v), executor), true
+}
+
+// xmlNodeAttr returns an attribute value of a node list
+func xmlNodeAttr(node StoredList, name string, executor *Executor) (string, bool) {
+	attrsVal, ok := node.NamedArgs()["attrs"]
+	if !ok {
+		return "", false
+	}
+	attrs, ok := executor.resolveValue(attrsVal).(StoredList)
+	if !ok {
+		return "", false
+	}
+	return xmlNamedString(attrs, name, executor)
+}
+
+// queryXML evaluates an XPath-lite path against a node list
+// Absolute paths match their first step against the node itself
+// Returns node values, or strings for trailing @attr and text() steps
+func queryXML(root interface{}, path string, executor *Executor) ([]interface{}, error) {
+	steps, absolute, err := parseXMLPath(path)
+	if err != nil {
+		return nil, err
+	}
+	rootList, ok := executor.resolveValue(root).(StoredList)
+	if !ok {
+		return nil, fmt.Errorf("not an XML node")
+	}
+
+	// Absolute paths start from a virtual document whose only child is the root
+	current := []xmlMatch{{value: root, list: rootList}}
+	if absolute {
+		current = []xmlMatch{{list: NewStoredListWithNamed([]interface{}{root}, nil)}}
+	}
+
+	for _, step := range steps {
+		if strings.HasPrefix(step.name, "@") || step.name == "text()" {
+			var results []interface{}
+			for _, m := range current {
+				if step.name == "text()" {
+					if text, ok := xmlNamedString(m.list, "text", executor); ok {
+						results = append(results, text)
+					}
+				} else if value, ok := xmlNodeAttr(m.list, step.name[1:], executor); ok {
+					results = append(results, value)
+				}
+			}
+			return results, nil
+		}
+
+		var next []xmlMatch
+		seen := make(map[ObjectRef]bool)
+		for _, m := range current {
+			candidates := xmlNodeChildren(m.list, executor)
+			if step.descendant {
+				candidates = xmlNodeDescendants(m.list, executor)
+			}
+
+			var matched []xmlMatch
+			for _, c := range candidates {
+				if step.name != "*" {
+					if tag, _ := xmlNamedString(c.list, "tag", executor); tag != step.name {
+						continue
+					}
+				}
+				if step.attr != "" {
+					value, ok := xmlNodeAttr(c.list, step.attr, executor)
+					if !ok || (step.attrValue != nil && value != *step.attrValue) {
+						continue
+					}
+				}
+				matched = append(matched, c)
+			}
+
+			switch {
+			case step.index == -1 && len(matched) > 0:
+				matched = matched[len(matched)-1:]
+			case step.index > 0 && step.index <= len(matched):
+				matched = matched[step.index-1 : step.index]
+			case step.index != 0:
+				matched = nil
+			}
+
+			for _, c := range matched {
+				if ref, isRef := c.value.(ObjectRef); isRef {
+					if seen[ref] {
+						continue
+					}
+					seen[ref] = true
+				}
+				next = append(next, c)
+			}
+		}
+		current = next
+	}
+
+	results := make([]interface{}, 0, len(current))
+	for _, m := range current {
+		results = append(results, m.value)
+	}
+	return results, nil
+}
+
+// RegisterXMLLib registers XML parsing and query commands
+// This library is NOT auto-imported - users must explicitly use IMPORT xml.
+// Module: xml
+func (ps *PawScript) RegisterXMLLib() {
+	// Helper function to set a StoredList as result with proper reference counting
+	setListResult := func(ctx *Context, list StoredList) {
+		ref := ctx.executor.RegisterObject(list, ObjList)
+		ctx.state.SetResultWithoutClaim(ref)
+	}
+
+	// ==================== xml:: module ====================
+
+	// xml_parse - parse an XML document into a node list
+	// Usage: xml_parse <string> [, strict: false]
+	// Each node is (child nodes..., tag: "name", attrs: (key: "value"), text: "own text")
+	// strict: false tolerates HTML-style unclosed tags and entities
+	ps.RegisterCommandInModule("xml", "xml_parse", func(ctx *Context) Result {
+		if len(ctx.Args) < 1 {
+			ctx.LogError(CatCommand, "Usage: xml_parse <string> [, strict: false]")
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+
+		strict := true
+		if strictVal, exists := ctx.NamedArgs["strict"]; exists {
+			strict = toBool(ctx.executor.resolveValue(strictVal))
+		}
+
+		var src string
+		switch v := ctx.executor.resolveValue(ctx.Args[0]).(type) {
+		case StoredBytes:
+			src = string(v.Data())
+		default:
+			src = formatArgForDisplay(v, ctx.executor)
+		}
+
+		root, err := parseXMLTree(src, strict)
+		if err != nil {
+			ctx.LogError(CatArgument, fmt.Sprintf("xml_parse: %v", err))
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+		setListResult(ctx, root.toStoredList(ctx.executor))
+		return BoolStatus(true)
+	})
+
+	// xml_query - find nodes in a parsed document with an XPath-lite path
+	// Usage: xml_query <node>, <path> [, first: true]
+	// Paths: /rss/channel/item, //item, item[2], item[last()], item[@id='x'], //link/@href, title/text()
+	// Returns a list of matches (nodes, or strings for @attr and text()); status false if none
+	// first: true returns only the first match (nil if none)
+	ps.RegisterCommandInModule("xml", "xml_query", func(ctx *Context) Result {
+		if len(ctx.Args) < 2 {
+			ctx.LogError(CatCommand, "Usage: xml_query <node>, <path> [, first: true]")
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+
+		path := fmt.Sprintf("%v", ctx.executor.resolveValue(ctx.Args[1]))
+		matches, err := queryXML(ctx.Args[0], path, ctx.executor)
+		if err != nil {
+			ctx.LogError(CatArgument, fmt.Sprintf("xml_query: %v", err))
+			ctx.SetResult(nil)
+			return BoolStatus(false)
+		}
+
+		for i, m := range matches {
+			if s, isString := m.(string); isString {
+				matches[i] = QuotedString(s)
+			}
+		}
+
+		if firstVal, exists := ctx.NamedArgs["first"]; exists && toBool(firstVal) {
+			if len(matches) == 0 {
+				ctx.SetResult(nil)
+				return BoolStatus(false)
+			}
+			ctx.SetResult(matches[0])
+			return BoolStatus(true)
+		}
+
+		setListResult(ctx, NewStoredListWithNamed(matches, nil))
+		return BoolStatus(len(matches) > 0)
+	})
+}
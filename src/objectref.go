@@ -22,6 +22,7 @@ const (
 	ObjStructArray
 	ObjFile
 	ObjToken // Async completion token with lifecycle management
+	ObjImage
 )
 
 // String returns the string representation of an ObjectType
@@ -53,6 +54,8 @@ func (t ObjectType) String() string {
 		return "file"
 	case ObjToken:
 		return "token"
+	case ObjImage:
+		return "image"
 	default:
 		return "unknown"
 	}
@@ -85,6 +88,8 @@ func ObjectTypeFromString(s string) ObjectType {
 		return ObjFile
 	case "token":
 		return ObjToken
+	case "image":
+		return ObjImage
 	default:
 		return ObjNone
 	}
@@ -0,0 +1,15 @@
+//go:build darwin
+
+package pawscript
+
+import "golang.org/x/sys/unix"
+
+// systemMemory returns total physical memory in bytes via sysctl
+// Available memory is not reported on macOS (returned as 0)
+func systemMemory() (total, available uint64, ok bool) {
+	total, err := unix.SysctlUint64("hw.memsize")
+	if err != nil {
+		return 0, 0, false
+	}
+	return total, 0, true
+}
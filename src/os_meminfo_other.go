@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !windows
+
+package pawscript
+
+// systemMemory is not supported on this platform
+func systemMemory() (total, available uint64, ok bool) {
+	return 0, 0, false
+}
@@ -0,0 +1,36 @@
+//go:build windows
+
+package pawscript
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// memoryStatusEx mirrors the Win32 MEMORYSTATUSEX structure
+type memoryStatusEx struct {
+	Length               uint32
+	MemoryLoad           uint32
+	TotalPhys            uint64
+	AvailPhys            uint64
+	TotalPageFile        uint64
+	AvailPageFile        uint64
+	TotalVirtual         uint64
+	AvailVirtual         uint64
+	AvailExtendedVirtual uint64
+}
+
+var procGlobalMemoryStatusEx = windows.NewLazySystemDLL("kernel32.dll").NewProc("GlobalMemoryStatusEx")
+
+// systemMemory returns total and available physical memory in bytes
+// Uses GlobalMemoryStatusEx; ok is false if the call fails
+func systemMemory() (total, available uint64, ok bool) {
+	var status memoryStatusEx
+	status.Length = uint32(unsafe.Sizeof(status))
+	r, _, _ := procGlobalMemoryStatusEx.Call(uintptr(unsafe.Pointer(&status)))
+	if r == 0 {
+		return 0, 0, false
+	}
+	return status.TotalPhys, status.AvailPhys, true
+}
@@ -17,6 +17,13 @@ type PawScript struct {
 	startTime     time.Time          // Time when interpreter was initialized
 	terminalState *TerminalState     // Terminal/cursor state for io commands
 	lastResult    interface{}        // Last execution result value (for REPL)
+	catalog       *Catalog           // Message catalog and locale for i18n commands
+	topics        *TopicBus          // Named topics for topic_publish/topic_subscribe
+	quotas        *quotaTracker      // Resource use counted against config.Quotas
+	auditLog      auditLog           // Sandbox decisions, when config.AuditLimit is set
+	grants        permissionGrants   // Roots the permission prompt granted this session
+	dryRunLog     dryRunLog          // Accesses recorded when config.DryRun is set
+	cancel        cancelState        // Subprocesses Cancel kills
 }
 
 // New creates a new PawScript interpreter
@@ -45,6 +52,12 @@ func New(config *Config) *PawScript {
 	// Create root module environment for all execution states
 	rootModuleEnv := NewModuleEnvironment()
 
+	// Use configured locale, or detect it from the environment
+	locale := config.Locale
+	if locale == "" {
+		locale = DetectLocale()
+	}
+
 	ps := &PawScript{
 		config:        config,
 		logger:        logger,
@@ -52,6 +65,9 @@ func New(config *Config) *PawScript {
 		rootModuleEnv: rootModuleEnv,
 		startTime:     time.Now(),
 		terminalState: NewTerminalState(),
+		catalog:       NewCatalog(locale),
+		topics:        NewTopicBus(),
+		quotas:        newQuotaTracker(config.Quotas),
 	}
 
 	// Set up macro fallback handler
@@ -113,6 +129,13 @@ func New(config *Config) *PawScript {
 	// Set root state on executor for error routing fallback
 	executor.SetRootState(ps.rootState)
 
+	// Pause before commands at breakpoints and steps
+	if config.Debugger != nil {
+		executor.debugHook = func(cmd *ParsedCommand, state *ExecutionState) {
+			config.Debugger.check(ps, cmd, state)
+		}
+	}
+
 	return ps
 }
 
@@ -121,10 +144,41 @@ func (ps *PawScript) Configure(config *Config) {
 	// Update config
 	ps.config = config
 	ps.logger.SetEnabled(config.Debug)
+	ps.quotas.setLimits(config.Quotas)
+}
+
+// Catalog returns the interpreter's message catalog
+// Host applications can load the same PSL catalog files used by scripts
+func (ps *PawScript) Catalog() *Catalog {
+	return ps.catalog
+}
+
+// Topics returns the interpreter's topic bus
+// Host applications can publish to script subscriptions, or subscribe to script events
+func (ps *PawScript) Topics() *TopicBus {
+	return ps.topics
+}
+
+// Publish sends a value on a topic to every matching script subscription
+// Subscribers receive it as a (topic: <name>, value: <value>) list; returns how many accepted it
+func (ps *PawScript) Publish(topic string, value interface{}) (int, error) {
+	return ps.topics.Publish(topic, ps.topicMessage(topic, value))
+}
+
+// topicMessage returns a constructor for the list delivered to each topic subscriber
+func (ps *PawScript) topicMessage(topic string, value interface{}) func() interface{} {
+	return func() interface{} {
+		msg := NewStoredListWithNamed(nil, map[string]interface{}{
+			"topic": QuotedString(topic),
+			"value": value,
+		})
+		return ps.executor.RegisterObject(msg, ObjList)
+	}
 }
 
 // RegisterCommand registers a command handler (legacy - adds to CommandRegistryInherited directly)
 func (ps *PawScript) RegisterCommand(name string, handler Handler) {
+	handler = ps.restrictHandler("", name, handler)
 	ps.executor.RegisterCommand(name, handler)
 	// Also register to root module environment
 	ps.rootModuleEnv.CommandRegistryInherited[name] = handler
@@ -133,6 +187,8 @@ func (ps *PawScript) RegisterCommand(name string, handler Handler) {
 
 // RegisterCommandInModule registers a command handler in a specific module within LibraryInherited
 func (ps *PawScript) RegisterCommandInModule(moduleName, cmdName string, handler Handler) {
+	handler = ps.restrictHandler(moduleName, cmdName, handler)
+
 	ps.rootModuleEnv.mu.Lock()
 	defer ps.rootModuleEnv.mu.Unlock()
 
@@ -168,7 +224,7 @@ func (ps *PawScript) RegisterObjectInModule(moduleName, objName string, value in
 // RegisterCommands registers multiple command handlers
 func (ps *PawScript) RegisterCommands(commands map[string]Handler) {
 	for name, handler := range commands {
-		ps.executor.RegisterCommand(name, handler)
+		ps.executor.RegisterCommand(name, ps.restrictHandler("", name, handler))
 	}
 }
 
@@ -184,7 +240,13 @@ func (ps *PawScript) NewExecutionStateFromRoot() *ExecutionState {
 // Uses the persistent root state so variables, macros, and objects persist.
 // If the script contains async operations (like msleep), this function waits
 // for the entire script to complete before returning and merging exports.
+// With Config.RequireSignature set, the script must have a trusted signature.
 func (ps *PawScript) ExecuteFile(commandString, filename string) Result {
+	if err := ps.verifyScriptFile(commandString, filename); err != nil {
+		ps.logger.ErrorCat(CatSystem, "%v", err)
+		return BoolStatus(false)
+	}
+
 	// Use the persistent root state - variables and objects persist across calls
 	result := ps.executor.ExecuteWithState(commandString, ps.rootState, nil, filename, 0, 0)
 
@@ -375,10 +437,20 @@ func (ps *PawScript) dumpRemainingBubbles(state *ExecutionState) {
 	}
 }
 
+// Shutdown releases what the interpreter's scripts hold outside it: the channels
+// they export stop being served, and the files psl_stream reads are closed. Hosts
+// call it when a script run ends; Cancel and Cleanup call it too. The interpreter
+// can still be used afterwards.
+func (ps *PawScript) Shutdown() {
+	unexportChannels(func(export *channelExport) bool { return export.owner == ps })
+	ps.closeStreams()
+}
+
 // Cleanup releases all resources held by the interpreter.
 // Call this when the host application is done with the interpreter.
 // After calling Cleanup, the interpreter should not be used.
 func (ps *PawScript) Cleanup() {
+	ps.Shutdown()
 	if ps.rootState != nil {
 		// Dump any remaining bubbles to stderr before releasing
 		ps.dumpRemainingBubbles(ps.rootState)
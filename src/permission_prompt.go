@@ -0,0 +1,109 @@
+package pawscript
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Interactive permission prompts
+// With Config.PermissionPrompt set, a file access outside the read or write roots
+// asks the host instead of failing at once. The host can ask the user and answer
+// deny, allow once, or allow always; "always" grants the directory for the rest of
+// the session, and hosts that want it to last record the grant (e.g. in their config)
+// and pass it back among the roots next time.
+
+// PermissionDecision is the answer to a PermissionRequest
+type PermissionDecision int
+
+const (
+	PermissionDeny        PermissionDecision = iota
+	PermissionAllowOnce                      // Allow this access only
+	PermissionAllowAlways                    // Allow access anywhere under the request's Root
+)
+
+// PermissionRequest describes a file access a script made outside its roots
+type PermissionRequest struct {
+	Action    AuditAction // AuditRead or AuditWrite
+	Path      string      // Absolute path the script asked for
+	Root      string      // Directory an "allow always" grants: Path if it is a directory, else its parent
+	ScriptDir string      // Directory of the script asking, if known
+}
+
+// PermissionPrompt answers a PermissionRequest, usually by asking the user
+// It is called from the goroutine running the script and may block until the user
+// answers. Requests are made one at a time.
+type PermissionPrompt func(request PermissionRequest) PermissionDecision
+
+// permissionGrants holds the roots granted with PermissionAllowAlways this session
+type permissionGrants struct {
+	mu       sync.Mutex
+	prompt   sync.Mutex // Held while the prompt runs, so requests are asked one at a time
+	readable []string
+	writable []string
+}
+
+// granted reports whether a path is under a root granted this session
+// A write grant also allows reading.
+func (g *permissionGrants) granted(absPath string, needsWrite bool) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	roots := g.writable
+	if !needsWrite {
+		roots = append(roots[:len(roots):len(roots)], g.readable...)
+	}
+	for _, root := range roots {
+		if pathHasPrefix(absPath, root+string(filepath.Separator)) || pathEquals(absPath, root) {
+			return true
+		}
+	}
+	return false
+}
+
+// pathDenied is called when a path is outside the roots, with the denial
+// It returns the path and nil if the host's prompt allows the access (or an earlier
+// "allow always" covers it), and otherwise "" and the denial.
+func (ps *PawScript) pathDenied(absPath string, needsWrite bool, denial error) (string, error) {
+	if ps.config == nil || ps.config.PermissionPrompt == nil {
+		return "", denial
+	}
+	grants := &ps.grants
+	if grants.granted(absPath, needsWrite) {
+		return absPath, nil
+	}
+
+	grants.prompt.Lock()
+	defer grants.prompt.Unlock()
+	// Another request may have been granted while this one waited
+	if grants.granted(absPath, needsWrite) {
+		return absPath, nil
+	}
+
+	request := PermissionRequest{
+		Action:    AuditRead,
+		Path:      absPath,
+		Root:      filepath.Dir(absPath),
+		ScriptDir: ps.config.ScriptDir,
+	}
+	if needsWrite {
+		request.Action = AuditWrite
+	}
+	if info, err := os.Stat(absPath); err == nil && info.IsDir() {
+		request.Root = absPath
+	}
+
+	switch ps.config.PermissionPrompt(request) {
+	case PermissionAllowOnce:
+		return absPath, nil
+	case PermissionAllowAlways:
+		grants.mu.Lock()
+		if needsWrite {
+			grants.writable = append(grants.writable, request.Root)
+		} else {
+			grants.readable = append(grants.readable, request.Root)
+		}
+		grants.mu.Unlock()
+		return absPath, nil
+	}
+	return "", denial
+}
@@ -0,0 +1,120 @@
+package pawscript
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Permission profiles
+// A PermissionProfile tightens what scripts may do on top of FileAccessConfig: it can
+// disable individual commands and make file access read-only. Hosts choose a profile
+// per interpreter, so the launcher can run example scripts under "untrusted" while
+// CLI runs use none. Profiles are applied as commands are registered, so set
+// Config.Permissions before calling RegisterStandardLibrary.
+
+// PermissionProfile is a named set of command restrictions
+type PermissionProfile struct {
+	Name           string   // Shown in errors when the profile refuses something
+	DeniedCommands []string // Commands that fail instead of running (see below)
+	ReadOnly       bool     // Refuse file writes, even inside the write roots
+}
+
+// DeniedCommands entries name a command ("exec"), a group by prefix ("file_*"), or
+// either of those within one module ("os::exec", "os::secret_*", "os::*").
+
+var (
+	permissionProfilesMu sync.RWMutex
+	permissionProfiles   = map[string]*PermissionProfile{
+		"trusted": {Name: "trusted"},
+		"untrusted": {
+			Name: "untrusted",
+			DeniedCommands: []string{
+				"os::exec",
+				"os::secret_*",
+				"channels::channel_export",
+				"channels::channel_connect",
+			},
+			ReadOnly: true,
+		},
+	}
+)
+
+// RegisterPermissionProfile adds a profile that LookupPermissionProfile can find by name
+// A profile with the same name is replaced, including the built-in ones.
+func RegisterPermissionProfile(profile *PermissionProfile) {
+	permissionProfilesMu.Lock()
+	defer permissionProfilesMu.Unlock()
+	permissionProfiles[profile.Name] = profile.clone()
+}
+
+// LookupPermissionProfile returns a copy of the named profile
+// Built in are "trusted", which restricts nothing, and "untrusted", which denies
+// exec, keychain secrets and cross-process channels, and makes file access read-only.
+func LookupPermissionProfile(name string) (*PermissionProfile, bool) {
+	permissionProfilesMu.RLock()
+	defer permissionProfilesMu.RUnlock()
+	profile, exists := permissionProfiles[name]
+	if !exists {
+		return nil, false
+	}
+	return profile.clone(), true
+}
+
+// PermissionProfileNames returns the names of the profiles there are, sorted
+func PermissionProfileNames() []string {
+	permissionProfilesMu.RLock()
+	defer permissionProfilesMu.RUnlock()
+	names := make([]string, 0, len(permissionProfiles))
+	for name := range permissionProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (p *PermissionProfile) clone() *PermissionProfile {
+	copied := *p
+	copied.DeniedCommands = append([]string(nil), p.DeniedCommands...)
+	return &copied
+}
+
+// allows reports whether the profile lets a command run; a nil profile allows all
+func (p *PermissionProfile) allows(module, command string) bool {
+	if p == nil {
+		return true
+	}
+	for _, entry := range p.DeniedCommands {
+		pattern := entry
+		if i := strings.Index(entry, "::"); i >= 0 {
+			if entry[:i] != module {
+				continue
+			}
+			pattern = entry[i+2:]
+		}
+		if prefix, wildcard := strings.CutSuffix(pattern, "*"); wildcard {
+			if strings.HasPrefix(command, prefix) {
+				return false
+			}
+		} else if command == pattern {
+			return false
+		}
+	}
+	return true
+}
+
+// restrictHandler returns the handler to register for a command
+// Commands the permission profile denies get a handler that reports the denial.
+func (ps *PawScript) restrictHandler(module, command string, handler Handler) Handler {
+	if ps.config == nil || ps.config.Permissions.allows(module, command) {
+		return handler
+	}
+	err := fmt.Errorf("not permitted by the %q permission profile", ps.config.Permissions.Name)
+	return func(ctx *Context) Result {
+		ps.audit(ctx, command, AuditCommand, command, err)
+		ctx.LogError(CatCommand, fmt.Sprintf("%s: %v", command, err))
+		ctx.SetResult(nil)
+		return BoolStatus(false)
+	}
+}
@@ -0,0 +1,67 @@
+package pawscript
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+)
+
+func TestPermissionProfileAllows(t *testing.T) {
+	trusted, _ := LookupPermissionProfile("trusted")
+	untrusted, _ := LookupPermissionProfile("untrusted")
+	custom := &PermissionProfile{Name: "custom", DeniedCommands: []string{"file_*", "rm", "os::*"}}
+
+	tests := []struct {
+		profile         *PermissionProfile
+		module, command string
+		want            bool
+	}{
+		{nil, "os", "exec", true},
+		{trusted, "os", "exec", true},
+		{trusted, "os", "secret_get", true},
+		{untrusted, "os", "exec", false},
+		{untrusted, "os", "secret_get", false},
+		{untrusted, "os", "secret_set", false},
+		{untrusted, "channels", "channel_export", false},
+		{untrusted, "channels", "channel_connect", false},
+		{untrusted, "channels", "channel_send", true},
+		{untrusted, "files", "file", true},
+		{untrusted, "other", "exec", true},
+		{custom, "files", "file_info", false},
+		{custom, "files", "file", true},
+		{custom, "files", "rm", false},
+		{custom, "os", "env", false},
+	}
+	for _, tt := range tests {
+		name := "none"
+		if tt.profile != nil {
+			name = tt.profile.Name
+		}
+		if got := tt.profile.allows(tt.module, tt.command); got != tt.want {
+			t.Errorf("%s: %s::%s allowed = %v, want %v", name, tt.module, tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestPermissionProfileScripts(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.ToSlash(filepath.Join(dir, "out.txt"))
+
+	for _, tt := range []struct {
+		profile, script string
+		want            bool
+	}{
+		{"trusted", `IMPORT files; file "` + path + `", mode: w, create: true`, true},
+		{"untrusted", `IMPORT files; file "` + path + `", mode: w, create: true`, false},
+		{"untrusted", `IMPORT files; file "` + path + `"`, true},
+		{"untrusted", `IMPORT os; exec echo, hi`, false},
+	} {
+		profile, _ := LookupPermissionProfile(tt.profile)
+		ps := New(&Config{Permissions: profile, Stderr: io.Discard})
+		ps.RegisterStandardLibrary([]string{})
+		if got := ps.Execute(tt.script); got != BoolStatus(tt.want) {
+			t.Errorf("%s: %s: got %v, want %v", tt.profile, tt.script, got, tt.want)
+		}
+		ps.Shutdown()
+	}
+}
@@ -1,9 +1,7 @@
 package pawgui
 
 import (
-	"fmt"
 	"io"
-	"strings"
 	"sync"
 	"time"
 
@@ -44,7 +42,8 @@ type ConsoleChannels struct {
 	stdinReader  *io.PipeReader
 	stdinWriter  *io.PipeWriter
 	outputQueue  chan interface{}
-	inputQueue   chan byte
+	outputDone   chan struct{} // closed to stop the output writer
+	inputQueue   chan interface{}
 	clearInput   func()
 	flush        func()
 	inputRunning bool
@@ -84,6 +83,7 @@ func NewConsoleChannels(opts ConsoleOptions) *ConsoleChannels {
 		ColorDepth:    256,
 		Width:         opts.Width,
 		Height:        opts.Height,
+		DarkTheme:     true,
 		SupportsInput: true,
 		EchoEnabled:   false,
 		LineMode:      false,
@@ -94,30 +94,36 @@ func NewConsoleChannels(opts ConsoleOptions) *ConsoleChannels {
 	stdinReader, stdinWriter := io.Pipe()
 
 	// Output queue for non-blocking writes to terminal
+	// It is never closed, as a script may still be sending to it; Close closes OutCh instead
 	outputQueue := make(chan interface{}, 256)
+	outputDone := make(chan struct{})
 
 	// Input queue
-	inputQueue := make(chan byte, 256)
+	inputQueue := make(chan interface{}, 256)
 
 	cc := &ConsoleChannels{
 		TermCaps:    termCaps,
 		stdinReader: stdinReader,
 		stdinWriter: stdinWriter,
 		outputQueue: outputQueue,
+		outputDone:  outputDone,
 		inputQueue:  inputQueue,
 	}
 
 	// Start output writer goroutine
 	go func() {
-		for v := range outputQueue {
-			switch d := v.(type) {
-			case []byte:
-				opts.Terminal.Feed(string(d))
-			case string:
-				opts.Terminal.Feed(d)
-			case chan struct{}:
-				// Flush sentinel - signal completion
-				close(d)
+		for {
+			select {
+			case v := <-outputQueue:
+				switch d := v.(type) {
+				case chan struct{}:
+					// Flush sentinel - signal completion
+					close(d)
+				default:
+					opts.Terminal.Feed(string(pawscript.ConsoleBytes(d)))
+				}
+			case <-outputDone:
+				return
 			}
 		}
 	}()
@@ -136,7 +142,7 @@ func NewConsoleChannels(opts ConsoleOptions) *ConsoleChannels {
 				return
 			}
 			select {
-			case inputQueue <- buf[0]:
+			case inputQueue <- []byte{buf[0]}:
 			default:
 				// Drop oldest if full
 				select {
@@ -144,7 +150,7 @@ func NewConsoleChannels(opts ConsoleOptions) *ConsoleChannels {
 				default:
 				}
 				select {
-				case inputQueue <- buf[0]:
+				case inputQueue <- []byte{buf[0]}:
 				default:
 				}
 			}
@@ -181,61 +187,15 @@ func NewConsoleChannels(opts ConsoleOptions) *ConsoleChannels {
 	}
 
 	// Create output channel
-	cc.OutCh = &pawscript.StoredChannel{
-		BufferSize:       0,
-		Messages:         make([]pawscript.ChannelMessage, 0),
-		Subscribers:      make(map[int]*pawscript.StoredChannel),
-		NextSubscriberID: 1,
-		IsClosed:         false,
-		Timestamp:        time.Now(),
-		Terminal:         termCaps,
-		NativeSend: func(v interface{}) error {
-			var text string
-			switch d := v.(type) {
-			case []byte:
-				text = string(d)
-			case string:
-				text = d
-			default:
-				text = fmt.Sprintf("%v", v)
-			}
-			// Normalize newlines: \r\n -> \n -> \r\n
-			text = strings.ReplaceAll(text, "\r\n", "\n")
-			text = strings.ReplaceAll(text, "\n", "\r\n")
-			data := []byte(text)
-			select {
-			case outputQueue <- data:
-			default:
-				// Queue full - silently drop to prevent deadlock
-			}
-			return nil
-		},
-		NativeFlush: func() error {
-			cc.flush()
-			return nil
-		},
+	cc.OutCh = pawscript.NewChannelFromGo(outputQueue, nil, termCaps)
+	cc.OutCh.Overflow = pawscript.OverflowDropNewest // a stalled GUI drops output rather than blocking the script
+	cc.OutCh.NativeFlush = func() error {
+		cc.flush()
+		return nil
 	}
 
 	// Create input channel
-	cc.InCh = &pawscript.StoredChannel{
-		BufferSize:       0,
-		Messages:         make([]pawscript.ChannelMessage, 0),
-		Subscribers:      make(map[int]*pawscript.StoredChannel),
-		NextSubscriberID: 1,
-		IsClosed:         false,
-		Timestamp:        time.Now(),
-		Terminal:         termCaps,
-		NativeRecv: func() (interface{}, error) {
-			b, ok := <-inputQueue
-			if !ok {
-				return nil, fmt.Errorf("input closed")
-			}
-			return []byte{b}, nil
-		},
-		NativeSend: func(v interface{}) error {
-			return fmt.Errorf("cannot send to console_in")
-		},
-	}
+	cc.InCh = pawscript.NewChannelFromGo(nil, inputQueue, termCaps)
 
 	return cc
 }
@@ -249,6 +209,17 @@ func (cc *ConsoleChannels) GetIOConfig() *pawscript.IOChannelConfig {
 	}
 }
 
+// OutputStats returns the console output channel's message counters.
+// Depth is the number of writes still queued for the terminal, so a frontend
+// can show an I/O health indicator (a growing depth or drop count means the
+// display is falling behind).
+func (cc *ConsoleChannels) OutputStats() pawscript.ChannelStats {
+	stats := pawscript.ChannelGetStats(cc.OutCh)
+	stats.Depth = len(cc.outputQueue)
+	stats.Capacity = cap(cc.outputQueue)
+	return stats
+}
+
 // WriteInput writes data to the stdin pipe (for script input).
 func (cc *ConsoleChannels) WriteInput(data []byte) {
 	if cc.stdinWriter != nil {
@@ -278,5 +249,6 @@ func (cc *ConsoleChannels) Close() {
 	if cc.stdinReader != nil {
 		cc.stdinReader.Close()
 	}
-	close(cc.outputQueue)
+	_ = pawscript.ChannelClose(cc.OutCh)
+	close(cc.outputDone)
 }
@@ -7,6 +7,10 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/phroun/pawscript/src"
 	"github.com/phroun/pawscript/src/pkg/purfecterm"
@@ -373,36 +377,522 @@ func (h *ConfigHelper) GetBlinkMode() purfecterm.BlinkMode {
 	return purfecterm.BlinkModeBounce
 }
 
+// GetCursorStyle returns the configured cursor shape and blink mode, as
+// purfecterm.Buffer.SetCursorStyle takes them.
+// cursor_shape: "block" (default), "underline" or "bar"
+// cursor_blink: "off" (default), "slow" or "fast"
+func (h *ConfigHelper) GetCursorStyle() (shape, blink int) {
+	if h.Config != nil {
+		shape = purfecterm.ParseCursorShape(h.Config.GetString("cursor_shape", "block"))
+		blink = purfecterm.ParseCursorBlink(h.Config.GetString("cursor_blink", "off"))
+	}
+	return shape, blink
+}
+
+// DefaultScrollbackLines is how many lines scroll back when scrollback_lines isn't set
+const DefaultScrollbackLines = 100000
+
+// GetScrollbackLines returns how many lines of output a console keeps once they
+// scroll off its screen. It's read as consoles open.
+func (h *ConfigHelper) GetScrollbackLines() int {
+	if h.Config != nil {
+		if lines := h.Config.GetInt("scrollback_lines", DefaultScrollbackLines); lines > 0 {
+			return lines
+		}
+	}
+	return DefaultScrollbackLines
+}
+
+// The values of default_blink, cursor_shape and cursor_blink, in the order the
+// settings dialogs list them
+var (
+	BlinkModeValues   = []string{"bounce", "blink", "bright"}
+	CursorShapeValues = []string{"block", "underline", "bar"}
+	CursorBlinkValues = []string{"off", "slow", "fast"}
+)
+
+// ValueIndex returns where value is in values, 0 (the default) if it isn't there
+func ValueIndex(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return 0
+}
+
+// GetClipboardAccess returns what programs may do with the clipboard through OSC 52
+// clipboard_access: "off", "write" (default) or "read-write"
+func (h *ConfigHelper) GetClipboardAccess() purfecterm.ClipboardAccess {
+	if h.Config != nil {
+		return purfecterm.ParseClipboardAccess(h.Config.GetString("clipboard_access", "write"))
+	}
+	return purfecterm.ClipboardAccessWrite
+}
+
+// GetPrimarySelection returns whether selecting text copies it to the primary
+// selection and the middle button pastes it (default true)
+func (h *ConfigHelper) GetPrimarySelection() bool {
+	if h.Config != nil {
+		return h.Config.GetBool("primary_selection", true)
+	}
+	return true
+}
+
+// GetTerminalIdentity returns the name the terminal gives in replies to XTVERSION
+// terminal_identity: default "purfecterm"
+func (h *ConfigHelper) GetTerminalIdentity() string {
+	if h.Config != nil {
+		return h.Config.GetString("terminal_identity", purfecterm.DefaultTerminalIdentity)
+	}
+	return purfecterm.DefaultTerminalIdentity
+}
+
+// GetVisualBell returns whether the terminal flashes when the bell rings (default true)
+func (h *ConfigHelper) GetVisualBell() bool {
+	if h.Config != nil {
+		return h.Config.GetBool("visual_bell", true)
+	}
+	return true
+}
+
+// GetMinimizeToTray returns whether minimizing the launcher hides it in the
+// system tray (default false)
+func (h *ConfigHelper) GetMinimizeToTray() bool {
+	if h.Config != nil {
+		return h.Config.GetBool("minimize_to_tray", false)
+	}
+	return false
+}
+
+// GetSessionRestore returns whether the launcher reopens the windows open when it
+// last quit: "ask" (default), "always" or "never"
+func (h *ConfigHelper) GetSessionRestore() string {
+	if h.Config != nil {
+		switch mode := h.Config.GetString("session_restore", "ask"); mode {
+		case "always", "never":
+			return mode
+		}
+	}
+	return "ask"
+}
+
+// GetSessionScrollback returns whether a saved session keeps what each terminal
+// showed, to restore with it (default false)
+func (h *ConfigHelper) GetSessionScrollback() bool {
+	if h.Config != nil {
+		return h.Config.GetBool("session_scrollback", false)
+	}
+	return false
+}
+
+// GetGPURendering returns whether the terminal is drawn with the GPU where the
+// widget supports it (default false)
+func (h *ConfigHelper) GetGPURendering() bool {
+	if h.Config != nil {
+		return h.Config.GetBool("gpu_rendering", false)
+	}
+	return false
+}
+
+// GetFontLigatures returns whether runs of text are drawn together so the font's
+// ligatures join them (default true)
+func (h *ConfigHelper) GetFontLigatures() bool {
+	if h.Config != nil {
+		return h.Config.GetBool("font_ligatures", true)
+	}
+	return true
+}
+
+// GetBackgroundOpacity returns how opaque the terminal background is, from 0.1
+// to 1 (default 1, opaque); where the window can't be see-through it stays opaque
+func (h *ConfigHelper) GetBackgroundOpacity() float64 {
+	if h.Config != nil {
+		if opacity := h.Config.GetFloat("background_opacity", 1.0); opacity > 0 {
+			return min(max(opacity, purfecterm.MinBackgroundOpacity), 1.0)
+		}
+	}
+	return 1.0
+}
+
+// GetBackgroundBlur returns whether what shows through a see-through terminal
+// background is blurred, where the window manager can (default false)
+func (h *ConfigHelper) GetBackgroundBlur() bool {
+	if h.Config != nil {
+		return h.Config.GetBool("background_blur", false)
+	}
+	return false
+}
+
+// GetBackgroundImage returns the path of the image drawn under the terminal text
+// (default "", none)
+func (h *ConfigHelper) GetBackgroundImage() string {
+	if h.Config != nil {
+		return h.Config.GetString("background_image", "")
+	}
+	return ""
+}
+
+// GetBackgroundDim returns how far the background image is faded toward the
+// background color, from 0 to 1 (default 0.5)
+func (h *ConfigHelper) GetBackgroundDim() float64 {
+	if h.Config != nil {
+		return min(max(h.Config.GetFloat("background_dim", 0.5), 0), 1)
+	}
+	return 0.5
+}
+
+// GetWindowZoom returns the font zoom the user last set in windows of a type, such
+// as "launcher", "console", "shell" or "script" (default 1.0). The window_zoom
+// section holds a zoom by window type, independent of font_size.
+func (h *ConfigHelper) GetWindowZoom(windowType string) float64 {
+	if h.Config != nil {
+		if section, ok := h.Config["window_zoom"].(pawscript.PSLMap); ok {
+			return purfecterm.ClampZoom(section.GetFloat(windowType, 1.0))
+		}
+	}
+	return 1.0
+}
+
+// SetWindowZoom records the font zoom for windows of a type (1.0 removes it).
+// Returns false if it is unchanged, so there is nothing to save.
+func (h *ConfigHelper) SetWindowZoom(windowType string, zoom float64) bool {
+	if h.Config == nil {
+		return false
+	}
+	zoom = purfecterm.ClampZoom(zoom)
+	if zoom == h.GetWindowZoom(windowType) {
+		return false
+	}
+	section := pawscript.PSLConfig{}
+	if old, ok := h.Config["window_zoom"].(pawscript.PSLMap); ok {
+		for key, value := range old {
+			section.Set(key, value)
+		}
+	}
+	if zoom == 1.0 {
+		delete(section, windowType)
+	} else {
+		section.Set(windowType, zoom)
+	}
+	h.Config.Set("window_zoom", section)
+	return true
+}
+
 // GetQuitShortcut returns the configured quit shortcut.
 // Valid values: "Cmd+Q", "Ctrl+Q", "Alt+F4", or "" (disabled)
 func (h *ConfigHelper) GetQuitShortcut() string {
+	return h.GetShortcut(ShortcutQuit)
+}
+
+// GetCloseShortcut returns the configured close window shortcut.
+// Valid values: any shortcut string like "Cmd+W", "Ctrl+F4", or "" (disabled)
+func (h *ConfigHelper) GetCloseShortcut() string {
+	return h.GetShortcut(ShortcutClose)
+}
+
+// Permission profiles for scripts run from the launcher
+const (
+	DefaultLauncherProfile = "trusted"   // The user's own scripts, unless launcher_profile says otherwise
+	ExampleScriptsProfile  = "untrusted" // Scripts in the examples directory
+)
+
+// GetLauncherPermissions returns the permission profile for a script run from the launcher.
+// Scripts in examplesDir ("" for none) run under "untrusted"; others under the profile
+// the launcher_profile setting names (default "trusted"). An unknown name gives
+// "untrusted", so a typo never loosens the policy.
+func (h *ConfigHelper) GetLauncherPermissions(script, examplesDir string) *pawscript.PermissionProfile {
+	name := DefaultLauncherProfile
 	if h.Config != nil {
-		if val, exists := h.Config["quit_shortcut"]; exists {
-			if val == nil {
-				return "" // nil means disabled
-			}
-			if s, ok := val.(string); ok {
-				return s
+		name = h.Config.GetString("launcher_profile", name)
+	}
+	if examplesDir != "" {
+		absScript, err1 := filepath.Abs(script)
+		absExamples, err2 := filepath.Abs(examplesDir)
+		if err1 == nil && err2 == nil && isInside(absScript, absExamples) {
+			name = ExampleScriptsProfile
+		}
+	}
+	return launcherProfile(name)
+}
+
+// launcherProfile returns the permission profile with the name given, or
+// "untrusted" for a name there's none with
+func launcherProfile(name string) *pawscript.PermissionProfile {
+	if profile, exists := pawscript.LookupPermissionProfile(name); exists {
+		return profile
+	}
+	profile, _ := pawscript.LookupPermissionProfile(ExampleScriptsProfile)
+	return profile
+}
+
+// Default resource quotas for scripts run from the launcher
+const (
+	DefaultLauncherOpenFiles    = 64
+	DefaultLauncherBytesWritten = "1GB"
+	DefaultLauncherProcesses    = 64
+)
+
+// GetLauncherQuotas returns the resource quotas for scripts run from the launcher.
+// The launcher_quotas section sets open_files, bytes_written (a size such as "512MB")
+// and processes; 0 removes a cap.
+func (h *ConfigHelper) GetLauncherQuotas() *pawscript.ResourceQuotas {
+	section := pawscript.PSLMap{}
+	if h.Config != nil {
+		if m, ok := h.Config["launcher_quotas"].(pawscript.PSLMap); ok {
+			section = m
+		}
+	}
+	defaultBytes, _ := pawscript.ParsePSLSize(DefaultLauncherBytesWritten)
+	return &pawscript.ResourceQuotas{
+		MaxOpenFiles:    section.GetInt("open_files", DefaultLauncherOpenFiles),
+		MaxBytesWritten: section.GetSize("bytes_written", defaultBytes),
+		MaxProcesses:    section.GetInt("processes", DefaultLauncherProcesses),
+	}
+}
+
+// grantedRootsKey is the config key holding the roots granted from permission prompts
+func grantedRootsKey(write bool) string {
+	if write {
+		return "granted_write_roots"
+	}
+	return "granted_read_roots"
+}
+
+// GetGrantedRoots returns the directories the user chose to always allow launcher
+// scripts to read (or, with write set, to write) when prompted.
+func (h *ConfigHelper) GetGrantedRoots(write bool) []string {
+	var roots []string
+	if h.Config != nil {
+		if list, ok := h.Config[grantedRootsKey(write)].(pawscript.PSLList); ok {
+			for _, item := range list {
+				if s, ok := item.(string); ok && s != "" {
+					roots = append(roots, s)
+				}
 			}
 		}
 	}
-	return GetDefaultQuitShortcut()
+	return roots
 }
 
-// GetCloseShortcut returns the configured close window shortcut.
-// Valid values: any shortcut string like "Cmd+W", "Ctrl+F4", or "" (disabled)
-func (h *ConfigHelper) GetCloseShortcut() string {
+// AddGrantedRoot records a directory the user chose to always allow.
+// Returns false if it was already granted, so there is nothing to save.
+func (h *ConfigHelper) AddGrantedRoot(write bool, root string) bool {
+	if h.Config == nil {
+		return false
+	}
+	roots := h.GetGrantedRoots(write)
+	for _, r := range roots {
+		if r == root {
+			return false
+		}
+	}
+	list := make(pawscript.PSLList, 0, len(roots)+1)
+	for _, r := range append(roots, root) {
+		list = append(list, r)
+	}
+	h.Config.Set(grantedRootsKey(write), list)
+	return true
+}
+
+// ApplyGrantedRoots adds the granted roots to a launcher script's file access.
+// Write grants allow reading too.
+func (h *ConfigHelper) ApplyGrantedRoots(fileAccess *pawscript.FileAccessConfig) {
+	writable := h.GetGrantedRoots(true)
+	fileAccess.ReadRoots = append(fileAccess.ReadRoots, h.GetGrantedRoots(false)...)
+	fileAccess.ReadRoots = append(fileAccess.ReadRoots, writable...)
+	fileAccess.WriteRoots = append(fileAccess.WriteRoots, writable...)
+}
+
+// MaxRecentScripts is how many scripts the launcher remembers having run
+const MaxRecentScripts = 10
+
+// How the last run of a recent script ended
+const (
+	RunOK      = "ok"
+	RunFailed  = "failed"
+	RunStopped = "stopped"
+)
+
+// RecentScript is a script the launcher ran, with when it last ran and how that
+// run ended
+type RecentScript struct {
+	Path    string
+	LastRun time.Time // Zero if not known
+	Status  string    // RunOK, RunFailed or RunStopped; "" while running or if not known
+}
+
+// Label returns how menus show the script: its name, when it last ran and how
+// that run ended, "build.paw - 14:05, failed"
+func (r RecentScript) Label() string {
+	var details []string
+	if !r.LastRun.IsZero() {
+		lastRun, now := r.LastRun.Local(), time.Now()
+		switch {
+		case lastRun.YearDay() == now.YearDay() && lastRun.Year() == now.Year():
+			details = append(details, lastRun.Format("15:04"))
+		case lastRun.Year() == now.Year():
+			details = append(details, lastRun.Format("Jan 2 15:04"))
+		default:
+			details = append(details, lastRun.Format("Jan 2 2006"))
+		}
+	}
+	if r.Status != "" {
+		details = append(details, Tr(r.Status))
+	}
+	if len(details) == 0 {
+		return filepath.Base(r.Path)
+	}
+	return filepath.Base(r.Path) + " - " + strings.Join(details, ", ")
+}
+
+// toPSL returns the config's entry for the script
+func (r RecentScript) toPSL() pawscript.PSLMap {
+	saved := pawscript.PSLMap{"path": r.Path}
+	if !r.LastRun.IsZero() {
+		saved.Set("last_run", r.LastRun.Format(time.RFC3339))
+	}
+	if r.Status != "" {
+		saved.Set("status", r.Status)
+	}
+	return saved
+}
+
+// GetRecentScriptRuns returns the scripts the launcher ran last, most recent first
+// Entries saved as plain paths have no time or status.
+func (h *ConfigHelper) GetRecentScriptRuns() []RecentScript {
+	var scripts []RecentScript
 	if h.Config != nil {
-		if val, exists := h.Config["close_shortcut"]; exists {
-			if val == nil {
-				return "" // nil means disabled
+		if list, ok := h.Config["launcher_recent_scripts"].(pawscript.PSLList); ok {
+			for _, item := range list {
+				switch saved := item.(type) {
+				case string:
+					if saved != "" {
+						scripts = append(scripts, RecentScript{Path: saved})
+					}
+				case pawscript.PSLMap:
+					if path := saved.GetString("path", ""); path != "" {
+						lastRun, _ := time.Parse(time.RFC3339, saved.GetString("last_run", ""))
+						scripts = append(scripts, RecentScript{
+							Path:    path,
+							LastRun: lastRun,
+							Status:  saved.GetString("status", ""),
+						})
+					}
+				}
 			}
-			if s, ok := val.(string); ok {
-				return s
+		}
+	}
+	return scripts
+}
+
+// GetRecentScripts returns the paths of the scripts the launcher ran last, most
+// recent first
+func (h *ConfigHelper) GetRecentScripts() []string {
+	var paths []string
+	for _, script := range h.GetRecentScriptRuns() {
+		paths = append(paths, script.Path)
+	}
+	return paths
+}
+
+// AddRecentScript records a script the launcher is running, moving it to the
+// front. Returns false if there is no config to record it in.
+func (h *ConfigHelper) AddRecentScript(path string) bool {
+	if h.Config == nil || path == "" {
+		return false
+	}
+	list := pawscript.PSLList{RecentScript{Path: path, LastRun: time.Now()}.toPSL()}
+	for _, script := range h.GetRecentScriptRuns() {
+		if script.Path != path && len(list) < MaxRecentScripts {
+			list = append(list, script.toPSL())
+		}
+	}
+	h.Config.Set("launcher_recent_scripts", list)
+	return true
+}
+
+// SetRecentScriptStatus records how the last run of a recent script ended.
+// Returns false if the script isn't a recent one, so there is nothing to save.
+func (h *ConfigHelper) SetRecentScriptStatus(path, status string) bool {
+	scripts := h.GetRecentScriptRuns()
+	found := false
+	list := make(pawscript.PSLList, 0, len(scripts))
+	for _, script := range scripts {
+		if script.Path == path && !found {
+			script.Status = status
+			found = true
+		}
+		list = append(list, script.toPSL())
+	}
+	if found {
+		h.Config.Set("launcher_recent_scripts", list)
+	}
+	return found
+}
+
+// RunStatus returns the status of a run that ended ok or not, or was stopped
+func RunStatus(ok, stopped bool) string {
+	switch {
+	case stopped:
+		return RunStopped
+	case ok:
+		return RunOK
+	default:
+		return RunFailed
+	}
+}
+
+// GetScheduledTasks returns the tasks of the Scheduled Tasks manager
+func (h *ConfigHelper) GetScheduledTasks() []ScheduledTask {
+	var tasks []ScheduledTask
+	if h.Config != nil {
+		if list, ok := h.Config["scheduled_tasks"].(pawscript.PSLList); ok {
+			for _, item := range list {
+				saved, ok := item.(pawscript.PSLMap)
+				if !ok || saved.GetString("name", "") == "" {
+					continue
+				}
+				tasks = append(tasks, ScheduledTask{
+					Name:     saved.GetString("name", ""),
+					Script:   saved.GetString("script", ""),
+					Schedule: saved.GetString("schedule", ""),
+					Profile:  saved.GetString("profile", "untrusted"),
+					Enabled:  saved.GetBool("enabled", true),
+				})
 			}
 		}
 	}
-	return GetDefaultCloseShortcut()
+	return tasks
+}
+
+// SetScheduledTasks replaces the tasks of the Scheduled Tasks manager
+func (h *ConfigHelper) SetScheduledTasks(tasks []ScheduledTask) {
+	if h.Config == nil {
+		return
+	}
+	list := pawscript.PSLList{}
+	for _, task := range tasks {
+		list = append(list, pawscript.PSLMap{
+			"name":     task.Name,
+			"script":   task.Script,
+			"schedule": task.Schedule,
+			"profile":  task.Profile,
+			"enabled":  task.Enabled,
+		})
+	}
+	h.Config.Set("scheduled_tasks", list)
+}
+
+// PermissionPromptText returns the question to ask the user about a permission request.
+func PermissionPromptText(request pawscript.PermissionRequest) string {
+	verb, scope := "read", "read files in"
+	if request.Action == pawscript.AuditWrite {
+		verb, scope = "write to", "write files in"
+	}
+	return fmt.Sprintf("A script wants to %s:\n\n%s\n\n"+
+		"Allow Once permits only this access. Always Allow lets scripts %s\n\n%s\n\nfrom now on.",
+		verb, request.Path, scope, request.Root)
 }
 
 // GetTheme returns the configured GUI theme mode.
@@ -421,13 +911,17 @@ func (h *ConfigHelper) GetTheme() ThemeMode {
 }
 
 // GetTermTheme returns the configured terminal theme.
-// Valid values: "light", "dark" (default: "dark")
+// Valid values: "light", "dark" (default: "dark"), or "auto" for the OS's theme
+// where SystemDarkTheme knows it (dark where it doesn't)
 func (h *ConfigHelper) GetTermTheme() string {
 	if h.Config != nil {
 		theme := h.Config.GetString("term_theme", "dark")
 		if theme == "light" || theme == "dark" {
 			return theme
 		}
+		if dark, ok := SystemDarkTheme(); theme == "auto" && ok && !dark {
+			return "light"
+		}
 	}
 	return "dark"
 }
@@ -447,6 +941,7 @@ func (h *ConfigHelper) GetColorScheme() purfecterm.ColorScheme {
 // This allows the terminal to switch between modes via DECSCNM without needing
 // to reload colors from config.
 func (h *ConfigHelper) GetDualColorScheme() purfecterm.ColorScheme {
+	cursorShape, cursorBlink := h.GetCursorStyle()
 	return purfecterm.ColorScheme{
 		// Dark mode colors
 		DarkForeground: h.GetTerminalForegroundForTheme(true),
@@ -462,6 +957,25 @@ func (h *ConfigHelper) GetDualColorScheme() purfecterm.ColorScheme {
 		Cursor:    purfecterm.TrueColor(255, 255, 255),
 		Selection: purfecterm.TrueColor(68, 68, 68),
 		BlinkMode: h.GetBlinkMode(),
+
+		CursorShape: cursorShape,
+		CursorBlink: cursorBlink,
+		VisualBell:  h.GetVisualBell(),
+		Clipboard:   h.GetClipboardAccess(),
+
+		PrimarySelection: h.GetPrimarySelection(),
+		Identity:         h.GetTerminalIdentity(),
+		GPURendering:     h.GetGPURendering(),
+		Ligatures:        h.GetFontLigatures(),
+
+		BackgroundOpacity: h.GetBackgroundOpacity(),
+		BackgroundBlur:    h.GetBackgroundBlur(),
+		BackgroundImage:   h.GetBackgroundImage(),
+		BackgroundDim:     h.GetBackgroundDim(),
+
+		SearchMatch:   purfecterm.TrueColor(170, 140, 40),
+		SearchCurrent: purfecterm.TrueColor(255, 150, 50),
+		SearchText:    purfecterm.TrueColor(0, 0, 0),
 	}
 }
 
@@ -626,6 +1140,12 @@ func (h *ConfigHelper) PopulateDefaults() bool {
 		h.Config.Set("close_shortcut", GetDefaultCloseShortcut())
 		modified = true
 	}
+	for _, action := range ShortcutActions {
+		if _, exists := h.Config[action.Name+"_shortcut"]; action.Console && !exists {
+			h.Config.Set(action.Name+"_shortcut", GetDefaultShortcut(action.Name))
+			modified = true
+		}
+	}
 	if _, exists := h.Config["theme"]; !exists {
 		h.Config.Set("theme", "auto")
 		modified = true
@@ -638,6 +1158,82 @@ func (h *ConfigHelper) PopulateDefaults() bool {
 		h.Config.Set("default_blink", "bounce")
 		modified = true
 	}
+	if _, exists := h.Config["cursor_shape"]; !exists {
+		h.Config.Set("cursor_shape", "block")
+		modified = true
+	}
+	if _, exists := h.Config["cursor_blink"]; !exists {
+		h.Config.Set("cursor_blink", "off")
+		modified = true
+	}
+	if _, exists := h.Config["scrollback_lines"]; !exists {
+		h.Config.Set("scrollback_lines", DefaultScrollbackLines)
+		modified = true
+	}
+	if _, exists := h.Config["language"]; !exists {
+		h.Config.Set("language", "auto")
+		modified = true
+	}
+	if _, exists := h.Config["visual_bell"]; !exists {
+		h.Config.Set("visual_bell", true)
+		modified = true
+	}
+	if _, exists := h.Config["clipboard_access"]; !exists {
+		h.Config.Set("clipboard_access", "write")
+		modified = true
+	}
+	if _, exists := h.Config["primary_selection"]; !exists {
+		h.Config.Set("primary_selection", true)
+		modified = true
+	}
+	if _, exists := h.Config["terminal_identity"]; !exists {
+		h.Config.Set("terminal_identity", purfecterm.DefaultTerminalIdentity)
+		modified = true
+	}
+	if _, exists := h.Config["minimize_to_tray"]; !exists {
+		h.Config.Set("minimize_to_tray", false)
+		modified = true
+	}
+	if _, exists := h.Config["session_restore"]; !exists {
+		h.Config.Set("session_restore", "ask")
+		modified = true
+	}
+	if _, exists := h.Config["session_scrollback"]; !exists {
+		h.Config.Set("session_scrollback", false)
+		modified = true
+	}
+	if _, exists := h.Config["gpu_rendering"]; !exists {
+		h.Config.Set("gpu_rendering", false)
+		modified = true
+	}
+	if _, exists := h.Config["font_ligatures"]; !exists {
+		h.Config.Set("font_ligatures", true)
+		modified = true
+	}
+	if _, exists := h.Config["background_opacity"]; !exists {
+		h.Config.Set("background_opacity", 1.0)
+		modified = true
+	}
+	if _, exists := h.Config["background_blur"]; !exists {
+		h.Config.Set("background_blur", false)
+		modified = true
+	}
+	if _, exists := h.Config["background_image"]; !exists {
+		h.Config.Set("background_image", "")
+		modified = true
+	}
+	if _, exists := h.Config["background_dim"]; !exists {
+		h.Config.Set("background_dim", 0.5)
+		modified = true
+	}
+	if _, exists := h.Config["launcher_quotas"]; !exists {
+		quotas := pawscript.PSLConfig{}
+		quotas.Set("open_files", DefaultLauncherOpenFiles)
+		quotas.Set("bytes_written", DefaultLauncherBytesWritten)
+		quotas.Set("processes", DefaultLauncherProcesses)
+		h.Config.Set("launcher_quotas", quotas)
+		modified = true
+	}
 
 	// term_colors: base palette colors (can be overridden by theme-specific sections)
 	if _, exists := h.Config["term_colors"]; !exists {
@@ -730,3 +1326,172 @@ func GetConfigDir() string {
 func GetConfigPath() string {
 	return filepath.Join(GetConfigDir(), "pawgui.psl")
 }
+
+// ConfigSchema describes the settings the GUI launchers read from their config files.
+// Keys it doesn't mention are left alone.
+const ConfigSchema = `(
+	theme: (type: string, values: (auto, dark, light)),
+	term_theme: (type: string, values: (auto, dark, light)),
+	ui_scale: (type: number, min: 0.1, max: 10),
+	font_family: (type: string),
+	font_family_unicode: (type: string),
+	font_family_cjk: (type: string),
+	font_size: (type: int, min: 1, max: 500),
+	optimization_level: (type: int, min: 0, max: 1),
+	stop_timeout: (type: int, min: 0),
+	run_log: (type: bool),
+	run_log_dir: (type: string),
+	run_log_keep: (type: int, min: 0),
+	run_log_days: (type: int, min: 0),
+	default_blink: (type: string, values: (bounce, blink, bright)),
+	cursor_shape: (type: string, values: (block, underline, bar)),
+	cursor_blink: (type: string, values: (off, slow, fast)),
+	scrollback_lines: (type: int, min: 100, max: 10000000),
+	language: (type: string),
+	visual_bell: (type: bool),
+	clipboard_access: (type: string, values: (off, write, read-write)),
+	primary_selection: (type: bool),
+	terminal_identity: (type: string),
+	minimize_to_tray: (type: bool),
+	session_restore: (type: string, values: (ask, always, never)),
+	scheduled_tasks: (type: list),
+	script_profiles: (type: list),
+	session_scrollback: (type: bool),
+	gpu_rendering: (type: bool),
+	font_ligatures: (type: bool),
+	background_opacity: (type: number, min: 0.1, max: 1),
+	background_blur: (type: bool),
+	background_image: (type: string),
+	background_dim: (type: number, min: 0, max: 1),
+	window_zoom: (type: map, items: (type: number, min: 0.5, max: 3)),
+	key_macros: (type: list, items: (type: list, min: 2, max: 2, items: (type: string))),
+	quit_shortcut: (type: (string, nil)),
+	close_shortcut: (type: (string, nil)),
+	copy_shortcut: (type: (string, nil)),
+	paste_shortcut: (type: (string, nil)),
+	find_shortcut: (type: (string, nil)),
+	zoom_in_shortcut: (type: (string, nil)),
+	zoom_out_shortcut: (type: (string, nil)),
+	zoom_reset_shortcut: (type: (string, nil)),
+	term_colors: (type: map, items: (type: color)),
+	term_colors_dark: (type: map, items: (type: color)),
+	term_colors_light: (type: map, items: (type: color)),
+	psl_colors: (type: map, items: (type: string)),
+	psl_colors_dark: (type: map, items: (type: string)),
+	psl_colors_light: (type: map, items: (type: string)),
+	last_browse_dir: (type: string),
+	launcher_width: (type: int, min: 0),
+	launcher_editor: (type: bool),
+	launcher_position: (type: list, min: 2, max: 2, items: (type: int)),
+	launcher_size: (type: list, min: 2, max: 2, items: (type: int, min: 1)),
+	launcher_recent_paths: (type: list, items: (type: string)),
+	launcher_recent_scripts: (type: list, items: (type: (string, map), keys: (
+		path: (type: string),
+		last_run: (type: string),
+		status: (type: string, values: (ok, failed, stopped)),
+	))),
+	launcher_profile: (type: string),
+	launcher_project: (type: string),
+	settings_profile: (type: string),
+	granted_read_roots: (type: list, items: (type: string)),
+	granted_write_roots: (type: list, items: (type: string)),
+	launcher_quotas: (type: map, keys: (
+		open_files: (type: int, min: 0),
+		bytes_written: (type: size),
+		processes: (type: int, min: 0),
+	)),
+)`
+
+var (
+	configSchemaOnce sync.Once
+	configSchema     pawscript.PSLMap
+)
+
+// ValidateConfig checks a loaded config against ConfigSchema.
+// The entries with problems are removed from the config, only as deep as the
+// problem (a bad color removes that color, not the palette), so their defaults
+// apply (and PopulateDefaults restores them); the problems are returned for reporting.
+func ValidateConfig(config pawscript.PSLConfig) []pawscript.PSLSchemaError {
+	configSchemaOnce.Do(func() {
+		schema, err := pawscript.ParsePSL(ConfigSchema)
+		if err != nil {
+			panic("pawgui: invalid ConfigSchema: " + err.Error())
+		}
+		configSchema = schema
+	})
+
+	problems := pawscript.ValidatePSL(config, configSchema)
+
+	// Remove one entry at a time, so the list indexes in the other paths stay right;
+	// removing an item can leave its list too short, which the next pass catches
+	for remaining := problems; len(remaining) > 0; remaining = pawscript.ValidatePSL(config, configSchema) {
+		steps := configPathSteps(remaining[0].Path)
+		if _, ok := removeConfigEntry(config, steps); ok {
+			continue // The config is a map, changed in place
+		}
+		// A path that can't be followed removes the whole setting
+		key, _ := steps[0].(string)
+		if _, exists := config[key]; !exists {
+			break
+		}
+		delete(config, key)
+	}
+	return problems
+}
+
+// configPathSteps splits a PSLSchemaError path into map keys (strings) and list
+// indexes (ints): "key_macros[2][0]" is key_macros, 2, 0
+func configPathSteps(path string) []interface{} {
+	var steps []interface{}
+	for _, part := range strings.Split(path, ".") {
+		key, indexes, _ := strings.Cut(part, "[")
+		steps = append(steps, key)
+		if indexes == "" {
+			continue
+		}
+		for _, index := range strings.Split(strings.TrimSuffix(indexes, "]"), "][") {
+			n, err := strconv.Atoi(index)
+			if err != nil {
+				n = -1 // Not a path ValidatePSL writes; followed no further
+			}
+			steps = append(steps, n)
+		}
+	}
+	return steps
+}
+
+// removeConfigEntry removes the map entry or list item that steps lead to from a
+// config value, returning the value it leaves (a list is shortened in a copy)
+func removeConfigEntry(value interface{}, steps []interface{}) (interface{}, bool) {
+	switch container := value.(type) {
+	case pawscript.PSLMap:
+		key, ok := steps[0].(string)
+		child, exists := container[key]
+		if !ok || !exists {
+			return value, false
+		}
+		if len(steps) == 1 {
+			delete(container, key)
+			return container, true
+		}
+		child, ok = removeConfigEntry(child, steps[1:])
+		if ok {
+			container[key] = child
+		}
+		return container, ok
+	case pawscript.PSLList:
+		i, ok := steps[0].(int)
+		if !ok || i < 0 || i >= len(container) {
+			return value, false
+		}
+		if len(steps) == 1 {
+			return append(container[:i:i], container[i+1:]...), true
+		}
+		child, ok := removeConfigEntry(container[i], steps[1:])
+		if ok {
+			container[i] = child
+		}
+		return container, ok
+	}
+	return value, false
+}
@@ -0,0 +1,55 @@
+package pawgui
+
+import (
+	"testing"
+
+	pawscript "github.com/phroun/pawscript/src"
+)
+
+func TestValidateConfigRemovesOnlyBadEntries(t *testing.T) {
+	config, err := pawscript.ParsePSL(`(
+		theme: purple,
+		font_size: 14,
+		term_colors: (color0: "#000000", color1: "not a color"),
+		launcher_quotas: (open_files: 8, processes: -1),
+		launcher_recent_paths: ("/a", 3, "/b"),
+		launcher_size: (800, 0),
+		key_macros: (("F1", "help"), ("F2", 2)),
+	)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if problems := ValidateConfig(config); len(problems) == 0 {
+		t.Fatal("expected problems")
+	}
+
+	if _, ok := config["theme"]; ok {
+		t.Error("bad top-level setting kept")
+	}
+	if config.GetInt("font_size", 0) != 14 {
+		t.Error("good setting removed")
+	}
+	colors, _ := config["term_colors"].(pawscript.PSLMap)
+	if _, ok := colors["color1"]; ok || colors["color0"] != "#000000" {
+		t.Errorf("term_colors: got %v, want only color0", colors)
+	}
+	quotas, _ := config["launcher_quotas"].(pawscript.PSLMap)
+	if _, ok := quotas["processes"]; ok || quotas.GetInt("open_files", 0) != 8 {
+		t.Errorf("launcher_quotas: got %v, want only open_files", quotas)
+	}
+	paths, _ := config["launcher_recent_paths"].(pawscript.PSLList)
+	if len(paths) != 2 || paths[0] != "/a" || paths[1] != "/b" {
+		t.Errorf("launcher_recent_paths: got %v, want (/a, /b)", paths)
+	}
+	// A fixed-length list left too short goes as a whole
+	if _, ok := config["launcher_size"]; ok {
+		t.Error("launcher_size kept with one item")
+	}
+	macros, _ := config["key_macros"].(pawscript.PSLList)
+	if len(macros) != 1 {
+		t.Errorf("key_macros: got %v, want the F1 macro only", macros)
+	}
+	if problems := ValidateConfig(config); len(problems) != 0 {
+		t.Errorf("problems left: %v", problems)
+	}
+}
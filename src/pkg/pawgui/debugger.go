@@ -0,0 +1,59 @@
+package pawgui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	pawscript "github.com/phroun/pawscript/src"
+)
+
+// Debugger panel text
+// Both launchers show a paused script the same way: where it stopped, then the
+// call stack and the variables in scope as lines of text.
+
+// DebugPauseStatus is the panel's status line for a paused script
+func DebugPauseStatus(pause *pawscript.DebugPause) string {
+	command := strings.TrimSpace(pause.Command)
+	if i := strings.IndexByte(command, '\n'); i >= 0 {
+		command = command[:i] + " ..."
+	}
+	return fmt.Sprintf("Paused at %s:%d: %s", filepath.Base(pause.File), pause.Line, command)
+}
+
+// DebugStackText is the call stack, innermost first, a frame a line
+func DebugStackText(pause *pawscript.DebugPause) string {
+	var lines []string
+	for _, frame := range pause.Stack {
+		name := frame.Macro
+		if name == "" {
+			name = "(script)"
+		}
+		lines = append(lines, fmt.Sprintf("%s  %s:%d", name, filepath.Base(frame.File), frame.Line))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// DebugVariablesText is the variables in scope, a name and value a line
+func DebugVariablesText(pause *pawscript.DebugPause) string {
+	var lines []string
+	for _, v := range pause.Variables {
+		lines = append(lines, v.Name+" = "+v.Value)
+	}
+	if len(lines) == 0 {
+		return "(no variables)"
+	}
+	return strings.Join(lines, "\n")
+}
+
+// DebugGutterMark is what the editor's gutter shows beside a line: the paused
+// line, a breakpoint, or nothing
+func DebugGutterMark(breakpoint, paused bool) string {
+	switch {
+	case paused:
+		return "▶"
+	case breakpoint:
+		return "●"
+	}
+	return ""
+}
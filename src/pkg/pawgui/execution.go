@@ -36,6 +36,7 @@ type ScriptRunner struct {
 	configHelper *ConfigHelper
 	repl         *pawscript.REPL
 	outputFunc   func(string)
+	examplesDir  string
 
 	// Callbacks
 	OnScriptStart func()
@@ -47,6 +48,7 @@ type ScriptRunnerOptions struct {
 	Channels     *ConsoleChannels
 	ConfigHelper *ConfigHelper
 	OutputFunc   func(string) // Function to output text to terminal
+	ExamplesDir  string       // Scripts in it run under the "untrusted" profile
 }
 
 // NewScriptRunner creates a new ScriptRunner.
@@ -55,6 +57,7 @@ func NewScriptRunner(opts ScriptRunnerOptions) *ScriptRunner {
 		channels:     opts.Channels,
 		configHelper: opts.ConfigHelper,
 		outputFunc:   opts.OutputFunc,
+		examplesDir:  opts.ExamplesDir,
 	}
 }
 
@@ -126,10 +129,15 @@ func (sr *ScriptRunner) ExecuteScript(filePath string, content []byte, onComplet
 	scriptDir := filepath.Dir(filePath)
 	fileAccess := CreateFileAccessConfig(scriptDir)
 
-	// Get optimization level
+	// Get optimization level, permission profile and quotas
 	optLevel := 1
+	var permissions *pawscript.PermissionProfile
+	var quotas *pawscript.ResourceQuotas
 	if sr.configHelper != nil {
+		sr.configHelper.ApplyGrantedRoots(fileAccess)
 		optLevel = sr.configHelper.GetOptimizationLevel()
+		permissions = sr.configHelper.GetLauncherPermissions(filePath, sr.examplesDir)
+		quotas = sr.configHelper.GetLauncherQuotas()
 	}
 
 	// Create PawScript instance
@@ -140,7 +148,11 @@ func (sr *ScriptRunner) ExecuteScript(filePath string, content []byte, onComplet
 		ShowErrorContext:     true,
 		ContextLines:         2,
 		FileAccess:           fileAccess,
+		Permissions:          permissions,
+		Quotas:               quotas,
+		EnvAllowlist:         pawscript.DefaultEnvAllowlist,
 		ScriptDir:            scriptDir,
+		SecretNamespace:      secretNamespace(filePath),
 		OptLevel:             pawscript.OptimizationLevel(optLevel),
 	})
 
@@ -152,6 +164,7 @@ func (sr *ScriptRunner) ExecuteScript(filePath string, content []byte, onComplet
 		// Create restricted snapshot and execute
 		snapshot := ps.CreateRestrictedSnapshot()
 		ps.ExecuteWithEnvironment(string(content), snapshot, filePath, 0, 0)
+		ps.Shutdown()
 
 		// Flush output
 		sr.channels.Flush()
@@ -172,6 +185,14 @@ func (sr *ScriptRunner) ExecuteScript(filePath string, content []byte, onComplet
 	}()
 }
 
+// secretNamespace returns the keychain namespace for a script (its absolute path)
+func secretNamespace(filePath string) string {
+	if absPath, err := filepath.Abs(filePath); err == nil {
+		return absPath
+	}
+	return filePath
+}
+
 // CreatePawScriptInstance creates a new PawScript instance configured for script execution.
 func CreatePawScriptInstance(filePath string, optLevel int) *pawscript.PawScript {
 	scriptDir := filepath.Dir(filePath)
@@ -185,6 +206,7 @@ func CreatePawScriptInstance(filePath string, optLevel int) *pawscript.PawScript
 		ContextLines:         2,
 		FileAccess:           fileAccess,
 		ScriptDir:            scriptDir,
+		SecretNamespace:      secretNamespace(filePath),
 		OptLevel:             pawscript.OptimizationLevel(optLevel),
 	})
 }
@@ -0,0 +1,273 @@
+package pawgui
+
+import "unicode"
+
+// Script highlighting
+// The launcher's editor pane colors PawScript a line at a time, as the toolkits'
+// text widgets redraw it. What a line ends inside of, a block comment or a quoted
+// string, is carried to the next as a HighlightState, so an edit to one line only
+// recolors those after it while that changes.
+
+// HighlightKind is what a run of script text is
+type HighlightKind int
+
+const (
+	HighlightPlain    HighlightKind = iota
+	HighlightComment                // # comments and #( )# or #{ }# blocks
+	HighlightString                 // "..." and '...'
+	HighlightNumber                 // 42, -1.5
+	HighlightCommand                // The first word of a statement
+	HighlightVariable               // $1, ~name, ?name, and name: being assigned
+	HighlightBracket                // ( ) { } [ ]
+)
+
+// HighlightSpan is a run of one kind, from Start up to End in runes of the line
+type HighlightSpan struct {
+	Start int
+	End   int
+	Kind  HighlightKind
+}
+
+// HighlightState is what a line ends inside of, for the next line. The zero value
+// is outside of everything, as the first line begins.
+type HighlightState int
+
+const (
+	stateDoubleQuote HighlightState = 1
+	stateSingleQuote HighlightState = 2
+	stateBlockParen  HighlightState = 3 // Low bits; the nesting depth is above them
+	stateBlockBrace  HighlightState = 4
+	stateDepthShift                 = 3
+)
+
+// blockComment returns the closing character and depth of the block comment the
+// state is inside, if it is
+func (s HighlightState) blockComment() (closing rune, depth int, ok bool) {
+	switch s & (1<<stateDepthShift - 1) {
+	case stateBlockParen:
+		return ')', int(s >> stateDepthShift), true
+	case stateBlockBrace:
+		return '}', int(s >> stateDepthShift), true
+	}
+	return 0, 0, false
+}
+
+// inBlockComment is the state inside a block comment closed by closing, depth deep
+func inBlockComment(closing rune, depth int) HighlightState {
+	s := stateBlockParen
+	if closing == '}' {
+		s = stateBlockBrace
+	}
+	return s | HighlightState(depth)<<stateDepthShift
+}
+
+// HighlightLine splits a line of script, without its newline, into the runs to
+// color, given the state the line before it ended in. Runs that are plain text are
+// left out.
+func HighlightLine(line string, state HighlightState) ([]HighlightSpan, HighlightState) {
+	runes := []rune(line)
+	var spans []HighlightSpan
+	add := func(start, end int, kind HighlightKind) {
+		if end > start {
+			spans = append(spans, HighlightSpan{Start: start, End: end, Kind: kind})
+		}
+	}
+
+	i := 0
+	statementStart := true
+	switch {
+	case state == stateDoubleQuote || state == stateSingleQuote:
+		quote := '"'
+		if state == stateSingleQuote {
+			quote = '\''
+		}
+		end, closed := scanQuoted(runes, 0, quote)
+		add(0, end, HighlightString)
+		if !closed {
+			return spans, state
+		}
+		i = end
+		statementStart = false
+	default:
+		if closing, depth, ok := state.blockComment(); ok {
+			end, depth := scanBlockComment(runes, 0, closing, depth)
+			add(0, end, HighlightComment)
+			if depth > 0 {
+				return spans, inBlockComment(closing, depth)
+			}
+			i = end
+		}
+	}
+
+	for i < len(runes) {
+		r := runes[i]
+		switch {
+		case r == '\\' && i+1 < len(runes):
+			i += 2
+			statementStart = false
+
+		case r == '"' || r == '\'':
+			end, closed := scanQuoted(runes, i+1, r)
+			add(i, end, HighlightString)
+			if !closed {
+				if r == '\'' {
+					return spans, stateSingleQuote
+				}
+				return spans, stateDoubleQuote
+			}
+			i = end
+			statementStart = false
+
+		case r == '#' && i+1 < len(runes) && (runes[i+1] == '(' || runes[i+1] == '{'):
+			closing := ')'
+			if runes[i+1] == '{' {
+				closing = '}'
+			}
+			end, depth := scanBlockComment(runes, i+2, closing, 1)
+			add(i, end, HighlightComment)
+			if depth > 0 {
+				return spans, inBlockComment(closing, depth)
+			}
+			i = end
+
+		case r == '#' && (i == 0 || unicode.IsSpace(runes[i-1])) &&
+			(i+1 == len(runes) || unicode.IsSpace(runes[i+1]) || runes[i+1] == '!'):
+			add(i, len(runes), HighlightComment)
+			return spans, 0
+
+		case unicode.IsSpace(r) || r == ',':
+			i++
+
+		case r == ';':
+			i++
+			statementStart = true
+
+		case isBracket(r):
+			add(i, i+1, HighlightBracket)
+			i++
+			statementStart = r == '(' || r == '{'
+
+		case (r == '$' || r == '~' || r == '?') && i+1 < len(runes) && isWordRune(runes[i+1]):
+			end := scanWord(runes, i+1)
+			add(i, end, HighlightVariable)
+			i = end
+			statementStart = false
+
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			end := i + 1
+			for end < len(runes) && (unicode.IsDigit(runes[end]) || runes[end] == '.') {
+				end++
+			}
+			add(i, end, HighlightNumber)
+			i = end
+			statementStart = false
+
+		case isWordRune(r):
+			end := scanWord(runes, i)
+			if end < len(runes) && runes[end] == ':' {
+				add(i, end, HighlightVariable)
+			} else if statementStart {
+				add(i, end, HighlightCommand)
+			}
+			i = end
+			statementStart = false
+
+		default:
+			i++
+			statementStart = false
+		}
+	}
+	return spans, 0
+}
+
+// isBracket reports whether r opens or closes a block, expression or list
+func isBracket(r rune) bool {
+	switch r {
+	case '(', ')', '{', '}', '[', ']':
+		return true
+	}
+	return false
+}
+
+// isWordRune reports whether r can be part of a command or variable name
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.'
+}
+
+// scanWord returns where the name starting at i ends
+func scanWord(runes []rune, i int) int {
+	for i < len(runes) && isWordRune(runes[i]) {
+		i++
+	}
+	return i
+}
+
+// scanQuoted returns where the quoted string whose text starts at i ends, after
+// its closing quote, and whether it closed on this line
+func scanQuoted(runes []rune, i int, quote rune) (int, bool) {
+	for i < len(runes) {
+		switch runes[i] {
+		case '\\':
+			i += 2
+			continue
+		case quote:
+			return i + 1, true
+		}
+		i++
+	}
+	return len(runes), false
+}
+
+// scanBlockComment returns where the block comment continuing at i ends, after
+// its closing mark, and how deeply nested it still is there (0 once it closed)
+func scanBlockComment(runes []rune, i int, closing rune, depth int) (int, int) {
+	open := '('
+	if closing == '}' {
+		open = '{'
+	}
+	for i < len(runes) {
+		switch {
+		case runes[i] == '\\':
+			i += 2
+			continue
+		case runes[i] == '#' && i+1 < len(runes) && runes[i+1] == open:
+			depth++
+			i += 2
+			continue
+		case runes[i] == closing && i+1 < len(runes) && runes[i+1] == '#':
+			depth--
+			i += 2
+			if depth == 0 {
+				return i, 0
+			}
+			continue
+		}
+		i++
+	}
+	return len(runes), depth
+}
+
+// HighlightColor is the color, as #rrggbb, that the editor shows a kind in, on a
+// dark or light background. Plain text takes the editor's own color ("").
+func HighlightColor(kind HighlightKind, isDark bool) string {
+	dark := map[HighlightKind]string{
+		HighlightComment:  "#6a9955",
+		HighlightString:   "#ce9178",
+		HighlightNumber:   "#b5cea8",
+		HighlightCommand:  "#569cd6",
+		HighlightVariable: "#9cdcfe",
+		HighlightBracket:  "#d7ba7d",
+	}
+	light := map[HighlightKind]string{
+		HighlightComment:  "#008000",
+		HighlightString:   "#a31515",
+		HighlightNumber:   "#098658",
+		HighlightCommand:  "#0000ff",
+		HighlightVariable: "#001080",
+		HighlightBracket:  "#795e26",
+	}
+	if isDark {
+		return dark[kind]
+	}
+	return light[kind]
+}
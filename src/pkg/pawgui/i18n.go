@@ -0,0 +1,243 @@
+package pawgui
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/phroun/pawscript/src"
+)
+
+// Localization
+// The launchers show their text through Tr, which looks it up in the message
+// catalog of the language in use. A catalog is a PSL file listing pairs of the
+// English text and its translation:
+//
+//	(
+//		language: "Español",
+//		messages: (
+//			("Settings...", "Configuración..."),
+//			("Failed to save file: %v", "No se pudo guardar el archivo: %v"),
+//		),
+//	)
+//
+// The catalogs in locales/ are built in. One in ~/.paw/locales named for its
+// language (es.psl, or pt_BR.psl for a region's) adds to the built-in one or adds
+// a language. Text a catalog doesn't have is shown in English. The language
+// setting picks the language by its code; "auto", the default, follows the OS's.
+// The launchers' text is read as their windows are made, so a new language shows
+// once they restart.
+
+//go:embed locales/*.psl
+var builtinCatalogs embed.FS
+
+// Language is a language the launchers can be shown in
+type Language struct {
+	Code string // As the language setting has it: "es"
+	Name string // In the language itself: "Español"
+}
+
+// catalog is the message catalog Tr uses
+var catalog struct {
+	sync.RWMutex
+	code     string
+	messages map[string]string
+}
+
+// GetLocaleDir returns the directory of the user's message catalogs
+func GetLocaleDir() string {
+	return filepath.Join(GetConfigDir(), "locales")
+}
+
+// ParseCatalog reads a message catalog, returning the name of its language and
+// its translations by their English text
+func ParseCatalog(data string) (name string, messages map[string]string, err error) {
+	parsed, err := pawscript.ParsePSL(data)
+	if err != nil {
+		return "", nil, err
+	}
+	messages = make(map[string]string)
+	items, _ := parsed["messages"].(pawscript.PSLList)
+	for i, item := range items {
+		pair, ok := item.(pawscript.PSLList)
+		if !ok || len(pair) != 2 {
+			return "", nil, fmt.Errorf("messages[%d]: expected a pair of strings", i)
+		}
+		english, ok1 := pair[0].(string)
+		translated, ok2 := pair[1].(string)
+		if !ok1 || !ok2 {
+			return "", nil, fmt.Errorf("messages[%d]: expected a pair of strings", i)
+		}
+		messages[english] = translated
+	}
+	return parsed.GetString("language", ""), messages, nil
+}
+
+// readCatalog reads the catalog of the language with the code given, built in
+// and the user's together, ok being false if there's neither
+func readCatalog(code string) (name string, messages map[string]string, ok bool, err error) {
+	messages = make(map[string]string)
+	sources := []func() ([]byte, error){
+		func() ([]byte, error) { return builtinCatalogs.ReadFile("locales/" + code + ".psl") },
+		func() ([]byte, error) { return os.ReadFile(filepath.Join(GetLocaleDir(), code+".psl")) },
+	}
+	for _, source := range sources {
+		data, readErr := source()
+		if readErr != nil {
+			continue
+		}
+		ok = true
+		sourceName, sourceMessages, parseErr := ParseCatalog(string(data))
+		if parseErr != nil {
+			err = fmt.Errorf("%s catalog: %w", code, parseErr)
+			continue
+		}
+		if sourceName != "" {
+			name = sourceName
+		}
+		for english, translated := range sourceMessages {
+			messages[english] = translated
+		}
+	}
+	return name, messages, ok, err
+}
+
+// Languages returns the languages there are catalogs for, English first
+func Languages() []Language {
+	codes := map[string]bool{}
+	if entries, err := builtinCatalogs.ReadDir("locales"); err == nil {
+		for _, entry := range entries {
+			codes[strings.TrimSuffix(entry.Name(), ".psl")] = true
+		}
+	}
+	if entries, err := os.ReadDir(GetLocaleDir()); err == nil {
+		for _, entry := range entries {
+			if entry.Type().IsRegular() && strings.HasSuffix(entry.Name(), ".psl") {
+				codes[strings.TrimSuffix(entry.Name(), ".psl")] = true
+			}
+		}
+	}
+
+	var languages []Language
+	for code := range codes {
+		name, _, _, _ := readCatalog(code)
+		if name == "" {
+			name = code
+		}
+		languages = append(languages, Language{Code: code, Name: name})
+	}
+	sort.Slice(languages, func(i, j int) bool {
+		return languages[i].Code < languages[j].Code
+	})
+	return append([]Language{{Code: "en", Name: "English"}}, languages...)
+}
+
+// SetLanguage has Tr translate to the language with the code given, "en" or ""
+// for English. With no catalog for the language, Tr shows English and an error
+// is returned; so it is if the catalog has problems, after using what it could.
+func SetLanguage(code string) error {
+	messages := map[string]string{}
+	var err error
+	if code != "" && code != "en" {
+		var ok bool
+		_, messages, ok, err = readCatalog(code)
+		if !ok {
+			code, err = "en", fmt.Errorf("no catalog for language %q", code)
+		}
+	}
+
+	catalog.Lock()
+	catalog.code, catalog.messages = code, messages
+	catalog.Unlock()
+	return err
+}
+
+// CurrentLanguage returns the code of the language Tr translates to
+func CurrentLanguage() string {
+	catalog.RLock()
+	defer catalog.RUnlock()
+	if catalog.code == "" {
+		return "en"
+	}
+	return catalog.code
+}
+
+// Tr returns text, in English, in the current language
+func Tr(text string) string {
+	catalog.RLock()
+	defer catalog.RUnlock()
+	if translated, ok := catalog.messages[text]; ok && translated != "" {
+		return translated
+	}
+	return text
+}
+
+// localeCodes returns the catalog codes a locale name could have, most specific
+// first: "pt_BR" and "pt" for pt_BR.UTF-8 or pt-BR
+func localeCodes(locale string) []string {
+	if i := strings.IndexAny(locale, ".@"); i >= 0 {
+		locale = locale[:i]
+	}
+	locale = strings.ReplaceAll(locale, "-", "_")
+	lang, region, hasRegion := strings.Cut(locale, "_")
+	lang = strings.ToLower(lang)
+	if lang == "" || lang == "c" || lang == "posix" {
+		return nil
+	}
+	if hasRegion && region != "" {
+		return []string{lang + "_" + strings.ToUpper(region), lang}
+	}
+	return []string{lang}
+}
+
+// DetectLanguage returns the code of the first of the OS's languages there's a
+// catalog for, "en" if none
+func DetectLanguage() string {
+	var locales []string
+	if language := os.Getenv("LANGUAGE"); language != "" {
+		locales = append(locales, strings.Split(language, ":")...)
+	}
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if locale := os.Getenv(name); locale != "" {
+			locales = append(locales, locale)
+		}
+	}
+	locales = append(locales, osLanguages()...)
+
+	available := map[string]bool{}
+	for _, language := range Languages() {
+		available[language.Code] = true
+	}
+	for _, locale := range locales {
+		for _, code := range localeCodes(locale) {
+			if available[code] {
+				return code
+			}
+		}
+	}
+	return "en"
+}
+
+// GetLanguage returns the language setting: a language's code, or "auto" for
+// the OS's
+func (h *ConfigHelper) GetLanguage() string {
+	if h.Config != nil {
+		if language := h.Config.GetString("language", "auto"); language != "" {
+			return language
+		}
+	}
+	return "auto"
+}
+
+// ApplyLanguage has Tr translate to the language the settings pick
+func (h *ConfigHelper) ApplyLanguage() error {
+	language := h.GetLanguage()
+	if language == "auto" {
+		language = DetectLanguage()
+	}
+	return SetLanguage(language)
+}
@@ -0,0 +1,26 @@
+//go:build !windows
+
+package pawgui
+
+import (
+	"os/exec"
+	"regexp"
+	"runtime"
+)
+
+// appleLanguage matches a language in the list defaults prints for AppleLanguages
+var appleLanguage = regexp.MustCompile(`[A-Za-z]{2,3}(?:[-_][A-Za-z0-9]+)*`)
+
+// osLanguages returns the user's languages beyond the locale environment
+// variables, most preferred first: on macOS those of AppleLanguages, which apps
+// started from the Finder have no environment for
+func osLanguages() []string {
+	if runtime.GOOS != "darwin" {
+		return nil
+	}
+	out, err := exec.Command("defaults", "read", "-g", "AppleLanguages").Output()
+	if err != nil {
+		return nil
+	}
+	return appleLanguage.FindAllString(string(out), -1)
+}
@@ -0,0 +1,101 @@
+package pawgui
+
+import (
+	"fmt"
+
+	pawscript "github.com/phroun/pawscript/src"
+	"github.com/phroun/pawscript/src/pkg/purfecterm"
+)
+
+// KeyMacro is a key chord and the text it sends, from the key_macros setting
+type KeyMacro struct {
+	Chord string
+	Text  string
+}
+
+// GetKeyMacros returns the configured key macros.
+// key_macros is a list of (chord, text) pairs, such as (("F5", "make\n"), ("Ctrl+Shift+L", "ls -l\n")).
+func (h *ConfigHelper) GetKeyMacros() []KeyMacro {
+	var macros []KeyMacro
+	if h.Config == nil {
+		return macros
+	}
+	list, ok := h.Config["key_macros"].(pawscript.PSLList)
+	if !ok {
+		return macros
+	}
+	for _, item := range list {
+		pair, ok := item.(pawscript.PSLList)
+		if !ok || len(pair) != 2 {
+			continue
+		}
+		chord, chordOK := pair[0].(string)
+		text, textOK := pair[1].(string)
+		if chordOK && textOK {
+			macros = append(macros, KeyMacro{Chord: chord, Text: text})
+		}
+	}
+	return macros
+}
+
+// NewKeyMap returns a key map holding the configured key macros, and the problems
+// with any that couldn't be bound
+func (h *ConfigHelper) NewKeyMap() (*purfecterm.KeyMap, []error) {
+	keyMap := purfecterm.NewKeyMap()
+	var errs []error
+	for _, macro := range h.GetKeyMacros() {
+		if err := keyMap.Bind(macro.Chord, macro.Text); err != nil {
+			errs = append(errs, fmt.Errorf("key_macros: %w", err))
+		}
+	}
+	return keyMap, errs
+}
+
+// RegisterKeyMacroCommand registers the key_macro command, which binds key chords
+// in a console window's key map:
+//
+//	key_macro "F5", "make\n"       F5 types make and Enter
+//	key_macro "Ctrl+K", (clear)    Ctrl+K runs the block in the window's interpreter
+//	key_macro "F5"                 F5 sends its usual sequence again
+//	key_macro                      returns the bound chords
+func RegisterKeyMacroCommand(ps *pawscript.PawScript, keyMap *purfecterm.KeyMap) {
+	ps.RegisterCommand("key_macro", func(ctx *pawscript.Context) pawscript.Result {
+		if keyMap == nil {
+			return pawscript.BoolStatus(false)
+		}
+		if len(ctx.Args) == 0 {
+			chords := make([]interface{}, 0)
+			for _, chord := range keyMap.Chords() {
+				chords = append(chords, chord)
+			}
+			ctx.SetResult(ctx.NewStoredListWithRefs(chords, nil))
+			return pawscript.BoolStatus(true)
+		}
+
+		chord := fmt.Sprintf("%v", ps.ResolveValue(ctx.Args[0]))
+		if len(ctx.Args) == 1 {
+			bound, err := keyMap.Unbind(chord)
+			if err != nil {
+				ctx.LogError(pawscript.CatArgument, fmt.Sprintf("key_macro: %v", err))
+				return pawscript.BoolStatus(false)
+			}
+			ctx.SetResult(bound)
+			return pawscript.BoolStatus(true)
+		}
+
+		var err error
+		switch action := ps.ResolveValue(ctx.Args[1]).(type) {
+		case pawscript.ParenGroup:
+			err = keyMap.BindFunc(chord, func() {
+				ps.Execute(string(action))
+			})
+		default:
+			err = keyMap.Bind(chord, fmt.Sprintf("%v", action))
+		}
+		if err != nil {
+			ctx.LogError(pawscript.CatArgument, fmt.Sprintf("key_macro: %v", err))
+			return pawscript.BoolStatus(false)
+		}
+		return pawscript.BoolStatus(true)
+	})
+}
@@ -0,0 +1,37 @@
+package pawgui
+
+import (
+	"os"
+	"strings"
+)
+
+// Launcher drops
+// Dropping .paw files on a launcher window runs them, as the Run button does (in
+// a console window when the launcher is busy), and dropping a folder opens it in
+// the file list. Drops of anything else are left to whatever they land on: the
+// terminal types their paths.
+
+// LauncherDrop sorts files dropped on a launcher into the scripts it runs or the
+// folder it opens, ok being false when the drop isn't one the launcher takes
+func LauncherDrop(paths []string) (scripts []string, folder string, ok bool) {
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		switch {
+		case err != nil:
+			return nil, "", false
+		case info.IsDir():
+			if folder != "" {
+				return nil, "", false
+			}
+			folder = path
+		case info.Mode().IsRegular() && strings.HasSuffix(strings.ToLower(path), ".paw"):
+			scripts = append(scripts, path)
+		default:
+			return nil, "", false
+		}
+	}
+	if folder != "" && len(scripts) > 0 {
+		return nil, "", false
+	}
+	return scripts, folder, folder != "" || len(scripts) > 0
+}
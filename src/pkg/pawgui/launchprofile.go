@@ -0,0 +1,315 @@
+package pawgui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	pawscript "github.com/phroun/pawscript/src"
+)
+
+// Launch profiles
+// A script can have a launch profile: the arguments it's run with, its
+// optimization level, directories it may use beyond the usual roots, the size of
+// a console window of its own, and whether it's run again when it ends. The
+// launcher applies it whenever it runs the script. A profile comes from a sidecar
+// file beside the script (game.paw.psl for game.paw), as its author wrote it, and
+// from script_profiles in the config, a list of settings each naming its script's
+// absolute path, as the user set it in the Launch Profile dialog; the user's
+// settings win. A sidecar comes with the script, so the roots it adds must be
+// inside the script's directory; only the user can grant more.
+
+// When a script is run again after it ends
+const (
+	RestartNever     = "never"
+	RestartOnFailure = "on_failure"
+	RestartAlways    = "always"
+)
+
+// RestartDelay is how long the launcher waits before running a script again
+const RestartDelay = 3 * time.Second
+
+// LaunchProfile is how the launcher runs a script
+type LaunchProfile struct {
+	Args        []string
+	OptLevel    int      // -1 for the optimization_level setting
+	ReadRoots   []string // Added to the usual roots; relative to the script's directory
+	WriteRoots  []string
+	ExecRoots   []string
+	Width       int    // Size of the console window the script runs in,
+	Height      int    // 0 to run it where scripts usually run
+	AutoRestart string // RestartNever, RestartOnFailure or RestartAlways
+}
+
+// DefaultLaunchProfile returns the profile of a script that has none
+func DefaultLaunchProfile() LaunchProfile {
+	return LaunchProfile{OptLevel: -1, AutoRestart: RestartNever}
+}
+
+// LaunchProfileSidecar returns the path of a script's sidecar profile
+func LaunchProfileSidecar(script string) string {
+	return script + ".psl"
+}
+
+// GetLaunchProfile returns a script's launch profile: its sidecar's settings,
+// overridden by the config's. A sidecar that can't be read is reported, and the
+// config's settings still apply.
+func (h *ConfigHelper) GetLaunchProfile(script string) (LaunchProfile, error) {
+	if abs, err := filepath.Abs(script); err == nil {
+		script = abs
+	}
+	merged := pawscript.PSLMap{}
+	var err error
+	if data, readErr := os.ReadFile(LaunchProfileSidecar(script)); readErr == nil {
+		sidecar, parseErr := pawscript.ParsePSL(string(data))
+		if parseErr != nil {
+			err = fmt.Errorf("%s: %w", LaunchProfileSidecar(script), parseErr)
+		}
+		for key, value := range sidecar {
+			merged[key] = value
+		}
+		var ignored []string
+		for _, key := range []string{"read_roots", "write_roots", "exec_roots"} {
+			roots, outside := rootsInside(pslStrings(merged[key]), filepath.Dir(script))
+			merged[key] = pslList(roots)
+			ignored = append(ignored, outside...)
+		}
+		if len(ignored) > 0 && err == nil {
+			err = fmt.Errorf("%s: roots outside the script's directory ignored: %s",
+				LaunchProfileSidecar(script), strings.Join(ignored, ", "))
+		}
+	}
+	for key, value := range h.savedLaunchProfile(script) {
+		merged[key] = value
+	}
+	return launchProfileFromPSL(merged), err
+}
+
+// HasSavedLaunchProfile reports whether the config has settings for a script
+func (h *ConfigHelper) HasSavedLaunchProfile(script string) bool {
+	if abs, err := filepath.Abs(script); err == nil {
+		script = abs
+	}
+	return h.savedLaunchProfile(script) != nil
+}
+
+// SetLaunchProfile saves a script's launch profile in the config, or removes it
+// for nil, leaving the sidecar's
+func (h *ConfigHelper) SetLaunchProfile(script string, profile *LaunchProfile) {
+	if h.Config == nil {
+		return
+	}
+	if abs, err := filepath.Abs(script); err == nil {
+		script = abs
+	}
+	profiles := pawscript.PSLList{}
+	if list, ok := h.Config["script_profiles"].(pawscript.PSLList); ok {
+		for _, item := range list {
+			if saved, ok := item.(pawscript.PSLMap); !ok || saved.GetString("script", "") != script {
+				profiles = append(profiles, item)
+			}
+		}
+	}
+	if profile != nil {
+		saved := profile.toPSL()
+		saved.Set("script", script)
+		profiles = append(profiles, saved)
+	}
+	if len(profiles) == 0 {
+		delete(h.Config, "script_profiles")
+		return
+	}
+	h.Config.Set("script_profiles", profiles)
+}
+
+// savedLaunchProfile returns the config's settings for a script, nil for none
+func (h *ConfigHelper) savedLaunchProfile(script string) pawscript.PSLMap {
+	if h.Config == nil {
+		return nil
+	}
+	list, _ := h.Config["script_profiles"].(pawscript.PSLList)
+	for _, item := range list {
+		if saved, ok := item.(pawscript.PSLMap); ok && saved.GetString("script", "") == script {
+			return saved
+		}
+	}
+	return nil
+}
+
+// rootsInside splits roots into those inside dir, relative roots being resolved
+// against it, and those outside
+func rootsInside(roots []string, dir string) (inside, outside []string) {
+	return rootsInsideFrom(roots, dir, dir)
+}
+
+// launchProfileFromPSL reads a profile's settings
+func launchProfileFromPSL(saved pawscript.PSLMap) LaunchProfile {
+	profile := DefaultLaunchProfile()
+	profile.Args = pslStrings(saved["args"])
+	profile.OptLevel = saved.GetInt("optimization_level", -1)
+	if profile.OptLevel < -1 || profile.OptLevel > int(pawscript.OptimizeBasic) {
+		profile.OptLevel = -1
+	}
+	profile.ReadRoots = pslStrings(saved["read_roots"])
+	profile.WriteRoots = pslStrings(saved["write_roots"])
+	profile.ExecRoots = pslStrings(saved["exec_roots"])
+	profile.Width = saved.GetInt("window_width", 0)
+	profile.Height = saved.GetInt("window_height", 0)
+	if profile.Width <= 0 || profile.Height <= 0 {
+		profile.Width, profile.Height = 0, 0
+	}
+	switch restart := saved.GetString("auto_restart", RestartNever); restart {
+	case RestartOnFailure, RestartAlways:
+		profile.AutoRestart = restart
+	}
+	return profile
+}
+
+// toPSL returns the settings of a profile that differ from the defaults
+func (p LaunchProfile) toPSL() pawscript.PSLMap {
+	saved := pawscript.PSLMap{}
+	if len(p.Args) > 0 {
+		saved.Set("args", pslList(p.Args))
+	}
+	if p.OptLevel >= 0 {
+		saved.Set("optimization_level", p.OptLevel)
+	}
+	if len(p.ReadRoots) > 0 {
+		saved.Set("read_roots", pslList(p.ReadRoots))
+	}
+	if len(p.WriteRoots) > 0 {
+		saved.Set("write_roots", pslList(p.WriteRoots))
+	}
+	if len(p.ExecRoots) > 0 {
+		saved.Set("exec_roots", pslList(p.ExecRoots))
+	}
+	if p.Width > 0 && p.Height > 0 {
+		saved.Set("window_width", p.Width)
+		saved.Set("window_height", p.Height)
+	}
+	if p.AutoRestart != "" && p.AutoRestart != RestartNever {
+		saved.Set("auto_restart", p.AutoRestart)
+	}
+	return saved
+}
+
+// pslStrings returns the strings of a PSL list
+func pslStrings(value interface{}) []string {
+	var strs []string
+	list, _ := value.(pawscript.PSLList)
+	for _, item := range list {
+		if s, ok := item.(string); ok && s != "" {
+			strs = append(strs, s)
+		}
+	}
+	return strs
+}
+
+// pslList returns a PSL list of strings
+func pslList(strs []string) pawscript.PSLList {
+	list := make(pawscript.PSLList, 0, len(strs))
+	for _, s := range strs {
+		list = append(list, s)
+	}
+	return list
+}
+
+// OptimizationLevel returns the level to run the script at, given the
+// optimization_level setting
+func (p LaunchProfile) OptimizationLevel(configured int) int {
+	if p.OptLevel >= 0 {
+		return p.OptLevel
+	}
+	return configured
+}
+
+// ApplyRoots adds the profile's roots to a script's file access, resolving
+// relative ones against the script's directory. Write roots allow reading too.
+func (p LaunchProfile) ApplyRoots(fileAccess *pawscript.FileAccessConfig, scriptDir string) {
+	resolve := func(roots []string) []string {
+		resolved := make([]string, 0, len(roots))
+		for _, root := range roots {
+			if !filepath.IsAbs(root) {
+				root = filepath.Join(scriptDir, root)
+			}
+			resolved = append(resolved, filepath.Clean(root))
+		}
+		return resolved
+	}
+	writable := resolve(p.WriteRoots)
+	fileAccess.ReadRoots = append(fileAccess.ReadRoots, resolve(p.ReadRoots)...)
+	fileAccess.ReadRoots = append(fileAccess.ReadRoots, writable...)
+	fileAccess.WriteRoots = append(fileAccess.WriteRoots, writable...)
+	fileAccess.ExecRoots = append(fileAccess.ExecRoots, resolve(p.ExecRoots)...)
+}
+
+// Restarts reports whether the script is run again after it ends, ok or not
+func (p LaunchProfile) Restarts(ok bool) bool {
+	return p.AutoRestart == RestartAlways || (p.AutoRestart == RestartOnFailure && !ok)
+}
+
+// OwnWindow reports whether the script runs in a console window of its own
+func (p LaunchProfile) OwnWindow() bool {
+	return p.Width > 0 && p.Height > 0
+}
+
+// SplitArgs splits a line of arguments at spaces, as a shell does: quotes (' or
+// ") keep spaces in an argument, and a backslash keeps the character after it
+// outside single quotes
+func SplitArgs(line string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	inArg, escaped := false, false
+	var quote rune
+	for _, r := range line {
+		switch {
+		case escaped:
+			arg.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inArg = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in arguments", quote)
+	}
+	if escaped {
+		return nil, fmt.Errorf("arguments end with a backslash")
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
+
+// JoinArgs writes arguments as a line SplitArgs splits back into them
+func JoinArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg != "" && !strings.ContainsAny(arg, " \t'\"\\") {
+			quoted[i] = arg
+		} else {
+			quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+	}
+	return strings.Join(quoted, " ")
+}
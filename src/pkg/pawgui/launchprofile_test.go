@@ -0,0 +1,87 @@
+package pawgui
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"", nil},
+		{"  a  b\tc ", []string{"a", "b", "c"}},
+		{`"a b" 'c d'`, []string{"a b", "c d"}},
+		{`a"b c"d`, []string{"ab cd"}},
+		{`'' ""`, []string{"", ""}},
+		{`a\ b \"c\"`, []string{"a b", `"c"`}},
+		{`'a\b' "a\"b"`, []string{`a\b`, `a"b`}},
+		{`'it'\''s'`, []string{"it's"}},
+	}
+	for _, tt := range tests {
+		got, err := SplitArgs(tt.line)
+		if err != nil {
+			t.Errorf("%q: %v", tt.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %q, want %q", tt.line, got, tt.want)
+		}
+	}
+
+	for _, line := range []string{`"a`, `'a`, `a\`} {
+		if _, err := SplitArgs(line); err == nil {
+			t.Errorf("%q: no error", line)
+		}
+	}
+}
+
+func TestJoinArgsRoundTrip(t *testing.T) {
+	for _, args := range [][]string{
+		{"plain", "--flag=1"},
+		{"two words", "tab\there"},
+		{"", "empty before"},
+		{"it's", `say "hi"`, `back\slash`, `'\''`},
+	} {
+		line := JoinArgs(args)
+		got, err := SplitArgs(line)
+		if err != nil {
+			t.Errorf("%q: %v", args, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, args) {
+			t.Errorf("%q: joined as %s, split back as %q", args, line, got)
+		}
+	}
+}
+
+func TestRootsInside(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "project")
+	roots := []string{
+		"assets",
+		filepath.Join("a", "..", "b"),
+		"..",
+		filepath.Join("..", "project-other"),
+		filepath.Join("..", "project", "data"),
+		filepath.Join(dir, "abs"),
+		dir,
+		filepath.Dir(dir),
+	}
+	inside, outside := rootsInside(roots, dir)
+	wantInside := []string{
+		"assets",
+		filepath.Join("a", "..", "b"),
+		filepath.Join("..", "project", "data"),
+		filepath.Join(dir, "abs"),
+		dir,
+	}
+	wantOutside := []string{"..", filepath.Join("..", "project-other"), filepath.Dir(dir)}
+	if !reflect.DeepEqual(inside, wantInside) {
+		t.Errorf("inside: got %q, want %q", inside, wantInside)
+	}
+	if !reflect.DeepEqual(outside, wantOutside) {
+		t.Errorf("outside: got %q, want %q", outside, wantOutside)
+	}
+}
@@ -0,0 +1,50 @@
+package pawgui
+
+import (
+	"fmt"
+
+	pawscript "github.com/phroun/pawscript/src"
+	"github.com/phroun/pawscript/src/pkg/purfecterm"
+)
+
+// RegisterMenuItemCommand registers the menu_item command, which adds entries to
+// a console window's context menu:
+//
+//	menu_item "Build", (make)    Build runs the block in the window's interpreter
+//	menu_item "Build"            removes the entry
+//	menu_item                    returns the added entries' labels
+func RegisterMenuItemCommand(ps *pawscript.PawScript, items *purfecterm.MenuItems) {
+	ps.RegisterCommand("menu_item", func(ctx *pawscript.Context) pawscript.Result {
+		if items == nil {
+			return pawscript.BoolStatus(false)
+		}
+		if len(ctx.Args) == 0 {
+			labels := make([]interface{}, 0)
+			for _, item := range items.Items() {
+				labels = append(labels, item.Label)
+			}
+			ctx.SetResult(ctx.NewStoredListWithRefs(labels, nil))
+			return pawscript.BoolStatus(true)
+		}
+
+		label := fmt.Sprintf("%v", ps.ResolveValue(ctx.Args[0]))
+		if len(ctx.Args) == 1 {
+			ctx.SetResult(items.Remove(label))
+			return pawscript.BoolStatus(true)
+		}
+
+		action, ok := ps.ResolveValue(ctx.Args[1]).(pawscript.ParenGroup)
+		if !ok {
+			ctx.LogError(pawscript.CatArgument, "menu_item: the action must be a block")
+			return pawscript.BoolStatus(false)
+		}
+		items.Add(purfecterm.MenuItem{
+			Label: label,
+			Activate: func() {
+				// The menu runs this on the UI thread; the script may take its time
+				go ps.Execute(string(action))
+			},
+		})
+		return pawscript.BoolStatus(true)
+	})
+}
@@ -0,0 +1,235 @@
+package pawgui
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	pawscript "github.com/phroun/pawscript/src"
+)
+
+// Script menus
+// A running script adds entries to its window's hamburger menu with the
+// menu_register command. Each entry has a path, "Tools/Build", whose leading parts
+// are submenus, and a path ending in "-" adds a separator there. The launchers
+// show them at the top of the menu, and clear them when the script that added
+// them ends.
+
+// ScriptMenuSeparator is the last part of the path of a separator
+const ScriptMenuSeparator = "-"
+
+// ScriptMenuItem is an entry of the menus scripts added, as the launchers show it
+type ScriptMenuItem struct {
+	Label     string
+	Separator bool
+	Activate  func()            // Run on the UI thread when chosen; nil for a submenu or separator
+	Submenu   []*ScriptMenuItem // Entries of a submenu, in order
+}
+
+// scriptMenuEntry is an entry as it was added
+type scriptMenuEntry struct {
+	path     []string
+	activate func()
+}
+
+// ScriptMenus is the entries scripts added to a window's hamburger menu
+type ScriptMenus struct {
+	mu         sync.Mutex
+	entries    []scriptMenuEntry
+	generation int // Counts changes, so a menu knows when to rebuild
+}
+
+// NewScriptMenus creates an empty set of script menus
+func NewScriptMenus() *ScriptMenus {
+	return &ScriptMenus{}
+}
+
+// SplitScriptMenuPath splits "Tools/Build" into its parts, dropping empty ones
+func SplitScriptMenuPath(path string) []string {
+	var parts []string
+	for _, part := range strings.Split(path, "/") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// samePath reports whether two paths are the same
+func samePath(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// hasPrefix reports whether path is prefix or inside it
+func hasPrefix(path, prefix []string) bool {
+	return len(path) >= len(prefix) && samePath(path[:len(prefix)], prefix)
+}
+
+// Add adds an entry after the others, or replaces the entry with its path where
+// it is. Separators are always added.
+func (m *ScriptMenus) Add(path []string, activate func()) {
+	if len(path) == 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.generation++
+	if path[len(path)-1] != ScriptMenuSeparator {
+		for i := range m.entries {
+			if samePath(m.entries[i].path, path) {
+				m.entries[i].activate = activate
+				return
+			}
+		}
+	}
+	m.entries = append(m.entries, scriptMenuEntry{path: path, activate: activate})
+}
+
+// Remove removes the entry with the path, or the submenu and everything in it,
+// returning whether there was one
+func (m *ScriptMenus) Remove(path []string) bool {
+	if len(path) == 0 {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	kept := m.entries[:0]
+	for _, entry := range m.entries {
+		if !hasPrefix(entry.path, path) {
+			kept = append(kept, entry)
+		}
+	}
+	removed := len(kept) < len(m.entries)
+	m.entries = kept
+	if removed {
+		m.generation++
+	}
+	return removed
+}
+
+// Clear removes every entry, as when the script that added them ends
+func (m *ScriptMenus) Clear() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.entries) > 0 {
+		m.entries = nil
+		m.generation++
+	}
+}
+
+// Paths returns the entries' paths in order, joined with "/"
+func (m *ScriptMenus) Paths() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	paths := make([]string, 0, len(m.entries))
+	for _, entry := range m.entries {
+		paths = append(paths, strings.Join(entry.path, "/"))
+	}
+	return paths
+}
+
+// Items returns the entries as a tree of menus, and the generation it is of
+func (m *ScriptMenus) Items() ([]*ScriptMenuItem, int) {
+	if m == nil {
+		return nil, 0
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var items []*ScriptMenuItem
+	for _, entry := range m.entries {
+		level := &items
+		for _, part := range entry.path[:len(entry.path)-1] {
+			var submenu *ScriptMenuItem
+			for _, item := range *level {
+				if item.Label == part && item.Activate == nil && !item.Separator {
+					submenu = item
+					break
+				}
+			}
+			if submenu == nil {
+				submenu = &ScriptMenuItem{Label: part}
+				*level = append(*level, submenu)
+			}
+			level = &submenu.Submenu
+		}
+		last := entry.path[len(entry.path)-1]
+		if last == ScriptMenuSeparator {
+			*level = append(*level, &ScriptMenuItem{Separator: true})
+		} else {
+			*level = append(*level, &ScriptMenuItem{Label: last, Activate: entry.activate})
+		}
+	}
+	return items, m.generation
+}
+
+// Generation returns a count that changes whenever the entries do
+func (m *ScriptMenus) Generation() int {
+	if m == nil {
+		return 0
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.generation
+}
+
+// RegisterMenuRegisterCommand registers the menu_register command, which adds
+// entries to a window's hamburger menu:
+//
+//	menu_register "Tools/Build", (make)    adds Build to a Tools submenu, running the block
+//	menu_register "Tools/-"                adds a separator to the Tools submenu
+//	menu_register "Tools"                  removes Tools and everything in it
+//	menu_register                          returns the entries' paths
+//
+// The block runs in the environment of the script that added the entry.
+func RegisterMenuRegisterCommand(ps *pawscript.PawScript, menus *ScriptMenus) {
+	ps.RegisterCommand("menu_register", func(ctx *pawscript.Context) pawscript.Result {
+		if menus == nil {
+			return pawscript.BoolStatus(false)
+		}
+		if len(ctx.Args) == 0 {
+			paths := make([]interface{}, 0)
+			for _, path := range menus.Paths() {
+				paths = append(paths, path)
+			}
+			ctx.SetResult(ctx.NewStoredListWithRefs(paths, nil))
+			return pawscript.BoolStatus(true)
+		}
+
+		path := SplitScriptMenuPath(fmt.Sprintf("%v", ps.ResolveValue(ctx.Args[0])))
+		if len(path) == 0 {
+			ctx.LogError(pawscript.CatArgument, "menu_register requires a menu path")
+			return pawscript.BoolStatus(false)
+		}
+		if path[len(path)-1] == ScriptMenuSeparator {
+			menus.Add(path, nil)
+			return pawscript.BoolStatus(true)
+		}
+		if len(ctx.Args) == 1 {
+			ctx.SetResult(menus.Remove(path))
+			return pawscript.BoolStatus(true)
+		}
+
+		action, ok := ps.ResolveValue(ctx.Args[1]).(pawscript.ParenGroup)
+		if !ok {
+			ctx.LogError(pawscript.CatArgument, "menu_register: the action must be a block")
+			return pawscript.BoolStatus(false)
+		}
+		env := ctx.GetModuleEnv()
+		menus.Add(path, func() {
+			// The menu runs this on the UI thread; the script may take its time
+			go ps.ExecuteWithEnvironment(string(action), env, "", 0, 0)
+		})
+		return pawscript.BoolStatus(true)
+	})
+}
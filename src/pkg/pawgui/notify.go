@@ -0,0 +1,86 @@
+package pawgui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	pawscript "github.com/phroun/pawscript/src"
+)
+
+// Desktop notifications
+// Scripts tell the user they're done with the notify command, which reaches them
+// even when the script's window is minimized or behind others. It goes through
+// the system's own notifications: libnotify's notify-send, Notification Center on
+// macOS, or a toast on Windows.
+
+// notifyAppName is the application notifications are shown under
+const notifyAppName = "PawScript"
+
+// windowsToastScript shows a toast with PowerShell, reading the title and body
+// from the environment so they need no quoting
+const windowsToastScript = `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $template.GetElementsByTagName('text')
+$text.Item(0).AppendChild($template.CreateTextNode($env:PAWSCRIPT_NOTIFY_TITLE)) > $null
+$text.Item(1).AppendChild($template.CreateTextNode($env:PAWSCRIPT_NOTIFY_BODY)) > $null
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('` + notifyAppName + `').Show([Windows.UI.Notifications.ToastNotification]::new($template))`
+
+// Notify shows a desktop notification
+func Notify(title, body string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		// Arguments reach the script through argv, so they need no quoting
+		cmd = exec.Command("osascript",
+			"-e", "on run argv",
+			"-e", "display notification (item 2 of argv) with title (item 1 of argv)",
+			"-e", "end run",
+			title, body)
+	case "windows":
+		cmd = exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", windowsToastScript)
+		cmd.Env = append(os.Environ(), "PAWSCRIPT_NOTIFY_TITLE="+title, "PAWSCRIPT_NOTIFY_BODY="+body)
+	default:
+		if _, err := exec.LookPath("notify-send"); err != nil {
+			return fmt.Errorf("notify-send not found (install libnotify)")
+		}
+		cmd = exec.Command("notify-send", "--app-name="+notifyAppName, "--", title, body)
+	}
+
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return errors.New(msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// RegisterNotifyCommand registers the notify command, which shows a desktop
+// notification:
+//
+//	notify "Build finished"                          a title alone
+//	notify "Build finished", "All 12 targets built"  a title and body
+func RegisterNotifyCommand(ps *pawscript.PawScript) {
+	ps.RegisterCommand("notify", func(ctx *pawscript.Context) pawscript.Result {
+		if len(ctx.Args) < 1 || len(ctx.Args) > 2 {
+			ctx.LogError(pawscript.CatCommand, "Usage: notify <title>, [body]")
+			return pawscript.BoolStatus(false)
+		}
+		title := fmt.Sprintf("%v", ps.ResolveValue(ctx.Args[0]))
+		body := ""
+		if len(ctx.Args) > 1 {
+			body = fmt.Sprintf("%v", ps.ResolveValue(ctx.Args[1]))
+		}
+		if err := Notify(title, body); err != nil {
+			ctx.LogError(pawscript.CatIO, "notify: "+err.Error())
+			return pawscript.BoolStatus(false)
+		}
+		return pawscript.BoolStatus(true)
+	})
+}
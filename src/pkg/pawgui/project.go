@@ -0,0 +1,357 @@
+package pawgui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	pawscript "github.com/phroun/pawscript/src"
+)
+
+// Projects
+// A directory can be opened in the launcher as a project. Its settings are in
+// .paw-project.psl at its root, where they travel with it:
+//
+//	(
+//		name: "Space Game",
+//		pinned: ("main.paw", "tools/build.paw"),
+//		read_roots: (assets),
+//		write_roots: (build),
+//		run_configs: (
+//			(name: "Debug", script: "main.paw", args: (--debug), optimization_level: 0),
+//		),
+//	)
+//
+// Pinned scripts are listed at the top of the file list wherever it is. The
+// roots are added to the usual ones of every script in the project, relative ones
+// being in the project root. A run configuration is a named launch profile (see
+// launchprofile.go) for one of the project's scripts, its roots relative to the
+// script's directory. Paths are written with slashes. As with a sidecar, the file
+// comes with the directory, so roots outside it are ignored.
+// launcher_project in the config remembers the project open in the launcher.
+
+// ProjectFileName is the name of a project's settings file, in its root
+const ProjectFileName = ".paw-project.psl"
+
+// RunConfig is a named way of running one of a project's scripts
+type RunConfig struct {
+	Name    string
+	Script  string // Relative to the project root
+	Profile LaunchProfile
+}
+
+// Project is a directory opened as a project
+type Project struct {
+	Root       string // Absolute
+	Name       string
+	Pinned     []string // Relative to Root
+	ReadRoots  []string // Relative to Root
+	WriteRoots []string
+	ExecRoots  []string
+	RunConfigs []RunConfig
+}
+
+// ProjectFile returns the path of the settings file of the project at root
+func ProjectFile(root string) string {
+	return filepath.Join(root, ProjectFileName)
+}
+
+// IsProject reports whether dir has a project settings file
+func IsProject(dir string) bool {
+	info, err := os.Stat(ProjectFile(dir))
+	return err == nil && !info.IsDir()
+}
+
+// OpenProject opens dir as a project, with the settings of its project file if
+// it has one. Settings that can't be used are reported with the project, which
+// keeps those that can; a directory that isn't one is an error.
+func OpenProject(dir string) (*Project, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(root); err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("%s: not a directory", root)
+	}
+	project := &Project{Root: root, Name: filepath.Base(root)}
+
+	data, err := os.ReadFile(ProjectFile(root))
+	if os.IsNotExist(err) {
+		return project, nil
+	}
+	if err != nil {
+		return project, err
+	}
+	saved, err := pawscript.ParsePSL(string(data))
+	if err != nil {
+		return project, fmt.Errorf("%s: %w", ProjectFile(root), err)
+	}
+	if name := saved.GetString("name", ""); name != "" {
+		project.Name = name
+	}
+	project.Pinned = fromSlashes(pslStrings(saved["pinned"]))
+
+	var ignored []string
+	for _, roots := range []struct {
+		key  string
+		into *[]string
+	}{
+		{"read_roots", &project.ReadRoots},
+		{"write_roots", &project.WriteRoots},
+		{"exec_roots", &project.ExecRoots},
+	} {
+		inside, outside := rootsInside(fromSlashes(pslStrings(saved[roots.key])), root)
+		*roots.into = inside
+		ignored = append(ignored, outside...)
+	}
+
+	configs, _ := saved["run_configs"].(pawscript.PSLList)
+	for _, item := range configs {
+		settings, ok := item.(pawscript.PSLMap)
+		if !ok {
+			continue
+		}
+		config := RunConfig{
+			Name:    settings.GetString("name", ""),
+			Script:  filepath.FromSlash(settings.GetString("script", "")),
+			Profile: launchProfileFromPSL(settings),
+		}
+		if config.Name == "" || config.Script == "" {
+			continue
+		}
+		scriptDir := filepath.Dir(project.Path(config.Script))
+		for _, roots := range []*[]string{&config.Profile.ReadRoots, &config.Profile.WriteRoots, &config.Profile.ExecRoots} {
+			var outside []string
+			*roots, outside = rootsInsideFrom(fromSlashes(*roots), scriptDir, root)
+			ignored = append(ignored, outside...)
+		}
+		project.RunConfigs = append(project.RunConfigs, config)
+	}
+	if len(ignored) > 0 {
+		return project, fmt.Errorf("%s: roots outside the project ignored: %s",
+			ProjectFile(root), strings.Join(ignored, ", "))
+	}
+	return project, nil
+}
+
+// Save writes the project's settings to its project file
+func (p *Project) Save() error {
+	saved := pawscript.PSLMap{}
+	saved.Set("name", p.Name)
+	for _, list := range []struct {
+		key   string
+		paths []string
+	}{
+		{"pinned", p.Pinned},
+		{"read_roots", p.ReadRoots},
+		{"write_roots", p.WriteRoots},
+		{"exec_roots", p.ExecRoots},
+	} {
+		if len(list.paths) > 0 {
+			saved.Set(list.key, pslList(toSlashes(list.paths)))
+		}
+	}
+	if len(p.RunConfigs) > 0 {
+		configs := pawscript.PSLList{}
+		for _, config := range p.RunConfigs {
+			settings := config.Profile.toPSL()
+			for _, key := range []string{"read_roots", "write_roots", "exec_roots"} {
+				if roots, ok := settings[key]; ok {
+					settings.Set(key, pslList(toSlashes(pslStrings(roots))))
+				}
+			}
+			settings.Set("name", config.Name)
+			settings.Set("script", filepath.ToSlash(config.Script))
+			configs = append(configs, settings)
+		}
+		saved.Set("run_configs", configs)
+	}
+	return os.WriteFile(ProjectFile(p.Root), []byte(pawscript.SerializePSLPretty(saved)+"\n"), 0644)
+}
+
+// Path returns the absolute path of a path relative to the project root
+func (p *Project) Path(rel string) string {
+	if filepath.IsAbs(rel) {
+		return filepath.Clean(rel)
+	}
+	return filepath.Join(p.Root, rel)
+}
+
+// Rel returns a path relative to the project root, ok being false if it isn't
+// in the project
+func (p *Project) Rel(path string) (rel string, ok bool) {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if !isInside(path, p.Root) {
+		return "", false
+	}
+	rel, err := filepath.Rel(p.Root, path)
+	return rel, err == nil
+}
+
+// Contains reports whether a path is in the project
+func (p *Project) Contains(path string) bool {
+	_, ok := p.Rel(path)
+	return ok
+}
+
+// IsPinned reports whether a script is pinned to the file list
+func (p *Project) IsPinned(path string) bool {
+	rel, ok := p.Rel(path)
+	if !ok {
+		return false
+	}
+	for _, pinned := range p.Pinned {
+		if pinned == rel {
+			return true
+		}
+	}
+	return false
+}
+
+// SetPinned pins a script in the project to the file list or unpins it
+func (p *Project) SetPinned(path string, pinned bool) {
+	rel, ok := p.Rel(path)
+	if !ok || p.IsPinned(path) == pinned {
+		return
+	}
+	if pinned {
+		p.Pinned = append(p.Pinned, rel)
+		return
+	}
+	kept := p.Pinned[:0]
+	for _, other := range p.Pinned {
+		if other != rel {
+			kept = append(kept, other)
+		}
+	}
+	p.Pinned = kept
+}
+
+// PinnedScripts returns the absolute paths of the pinned scripts that exist
+func (p *Project) PinnedScripts() []string {
+	var scripts []string
+	for _, rel := range p.Pinned {
+		path := p.Path(rel)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			scripts = append(scripts, path)
+		}
+	}
+	return scripts
+}
+
+// ApplyRoots adds the project's roots to the file access of a script in the
+// project; those of scripts elsewhere are left as they are
+func (p *Project) ApplyRoots(fileAccess *pawscript.FileAccessConfig, script string) {
+	if !p.Contains(script) {
+		return
+	}
+	roots := LaunchProfile{ReadRoots: p.ReadRoots, WriteRoots: p.WriteRoots, ExecRoots: p.ExecRoots}
+	roots.ApplyRoots(fileAccess, p.Root)
+}
+
+// RunConfig returns the run configuration with the name given
+func (p *Project) RunConfig(name string) (RunConfig, bool) {
+	for _, config := range p.RunConfigs {
+		if config.Name == name {
+			return config, true
+		}
+	}
+	return RunConfig{}, false
+}
+
+// SetRunConfig adds a run configuration, replacing the one of the same name
+func (p *Project) SetRunConfig(config RunConfig) {
+	for i, other := range p.RunConfigs {
+		if other.Name == config.Name {
+			p.RunConfigs[i] = config
+			return
+		}
+	}
+	p.RunConfigs = append(p.RunConfigs, config)
+}
+
+// DeleteRunConfig removes the run configuration with the name given
+func (p *Project) DeleteRunConfig(name string) {
+	kept := p.RunConfigs[:0]
+	for _, config := range p.RunConfigs {
+		if config.Name != name {
+			kept = append(kept, config)
+		}
+	}
+	p.RunConfigs = kept
+}
+
+// ShellCdCommand returns the line that has the system shell change to dir,
+// for the project terminal to follow the project root
+func ShellCdCommand(dir string) string {
+	if runtime.GOOS == "windows" {
+		return `cd /d "` + dir + "\"\r"
+	}
+	return "cd '" + strings.ReplaceAll(dir, "'", `'\''`) + "'\r"
+}
+
+// GetProject returns the root of the project open in the launcher, "" for none
+func (h *ConfigHelper) GetProject() string {
+	if h.Config == nil {
+		return ""
+	}
+	return h.Config.GetString("launcher_project", "")
+}
+
+// SetProject remembers the project open in the launcher, "" for none
+func (h *ConfigHelper) SetProject(root string) {
+	if h.Config == nil {
+		return
+	}
+	if root == "" {
+		delete(h.Config, "launcher_project")
+		return
+	}
+	h.Config.Set("launcher_project", root)
+}
+
+// isInside reports whether path is dir or in it
+func isInside(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// rootsInsideFrom splits roots, relative ones being resolved against base, into
+// those inside dir and those outside
+func rootsInsideFrom(roots []string, base, dir string) (inside, outside []string) {
+	for _, root := range roots {
+		path := root
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(base, path)
+		}
+		if isInside(path, dir) {
+			inside = append(inside, root)
+		} else {
+			outside = append(outside, root)
+		}
+	}
+	return inside, outside
+}
+
+// fromSlashes converts paths written with slashes to the OS's separator
+func fromSlashes(paths []string) []string {
+	for i, path := range paths {
+		paths[i] = filepath.FromSlash(path)
+	}
+	return paths
+}
+
+// toSlashes returns paths written with slashes
+func toSlashes(paths []string) []string {
+	written := make([]string, len(paths))
+	for i, path := range paths {
+		written[i] = filepath.ToSlash(path)
+	}
+	return written
+}
@@ -0,0 +1,257 @@
+package pawgui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Run logs
+// With run_log on, the terminal output of every script run is also written to a
+// log file of its own in run_log_dir (~/.paw/logs unless set), named for when the
+// run started and its script: 20260314-093005-build.log. Logs are plain text, the
+// output with its escape sequences taken out. Each new log prunes the directory
+// to the newest run_log_keep logs, dropping those older than run_log_days days
+// (0 for no limit on either). Only files named as logs are pruned.
+
+const (
+	// DefaultRunLogKeep is how many run logs are kept when run_log_keep isn't set
+	DefaultRunLogKeep = 100
+	// DefaultRunLogDays is how many days run logs are kept when run_log_days isn't set
+	DefaultRunLogDays = 30
+)
+
+// runLogTimeFormat starts the name of a run log
+const runLogTimeFormat = "20060102-150405"
+
+// runLogName matches the names of run logs, and nothing else PruneRunLogs may delete
+var runLogName = regexp.MustCompile(`^\d{8}-\d{6}-.*\.log$`)
+
+// GetRunLog returns whether script runs are logged
+func (h *ConfigHelper) GetRunLog() bool {
+	if h.Config != nil {
+		return h.Config.GetBool("run_log", false)
+	}
+	return false
+}
+
+// GetRunLogDir returns the directory run logs are written to
+func (h *ConfigHelper) GetRunLogDir() string {
+	dir := ""
+	if h.Config != nil {
+		dir = h.Config.GetString("run_log_dir", "")
+	}
+	if dir == "" {
+		return filepath.Join(GetConfigDir(), "logs")
+	}
+	if dir == "~" || strings.HasPrefix(dir, "~/") || strings.HasPrefix(dir, "~"+string(filepath.Separator)) {
+		if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, dir[1:])
+		}
+	}
+	return dir
+}
+
+// GetRunLogKeep returns how many run logs are kept, 0 for any number
+func (h *ConfigHelper) GetRunLogKeep() int {
+	keep := DefaultRunLogKeep
+	if h.Config != nil {
+		keep = h.Config.GetInt("run_log_keep", DefaultRunLogKeep)
+	}
+	if keep < 0 {
+		keep = 0
+	}
+	return keep
+}
+
+// GetRunLogMaxAge returns how long run logs are kept, 0 for any time
+func (h *ConfigHelper) GetRunLogMaxAge() time.Duration {
+	days := DefaultRunLogDays
+	if h.Config != nil {
+		days = h.Config.GetInt("run_log_days", DefaultRunLogDays)
+	}
+	if days < 0 {
+		days = 0
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// OpenRunLog starts the log of a run of script, pruning the old logs. It returns
+// nil without an error when runs aren't logged.
+func (h *ConfigHelper) OpenRunLog(script string) (*RunLog, error) {
+	if !h.GetRunLog() {
+		return nil, nil
+	}
+	dir := h.GetRunLogDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	base := strings.TrimSuffix(filepath.Base(script), filepath.Ext(script))
+	if script == "" || base == "" || base == "." {
+		base = "script"
+	}
+	stamp := time.Now().Format(runLogTimeFormat)
+	var f *os.File
+	for i := 1; f == nil; i++ {
+		name := stamp + "-" + base + ".log"
+		if i > 1 {
+			name = fmt.Sprintf("%s-%s-%d.log", stamp, base, i)
+		}
+		var err error
+		f, err = os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil && (!os.IsExist(err) || i >= 100) {
+			return nil, err
+		}
+	}
+
+	PruneRunLogs(dir, h.GetRunLogKeep(), h.GetRunLogMaxAge())
+	return &RunLog{name: f.Name(), f: f}, nil
+}
+
+// PruneRunLogs deletes the run logs in dir beyond the newest keep of them and
+// those last written more than maxAge ago (0 for no limit on either)
+func PruneRunLogs(dir string, keep int, maxAge time.Duration) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	type runLogFile struct {
+		name    string
+		modTime time.Time
+	}
+	var logs []runLogFile
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !runLogName.MatchString(entry.Name()) {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			logs = append(logs, runLogFile{entry.Name(), info.ModTime()})
+		}
+	}
+	// Oldest first: names start with when their runs started, and the logs of runs
+	// started in the same second go by when they were last written
+	sort.Slice(logs, func(i, j int) bool {
+		stampI, stampJ := logs[i].name[:len(runLogTimeFormat)], logs[j].name[:len(runLogTimeFormat)]
+		if stampI != stampJ {
+			return stampI < stampJ
+		}
+		return logs[i].modTime.Before(logs[j].modTime)
+	})
+
+	now := time.Now()
+	for i, log := range logs {
+		old := keep > 0 && i < len(logs)-keep
+		if maxAge > 0 && now.Sub(log.modTime) > maxAge {
+			old = true
+		}
+		if old {
+			os.Remove(filepath.Join(dir, log.name))
+		}
+	}
+}
+
+// RunLog writes a run's terminal output to its log as plain text
+type RunLog struct {
+	mu    sync.Mutex
+	name  string
+	f     *os.File
+	state runLogState
+	cr    bool // A carriage return was written last
+}
+
+// runLogState is where a RunLog is in an escape sequence split between writes
+type runLogState int
+
+const (
+	runLogText     runLogState = iota
+	runLogEscape               // After ESC
+	runLogCSI                  // In a control sequence, ESC [
+	runLogString               // In an OSC, DCS, APC, PM or SOS string
+	runLogStringST             // After ESC in a string, which ends with ESC \
+	runLogCharset              // After ESC ( and the like, before the charset
+)
+
+// Name returns the path of the log
+func (l *RunLog) Name() string {
+	return l.name
+}
+
+// Write adds terminal output to the log, leaving out escape sequences and other
+// control characters than newlines and tabs. A carriage return ends a line, as
+// it does with the newline usually after it.
+func (l *RunLog) Write(data []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return 0, os.ErrClosed
+	}
+
+	text := make([]byte, 0, len(data))
+	for _, b := range data {
+		switch l.state {
+		case runLogText:
+			if l.cr && b != '\n' && b != '\r' {
+				text = append(text, '\n')
+			}
+			l.cr = b == '\r'
+			switch {
+			case b == 0x1b:
+				l.state = runLogEscape
+			case b == '\n' || b == '\t' || b >= 0x20 && b != 0x7f:
+				text = append(text, b)
+			}
+		case runLogEscape:
+			switch {
+			case b == '[':
+				l.state = runLogCSI
+			case b == ']' || b == 'P' || b == '_' || b == '^' || b == 'X':
+				l.state = runLogString
+			case b >= 0x20 && b <= 0x2f:
+				l.state = runLogCharset
+			default:
+				l.state = runLogText
+			}
+		case runLogCSI:
+			if b >= 0x40 && b <= 0x7e {
+				l.state = runLogText
+			}
+		case runLogString:
+			switch b {
+			case 0x07:
+				l.state = runLogText
+			case 0x1b:
+				l.state = runLogStringST
+			}
+		case runLogStringST:
+			if b == '\\' {
+				l.state = runLogText
+			} else if b != 0x1b {
+				l.state = runLogString
+			}
+		case runLogCharset:
+			l.state = runLogText
+		}
+	}
+	if _, err := l.f.Write(text); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// Close ends the log
+func (l *RunLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return nil
+	}
+	err := l.f.Close()
+	l.f = nil
+	return err
+}
@@ -0,0 +1,502 @@
+package pawgui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pawscript "github.com/phroun/pawscript/src"
+)
+
+// Scheduled tasks
+// The Scheduled Tasks manager runs scripts on a schedule while the launcher is
+// open: at an interval ("every 30m") or at times given as cron fields
+// ("0 9 * * 1-5"). Each task runs with a permission profile of its own, in a
+// console window opened in the background, and its runs are kept in a history.
+// Tasks are saved in the scheduled_tasks setting, the history in
+// task_history.psl in the config directory.
+
+// MaxTaskHistory is how many runs the history keeps
+const MaxTaskHistory = 200
+
+// schedulerTick is how often the scheduler looks for tasks that are due
+const schedulerTick = 5 * time.Second
+
+// ScheduledTask is a script the launcher runs on a schedule
+type ScheduledTask struct {
+	Name     string // Unique among the tasks
+	Script   string // The path of the .paw file
+	Schedule string // "every <duration>" or five cron fields; see ParseSchedule
+	Profile  string // The permission profile it runs with
+	Enabled  bool
+}
+
+// Permissions returns the permission profile the task runs with. An unknown name
+// gives "untrusted", so a typo never loosens the policy.
+func (t ScheduledTask) Permissions() *pawscript.PermissionProfile {
+	if profile, exists := pawscript.LookupPermissionProfile(t.Profile); exists {
+		return profile
+	}
+	profile, _ := pawscript.LookupPermissionProfile("untrusted")
+	return profile
+}
+
+// Validate checks a task before it's saved, beside the other tasks
+func (t ScheduledTask) Validate(others []ScheduledTask) error {
+	if strings.TrimSpace(t.Name) == "" {
+		return fmt.Errorf("the task needs a name")
+	}
+	for _, other := range others {
+		if other.Name == t.Name {
+			return fmt.Errorf("there is already a task named %q", t.Name)
+		}
+	}
+	if info, err := os.Stat(t.Script); err != nil || info.IsDir() {
+		return fmt.Errorf("script not found: %s", t.Script)
+	}
+	_, err := ParseSchedule(t.Schedule)
+	return err
+}
+
+// Schedule is when a task runs: at an interval, or at the minutes matching cron
+// fields
+type Schedule struct {
+	interval time.Duration
+
+	// Cron fields, as a bit for each value: minute, hour, day of month, month
+	// and day of week (0 is Sunday)
+	minute, hour, day, month, weekday uint64
+	anyDay, anyWeekday                bool
+}
+
+// cronRanges are the values each cron field takes
+var cronRanges = [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+
+// ParseSchedule parses a schedule, either an interval:
+//
+//	every 30m          every 30 minutes (a Go duration, at least a minute)
+//	every 2h30m
+//
+// or five cron fields, minute, hour, day of month, month and day of week, each
+// "*", a value, a range "a-b" or a list "a,b", with a step "/n" on "*" or ranges:
+//
+//	0 9 * * 1-5        at 9:00 on weekdays
+//	*/15 * * * *       every quarter hour
+func ParseSchedule(text string) (*Schedule, error) {
+	text = strings.TrimSpace(text)
+	if rest, ok := strings.CutPrefix(text, "every "); ok {
+		interval, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval %q", strings.TrimSpace(rest))
+		}
+		if interval < time.Minute {
+			return nil, fmt.Errorf("the interval must be at least a minute")
+		}
+		return &Schedule{interval: interval}, nil
+	}
+
+	fields := strings.Fields(text)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected \"every <interval>\" or five cron fields, got %q", text)
+	}
+	var bits [5]uint64
+	for i, field := range fields {
+		var err error
+		if bits[i], err = parseCronField(field, cronRanges[i][0], cronRanges[i][1]); err != nil {
+			return nil, err
+		}
+	}
+	s := &Schedule{minute: bits[0], hour: bits[1], day: bits[2], month: bits[3], weekday: bits[4],
+		anyDay: fields[2] == "*", anyWeekday: fields[4] == "*"}
+	if s.weekday&(1<<7) != 0 {
+		s.weekday |= 1 // 7 is Sunday too
+	}
+	return s, nil
+}
+
+// parseCronField parses one cron field into a bit for each value it matches
+func parseCronField(field string, low, high int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		spec, step := part, 1
+		if before, after, ok := strings.Cut(part, "/"); ok {
+			n, err := strconv.Atoi(after)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step in %q", field)
+			}
+			spec, step = before, n
+		}
+
+		first, last := low, high
+		switch {
+		case spec == "*":
+		case strings.Contains(spec, "-"):
+			a, b, _ := strings.Cut(spec, "-")
+			var errA, errB error
+			first, errA = strconv.Atoi(a)
+			last, errB = strconv.Atoi(b)
+			if errA != nil || errB != nil || first > last {
+				return 0, fmt.Errorf("invalid range in %q", field)
+			}
+		default:
+			n, err := strconv.Atoi(spec)
+			if err != nil || step != 1 {
+				return 0, fmt.Errorf("invalid value in %q", field)
+			}
+			first, last = n, n
+		}
+		if first < low || last > high {
+			return 0, fmt.Errorf("%q is outside %d-%d", field, low, high)
+		}
+		for v := first; v <= last; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// Next returns when the schedule next runs after t, or the zero time if a cron
+// schedule never does (such as February 30)
+func (s *Schedule) Next(t time.Time) time.Time {
+	if s.interval > 0 {
+		return t.Add(s.interval)
+	}
+
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	for limit := t.AddDate(5, 0, 0); t.Before(limit); {
+		y, mo, d := t.Date()
+		switch {
+		case s.month&(1<<uint(mo)) == 0:
+			t = time.Date(y, mo+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.matchesDay(t):
+			t = time.Date(y, mo, d+1, 0, 0, 0, 0, t.Location())
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(y, mo, d, t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// matchesDay reports whether t's day matches; as in cron, when both the day of
+// month and day of week are restricted, either matching is enough
+func (s *Schedule) matchesDay(t time.Time) bool {
+	day := s.day&(1<<uint(t.Day())) != 0
+	weekday := s.weekday&(1<<uint(t.Weekday())) != 0
+	if !s.anyDay && !s.anyWeekday {
+		return day || weekday
+	}
+	return day && weekday
+}
+
+// TaskRun is a run of a scheduled task, in the history
+type TaskRun struct {
+	Task     string
+	Script   string
+	Started  time.Time
+	Finished time.Time // Zero while it runs
+	OK       bool
+}
+
+// Describe summarizes a run for the history, e.g.
+// "2026-10-17 09:00  backup  ok in 12s"
+func (r TaskRun) Describe() string {
+	result := Tr("failed")
+	if r.OK {
+		result = Tr("ok")
+	}
+	took := r.Finished.Sub(r.Started).Round(time.Second)
+	return fmt.Sprintf("%s  %s  %s in %s", r.Started.Format("2006-01-02 15:04"), r.Task, result, took)
+}
+
+// Scheduler runs the enabled scheduled tasks when they are due. A task still
+// running when it's due again is skipped.
+type Scheduler struct {
+	mu      sync.Mutex
+	tasks   []ScheduledTask
+	next    map[string]time.Time // When each enabled task runs next
+	running map[string]bool
+	history []TaskRun // Oldest first
+	stop    chan struct{}
+
+	run func(task ScheduledTask, done func(ok bool))
+
+	// OnChange is called after a task starts or finishes, on the scheduler's or
+	// the script's goroutine
+	OnChange func()
+}
+
+// NewScheduler creates a scheduler, with the history saved before. run starts a
+// task, on the scheduler's goroutine or RunNow's caller, and calls done when it
+// finishes.
+func NewScheduler(run func(task ScheduledTask, done func(ok bool))) *Scheduler {
+	return &Scheduler{
+		next:    make(map[string]time.Time),
+		running: make(map[string]bool),
+		history: loadTaskHistory(),
+		run:     run,
+	}
+}
+
+// SetTasks replaces the tasks, scheduling each enabled one that is new or changed
+// from now
+func (s *Scheduler) SetTasks(tasks []ScheduledTask) {
+	now := time.Now()
+	s.mu.Lock()
+	old := make(map[string]ScheduledTask)
+	for _, task := range s.tasks {
+		old[task.Name] = task
+	}
+	previous := s.next
+	s.tasks = append([]ScheduledTask(nil), tasks...)
+	s.next = make(map[string]time.Time)
+	for _, task := range tasks {
+		if !task.Enabled {
+			continue
+		}
+		if next, ok := previous[task.Name]; ok && old[task.Name] == task {
+			s.next[task.Name] = next // Unchanged, so still due when it was
+			continue
+		}
+		if schedule, err := ParseSchedule(task.Schedule); err == nil {
+			if next := schedule.Next(now); !next.IsZero() {
+				s.next[task.Name] = next
+			}
+		}
+	}
+	s.mu.Unlock()
+	s.changed()
+}
+
+// Tasks returns the tasks
+func (s *Scheduler) Tasks() []ScheduledTask {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]ScheduledTask(nil), s.tasks...)
+}
+
+// NextRun returns when a task runs next, or the zero time if it isn't scheduled
+func (s *Scheduler) NextRun(name string) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.next[name]
+}
+
+// IsRunning reports whether a task is running
+func (s *Scheduler) IsRunning(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running[name]
+}
+
+// Status describes a task for the Scheduled Tasks list, e.g. "next at Mon 09:00"
+func (s *Scheduler) Status(task ScheduledTask) string {
+	switch {
+	case s.IsRunning(task.Name):
+		return "running"
+	case !task.Enabled:
+		return "disabled"
+	}
+	if _, err := ParseSchedule(task.Schedule); err != nil {
+		return err.Error()
+	}
+	next := s.NextRun(task.Name)
+	if next.IsZero() {
+		return "never due"
+	}
+	return "next at " + next.Format("Mon 15:04")
+}
+
+// History returns the runs kept, newest first
+func (s *Scheduler) History() []TaskRun {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	runs := make([]TaskRun, len(s.history))
+	for i, run := range s.history {
+		runs[len(runs)-1-i] = run
+	}
+	return runs
+}
+
+// Start runs tasks as they fall due, until Stop
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	if s.stop != nil {
+		s.mu.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	s.stop = stop
+	s.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(schedulerTick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				s.runDue(now)
+			}
+		}
+	}()
+}
+
+// Stop stops running tasks as they fall due; runs already started go on
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// RunNow runs a task straight away, whether or not it's enabled. Returns false
+// if there is no such task or it is already running.
+func (s *Scheduler) RunNow(name string) bool {
+	s.mu.Lock()
+	for _, task := range s.tasks {
+		if task.Name == name {
+			s.mu.Unlock()
+			return s.start(task)
+		}
+	}
+	s.mu.Unlock()
+	return false
+}
+
+// runDue starts the tasks due by now and schedules their next runs
+func (s *Scheduler) runDue(now time.Time) {
+	var due []ScheduledTask
+	s.mu.Lock()
+	for _, task := range s.tasks {
+		next, scheduled := s.next[task.Name]
+		if !scheduled || now.Before(next) {
+			continue
+		}
+		due = append(due, task)
+		schedule, err := ParseSchedule(task.Schedule)
+		if err != nil {
+			delete(s.next, task.Name)
+			continue
+		}
+		// Runs missed while the computer slept are not made up
+		for !next.IsZero() && !now.Before(next) {
+			next = schedule.Next(next)
+		}
+		s.next[task.Name] = next
+	}
+	s.mu.Unlock()
+
+	for _, task := range due {
+		s.start(task)
+	}
+}
+
+// start runs a task unless it's already running
+func (s *Scheduler) start(task ScheduledTask) bool {
+	s.mu.Lock()
+	if s.running[task.Name] {
+		s.mu.Unlock()
+		return false
+	}
+	s.running[task.Name] = true
+	started := time.Now()
+	s.mu.Unlock()
+	s.changed()
+
+	var once sync.Once
+	s.run(task, func(ok bool) {
+		once.Do(func() {
+			s.finished(TaskRun{Task: task.Name, Script: task.Script, Started: started, Finished: time.Now(), OK: ok})
+		})
+	})
+	return true
+}
+
+// finished records a run in the history
+func (s *Scheduler) finished(run TaskRun) {
+	s.mu.Lock()
+	delete(s.running, run.Task)
+	s.history = append(s.history, run)
+	if len(s.history) > MaxTaskHistory {
+		s.history = s.history[len(s.history)-MaxTaskHistory:]
+	}
+	history := append([]TaskRun(nil), s.history...)
+	s.mu.Unlock()
+
+	if err := saveTaskHistory(history); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to save task history: %v\n", err)
+	}
+	s.changed()
+}
+
+// changed tells the launcher the tasks' state changed
+func (s *Scheduler) changed() {
+	if s.OnChange != nil {
+		s.OnChange()
+	}
+}
+
+// getTaskHistoryPath returns the path of the saved run history
+func getTaskHistoryPath() string {
+	return filepath.Join(GetConfigDir(), "task_history.psl")
+}
+
+// loadTaskHistory reads the saved run history, oldest first
+func loadTaskHistory() []TaskRun {
+	data, err := os.ReadFile(getTaskHistoryPath())
+	if err != nil {
+		return nil
+	}
+	saved, err := pawscript.ParsePSL(string(data))
+	if err != nil {
+		return nil
+	}
+	list, _ := saved["runs"].(pawscript.PSLList)
+	var runs []TaskRun
+	for _, item := range list {
+		run, ok := item.(pawscript.PSLMap)
+		if !ok {
+			continue
+		}
+		started, _ := time.Parse(time.RFC3339, run.GetString("started", ""))
+		finished, _ := time.Parse(time.RFC3339, run.GetString("finished", ""))
+		runs = append(runs, TaskRun{
+			Task:     run.GetString("task", ""),
+			Script:   run.GetString("script", ""),
+			Started:  started,
+			Finished: finished,
+			OK:       run.GetBool("ok", false),
+		})
+	}
+	return runs
+}
+
+// saveTaskHistory saves the run history
+func saveTaskHistory(runs []TaskRun) error {
+	if err := os.MkdirAll(GetConfigDir(), 0755); err != nil {
+		return err
+	}
+	list := pawscript.PSLList{}
+	for _, run := range runs {
+		list = append(list, pawscript.PSLMap{
+			"task":     run.Task,
+			"script":   run.Script,
+			"started":  run.Started.Format(time.RFC3339),
+			"finished": run.Finished.Format(time.RFC3339),
+			"ok":       run.OK,
+		})
+	}
+	data := pawscript.SerializePSLPretty(pawscript.PSLMap{"runs": list})
+	return os.WriteFile(getTaskHistoryPath(), []byte(data+"\n"), 0644)
+}
@@ -0,0 +1,125 @@
+package pawgui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Script details
+// The launchers' file lists show a line under each script's name with its
+// description, the text of the comment it starts with, its size and when it was
+// last modified. The filter box above the list keeps the entries whose names or
+// descriptions have every word typed in it.
+
+// scriptInfoLines is how many lines ScriptDescription reads looking for a comment
+const scriptInfoLines = 50
+
+// ScriptInfo is what a file list shows about a script
+type ScriptInfo struct {
+	Description string // Its first comment's text, if it starts with one
+	Size        int64
+	ModTime     time.Time
+}
+
+// ReadScriptInfo reads the details of the script at path
+func ReadScriptInfo(path string) (ScriptInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return ScriptInfo{}, err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return ScriptInfo{}, err
+	}
+	return ScriptInfo{
+		Description: ScriptDescription(f),
+		Size:        stat.Size(),
+		ModTime:     stat.ModTime(),
+	}, nil
+}
+
+// ScriptDescription returns the first line of text in the comments a script
+// starts with, after any #! line: "Fibonacci Benchmark" for a script starting
+// "# Fibonacci Benchmark", or "" if the script doesn't start with a comment
+func ScriptDescription(r io.Reader) string {
+	scanner := bufio.NewScanner(r)
+	closer := "" // The end of the block comment being read, if any
+	for i := 0; i < scriptInfoLines && scanner.Scan(); i++ {
+		line := strings.TrimSpace(scanner.Text())
+		if i == 0 && strings.HasPrefix(line, "#!") {
+			continue
+		}
+
+		if closer == "" {
+			switch {
+			case line == "":
+				continue
+			case strings.HasPrefix(line, "#("):
+				closer = ")#"
+				line = line[2:]
+			case strings.HasPrefix(line, "#{"):
+				closer = "}#"
+				line = line[2:]
+			case strings.HasPrefix(line, "#"):
+				line = strings.TrimLeft(line, "#")
+			default:
+				return ""
+			}
+		}
+		if closer != "" {
+			if end := strings.Index(line, closer); end >= 0 {
+				line = line[:end]
+				closer = ""
+			}
+		}
+
+		if text := strings.TrimSpace(line); text != "" {
+			return text
+		}
+	}
+	return ""
+}
+
+// Summary returns the line shown under the script's name
+func (info ScriptInfo) Summary() string {
+	parts := []string{FormatFileSize(info.Size), info.ModTime.Format("2006-01-02 15:04")}
+	if info.Description != "" {
+		parts = append([]string{info.Description}, parts...)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// FormatFileSize writes a size in bytes as people read it: 512 B, 1.5 KB, 12 MB
+func FormatFileSize(size int64) string {
+	if size < 1024 {
+		return fmt.Sprintf("%d B", size)
+	}
+	value := float64(size)
+	for _, unit := range []string{"KB", "MB", "GB", "TB"} {
+		value /= 1024
+		if value < 1024 || unit == "TB" {
+			if value < 10 {
+				return fmt.Sprintf("%.1f %s", value, unit)
+			}
+			return fmt.Sprintf("%.0f %s", value, unit)
+		}
+	}
+	return ""
+}
+
+// FileListMatches reports whether a file list entry passes the filter: every
+// word in it is in the entry's name or description, ignoring case
+func FileListMatches(filter, name, description string) bool {
+	text := strings.ToLower(name + "\n" + description)
+	for _, word := range strings.Fields(strings.ToLower(filter)) {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,234 @@
+package pawgui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	pawscript "github.com/phroun/pawscript/src"
+)
+
+// Session restore
+// When the launcher quits, it saves its open tab windows: where they were, their
+// tabs in order, the shell's directory or the running script of each, and, with
+// session_scrollback on, a snapshot of each terminal. On the next launch it offers
+// to open them again (session_restore: ask, always or never). The session is
+// saved to session.psl in the config directory, with the snapshots beside it, and
+// removed once it has been offered.
+
+// Kinds of tab in a session
+const (
+	SessionConsole = "console" // A console with a REPL
+	SessionShell   = "shell"   // The user's shell, in Dir
+	SessionScript  = "script"  // A script still running, run again on restore
+)
+
+// SessionTab is a tab of a saved session
+type SessionTab struct {
+	Kind       string
+	Dir        string // The directory a shell started in
+	Script     string // The path of the script a script tab was running
+	Scrollback []byte // Snapshot of the terminal (purfecterm), if saved
+}
+
+// SessionWindow is a tab window of a saved session
+type SessionWindow struct {
+	X, Y          int
+	Width, Height int
+	Current       int // Index of the tab shown
+	Tabs          []SessionTab
+}
+
+// Session is what was open when the launcher last quit
+type Session struct {
+	Windows        []SessionWindow
+	LauncherScript string // The script running in the launcher, if any
+}
+
+// Empty reports whether there is nothing to restore
+func (s *Session) Empty() bool {
+	if s == nil {
+		return true
+	}
+	if s.LauncherScript != "" {
+		return false
+	}
+	for _, window := range s.Windows {
+		if len(window.Tabs) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Describe summarizes the session for the question of whether to restore it,
+// e.g. "2 windows with 3 tabs, running build.paw again"
+func (s *Session) Describe() string {
+	windows, tabs := 0, 0
+	var scripts []string
+	if s.LauncherScript != "" {
+		scripts = append(scripts, filepath.Base(s.LauncherScript))
+	}
+	for _, window := range s.Windows {
+		if len(window.Tabs) == 0 {
+			continue
+		}
+		windows++
+		tabs += len(window.Tabs)
+		for _, tab := range window.Tabs {
+			if tab.Kind == SessionScript {
+				scripts = append(scripts, filepath.Base(tab.Script))
+			}
+		}
+	}
+
+	text := "the launcher"
+	if windows > 0 {
+		text = fmt.Sprintf("%s with %s", plural(windows, "window"), plural(tabs, "tab"))
+	}
+	if len(scripts) > 0 {
+		text += ", running " + joinNames(scripts) + " again"
+	}
+	return text
+}
+
+// plural returns a count of things, e.g. "1 tab" or "3 tabs"
+func plural(n int, thing string) string {
+	if n == 1 {
+		return "1 " + thing
+	}
+	return fmt.Sprintf("%d %ss", n, thing)
+}
+
+// joinNames joins names into a list, e.g. "a, b and c"
+func joinNames(names []string) string {
+	if len(names) == 1 {
+		return names[0]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+}
+
+// GetSessionPath returns the path of the saved session
+func GetSessionPath() string {
+	return filepath.Join(GetConfigDir(), "session.psl")
+}
+
+// sessionScrollbackDir returns the directory of the saved terminal snapshots
+func sessionScrollbackDir() string {
+	return filepath.Join(GetConfigDir(), "session")
+}
+
+// SaveSession saves the session for the next launch, replacing any saved before.
+// An empty session removes it.
+func SaveSession(session *Session) error {
+	if err := ClearSession(); err != nil {
+		return err
+	}
+	if session.Empty() {
+		return nil
+	}
+	if err := os.MkdirAll(GetConfigDir(), 0755); err != nil {
+		return err
+	}
+
+	windows := pawscript.PSLList{}
+	for w, window := range session.Windows {
+		if len(window.Tabs) == 0 {
+			continue
+		}
+		tabs := pawscript.PSLList{}
+		for t, tab := range window.Tabs {
+			item := pawscript.PSLMap{"kind": tab.Kind}
+			if tab.Dir != "" {
+				item["dir"] = tab.Dir
+			}
+			if tab.Script != "" {
+				item["script"] = tab.Script
+			}
+			if len(tab.Scrollback) > 0 {
+				name := fmt.Sprintf("tab-%d-%d.snap", w, t)
+				if err := os.MkdirAll(sessionScrollbackDir(), 0700); err != nil {
+					return err
+				}
+				if err := os.WriteFile(filepath.Join(sessionScrollbackDir(), name), tab.Scrollback, 0600); err != nil {
+					return err
+				}
+				item["scrollback"] = name
+			}
+			tabs = append(tabs, item)
+		}
+		windows = append(windows, pawscript.PSLMap{
+			"x":       window.X,
+			"y":       window.Y,
+			"width":   window.Width,
+			"height":  window.Height,
+			"current": window.Current,
+			"tabs":    tabs,
+		})
+	}
+
+	saved := pawscript.PSLMap{"windows": windows}
+	if session.LauncherScript != "" {
+		saved["launcher_script"] = session.LauncherScript
+	}
+	return os.WriteFile(GetSessionPath(), []byte(pawscript.SerializePSLPretty(saved)+"\n"), 0600)
+}
+
+// LoadSession reads the saved session, or returns nil if there is none
+func LoadSession() (*Session, error) {
+	data, err := os.ReadFile(GetSessionPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	saved, err := pawscript.ParsePSL(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	session := &Session{LauncherScript: saved.GetString("launcher_script", "")}
+	windows, _ := saved["windows"].(pawscript.PSLList)
+	for _, item := range windows {
+		savedWindow, ok := item.(pawscript.PSLMap)
+		if !ok {
+			continue
+		}
+		window := SessionWindow{
+			X:       savedWindow.GetInt("x", -1),
+			Y:       savedWindow.GetInt("y", -1),
+			Width:   savedWindow.GetInt("width", 0),
+			Height:  savedWindow.GetInt("height", 0),
+			Current: savedWindow.GetInt("current", 0),
+		}
+		tabs, _ := savedWindow["tabs"].(pawscript.PSLList)
+		for _, item := range tabs {
+			savedTab, ok := item.(pawscript.PSLMap)
+			if !ok {
+				continue
+			}
+			tab := SessionTab{
+				Kind:   savedTab.GetString("kind", SessionConsole),
+				Dir:    savedTab.GetString("dir", ""),
+				Script: savedTab.GetString("script", ""),
+			}
+			if name := savedTab.GetString("scrollback", ""); name != "" {
+				// A missing snapshot just leaves the terminal empty
+				tab.Scrollback, _ = os.ReadFile(filepath.Join(sessionScrollbackDir(), filepath.Base(name)))
+			}
+			window.Tabs = append(window.Tabs, tab)
+		}
+		session.Windows = append(session.Windows, window)
+	}
+	return session, nil
+}
+
+// ClearSession removes the saved session and its snapshots
+func ClearSession() error {
+	if err := os.Remove(GetSessionPath()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.RemoveAll(sessionScrollbackDir())
+}
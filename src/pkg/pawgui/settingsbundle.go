@@ -0,0 +1,356 @@
+package pawgui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/phroun/pawscript/src"
+)
+
+// Settings bundles and profiles
+// Export Settings writes the settings worth carrying to another machine into a
+// single PSL file, a bundle, with a map for each group of them: appearance,
+// palette, shortcuts and sandbox. Import Settings reads one back, replacing the
+// settings of each group the bundle has and leaving the rest alone; settings that
+// don't fit the schema are dropped, as they are when the config loads. Window
+// positions, recent files and the like stay with the machine. Before a bundle's
+// sandbox settings apply, the launchers show what they would let scripts do that
+// they couldn't before (see SandboxWidening) and ask the user to confirm.
+//
+// Settings profiles are bundles kept in ~/.paw/profiles as <name>.psl. Switching
+// to one imports it, and settings_profile remembers which it was; changing
+// settings afterwards doesn't change the profile until it's saved again.
+
+// SettingsBundleVersion marks a file as a settings bundle, and which format it has
+const SettingsBundleVersion = 1
+
+// settingsBundleKey holds SettingsBundleVersion in a bundle
+const settingsBundleKey = "pawgui_settings"
+
+// SettingsGroup is a group of settings a bundle carries as a map of its own
+type SettingsGroup struct {
+	Name string   // Its key in a bundle
+	Keys []string // The settings in it
+}
+
+// SettingsGroups are the groups of settings bundles carry
+var SettingsGroups = []SettingsGroup{
+	{Name: "appearance", Keys: []string{
+		"theme", "term_theme", "ui_scale", "language",
+		"font_family", "font_family_unicode", "font_family_cjk", "font_size", "font_ligatures",
+		"default_blink", "cursor_shape", "cursor_blink", "scrollback_lines", "visual_bell", "gpu_rendering",
+		"background_opacity", "background_blur", "background_image", "background_dim",
+	}},
+	{Name: "palette", Keys: []string{
+		"term_colors", "term_colors_dark", "term_colors_light",
+		"psl_colors", "psl_colors_dark", "psl_colors_light",
+	}},
+	{Name: "shortcuts", Keys: shortcutSettingKeys()},
+	{Name: "sandbox", Keys: []string{
+		"launcher_profile", "granted_read_roots", "granted_write_roots", "launcher_quotas",
+		"clipboard_access",
+	}},
+}
+
+// shortcutSettingKeys returns the settings of the shortcuts group: each action's
+// <name>_shortcut, and the key macros
+func shortcutSettingKeys() []string {
+	var keys []string
+	for _, action := range ShortcutActions {
+		keys = append(keys, action.Name+"_shortcut")
+	}
+	return append(keys, "key_macros")
+}
+
+// ExportSettings returns a bundle of the settings in config
+func ExportSettings(config pawscript.PSLConfig) pawscript.PSLConfig {
+	bundle := pawscript.PSLConfig{settingsBundleKey: SettingsBundleVersion}
+	for _, group := range SettingsGroups {
+		settings := pawscript.PSLMap{}
+		for _, key := range group.Keys {
+			if value, ok := config[key]; ok {
+				settings[key] = pawscript.ClonePSLValue(value)
+			}
+		}
+		bundle[group.Name] = settings
+	}
+	return bundle
+}
+
+// ImportSettings replaces the settings in config of each group the bundle has
+// with the bundle's, returning the settings dropped for not fitting the schema
+func ImportSettings(config, bundle pawscript.PSLConfig) ([]pawscript.PSLSchemaError, error) {
+	if err := checkSettingsBundle(bundle); err != nil {
+		return nil, err
+	}
+
+	var problems []pawscript.PSLSchemaError
+	for _, group := range SettingsGroups {
+		value, ok := bundle[group.Name]
+		if !ok {
+			continue
+		}
+		settings, ok := value.(pawscript.PSLMap)
+		if value == nil || value == "" {
+			// An empty map is written as ""
+			settings, ok = pawscript.PSLMap{}, true
+		}
+		if !ok {
+			problems = append(problems, pawscript.PSLSchemaError{Path: group.Name, Message: "expected a map"})
+			continue
+		}
+
+		imported := pawscript.PSLConfig{}
+		for _, key := range group.Keys {
+			if value, ok := settings[key]; ok {
+				imported[key] = pawscript.ClonePSLValue(value)
+			}
+		}
+		for _, problem := range ValidateConfig(imported) {
+			problem.Path = group.Name + "." + problem.Path
+			problems = append(problems, problem)
+		}
+
+		for _, key := range group.Keys {
+			delete(config, key)
+		}
+		for key, value := range imported {
+			config[key] = value
+		}
+	}
+	return problems, nil
+}
+
+// SandboxWidening returns the ways importing bundle into config would let scripts
+// run from the launcher do more than they can now, one line each: a less
+// restrictive permission profile, roots granted, quotas raised or removed, and
+// more clipboard access. It returns nil if the bundle widens nothing.
+func SandboxWidening(config, bundle pawscript.PSLConfig) []string {
+	after, _ := pawscript.ClonePSLValue(config).(pawscript.PSLMap)
+	if after == nil {
+		after = pawscript.PSLMap{}
+	}
+	if _, err := ImportSettings(after, bundle); err != nil {
+		return nil
+	}
+	before, imported := NewConfigHelper(config), NewConfigHelper(after)
+
+	var changes []string
+	oldName := before.Config.GetString("launcher_profile", DefaultLauncherProfile)
+	newName := imported.Config.GetString("launcher_profile", DefaultLauncherProfile)
+	if profileWidens(launcherProfile(oldName), launcherProfile(newName)) {
+		changes = append(changes, fmt.Sprintf(Tr("Scripts run under the %q permission profile instead of %q"), newName, oldName))
+	}
+
+	for _, grant := range []struct {
+		write  bool
+		format string
+	}{
+		{false, Tr("Scripts may read %s")},
+		{true, Tr("Scripts may write %s")},
+	} {
+		granted := map[string]bool{}
+		for _, root := range before.GetGrantedRoots(grant.write) {
+			granted[root] = true
+		}
+		for _, root := range imported.GetGrantedRoots(grant.write) {
+			if !granted[root] {
+				changes = append(changes, fmt.Sprintf(grant.format, root))
+			}
+		}
+	}
+
+	oldQuotas, newQuotas := before.GetLauncherQuotas(), imported.GetLauncherQuotas()
+	for _, quota := range []struct {
+		name     string
+		old, new int64
+	}{
+		{Tr("open files"), int64(oldQuotas.MaxOpenFiles), int64(newQuotas.MaxOpenFiles)},
+		{Tr("bytes written"), oldQuotas.MaxBytesWritten, newQuotas.MaxBytesWritten},
+		{Tr("processes"), int64(oldQuotas.MaxProcesses), int64(newQuotas.MaxProcesses)},
+	} {
+		if quotaWidens(quota.old, quota.new) {
+			changes = append(changes, fmt.Sprintf(Tr("The %s quota rises from %s to %s"),
+				quota.name, quotaText(quota.old), quotaText(quota.new)))
+		}
+	}
+
+	if imported.GetClipboardAccess() > before.GetClipboardAccess() {
+		changes = append(changes, fmt.Sprintf(Tr("Clipboard access becomes %q instead of %q"),
+			imported.Config.GetString("clipboard_access", "write"), before.Config.GetString("clipboard_access", "write")))
+	}
+	return changes
+}
+
+// profileWidens reports whether scripts may do something under to that they
+// can't under from
+func profileWidens(from, to *pawscript.PermissionProfile) bool {
+	if from.ReadOnly && !to.ReadOnly {
+		return true
+	}
+	denied := map[string]bool{}
+	for _, command := range to.DeniedCommands {
+		denied[command] = true
+	}
+	for _, command := range from.DeniedCommands {
+		if !denied[command] {
+			return true
+		}
+	}
+	return false
+}
+
+// quotaWidens reports whether a quota changing from from to to raises it, 0
+// being no limit
+func quotaWidens(from, to int64) bool {
+	return from > 0 && (to == 0 || to > from)
+}
+
+// quotaText returns a quota as shown to the user
+func quotaText(limit int64) string {
+	if limit == 0 {
+		return Tr("no limit")
+	}
+	return fmt.Sprint(limit)
+}
+
+// checkSettingsBundle returns an error if bundle isn't a settings bundle this
+// version can read
+func checkSettingsBundle(bundle pawscript.PSLConfig) error {
+	if _, ok := bundle[settingsBundleKey]; !ok {
+		return fmt.Errorf("not a settings bundle")
+	}
+	if version := bundle.GetInt(settingsBundleKey, 0); version < 1 || version > SettingsBundleVersion {
+		return fmt.Errorf("settings bundle version %v isn't supported", bundle[settingsBundleKey])
+	}
+	return nil
+}
+
+// WriteSettingsBundle writes a bundle of the settings in config to path
+func WriteSettingsBundle(path string, config pawscript.PSLConfig) error {
+	data := pawscript.SerializePSLPretty(ExportSettings(config)) + "\n"
+	return os.WriteFile(path, []byte(data), 0644)
+}
+
+// ReadSettingsBundle reads the settings bundle at path
+func ReadSettingsBundle(path string) (pawscript.PSLConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	bundle, err := pawscript.ParsePSL(string(data))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSettingsBundle(bundle); err != nil {
+		return nil, err
+	}
+	return bundle, nil
+}
+
+// GetSettingsProfileDir returns the directory settings profiles are kept in
+func GetSettingsProfileDir() string {
+	return filepath.Join(GetConfigDir(), "profiles")
+}
+
+// settingsProfilePath returns the path of the settings profile with the name given
+func settingsProfilePath(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\:`) {
+		return "", fmt.Errorf("%q isn't a usable profile name", name)
+	}
+	return filepath.Join(GetSettingsProfileDir(), name+".psl"), nil
+}
+
+// ListSettingsProfiles returns the names of the settings profiles, sorted
+func ListSettingsProfiles() []string {
+	entries, err := os.ReadDir(GetSettingsProfileDir())
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && strings.HasSuffix(entry.Name(), ".psl") {
+			names = append(names, strings.TrimSuffix(entry.Name(), ".psl"))
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return strings.ToLower(names[i]) < strings.ToLower(names[j])
+	})
+	return names
+}
+
+// GetSettingsProfile returns the name of the settings profile last switched to
+// or saved, "" if none
+func (h *ConfigHelper) GetSettingsProfile() string {
+	if h.Config != nil {
+		return h.Config.GetString("settings_profile", "")
+	}
+	return ""
+}
+
+// SaveSettingsProfile saves the settings as the settings profile with the name
+// given, replacing any it had, and makes it the current one
+func (h *ConfigHelper) SaveSettingsProfile(name string) error {
+	path, err := settingsProfilePath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(GetSettingsProfileDir(), 0755); err != nil {
+		return err
+	}
+	if err := WriteSettingsBundle(path, h.Config); err != nil {
+		return err
+	}
+	h.Config.Set("settings_profile", strings.TrimSpace(name))
+	return nil
+}
+
+// ReadSettingsProfile reads the settings profile with the name given
+func ReadSettingsProfile(name string) (pawscript.PSLConfig, error) {
+	path, err := settingsProfilePath(name)
+	if err != nil {
+		return nil, err
+	}
+	return ReadSettingsBundle(path)
+}
+
+// UseSettingsProfile switches to the settings profile with the name given,
+// returning the settings dropped from it for not fitting the schema
+func (h *ConfigHelper) UseSettingsProfile(name string) ([]pawscript.PSLSchemaError, error) {
+	bundle, err := ReadSettingsProfile(name)
+	if err != nil {
+		return nil, err
+	}
+	return h.UseSettingsProfileBundle(name, bundle)
+}
+
+// UseSettingsProfileBundle switches to the settings profile with the name given,
+// already read with ReadSettingsProfile
+func (h *ConfigHelper) UseSettingsProfileBundle(name string, bundle pawscript.PSLConfig) ([]pawscript.PSLSchemaError, error) {
+	problems, err := ImportSettings(h.Config, bundle)
+	if err != nil {
+		return nil, err
+	}
+	h.Config.Set("settings_profile", strings.TrimSpace(name))
+	return problems, nil
+}
+
+// DeleteSettingsProfile deletes the settings profile with the name given,
+// forgetting it if it's the current one
+func (h *ConfigHelper) DeleteSettingsProfile(name string) error {
+	path, err := settingsProfilePath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	if h.GetSettingsProfile() == strings.TrimSpace(name) {
+		delete(h.Config, "settings_profile")
+	}
+	return nil
+}
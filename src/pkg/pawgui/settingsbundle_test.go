@@ -0,0 +1,137 @@
+package pawgui
+
+import (
+	"strings"
+	"testing"
+
+	pawscript "github.com/phroun/pawscript/src"
+)
+
+func parseConfig(t *testing.T, source string) pawscript.PSLConfig {
+	t.Helper()
+	config, err := pawscript.ParsePSL(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config
+}
+
+func TestSandboxWidening(t *testing.T) {
+	config := parseConfig(t, `(
+		launcher_profile: untrusted,
+		granted_read_roots: ("/data"),
+		launcher_quotas: (open_files: 8, processes: 2),
+		clipboard_access: "off",
+	)`)
+	// A bundle's sandbox group replaces all of the sandbox settings
+	sandbox := func(settings string) string {
+		return `(pawgui_settings: 1, sandbox: (
+			launcher_profile: untrusted,
+			granted_read_roots: ("/data"),
+			launcher_quotas: (open_files: 8, processes: 2),
+			clipboard_access: "off",
+		` + settings + `))`
+	}
+
+	tests := []struct {
+		name   string
+		bundle string
+		want   []string // Substrings of the changes, in order
+	}{
+		{"nothing", `(pawgui_settings: 1)`, nil},
+		{"same", sandbox(""), nil},
+		{"narrower", sandbox(`launcher_quotas: (open_files: 4, processes: 1), granted_read_roots: ()`), nil},
+		{"profile", sandbox(`launcher_profile: trusted`), []string{`"trusted" permission profile`}},
+		{"unknown profile", sandbox(`launcher_profile: nonesuch`), nil},
+		{"roots", sandbox(`granted_read_roots: ("/data", "/home"), granted_write_roots: ("/tmp")`),
+			[]string{"read /home", "write /tmp"}},
+		{"quotas", sandbox(`launcher_quotas: (open_files: 16, processes: 0)`),
+			[]string{"open files quota rises from 8 to 16", "processes quota rises from 2 to no limit"}},
+		{"clipboard", sandbox(`clipboard_access: "read-write"`), []string{`"read-write" instead of "off"`}},
+		{"settings left out", `(pawgui_settings: 1, sandbox: ())`,
+			[]string{"permission profile", "open files quota", "processes quota", "Clipboard access"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := pawscript.SerializePSL(config)
+			changes := SandboxWidening(config, parseConfig(t, tt.bundle))
+			if pawscript.SerializePSL(config) != before {
+				t.Error("config changed")
+			}
+			if len(changes) != len(tt.want) {
+				t.Fatalf("got %q, want %d changes", changes, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(changes[i], want) {
+					t.Errorf("change %d: got %q, want it to contain %q", i, changes[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestImportSettings(t *testing.T) {
+	config := parseConfig(t, `(
+		theme: dark,
+		font_size: 14,
+		launcher_profile: untrusted,
+		granted_read_roots: ("/data"),
+		launcher_size: (800, 600),
+	)`)
+	bundle := parseConfig(t, `(
+		pawgui_settings: 1,
+		appearance: (font_size: 16, cursor_blink: "not a setting's value"),
+		sandbox: (clipboard_access: "off"),
+		launcher_size: (10, 10),
+	)`)
+
+	problems, err := ImportSettings(config, bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 1 || !strings.HasPrefix(problems[0].Path, "appearance.cursor_blink") {
+		t.Errorf("problems: got %v, want one for appearance.cursor_blink", problems)
+	}
+	want := parseConfig(t, `(
+		font_size: 16,
+		clipboard_access: "off",
+		launcher_size: (800, 600),
+	)`)
+	if pawscript.SerializePSL(config) != pawscript.SerializePSL(want) {
+		t.Errorf("got %s, want %s", pawscript.SerializePSL(config), pawscript.SerializePSL(want))
+	}
+}
+
+func TestImportSettingsRoundTrip(t *testing.T) {
+	config := parseConfig(t, `(
+		theme: light,
+		term_colors: (color1: "#aa0000"),
+		launcher_quotas: (open_files: 8),
+		launcher_recent_paths: ("/a"),
+	)`)
+	bundle := ExportSettings(config)
+	imported := pawscript.PSLConfig{"launcher_recent_paths": pawscript.PSLList{"/b"}}
+	if _, err := ImportSettings(imported, bundle); err != nil {
+		t.Fatal(err)
+	}
+	config["launcher_recent_paths"] = pawscript.PSLList{"/b"}
+	if pawscript.SerializePSL(imported) != pawscript.SerializePSL(config) {
+		t.Errorf("got %s, want %s", pawscript.SerializePSL(imported), pawscript.SerializePSL(config))
+	}
+}
+
+func TestImportSettingsRejects(t *testing.T) {
+	for _, source := range []string{
+		`(theme: dark)`,
+		`(pawgui_settings: 0)`,
+		`(pawgui_settings: 99, appearance: (theme: dark))`,
+	} {
+		config := pawscript.PSLConfig{"theme": "light"}
+		if _, err := ImportSettings(config, parseConfig(t, source)); err == nil {
+			t.Errorf("%s: no error", source)
+		}
+		if config.GetString("theme", "") != "light" {
+			t.Errorf("%s: config changed", source)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package pawgui
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"unicode"
+
+	"github.com/phroun/pawscript/src/pkg/purfecterm"
+)
+
+// Keyboard shortcuts
+// Each action has a <name>_shortcut setting holding its shortcut, such as
+// "Ctrl+Shift+C", or nil to have none. Quit and Close are handled by the window;
+// the others act on the console they're pressed in, through its key map, so they
+// take the place of a key macro on the same chord. The console's own keys (Ctrl+C
+// with a selection, and the find and zoom keys) keep working either way.
+
+// Shortcut actions
+const (
+	ShortcutQuit      = "quit"
+	ShortcutClose     = "close"
+	ShortcutCopy      = "copy"
+	ShortcutPaste     = "paste"
+	ShortcutFind      = "find"
+	ShortcutZoomIn    = "zoom_in"
+	ShortcutZoomOut   = "zoom_out"
+	ShortcutZoomReset = "zoom_reset"
+)
+
+// ShortcutAction is an action a keyboard shortcut runs
+type ShortcutAction struct {
+	Name    string // As in its <name>_shortcut setting
+	Label   string
+	Console bool // Whether it acts on a console, bound in its key map
+}
+
+// ShortcutActions are the actions, in the order settings show them
+var ShortcutActions = []ShortcutAction{
+	{Name: ShortcutQuit, Label: "Quit"},
+	{Name: ShortcutClose, Label: "Close Window or Tab"},
+	{Name: ShortcutCopy, Label: "Copy", Console: true},
+	{Name: ShortcutPaste, Label: "Paste", Console: true},
+	{Name: ShortcutFind, Label: "Find", Console: true},
+	{Name: ShortcutZoomIn, Label: "Zoom In", Console: true},
+	{Name: ShortcutZoomOut, Label: "Zoom Out", Console: true},
+	{Name: ShortcutZoomReset, Label: "Actual Size", Console: true},
+}
+
+// findShortcutAction returns the action with the name given
+func findShortcutAction(name string) (ShortcutAction, bool) {
+	for _, action := range ShortcutActions {
+		if action.Name == name {
+			return action, true
+		}
+	}
+	return ShortcutAction{}, false
+}
+
+// GetDefaultShortcut returns the platform-appropriate default shortcut for an action.
+// Like quit and close, the console actions leave plain Ctrl+key to the program
+// running in the console.
+func GetDefaultShortcut(action string) string {
+	mac := runtime.GOOS == "darwin"
+	key := ""
+	switch action {
+	case ShortcutQuit:
+		return GetDefaultQuitShortcut()
+	case ShortcutClose:
+		return GetDefaultCloseShortcut()
+	case ShortcutCopy:
+		key = "C"
+	case ShortcutPaste:
+		key = "V"
+	case ShortcutFind:
+		key = "F"
+	case ShortcutZoomIn:
+		key = "="
+	case ShortcutZoomOut:
+		key = "-"
+	case ShortcutZoomReset:
+		key = "0"
+	default:
+		return ""
+	}
+	if mac {
+		return "Cmd+" + key
+	}
+	return "Ctrl+Shift+" + key
+}
+
+// shortcutKeyNames are the canonical names of the named keys, by lowercase name
+var shortcutKeyNames = map[string]string{
+	"esc": "Esc", "escape": "Esc",
+	"tab":   "Tab",
+	"enter": "Enter", "return": "Enter",
+	"space":     "Space",
+	"backspace": "Backspace", "bs": "Backspace",
+	"delete": "Delete", "del": "Delete",
+	"insert": "Insert", "ins": "Insert",
+	"home": "Home", "end": "End",
+	"pageup": "PageUp", "pgup": "PageUp",
+	"pagedown": "PageDown", "pgdn": "PageDown",
+	"up": "Up", "down": "Down", "left": "Left", "right": "Right",
+	"f1": "F1", "f2": "F2", "f3": "F3", "f4": "F4", "f5": "F5", "f6": "F6",
+	"f7": "F7", "f8": "F8", "f9": "F9", "f10": "F10", "f11": "F11", "f12": "F12",
+}
+
+// shiftedKeys are the keys of the characters typed with Shift on a US keyboard,
+// which the console sends as the key with Shift held
+var shiftedKeys = map[rune]rune{
+	'~': '`', '!': '1', '@': '2', '#': '3', '$': '4', '%': '5', '^': '6', '&': '7',
+	'*': '8', '(': '9', ')': '0', '_': '-', '+': '=', '{': '[', '}': ']', '|': '\\',
+	':': ';', '"': '\'', '<': ',', '>': '.', '?': '/',
+}
+
+// ShortcutString returns the shortcut for a key with modifiers, as settings write
+// it: the modifiers in the order Ctrl, Alt, Shift and Cmd (Super off macOS), then
+// the key, a named key (see NormalizeShortcut) or a single character, letters in
+// uppercase. With Shift held, a shifted character is written as its key, so
+// Ctrl+Shift++ is Ctrl+Shift+=. It returns "" for a key it has no name for.
+func ShortcutString(key string, ctrl, alt, shift, meta bool) string {
+	if name, ok := shortcutKeyNames[strings.ToLower(key)]; ok {
+		key = name
+	} else if runes := []rune(key); len(runes) == 1 && unicode.IsPrint(runes[0]) && !unicode.IsSpace(runes[0]) {
+		if base, ok := shiftedKeys[runes[0]]; ok && shift {
+			runes[0] = base
+		}
+		key = strings.ToUpper(string(runes))
+	} else {
+		return ""
+	}
+
+	var parts []string
+	if ctrl {
+		parts = append(parts, "Ctrl")
+	}
+	if alt {
+		parts = append(parts, "Alt")
+	}
+	if shift {
+		parts = append(parts, "Shift")
+	}
+	if meta {
+		if runtime.GOOS == "darwin" {
+			parts = append(parts, "Cmd")
+		} else {
+			parts = append(parts, "Super")
+		}
+	}
+	return strings.Join(append(parts, key), "+")
+}
+
+// NormalizeShortcut returns a shortcut as ShortcutString writes it, so two ways
+// of writing the same one compare equal, and whether it could be read. Shortcuts
+// are modifiers and a key joined with '+', like key macro chords: the modifiers
+// are Ctrl (Control), Alt (Opt, Option), Shift and Cmd (Meta, Super, Win); the
+// keys are single characters ("Ctrl+Shift++" for plus) and Esc, Tab, Enter,
+// Space, Backspace, Delete, Insert, Home, End, PageUp, PageDown, the arrows Up,
+// Down, Left and Right, and F1-F12. "" is no shortcut.
+func NormalizeShortcut(shortcut string) (string, bool) {
+	shortcut = strings.TrimSpace(shortcut)
+	if shortcut == "" {
+		return "", true
+	}
+	parts := strings.Split(shortcut, "+")
+	if strings.HasSuffix(shortcut, "++") {
+		// The '+' key itself
+		parts = append(parts[:len(parts)-2], "+")
+	}
+
+	var ctrl, alt, shift, meta bool
+	for _, part := range parts[:len(parts)-1] {
+		switch strings.ToLower(strings.TrimSpace(part)) {
+		case "ctrl", "control":
+			ctrl = true
+		case "alt", "opt", "option":
+			alt = true
+		case "shift":
+			shift = true
+		case "cmd", "command", "meta", "super", "win":
+			meta = true
+		default:
+			return "", false
+		}
+	}
+	normalized := ShortcutString(strings.TrimSpace(parts[len(parts)-1]), ctrl, alt, shift, meta)
+	return normalized, normalized != ""
+}
+
+// GetShortcut returns the configured shortcut for an action, "" for none
+func (h *ConfigHelper) GetShortcut(action string) string {
+	if h.Config != nil {
+		if val, exists := h.Config[action+"_shortcut"]; exists {
+			if val == nil {
+				return "" // nil means disabled
+			}
+			if s, ok := val.(string); ok {
+				return s
+			}
+		}
+	}
+	return GetDefaultShortcut(action)
+}
+
+// SetShortcut sets the shortcut for an action, "" for none
+func (h *ConfigHelper) SetShortcut(action, shortcut string) {
+	if shortcut == "" {
+		h.Config.Set(action+"_shortcut", nil)
+	} else {
+		h.Config.Set(action+"_shortcut", shortcut)
+	}
+}
+
+// GetShortcuts returns the configured shortcut of each action, by action name
+func (h *ConfigHelper) GetShortcuts() map[string]string {
+	shortcuts := make(map[string]string)
+	for _, action := range ShortcutActions {
+		shortcuts[action.Name] = h.GetShortcut(action.Name)
+	}
+	return shortcuts
+}
+
+// CheckShortcuts returns the problems with a set of shortcuts, by action name:
+// shortcuts that can't be read, console shortcuts the console can't tell apart
+// from other keys, and shortcuts shared by two actions or with a key macro
+func CheckShortcuts(shortcuts map[string]string, macros []KeyMacro) []string {
+	var problems []string
+	used := make(map[string]string) // Action label by normalized shortcut
+	for _, action := range ShortcutActions {
+		shortcut := shortcuts[action.Name]
+		if shortcut == "" {
+			continue
+		}
+		normalized, ok := NormalizeShortcut(shortcut)
+		if !ok {
+			problems = append(problems, fmt.Sprintf(Tr("%s: %q isn't a shortcut"), Tr(action.Label), shortcut))
+			continue
+		}
+		if action.Console {
+			if _, err := purfecterm.ParseKeyChord(normalized); err != nil {
+				problems = append(problems, fmt.Sprintf(Tr("%s: %s can't be used in the console"), Tr(action.Label), normalized))
+				continue
+			}
+		}
+		if other, exists := used[normalized]; exists {
+			problems = append(problems, fmt.Sprintf(Tr("%s and %s both use %s"), other, Tr(action.Label), normalized))
+			continue
+		}
+		used[normalized] = Tr(action.Label)
+	}
+
+	for _, macro := range macros {
+		normalized, ok := NormalizeShortcut(macro.Chord)
+		if !ok {
+			continue
+		}
+		if label, exists := used[normalized]; exists {
+			problems = append(problems, fmt.Sprintf(Tr("%s uses %s, which a key macro also uses"), label, normalized))
+		}
+	}
+	return problems
+}
+
+// BindShortcuts binds the console shortcuts in a key map to the functions given
+// by action name, returning the problems with any that couldn't be bound. The
+// functions are run on a goroutine of their own (see purfecterm.KeyMap.BindFunc).
+func (h *ConfigHelper) BindShortcuts(keyMap *purfecterm.KeyMap, actions map[string]func()) []error {
+	var errs []error
+	for name, fn := range actions {
+		action, ok := findShortcutAction(name)
+		if !ok || !action.Console {
+			continue
+		}
+		shortcut := h.GetShortcut(name)
+		if shortcut == "" {
+			continue
+		}
+		normalized, ok := NormalizeShortcut(shortcut)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s_shortcut: %q isn't a shortcut", name, shortcut))
+			continue
+		}
+		if err := keyMap.BindFunc(normalized, fn); err != nil {
+			errs = append(errs, fmt.Errorf("%s_shortcut: %w", name, err))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,252 @@
+package pawgui
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	pawscript "github.com/phroun/pawscript/src"
+)
+
+// Status bar
+// Script and console windows have a thin bar under the terminal where scripts
+// report progress with the status_set command, rather than writing it into their
+// output. It has a left and a right segment, and a message can stand in for the
+// left segment for a few seconds. The progress command adds a progress bar to
+// it. The launchers draw it; this keeps its text and progress.
+
+// StatusBar is the text of a window's status bar
+type StatusBar struct {
+	mu       sync.Mutex
+	left     string
+	right    string
+	message  string  // Shown in place of left until it times out
+	messages int     // Counts messages, so an old one's timeout leaves a newer one alone
+	progress float64 // Fraction done, 0 to 1
+	showing  bool    // Whether the progress bar is shown
+
+	// OnChange is called after the text changes, on the script's goroutine or a
+	// timer's
+	OnChange func()
+}
+
+// NewStatusBar creates an empty status bar
+func NewStatusBar(onChange func()) *StatusBar {
+	return &StatusBar{OnChange: onChange}
+}
+
+// SetLeft sets the left segment
+func (s *StatusBar) SetLeft(text string) {
+	s.mu.Lock()
+	s.left = text
+	s.mu.Unlock()
+	s.changed()
+}
+
+// SetRight sets the right segment
+func (s *StatusBar) SetRight(text string) {
+	s.mu.Lock()
+	s.right = text
+	s.mu.Unlock()
+	s.changed()
+}
+
+// ShowMessage shows text in place of the left segment until the timeout passes
+func (s *StatusBar) ShowMessage(text string, timeout time.Duration) {
+	s.mu.Lock()
+	s.message = text
+	s.messages++
+	message := s.messages
+	s.mu.Unlock()
+	s.changed()
+
+	time.AfterFunc(timeout, func() {
+		s.mu.Lock()
+		current := s.messages == message
+		if current {
+			s.message = ""
+		}
+		s.mu.Unlock()
+		if current {
+			s.changed()
+		}
+	})
+}
+
+// SetProgress shows the progress bar, with fraction (0 to 1) of it filled
+func (s *StatusBar) SetProgress(fraction float64) {
+	s.mu.Lock()
+	s.progress = min(max(fraction, 0), 1)
+	s.showing = true
+	s.mu.Unlock()
+	s.changed()
+}
+
+// HideProgress hides the progress bar
+func (s *StatusBar) HideProgress() {
+	s.mu.Lock()
+	showing := s.showing
+	s.progress, s.showing = 0, false
+	s.mu.Unlock()
+	if showing {
+		s.changed()
+	}
+}
+
+// Progress returns how much of the progress bar is filled, and whether it's shown
+func (s *StatusBar) Progress() (fraction float64, shown bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.progress, s.showing
+}
+
+// Clear empties both segments and any message, and hides the progress bar, as
+// when the script that set them ends
+func (s *StatusBar) Clear() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	empty := s.left == "" && s.right == "" && s.message == "" && !s.showing
+	s.left, s.right, s.message = "", "", ""
+	s.progress, s.showing = 0, false
+	s.messages++
+	s.mu.Unlock()
+	if !empty {
+		s.changed()
+	}
+}
+
+// Text returns what the bar shows: the message or left segment, and the right
+// segment. The bar hides while both are empty and there's no progress bar.
+func (s *StatusBar) Text() (left, right string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.message != "" {
+		return s.message, s.right
+	}
+	return s.left, s.right
+}
+
+// changed tells the window its status bar changed
+func (s *StatusBar) changed() {
+	if s.OnChange != nil {
+		s.OnChange()
+	}
+}
+
+// RegisterStatusSetCommand registers the status_set command, which sets the text
+// of a window's status bar:
+//
+//	status_set "Compiling..."             sets the left segment
+//	status_set "Compiling...", "3/10"     sets the left and right segments
+//	status_set right: "3/10"              sets only the right segment
+//	status_set "Saved", timeout: 2        shows a message instead of the left segment for 2 seconds
+//	status_set                            clears the status bar
+func RegisterStatusSetCommand(ps *pawscript.PawScript, status *StatusBar) {
+	ps.RegisterCommand("status_set", func(ctx *pawscript.Context) pawscript.Result {
+		if status == nil {
+			return pawscript.BoolStatus(false)
+		}
+		if len(ctx.Args) == 0 && len(ctx.NamedArgs) == 0 {
+			status.Clear()
+			return pawscript.BoolStatus(true)
+		}
+
+		text := func(value interface{}) string {
+			return fmt.Sprintf("%v", ps.ResolveValue(value))
+		}
+		var left, right *string
+		if len(ctx.Args) > 0 {
+			value := text(ctx.Args[0])
+			left = &value
+		}
+		if len(ctx.Args) > 1 {
+			value := text(ctx.Args[1])
+			right = &value
+		}
+		if value, ok := ctx.NamedArgs["left"]; ok {
+			resolved := text(value)
+			left = &resolved
+		}
+		if value, ok := ctx.NamedArgs["right"]; ok {
+			resolved := text(value)
+			right = &resolved
+		}
+
+		timeout := time.Duration(0)
+		if value, ok := ctx.NamedArgs["timeout"]; ok {
+			seconds, err := strconv.ParseFloat(text(value), 64)
+			if err != nil || seconds <= 0 {
+				ctx.LogError(pawscript.CatArgument, "status_set: invalid timeout: "+text(value))
+				return pawscript.BoolStatus(false)
+			}
+			if left == nil {
+				ctx.LogError(pawscript.CatArgument, "status_set: a timeout needs a message")
+				return pawscript.BoolStatus(false)
+			}
+			timeout = time.Duration(seconds * float64(time.Second))
+		}
+
+		if right != nil {
+			status.SetRight(*right)
+		}
+		if timeout > 0 {
+			status.ShowMessage(*left, timeout)
+			return pawscript.BoolStatus(true)
+		}
+		if left != nil {
+			status.SetLeft(*left)
+		}
+		return pawscript.BoolStatus(true)
+	})
+}
+
+// RegisterProgressCommand registers the progress command, which shows a progress
+// bar in a window's status bar:
+//
+//	progress 40          shows 40% done
+//	progress 3, 10       shows 3 of 10 done
+//	progress             hides the progress bar
+func RegisterProgressCommand(ps *pawscript.PawScript, status *StatusBar) {
+	ps.RegisterCommand("progress", func(ctx *pawscript.Context) pawscript.Result {
+		if status == nil {
+			return pawscript.BoolStatus(false)
+		}
+		if len(ctx.Args) == 0 {
+			status.HideProgress()
+			return pawscript.BoolStatus(true)
+		}
+		if len(ctx.Args) > 2 {
+			ctx.LogError(pawscript.CatCommand, "Usage: progress [done], [total]")
+			return pawscript.BoolStatus(false)
+		}
+
+		number := func(value interface{}) (float64, bool) {
+			text := fmt.Sprintf("%v", ps.ResolveValue(value))
+			n, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				ctx.LogError(pawscript.CatArgument, "progress: not a number: "+text)
+				return 0, false
+			}
+			return n, true
+		}
+		done, ok := number(ctx.Args[0])
+		if !ok {
+			return pawscript.BoolStatus(false)
+		}
+		total := 100.0
+		if len(ctx.Args) > 1 {
+			if total, ok = number(ctx.Args[1]); !ok {
+				return pawscript.BoolStatus(false)
+			}
+			if total <= 0 {
+				ctx.LogError(pawscript.CatArgument, "progress: the total must be more than 0")
+				return pawscript.BoolStatus(false)
+			}
+		}
+		status.SetProgress(done / total)
+		return pawscript.BoolStatus(true)
+	})
+}
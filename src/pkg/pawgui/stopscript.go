@@ -0,0 +1,158 @@
+package pawgui
+
+import (
+	"sync"
+	"time"
+
+	pawscript "github.com/phroun/pawscript/src"
+)
+
+// Stopping scripts
+// Stop Script cancels a running script (see PawScript.Cancel): its commands stop
+// running, the subprocesses it started are killed, and it ends as soon as the
+// command it is in returns. A script stuck in a command that doesn't return, such
+// as a read no one answers, is killed once stop_timeout seconds have passed: the
+// launcher treats it as ended and ignores whatever its goroutine does afterwards.
+// A script given its channels through ScriptRun.IO loses them when it is killed,
+// so what its goroutine does afterwards can't reach the window's terminal.
+
+// DefaultStopTimeout is how long Stop Script waits before killing a script, in
+// seconds, when stop_timeout isn't set
+const DefaultStopTimeout = 5
+
+// GetStopTimeout returns how long Stop Script waits for a script to end before
+// killing it, 0 to wait for as long as it takes
+func (h *ConfigHelper) GetStopTimeout() time.Duration {
+	seconds := DefaultStopTimeout
+	if h.Config != nil {
+		seconds = h.Config.GetInt("stop_timeout", DefaultStopTimeout)
+	}
+	if seconds < 0 {
+		seconds = 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// ScriptRun is a script a window is running, for Stop Script to stop
+type ScriptRun struct {
+	ps     *pawscript.PawScript
+	onKill func() // Cleans up after the script when it is killed
+	done   chan struct{}
+
+	mu       sync.Mutex
+	stopping bool
+	ended    bool
+	killed   bool
+	detach   []func() // Detaches the channels handed out by IO
+}
+
+// NewScriptRun tracks a script running in ps. onKill is called, on a goroutine of
+// its own, if the script is killed; it should do what the window does when the
+// script ends.
+func NewScriptRun(ps *pawscript.PawScript, onKill func()) *ScriptRun {
+	return &ScriptRun{ps: ps, onKill: onKill, done: make(chan struct{})}
+}
+
+// Stop cancels the script, killing it if it hasn't ended after timeout (0 to
+// never kill it). It does nothing once the script is stopping or has ended.
+func (r *ScriptRun) Stop(timeout time.Duration) {
+	r.mu.Lock()
+	if r.stopping || r.ended {
+		r.mu.Unlock()
+		return
+	}
+	r.stopping = true
+	r.mu.Unlock()
+
+	r.ps.Cancel()
+	if timeout <= 0 || r.onKill == nil {
+		return
+	}
+	go func() {
+		select {
+		case <-r.done:
+			return
+		case <-time.After(timeout):
+		}
+		r.mu.Lock()
+		if r.ended {
+			r.mu.Unlock()
+			return
+		}
+		r.killed = true
+		r.mu.Unlock()
+		r.detachIO()
+		r.onKill()
+	}()
+}
+
+// IO returns a copy of io whose channels stand in for the ones in it (see
+// pawscript.DetachableChannel), for the script to run with. They are detached when
+// the script is killed, before onKill runs, and when it ends, leaving the window's
+// own channels to whatever runs next.
+func (r *ScriptRun) IO(io *pawscript.IOChannelConfig) *pawscript.IOChannelConfig {
+	stand := make(map[*pawscript.StoredChannel]*pawscript.StoredChannel)
+	standIn := func(ch *pawscript.StoredChannel) *pawscript.StoredChannel {
+		if ch == nil {
+			return nil
+		}
+		if proxy, ok := stand[ch]; ok {
+			return proxy
+		}
+		proxy, detach := pawscript.DetachableChannel(ch)
+		stand[ch] = proxy
+		r.mu.Lock()
+		r.detach = append(r.detach, detach)
+		r.mu.Unlock()
+		return proxy
+	}
+
+	runIO := *io
+	runIO.Stdin = standIn(io.Stdin)
+	runIO.Stdout = standIn(io.Stdout)
+	runIO.Stderr = standIn(io.Stderr)
+	runIO.Stdio = standIn(io.Stdio)
+	if io.CustomChannels != nil {
+		runIO.CustomChannels = make(map[string]*pawscript.StoredChannel, len(io.CustomChannels))
+		for name, ch := range io.CustomChannels {
+			runIO.CustomChannels[name] = standIn(ch)
+		}
+	}
+	return &runIO
+}
+
+// detachIO detaches the channels handed out by IO
+func (r *ScriptRun) detachIO() {
+	r.mu.Lock()
+	detach := r.detach
+	r.detach = nil
+	r.mu.Unlock()
+	for _, d := range detach {
+		d()
+	}
+}
+
+// Stopped reports whether Stop was called
+func (r *ScriptRun) Stopped() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stopping
+}
+
+// End records that the script ended, releasing what it holds outside the
+// interpreter (see PawScript.Shutdown), and returns false if it was killed first,
+// in which case onKill has already cleaned up after it
+func (r *ScriptRun) End() bool {
+	r.ps.Shutdown()
+	r.detachIO()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.killed {
+		return false
+	}
+	if !r.ended {
+		r.ended = true
+		close(r.done)
+	}
+	return true
+}
@@ -0,0 +1,48 @@
+package pawgui
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	pawscript "github.com/phroun/pawscript/src"
+)
+
+func TestScriptRunKillDetachesIO(t *testing.T) {
+	ps := pawscript.New(&pawscript.Config{Stderr: io.Discard})
+	outQueue := make(chan interface{}, 4)
+	out := pawscript.NewChannelFromGo(outQueue, nil, nil)
+
+	killed := make(chan error, 1)
+	var runIO *pawscript.IOChannelConfig
+	run := NewScriptRun(ps, func() {
+		killed <- pawscript.ChannelSend(runIO.Stdout, "after kill")
+	})
+	runIO = run.IO(&pawscript.IOChannelConfig{Stdout: out, Stderr: out})
+	if runIO.Stdout != runIO.Stderr {
+		t.Error("one channel got two stand-ins")
+	}
+	if err := pawscript.ChannelSend(runIO.Stdout, "running"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing ends the run, so it is killed once the timeout passes
+	run.Stop(10 * time.Millisecond)
+	select {
+	case err := <-killed:
+		if err == nil {
+			t.Error("killed script could still send")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("script not killed")
+	}
+	if got := len(outQueue); got != 1 {
+		t.Errorf("%d messages reached the window, want 1", got)
+	}
+	if err := pawscript.ChannelSend(out, "next"); err != nil {
+		t.Errorf("window channel unusable after the kill: %v", err)
+	}
+	if run.End() {
+		t.Error("End reported a killed script as ended")
+	}
+}
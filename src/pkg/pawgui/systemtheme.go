@@ -0,0 +1,52 @@
+package pawgui
+
+import "sync"
+
+// System theme
+// With theme or term_theme set to auto, the launchers follow the OS's dark or
+// light setting, switching their windows, icons and console palettes when it
+// changes. WatchSystemTheme reads the setting and watches it: the freedesktop
+// settings portal's color-scheme on Linux and the BSDs, the AppsUseLightTheme
+// value WM_SETTINGCHANGE announces changes to on Windows, and
+// AppleInterfaceThemeChangedNotification on macOS. Where none of them answers, the
+// launchers judge by their toolkit's colors, and auto consoles are dark.
+
+var systemTheme struct {
+	sync.Mutex
+	known bool // Whether the OS has said which it uses
+	dark  bool
+}
+
+// SystemDarkTheme returns whether the OS uses a dark theme, ok being false when
+// WatchSystemTheme hasn't learned it
+func SystemDarkTheme() (dark, ok bool) {
+	systemTheme.Lock()
+	defer systemTheme.Unlock()
+	return systemTheme.dark, systemTheme.known
+}
+
+// setSystemTheme records the OS's theme, returning whether it changed
+func setSystemTheme(dark bool) bool {
+	systemTheme.Lock()
+	defer systemTheme.Unlock()
+	changed := !systemTheme.known || systemTheme.dark != dark
+	systemTheme.known, systemTheme.dark = true, dark
+	return changed
+}
+
+// WatchSystemTheme reads the OS's theme for SystemDarkTheme, then calls onChange,
+// from a goroutine of its own, each time the OS switches between dark and light,
+// until stop is called. The error says why changes can't be watched here;
+// SystemDarkTheme may know the theme even then.
+func WatchSystemTheme(onChange func(dark bool)) (stop func(), err error) {
+	return watchSystemTheme(func(dark bool) {
+		if setSystemTheme(dark) {
+			onChange(dark)
+		}
+	})
+}
+
+// IsTermThemeAuto returns whether the console theme follows the OS's
+func (h *ConfigHelper) IsTermThemeAuto() bool {
+	return h.Config != nil && h.Config.GetString("term_theme", "dark") == "auto"
+}
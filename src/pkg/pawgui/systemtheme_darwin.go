@@ -0,0 +1,62 @@
+//go:build darwin && cgo
+
+package pawgui
+
+/*
+#cgo LDFLAGS: -framework Foundation
+
+void pawguiObserveSystemTheme(void);
+void pawguiStopObservingSystemTheme(void);
+*/
+import "C"
+
+import (
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// The function told when macOS switches theme, and its lock
+var (
+	systemThemeMu       sync.Mutex
+	systemThemeObserver func(dark bool)
+)
+
+// appleInterfaceDark reads AppleInterfaceStyle, which is "Dark" in dark mode and
+// not set in light mode
+func appleInterfaceDark() bool {
+	out, err := exec.Command("defaults", "read", "-g", "AppleInterfaceStyle").Output()
+	return err == nil && strings.TrimSpace(string(out)) == "Dark"
+}
+
+// watchSystemTheme reads AppleInterfaceStyle and reads it again each time
+// AppleInterfaceThemeChangedNotification is posted
+func watchSystemTheme(changed func(dark bool)) (func(), error) {
+	setSystemTheme(appleInterfaceDark())
+
+	systemThemeMu.Lock()
+	systemThemeObserver = changed
+	systemThemeMu.Unlock()
+	C.pawguiObserveSystemTheme()
+
+	var stop sync.Once
+	return func() {
+		stop.Do(func() {
+			C.pawguiStopObservingSystemTheme()
+			systemThemeMu.Lock()
+			systemThemeObserver = nil
+			systemThemeMu.Unlock()
+		})
+	}, nil
+}
+
+//export pawguiSystemThemeChanged
+func pawguiSystemThemeChanged() {
+	systemThemeMu.Lock()
+	changed := systemThemeObserver
+	systemThemeMu.Unlock()
+	if changed != nil {
+		// Off the main thread, which posts the notification
+		go changed(appleInterfaceDark())
+	}
+}
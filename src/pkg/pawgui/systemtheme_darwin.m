@@ -0,0 +1,32 @@
+// The observer of AppleInterfaceThemeChangedNotification for systemtheme_darwin.go
+
+#import <Foundation/Foundation.h>
+#include "_cgo_export.h"
+
+static id pawguiThemeObserver = nil;
+
+void pawguiObserveSystemTheme(void) {
+	@autoreleasepool {
+		if (pawguiThemeObserver != nil) {
+			return;
+		}
+		pawguiThemeObserver = [[[NSDistributedNotificationCenter defaultCenter]
+			addObserverForName:@"AppleInterfaceThemeChangedNotification"
+			            object:nil
+			             queue:[NSOperationQueue mainQueue]
+			        usingBlock:^(NSNotification *note) {
+				pawguiSystemThemeChanged();
+			}] retain];
+	}
+}
+
+void pawguiStopObservingSystemTheme(void) {
+	@autoreleasepool {
+		if (pawguiThemeObserver == nil) {
+			return;
+		}
+		[[NSDistributedNotificationCenter defaultCenter] removeObserver:pawguiThemeObserver];
+		[pawguiThemeObserver release];
+		pawguiThemeObserver = nil;
+	}
+}
@@ -0,0 +1,10 @@
+//go:build js || (darwin && !cgo)
+
+package pawgui
+
+import "errors"
+
+// watchSystemTheme can't learn the theme here
+func watchSystemTheme(changed func(dark bool)) (func(), error) {
+	return nil, errors.New("the system theme can't be watched on this platform")
+}
@@ -0,0 +1,74 @@
+//go:build !darwin && !windows && !js
+
+package pawgui
+
+import (
+	"context"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	portalName          = "org.freedesktop.portal.Desktop"
+	portalPath          = "/org/freedesktop/portal/desktop"
+	portalSettings      = "org.freedesktop.portal.Settings"
+	appearanceNamespace = "org.freedesktop.appearance"
+	colorSchemeKey      = "color-scheme"
+)
+
+// portalTimeout limits how long reading the theme from the portal may take
+const portalTimeout = 2 * time.Second
+
+// watchSystemTheme reads and watches the color-scheme setting of the freedesktop
+// settings portal: 1 prefers dark, 2 prefers light, and 0 has no preference,
+// which leaves the theme unknown at the start and is light once it changes to it
+func watchSystemTheme(changed func(dark bool)) (func(), error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, err
+	}
+	portal := conn.Object(portalName, portalPath)
+
+	ctx, cancel := context.WithTimeout(context.Background(), portalTimeout)
+	defer cancel()
+	var value dbus.Variant
+	err = portal.CallWithContext(ctx, portalSettings+".ReadOne", 0, appearanceNamespace, colorSchemeKey).Store(&value)
+	if err != nil {
+		// Portals before version 2 only have Read, which wraps the value once more
+		var wrapped dbus.Variant
+		if portal.CallWithContext(ctx, portalSettings+".Read", 0, appearanceNamespace, colorSchemeKey).Store(&wrapped) == nil {
+			value, _ = wrapped.Value().(dbus.Variant)
+		}
+	}
+	if scheme, ok := value.Value().(uint32); ok && scheme != 0 {
+		setSystemTheme(scheme == 1)
+	}
+
+	err = conn.AddMatchSignal(
+		dbus.WithMatchObjectPath(portalPath),
+		dbus.WithMatchInterface(portalSettings),
+		dbus.WithMatchMember("SettingChanged"),
+		dbus.WithMatchArg(0, appearanceNamespace),
+	)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	signals := make(chan *dbus.Signal, 8)
+	conn.Signal(signals)
+	go func() {
+		// Closing the connection closes signals
+		for signal := range signals {
+			if len(signal.Body) != 3 || signal.Body[0] != appearanceNamespace || signal.Body[1] != colorSchemeKey {
+				continue
+			}
+			if value, ok := signal.Body[2].(dbus.Variant); ok {
+				if scheme, ok := value.Value().(uint32); ok {
+					changed(scheme == 1)
+				}
+			}
+		}
+	}()
+	return func() { conn.Close() }, nil
+}
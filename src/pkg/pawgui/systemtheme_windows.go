@@ -0,0 +1,61 @@
+//go:build windows
+
+package pawgui
+
+import (
+	"sync"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+// personalizeKey holds AppsUseLightTheme, 0 when apps are to use a dark theme
+const personalizeKey = `Software\Microsoft\Windows\CurrentVersion\Themes\Personalize`
+
+// watchSystemTheme reads AppsUseLightTheme and waits on the registry for changes
+// to it, which is what Windows changes before sending WM_SETTINGCHANGE for it
+func watchSystemTheme(changed func(dark bool)) (func(), error) {
+	key, err := registry.OpenKey(registry.CURRENT_USER, personalizeKey, registry.QUERY_VALUE|registry.NOTIFY)
+	if err != nil {
+		return nil, err
+	}
+	appsDark := func() (bool, bool) {
+		light, _, err := key.GetIntegerValue("AppsUseLightTheme")
+		return light == 0, err == nil
+	}
+	if dark, ok := appsDark(); ok {
+		setSystemTheme(dark)
+	}
+
+	notified, err := windows.CreateEvent(nil, 0, 0, nil)
+	if err != nil {
+		key.Close()
+		return nil, err
+	}
+	stopped, err := windows.CreateEvent(nil, 1, 0, nil)
+	if err != nil {
+		windows.CloseHandle(notified)
+		key.Close()
+		return nil, err
+	}
+
+	go func() {
+		defer key.Close()
+		defer windows.CloseHandle(notified)
+		defer windows.CloseHandle(stopped)
+		for {
+			if windows.RegNotifyChangeKeyValue(windows.Handle(key), false, windows.REG_NOTIFY_CHANGE_LAST_SET, notified, true) != nil {
+				return
+			}
+			event, err := windows.WaitForMultipleObjects([]windows.Handle{notified, stopped}, false, windows.INFINITE)
+			if err != nil || event != windows.WAIT_OBJECT_0 {
+				return
+			}
+			if dark, ok := appsDark(); ok {
+				changed(dark)
+			}
+		}
+	}()
+	var stop sync.Once
+	return func() { stop.Do(func() { windows.SetEvent(stopped) }) }, nil
+}
@@ -0,0 +1,217 @@
+package pawgui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	pawscript "github.com/phroun/pawscript/src"
+)
+
+// Toolbar buttons
+// Scripts add buttons of their own to their window's toolbar strip, under the
+// hamburger menu, with the toolbar_button command. Each has a name the script
+// chooses, so it can change the button's icon or tooltip later, or remove it. The
+// launchers draw them; this keeps the list and runs the buttons' blocks.
+
+// ToolbarIconNames are the icons built into the launchers, by name
+var ToolbarIconNames = []string{"star", "trash", "folder", "folder-up", "home", "file", "paw", "checked", "unchecked"}
+
+// ToolbarButton is a button a script added to a toolbar strip
+type ToolbarButton struct {
+	Name     string // Chosen by the script, to update or remove the button
+	Icon     string // One of ToolbarIconNames, or SVG markup
+	Tooltip  string
+	Activate func() // Run on the UI thread when the button is clicked
+}
+
+// ToolbarButtons is the buttons scripts added to a window's toolbar strip
+type ToolbarButtons struct {
+	mu      sync.Mutex
+	buttons []ToolbarButton
+
+	// OnChange is called after the buttons change, on the script's goroutine
+	OnChange func()
+}
+
+// NewToolbarButtons creates an empty list of buttons
+func NewToolbarButtons(onChange func()) *ToolbarButtons {
+	return &ToolbarButtons{OnChange: onChange}
+}
+
+// Set adds a button after the others, or replaces the button with its name where
+// it is
+func (t *ToolbarButtons) Set(button ToolbarButton) {
+	t.mu.Lock()
+	replaced := false
+	for i := range t.buttons {
+		if t.buttons[i].Name == button.Name {
+			t.buttons[i] = button
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		t.buttons = append(t.buttons, button)
+	}
+	t.mu.Unlock()
+	t.changed()
+}
+
+// Update changes a button in place, returning whether there was one by its name
+func (t *ToolbarButtons) Update(name string, update func(button *ToolbarButton)) bool {
+	t.mu.Lock()
+	found := false
+	for i := range t.buttons {
+		if t.buttons[i].Name == name {
+			update(&t.buttons[i])
+			found = true
+			break
+		}
+	}
+	t.mu.Unlock()
+	if found {
+		t.changed()
+	}
+	return found
+}
+
+// Remove removes the button with the name, returning whether there was one
+func (t *ToolbarButtons) Remove(name string) bool {
+	t.mu.Lock()
+	found := false
+	for i := range t.buttons {
+		if t.buttons[i].Name == name {
+			t.buttons = append(t.buttons[:i], t.buttons[i+1:]...)
+			found = true
+			break
+		}
+	}
+	t.mu.Unlock()
+	if found {
+		t.changed()
+	}
+	return found
+}
+
+// Buttons returns the buttons in order (none for a nil list)
+func (t *ToolbarButtons) Buttons() []ToolbarButton {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]ToolbarButton(nil), t.buttons...)
+}
+
+// changed tells the window its buttons changed
+func (t *ToolbarButtons) changed() {
+	if t.OnChange != nil {
+		t.OnChange()
+	}
+}
+
+// RegisterToolbarButtonCommand registers the toolbar_button command, which adds
+// buttons to a window's toolbar strip:
+//
+//	toolbar_button "build", "star", "Build", (make)    adds the button, or replaces it
+//	toolbar_button "build", tooltip: "Building..."     changes the button's icon: or tooltip:
+//	toolbar_button "build"                             removes the button
+//	toolbar_button                                     returns the buttons' names
+//
+// The icon is one of ToolbarIconNames or an .svg file the script may read. The
+// block runs in the environment of the script that added the button.
+func RegisterToolbarButtonCommand(ps *pawscript.PawScript, buttons *ToolbarButtons) {
+	ps.RegisterCommand("toolbar_button", func(ctx *pawscript.Context) pawscript.Result {
+		if buttons == nil {
+			return pawscript.BoolStatus(false)
+		}
+		if len(ctx.Args) == 0 {
+			names := make([]interface{}, 0)
+			for _, button := range buttons.Buttons() {
+				names = append(names, button.Name)
+			}
+			ctx.SetResult(ctx.NewStoredListWithRefs(names, nil))
+			return pawscript.BoolStatus(true)
+		}
+
+		name := fmt.Sprintf("%v", ps.ResolveValue(ctx.Args[0]))
+		if len(ctx.Args) == 1 {
+			if len(ctx.NamedArgs) == 0 {
+				ctx.SetResult(buttons.Remove(name))
+				return pawscript.BoolStatus(true)
+			}
+			var icon, tooltip *string
+			if value, ok := ctx.NamedArgs["icon"]; ok {
+				resolved, err := toolbarIcon(ps, ctx, fmt.Sprintf("%v", ps.ResolveValue(value)))
+				if err != nil {
+					ctx.LogError(pawscript.CatIO, "toolbar_button: "+err.Error())
+					return pawscript.BoolStatus(false)
+				}
+				icon = &resolved
+			}
+			if value, ok := ctx.NamedArgs["tooltip"]; ok {
+				text := fmt.Sprintf("%v", ps.ResolveValue(value))
+				tooltip = &text
+			}
+			found := buttons.Update(name, func(button *ToolbarButton) {
+				if icon != nil {
+					button.Icon = *icon
+				}
+				if tooltip != nil {
+					button.Tooltip = *tooltip
+				}
+			})
+			ctx.SetResult(found)
+			return pawscript.BoolStatus(true)
+		}
+
+		if len(ctx.Args) < 4 {
+			ctx.LogError(pawscript.CatArgument, "toolbar_button requires a name, icon, tooltip and block")
+			return pawscript.BoolStatus(false)
+		}
+		icon, err := toolbarIcon(ps, ctx, fmt.Sprintf("%v", ps.ResolveValue(ctx.Args[1])))
+		if err != nil {
+			ctx.LogError(pawscript.CatIO, "toolbar_button: "+err.Error())
+			return pawscript.BoolStatus(false)
+		}
+		action, ok := ps.ResolveValue(ctx.Args[3]).(pawscript.ParenGroup)
+		if !ok {
+			ctx.LogError(pawscript.CatArgument, "toolbar_button: the action must be a block")
+			return pawscript.BoolStatus(false)
+		}
+		env := ctx.GetModuleEnv()
+		buttons.Set(ToolbarButton{
+			Name:    name,
+			Icon:    icon,
+			Tooltip: fmt.Sprintf("%v", ps.ResolveValue(ctx.Args[2])),
+			Activate: func() {
+				// The button runs this on the UI thread; the script may take its time
+				go ps.ExecuteWithEnvironment(string(action), env, "", 0, 0)
+			},
+		})
+		return pawscript.BoolStatus(true)
+	})
+}
+
+// toolbarIcon returns a built-in icon's name as it is, or reads an SVG file
+func toolbarIcon(ps *pawscript.PawScript, ctx *pawscript.Context, icon string) (string, error) {
+	if !strings.HasSuffix(strings.ToLower(icon), ".svg") {
+		for _, name := range ToolbarIconNames {
+			if icon == name {
+				return icon, nil
+			}
+		}
+		return "", fmt.Errorf("unknown icon %q", icon)
+	}
+	path, err := ps.CheckPathAccess(ctx, "toolbar_button", icon, false)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
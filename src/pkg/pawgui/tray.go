@@ -0,0 +1,203 @@
+package pawgui
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"sync"
+
+	pawscript "github.com/phroun/pawscript/src"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// System tray
+// The launchers put an icon in the system tray, with a menu to open a console,
+// run a recent script or show the launcher, and hide the launcher there when it's
+// minimized if the minimize_to_tray setting is on. Scripts report on work going
+// on in the background with the tray_set command, which sets the icon's tooltip
+// and puts a badge on it. The launchers draw the icon; this keeps its tooltip
+// and badge.
+
+// DefaultTrayTooltip is the tooltip of the tray icon until a script sets one
+const DefaultTrayTooltip = "PawScript"
+
+// maxTrayBadge is the most characters a badge shows
+const maxTrayBadge = 3
+
+// trayBadgeColor is the background of a badge
+var trayBadgeColor = color.RGBA{0xdc, 0x32, 0x2f, 0xff}
+
+// Tray is the tooltip and badge of the launcher's tray icon
+type Tray struct {
+	mu      sync.Mutex
+	tooltip string
+	badge   string
+
+	// OnChange is called after the tooltip or badge changes, on the script's
+	// goroutine
+	OnChange func()
+}
+
+// NewTray creates a tray icon's state, with the default tooltip and no badge
+func NewTray(onChange func()) *Tray {
+	return &Tray{tooltip: DefaultTrayTooltip, OnChange: onChange}
+}
+
+// SetTooltip sets the tooltip; "" restores the default
+func (t *Tray) SetTooltip(text string) {
+	if text == "" {
+		text = DefaultTrayTooltip
+	}
+	t.mu.Lock()
+	t.tooltip = text
+	t.mu.Unlock()
+	t.changed()
+}
+
+// SetBadge sets the badge drawn on the icon, such as a count; "" removes it.
+// Only its first few characters fit.
+func (t *Tray) SetBadge(text string) {
+	if runes := []rune(text); len(runes) > maxTrayBadge {
+		text = string(runes[:maxTrayBadge])
+	}
+	t.mu.Lock()
+	t.badge = text
+	t.mu.Unlock()
+	t.changed()
+}
+
+// Tooltip returns the tooltip
+func (t *Tray) Tooltip() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.tooltip
+}
+
+// Badge returns the badge, "" for none
+func (t *Tray) Badge() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.badge
+}
+
+// changed tells the launcher its tray icon changed
+func (t *Tray) changed() {
+	if t.OnChange != nil {
+		t.OnChange()
+	}
+}
+
+// TrayIconPNG returns a PNG icon with a badge drawn over its bottom right corner,
+// or the icon as it is when the badge is ""
+func TrayIconPNG(icon []byte, badge string) ([]byte, error) {
+	if badge == "" {
+		return icon, nil
+	}
+	src, err := png.Decode(bytes.NewReader(icon))
+	if err != nil {
+		return nil, err
+	}
+	bounds := src.Bounds()
+	img := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(img, img.Bounds(), src, bounds.Min, draw.Src)
+	drawTrayBadge(img, badge)
+
+	var out bytes.Buffer
+	if err := png.Encode(&out, img); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// drawTrayBadge draws a badge, a pill with white text, over the bottom right
+// corner of an icon
+func drawTrayBadge(img *image.RGBA, badge string) {
+	size := min(img.Bounds().Dx(), img.Bounds().Dy())
+
+	// The text is drawn at the font's size, then scaled to fit the pill
+	face := basicfont.Face7x13
+	textWidth := font.MeasureString(face, badge).Ceil()
+	text := image.NewRGBA(image.Rect(0, 0, textWidth, face.Height))
+	drawer := font.Drawer{Dst: text, Src: image.White, Face: face, Dot: fixed.P(0, face.Ascent)}
+	drawer.DrawString(badge)
+
+	height := size * 9 / 16
+	scaledHeight := height * 3 / 4
+	scaledWidth := textWidth * scaledHeight / face.Height
+	width := min(max(height, scaledWidth+height/2), size)
+	if scaledWidth > width-height/4 {
+		// Too wide for the icon: narrow the text rather than overflow
+		scaledWidth = width - height/4
+	}
+	pill := image.Rect(size-width, size-height, size, size)
+
+	// Fill the pill, smoothing its round ends
+	radius := float64(height) / 2
+	centerY := float64(pill.Min.Y) + radius
+	left, right := float64(pill.Min.X)+radius, float64(pill.Max.X)-radius
+	for y := pill.Min.Y; y < pill.Max.Y; y++ {
+		for x := pill.Min.X; x < pill.Max.X; x++ {
+			px, py := float64(x)+0.5, float64(y)+0.5
+			nearest := min(max(px, left), right)
+			distance := math.Hypot(px-nearest, py-centerY)
+			coverage := min(max(radius-distance+0.5, 0), 1)
+			if coverage == 0 {
+				continue
+			}
+			badgeColor := trayBadgeColor
+			badgeColor.A = uint8(coverage * 255)
+			draw.Draw(img, image.Rect(x, y, x+1, y+1), image.NewUniform(badgeColor), image.Point{}, draw.Over)
+		}
+	}
+
+	textRect := image.Rect(0, 0, scaledWidth, scaledHeight).Add(image.Pt(
+		pill.Min.X+(width-scaledWidth)/2,
+		pill.Min.Y+(height-scaledHeight)/2,
+	))
+	xdraw.CatmullRom.Scale(img, textRect, text, text.Bounds(), draw.Over, nil)
+}
+
+// RegisterTraySetCommand registers the tray_set command, which sets the tooltip
+// and badge of the launcher's tray icon:
+//
+//	tray_set "Building..."             sets the tooltip
+//	tray_set badge: 3                  puts a badge on the icon
+//	tray_set "Idle", badge: ""         sets the tooltip and removes the badge
+//	tray_set                           restores the tooltip and removes the badge
+func RegisterTraySetCommand(ps *pawscript.PawScript, tray *Tray) {
+	ps.RegisterCommand("tray_set", func(ctx *pawscript.Context) pawscript.Result {
+		if tray == nil {
+			return pawscript.BoolStatus(false)
+		}
+		if len(ctx.Args) > 1 {
+			ctx.LogError(pawscript.CatCommand, "Usage: tray_set [tooltip], [badge: <text>]")
+			return pawscript.BoolStatus(false)
+		}
+		text := func(value interface{}) string {
+			return fmt.Sprintf("%v", ps.ResolveValue(value))
+		}
+
+		if len(ctx.Args) == 0 && len(ctx.NamedArgs) == 0 {
+			tray.SetTooltip("")
+			tray.SetBadge("")
+			return pawscript.BoolStatus(true)
+		}
+		if len(ctx.Args) > 0 {
+			tray.SetTooltip(text(ctx.Args[0]))
+		}
+		if value, ok := ctx.NamedArgs["tooltip"]; ok {
+			tray.SetTooltip(text(value))
+		}
+		if value, ok := ctx.NamedArgs["badge"]; ok {
+			tray.SetBadge(text(value))
+		}
+		return pawscript.BoolStatus(true)
+	})
+}
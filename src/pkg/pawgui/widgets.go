@@ -0,0 +1,432 @@
+package pawgui
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	pawscript "github.com/phroun/pawscript/src"
+)
+
+// Script widgets
+// Scripts build simple windows of their own with window_create, and fill them
+// with labels, buttons and sliders laid out in rows and columns. Windows and
+// widgets are known to scripts by number. This keeps them; the hosting launcher
+// draws them through a WidgetHost.
+
+// WidgetKind is what a widget is
+type WidgetKind int
+
+const (
+	WidgetColumn WidgetKind = iota // Lays out its children top to bottom; a window's root
+	WidgetRow                      // Lays out its children left to right
+	WidgetLabel
+	WidgetButton
+	WidgetSlider
+)
+
+// Widget is a widget in a script's window
+type Widget struct {
+	ID       int
+	Kind     WidgetKind
+	Window   *WidgetWindow
+	Min, Max int // A slider's range
+
+	mu       sync.Mutex
+	text     string // A label's or button's text
+	value    int    // A slider's value
+	activate func() // Runs the script's block when a button is clicked or a slider moved
+}
+
+// Text returns a label's or button's text
+func (w *Widget) Text() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.text
+}
+
+// Value returns a slider's value
+func (w *Widget) Value() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.value
+}
+
+// IsContainer reports whether other widgets can be added to the widget
+func (w *Widget) IsContainer() bool {
+	return w.Kind == WidgetColumn || w.Kind == WidgetRow
+}
+
+// Clicked runs a button's block; the host calls it on the UI thread
+func (w *Widget) Clicked() {
+	if w.activate != nil {
+		w.activate()
+	}
+}
+
+// Moved records a slider's new value and runs its block; the host calls it on
+// the UI thread
+func (w *Widget) Moved(value int) {
+	w.mu.Lock()
+	changed := w.value != value
+	w.value = value
+	w.mu.Unlock()
+	if changed && w.activate != nil {
+		w.activate()
+	}
+}
+
+// WidgetWindow is a window a script created
+type WidgetWindow struct {
+	ID            int
+	Title         string
+	Width, Height int     // Requested size in pixels (0 = fit the widgets)
+	Root          *Widget // The column the window's widgets go in
+}
+
+// WidgetHost draws script windows for a launcher
+// Its methods are called from the script's goroutine; hosts do the work on their
+// UI thread, in the order they were called.
+type WidgetHost interface {
+	OpenWindow(window *WidgetWindow)
+	AddWidget(parent, widget *Widget)
+	UpdateWidget(widget *Widget) // Its text or value changed
+	CloseWindow(window *WidgetWindow)
+}
+
+// ScriptWidgets is the windows and widgets scripts created, by number
+type ScriptWidgets struct {
+	host WidgetHost
+
+	mu      sync.Mutex
+	lastID  int
+	windows map[int]*WidgetWindow
+	widgets map[int]*Widget
+}
+
+// NewScriptWidgets creates an empty set of script windows, drawn by host
+func NewScriptWidgets(host WidgetHost) *ScriptWidgets {
+	return &ScriptWidgets{
+		host:    host,
+		windows: make(map[int]*WidgetWindow),
+		widgets: make(map[int]*Widget),
+	}
+}
+
+// nextID returns a number for a new window or widget; call with mu held
+func (s *ScriptWidgets) nextID() int {
+	s.lastID++
+	return s.lastID
+}
+
+// OpenWindow creates a window and shows it, empty
+func (s *ScriptWidgets) OpenWindow(title string, width, height int) *WidgetWindow {
+	s.mu.Lock()
+	window := &WidgetWindow{ID: s.nextID(), Title: title, Width: width, Height: height}
+	window.Root = &Widget{ID: s.nextID(), Kind: WidgetColumn, Window: window}
+	s.windows[window.ID] = window
+	s.widgets[window.Root.ID] = window.Root
+	s.mu.Unlock()
+
+	s.host.OpenWindow(window)
+	return window
+}
+
+// Add adds a widget to a window (by its number) or to a row or column
+func (s *ScriptWidgets) Add(parentID int, widget *Widget) error {
+	s.mu.Lock()
+	parent := s.widgets[parentID]
+	if window, ok := s.windows[parentID]; ok {
+		parent = window.Root
+	}
+	if parent == nil || !parent.IsContainer() {
+		s.mu.Unlock()
+		return fmt.Errorf("no window, row or column %d", parentID)
+	}
+	widget.ID = s.nextID()
+	widget.Window = parent.Window
+	s.widgets[widget.ID] = widget
+	s.mu.Unlock()
+
+	s.host.AddWidget(parent, widget)
+	return nil
+}
+
+// Widget returns the widget with a number, or nil
+func (s *ScriptWidgets) Widget(id int) *Widget {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.widgets[id]
+}
+
+// Update changes a label's or button's text, or a slider's value
+func (s *ScriptWidgets) Update(widget *Widget, value string) error {
+	widget.mu.Lock()
+	switch widget.Kind {
+	case WidgetLabel, WidgetButton:
+		widget.text = value
+	case WidgetSlider:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			widget.mu.Unlock()
+			return fmt.Errorf("a slider's value must be a whole number, not %q", value)
+		}
+		widget.value = min(max(n, widget.Min), widget.Max)
+	default:
+		widget.mu.Unlock()
+		return fmt.Errorf("widget %d has no value", widget.ID)
+	}
+	widget.mu.Unlock()
+
+	s.host.UpdateWidget(widget)
+	return nil
+}
+
+// CloseWindow closes a script's window, returning whether it was open
+func (s *ScriptWidgets) CloseWindow(id int) bool {
+	s.mu.Lock()
+	window, ok := s.windows[id]
+	s.mu.Unlock()
+	if !ok {
+		return false
+	}
+	s.WindowClosed(window)
+	s.host.CloseWindow(window)
+	return true
+}
+
+// WindowClosed forgets a window and its widgets; the host calls it when the user
+// closes one
+func (s *ScriptWidgets) WindowClosed(window *WidgetWindow) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.windows, window.ID)
+	for id, widget := range s.widgets {
+		if widget.Window == window {
+			delete(s.widgets, id)
+		}
+	}
+}
+
+// callbackQueue runs a script's widget blocks one at a time, in the order they
+// were queued, on a goroutine that lasts while there are blocks waiting
+type callbackQueue struct {
+	mu      sync.Mutex
+	pending []func()
+	running bool
+}
+
+// run queues f, starting the goroutine that runs the queue if it isn't running
+func (q *callbackQueue) run(f func()) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending = append(q.pending, f)
+	if !q.running {
+		q.running = true
+		go q.drain()
+	}
+}
+
+// drain runs the queued blocks until none are left
+func (q *callbackQueue) drain() {
+	for {
+		q.mu.Lock()
+		if len(q.pending) == 0 {
+			q.running = false
+			q.mu.Unlock()
+			return
+		}
+		f := q.pending[0]
+		q.pending = q.pending[1:]
+		q.mu.Unlock()
+		f()
+	}
+}
+
+// RegisterWidgetCommands registers the commands scripts build windows with:
+//
+//	win: {window_create "Mixer", width: 300}          opens an empty window, returning its number
+//	row: {add_row ~win}                               adds a row (add_column: a column) to a window, row or column
+//	add_label ~row, "Volume"                          adds a label
+//	vol: {add_slider ~row, 0, 100, (mix), value: 50}  adds a slider; the block runs when it moves
+//	add_button ~win, "Reset", (reset)                 adds a button that runs the block when clicked
+//	widget_get ~vol                                   returns a slider's value, or a label's or button's text
+//	widget_set ~vol, 75                               sets it
+//	window_close ~win                                 closes the window
+//
+// Blocks run in the environment of the script that added the widget, so they can
+// call its macros, and one at a time, in the order their events came.
+func RegisterWidgetCommands(ps *pawscript.PawScript, widgets *ScriptWidgets) {
+	text := func(value interface{}) string {
+		return fmt.Sprintf("%v", ps.ResolveValue(value))
+	}
+	number := func(value interface{}) (int, bool) {
+		n, err := strconv.ParseFloat(text(value), 64)
+		return int(n), err == nil
+	}
+	// action returns a block's runner, or nil if value isn't a block
+	callbacks := &callbackQueue{}
+	action := func(ctx *pawscript.Context, value interface{}) func() {
+		block, ok := ps.ResolveValue(value).(pawscript.ParenGroup)
+		if !ok {
+			return nil
+		}
+		env := ctx.GetModuleEnv()
+		return func() {
+			// The host runs this on the UI thread; the script may take its time
+			callbacks.run(func() {
+				ps.ExecuteWithEnvironment(string(block), env, "", 0, 0)
+			})
+		}
+	}
+	// add adds a widget under the first argument, returning its number
+	add := func(ctx *pawscript.Context, name string, widget *Widget) pawscript.Result {
+		parent, ok := number(ctx.Args[0])
+		if !ok {
+			ctx.LogError(pawscript.CatArgument, name+": the first argument must be a window, row or column")
+			return pawscript.BoolStatus(false)
+		}
+		if err := widgets.Add(parent, widget); err != nil {
+			ctx.LogError(pawscript.CatArgument, name+": "+err.Error())
+			return pawscript.BoolStatus(false)
+		}
+		ctx.SetResult(int64(widget.ID))
+		return pawscript.BoolStatus(true)
+	}
+	// find returns the widget the first argument names, logging if there is none
+	find := func(ctx *pawscript.Context, name string) *Widget {
+		if len(ctx.Args) > 0 {
+			if id, ok := number(ctx.Args[0]); ok {
+				if widget := widgets.Widget(id); widget != nil {
+					return widget
+				}
+			}
+			ctx.LogError(pawscript.CatArgument, name+": no widget "+text(ctx.Args[0]))
+			return nil
+		}
+		ctx.LogError(pawscript.CatCommand, "Usage: "+name+" <widget>")
+		return nil
+	}
+
+	ps.RegisterCommand("window_create", func(ctx *pawscript.Context) pawscript.Result {
+		title := "PawScript"
+		if len(ctx.Args) > 0 {
+			title = text(ctx.Args[0])
+		}
+		var width, height int
+		if value, ok := ctx.NamedArgs["width"]; ok {
+			width, _ = number(value)
+		}
+		if value, ok := ctx.NamedArgs["height"]; ok {
+			height, _ = number(value)
+		}
+		window := widgets.OpenWindow(title, width, height)
+		ctx.SetResult(int64(window.ID))
+		return pawscript.BoolStatus(true)
+	})
+
+	ps.RegisterCommand("add_row", func(ctx *pawscript.Context) pawscript.Result {
+		if len(ctx.Args) < 1 {
+			ctx.LogError(pawscript.CatCommand, "Usage: add_row <parent>")
+			return pawscript.BoolStatus(false)
+		}
+		return add(ctx, "add_row", &Widget{Kind: WidgetRow})
+	})
+
+	ps.RegisterCommand("add_column", func(ctx *pawscript.Context) pawscript.Result {
+		if len(ctx.Args) < 1 {
+			ctx.LogError(pawscript.CatCommand, "Usage: add_column <parent>")
+			return pawscript.BoolStatus(false)
+		}
+		return add(ctx, "add_column", &Widget{Kind: WidgetColumn})
+	})
+
+	ps.RegisterCommand("add_label", func(ctx *pawscript.Context) pawscript.Result {
+		if len(ctx.Args) < 2 {
+			ctx.LogError(pawscript.CatCommand, "Usage: add_label <parent>, <text>")
+			return pawscript.BoolStatus(false)
+		}
+		return add(ctx, "add_label", &Widget{Kind: WidgetLabel, text: text(ctx.Args[1])})
+	})
+
+	ps.RegisterCommand("add_button", func(ctx *pawscript.Context) pawscript.Result {
+		if len(ctx.Args) < 3 {
+			ctx.LogError(pawscript.CatCommand, "Usage: add_button <parent>, <label>, (block)")
+			return pawscript.BoolStatus(false)
+		}
+		activate := action(ctx, ctx.Args[2])
+		if activate == nil {
+			ctx.LogError(pawscript.CatArgument, "add_button: the action must be a block")
+			return pawscript.BoolStatus(false)
+		}
+		return add(ctx, "add_button", &Widget{Kind: WidgetButton, text: text(ctx.Args[1]), activate: activate})
+	})
+
+	ps.RegisterCommand("add_slider", func(ctx *pawscript.Context) pawscript.Result {
+		if len(ctx.Args) < 3 {
+			ctx.LogError(pawscript.CatCommand, "Usage: add_slider <parent>, <min>, <max> [, (block)] [, value: N]")
+			return pawscript.BoolStatus(false)
+		}
+		low, okLow := number(ctx.Args[1])
+		high, okHigh := number(ctx.Args[2])
+		if !okLow || !okHigh || low > high {
+			ctx.LogError(pawscript.CatArgument, "add_slider: the range must be two numbers, lowest first")
+			return pawscript.BoolStatus(false)
+		}
+		widget := &Widget{Kind: WidgetSlider, Min: low, Max: high, value: low}
+		if value, ok := ctx.NamedArgs["value"]; ok {
+			n, _ := number(value)
+			widget.value = min(max(n, low), high)
+		}
+		if len(ctx.Args) > 3 {
+			if widget.activate = action(ctx, ctx.Args[3]); widget.activate == nil {
+				ctx.LogError(pawscript.CatArgument, "add_slider: the action must be a block")
+				return pawscript.BoolStatus(false)
+			}
+		}
+		return add(ctx, "add_slider", widget)
+	})
+
+	ps.RegisterCommand("widget_get", func(ctx *pawscript.Context) pawscript.Result {
+		widget := find(ctx, "widget_get")
+		if widget == nil {
+			return pawscript.BoolStatus(false)
+		}
+		switch widget.Kind {
+		case WidgetSlider:
+			ctx.SetResult(int64(widget.Value()))
+		case WidgetLabel, WidgetButton:
+			ctx.SetResult(widget.Text())
+		default:
+			ctx.SetResult(nil)
+			return pawscript.BoolStatus(false)
+		}
+		return pawscript.BoolStatus(true)
+	})
+
+	ps.RegisterCommand("widget_set", func(ctx *pawscript.Context) pawscript.Result {
+		if len(ctx.Args) < 2 {
+			ctx.LogError(pawscript.CatCommand, "Usage: widget_set <widget>, <value>")
+			return pawscript.BoolStatus(false)
+		}
+		widget := find(ctx, "widget_set")
+		if widget == nil {
+			return pawscript.BoolStatus(false)
+		}
+		if err := widgets.Update(widget, text(ctx.Args[1])); err != nil {
+			ctx.LogError(pawscript.CatArgument, "widget_set: "+err.Error())
+			return pawscript.BoolStatus(false)
+		}
+		return pawscript.BoolStatus(true)
+	})
+
+	ps.RegisterCommand("window_close", func(ctx *pawscript.Context) pawscript.Result {
+		if len(ctx.Args) < 1 {
+			ctx.LogError(pawscript.CatCommand, "Usage: window_close <window>")
+			return pawscript.BoolStatus(false)
+		}
+		id, _ := number(ctx.Args[0])
+		ctx.SetResult(widgets.CloseWindow(id))
+		return pawscript.BoolStatus(true)
+	})
+}
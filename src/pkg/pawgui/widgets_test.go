@@ -0,0 +1,37 @@
+package pawgui
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCallbackQueueRunsInOrderOneAtATime(t *testing.T) {
+	var q callbackQueue
+	var running atomic.Int32
+	var mu sync.Mutex
+	var order []int
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		i := i
+		q.run(func() {
+			defer wg.Done()
+			if running.Add(1) != 1 {
+				t.Error("callbacks ran at the same time")
+			}
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+			running.Add(-1)
+		})
+	}
+	wg.Wait()
+	for i, n := range order {
+		if n != i {
+			t.Fatalf("callbacks ran out of order: %v", order)
+		}
+	}
+}
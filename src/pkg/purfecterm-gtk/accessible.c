@@ -0,0 +1,130 @@
+#include <stdlib.h>
+#include <gtk/gtk.h>
+#include <gtk/gtk-a11y.h>
+#include "_cgo_export.h"
+
+// The terminal's drawing area: a GtkDrawingArea whose accessible is a terminal
+// exposing the visible screen through AtkText. The text comes from the Go widget
+// whose handle is set on the area (see accessible.go).
+
+typedef struct { GtkDrawingArea parent; } PurfectermArea;
+typedef struct { GtkDrawingAreaClass parent_class; } PurfectermAreaClass;
+
+typedef struct { GtkWidgetAccessible parent; } PurfectermAccessible;
+typedef struct { GtkWidgetAccessibleClass parent_class; } PurfectermAccessibleClass;
+
+static void purfecterm_accessible_text_init(AtkTextIface *iface);
+
+G_DEFINE_TYPE(PurfectermArea, purfecterm_area, GTK_TYPE_DRAWING_AREA)
+G_DEFINE_TYPE_WITH_CODE(PurfectermAccessible, purfecterm_accessible, GTK_TYPE_WIDGET_ACCESSIBLE,
+    G_IMPLEMENT_INTERFACE(ATK_TYPE_TEXT, purfecterm_accessible_text_init))
+
+static void purfecterm_area_init(PurfectermArea *area) {}
+
+static void purfecterm_area_class_init(PurfectermAreaClass *klass) {
+    gtk_widget_class_set_accessible_type(GTK_WIDGET_CLASS(klass), purfecterm_accessible_get_type());
+    gtk_widget_class_set_accessible_role(GTK_WIDGET_CLASS(klass), ATK_ROLE_TERMINAL);
+}
+
+GtkWidget *purfecterm_area_new(void) {
+    return g_object_new(purfecterm_area_get_type(), NULL);
+}
+
+void purfecterm_area_set_handle(GtkWidget *area, uintptr_t handle) {
+    g_object_set_data(G_OBJECT(area), "purfecterm-handle", (gpointer)handle);
+}
+
+// Whether the area's accessible has been made, as it is once a screen reader
+// (or other assistive technology) looks at the window
+int purfecterm_area_accessible_active(GtkWidget *area) {
+    return g_object_get_data(G_OBJECT(area), "purfecterm-accessible") != NULL;
+}
+
+void purfecterm_area_text_changed(GtkWidget *area, int inserted, int pos, int length) {
+    AtkObject *acc = gtk_widget_get_accessible(area);
+    g_signal_emit_by_name(acc, inserted ? "text-changed::insert" : "text-changed::delete", pos, length);
+}
+
+void purfecterm_area_caret_moved(GtkWidget *area, int caret) {
+    g_signal_emit_by_name(gtk_widget_get_accessible(area), "text-caret-moved", caret);
+}
+
+static void purfecterm_accessible_initialize(AtkObject *obj, gpointer data) {
+    ATK_OBJECT_CLASS(purfecterm_accessible_parent_class)->initialize(obj, data);
+    g_object_set_data(G_OBJECT(data), "purfecterm-accessible", obj);
+}
+
+static void purfecterm_accessible_init(PurfectermAccessible *acc) {}
+
+static void purfecterm_accessible_class_init(PurfectermAccessibleClass *klass) {
+    ATK_OBJECT_CLASS(klass)->initialize = purfecterm_accessible_initialize;
+}
+
+// The handle of the Go widget behind an accessible, 0 once it is gone
+static uintptr_t accessible_handle(AtkText *text) {
+    GtkWidget *area = gtk_accessible_get_widget(GTK_ACCESSIBLE(text));
+    return area ? (uintptr_t)g_object_get_data(G_OBJECT(area), "purfecterm-handle") : 0;
+}
+
+// take_string returns a string from Go as ATK wants it, freeing Go's copy
+static gchar *take_string(char *s) {
+    gchar *result = g_strdup(s ? s : "");
+    free(s);
+    return result;
+}
+
+static gchar *get_text(AtkText *text, gint start, gint end) {
+    uintptr_t handle = accessible_handle(text);
+    return handle ? take_string(goAccessibleText(handle, start, end)) : g_strdup("");
+}
+
+static gint get_character_count(AtkText *text) {
+    uintptr_t handle = accessible_handle(text);
+    return handle ? goAccessibleCharacterCount(handle) : 0;
+}
+
+static gint get_caret_offset(AtkText *text) {
+    uintptr_t handle = accessible_handle(text);
+    return handle ? goAccessibleCaret(handle) : -1;
+}
+
+static gunichar get_character_at_offset(AtkText *text, gint offset) {
+    uintptr_t handle = accessible_handle(text);
+    return handle ? goAccessibleCharacter(handle, offset) : 0;
+}
+
+// The units are purfecterm.TextUnit: 0 a character, 1 a word, 2 a line. Sentences
+// and paragraphs are read as lines.
+static gchar *text_unit(AtkText *text, gint offset, int unit, gint *start, gint *end) {
+    uintptr_t handle = accessible_handle(text);
+    if (!handle) {
+        *start = *end = 0;
+        return g_strdup("");
+    }
+    return take_string(goAccessibleTextUnit(handle, offset, unit, start, end));
+}
+
+static gchar *get_string_at_offset(AtkText *text, gint offset, AtkTextGranularity granularity,
+                                   gint *start, gint *end) {
+    int unit = 2;
+    if (granularity == ATK_TEXT_GRANULARITY_CHAR) unit = 0;
+    else if (granularity == ATK_TEXT_GRANULARITY_WORD) unit = 1;
+    return text_unit(text, offset, unit, start, end);
+}
+
+static gchar *get_text_at_offset(AtkText *text, gint offset, AtkTextBoundary boundary,
+                                 gint *start, gint *end) {
+    int unit = 2;
+    if (boundary == ATK_TEXT_BOUNDARY_CHAR) unit = 0;
+    else if (boundary == ATK_TEXT_BOUNDARY_WORD_START || boundary == ATK_TEXT_BOUNDARY_WORD_END) unit = 1;
+    return text_unit(text, offset, unit, start, end);
+}
+
+static void purfecterm_accessible_text_init(AtkTextIface *iface) {
+    iface->get_text = get_text;
+    iface->get_character_count = get_character_count;
+    iface->get_caret_offset = get_caret_offset;
+    iface->get_character_at_offset = get_character_at_offset;
+    iface->get_string_at_offset = get_string_at_offset;
+    iface->get_text_at_offset = get_text_at_offset;
+}
@@ -0,0 +1,137 @@
+package purfectermgtk
+
+/*
+#include <stdint.h>
+#include <gtk/gtk.h>
+
+// Defined in accessible.c
+GtkWidget *purfecterm_area_new(void);
+void purfecterm_area_set_handle(GtkWidget *area, uintptr_t handle);
+int purfecterm_area_accessible_active(GtkWidget *area);
+void purfecterm_area_text_changed(GtkWidget *area, int inserted, int pos, int length);
+void purfecterm_area_caret_moved(GtkWidget *area, int caret);
+*/
+import "C"
+
+import (
+	"fmt"
+	"runtime/cgo"
+	"unsafe"
+
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/phroun/pawscript/src/pkg/purfecterm"
+)
+
+// Accessibility
+// The drawing area's accessible (see accessible.c) is an AT-SPI terminal whose
+// text is the visible screen, with the cursor as the caret (see
+// purfecterm/accessible.go). Once a screen reader has asked for it, onDraw
+// compares the screen with the text it last gave and reports the change as text
+// deleted and inserted, and the caret moving, so output is read as it arrives.
+
+// accessibleText is the text the accessible last gave, so changes can be reported.
+// It is only used on the UI thread.
+type accessibleText struct {
+	handle cgo.Handle
+	text   []rune // nil until first asked for
+	caret  int
+}
+
+// newTerminalArea creates the drawing area with the terminal's accessible
+func (w *Widget) newTerminalArea() (*gtk.DrawingArea, error) {
+	c := C.purfecterm_area_new()
+	if c == nil {
+		return nil, fmt.Errorf("creating the terminal's drawing area failed")
+	}
+	obj := glib.Take(unsafe.Pointer(c))
+	area := &gtk.DrawingArea{Widget: gtk.Widget{InitiallyUnowned: glib.InitiallyUnowned{Object: obj}}}
+
+	w.a11y.handle = cgo.NewHandle(w)
+	C.purfecterm_area_set_handle(c, C.uintptr_t(w.a11y.handle))
+	area.Connect("destroy", func() {
+		C.purfecterm_area_set_handle(c, 0)
+		w.a11y.handle.Delete()
+	})
+	return area, nil
+}
+
+// accessibleSnapshot returns the text the accessible gives, reading the screen
+// the first time
+func (w *Widget) accessibleSnapshot() ([]rune, int) {
+	if w.a11y.text == nil {
+		text, caret := w.buffer.AccessibleText()
+		w.a11y.text, w.a11y.caret = []rune(text), caret
+	}
+	return w.a11y.text, w.a11y.caret
+}
+
+// updateAccessible reports how the screen changed since the accessible last gave
+// it, if a screen reader has asked for it
+func (w *Widget) updateAccessible() {
+	area := (*C.GtkWidget)(unsafe.Pointer(w.drawingArea.Native()))
+	if C.purfecterm_area_accessible_active(area) == 0 || w.a11y.text == nil {
+		return
+	}
+	s, caret := w.buffer.AccessibleText()
+	text := []rune(s)
+	pos, removed, inserted := purfecterm.TextChange(w.a11y.text, text)
+	// The removed text is still there while the deletion is reported
+	if len(removed) > 0 {
+		C.purfecterm_area_text_changed(area, 0, C.int(pos), C.int(len(removed)))
+	}
+	w.a11y.text = text
+	if len(inserted) > 0 {
+		C.purfecterm_area_text_changed(area, 1, C.int(pos), C.int(len(inserted)))
+	}
+	if caret != w.a11y.caret {
+		w.a11y.caret = caret
+		if caret >= 0 {
+			C.purfecterm_area_caret_moved(area, C.int(caret))
+		}
+	}
+}
+
+// accessibleWidget returns the widget behind a handle from accessible.c
+func accessibleWidget(handle C.uintptr_t) *Widget {
+	return cgo.Handle(handle).Value().(*Widget)
+}
+
+//export goAccessibleText
+func goAccessibleText(handle C.uintptr_t, start, end C.int) *C.char {
+	text, _ := accessibleWidget(handle).accessibleSnapshot()
+	s, e := min(max(int(start), 0), len(text)), len(text)
+	if end >= 0 {
+		e = min(max(int(end), s), len(text))
+	}
+	return C.CString(string(text[s:e]))
+}
+
+//export goAccessibleCharacterCount
+func goAccessibleCharacterCount(handle C.uintptr_t) C.int {
+	text, _ := accessibleWidget(handle).accessibleSnapshot()
+	return C.int(len(text))
+}
+
+//export goAccessibleCaret
+func goAccessibleCaret(handle C.uintptr_t) C.int {
+	_, caret := accessibleWidget(handle).accessibleSnapshot()
+	return C.int(caret)
+}
+
+//export goAccessibleCharacter
+func goAccessibleCharacter(handle C.uintptr_t, offset C.int) C.uint {
+	text, _ := accessibleWidget(handle).accessibleSnapshot()
+	if offset < 0 || int(offset) >= len(text) {
+		return 0
+	}
+	return C.uint(text[offset])
+}
+
+//export goAccessibleTextUnit
+func goAccessibleTextUnit(handle C.uintptr_t, offset, unit C.int, start, end *C.int) *C.char {
+	text, _ := accessibleWidget(handle).accessibleSnapshot()
+	s, e := purfecterm.TextUnitAt(text, int(offset), purfecterm.TextUnit(unit))
+	*start, *end = C.int(s), C.int(e)
+	return C.CString(string(text[s:e]))
+}
@@ -0,0 +1,173 @@
+package purfectermgtk
+
+/*
+#cgo pkg-config: gtk+-3.0
+#include <gtk/gtk.h>
+#include <string.h>
+
+// Ask the window manager to blur what shows through a window, or stop, with the
+// property KWin reads (an empty region blurs the whole window). Only X11 has it;
+// elsewhere this does nothing.
+static void set_blur_behind(GdkWindow *win, int blur) {
+    win = gdk_window_get_toplevel(win);
+    if (strcmp(G_OBJECT_TYPE_NAME(gdk_window_get_display(win)), "GdkX11Display") != 0) return;
+    GdkAtom atom = gdk_atom_intern_static_string("_KDE_NET_WM_BLUR_BEHIND_REGION");
+    if (blur) {
+        gdk_property_change(win, atom, gdk_atom_intern_static_string("CARDINAL"), 32,
+            GDK_PROP_MODE_REPLACE, NULL, 0);
+    } else {
+        gdk_property_delete(win, atom);
+    }
+}
+
+// Whether a window has an alpha channel, so what is drawn see-through shows the
+// desktop
+static int window_has_alpha(GdkWindow *win) {
+    GdkScreen *screen = gdk_window_get_screen(win);
+    return gdk_screen_is_composited(screen) &&
+        gdk_window_get_visual(gdk_window_get_toplevel(win)) == gdk_screen_get_rgba_visual(screen);
+}
+*/
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/gotk3/gotk3/cairo"
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/phroun/pawscript/src/pkg/purfecterm"
+)
+
+// Background
+// onDraw paints the scheme's background (see purfecterm/background.go) with its
+// opacity, replacing what the window drew under the terminal so the desktop shows
+// through where the window has an alpha channel (see UseRGBAVisual), and draws the
+// background image under the text, scaled to cover the widget and faded toward the
+// background color. Blur is asked of the window manager where it can do it.
+
+// backdrop is the background image, loaded and scaled for the widget's size, and
+// the window the blur was last set on. It is only used on the UI thread.
+type backdrop struct {
+	path   string      // Image file the image was loaded from
+	image  *gdk.Pixbuf // nil if there is none or it couldn't be loaded
+	scaled *gdk.Pixbuf // The image scaled to cover width x height
+	width  int
+	height int
+	x, y   float64 // Where the scaled image is drawn
+
+	blurWindow uintptr // The GDK window blur was last set on
+	blurred    bool
+}
+
+// UseRGBAVisual gives a top-level window an alpha channel where the screen has a
+// compositor, so a terminal's background opacity shows the desktop through it.
+// Call it before the window is shown; the rest of the window is drawn as before.
+func UseRGBAVisual(win *gtk.Window) {
+	screen := win.GetScreen()
+	if screen == nil || !screen.IsComposited() {
+		return
+	}
+	if visual, err := screen.GetRGBAVisual(); err == nil && visual != nil {
+		win.SetVisual(visual)
+	}
+}
+
+// drawBackground fills the widget with the scheme's background, its opacity and
+// its image
+func (w *Widget) drawBackground(cr *cairo.Context, width, height int, scheme purfecterm.ColorScheme, isDark bool) {
+	bg := scheme.Background(isDark)
+	r, g, b := float64(bg.R)/255.0, float64(bg.G)/255.0, float64(bg.B)/255.0
+	alpha := scheme.BackgroundAlpha()
+	if alpha < 1 && !w.windowHasAlpha() {
+		// Drawn see-through, it would only be darker
+		alpha = 1
+	}
+	if alpha == 1 && scheme.BackgroundImage == "" {
+		cr.SetSourceRGB(r, g, b)
+		cr.Rectangle(0, 0, float64(width), float64(height))
+		cr.Fill()
+		return
+	}
+
+	cr.Save()
+	cr.Rectangle(0, 0, float64(width), float64(height))
+	cr.Clip()
+	// Clear what the window drew, then lay the opaque background over it at the
+	// scheme's opacity
+	cr.SetOperator(cairo.OPERATOR_SOURCE)
+	cr.SetSourceRGBA(0, 0, 0, 0)
+	cr.Paint()
+	cr.SetOperator(cairo.OPERATOR_OVER)
+	cr.PushGroup()
+	cr.SetSourceRGB(r, g, b)
+	cr.Paint()
+	if image := w.backgroundImage(scheme.BackgroundImage, width, height); image != nil {
+		gtk.GdkCairoSetSourcePixBuf(cr, image, w.backdrop.x, w.backdrop.y)
+		cr.Paint()
+		if dim := scheme.BackgroundDimming(); dim > 0 {
+			cr.SetSourceRGBA(r, g, b, dim)
+			cr.Paint()
+		}
+	}
+	cr.PopGroupToSource()
+	cr.PaintWithAlpha(alpha)
+	cr.Restore()
+}
+
+// backgroundImage returns the image at path scaled to cover width x height, or nil
+// if there is none or it can't be loaded
+func (w *Widget) backgroundImage(path string, width, height int) *gdk.Pixbuf {
+	d := &w.backdrop
+	if path != d.path {
+		*d = backdrop{path: path, blurWindow: d.blurWindow, blurred: d.blurred}
+		if path != "" {
+			d.image, _ = gdk.PixbufNewFromFile(path)
+		}
+	}
+	if d.image == nil || width <= 0 || height <= 0 {
+		return nil
+	}
+	if d.scaled == nil || width != d.width || height != d.height {
+		scale, x, y := purfecterm.ImageCover(d.image.GetWidth(), d.image.GetHeight(), width, height)
+		scaledW := max(int(float64(d.image.GetWidth())*scale+0.5), 1)
+		scaledH := max(int(float64(d.image.GetHeight())*scale+0.5), 1)
+		scaled, err := d.image.ScaleSimple(scaledW, scaledH, gdk.INTERP_BILINEAR)
+		if err != nil {
+			return nil
+		}
+		d.scaled, d.width, d.height, d.x, d.y = scaled, width, height, x, y
+	}
+	return d.scaled
+}
+
+// windowHasAlpha returns whether the widget's window has an alpha channel
+func (w *Widget) windowHasAlpha() bool {
+	win, err := w.drawingArea.GetWindow()
+	if err != nil || win == nil {
+		return false
+	}
+	return C.window_has_alpha((*C.GdkWindow)(unsafe.Pointer(win.Native()))) != 0
+}
+
+// updateWindowBlur asks for what shows through the window to be blurred, or not,
+// as the scheme has it, when that or the window changed
+func (w *Widget) updateWindowBlur(scheme purfecterm.ColorScheme) {
+	blur := scheme.BackgroundBlurred()
+	if !blur && !w.backdrop.blurred {
+		return
+	}
+	win, err := w.drawingArea.GetWindow()
+	if err != nil || win == nil {
+		return
+	}
+	if win.Native() == w.backdrop.blurWindow && blur == w.backdrop.blurred {
+		return
+	}
+	cBlur := C.int(0)
+	if blur {
+		cBlur = 1
+	}
+	C.set_blur_behind((*C.GdkWindow)(unsafe.Pointer(win.Native())), cBlur)
+	w.backdrop.blurWindow, w.backdrop.blurred = win.Native(), blur
+}
@@ -0,0 +1,89 @@
+package purfectermgtk
+
+import (
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/phroun/pawscript/src/pkg/purfecterm"
+)
+
+// File drops
+// Files dropped on the terminal are typed at the cursor as their quoted paths (see
+// purfecterm/filedrop.go), and dropped text is typed as it is, both delivered as a
+// paste. URIs that aren't local files are typed as the URI. The embedder's file
+// drop callback is offered dropped files first, and may take them instead.
+
+// Drop target kinds
+const (
+	dropURIs = iota
+	dropText
+)
+
+// initFileDrop makes the drawing area a drop target for files and text
+func (w *Widget) initFileDrop() {
+	var targets []gtk.TargetEntry
+	for _, t := range []struct {
+		name string
+		info uint
+	}{
+		{"text/uri-list", dropURIs},
+		{"text/plain;charset=utf-8", dropText},
+		{"UTF8_STRING", dropText},
+	} {
+		if entry, err := gtk.TargetEntryNew(t.name, gtk.TARGET_OTHER_APP, t.info); err == nil {
+			targets = append(targets, *entry)
+		}
+	}
+	if len(targets) == 0 {
+		return
+	}
+	w.drawingArea.DragDestSet(gtk.DEST_DEFAULT_ALL, targets, gdk.ACTION_COPY)
+	w.drawingArea.Connect("drag-data-received", w.onDragDataReceived)
+}
+
+// onDragDataReceived types what was dropped
+func (w *Widget) onDragDataReceived(da *gtk.DrawingArea, ctx *gdk.DragContext, x, y int, data *gtk.SelectionData, info, time uint) {
+	var text string
+	switch info {
+	case dropURIs:
+		var paths []string
+		local := true
+		for _, uri := range data.GetURIs() {
+			if path, ok := purfecterm.FileURIPath(uri); ok {
+				paths = append(paths, path)
+			} else {
+				paths = append(paths, uri)
+				local = false
+			}
+		}
+		if local && w.offerFileDrop(paths) {
+			return
+		}
+		text = purfecterm.DropText(paths)
+	case dropText:
+		text = data.GetText()
+	}
+	w.typeDrop(text)
+}
+
+// typeDrop delivers dropped text as a paste and focuses the terminal
+func (w *Widget) typeDrop(text string) {
+	w.mu.Lock()
+	onInput := w.onInput
+	w.mu.Unlock()
+
+	if onInput == nil || text == "" {
+		return
+	}
+	onInput(purfecterm.BracketPaste(text, w.buffer.IsBracketedPasteModeEnabled()))
+	w.drawingArea.GrabFocus()
+}
+
+// offerFileDrop offers dropped files to the file drop callback, returning whether
+// it took them
+func (w *Widget) offerFileDrop(paths []string) bool {
+	w.mu.Lock()
+	onFileDrop := w.onFileDrop
+	w.mu.Unlock()
+
+	return onFileDrop != nil && len(paths) > 0 && onFileDrop(paths)
+}
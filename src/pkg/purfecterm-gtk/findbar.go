@@ -0,0 +1,168 @@
+package purfectermgtk
+
+import (
+	"runtime"
+
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/phroun/pawscript/src/pkg/purfecterm"
+)
+
+// Find bar
+// Ctrl+Shift+F (Cmd+F on macOS; plain Ctrl+F belongs to the program, as readline's
+// forward-char) opens a bar above the terminal that searches the scrollback as the
+// pattern is typed (see purfecterm/search.go). Enter and the up button move to the
+// previous (older) match, Shift+Enter and the down button to the next; Escape or
+// the close button hides the bar and clears the highlights.
+
+// findBar is the widgets of the find bar
+type findBar struct {
+	box       *gtk.Box
+	entry     *gtk.SearchEntry
+	matchCase *gtk.CheckButton
+	regex     *gtk.CheckButton
+	status    *gtk.Label
+}
+
+// initFindBar creates the find bar, hidden, at the top of the widget
+func (w *Widget) initFindBar() error {
+	box, err := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 4)
+	if err != nil {
+		return err
+	}
+	entry, err := gtk.SearchEntryNew()
+	if err != nil {
+		return err
+	}
+	prev, err := gtk.ButtonNewFromIconName("go-up-symbolic", gtk.ICON_SIZE_MENU)
+	if err != nil {
+		return err
+	}
+	next, err := gtk.ButtonNewFromIconName("go-down-symbolic", gtk.ICON_SIZE_MENU)
+	if err != nil {
+		return err
+	}
+	matchCase, err := gtk.CheckButtonNewWithLabel("Match case")
+	if err != nil {
+		return err
+	}
+	regex, err := gtk.CheckButtonNewWithLabel("Regex")
+	if err != nil {
+		return err
+	}
+	status, err := gtk.LabelNew("")
+	if err != nil {
+		return err
+	}
+	closeButton, err := gtk.ButtonNewFromIconName("window-close-symbolic", gtk.ICON_SIZE_MENU)
+	if err != nil {
+		return err
+	}
+	w.find = findBar{box: box, entry: entry, matchCase: matchCase, regex: regex, status: status}
+
+	entry.SetWidthChars(24)
+	prev.SetTooltipText("Previous match (Enter)")
+	next.SetTooltipText("Next match (Shift+Enter)")
+	closeButton.SetTooltipText("Close (Escape)")
+	for _, b := range []*gtk.Button{prev, next, closeButton} {
+		b.SetRelief(gtk.RELIEF_NONE)
+		b.SetFocusOnClick(false)
+	}
+
+	box.SetMarginStart(4)
+	box.SetMarginEnd(4)
+	box.PackStart(entry, false, false, 0)
+	box.PackStart(prev, false, false, 0)
+	box.PackStart(next, false, false, 0)
+	box.PackStart(matchCase, false, false, 0)
+	box.PackStart(regex, false, false, 0)
+	box.PackStart(status, false, false, 0)
+	box.PackEnd(closeButton, false, false, 0)
+
+	entry.Connect("search-changed", func() { w.runFind(purfecterm.SearchBackward) })
+	entry.Connect("activate", func() { w.findNext(purfecterm.SearchBackward) })
+	entry.Connect("stop-search", w.HideFindBar)
+	entry.Connect("key-press-event", func(_ *gtk.SearchEntry, ev *gdk.Event) bool {
+		key := gdk.EventKeyNewFromEvent(ev)
+		if (key.KeyVal() == gdk.KEY_Return || key.KeyVal() == gdk.KEY_KP_Enter) && key.State()&uint(gdk.SHIFT_MASK) != 0 {
+			w.findNext(purfecterm.SearchForward)
+			return true
+		}
+		return false
+	})
+	prev.Connect("clicked", func() { w.findNext(purfecterm.SearchBackward) })
+	next.Connect("clicked", func() { w.findNext(purfecterm.SearchForward) })
+	matchCase.Connect("toggled", func() { w.runFind(purfecterm.SearchBackward) })
+	regex.Connect("toggled", func() { w.runFind(purfecterm.SearchBackward) })
+	closeButton.Connect("clicked", w.HideFindBar)
+
+	// Hidden until opened, even when the host shows everything
+	box.ShowAll()
+	box.SetNoShowAll(true)
+	box.Hide()
+	w.box.PackStart(box, false, false, 2)
+	w.box.ReorderChild(box, 0)
+	return nil
+}
+
+// isFindShortcut returns whether a key press opens the find bar
+func isFindShortcut(keyval uint, hasShift, hasCtrl, hasAlt, hasMeta bool) bool {
+	if keyval != gdk.KEY_f && keyval != gdk.KEY_F {
+		return false
+	}
+	if runtime.GOOS == "darwin" && hasMeta && !hasCtrl && !hasAlt {
+		return true
+	}
+	return hasCtrl && hasShift && !hasAlt && !hasMeta
+}
+
+// ShowFindBar opens the find bar and focuses its pattern, selected so typing
+// replaces it
+func (w *Widget) ShowFindBar() {
+	if w.find.box == nil {
+		return
+	}
+	w.find.box.Show()
+	w.find.entry.GrabFocus()
+	if text, _ := w.find.entry.GetText(); text != "" {
+		w.runFind(purfecterm.SearchBackward)
+	}
+}
+
+// HideFindBar closes the find bar, clears the highlights and focuses the terminal
+func (w *Widget) HideFindBar() {
+	if w.find.box == nil || !w.find.box.GetVisible() {
+		return
+	}
+	w.find.box.Hide()
+	w.buffer.ClearSearch()
+	w.drawingArea.GrabFocus()
+	w.drawingArea.QueueDraw()
+}
+
+// runFind searches for the find bar's pattern from the view
+func (w *Widget) runFind(direction purfecterm.SearchDirection) {
+	text, _ := w.find.entry.GetText()
+	pattern := purfecterm.SearchPattern(text, w.find.regex.GetActive(), w.find.matchCase.GetActive())
+	matches, current, err := w.buffer.Search(pattern, direction)
+	if pattern == "" {
+		w.find.status.SetText("")
+	} else {
+		w.find.status.SetText(purfecterm.SearchStatus(len(matches), current, err))
+	}
+	w.updateScrollbar()
+	w.drawingArea.QueueDraw()
+}
+
+// findNext moves to the next match in direction, searching first if the output
+// has no search (as after it was cleared)
+func (w *Widget) findNext(direction purfecterm.SearchDirection) {
+	if _, ok := w.buffer.SearchNext(direction); !ok {
+		w.runFind(direction)
+		return
+	}
+	matches, current := w.buffer.GetSearchMatches()
+	w.find.status.SetText(purfecterm.SearchStatus(len(matches), current, nil))
+	w.updateScrollbar()
+	w.drawingArea.QueueDraw()
+}
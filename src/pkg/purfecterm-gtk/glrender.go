@@ -0,0 +1,518 @@
+package purfectermgtk
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/go-gl/gl/v3.2-core/gl"
+	"github.com/gotk3/gotk3/cairo"
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/phroun/pawscript/src/pkg/purfecterm"
+)
+
+// GPU rendering
+// With ColorScheme.GPURendering set, the cells are drawn by OpenGL in a GtkGLArea
+// behind the drawing area, which then only paints what sits on top of the text
+// (images, sprites in front, the scrollback boundary and the visual bell) and keeps
+// handling input as before. Glyphs are rendered once each with Pango into a texture
+// atlas (see purfecterm/glyphatlas.go) and drawn from there as textured quads, and
+// each frame is compared with the last (see purfecterm/gridframe.go) so only the
+// rows that changed have their vertices rebuilt. Frames the grid can't describe,
+// and systems without OpenGL 3.2, are drawn by the Cairo renderer in onDraw, which
+// then paints over the GL area.
+
+// glAtlasSize is the largest glyph atlas texture used, in pixels per side
+const glAtlasSize = 2048
+
+// glFloatsPerVertex is the size of a vertex: position, texture coordinates and color
+const glFloatsPerVertex = 8
+
+const glVertexShader = `#version 150 core
+in vec2 position;
+in vec2 texCoord;
+in vec4 color;
+uniform vec2 viewport;
+out vec2 fragTexCoord;
+out vec4 fragColor;
+void main() {
+	gl_Position = vec4(position.x / viewport.x * 2.0 - 1.0, 1.0 - position.y / viewport.y * 2.0, 0.0, 1.0);
+	fragTexCoord = texCoord;
+	fragColor = color;
+}
+` + "\x00"
+
+// The atlas holds premultiplied white glyphs, so multiplying by the color tints them
+const glFragmentShader = `#version 150 core
+in vec2 fragTexCoord;
+in vec4 fragColor;
+uniform sampler2D atlas;
+out vec4 outColor;
+void main() {
+	outColor = texture(atlas, fragTexCoord) * fragColor;
+}
+` + "\x00"
+
+// glSolidKey is the atlas entry of the white texel that solid rectangles are drawn with
+var glSolidKey = purfecterm.AtlasKey{}
+
+// glRenderer is the state of a widget's GL renderer
+// It is only used on the UI thread.
+type glRenderer struct {
+	overlay *gtk.Overlay // Holds the GL area with the drawing area over it
+	area    *gtk.GLArea
+
+	ready  bool  // GL is set up
+	err    error // Why GL couldn't be set up
+	active bool  // The last frame's cells were drawn by GL, so onDraw leaves them alone
+
+	program  uint32
+	vao      uint32
+	vbo      uint32
+	texture  uint32
+	viewport int32 // Uniform locations
+	sampler  int32
+
+	// Glyph atlas, for glyphs rendered in this font at this cell size and scale
+	atlas      *purfecterm.GlyphAtlas
+	fontFamily string
+	fontSize   int
+	cellW      int
+	cellH      int
+	scale      int
+
+	// Damage tracking: the last frame drawn and each row's vertices
+	prev     *purfecterm.GridFrame
+	rows     [][]float32
+	vertices []float32
+}
+
+// enableGL puts a GL area behind the drawing area, to draw the cells with GL
+func (w *Widget) enableGL() {
+	w.gl = &glRenderer{}
+	overlay, err := gtk.OverlayNew()
+	if err != nil {
+		w.gl.err = err
+		return
+	}
+	area, err := gtk.GLAreaNew()
+	if err != nil {
+		w.gl.err = err
+		return
+	}
+	area.SetRequiredVersion(3, 2)
+	area.SetHasDepthBuffer(false)
+	area.SetHasStencilBuffer(false)
+
+	r := w.gl
+	r.overlay, r.area = overlay, area
+	area.Connect("realize", func(area *gtk.GLArea) {
+		r.err = r.setup()
+	})
+	area.Connect("unrealize", func(area *gtk.GLArea) {
+		r.teardown()
+	})
+	area.Connect("render", w.onGLRender)
+
+	// Move the drawing area into the overlay, holding a reference while it is in neither
+	w.drawingArea.Ref()
+	w.innerBox.Remove(w.drawingArea)
+	overlay.Add(area)
+	overlay.AddOverlay(w.drawingArea)
+	w.drawingArea.Unref()
+	w.innerBox.PackStart(overlay, true, true, 0)
+	w.innerBox.ReorderChild(overlay, 0)
+	overlay.ShowAll()
+}
+
+// GLError returns why GPU rendering isn't available, or nil if it is or wasn't asked for
+func (w *Widget) GLError() error {
+	if w.gl == nil {
+		return nil
+	}
+	return w.gl.err
+}
+
+// setup compiles the shaders and creates the buffers and atlas texture
+func (r *glRenderer) setup() error {
+	r.area.MakeCurrent()
+	if err := r.area.GetError(); err != nil {
+		return err
+	}
+	if err := gl.Init(); err != nil {
+		return err
+	}
+
+	vertexShader, err := glCompileShader(glVertexShader, gl.VERTEX_SHADER)
+	if err != nil {
+		return err
+	}
+	fragmentShader, err := glCompileShader(glFragmentShader, gl.FRAGMENT_SHADER)
+	if err != nil {
+		gl.DeleteShader(vertexShader)
+		return err
+	}
+	r.program = gl.CreateProgram()
+	gl.AttachShader(r.program, vertexShader)
+	gl.AttachShader(r.program, fragmentShader)
+	gl.BindFragDataLocation(r.program, 0, gl.Str("outColor\x00"))
+	gl.LinkProgram(r.program)
+	gl.DeleteShader(vertexShader)
+	gl.DeleteShader(fragmentShader)
+	var status int32
+	gl.GetProgramiv(r.program, gl.LINK_STATUS, &status)
+	if status == gl.FALSE {
+		gl.DeleteProgram(r.program)
+		return fmt.Errorf("linking the terminal shaders failed")
+	}
+	r.viewport = gl.GetUniformLocation(r.program, gl.Str("viewport\x00"))
+	r.sampler = gl.GetUniformLocation(r.program, gl.Str("atlas\x00"))
+
+	gl.GenVertexArrays(1, &r.vao)
+	gl.BindVertexArray(r.vao)
+	gl.GenBuffers(1, &r.vbo)
+	gl.BindBuffer(gl.ARRAY_BUFFER, r.vbo)
+	stride := int32(glFloatsPerVertex * 4)
+	for _, attr := range []struct {
+		name   string
+		size   int32
+		offset uintptr
+	}{
+		{"position\x00", 2, 0},
+		{"texCoord\x00", 2, 2 * 4},
+		{"color\x00", 4, 4 * 4},
+	} {
+		location := uint32(gl.GetAttribLocation(r.program, gl.Str(attr.name)))
+		gl.EnableVertexAttribArray(location)
+		gl.VertexAttribPointerWithOffset(location, attr.size, gl.FLOAT, false, stride, attr.offset)
+	}
+
+	var maxSize int32
+	gl.GetIntegerv(gl.MAX_TEXTURE_SIZE, &maxSize)
+	size := min(int(maxSize), glAtlasSize)
+	gl.GenTextures(1, &r.texture)
+	gl.BindTexture(gl.TEXTURE_2D, r.texture)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA8, int32(size), int32(size), 0, gl.BGRA, gl.UNSIGNED_BYTE, nil)
+	r.atlas = purfecterm.NewGlyphAtlas(size, size)
+	r.resetAtlas()
+
+	r.ready = true
+	return nil
+}
+
+// teardown deletes the GL objects along with the context
+func (r *glRenderer) teardown() {
+	if !r.ready {
+		return
+	}
+	r.area.MakeCurrent()
+	gl.DeleteTextures(1, &r.texture)
+	gl.DeleteBuffers(1, &r.vbo)
+	gl.DeleteVertexArrays(1, &r.vao)
+	gl.DeleteProgram(r.program)
+	r.ready = false
+	r.active = false
+	r.prev = nil
+}
+
+// glCompileShader compiles a shader from its null-terminated source
+func glCompileShader(source string, shaderType uint32) (uint32, error) {
+	shader := gl.CreateShader(shaderType)
+	sources, free := gl.Strs(source)
+	gl.ShaderSource(shader, 1, sources, nil)
+	free()
+	gl.CompileShader(shader)
+
+	var status int32
+	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
+	if status == gl.FALSE {
+		var logLength int32
+		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
+		log := make([]byte, logLength+1)
+		gl.GetShaderInfoLog(shader, logLength, nil, &log[0])
+		gl.DeleteShader(shader)
+		return 0, fmt.Errorf("compiling a terminal shader failed: %s", strings.TrimRight(string(log), "\x00"))
+	}
+	return shader, nil
+}
+
+// resetAtlas empties the atlas, keeping only the white texel for solid rectangles
+func (r *glRenderer) resetAtlas() {
+	r.atlas.Reset()
+	rect, _ := r.atlas.Add(glSolidKey, 1, 1)
+	white := []byte{255, 255, 255, 255}
+	gl.BindTexture(gl.TEXTURE_2D, r.texture)
+	gl.PixelStorei(gl.UNPACK_ROW_LENGTH, 0)
+	gl.TexSubImage2D(gl.TEXTURE_2D, 0, int32(rect.X), int32(rect.Y), 1, 1, gl.BGRA, gl.UNSIGNED_BYTE, gl.Ptr(white))
+	r.prev = nil
+}
+
+// onGLRender draws the cells, or leaves them to onDraw when the frame can't be drawn
+// with GL. The GL area is drawn before the drawing area over it, so onDraw sees
+// whether this frame was drawn here.
+func (w *Widget) onGLRender(area *gtk.GLArea, ctx *gdk.GLContext) bool {
+	r := w.gl
+	w.mu.Lock()
+	scheme := w.scheme
+	fontFamily := w.fontFamily
+	fontSize := w.fontSize
+	cellW := w.charWidth
+	cellH := w.charHeight
+	opts := purfecterm.GridFrameOptions{
+		Scheme:        scheme,
+		BlinkPhase:    w.blinkPhase,
+		CursorBlinkOn: w.cursorBlinkOn,
+		Focused:       w.hasFocus,
+		HoverLink:     w.hoverLink,
+	}
+	w.mu.Unlock()
+
+	r.active = false
+	if !r.ready || !scheme.GPURendering || !scheme.SolidBackground() || w.smoothScrollShift() != 0 {
+		// Frames part way through a smooth scroll, and backgrounds with an opacity or
+		// image (see background.go), are drawn by onDraw
+		return true
+	}
+	frame, ok := purfecterm.BuildGridFrame(w.buffer, opts)
+	if !ok {
+		r.prev = nil
+		return true
+	}
+
+	scale := area.GetScaleFactor()
+	if fontFamily != r.fontFamily || fontSize != r.fontSize || cellW != r.cellW || cellH != r.cellH || scale != r.scale {
+		r.fontFamily, r.fontSize, r.cellW, r.cellH, r.scale = fontFamily, fontSize, cellW, cellH, scale
+		r.resetAtlas()
+	}
+
+	if !r.updateRows(w, frame) {
+		// The glyphs on screen don't fit in the atlas even after starting over
+		r.resetAtlas()
+		return true
+	}
+	r.prev = frame
+
+	// Gather the rows and the cursor into one buffer
+	r.vertices = r.vertices[:0]
+	for _, row := range r.rows {
+		r.vertices = append(r.vertices, row...)
+	}
+	r.vertices = r.appendCursor(r.vertices, frame)
+
+	alloc := area.GetAllocation()
+	bg := frame.Background
+	gl.ClearColor(float32(bg.R)/255, float32(bg.G)/255, float32(bg.B)/255, 1)
+	gl.Clear(gl.COLOR_BUFFER_BIT)
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.ONE, gl.ONE_MINUS_SRC_ALPHA)
+	gl.UseProgram(r.program)
+	gl.Uniform2f(r.viewport, float32(alloc.GetWidth()), float32(alloc.GetHeight()))
+	gl.Uniform1i(r.sampler, 0)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, r.texture)
+	gl.BindVertexArray(r.vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, r.vbo)
+	if len(r.vertices) > 0 {
+		gl.BufferData(gl.ARRAY_BUFFER, len(r.vertices)*4, gl.Ptr(r.vertices), gl.STREAM_DRAW)
+		gl.DrawArrays(gl.TRIANGLES, 0, int32(len(r.vertices)/glFloatsPerVertex))
+	}
+
+	r.active = true
+	return true
+}
+
+// updateRows rebuilds the vertices of the rows that changed since the last frame,
+// returning false if their glyphs don't fit in the atlas
+func (r *glRenderer) updateRows(w *Widget, frame *purfecterm.GridFrame) bool {
+	if len(r.rows) != frame.Rows {
+		r.rows = make([][]float32, frame.Rows)
+		r.prev = nil
+	}
+	damaged := frame.DamagedRows(r.prev)
+	for attempt := 0; attempt < 2; attempt++ {
+		full := false
+		for _, y := range damaged {
+			row, ok := r.buildRow(w, frame, y, r.rows[y][:0])
+			if !ok {
+				full = true
+				break
+			}
+			r.rows[y] = row
+		}
+		if !full {
+			return true
+		}
+		// Start the atlas over with just this frame's glyphs
+		r.resetAtlas()
+		damaged = frame.DamagedRows(nil)
+	}
+	return false
+}
+
+// buildRow appends the vertices of row y of the frame to verts, returning false
+// if a glyph doesn't fit in the atlas
+func (r *glRenderer) buildRow(w *Widget, frame *purfecterm.GridFrame, y int, verts []float32) ([]float32, bool) {
+	cellW, cellH := float32(r.cellW), float32(r.cellH)
+	cellY := float32(y) * cellH
+	var runs []purfecterm.LigatureRun
+	if frame.Runs != nil {
+		runs = frame.Runs[y]
+	}
+	for x, cell := range frame.Cells[y] {
+		cellX := float32(x)*cellW + terminalLeftPadding
+
+		if cell.Bg != frame.Background {
+			verts = r.appendRect(verts, cellX, cellY, cellW, cellH, cell.Bg)
+		}
+
+		// A ligature run is one atlas image as wide as its cells, drawn with its last
+		// cell so the backgrounds of the others are under it
+		text, width, textX := cell.Text, 1, cellX
+		if i := purfecterm.LigatureRunAt(runs, x); i >= 0 {
+			text, width = "", 0
+			if runs[i].End-1 == x {
+				text, width = runs[i].Text, runs[i].End-runs[i].Start
+				textX -= float32(width-1) * cellW
+			}
+		}
+		if text != "" {
+			key := purfecterm.AtlasKey{Text: text, Bold: cell.Bold, Italic: cell.Italic}
+			rect, ok := r.atlas.Lookup(key)
+			if !ok {
+				if rect, ok = r.atlas.Add(key, width*r.cellW*r.scale, r.cellH*r.scale); !ok {
+					return verts, false
+				}
+				r.uploadGlyph(w, key, rect)
+			}
+			verts = r.appendQuad(verts, textX, cellY, float32(width)*cellW, cellH, rect, cell.Fg)
+		}
+
+		if cell.Underline != purfecterm.UnderlineNone {
+			underlineY := cellY + cellH - 2
+			switch cell.Underline {
+			case purfecterm.UnderlineSingle:
+				verts = r.appendRect(verts, cellX, underlineY, cellW, 1, cell.UnderlineColor)
+			case purfecterm.UnderlineDouble:
+				verts = r.appendRect(verts, cellX, underlineY-2, cellW, 1, cell.UnderlineColor)
+				verts = r.appendRect(verts, cellX, underlineY+1, cellW, 1, cell.UnderlineColor)
+			case purfecterm.UnderlineCurly:
+				// Two cycles of a sine wave, drawn as dots along it
+				steps := max(int(cellW/2), 4)
+				for s := 0; s < steps; s++ {
+					t := float64(s) / float64(steps)
+					waveY := underlineY + float32(1.5*math.Sin(t*2*2*math.Pi))
+					verts = r.appendRect(verts, cellX+float32(t)*cellW, waveY, cellW/float32(steps)+0.5, 1, cell.UnderlineColor)
+				}
+			case purfecterm.UnderlineDotted:
+				for dotX := cellX; dotX < cellX+cellW; dotX += 3 {
+					verts = r.appendRect(verts, dotX, underlineY, 1, 1, cell.UnderlineColor)
+				}
+			case purfecterm.UnderlineDashed:
+				for dashX := cellX; dashX < cellX+cellW; dashX += 6 {
+					verts = r.appendRect(verts, dashX, underlineY, min(4, cellX+cellW-dashX), 1, cell.UnderlineColor)
+				}
+			}
+		}
+		if cell.Strikethrough {
+			verts = r.appendRect(verts, cellX, cellY+cellH*0.4, cellW, 1, cell.Fg)
+		}
+		if cell.Overline {
+			verts = r.appendRect(verts, cellX, cellY, cellW, 1, cell.Fg)
+		}
+	}
+	return verts, true
+}
+
+// appendCursor appends the cursor's vertices: its shape, or the outline of an
+// unfocused block (a focused block is drawn by its cell's swapped colors)
+func (r *glRenderer) appendCursor(verts []float32, frame *purfecterm.GridFrame) []float32 {
+	c := frame.Cursor
+	if !c.Visible {
+		return verts
+	}
+	cellW, cellH := float32(r.cellW), float32(r.cellH)
+	x := float32(c.X)*cellW + terminalLeftPadding
+	y := float32(c.Y) * cellH
+	switch c.Shape {
+	case 0:
+		if !c.Focused {
+			verts = r.appendRect(verts, x, y, cellW, 1, c.Color)
+			verts = r.appendRect(verts, x, y+cellH-1, cellW, 1, c.Color)
+			verts = r.appendRect(verts, x, y, 1, cellH, c.Color)
+			verts = r.appendRect(verts, x+cellW-1, y, 1, cellH, c.Color)
+		}
+	case 1:
+		thickness := cellH / 4
+		if !c.Focused {
+			thickness = cellH / 6
+		}
+		verts = r.appendRect(verts, x, y+cellH-thickness, cellW, thickness, c.Color)
+	case 2:
+		thickness := float32(2)
+		if !c.Focused {
+			thickness = 1
+		}
+		verts = r.appendRect(verts, x, y, thickness, cellH, c.Color)
+	}
+	return verts
+}
+
+// appendRect appends a solid rectangle, drawn with the atlas's white texel
+func (r *glRenderer) appendRect(verts []float32, x, y, width, height float32, color purfecterm.Color) []float32 {
+	rect, _ := r.atlas.Lookup(glSolidKey)
+	return r.appendQuad(verts, x, y, width, height, rect, color)
+}
+
+// appendQuad appends two triangles drawing the atlas rectangle at x, y in the color
+func (r *glRenderer) appendQuad(verts []float32, x, y, width, height float32, rect purfecterm.AtlasRect, color purfecterm.Color) []float32 {
+	atlasW, atlasH := r.atlas.Size()
+	u0 := float32(rect.X) / float32(atlasW)
+	v0 := float32(rect.Y) / float32(atlasH)
+	u1 := float32(rect.X+rect.W) / float32(atlasW)
+	v1 := float32(rect.Y+rect.H) / float32(atlasH)
+	red, green, blue := float32(color.R)/255, float32(color.G)/255, float32(color.B)/255
+	x1, y1 := x+width, y+height
+	return append(verts,
+		x, y, u0, v0, red, green, blue, 1,
+		x1, y, u1, v0, red, green, blue, 1,
+		x, y1, u0, v1, red, green, blue, 1,
+		x1, y, u1, v0, red, green, blue, 1,
+		x1, y1, u1, v1, red, green, blue, 1,
+		x, y1, u0, v1, red, green, blue, 1,
+	)
+}
+
+// uploadGlyph renders a glyph, or a ligature run's text, in white with Pango and copies
+// it to its atlas rectangle. It is fitted to its cells as onDraw fits text: squeezed if
+// wider, centered if narrower.
+func (r *glRenderer) uploadGlyph(w *Widget, key purfecterm.AtlasKey, rect purfecterm.AtlasRect) {
+	surface := cairo.CreateImageSurface(cairo.FORMAT_ARGB32, rect.W, rect.H)
+	cr := cairo.Create(surface)
+	cr.Scale(float64(r.scale), float64(r.scale))
+
+	charFont := w.getFontForCharacter([]rune(key.Text)[0], r.fontFamily, r.fontSize)
+	actualWidth := float64(pangoTextWidth(cr, key.Text, charFont, r.fontSize, key.Bold, key.Italic))
+	cellW := float64(rect.W / r.scale)
+	if actualWidth > cellW {
+		cr.Scale(cellW/actualWidth, 1)
+	} else {
+		cr.Translate((cellW-actualWidth)/2, 0)
+	}
+	pangoRenderText(cr, key.Text, charFont, r.fontSize, key.Bold, key.Italic, 1, 1, 1)
+	surface.Flush()
+
+	// Cairo's ARGB32 is premultiplied native-endian words, which is BGRA in memory here
+	stride := cairo.FormatStrideForWidth(cairo.FORMAT_ARGB32, rect.W)
+	gl.BindTexture(gl.TEXTURE_2D, r.texture)
+	gl.PixelStorei(gl.UNPACK_ROW_LENGTH, int32(stride/4))
+	gl.TexSubImage2D(gl.TEXTURE_2D, 0, int32(rect.X), int32(rect.Y), int32(rect.W), int32(rect.H),
+		gl.BGRA, gl.UNSIGNED_BYTE, surface.GetData())
+	gl.PixelStorei(gl.UNPACK_ROW_LENGTH, 0)
+	cr.Close()
+	surface.Close()
+}
@@ -0,0 +1,153 @@
+package purfectermgtk
+
+/*
+#cgo pkg-config: gtk+-3.0
+#include <stdlib.h>
+#include <gtk/gtk.h>
+*/
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/gotk3/gotk3/cairo"
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/phroun/pawscript/src/pkg/purfecterm"
+)
+
+// Input methods
+// Keys go to a GtkIMMulticontext (the input method the user has chosen, such as
+// IBus or Fcitx) before the terminal sees them. Text the input method commits is
+// sent like typed text, and the text being composed (the preedit) is drawn over the
+// cells at the cursor, underlined, until it is committed or cancelled. The cursor
+// location is passed back so the candidate window opens next to it.
+
+// imState is the widget's input method context and its preedit
+type imState struct {
+	context *glib.Object // The GtkIMMulticontext
+
+	preedit       string
+	preeditCursor int // The input method's cursor, in characters into preedit
+}
+
+// initInputMethod creates the input method context and connects it to the drawing area
+func (w *Widget) initInputMethod() {
+	ctx := C.gtk_im_multicontext_new()
+	w.im.context = glib.Take(unsafe.Pointer(ctx))
+	C.g_object_unref(C.gpointer(ctx)) // Take holds its own reference
+
+	w.im.context.Connect("commit", func(_ *glib.Object, text string) {
+		w.onIMCommit(text)
+	})
+	w.im.context.Connect("preedit-changed", func() {
+		w.updatePreedit()
+	})
+	w.im.context.Connect("preedit-end", func() {
+		w.updatePreedit()
+	})
+
+	w.drawingArea.Connect("realize", func(da *gtk.DrawingArea) {
+		if win, err := da.GetWindow(); err == nil {
+			C.gtk_im_context_set_client_window(w.imContext(), (*C.GdkWindow)(unsafe.Pointer(win.Native())))
+		}
+	})
+	w.drawingArea.Connect("unrealize", func(da *gtk.DrawingArea) {
+		C.gtk_im_context_set_client_window(w.imContext(), nil)
+	})
+}
+
+// imContext returns the native input method context
+func (w *Widget) imContext() *C.GtkIMContext {
+	return (*C.GtkIMContext)(unsafe.Pointer(w.im.context.Native()))
+}
+
+// imFilterKey gives a key event to the input method, returning true if it used it.
+// Keys with shortcut modifiers are only given to it while it is composing, so
+// they still reach the terminal otherwise.
+func (w *Widget) imFilterKey(ev *gdk.Event, shortcut bool) bool {
+	if shortcut && w.im.preedit == "" {
+		return false
+	}
+	return C.gtk_im_context_filter_keypress(w.imContext(), (*C.GdkEventKey)(unsafe.Pointer(ev.Native()))) != 0
+}
+
+// onKeyRelease gives key releases to the input method, which some need to compose
+func (w *Widget) onKeyRelease(da *gtk.DrawingArea, ev *gdk.Event) bool {
+	return w.imFilterKey(ev, false)
+}
+
+// imFocus tells the input method whether the terminal has keyboard focus
+func (w *Widget) imFocus(focused bool) {
+	if focused {
+		C.gtk_im_context_focus_in(w.imContext())
+	} else {
+		C.gtk_im_context_focus_out(w.imContext())
+	}
+}
+
+// onIMCommit sends text the input method has composed, as typed text is sent
+func (w *Widget) onIMCommit(text string) {
+	w.mu.Lock()
+	onInput := w.onInput
+	keyMap := w.keyMap
+	w.mu.Unlock()
+
+	if onInput == nil || text == "" {
+		return
+	}
+	w.buffer.NotifyKeyboardActivity()
+	if data := keyMap.Translate([]byte(text)); len(data) > 0 {
+		onInput(data)
+	}
+}
+
+// updatePreedit fetches the text being composed and redraws it
+func (w *Widget) updatePreedit() {
+	var str *C.gchar
+	var cursorPos C.gint
+	C.gtk_im_context_get_preedit_string(w.imContext(), &str, nil, &cursorPos)
+	w.im.preedit = C.GoString((*C.char)(str))
+	w.im.preeditCursor = int(cursorPos)
+	C.g_free(C.gpointer(str))
+	w.drawingArea.QueueDraw()
+}
+
+// imCursorLocation tells the input method where the cursor is drawn, so it can place
+// its candidate window there
+func (w *Widget) imCursorLocation(x, y, width, height int) {
+	rect := C.GdkRectangle{x: C.int(x), y: C.int(y), width: C.int(width), height: C.int(height)}
+	C.gtk_im_context_set_cursor_location(w.imContext(), &rect)
+}
+
+// drawPreedit draws the text being composed over the cells at the cursor: on the
+// terminal background, underlined, with a bar at the input method's cursor
+func (w *Widget) drawPreedit(cr *cairo.Context, x, y, cellH float64, fontFamily string, fontSize int, fg, bg purfecterm.Color) {
+	text := w.im.preedit
+	if text == "" {
+		return
+	}
+	width := float64(pangoTextWidth(cr, text, fontFamily, fontSize, false, false))
+
+	cr.SetSourceRGB(float64(bg.R)/255.0, float64(bg.G)/255.0, float64(bg.B)/255.0)
+	cr.Rectangle(x, y, width, cellH)
+	cr.Fill()
+
+	fgR, fgG, fgB := float64(fg.R)/255.0, float64(fg.G)/255.0, float64(fg.B)/255.0
+	cr.Save()
+	cr.Translate(x, y)
+	pangoRenderText(cr, text, fontFamily, fontSize, false, false, fgR, fgG, fgB)
+	cr.Restore()
+
+	cr.SetSourceRGB(fgR, fgG, fgB)
+	cr.Rectangle(x, y+cellH-2, width, 1)
+	cr.Fill()
+
+	runes := []rune(text)
+	if w.im.preeditCursor >= 0 && w.im.preeditCursor <= len(runes) {
+		cursorX := x + float64(pangoTextWidth(cr, string(runes[:w.im.preeditCursor]), fontFamily, fontSize, false, false))
+		cr.Rectangle(cursorX, y, 1, cellH)
+		cr.Fill()
+	}
+}
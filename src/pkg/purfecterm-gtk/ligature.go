@@ -0,0 +1,59 @@
+package purfectermgtk
+
+import (
+	"github.com/gotk3/gotk3/cairo"
+	"github.com/phroun/pawscript/src/pkg/purfecterm"
+)
+
+// Ligatures
+// With ColorScheme.Ligatures set, onDraw draws runs of cells that may join (see
+// purfecterm/ligature.go) as one Pango layout, so the font's ligatures and
+// contextual alternates are applied. The run is drawn with its last cell, after
+// the backgrounds of all its cells, fitted to the run's width. Runs are only found
+// on normal lines; cells with custom glyphs, blink, flex width or the cursor are
+// left out so they keep being drawn alone.
+
+// ligatureRuns returns the ligature runs of visible row y, by screen column, for
+// the cols columns from horizOffset. cursorX is the screen column the cursor is
+// drawn in on this row, or -1.
+func (w *Widget) ligatureRuns(y, cols, horizOffset, cursorX int) []purfecterm.LigatureRun {
+	cells := make([]purfecterm.Cell, cols)
+	texts := make([]string, cols)
+	for x := 0; x < cols; x++ {
+		cell := w.buffer.GetVisibleCell(x, y)
+		cells[x] = cell
+		if x == cursorX || cell.Blink || cell.FlexWidth || w.buffer.GetGlyph(cell.Char) != nil {
+			continue
+		}
+		texts[x] = cell.String()
+	}
+	return purfecterm.FindLigatureRuns(texts, func(a, b int) bool {
+		ca, cb := &cells[a], &cells[b]
+		return ca.Foreground == cb.Foreground && ca.Background == cb.Background &&
+			ca.Bold == cb.Bold && ca.Italic == cb.Italic && ca.Reverse == cb.Reverse &&
+			w.buffer.GetSearchHighlight(a+horizOffset, y) == w.buffer.GetSearchHighlight(b+horizOffset, y) &&
+			w.buffer.IsInSelection(a+horizOffset, y) == w.buffer.IsInSelection(b+horizOffset, y)
+	})
+}
+
+// drawLigatureRun draws a run's text in one go over its cells, which start at runX
+// and are cellW wide, centered if narrower than the run and squeezed if wider
+func (w *Widget) drawLigatureRun(cr *cairo.Context, run purfecterm.LigatureRun, runX, cellW, textY float64, fontFamily string, fontSize int, bold, italic bool, fg purfecterm.Color, horizScale, vertScale float64) {
+	runFont := w.getFontForCharacter(rune(run.Text[0]), fontFamily, fontSize)
+	actualWidth := float64(pangoTextWidth(cr, run.Text, runFont, fontSize, bold, italic))
+	targetWidth := float64(run.End-run.Start) * cellW / horizScale // Unscaled target width
+	textScaleX := horizScale
+	xOff := 0.0
+	if actualWidth > targetWidth {
+		textScaleX *= targetWidth / actualWidth
+	} else {
+		xOff = (targetWidth - actualWidth) / 2.0 * horizScale
+	}
+
+	cr.Save()
+	cr.Translate(runX+xOff, textY)
+	cr.Scale(textScaleX, vertScale)
+	pangoRenderText(cr, run.Text, runFont, fontSize, bold, italic,
+		float64(fg.R)/255.0, float64(fg.G)/255.0, float64(fg.B)/255.0)
+	cr.Restore()
+}
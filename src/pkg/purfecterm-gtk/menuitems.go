@@ -0,0 +1,56 @@
+package purfectermgtk
+
+import "github.com/gotk3/gotk3/gtk"
+
+// Context menu items
+// The host builds the terminal's context menu, and calls PrepareContextMenu before
+// opening it to put the entries the embedder added (see purfecterm/menuitems.go)
+// at its end, after a separator. They are made again each time, so entries added
+// or removed since appear, enabled as their predicates say.
+
+// PrepareContextMenu puts the entries added to the context menu (see
+// ContextMenuItems) at the end of menu, replacing those it put there before.
+// newItem makes each entry, so it can match the host's own; nil makes plain ones.
+func (t *Terminal) PrepareContextMenu(menu *gtk.Menu, newItem func(label string, activate func()) *gtk.MenuItem) {
+	if t.menuEntries == nil {
+		t.menuEntries = make(map[*gtk.Menu][]*gtk.Widget)
+	}
+	old, seen := t.menuEntries[menu]
+	for _, entry := range old {
+		entry.Destroy()
+	}
+	if !seen {
+		menu.Connect("destroy", func() {
+			delete(t.menuEntries, menu)
+		})
+	}
+
+	var entries []*gtk.Widget
+	items := t.menuItems.Items()
+	if len(items) > 0 {
+		if sep, err := gtk.SeparatorMenuItemNew(); err == nil {
+			menu.Append(sep)
+			entries = append(entries, sep.ToWidget())
+		}
+	}
+	for _, item := range items {
+		activate := item.Activate
+		if activate == nil {
+			activate = func() {}
+		}
+		var entry *gtk.MenuItem
+		if newItem != nil {
+			entry = newItem(item.Label, activate)
+		} else if entry, _ = gtk.MenuItemNewWithLabel(item.Label); entry != nil {
+			entry.Connect("activate", activate)
+		}
+		if entry == nil {
+			continue
+		}
+		entry.SetSensitive(item.IsEnabled())
+		menu.Append(entry)
+		entries = append(entries, entry.ToWidget())
+	}
+	menu.ShowAll()
+	t.menuEntries[menu] = entries
+}
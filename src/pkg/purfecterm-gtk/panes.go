@@ -0,0 +1,153 @@
+package purfectermgtk
+
+import (
+	"runtime"
+
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/phroun/pawscript/src/pkg/purfecterm"
+)
+
+// Panes
+// When a program splits the terminal (OSC 7005, see purfecterm/panes.go) each pane
+// gets a widget of its own, and the terminal's container lays them out with GtkPaned
+// splitters following the buffer's pane layout. The layout is rebuilt whenever it
+// changes; the pane widgets are kept and moved into the new splitters. The panes share
+// the terminal's font, colors and callbacks, so their input goes to the same program.
+
+// setupPanes creates the container holding the pane layout
+func (t *Terminal) setupPanes() error {
+	container, err := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 0)
+	if err != nil {
+		return err
+	}
+	container.PackStart(t.widget.Box(), true, true, 0)
+	t.container = container
+	t.panes = make(map[int]*Widget)
+
+	buffer := t.widget.Buffer()
+	t.widget.SetFocusCallback(func() {
+		buffer.FocusPane(0)
+	})
+	buffer.SetPaneCallback(func(layoutChanged bool) {
+		glib.IdleAdd(func() {
+			if layoutChanged {
+				t.updatePanes()
+			}
+			if w := t.paneWidget(buffer.FocusedPane()); w != nil {
+				w.GrabFocus()
+			}
+		})
+	})
+	return nil
+}
+
+// paneWidget returns the widget of pane id, or nil if there is no such pane
+func (t *Terminal) paneWidget(id int) *Widget {
+	if id == 0 {
+		return t.widget
+	}
+	return t.panes[id]
+}
+
+// newPaneWidget creates the widget for a pane, set up like the terminal's own
+func (t *Terminal) newPaneWidget(id int, buffer *purfecterm.Buffer) *Widget {
+	w, err := NewWidgetForBuffer(buffer)
+	if err != nil {
+		return nil
+	}
+	w.SetZoom(t.widget.GetZoom())
+	w.SetFont(t.options.FontFamily, t.options.FontSize)
+	w.SetFontFallbacks(t.unicodeFont, t.cjkFont)
+	w.SetColorScheme(t.options.Scheme)
+	w.SetInputCallback(t.onInput)
+	w.SetKeyMap(t.keyMap)
+	w.SetLinkClickCallback(t.onLinkClick)
+	w.SetFileDropCallback(t.onFileDrop)
+	w.SetZoomCallback(t.zoomed)
+	root := t.widget.Buffer()
+	w.SetFocusCallback(func() {
+		root.FocusPane(id)
+	})
+	return w
+}
+
+// forEachPane calls fn with the widgets of the panes other than 0
+func (t *Terminal) forEachPane(fn func(w *Widget)) {
+	for _, w := range t.panes {
+		fn(w)
+	}
+}
+
+// updatePanes rebuilds the splitters for the current pane layout
+func (t *Terminal) updatePanes() {
+	layout := t.widget.Buffer().PaneLayout()
+	width := t.container.GetAllocatedWidth()
+	height := t.container.GetAllocatedHeight()
+
+	// Take the widgets out of the old splitters, which are then destroyed
+	for _, paned := range t.paneds {
+		for _, get := range []func() (gtk.IWidget, error){paned.GetChild1, paned.GetChild2} {
+			if child, err := get(); err == nil && child != nil {
+				paned.Remove(child)
+			}
+		}
+	}
+	t.container.GetChildren().Foreach(func(item interface{}) {
+		if child, ok := item.(gtk.IWidget); ok {
+			t.container.Remove(child)
+		}
+	})
+	for _, paned := range t.paneds {
+		paned.Destroy()
+	}
+	t.paneds = nil
+
+	// Destroy the widgets of closed panes
+	for id, w := range t.panes {
+		if layout == nil || t.widget.Buffer().PaneBuffer(id) == nil {
+			w.Destroy()
+			delete(t.panes, id)
+		}
+	}
+
+	if layout == nil {
+		t.container.PackStart(t.widget.Box(), true, true, 0)
+	} else {
+		t.container.PackStart(t.buildPanes(layout, width, height), true, true, 0)
+	}
+	t.container.ShowAll()
+	runtime.GC() // Run the finalizers of the old splitters now (see docs/CRITICAL-gotk3-safety-issues.md)
+}
+
+// buildPanes returns the widget for a node of the pane layout, given the space it has
+func (t *Terminal) buildPanes(node *purfecterm.PaneNode, width, height int) gtk.IWidget {
+	if !node.IsSplit() {
+		w := t.paneWidget(node.ID)
+		if w == nil {
+			w = t.newPaneWidget(node.ID, node.Buffer)
+			t.panes[node.ID] = w
+		}
+		return w.Box()
+	}
+
+	orientation := gtk.ORIENTATION_HORIZONTAL
+	firstWidth, firstHeight := int(float64(width)*node.Ratio), height
+	secondWidth, secondHeight := width-firstWidth, height
+	if node.Direction == purfecterm.PaneStacked {
+		orientation = gtk.ORIENTATION_VERTICAL
+		firstWidth, firstHeight = width, int(float64(height)*node.Ratio)
+		secondWidth, secondHeight = width, height-firstHeight
+	}
+
+	paned, _ := gtk.PanedNew(orientation)
+	paned.Pack1(t.buildPanes(node.First, firstWidth, firstHeight), true, true)
+	paned.Pack2(t.buildPanes(node.Second, secondWidth, secondHeight), true, true)
+	if node.Direction == purfecterm.PaneStacked {
+		paned.SetPosition(firstHeight)
+	} else {
+		paned.SetPosition(firstWidth)
+	}
+	t.paneds = append(t.paneds, paned)
+	return paned
+}
@@ -0,0 +1,81 @@
+package purfectermgtk
+
+import (
+	"math"
+
+	"github.com/gotk3/gotk3/cairo"
+	"github.com/phroun/pawscript/src/pkg/purfecterm"
+)
+
+// Scroll indicator
+// While the view is scrolled back, onDraw shows how far back at the bottom right
+// and, once more output has arrived, a "N new lines" pill at the bottom center
+// (see purfecterm/scrollindicator.go). Clicking the pill jumps to the bottom.
+
+// scrollBadgePadding is the space around the indicator's and the pill's text
+const scrollBadgePadding = 8.0
+
+// pillRect is where the new lines pill was last drawn (zero Width for nowhere)
+type pillRect struct {
+	X, Y, Width, Height float64
+}
+
+// drawScrollIndicator draws the scroll indicator and new lines pill over the cells
+// of a width by height drawing area
+func (w *Widget) drawScrollIndicator(cr *cairo.Context, width, height float64, fontFamily string, fontSize, lineHeight int) {
+	offset := w.buffer.GetEffectiveScrollOffset()
+	newLines := w.newOutput.Update(offset, w.buffer.GetLinesScrolledOff())
+	w.newLinesPill = pillRect{}
+	if offset <= 0 {
+		return
+	}
+
+	badgeH := float64(lineHeight) + scrollBadgePadding/2
+	bottom := height - badgeH - scrollBadgePadding
+
+	text := purfecterm.ScrollIndicatorText(offset)
+	textW := float64(pangoTextWidth(cr, text, fontFamily, fontSize, false, false))
+	badgeW := textW + 2*scrollBadgePadding
+	drawBadge(cr, width-badgeW-scrollBadgePadding, bottom, badgeW, badgeH, text, fontFamily, fontSize, 0.75)
+
+	if pill := purfecterm.NewLinesText(newLines); pill != "" {
+		textW = float64(pangoTextWidth(cr, pill, fontFamily, fontSize, true, false))
+		pillW := textW + 3*scrollBadgePadding
+		x := math.Round((width - pillW) / 2)
+		drawBadge(cr, x, bottom, pillW, badgeH, pill, fontFamily, fontSize, 0.9)
+		w.newLinesPill = pillRect{X: x, Y: bottom, Width: pillW, Height: badgeH}
+	}
+}
+
+// drawBadge draws text centered in a dark rounded box of the given opacity
+func drawBadge(cr *cairo.Context, x, y, width, height float64, text, fontFamily string, fontSize int, alpha float64) {
+	r := height / 2
+	cr.NewPath()
+	cr.Arc(x+r, y+r, r, math.Pi/2, 3*math.Pi/2)
+	cr.Arc(x+width-r, y+r, r, -math.Pi/2, math.Pi/2)
+	cr.ClosePath()
+	cr.SetSourceRGBA(0.15, 0.15, 0.15, alpha)
+	cr.Fill()
+
+	bold := alpha > 0.8
+	textW := float64(pangoTextWidth(cr, text, fontFamily, fontSize, bold, false))
+	cr.Save()
+	cr.Translate(math.Round(x+(width-textW)/2), y+scrollBadgePadding/4)
+	pangoRenderText(cr, text, fontFamily, fontSize, bold, false, 1, 1, 1)
+	cr.Restore()
+}
+
+// newLinesPillAt returns whether the new lines pill is at a point of the drawing area
+func (w *Widget) newLinesPillAt(x, y float64) bool {
+	p := w.newLinesPill
+	return p.Width > 0 && x >= p.X && x < p.X+p.Width && y >= p.Y && y < p.Y+p.Height
+}
+
+// scrollToBottom scrolls the view back to the newest output
+func (w *Widget) scrollToBottom() {
+	w.buffer.SetScrollOffset(0)
+	w.buffer.NotifyManualVertScroll() // User initiated scroll
+	w.syncSmoothScroll()
+	w.updateScrollbar()
+	w.drawingArea.QueueDraw()
+}
@@ -0,0 +1,147 @@
+package purfectermgtk
+
+/*
+#cgo pkg-config: gtk+-3.0
+#include <gtk/gtk.h>
+
+// Whether a scroll event comes from a touchpad or touch screen, rather than a wheel
+static int scroll_is_touch(GdkEvent *ev) {
+    GdkDevice *device = gdk_event_get_source_device(ev);
+    if (!device) return 0;
+    GdkInputSource source = gdk_device_get_source(device);
+    return source == GDK_SOURCE_TOUCHPAD || source == GDK_SOURCE_TOUCHSCREEN;
+}
+
+// Whether a scroll event marks the fingers lifting
+static int scroll_is_stop(GdkEvent *ev) {
+    return gdk_event_is_scroll_stop_event(ev);
+}
+*/
+import "C"
+
+import (
+	"time"
+	"unsafe"
+
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/glib"
+)
+
+// Smooth scrolling
+// With smooth scroll events, the vertical scroll position moves by pixels (see
+// purfecterm/smoothscroll.go): wheel clicks glide three lines, touchpads follow the
+// fingers and coast when they lift. onDraw draws the cells shifted up by the part of
+// a line scrolled, and the GL renderer leaves such frames to it. Horizontal
+// scrolling, and scrolling reported to an application tracking the mouse, still go
+// by whole wheel clicks, gathered from the smooth deltas.
+
+// wheelScrollLines is how many lines a wheel click scrolls
+const wheelScrollLines = 3
+
+// touchpadScrollPixels is how many pixels one smooth scroll unit from a touchpad is
+const touchpadScrollPixels = 10.0
+
+// smoothScrollInterval is the frame time of gliding and coasting, in milliseconds
+const smoothScrollInterval = 16
+
+// onSmoothScroll handles a smooth scroll event
+func (w *Widget) onSmoothScroll(ev *gdk.Event, scroll *gdk.EventScroll) {
+	state := scroll.State()
+	dx, dy := scroll.DeltaX(), scroll.DeltaY()
+	native := (*C.GdkEvent)(unsafe.Pointer(ev.Native()))
+	touch := C.scroll_is_touch(native) != 0
+
+	if state&gdk.SHIFT_MASK != 0 && dx == 0 {
+		dx, dy = dy, 0
+	}
+	if w.mouseReporting(state) || dx != 0 {
+		// Gather whole clicks for horizontal scrolling or the application
+		w.scrollClicksX += dx
+		w.scrollClicksY += dy
+		for ; w.scrollClicksX >= 1; w.scrollClicksX-- {
+			w.scrollStep(gdk.SCROLL_RIGHT, state, scroll.X(), scroll.Y())
+		}
+		for ; w.scrollClicksX <= -1; w.scrollClicksX++ {
+			w.scrollStep(gdk.SCROLL_LEFT, state, scroll.X(), scroll.Y())
+		}
+		if w.mouseReporting(state) {
+			for ; w.scrollClicksY >= 1; w.scrollClicksY-- {
+				w.scrollStep(gdk.SCROLL_DOWN, state, scroll.X(), scroll.Y())
+			}
+			for ; w.scrollClicksY <= -1; w.scrollClicksY++ {
+				w.scrollStep(gdk.SCROLL_UP, state, scroll.X(), scroll.Y())
+			}
+			return
+		}
+	}
+
+	rowHeight := w.rowHeight()
+	w.syncSmoothScroll()
+	now := time.Now()
+	switch {
+	case touch && C.scroll_is_stop(native) != 0:
+		w.smooth.Release(now)
+	case touch:
+		w.smooth.Drag(-dy*touchpadScrollPixels, now)
+	case dy != 0:
+		w.smooth.Glide(-dy * wheelScrollLines * float64(rowHeight))
+	}
+	w.applySmoothScroll()
+	w.startSmoothScrollTimer()
+}
+
+// rowHeight returns the height of a row on screen, with the screen's vertical scale
+func (w *Widget) rowHeight() int {
+	w.mu.Lock()
+	charHeight := w.charHeight
+	w.mu.Unlock()
+	return max(int(float64(charHeight)*w.buffer.GetVerticalScale()), 1)
+}
+
+// syncSmoothScroll takes the scroll position from the buffer if it has scrolled by
+// other means
+func (w *Widget) syncSmoothScroll() {
+	w.smooth.Sync(w.buffer.GetScrollOffset(), w.buffer.GetMaxScrollOffset(), w.rowHeight())
+}
+
+// applySmoothScroll scrolls the buffer to the line the smooth position shows
+func (w *Widget) applySmoothScroll() {
+	offset := w.buffer.GetScrollOffset()
+	if lines := w.smooth.Lines(); lines != offset {
+		w.buffer.SetScrollOffset(lines)
+		if lines < offset {
+			// Snap to 0 if in the magnetic zone, as wheel clicks do
+			w.buffer.NormalizeScrollOffset()
+		}
+		w.buffer.NotifyManualVertScroll() // User initiated scroll
+		w.updateScrollbar()
+		w.syncSmoothScroll()
+	}
+	w.drawingArea.QueueDraw()
+}
+
+// startSmoothScrollTimer runs the glide or coast, if one is under way, until it stops
+func (w *Widget) startSmoothScrollTimer() {
+	if w.smoothScrollTimerID != 0 || !w.smooth.Moving() {
+		return
+	}
+	w.smoothScrollTimerID = glib.TimeoutAdd(smoothScrollInterval, func() bool {
+		w.syncSmoothScroll()
+		moving := w.smooth.Step(time.Now())
+		w.applySmoothScroll()
+		if !moving {
+			w.smoothScrollTimerID = 0
+		}
+		return moving
+	})
+}
+
+// smoothScrollShift returns how many pixels higher the cells are drawn for the part
+// of a line scrolled (0 while the magnetic zone holds the screen in place)
+func (w *Widget) smoothScrollShift() int {
+	offset := w.buffer.GetScrollOffset()
+	if w.buffer.GetEffectiveScrollOffset() != offset {
+		return 0
+	}
+	return w.smooth.Shift(offset)
+}
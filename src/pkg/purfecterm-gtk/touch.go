@@ -0,0 +1,136 @@
+package purfectermgtk
+
+/*
+#include <gtk/gtk.h>
+
+// Where the fingers of a gesture are, as the midpoint of their bounding box
+static void gesture_center(GtkGesture *gesture, double *x, double *y) {
+    if (!gtk_gesture_get_bounding_box_center(gesture, x, y)) {
+        *x = 0;
+        *y = 0;
+    }
+}
+
+// How much the fingers' spread has scaled since the gesture began
+static double gesture_scale(GtkGesture *gesture) {
+    return gtk_gesture_zoom_get_scale_delta(GTK_GESTURE_ZOOM(gesture));
+}
+
+// A long press that only touch screens make, leaving the mouse button alone
+static GtkGesture *touch_long_press_new(GtkWidget *widget) {
+    GtkGesture *gesture = gtk_gesture_long_press_new(widget);
+    gtk_gesture_single_set_touch_only(GTK_GESTURE_SINGLE(gesture), TRUE);
+    return gesture;
+}
+
+// Sends the widget a right button press at x, y, which goes up to whoever opens
+// the context menu
+static void send_right_click(GtkWidget *widget, double x, double y) {
+    GdkWindow *window = gtk_widget_get_window(widget);
+    if (!window) return;
+    GdkEvent *ev = gdk_event_new(GDK_BUTTON_PRESS);
+    ev->button.window = g_object_ref(window);
+    ev->button.send_event = TRUE;
+    ev->button.time = gtk_get_current_event_time();
+    ev->button.x = x;
+    ev->button.y = y;
+    int ox = 0, oy = 0;
+    gdk_window_get_origin(window, &ox, &oy);
+    ev->button.x_root = ox + x;
+    ev->button.y_root = oy + y;
+    ev->button.button = 3;
+    GdkSeat *seat = gdk_display_get_default_seat(gdk_window_get_display(window));
+    if (seat) gdk_event_set_device(ev, gdk_seat_get_pointer(seat));
+    gtk_propagate_event(widget, ev);
+    gdk_event_free(ev);
+}
+*/
+import "C"
+
+import (
+	"time"
+	"unsafe"
+
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/glib"
+	"github.com/phroun/pawscript/src/pkg/purfecterm"
+)
+
+// Touch gestures
+// Touch screens send the drawing area touch events, which GTK turns into mouse
+// events for the first finger, so one finger selects as the mouse does. Two
+// fingers are followed by a zoom gesture, which scrolls or zooms the font (see
+// purfecterm/touch.go), and a long press sends a right click, which opens the
+// context menu wherever the host opens it.
+
+// touchGestures is the drawing area's gestures, kept with the widget so they live
+// as long as it does
+type touchGestures struct {
+	zoom      *glib.Object
+	longPress *glib.Object
+	twoFinger purfecterm.TwoFingerGesture
+}
+
+// initTouch sets up the touch gestures on the drawing area
+func (w *Widget) initTouch() {
+	w.drawingArea.AddEvents(int(gdk.TOUCH_MASK))
+	area := (*C.GtkWidget)(unsafe.Pointer(w.drawingArea.Native()))
+
+	zoom := C.gtk_gesture_zoom_new(area)
+	w.touch.zoom = glib.Take(unsafe.Pointer(zoom))
+	w.touch.zoom.Connect("begin", func() {
+		// The first finger has started a selection; two fingers don't select
+		w.cancelTouchSelection()
+		var x, y C.double
+		C.gesture_center(zoom, &x, &y)
+		w.syncSmoothScroll()
+		w.touch.twoFinger.Begin(float64(y), w.GetZoom())
+	})
+	w.touch.zoom.Connect("update", func() {
+		var x, y C.double
+		C.gesture_center(zoom, &x, &y)
+		scroll, z := w.touch.twoFinger.Update(float64(y), float64(C.gesture_scale(zoom)))
+		if z != 0 {
+			w.zoomTo(z)
+			return
+		}
+		if scroll != 0 {
+			w.syncSmoothScroll()
+			w.smooth.Drag(scroll, time.Now())
+			w.applySmoothScroll()
+		}
+	})
+	w.touch.zoom.Connect("end", func() {
+		if w.touch.twoFinger.Pinching() {
+			return
+		}
+		// Coast as a touchpad does when the fingers lift
+		w.syncSmoothScroll()
+		w.smooth.Release(time.Now())
+		w.applySmoothScroll()
+		w.startSmoothScrollTimer()
+	})
+
+	longPress := C.touch_long_press_new(area)
+	w.touch.longPress = glib.Take(unsafe.Pointer(longPress))
+	w.touch.longPress.Connect("pressed", func() {
+		w.cancelTouchSelection()
+		var x, y C.double
+		C.gesture_center(longPress, &x, &y)
+		C.send_right_click(area, x, y)
+	})
+}
+
+// cancelTouchSelection drops the selection the first finger's button press began,
+// when the touch turns out to be a gesture
+func (w *Widget) cancelTouchSelection() {
+	if !w.mouseDown {
+		return
+	}
+	w.mouseDown = false
+	w.stopAutoScroll()
+	if w.selecting {
+		w.selecting = false
+		w.buffer.ClearSelection()
+	}
+}
@@ -193,7 +193,9 @@ static int pango_text_width_standalone(const char *text, const char *font_family
 import "C"
 
 import (
+	"encoding/binary"
 	"fmt"
+	"image"
 	"math"
 	"runtime"
 	"strings"
@@ -361,6 +363,12 @@ type Widget struct {
 	// Glyph cache for rendered characters
 	glyphCache *glyphCache
 
+	// GL renderer, once GPU rendering has been turned on (see glrender.go)
+	gl *glRenderer
+
+	// Cairo surfaces for on-screen inline images, by image ID
+	imageSurfaces map[int]*cairo.Surface
+
 	// Font settings
 	fontFamily        string
 	fontFamilyUnicode string // Fallback for Unicode characters missing from main font
@@ -381,6 +389,7 @@ type Widget struct {
 	mouseDownX     int
 	mouseDownY     int
 	selectionMoved bool // True if mouse moved since button press
+	selectBlock    bool // True if the press had Alt held (rectangular selection)
 
 	// Auto-scroll when dragging beyond edges
 	autoScrollTimerID    glib.SourceHandle // Timer for auto-scrolling
@@ -389,6 +398,12 @@ type Widget struct {
 	lastMouseX           int               // Last known mouse X cell position
 	lastMouseY           int               // Last known mouse Y cell position
 
+	// Smooth scrolling: the pixel position, its animation timer, and the parts of
+	// wheel clicks gathered from smooth deltas (see smoothscroll.go)
+	smooth                       purfecterm.SmoothScroll
+	smoothScrollTimerID          glib.SourceHandle
+	scrollClicksX, scrollClicksY float64
+
 	// Cursor blink
 	cursorBlinkOn  bool
 	blinkTimerID   glib.SourceHandle
@@ -403,8 +418,52 @@ type Widget struct {
 	// Callback when data should be written to PTY
 	onInput func([]byte)
 
+	// Key macros applied to keys before they are sent (nil for none)
+	keyMap *purfecterm.KeyMap
+
+	// Input method context and the text it is composing (see imcontext.go)
+	im imState
+
+	// Scrollback find bar (see findbar.go)
+	find findBar
+
+	// Output written while scrolled back, and where its pill is (see scrollindicator.go)
+	newOutput    purfecterm.NewOutput
+	newLinesPill pillRect
+
+	// Background image and window blur (see background.go)
+	backdrop backdrop
+
+	// The screen as last given to screen readers (see accessible.go)
+	a11y accessibleText
+
+	// Two-finger and long press gestures (see touch.go)
+	touch touchGestures
+
+	// Font zoom, the size given to SetFont, and the zoom callback (see zoom.go)
+	zoom         float64
+	baseFontSize int
+	zoomClicks   float64
+	onZoom       func(zoom float64)
+
+	// Hyperlinks: the link under the mouse (underlined), and the Ctrl+click callback
+	hoverLink   int
+	onLinkClick func(uri string)
+
+	// Callback offered dropped files before they're typed (see filedrop.go)
+	onFileDrop func(paths []string) bool
+
+	// Callback when the widget gains keyboard focus
+	onFocus func()
+
+	// Mouse reporting: the button held since a reported press, and the last cell reported
+	mouseReportButton int
+	mouseReportCol    int
+	mouseReportRow    int
+
 	// Clipboard
 	clipboard *gtk.Clipboard
+	primary   *gtk.Clipboard // The primary selection
 
 	// Terminal capabilities (for PawScript channel integration)
 	// Automatically updated on resize
@@ -413,30 +472,43 @@ type Widget struct {
 
 // NewWidget creates a new terminal widget with the specified dimensions
 func NewWidget(cols, rows, scrollbackSize int) (*Widget, error) {
+	return NewWidgetForBuffer(purfecterm.NewBuffer(cols, rows, scrollbackSize))
+}
+
+// NewWidgetForBuffer creates a terminal widget showing an existing buffer, such as a
+// pane's (see purfecterm.Buffer.SplitPane)
+func NewWidgetForBuffer(buffer *purfecterm.Buffer) (*Widget, error) {
+	cols, rows := buffer.GetSize()
 	w := &Widget{
 		fontFamily:    "Menlo",
 		fontSize:      14,
+		baseFontSize:  14,
+		zoom:          1.0,
 		charWidth:     10, // Will be calculated properly
 		charHeight:    20,
 		charAscent:    16,
 		scheme:        purfecterm.DefaultColorScheme(),
 		cursorBlinkOn: true,
 		glyphCache:    newGlyphCache(4096), // Cache up to 4096 rendered glyphs
+
+		mouseReportButton: purfecterm.MouseButtonNone,
 	}
 
-	// Create buffer and parser
-	w.buffer = purfecterm.NewBuffer(cols, rows, scrollbackSize)
+	// Create parser
+	w.buffer = buffer
 	w.parser = purfecterm.NewParser(w.buffer)
 
-	// Initialize terminal capabilities (auto-updated on resize)
+	// Initialize terminal capabilities (auto-updated on resize and theme change)
 	w.termCaps = &pawscript.TerminalCapabilities{
 		TermType:      "gui-console",
 		IsTerminal:    true,
 		SupportsANSI:  true,
 		SupportsColor: true,
 		ColorDepth:    256,
+		SupportsSixel: true,
 		Width:         cols,
 		Height:        rows,
+		DarkTheme:     w.buffer.IsDarkTheme(),
 		SupportsInput: true,
 		EchoEnabled:   false,
 		LineMode:      false,
@@ -446,6 +518,8 @@ func NewWidget(cols, rows, scrollbackSize int) (*Widget, error) {
 	// Set up dirty callback to trigger redraws and scrollbar updates
 	w.buffer.SetDirtyCallback(func() {
 		glib.IdleAdd(func() {
+			// Keep capabilities in sync with theme changes (CSI ? 5 h/l)
+			w.termCaps.SetDarkTheme(w.buffer.IsDarkTheme())
 			if w.drawingArea != nil {
 				w.drawingArea.QueueDraw()
 				w.updateScrollbar()
@@ -453,6 +527,26 @@ func NewWidget(cols, rows, scrollbackSize int) (*Widget, error) {
 		})
 	})
 
+	// Carry out clipboard requests from programs (OSC 52) on the UI thread
+	w.buffer.SetClipboardCallback(func(req purfecterm.ClipboardRequest) {
+		glib.IdleAdd(func() {
+			w.handleClipboardRequest(req)
+		})
+	})
+
+	// Send replies to queries (DA, DSR, XTVERSION) to the program from the UI thread,
+	// in order with its other input
+	w.buffer.SetResponseCallback(func(reply []byte) {
+		glib.IdleAdd(func() {
+			w.mu.Lock()
+			onInput := w.onInput
+			w.mu.Unlock()
+			if onInput != nil {
+				onInput(reply)
+			}
+		})
+	})
+
 	// Create GTK widgets
 	var err error
 
@@ -469,14 +563,15 @@ func NewWidget(cols, rows, scrollbackSize int) (*Widget, error) {
 	}
 
 	// Drawing area for terminal content
-	w.drawingArea, err = gtk.DrawingAreaNew()
+	w.drawingArea, err = w.newTerminalArea()
 	if err != nil {
 		return nil, err
 	}
 
 	// Enable events
 	w.drawingArea.AddEvents(int(gdk.BUTTON_PRESS_MASK | gdk.BUTTON_RELEASE_MASK |
-		gdk.POINTER_MOTION_MASK | gdk.SCROLL_MASK | gdk.KEY_PRESS_MASK))
+		gdk.POINTER_MOTION_MASK | gdk.LEAVE_NOTIFY_MASK | gdk.SCROLL_MASK | gdk.SMOOTH_SCROLL_MASK |
+		gdk.KEY_PRESS_MASK | gdk.KEY_RELEASE_MASK))
 	w.drawingArea.SetCanFocus(true)
 
 	// Connect signals
@@ -484,11 +579,16 @@ func NewWidget(cols, rows, scrollbackSize int) (*Widget, error) {
 	w.drawingArea.Connect("button-press-event", w.onButtonPress)
 	w.drawingArea.Connect("button-release-event", w.onButtonRelease)
 	w.drawingArea.Connect("motion-notify-event", w.onMotionNotify)
+	w.drawingArea.Connect("leave-notify-event", w.onLeaveNotify)
 	w.drawingArea.Connect("scroll-event", w.onScroll)
 	w.drawingArea.Connect("key-press-event", w.onKeyPress)
+	w.drawingArea.Connect("key-release-event", w.onKeyRelease)
 	w.drawingArea.Connect("configure-event", w.onConfigure)
 	w.drawingArea.Connect("focus-in-event", w.onFocusIn)
 	w.drawingArea.Connect("focus-out-event", w.onFocusOut)
+	w.initInputMethod()
+	w.initFileDrop()
+	w.initTouch()
 
 	// Create vertical scrollbar
 	adjustment, _ := gtk.AdjustmentNew(0, 0, 100, 1, 10, 10)
@@ -541,9 +641,13 @@ func NewWidget(cols, rows, scrollbackSize int) (*Widget, error) {
 	// Outer box holds inner box and bottom box
 	w.box.PackStart(w.innerBox, true, true, 0)
 	w.box.PackStart(w.bottomBox, false, false, 0)
+	if err := w.initFindBar(); err != nil {
+		return nil, err
+	}
 
 	// Get clipboard
 	w.clipboard, _ = gtk.ClipboardGet(gdk.SELECTION_CLIPBOARD)
+	w.primary, _ = gtk.ClipboardGet(gdk.SELECTION_PRIMARY)
 
 	// Set minimum size (small fixed value to allow flexible resizing)
 	w.updateFontMetrics()
@@ -590,6 +694,12 @@ func (w *Widget) Box() *gtk.Box {
 	return w.box
 }
 
+// Destroy stops the widget's timer and destroys its GTK widgets
+func (w *Widget) Destroy() {
+	glib.SourceRemove(w.blinkTimerID)
+	w.box.Destroy()
+}
+
 // DrawingArea returns the drawing area widget
 func (w *Widget) DrawingArea() *gtk.DrawingArea {
 	return w.drawingArea
@@ -603,7 +713,8 @@ func (w *Widget) SetFont(family string, size int) {
 
 	w.mu.Lock()
 	w.fontFamily = resolvedFont
-	w.fontSize = size
+	w.baseFontSize = size
+	w.fontSize = purfecterm.ZoomFontSize(size, w.zoom)
 	w.mu.Unlock()
 	// Trigger full configure handling to recalculate terminal dimensions,
 	// scrollbars, and update the buffer with new character metrics
@@ -803,18 +914,64 @@ func pangoTextWidthStandalone(text, fontFamily string, fontSize int, bold, itali
 	return int(C.pango_text_width_standalone(cText, cFont, C.int(fontSize), C.int(boldInt), C.int(italicInt)))
 }
 
+// renderImages draws the inline images on screen at their native pixel size
+func (w *Widget) renderImages(cr *cairo.Context, charWidth, charHeight, horizOffsetX int) {
+	images := w.buffer.GetVisibleImages()
+	if len(images) == 0 && len(w.imageSurfaces) == 0 {
+		return
+	}
+	surfaces := make(map[int]*cairo.Surface, len(images))
+	for _, img := range images {
+		surface := w.imageSurfaces[img.ID]
+		if surface == nil {
+			surface = createImageSurface(img.Image)
+		}
+		surfaces[img.ID] = surface
+		x := float64(terminalLeftPadding + (img.Col-horizOffsetX)*charWidth)
+		y := float64(img.Row * charHeight)
+		cr.SetSourceSurface(surface, x, y)
+		cr.Paint()
+	}
+	// Keep surfaces only for images still on screen
+	w.imageSurfaces = surfaces
+}
+
+// createImageSurface copies an image into a Cairo surface
+// Cairo's ARGB32 is premultiplied, stored as native-endian 32-bit words.
+func createImageSurface(img *image.NRGBA) *cairo.Surface {
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	surface := cairo.CreateImageSurface(cairo.FORMAT_ARGB32, width, height)
+	surface.Flush()
+	stride := cairo.FormatStrideForWidth(cairo.FORMAT_ARGB32, width)
+	data := unsafe.Slice((*byte)(surface.GetData()), stride*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := img.NRGBAAt(img.Bounds().Min.X+x, img.Bounds().Min.Y+y)
+			a := uint32(c.A)
+			r := uint32(c.R) * a / 255
+			g := uint32(c.G) * a / 255
+			b := uint32(c.B) * a / 255
+			binary.NativeEndian.PutUint32(data[y*stride+x*4:], a<<24|r<<16|g<<8|b)
+		}
+	}
+	surface.MarkDirty()
+	return surface
+}
+
 // createCustomGlyphSurface renders a custom glyph to a cached Cairo surface.
 // The surface is rendered at the specified cell size with all palette colors resolved.
 // scaleY is used for double-height mode (1.0 for normal, 2.0 for double-height).
+// scale is the window's scale factor; the surface has that many pixels per cell pixel.
 func (w *Widget) createCustomGlyphSurface(cell *purfecterm.Cell, glyph *purfecterm.CustomGlyph,
-	cellW, cellH int, scaleY float64) *cairo.Surface {
+	cellW, cellH int, scaleY float64, scale int) *cairo.Surface {
 
 	glyphW := glyph.Width
 	glyphH := glyph.Height
 
 	// Calculate surface dimensions (account for scaleY for double-height)
 	surfaceH := int(float64(cellH) * scaleY)
-	surface := cairo.CreateImageSurface(cairo.FORMAT_ARGB32, cellW, surfaceH)
+	surface := cairo.CreateImageSurface(cairo.FORMAT_ARGB32, cellW*scale, surfaceH*scale)
+	C.cairo_surface_set_device_scale((*C.cairo_surface_t)(unsafe.Pointer(surface.Native())), C.double(scale), C.double(scale))
 	cr := cairo.Create(surface)
 
 	// Calculate pixel size (scale glyph to fill cell)
@@ -938,10 +1095,12 @@ func (w *Widget) renderCustomGlyph(cr *cairo.Context, cell *purfecterm.Cell, cel
 		usesBg = true
 	}
 
-	// Build cache key
+	// Build cache key (sized in device pixels, so moving to a screen with another
+	// scale renders it again)
+	scale := w.drawingArea.GetScaleFactor()
 	cacheKey := buildCustomGlyphKey(
 		cell.Char,
-		int(cellW), int(cellH*scaleY),
+		int(cellW)*scale, int(cellH*scaleY)*scale,
 		cell.XFlip, cell.YFlip,
 		paletteHash, glyph.ComputeHash(),
 		usesDefaultFG, usesBg,
@@ -952,7 +1111,7 @@ func (w *Widget) renderCustomGlyph(cr *cairo.Context, cell *purfecterm.Cell, cel
 	cachedSurface := w.glyphCache.get(cacheKey)
 	if cachedSurface == nil {
 		// Cache miss - create and cache the surface
-		cachedSurface = w.createCustomGlyphSurface(cell, glyph, int(cellW), int(cellH), scaleY)
+		cachedSurface = w.createCustomGlyphSurface(cell, glyph, int(cellW), int(cellH), scaleY, scale)
 		w.glyphCache.put(cacheKey, cachedSurface)
 	}
 
@@ -1179,6 +1338,12 @@ func (w *Widget) SetColorScheme(scheme purfecterm.ColorScheme) {
 	w.mu.Lock()
 	w.scheme = scheme
 	w.mu.Unlock()
+	w.buffer.SetDefaultCursorStyle(scheme.CursorShape, scheme.CursorBlink)
+	w.buffer.SetClipboardAccess(scheme.Clipboard)
+	w.buffer.SetTerminalIdentity(scheme.Identity)
+	if scheme.GPURendering && w.gl == nil {
+		w.enableGL()
+	}
 	w.applyScrollbarCSS() // Update scrollbar background to match
 	w.drawingArea.QueueDraw()
 	w.cornerArea.QueueDraw() // Update corner area background
@@ -1302,6 +1467,40 @@ func (w *Widget) SetInputCallback(fn func([]byte)) {
 	w.mu.Unlock()
 }
 
+// SetKeyMap sets the key macros applied to keys before they are sent (nil for none)
+func (w *Widget) SetKeyMap(keyMap *purfecterm.KeyMap) {
+	w.mu.Lock()
+	w.keyMap = keyMap
+	w.mu.Unlock()
+}
+
+// SetLinkClickCallback sets the callback for Ctrl+click on a hyperlink
+func (w *Widget) SetLinkClickCallback(fn func(uri string)) {
+	w.mu.Lock()
+	w.onLinkClick = fn
+	w.mu.Unlock()
+}
+
+// SetFileDropCallback sets the callback offered files dropped on the widget,
+// which returns whether it took them
+func (w *Widget) SetFileDropCallback(fn func(paths []string) bool) {
+	w.mu.Lock()
+	w.onFileDrop = fn
+	w.mu.Unlock()
+}
+
+// SetFocusCallback sets the callback for when the widget gains keyboard focus
+func (w *Widget) SetFocusCallback(fn func()) {
+	w.mu.Lock()
+	w.onFocus = fn
+	w.mu.Unlock()
+}
+
+// GrabFocus gives the widget keyboard focus
+func (w *Widget) GrabFocus() {
+	w.drawingArea.GrabFocus()
+}
+
 // Feed writes data to the terminal (for local echo or PTY output)
 func (w *Widget) Feed(data []byte) {
 	w.parser.Parse(data)
@@ -1617,8 +1816,12 @@ func (w *Widget) onDraw(da *gtk.DrawingArea, cr *cairo.Context) bool {
 	baseCharWidth := w.charWidth
 	baseCharHeight := w.charHeight
 	blinkPhase := w.blinkPhase
+	hoverLink := w.hoverLink
 	w.mu.Unlock()
 
+	// Whether the GL area below has drawn the cells this frame (see glrender.go)
+	glDrawn := w.gl != nil && w.gl.active
+
 	// Get current theme mode (dark/light) from buffer's DECSCNM state
 	isDark := w.buffer.IsDarkTheme()
 
@@ -1655,13 +1858,17 @@ func (w *Widget) onDraw(da *gtk.DrawingArea, cr *cairo.Context) bool {
 	// Draw background - fill entire widget area (not just cell area)
 	// This ensures any extra space at edges is filled with terminal background
 	alloc := da.GetAllocation()
-	schemeBg := scheme.Background(isDark)
-	cr.SetSourceRGB(
-		float64(schemeBg.R)/255.0,
-		float64(schemeBg.G)/255.0,
-		float64(schemeBg.B)/255.0)
-	cr.Rectangle(0, 0, float64(alloc.GetWidth()), float64(alloc.GetHeight()))
-	cr.Fill()
+	if !glDrawn {
+		// With its opacity and image (see background.go)
+		w.drawBackground(cr, alloc.GetWidth(), alloc.GetHeight(), scheme, isDark)
+	}
+	w.updateWindowBlur(scheme)
+
+	// Draw everything on the screen higher by the part of a line smooth scrolling
+	// has moved (see smoothscroll.go)
+	smoothShift := w.smoothScrollShift()
+	cr.Save()
+	cr.Translate(0, -float64(smoothShift))
 
 	// Apply screen crop clipping if set (crop values are in sprite coordinate units)
 	widthCrop, heightCrop := w.buffer.GetScreenCrop()
@@ -1720,6 +1927,20 @@ func (w *Widget) onDraw(da *gtk.DrawingArea, cr *cairo.Context) bool {
 		// Calculate the range of logical columns to render
 		startCol := horizOffset
 		endCol := horizOffset + effectiveCols
+		if glDrawn {
+			// The cells are already drawn, so only the cursor memo below is needed
+			endCol = startCol
+		}
+
+		// Runs of cells drawn together for ligatures (see ligature.go)
+		var runs []purfecterm.LigatureRun
+		if scheme.Ligatures && lineAttr == purfecterm.LineAttrNormal && !glDrawn {
+			cursorX := -1
+			if cursorVisible && y == cursorVisibleY && w.cursorBlinkOn {
+				cursorX = cursorVisibleX
+			}
+			runs = w.ligatureRuns(y, effectiveCols, horizOffset, cursorX)
+		}
 
 		// Track accumulated visual width for flex-width rendering
 		// This is the accumulated width in base cell units (before line attribute scaling)
@@ -1731,6 +1952,11 @@ func (w *Widget) onDraw(da *gtk.DrawingArea, cr *cairo.Context) bool {
 			// GetVisibleCell takes screen position and applies horizOffset internally
 			cell := w.buffer.GetVisibleCell(x, y)
 
+			// Underline the hyperlink under the mouse
+			if cell.LinkID != 0 && cell.LinkID == hoverLink && cell.UnderlineStyle == purfecterm.UnderlineNone {
+				cell.UnderlineStyle = purfecterm.UnderlineSingle
+			}
+
 			// Calculate this cell's visual width
 			cellVisualWidth := 1.0
 			if cell.FlexWidth && cell.CellWidth > 0 {
@@ -1765,6 +1991,14 @@ func (w *Widget) onDraw(da *gtk.DrawingArea, cr *cairo.Context) bool {
 				}
 			}
 
+			// Handle search highlighting
+			switch w.buffer.GetSearchHighlight(logicalX, y) {
+			case purfecterm.SearchHighlightMatch:
+				fg, bg = scheme.SearchText, scheme.SearchMatch
+			case purfecterm.SearchHighlightCurrent:
+				fg, bg = scheme.SearchText, scheme.SearchCurrent
+			}
+
 			// Handle selection highlighting (use logicalX for buffer position)
 			if w.buffer.IsInSelection(logicalX, y) {
 				bg = scheme.Selection
@@ -1815,7 +2049,13 @@ func (w *Widget) onDraw(da *gtk.DrawingArea, cr *cairo.Context) bool {
 			}
 
 			// Draw character (skip if traditional blink mode and currently invisible)
-			if cell.Char != ' ' && cell.Char != 0 && blinkVisible {
+			if i := purfecterm.LigatureRunAt(runs, x); i >= 0 {
+				// A ligature run is drawn with its last cell, over all its backgrounds
+				if x == runs[i].End-1 {
+					runX := cellX - float64(x-runs[i].Start)*cellW
+					w.drawLigatureRun(cr, runs[i], runX, cellW, cellY, fontFamily, fontSize, cell.Bold, cell.Italic, fg, horizScale, vertScale)
+				}
+			} else if cell.Char != ' ' && cell.Char != 0 && blinkVisible {
 				// Check for custom glyph first
 				if w.renderCustomGlyph(cr, &cell, cellX, cellY, cellW, cellH, x, blinkPhase, scheme.BlinkMode, lineAttr) {
 					// Custom glyph was rendered, skip normal text rendering
@@ -2053,6 +2293,20 @@ func (w *Widget) onDraw(da *gtk.DrawingArea, cr *cairo.Context) bool {
 				cr.Fill()
 			}
 
+			// Draw overline if needed
+			if cell.Overline {
+				cr.SetSourceRGB(
+					float64(fg.R)/255.0,
+					float64(fg.G)/255.0,
+					float64(fg.B)/255.0)
+				overH := 1.0
+				if lineAttr == purfecterm.LineAttrDoubleTop || lineAttr == purfecterm.LineAttrDoubleBottom {
+					overH = 2.0
+				}
+				cr.Rectangle(cellX, cellY, cellW, overH)
+				cr.Fill()
+			}
+
 			// Draw cursor based on shape (0=block, 1=underline, 2=bar)
 			if isCursor {
 				cr.SetSourceRGB(
@@ -2132,6 +2386,10 @@ func (w *Widget) onDraw(da *gtk.DrawingArea, cr *cairo.Context) bool {
 		}
 	}
 
+	// Render inline images (sixel graphics) over the text they were placed on
+	w.buffer.SetCellPixelSize(charWidth, charHeight)
+	w.renderImages(cr, charWidth, charHeight, horizOffset)
+
 	// Render front sprites (overlay on top of text)
 	w.renderSprites(cr, frontSprites, charWidth, charHeight, scheme, isDark, scrollOffset, horizOffset)
 
@@ -2150,6 +2408,15 @@ func (w *Widget) onDraw(da *gtk.DrawingArea, cr *cairo.Context) bool {
 		w.buffer.SetSplitContentWidth(0)
 	}
 
+	// Tell the input method where the cursor is, and draw what it is composing there
+	if cursorVisible {
+		imX := cursorVisibleX*charWidth + terminalLeftPadding
+		imY := cursorVisibleY * charHeight
+		w.imCursorLocation(imX, imY, charWidth, charHeight)
+		w.drawPreedit(cr, float64(imX), float64(imY), float64(charHeight), fontFamily, fontSize,
+			scheme.Foreground(isDark), scheme.Background(isDark))
+	}
+
 	// Draw yellow dashed line between scrollback and logical screen
 	boundaryRow := w.buffer.GetScrollbackBoundaryVisibleRow()
 	if boundaryRow > 0 {
@@ -2167,6 +2434,10 @@ func (w *Widget) onDraw(da *gtk.DrawingArea, cr *cairo.Context) bool {
 	if hasCrop {
 		cr.Restore()
 	}
+	cr.Restore() // Smooth scroll shift
+
+	// Show how far back the view is, and any output written meanwhile
+	w.drawScrollIndicator(cr, float64(alloc.GetWidth()), float64(alloc.GetHeight()), fontFamily, fontSize, baseCharHeight)
 
 	// Report whether cursor's LINE was rendered for auto-scroll logic
 	// We track the line, not the cursor itself - the cursor may be horizontally
@@ -2185,6 +2456,22 @@ func (w *Widget) onDraw(da *gtk.DrawingArea, cr *cairo.Context) bool {
 		w.updateScrollbar()
 	}
 
+	// Visual bell: wash the whole area with the foreground color briefly
+	// (the blink timer redraws once it is over)
+	if scheme.VisualBell && w.buffer.IsBellFlashing() {
+		fg := scheme.Foreground(isDark)
+		cr.SetSourceRGBA(
+			float64(fg.R)/255.0,
+			float64(fg.G)/255.0,
+			float64(fg.B)/255.0,
+			0.25)
+		cr.Rectangle(0, 0, float64(alloc.GetWidth()), float64(alloc.GetHeight()))
+		cr.Fill()
+	}
+
+	// Tell screen readers what changed (see accessible.go)
+	w.updateAccessible()
+
 	w.buffer.ClearDirty()
 	return true
 }
@@ -2202,7 +2489,7 @@ func (w *Widget) screenToCell(screenX, screenY float64) (cellX, cellY int) {
 	charHeight := int(float64(baseCharHeight) * vertScale)
 
 	// Calculate row first (needed to check line attributes)
-	cellY = int(screenY) / charHeight
+	cellY = purfecterm.CellIndex(screenY, charHeight)
 
 	cols, rows := w.buffer.GetSize()
 	if cellY < 0 {
@@ -2268,17 +2555,56 @@ func (w *Widget) onButtonPress(da *gtk.DrawingArea, ev *gdk.Event) bool {
 	x, y := btn.X(), btn.Y()
 	button := btn.Button()
 
+	// The new lines pill jumps to the bottom (see scrollindicator.go)
+	if button == 1 && w.newLinesPillAt(x, y) {
+		w.scrollToBottom()
+		return true
+	}
+
+	// With mouse tracking on, clicks go to the application (Shift+click still selects)
+	if w.mouseReporting(gdk.ModifierType(btn.State())) {
+		if reportButton, ok := gtkMouseButton(button); ok {
+			// Double and triple clicks arrive as extra events after the presses themselves
+			if btn.Type() == gdk.EVENT_BUTTON_PRESS {
+				w.mu.Lock()
+				w.mouseReportButton = reportButton
+				w.mu.Unlock()
+				w.reportMouse(purfecterm.MousePress, reportButton, gdk.ModifierType(btn.State()), x, y)
+			}
+			da.GrabFocus()
+			return true
+		}
+	}
+
 	if button == 1 { // Left button
+		// Ctrl+click opens a hyperlink instead of starting a selection
+		if btn.State()&uint(gdk.CONTROL_MASK) != 0 {
+			w.mu.Lock()
+			onLinkClick := w.onLinkClick
+			w.mu.Unlock()
+			if uri := w.buffer.GetHyperlink(w.linkAt(x, y)); uri != "" && onLinkClick != nil {
+				onLinkClick(uri)
+				return true
+			}
+		}
 		cellX, cellY := w.screenToCell(x, y)
 		// Record press position but don't start selection yet
 		w.mouseDown = true
 		w.mouseDownX = cellX
 		w.mouseDownY = cellY
 		w.selectionMoved = false
+		w.selectBlock = btn.State()&uint(gdk.MOD1_MASK) != 0 // Alt+drag selects a rectangle
 		w.buffer.ClearSelection()
 		da.GrabFocus()
 		return true
 	}
+	if button == 2 && w.primarySelectionEnabled() { // Middle button pastes the primary selection
+		if btn.Type() == gdk.EVENT_BUTTON_PRESS {
+			w.PastePrimary()
+		}
+		da.GrabFocus()
+		return true
+	}
 	// Let other buttons (like right-click) propagate for context menus
 	return false
 }
@@ -2287,25 +2613,49 @@ func (w *Widget) onButtonRelease(da *gtk.DrawingArea, ev *gdk.Event) bool {
 	btn := gdk.EventButtonNewFromEvent(ev)
 	button := btn.Button()
 
+	w.mu.Lock()
+	reportButton := w.mouseReportButton
+	w.mu.Unlock()
+	if held, ok := gtkMouseButton(button); ok && held == reportButton {
+		w.mu.Lock()
+		w.mouseReportButton = purfecterm.MouseButtonNone
+		w.mu.Unlock()
+		w.reportMouse(purfecterm.MouseRelease, held, gdk.ModifierType(btn.State()), btn.X(), btn.Y())
+		return true
+	}
+
 	if button == 1 {
 		w.mouseDown = false
 		w.stopAutoScroll() // Stop any auto-scrolling
 		if w.selecting {
 			w.selecting = false
 			w.buffer.EndSelection()
+			if w.primarySelectionEnabled() {
+				w.copySelectionToPrimary()
+			}
 		}
 	}
 	return true
 }
 
 func (w *Widget) onMotionNotify(da *gtk.DrawingArea, ev *gdk.Event) bool {
+	// Use C helper to get coordinates from the event
+	var x, y C.double
+	C.get_event_coords((*C.GdkEvent)(unsafe.Pointer(ev.Native())), &x, &y)
+
+	w.mu.Lock()
+	reportButton := w.mouseReportButton
+	w.mu.Unlock()
+	if state := gdk.EventMotionNewFromEvent(ev).State(); !w.mouseDown && w.mouseReporting(state) {
+		w.reportMouse(purfecterm.MouseMove, reportButton, state, float64(x), float64(y))
+		return true
+	}
+
 	if !w.mouseDown {
+		w.setHoverLink(w.linkAt(float64(x), float64(y)))
 		return false
 	}
 
-	// Use C helper to get coordinates from the event
-	var x, y C.double
-	C.get_event_coords((*C.GdkEvent)(unsafe.Pointer(ev.Native())), &x, &y)
 	cellX, cellY := w.screenToCell(float64(x), float64(y))
 
 	// Get terminal dimensions for edge detection
@@ -2328,7 +2678,11 @@ func (w *Widget) onMotionNotify(da *gtk.DrawingArea, ev *gdk.Event) bool {
 			w.selecting = true
 			w.selectStartX = w.mouseDownX
 			w.selectStartY = w.mouseDownY
-			w.buffer.StartSelection(w.mouseDownX, w.mouseDownY)
+			if w.selectBlock {
+				w.buffer.StartBlockSelection(w.mouseDownX, w.mouseDownY)
+			} else {
+				w.buffer.StartSelection(w.mouseDownX, w.mouseDownY)
+			}
 		} else {
 			return true // Mouse still in same cell, don't select yet
 		}
@@ -2391,6 +2745,79 @@ func (w *Widget) onMotionNotify(da *gtk.DrawingArea, ev *gdk.Event) bool {
 	return true
 }
 
+// onLeaveNotify clears the hyperlink hover when the mouse leaves the terminal
+func (w *Widget) onLeaveNotify(da *gtk.DrawingArea, ev *gdk.Event) bool {
+	w.setHoverLink(0)
+	return false
+}
+
+// linkAt returns the hyperlink ID of the cell at a widget position (0 = none)
+func (w *Widget) linkAt(x, y float64) int {
+	cellX, cellY := w.screenToCell(x, y)
+	return w.buffer.GetVisibleCell(cellX-w.buffer.GetHorizOffset(), cellY).LinkID
+}
+
+// setHoverLink changes which hyperlink is underlined, redrawing if it changed
+func (w *Widget) setHoverLink(linkID int) {
+	w.mu.Lock()
+	changed := w.hoverLink != linkID
+	w.hoverLink = linkID
+	w.mu.Unlock()
+	if changed {
+		w.drawingArea.QueueDraw()
+	}
+}
+
+// gtkMouseButton converts a GDK button number to a reported mouse button
+func gtkMouseButton(button gdk.Button) (int, bool) {
+	switch button {
+	case 1:
+		return purfecterm.MouseButtonLeft, true
+	case 2:
+		return purfecterm.MouseButtonMiddle, true
+	case 3:
+		return purfecterm.MouseButtonRight, true
+	}
+	return 0, false
+}
+
+// mouseReporting returns whether mouse events go to the application rather than
+// to selection and scrolling: tracking is on and Shift isn't held
+func (w *Widget) mouseReporting(state gdk.ModifierType) bool {
+	return w.buffer.GetMouseTracking() != purfecterm.MouseTrackingOff && state&gdk.SHIFT_MASK == 0
+}
+
+// reportMouse sends a mouse event at a widget position to the application
+// Motion is only reported when it reaches a different cell.
+func (w *Widget) reportMouse(action purfecterm.MouseAction, button int, state gdk.ModifierType, x, y float64) {
+	cellX, cellY := w.screenToCell(x, y)
+	col := cellX - w.buffer.GetHorizOffset()
+
+	w.mu.Lock()
+	if action == purfecterm.MouseMove && col == w.mouseReportCol && cellY == w.mouseReportRow {
+		w.mu.Unlock()
+		return
+	}
+	w.mouseReportCol, w.mouseReportRow = col, cellY
+	onInput := w.onInput
+	w.mu.Unlock()
+
+	mods := 0
+	if state&gdk.SHIFT_MASK != 0 {
+		mods |= purfecterm.MouseModShift
+	}
+	if state&gdk.MOD1_MASK != 0 {
+		mods |= purfecterm.MouseModAlt
+	}
+	if state&gdk.CONTROL_MASK != 0 {
+		mods |= purfecterm.MouseModCtrl
+	}
+	data := w.buffer.EncodeMouseEvent(purfecterm.MouseEvent{Action: action, Button: button, Mods: mods, Col: col, Row: cellY})
+	if data != nil && onInput != nil {
+		onInput(data)
+	}
+}
+
 // startAutoScroll begins auto-scrolling in the given direction(s)
 // vertDelta: negative = scroll up (toward scrollback), positive = scroll down (toward current)
 // horizDelta: negative = scroll left, positive = scroll right
@@ -2498,8 +2925,38 @@ func (w *Widget) stopAutoScroll() {
 
 func (w *Widget) onScroll(da *gtk.DrawingArea, ev *gdk.Event) bool {
 	scroll := gdk.EventScrollNewFromEvent(ev)
-	dir := scroll.Direction()
-	state := scroll.State()
+	if w.zoomScroll(scroll) {
+		return true
+	}
+	if scroll.Direction() == gdk.SCROLL_SMOOTH {
+		w.onSmoothScroll(ev, scroll)
+		return true
+	}
+	w.scrollStep(scroll.Direction(), scroll.State(), scroll.X(), scroll.Y())
+	return true
+}
+
+// scrollStep scrolls by one wheel click in a direction, or reports it to the
+// application when it is tracking the mouse
+func (w *Widget) scrollStep(dir gdk.ScrollDirection, state gdk.ModifierType, x, y float64) {
+	// With mouse tracking on, the wheel goes to the application
+	if w.mouseReporting(state) {
+		button := -1
+		switch dir {
+		case gdk.SCROLL_UP:
+			button = purfecterm.MouseWheelUp
+		case gdk.SCROLL_DOWN:
+			button = purfecterm.MouseWheelDown
+		case gdk.SCROLL_LEFT:
+			button = purfecterm.MouseWheelLeft
+		case gdk.SCROLL_RIGHT:
+			button = purfecterm.MouseWheelRight
+		}
+		if button >= 0 {
+			w.reportMouse(purfecterm.MousePress, button, state, x, y)
+		}
+		return
+	}
 
 	// Check for Shift modifier for horizontal scrolling
 	hasShift := state&gdk.SHIFT_MASK != 0
@@ -2568,7 +3025,6 @@ func (w *Widget) onScroll(da *gtk.DrawingArea, ev *gdk.Event) bool {
 	}
 
 	w.updateScrollbar()
-	return true
 }
 
 func (w *Widget) onKeyPress(da *gtk.DrawingArea, ev *gdk.Event) bool {
@@ -2578,6 +3034,7 @@ func (w *Widget) onKeyPress(da *gtk.DrawingArea, ev *gdk.Event) bool {
 
 	w.mu.Lock()
 	onInput := w.onInput
+	keyMap := w.keyMap
 	w.mu.Unlock()
 
 	// Extract modifier states (cast ModifierType to uint for bitwise ops)
@@ -2587,6 +3044,11 @@ func (w *Widget) onKeyPress(da *gtk.DrawingArea, ev *gdk.Event) bool {
 	hasMeta := state&uint(gdk.META_MASK) != 0 // Meta/Command key
 	hasSuper := state&uint(gdk.SUPER_MASK) != 0
 
+	// Let the input method compose text first (see imcontext.go)
+	if w.imFilterKey(ev, hasCtrl || hasAlt || hasMeta || hasSuper) {
+		return true
+	}
+
 	// Ignore modifier-only key presses (they don't produce terminal output)
 	if isModifierKey(keyval) {
 		return false
@@ -2615,6 +3077,17 @@ func (w *Widget) onKeyPress(da *gtk.DrawingArea, ev *gdk.Event) bool {
 		// Plain Tab or Tab with Alt/Meta/Super: continue to send to terminal
 	}
 
+	// Open the find bar (see findbar.go)
+	if isFindShortcut(keyval, hasShift, hasCtrl, hasAlt, hasMeta || hasSuper) {
+		w.ShowFindBar()
+		return true
+	}
+
+	// Zoom the font (see zoom.go)
+	if w.zoomKey(keyval, hasShift, hasCtrl, hasAlt, hasMeta || hasSuper) {
+		return true
+	}
+
 	// Handle clipboard copy (Ctrl+C with selection only)
 	// Note: Ctrl+V paste is NOT handled here - use PasteClipboard() via context menu
 	// Note: Ctrl+A is NOT handled here - it passes through to the terminal
@@ -2793,7 +3266,9 @@ func (w *Widget) onKeyPress(da *gtk.DrawingArea, ev *gdk.Event) bool {
 	if len(data) > 0 {
 		// Notify buffer of keyboard activity for auto-scroll-to-cursor
 		w.buffer.NotifyKeyboardActivity()
-		onInput(data)
+		if data = keyMap.Translate(data); len(data) > 0 {
+			onInput(data)
+		}
 		return true
 	}
 
@@ -3139,12 +3614,21 @@ func (w *Widget) onConfigure(da *gtk.DrawingArea, ev *gdk.Event) bool {
 func (w *Widget) onFocusIn(da *gtk.DrawingArea, ev *gdk.Event) bool {
 	w.hasFocus = true
 	w.cursorBlinkOn = true // Reset blink so cursor is immediately visible
+	w.imFocus(true)
 	w.drawingArea.QueueDraw()
+
+	w.mu.Lock()
+	onFocus := w.onFocus
+	w.mu.Unlock()
+	if onFocus != nil {
+		onFocus()
+	}
 	return false
 }
 
 func (w *Widget) onFocusOut(da *gtk.DrawingArea, ev *gdk.Event) bool {
 	w.hasFocus = false
+	w.imFocus(false)
 	w.drawingArea.QueueDraw()
 	return false
 }
@@ -3264,40 +3748,66 @@ func (w *Widget) CopySelection() {
 }
 
 // PasteClipboard pastes text from clipboard into terminal
-// Uses bracketed paste mode if enabled by the application or if the
-// pasted text contains special characters (newlines, control chars, etc.)
+// When the application has enabled bracketed paste mode (2004), the text is
+// delivered in one input call wrapped in ESC[200~ ... ESC[201~ markers.
 func (w *Widget) PasteClipboard() {
 	if w.clipboard != nil && w.onInput != nil {
 		text, err := w.clipboard.WaitForText()
 		if err == nil && len(text) > 0 {
-			// Determine if we should use bracketed paste
-			useBracketedPaste := w.buffer.IsBracketedPasteModeEnabled()
-
-			// Also use bracketed paste if text contains special characters
-			// even if the application hasn't requested it
-			if !useBracketedPaste {
-				for _, c := range text {
-					// Check for newlines, control chars, or escape
-					if c == '\n' || c == '\r' || c == '\x1b' || c < 32 {
-						useBracketedPaste = true
-						break
-					}
-				}
-			}
+			w.onInput(purfecterm.BracketPaste(text, w.buffer.IsBracketedPasteModeEnabled()))
+		}
+	}
+}
 
-			if useBracketedPaste {
-				// Send bracketed paste start sequence
-				w.onInput([]byte("\x1b[200~"))
-				w.onInput([]byte(text))
-				// Send bracketed paste end sequence
-				w.onInput([]byte("\x1b[201~"))
-			} else {
-				w.onInput([]byte(text))
-			}
+// primarySelectionEnabled returns whether selections go to the primary selection
+// and the middle button pastes it
+func (w *Widget) primarySelectionEnabled() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.scheme.PrimarySelection && w.primary != nil
+}
+
+// copySelectionToPrimary puts the selected text in the primary selection
+func (w *Widget) copySelectionToPrimary() {
+	if w.buffer.HasSelection() {
+		w.primary.SetText(w.buffer.GetSelectedText())
+	}
+}
+
+// PastePrimary pastes the primary selection into the terminal, as a middle click does
+func (w *Widget) PastePrimary() {
+	if w.primary != nil && w.onInput != nil {
+		text, err := w.primary.WaitForText()
+		if err == nil && len(text) > 0 {
+			w.onInput(purfecterm.BracketPaste(text, w.buffer.IsBracketedPasteModeEnabled()))
 		}
 	}
 }
 
+// handleClipboardRequest sets the clipboard or primary selection for a program, or
+// sends the program its contents
+func (w *Widget) handleClipboardRequest(req purfecterm.ClipboardRequest) {
+	clipboard := w.clipboard
+	if req.Primary {
+		clipboard = w.primary
+	}
+	if clipboard == nil {
+		return
+	}
+	if !req.Query {
+		clipboard.SetText(req.Text)
+		return
+	}
+
+	w.mu.Lock()
+	onInput := w.onInput
+	w.mu.Unlock()
+	if onInput != nil {
+		text, _ := clipboard.WaitForText()
+		onInput(purfecterm.EncodeClipboardReply(req, text))
+	}
+}
+
 // SelectAll selects all text in the terminal
 func (w *Widget) SelectAll() {
 	w.buffer.SelectAll()
@@ -0,0 +1,108 @@
+package purfectermgtk
+
+import (
+	"runtime"
+
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/phroun/pawscript/src/pkg/purfecterm"
+)
+
+// Zoom
+// Ctrl+wheel (also Cmd+wheel on macOS) and Ctrl+Shift+plus, minus and 0 (Cmd+=,
+// Cmd+- and Cmd+0 on macOS) zoom the font in, out and back to 100% (see
+// purfecterm/zoom.go). The zoom applies on top of the size given to SetFont, so the
+// configured font size is unchanged; the host is told of each change the user makes
+// through the zoom callback, to keep it per window.
+
+// SetZoom sets the font zoom factor (1.0 for the size given to SetFont)
+func (w *Widget) SetZoom(zoom float64) {
+	zoom = purfecterm.ClampZoom(zoom)
+	w.mu.Lock()
+	if zoom == w.zoom {
+		w.mu.Unlock()
+		return
+	}
+	w.zoom = zoom
+	w.fontSize = purfecterm.ZoomFontSize(w.baseFontSize, zoom)
+	w.mu.Unlock()
+	w.onConfigure(w.drawingArea, nil)
+	w.updateScrollbar()
+	w.updateHorizScrollbar()
+	w.drawingArea.QueueDraw()
+}
+
+// GetZoom returns the font zoom factor
+func (w *Widget) GetZoom() float64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.zoom
+}
+
+// SetZoomCallback sets a callback for when the user changes the zoom
+func (w *Widget) SetZoomCallback(fn func(zoom float64)) {
+	w.onZoom = fn
+}
+
+// zoomTo sets the zoom as the user asked, telling the zoom callback if it changed
+func (w *Widget) zoomTo(zoom float64) {
+	before := w.GetZoom()
+	w.SetZoom(zoom)
+	if after := w.GetZoom(); after != before && w.onZoom != nil {
+		w.onZoom(after)
+	}
+}
+
+// isZoomModifier returns whether the modifiers held zoom with the wheel
+func isZoomModifier(state gdk.ModifierType) bool {
+	if state&gdk.CONTROL_MASK != 0 {
+		return true
+	}
+	return runtime.GOOS == "darwin" && (state&gdk.META_MASK != 0 || state&gdk.SUPER_MASK != 0)
+}
+
+// zoomScroll zooms for a wheel event with the zoom modifier held, returning
+// whether it did
+func (w *Widget) zoomScroll(scroll *gdk.EventScroll) bool {
+	if !isZoomModifier(scroll.State()) {
+		return false
+	}
+	steps := 0
+	switch scroll.Direction() {
+	case gdk.SCROLL_UP:
+		steps = 1
+	case gdk.SCROLL_DOWN:
+		steps = -1
+	case gdk.SCROLL_SMOOTH:
+		// Gather whole clicks, so touchpads zoom at a steady pace
+		w.zoomClicks -= scroll.DeltaY()
+		for ; w.zoomClicks >= 1; w.zoomClicks-- {
+			steps++
+		}
+		for ; w.zoomClicks <= -1; w.zoomClicks++ {
+			steps--
+		}
+	}
+	if steps != 0 {
+		w.zoomTo(purfecterm.StepZoom(w.GetZoom(), steps))
+	}
+	return true
+}
+
+// zoomKey zooms for a zoom shortcut, returning whether the key press was one
+func (w *Widget) zoomKey(keyval uint, hasShift, hasCtrl, hasAlt, hasMeta bool) bool {
+	macCmd := runtime.GOOS == "darwin" && hasMeta && !hasCtrl && !hasAlt
+	if !macCmd && !(hasCtrl && hasShift && !hasAlt && !hasMeta) {
+		return false
+	}
+	switch keyval {
+	case gdk.KEY_plus, gdk.KEY_equal, gdk.KEY_KP_Add:
+		w.zoomTo(purfecterm.StepZoom(w.GetZoom(), 1))
+	case gdk.KEY_minus, gdk.KEY_underscore, gdk.KEY_KP_Subtract:
+		w.zoomTo(purfecterm.StepZoom(w.GetZoom(), -1))
+	case gdk.KEY_0, gdk.KEY_parenright, gdk.KEY_KP_0, gdk.KEY_KP_Insert:
+		w.zoomTo(1.0)
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,29 @@
+package purfectermqt
+
+import "github.com/mappu/miqt/qt"
+
+// Accessibility
+// miqt can't give a widget an accessible of its own, so screen readers read the
+// terminal through the one Qt makes for any widget: its name is "Terminal" and its
+// description is the visible screen (see purfecterm/accessible.go), kept up to
+// date while an assistive technology is active. Qt tells the reader each time the
+// description changes. Without a text interface there is no caret to follow.
+
+// initAccessible names the widget for screen readers
+func (w *Widget) initAccessible() {
+	w.widget.SetAccessibleName("Terminal")
+}
+
+// updateAccessible sets the description to the visible screen if it changed and
+// a screen reader is listening
+func (w *Widget) updateAccessible() {
+	if !qt.QAccessible_IsActive() {
+		return
+	}
+	text, _ := w.buffer.AccessibleText()
+	if text == w.a11yText {
+		return
+	}
+	w.a11yText = text
+	w.widget.SetAccessibleDescription(text)
+}
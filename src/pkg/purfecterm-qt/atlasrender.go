@@ -0,0 +1,305 @@
+package purfectermqt
+
+import (
+	"math"
+	"strings"
+
+	"github.com/mappu/miqt/qt"
+	"github.com/phroun/pawscript/src/pkg/purfecterm"
+)
+
+// Atlas rendering
+// With ColorScheme.GPURendering set, the cells are drawn the way the GTK widget's GL
+// renderer draws them: each frame is compared with the last (see
+// purfecterm/gridframe.go) and only the rows that changed are repainted, into a back
+// buffer that paintEvent copies to the screen, with glyphs copied from an atlas
+// pixmap they were rendered into once each (see purfecterm/glyphatlas.go). The Qt
+// bindings don't include QOpenGLWidget, so the copies are done by QPainter; the
+// saving is in no longer shaping and rasterizing every glyph of every row on each
+// frame. QPainter can't tint white glyphs, so they are kept in the atlas by color.
+// Frames the grid can't describe are drawn by the usual renderer in paintEvent.
+
+// atlasSize is the size of the glyph atlas pixmap, in pixels per side
+const atlasSize = 2048
+
+// atlasRenderer is the state of a widget's atlas renderer
+// It is only used on the UI thread.
+type atlasRenderer struct {
+	atlas  *purfecterm.GlyphAtlas
+	glyphs *qt.QPixmap // The atlas's images
+
+	// Glyphs are rendered in this font at this cell size and scale
+	fontFamily string
+	fontSize   int
+	cellW      int
+	cellH      int
+	ascent     int
+	scale      float64 // Device pixels per logical pixel
+
+	// Damage tracking: the last frame drawn into the back buffer
+	back *qt.QPixmap
+	prev *purfecterm.GridFrame
+}
+
+// newAtlasRenderer creates an atlas renderer with an empty atlas
+func newAtlasRenderer() *atlasRenderer {
+	return &atlasRenderer{
+		atlas:  purfecterm.NewGlyphAtlas(atlasSize, atlasSize),
+		glyphs: qt.NewQPixmap2(atlasSize, atlasSize),
+	}
+}
+
+// resetAtlas empties the atlas and forgets the last frame
+func (r *atlasRenderer) resetAtlas() {
+	r.atlas.Reset()
+	r.glyphs.FillWithFillColor(qt.NewQColor2(qt.Transparent))
+	r.prev = nil
+}
+
+// paintCells draws the cells with the atlas renderer, returning false if the frame
+// can't be drawn this way and paintEvent should draw the cells itself
+func (w *Widget) paintCells(painter *qt.QPainter, opts purfecterm.GridFrameOptions, fontFamily string, fontSize, cellW, cellH, ascent int) bool {
+	frame, ok := purfecterm.BuildGridFrame(w.buffer, opts)
+	if !ok {
+		if w.atlas != nil {
+			w.atlas.prev = nil
+		}
+		return false
+	}
+	if w.atlas == nil {
+		w.atlas = newAtlasRenderer()
+	}
+	r := w.atlas
+
+	scale := purfecterm.DeviceScale(w.widget.DevicePixelRatioF())
+	if fontFamily != r.fontFamily || fontSize != r.fontSize || cellW != r.cellW || cellH != r.cellH || ascent != r.ascent || scale != r.scale {
+		r.fontFamily, r.fontSize, r.cellW, r.cellH, r.ascent, r.scale = fontFamily, fontSize, cellW, cellH, ascent, scale
+		r.resetAtlas()
+		r.back = nil
+	}
+
+	width, height := purfecterm.DevicePixels(w.widget.Width(), scale), purfecterm.DevicePixels(w.widget.Height(), scale)
+	if r.back == nil || r.back.Width() != width || r.back.Height() != height {
+		r.back = qt.NewQPixmap2(width, height)
+		r.back.SetDevicePixelRatio(scale)
+		r.prev = nil
+	}
+
+	if !r.updateRows(w, frame) {
+		// The glyphs on screen don't fit in the atlas even after starting over
+		r.resetAtlas()
+		return false
+	}
+	r.prev = frame
+
+	painter.DrawPixmap9(0, 0, r.back)
+	r.drawCursor(painter, frame.Cursor)
+	return true
+}
+
+// updateRows repaints the back buffer's rows that changed since the last frame,
+// returning false if their glyphs don't fit in the atlas
+func (r *atlasRenderer) updateRows(w *Widget, frame *purfecterm.GridFrame) bool {
+	back := qt.NewQPainter2(r.back.QPaintDevice)
+	defer back.End()
+
+	damaged := frame.DamagedRows(r.prev)
+	if r.prev == nil {
+		// Fill around the cells too
+		bg := frame.Background
+		back.FillRect5(0, 0, w.widget.Width(), w.widget.Height(), qt.NewQColor3(int(bg.R), int(bg.G), int(bg.B)))
+	}
+	for attempt := 0; attempt < 2; attempt++ {
+		full := false
+		for _, y := range damaged {
+			if !r.paintRow(w, back, frame, y) {
+				full = true
+				break
+			}
+		}
+		if !full {
+			return true
+		}
+		// Start the atlas over with just this frame's glyphs
+		r.resetAtlas()
+		damaged = frame.DamagedRows(nil)
+	}
+	return false
+}
+
+// paintRow paints row y of the frame into the back buffer, returning false if a
+// glyph doesn't fit in the atlas
+func (r *atlasRenderer) paintRow(w *Widget, back *qt.QPainter, frame *purfecterm.GridFrame, y int) bool {
+	cellW, cellH := r.cellW, r.cellH
+	cellY := y * cellH
+	bg := frame.Background
+	back.FillRect5(terminalLeftPadding, cellY, frame.Cols*cellW, cellH, qt.NewQColor3(int(bg.R), int(bg.G), int(bg.B)))
+
+	var runs []purfecterm.LigatureRun
+	if frame.Runs != nil {
+		runs = frame.Runs[y]
+	}
+	for x, cell := range frame.Cells[y] {
+		cellX := x*cellW + terminalLeftPadding
+
+		if cell.Bg != frame.Background {
+			back.FillRect5(cellX, cellY, cellW, cellH, qt.NewQColor3(int(cell.Bg.R), int(cell.Bg.G), int(cell.Bg.B)))
+		}
+
+		// A ligature run is one atlas image as wide as its cells, drawn with its last
+		// cell so the backgrounds of the others are under it
+		text, width, textX := cell.Text, 1, cellX
+		if i := purfecterm.LigatureRunAt(runs, x); i >= 0 {
+			text, width = "", 0
+			if runs[i].End-1 == x {
+				text, width = runs[i].Text, runs[i].End-runs[i].Start
+				textX -= (width - 1) * cellW
+			}
+		}
+		if text != "" {
+			key := purfecterm.AtlasKey{Text: text, Bold: cell.Bold, Italic: cell.Italic, Color: cell.Fg}
+			rect, ok := r.atlas.Lookup(key)
+			if !ok {
+				if rect, ok = r.atlas.Add(key, purfecterm.DevicePixels(width*cellW, r.scale), purfecterm.DevicePixels(cellH, r.scale)); !ok {
+					return false
+				}
+				r.renderGlyph(w, key, rect)
+			}
+			r.copyGlyph(back, rect, textX, cellY)
+		}
+
+		if cell.Underline != purfecterm.UnderlineNone {
+			ulQColor := qt.NewQColor3(int(cell.UnderlineColor.R), int(cell.UnderlineColor.G), int(cell.UnderlineColor.B))
+			underlineY := cellY + cellH - 2
+			switch cell.Underline {
+			case purfecterm.UnderlineSingle:
+				back.FillRect5(cellX, underlineY, cellW, 1, ulQColor)
+			case purfecterm.UnderlineDouble:
+				back.FillRect5(cellX, underlineY-2, cellW, 1, ulQColor)
+				back.FillRect5(cellX, underlineY+1, cellW, 1, ulQColor)
+			case purfecterm.UnderlineCurly:
+				// Sine wave: 2 cycles per cell
+				pen := qt.NewQPen3(ulQColor)
+				pen.SetWidth(1)
+				back.SetPenWithPen(pen)
+				path := qt.NewQPainterPath()
+				path.MoveTo2(float64(cellX), float64(underlineY))
+				steps := max(cellW/2, 4)
+				for s := 0; s <= steps; s++ {
+					t := float64(s) / float64(steps)
+					path.LineTo2(float64(cellX)+t*float64(cellW), float64(underlineY)+1.5*math.Sin(t*2*2*math.Pi))
+				}
+				back.DrawPath(path)
+			case purfecterm.UnderlineDotted:
+				for dotX := cellX; dotX < cellX+cellW; dotX += 3 {
+					back.FillRect5(dotX, underlineY, 1, 1, ulQColor)
+				}
+			case purfecterm.UnderlineDashed:
+				for dashX := cellX; dashX < cellX+cellW; dashX += 6 {
+					back.FillRect5(dashX, underlineY, min(4, cellX+cellW-dashX), 1, ulQColor)
+				}
+			}
+		}
+		if cell.Strikethrough {
+			back.FillRect5(cellX, cellY+(cellH*4/10), cellW, 1, qt.NewQColor3(int(cell.Fg.R), int(cell.Fg.G), int(cell.Fg.B)))
+		}
+		if cell.Overline {
+			back.FillRect5(cellX, cellY, cellW, 1, qt.NewQColor3(int(cell.Fg.R), int(cell.Fg.G), int(cell.Fg.B)))
+		}
+	}
+	return true
+}
+
+// copyGlyph copies a glyph's atlas image to the cell at x, y, pixel for pixel from
+// the device pixel the cell starts on, so it isn't resampled at fractional scales
+// (see purfecterm/hidpi.go)
+func (r *atlasRenderer) copyGlyph(back *qt.QPainter, rect purfecterm.AtlasRect, x, y int) {
+	target := qt.NewQRectF4(
+		float64(purfecterm.SnapToDevice(float64(x), r.scale))/r.scale,
+		float64(purfecterm.SnapToDevice(float64(y), r.scale))/r.scale,
+		float64(rect.W)/r.scale, float64(rect.H)/r.scale)
+	back.DrawPixmap(target, r.glyphs, qt.NewQRectF4(float64(rect.X), float64(rect.Y), float64(rect.W), float64(rect.H)))
+}
+
+// drawCursor draws the cursor over the copied back buffer: its shape, or the outline
+// of an unfocused block (a focused block is drawn by its cell's swapped colors)
+func (r *atlasRenderer) drawCursor(painter *qt.QPainter, c purfecterm.GridCursor) {
+	if !c.Visible {
+		return
+	}
+	cellW, cellH := r.cellW, r.cellH
+	cellX := c.X*cellW + terminalLeftPadding
+	cellY := c.Y * cellH
+	cursorQColor := qt.NewQColor3(int(c.Color.R), int(c.Color.G), int(c.Color.B))
+	switch c.Shape {
+	case 0:
+		if !c.Focused {
+			pen := qt.NewQPen3(cursorQColor)
+			pen.SetWidth(1)
+			painter.SetPenWithPen(pen)
+			painter.DrawRect2(cellX, cellY, cellW-1, cellH-1)
+		}
+	case 1:
+		thickness := cellH / 4
+		if !c.Focused {
+			thickness = cellH / 6
+		}
+		painter.FillRect5(cellX, cellY+cellH-thickness, cellW, thickness, cursorQColor)
+	case 2:
+		thickness := 2
+		if !c.Focused {
+			thickness = 1
+		}
+		painter.FillRect5(cellX, cellY, thickness, cellH, cursorQColor)
+	}
+}
+
+// renderGlyph renders a glyph, or a ligature run's text, in its color into its atlas
+// rectangle. It is fitted to its cells as paintEvent fits text: squeezed if wider,
+// centered if narrower.
+func (r *atlasRenderer) renderGlyph(w *Widget, key purfecterm.AtlasKey, rect purfecterm.AtlasRect) {
+	painter := qt.NewQPainter2(r.glyphs.QPaintDevice)
+	defer painter.End()
+	painter.SetCompositionMode(qt.QPainter__CompositionMode_Source)
+	painter.FillRect5(rect.X, rect.Y, rect.W, rect.H, qt.NewQColor2(qt.Transparent))
+	painter.SetCompositionMode(qt.QPainter__CompositionMode_SourceOver)
+	painter.SetClipRect2(rect.X, rect.Y, rect.W, rect.H)
+
+	charFontFamily := w.getFontForCharacter([]rune(key.Text)[0], r.fontFamily, r.fontSize)
+	font := qt.NewQFont6(charFontFamily, r.fontSize)
+	font.SetFixedPitch(charFontFamily == r.fontFamily)
+	font.SetBold(key.Bold)
+	font.SetItalic(key.Italic)
+	painter.SetFont(font)
+	painter.SetPen(qt.NewQColor3(int(key.Color.R), int(key.Color.G), int(key.Color.B)))
+
+	// Same faux bold test as paintEvent
+	useFauxBold := false
+	if key.Bold {
+		if strings.HasPrefix(charFontFamily, "Menlo") {
+			useFauxBold = true
+		} else if !strings.Contains(qt.NewQFontInfo(font).StyleName(), "Bold") {
+			useFauxBold = true
+		}
+	}
+
+	actualWidth := float64(qt.NewQFontMetrics(font).HorizontalAdvance(key.Text))
+	cellW := float64(rect.W) / r.scale
+	textScaleX := 1.0
+	xOffset := 0.0
+	if actualWidth > cellW {
+		textScaleX = cellW / actualWidth
+	} else {
+		xOffset = (cellW - actualWidth) / 2
+	}
+
+	painter.Translate2(float64(rect.X), float64(rect.Y))
+	painter.Scale(r.scale, r.scale)
+	painter.Translate2(xOffset, float64(r.ascent))
+	painter.Scale(textScaleX, 1)
+	painter.DrawText3(0, 0, key.Text)
+	if useFauxBold {
+		fauxOffset := math.Ceil(float64(r.fontSize)/20.0) / textScaleX
+		painter.DrawText3(int(math.Round(fauxOffset)), 0, key.Text)
+	}
+}
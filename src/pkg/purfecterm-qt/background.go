@@ -0,0 +1,106 @@
+package purfectermqt
+
+import (
+	"math"
+
+	"github.com/mappu/miqt/qt"
+	"github.com/phroun/pawscript/src/pkg/purfecterm"
+)
+
+// Background
+// paintEvent paints the scheme's background (see purfecterm/background.go) with its
+// opacity, replacing what the window drew under the terminal so the desktop shows
+// through where the window is translucent (see UseTranslucentWindow), and draws the
+// background image under the text, scaled to cover the widget and faded toward the
+// background color. The image, fade and color are composed once per size into a
+// pixmap. Qt has no portable way to ask for blur, so BackgroundBlur is ignored.
+
+// backdrop is the background image and the background composed from it for the
+// widget's size
+type backdrop struct {
+	path  string      // Image file the image was loaded from
+	image *qt.QPixmap // nil if there is none or it couldn't be loaded
+
+	composed *qt.QPixmap // Color, image and fade, at the size, color and fade below
+	width    int
+	height   int
+	scale    float64
+	bg       purfecterm.Color
+	dim      float64
+}
+
+// UseTranslucentWindow lets a top-level window show a terminal's background
+// opacity: the window gets an alpha channel, and content, its central widget, keeps
+// filling the rest of it as before. Call it before the window is shown.
+func UseTranslucentWindow(win, content *qt.QWidget) {
+	win.SetAttribute(qt.WA_TranslucentBackground)
+	content.SetAutoFillBackground(true)
+}
+
+// drawBackground fills the widget with the scheme's background, its opacity and
+// its image
+func (w *Widget) drawBackground(painter *qt.QPainter, scheme purfecterm.ColorScheme, isDark bool) {
+	bg := scheme.Background(isDark)
+	width, height := w.widget.Width(), w.widget.Height()
+	alpha := scheme.BackgroundAlpha()
+	if alpha < 1 && !w.widget.Window().TestAttribute(qt.WA_TranslucentBackground) {
+		// Drawn see-through, it would only be darker
+		alpha = 1
+	}
+	if alpha == 1 && scheme.BackgroundImage == "" {
+		painter.FillRect5(0, 0, width, height, qt.NewQColor3(int(bg.R), int(bg.G), int(bg.B)))
+		return
+	}
+
+	painter.Save()
+	// Clear what the window drew, then lay the opaque background over it at the
+	// scheme's opacity
+	painter.SetCompositionMode(qt.QPainter__CompositionMode_Source)
+	painter.FillRect5(0, 0, width, height, qt.NewQColor11(0, 0, 0, 0))
+	painter.SetCompositionMode(qt.QPainter__CompositionMode_SourceOver)
+	painter.SetOpacity(alpha)
+	if composed := w.composeBackground(scheme.BackgroundImage, bg, scheme.BackgroundDimming(), width, height); composed != nil {
+		painter.DrawPixmap9(0, 0, composed)
+	} else {
+		painter.FillRect5(0, 0, width, height, qt.NewQColor3(int(bg.R), int(bg.G), int(bg.B)))
+	}
+	painter.Restore()
+}
+
+// composeBackground returns the background color with the image at path over it,
+// scaled to cover width x height and faded by dim, or nil if there is no image or
+// it can't be loaded
+func (w *Widget) composeBackground(path string, bg purfecterm.Color, dim float64, width, height int) *qt.QPixmap {
+	d := &w.backdrop
+	if path != d.path {
+		*d = backdrop{path: path}
+		if path != "" {
+			if image := qt.NewQPixmap4(path); !image.IsNull() {
+				d.image = image
+			}
+		}
+	}
+	if d.image == nil || width <= 0 || height <= 0 {
+		return nil
+	}
+	scale := purfecterm.DeviceScale(w.widget.DevicePixelRatioF())
+	if d.composed != nil && width == d.width && height == d.height && scale == d.scale && bg == d.bg && dim == d.dim {
+		return d.composed
+	}
+
+	composed := qt.NewQPixmap2(purfecterm.DevicePixels(width, scale), purfecterm.DevicePixels(height, scale))
+	composed.SetDevicePixelRatio(scale)
+	painter := qt.NewQPainter2(composed.QPaintDevice)
+	painter.FillRect5(0, 0, width, height, qt.NewQColor3(int(bg.R), int(bg.G), int(bg.B)))
+	coverScale, x, y := purfecterm.ImageCover(d.image.Width(), d.image.Height(), width, height)
+	painter.SetRenderHint(qt.QPainter__SmoothPixmapTransform)
+	painter.DrawPixmap11(int(math.Round(x)), int(math.Round(y)),
+		int(math.Round(float64(d.image.Width())*coverScale)), int(math.Round(float64(d.image.Height())*coverScale)), d.image)
+	if dim > 0 {
+		painter.FillRect5(0, 0, width, height, qt.NewQColor11(int(bg.R), int(bg.G), int(bg.B), int(math.Round(dim*255))))
+	}
+	painter.End()
+
+	d.composed, d.width, d.height, d.scale, d.bg, d.dim = composed, width, height, scale, bg, dim
+	return composed
+}
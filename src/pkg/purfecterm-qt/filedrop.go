@@ -0,0 +1,73 @@
+package purfectermqt
+
+import (
+	"github.com/mappu/miqt/qt"
+	"github.com/phroun/pawscript/src/pkg/purfecterm"
+)
+
+// File drops
+// Files dropped on the terminal are typed at the cursor as their quoted paths (see
+// purfecterm/filedrop.go), and dropped text is typed as it is, both delivered as a
+// paste. URLs that aren't local files are typed as the URL. The embedder's file
+// drop callback is offered dropped files first, and may take them instead.
+
+// acceptsDrop returns whether dropped data is something the terminal types
+func acceptsDrop(mime *qt.QMimeData) bool {
+	return mime != nil && (mime.HasUrls() || mime.HasText())
+}
+
+// dragMoveEvent accepts drags of files and text
+func (w *Widget) dragMoveEvent(event *qt.QDragMoveEvent) {
+	if acceptsDrop(event.MimeData()) {
+		event.AcceptProposedAction()
+	}
+}
+
+// dropEvent types what was dropped
+func (w *Widget) dropEvent(event *qt.QDropEvent) {
+	mime := event.MimeData()
+	if !acceptsDrop(mime) {
+		return
+	}
+	event.AcceptProposedAction()
+
+	var text string
+	if mime.HasUrls() {
+		var paths []string
+		local := true
+		for _, u := range mime.Urls() {
+			if u.IsLocalFile() {
+				paths = append(paths, u.ToLocalFile())
+			} else {
+				paths = append(paths, u.ToString())
+				local = false
+			}
+		}
+		if local && w.offerFileDrop(paths) {
+			return
+		}
+		text = purfecterm.DropText(paths)
+	} else {
+		text = mime.Text()
+	}
+
+	w.mu.Lock()
+	onInput := w.onInput
+	w.mu.Unlock()
+
+	if onInput == nil || text == "" {
+		return
+	}
+	onInput(purfecterm.BracketPaste(text, w.buffer.IsBracketedPasteModeEnabled()))
+	w.widget.SetFocus()
+}
+
+// offerFileDrop offers dropped files to the file drop callback, returning whether
+// it took them
+func (w *Widget) offerFileDrop(paths []string) bool {
+	w.mu.Lock()
+	onFileDrop := w.onFileDrop
+	w.mu.Unlock()
+
+	return onFileDrop != nil && len(paths) > 0 && onFileDrop(paths)
+}
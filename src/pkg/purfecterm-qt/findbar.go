@@ -0,0 +1,169 @@
+package purfectermqt
+
+import (
+	"runtime"
+
+	"github.com/mappu/miqt/qt"
+	"github.com/phroun/pawscript/src/pkg/purfecterm"
+)
+
+// Find bar
+// Ctrl+Shift+F (Cmd+F on macOS; plain Ctrl+F belongs to the program, as readline's
+// forward-char) opens a bar over the top right of the terminal that searches the
+// scrollback as the pattern is typed (see purfecterm/search.go). Enter, Up and the
+// up button move to the previous (older) match, Shift+Enter, Down and the down
+// button to the next; Escape or the close button hides the bar and clears the
+// highlights. Keys the bar doesn't use stay in it rather than reaching the terminal.
+
+// findBar is the widgets of the find bar, created when it is first opened
+type findBar struct {
+	frame     *qt.QFrame
+	entry     *qt.QLineEdit
+	matchCase *qt.QCheckBox
+	regex     *qt.QCheckBox
+	status    *qt.QLabel
+}
+
+// initFindBar creates the find bar, hidden, over the terminal
+func (w *Widget) initFindBar() {
+	if w.find.frame != nil {
+		return
+	}
+	frame := qt.NewQFrame(w.widget)
+	frame.SetFrameShape(qt.QFrame__StyledPanel)
+	frame.SetAutoFillBackground(true)
+	layout := qt.NewQHBoxLayout(frame.QWidget)
+	layout.SetContentsMargins(4, 2, 4, 2)
+
+	entry := qt.NewQLineEdit(frame.QWidget)
+	entry.SetPlaceholderText("Find")
+	entry.SetClearButtonEnabled(true)
+	entry.SetMinimumWidth(180)
+	prev := qt.NewQToolButton(frame.QWidget)
+	prev.SetArrowType(qt.UpArrow)
+	prev.SetToolTip("Previous match (Enter)")
+	next := qt.NewQToolButton(frame.QWidget)
+	next.SetArrowType(qt.DownArrow)
+	next.SetToolTip("Next match (Shift+Enter)")
+	matchCase := qt.NewQCheckBox4("Match case", frame.QWidget)
+	regex := qt.NewQCheckBox4("Regex", frame.QWidget)
+	status := qt.NewQLabel(frame.QWidget)
+	closeButton := qt.NewQToolButton(frame.QWidget)
+	closeButton.SetText("✕")
+	closeButton.SetToolTip("Close (Escape)")
+	for _, b := range []*qt.QToolButton{prev, next, closeButton} {
+		b.SetAutoRaise(true)
+		b.SetFocusPolicy(qt.NoFocus)
+	}
+
+	layout.AddWidget(entry.QWidget)
+	layout.AddWidget(prev.QWidget)
+	layout.AddWidget(next.QWidget)
+	layout.AddWidget(matchCase.QWidget)
+	layout.AddWidget(regex.QWidget)
+	layout.AddWidget(status.QWidget)
+	layout.AddWidget(closeButton.QWidget)
+	w.find = findBar{frame: frame, entry: entry, matchCase: matchCase, regex: regex, status: status}
+
+	entry.OnTextChanged(func(string) { w.runFind(purfecterm.SearchBackward) })
+	entry.OnReturnPressed(func() {
+		if qt.QGuiApplication_KeyboardModifiers()&qt.ShiftModifier != 0 {
+			w.findNext(purfecterm.SearchForward)
+		} else {
+			w.findNext(purfecterm.SearchBackward)
+		}
+	})
+	prev.OnClicked(func() { w.findNext(purfecterm.SearchBackward) })
+	next.OnClicked(func() { w.findNext(purfecterm.SearchForward) })
+	matchCase.OnToggled(func(bool) { w.runFind(purfecterm.SearchBackward) })
+	regex.OnToggled(func(bool) { w.runFind(purfecterm.SearchBackward) })
+	closeButton.OnClicked(w.HideFindBar)
+
+	// The keys the bar's widgets ignore end here instead of going on to the terminal
+	frame.OnKeyPressEvent(func(super func(event *qt.QKeyEvent), event *qt.QKeyEvent) {
+		event.Accept()
+		switch qt.Key(event.Key()) {
+		case qt.Key_Escape:
+			w.HideFindBar()
+		case qt.Key_Up:
+			w.findNext(purfecterm.SearchBackward)
+		case qt.Key_Down:
+			w.findNext(purfecterm.SearchForward)
+		}
+	})
+
+	frame.Hide()
+}
+
+// isFindShortcut returns whether a key press opens the find bar (with hasCtrl the
+// physical Ctrl key and hasMeta Command on macOS, as keyPressEvent has them)
+func isFindShortcut(key qt.Key, hasShift, hasCtrl, hasAlt, hasMeta bool) bool {
+	if key != qt.Key_F {
+		return false
+	}
+	if runtime.GOOS == "darwin" && hasMeta && !hasCtrl && !hasAlt {
+		return true
+	}
+	return hasCtrl && hasShift && !hasAlt && !hasMeta
+}
+
+// positionFindBar places the find bar at the top right, left of the scrollbar
+func (w *Widget) positionFindBar() {
+	if w.find.frame == nil {
+		return
+	}
+	size := w.find.frame.SizeHint()
+	width := min(size.Width(), w.widget.Width()-16)
+	w.find.frame.SetGeometry(max(w.widget.Width()-width-16, 0), 4, width, size.Height())
+}
+
+// ShowFindBar opens the find bar and focuses its pattern, selected so typing
+// replaces it
+func (w *Widget) ShowFindBar() {
+	w.initFindBar()
+	w.positionFindBar()
+	w.find.frame.Show()
+	w.find.frame.Raise()
+	w.find.entry.SetFocus()
+	w.find.entry.SelectAll()
+	if w.find.entry.Text() != "" {
+		w.runFind(purfecterm.SearchBackward)
+	}
+}
+
+// HideFindBar closes the find bar, clears the highlights and focuses the terminal
+func (w *Widget) HideFindBar() {
+	if w.find.frame == nil || !w.find.frame.IsVisible() {
+		return
+	}
+	w.find.frame.Hide()
+	w.buffer.ClearSearch()
+	w.widget.SetFocus()
+	w.widget.Update()
+}
+
+// runFind searches for the find bar's pattern from the view
+func (w *Widget) runFind(direction purfecterm.SearchDirection) {
+	pattern := purfecterm.SearchPattern(w.find.entry.Text(), w.find.regex.IsChecked(), w.find.matchCase.IsChecked())
+	matches, current, err := w.buffer.Search(pattern, direction)
+	if pattern == "" {
+		w.find.status.SetText("")
+	} else {
+		w.find.status.SetText(purfecterm.SearchStatus(len(matches), current, err))
+	}
+	w.updateScrollbar()
+	w.widget.Update()
+}
+
+// findNext moves to the next match in direction, searching first if the output
+// has no search (as after it was cleared)
+func (w *Widget) findNext(direction purfecterm.SearchDirection) {
+	if _, ok := w.buffer.SearchNext(direction); !ok {
+		w.runFind(direction)
+		return
+	}
+	matches, current := w.buffer.GetSearchMatches()
+	w.find.status.SetText(purfecterm.SearchStatus(len(matches), current, nil))
+	w.updateScrollbar()
+	w.widget.Update()
+}
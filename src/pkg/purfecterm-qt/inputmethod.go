@@ -0,0 +1,94 @@
+package purfectermqt
+
+import (
+	"github.com/mappu/miqt/qt"
+	"github.com/phroun/pawscript/src/pkg/purfecterm"
+)
+
+// Input methods
+// Qt gives the widget the input method's events (WA_InputMethodEnabled): text it
+// commits is sent like typed text, and the text being composed (the preedit) is
+// drawn over the cells at the cursor, underlined, until it is committed or
+// cancelled. Qt asks where the cursor is so the candidate window opens next to it,
+// and is told when that changes.
+
+// imState is the text the input method is composing and where it was told the cursor is
+type imState struct {
+	preedit       string
+	preeditCursor int // The input method's cursor, in characters into preedit
+
+	cursorRect [4]int // x, y, width, height
+}
+
+// inputMethodEvent sends committed text and keeps the preedit for drawing
+func (w *Widget) inputMethodEvent(event *qt.QInputMethodEvent) {
+	event.Accept()
+	if commit := event.CommitString(); commit != "" {
+		w.mu.Lock()
+		onInput := w.onInput
+		keyMap := w.keyMap
+		w.mu.Unlock()
+
+		if onInput != nil {
+			w.buffer.NotifyKeyboardActivity()
+			if data := keyMap.Translate([]byte(commit)); len(data) > 0 {
+				onInput(data)
+			}
+		}
+	}
+
+	w.im.preedit = event.PreeditString()
+	w.im.preeditCursor = len([]rune(w.im.preedit))
+	for _, attr := range event.Attributes() {
+		if attr.Type() == qt.QInputMethodEvent__Cursor {
+			w.im.preeditCursor = attr.Start()
+		}
+	}
+	w.widget.Update()
+}
+
+// inputMethodQuery answers the input method's questions about the cursor
+func (w *Widget) inputMethodQuery(super func(query qt.InputMethodQuery) *qt.QVariant, query qt.InputMethodQuery) *qt.QVariant {
+	switch query {
+	case qt.ImEnabled:
+		return qt.NewQVariant11(true)
+	case qt.ImCursorRectangle:
+		r := w.im.cursorRect
+		return qt.NewQVariant31(qt.NewQRect4(r[0], r[1], r[2], r[3]))
+	}
+	return super(query)
+}
+
+// setIMCursorRect records where the cursor is drawn, telling the input method if it moved
+func (w *Widget) setIMCursorRect(x, y, width, height int) {
+	rect := [4]int{x, y, width, height}
+	if rect != w.im.cursorRect {
+		w.im.cursorRect = rect
+		qt.QGuiApplication_InputMethod().Update(qt.ImCursorRectangle)
+	}
+}
+
+// drawPreedit draws the text being composed over the cells at the cursor: on the
+// terminal background, underlined, with a bar at the input method's cursor
+func (w *Widget) drawPreedit(painter *qt.QPainter, x, y, cellH, ascent int, fontFamily string, fontSize int, fg, bg purfecterm.Color) {
+	text := w.im.preedit
+	if text == "" {
+		return
+	}
+	font := qt.NewQFont6(fontFamily, fontSize)
+	metrics := qt.NewQFontMetrics(font)
+	width := metrics.HorizontalAdvance(text)
+
+	fgQColor := qt.NewQColor3(int(fg.R), int(fg.G), int(fg.B))
+	painter.FillRect5(x, y, width, cellH, qt.NewQColor3(int(bg.R), int(bg.G), int(bg.B)))
+	painter.SetFont(font)
+	painter.SetPen(fgQColor)
+	painter.DrawText3(x, y+ascent, text)
+	painter.FillRect5(x, y+cellH-2, width, 1, fgQColor)
+
+	runes := []rune(text)
+	if w.im.preeditCursor >= 0 && w.im.preeditCursor <= len(runes) {
+		cursorX := x + metrics.HorizontalAdvance(string(runes[:w.im.preeditCursor]))
+		painter.FillRect5(cursorX, y, 1, cellH, fgQColor)
+	}
+}
@@ -0,0 +1,84 @@
+package purfectermqt
+
+import (
+	"math"
+	"strings"
+
+	"github.com/mappu/miqt/qt"
+	"github.com/phroun/pawscript/src/pkg/purfecterm"
+)
+
+// Ligatures
+// With ColorScheme.Ligatures set, paintEvent draws runs of cells that may join (see
+// purfecterm/ligature.go) with one DrawText, so Qt shapes them together and the
+// font's ligatures and contextual alternates are applied. The run is drawn with its
+// last cell, after the backgrounds of all its cells, fitted to the run's width. Runs
+// are only found on normal lines; cells with custom glyphs, blink, flex width or the
+// cursor are left out so they keep being drawn alone. The atlas renderer keeps a
+// run's text as one image (see atlasrender.go).
+
+// ligatureRuns returns the ligature runs of visible row y, by screen column, for
+// the cols columns from horizOffset. cursorX is the screen column the cursor is
+// drawn in on this row, or -1.
+func (w *Widget) ligatureRuns(y, cols, horizOffset, cursorX int) []purfecterm.LigatureRun {
+	cells := make([]purfecterm.Cell, cols)
+	texts := make([]string, cols)
+	for x := 0; x < cols; x++ {
+		cell := w.buffer.GetVisibleCell(x, y)
+		cells[x] = cell
+		if x == cursorX || cell.Blink || cell.FlexWidth || w.buffer.GetGlyph(cell.Char) != nil {
+			continue
+		}
+		texts[x] = cell.String()
+	}
+	return purfecterm.FindLigatureRuns(texts, func(a, b int) bool {
+		ca, cb := &cells[a], &cells[b]
+		return ca.Foreground == cb.Foreground && ca.Background == cb.Background &&
+			ca.Bold == cb.Bold && ca.Italic == cb.Italic && ca.Reverse == cb.Reverse &&
+			w.buffer.GetSearchHighlight(a+horizOffset, y) == w.buffer.GetSearchHighlight(b+horizOffset, y) &&
+			w.buffer.IsInSelection(a+horizOffset, y) == w.buffer.IsInSelection(b+horizOffset, y)
+	})
+}
+
+// drawLigatureRun draws a run's text in one go over its cells, which start at runX
+// and are baseCharWidth wide before scaling, centered if narrower than the run and
+// squeezed if wider
+func (w *Widget) drawLigatureRun(painter *qt.QPainter, run purfecterm.LigatureRun, runX, cellY int, fontFamily string, fontSize int, bold, italic bool, fg purfecterm.Color, baseCharWidth, baseCharAscent int, horizScale, vertScale float64) {
+	runFont := w.getFontForCharacter(rune(run.Text[0]), fontFamily, fontSize)
+	drawFont := qt.NewQFont6(runFont, fontSize)
+	drawFont.SetFixedPitch(runFont == fontFamily)
+	drawFont.SetBold(bold)
+	drawFont.SetItalic(italic)
+
+	// Same faux bold test as paintEvent
+	useFauxBold := false
+	if bold {
+		if strings.HasPrefix(runFont, "Menlo") {
+			useFauxBold = true
+		} else if !strings.Contains(qt.NewQFontInfo(drawFont).StyleName(), "Bold") {
+			useFauxBold = true
+		}
+	}
+
+	actualWidth := float64(qt.NewQFontMetrics(drawFont).HorizontalAdvance(run.Text))
+	targetWidth := float64((run.End - run.Start) * baseCharWidth)
+	textScaleX := horizScale
+	xOffset := 0.0
+	if actualWidth > targetWidth {
+		textScaleX *= targetWidth / actualWidth
+	} else {
+		xOffset = (targetWidth - actualWidth) / 2.0 * horizScale
+	}
+
+	painter.Save()
+	painter.SetFont(drawFont)
+	painter.SetPen(qt.NewQColor3(int(fg.R), int(fg.G), int(fg.B)))
+	painter.Translate2(float64(runX)+xOffset, float64(cellY)+float64(baseCharAscent)*vertScale)
+	painter.Scale(textScaleX, vertScale)
+	painter.DrawText3(0, 0, run.Text)
+	if useFauxBold {
+		fauxOffset := math.Ceil(float64(fontSize)/20.0) / textScaleX
+		painter.DrawText3(int(math.Round(fauxOffset)), 0, run.Text)
+	}
+	painter.Restore()
+}
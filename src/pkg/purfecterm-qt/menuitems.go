@@ -0,0 +1,42 @@
+package purfectermqt
+
+import "github.com/phroun/pawscript/src/pkg/purfecterm"
+
+// Context menu items
+// The entries the embedder adds (see purfecterm/menuitems.go) follow the widget's
+// own in the context menu, after a separator. They are made again each time the
+// menu opens, so entries added or removed since appear, enabled as their
+// predicates say.
+
+// SetContextMenuItems sets the entries added to the context menu (nil for none)
+func (w *Widget) SetContextMenuItems(items *purfecterm.MenuItems) {
+	w.mu.Lock()
+	w.menuItems = items
+	w.mu.Unlock()
+}
+
+// updateContextMenuItems replaces the added entries in the context menu with the
+// current ones
+func (w *Widget) updateContextMenuItems() {
+	for _, action := range w.menuActions {
+		w.contextMenu.RemoveAction(action)
+		action.DeleteLater()
+	}
+	w.menuActions = nil
+
+	w.mu.Lock()
+	items := w.menuItems.Items()
+	w.mu.Unlock()
+	if len(items) == 0 {
+		return
+	}
+	w.menuActions = append(w.menuActions, w.contextMenu.AddSeparator())
+	for _, item := range items {
+		action := w.contextMenu.AddAction(item.Label)
+		action.SetEnabled(item.IsEnabled())
+		if activate := item.Activate; activate != nil {
+			action.OnTriggered(activate)
+		}
+		w.menuActions = append(w.menuActions, action)
+	}
+}
@@ -0,0 +1,145 @@
+package purfectermqt
+
+import (
+	"github.com/mappu/miqt/qt"
+	"github.com/mappu/miqt/qt/mainthread"
+	"github.com/phroun/pawscript/src/pkg/purfecterm"
+)
+
+// Panes
+// When a program splits the terminal (OSC 7005, see purfecterm/panes.go) each pane
+// gets a widget of its own, and the terminal's container lays them out with
+// QSplitters following the buffer's pane layout. The layout is rebuilt whenever it
+// changes; the pane widgets are kept and moved into the new splitters. The panes share
+// the terminal's font, colors and callbacks, so their input goes to the same program.
+
+// setupPanes creates the container holding the pane layout
+func (t *Terminal) setupPanes() error {
+	t.container = qt.NewQWidget2()
+	layout := qt.NewQVBoxLayout2()
+	layout.SetContentsMargins(0, 0, 0, 0)
+	t.container.SetLayout(layout.QLayout)
+	layout.AddWidget(t.widget.QWidget())
+	t.panes = make(map[int]*Widget)
+
+	buffer := t.widget.Buffer()
+	t.widget.SetFocusCallback(func() {
+		buffer.FocusPane(0)
+	})
+	buffer.SetPaneCallback(func(layoutChanged bool) {
+		mainthread.Start(func() {
+			if layoutChanged {
+				t.updatePanes()
+			}
+			if w := t.paneWidget(buffer.FocusedPane()); w != nil {
+				w.GrabFocus()
+			}
+		})
+	})
+	return nil
+}
+
+// paneWidget returns the widget of pane id, or nil if there is no such pane
+func (t *Terminal) paneWidget(id int) *Widget {
+	if id == 0 {
+		return t.widget
+	}
+	return t.panes[id]
+}
+
+// newPaneWidget creates the widget for a pane, set up like the terminal's own
+func (t *Terminal) newPaneWidget(id int, buffer *purfecterm.Buffer) *Widget {
+	w := NewWidgetForBuffer(buffer)
+	w.SetZoom(t.widget.GetZoom())
+	w.SetFont(t.options.FontFamily, t.options.FontSize)
+	w.SetFontFallbacks(t.unicodeFont, t.cjkFont)
+	w.SetColorScheme(t.options.Scheme)
+	w.SetInputCallback(t.onInput)
+	w.SetKeyMap(t.keyMap)
+	w.SetContextMenuItems(t.menuItems)
+	w.SetLinkClickCallback(t.onLinkClick)
+	w.SetFileDropCallback(t.onFileDrop)
+	w.SetZoomCallback(t.zoomed)
+	root := t.widget.Buffer()
+	w.SetFocusCallback(func() {
+		root.FocusPane(id)
+	})
+	return w
+}
+
+// forEachPane calls fn with the widgets of the panes other than 0
+func (t *Terminal) forEachPane(fn func(w *Widget)) {
+	for _, w := range t.panes {
+		fn(w)
+	}
+}
+
+// updatePanes rebuilds the splitters for the current pane layout
+func (t *Terminal) updatePanes() {
+	layout := t.widget.Buffer().PaneLayout()
+	width, height := t.container.Width(), t.container.Height()
+
+	// Take the widgets out of the old splitters, which are then deleted
+	containerLayout := t.container.Layout()
+	containerLayout.RemoveWidget(t.widget.QWidget())
+	t.widget.QWidget().SetParent(t.container)
+	t.forEachPane(func(w *Widget) {
+		w.QWidget().SetParent(t.container)
+	})
+	for _, splitter := range t.splitters {
+		containerLayout.RemoveWidget(splitter.QWidget)
+		splitter.DeleteLater()
+	}
+	t.splitters = nil
+
+	// Delete the widgets of closed panes
+	for id, w := range t.panes {
+		if layout == nil || t.widget.Buffer().PaneBuffer(id) == nil {
+			w.QWidget().DeleteLater()
+			delete(t.panes, id)
+		}
+	}
+
+	if layout == nil {
+		containerLayout.AddWidget(t.widget.QWidget())
+	} else {
+		containerLayout.AddWidget(t.buildPanes(layout, width, height))
+	}
+	t.widget.QWidget().Show()
+	t.forEachPane(func(w *Widget) {
+		w.QWidget().Show()
+	})
+}
+
+// buildPanes returns the widget for a node of the pane layout, given the space it has
+func (t *Terminal) buildPanes(node *purfecterm.PaneNode, width, height int) *qt.QWidget {
+	if !node.IsSplit() {
+		w := t.paneWidget(node.ID)
+		if w == nil {
+			w = t.newPaneWidget(node.ID, node.Buffer)
+			t.panes[node.ID] = w
+		}
+		return w.QWidget()
+	}
+
+	orientation := qt.Horizontal
+	firstWidth, firstHeight := int(float64(width)*node.Ratio), height
+	secondWidth, secondHeight := width-firstWidth, height
+	if node.Direction == purfecterm.PaneStacked {
+		orientation = qt.Vertical
+		firstWidth, firstHeight = width, int(float64(height)*node.Ratio)
+		secondWidth, secondHeight = width, height-firstHeight
+	}
+
+	splitter := qt.NewQSplitter3(orientation)
+	splitter.SetChildrenCollapsible(false)
+	splitter.AddWidget(t.buildPanes(node.First, firstWidth, firstHeight))
+	splitter.AddWidget(t.buildPanes(node.Second, secondWidth, secondHeight))
+	if node.Direction == purfecterm.PaneStacked {
+		splitter.SetSizes([]int{firstHeight, secondHeight})
+	} else {
+		splitter.SetSizes([]int{firstWidth, secondWidth})
+	}
+	t.splitters = append(t.splitters, splitter)
+	return splitter.QWidget
+}
@@ -0,0 +1,78 @@
+package purfectermqt
+
+import (
+	"github.com/mappu/miqt/qt"
+	"github.com/phroun/pawscript/src/pkg/purfecterm"
+)
+
+// Scroll indicator
+// While the view is scrolled back, paintEvent shows how far back at the bottom
+// right and, once more output has arrived, a "N new lines" pill at the bottom
+// center (see purfecterm/scrollindicator.go). Clicking the pill jumps to the bottom.
+
+// scrollBadgePadding is the space around the indicator's and the pill's text
+const scrollBadgePadding = 8
+
+// drawScrollIndicator draws the scroll indicator and new lines pill over the cells,
+// clear of the scrollbars
+func (w *Widget) drawScrollIndicator(painter *qt.QPainter, fontFamily string, fontSize, lineHeight int) {
+	offset := w.buffer.GetEffectiveScrollOffset()
+	newLines := w.newOutput.Update(offset, w.buffer.GetLinesScrolledOff())
+	w.newLinesPill = nil
+	if offset <= 0 {
+		return
+	}
+
+	width, height := w.widget.Width(), w.widget.Height()
+	if w.scrollbar != nil && w.scrollbar.IsVisible() {
+		width -= w.scrollbar.Width()
+	}
+	if w.horizScrollbar != nil && w.horizScrollbar.IsVisible() {
+		height -= w.horizScrollbar.Height()
+	}
+	badgeH := lineHeight + scrollBadgePadding/2
+	bottom := height - badgeH - scrollBadgePadding
+
+	painter.Save()
+	defer painter.Restore()
+	painter.SetRenderHint(qt.QPainter__Antialiasing)
+	painter.SetPenWithStyle(qt.NoPen)
+
+	font := qt.NewQFont6(fontFamily, fontSize)
+	text := purfecterm.ScrollIndicatorText(offset)
+	badgeW := qt.NewQFontMetrics(font).HorizontalAdvance(text) + 2*scrollBadgePadding
+	drawBadge(painter, width-badgeW-scrollBadgePadding, bottom, badgeW, badgeH, text, font, 190)
+
+	if pill := purfecterm.NewLinesText(newLines); pill != "" {
+		font.SetBold(true)
+		pillW := qt.NewQFontMetrics(font).HorizontalAdvance(pill) + 3*scrollBadgePadding
+		x := (width - pillW) / 2
+		drawBadge(painter, x, bottom, pillW, badgeH, pill, font, 230)
+		w.newLinesPill = qt.NewQRect4(x, bottom, pillW, badgeH)
+	}
+}
+
+// drawBadge draws text centered in a dark rounded box of the given opacity (0-255)
+func drawBadge(painter *qt.QPainter, x, y, width, height int, text string, font *qt.QFont, alpha int) {
+	painter.SetBrush(qt.NewQBrush3(qt.NewQColor11(38, 38, 38, alpha)))
+	radius := float64(height) / 2
+	painter.DrawRoundedRect2(x, y, width, height, radius, radius)
+	painter.SetFont(font)
+	painter.SetPen(qt.NewQColor3(255, 255, 255))
+	painter.DrawText7(x, y, width, height, int(qt.AlignCenter), text)
+	painter.SetPenWithStyle(qt.NoPen)
+}
+
+// newLinesPillAt returns whether the new lines pill is at a point of the widget
+func (w *Widget) newLinesPillAt(pos *qt.QPoint) bool {
+	return w.newLinesPill != nil && w.newLinesPill.ContainsWithQPoint(pos)
+}
+
+// scrollToBottom scrolls the view back to the newest output
+func (w *Widget) scrollToBottom() {
+	w.buffer.SetScrollOffset(0)
+	w.buffer.NotifyManualVertScroll() // User initiated scroll
+	w.syncSmoothScroll()
+	w.updateScrollbar()
+	w.widget.Update()
+}
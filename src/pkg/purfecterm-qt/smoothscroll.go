@@ -0,0 +1,99 @@
+package purfectermqt
+
+import (
+	"time"
+
+	"github.com/mappu/miqt/qt"
+)
+
+// Smooth scrolling
+// The vertical scroll position moves by pixels (see purfecterm/smoothscroll.go):
+// wheel clicks glide three lines, and touchpads, which send pixel deltas, follow the
+// fingers and coast when they lift. Where the system coasts itself (momentum events
+// on macOS) its deltas are followed instead. paintEvent draws the cells shifted up
+// by the part of a line scrolled, and the atlas renderer leaves such frames to it.
+
+// wheelScrollLines is how many lines a wheel click scrolls
+const wheelScrollLines = 3
+
+// smoothScrollInterval is the frame time of gliding and coasting, in milliseconds
+const smoothScrollInterval = 16
+
+// smoothWheel scrolls vertically for a wheel event
+func (w *Widget) smoothWheel(event *qt.QWheelEvent) {
+	w.syncSmoothScroll()
+	now := time.Now()
+	pixels := event.PixelDelta()
+	switch {
+	case event.Phase() == qt.ScrollEnd:
+		w.smooth.Release(now)
+	case !pixels.IsNull():
+		// Positive deltas move the content down, back into the scrollback
+		w.smooth.Drag(float64(pixels.Y()), now)
+	default:
+		clicks := float64(event.AngleDelta().Y()) / 120
+		w.smooth.Glide(clicks * wheelScrollLines * float64(w.rowHeight()))
+	}
+	w.applySmoothScroll()
+	w.startSmoothScrollTimer()
+}
+
+// rowHeight returns the height of a row on screen, with the screen's vertical scale
+func (w *Widget) rowHeight() int {
+	w.mu.Lock()
+	charHeight := w.charHeight
+	w.mu.Unlock()
+	return max(int(float64(charHeight)*w.buffer.GetVerticalScale()), 1)
+}
+
+// syncSmoothScroll takes the scroll position from the buffer if it has scrolled by
+// other means
+func (w *Widget) syncSmoothScroll() {
+	w.smooth.Sync(w.buffer.GetScrollOffset(), w.buffer.GetMaxScrollOffset(), w.rowHeight())
+}
+
+// applySmoothScroll scrolls the buffer to the line the smooth position shows
+func (w *Widget) applySmoothScroll() {
+	offset := w.buffer.GetScrollOffset()
+	if lines := w.smooth.Lines(); lines != offset {
+		w.buffer.SetScrollOffset(lines)
+		if lines < offset {
+			// Only snap to 0 when scrolling DOWN into the magnetic zone
+			w.buffer.NormalizeScrollOffset()
+		}
+		w.buffer.NotifyManualVertScroll() // User initiated scroll
+		w.updateScrollbar()
+		w.syncSmoothScroll()
+	}
+	w.widget.Update()
+}
+
+// startSmoothScrollTimer runs the glide or coast, if one is under way, until it stops
+func (w *Widget) startSmoothScrollTimer() {
+	if !w.smooth.Moving() {
+		return
+	}
+	if w.smoothScrollTimer == nil {
+		w.smoothScrollTimer = qt.NewQTimer2(w.widget.QObject)
+		w.smoothScrollTimer.OnTimeout(func() {
+			w.syncSmoothScroll()
+			if !w.smooth.Step(time.Now()) {
+				w.smoothScrollTimer.Stop()
+			}
+			w.applySmoothScroll()
+		})
+	}
+	if !w.smoothScrollTimer.IsActive() {
+		w.smoothScrollTimer.Start(smoothScrollInterval)
+	}
+}
+
+// smoothScrollShift returns how many pixels higher the cells are drawn for the part
+// of a line scrolled (0 while the magnetic zone holds the screen in place)
+func (w *Widget) smoothScrollShift() int {
+	offset := w.buffer.GetScrollOffset()
+	if w.buffer.GetEffectiveScrollOffset() != offset {
+		return 0
+	}
+	return w.smooth.Shift(offset)
+}
@@ -3,23 +3,23 @@ package purfectermqt
 import (
 	"io"
 	"os"
-	"os/exec"
 	"sync"
 
 	"github.com/mappu/miqt/qt"
 	"github.com/phroun/pawscript/src"
 	"github.com/phroun/pawscript/src/pkg/purfecterm"
+	"github.com/phroun/pawscript/src/pkg/purfecterm/pty"
 )
 
 // Options configures terminal creation
 type Options struct {
 	Cols           int                    // Terminal width in columns (default: 80)
 	Rows           int                    // Terminal height in rows (default: 24)
-	ScrollbackSize int                    // Number of scrollback lines (default: 10000)
+	ScrollbackSize int                    // Number of scrollback lines (default: 100000)
 	FontFamily     string                 // Font family (default: "Monospace")
 	FontSize       int                    // Font size in points (default: 14)
 	Scheme         purfecterm.ColorScheme // Color scheme (default: DefaultColorScheme())
-	Shell          string                 // Shell to run (default: $SHELL or /bin/sh)
+	Shell          string                 // Shell to run (default: pty.DefaultShell())
 	WorkingDir     string                 // Initial working directory (default: current dir)
 }
 
@@ -28,13 +28,24 @@ type Terminal struct {
 	mu sync.Mutex
 
 	widget  *Widget
-	pty     purfecterm.PTY
-	cmd     *exec.Cmd
 	options Options
 
 	// I/O
-	running bool
-	done    chan struct{}
+	session     *pty.Session    // The running command, if any
+	onExit      func(err error) // Called when the command exits
+	onInput     func([]byte)    // Input from the terminal and its panes
+	onLinkClick func(uri string)
+	onFileDrop  func(paths []string) bool
+	keyMap      *purfecterm.KeyMap    // Key macros of the terminal and its panes
+	menuItems   *purfecterm.MenuItems // Entries the embedder added to the context menu
+	onZoom      func(zoom float64)    // Called when the user zooms the terminal or a pane
+
+	// Panes (see panes.go); only used on the UI thread
+	container   *qt.QWidget     // Holds the pane layout
+	panes       map[int]*Widget // Widgets of the panes other than 0
+	splitters   []*qt.QSplitter // Splitters of the current layout
+	unicodeFont string          // Font fallbacks, for new panes
+	cjkFont     string
 }
 
 // New creates a new terminal emulator
@@ -47,7 +58,7 @@ func New(opts Options) (*Terminal, error) {
 		opts.Rows = 24
 	}
 	if opts.ScrollbackSize <= 0 {
-		opts.ScrollbackSize = 10000
+		opts.ScrollbackSize = 100000
 	}
 	if opts.FontFamily == "" {
 		opts.FontFamily = "Monospace"
@@ -56,10 +67,7 @@ func New(opts Options) (*Terminal, error) {
 		opts.FontSize = 14
 	}
 	if opts.Shell == "" {
-		opts.Shell = os.Getenv("SHELL")
-		if opts.Shell == "" {
-			opts.Shell = "/bin/sh"
-		}
+		opts.Shell = pty.DefaultShell()
 	}
 	if opts.WorkingDir == "" {
 		opts.WorkingDir, _ = os.Getwd()
@@ -74,27 +82,29 @@ func New(opts Options) (*Terminal, error) {
 	widget.SetColorScheme(opts.Scheme)
 
 	t := &Terminal{
-		widget:  widget,
-		options: opts,
-		done:    make(chan struct{}),
+		widget:    widget,
+		options:   opts,
+		menuItems: purfecterm.NewMenuItems(),
 	}
 
 	// Set input callback
-	widget.SetInputCallback(func(data []byte) {
-		t.mu.Lock()
-		pty := t.pty
-		t.mu.Unlock()
-		if pty != nil {
-			pty.Write(data)
-		}
-	})
+	t.onInput = func(data []byte) {
+		t.Write(data)
+	}
+	widget.SetInputCallback(t.onInput)
+	widget.SetZoomCallback(t.zoomed)
+	widget.SetContextMenuItems(t.menuItems)
+
+	if err := t.setupPanes(); err != nil {
+		return nil, err
+	}
 
 	return t, nil
 }
 
-// Widget returns the Qt widget
+// Widget returns the Qt widget containing the terminal and its panes
 func (t *Terminal) Widget() *qt.QWidget {
-	return t.widget.QWidget()
+	return t.container
 }
 
 // UpdateScrollbars updates both vertical and horizontal scrollbars
@@ -125,94 +135,52 @@ func (t *Terminal) RunShell() error {
 // RunCommand runs a command in the terminal
 func (t *Terminal) RunCommand(name string, args ...string) error {
 	t.mu.Lock()
-	if t.running {
-		t.mu.Unlock()
-		return nil
+	defer t.mu.Unlock()
+	if t.session != nil && t.session.IsRunning() {
+		return nil // Already running
 	}
-	t.done = make(chan struct{})
-	t.mu.Unlock()
 
-	// Create PTY
-	pty, err := purfecterm.NewPTY()
+	session, err := pty.Start(t.widget.Buffer(), pty.Options{
+		Command: name,
+		Args:    args,
+		Dir:     t.options.WorkingDir,
+		Feed:    t.widget.Feed,
+		OnExit:  t.exited,
+	})
 	if err != nil {
 		return err
 	}
+	t.session = session
+	return nil
+}
 
+// exited passes a command's exit to the exit callback
+func (t *Terminal) exited(err error) {
 	t.mu.Lock()
-	t.pty = pty
+	onExit := t.onExit
 	t.mu.Unlock()
-
-	// Create command
-	cmd := exec.Command(name, args...)
-	cmd.Dir = t.options.WorkingDir
-	cmd.Env = append(os.Environ(),
-		"TERM=xterm-256color",
-		"COLORTERM=truecolor",
-	)
-
-	// Start PTY
-	if err := pty.Start(cmd); err != nil {
-		pty.Close()
-		return err
+	if onExit != nil {
+		onExit(err)
 	}
+}
 
+// SetExitCallback sets a callback to be invoked when the command run in the terminal exits
+// It is called from a background goroutine, not the UI thread.
+func (t *Terminal) SetExitCallback(fn func(err error)) {
 	t.mu.Lock()
-	t.cmd = cmd
-	t.running = true
+	t.onExit = fn
 	t.mu.Unlock()
-
-	// Set initial size
-	pty.Resize(t.options.Cols, t.options.Rows)
-
-	// Start reading from PTY
-	go t.readLoop()
-
-	// Wait for command to exit
-	go func() {
-		cmd.Wait()
-		t.mu.Lock()
-		t.running = false
-		t.mu.Unlock()
-		close(t.done)
-	}()
-
-	return nil
-}
-
-func (t *Terminal) readLoop() {
-	buf := make([]byte, 4096)
-	for {
-		t.mu.Lock()
-		pty := t.pty
-		running := t.running
-		t.mu.Unlock()
-
-		if !running || pty == nil {
-			return
-		}
-
-		n, err := pty.Read(buf)
-		if n > 0 {
-			t.widget.Feed(buf[:n])
-		}
-		if err != nil {
-			if err != io.EOF {
-				// Log error?
-			}
-			return
-		}
-	}
 }
 
 // Write writes to the terminal's PTY
 func (t *Terminal) Write(data []byte) (int, error) {
 	t.mu.Lock()
-	pty := t.pty
+	session := t.session
 	t.mu.Unlock()
-	if pty == nil {
+	if session == nil {
 		return 0, nil
 	}
-	return pty.Write(data)
+	return session.Write(data)
 }
 
 // WriteString writes a string to the terminal's PTY
@@ -227,13 +195,7 @@ func (t *Terminal) Clear() {
 
 // Resize resizes the terminal
 func (t *Terminal) Resize(cols, rows int) {
-	t.widget.Resize(cols, rows)
-	t.mu.Lock()
-	pty := t.pty
-	t.mu.Unlock()
-	if pty != nil {
-		pty.Resize(cols, rows)
-	}
+	t.widget.Resize(cols, rows) // The buffer resizes the command's pty
 }
 
 // GetSize returns the terminal size
@@ -247,32 +209,32 @@ func (t *Terminal) GetTerminalCapabilities() *pawscript.TerminalCapabilities {
 	return t.widget.GetTerminalCapabilities()
 }
 
-// Close closes the terminal
+// Close closes the terminal, killing its command if it is still running
 func (t *Terminal) Close() error {
 	t.mu.Lock()
-	pty := t.pty
-	cmd := t.cmd
+	session := t.session
 	t.mu.Unlock()
-
-	if cmd != nil && cmd.Process != nil {
-		cmd.Process.Kill()
-	}
-	if pty != nil {
-		pty.Close()
+	if session != nil {
+		return session.Close()
 	}
 	return nil
 }
 
-// Wait waits for the terminal process to exit
+// Wait waits for the terminal's command to exit
 func (t *Terminal) Wait() {
-	<-t.done
+	t.mu.Lock()
+	session := t.session
+	t.mu.Unlock()
+	if session != nil {
+		session.Wait()
+	}
 }
 
 // IsRunning returns true if a command is running
 func (t *Terminal) IsRunning() bool {
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	return t.running
+	return t.session != nil && t.session.IsRunning()
 }
 
 // GetSelectedText returns currently selected text
@@ -290,11 +252,21 @@ func (t *Terminal) PasteClipboard() {
 	t.widget.PasteClipboard()
 }
 
+// PastePrimary pastes the primary selection into terminal
+func (t *Terminal) PastePrimary() {
+	t.widget.PastePrimary()
+}
+
 // SelectAll selects all text
 func (t *Terminal) SelectAll() {
 	t.widget.SelectAll()
 }
 
+// ShowFindBar opens the scrollback find bar
+func (t *Terminal) ShowFindBar() {
+	t.widget.ShowFindBar()
+}
+
 // SetCursorVisible shows or hides the cursor
 func (t *Terminal) SetCursorVisible(visible bool) {
 	t.widget.SetCursorVisible(visible)
@@ -309,17 +281,101 @@ func (t *Terminal) SetWorkingDirectory(dir string) {
 
 // SetInputCallback sets a callback for handling keyboard input
 func (t *Terminal) SetInputCallback(fn func([]byte)) {
+	t.onInput = fn
 	t.widget.SetInputCallback(fn)
+	t.forEachPane(func(w *Widget) { w.SetInputCallback(fn) })
+}
+
+// SetKeyMap sets the key macros applied to keys before they are sent (nil for none)
+func (t *Terminal) SetKeyMap(keyMap *purfecterm.KeyMap) {
+	t.keyMap = keyMap
+	t.widget.SetKeyMap(keyMap)
+	t.forEachPane(func(w *Widget) { w.SetKeyMap(keyMap) })
+}
+
+// KeyMap returns the key macros applied to keys before they are sent, or nil
+func (t *Terminal) KeyMap() *purfecterm.KeyMap {
+	return t.keyMap
+}
+
+// SetContextMenuItems sets the entries added to the context menu of the terminal
+// and its panes (nil for none)
+func (t *Terminal) SetContextMenuItems(items *purfecterm.MenuItems) {
+	t.menuItems = items
+	t.widget.SetContextMenuItems(items)
+	t.forEachPane(func(w *Widget) { w.SetContextMenuItems(items) })
+}
+
+// ContextMenuItems returns the entries added to the context menu, to which the
+// embedder can add its own, such as "Run Selection as PawScript"
+func (t *Terminal) ContextMenuItems() *purfecterm.MenuItems {
+	return t.menuItems
+}
+
+// SetLinkClickCallback sets a callback for Ctrl+click on a hyperlink (OSC 8)
+func (t *Terminal) SetLinkClickCallback(fn func(uri string)) {
+	t.onLinkClick = fn
+	t.widget.SetLinkClickCallback(fn)
+	t.forEachPane(func(w *Widget) { w.SetLinkClickCallback(fn) })
+}
+
+// SetFileDropCallback sets a callback for files dropped on the terminal, which
+// returns whether it took them; files it doesn't take are typed as their paths
+func (t *Terminal) SetFileDropCallback(fn func(paths []string) bool) {
+	t.onFileDrop = fn
+	t.widget.SetFileDropCallback(fn)
+	t.forEachPane(func(w *Widget) { w.SetFileDropCallback(fn) })
 }
 
 // SetFontFallbacks sets the fallback fonts for Unicode and CJK characters
 func (t *Terminal) SetFontFallbacks(unicodeFont, cjkFont string) {
+	t.unicodeFont, t.cjkFont = unicodeFont, cjkFont
 	t.widget.SetFontFallbacks(unicodeFont, cjkFont)
+	t.forEachPane(func(w *Widget) { w.SetFontFallbacks(unicodeFont, cjkFont) })
 }
 
 // SetFont sets the terminal font family and size
 func (t *Terminal) SetFont(family string, size int) {
+	t.options.FontFamily, t.options.FontSize = family, size
 	t.widget.SetFont(family, size)
+	t.forEachPane(func(w *Widget) { w.SetFont(family, size) })
+}
+
+// SetZoom sets the font zoom factor of the terminal and its panes (1.0 for the
+// font size)
+func (t *Terminal) SetZoom(zoom float64) {
+	t.widget.SetZoom(zoom)
+	t.forEachPane(func(w *Widget) { w.SetZoom(zoom) })
+}
+
+// GetZoom returns the font zoom factor
+func (t *Terminal) GetZoom() float64 {
+	return t.widget.GetZoom()
+}
+
+// SetZoomCallback sets a callback for when the user zooms the terminal or a pane
+func (t *Terminal) SetZoomCallback(fn func(zoom float64)) {
+	t.onZoom = fn
+}
+
+// ZoomBy zooms the terminal and its panes in or out by steps, or back to 100% for
+// 0, as the zoom keys do, telling the zoom callback of a change
+func (t *Terminal) ZoomBy(steps int) {
+	zoom := 1.0
+	if steps != 0 {
+		zoom = purfecterm.StepZoom(t.GetZoom(), steps)
+	}
+	if zoom != t.GetZoom() {
+		t.zoomed(zoom)
+	}
+}
+
+// zoomed keeps the terminal and its panes at the zoom the user set in one of them
+func (t *Terminal) zoomed(zoom float64) {
+	t.SetZoom(zoom)
+	if t.onZoom != nil {
+		t.onZoom(zoom)
+	}
 }
 
 // --- Screen Scaling Mode Methods ---
@@ -384,6 +440,42 @@ func (t *Terminal) SaveScrollbackANS() string {
 	return t.widget.buffer.SaveScrollbackANS()
 }
 
+// StartRecording starts recording the terminal's output to w as an asciicast v2 cast
+func (t *Terminal) StartRecording(w io.Writer, title string) error {
+	return t.widget.buffer.StartRecording(w, title)
+}
+
+// StopRecording stops recording, closing the writer if it is an io.Closer
+func (t *Terminal) StopRecording() error {
+	return t.widget.buffer.StopRecording()
+}
+
+// IsRecording returns whether the terminal's output is being recorded
+func (t *Terminal) IsRecording() bool {
+	return t.widget.buffer.IsRecording()
+}
+
+// SetOutputTee copies the terminal's output from now on to w (nil to stop)
+func (t *Terminal) SetOutputTee(w io.Writer) {
+	t.widget.buffer.SetOutputTee(w)
+}
+
+// SaveSnapshot writes the terminal's complete state (see purfecterm.Buffer.SaveSnapshot)
+func (t *Terminal) SaveSnapshot(w io.Writer) error {
+	return t.widget.buffer.SaveSnapshot(w)
+}
+
+// RestoreSnapshot replaces the terminal's state with a snapshot from SaveSnapshot
+func (t *Terminal) RestoreSnapshot(r io.Reader) error {
+	return t.widget.buffer.RestoreSnapshot(r)
+}
+
+// SetBellCallback sets a callback to be invoked when the bell rings
+// It is called from the goroutine that feeds the terminal, not the UI thread.
+func (t *Terminal) SetBellCallback(fn func()) {
+	t.widget.buffer.SetBellCallback(fn)
+}
+
 // Buffer returns the underlying terminal buffer
 func (t *Terminal) Buffer() *purfecterm.Buffer {
 	return t.widget.Buffer()
@@ -391,5 +483,7 @@ func (t *Terminal) Buffer() *purfecterm.Buffer {
 
 // SetColorScheme sets the terminal color scheme
 func (t *Terminal) SetColorScheme(scheme purfecterm.ColorScheme) {
+	t.options.Scheme = scheme
 	t.widget.SetColorScheme(scheme)
+	t.forEachPane(func(w *Widget) { w.SetColorScheme(scheme) })
 }
@@ -0,0 +1,99 @@
+package purfectermqt
+
+import (
+	"time"
+
+	"github.com/mappu/miqt/qt"
+	"github.com/phroun/pawscript/src/pkg/purfecterm"
+)
+
+// Touch gestures
+// Touch events the widget doesn't take are turned into mouse events by Qt, so one
+// finger selects as the mouse does. Two fingers are followed by Qt's pinch gesture,
+// which scrolls or zooms the font (see purfecterm/touch.go), and a finger held
+// still opens the context menu, as a right click does.
+
+// touchState is where the touch gestures are up to
+type touchState struct {
+	touching  bool // A finger is on the screen, so a tap and hold isn't the mouse's
+	twoFinger purfecterm.TwoFingerGesture
+}
+
+// initTouch asks for touch events and the gestures made from them
+func (w *Widget) initTouch() {
+	w.widget.SetAttribute(qt.WA_AcceptTouchEvents)
+	w.widget.GrabGesture(qt.PinchGesture)
+	w.widget.GrabGesture(qt.TapAndHoldGesture)
+}
+
+// touchEvent handles the gesture events, and notes when fingers are down
+func (w *Widget) touchEvent(super func(event *qt.QEvent) bool, event *qt.QEvent) bool {
+	switch event.Type() {
+	case qt.QEvent__TouchBegin:
+		w.touch.touching = true
+	case qt.QEvent__TouchEnd, qt.QEvent__TouchCancel:
+		w.touch.touching = false
+	case qt.QEvent__Gesture:
+		gestures := qt.UnsafeNewQGestureEvent(event.UnsafePointer())
+		if g := gestures.Gesture(qt.PinchGesture); g != nil {
+			w.pinchGesture(qt.UnsafeNewQPinchGesture(g.UnsafePointer()))
+			gestures.Accept(g)
+		}
+		if g := gestures.Gesture(qt.TapAndHoldGesture); g != nil {
+			if g.State() == qt.GestureFinished && w.touch.touching {
+				hold := qt.UnsafeNewQTapAndHoldGesture(g.UnsafePointer())
+				w.cancelTouchSelection()
+				w.widget.CustomContextMenuRequested(w.widget.MapFromGlobal(hold.Position().ToPoint()))
+			}
+			gestures.Accept(g)
+		}
+		return true
+	}
+	return super(event)
+}
+
+// pinchGesture scrolls or zooms as two fingers move
+func (w *Widget) pinchGesture(pinch *qt.QPinchGesture) {
+	y := pinch.CenterPoint().Y()
+	switch pinch.State() {
+	case qt.GestureStarted:
+		// The first finger has started a selection; two fingers don't select
+		w.cancelTouchSelection()
+		w.syncSmoothScroll()
+		w.touch.twoFinger.Begin(y, w.GetZoom())
+	case qt.GestureUpdated:
+		scroll, zoom := w.touch.twoFinger.Update(y, pinch.TotalScaleFactor())
+		if zoom != 0 {
+			w.zoomTo(zoom)
+			return
+		}
+		if scroll != 0 {
+			w.syncSmoothScroll()
+			w.smooth.Drag(scroll, time.Now())
+			w.applySmoothScroll()
+		}
+	case qt.GestureFinished, qt.GestureCanceled:
+		if w.touch.twoFinger.Pinching() {
+			return
+		}
+		// Coast as a touchpad does when the fingers lift
+		w.syncSmoothScroll()
+		w.smooth.Release(time.Now())
+		w.applySmoothScroll()
+		w.startSmoothScrollTimer()
+	}
+}
+
+// cancelTouchSelection drops the selection the first finger's mouse press began,
+// when the touch turns out to be a gesture
+func (w *Widget) cancelTouchSelection() {
+	if !w.mouseDown {
+		return
+	}
+	w.mouseDown = false
+	w.stopAutoScroll()
+	if w.selecting {
+		w.selecting = false
+		w.buffer.ClearSelection()
+	}
+}
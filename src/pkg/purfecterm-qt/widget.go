@@ -2,10 +2,12 @@ package purfectermqt
 
 import (
 	"fmt"
+	"image"
 	"math"
 	"runtime"
 	"strings"
 	"sync"
+	"unsafe"
 
 	"github.com/mappu/miqt/qt"
 	"github.com/phroun/pawscript/src"
@@ -146,6 +148,12 @@ type Widget struct {
 	// Glyph cache for rendered characters
 	glyphCache *glyphCache
 
+	// Atlas renderer, once GPU rendering has drawn a frame (see atlasrender.go)
+	atlas *atlasRenderer
+
+	// Pixmaps for on-screen inline images, by image ID
+	imagePixmaps map[int]*qt.QPixmap
+
 	// Font settings
 	fontFamily        string
 	fontFamilyUnicode string // Fallback for Unicode characters missing from main font
@@ -166,16 +174,27 @@ type Widget struct {
 	mouseDownX      int
 	mouseDownY      int
 	selectionMoved       bool
+	selectBlock          bool       // True if the press had Alt held (rectangular selection)
 	autoScrollTimer      *qt.QTimer // Timer for auto-scrolling
 	autoScrollDelta      int        // Vertical scroll direction (-1=up, 1=down), magnitude used for speed
 	autoScrollHorizDelta int        // Horizontal scroll direction (-1=left, 1=right), magnitude for speed
 	lastMouseX           int        // Last known mouse X cell position
 	lastMouseY           int        // Last known mouse Y cell position
 
+	// Smooth scrolling: the pixel position and its animation timer (see smoothscroll.go)
+	smooth            purfecterm.SmoothScroll
+	smoothScrollTimer *qt.QTimer
+
 	// Update coalescing for thread-safe redraws
 	updatePending bool
 	updateTimer   *qt.QTimer
 
+	// Clipboard requests from programs (OSC 52), carried out by the update timer
+	clipboardRequests []purfecterm.ClipboardRequest
+
+	// Replies to queries (DA, DSR, XTVERSION), sent to the program by the update timer
+	responses [][]byte
+
 	// Cursor blink
 	cursorBlinkOn  bool
 	blinkTimer     *qt.QTimer
@@ -190,8 +209,53 @@ type Widget struct {
 	// Callback when data should be written to PTY
 	onInput func([]byte)
 
-	// Context menu
+	// Key macros applied to keys before they are sent (nil for none)
+	keyMap *purfecterm.KeyMap
+
+	// The text the input method is composing (see inputmethod.go)
+	im imState
+
+	// Scrollback find bar (see findbar.go)
+	find findBar
+
+	// Output written while scrolled back, and where its pill is (see scrollindicator.go)
+	newOutput    purfecterm.NewOutput
+	newLinesPill *qt.QRect
+
+	// Background image, composed for the widget's size (see background.go)
+	backdrop backdrop
+
+	// The screen as last given to screen readers (see accessible.go)
+	a11yText string
+
+	// Fingers down, and the two-finger gesture under way (see touch.go)
+	touch touchState
+
+	// Font zoom, the size given to SetFont, and the zoom callback (see zoom.go)
+	zoom         float64
+	baseFontSize int
+	zoomClicks   float64
+	onZoom       func(zoom float64)
+
+	// Hyperlinks: the link under the mouse (underlined), and the Ctrl+click callback
+	hoverLink   int
+	onLinkClick func(uri string)
+
+	// Callback offered dropped files before they're typed (see filedrop.go)
+	onFileDrop func(paths []string) bool
+
+	// Callback when the widget gains keyboard focus
+	onFocus func()
+
+	// Mouse reporting: the button held since a reported press, and the last cell reported
+	mouseReportButton int
+	mouseReportCol    int
+	mouseReportRow    int
+
+	// Context menu, and the entries the embedder added to it (see menuitems.go)
 	contextMenu *qt.QMenu
+	menuItems   *purfecterm.MenuItems
+	menuActions []*qt.QAction
 
 	// Scrollbar update flag
 	scrollbarUpdating bool
@@ -203,31 +267,44 @@ type Widget struct {
 
 // NewWidget creates a new terminal widget with the specified dimensions
 func NewWidget(cols, rows, scrollbackSize int) *Widget {
+	return NewWidgetForBuffer(purfecterm.NewBuffer(cols, rows, scrollbackSize))
+}
+
+// NewWidgetForBuffer creates a terminal widget showing an existing buffer, such as a
+// pane's (see purfecterm.Buffer.SplitPane)
+func NewWidgetForBuffer(buffer *purfecterm.Buffer) *Widget {
+	cols, rows := buffer.GetSize()
 	w := &Widget{
 		widget:        qt.NewQWidget2(),
 		fontFamily:    "Monospace",
 		fontSize:      14,
+		baseFontSize:  14,
+		zoom:          1.0,
 		charWidth:     10,
 		charHeight:    20,
 		charAscent:    16,
 		scheme:        purfecterm.DefaultColorScheme(),
 		cursorBlinkOn: true,
 		glyphCache:    newGlyphCache(4096),
+
+		mouseReportButton: purfecterm.MouseButtonNone,
 	}
 
-	// Create buffer and parser
-	w.buffer = purfecterm.NewBuffer(cols, rows, scrollbackSize)
+	// Create parser
+	w.buffer = buffer
 	w.parser = purfecterm.NewParser(w.buffer)
 
-	// Initialize terminal capabilities (auto-updated on resize)
+	// Initialize terminal capabilities (auto-updated on resize and theme change)
 	w.termCaps = &pawscript.TerminalCapabilities{
 		TermType:      "gui-console",
 		IsTerminal:    true,
 		SupportsANSI:  true,
 		SupportsColor: true,
 		ColorDepth:    256,
+		SupportsSixel: true,
 		Width:         cols,
 		Height:        rows,
+		DarkTheme:     w.buffer.IsDarkTheme(),
 		SupportsInput: true,
 		EchoEnabled:   false,
 		LineMode:      false,
@@ -238,8 +315,26 @@ func NewWidget(cols, rows, scrollbackSize int) *Widget {
 	// This coalesces updates from background threads onto the Qt main thread
 	w.updateTimer = qt.NewQTimer2(w.widget.QObject)
 	w.updateTimer.OnTimeout(func() {
+		w.mu.Lock()
+		requests := w.clipboardRequests
+		w.clipboardRequests = nil
+		responses := w.responses
+		w.responses = nil
+		onInput := w.onInput
+		w.mu.Unlock()
+		for _, req := range requests {
+			w.handleClipboardRequest(req)
+		}
+		if onInput != nil {
+			for _, reply := range responses {
+				onInput(reply)
+			}
+		}
+
 		if w.updatePending {
 			w.updatePending = false
+			// Keep capabilities in sync with theme changes (CSI ? 5 h/l)
+			w.termCaps.SetDarkTheme(w.buffer.IsDarkTheme())
 			w.widget.Update()
 		}
 	})
@@ -254,10 +349,29 @@ func NewWidget(cols, rows, scrollbackSize int) *Widget {
 		w.updatePending = true
 	})
 
+	// Queue clipboard requests from programs (OSC 52) for the update timer, so they
+	// are carried out on the Qt main thread
+	w.buffer.SetClipboardCallback(func(req purfecterm.ClipboardRequest) {
+		w.mu.Lock()
+		w.clipboardRequests = append(w.clipboardRequests, req)
+		w.mu.Unlock()
+	})
+
+	// Queue replies to queries for the update timer too, so they reach the program in
+	// order with its other input
+	w.buffer.SetResponseCallback(func(reply []byte) {
+		w.mu.Lock()
+		w.responses = append(w.responses, reply)
+		w.mu.Unlock()
+	})
+
 	// Enable focus and mouse tracking on the terminal widget
 	w.widget.SetFocusPolicy(qt.StrongFocus)
 	w.widget.SetMouseTracking(true)
 	w.widget.SetAttribute(qt.WA_InputMethodEnabled)
+	w.widget.SetAcceptDrops(true)
+	w.initAccessible()
+	w.initTouch()
 
 	// Calculate font metrics
 	w.updateFontMetrics()
@@ -288,9 +402,27 @@ func NewWidget(cols, rows, scrollbackSize int) *Widget {
 	w.widget.OnMouseMoveEvent(func(super func(event *qt.QMouseEvent), event *qt.QMouseEvent) {
 		w.mouseMoveEvent(event)
 	})
+	w.widget.OnLeaveEvent(func(super func(event *qt.QEvent), event *qt.QEvent) {
+		w.setHoverLink(0)
+	})
 	w.widget.OnWheelEvent(func(super func(event *qt.QWheelEvent), event *qt.QWheelEvent) {
 		w.wheelEvent(event)
 	})
+	w.widget.OnInputMethodEvent(func(super func(event *qt.QInputMethodEvent), event *qt.QInputMethodEvent) {
+		w.inputMethodEvent(event)
+	})
+	w.widget.OnInputMethodQuery(func(super func(query qt.InputMethodQuery) *qt.QVariant, query qt.InputMethodQuery) *qt.QVariant {
+		return w.inputMethodQuery(super, query)
+	})
+	w.widget.OnDragEnterEvent(func(super func(event *qt.QDragEnterEvent), event *qt.QDragEnterEvent) {
+		w.dragMoveEvent(event.QDragMoveEvent)
+	})
+	w.widget.OnDragMoveEvent(func(super func(event *qt.QDragMoveEvent), event *qt.QDragMoveEvent) {
+		w.dragMoveEvent(event)
+	})
+	w.widget.OnDropEvent(func(super func(event *qt.QDropEvent), event *qt.QDropEvent) {
+		w.dropEvent(event)
+	})
 	w.widget.OnFocusInEvent(func(super func(event *qt.QFocusEvent), event *qt.QFocusEvent) {
 		w.focusInEvent(event)
 	})
@@ -300,6 +432,9 @@ func NewWidget(cols, rows, scrollbackSize int) *Widget {
 	w.widget.OnResizeEvent(func(super func(event *qt.QResizeEvent), event *qt.QResizeEvent) {
 		w.resizeEvent(event)
 	})
+	w.widget.OnEvent(func(super func(event *qt.QEvent) bool, event *qt.QEvent) bool {
+		return w.touchEvent(super, event)
+	})
 
 	// Create context menu for right-click
 	w.contextMenu = qt.NewQMenu(w.widget)
@@ -321,9 +456,19 @@ func NewWidget(cols, rows, scrollbackSize int) *Widget {
 		w.SelectAll()
 	})
 
+	findAction := w.contextMenu.AddAction("Find...")
+	findAction.OnTriggered(func() {
+		w.ShowFindBar()
+	})
+
 	// Enable context menu policy for right-click
 	w.widget.SetContextMenuPolicy(qt.CustomContextMenu)
 	w.widget.OnCustomContextMenuRequested(func(pos *qt.QPoint) {
+		// With mouse tracking on, right-clicks go to the application (Shift+right-click still opens the menu)
+		if w.mouseReporting(qt.QGuiApplication_KeyboardModifiers()) {
+			return
+		}
+		w.updateContextMenuItems()
 		w.contextMenu.ExecWithPos(w.widget.MapToGlobal(pos))
 	})
 
@@ -335,13 +480,7 @@ func NewWidget(cols, rows, scrollbackSize int) *Widget {
 	tabShortcut := qt.NewQShortcut2(qt.NewQKeySequence2("Tab"), w.widget)
 	tabShortcut.SetContext(qt.WidgetWithChildrenShortcut)
 	tabShortcut.OnActivated(func() {
-		w.mu.Lock()
-		onInput := w.onInput
-		w.mu.Unlock()
-		if onInput != nil {
-			w.buffer.NotifyKeyboardActivity()
-			onInput([]byte{'\t'})
-		}
+		w.sendKey([]byte{'\t'})
 	})
 
 	ctrlTabShortcut := qt.NewQShortcut2(qt.NewQKeySequence2("Ctrl+Tab"), w.widget)
@@ -369,53 +508,29 @@ func NewWidget(cols, rows, scrollbackSize int) *Widget {
 	altTabShortcut := qt.NewQShortcut2(qt.NewQKeySequence2("Alt+Tab"), w.widget)
 	altTabShortcut.SetContext(qt.WidgetWithChildrenShortcut)
 	altTabShortcut.OnActivated(func() {
-		w.mu.Lock()
-		onInput := w.onInput
-		w.mu.Unlock()
-		if onInput != nil {
-			w.buffer.NotifyKeyboardActivity()
-			// Alt+Tab = mod 3 (1 + 2 for alt)
-			onInput([]byte{0x1b, '[', '9', ';', '3', 'u'}) // CSI 9 ; 3 u
-		}
+		// Alt+Tab = mod 3 (1 + 2 for alt)
+		w.sendKey([]byte{0x1b, '[', '9', ';', '3', 'u'}) // CSI 9 ; 3 u
 	})
 
 	shiftAltTabShortcut := qt.NewQShortcut2(qt.NewQKeySequence2("Shift+Alt+Tab"), w.widget)
 	shiftAltTabShortcut.SetContext(qt.WidgetWithChildrenShortcut)
 	shiftAltTabShortcut.OnActivated(func() {
-		w.mu.Lock()
-		onInput := w.onInput
-		w.mu.Unlock()
-		if onInput != nil {
-			w.buffer.NotifyKeyboardActivity()
-			// Shift+Alt+Tab = mod 4 (1 + 1 for shift + 2 for alt)
-			onInput([]byte{0x1b, '[', '9', ';', '4', 'u'}) // CSI 9 ; 4 u
-		}
+		// Shift+Alt+Tab = mod 4 (1 + 1 for shift + 2 for alt)
+		w.sendKey([]byte{0x1b, '[', '9', ';', '4', 'u'}) // CSI 9 ; 4 u
 	})
 
 	metaTabShortcut := qt.NewQShortcut2(qt.NewQKeySequence2("Meta+Tab"), w.widget)
 	metaTabShortcut.SetContext(qt.WidgetWithChildrenShortcut)
 	metaTabShortcut.OnActivated(func() {
-		w.mu.Lock()
-		onInput := w.onInput
-		w.mu.Unlock()
-		if onInput != nil {
-			w.buffer.NotifyKeyboardActivity()
-			// Meta+Tab = mod 9 (1 + 8 for meta)
-			onInput([]byte{0x1b, '[', '9', ';', '9', 'u'}) // CSI 9 ; 9 u
-		}
+		// Meta+Tab = mod 9 (1 + 8 for meta)
+		w.sendKey([]byte{0x1b, '[', '9', ';', '9', 'u'}) // CSI 9 ; 9 u
 	})
 
 	shiftMetaTabShortcut := qt.NewQShortcut2(qt.NewQKeySequence2("Shift+Meta+Tab"), w.widget)
 	shiftMetaTabShortcut.SetContext(qt.WidgetWithChildrenShortcut)
 	shiftMetaTabShortcut.OnActivated(func() {
-		w.mu.Lock()
-		onInput := w.onInput
-		w.mu.Unlock()
-		if onInput != nil {
-			w.buffer.NotifyKeyboardActivity()
-			// Shift+Meta+Tab = mod 10 (1 + 1 for shift + 8 for meta)
-			onInput([]byte{0x1b, '[', '9', ';', '1', '0', 'u'}) // CSI 9 ; 10 u
-		}
+		// Shift+Meta+Tab = mod 10 (1 + 1 for shift + 8 for meta)
+		w.sendKey([]byte{0x1b, '[', '9', ';', '1', '0', 'u'}) // CSI 9 ; 10 u
 	})
 
 	return w
@@ -617,7 +732,8 @@ func (w *Widget) onBlinkTimer() {
 func (w *Widget) SetFont(family string, size int) {
 	w.mu.Lock()
 	w.fontFamily = family
-	w.fontSize = size
+	w.baseFontSize = size
+	w.fontSize = purfecterm.ZoomFontSize(size, w.zoom)
 	w.mu.Unlock()
 	// Trigger full resize handling to recalculate terminal dimensions,
 	// scrollbars, and update the buffer with new character metrics
@@ -636,6 +752,9 @@ func (w *Widget) SetColorScheme(scheme purfecterm.ColorScheme) {
 	w.mu.Lock()
 	w.scheme = scheme
 	w.mu.Unlock()
+	w.buffer.SetDefaultCursorStyle(scheme.CursorShape, scheme.CursorBlink)
+	w.buffer.SetClipboardAccess(scheme.Clipboard)
+	w.buffer.SetTerminalIdentity(scheme.Identity)
 	w.widget.Update()
 }
 
@@ -806,6 +925,33 @@ func (w *Widget) getFontForCharacter(r rune, mainFont string, fontSize int) stri
 	return mainFont
 }
 
+// SetLinkClickCallback sets the callback for Ctrl+click on a hyperlink
+func (w *Widget) SetLinkClickCallback(fn func(uri string)) {
+	w.mu.Lock()
+	w.onLinkClick = fn
+	w.mu.Unlock()
+}
+
+// SetFileDropCallback sets the callback offered files dropped on the widget,
+// which returns whether it took them
+func (w *Widget) SetFileDropCallback(fn func(paths []string) bool) {
+	w.mu.Lock()
+	w.onFileDrop = fn
+	w.mu.Unlock()
+}
+
+// SetFocusCallback sets the callback for when the widget gains keyboard focus
+func (w *Widget) SetFocusCallback(fn func()) {
+	w.mu.Lock()
+	w.onFocus = fn
+	w.mu.Unlock()
+}
+
+// GrabFocus gives the widget keyboard focus
+func (w *Widget) GrabFocus() {
+	w.widget.SetFocus()
+}
+
 // SetInputCallback sets the callback for handling input
 func (w *Widget) SetInputCallback(fn func([]byte)) {
 	w.mu.Lock()
@@ -813,6 +959,13 @@ func (w *Widget) SetInputCallback(fn func([]byte)) {
 	w.mu.Unlock()
 }
 
+// SetKeyMap sets the key macros applied to keys before they are sent (nil for none)
+func (w *Widget) SetKeyMap(keyMap *purfecterm.KeyMap) {
+	w.mu.Lock()
+	w.keyMap = keyMap
+	w.mu.Unlock()
+}
+
 // Feed writes data to the terminal
 func (w *Widget) Feed(data []byte) {
 	w.parser.Parse(data)
@@ -860,18 +1013,57 @@ func (w *Widget) updateFontMetrics() {
 
 // renderCustomGlyph renders a custom glyph for a cell at the specified position
 // Returns true if a custom glyph was rendered, false if normal text rendering should be used
+// renderImages draws the inline images on screen at their native pixel size
+func (w *Widget) renderImages(painter *qt.QPainter, charWidth, charHeight, horizOffsetX int) {
+	images := w.buffer.GetVisibleImages()
+	if len(images) == 0 && len(w.imagePixmaps) == 0 {
+		return
+	}
+	pixmaps := make(map[int]*qt.QPixmap, len(images))
+	for _, img := range images {
+		pixmap := w.imagePixmaps[img.ID]
+		if pixmap == nil {
+			pixmap = createImagePixmap(img.Image)
+		}
+		pixmaps[img.ID] = pixmap
+		x := terminalLeftPadding + (img.Col-horizOffsetX)*charWidth
+		painter.DrawPixmap9(x, img.Row*charHeight, pixmap)
+	}
+	// Free pixmaps for images that left the screen
+	for id, pixmap := range w.imagePixmaps {
+		if pixmaps[id] == nil {
+			pixmap.Delete()
+		}
+	}
+	w.imagePixmaps = pixmaps
+}
+
+// createImagePixmap copies an image into a QPixmap
+func createImagePixmap(img *image.NRGBA) *qt.QPixmap {
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	qimg := qt.NewQImage3(width, height, qt.QImage__Format_RGBA8888)
+	defer qimg.Delete()
+	for y := 0; y < height; y++ {
+		start := img.PixOffset(img.Bounds().Min.X, img.Bounds().Min.Y+y)
+		copy(unsafe.Slice(qimg.ScanLine(y), width*4), img.Pix[start:start+width*4])
+	}
+	return qt.QPixmap_FromImage(qimg)
+}
+
 // createCustomGlyphPixmap renders a custom glyph to a cached QPixmap.
 // The pixmap is rendered at the specified cell size with all palette colors resolved.
 // scaleY is used for double-height mode (1.0 for normal, 2.0 for double-height).
+// scale is the device pixel ratio; the pixmap has that many pixels per cell pixel.
 func (w *Widget) createCustomGlyphPixmap(cell *purfecterm.Cell, glyph *purfecterm.CustomGlyph,
-	cellW, cellH int, scaleY float64) *qt.QPixmap {
+	cellW, cellH int, scaleY, scale float64) *qt.QPixmap {
 
 	glyphW := glyph.Width
 	glyphH := glyph.Height
 
 	// Calculate pixmap dimensions (account for scaleY for double-height)
 	pixmapH := int(float64(cellH) * scaleY)
-	pixmap := qt.NewQPixmap2(cellW, pixmapH)
+	pixmap := qt.NewQPixmap2(purfecterm.DevicePixels(cellW, scale), purfecterm.DevicePixels(pixmapH, scale))
+	pixmap.SetDevicePixelRatio(scale)
 	pixmap.FillWithFillColor(qt.NewQColor2(qt.Transparent))
 
 	painter := qt.NewQPainter2(pixmap.QPaintDevice)
@@ -994,10 +1186,12 @@ func (w *Widget) renderCustomGlyph(painter *qt.QPainter, cell *purfecterm.Cell,
 		usesBg = true
 	}
 
-	// Build cache key
+	// Build cache key (sized in device pixels, so moving to a screen with another
+	// scale renders it again)
+	scale := purfecterm.DeviceScale(w.widget.DevicePixelRatioF())
 	cacheKey := buildCustomGlyphKey(
 		cell.Char,
-		cellW, int(float64(cellH)*scaleY),
+		purfecterm.DevicePixels(cellW, scale), purfecterm.DevicePixels(int(float64(cellH)*scaleY), scale),
 		cell.XFlip, cell.YFlip,
 		paletteHash, glyph.ComputeHash(),
 		usesDefaultFG, usesBg,
@@ -1008,7 +1202,7 @@ func (w *Widget) renderCustomGlyph(painter *qt.QPainter, cell *purfecterm.Cell,
 	cachedPixmap := w.glyphCache.get(cacheKey)
 	if cachedPixmap == nil {
 		// Cache miss - create and cache the pixmap
-		cachedPixmap = w.createCustomGlyphPixmap(cell, glyph, cellW, cellH, scaleY)
+		cachedPixmap = w.createCustomGlyphPixmap(cell, glyph, cellW, cellH, scaleY, scale)
 		w.glyphCache.put(cacheKey, cachedPixmap)
 	}
 
@@ -1461,6 +1655,7 @@ func (w *Widget) paintEvent(event *qt.QPaintEvent) {
 	baseCharHeight := w.charHeight
 	baseCharAscent := w.charAscent
 	blinkPhase := w.blinkPhase
+	hoverLink := w.hoverLink
 	w.mu.Unlock()
 
 	// Get current theme mode (dark/light) from buffer's DECSCNM state
@@ -1500,10 +1695,32 @@ func (w *Widget) paintEvent(event *qt.QPaintEvent) {
 	painter := qt.NewQPainter2(w.widget.QPaintDevice)
 	defer painter.End()
 
-	// Fill background with theme-appropriate color
-	schemeBg := scheme.Background(isDark)
-	bgColor := qt.NewQColor3(int(schemeBg.R), int(schemeBg.G), int(schemeBg.B))
-	painter.FillRect5(0, 0, w.widget.Width(), w.widget.Height(), bgColor)
+	// Let the atlas renderer draw the cells if it can (see atlasrender.go); frames part
+	// way through a smooth scroll, and backgrounds with an opacity or image, are drawn
+	// here
+	smoothShift := w.smoothScrollShift()
+	atlasDrawn := false
+	if scheme.GPURendering && scheme.SolidBackground() && smoothShift == 0 {
+		opts := purfecterm.GridFrameOptions{
+			Scheme:        scheme,
+			BlinkPhase:    blinkPhase,
+			CursorBlinkOn: w.cursorBlinkOn,
+			Focused:       w.hasFocus,
+			HoverLink:     hoverLink,
+		}
+		atlasDrawn = w.paintCells(painter, opts, fontFamily, fontSize, baseCharWidth, baseCharHeight, baseCharAscent)
+	}
+
+	// Fill background with theme-appropriate color, its opacity and image (see
+	// background.go)
+	if !atlasDrawn {
+		w.drawBackground(painter, scheme, isDark)
+	}
+
+	// Draw everything on the screen higher by the part of a line smooth scrolling
+	// has moved (see smoothscroll.go)
+	painter.Save()
+	painter.Translate2(0, -float64(smoothShift))
 
 	// Apply screen crop clipping if set (crop values are in sprite coordinate units)
 	widthCrop, heightCrop := w.buffer.GetScreenCrop()
@@ -1557,6 +1774,20 @@ func (w *Widget) paintEvent(event *qt.QPaintEvent) {
 		// Calculate the range of logical columns to render
 		startCol := horizOffset
 		endCol := horizOffset + effectiveCols
+		if atlasDrawn {
+			// The cells are already drawn, so only the cursor memo below is needed
+			endCol = startCol
+		}
+
+		// Runs of cells drawn together for ligatures (see ligature.go)
+		var runs []purfecterm.LigatureRun
+		if scheme.Ligatures && lineAttr == purfecterm.LineAttrNormal && !atlasDrawn {
+			cursorX := -1
+			if cursorVisible && y == cursorVisibleY && w.cursorBlinkOn {
+				cursorX = cursorVisibleX
+			}
+			runs = w.ligatureRuns(y, effectiveCols, horizOffset, cursorX)
+		}
 
 		// Track accumulated visual width for flex-width rendering
 		visibleAccumulatedWidth := 0.0
@@ -1567,6 +1798,11 @@ func (w *Widget) paintEvent(event *qt.QPaintEvent) {
 			// GetVisibleCell takes screen position and applies horizOffset internally
 			cell := w.buffer.GetVisibleCell(x, y)
 
+			// Underline the hyperlink under the mouse
+			if cell.LinkID != 0 && cell.LinkID == hoverLink && cell.UnderlineStyle == purfecterm.UnderlineNone {
+				cell.UnderlineStyle = purfecterm.UnderlineSingle
+			}
+
 			// Calculate this cell's visual width
 			cellVisualWidth := 1.0
 			if cell.FlexWidth && cell.CellWidth > 0 {
@@ -1596,6 +1832,14 @@ func (w *Widget) paintEvent(event *qt.QPaintEvent) {
 				}
 			}
 
+			// Handle search highlighting
+			switch w.buffer.GetSearchHighlight(logicalX, y) {
+			case purfecterm.SearchHighlightMatch:
+				fg, bg = scheme.SearchText, scheme.SearchMatch
+			case purfecterm.SearchHighlightCurrent:
+				fg, bg = scheme.SearchText, scheme.SearchCurrent
+			}
+
 			// Handle selection (use logicalX for buffer position)
 			if w.buffer.IsInSelection(logicalX, y) {
 				bg = scheme.Selection
@@ -1641,7 +1885,13 @@ func (w *Widget) paintEvent(event *qt.QPaintEvent) {
 			}
 
 			// Draw character
-			if cell.Char != ' ' && cell.Char != 0 && blinkVisible {
+			if i := purfecterm.LigatureRunAt(runs, x); i >= 0 {
+				// A ligature run is drawn with its last cell, over all its backgrounds
+				if x == runs[i].End-1 {
+					runX := cellX - (x-runs[i].Start)*cellW
+					w.drawLigatureRun(painter, runs[i], runX, cellY, fontFamily, fontSize, cell.Bold, cell.Italic, fg, baseCharWidth, baseCharAscent, horizScale, vertScale)
+				}
+			} else if cell.Char != ' ' && cell.Char != 0 && blinkVisible {
 				// Check for custom glyph first
 				if w.renderCustomGlyph(painter, &cell, cellX, cellY, cellW, cellH, x, blinkPhase, scheme.BlinkMode, lineAttr) {
 					// Custom glyph was rendered, skip normal text rendering
@@ -1904,6 +2154,16 @@ func (w *Widget) paintEvent(event *qt.QPaintEvent) {
 				painter.FillRect5(cellX, strikeY, cellW, strikeH, fgQColor)
 			}
 
+			// Draw overline
+			if cell.Overline {
+				fgQColor := qt.NewQColor3(int(fg.R), int(fg.G), int(fg.B))
+				overH := 1
+				if lineAttr == purfecterm.LineAttrDoubleTop || lineAttr == purfecterm.LineAttrDoubleBottom {
+					overH = 2
+				}
+				painter.FillRect5(cellX, cellY, cellW, overH, fgQColor)
+			}
+
 			// Draw cursor
 			if isCursor {
 				cursorQColor := qt.NewQColor3(int(scheme.Cursor.R), int(scheme.Cursor.G), int(scheme.Cursor.B))
@@ -1974,6 +2234,10 @@ func (w *Widget) paintEvent(event *qt.QPaintEvent) {
 		}
 	}
 
+	// Render inline images (sixel graphics) over the text they were placed on
+	w.buffer.SetCellPixelSize(charWidth, charHeight)
+	w.renderImages(painter, charWidth, charHeight, horizOffset)
+
 	// Render front sprites (overlay on top of text)
 	w.renderSprites(painter, frontSprites, charWidth, charHeight, scheme, isDark, scrollOffset, horizOffset)
 
@@ -1991,6 +2255,15 @@ func (w *Widget) paintEvent(event *qt.QPaintEvent) {
 		w.buffer.SetSplitContentWidth(0)
 	}
 
+	// Tell the input method where the cursor is, and draw what it is composing there
+	if cursorVisible {
+		imX := cursorVisibleX*charWidth + terminalLeftPadding
+		imY := cursorVisibleY * charHeight
+		w.setIMCursorRect(imX, imY, charWidth, charHeight)
+		w.drawPreedit(painter, imX, imY, charHeight, baseCharAscent, fontFamily, fontSize,
+			scheme.Foreground(isDark), scheme.Background(isDark))
+	}
+
 	// Draw yellow dashed line between scrollback and logical screen
 	boundaryRow := w.buffer.GetScrollbackBoundaryVisibleRow()
 	if boundaryRow > 0 {
@@ -2007,6 +2280,10 @@ func (w *Widget) paintEvent(event *qt.QPaintEvent) {
 	if hasCrop {
 		painter.Restore()
 	}
+	painter.Restore() // Smooth scroll shift
+
+	// Show how far back the view is, and any output written meanwhile
+	w.drawScrollIndicator(painter, fontFamily, fontSize, baseCharHeight)
 
 	// Report whether cursor's LINE was rendered for auto-scroll logic
 	// We track the line, not the cursor itself - the cursor may be horizontally
@@ -2026,10 +2303,22 @@ func (w *Widget) paintEvent(event *qt.QPaintEvent) {
 	// Update scrollbars after rendering (safe here since we're not holding buffer lock)
 	w.updateScrollbar()
 
+	// Visual bell: wash the whole area with the foreground color briefly
+	// (the blink timer redraws once it is over)
+	if scheme.VisualBell && w.buffer.IsBellFlashing() {
+		fg := scheme.Foreground(isDark)
+		painter.FillRect5(0, 0, w.widget.Width(), w.widget.Height(), qt.NewQColor11(int(fg.R), int(fg.G), int(fg.B), 64))
+	}
+
+	// Tell screen readers what changed (see accessible.go)
+	w.updateAccessible()
+
 	w.buffer.ClearDirty()
 }
 
-func (w *Widget) screenToCell(screenX, screenY int) (cellX, cellY int) {
+// screenToCell returns the cell at a widget position, which at fractional scale
+// factors can be between logical pixels (see purfecterm/hidpi.go)
+func (w *Widget) screenToCell(screenX, screenY float64) (cellX, cellY int) {
 	w.mu.Lock()
 	baseCharWidth := w.charWidth
 	baseCharHeight := w.charHeight
@@ -2041,7 +2330,7 @@ func (w *Widget) screenToCell(screenX, screenY int) (cellX, cellY int) {
 	charWidth := int(float64(baseCharWidth) * horizScale)
 	charHeight := int(float64(baseCharHeight) * vertScale)
 
-	cellY = screenY / charHeight
+	cellY = purfecterm.CellIndex(screenY, charHeight)
 	cols, rows := w.buffer.GetSize()
 	if cellY < 0 {
 		cellY = 0
@@ -2060,7 +2349,7 @@ func (w *Widget) screenToCell(screenX, screenY int) (cellX, cellY int) {
 
 	// Calculate which cell the mouse is in, accounting for flex width
 	// First, get the x position relative to content area
-	relativeX := float64(screenX - terminalLeftPadding)
+	relativeX := screenX - float64(terminalLeftPadding)
 	if relativeX < 0 {
 		cellX = 0
 		return
@@ -2119,6 +2408,7 @@ func (w *Widget) keyPressEvent(super func(event *qt.QKeyEvent), event *qt.QKeyEv
 
 	w.mu.Lock()
 	onInput := w.onInput
+	keyMap := w.keyMap
 	w.mu.Unlock()
 
 	if onInput == nil {
@@ -2140,6 +2430,17 @@ func (w *Widget) keyPressEvent(super func(event *qt.QKeyEvent), event *qt.QKeyEv
 		hasCtrl, hasMeta = hasMeta, hasCtrl
 	}
 
+	// Open the find bar (see findbar.go)
+	if isFindShortcut(qt.Key(key), hasShift, hasCtrl, hasAlt, hasMeta) {
+		w.ShowFindBar()
+		return
+	}
+
+	// Zoom the font (see zoom.go)
+	if w.zoomKey(qt.Key(key), hasShift, hasCtrl, hasAlt, hasMeta) {
+		return
+	}
+
 	var data []byte
 	hasModifiers := hasShift || hasCtrl || hasAlt || hasMeta
 
@@ -2236,6 +2537,23 @@ func (w *Widget) keyPressEvent(super func(event *qt.QKeyEvent), event *qt.QKeyEv
 	if len(data) > 0 {
 		// Notify buffer of keyboard activity for auto-scroll-to-cursor
 		w.buffer.NotifyKeyboardActivity()
+		if data = keyMap.Translate(data); len(data) > 0 {
+			onInput(data)
+		}
+	}
+}
+
+// sendKey sends the sequence of a key handled by a shortcut, applying the key macros
+func (w *Widget) sendKey(data []byte) {
+	w.mu.Lock()
+	onInput := w.onInput
+	keyMap := w.keyMap
+	w.mu.Unlock()
+	if onInput == nil {
+		return
+	}
+	w.buffer.NotifyKeyboardActivity()
+	if data = keyMap.Translate(data); len(data) > 0 {
 		onInput(data)
 	}
 }
@@ -2623,35 +2941,158 @@ func isModifierKey(key qt.Key) bool {
 }
 
 func (w *Widget) mousePressEvent(event *qt.QMouseEvent) {
+	// The new lines pill jumps to the bottom (see scrollindicator.go)
+	if event.Button() == qt.LeftButton && w.newLinesPillAt(event.Pos()) {
+		w.scrollToBottom()
+		return
+	}
+
+	// With mouse tracking on, clicks go to the application (Shift+click still selects)
+	if w.mouseReporting(event.Modifiers()) {
+		if button, ok := qtMouseButton(event.Button()); ok {
+			w.mu.Lock()
+			w.mouseReportButton = button
+			w.mu.Unlock()
+			w.reportMouse(purfecterm.MousePress, button, event.Modifiers(), event.LocalPos())
+			w.widget.SetFocus()
+			return
+		}
+	}
+
 	if event.Button() == qt.LeftButton {
-		pos := event.Pos()
+		pos := event.LocalPos()
+		// Ctrl+click opens a hyperlink instead of starting a selection
+		if event.Modifiers()&qt.ControlModifier != 0 {
+			w.mu.Lock()
+			onLinkClick := w.onLinkClick
+			w.mu.Unlock()
+			if uri := w.buffer.GetHyperlink(w.linkAt(pos.X(), pos.Y())); uri != "" && onLinkClick != nil {
+				onLinkClick(uri)
+				return
+			}
+		}
 		cellX, cellY := w.screenToCell(pos.X(), pos.Y())
 		w.mouseDown = true
 		w.mouseDownX = cellX
 		w.mouseDownY = cellY
 		w.selectionMoved = false
+		w.selectBlock = event.Modifiers()&qt.AltModifier != 0 // Alt+drag selects a rectangle
 		w.buffer.ClearSelection()
 		w.widget.SetFocus()
+	} else if event.Button() == qt.MiddleButton && w.primarySelectionEnabled() {
+		w.PastePrimary()
+		w.widget.SetFocus()
+	}
+}
+
+// linkAt returns the hyperlink ID of the cell at a widget position (0 = none)
+func (w *Widget) linkAt(x, y float64) int {
+	cellX, cellY := w.screenToCell(x, y)
+	return w.buffer.GetVisibleCell(cellX-w.buffer.GetHorizOffset(), cellY).LinkID
+}
+
+// setHoverLink changes which hyperlink is underlined, redrawing if it changed
+func (w *Widget) setHoverLink(linkID int) {
+	w.mu.Lock()
+	changed := w.hoverLink != linkID
+	w.hoverLink = linkID
+	w.mu.Unlock()
+	if changed {
+		w.widget.Update()
+	}
+}
+
+// qtMouseButton converts a Qt mouse button to a reported mouse button
+func qtMouseButton(button qt.MouseButton) (int, bool) {
+	switch button {
+	case qt.LeftButton:
+		return purfecterm.MouseButtonLeft, true
+	case qt.MiddleButton:
+		return purfecterm.MouseButtonMiddle, true
+	case qt.RightButton:
+		return purfecterm.MouseButtonRight, true
+	}
+	return 0, false
+}
+
+// mouseReporting returns whether mouse events go to the application rather than
+// to selection and scrolling: tracking is on and Shift isn't held
+func (w *Widget) mouseReporting(modifiers qt.KeyboardModifier) bool {
+	return w.buffer.GetMouseTracking() != purfecterm.MouseTrackingOff && modifiers&qt.ShiftModifier == 0
+}
+
+// reportMouse sends a mouse event at a widget position to the application
+// Motion is only reported when it reaches a different cell.
+func (w *Widget) reportMouse(action purfecterm.MouseAction, button int, modifiers qt.KeyboardModifier, pos *qt.QPointF) {
+	cellX, cellY := w.screenToCell(pos.X(), pos.Y())
+	col := cellX - w.buffer.GetHorizOffset()
+
+	w.mu.Lock()
+	if action == purfecterm.MouseMove && col == w.mouseReportCol && cellY == w.mouseReportRow {
+		w.mu.Unlock()
+		return
+	}
+	w.mouseReportCol, w.mouseReportRow = col, cellY
+	onInput := w.onInput
+	w.mu.Unlock()
+
+	mods := 0
+	if modifiers&qt.ShiftModifier != 0 {
+		mods |= purfecterm.MouseModShift
+	}
+	if modifiers&qt.AltModifier != 0 {
+		mods |= purfecterm.MouseModAlt
+	}
+	if modifiers&qt.ControlModifier != 0 {
+		mods |= purfecterm.MouseModCtrl
+	}
+	data := w.buffer.EncodeMouseEvent(purfecterm.MouseEvent{Action: action, Button: button, Mods: mods, Col: col, Row: cellY})
+	if data != nil && onInput != nil {
+		onInput(data)
 	}
 }
 
 func (w *Widget) mouseReleaseEvent(event *qt.QMouseEvent) {
+	w.mu.Lock()
+	reportButton := w.mouseReportButton
+	w.mu.Unlock()
+	if held, ok := qtMouseButton(event.Button()); ok && held == reportButton {
+		w.mu.Lock()
+		w.mouseReportButton = purfecterm.MouseButtonNone
+		w.mu.Unlock()
+		w.reportMouse(purfecterm.MouseRelease, held, event.Modifiers(), event.LocalPos())
+		return
+	}
+
 	if event.Button() == qt.LeftButton {
 		w.mouseDown = false
 		w.stopAutoScroll()
 		if w.selecting {
 			w.selecting = false
 			w.buffer.EndSelection()
+			if w.primarySelectionEnabled() {
+				w.copySelectionToPrimary()
+			}
 		}
 	}
 }
 
 func (w *Widget) mouseMoveEvent(event *qt.QMouseEvent) {
+	pos := event.LocalPos()
+
+	w.mu.Lock()
+	reportButton := w.mouseReportButton
+	w.mu.Unlock()
+	if !w.mouseDown && w.mouseReporting(event.Modifiers()) {
+		w.reportMouse(purfecterm.MouseMove, reportButton, event.Modifiers(), pos)
+		return
+	}
+
 	if !w.mouseDown {
+		w.setHoverLink(w.linkAt(pos.X(), pos.Y()))
 		return
 	}
 
-	pos := event.Pos()
 	cellX, cellY := w.screenToCell(pos.X(), pos.Y())
 
 	if !w.selectionMoved {
@@ -2660,7 +3101,11 @@ func (w *Widget) mouseMoveEvent(event *qt.QMouseEvent) {
 			w.selecting = true
 			w.selectStartX = w.mouseDownX
 			w.selectStartY = w.mouseDownY
-			w.buffer.StartSelection(w.mouseDownX, w.mouseDownY)
+			if w.selectBlock {
+				w.buffer.StartBlockSelection(w.mouseDownX, w.mouseDownY)
+			} else {
+				w.buffer.StartSelection(w.mouseDownX, w.mouseDownY)
+			}
 		} else {
 			return
 		}
@@ -2674,8 +3119,8 @@ func (w *Widget) mouseMoveEvent(event *qt.QMouseEvent) {
 	cols, rows := w.buffer.GetSize()
 	charWidth := w.charWidth
 	charHeight := w.charHeight
-	mouseX := pos.X()
-	mouseY := pos.Y()
+	mouseX := int(math.Floor(pos.X()))
+	mouseY := int(math.Floor(pos.Y()))
 	terminalWidth := cols * charWidth
 	terminalHeight := rows * charHeight
 
@@ -2835,12 +3280,34 @@ func (w *Widget) stopAutoScroll() {
 }
 
 func (w *Widget) wheelEvent(event *qt.QWheelEvent) {
+	if w.zoomWheel(event) {
+		return
+	}
 	modifiers := event.Modifiers()
 	hasShift := modifiers&qt.ShiftModifier != 0
 
 	deltaY := event.AngleDelta().Y()
 	deltaX := event.AngleDelta().X()
 
+	// With mouse tracking on, the wheel goes to the application
+	if w.mouseReporting(modifiers) {
+		button := -1
+		switch {
+		case deltaY > 0:
+			button = purfecterm.MouseWheelUp
+		case deltaY < 0:
+			button = purfecterm.MouseWheelDown
+		case deltaX > 0:
+			button = purfecterm.MouseWheelLeft
+		case deltaX < 0:
+			button = purfecterm.MouseWheelRight
+		}
+		if button >= 0 {
+			w.reportMouse(purfecterm.MousePress, button, modifiers, event.PosF())
+		}
+		return
+	}
+
 	// Shift+scroll or horizontal scroll = horizontal scrolling
 	if hasShift || (deltaX != 0 && deltaY == 0) {
 		delta := deltaY
@@ -2869,31 +3336,8 @@ func (w *Widget) wheelEvent(event *qt.QWheelEvent) {
 		return
 	}
 
-	// Vertical scrolling
-	offset := w.buffer.GetScrollOffset()
-	scrollbackSize := w.buffer.GetScrollbackSize()
-
-	if deltaY > 0 {
-		// Scrolling UP into scrollback - don't normalize, let them push through
-		offset += 3
-		if offset > scrollbackSize {
-			offset = scrollbackSize
-		}
-		w.buffer.SetScrollOffset(offset)
-		w.buffer.NotifyManualVertScroll() // User initiated scroll
-	} else if deltaY < 0 {
-		// Scrolling DOWN toward logical screen
-		offset -= 3
-		if offset < 0 {
-			offset = 0
-		}
-		w.buffer.SetScrollOffset(offset)
-		// Only snap to 0 when scrolling DOWN into the magnetic zone
-		w.buffer.NormalizeScrollOffset()
-		w.buffer.NotifyManualVertScroll() // User initiated scroll
-	}
-
-	w.updateScrollbar()
+	// Vertical scrolling, by pixels (see smoothscroll.go)
+	w.smoothWheel(event)
 	w.updateHorizScrollbar() // Visibility may change based on scroll position
 }
 
@@ -2901,6 +3345,13 @@ func (w *Widget) focusInEvent(event *qt.QFocusEvent) {
 	w.hasFocus = true
 	w.cursorBlinkOn = true
 	w.widget.Update()
+
+	w.mu.Lock()
+	onFocus := w.onFocus
+	w.mu.Unlock()
+	if onFocus != nil {
+		onFocus()
+	}
 }
 
 func (w *Widget) focusOutEvent(event *qt.QFocusEvent) {
@@ -2943,6 +3394,7 @@ func (w *Widget) resizeEvent(event *qt.QResizeEvent) {
 			w.horizScrollbar.Hide()
 		}
 	}
+	w.positionFindBar()
 
 	// Apply screen scaling to character dimensions
 	horizScale := w.buffer.GetHorizontalScale()
@@ -3011,6 +3463,8 @@ func (w *Widget) CopySelection() {
 }
 
 // PasteClipboard pastes text from clipboard
+// When the application has enabled bracketed paste mode (2004), the text is
+// delivered in one input call wrapped in ESC[200~ ... ESC[201~ markers.
 func (w *Widget) PasteClipboard() {
 	w.mu.Lock()
 	onInput := w.onInput
@@ -3023,24 +3477,60 @@ func (w *Widget) PasteClipboard() {
 	clipboard := qt.QGuiApplication_Clipboard()
 	text := clipboard.Text()
 	if text != "" {
-		useBracketedPaste := w.buffer.IsBracketedPasteModeEnabled()
+		onInput(purfecterm.BracketPaste(text, w.buffer.IsBracketedPasteModeEnabled()))
+	}
+}
 
-		if !useBracketedPaste {
-			for _, c := range text {
-				if c == '\n' || c == '\r' || c == '\x1b' || c < 32 {
-					useBracketedPaste = true
-					break
-				}
-			}
-		}
+// primarySelectionEnabled returns whether selections go to the primary selection
+// and the middle button pastes it (only where the platform has one, as on X11)
+func (w *Widget) primarySelectionEnabled() bool {
+	w.mu.Lock()
+	enabled := w.scheme.PrimarySelection
+	w.mu.Unlock()
+	return enabled && qt.QGuiApplication_Clipboard().SupportsSelection()
+}
 
-		if useBracketedPaste {
-			onInput([]byte("\x1b[200~"))
-			onInput([]byte(text))
-			onInput([]byte("\x1b[201~"))
-		} else {
-			onInput([]byte(text))
-		}
+// copySelectionToPrimary puts the selected text in the primary selection
+func (w *Widget) copySelectionToPrimary() {
+	if w.buffer.HasSelection() {
+		qt.QGuiApplication_Clipboard().SetText2(w.buffer.GetSelectedText(), qt.QClipboard__Selection)
+	}
+}
+
+// PastePrimary pastes the primary selection into the terminal, as a middle click does
+func (w *Widget) PastePrimary() {
+	w.mu.Lock()
+	onInput := w.onInput
+	w.mu.Unlock()
+
+	if onInput == nil {
+		return
+	}
+
+	text := qt.QGuiApplication_Clipboard().TextWithMode(qt.QClipboard__Selection)
+	if text != "" {
+		onInput(purfecterm.BracketPaste(text, w.buffer.IsBracketedPasteModeEnabled()))
+	}
+}
+
+// handleClipboardRequest sets the clipboard or primary selection for a program, or
+// sends the program its contents
+func (w *Widget) handleClipboardRequest(req purfecterm.ClipboardRequest) {
+	clipboard := qt.QGuiApplication_Clipboard()
+	mode := qt.QClipboard__Clipboard
+	if req.Primary {
+		mode = qt.QClipboard__Selection
+	}
+	if !req.Query {
+		clipboard.SetText2(req.Text, mode)
+		return
+	}
+
+	w.mu.Lock()
+	onInput := w.onInput
+	w.mu.Unlock()
+	if onInput != nil {
+		onInput(purfecterm.EncodeClipboardReply(req, clipboard.TextWithMode(mode)))
 	}
 }
 
@@ -0,0 +1,104 @@
+package purfectermqt
+
+import (
+	"runtime"
+
+	"github.com/mappu/miqt/qt"
+	"github.com/phroun/pawscript/src/pkg/purfecterm"
+)
+
+// Zoom
+// Ctrl+wheel (also Cmd+wheel on macOS) and Ctrl+Shift+plus, minus and 0 (Cmd+=,
+// Cmd+- and Cmd+0 on macOS) zoom the font in, out and back to 100% (see
+// purfecterm/zoom.go). The zoom applies on top of the size given to SetFont, so the
+// configured font size is unchanged; the host is told of each change the user makes
+// through the zoom callback, to keep it per window.
+
+// SetZoom sets the font zoom factor (1.0 for the size given to SetFont)
+func (w *Widget) SetZoom(zoom float64) {
+	zoom = purfecterm.ClampZoom(zoom)
+	w.mu.Lock()
+	if zoom == w.zoom {
+		w.mu.Unlock()
+		return
+	}
+	w.zoom = zoom
+	w.fontSize = purfecterm.ZoomFontSize(w.baseFontSize, zoom)
+	w.mu.Unlock()
+	w.resizeEvent(nil)
+	w.widget.Update()
+}
+
+// GetZoom returns the font zoom factor
+func (w *Widget) GetZoom() float64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.zoom
+}
+
+// SetZoomCallback sets a callback for when the user changes the zoom
+func (w *Widget) SetZoomCallback(fn func(zoom float64)) {
+	w.onZoom = fn
+}
+
+// zoomTo sets the zoom as the user asked, telling the zoom callback if it changed
+func (w *Widget) zoomTo(zoom float64) {
+	before := w.GetZoom()
+	w.SetZoom(zoom)
+	if after := w.GetZoom(); after != before && w.onZoom != nil {
+		w.onZoom(after)
+	}
+}
+
+// isZoomModifier returns whether the modifiers held zoom with the wheel (Qt's
+// ControlModifier is Command on macOS, and MetaModifier Ctrl)
+func isZoomModifier(modifiers qt.KeyboardModifier) bool {
+	if modifiers&qt.ControlModifier != 0 {
+		return true
+	}
+	return runtime.GOOS == "darwin" && modifiers&qt.MetaModifier != 0
+}
+
+// zoomWheel zooms for a wheel event with the zoom modifier held, returning
+// whether it did
+func (w *Widget) zoomWheel(event *qt.QWheelEvent) bool {
+	if !isZoomModifier(event.Modifiers()) {
+		return false
+	}
+	// Gather whole clicks (120 eighths of a degree), so touchpads zoom at a
+	// steady pace
+	w.zoomClicks += float64(event.AngleDelta().Y()) / 120
+	steps := 0
+	for ; w.zoomClicks >= 1; w.zoomClicks-- {
+		steps++
+	}
+	for ; w.zoomClicks <= -1; w.zoomClicks++ {
+		steps--
+	}
+	if steps != 0 {
+		w.zoomTo(purfecterm.StepZoom(w.GetZoom(), steps))
+	}
+	event.Accept()
+	return true
+}
+
+// zoomKey zooms for a zoom shortcut, returning whether the key press was one
+// (with hasCtrl the physical Ctrl key and hasMeta Command on macOS, as
+// keyPressEvent has them)
+func (w *Widget) zoomKey(key qt.Key, hasShift, hasCtrl, hasAlt, hasMeta bool) bool {
+	macCmd := runtime.GOOS == "darwin" && hasMeta && !hasCtrl && !hasAlt
+	if !macCmd && !(hasCtrl && hasShift && !hasAlt && !hasMeta) {
+		return false
+	}
+	switch key {
+	case qt.Key_Plus, qt.Key_Equal:
+		w.zoomTo(purfecterm.StepZoom(w.GetZoom(), 1))
+	case qt.Key_Minus, qt.Key_Underscore:
+		w.zoomTo(purfecterm.StepZoom(w.GetZoom(), -1))
+	case qt.Key_0, qt.Key_ParenRight:
+		w.zoomTo(1.0)
+	default:
+		return false
+	}
+	return true
+}
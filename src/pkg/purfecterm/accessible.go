@@ -0,0 +1,118 @@
+package purfecterm
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Accessibility
+// The widgets expose the visible screen to screen readers as plain text, one line
+// per visible row, with the cursor as the caret. As the screen changes they report
+// what changed (TextChange), so a reader can speak output as it arrives, as it
+// does for REPL interaction. Offsets in the text are in runes, as the platforms'
+// accessibility interfaces count characters.
+
+// TextUnit is a unit of accessible text a screen reader moves or reads by
+type TextUnit int
+
+const (
+	TextChar TextUnit = iota
+	TextWord          // A word with the blanks after it
+	TextLine          // A line with its line break
+)
+
+// AccessibleText returns the visible screen as text, each row with its trailing
+// blanks dropped, and the cursor's offset in it (-1 when it is off screen)
+func (b *Buffer) AccessibleText() (string, int) {
+	cursorX, cursorY := b.GetCursorVisiblePosition()
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var text strings.Builder
+	caret := -1
+	n := 0 // Runes written so far
+	line := make([]rune, 0, b.cols)
+	colStart := make([]int, b.cols) // Offset in line of each column
+	for y := 0; y < b.rows; y++ {
+		if y > 0 {
+			text.WriteByte('\n')
+			n++
+		}
+		line = line[:0]
+		end := 0 // Length of line without its trailing blanks
+		for x := 0; x < b.cols; x++ {
+			colStart[x] = len(line)
+			cell := b.getVisibleCellInternal(x, y)
+			ch := cell.Char
+			if ch == 0 {
+				ch = ' '
+			}
+			line = append(line, ch)
+			line = append(line, []rune(cell.Combining)...)
+			if ch != ' ' {
+				end = len(line)
+			}
+		}
+		if y == cursorY {
+			// Blanks before the cursor stay, as after a prompt
+			end = max(end, colStart[cursorX])
+			caret = n + colStart[cursorX]
+		}
+		text.WriteString(string(line[:end]))
+		n += end
+	}
+	return text.String(), caret
+}
+
+// TextChange returns how text changed from old to new: the offset of the change,
+// the text removed there and the text inserted in its place
+func TextChange(old, new []rune) (pos int, removed, inserted []rune) {
+	for pos < len(old) && pos < len(new) && old[pos] == new[pos] {
+		pos++
+	}
+	oldEnd, newEnd := len(old), len(new)
+	for oldEnd > pos && newEnd > pos && old[oldEnd-1] == new[newEnd-1] {
+		oldEnd--
+		newEnd--
+	}
+	return pos, old[pos:oldEnd], new[pos:newEnd]
+}
+
+// TextUnitAt returns the start and end offsets of the unit of text at offset. A
+// word offset in the blanks after a word gives that word.
+func TextUnitAt(text []rune, offset int, unit TextUnit) (start, end int) {
+	offset = min(max(offset, 0), len(text))
+	switch unit {
+	case TextWord:
+		start = offset
+		for start > 0 && (start == len(text) || unicode.IsSpace(text[start])) {
+			start--
+		}
+		for start > 0 && !unicode.IsSpace(text[start-1]) {
+			start--
+		}
+		end = start
+		for end < len(text) && !unicode.IsSpace(text[end]) {
+			end++
+		}
+		for end < len(text) && unicode.IsSpace(text[end]) && text[end] != '\n' {
+			end++
+		}
+		return start, end
+	case TextLine:
+		start, end = offset, offset
+		for start > 0 && text[start-1] != '\n' {
+			start--
+		}
+		for end < len(text) && text[end] != '\n' {
+			end++
+		}
+		if end < len(text) {
+			end++
+		}
+		return start, end
+	default:
+		return offset, min(offset+1, len(text))
+	}
+}
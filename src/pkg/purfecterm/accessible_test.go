@@ -0,0 +1,67 @@
+package purfecterm
+
+import "testing"
+
+func TestAccessibleText(t *testing.T) {
+	b := newGridTestBuffer("hello   \r\nwo\u0301rld\r\n> ")
+	text, caret := b.AccessibleText()
+	if want := "hello\nwo\u0301rld\n> \n"; text != want {
+		t.Errorf("AccessibleText() text = %q, want %q", text, want)
+	}
+	if caret != 15 {
+		t.Errorf("AccessibleText() caret = %d, want 15", caret)
+	}
+
+	b = newGridTestBuffer("a\r\n\r\n\r\n\r\n")
+	if text, caret := b.AccessibleText(); text != "\n\n\n" || caret != 3 {
+		t.Errorf("AccessibleText() after scrolling = %q, %d, want %q, 3", text, caret, "\n\n\n")
+	}
+}
+
+func TestTextChange(t *testing.T) {
+	tests := []struct {
+		old, new          string
+		pos               int
+		removed, inserted string
+	}{
+		{"> ", "> ls", 2, "", "ls"},
+		{"> ls", "> l", 3, "s", ""},
+		{"abc", "abc", 3, "", ""},
+		{"a\nb", "x\nb", 0, "a", "x"},
+		{"aaa", "aaaa", 3, "", "a"},
+	}
+	for _, tt := range tests {
+		pos, removed, inserted := TextChange([]rune(tt.old), []rune(tt.new))
+		if pos != tt.pos || string(removed) != tt.removed || string(inserted) != tt.inserted {
+			t.Errorf("TextChange(%q, %q) = %d, %q, %q, want %d, %q, %q",
+				tt.old, tt.new, pos, string(removed), string(inserted), tt.pos, tt.removed, tt.inserted)
+		}
+	}
+}
+
+func TestTextUnitAt(t *testing.T) {
+	text := []rune("ls -la  foo\n> bar")
+	tests := []struct {
+		offset     int
+		unit       TextUnit
+		start, end int
+	}{
+		{0, TextChar, 0, 1},
+		{17, TextChar, 17, 17},
+		{1, TextWord, 0, 3},
+		{3, TextWord, 3, 8},
+		{6, TextWord, 3, 8},
+		{10, TextWord, 8, 11},
+		{11, TextWord, 8, 11},
+		{17, TextWord, 14, 17},
+		{4, TextLine, 0, 12},
+		{11, TextLine, 0, 12},
+		{12, TextLine, 12, 17},
+		{-5, TextLine, 0, 12},
+	}
+	for _, tt := range tests {
+		if start, end := TextUnitAt(text, tt.offset, tt.unit); start != tt.start || end != tt.end {
+			t.Errorf("TextUnitAt(%d, %d) = %d, %d, want %d, %d", tt.offset, tt.unit, start, end, tt.start, tt.end)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package purfecterm
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Automatic links
+// When a line feed leaves a line, plain-text URLs on it (and on the lines that
+// auto-wrapped onto it) become links, as if the program had written them with OSC 8, so the widgets underline them on hover and
+// open them on Ctrl+click. Text already inside an OSC 8 link is left alone. The link
+// is stored in each cell's LinkID, so it stays with its text as the text moves.
+// Mode 7703 turns detection off (h) and on again (l).
+
+// autoLinkPattern matches URLs in plain text
+var autoLinkPattern = regexp.MustCompile(`\b(?:(?:https?|ftp)://|mailto:|www\.)[^\s<>"'` + "`" + `]+`)
+
+// SetAutoLinks enables or disables automatic URL detection
+func (b *Buffer) SetAutoLinks(enabled bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.autoLinks = enabled
+}
+
+// IsAutoLinksEnabled returns whether automatic URL detection is enabled
+func (b *Buffer) IsAutoLinksEnabled() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.autoLinks
+}
+
+// detectLinks turns the plain-text URLs on a screen row into links, along with the
+// rows above it that wrapped onto it, so a URL split by auto-wrap is one link
+// Must be called with the lock held.
+func (b *Buffer) detectLinks(row int) {
+	if !b.autoLinks || row < 0 || row >= len(b.screen) {
+		return
+	}
+	first := row
+	for first > 0 && first-1 < len(b.lineInfos) && b.lineInfos[first-1].Wrapped {
+		first--
+	}
+
+	// cellRows and cols give the row and column each byte of text comes from
+	var text strings.Builder
+	var cellRows, cols []int
+	for y := first; y <= row; y++ {
+		start := 0
+		if y > first && y < len(b.lineInfos) {
+			start = min(b.lineInfos[y].WrapIndent, len(b.screen[y])) // Smart wrap's indent
+		}
+		for col := start; col < len(b.screen[y]); col++ {
+			cell := b.screen[y][col]
+			ch := cell.Char
+			if ch == 0 || cell.LinkID != 0 {
+				ch = ' ' // Keeps URLs from running into OSC 8 links
+			}
+			text.WriteRune(ch)
+			for range len(string(ch)) {
+				cellRows = append(cellRows, y)
+				cols = append(cols, col)
+			}
+		}
+	}
+
+	str := text.String()
+	for _, loc := range autoLinkPattern.FindAllStringIndex(str, -1) {
+		url := trimURL(str[loc[0]:loc[1]])
+		if !autoLinkPattern.MatchString(url) {
+			continue // Nothing left after the scheme
+		}
+		end := loc[0] + len(url)
+		if strings.HasPrefix(url, "www.") {
+			url = "http://" + url
+		}
+		linkID := b.addHyperlink(hyperlink{uri: url})
+		for i := loc[0]; i < end; i++ {
+			b.screen[cellRows[i]][cols[i]].LinkID = linkID
+		}
+	}
+}
+
+// trimURL drops punctuation that ends the sentence around a URL rather than the URL,
+// keeping closing brackets that match an opening one inside it
+func trimURL(url string) string {
+	for len(url) > 0 {
+		last := url[len(url)-1]
+		switch last {
+		case '.', ',', ';', ':', '!', '?':
+		case ')', ']', '}':
+			open := "([{"[strings.IndexByte(")]}", last)]
+			if strings.Count(url, string(open)) >= strings.Count(url, string(last)) {
+				return url
+			}
+		default:
+			return url
+		}
+		url = url[:len(url)-1]
+	}
+	return url
+}
@@ -0,0 +1,49 @@
+package purfecterm
+
+// Background
+// A scheme can make the terminal's background see-through (BackgroundOpacity),
+// blurring what shows through it (BackgroundBlur), and draw an image under the
+// text (BackgroundImage), faded toward the background color by BackgroundDim so
+// the text stays readable. Only cells with the default background show them; the
+// widgets draw schemes with them without the GPU renderers.
+
+// MinBackgroundOpacity is the least opacity a background is drawn with
+const MinBackgroundOpacity = 0.1
+
+// BackgroundAlpha returns the opacity to draw the background with, 1 for opaque
+func (s ColorScheme) BackgroundAlpha() float64 {
+	if s.BackgroundOpacity <= 0 || s.BackgroundOpacity >= 1 {
+		return 1
+	}
+	return max(s.BackgroundOpacity, MinBackgroundOpacity)
+}
+
+// BackgroundDimming returns how far the background image is faded toward the
+// background color, from 0 (not at all) to 1 (hidden)
+func (s ColorScheme) BackgroundDimming() float64 {
+	return min(max(s.BackgroundDim, 0), 1)
+}
+
+// SolidBackground returns whether the background is an opaque color, with no
+// image, as the GPU renderers draw it
+func (s ColorScheme) SolidBackground() bool {
+	return s.BackgroundAlpha() == 1 && s.BackgroundImage == ""
+}
+
+// BackgroundBlurred returns whether what shows through the background is blurred
+func (s ColorScheme) BackgroundBlurred() bool {
+	return s.BackgroundBlur && s.BackgroundAlpha() < 1
+}
+
+// ImageCover returns how to scale and place an image so it covers an area,
+// keeping its aspect ratio and centering it: the scale, and the position of the
+// scaled image's top left corner (zero or negative)
+func ImageCover(imageW, imageH, areaW, areaH int) (scale, x, y float64) {
+	if imageW <= 0 || imageH <= 0 {
+		return 1, 0, 0
+	}
+	scale = max(float64(areaW)/float64(imageW), float64(areaH)/float64(imageH))
+	x = (float64(areaW) - float64(imageW)*scale) / 2
+	y = (float64(areaH) - float64(imageH)*scale) / 2
+	return scale, x, y
+}
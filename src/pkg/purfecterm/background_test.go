@@ -0,0 +1,66 @@
+package purfecterm
+
+import "testing"
+
+func TestBackgroundAlpha(t *testing.T) {
+	tests := []struct {
+		opacity float64
+		want    float64
+	}{
+		{0, 1}, // Unset
+		{1, 1},
+		{0.8, 0.8},
+		{0.01, MinBackgroundOpacity},
+		{2, 1},
+	}
+	for _, tt := range tests {
+		s := ColorScheme{BackgroundOpacity: tt.opacity}
+		if got := s.BackgroundAlpha(); got != tt.want {
+			t.Errorf("BackgroundAlpha with opacity %v = %v, want %v", tt.opacity, got, tt.want)
+		}
+	}
+}
+
+func TestSolidBackground(t *testing.T) {
+	if !DefaultColorScheme().SolidBackground() {
+		t.Error("default scheme: background not solid")
+	}
+	if (ColorScheme{BackgroundOpacity: 0.9}).SolidBackground() {
+		t.Error("opacity 0.9: background solid")
+	}
+	if (ColorScheme{BackgroundImage: "/tmp/cat.png"}).SolidBackground() {
+		t.Error("with an image: background solid")
+	}
+}
+
+func TestBackgroundBlurred(t *testing.T) {
+	if (ColorScheme{BackgroundBlur: true}).BackgroundBlurred() {
+		t.Error("blur on an opaque background")
+	}
+	if !(ColorScheme{BackgroundBlur: true, BackgroundOpacity: 0.7}).BackgroundBlurred() {
+		t.Error("no blur on a see-through background")
+	}
+}
+
+func TestImageCover(t *testing.T) {
+	tests := []struct {
+		imageW, imageH, areaW, areaH int
+		scale, x, y                  float64
+	}{
+		{100, 100, 200, 100, 2, 0, -50},   // Wider area: fit the width
+		{100, 100, 100, 200, 2, -50, 0},   // Taller area: fit the height
+		{400, 200, 200, 100, 0.5, 0, 0},   // Same shape, smaller
+		{0, 100, 200, 100, 1, 0, 0},       // Empty image
+		{200, 100, 100, 100, 1, -50, 0},   // Wider image
+		{100, 400, 100, 100, 1, 0, -150},  // Taller image
+		{300, 300, 600, 300, 2, 0, -150},  // Scaled up
+		{1000, 500, 500, 500, 1, -250, 0}, // Cropped at the sides
+	}
+	for _, tt := range tests {
+		scale, x, y := ImageCover(tt.imageW, tt.imageH, tt.areaW, tt.areaH)
+		if scale != tt.scale || x != tt.x || y != tt.y {
+			t.Errorf("ImageCover(%d, %d, %d, %d) = %v, %v, %v, want %v, %v, %v",
+				tt.imageW, tt.imageH, tt.areaW, tt.areaH, scale, x, y, tt.scale, tt.x, tt.y)
+		}
+	}
+}
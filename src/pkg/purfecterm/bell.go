@@ -0,0 +1,40 @@
+package purfecterm
+
+import (
+	"time"
+)
+
+// Bell
+// BEL (0x07) outside a string sequence rings the bell: the renderers flash the screen
+// for VisualBellDuration if ColorScheme.VisualBell is set, and the bell callback
+// lets the host react too, such as flagging a window in the background.
+
+// VisualBellDuration is how long the screen flashes for a bell
+const VisualBellDuration = 150 * time.Millisecond
+
+// SetBellCallback sets a callback to be invoked when the bell rings
+// It is called from whatever goroutine feeds the parser, without the lock held.
+func (b *Buffer) SetBellCallback(fn func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onBell = fn
+}
+
+// Bell rings the bell
+func (b *Buffer) Bell() {
+	b.mu.Lock()
+	b.lastBell = time.Now()
+	b.markDirty()
+	onBell := b.onBell
+	b.mu.Unlock()
+	if onBell != nil {
+		onBell()
+	}
+}
+
+// IsBellFlashing returns whether a visual bell should be showing
+func (b *Buffer) IsBellFlashing() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return time.Since(b.lastBell) < VisualBellDuration
+}
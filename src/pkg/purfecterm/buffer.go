@@ -2,6 +2,7 @@ package purfecterm
 
 import (
 	"fmt"
+	"io"
 	"sort"
 	"strings"
 	"sync"
@@ -119,8 +120,28 @@ type Buffer struct {
 	cursorShape   int // 0=block, 1=underline, 2=bar
 	cursorBlink   int // 0=no blink, 1=slow blink, 2=fast blink
 
+	// Cursor style restored by reset and DECSCUSR 0 (see SetDefaultCursorStyle)
+	defaultCursorShape int
+	defaultCursorBlink int
+
 	bracketedPasteMode bool
 
+	// Mouse reporting (modes 1000/1002/1003 and 1006)
+	mouseTracking MouseTracking
+	mouseSGR      bool
+
+	// Matches of the last Search (nil = no search)
+	search *searchState
+
+	// Cast being recorded (nil = not recording)
+	recording *castRecorder
+
+	// Writer output is copied to (nil = none)
+	tee io.Writer
+
+	// Automatic URL detection (mode 7703 turns it off)
+	autoLinks bool
+
 	currentFg        Color
 	currentBg            Color
 	currentBold          bool
@@ -132,7 +153,9 @@ type Buffer struct {
 	currentReverse       bool
 	currentBlink         bool
 	currentStrikethrough bool
+	currentOverline      bool
 	currentFlexWidth     bool // Current attribute for East Asian Width mode
+	currentLink          int  // Hyperlink ID for new characters (0 = none)
 
 	// Flexible cell width mode (East Asian Width)
 	flexWidthMode      bool               // When true, new chars get FlexWidth=true and calculated CellWidth
@@ -149,6 +172,8 @@ type Buffer struct {
 	// Scrollback storage
 	scrollback         [][]Cell
 	scrollbackInfo     []LineInfo
+	scrollbackPacked   []*packedLine // Compressed form of aged lines, whose cells are then nil
+	unpacked           unpackedCache
 	maxScrollback      int
 	scrollOffset       int  // Vertical scroll offset
 	scrollbackDisabled bool // When true, scrollback accumulation is disabled (for games)
@@ -179,16 +204,44 @@ type Buffer struct {
 	smartWordWrap bool // When true, wrap at word boundaries instead of mid-word
 
 	selectionActive      bool
+	selectionBlock       bool // Rectangular selection: the same columns on every line
 	selStartX, selStartY int
 	selEndX, selEndY     int
 
 	savedCursorX int
 	savedCursorY int
 
+	// Margins (see margins.go): first row/column and the one after the last,
+	// 0 for the bottom or right meaning the screen edge
+	marginTop             int
+	marginBottom          int
+	marginLeft            int
+	marginRight           int
+	leftRightMarginMode   bool // DECLRMM: ESC [ s sets left and right margins
+	originMode            bool // DECOM: cursor positions are relative to the margins
+	savedOriginMode       bool
+	noClearOnColumnChange bool // DECNCSM: DECCOLM keeps the screen
+
 	dirty         bool
 	onDirty       func()
-	onScaleChange func()     // Called when screen scaling modes change
-	onThemeChange func(bool) // Called when theme changes (arg: isDark)
+	onScaleChange func()         // Called when screen scaling modes change
+	onThemeChange func(bool)     // Called when theme changes (arg: isDark)
+	onResize      func(int, int) // Called when the physical size changes (args: cols, rows)
+	onBell        func()         // Called when the bell rings (see bell.go)
+	lastBell      time.Time      // When the bell last rang, for the visual bell
+
+	// Clipboard (OSC 52, see clipboard.go)
+	clipboardAccess ClipboardAccess
+	onClipboard     func(ClipboardRequest)
+
+	// Query responses (DA, DSR, XTVERSION, see responses.go)
+	identity   string // Given in the XTVERSION reply ("" for DefaultTerminalIdentity)
+	onResponse func([]byte)
+
+	// Panes (OSC 7005, see panes.go)
+	panes        *PaneNode // The layout, nil until the terminal is split
+	focusedPane  int
+	onPaneChange func(layoutChanged bool)
 
 	// Theme state (DECSCNM - Screen Mode)
 	darkTheme          bool // Current theme: true=dark, false=light
@@ -226,6 +279,18 @@ type Buffer struct {
 
 	// Max content width from splits (for horizontal scrollbar, independent from scrollback)
 	splitContentWidth int
+
+	// Hyperlinks (OSC 8) referenced by Cell.LinkID
+	links      map[int]hyperlink
+	linkIDs    map[hyperlink]int // Links with an id= parameter, for reuse
+	nextLinkID int
+
+	// Inline images (sixel graphics), anchored to lines
+	images       []*InlineImage
+	nextImageID  int
+	linesDropped int64 // Lines discarded from the top of scrollback, for image anchors
+	cellPixelW   int   // Cell size in pixels, used to size images in cells
+	cellPixelH   int
 }
 
 // ScreenSplit defines a split region that can show a different part of the buffer.
@@ -268,7 +333,14 @@ func NewBuffer(cols, rows, maxScrollback int) *Buffer {
 		widthCrop:           -1, // -1 = no crop
 		heightCrop:          -1, // -1 = no crop
 		screenSplits:        make(map[int]*ScreenSplit),
+		nextImageID:         1,
+		links:               make(map[int]hyperlink),
+		linkIDs:             make(map[hyperlink]int),
+		nextLinkID:          1,
+		cellPixelW:          10,
+		cellPixelH:          20,
 		autoWrapMode:        true, // DECAWM default enabled
+		autoLinks:           true,
 		smartWordWrap:       true, // Smart word wrap default enabled
 	}
 	b.initScreen()
@@ -319,6 +391,14 @@ func (b *Buffer) notifyScaleChange() {
 	}
 }
 
+// SetResizeCallback sets a callback to be invoked when the physical size changes
+// It is called with the buffer locked, so it must not call back into the buffer.
+func (b *Buffer) SetResizeCallback(fn func(cols, rows int)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onResize = fn
+}
+
 // SetThemeChangeCallback sets a callback to be invoked when the terminal theme changes
 // The callback receives true for dark theme, false for light theme
 func (b *Buffer) SetThemeChangeCallback(fn func(bool)) {
@@ -436,6 +516,7 @@ func (b *Buffer) Resize(cols, rows int) {
 	}
 
 	// Calculate logicalHiddenAbove BEFORE resize to track scrollback visibility state
+	oldEffectiveCols := b.EffectiveCols()
 	oldEffectiveRows := b.EffectiveRows()
 	oldLogicalHiddenAbove := 0
 	if oldEffectiveRows > b.rows {
@@ -459,9 +540,15 @@ func (b *Buffer) Resize(cols, rows int) {
 		}
 	}
 
+	colsChanged := cols != b.cols
 	b.cols = cols
 	b.rows = rows
 
+	// Rewrap soft-wrapped lines to the new width (see reflow.go)
+	if colsChanged && b.logicalCols == 0 && b.autoWrapMode && b.isFullScrollRegion() {
+		b.reflow()
+	}
+
 	// If logical dimensions are 0 (using physical), we may need to adjust screen size
 	if b.logicalRows == 0 {
 		b.adjustScreenToRows(rows)
@@ -498,6 +585,13 @@ func (b *Buffer) Resize(cols, rows int) {
 		b.scrollOffset = maxOffset
 	}
 
+	if effectiveCols != oldEffectiveCols || effectiveRows != oldEffectiveRows {
+		b.recordResize()
+	}
+	if b.onResize != nil {
+		b.onResize(cols, rows)
+	}
+
 	b.markDirty()
 }
 
@@ -572,6 +666,8 @@ func (b *Buffer) adjustScreenToRows(targetRows int) {
 func (b *Buffer) pushLineToScrollback(line []Cell, info LineInfo) {
 	// Skip if scrollback is disabled (lines are discarded instead)
 	if b.scrollbackDisabled {
+		b.linesDropped++
+		b.pruneImages()
 		return
 	}
 
@@ -579,10 +675,15 @@ func (b *Buffer) pushLineToScrollback(line []Cell, info LineInfo) {
 	if len(b.scrollback) >= b.maxScrollback {
 		b.scrollback = b.scrollback[1:]
 		b.scrollbackInfo = b.scrollbackInfo[1:]
+		b.scrollbackPacked = b.scrollbackPacked[1:]
+		b.linesDropped++
+		b.pruneImages()
 		trimmed = true
 	}
 	b.scrollback = append(b.scrollback, line)
 	b.scrollbackInfo = append(b.scrollbackInfo, info)
+	b.scrollbackPacked = append(b.scrollbackPacked, nil)
+	b.packAgedScrollback()
 
 	// If scrollback was trimmed from front and we're scrolled into scrollback,
 	// adjust offset to keep viewing the same content
@@ -772,6 +873,9 @@ func (b *Buffer) setCursorInternal(x, y int) {
 	if y >= effectiveRows {
 		y = effectiveRows - 1
 	}
+	if lineCols := b.lineCols(y); x >= lineCols {
+		x = lineCols - 1 // Double-width lines hold half as many columns
+	}
 
 	b.trackCursorYMove(y)
 	b.setHorizMoveDir(0, true) // Absolute positioning - direction unknown
@@ -959,6 +1063,29 @@ func (b *Buffer) SetCursorStyle(shape, blink int) {
 	b.markDirty()
 }
 
+// SetDefaultCursorStyle sets the cursor style that reset and DECSCUSR 0 restore
+// The cursor takes it now too, unless a program has changed the style.
+func (b *Buffer) SetDefaultCursorStyle(shape, blink int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.cursorShape == b.defaultCursorShape && b.cursorBlink == b.defaultCursorBlink {
+		b.cursorShape = shape
+		b.cursorBlink = blink
+		b.markDirty()
+	}
+	b.defaultCursorShape = shape
+	b.defaultCursorBlink = blink
+}
+
+// ResetCursorStyle restores the default cursor style
+func (b *Buffer) ResetCursorStyle() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.cursorShape = b.defaultCursorShape
+	b.cursorBlink = b.defaultCursorBlink
+	b.markDirty()
+}
+
 // GetCursorStyle returns the cursor shape and blink mode
 func (b *Buffer) GetCursorStyle() (shape, blink int) {
 	b.mu.RLock()
@@ -980,6 +1107,22 @@ func (b *Buffer) IsBracketedPasteModeEnabled() bool {
 	return b.bracketedPasteMode
 }
 
+// Bracketed paste markers (mode 2004)
+const (
+	PasteStart = "\x1b[200~"
+	PasteEnd   = "\x1b[201~"
+)
+
+// BracketPaste returns the input to send for pasted text
+// With bracketed paste mode enabled the text is wrapped in PasteStart/PasteEnd; any
+// end marker inside the text is removed so the paste can't end early and inject keys.
+func BracketPaste(text string, bracketed bool) []byte {
+	if !bracketed {
+		return []byte(text)
+	}
+	return []byte(PasteStart + strings.ReplaceAll(text, PasteEnd, "") + PasteEnd)
+}
+
 // SetFlexWidthMode enables or disables flexible East Asian Width mode
 // When enabled, new characters get FlexWidth=true and their CellWidth calculated
 // based on Unicode East_Asian_Width property (0.5/1.0/1.5/2.0 cell units)
@@ -1036,6 +1179,7 @@ func (b *Buffer) SaveCursor() {
 	defer b.mu.Unlock()
 	b.savedCursorX = b.cursorX
 	b.savedCursorY = b.cursorY
+	b.savedOriginMode = b.originMode
 }
 
 // RestoreCursor restores the saved cursor position
@@ -1045,6 +1189,7 @@ func (b *Buffer) RestoreCursor() {
 	b.cursorX = b.savedCursorX
 	b.trackCursorYMove(b.savedCursorY)
 	b.cursorY = b.savedCursorY
+	b.originMode = b.savedOriginMode
 	b.markDirty()
 }
 
@@ -1058,26 +1203,7 @@ func (b *Buffer) WriteChar(ch rune) {
 // getPreviousCellWidth returns the width of the previous cell for ambiguous auto-matching.
 // If there's no previous cell or it doesn't have FlexWidth set, returns 1.0.
 func (b *Buffer) getPreviousCellWidth() float64 {
-	// Find the previous cell
-	prevX := b.cursorX - 1
-	prevY := b.cursorY
-
-	// If we're at the start of a line, try the end of the previous line
-	if prevX < 0 {
-		if prevY > 0 {
-			prevY--
-			if prevY < len(b.screen) && len(b.screen[prevY]) > 0 {
-				prevX = len(b.screen[prevY]) - 1
-			} else {
-				return 1.0 // No previous cell, default to 1.0
-			}
-		} else {
-			return 1.0 // At start of buffer, default to 1.0
-		}
-	}
-
-	// Get the previous cell
-	if prevY < len(b.screen) && prevX < len(b.screen[prevY]) {
+	if prevX, prevY, ok := b.previousCell(); ok {
 		prevCell := b.screen[prevY][prevX]
 		if prevCell.FlexWidth && prevCell.CellWidth > 0 {
 			return prevCell.CellWidth
@@ -1124,15 +1250,26 @@ func (b *Buffer) GetTotalLineVisualWidth(row int) float64 {
 }
 
 func (b *Buffer) writeCharInternal(ch rune) {
-	// Handle combining characters (Hebrew vowel points, diacritics, etc.)
+	// Handle characters that continue a grapheme cluster (Hebrew vowel points,
+	// diacritics, emoji modifiers and ZWJ sequences, flags, etc.)
 	// These should be appended to the previous cell, not placed in a new cell
-	if IsCombiningMark(ch) {
+	if prevX, prevY, ok := b.previousCell(); ok && continuesCluster(b.screen[prevY][prevX], ch) {
 		b.appendCombiningMark(ch)
 		return
+	} else if !ok && isClusterExtender(ch) {
+		return // Nothing to attach to
 	}
 
 	effectiveCols := b.EffectiveCols()
-	effectiveRows := b.EffectiveRows()
+
+	// Inside left and right margins, text wraps at the right margin to the left one
+	// (a cursor just past the right margin is waiting to wrap)
+	left, right := b.horizontalMargins()
+	wrapCols := effectiveCols
+	if b.cursorX <= right+1 {
+		wrapCols = right + 1
+	}
+	wrapCols = min(wrapCols, b.lineCols(b.cursorY))
 
 	// Check if this character has a custom glyph defined
 	hasCustomGlyph := b.customGlyphs[ch] != nil
@@ -1181,16 +1318,16 @@ func (b *Buffer) writeCharInternal(ch rune) {
 	if b.visualWidthWrap && b.currentFlexWidth {
 		// Visual width wrap: wrap when adding this char would exceed column limit
 		currentVisualWidth := b.getLineVisualWidth(b.cursorY, b.cursorX)
-		shouldWrap = (currentVisualWidth + charWidth) > float64(effectiveCols)
+		shouldWrap = (currentVisualWidth + charWidth) > float64(wrapCols)
 	} else {
 		// Traditional cell-count wrap
-		shouldWrap = b.cursorX >= effectiveCols
+		shouldWrap = b.cursorX >= wrapCols
 	}
 
 	if shouldWrap {
 		if b.autoWrapMode {
 			// Check for smart word wrap
-			if b.smartWordWrap && b.cursorY < len(b.screen) {
+			if b.smartWordWrap && !b.hasHorizontalMargins() && b.cursorY < len(b.screen) {
 				line := b.screen[b.cursorY]
 
 				// Count leading spaces for indentation preservation
@@ -1202,13 +1339,15 @@ func (b *Buffer) writeCharInternal(ch rune) {
 						break
 					}
 				}
+				if leadingSpaces >= wrapCols {
+					leadingSpaces = 0 // An indent leaving no room for text isn't kept
+				}
 
 				// Look backwards for a word boundary character AFTER the leading indent
 				// Word boundaries: space, hyphen, comma, semicolon, emdash (U+2014)
 				wrapPoint := -1
 				for i := len(line) - 1; i > leadingSpaces; i-- {
-					ch := line[i].Char
-					if ch == ' ' || ch == '-' || ch == ',' || ch == ';' || ch == '—' {
+					if isWordBoundary(line[i].Char) {
 						wrapPoint = i
 						break
 					}
@@ -1216,12 +1355,7 @@ func (b *Buffer) writeCharInternal(ch rune) {
 
 				// Move to next line
 				b.setHorizMoveDir(-1, false)
-				b.trackCursorYMove(b.cursorY + 1)
-				b.cursorY++
-				if b.cursorY >= effectiveRows {
-					b.scrollUpInternal()
-					b.cursorY = effectiveRows - 1
-				}
+				b.indexInternal()
 
 				// Ensure screen has enough rows
 				for b.cursorY >= len(b.screen) {
@@ -1261,20 +1395,19 @@ func (b *Buffer) writeCharInternal(ch rune) {
 					}
 					b.cursorX = leadingSpaces
 				}
+				b.markWrapped(leadingSpaces)
 			} else {
-				// Standard auto-wrap: move to next line
+				// Standard auto-wrap: move to the start of the next line (or the left margin)
 				b.setHorizMoveDir(-1, false)
-				b.cursorX = 0
-				b.trackCursorYMove(b.cursorY + 1)
-				b.cursorY++
-				if b.cursorY >= effectiveRows {
-					b.scrollUpInternal()
-					b.cursorY = effectiveRows - 1
+				b.cursorX = left
+				b.indexInternal()
+				if !b.hasHorizontalMargins() {
+					b.markWrapped(0)
 				}
 			}
 		} else {
 			// Auto-wrap disabled (DECAWM off): stay at last column, overwrite character
-			b.cursorX = effectiveCols - 1
+			b.cursorX = wrapCols - 1
 		}
 	}
 
@@ -1306,10 +1439,12 @@ func (b *Buffer) writeCharInternal(ch rune) {
 		Reverse:           b.currentReverse,
 		Blink:             b.currentBlink,
 		Strikethrough:     b.currentStrikethrough,
+		Overline:          b.currentOverline,
 		FlexWidth:         b.currentFlexWidth,
 		BGP:               b.currentBGP,
 		XFlip:             b.currentXFlip,
 		YFlip:             b.currentYFlip,
+		LinkID:            b.currentLink,
 	}
 
 	// Use the calculated charWidth (already accounts for custom glyphs and ambiguous width mode)
@@ -1327,36 +1462,39 @@ func (b *Buffer) writeCharInternal(ch rune) {
 // appendCombiningMark appends a combining character to the previous cell.
 // If there's no previous cell to attach to, the character is ignored.
 func (b *Buffer) appendCombiningMark(ch rune) {
-	// Find the previous cell to attach the combining mark to
-	prevX := b.cursorX - 1
-	prevY := b.cursorY
-
-	// If we're at the start of a line, try the end of the previous line
-	if prevX < 0 {
-		if prevY > 0 {
-			prevY--
-			if prevY < len(b.screen) && len(b.screen[prevY]) > 0 {
-				prevX = len(b.screen[prevY]) - 1
-			} else {
-				// No previous cell to attach to
-				return
-			}
-		} else {
-			// No previous cell to attach to (very start of buffer)
-			return
-		}
-	}
-
-	// Ensure the previous row exists and has the cell
-	if prevY >= len(b.screen) || prevX >= len(b.screen[prevY]) {
+	prevX, prevY, ok := b.previousCell()
+	if !ok {
 		return
 	}
 
-	// Append the combining mark to the previous cell
-	b.screen[prevY][prevX].Combining += string(ch)
+	// Append the combining mark to the previous cell, whose flex width may change
+	// with it (see grapheme.go)
+	cell := &b.screen[prevY][prevX]
+	if cell.FlexWidth {
+		cell.CellWidth = clusterWidth(*cell, ch)
+	}
+	cell.Combining += string(ch)
 	b.markDirty()
 }
 
+// previousCell returns the position of the cell before the cursor, which characters
+// that continue a grapheme cluster attach to (at the start of a line, the last cell
+// of the line above). Returns false if there is none.
+func (b *Buffer) previousCell() (x, y int, ok bool) {
+	x, y = b.cursorX-1, b.cursorY
+	if x < 0 {
+		if y <= 0 || y > len(b.screen) {
+			return 0, 0, false
+		}
+		y--
+		x = len(b.screen[y]) - 1
+	}
+	if y >= len(b.screen) || x < 0 || x >= len(b.screen[y]) {
+		return 0, 0, false
+	}
+	return x, y, true
+}
+
 // ensureLineLength ensures a line has at least the specified length,
 // filling gaps with the line's default cell
 func (b *Buffer) ensureLineLength(row, length int) {
@@ -1387,13 +1525,7 @@ func (b *Buffer) Newline() {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 	b.cursorX = 0
-	b.trackCursorYMove(b.cursorY + 1)
-	b.cursorY++
-	effectiveRows := b.EffectiveRows()
-	if b.cursorY >= effectiveRows {
-		b.scrollUpInternal()
-		b.cursorY = effectiveRows - 1
-	}
+	b.indexInternal()
 	b.markDirty()
 }
 
@@ -1407,16 +1539,12 @@ func (b *Buffer) CarriageReturn() {
 }
 
 // LineFeed moves cursor down one line
+// The line it leaves is committed: plain-text URLs on it become links.
 func (b *Buffer) LineFeed() {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	b.trackCursorYMove(b.cursorY + 1)
-	b.cursorY++
-	effectiveRows := b.EffectiveRows()
-	if b.cursorY >= effectiveRows {
-		b.scrollUpInternal()
-		b.cursorY = effectiveRows - 1
-	}
+	b.detectLinks(b.cursorY)
+	b.indexInternal()
 	b.markDirty()
 }
 
@@ -1426,9 +1554,9 @@ func (b *Buffer) Tab() {
 	defer b.mu.Unlock()
 	b.setHorizMoveDir(1, false) // Moving right
 	b.cursorX = ((b.cursorX / 8) + 1) * 8
-	effectiveCols := b.EffectiveCols()
-	if b.cursorX >= effectiveCols {
-		b.cursorX = effectiveCols - 1
+	lineCols := b.lineCols(b.cursorY)
+	if b.cursorX >= lineCols {
+		b.cursorX = lineCols - 1
 	}
 	b.markDirty()
 }
@@ -1469,20 +1597,25 @@ func (b *Buffer) scrollUpInternal() {
 	b.markDirty()
 }
 
-// ScrollUp scrolls up by n lines
+// ScrollUp scrolls up by n lines (the scroll region, if margins are set)
 func (b *Buffer) ScrollUp(n int) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	for i := 0; i < n; i++ {
-		b.scrollUpInternal()
-	}
+	top, bottom := b.scrollRegion()
+	b.scrollRegionUp(top, bottom, n)
 }
 
-// ScrollDown scrolls down by n lines
+// ScrollDown scrolls down by n lines (the scroll region, if margins are set)
 func (b *Buffer) ScrollDown(n int) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
+	if !b.isFullScrollRegion() {
+		top, bottom := b.scrollRegion()
+		b.scrollRegionDown(top, bottom, n)
+		return
+	}
 	screenLen := len(b.screen)
+	n = min(n, screenLen) // More lines than the screen has clear it
 	for i := 0; i < n && screenLen > 0; i++ {
 		copy(b.screen[1:], b.screen[:screenLen-1])
 		copy(b.lineInfos[1:], b.lineInfos[:screenLen-1])
@@ -1497,6 +1630,7 @@ func (b *Buffer) ClearScreen() {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 	b.updateScreenInfo() // Update screen default attributes
+	b.deleteScreenImages()
 	b.initScreen()
 
 	// Reset cursor to top-left
@@ -1527,8 +1661,10 @@ func (b *Buffer) ClearToEndOfLine() {
 	}
 
 	// Update line info with current attributes (for rendering beyond stored content)
+	// The line no longer continues on the next line
 	if b.cursorY < len(b.lineInfos) {
 		b.lineInfos[b.cursorY].DefaultCell = b.currentDefaultCell()
+		b.lineInfos[b.cursorY].Wrapped = false
 	}
 
 	// Truncate line at cursor position (variable width lines)
@@ -1579,6 +1715,8 @@ func (b *Buffer) ClearLine() {
 	// Update line info with current attributes
 	if b.cursorY < len(b.lineInfos) {
 		b.lineInfos[b.cursorY].DefaultCell = b.currentDefaultCell()
+		b.lineInfos[b.cursorY].Wrapped = false
+		b.lineInfos[b.cursorY].WrapIndent = 0
 	}
 
 	// Clear the line (make it empty - variable width)
@@ -1599,6 +1737,7 @@ func (b *Buffer) ClearToEndOfScreen() {
 	if b.cursorY < len(b.screen) {
 		if b.cursorY < len(b.lineInfos) {
 			b.lineInfos[b.cursorY].DefaultCell = b.currentDefaultCell()
+			b.lineInfos[b.cursorY].Wrapped = false
 		}
 		if b.cursorX < len(b.screen[b.cursorY]) {
 			b.screen[b.cursorY] = b.screen[b.cursorY][:b.cursorX]
@@ -1672,6 +1811,7 @@ func (b *Buffer) ResetAttributes() {
 	b.currentReverse = false
 	b.currentBlink = false
 	b.currentStrikethrough = false
+	b.currentOverline = false
 }
 
 // SetForeground sets the current foreground color
@@ -1758,6 +1898,13 @@ func (b *Buffer) SetStrikethrough(strikethrough bool) {
 	b.currentStrikethrough = strikethrough
 }
 
+// SetOverline sets overline attribute
+func (b *Buffer) SetOverline(overline bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.currentOverline = overline
+}
+
 // GetCell returns the cell at the given screen position
 // For positions beyond stored line length, returns the line's default cell
 func (b *Buffer) GetCell(x, y int) Cell {
@@ -1846,7 +1993,7 @@ func (b *Buffer) getScrollbackCell(x, scrollbackY int) Cell {
 		return b.screenInfo.DefaultCell
 	}
 
-	line := b.scrollback[scrollbackY]
+	line := b.scrollbackLine(scrollbackY)
 	if x < 0 || x >= len(line) {
 		// Beyond line content - use line's default
 		if scrollbackY < len(b.scrollbackInfo) {
@@ -1890,6 +2037,15 @@ func (b *Buffer) GetScrollbackSize() int {
 	return len(b.scrollback)
 }
 
+// GetLinesScrolledOff returns how many lines have scrolled off the top of the screen,
+// kept in the scrollback or discarded. It only grows, so the difference between two
+// calls is how much output scrolled in between (see scrollindicator.go).
+func (b *Buffer) GetLinesScrolledOff() int64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.linesDropped + int64(len(b.scrollback))
+}
+
 // GetMaxScrollOffset returns the maximum vertical scroll offset
 // This accounts for scrollback AND any logical rows hidden above the visible area
 func (b *Buffer) GetMaxScrollOffset() int {
@@ -2495,10 +2651,8 @@ func (b *Buffer) GetLongestLineInScrollback() int {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 	longest := 0
-	for _, line := range b.scrollback {
-		if len(line) > longest {
-			longest = len(line)
-		}
+	for i := range b.scrollback {
+		longest = max(longest, b.scrollbackLineLen(i))
 	}
 	return longest
 }
@@ -2517,10 +2671,8 @@ func (b *Buffer) GetLongestLineVisible() int {
 	// Only include scrollback width if the boundary is visible
 	// (meaning we can actually see scrollback content)
 	if boundaryVisible {
-		for _, line := range b.scrollback {
-			if len(line) > longest {
-				longest = len(line)
-			}
+		for i := range b.scrollback {
+			longest = max(longest, b.scrollbackLineLen(i))
 		}
 	}
 
@@ -2612,8 +2764,13 @@ func (b *Buffer) MoveCursorUp(n int) {
 	if newY < 0 {
 		newY = 0
 	}
+	// The cursor stops at the top margin if it starts below it
+	if top, _ := b.scrollRegion(); b.cursorY >= top && newY < top {
+		newY = top
+	}
 	b.trackCursorYMove(newY)
 	b.cursorY = newY
+	b.clampCursorToLine()
 	b.markDirty()
 }
 
@@ -2626,8 +2783,13 @@ func (b *Buffer) MoveCursorDown(n int) {
 	if newY >= effectiveRows {
 		newY = effectiveRows - 1
 	}
+	// The cursor stops at the bottom margin if it starts above it
+	if _, bottom := b.scrollRegion(); b.cursorY <= bottom && newY > bottom {
+		newY = bottom
+	}
 	b.trackCursorYMove(newY)
 	b.cursorY = newY
+	b.clampCursorToLine()
 	b.markDirty()
 }
 
@@ -2636,10 +2798,15 @@ func (b *Buffer) MoveCursorForward(n int) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 	b.setHorizMoveDir(1, false) // Moving right
+	_, right := b.horizontalMargins()
+	startX := b.cursorX
 	b.cursorX += n
-	effectiveCols := b.EffectiveCols()
-	if b.cursorX >= effectiveCols {
-		b.cursorX = effectiveCols - 1
+	lineCols := b.lineCols(b.cursorY)
+	if b.cursorX >= lineCols {
+		b.cursorX = lineCols - 1
+	}
+	if startX <= right && b.cursorX > right {
+		b.cursorX = right // Stops at the right margin
 	}
 	b.markDirty()
 }
@@ -2649,18 +2816,31 @@ func (b *Buffer) MoveCursorBackward(n int) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 	b.setHorizMoveDir(-1, false) // Moving left
+	left, _ := b.horizontalMargins()
+	startX := b.cursorX
 	b.cursorX -= n
 	if b.cursorX < 0 {
 		b.cursorX = 0
 	}
+	if startX >= left && b.cursorX < left {
+		b.cursorX = left // Stops at the left margin
+	}
 	b.markDirty()
 }
 
 // InsertLines inserts n blank lines at cursor
+// Inside the scroll region, lines below move down to the bottom margin.
 func (b *Buffer) InsertLines(n int) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
+	if !b.isFullScrollRegion() {
+		if top, bottom := b.scrollRegion(); b.cursorInMargins(top, bottom) {
+			b.scrollRegionDown(b.cursorY, bottom, n)
+		}
+		return
+	}
 	screenLen := len(b.screen)
+	n = min(n, screenLen) // More lines than the screen has clear it
 	for i := 0; i < n && screenLen > 0; i++ {
 		if b.cursorY < screenLen-1 {
 			copy(b.screen[b.cursorY+1:], b.screen[b.cursorY:screenLen-1])
@@ -2673,10 +2853,18 @@ func (b *Buffer) InsertLines(n int) {
 }
 
 // DeleteLines deletes n lines at cursor
+// Inside the scroll region, lines below move up from the bottom margin.
 func (b *Buffer) DeleteLines(n int) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
+	if !b.isFullScrollRegion() {
+		if top, bottom := b.scrollRegion(); b.cursorInMargins(top, bottom) {
+			b.scrollRegionUp(b.cursorY, bottom, n)
+		}
+		return
+	}
 	screenLen := len(b.screen)
+	n = min(n, screenLen) // More lines than the screen has clear it
 	for i := 0; i < n && screenLen > 0; i++ {
 		if b.cursorY < screenLen-1 {
 			copy(b.screen[b.cursorY:], b.screen[b.cursorY+1:])
@@ -2729,7 +2917,8 @@ func (b *Buffer) InsertChars(n int) {
 	line := b.screen[b.cursorY]
 	lineLen := len(line)
 
-	// Create space for new characters
+	// Create space for new characters (more than the line's width push it all off)
+	n = min(n, b.lineCols(b.cursorY))
 	newCells := make([]Cell, n)
 	fillCell := b.currentDefaultCell()
 	for i := range newCells {
@@ -2831,7 +3020,20 @@ func (b *Buffer) bufferToScreenY(bufferY int) int {
 func (b *Buffer) StartSelection(x, y int) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
+	b.startSelectionInternal(x, y, false)
+}
+
+// StartBlockSelection begins a rectangular selection (coordinates are screen-relative)
+// It covers the same columns on every line, between the start and end columns.
+func (b *Buffer) StartBlockSelection(x, y int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.startSelectionInternal(x, y, true)
+}
+
+func (b *Buffer) startSelectionInternal(x, y int, block bool) {
 	b.selectionActive = true
+	b.selectionBlock = block
 	// Convert to buffer-absolute coordinates for stable selection
 	bufferY := b.screenToBufferY(y)
 	b.selStartX = x
@@ -2875,19 +3077,37 @@ func (b *Buffer) HasSelection() bool {
 	return b.selectionActive
 }
 
+// IsBlockSelection returns true if the active selection is rectangular
+func (b *Buffer) IsBlockSelection() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.selectionActive && b.selectionBlock
+}
+
 // GetSelection returns the normalized selection bounds in buffer-absolute coordinates
+// For a rectangular selection these are the top-left and bottom-right corners.
 func (b *Buffer) GetSelection() (startX, startY, endX, endY int, active bool) {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 	if !b.selectionActive {
 		return 0, 0, 0, 0, false
 	}
-	sx, sy := b.selStartX, b.selStartY
-	ex, ey := b.selEndX, b.selEndY
+	sx, sy, ex, ey := b.normalizedSelection()
+	return sx, sy, ex, ey, true
+}
+
+// normalizedSelection returns the selection bounds with the start first
+// Must be called with the lock held.
+func (b *Buffer) normalizedSelection() (sx, sy, ex, ey int) {
+	sx, sy = b.selStartX, b.selStartY
+	ex, ey = b.selEndX, b.selEndY
+	if b.selectionBlock {
+		return min(sx, ex), min(sy, ey), max(sx, ex), max(sy, ey)
+	}
 	if sy > ey || (sy == ey && sx > ex) {
 		sx, sy, ex, ey = ex, ey, sx, sy
 	}
-	return sx, sy, ex, ey, true
+	return sx, sy, ex, ey
 }
 
 // IsCellInSelection checks if a cell at screen coordinates is within the selection
@@ -2902,16 +3122,15 @@ func (b *Buffer) IsCellInSelection(screenX, screenY int) bool {
 	bufferY := b.screenToBufferY(screenY)
 
 	// Get normalized selection bounds
-	sx, sy := b.selStartX, b.selStartY
-	ex, ey := b.selEndX, b.selEndY
-	if sy > ey || (sy == ey && sx > ex) {
-		sx, sy, ex, ey = ex, ey, sx, sy
-	}
+	sx, sy, ex, ey := b.normalizedSelection()
 
 	// Check if the cell is within the selection
 	if bufferY < sy || bufferY > ey {
 		return false
 	}
+	if b.selectionBlock {
+		return screenX >= sx && screenX <= ex
+	}
 	if bufferY == sy && screenX < sx {
 		return false
 	}
@@ -2941,13 +3160,12 @@ func (b *Buffer) getCellByAbsoluteY(x, bufferY int) Cell {
 
 // GetSelectedText returns the text in the current selection
 func (b *Buffer) GetSelectedText() string {
-	sx, sy, ex, ey, active := b.GetSelection()
-	if !active {
-		return ""
-	}
-
 	b.mu.RLock()
 	defer b.mu.RUnlock()
+	if !b.selectionActive {
+		return ""
+	}
+	sx, sy, ex, ey := b.normalizedSelection()
 
 	// Calculate total buffer height for bounds checking
 	scrollbackSize := len(b.scrollback)
@@ -2955,25 +3173,42 @@ func (b *Buffer) GetSelectedText() string {
 	totalBufferHeight := scrollbackSize + effectiveRows
 
 	var lines []string
+	joinNext := false // The previous line wrapped onto this one
 	for bufferY := sy; bufferY <= ey && bufferY < totalBufferHeight; bufferY++ {
+		cells, info := b.getLineByAbsoluteY(bufferY)
+		wrapped := info.Wrapped && !b.selectionBlock && bufferY < ey
 		startX := 0
 		endX := b.cols
-		if bufferY == sy {
+		if bufferY == sy || b.selectionBlock {
 			startX = sx
+		} else if joinNext {
+			startX = info.WrapIndent // Smart wrap's indent isn't part of the text
 		}
-		if bufferY == ey {
+		if bufferY == ey || b.selectionBlock {
 			endX = ex + 1
 		}
+		if wrapped {
+			endX = min(endX, len(cells))
+		}
 		var lineRunes []rune
 		for x := startX; x < endX && x < b.cols; x++ {
 			cell := b.getCellByAbsoluteY(x, bufferY)
 			lineRunes = append(lineRunes, cell.Char)
 		}
 		line := string(lineRunes)
-		for len(line) > 0 && (line[len(line)-1] == ' ' || line[len(line)-1] == 0) {
-			line = line[:len(line)-1]
+
+		// A wrapped line goes on to the next without a break, keeping its spaces
+		if !wrapped {
+			for len(line) > 0 && (line[len(line)-1] == ' ' || line[len(line)-1] == 0) {
+				line = line[:len(line)-1]
+			}
+		}
+		if joinNext {
+			lines[len(lines)-1] += line
+		} else {
+			lines = append(lines, line)
 		}
-		lines = append(lines, line)
+		joinNext = wrapped
 	}
 
 	result := ""
@@ -2986,6 +3221,20 @@ func (b *Buffer) GetSelectedText() string {
 	return result
 }
 
+// getLineByAbsoluteY gets a line's cells and info using buffer-absolute Y coordinate
+func (b *Buffer) getLineByAbsoluteY(bufferY int) ([]Cell, LineInfo) {
+	if bufferY < 0 {
+		return nil, DefaultLineInfo()
+	}
+	if bufferY < len(b.scrollback) {
+		return b.scrollbackLine(bufferY), b.scrollbackInfo[bufferY]
+	}
+	if logicalY := bufferY - len(b.scrollback); logicalY < len(b.screen) && logicalY < len(b.lineInfos) {
+		return b.screen[logicalY], b.lineInfos[logicalY]
+	}
+	return nil, DefaultLineInfo()
+}
+
 // IsInSelection returns true if the given screen position is within the selection
 // Deprecated: Use IsCellInSelection for clearer semantics
 func (b *Buffer) IsInSelection(x, y int) bool {
@@ -2997,6 +3246,7 @@ func (b *Buffer) SelectAll() {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 	b.selectionActive = true
+	b.selectionBlock = false
 	b.selStartX = 0
 	b.selStartY = 0 // Buffer-absolute 0 = oldest scrollback line
 	b.selEndX = b.cols - 1
@@ -3008,15 +3258,41 @@ func (b *Buffer) SelectAll() {
 }
 
 // SetLineAttribute sets the display attribute for the current line
+// A double-width or double-height line holds half as many columns: characters past
+// the half are lost, and the cursor moves back inside it.
 func (b *Buffer) SetLineAttribute(attr LineAttribute) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 	if b.cursorY >= 0 && b.cursorY < len(b.lineInfos) {
 		b.lineInfos[b.cursorY].Attribute = attr
+		if lineCols := b.lineCols(b.cursorY); attr != LineAttrNormal && b.cursorY < len(b.screen) &&
+			len(b.screen[b.cursorY]) > lineCols {
+			b.screen[b.cursorY] = b.screen[b.cursorY][:lineCols]
+		}
+		b.clampCursorToLine()
 		b.markDirty()
 	}
 }
 
+// lineCols returns how many columns a screen row holds: half the logical width on
+// double-width and double-height lines
+// Must be called with the lock held.
+func (b *Buffer) lineCols(row int) int {
+	effectiveCols := b.EffectiveCols()
+	if row >= 0 && row < len(b.lineInfos) && b.lineInfos[row].Attribute != LineAttrNormal {
+		return max(1, effectiveCols/2)
+	}
+	return effectiveCols
+}
+
+// clampCursorToLine moves the cursor back inside the half of a double-width line
+// Must be called with the lock held.
+func (b *Buffer) clampCursorToLine() {
+	if lineCols := b.lineCols(b.cursorY); lineCols < b.EffectiveCols() && b.cursorX >= lineCols {
+		b.cursorX = lineCols - 1
+	}
+}
+
 // GetLineAttribute returns the display attribute for the specified line
 func (b *Buffer) GetLineAttribute(y int) LineAttribute {
 	b.mu.RLock()
@@ -3955,7 +4231,7 @@ func (b *Buffer) GetLineLengthForSplit(screenY, bufferRow, bufferCol int) int {
 		absoluteY := totalScrollableAbove - b.scrollOffset + actualY
 		if absoluteY < scrollbackSize {
 			if absoluteY >= 0 && absoluteY < len(b.scrollback) {
-				lineLen = len(b.scrollback[absoluteY])
+				lineLen = b.scrollbackLineLen(absoluteY)
 			}
 		} else {
 			logicalY := absoluteY - scrollbackSize
@@ -4089,8 +4365,12 @@ func (b *Buffer) resolveSpriteEntry(entry *PaletteEntry, fg, bg Color) (Color, b
 func (b *Buffer) ClearScrollback() {
 	b.mu.Lock()
 	defer b.mu.Unlock()
+	b.linesDropped += int64(len(b.scrollback))
+	b.pruneImages()
 	b.scrollback = nil
 	b.scrollbackInfo = nil
+	b.scrollbackPacked = nil
+	b.clearUnpackedCache()
 	b.scrollOffset = 0
 	b.markDirty()
 }
@@ -4101,6 +4381,9 @@ func (b *Buffer) Reset() {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	// Images on the screen go with it (empty lines aren't kept to anchor them)
+	b.deleteScreenImages()
+
 	// Push current screen content to scrollback first
 	for i := 0; i < len(b.screen); i++ {
 		if len(b.screen[i]) > 0 {
@@ -4115,10 +4398,18 @@ func (b *Buffer) Reset() {
 	b.cursorX = 0
 	b.cursorY = 0
 	b.cursorVisible = true
-	b.cursorShape = 0
-	b.cursorBlink = 0
+	b.cursorShape = b.defaultCursorShape
+	b.cursorBlink = b.defaultCursorBlink
 	b.savedCursorX = 0
 	b.savedCursorY = 0
+	b.savedOriginMode = false
+
+	// Reset margins
+	b.marginTop, b.marginBottom = 0, 0
+	b.marginLeft, b.marginRight = 0, 0
+	b.leftRightMarginMode = false
+	b.originMode = false
+	b.noClearOnColumnChange = false
 
 	// Reset attributes
 	b.currentFg = DefaultForeground
@@ -4126,13 +4417,21 @@ func (b *Buffer) Reset() {
 	b.currentBold = false
 	b.currentItalic = false
 	b.currentUnderline = false
+	b.currentUnderlineStyle = UnderlineNone
+	b.currentHasUnderlineColor = false
 	b.currentReverse = false
 	b.currentBlink = false
 	b.currentStrikethrough = false
+	b.currentOverline = false
 	b.currentFlexWidth = false
+	b.currentLink = 0
 
 	// Reset modes
 	b.bracketedPasteMode = false
+	b.mouseTracking = MouseTrackingOff
+	b.mouseSGR = false
+	b.search = nil
+	b.autoLinks = true
 	b.flexWidthMode = false
 	b.visualWidthWrap = false
 	b.ambiguousWidthMode = AmbiguousWidthAuto
@@ -4172,8 +4471,8 @@ func (b *Buffer) SaveScrollbackText() string {
 	var result strings.Builder
 
 	// Output scrollback lines
-	for _, line := range b.scrollback {
-		for _, cell := range line {
+	for i := range b.scrollback {
+		for _, cell := range b.scrollbackLine(i) {
 			if cell.Char != 0 {
 				result.WriteRune(cell.Char)
 			}
@@ -4289,7 +4588,10 @@ func (b *Buffer) SaveScrollbackANS() string {
 
 	// Track current attributes to minimize escape sequences
 	var lastFg, lastBg Color
-	var lastBold, lastItalic, lastUnderline, lastReverse, lastBlink, lastStrikethrough bool
+	var lastBold, lastItalic, lastReverse, lastBlink, lastStrikethrough, lastOverline bool
+	var lastUnderlineStyle UnderlineStyle
+	var lastUnderlineColor Color
+	var lastHasUnderlineColor bool
 	var lastFlexWidth bool // Track flex width mode state
 	var lastAmbiguousWide bool                                // Track if ambiguous width is set to wide
 	var lastBGP int = -1
@@ -4328,8 +4630,10 @@ func (b *Buffer) SaveScrollbackANS() string {
 			// Check if standard attributes changed (need reset)
 			needsReset := false
 			if cell.Bold != lastBold || cell.Italic != lastItalic ||
-				cell.Underline != lastUnderline || cell.Reverse != lastReverse ||
-				cell.Blink != lastBlink || cell.Strikethrough != lastStrikethrough {
+				cell.UnderlineStyle != lastUnderlineStyle || cell.Reverse != lastReverse ||
+				cell.Blink != lastBlink || cell.Strikethrough != lastStrikethrough ||
+				cell.Overline != lastOverline ||
+				(lastHasUnderlineColor && !cell.HasUnderlineColor) {
 				needsReset = true
 			}
 
@@ -4339,10 +4643,12 @@ func (b *Buffer) SaveScrollbackANS() string {
 				lastBg = Color{}
 				lastBold = false
 				lastItalic = false
-				lastUnderline = false
+				lastUnderlineStyle = UnderlineNone
+				lastHasUnderlineColor = false
 				lastReverse = false
 				lastBlink = false
 				lastStrikethrough = false
+				lastOverline = false
 				// Reset doesn't affect BGP/flip, but we track them separately
 			}
 
@@ -4355,9 +4661,18 @@ func (b *Buffer) SaveScrollbackANS() string {
 				result.WriteString("\x1b[3m")
 				lastItalic = true
 			}
-			if cell.Underline && !lastUnderline {
-				result.WriteString("\x1b[4m")
-				lastUnderline = true
+			if cell.UnderlineStyle != lastUnderlineStyle {
+				if cell.UnderlineStyle == UnderlineSingle {
+					result.WriteString("\x1b[4m")
+				} else {
+					result.WriteString(fmt.Sprintf("\x1b[4:%dm", cell.UnderlineStyle))
+				}
+				lastUnderlineStyle = cell.UnderlineStyle
+			}
+			if cell.HasUnderlineColor && (!lastHasUnderlineColor || cell.UnderlineColor != lastUnderlineColor) {
+				result.WriteString("\x1b[" + cell.UnderlineColor.ToUnderlineSGRCode() + "m")
+				lastUnderlineColor = cell.UnderlineColor
+				lastHasUnderlineColor = true
 			}
 			if cell.Reverse && !lastReverse {
 				result.WriteString("\x1b[7m")
@@ -4371,6 +4686,10 @@ func (b *Buffer) SaveScrollbackANS() string {
 				result.WriteString("\x1b[9m")
 				lastStrikethrough = true
 			}
+			if cell.Overline && !lastOverline {
+				result.WriteString("\x1b[53m")
+				lastOverline = true
+			}
 
 			// Set colors
 			if cell.Foreground != lastFg {
@@ -4457,10 +4776,12 @@ func (b *Buffer) SaveScrollbackANS() string {
 		lastBg = Color{}
 		lastBold = false
 		lastItalic = false
-		lastUnderline = false
+		lastUnderlineStyle = UnderlineNone
+		lastHasUnderlineColor = false
 		lastReverse = false
 		lastBlink = false
 		lastStrikethrough = false
+		lastOverline = false
 
 		// If background was dirty, clear the next line to prevent bleeding
 		if hasNonDefaultBg {
@@ -4469,12 +4790,12 @@ func (b *Buffer) SaveScrollbackANS() string {
 	}
 
 	// Output scrollback lines
-	for i, line := range b.scrollback {
+	for i := range b.scrollback {
 		var lineInfo LineInfo
 		if i < len(b.scrollbackInfo) {
 			lineInfo = b.scrollbackInfo[i]
 		}
-		outputLine(line, lineInfo)
+		outputLine(b.scrollbackLine(i), lineInfo)
 	}
 
 	// Output screen lines
@@ -27,11 +27,13 @@ type Cell struct {
 	Reverse        bool
 	Blink          bool    // When true, character animates (bobbing wave instead of traditional blink)
 	Strikethrough  bool    // When true, draw a line through the character
+	Overline       bool    // When true, draw a line along the top of the cell (SGR 53)
 	FlexWidth      bool    // When true, cell uses East Asian Width for variable width rendering
 	CellWidth      float64 // Visual width in cell units (0.5, 1.0, 1.5, 2.0) - only used when FlexWidth is true
 	BGP            int     // Base Glyph Palette index (-1 = use foreground color code as palette)
 	XFlip          bool    // Horizontal flip for custom glyphs
 	YFlip          bool    // Vertical flip for custom glyphs
+	LinkID         int     // Hyperlink (OSC 8) the cell belongs to, 0 = none (see Buffer.GetHyperlink)
 }
 
 // String returns the full character including any combining marks
@@ -344,12 +346,9 @@ func getEastAsianWidthCategory(r rune) EastAsianWidth {
 	if r >= 0x30000 && r <= 0x3134F {
 		return EAWidthWide
 	}
-	// Emoji (many are wide)
-	if r >= 0x1F300 && r <= 0x1F9FF {
-		return EAWidthWide
-	}
-	// More emoji
-	if r >= 0x1FA00 && r <= 0x1FAFF {
+	// Emoji with emoji presentation, and the other wide scripts and symbols
+	// (before the ambiguous blocks, which some of them are in)
+	if isWideSymbol(r) {
 		return EAWidthWide
 	}
 
@@ -720,6 +719,8 @@ const (
 type LineInfo struct {
 	Attribute   LineAttribute // DECDWL/DECDHL display mode
 	DefaultCell Cell          // Used for rendering beyond stored line length
+	Wrapped     bool          // Auto-wrap continued the line on the next line
+	WrapIndent  int           // Indent cells smart word wrap put at the start of this line
 }
 
 // DefaultLineInfo returns a LineInfo with normal attributes and default colors
@@ -0,0 +1,111 @@
+package purfecterm
+
+import (
+	"encoding/base64"
+	"strings"
+)
+
+// Clipboard (OSC 52)
+//   ESC ] 52 ; Pc ; Pd ST   set the clipboard to the base64 text Pd
+//   ESC ] 52 ; Pc ; ? ST    query the clipboard; the reply is ESC ] 52 ; Pc ; Pd BEL
+// Pc names the selections: c is the clipboard and p the primary selection; others
+// (and an empty Pc) mean the clipboard. Pd that isn't base64 clears the selection.
+// What programs may do is set with SetClipboardAccess, since a program that can read
+// the clipboard can see whatever the user last copied anywhere. The host does the
+// actual work in the clipboard callback.
+
+// maxClipboardText is the longest text OSC 52 may put on the clipboard
+const maxClipboardText = 1 << 20
+
+// ClipboardAccess is what programs may do with the clipboard through OSC 52
+type ClipboardAccess int
+
+const (
+	ClipboardAccessNone      ClipboardAccess = iota // OSC 52 is ignored
+	ClipboardAccessWrite                            // Programs may set the clipboard
+	ClipboardAccessReadWrite                        // Programs may also query it
+)
+
+// ParseClipboardAccess parses a clipboard access string ("off", "write" or "read-write")
+func ParseClipboardAccess(s string) ClipboardAccess {
+	switch s {
+	case "write":
+		return ClipboardAccessWrite
+	case "read-write":
+		return ClipboardAccessReadWrite
+	default:
+		return ClipboardAccessNone
+	}
+}
+
+// ClipboardRequest is a program's request to set or query the clipboard
+type ClipboardRequest struct {
+	Primary bool   // The primary selection rather than the clipboard
+	Query   bool   // Reply with the contents (see EncodeClipboardReply) instead of setting them
+	Text    string // The text to set; empty clears the selection
+}
+
+// SetClipboardAccess sets what programs may do with the clipboard
+func (b *Buffer) SetClipboardAccess(access ClipboardAccess) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.clipboardAccess = access
+}
+
+// GetClipboardAccess returns what programs may do with the clipboard
+func (b *Buffer) GetClipboardAccess() ClipboardAccess {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.clipboardAccess
+}
+
+// SetClipboardCallback sets a callback to be invoked for each permitted clipboard request
+// It is called from the goroutine that feeds the parser, without the lock held.
+func (b *Buffer) SetClipboardCallback(fn func(ClipboardRequest)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onClipboard = fn
+}
+
+// RequestClipboard passes a clipboard request to the host if the access allows it
+func (b *Buffer) RequestClipboard(req ClipboardRequest) {
+	b.mu.RLock()
+	access := b.clipboardAccess
+	onClipboard := b.onClipboard
+	b.mu.RUnlock()
+
+	allowed := access >= ClipboardAccessWrite
+	if req.Query {
+		allowed = access >= ClipboardAccessReadWrite
+	}
+	if allowed && onClipboard != nil {
+		onClipboard(req)
+	}
+}
+
+// parseClipboardRequest parses the arguments of OSC 52
+// Returns false if the text is too long.
+func parseClipboardRequest(args string) (ClipboardRequest, bool) {
+	selections, data, _ := strings.Cut(args, ";")
+	req := ClipboardRequest{Primary: strings.Contains(selections, "p") && !strings.Contains(selections, "c")}
+	if data == "?" {
+		req.Query = true
+		return req, true
+	}
+	if base64.StdEncoding.DecodedLen(len(data)) > maxClipboardText {
+		return req, false
+	}
+	if text, err := base64.StdEncoding.DecodeString(data); err == nil {
+		req.Text = string(text)
+	}
+	return req, true
+}
+
+// EncodeClipboardReply returns the reply to a clipboard query
+func EncodeClipboardReply(req ClipboardRequest, text string) []byte {
+	selection := "c"
+	if req.Primary {
+		selection = "p"
+	}
+	return []byte("\x1b]52;" + selection + ";" + base64.StdEncoding.EncodeToString([]byte(text)) + "\x07")
+}
@@ -110,6 +110,18 @@ func (c Color) ToSGRCode(isFg bool) string {
 	return ""
 }
 
+// ToUnderlineSGRCode returns the SGR code that sets this color as the underline
+// color (SGR 58), in the colon form that doesn't confuse terminals without it
+func (c Color) ToUnderlineSGRCode() string {
+	switch c.Type {
+	case ColorTypeStandard, ColorTypePalette:
+		return "58:5:" + itoa(int(c.Index))
+	case ColorTypeTrueColor:
+		return "58:2::" + itoa(int(c.R)) + ":" + itoa(int(c.G)) + ":" + itoa(int(c.B))
+	}
+	return "59"
+}
+
 // itoa is a simple int to string conversion
 func itoa(i int) string {
 	if i == 0 {
@@ -275,6 +287,41 @@ type ColorScheme struct {
 	Cursor    Color
 	Selection Color
 	BlinkMode BlinkMode
+
+	// Flash the screen when the bell rings
+	VisualBell bool
+
+	// What programs may do with the clipboard through OSC 52
+	Clipboard ClipboardAccess
+
+	// Copy selections to the primary selection and paste it with the middle button,
+	// as X11 terminals do (where the platform has a primary selection)
+	PrimarySelection bool
+
+	// The terminal's name in replies to XTVERSION ("" for DefaultTerminalIdentity)
+	Identity string
+
+	// Draw the cells with the GPU where the widget supports it (see gridframe.go)
+	GPURendering bool
+
+	// Draw runs of text together so fonts can join them into ligatures (see ligature.go)
+	Ligatures bool
+
+	// Cursor style until a program changes it with DECSCUSR (see Buffer.SetCursorStyle)
+	CursorShape int // 0=block, 1=underline, 2=bar
+	CursorBlink int // 0=no blink, 1=slow blink, 2=fast blink
+
+	// Search highlights: the background of matches and of the current match,
+	// drawn with SearchText as the foreground
+	SearchMatch   Color
+	SearchCurrent Color
+	SearchText    Color
+
+	// Background opacity, blur and image (see background.go)
+	BackgroundOpacity float64 // 0..1, with 0 (unset) for opaque
+	BackgroundBlur    bool    // Blur what shows through, where the platform can
+	BackgroundImage   string  // Path of an image drawn under the text ("" for none)
+	BackgroundDim     float64 // How far the image is faded toward the background, 0..1
 }
 
 // Foreground returns the foreground color for the specified mode
@@ -346,6 +393,30 @@ func ParseBlinkMode(s string) BlinkMode {
 	}
 }
 
+// ParseCursorShape parses a cursor shape string ("block", "underline" or "bar")
+func ParseCursorShape(s string) int {
+	switch s {
+	case "underline":
+		return 1
+	case "bar":
+		return 2
+	default:
+		return 0
+	}
+}
+
+// ParseCursorBlink parses a cursor blink string ("off", "slow" or "fast")
+func ParseCursorBlink(s string) int {
+	switch s {
+	case "slow":
+		return 1
+	case "fast":
+		return 2
+	default:
+		return 0
+	}
+}
+
 // DefaultPaletteHex returns the default 16-color palette as hex strings in VGA order
 func DefaultPaletteHex() []string {
 	result := make([]string, 16)
@@ -379,7 +450,18 @@ func DefaultColorScheme() ColorScheme {
 		LightPalette:    ANSIColors,
 
 		// Shared
-		Cursor:    TrueColor(255, 255, 255),
-		Selection: TrueColor(68, 68, 68),
+		Cursor:     TrueColor(255, 255, 255),
+		Selection:  TrueColor(68, 68, 68),
+		VisualBell: true,
+		Clipboard:  ClipboardAccessWrite,
+
+		PrimarySelection: true,
+		Ligatures:        true,
+
+		BackgroundOpacity: 1,
+
+		SearchMatch:   TrueColor(170, 140, 40),
+		SearchCurrent: TrueColor(255, 150, 50),
+		SearchText:    TrueColor(0, 0, 0),
 	}
 }
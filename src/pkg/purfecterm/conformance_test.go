@@ -0,0 +1,446 @@
+package purfecterm
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Conformance tests in the style of esctest: each case feeds a sequence to a fresh
+// 10x5 terminal and checks the screen, the cursor and any replies, as xterm does.
+
+const (
+	confCols = 10
+	confRows = 5
+)
+
+// confTerminal is a buffer and parser that collect the terminal's replies
+type confTerminal struct {
+	buffer  *Buffer
+	parser  *Parser
+	replies strings.Builder
+}
+
+func newConfTerminal() *confTerminal {
+	t := &confTerminal{buffer: NewBuffer(confCols, confRows, 100)}
+	t.parser = NewParser(t.buffer)
+	t.buffer.SetResponseCallback(func(reply []byte) {
+		t.replies.Write(reply)
+	})
+	return t
+}
+
+// screen returns the screen's rows with trailing blanks removed
+func (t *confTerminal) screen() []string {
+	cols, rows := t.buffer.GetSize()
+	lines := make([]string, rows)
+	for y := 0; y < rows; y++ {
+		var line strings.Builder
+		for x := 0; x < cols; x++ {
+			cell := t.buffer.GetCell(x, y)
+			if cell.Char == 0 {
+				line.WriteByte(' ')
+			} else {
+				line.WriteString(cell.String())
+			}
+		}
+		lines[y] = strings.TrimRight(line.String(), " ")
+	}
+	return lines
+}
+
+// confCase is a conformance case: the input, then the expected screen (nil to skip
+// the check), cursor (1-based, as CUP takes it, 0 to skip the check) and replies.
+// check can test anything else, returning a description of the problem or "".
+// A case marked as a known bug is skipped while it fails, and fails once it passes,
+// so the mark is removed when the bug is fixed.
+type confCase struct {
+	name     string
+	input    string
+	screen   []string
+	row, col int
+	replies  string
+	check    func(t *confTerminal) string
+	knownBug string
+}
+
+func runConfCases(t *testing.T, cases []confCase) {
+	t.Helper()
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			term := newConfTerminal()
+			term.parser.ParseString(c.input)
+
+			var problems []string
+			if c.screen != nil {
+				got := term.screen()
+				want := make([]string, confRows)
+				copy(want, c.screen)
+				for i := range want {
+					if got[i] != want[i] {
+						problems = append(problems, fmt.Sprintf("screen mismatch\n got: %q\nwant: %q", got, want))
+						break
+					}
+				}
+			}
+			if c.row > 0 {
+				// A cursor waiting to wrap is reported in the last column, as CPR does
+				x, y := term.buffer.GetCursor()
+				x = min(x, confCols-1)
+				if y+1 != c.row || x+1 != c.col {
+					problems = append(problems, fmt.Sprintf("cursor at %d;%d, want %d;%d", y+1, x+1, c.row, c.col))
+				}
+			}
+			if got := term.replies.String(); got != c.replies {
+				problems = append(problems, fmt.Sprintf("replies %q, want %q", got, c.replies))
+			}
+			if c.check != nil {
+				if problem := c.check(term); problem != "" {
+					problems = append(problems, problem)
+				}
+			}
+
+			switch {
+			case c.knownBug != "" && len(problems) > 0:
+				t.Skipf("known bug: %s", c.knownBug)
+			case c.knownBug != "":
+				t.Errorf("passes, but is marked as a known bug (%s)", c.knownBug)
+			}
+			for _, problem := range problems {
+				t.Error(problem)
+			}
+		})
+	}
+}
+
+func TestConformanceCursorMovement(t *testing.T) {
+	runConfCases(t, []confCase{
+		{name: "CUP", input: "\x1b[3;4H", row: 3, col: 4},
+		{name: "CUP defaults to home", input: "\x1b[3;4H\x1b[H", row: 1, col: 1},
+		{name: "CUP clamps to the screen", input: "\x1b[99;99H", row: 5, col: 10},
+		{name: "HVP", input: "\x1b[2;7f", row: 2, col: 7},
+		{name: "CUU", input: "\x1b[4;4H\x1b[2A", row: 2, col: 4},
+		{name: "CUU stops at the top", input: "\x1b[2;4H\x1b[9A", row: 1, col: 4},
+		{name: "CUD", input: "\x1b[B\x1b[2B", row: 4, col: 1},
+		{name: "CUD stops at the bottom", input: "\x1b[9B", row: 5, col: 1},
+		{name: "CUF", input: "\x1b[C\x1b[3C", row: 1, col: 5},
+		{name: "CUF stops at the right margin", input: "\x1b[99C", row: 1, col: 10},
+		{name: "CUB", input: "\x1b[1;8H\x1b[2D", row: 1, col: 6},
+		{name: "CUB stops at the left margin", input: "\x1b[1;3H\x1b[9D", row: 1, col: 1},
+		{name: "CNL", input: "\x1b[1;5H\x1b[2E", row: 3, col: 1},
+		{name: "CPL", input: "\x1b[4;5H\x1b[2F", row: 2, col: 1},
+		{name: "CHA", input: "\x1b[2;2H\x1b[7G", row: 2, col: 7},
+		{name: "VPA", input: "\x1b[2;6H\x1b[4d", row: 4, col: 6},
+		{name: "zero parameters count as one", input: "\x1b[3;3H\x1b[0A\x1b[0D", row: 2, col: 2},
+		{name: "CR", input: "abc\r", row: 1, col: 1},
+		{name: "LF keeps the column", input: "abc\n", row: 2, col: 4},
+		{name: "BS", input: "abc\b", row: 1, col: 3},
+		{name: "BS stops at the left margin", input: "\b\b", row: 1, col: 1},
+		{name: "IND", input: "\x1b[1;3H\x1bD", row: 2, col: 3},
+		{name: "NEL", input: "\x1b[1;3H\x1bE", row: 2, col: 1},
+		{name: "RI", input: "\x1b[3;3H\x1bM", row: 2, col: 3},
+	})
+}
+
+func TestConformanceSaveRestore(t *testing.T) {
+	runConfCases(t, []confCase{
+		{name: "DECSC and DECRC", input: "\x1b[2;3H\x1b7\x1b[5;5H\x1b8", row: 2, col: 3},
+		{name: "SCOSC and SCORC", input: "\x1b[4;6H\x1b[s\x1b[H\x1b[u", row: 4, col: 6},
+		{
+			name:   "DECRC restores attributes",
+			input:  "\x1b[1m\x1b7\x1b[m\x1b8B",
+			screen: []string{"B"},
+			row:    1, col: 2,
+			check: func(t *confTerminal) string {
+				if !t.buffer.GetCell(0, 0).Bold {
+					return "character not bold after restoring bold attributes"
+				}
+				return ""
+			},
+			knownBug: "DECSC saves only the position and origin mode",
+		},
+	})
+}
+
+func TestConformanceWrapping(t *testing.T) {
+	runConfCases(t, []confCase{
+		{
+			name:   "last column defers the wrap",
+			input:  "0123456789",
+			screen: []string{"0123456789"},
+			row:    1, col: 10,
+		},
+		{
+			name:   "next character wraps",
+			input:  "0123456789X",
+			screen: []string{"0123456789", "X"},
+			row:    2, col: 2,
+		},
+		{
+			name:   "CR cancels the pending wrap",
+			input:  "0123456789\rX",
+			screen: []string{"X123456789"},
+			row:    1, col: 2,
+		},
+		{
+			name:   "DECAWM off overwrites the last column",
+			input:  "\x1b[?7l0123456789XY",
+			screen: []string{"012345678Y"},
+			row:    1, col: 10,
+		},
+		{
+			name:   "wrapping at the bottom scrolls",
+			input:  "\x1b[5;1H0123456789X",
+			screen: []string{"", "", "", "0123456789", "X"},
+			row:    5, col: 2,
+		},
+	})
+}
+
+func TestConformanceErase(t *testing.T) {
+	const fill = "\x1b[1;1HAAAAAAAAAA\x1b[2;1HBBBBBBBBBB\x1b[3;1HCCCCCCCCCC\x1b[4;1HDDDDDDDDDD\x1b[5;1HEEEEEEEEEE"
+	runConfCases(t, []confCase{
+		{
+			name:   "ED 0 erases below",
+			input:  fill + "\x1b[3;4H\x1b[J",
+			screen: []string{"AAAAAAAAAA", "BBBBBBBBBB", "CCC"},
+			row:    3, col: 4,
+		},
+		{
+			name:   "ED 1 erases above",
+			input:  fill + "\x1b[3;4H\x1b[1J",
+			screen: []string{"", "", "    CCCCCC", "DDDDDDDDDD", "EEEEEEEEEE"},
+			row:    3, col: 4,
+		},
+		{
+			name:   "ED 2 erases the screen",
+			input:  fill + "\x1b[3;4H\x1b[2J",
+			screen: []string{},
+			row:    3, col: 4,
+			knownBug: "ED 2 also homes the cursor",
+		},
+		{
+			name:   "EL 0 erases to the right",
+			input:  fill + "\x1b[2;4H\x1b[K",
+			screen: []string{"AAAAAAAAAA", "BBB", "CCCCCCCCCC", "DDDDDDDDDD", "EEEEEEEEEE"},
+			row:    2, col: 4,
+		},
+		{
+			name:   "EL 1 erases to the left",
+			input:  fill + "\x1b[2;4H\x1b[1K",
+			screen: []string{"AAAAAAAAAA", "    BBBBBB", "CCCCCCCCCC", "DDDDDDDDDD", "EEEEEEEEEE"},
+			row:    2, col: 4,
+		},
+		{
+			name:   "EL 2 erases the line",
+			input:  fill + "\x1b[2;4H\x1b[2K",
+			screen: []string{"AAAAAAAAAA", "", "CCCCCCCCCC", "DDDDDDDDDD", "EEEEEEEEEE"},
+			row:    2, col: 4,
+		},
+		{
+			name:   "ECH",
+			input:  fill + "\x1b[1;3H\x1b[4X",
+			screen: []string{"AA    AAAA", "BBBBBBBBBB", "CCCCCCCCCC", "DDDDDDDDDD", "EEEEEEEEEE"},
+			row:    1, col: 3,
+		},
+	})
+}
+
+func TestConformanceInsertDelete(t *testing.T) {
+	runConfCases(t, []confCase{
+		{
+			name:   "ICH",
+			input:  "abcdef\x1b[1;3H\x1b[2@",
+			screen: []string{"ab  cdef"},
+			row:    1, col: 3,
+		},
+		{
+			name:   "ICH pushes characters off the line",
+			input:  "0123456789\x1b[1;1H\x1b[3@",
+			screen: []string{"   0123456"},
+			row:    1, col: 1,
+		},
+		{
+			name:   "DCH",
+			input:  "abcdef\x1b[1;2H\x1b[2P",
+			screen: []string{"adef"},
+			row:    1, col: 2,
+		},
+		{
+			name:   "IRM inserts",
+			input:  "abc\x1b[1;2H\x1b[4hXY\x1b[4l",
+			screen: []string{"aXYbc"},
+			row:    1, col: 4,
+			knownBug: "IRM is not implemented",
+		},
+		{
+			name:   "IL",
+			input:  "1\r\n2\r\n3\r\n4\x1b[2;1H\x1b[L",
+			screen: []string{"1", "", "2", "3", "4"},
+			row:    2, col: 1,
+		},
+		{
+			name:   "DL",
+			input:  "1\r\n2\r\n3\r\n4\x1b[2;1H\x1b[2M",
+			screen: []string{"1", "4"},
+			row:    2, col: 1,
+		},
+	})
+}
+
+func TestConformanceScrolling(t *testing.T) {
+	const lines = "1\r\n2\r\n3\r\n4\r\n5"
+	runConfCases(t, []confCase{
+		{
+			name:   "LF at the bottom scrolls",
+			input:  lines + "\r\n6",
+			screen: []string{"2", "3", "4", "5", "6"},
+			row:    5, col: 2,
+		},
+		{
+			name:   "SU",
+			input:  lines + "\x1b[2S",
+			screen: []string{"3", "4", "5"},
+		},
+		{
+			name:   "SD",
+			input:  lines + "\x1b[2T",
+			screen: []string{"", "", "1", "2", "3"},
+		},
+		{
+			name:  "DECSTBM homes the cursor",
+			input: "\x1b[3;3H\x1b[2;4r",
+			row:   1, col: 1,
+		},
+		{
+			name:   "LF scrolls only the region",
+			input:  lines + "\x1b[2;4r\x1b[4;1H\n",
+			screen: []string{"1", "3", "4", "", "5"},
+			row:    4, col: 1,
+		},
+		{
+			name:   "RI scrolls the region down",
+			input:  lines + "\x1b[2;4r\x1b[2;1H\x1bM",
+			screen: []string{"1", "", "2", "3", "5"},
+			row:    2, col: 1,
+		},
+		{
+			name:  "DECOM makes CUP relative to the region",
+			input: "\x1b[2;4r\x1b[?6h\x1b[2;2H",
+			row:   3, col: 2,
+		},
+		{
+			name:  "DECOM keeps the cursor in the region",
+			input: "\x1b[2;4r\x1b[?6h\x1b[9;1H",
+			row:   4, col: 1,
+		},
+	})
+}
+
+func TestConformanceTabs(t *testing.T) {
+	runConfCases(t, []confCase{
+		{name: "HT", input: "\t", row: 1, col: 9},
+		{name: "HT stops at the right margin", input: "\t\t\t", row: 1, col: 10},
+		{name: "HTS", input: "\x1b[1;4H\x1bH\r\t", row: 1, col: 4, knownBug: "tab stops are fixed every 8 columns"},
+		{name: "TBC 0", input: "\x1b[1;9H\x1b[g\r\t", row: 1, col: 10, knownBug: "tab stops are fixed every 8 columns"},
+		{name: "TBC 3", input: "\x1b[3g\t", row: 1, col: 10, knownBug: "tab stops are fixed every 8 columns"},
+		{name: "CHT", input: "\x1b[1;4H\x1bH\r\x1b[2I", row: 1, col: 9, knownBug: "CHT is not implemented"},
+		{name: "CBT", input: "\x1b[1;10H\x1b[Z", row: 1, col: 9, knownBug: "CBT is not implemented"},
+	})
+}
+
+func TestConformanceSGR(t *testing.T) {
+	term := newConfTerminal()
+	term.parser.ParseString("\x1b[1;3;4;7;9mA\x1b[22;23;24;27;29mB\x1b[31;42mC\x1b[38;5;200;48;2;1;2;3mD\x1b[mE")
+
+	a := term.buffer.GetCell(0, 0)
+	if !a.Bold || !a.Italic || !a.Underline || !a.Reverse || !a.Strikethrough {
+		t.Errorf("SGR 1;3;4;7;9: got %+v", a)
+	}
+	b := term.buffer.GetCell(1, 0)
+	if b.Bold || b.Italic || b.Underline || b.Reverse || b.Strikethrough {
+		t.Errorf("SGR 22;23;24;27;29: got %+v", b)
+	}
+	c := term.buffer.GetCell(2, 0)
+	if c.Foreground != StandardColor(1) || c.Background != StandardColor(2) {
+		t.Errorf("SGR 31;42: got fg %+v bg %+v", c.Foreground, c.Background)
+	}
+	d := term.buffer.GetCell(3, 0)
+	if d.Foreground != PaletteColor(200) || d.Background != TrueColor(1, 2, 3) {
+		t.Errorf("SGR 38;5;200;48;2;1;2;3: got fg %+v bg %+v", d.Foreground, d.Background)
+	}
+	e := term.buffer.GetCell(4, 0)
+	if e.Foreground != DefaultForeground || e.Background != DefaultBackground {
+		t.Errorf("SGR 0: got fg %+v bg %+v", e.Foreground, e.Background)
+	}
+}
+
+func TestConformanceReports(t *testing.T) {
+	runConfCases(t, []confCase{
+		{name: "DA1", input: "\x1b[c", replies: primaryDeviceAttributes},
+		{name: "DA1 with 0", input: "\x1b[0c", replies: primaryDeviceAttributes},
+		{name: "DA2", input: "\x1b[>c", replies: secondaryDeviceAttributes},
+		{name: "DSR status", input: "\x1b[5n", replies: "\x1b[0n"},
+		{name: "CPR", input: "\x1b[3;7H\x1b[6n", row: 3, col: 7, replies: "\x1b[3;7R"},
+		{name: "DECXCPR", input: "\x1b[2;4H\x1b[?6n", replies: "\x1b[?2;4;1R"},
+		{name: "CPR in origin mode", input: "\x1b[2;4r\x1b[?6h\x1b[2;3H\x1b[6n", replies: "\x1b[2;3R"},
+		{name: "XTVERSION", input: "\x1b[>q", replies: "\x1bP>|" + DefaultTerminalIdentity + "\x1b\\"},
+	})
+}
+
+func TestConformanceParser(t *testing.T) {
+	runConfCases(t, []confCase{
+		{
+			name:   "C0 controls inside a CSI sequence are executed",
+			input:  "ab\x1b[\r2C",
+			screen: []string{"ab"},
+			row:    1, col: 3,
+			knownBug: "C0 controls end a CSI sequence instead",
+		},
+		{
+			name:   "CAN cancels a sequence",
+			input:  "\x1b[3\x18A",
+			screen: []string{"A"},
+			row:    1, col: 2,
+		},
+		{
+			name:   "unknown CSI sequences are ignored",
+			input:  "a\x1b[99;99~b",
+			screen: []string{"ab"},
+			row:    1, col: 3,
+		},
+		{
+			name:   "OSC ends with ST",
+			input:  "\x1b]0;title\x1b\\ok",
+			screen: []string{"ok"},
+			row:    1, col: 3,
+		},
+		{
+			name:   "OSC ends with BEL",
+			input:  "\x1b]2;title\x07ok",
+			screen: []string{"ok"},
+			row:    1, col: 3,
+		},
+		{
+			name:   "UTF-8",
+			input:  "héllo",
+			screen: []string{"héllo"},
+			row:    1, col: 6,
+		},
+		{
+			name:   "RIS",
+			input:  "abc\x1b[1m\x1b[3;3H\x1bc",
+			screen: []string{},
+			row:    1, col: 1,
+		},
+	})
+
+	// The same sequence fed a byte at a time
+	term := newConfTerminal()
+	for _, b := range []byte("\x1b[2;5Hx\x1b]0;t\x1b\\y") {
+		term.parser.Parse([]byte{b})
+	}
+	if got := term.screen()[1]; got != "    xy" {
+		t.Errorf("byte at a time: row 2 is %q, want %q", got, "    xy")
+	}
+}
@@ -0,0 +1,65 @@
+package purfecterm
+
+import (
+	"net/url"
+	"runtime"
+	"strings"
+)
+
+// File drops
+// Files dropped on a terminal are typed at the cursor as their paths, quoted for
+// the shell and separated by spaces, with a space after the last so the next word
+// can follow, as mainstream terminals do. The widgets deliver the text as a paste
+// (see BracketPaste), so programs with bracketed paste mode see it as one.
+
+// shellSafe holds the characters a POSIX shell word may contain unquoted
+const shellSafe = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789@%+=:,./-_"
+
+// ShellQuote quotes a word for a POSIX shell, leaving it as is if it needs no quoting
+func ShellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	if strings.Trim(s, shellSafe) == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// quoteDropPath quotes a dropped path for the platform's shell: POSIX quoting, or
+// double quotes around Windows paths with spaces (which can't hold quotes)
+func quoteDropPath(path string, windows bool) string {
+	if !windows {
+		return ShellQuote(path)
+	}
+	if strings.ContainsAny(path, " &()[]{}^=;!'+,`~%") {
+		return `"` + path + `"`
+	}
+	return path
+}
+
+// DropText returns the text typed for dropped files: their paths, quoted and
+// separated by spaces, with a trailing space ("" for no paths)
+func DropText(paths []string) string {
+	var b strings.Builder
+	for _, path := range paths {
+		b.WriteString(quoteDropPath(path, runtime.GOOS == "windows"))
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+// FileURIPath returns the local path of a file: URI, as drops deliver them, or
+// false if the URI isn't a local file
+func FileURIPath(uri string) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(uri))
+	if err != nil || u.Scheme != "file" || (u.Host != "" && u.Host != "localhost") || u.Path == "" {
+		return "", false
+	}
+	path := u.Path
+	if runtime.GOOS == "windows" {
+		// file:///C:/dir -> C:\dir
+		path = strings.ReplaceAll(strings.TrimPrefix(path, "/"), "/", `\`)
+	}
+	return path, true
+}
@@ -0,0 +1,49 @@
+package purfecterm
+
+import "testing"
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"", "''"},
+		{"/home/user/notes.txt", "/home/user/notes.txt"},
+		{"/tmp/my file", "'/tmp/my file'"},
+		{"it's", `'it'\''s'`},
+		{"$HOME", "'$HOME'"},
+	}
+	for _, tt := range tests {
+		if got := ShellQuote(tt.in); got != tt.want {
+			t.Errorf("ShellQuote(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestQuoteDropPath(t *testing.T) {
+	if got := quoteDropPath(`C:\Program Files\x`, true); got != `"C:\Program Files\x"` {
+		t.Errorf("Windows path with a space: got %q", got)
+	}
+	if got := quoteDropPath(`C:\dir\x.txt`, true); got != `C:\dir\x.txt` {
+		t.Errorf("plain Windows path: got %q", got)
+	}
+	if got := quoteDropPath("/a b", false); got != "'/a b'" {
+		t.Errorf("POSIX path: got %q", got)
+	}
+}
+
+func TestFileURIPath(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want string
+		ok   bool
+	}{
+		{"file:///tmp/my%20file.txt", "/tmp/my file.txt", true},
+		{"file://localhost/etc/hosts\r\n", "/etc/hosts", true},
+		{"file://otherhost/etc/hosts", "", false},
+		{"https://example.com/x", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := FileURIPath(tt.uri)
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Errorf("FileURIPath(%q) = %q, %v; want %q, %v", tt.uri, got, ok, tt.want, tt.ok)
+		}
+	}
+}
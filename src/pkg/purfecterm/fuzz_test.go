@@ -0,0 +1,47 @@
+package purfecterm
+
+import (
+	"testing"
+)
+
+// FuzzParser feeds arbitrary bytes to the parser, which must not panic or leave the
+// cursor off the screen. Run it with: go test -fuzz=FuzzParser ./src/pkg/purfecterm
+func FuzzParser(f *testing.F) {
+	seeds := []string{
+		"plain text\r\n",
+		"\x1b[3;4H\x1b[2J\x1b[K\x1b[1;31;42mcolor\x1b[m",
+		"\x1b[38;5;200;48;2;1;2;3m\x1b[4:3m\x1b[58;2;9;9;9m",
+		"\x1b[2;4r\x1b[?6h\x1b[9;9H\x1bM\x1bD\x1b[S\x1b[T",
+		"\x1b[?7l0123456789XY\x1b[?7h\x1b[4h\x1b[@\x1b[P\x1b[L\x1b[M\x1b[X",
+		"\x1b7\x1b8\x1b[s\x1b[u\x1bc\x1b#8\x1b#3\x1b#6",
+		"\x1b[c\x1b[>c\x1b[5n\x1b[6n\x1b[?6n\x1b[>q",
+		"\x1b]0;title\x07\x1b]8;;http://example.com\x1b\\link\x1b]8;;\x1b\\",
+		"\x1b]52;c;aGVsbG8=\x07\x1b]7005;s;1;0;v;40\x07\x1b]7005;w;1\x07x\x1b]7005;c;1\x07",
+		"\x1bPq#0;2;0;0;0#1;2;100;100;0#1~~@@vv@@~~$-\x1b\\",
+		"\x1b_Ga=T,f=100;AAAA\x1b\\",
+		"héllo 世界 👍🏽 é",
+		"\x1b[?1049h\x1b[?1049l\x1b[?25l\x1b[?2004h\x1b[?1000h\x1b[?1006h",
+		"a\x1b[999999999b\x1b[999999999@\x1b[999999999L\x1b[999999999M\x1b[999999999S\x1b[999999999T",
+		"\x1b[", "\x1b]", "\x1bP", "\xff\xfe\x80",
+	}
+	for _, seed := range seeds {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		buffer := NewBuffer(20, 6, 50)
+		parser := NewParser(buffer)
+		parser.Parse(data)
+
+		cols, rows := buffer.GetSize()
+		x, y := buffer.GetCursor()
+		if x < 0 || x > cols || y < 0 || y >= rows {
+			t.Fatalf("cursor at %d,%d on a %dx%d screen", x, y, cols, rows)
+		}
+		for row := 0; row < rows; row++ {
+			for col := 0; col < cols; col++ {
+				buffer.GetCell(col, row)
+			}
+		}
+	})
+}
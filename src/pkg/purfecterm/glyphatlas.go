@@ -0,0 +1,88 @@
+package purfecterm
+
+// Glyph atlas
+// The GPU renderers keep their glyphs in one texture, rendered once each at the cell
+// size and drawn from there. GlyphAtlas does the bookkeeping: it places glyph images
+// on shelves (rows as tall as their tallest image, filled left to right) and
+// remembers where each one went. It holds no pixels; the renderer uploads each image
+// to the rectangle it is given. When the atlas is full the renderer resets it and
+// starts over with the glyphs on screen.
+
+// AtlasKey identifies a glyph image in the atlas
+type AtlasKey struct {
+	Text   string
+	Bold   bool
+	Italic bool
+	Color  Color // The color the glyph is rendered in, for renderers that can't tint white glyphs
+}
+
+// AtlasRect is where an image is in the atlas, in pixels
+type AtlasRect struct {
+	X, Y, W, H int
+}
+
+// atlasPadding is the gap left around each image so neighbors don't bleed together
+const atlasPadding = 1
+
+// GlyphAtlas places glyph images in a texture of a fixed size
+type GlyphAtlas struct {
+	width, height int
+	glyphs        map[AtlasKey]AtlasRect
+
+	// The shelf being filled
+	shelfX, shelfY, shelfH int
+}
+
+// NewGlyphAtlas creates an empty atlas for a texture of the given size
+func NewGlyphAtlas(width, height int) *GlyphAtlas {
+	a := &GlyphAtlas{width: width, height: height}
+	a.Reset()
+	return a
+}
+
+// Size returns the size of the atlas texture
+func (a *GlyphAtlas) Size() (width, height int) {
+	return a.width, a.height
+}
+
+// Len returns the number of images in the atlas
+func (a *GlyphAtlas) Len() int {
+	return len(a.glyphs)
+}
+
+// Lookup returns where the image for key is, if it has been added
+func (a *GlyphAtlas) Lookup(key AtlasKey) (AtlasRect, bool) {
+	rect, ok := a.glyphs[key]
+	return rect, ok
+}
+
+// Add finds room for an image of the given size for key, returning false if the
+// atlas is full. Adding a key again returns the room it already has.
+func (a *GlyphAtlas) Add(key AtlasKey, width, height int) (AtlasRect, bool) {
+	if rect, ok := a.glyphs[key]; ok {
+		return rect, true
+	}
+	if width <= 0 || height <= 0 || width+atlasPadding > a.width || height+atlasPadding > a.height {
+		return AtlasRect{}, false
+	}
+	if a.shelfX+width+atlasPadding > a.width {
+		// Start a new shelf below this one
+		a.shelfX = 0
+		a.shelfY += a.shelfH
+		a.shelfH = 0
+	}
+	if a.shelfY+height+atlasPadding > a.height {
+		return AtlasRect{}, false
+	}
+	rect := AtlasRect{X: a.shelfX, Y: a.shelfY, W: width, H: height}
+	a.shelfX += width + atlasPadding
+	a.shelfH = max(a.shelfH, height+atlasPadding)
+	a.glyphs[key] = rect
+	return rect, true
+}
+
+// Reset empties the atlas
+func (a *GlyphAtlas) Reset() {
+	a.glyphs = make(map[AtlasKey]AtlasRect)
+	a.shelfX, a.shelfY, a.shelfH = 0, 0, 0
+}
@@ -0,0 +1,155 @@
+package purfecterm
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// Grapheme clusters
+// What a reader sees as one character can be several code points: a base with
+// combining marks, an emoji with a skin tone modifier or variation selector, emoji
+// joined with ZERO WIDTH JOINER into one picture, or a flag made of two regional
+// indicators. The parser hands the buffer one code point at a time, so the buffer
+// decides whether each continues the cluster in the cell before the cursor (going
+// into its Combining string) or starts a new cell. A cluster takes one cell and moves
+// the cursor once, however many code points it has.
+//
+// In flex width mode a cluster's width follows its presentation: VS16 (U+FE0F) makes
+// a pictograph an emoji, 2.0 wide, and a flag is 2.0 wide.
+
+// runeRange is an inclusive range of code points
+type runeRange struct {
+	first, last rune
+}
+
+// inRanges returns whether r falls in one of a sorted list of ranges
+func inRanges(r rune, ranges []runeRange) bool {
+	i := sort.Search(len(ranges), func(i int) bool { return ranges[i].last >= r })
+	return i < len(ranges) && ranges[i].first <= r
+}
+
+// wideSymbolRanges are the wide (W) characters of Unicode 15.1 that aren't CJK
+// ideographs or kana: mostly emoji with emoji presentation, plus Tangut, Khitan, the
+// kana supplements and the Hangul leading consonants
+var wideSymbolRanges = []runeRange{
+	{0x1100, 0x115F}, {0x231A, 0x231B}, {0x2329, 0x232A}, {0x23E9, 0x23EC},
+	{0x23F0, 0x23F0}, {0x23F3, 0x23F3}, {0x25FD, 0x25FE}, {0x2614, 0x2615},
+	{0x2648, 0x2653}, {0x267F, 0x267F}, {0x2693, 0x2693}, {0x26A1, 0x26A1},
+	{0x26AA, 0x26AB}, {0x26BD, 0x26BE}, {0x26C4, 0x26C5}, {0x26CE, 0x26CE},
+	{0x26D4, 0x26D4}, {0x26EA, 0x26EA}, {0x26F2, 0x26F3}, {0x26F5, 0x26F5},
+	{0x26FA, 0x26FA}, {0x26FD, 0x26FD}, {0x2705, 0x2705}, {0x270A, 0x270B},
+	{0x2728, 0x2728}, {0x274C, 0x274C}, {0x274E, 0x274E}, {0x2753, 0x2755},
+	{0x2757, 0x2757}, {0x2795, 0x2797}, {0x27B0, 0x27B0}, {0x27BF, 0x27BF},
+	{0x2B1B, 0x2B1C}, {0x2B50, 0x2B50}, {0x2B55, 0x2B55},
+	{0x16FE0, 0x16FE4}, {0x16FF0, 0x16FF1}, {0x17000, 0x187F7}, {0x18800, 0x18CD5},
+	{0x18D00, 0x18D08}, {0x1AFF0, 0x1AFF3}, {0x1AFF5, 0x1AFFB}, {0x1AFFD, 0x1AFFE},
+	{0x1B000, 0x1B122}, {0x1B132, 0x1B132}, {0x1B150, 0x1B152}, {0x1B155, 0x1B155},
+	{0x1B164, 0x1B167}, {0x1B170, 0x1B2FB},
+	{0x1F004, 0x1F004}, {0x1F0CF, 0x1F0CF}, {0x1F18E, 0x1F18E}, {0x1F191, 0x1F19A},
+	{0x1F200, 0x1F202}, {0x1F210, 0x1F23B}, {0x1F240, 0x1F248}, {0x1F250, 0x1F251},
+	{0x1F260, 0x1F265}, {0x1F300, 0x1F320}, {0x1F32D, 0x1F335}, {0x1F337, 0x1F37C},
+	{0x1F37E, 0x1F393}, {0x1F3A0, 0x1F3CA}, {0x1F3CF, 0x1F3D3}, {0x1F3E0, 0x1F3F0},
+	{0x1F3F4, 0x1F3F4}, {0x1F3F8, 0x1F43E}, {0x1F440, 0x1F440}, {0x1F442, 0x1F4FC},
+	{0x1F4FF, 0x1F53D}, {0x1F54B, 0x1F54E}, {0x1F550, 0x1F567}, {0x1F57A, 0x1F57A},
+	{0x1F595, 0x1F596}, {0x1F5A4, 0x1F5A4}, {0x1F5FB, 0x1F64F}, {0x1F680, 0x1F6C5},
+	{0x1F6CC, 0x1F6CC}, {0x1F6D0, 0x1F6D2}, {0x1F6D5, 0x1F6D7}, {0x1F6DC, 0x1F6DF},
+	{0x1F6EB, 0x1F6EC}, {0x1F6F4, 0x1F6FC}, {0x1F7E0, 0x1F7EB}, {0x1F7F0, 0x1F7F0},
+	{0x1F90C, 0x1F93A}, {0x1F93C, 0x1F945}, {0x1F947, 0x1F9FF}, {0x1FA70, 0x1FA7C},
+	{0x1FA80, 0x1FA88}, {0x1FA90, 0x1FABD}, {0x1FABF, 0x1FAC5}, {0x1FACE, 0x1FADB},
+	{0x1FAE0, 0x1FAE8}, {0x1FAF0, 0x1FAF8},
+}
+
+// pictographicRanges are the Extended_Pictographic characters of Unicode 15.1: the
+// ones that can be emoji, and that ZERO WIDTH JOINER joins
+var pictographicRanges = []runeRange{
+	{0x00A9, 0x00A9}, {0x00AE, 0x00AE}, {0x203C, 0x203C}, {0x2049, 0x2049},
+	{0x2122, 0x2122}, {0x2139, 0x2139}, {0x2194, 0x2199}, {0x21A9, 0x21AA},
+	{0x231A, 0x231B}, {0x2328, 0x2328}, {0x2388, 0x2388}, {0x23CF, 0x23CF},
+	{0x23E9, 0x23F3}, {0x23F8, 0x23FA}, {0x24C2, 0x24C2}, {0x25AA, 0x25AB},
+	{0x25B6, 0x25B6}, {0x25C0, 0x25C0}, {0x25FB, 0x25FE}, {0x2600, 0x2605},
+	{0x2607, 0x2612}, {0x2614, 0x2685}, {0x2690, 0x2705}, {0x2708, 0x2712},
+	{0x2714, 0x2714}, {0x2716, 0x2716}, {0x271D, 0x271D}, {0x2721, 0x2721},
+	{0x2728, 0x2728}, {0x2733, 0x2734}, {0x2744, 0x2744}, {0x2747, 0x2747},
+	{0x274C, 0x274C}, {0x274E, 0x274E}, {0x2753, 0x2755}, {0x2757, 0x2757},
+	{0x2763, 0x2767}, {0x2795, 0x2797}, {0x27A1, 0x27A1}, {0x27B0, 0x27B0},
+	{0x27BF, 0x27BF}, {0x2934, 0x2935}, {0x2B05, 0x2B07}, {0x2B1B, 0x2B1C},
+	{0x2B50, 0x2B50}, {0x2B55, 0x2B55}, {0x3030, 0x3030}, {0x303D, 0x303D},
+	{0x3297, 0x3297}, {0x3299, 0x3299},
+	{0x1F000, 0x1F0FF}, {0x1F10D, 0x1F10F}, {0x1F12F, 0x1F12F}, {0x1F16C, 0x1F171},
+	{0x1F17E, 0x1F17F}, {0x1F18E, 0x1F18E}, {0x1F191, 0x1F19A}, {0x1F1AD, 0x1F1E5},
+	{0x1F201, 0x1F20F}, {0x1F21A, 0x1F21A}, {0x1F22F, 0x1F22F}, {0x1F232, 0x1F23A},
+	{0x1F23C, 0x1F23F}, {0x1F249, 0x1F3FA}, {0x1F400, 0x1F53D}, {0x1F546, 0x1F64F},
+	{0x1F680, 0x1F6FF}, {0x1F774, 0x1F77F}, {0x1F7D5, 0x1F7FF}, {0x1F80C, 0x1F80F},
+	{0x1F848, 0x1F84F}, {0x1F85A, 0x1F85F}, {0x1F888, 0x1F88F}, {0x1F8AE, 0x1F8FF},
+	{0x1F90C, 0x1F93A}, {0x1F93C, 0x1F945}, {0x1F947, 0x1FAFF}, {0x1FC00, 0x1FFFD},
+}
+
+// Code points with a part in clusters
+const (
+	zeroWidthJoiner           = 0x200D
+	emojiPresentationSelector = 0xFE0F // VS16
+)
+
+// isWideSymbol returns whether a character outside the CJK blocks is wide
+func isWideSymbol(r rune) bool {
+	return inRanges(r, wideSymbolRanges)
+}
+
+// IsPictographic returns whether a character is Extended_Pictographic (can be an emoji)
+func IsPictographic(r rune) bool {
+	return inRanges(r, pictographicRanges)
+}
+
+// isRegionalIndicator returns whether r is one of the letters that pair into flags
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+// isEmojiModifier returns whether r is a skin tone modifier
+func isEmojiModifier(r rune) bool {
+	return r >= 0x1F3FB && r <= 0x1F3FF
+}
+
+// isClusterExtender returns whether r always continues the cluster before it:
+// combining marks, skin tone modifiers, the tags of subdivision flags, and the
+// invisible format characters
+func isClusterExtender(r rune) bool {
+	switch {
+	case IsCombiningMark(r), isEmojiModifier(r):
+		return true
+	case r >= 0xE0020 && r <= 0xE007F: // Tags
+		return true
+	case r == 0x200B, r == 0x200E, r == 0x200F, r >= 0x2060 && r <= 0x2064, r == 0xFEFF:
+		return true
+	}
+	return false
+}
+
+// continuesCluster returns whether ch belongs to the cluster in a cell rather than
+// starting a new one
+func continuesCluster(prev Cell, ch rune) bool {
+	switch {
+	case isClusterExtender(ch):
+		return true
+	case isRegionalIndicator(ch):
+		// The second regional indicator of a flag
+		return isRegionalIndicator(prev.Char) && prev.Combining == ""
+	case IsPictographic(ch):
+		// A pictograph after ZERO WIDTH JOINER joins the sequence
+		last, _ := utf8.DecodeLastRuneInString(prev.Combining)
+		return last == zeroWidthJoiner
+	}
+	return false
+}
+
+// clusterWidth returns the flex width of a cell whose cluster just gained ch (an emoji
+// presentation selector or a flag's second half widens it)
+func clusterWidth(cell Cell, ch rune) float64 {
+	switch {
+	case ch == emojiPresentationSelector && IsPictographic(cell.Char):
+		return 2.0
+	case isRegionalIndicator(ch):
+		return 2.0
+	}
+	return cell.CellWidth
+}
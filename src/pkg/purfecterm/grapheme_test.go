@@ -0,0 +1,104 @@
+package purfecterm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGraphemeClusters(t *testing.T) {
+	type cell struct {
+		text  string  // The cell's character and combining marks
+		width float64 // Its flex width
+	}
+	tests := []struct {
+		name  string
+		input string
+		want  []cell
+	}{
+		{"combining marks", "e\u0323\u0301x", []cell{{"e\u0323\u0301", 1}, {"x", 1}}},
+		{"Hebrew points", "\u05E9\u05B8\u05C1", []cell{{"\u05E9\u05B8\u05C1", 1}}},
+		{"mark with nothing before it", "\u0301a", []cell{{"a", 1}}},
+		{"ZWJ family", "\U0001F468\u200D\U0001F469\u200D\U0001F467x",
+			[]cell{{"\U0001F468\u200D\U0001F469\u200D\U0001F467", 2}, {"x", 1}}},
+		{"ZWJ before a letter", "\U0001F468\u200Db", []cell{{"\U0001F468\u200D", 2}, {"b", 1}}},
+		{"skin tone", "\U0001F44D\U0001F3FD\U0001F44D", []cell{{"\U0001F44D\U0001F3FD", 2}, {"\U0001F44D", 2}}},
+		{"flag", "\U0001F1EF\U0001F1F5", []cell{{"\U0001F1EF\U0001F1F5", 2}}},
+		{"two flags", "\U0001F1EF\U0001F1F5\U0001F1FA\U0001F1F8",
+			[]cell{{"\U0001F1EF\U0001F1F5", 2}, {"\U0001F1FA\U0001F1F8", 2}}},
+		{"odd regional indicator", "\U0001F1EF\U0001F1F5\U0001F1FA",
+			[]cell{{"\U0001F1EF\U0001F1F5", 2}, {"\U0001F1FA", 1}}},
+		{"subdivision flag", "\U0001F3F4\U000E0067\U000E0062\U000E0073\U000E0063\U000E0074\U000E007F",
+			[]cell{{"\U0001F3F4\U000E0067\U000E0062\U000E0073\U000E0063\U000E0074\U000E007F", 2}}},
+		{"emoji presentation", "\u2764\uFE0F", []cell{{"\u2764\uFE0F", 2}}},
+		{"text presentation", "\u2764\uFE0E", []cell{{"\u2764\uFE0E", 1}}},
+		{"VS16 on a letter", "a\uFE0F", []cell{{"a\uFE0F", 1}}},
+		{"CJK", "\u4E16a", []cell{{"\u4E16", 2}, {"a", 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBuffer(20, 2, 0)
+			b.SetFlexWidthMode(true)
+			b.SetAmbiguousWidthMode(AmbiguousWidthNarrow)
+			NewParser(b).ParseString(tt.input)
+
+			var got []cell
+			for x := 0; x < 20; x++ {
+				c := b.GetCell(x, 0)
+				if c.Char == 0 || c.Char == ' ' {
+					break
+				}
+				got = append(got, cell{c.String(), c.CellWidth})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cells %v, want %v", got, tt.want)
+			}
+			if x, _ := b.GetCursor(); x != len(tt.want) {
+				t.Errorf("cursor at %d, want %d", x, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestClusterAcrossWrap(t *testing.T) {
+	// A mark that arrives after auto-wrap still joins the last cell of the line above
+	b := NewBuffer(3, 2, 0)
+	NewParser(b).ParseString("abć")
+	if got := b.GetCell(2, 0); got.String() != "ć" {
+		t.Errorf("got %+q, want c with its mark", got.String())
+	}
+}
+
+func TestWidthTables(t *testing.T) {
+	for name, ranges := range map[string][]runeRange{
+		"wideSymbolRanges":   wideSymbolRanges,
+		"pictographicRanges": pictographicRanges,
+	} {
+		for i, r := range ranges {
+			if r.first > r.last || (i > 0 && r.first <= ranges[i-1].last) {
+				t.Errorf("%s: %U-%U out of order", name, r.first, r.last)
+			}
+		}
+	}
+
+	tests := []struct {
+		r             rune
+		wide, picture bool
+	}{
+		{'a', false, false},
+		{0x231A, true, true},  // Watch
+		{0x2764, false, true}, // Heart, text presentation by default
+		{0x1F600, true, true}, // Grinning face
+		{0x1F1EF, false, false},
+		{0x1FAE8, true, true}, // Shaking face (Unicode 15)
+		{0x1100, true, false}, // Hangul leading consonant
+		{0x00A9, false, true},
+	}
+	for _, tt := range tests {
+		if got := isWideSymbol(tt.r); got != tt.wide {
+			t.Errorf("isWideSymbol(%U) = %v, want %v", tt.r, got, tt.wide)
+		}
+		if got := IsPictographic(tt.r); got != tt.picture {
+			t.Errorf("IsPictographic(%U) = %v, want %v", tt.r, got, tt.picture)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package purfecterm
+
+import "math"
+
+// Grid frames
+// The GPU renderers draw the screen as a grid of cells with their colors already
+// resolved, so they can compare one frame with the last and only rebuild the rows
+// that changed. A GridFrame is that grid, built from the same rules as the Cairo
+// and QPainter renderers use: blink modes, search and selection highlighting, the
+// hovered link and the block cursor. Frames using what the grid can't describe
+// (scaled or cropped screens, double-size lines, flex-width cells, custom glyphs,
+// sprites behind the text, screen splits and bouncing text) aren't built, and the
+// widgets draw those with their usual renderer instead.
+
+// GridCell is a cell as the GPU renderers draw it
+type GridCell struct {
+	Text           string // The character and its combining marks, or "" for none
+	Fg, Bg         Color
+	Bold, Italic   bool
+	Underline      UnderlineStyle
+	UnderlineColor Color
+	Strikethrough  bool
+	Overline       bool
+}
+
+// GridCursor is the cursor as the GPU renderers draw it
+// A focused block cursor is drawn by the swapped colors of its cell, so only the
+// other shapes, and the outline of an unfocused block, need drawing on top.
+type GridCursor struct {
+	Visible bool
+	X, Y    int
+	Shape   int // 0=block, 1=underline, 2=bar
+	Focused bool
+	Color   Color
+}
+
+// GridFrame is the visible screen as a grid of resolved cells
+type GridFrame struct {
+	Cols, Rows int
+	Background Color
+	Cells      [][]GridCell // Cells[y][x]
+	Cursor     GridCursor
+
+	// Runs of cells whose text is drawn together, by row, with ColorScheme.Ligatures
+	// (see ligature.go)
+	Runs [][]LigatureRun
+}
+
+// GridFrameOptions is the widget state a frame depends on
+type GridFrameOptions struct {
+	Scheme        ColorScheme
+	BlinkPhase    float64 // Text blink animation phase, 0 to 2*pi
+	CursorBlinkOn bool    // Whether a blinking cursor is in its visible phase
+	Focused       bool
+	HoverLink     int // The hyperlink under the mouse, underlined (0 for none)
+}
+
+// BuildGridFrame returns the buffer's visible screen as a grid frame, or false if
+// the screen uses something the grid can't describe (see the Grid frames comment).
+func BuildGridFrame(b *Buffer, opts GridFrameOptions) (*GridFrame, bool) {
+	if b.GetHorizontalScale() != 1 || b.GetVerticalScale() != 1 {
+		return nil, false
+	}
+	if widthCrop, heightCrop := b.GetScreenCrop(); widthCrop > 0 || heightCrop > 0 {
+		return nil, false
+	}
+	if behind, _ := b.GetSpritesForRendering(); len(behind) > 0 {
+		return nil, false
+	}
+	if len(b.GetScreenSplitsSorted()) > 0 {
+		return nil, false
+	}
+
+	scheme := opts.Scheme
+	isDark := b.IsDarkTheme()
+	cols, rows := b.GetSize()
+	f := &GridFrame{
+		Cols:       cols,
+		Rows:       rows,
+		Background: scheme.Background(isDark),
+		Cells:      make([][]GridCell, rows),
+	}
+	if scheme.Ligatures {
+		f.Runs = make([][]LigatureRun, rows)
+	}
+
+	cursorShape, _ := b.GetCursorStyle()
+	cursorX, cursorY := b.GetCursorVisiblePosition()
+	f.Cursor = GridCursor{
+		Visible: b.IsCursorVisible() && cursorX >= 0 && cursorY >= 0 && opts.CursorBlinkOn,
+		X:       cursorX,
+		Y:       cursorY,
+		Shape:   cursorShape,
+		Focused: opts.Focused,
+		Color:   scheme.Cursor,
+	}
+
+	horizOffset := b.GetHorizOffset()
+	palette := scheme.Palette(isDark)
+	for y := 0; y < rows; y++ {
+		if b.GetVisibleLineAttribute(y) != LineAttrNormal {
+			return nil, false
+		}
+		row := make([]GridCell, cols)
+		for x := 0; x < cols; x++ {
+			cell := b.GetVisibleCell(x, y)
+			if cell.FlexWidth && cell.CellWidth > 0 && cell.CellWidth != 1 {
+				return nil, false
+			}
+
+			gc := GridCell{
+				Fg:            scheme.ResolveColor(cell.Foreground, true, isDark),
+				Bg:            scheme.ResolveColor(cell.Background, false, isDark),
+				Bold:          cell.Bold,
+				Italic:        cell.Italic,
+				Underline:     cell.UnderlineStyle,
+				Strikethrough: cell.Strikethrough,
+				Overline:      cell.Overline,
+			}
+			if cell.LinkID != 0 && cell.LinkID == opts.HoverLink && gc.Underline == UnderlineNone {
+				gc.Underline = UnderlineSingle
+			}
+
+			textVisible := true
+			if cell.Blink {
+				switch scheme.BlinkMode {
+				case BlinkModeBright:
+					// Blink as a bright background (VGA style)
+					for i := 0; i < 8 && len(palette) > i+8; i++ {
+						if gc.Bg.R == palette[i].R && gc.Bg.G == palette[i].G && gc.Bg.B == palette[i].B {
+							gc.Bg = palette[i+8]
+							break
+						}
+					}
+				case BlinkModeBlink:
+					textVisible = opts.BlinkPhase < math.Pi
+				case BlinkModeBounce:
+					if cell.Char != ' ' && cell.Char != 0 {
+						return nil, false
+					}
+				}
+			}
+
+			logicalX := x + horizOffset
+			switch b.GetSearchHighlight(logicalX, y) {
+			case SearchHighlightMatch:
+				gc.Fg, gc.Bg = scheme.SearchText, scheme.SearchMatch
+			case SearchHighlightCurrent:
+				gc.Fg, gc.Bg = scheme.SearchText, scheme.SearchCurrent
+			}
+			if b.IsInSelection(logicalX, y) {
+				gc.Bg = scheme.Selection
+			}
+			if f.Cursor.Visible && opts.Focused && cursorShape == 0 && x == cursorX && y == cursorY {
+				gc.Fg, gc.Bg = gc.Bg, gc.Fg
+			}
+
+			if cell.Char != ' ' && cell.Char != 0 && textVisible {
+				if b.GetGlyph(cell.Char) != nil {
+					return nil, false
+				}
+				gc.Text = cell.String()
+			}
+			if gc.Underline != UnderlineNone {
+				gc.UnderlineColor = gc.Fg
+				if cell.HasUnderlineColor {
+					gc.UnderlineColor = scheme.ResolveColor(cell.UnderlineColor, true, isDark)
+				}
+			}
+			row[x] = gc
+		}
+		f.Cells[y] = row
+		if scheme.Ligatures {
+			f.Runs[y] = gridLigatureRuns(row)
+		}
+	}
+	return f, true
+}
+
+// gridLigatureRuns returns a row's ligature runs: cells drawn in the same colors
+// and style
+func gridLigatureRuns(row []GridCell) []LigatureRun {
+	texts := make([]string, len(row))
+	for x := range row {
+		texts[x] = row[x].Text
+	}
+	return FindLigatureRuns(texts, func(a, b int) bool {
+		return row[a].Fg == row[b].Fg && row[a].Bg == row[b].Bg && row[a].Bold == row[b].Bold && row[a].Italic == row[b].Italic
+	})
+}
+
+// DamagedRows returns the rows whose cells differ from prev's: all of them if prev
+// is nil or differs in size or background
+func (f *GridFrame) DamagedRows(prev *GridFrame) []int {
+	var damaged []int
+	all := prev == nil || prev.Cols != f.Cols || prev.Rows != f.Rows || prev.Background != f.Background
+	for y := 0; y < f.Rows; y++ {
+		if all || !gridRowsEqual(f.Cells[y], prev.Cells[y]) {
+			damaged = append(damaged, y)
+		}
+	}
+	return damaged
+}
+
+// gridRowsEqual returns whether two rows of the same length hold the same cells
+func gridRowsEqual(a, b []GridCell) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
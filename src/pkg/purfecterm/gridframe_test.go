@@ -0,0 +1,105 @@
+package purfecterm
+
+import "testing"
+
+func newGridTestBuffer(input string) *Buffer {
+	b := NewBuffer(10, 4, 100)
+	NewParser(b).ParseString(input)
+	return b
+}
+
+func TestGridFrameCells(t *testing.T) {
+	b := newGridTestBuffer("a\x1b[1;31mb\x1b[m\x1b[4mc\x1b[m\x1b[2;1H")
+	opts := GridFrameOptions{Scheme: DefaultColorScheme(), CursorBlinkOn: true, Focused: true}
+	f, ok := BuildGridFrame(b, opts)
+	if !ok {
+		t.Fatal("plain screen not built")
+	}
+	if f.Cols != 10 || f.Rows != 4 {
+		t.Fatalf("frame is %dx%d, want 10x4", f.Cols, f.Rows)
+	}
+	row := f.Cells[0]
+	if row[0].Text != "a" || row[1].Text != "b" || row[2].Text != "c" || row[3].Text != "" {
+		t.Errorf("row 0 text: %q %q %q %q", row[0].Text, row[1].Text, row[2].Text, row[3].Text)
+	}
+	if !row[1].Bold || row[1].Fg == row[0].Fg {
+		t.Errorf("cell b: got %+v", row[1])
+	}
+	if row[2].Underline != UnderlineSingle || row[2].UnderlineColor != row[2].Fg {
+		t.Errorf("cell c: got %+v", row[2])
+	}
+
+	// The focused block cursor swaps its cell's colors
+	cursorCell := f.Cells[1][0]
+	if !f.Cursor.Visible || f.Cursor.X != 0 || f.Cursor.Y != 1 {
+		t.Errorf("cursor: got %+v", f.Cursor)
+	}
+	if cursorCell.Fg != f.Cells[1][1].Bg || cursorCell.Bg != f.Cells[1][1].Fg {
+		t.Errorf("cursor cell colors not swapped: got %+v", cursorCell)
+	}
+}
+
+func TestGridFrameFallback(t *testing.T) {
+	opts := GridFrameOptions{Scheme: DefaultColorScheme()}
+	for _, c := range []struct {
+		name  string
+		input string
+	}{
+		{"double-width line", "\x1b#6wide"},
+		{"132-column mode", "\x1b[?40h\x1b[?3h"},
+	} {
+		if _, ok := BuildGridFrame(newGridTestBuffer(c.input), opts); ok {
+			t.Errorf("%s: built a grid frame", c.name)
+		}
+	}
+}
+
+func TestGridFrameDamage(t *testing.T) {
+	b := newGridTestBuffer("one\r\ntwo\r\nthree")
+	opts := GridFrameOptions{Scheme: DefaultColorScheme()}
+	first, _ := BuildGridFrame(b, opts)
+	if got := first.DamagedRows(nil); len(got) != 4 {
+		t.Errorf("first frame damaged %v, want every row", got)
+	}
+
+	NewParser(b).ParseString("\x1b[2;1HTWO")
+	second, _ := BuildGridFrame(b, opts)
+	if got := second.DamagedRows(first); len(got) != 1 || got[0] != 1 {
+		t.Errorf("damaged %v, want [1]", got)
+	}
+	if got := second.DamagedRows(second); len(got) != 0 {
+		t.Errorf("unchanged frame damaged %v", got)
+	}
+}
+
+func TestGlyphAtlas(t *testing.T) {
+	a := NewGlyphAtlas(32, 24)
+	var rects []AtlasRect
+	for _, text := range []string{"a", "b", "c", "d", "e", "f"} {
+		rect, ok := a.Add(AtlasKey{Text: text}, 9, 10)
+		if !ok {
+			t.Fatalf("no room for %q after %d glyphs", text, a.Len())
+		}
+		if rect.X+rect.W > 32 || rect.Y+rect.H > 24 {
+			t.Errorf("%q placed outside the atlas at %+v", text, rect)
+		}
+		for _, other := range rects {
+			if rect.X < other.X+other.W && other.X < rect.X+rect.W &&
+				rect.Y < other.Y+other.H && other.Y < rect.Y+rect.H {
+				t.Errorf("%q at %+v overlaps %+v", text, rect, other)
+			}
+		}
+		rects = append(rects, rect)
+	}
+
+	if rect, _ := a.Add(AtlasKey{Text: "a"}, 9, 10); rect != rects[0] {
+		t.Errorf("adding again moved the glyph to %+v", rect)
+	}
+	if _, ok := a.Add(AtlasKey{Text: "g"}, 9, 10); ok {
+		t.Error("full atlas took another glyph")
+	}
+	a.Reset()
+	if _, ok := a.Lookup(AtlasKey{Text: "a"}); ok || a.Len() != 0 {
+		t.Error("reset atlas still holds glyphs")
+	}
+}
@@ -0,0 +1,41 @@
+package purfecterm
+
+import "math"
+
+// High DPI
+// At fractional scale factors (125%, 150%) the device pixels don't line up with the
+// logical pixels the widgets lay cells out in. What the widgets cache as images
+// (glyphs, custom glyphs, the background) is made at the device resolution
+// (DevicePixels), and copied to whole device pixels (SnapToDevice) so it isn't
+// resampled. Pointer positions come in fractions of a logical pixel and are mapped
+// to the cell drawn under them (CellIndex): a device pixel belongs to the cell its
+// center is in, as the renderers fill it.
+
+// DeviceScale returns the scale factor to draw at for a device pixel ratio, 1 if
+// the ratio isn't a usable one
+func DeviceScale(ratio float64) float64 {
+	if ratio <= 0 || math.IsNaN(ratio) || math.IsInf(ratio, 0) {
+		return 1
+	}
+	return ratio
+}
+
+// DevicePixels returns how many device pixels cover size logical pixels
+func DevicePixels(size int, scale float64) int {
+	return max(int(math.Ceil(float64(size)*scale-1e-6)), 0)
+}
+
+// SnapToDevice returns the first device pixel at or after a logical position,
+// counting a pixel whose center is on the position as after it
+func SnapToDevice(pos, scale float64) int {
+	return int(math.Ceil(pos*scale - 0.5))
+}
+
+// CellIndex returns which of the cells, size logical pixels each, a position
+// offset into them falls in (negative before the first)
+func CellIndex(offset float64, size int) int {
+	if size <= 0 {
+		return 0
+	}
+	return int(math.Floor(offset / float64(size)))
+}
@@ -0,0 +1,117 @@
+package purfecterm
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"testing"
+)
+
+func TestDeviceScale(t *testing.T) {
+	tests := []struct {
+		ratio, want float64
+	}{
+		{1, 1},
+		{1.25, 1.25},
+		{0, 1},
+		{-2, 1},
+		{math.NaN(), 1},
+		{math.Inf(1), 1},
+	}
+	for _, tt := range tests {
+		if got := DeviceScale(tt.ratio); got != tt.want {
+			t.Errorf("DeviceScale(%v) = %v, want %v", tt.ratio, got, tt.want)
+		}
+	}
+}
+
+func TestDevicePixels(t *testing.T) {
+	tests := []struct {
+		size  int
+		scale float64
+		want  int
+	}{
+		{10, 1, 10},
+		{10, 1.25, 13},
+		{8, 1.25, 10},
+		{9, 1.5, 14},
+		{10, 2, 20},
+		{0, 1.5, 0},
+	}
+	for _, tt := range tests {
+		if got := DevicePixels(tt.size, tt.scale); got != tt.want {
+			t.Errorf("DevicePixels(%d, %v) = %d, want %d", tt.size, tt.scale, got, tt.want)
+		}
+	}
+}
+
+func TestCellIndex(t *testing.T) {
+	tests := []struct {
+		offset float64
+		size   int
+		want   int
+	}{
+		{0, 9, 0},
+		{8.9, 9, 0},
+		{9, 9, 1},
+		{-0.2, 9, -1},
+		{5, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := CellIndex(tt.offset, tt.size); got != tt.want {
+			t.Errorf("CellIndex(%v, %d) = %d, want %d", tt.offset, tt.size, got, tt.want)
+		}
+	}
+}
+
+// TestFractionalScaleRender renders a row of cells at the scales a screen can
+// have, the way the widgets do: each cell's background filled over the device
+// pixels snapped to its edges, then a glyph image made at the device resolution
+// copied to the cell's first device pixel. The render must cover every pixel
+// with a cell's own color, copy each glyph's one pixel stem unblended, and map
+// each pixel back to the cell drawn there.
+func TestFractionalScaleRender(t *testing.T) {
+	const cols, cellW, cellH = 12, 9, 18
+	stem := color.RGBA{255, 255, 255, 255}
+	for _, scale := range []float64{1, 1.25, 1.5, 1.75, 2} {
+		width, height := DevicePixels(cols*cellW, scale), DevicePixels(cellH, scale)
+		img := image.NewRGBA(image.Rect(0, 0, width, height))
+
+		glyph := image.NewRGBA(image.Rect(0, 0, DevicePixels(cellW, scale), DevicePixels(cellH, scale)))
+		for y := 0; y < glyph.Rect.Dy(); y++ {
+			glyph.Set(1, y, stem)
+		}
+
+		spans := make([]int, cols)
+		for x := 0; x < cols; x++ {
+			left, right := SnapToDevice(float64(x*cellW), scale), SnapToDevice(float64((x+1)*cellW), scale)
+			spans[x] = right - left
+			draw.Draw(img, image.Rect(left, 0, right, height), image.NewUniform(cellColor(x)), image.Point{}, draw.Src)
+			draw.Draw(img, glyph.Rect.Add(image.Pt(left, 0)), glyph, image.Point{}, draw.Over)
+		}
+
+		for x := 1; x < cols; x++ {
+			if d := spans[x] - spans[0]; d < -1 || d > 1 {
+				t.Errorf("scale %v: cell %d is %d device pixels wide, cell 0 is %d", scale, x, spans[x], spans[0])
+			}
+		}
+		for px := 0; px < width; px++ {
+			cell := CellIndex((float64(px)+0.5)/scale, cellW)
+			left := SnapToDevice(float64(cell*cellW), scale)
+			got := img.RGBAAt(px, height/2)
+			want := cellColor(cell)
+			if px == left+1 {
+				want = stem
+			}
+			if got != want {
+				t.Errorf("scale %v: device pixel %d (cell %d) is %v, want %v", scale, px, cell, got, want)
+			}
+		}
+	}
+}
+
+// cellColor is the background of cell x in TestFractionalScaleRender
+func cellColor(x int) color.RGBA {
+	return color.RGBA{uint8(20 * x), 64, 128, 255}
+}
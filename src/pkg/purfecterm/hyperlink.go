@@ -0,0 +1,104 @@
+package purfecterm
+
+import (
+	"strings"
+)
+
+// Hyperlinks (OSC 8)
+// ESC ] 8 ; params ; URI ST starts a link and ESC ] 8 ; ; ST ends it; characters
+// written in between carry the link's ID in Cell.LinkID. params holds colon-separated
+// key=value pairs. Text written under the same id= and URI belongs to one link, so
+// a link drawn in pieces (or across lines) highlights as a whole; links without an
+// id= are separate even when their URIs match.
+
+// maxHyperlinks is how many links the buffer remembers before dropping unused ones
+const maxHyperlinks = 4096
+
+// hyperlink identifies a link by its id= parameter and URI
+type hyperlink struct {
+	id  string
+	uri string
+}
+
+// parseHyperlinkID returns the id= value from OSC 8 parameters
+func parseHyperlinkID(params string) string {
+	for _, param := range strings.Split(params, ":") {
+		if value, ok := strings.CutPrefix(param, "id="); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// SetHyperlink makes characters written from now on part of a link to uri
+// An empty uri ends the current link. Links with the same non-empty id and uri share an ID.
+func (b *Buffer) SetHyperlink(uri, id string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if uri == "" {
+		b.currentLink = 0
+		return
+	}
+	link := hyperlink{id: id, uri: uri}
+	if id != "" {
+		if linkID, ok := b.linkIDs[link]; ok {
+			b.currentLink = linkID
+			return
+		}
+	}
+	b.currentLink = b.addHyperlink(link)
+}
+
+// addHyperlink registers a link and returns its new ID
+// Must be called with the lock held.
+func (b *Buffer) addHyperlink(link hyperlink) int {
+	if len(b.links) >= maxHyperlinks {
+		b.collectHyperlinks()
+	}
+	linkID := b.nextLinkID
+	b.nextLinkID++
+	b.links[linkID] = link
+	if link.id != "" {
+		b.linkIDs[link] = linkID
+	}
+	return linkID
+}
+
+// GetHyperlink returns the URI of a Cell.LinkID, or "" if there is none
+func (b *Buffer) GetHyperlink(linkID int) string {
+	if linkID == 0 {
+		return ""
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.links[linkID].uri
+}
+
+// collectHyperlinks forgets links no cell refers to any more
+// Must be called with the lock held.
+func (b *Buffer) collectHyperlinks() {
+	used := map[int]bool{b.currentLink: true}
+	for _, lines := range [][][]Cell{b.scrollback, b.screen} {
+		for _, line := range lines {
+			for _, cell := range line {
+				used[cell.LinkID] = true
+			}
+		}
+	}
+	for _, p := range b.scrollbackPacked {
+		if p != nil {
+			for _, run := range p.Runs {
+				used[run.Attrs.LinkID] = true
+			}
+		}
+	}
+	for linkID, link := range b.links {
+		if !used[linkID] {
+			delete(b.links, linkID)
+			if link.id != "" {
+				delete(b.linkIDs, link)
+			}
+		}
+	}
+}
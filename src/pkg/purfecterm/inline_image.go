@@ -0,0 +1,140 @@
+package purfecterm
+
+import (
+	"image"
+)
+
+// maxInlineImages caps how many images the buffer keeps; the oldest are dropped first
+const maxInlineImages = 64
+
+// InlineImage is a picture placed in the text (from a sixel sequence)
+// It is anchored to the line it started on, so it scrolls with the text into
+// scrollback, and is dropped once all of its lines have left the scrollback.
+type InlineImage struct {
+	ID    int
+	Image *image.NRGBA
+	Col   int // Left column of the image
+	Cols  int // Columns covered, measured in cells when the image arrived
+	Rows  int // Rows covered, measured in cells when the image arrived
+	line  int64
+}
+
+// VisibleImage is an inline image on screen, as returned by GetVisibleImages
+type VisibleImage struct {
+	*InlineImage
+	Row int // Screen row of the image's top edge (negative if scrolled partly off the top)
+}
+
+// SetCellPixelSize tells the buffer how many pixels a cell covers, so new images
+// reserve the right number of rows and columns. Widgets call this when they draw.
+func (b *Buffer) SetCellPixelSize(width, height int) {
+	if width <= 0 || height <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.cellPixelW = width
+	b.cellPixelH = height
+}
+
+// screenLine returns the anchor line number of a screen row
+// Must be called with the lock held.
+func (b *Buffer) screenLine(row int) int64 {
+	return b.linesDropped + int64(len(b.scrollback)) + int64(row)
+}
+
+// AddImage places an image at the cursor and moves the cursor to the start column
+// of the row below it, scrolling if needed. Returns the image ID.
+func (b *Buffer) AddImage(img *image.NRGBA) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	bounds := img.Bounds()
+	cols := (bounds.Dx() + b.cellPixelW - 1) / b.cellPixelW
+	rows := (bounds.Dy() + b.cellPixelH - 1) / b.cellPixelH
+	added := &InlineImage{
+		ID:    b.nextImageID,
+		Image: img,
+		Col:   b.cursorX,
+		Cols:  cols,
+		Rows:  rows,
+		line:  b.screenLine(b.cursorY),
+	}
+	b.nextImageID++
+
+	// An image drawn over others that it covers completely replaces them
+	b.pruneImages()
+	kept := b.images[:0]
+	for _, existing := range b.images {
+		covered := existing.line >= added.line && existing.line+int64(existing.Rows) <= added.line+int64(rows) &&
+			existing.Col >= added.Col && existing.Col+existing.Cols <= added.Col+cols
+		if !covered {
+			kept = append(kept, existing)
+		}
+	}
+	b.images = append(kept, added)
+	if len(b.images) > maxInlineImages {
+		b.images = b.images[len(b.images)-maxInlineImages:]
+	}
+
+	for i := 0; i < rows; i++ {
+		b.indexInternal()
+	}
+	b.cursorX = added.Col
+	b.markDirty()
+	return added.ID
+}
+
+// GetVisibleImages returns the images that are at least partly on screen, oldest first
+func (b *Buffer) GetVisibleImages() []VisibleImage {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	logicalHiddenAbove := 0
+	if effectiveRows := b.EffectiveRows(); effectiveRows > b.rows {
+		logicalHiddenAbove = effectiveRows - b.rows
+	}
+	top := b.linesDropped + int64(len(b.scrollback)+logicalHiddenAbove-b.getEffectiveScrollOffset())
+
+	var visible []VisibleImage
+	for _, img := range b.images {
+		row := int(img.line - top)
+		if row+img.Rows > 0 && row < b.rows {
+			visible = append(visible, VisibleImage{InlineImage: img, Row: row})
+		}
+	}
+	return visible
+}
+
+// DeleteAllImages removes every inline image
+func (b *Buffer) DeleteAllImages() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.images = nil
+	b.markDirty()
+}
+
+// pruneImages drops images whose lines have all left the scrollback
+// Must be called with the lock held.
+func (b *Buffer) pruneImages() {
+	kept := b.images[:0]
+	for _, img := range b.images {
+		if img.line+int64(img.Rows) > b.linesDropped {
+			kept = append(kept, img)
+		}
+	}
+	b.images = kept
+}
+
+// deleteScreenImages drops images anchored on the screen, before it is cleared
+// Must be called with the lock held.
+func (b *Buffer) deleteScreenImages() {
+	screenTop := b.screenLine(0)
+	kept := b.images[:0]
+	for _, img := range b.images {
+		if img.line < screenTop {
+			kept = append(kept, img)
+		}
+	}
+	b.images = kept
+}
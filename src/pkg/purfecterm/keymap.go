@@ -0,0 +1,296 @@
+package purfecterm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Key macros
+// A KeyMap translates keys before they reach the program: a bound key chord sends
+// text of the user's choosing, or runs a callback, instead of its usual sequence.
+// Chords are written as modifiers and a key joined with '+', such as "F5",
+// "Ctrl+Shift+K", "Alt+Up" or "Alt++". The modifiers are Shift, Ctrl (Control),
+// Alt (Option) and Meta (Super, Cmd, Win); the keys are single characters and
+// Enter, Tab, Esc, Space, Backspace, Up, Down, Left, Right, Home, End, PageUp,
+// PageDown, Insert, Delete and F1-F12. A chord is matched by the bytes the widgets
+// send for it, so a binding works the same in every widget.
+
+// KeyMap maps key chords to text or callbacks. The zero value is an empty map.
+type KeyMap struct {
+	mu       sync.Mutex
+	bindings map[string]keyBinding // Keyed by the bytes the chord sends
+}
+
+// keyBinding is what a chord is bound to
+type keyBinding struct {
+	chord string
+	text  []byte
+	fn    func()
+}
+
+// NewKeyMap creates an empty key map
+func NewKeyMap() *KeyMap {
+	return &KeyMap{}
+}
+
+// Bind makes the chord send text instead of its usual sequence
+func (m *KeyMap) Bind(chord, text string) error {
+	return m.bind(chord, keyBinding{text: []byte(text)})
+}
+
+// BindFunc makes the chord run fn instead of sending anything
+// fn is run on a goroutine of its own, so it may take its time.
+func (m *KeyMap) BindFunc(chord string, fn func()) error {
+	return m.bind(chord, keyBinding{fn: fn})
+}
+
+// bind adds a binding for the chord
+func (m *KeyMap) bind(chord string, binding keyBinding) error {
+	seq, err := ParseKeyChord(chord)
+	if err != nil {
+		return err
+	}
+	binding.chord = chord
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.bindings == nil {
+		m.bindings = make(map[string]keyBinding)
+	}
+	m.bindings[string(seq)] = binding
+	return nil
+}
+
+// Unbind removes the chord's binding, returning whether it had one
+func (m *KeyMap) Unbind(chord string) (bool, error) {
+	seq, err := ParseKeyChord(chord)
+	if err != nil {
+		return false, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, bound := m.bindings[string(seq)]
+	delete(m.bindings, string(seq))
+	return bound, nil
+}
+
+// Clear removes all bindings
+func (m *KeyMap) Clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bindings = nil
+}
+
+// Chords returns the bound chords, sorted, as they were given to Bind or BindFunc
+func (m *KeyMap) Chords() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	chords := make([]string, 0, len(m.bindings))
+	for _, binding := range m.bindings {
+		chords = append(chords, binding.chord)
+	}
+	sort.Strings(chords)
+	return chords
+}
+
+// Translate returns what to send for a key whose usual sequence is data: data itself
+// if the key isn't bound, the bound text, or nil if the key is bound to a callback,
+// which is started. A nil KeyMap translates nothing.
+func (m *KeyMap) Translate(data []byte) []byte {
+	if m == nil {
+		return data
+	}
+	m.mu.Lock()
+	binding, bound := m.bindings[string(data)]
+	m.mu.Unlock()
+	if !bound {
+		return data
+	}
+	if binding.fn != nil {
+		go binding.fn()
+		return nil
+	}
+	return binding.text
+}
+
+// Keys with their own sequences, by lowercase name
+var (
+	keymapCursorKeys = map[string]byte{
+		"up": 'A', "down": 'B', "right": 'C', "left": 'D', "home": 'H', "end": 'F',
+	}
+	keymapTildeKeys = map[string]int{
+		"insert": 2, "ins": 2, "delete": 3, "del": 3,
+		"pageup": 5, "pgup": 5, "pagedown": 6, "pgdn": 6,
+		"f5": 15, "f6": 17, "f7": 18, "f8": 19, "f9": 20, "f10": 21, "f11": 23, "f12": 24,
+	}
+	keymapFunctionKeys = map[string]byte{"f1": 'P', "f2": 'Q', "f3": 'R', "f4": 'S'}
+)
+
+// ParseKeyChord returns the bytes the widgets send for a key chord such as
+// "Ctrl+Shift+K" (see the Key macros comment above)
+func ParseKeyChord(chord string) ([]byte, error) {
+	parts := strings.Split(chord, "+")
+	if strings.HasSuffix(chord, "++") {
+		// The '+' key itself
+		parts = append(parts[:len(parts)-2], "+")
+	}
+	name := parts[len(parts)-1]
+	if name == "" {
+		return nil, fmt.Errorf("key chord %q has no key", chord)
+	}
+
+	var shift, ctrl, alt, meta bool
+	for _, part := range parts[:len(parts)-1] {
+		switch strings.ToLower(strings.TrimSpace(part)) {
+		case "shift":
+			shift = true
+		case "ctrl", "control":
+			ctrl = true
+		case "alt", "option", "opt":
+			alt = true
+		case "meta", "super", "cmd", "command", "win":
+			meta = true
+		default:
+			return nil, fmt.Errorf("unknown modifier %q in key chord %q", part, chord)
+		}
+	}
+
+	// xterm-style modifier parameter, as the widgets compute it
+	mod := 1
+	if shift {
+		mod += 1
+	}
+	if alt {
+		mod += 2
+	}
+	if ctrl {
+		mod += 4
+	}
+	if meta {
+		mod += 8
+	}
+	hasModifiers := mod > 1
+	kitty := func(code int) []byte {
+		return []byte(fmt.Sprintf("\x1b[%d;%du", code, mod))
+	}
+
+	key := strings.ToLower(strings.TrimSpace(name))
+	if final, ok := keymapCursorKeys[key]; ok {
+		if hasModifiers {
+			return []byte(fmt.Sprintf("\x1b[1;%d%c", mod, final)), nil
+		}
+		return []byte{0x1b, '[', final}, nil
+	}
+	if code, ok := keymapTildeKeys[key]; ok {
+		if hasModifiers {
+			return []byte(fmt.Sprintf("\x1b[%d;%d~", code, mod)), nil
+		}
+		return []byte(fmt.Sprintf("\x1b[%d~", code)), nil
+	}
+	if final, ok := keymapFunctionKeys[key]; ok {
+		if hasModifiers {
+			return []byte(fmt.Sprintf("\x1b[1;%d%c", mod, final)), nil
+		}
+		return []byte{0x1b, 'O', final}, nil
+	}
+
+	switch key {
+	case "enter", "return":
+		if hasModifiers {
+			return kitty(13), nil
+		}
+		return []byte{'\r'}, nil
+	case "tab":
+		if alt || meta {
+			return kitty(9), nil
+		}
+		if hasModifiers {
+			// Ctrl+Tab and Shift+Tab move the focus instead
+			return nil, fmt.Errorf("key chord %q is used for focus navigation", chord)
+		}
+		return []byte{'\t'}, nil
+	case "esc", "escape":
+		if hasModifiers {
+			return kitty(27), nil
+		}
+		return []byte{0x1b}, nil
+	case "space":
+		if ctrl && mod == 5 {
+			return []byte{0x00}, nil
+		}
+		if hasModifiers {
+			return kitty(32), nil
+		}
+		return []byte{' '}, nil
+	case "backspace", "bs":
+		if ctrl {
+			return []byte{0x08}, nil
+		}
+		if alt {
+			return []byte{0x1b, 0x7f}, nil
+		}
+		return []byte{0x7f}, nil
+	}
+
+	runes := []rune(name)
+	if len(runes) != 1 {
+		return nil, fmt.Errorf("unknown key %q in key chord %q", name, chord)
+	}
+	return chordCharacter(runes[0], shift, ctrl, alt, meta, mod, chord)
+}
+
+// chordCharacter returns the bytes sent for a character key with modifiers
+func chordCharacter(r rune, shift, ctrl, alt, meta bool, mod int, chord string) ([]byte, error) {
+	kitty := func(base rune) []byte {
+		return []byte(fmt.Sprintf("\x1b[%d;%du", base, mod))
+	}
+	multiMod := meta || (ctrl && shift) || (ctrl && alt) || (alt && shift)
+
+	switch {
+	case r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z':
+		lower := r | 0x20
+		switch {
+		case multiMod:
+			return kitty(lower), nil
+		case ctrl:
+			return []byte{byte(lower - 'a' + 1)}, nil
+		case alt:
+			return []byte{0x1b, byte(lower)}, nil
+		case shift:
+			return []byte{byte(lower - 32)}, nil
+		}
+		return []byte{byte(r)}, nil
+
+	case r >= '0' && r <= '9':
+		if ctrl && mod == 5 {
+			// The historic control characters of Ctrl+2 to Ctrl+8
+			switch r {
+			case '2':
+				return []byte{0x00}, nil
+			case '3':
+				return []byte{0x1b}, nil
+			case '4', '5', '6', '7':
+				return []byte{byte(0x1c + r - '4')}, nil
+			case '8':
+				return []byte{0x7f}, nil
+			}
+		}
+		if ctrl || alt || multiMod {
+			return kitty(r), nil
+		}
+
+	case strings.ContainsRune("`,./;'[]\\-=", r):
+		if ctrl || alt || multiMod {
+			return kitty(r), nil
+		}
+	}
+
+	switch {
+	case ctrl || meta || shift:
+		return nil, fmt.Errorf("key chord %q doesn't match a key the terminal sends", chord)
+	case alt:
+		return append([]byte{0x1b}, string(r)...), nil
+	}
+	return []byte(string(r)), nil
+}
@@ -0,0 +1,68 @@
+package purfecterm
+
+// Ligatures
+// Programming fonts join some character sequences (such as "=>", "!=" and "->")
+// into one glyph, which only happens when the sequence is shaped together rather
+// than drawn a cell at a time. With ColorScheme.Ligatures set the widgets find runs
+// of cells that may join and draw each run's text in one go, fitted to the run's
+// cells, so Pango or Qt shape it with HarfBuzz. Runs are printable ASCII other than
+// spaces (ligatures never span a space), drawn alike; the widgets decide what drawn
+// alike means, as they resolve colors differently. Everything else in the cells
+// (backgrounds, underlines, the cursor) is still drawn a cell at a time.
+
+// LigatureRun is a run of cells whose text is drawn together: cells Start up to
+// but not including End
+type LigatureRun struct {
+	Start, End int
+	Text       string
+}
+
+// ligatureText reports whether a cell's text may be part of a ligature run
+func ligatureText(text string) bool {
+	return len(text) == 1 && text[0] > ' ' && text[0] < 0x7f
+}
+
+// FindLigatureRuns returns the runs of at least two cells whose text may join,
+// given each cell's text (its character and combining marks, or "" for none) and
+// joinable(a, b), which reports whether neighboring cells a and b are drawn alike
+func FindLigatureRuns(texts []string, joinable func(a, b int) bool) []LigatureRun {
+	var runs []LigatureRun
+	start := -1
+	flush := func(end int) {
+		if start >= 0 && end-start >= 2 {
+			var text []byte
+			for _, t := range texts[start:end] {
+				text = append(text, t...)
+			}
+			runs = append(runs, LigatureRun{Start: start, End: end, Text: string(text)})
+		}
+		start = -1
+	}
+	for i, text := range texts {
+		if !ligatureText(text) {
+			flush(i)
+			continue
+		}
+		if start >= 0 && !joinable(i-1, i) {
+			flush(i)
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	flush(len(texts))
+	return runs
+}
+
+// LigatureRunAt returns the index of the run holding cell x, or -1
+func LigatureRunAt(runs []LigatureRun, x int) int {
+	for i, run := range runs {
+		if x < run.Start {
+			break
+		}
+		if x < run.End {
+			return i
+		}
+	}
+	return -1
+}
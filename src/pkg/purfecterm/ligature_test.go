@@ -0,0 +1,47 @@
+package purfecterm
+
+import "testing"
+
+func TestFindLigatureRuns(t *testing.T) {
+	texts := []string{"a", "=", ">", "", "!", "=", " ", "x", "é", "-", ">", "b"}
+	styles := []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 1}
+	runs := FindLigatureRuns(texts, func(a, b int) bool { return styles[a] == styles[b] })
+	want := []LigatureRun{
+		{Start: 0, End: 3, Text: "a=>"},
+		{Start: 4, End: 6, Text: "!="},
+		{Start: 10, End: 12, Text: ">b"},
+	}
+	if len(runs) != len(want) {
+		t.Fatalf("got %+v, want %+v", runs, want)
+	}
+	for i := range want {
+		if runs[i] != want[i] {
+			t.Errorf("run %d: got %+v, want %+v", i, runs[i], want[i])
+		}
+	}
+
+	if i := LigatureRunAt(runs, 5); i != 1 {
+		t.Errorf("run at 5: got %d, want 1", i)
+	}
+	if i := LigatureRunAt(runs, 3); i != -1 {
+		t.Errorf("run at 3: got %d, want -1", i)
+	}
+}
+
+func TestGridFrameLigatureRuns(t *testing.T) {
+	b := newGridTestBuffer("a=>b \x1b[1m!=\x1b[m=\x1b[2;1H")
+	scheme := DefaultColorScheme()
+	f, ok := BuildGridFrame(b, GridFrameOptions{Scheme: scheme})
+	if !ok {
+		t.Fatal("plain screen not built")
+	}
+	runs := f.Runs[0]
+	if len(runs) != 2 || runs[0].Text != "a=>b" || runs[1].Text != "!=" || runs[1].End != 7 {
+		t.Errorf("runs split by style: got %+v", runs)
+	}
+
+	scheme.Ligatures = false
+	if f, _ := BuildGridFrame(b, GridFrameOptions{Scheme: scheme}); f.Runs != nil {
+		t.Errorf("runs without ligatures: got %+v", f.Runs)
+	}
+}
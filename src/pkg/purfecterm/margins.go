@@ -0,0 +1,348 @@
+package purfecterm
+
+// Margins and origin mode
+//   ESC [ top ; bottom r   DECSTBM: scroll region rows (1-based, omitted = screen edge)
+//   ESC [ ? 69 h           DECLRMM: allow left and right margins
+//   ESC [ left ; right s   DECSLRM: left and right margins, while DECLRMM is set
+//                          (otherwise ESC [ s saves the cursor)
+//   ESC [ ? 6 h            DECOM: cursor positions are relative to the margins, and
+//                          the cursor can't leave them
+// Line feeds, IND and RI at a margin scroll only the region between the margins, as do
+// SU, SD, IL and DL. Only a scroll of the whole screen saves lines to the scrollback.
+// Text written inside left and right margins wraps from the right margin to the left.
+// Setting either kind of margin, or switching origin mode, homes the cursor.
+
+// SetScrollMargins sets the top and bottom margins (DECSTBM), as 1-based rows
+// 0 means the screen edge. A region of less than two rows is ignored.
+func (b *Buffer) SetScrollMargins(top, bottom int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	rows := b.EffectiveRows()
+	if top < 1 {
+		top = 1
+	}
+	if bottom < 1 || bottom > rows {
+		bottom = rows
+	}
+	if top >= bottom {
+		return
+	}
+	b.marginTop = top - 1
+	b.marginBottom = bottom
+	if bottom == rows {
+		b.marginBottom = 0 // Follows the screen when it is resized
+	}
+	b.homeCursor()
+}
+
+// SetHorizontalMargins sets the left and right margins (DECSLRM), as 1-based columns
+// 0 means the screen edge. It does nothing unless left/right margin mode is set.
+func (b *Buffer) SetHorizontalMargins(left, right int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.leftRightMarginMode {
+		return
+	}
+	cols := b.EffectiveCols()
+	if left < 1 {
+		left = 1
+	}
+	if right < 1 || right > cols {
+		right = cols
+	}
+	if left >= right {
+		return
+	}
+	b.marginLeft = left - 1
+	b.marginRight = right
+	if right == cols {
+		b.marginRight = 0
+	}
+	b.homeCursor()
+}
+
+// SetLeftRightMarginMode enables or disables left and right margins (DECLRMM)
+// Disabling it clears the margins.
+func (b *Buffer) SetLeftRightMarginMode(enabled bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.leftRightMarginMode = enabled
+	if !enabled {
+		b.marginLeft = 0
+		b.marginRight = 0
+	}
+}
+
+// IsLeftRightMarginModeEnabled returns whether left and right margins are allowed
+func (b *Buffer) IsLeftRightMarginModeEnabled() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.leftRightMarginMode
+}
+
+// SetOriginMode enables or disables origin mode (DECOM), and homes the cursor
+func (b *Buffer) SetOriginMode(enabled bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.originMode = enabled
+	b.homeCursor()
+}
+
+// IsOriginModeEnabled returns whether cursor positions are relative to the margins
+func (b *Buffer) IsOriginModeEnabled() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.originMode
+}
+
+// SetNoClearOnColumnChange sets whether DECCOLM keeps the screen (DECNCSM)
+func (b *Buffer) SetNoClearOnColumnChange(enabled bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.noClearOnColumnChange = enabled
+}
+
+// SelectColumnMode132 switches between 80 and 132 columns as DECCOLM does
+// Along with the 132-column scale (see Set132ColumnMode), it clears the margins and
+// homes the cursor, and clears the screen unless DECNCSM is set.
+func (b *Buffer) SelectColumnMode132(enabled bool) {
+	b.mu.Lock()
+	b.columnMode132 = enabled
+	b.marginTop, b.marginBottom = 0, 0
+	b.marginLeft, b.marginRight = 0, 0
+	b.homeCursor()
+	clearScreen := !b.noClearOnColumnChange
+	b.mu.Unlock()
+	if clearScreen {
+		b.ClearScreen()
+	}
+	b.notifyScaleChange()
+}
+
+// MoveCursorTo moves the cursor to a 0-based position, as CUP does
+// In origin mode the position is relative to the margins and kept inside them.
+func (b *Buffer) MoveCursorTo(x, y int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.moveCursorToInternal(x, y)
+}
+
+// MoveCursorToColumn moves the cursor to a 0-based column on its row, as CHA does
+func (b *Buffer) MoveCursorToColumn(x int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.moveCursorToInternal(x, b.originRow(b.cursorY))
+}
+
+// MoveCursorToRow moves the cursor to a 0-based row in its column, as VPA does
+func (b *Buffer) MoveCursorToRow(y int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	x := b.cursorX
+	if b.originMode {
+		left, _ := b.horizontalMargins()
+		x -= left
+	}
+	b.moveCursorToInternal(x, y)
+}
+
+// Index moves the cursor down a row, scrolling at the bottom margin (IND)
+func (b *Buffer) Index() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.indexInternal()
+	b.markDirty()
+}
+
+// ReverseIndex moves the cursor up a row, scrolling down at the top margin (RI)
+func (b *Buffer) ReverseIndex() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	top, bottom := b.scrollRegion()
+	if b.cursorY == top {
+		b.scrollRegionDown(top, bottom, 1)
+	} else if b.cursorY > 0 {
+		b.trackCursorYMove(b.cursorY - 1)
+		b.cursorY--
+	}
+	b.markDirty()
+}
+
+// scrollRegion returns the first and last rows of the scroll region
+// Must be called with the lock held.
+func (b *Buffer) scrollRegion() (top, bottom int) {
+	rows := b.EffectiveRows()
+	bottom = rows - 1
+	if b.marginBottom > 0 && b.marginBottom <= rows {
+		bottom = b.marginBottom - 1
+	}
+	if b.marginTop >= bottom {
+		return 0, rows - 1 // Left over from a taller screen
+	}
+	return b.marginTop, bottom
+}
+
+// horizontalMargins returns the first and last columns inside the left and right margins
+// Must be called with the lock held.
+func (b *Buffer) horizontalMargins() (left, right int) {
+	cols := b.EffectiveCols()
+	right = cols - 1
+	if !b.leftRightMarginMode {
+		return 0, right
+	}
+	if b.marginRight > 0 && b.marginRight <= cols {
+		right = b.marginRight - 1
+	}
+	if b.marginLeft >= right {
+		return 0, cols - 1
+	}
+	return b.marginLeft, right
+}
+
+// hasHorizontalMargins returns whether left or right margins narrow the screen
+// Must be called with the lock held.
+func (b *Buffer) hasHorizontalMargins() bool {
+	left, right := b.horizontalMargins()
+	return left > 0 || right < b.EffectiveCols()-1
+}
+
+// isFullScrollRegion returns whether scrolling moves the whole screen, and so
+// saves lines to the scrollback
+// Must be called with the lock held.
+func (b *Buffer) isFullScrollRegion() bool {
+	top, bottom := b.scrollRegion()
+	return top == 0 && bottom == b.EffectiveRows()-1 && !b.hasHorizontalMargins()
+}
+
+// cursorInMargins returns whether the cursor is between rows top and bottom and
+// inside the left and right margins
+// Must be called with the lock held.
+func (b *Buffer) cursorInMargins(top, bottom int) bool {
+	left, right := b.horizontalMargins()
+	return b.cursorY >= top && b.cursorY <= bottom && b.cursorX >= left && b.cursorX <= right
+}
+
+// homeCursor moves the cursor to the top left, of the margins in origin mode
+// Must be called with the lock held.
+func (b *Buffer) homeCursor() {
+	b.moveCursorToInternal(0, 0)
+}
+
+// originRow returns the row as a position for moveCursorToInternal
+// Must be called with the lock held.
+func (b *Buffer) originRow(y int) int {
+	if b.originMode {
+		top, _ := b.scrollRegion()
+		return y - top
+	}
+	return y
+}
+
+// moveCursorToInternal moves the cursor as MoveCursorTo does
+// Must be called with the lock held.
+func (b *Buffer) moveCursorToInternal(x, y int) {
+	if b.originMode {
+		top, bottom := b.scrollRegion()
+		left, right := b.horizontalMargins()
+		x = min(max(x+left, left), right)
+		y = min(max(y+top, top), bottom)
+	}
+	b.setCursorInternal(x, y)
+}
+
+// indexInternal moves the cursor down a row, scrolling the scroll region up when the
+// cursor is on its bottom margin, or the screen when it is on the bottom row
+// Must be called with the lock held.
+func (b *Buffer) indexInternal() {
+	effectiveRows := b.EffectiveRows()
+	b.trackCursorYMove(b.cursorY + 1)
+	if !b.isFullScrollRegion() {
+		top, bottom := b.scrollRegion()
+		switch {
+		case b.cursorY == bottom:
+			b.scrollRegionUp(top, bottom, 1)
+		case b.cursorY < effectiveRows-1:
+			b.cursorY++
+		}
+		return
+	}
+	b.cursorY++
+	if b.cursorY >= effectiveRows {
+		b.scrollUpInternal()
+		b.cursorY = effectiveRows - 1
+	}
+}
+
+// scrollRegionUp scrolls rows top to bottom up n rows, saving lines to the
+// scrollback if the region is the whole screen
+// Must be called with the lock held.
+func (b *Buffer) scrollRegionUp(top, bottom, n int) {
+	if top == 0 && b.isFullScrollRegion() {
+		n = min(n, len(b.screen)) // Scrolling further only adds blank lines
+		for i := 0; i < n; i++ {
+			b.scrollUpInternal()
+		}
+		return
+	}
+	b.scrollRect(top, bottom, n)
+}
+
+// scrollRegionDown scrolls rows top to bottom down n rows
+// Must be called with the lock held.
+func (b *Buffer) scrollRegionDown(top, bottom, n int) {
+	b.scrollRect(top, bottom, -n)
+}
+
+// scrollRect moves rows top to bottom up n rows (down if n is negative) between the
+// left and right margins, blanking the rows it uncovers
+// Must be called with the lock held.
+func (b *Buffer) scrollRect(top, bottom, n int) {
+	for len(b.screen) <= bottom {
+		b.screen = append(b.screen, b.makeEmptyLine())
+		b.lineInfos = append(b.lineInfos, b.makeDefaultLineInfo())
+	}
+	height := bottom - top + 1
+	n = max(-height, min(n, height))
+
+	if !b.hasHorizontalMargins() {
+		// Whole rows move, with their line attributes
+		if n > 0 {
+			copy(b.screen[top:], b.screen[top+n:bottom+1])
+			copy(b.lineInfos[top:], b.lineInfos[top+n:bottom+1])
+			for y := bottom + 1 - n; y <= bottom; y++ {
+				b.screen[y] = b.makeEmptyLine()
+				b.lineInfos[y] = b.makeDefaultLineInfo()
+			}
+		} else if n < 0 {
+			copy(b.screen[top-n:bottom+1], b.screen[top:bottom+1+n])
+			copy(b.lineInfos[top-n:bottom+1], b.lineInfos[top:bottom+1+n])
+			for y := top; y < top-n; y++ {
+				b.screen[y] = b.makeEmptyLine()
+				b.lineInfos[y] = b.makeDefaultLineInfo()
+			}
+		}
+		b.markDirty()
+		return
+	}
+
+	// Only the cells between the margins move
+	left, right := b.horizontalMargins()
+	width := right - left + 1
+	segments := make([][]Cell, height)
+	for i := range segments {
+		b.ensureLineLength(top+i, right+1)
+		segments[i] = append([]Cell(nil), b.screen[top+i][left:right+1]...)
+	}
+	blank := make([]Cell, width)
+	for i := range blank {
+		blank[i] = b.currentDefaultCell()
+	}
+	for i := range height {
+		segment := blank
+		if src := i + n; src >= 0 && src < height {
+			segment = segments[src]
+		}
+		copy(b.screen[top+i][left:], segment)
+	}
+	b.markDirty()
+}
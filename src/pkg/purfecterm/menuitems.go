@@ -0,0 +1,70 @@
+package purfecterm
+
+import "sync"
+
+// Context menu items
+// Embedders add entries of their own to a terminal's context menu, such as the
+// launcher's "Run Selection as PawScript" or those a script adds, without changing
+// how the widgets build it. The widgets show them after their own entries, in the
+// order they were added, each greyed out while its Enabled predicate says so.
+
+// MenuItem is an entry added to the context menu
+type MenuItem struct {
+	Label    string
+	Enabled  func() bool // Asked each time the menu opens; nil for always enabled
+	Activate func()      // Run on the UI thread when the entry is chosen
+}
+
+// IsEnabled returns whether the entry can be chosen now
+func (m MenuItem) IsEnabled() bool {
+	return m.Enabled == nil || m.Enabled()
+}
+
+// MenuItems is the entries added to a terminal's context menu. The zero value
+// has none.
+type MenuItems struct {
+	mu    sync.Mutex
+	items []MenuItem
+}
+
+// NewMenuItems creates an empty list of entries
+func NewMenuItems() *MenuItems {
+	return &MenuItems{}
+}
+
+// Add adds an entry after the others, or replaces the entry with its label where
+// it is
+func (m *MenuItems) Add(item MenuItem) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.items {
+		if m.items[i].Label == item.Label {
+			m.items[i] = item
+			return
+		}
+	}
+	m.items = append(m.items, item)
+}
+
+// Remove removes the entry with the label, returning whether there was one
+func (m *MenuItems) Remove(label string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.items {
+		if m.items[i].Label == label {
+			m.items = append(m.items[:i], m.items[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Items returns the entries in order (none for a nil list)
+func (m *MenuItems) Items() []MenuItem {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]MenuItem(nil), m.items...)
+}
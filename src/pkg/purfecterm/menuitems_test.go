@@ -0,0 +1,39 @@
+package purfecterm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMenuItems(t *testing.T) {
+	labels := func(m *MenuItems) []string {
+		var out []string
+		for _, item := range m.Items() {
+			out = append(out, item.Label)
+		}
+		return out
+	}
+
+	m := NewMenuItems()
+	m.Add(MenuItem{Label: "Run"})
+	m.Add(MenuItem{Label: "Open"})
+	m.Add(MenuItem{Label: "Run", Enabled: func() bool { return false }})
+	if got, want := labels(m), []string{"Run", "Open"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("labels = %v, want %v", got, want)
+	}
+	if items := m.Items(); items[0].IsEnabled() || !items[1].IsEnabled() {
+		t.Errorf("IsEnabled = %v, %v, want false, true", items[0].IsEnabled(), items[1].IsEnabled())
+	}
+
+	if !m.Remove("Run") || m.Remove("Run") {
+		t.Error("Remove(\"Run\") should remove the entry once")
+	}
+	if got, want := labels(m), []string{"Open"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("labels after Remove = %v, want %v", got, want)
+	}
+
+	var none *MenuItems
+	if items := none.Items(); items != nil {
+		t.Errorf("nil Items() = %v, want nil", items)
+	}
+}
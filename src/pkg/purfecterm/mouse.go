@@ -0,0 +1,142 @@
+package purfecterm
+
+import (
+	"fmt"
+)
+
+// Mouse reporting
+// An application turns on mouse tracking with a private mode, and the widget then
+// sends mouse events as input instead of using them for selection:
+//   ESC [ ? 1000 h   report button presses and releases, and the wheel
+//   ESC [ ? 1002 h   also report motion while a button is held
+//   ESC [ ? 1003 h   also report motion with no button held
+//   ESC [ ? 1006 h   use SGR encoding: ESC [ < Cb ; Cx ; Cy M (press) or m (release)
+// Without 1006, events use the legacy encoding ESC [ M Cb Cx Cy, with each value
+// offset by 32 in a single byte, which can't report columns or rows past 223.
+// Cb is the button (0-2 left/middle/right, 3 release or no button, 64-67 wheel up,
+// down, left, right) plus 4 for Shift, 8 for Alt and 16 for Ctrl, plus 32 for motion.
+// Cx and Cy are 1-based screen positions. Resetting any tracking mode turns tracking off.
+
+// MouseTracking selects which mouse events are reported
+type MouseTracking int
+
+const (
+	MouseTrackingOff    MouseTracking = iota
+	MouseTrackingClick                // 1000: presses, releases and wheel
+	MouseTrackingDrag                 // 1002: also motion with a button held
+	MouseTrackingMotion               // 1003: also motion with no button held
+)
+
+// MouseAction is what happened in a MouseEvent
+type MouseAction int
+
+const (
+	MousePress MouseAction = iota
+	MouseRelease
+	MouseMove
+)
+
+// Mouse buttons, as reported in Cb
+const (
+	MouseButtonLeft   = 0
+	MouseButtonMiddle = 1
+	MouseButtonRight  = 2
+	MouseButtonNone   = 3 // Motion with no button held
+	MouseWheelUp      = 64
+	MouseWheelDown    = 65
+	MouseWheelLeft    = 66
+	MouseWheelRight   = 67
+)
+
+// Modifier bits, as reported in Cb
+const (
+	MouseModShift = 4
+	MouseModAlt   = 8
+	MouseModCtrl  = 16
+)
+
+// mouseMotionBit marks a motion event in Cb
+const mouseMotionBit = 32
+
+// maxLegacyMousePos is the largest position the legacy encoding can carry
+const maxLegacyMousePos = 255 - 32
+
+// MouseEvent is a mouse event to report to the application
+type MouseEvent struct {
+	Action MouseAction
+	Button int // MouseButton* or MouseWheel* (wheel events are presses)
+	Mods   int // MouseMod* bits
+	Col    int // 0-based screen column
+	Row    int // 0-based screen row
+}
+
+// SetMouseTracking sets which mouse events are reported
+func (b *Buffer) SetMouseTracking(mode MouseTracking) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.mouseTracking = mode
+}
+
+// GetMouseTracking returns which mouse events are reported
+func (b *Buffer) GetMouseTracking() MouseTracking {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.mouseTracking
+}
+
+// SetMouseSGRMode enables or disables SGR mouse encoding (mode 1006)
+func (b *Buffer) SetMouseSGRMode(enabled bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.mouseSGR = enabled
+}
+
+// IsMouseSGRModeEnabled returns whether SGR mouse encoding is enabled
+func (b *Buffer) IsMouseSGRModeEnabled() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.mouseSGR
+}
+
+// EncodeMouseEvent returns the input that reports a mouse event, or nil if the
+// current tracking mode doesn't report it (or the legacy encoding can't)
+func (b *Buffer) EncodeMouseEvent(event MouseEvent) []byte {
+	b.mu.RLock()
+	mode := b.mouseTracking
+	sgr := b.mouseSGR
+	b.mu.RUnlock()
+
+	code := event.Button
+	switch event.Action {
+	case MousePress:
+		if mode == MouseTrackingOff {
+			return nil
+		}
+	case MouseRelease:
+		if mode == MouseTrackingOff || event.Button >= MouseWheelUp {
+			return nil
+		}
+		if !sgr {
+			code = MouseButtonNone // The legacy encoding doesn't say which button was released
+		}
+	case MouseMove:
+		if mode < MouseTrackingDrag || (event.Button == MouseButtonNone && mode < MouseTrackingMotion) {
+			return nil
+		}
+		code |= mouseMotionBit
+	}
+	code |= event.Mods & (MouseModShift | MouseModAlt | MouseModCtrl)
+
+	col, row := event.Col+1, event.Row+1
+	if sgr {
+		final := 'M'
+		if event.Action == MouseRelease {
+			final = 'm'
+		}
+		return []byte(fmt.Sprintf("\x1b[<%d;%d;%d%c", code, col, row, final))
+	}
+	if col < 1 || row < 1 || col > maxLegacyMousePos || row > maxLegacyMousePos {
+		return nil
+	}
+	return []byte{0x1b, '[', 'M', byte(32 + code), byte(32 + col), byte(32 + row)}
+}
@@ -0,0 +1,279 @@
+package purfecterm
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Panes
+//   ESC ] 7005 ; s ; ID ; FROM ; DIR ; SIZE BEL   split pane FROM, giving new pane ID
+//                                                  SIZE percent of it (default 50), to
+//                                                  the right (DIR h, the default) or below (v)
+//   ESC ] 7005 ; r ; ID ; SIZE BEL                 resize pane ID to SIZE percent of its split
+//   ESC ] 7005 ; c ; ID BEL                        close pane ID (its neighbour takes its space)
+//   ESC ] 7005 ; f ; ID BEL                        focus pane ID
+//   ESC ] 7005 ; w ; ID BEL                        send the output that follows to pane ID
+// A terminal's own buffer is pane 0. Splitting gives each new pane a Buffer of its own,
+// with its own cursor, modes and scrollback, so one program can lay out a dashboard in
+// a single window. The layout is a tree of splits kept by the pane 0 buffer; the host
+// shows a view for each pane (see PaneLayout), sends the input of the focused pane to
+// the program, and tells the buffer when the user focuses a pane (FocusPane).
+
+// PaneDirection is how a split arranges its two halves
+type PaneDirection int
+
+const (
+	PaneSideBySide PaneDirection = iota // The new pane goes to the right
+	PaneStacked                         // The new pane goes below
+)
+
+// Limits of a pane's share of its split, in percent
+const (
+	minPaneSize = 5
+	maxPaneSize = 95
+)
+
+// PaneNode is a node of the pane layout: a pane, or a split of two nodes
+type PaneNode struct {
+	// Panes
+	ID     int
+	Buffer *Buffer
+
+	// Splits
+	Direction PaneDirection
+	Ratio     float64 // Share of the split's space given to First (0-1)
+	First     *PaneNode
+	Second    *PaneNode
+}
+
+// IsSplit returns whether the node is a split rather than a pane
+func (n *PaneNode) IsSplit() bool {
+	return n.First != nil
+}
+
+// find returns the pane node with the ID and its parent split (nil for the root)
+func (n *PaneNode) find(id int, parent *PaneNode) (node, nodeParent *PaneNode) {
+	if !n.IsSplit() {
+		if n.ID == id {
+			return n, parent
+		}
+		return nil, nil
+	}
+	if node, nodeParent = n.First.find(id, n); node != nil {
+		return node, nodeParent
+	}
+	return n.Second.find(id, n)
+}
+
+// clone returns a copy of the tree
+func (n *PaneNode) clone() *PaneNode {
+	c := *n
+	if n.IsSplit() {
+		c.First = n.First.clone()
+		c.Second = n.Second.clone()
+	}
+	return &c
+}
+
+// SetPaneCallback sets a callback to be invoked when panes are split, resized, closed
+// or focused; layoutChanged is false when only the focus moved
+// It is called from whatever goroutine feeds the parser, without the lock held.
+func (b *Buffer) SetPaneCallback(fn func(layoutChanged bool)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onPaneChange = fn
+}
+
+// notifyPaneChange passes a pane change to the pane callback
+func (b *Buffer) notifyPaneChange(layoutChanged bool) {
+	b.mu.RLock()
+	onPaneChange := b.onPaneChange
+	b.mu.RUnlock()
+	if onPaneChange != nil {
+		onPaneChange(layoutChanged)
+	}
+}
+
+// SplitPane splits pane from, giving size percent of its space to a new pane with
+// the given ID. Returns false if from doesn't exist or id is taken.
+func (b *Buffer) SplitPane(from, id int, dir PaneDirection, size int) bool {
+	b.mu.Lock()
+	if b.panes == nil {
+		b.panes = &PaneNode{ID: 0, Buffer: b}
+	}
+	node, _ := b.panes.find(from, nil)
+	if existing, _ := b.panes.find(id, nil); node == nil || existing != nil || id < 0 {
+		b.mu.Unlock()
+		return false
+	}
+
+	size = min(max(size, minPaneSize), maxPaneSize)
+	cols, rows := b.cols, b.rows
+	if node.Buffer != b {
+		cols, rows = node.Buffer.GetSize()
+	}
+	if dir == PaneStacked {
+		rows = max(rows*size/100, 1)
+	} else {
+		cols = max(cols*size/100, 1)
+	}
+	pane := &PaneNode{ID: id, Buffer: NewBuffer(cols, rows, b.maxScrollback)}
+	pane.Buffer.darkTheme = b.darkTheme
+	pane.Buffer.preferredDarkTheme = b.preferredDarkTheme
+
+	*node = PaneNode{
+		Direction: dir,
+		Ratio:     float64(100-size) / 100,
+		First:     &PaneNode{ID: node.ID, Buffer: node.Buffer},
+		Second:    pane,
+	}
+	b.mu.Unlock()
+
+	b.notifyPaneChange(true)
+	return true
+}
+
+// ResizePane sets pane id's share of the split it is in, in percent
+// Returns false if the pane isn't in a split.
+func (b *Buffer) ResizePane(id, size int) bool {
+	b.mu.Lock()
+	var parent *PaneNode
+	if b.panes != nil {
+		_, parent = b.panes.find(id, nil)
+	}
+	if parent == nil {
+		b.mu.Unlock()
+		return false
+	}
+	ratio := float64(min(max(size, minPaneSize), maxPaneSize)) / 100
+	if parent.First.IsSplit() || parent.First.ID != id {
+		ratio = 1 - ratio
+	}
+	parent.Ratio = ratio
+	b.mu.Unlock()
+
+	b.notifyPaneChange(true)
+	return true
+}
+
+// ClosePane closes pane id, whose neighbour takes its space
+// Returns false for pane 0, which can't be closed, and panes that don't exist.
+func (b *Buffer) ClosePane(id int) bool {
+	b.mu.Lock()
+	var parent *PaneNode
+	if b.panes != nil && id != 0 {
+		_, parent = b.panes.find(id, nil)
+	}
+	if parent == nil {
+		b.mu.Unlock()
+		return false
+	}
+	if !parent.First.IsSplit() && parent.First.ID == id {
+		*parent = *parent.Second
+	} else {
+		*parent = *parent.First
+	}
+	if !b.panes.IsSplit() {
+		b.panes = nil
+	}
+	if b.focusedPane == id {
+		b.focusedPane = 0
+	}
+	b.mu.Unlock()
+
+	b.notifyPaneChange(true)
+	return true
+}
+
+// FocusPane makes pane id the focused pane
+// Returns false if the pane doesn't exist.
+func (b *Buffer) FocusPane(id int) bool {
+	b.mu.Lock()
+	if b.paneBufferInternal(id) == nil {
+		b.mu.Unlock()
+		return false
+	}
+	changed := b.focusedPane != id
+	b.focusedPane = id
+	b.mu.Unlock()
+
+	if changed {
+		b.notifyPaneChange(false)
+	}
+	return true
+}
+
+// FocusedPane returns the ID of the focused pane
+func (b *Buffer) FocusedPane() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.focusedPane
+}
+
+// PaneBuffer returns the buffer of pane id, or nil if there is no such pane
+func (b *Buffer) PaneBuffer(id int) *Buffer {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.paneBufferInternal(id)
+}
+
+// paneBufferInternal returns the buffer of pane id, or nil if there is no such pane
+// Must be called with the lock held (a read lock is enough).
+func (b *Buffer) paneBufferInternal(id int) *Buffer {
+	if id == 0 {
+		return b
+	}
+	if b.panes == nil {
+		return nil
+	}
+	if node, _ := b.panes.find(id, nil); node != nil {
+		return node.Buffer
+	}
+	return nil
+}
+
+// PaneLayout returns a copy of the pane layout, or nil if the terminal isn't split
+func (b *Buffer) PaneLayout() *PaneNode {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.panes == nil {
+		return nil
+	}
+	return b.panes.clone()
+}
+
+// executeOSCPanes handles OSC 7005 pane commands
+func (p *Parser) executeOSCPanes(args string) {
+	fields := strings.Split(args, ";")
+	param := func(i, defaultVal int) int {
+		if i < len(fields) && fields[i] != "" {
+			if n, err := strconv.Atoi(fields[i]); err == nil {
+				return n
+			}
+		}
+		return defaultVal
+	}
+
+	id := param(1, -1)
+	switch fields[0] {
+	case "s":
+		dir := PaneSideBySide
+		if len(fields) > 3 && fields[3] == "v" {
+			dir = PaneStacked
+		}
+		p.root.SplitPane(param(2, 0), id, dir, param(4, 50))
+	case "r":
+		p.root.ResizePane(id, param(2, 50))
+	case "c":
+		if buffer := p.root.PaneBuffer(id); buffer == p.buffer {
+			p.buffer = p.root
+		}
+		p.root.ClosePane(id)
+	case "f":
+		p.root.FocusPane(id)
+	case "w":
+		if buffer := p.root.PaneBuffer(id); buffer != nil {
+			p.buffer = buffer
+		}
+	}
+}
@@ -17,8 +17,13 @@ const (
 	stateOSCString               // Reading OSC string
 	stateCharset                 // After ESC ( or ESC )
 	stateDECLineAttr             // After ESC # (waiting for line attribute command)
+	stateDCS                     // After ESC P (reading DCS parameters)
+	stateDCSString               // Reading DCS data
 )
 
+// maxDCSData caps the data collected for one DCS sequence; the rest is dropped
+const maxDCSData = 16 << 20
+
 // SGRParam represents an SGR parameter with optional subparameters
 // For example, "38:2:255:128:0" becomes {Base: 38, Subs: [2, 255, 128, 0]}
 type SGRParam struct {
@@ -28,7 +33,8 @@ type SGRParam struct {
 
 // Parser parses ANSI escape sequences and updates a Buffer
 type Parser struct {
-	buffer *Buffer
+	buffer *Buffer // Where output goes: root, or the pane chosen with OSC 7005 w
+	root   *Buffer // The buffer the parser was made for
 	state  parserState
 
 	// CSI sequence accumulator
@@ -42,6 +48,11 @@ type Parser struct {
 	oscCmd int             // OSC command number (e.g., 7000 for palette, 7001 for glyph)
 	oscBuf strings.Builder // OSC command arguments
 
+	// DCS accumulator
+	dcsParams []int           // Numeric parameters before the final byte
+	dcsFinal  byte            // Final byte (q for sixel), 0 while reading parameters
+	dcsBuf    strings.Builder // Data after the final byte
+
 	// UTF-8 multi-byte handling
 	utf8Buf  []byte
 	utf8Need int
@@ -51,6 +62,7 @@ type Parser struct {
 func NewParser(buffer *Buffer) *Parser {
 	return &Parser{
 		buffer:    buffer,
+		root:      buffer,
 		state:     stateGround,
 		csiParams: make([]int, 0, 16),
 	}
@@ -58,6 +70,7 @@ func NewParser(buffer *Buffer) *Parser {
 
 // Parse processes input data and updates the terminal buffer
 func (p *Parser) Parse(data []byte) {
+	p.root.recordOutput(data)
 	for _, b := range data {
 		p.processByte(b)
 	}
@@ -125,6 +138,10 @@ func (p *Parser) processByte(b byte) {
 		p.state = stateGround
 	case stateDECLineAttr:
 		p.handleDECLineAttr(b)
+	case stateDCS:
+		p.handleDCS(b)
+	case stateDCSString:
+		p.handleDCSString(b)
 	}
 }
 
@@ -147,7 +164,8 @@ func decodeUTF8(buf []byte) rune {
 func (p *Parser) handleGround(b byte) {
 	switch b {
 	case 0x00: // NUL - ignore
-	case 0x07: // BEL - bell (ignore for now)
+	case 0x07: // BEL - bell
+		p.buffer.Bell()
 	case 0x08: // BS - backspace
 		p.buffer.Backspace()
 	case 0x09: // HT - horizontal tab
@@ -180,6 +198,11 @@ func (p *Parser) handleEscape(b byte) {
 	case ']': // OSC - Operating System Command
 		p.state = stateOSC
 		p.oscBuf.Reset()
+	case 'P': // DCS - Device Control String
+		p.state = stateDCS
+		p.dcsParams = append(p.dcsParams[:0], 0)
+		p.dcsFinal = 0
+		p.dcsBuf.Reset()
 	case '(', ')': // Character set designation
 		p.state = stateCharset
 	case '#': // DEC line attribute commands (DECDHL, DECDWL, DECSWL, DECALN)
@@ -191,30 +214,22 @@ func (p *Parser) handleEscape(b byte) {
 		p.buffer.RestoreCursor()
 		p.state = stateGround
 	case 'c': // RIS - Reset to Initial State
+		p.buffer.SetOriginMode(false)
+		p.buffer.SetLeftRightMarginMode(false)
+		p.buffer.SetScrollMargins(0, 0)
 		p.buffer.ClearScreen()
 		p.buffer.SetCursor(0, 0)
 		p.buffer.ResetAttributes()
 		p.state = stateGround
-	case 'D': // IND - Index (move down one line, scroll if needed)
-		_, rows := p.buffer.GetSize()
-		_, y := p.buffer.GetCursor()
-		if y >= rows-1 {
-			p.buffer.ScrollUp(1)
-		} else {
-			p.buffer.MoveCursorDown(1)
-		}
+	case 'D': // IND - Index (move down one line, scroll at the bottom margin)
+		p.buffer.Index()
 		p.state = stateGround
 	case 'E': // NEL - Next Line
 		p.buffer.CarriageReturn()
 		p.buffer.LineFeed()
 		p.state = stateGround
-	case 'M': // RI - Reverse Index (move up one line, scroll if needed)
-		_, y := p.buffer.GetCursor()
-		if y == 0 {
-			p.buffer.ScrollDown(1)
-		} else {
-			p.buffer.MoveCursorUp(1)
-		}
+	case 'M': // RI - Reverse Index (move up one line, scroll at the top margin)
+		p.buffer.ReverseIndex()
 		p.state = stateGround
 	case '=': // DECKPAM - Keypad Application Mode
 		p.state = stateGround
@@ -243,6 +258,8 @@ func (p *Parser) handleDECLineAttr(b byte) {
 	case '6': // DECDWL - double width
 		p.buffer.SetLineAttribute(LineAttrDoubleWidth)
 	case '8': // DECALN - Screen alignment test (fill with 'E')
+		p.buffer.SetOriginMode(false)
+		p.buffer.SetScrollMargins(0, 0)
 		cols, rows := p.buffer.GetSize()
 		for y := 0; y < rows; y++ {
 			p.buffer.SetCursor(0, y)
@@ -368,13 +385,12 @@ func (p *Parser) executeCSI(finalByte byte) {
 
 	case 'G': // CHA - Cursor Horizontal Absolute
 		x := p.getParam(0, 1) - 1 // 1-indexed to 0-indexed
-		_, y := p.buffer.GetCursor()
-		p.buffer.SetCursor(x, y)
+		p.buffer.MoveCursorToColumn(x)
 
-	case 'H', 'f': // CUP/HVP - Cursor Position
+	case 'H', 'f': // CUP/HVP - Cursor Position (relative to the margins in origin mode)
 		row := p.getParam(0, 1) - 1
 		col := p.getParam(1, 1) - 1
-		p.buffer.SetCursor(col, row)
+		p.buffer.MoveCursorTo(col, row)
 
 	case 'J': // ED - Erase in Display
 		switch p.getParam(0, 0) {
@@ -420,8 +436,7 @@ func (p *Parser) executeCSI(finalByte byte) {
 
 	case 'd': // VPA - Vertical Position Absolute
 		y := p.getParam(0, 1) - 1
-		x, _ := p.buffer.GetCursor()
-		p.buffer.SetCursor(x, y)
+		p.buffer.MoveCursorToRow(y)
 
 	case 'm': // SGR - Select Graphic Rendition
 		p.executeSGR()
@@ -436,27 +451,53 @@ func (p *Parser) executeCSI(finalByte byte) {
 			p.executePrivateModeSet(false)
 		}
 
-	case 's': // SCP - Save Cursor Position
-		p.buffer.SaveCursor()
+	case 's': // SCP - Save Cursor Position, or DECSLRM - Set Left and Right Margins
+		if p.csiPrivate != 0 {
+			break
+		}
+		if p.buffer.IsLeftRightMarginModeEnabled() {
+			p.buffer.SetHorizontalMargins(p.getParam(0, 0), p.getParam(1, 0))
+		} else {
+			p.buffer.SaveCursor()
+		}
 
 	case 'u': // RCP - Restore Cursor Position
 		p.buffer.RestoreCursor()
 
 	case 'n': // DSR - Device Status Report
-		// Would need to send response - ignore for now
+		switch {
+		case p.csiPrivate == 0 && p.getParam(0, 0) == 5:
+			p.buffer.ReportStatus()
+		case p.csiPrivate == 0 && p.getParam(0, 0) == 6:
+			p.buffer.ReportCursorPosition(false)
+		case p.csiPrivate == '?' && p.getParam(0, 0) == 6: // DECXCPR
+			p.buffer.ReportCursorPosition(true)
+		}
 
 	case 'r': // DECSTBM - Set Top and Bottom Margins
-		// Scroll region - not yet implemented
+		if p.csiPrivate == 0 {
+			p.buffer.SetScrollMargins(p.getParam(0, 0), p.getParam(1, 0))
+		}
 
 	case 'c': // DA - Device Attributes
-		// Would need to send response - ignore
+		if p.getParam(0, 0) != 0 {
+			break
+		}
+		switch p.csiPrivate {
+		case 0:
+			p.buffer.ReportDeviceAttributes(false)
+		case '>':
+			p.buffer.ReportDeviceAttributes(true)
+		}
 
 	case 't': // Window manipulation
 		p.executeWindowManipulation()
 
-	case 'q': // DECSCUSR - Set Cursor Style (with space intermediate)
+	case 'q': // DECSCUSR - Set Cursor Style (with space intermediate), or XTVERSION
 		if p.csiIntermediate == ' ' {
 			p.executeDECSCUSR()
+		} else if p.csiPrivate == '>' && p.getParam(0, 0) == 0 {
+			p.buffer.ReportVersion()
 		}
 	}
 }
@@ -515,8 +556,9 @@ func (p *Parser) executeWindowManipulation() {
 
 // executeDECSCUSR handles ESC [ Ps SP q - Set Cursor Style
 func (p *Parser) executeDECSCUSR() {
-	style := p.getParam(0, 1)
-	// Ps = 0, 1: Blinking block
+	style := p.getParam(0, 0)
+	// Ps = 0: The configured default style
+	// Ps = 1: Blinking block
 	// Ps = 2: Steady block
 	// Ps = 3: Blinking underline
 	// Ps = 4: Steady underline
@@ -524,7 +566,10 @@ func (p *Parser) executeDECSCUSR() {
 	// Ps = 6: Steady bar
 	var shape, blink int
 	switch style {
-	case 0, 1: // Blinking block (default)
+	case 0: // Default
+		p.buffer.ResetCursorStyle()
+		return
+	case 1: // Blinking block
 		shape, blink = 0, 1
 	case 2: // Steady block
 		shape, blink = 0, 0
@@ -608,6 +653,10 @@ func (p *Parser) executeSGR() {
 			p.buffer.SetReverse(false)
 		case 29: // Strikethrough off
 			p.buffer.SetStrikethrough(false)
+		case 53: // Overline
+			p.buffer.SetOverline(true)
+		case 55: // Overline off
+			p.buffer.SetOverline(false)
 
 		// Foreground colors (30-37)
 		case 30, 31, 32, 33, 34, 35, 36, 37:
@@ -735,6 +784,18 @@ func (p *Parser) executeSGR() {
 						r, g, b = sgr.Subs[1], sgr.Subs[2], sgr.Subs[3]
 					}
 					p.buffer.SetUnderlineColor(TrueColor(uint8(r), uint8(g), uint8(b)))
+				} else if i+2 < len(p.csiParams) && p.csiParams[i+1] == 5 {
+					// Semicolon format: 58;5;N
+					p.buffer.SetUnderlineColor(PaletteColor(p.csiParams[i+2]))
+					i += 2
+				} else if i+4 < len(p.csiParams) && p.csiParams[i+1] == 2 {
+					// Semicolon format: 58;2;R;G;B
+					p.buffer.SetUnderlineColor(TrueColor(
+						uint8(p.csiParams[i+2]),
+						uint8(p.csiParams[i+3]),
+						uint8(p.csiParams[i+4]),
+					))
+					i += 4
 				}
 			}
 
@@ -768,7 +829,14 @@ func (p *Parser) executePrivateModeSet(set bool) {
 	for _, param := range p.csiParams {
 		switch param {
 		case 3: // DECCOLM - 132 Column Mode (horizontal scale 0.6060)
-			p.buffer.Set132ColumnMode(set)
+			// Also clears the margins, homes the cursor and (without DECNCSM) clears the screen
+			p.buffer.SelectColumnMode132(set)
+		case 6: // DECOM - Origin Mode: cursor positions relative to the margins
+			p.buffer.SetOriginMode(set)
+		case 69: // DECLRMM - Left/Right Margin Mode: ESC [ s sets margins (DECSLRM)
+			p.buffer.SetLeftRightMarginMode(set)
+		case 95: // DECNCSM - No Clear on Column Change
+			p.buffer.SetNoClearOnColumnChange(set)
 		case 5: // DECSCNM - Screen Mode (reverse video)
 			// h = reverse video (light mode), l = normal video (dark mode)
 			p.buffer.SetDarkTheme(!set)
@@ -776,6 +844,21 @@ func (p *Parser) executePrivateModeSet(set bool) {
 			p.buffer.SetCursorVisible(set)
 		case 1049: // Alternate screen buffer
 			// Not yet implemented
+		case 1000, 1002, 1003: // Mouse tracking: clicks, drags, all motion
+			mode := MouseTrackingOff
+			if set {
+				switch param {
+				case 1000:
+					mode = MouseTrackingClick
+				case 1002:
+					mode = MouseTrackingDrag
+				case 1003:
+					mode = MouseTrackingMotion
+				}
+			}
+			p.buffer.SetMouseTracking(mode)
+		case 1006: // SGR mouse encoding
+			p.buffer.SetMouseSGRMode(set)
 		case 2004: // Bracketed paste mode
 			p.buffer.SetBracketedPasteMode(set)
 		case 2027: // Flexible East Asian Width mode
@@ -822,6 +905,9 @@ func (p *Parser) executePrivateModeSet(set bool) {
 		case 7702: // PurfecTerm: Smart word wrap
 			// h = enable smart word wrap (wrap at word boundaries), l = disable
 			p.buffer.SetSmartWordWrap(set)
+		case 7703: // PurfecTerm: Disable automatic URL detection
+			// h = disable (plain-text URLs stay plain), l = re-enable
+			p.buffer.SetAutoLinks(!set)
 		}
 	}
 }
@@ -851,17 +937,68 @@ func (p *Parser) handleOSCString(b byte) {
 	}
 	if b == 0x1B { // ESC might start ST (ESC \)
 		p.executeOSC()
-		p.state = stateGround
+		// The \ of ST reaches handleEscape, which ignores it
+		p.state = stateEscape
 		return
 	}
 	p.oscBuf.WriteByte(b)
 }
 
+// handleDCS reads DCS parameters up to the final byte
+func (p *Parser) handleDCS(b byte) {
+	switch {
+	case b >= '0' && b <= '9':
+		n := &p.dcsParams[len(p.dcsParams)-1]
+		if *n < 1<<20 {
+			*n = *n*10 + int(b-'0')
+		}
+	case b == ';':
+		p.dcsParams = append(p.dcsParams, 0)
+	case b >= 0x40 && b <= 0x7E:
+		p.dcsFinal = b
+		p.state = stateDCSString
+	case b == 0x1B:
+		p.state = stateEscape
+	}
+	// Intermediate bytes are ignored
+}
+
+// handleDCSString collects DCS data until ST (ESC \)
+func (p *Parser) handleDCSString(b byte) {
+	if b == 0x1B {
+		p.executeDCS()
+		// The \ of ST reaches handleEscape, which ignores it
+		p.state = stateEscape
+		return
+	}
+	if p.dcsBuf.Len() < maxDCSData {
+		p.dcsBuf.WriteByte(b)
+	}
+}
+
+// executeDCS processes a complete DCS sequence
+func (p *Parser) executeDCS() {
+	switch p.dcsFinal {
+	case 'q': // Sixel graphics
+		if img := DecodeSixel(p.dcsParams, []byte(p.dcsBuf.String())); img != nil {
+			p.buffer.AddImage(img)
+		}
+	}
+	p.dcsBuf.Reset()
+}
+
 // executeOSC processes a complete OSC command
 func (p *Parser) executeOSC() {
 	args := p.oscBuf.String()
 
 	switch p.oscCmd {
+	case 8: // Hyperlink: params ; URI (an empty URI ends the link)
+		params, uri, _ := strings.Cut(args, ";")
+		p.buffer.SetHyperlink(uri, parseHyperlinkID(params))
+	case 52: // Clipboard: selections ; base64 text, or ? to query
+		if req, ok := parseClipboardRequest(args); ok {
+			p.buffer.RequestClipboard(req)
+		}
 	case 7000: // Palette management
 		p.executeOSCPalette(args)
 	case 7001: // Glyph management
@@ -870,6 +1007,10 @@ func (p *Parser) executeOSC() {
 		p.executeOSCSprite(args)
 	case 7003: // Screen crop and splits
 		p.executeOSCScreenCrop(args)
+	case 7004: // Scrollback search
+		p.executeOSCSearch(args)
+	case 7005: // Panes
+		p.executeOSCPanes(args)
 	// Other OSC commands (title, etc.) could be added here
 	}
 }
@@ -1234,3 +1375,25 @@ func (p *Parser) executeOSCScreenCrop(args string) {
 		}
 	}
 }
+
+// executeOSCSearch handles OSC 7004 scrollback search commands
+// Format: ESC ] 7004 ; cmd BEL
+// Commands:
+//   s;PATTERN  - highlight matches of the regular expression PATTERN and show the
+//                last one at or above the bottom of the view
+//   n          - show the next older match
+//   p          - show the next newer match
+//   c          - clear the search
+func (p *Parser) executeOSCSearch(args string) {
+	cmd, pattern, _ := strings.Cut(args, ";")
+	switch cmd {
+	case "s":
+		p.buffer.Search(pattern, SearchBackward)
+	case "n":
+		p.buffer.SearchNext(SearchBackward)
+	case "p":
+		p.buffer.SearchNext(SearchForward)
+	case "c":
+		p.buffer.ClearSearch()
+	}
+}
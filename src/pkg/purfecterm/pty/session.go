@@ -0,0 +1,179 @@
+// Package pty runs programs on a pseudo-terminal attached to a purfecterm buffer,
+// so a shell or an interactive tool can be hosted in a terminal window.
+package pty
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/phroun/pawscript/src/pkg/purfecterm"
+)
+
+// exitDrainTime is how long output is still read after the program exits
+// A background process that inherited the terminal can keep it open, and ConPTY
+// never ends the output on its own, so the session doesn't wait for the end.
+const exitDrainTime = 250 * time.Millisecond
+
+// Options configures a session
+type Options struct {
+	Command string          // Program to run (default: DefaultShell())
+	Args    []string        // Arguments for the program
+	Dir     string          // Working directory (default: the current directory)
+	Env     []string        // Added to the environment after TERM and COLORTERM
+	Feed    func([]byte)    // Receives the output (default: a new parser on the buffer, whose query replies go to the program)
+	OnExit  func(err error) // Called once the program has exited, with its exit error
+}
+
+// Session is a program running on a pseudo-terminal
+type Session struct {
+	mu     sync.Mutex
+	pty    purfecterm.PTY
+	cmd    *exec.Cmd
+	done   chan struct{}
+	err    error // The program's exit error, once done is closed
+	closed bool
+}
+
+// DefaultShell returns the user's shell: $SHELL (or /bin/sh) on Unix, and
+// %COMSPEC% (or cmd.exe) on Windows
+func DefaultShell() string {
+	if runtime.GOOS == "windows" {
+		if shell := os.Getenv("COMSPEC"); shell != "" {
+			return shell
+		}
+		return "cmd.exe"
+	}
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return shell
+	}
+	return "/bin/sh"
+}
+
+// Start runs a program on a new pseudo-terminal the size of buffer and connects the
+// two: the program's output goes to the buffer, and the pseudo-terminal follows the
+// buffer's size (Start takes over the buffer's resize callback). Input for the
+// program is given to Write.
+func Start(buffer *purfecterm.Buffer, opts Options) (*Session, error) {
+	if opts.Command == "" {
+		opts.Command = DefaultShell()
+	}
+	feed := opts.Feed
+	if feed == nil {
+		feed = purfecterm.NewParser(buffer).Parse
+	}
+
+	pty, err := purfecterm.NewPTY()
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(opts.Command, opts.Args...)
+	cmd.Dir = opts.Dir
+	cmd.Env = append(os.Environ(), "TERM=xterm-256color", "COLORTERM=truecolor")
+	cmd.Env = append(cmd.Env, opts.Env...)
+	if err := pty.Start(cmd); err != nil {
+		pty.Close()
+		return nil, err
+	}
+
+	s := &Session{pty: pty, cmd: cmd, done: make(chan struct{})}
+	pty.Resize(buffer.GetSize())
+	buffer.SetResizeCallback(func(cols, rows int) {
+		pty.Resize(cols, rows)
+	})
+
+	if opts.Feed == nil {
+		buffer.SetResponseCallback(func(reply []byte) {
+			s.Write(reply)
+		})
+	}
+
+	readDone := make(chan struct{})
+	go s.read(feed, readDone)
+	go func() {
+		err := cmd.Wait()
+		select {
+		case <-readDone:
+		case <-time.After(exitDrainTime):
+		}
+		s.mu.Lock()
+		s.err = err
+		s.closed = true
+		s.mu.Unlock()
+		pty.Close()
+		close(s.done)
+		if opts.OnExit != nil {
+			opts.OnExit(err)
+		}
+	}()
+	return s, nil
+}
+
+// read passes the program's output to feed until the pseudo-terminal is closed
+func (s *Session) read(feed func([]byte), readDone chan struct{}) {
+	defer close(readDone)
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := s.pty.Read(buf)
+		if n > 0 {
+			feed(buf[:n])
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Write sends input to the program
+func (s *Session) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	closed := s.closed
+	s.mu.Unlock()
+	if closed {
+		return 0, os.ErrClosed
+	}
+	return s.pty.Write(p)
+}
+
+// Resize sets the size of the pseudo-terminal, for a session whose buffer is resized
+// some other way than Buffer.Resize
+func (s *Session) Resize(cols, rows int) error {
+	return s.pty.Resize(cols, rows)
+}
+
+// Pid returns the process ID of the program
+func (s *Session) Pid() int {
+	return s.cmd.Process.Pid
+}
+
+// Done returns a channel that is closed once the program has exited
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// Wait waits for the program to exit and returns its exit error
+func (s *Session) Wait() error {
+	<-s.done
+	return s.err
+}
+
+// IsRunning returns whether the program is still running
+func (s *Session) IsRunning() bool {
+	select {
+	case <-s.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// Close kills the program if it is still running and closes the pseudo-terminal
+func (s *Session) Close() error {
+	if s.IsRunning() {
+		s.cmd.Process.Kill()
+	}
+	<-s.done
+	return nil
+}
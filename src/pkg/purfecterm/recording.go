@@ -0,0 +1,160 @@
+package purfecterm
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Recording (asciicast v2)
+// StartRecording writes everything the parser is given to a writer as an asciinema
+// cast: a JSON header with the terminal size, then one JSON line per chunk of output,
+// [seconds since the start, "o", data]. Resizes are recorded as [seconds, "r",
+// "COLSxROWS"] events. The cast plays back with asciinema play or the web player.
+// SetOutputTee copies the same output, as it was given, to a writer of its own,
+// such as a log, independently of any recording.
+
+// castHeader is the first line of an asciicast v2 file
+type castHeader struct {
+	Version   int    `json:"version"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Timestamp int64  `json:"timestamp"`
+	Title     string `json:"title,omitempty"`
+}
+
+// castRecorder writes events to a cast
+type castRecorder struct {
+	mu      sync.Mutex // Output is recorded without the buffer lock
+	w       io.Writer
+	enc     *json.Encoder
+	start   time.Time
+	partial []byte // Bytes of an incomplete UTF-8 sequence, held for the next chunk
+	err     error  // First write error; later events are dropped
+}
+
+// StartRecording starts writing the terminal's output to w as an asciicast v2 cast
+// with the given title (which may be empty). A recording already in progress is stopped first.
+func (b *Buffer) StartRecording(w io.Writer, title string) error {
+	b.StopRecording()
+
+	rec := &castRecorder{w: w, enc: json.NewEncoder(w), start: time.Now()}
+	rec.enc.SetEscapeHTML(false)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	header := castHeader{
+		Version:   2,
+		Width:     b.EffectiveCols(),
+		Height:    b.EffectiveRows(),
+		Timestamp: rec.start.Unix(),
+		Title:     title,
+	}
+	if err := rec.enc.Encode(header); err != nil {
+		return err
+	}
+	b.recording = rec
+	return nil
+}
+
+// StopRecording stops the recording, closing its writer if it is an io.Closer
+// Returns the first error writing the cast, or nil if nothing was being recorded.
+func (b *Buffer) StopRecording() error {
+	b.mu.Lock()
+	rec := b.recording
+	b.recording = nil
+	b.mu.Unlock()
+	if rec == nil {
+		return nil
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.partial) > 0 {
+		rec.event("o", string(rec.partial))
+	}
+	if closer, ok := rec.w.(io.Closer); ok {
+		if err := closer.Close(); err != nil && rec.err == nil {
+			rec.err = err
+		}
+	}
+	return rec.err
+}
+
+// IsRecording returns whether output is being recorded
+func (b *Buffer) IsRecording() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.recording != nil
+}
+
+// SetOutputTee copies everything the parser is given from now on to w, replacing
+// the writer set before (nil stops copying). Write errors are ignored.
+func (b *Buffer) SetOutputTee(w io.Writer) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tee = w
+}
+
+// recordOutput adds data given to the parser to the recording and the output tee,
+// if there are any
+func (b *Buffer) recordOutput(data []byte) {
+	b.mu.RLock()
+	rec, tee := b.recording, b.tee
+	b.mu.RUnlock()
+	if len(data) == 0 {
+		return
+	}
+	if tee != nil {
+		tee.Write(data)
+	}
+	if rec == nil {
+		return
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	data = append(rec.partial, data...)
+	rec.partial = nil
+
+	// JSON strings hold text, so a multi-byte character split between chunks waits
+	// for the rest of it
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				rec.partial = append([]byte(nil), data[i:]...)
+				data = data[:i]
+			}
+			break
+		}
+	}
+	if len(data) > 0 {
+		rec.event("o", string(data))
+	}
+}
+
+// recordResize adds a resize to the recording, if there is one
+// Must be called with the lock held.
+func (b *Buffer) recordResize() {
+	rec := b.recording
+	if rec == nil {
+		return
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.event("r", strconv.Itoa(b.EffectiveCols())+"x"+strconv.Itoa(b.EffectiveRows()))
+}
+
+// event writes one event line
+// Must be called with the recorder's lock held.
+func (rec *castRecorder) event(code, data string) {
+	if rec.err != nil {
+		return
+	}
+	elapsed := math.Round(time.Since(rec.start).Seconds()*1e6) / 1e6
+	rec.err = rec.enc.Encode([]any{elapsed, code, data})
+}
@@ -0,0 +1,279 @@
+package purfecterm
+
+// Line reflow
+// Auto-wrap marks a line that continues on the next one (LineInfo.Wrapped), and smart
+// word wrap records the indent it put at the start of the continuation
+// (LineInfo.WrapIndent). When the width changes, Resize joins the wrapped lines of the
+// scrollback and screen back into logical lines and wraps them again at the new width,
+// as auto-wrap would have written them at that width. Selections copy wrapped lines
+// as one line. Lines with a double-width or double-height attribute are left as they
+// are, and nothing is reflowed while auto-wrap is off, margins are set, or the logical
+// width is fixed (ESC [ 8 t), since full-screen programs redraw for the new size
+// themselves.
+
+// reflowRow is a line of cells and its info, from the scrollback or the screen
+type reflowRow struct {
+	cells  []Cell
+	info   LineInfo
+	packed *packedLine // The cells in compressed form, in which case cells is nil
+}
+
+// unpacked returns the row's cells, unpacking them if needed
+func (r reflowRow) unpacked() []Cell {
+	if r.packed != nil {
+		return r.packed.unpack()
+	}
+	return r.cells
+}
+
+// isWordBoundary returns whether smart word wrap may break a line after ch
+func isWordBoundary(ch rune) bool {
+	return ch == ' ' || ch == '-' || ch == ',' || ch == ';' || ch == '—'
+}
+
+// markWrapped records that auto-wrap moved the cursor from the end of the line above
+// to the cursor line, after indent cells
+// Must be called with the lock held.
+func (b *Buffer) markWrapped(indent int) {
+	for b.cursorY >= len(b.screen) {
+		b.screen = append(b.screen, b.makeEmptyLine())
+		b.lineInfos = append(b.lineInfos, b.makeDefaultLineInfo())
+	}
+	if b.cursorY == 0 {
+		return // The wrapped line has no row above (a one-row screen)
+	}
+	b.lineInfos[b.cursorY-1].Wrapped = true
+	b.lineInfos[b.cursorY].WrapIndent = indent
+}
+
+// reflow rewraps the scrollback and the screen to the current width, keeping the
+// cursor and the top of the screen on the text they were on
+// Must be called with the lock held.
+func (b *Buffer) reflow() {
+	if b.cursorY >= len(b.screen) {
+		return
+	}
+	width := b.EffectiveCols()
+	rows := make([]reflowRow, 0, len(b.scrollback)+len(b.screen))
+	for i, line := range b.scrollback {
+		rows = append(rows, reflowRow{line, b.scrollbackInfo[i], b.scrollbackPacked[i]})
+	}
+	for i, line := range b.screen {
+		rows = append(rows, reflowRow{line, b.lineInfos[i], nil})
+	}
+	screenTop := len(b.scrollback)
+	screenLen := len(b.screen)
+	cursorRow := screenTop + b.cursorY
+
+	// Blank rows below the cursor are padding; the screen is padded again afterwards
+	end := len(rows)
+	for end > cursorRow+1 && len(rows[end-1].cells) == 0 && !rows[end-2].info.Wrapped {
+		end--
+	}
+
+	var wrapped []reflowRow
+	newIndex := make([]int, len(rows)) // New row holding the start of each old row
+	newCursorRow, newCursorX := -1, b.cursorX
+	for start := 0; start < end; {
+		// Join the rows of a logical line, dropping the indent smart wrap added
+		stop := start + 1
+		for stop < end && rows[stop-1].info.Wrapped &&
+			rows[stop-1].info.Attribute == LineAttrNormal && rows[stop].info.Attribute == LineAttrNormal {
+			stop++
+		}
+		var cells []Cell
+		offsets := make([]int, stop-start) // Where each old row starts in cells
+		indents := make([]int, stop-start)
+		packed := false
+		for i := start; i < stop; i++ {
+			line := rows[i].unpacked()
+			packed = packed || rows[i].packed != nil
+			if i > start {
+				indents[i-start] = min(rows[i].info.WrapIndent, len(line))
+				line = line[indents[i-start]:]
+			}
+			offsets[i-start] = len(cells)
+			cells = append(cells, line...)
+		}
+		info := rows[start].info
+		info.Wrapped = rows[stop-1].info.Wrapped
+		lines, starts := b.wrapCells(cells, width, info)
+		if packed {
+			// Pack the rows again straight away, so a long scrollback is never
+			// unpacked all at once
+			for k := range lines {
+				if p := packLine(lines[k].cells); p != nil {
+					lines[k].cells, lines[k].packed = nil, p
+				}
+			}
+		}
+
+		first := len(wrapped)
+		for i := start; i < stop; i++ {
+			newIndex[i] = first + rowAtOffset(starts, offsets[i-start])
+		}
+		if cursorRow >= start && cursorRow < stop {
+			offset := offsets[cursorRow-start] + max(0, b.cursorX-indents[cursorRow-start])
+			k := rowAtOffset(starts, offset)
+			newCursorRow = first + k
+			newCursorX = offset - starts[k] + lines[k].info.WrapIndent
+		}
+		wrapped = append(wrapped, lines...)
+		start = stop
+	}
+	for i := end; i < len(rows); i++ {
+		newIndex[i] = len(wrapped) + i - end
+	}
+
+	// Keep the top of the screen on the same text, but move it down if the screen
+	// grew too long, as far as keeping the cursor on screen allows, or up into the
+	// scrollback if it got shorter, so the blank rows below the cursor stay the same
+	newTop := newIndex[screenTop]
+	if len(wrapped)-newTop > screenLen {
+		newTop += min(len(wrapped)-newTop-screenLen, newCursorRow-newTop)
+		wrapped = wrapped[:min(len(wrapped), newTop+screenLen)]
+	} else if short := screenLen - (len(wrapped) - newTop) - (len(rows) - end); short > 0 {
+		newTop -= min(short, newTop)
+	}
+
+	b.scrollback = make([][]Cell, newTop)
+	b.scrollbackInfo = make([]LineInfo, newTop)
+	b.scrollbackPacked = make([]*packedLine, newTop)
+	for i, row := range wrapped[:newTop] {
+		b.scrollback[i] = row.cells
+		b.scrollbackInfo[i] = row.info
+		b.scrollbackPacked[i] = row.packed
+	}
+	b.screen = make([][]Cell, screenLen)
+	b.lineInfos = make([]LineInfo, screenLen)
+	padding := rows[end:]
+	for y := range screenLen {
+		switch {
+		case newTop+y < len(wrapped):
+			b.screen[y] = wrapped[newTop+y].unpacked()
+			b.lineInfos[y] = wrapped[newTop+y].info
+		case len(padding) > 0:
+			b.screen[y] = padding[0].cells
+			b.lineInfos[y] = padding[0].info
+			padding = padding[1:]
+		default:
+			b.screen[y] = b.makeEmptyLine()
+			b.lineInfos[y] = b.makeDefaultLineInfo()
+		}
+	}
+	b.cursorY = newCursorRow - newTop
+	b.cursorX = newCursorX
+
+	// Images stay on the text they were on; a row's new index is its position from
+	// the same linesDropped, before the scrollback is trimmed below
+	oldDropped := b.linesDropped
+	for _, img := range b.images {
+		if old := int(img.line - oldDropped); old >= 0 && old < len(newIndex) {
+			img.line = oldDropped + int64(newIndex[old])
+		}
+	}
+	dropped := 0
+	if b.scrollbackDisabled {
+		dropped = len(b.scrollback)
+	} else if len(b.scrollback) > b.maxScrollback {
+		dropped = len(b.scrollback) - b.maxScrollback
+	}
+	if dropped > 0 {
+		b.scrollback = b.scrollback[dropped:]
+		b.scrollbackInfo = b.scrollbackInfo[dropped:]
+		b.scrollbackPacked = b.scrollbackPacked[dropped:]
+		b.linesDropped += int64(dropped)
+	}
+	b.pruneImages()
+	b.clearUnpackedCache()
+	b.packAgedScrollback()
+
+	// Positions in the old layout no longer mean anything
+	b.search = nil
+	b.selectionActive = false
+	b.horizOffset = 0
+}
+
+// wrapCells splits a logical line into rows of width columns as auto-wrap would have
+// written it, returning the rows and the offset in cells that each row starts at
+// Must be called with the lock held.
+func (b *Buffer) wrapCells(cells []Cell, width int, info LineInfo) ([]reflowRow, []int) {
+	if info.Attribute != LineAttrNormal || len(cells) == 0 || width < 1 {
+		return []reflowRow{{cells, info, nil}}, []int{0}
+	}
+	wrappedOn := info.Wrapped
+	info.Wrapped = false
+	info.WrapIndent = 0
+
+	// Smart word wrap repeats the leading indent on each continuation row
+	indent := 0
+	if b.smartWordWrap {
+		for indent < len(cells) && cells[indent].Char == ' ' {
+			indent++
+		}
+		if indent >= width {
+			indent = 0
+		}
+	}
+
+	var rows []reflowRow
+	var starts []int
+	for pos := 0; pos < len(cells); {
+		rowIndent := 0
+		if pos > 0 {
+			rowIndent = indent
+		}
+
+		// Take as many cells as fit, at least one
+		n, used := 0, 0.0
+		for pos+n < len(cells) {
+			w := 1.0
+			if b.visualWidthWrap && cells[pos+n].FlexWidth && cells[pos+n].CellWidth > 0 {
+				w = cells[pos+n].CellWidth
+			}
+			if n > 0 && used+w > float64(width-rowIndent) {
+				break
+			}
+			used += w
+			n++
+		}
+
+		// Break after the last word boundary past the indent, if the line goes on
+		if b.smartWordWrap && pos+n < len(cells) {
+			minBreak := 0
+			if pos == 0 {
+				minBreak = indent
+			}
+			for i := n - 1; i > minBreak; i-- {
+				if isWordBoundary(cells[pos+i].Char) {
+					n = i + 1
+					break
+				}
+			}
+		}
+
+		// Each row gets its own cells, so writing to one can't spill into the next
+		line := make([]Cell, 0, rowIndent+n)
+		for range rowIndent {
+			line = append(line, EmptyCell())
+		}
+		line = append(line, cells[pos:pos+n]...)
+		rowInfo := info
+		rowInfo.WrapIndent = rowIndent
+		rowInfo.Wrapped = true
+		rows = append(rows, reflowRow{line, rowInfo, nil})
+		starts = append(starts, pos)
+		pos += n
+	}
+	rows[len(rows)-1].info.Wrapped = wrappedOn
+	return rows, starts
+}
+
+// rowAtOffset returns which of the rows starting at starts holds the cell at offset
+func rowAtOffset(starts []int, offset int) int {
+	k := 0
+	for k+1 < len(starts) && starts[k+1] <= offset {
+		k++
+	}
+	return k
+}
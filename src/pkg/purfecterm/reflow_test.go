@@ -0,0 +1,107 @@
+package purfecterm
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// reflowRows returns the text of every row of the scrollback and screen, with a
+// trailing "+" on rows that wrap onto the next, dropping blank rows at the end
+func reflowRows(b *Buffer) []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	var rows []string
+	for y := 0; y < len(b.scrollback)+len(b.screen); y++ {
+		cells, info := b.getLineByAbsoluteY(y)
+		var text strings.Builder
+		for _, cell := range cells {
+			if cell.Char == 0 {
+				text.WriteByte(' ')
+				continue
+			}
+			text.WriteString(cell.String())
+		}
+		row := strings.TrimRight(text.String(), " ")
+		if info.Wrapped {
+			row += "+"
+		}
+		rows = append(rows, row)
+	}
+	for len(rows) > 0 && rows[len(rows)-1] == "" {
+		rows = rows[:len(rows)-1]
+	}
+	return rows
+}
+
+func TestReflowRoundTrip(t *testing.T) {
+	b := NewBuffer(10, 4, 100)
+	NewParser(b).ParseString("abcdefghijklmnopqrstuvwxyz\r\nshort\r\n0123456789AB")
+	narrow := []string{"abcdefghij+", "klmnopqrst+", "uvwxyz", "short", "0123456789+", "AB"}
+	if got := reflowRows(b); !reflect.DeepEqual(got, narrow) {
+		t.Fatalf("before resize: got %q, want %q", got, narrow)
+	}
+	x, y := b.GetCursor()
+
+	b.Resize(30, 4)
+	wide := []string{"abcdefghijklmnopqrstuvwxyz", "short", "0123456789AB"}
+	if got := reflowRows(b); !reflect.DeepEqual(got, wide) {
+		t.Errorf("wide: got %q, want %q", got, wide)
+	}
+
+	b.Resize(10, 4)
+	if got := reflowRows(b); !reflect.DeepEqual(got, narrow) {
+		t.Errorf("narrow again: got %q, want %q", got, narrow)
+	}
+	if gotX, gotY := b.GetCursor(); gotX != x || gotY != y {
+		t.Errorf("cursor: got (%d, %d), want (%d, %d)", gotX, gotY, x, y)
+	}
+}
+
+func TestReflowWideCharacters(t *testing.T) {
+	b := NewBuffer(7, 4, 100)
+	b.SetFlexWidthMode(true)
+	b.SetVisualWidthWrap(true)
+	NewParser(b).ParseString("abc世界x")
+	if got, want := reflowRows(b), []string{"abc世界+", "x"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("at 7 columns: got %q, want %q", got, want)
+	}
+
+	// 世 would straddle the wrap column, so it moves to the next row whole
+	b.Resize(4, 4)
+	if got, want := reflowRows(b), []string{"abc+", "世界+", "x"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("at 4 columns: got %q, want %q", got, want)
+	}
+	b.Resize(5, 4)
+	if got, want := reflowRows(b), []string{"abc世+", "界x"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("at 5 columns: got %q, want %q", got, want)
+	}
+	b.Resize(7, 4)
+	if got, want := reflowRows(b), []string{"abc世界+", "x"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("back at 7 columns: got %q, want %q", got, want)
+	}
+}
+
+func TestReflowCursor(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		cols         int
+		wantX, wantY int
+	}{
+		{"end of line", "abcdefghijklmno", 20, 15, 0},
+		{"end of line narrower", "abcdefghijklmno", 4, 3, 3},
+		{"inside the line", "abcdefghijklmno\x1b[1;8H", 4, 3, 1}, // On h
+		{"on a later line", "abcdefghijklmno\r\nxy", 4, 2, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBuffer(10, 6, 100)
+			NewParser(b).ParseString(tt.input)
+			b.Resize(tt.cols, 6)
+			if x, y := b.GetCursor(); x != tt.wantX || y != tt.wantY {
+				t.Errorf("cursor: got (%d, %d), want (%d, %d); rows %q", x, y, tt.wantX, tt.wantY, reflowRows(b))
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package purfecterm
+
+import (
+	"fmt"
+)
+
+// Query responses
+//   ESC [ c           DA1: ESC [ ? 62 ; 4 ; 22 c (VT220 level, sixel, ANSI color)
+//   ESC [ > c         DA2: ESC [ > 1 ; 10 ; 0 c (VT220, version 10)
+//   ESC [ 5 n         DSR status: ESC [ 0 n (ready)
+//   ESC [ 6 n         CPR: ESC [ row ; col R
+//   ESC [ ? 6 n       DECXCPR: ESC [ ? row ; col ; 1 R
+//   ESC [ > q         XTVERSION: ESC P > | identity ESC \
+// Programs such as vim and htop send these to learn what the terminal can do. The
+// identity names the terminal in the XTVERSION reply and can be set with
+// SetTerminalIdentity. Cursor positions are 1-based, and relative to the margins in
+// origin mode. The host passes the replies to the program as if they were typed.
+
+// DefaultTerminalIdentity is the identity given in the XTVERSION reply by default
+const DefaultTerminalIdentity = "purfecterm"
+
+// Replies to the device attribute queries
+const (
+	primaryDeviceAttributes   = "\x1b[?62;4;22c"
+	secondaryDeviceAttributes = "\x1b[>1;10;0c"
+)
+
+// SetTerminalIdentity sets the identity given in the XTVERSION reply
+// An empty identity restores DefaultTerminalIdentity.
+func (b *Buffer) SetTerminalIdentity(identity string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.identity = identity
+}
+
+// GetTerminalIdentity returns the identity given in the XTVERSION reply
+func (b *Buffer) GetTerminalIdentity() string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.identity == "" {
+		return DefaultTerminalIdentity
+	}
+	return b.identity
+}
+
+// SetResponseCallback sets a callback to be invoked with replies to queries, which
+// the host should send to the program
+// It is called from whatever goroutine feeds the parser, without the lock held.
+func (b *Buffer) SetResponseCallback(fn func([]byte)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onResponse = fn
+}
+
+// respond passes a reply to the response callback
+func (b *Buffer) respond(reply string) {
+	b.mu.RLock()
+	onResponse := b.onResponse
+	b.mu.RUnlock()
+	if onResponse != nil {
+		onResponse([]byte(reply))
+	}
+}
+
+// ReportDeviceAttributes replies to DA1, or to DA2 if secondary is set
+func (b *Buffer) ReportDeviceAttributes(secondary bool) {
+	if secondary {
+		b.respond(secondaryDeviceAttributes)
+	} else {
+		b.respond(primaryDeviceAttributes)
+	}
+}
+
+// ReportStatus replies to a device status report that the terminal is ready
+func (b *Buffer) ReportStatus() {
+	b.respond("\x1b[0n")
+}
+
+// ReportCursorPosition replies with the cursor position (CPR), or with DECXCPR's
+// form, which adds the page, if extended is set
+func (b *Buffer) ReportCursorPosition(extended bool) {
+	b.mu.RLock()
+	x, y := min(b.cursorX, b.cols-1), b.cursorY
+	if b.originMode {
+		top, _ := b.scrollRegion()
+		left, _ := b.horizontalMargins()
+		x -= left
+		y -= top
+	}
+	b.mu.RUnlock()
+
+	if extended {
+		b.respond(fmt.Sprintf("\x1b[?%d;%d;1R", y+1, x+1))
+	} else {
+		b.respond(fmt.Sprintf("\x1b[%d;%dR", y+1, x+1))
+	}
+}
+
+// ReportVersion replies to XTVERSION with the terminal identity
+func (b *Buffer) ReportVersion() {
+	b.respond("\x1bP>|" + b.GetTerminalIdentity() + "\x1b\\")
+}
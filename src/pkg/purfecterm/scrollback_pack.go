@@ -0,0 +1,177 @@
+package purfecterm
+
+import (
+	"sync"
+	"unicode/utf8"
+)
+
+// Scrollback compression
+// A Cell is large, but neighbouring cells nearly always share their colors and
+// attributes, so once a scrollback line is older than the newest
+// scrollbackUnpackedLines it is packed: its characters become a string and its
+// attributes are run-length encoded. Packed lines are unpacked when something reads
+// them, and the last few unpacked are cached for the renderers, which read a cell at a time.
+
+// scrollbackUnpackedLines is how many of the newest scrollback lines stay unpacked
+const scrollbackUnpackedLines = 1000
+
+// maxUnpackedCache is how many unpacked copies of packed lines are cached
+const maxUnpackedCache = 256
+
+// packedLine is a scrollback line in compressed form
+// The fields are exported so snapshots can encode packed lines as they are.
+type packedLine struct {
+	Text      string       // The characters, one rune per cell
+	Runs      []packedRun  // Attributes of consecutive cells
+	Combining []packedMark // Combining marks, which few cells have
+	Cells     int          // Length of the line in cells
+}
+
+// packedRun is a run of cells with the same attributes
+type packedRun struct {
+	Attrs Cell // The cells without Char and Combining
+	Count int
+}
+
+// packedMark holds the combining marks of one cell
+type packedMark struct {
+	Col   int
+	Marks string
+}
+
+// unpackedCache holds unpacked copies of recently read packed lines
+type unpackedCache struct {
+	mu    sync.Mutex // Readers share the buffer lock, so the cache has its own
+	lines map[*packedLine][]Cell
+	order []*packedLine // Oldest first
+}
+
+// packLine compresses a line, or returns nil if it holds a character that can't be
+// stored in a string (the line is then kept as it is)
+func packLine(line []Cell) *packedLine {
+	text := make([]byte, 0, len(line))
+	p := &packedLine{Cells: len(line)}
+	for col, cell := range line {
+		if !utf8.ValidRune(cell.Char) {
+			return nil
+		}
+		text = utf8.AppendRune(text, cell.Char)
+		if cell.Combining != "" {
+			p.Combining = append(p.Combining, packedMark{Col: col, Marks: cell.Combining})
+		}
+		cell.Char = 0
+		cell.Combining = ""
+		if n := len(p.Runs); n > 0 && p.Runs[n-1].Attrs == cell {
+			p.Runs[n-1].Count++
+		} else {
+			p.Runs = append(p.Runs, packedRun{Attrs: cell, Count: 1})
+		}
+	}
+	p.Text = string(text)
+	return p
+}
+
+// unpack returns the cells of a packed line
+func (p *packedLine) unpack() []Cell {
+	line := make([]Cell, 0, p.Cells)
+	for _, run := range p.Runs {
+		for range run.Count {
+			line = append(line, run.Attrs)
+		}
+	}
+	col := 0
+	for _, ch := range p.Text {
+		line[col].Char = ch
+		col++
+	}
+	for _, mark := range p.Combining {
+		line[mark.Col].Combining = mark.Marks
+	}
+	return line
+}
+
+// valid returns whether a packed line's parts agree on its length, so it can be
+// unpacked (packLine always makes valid lines; snapshots are read from outside)
+func (p *packedLine) valid() bool {
+	if p.Cells < 0 || utf8.RuneCountInString(p.Text) != p.Cells {
+		return false
+	}
+	cells := 0
+	for _, run := range p.Runs {
+		if run.Count < 0 || run.Count > p.Cells-cells {
+			return false
+		}
+		cells += run.Count
+	}
+	if cells != p.Cells {
+		return false
+	}
+	for _, mark := range p.Combining {
+		if mark.Col < 0 || mark.Col >= p.Cells {
+			return false
+		}
+	}
+	return true
+}
+
+// scrollbackLine returns the cells of a scrollback line, unpacking it if needed
+// The cells of a packed line may be shared, so they must not be changed.
+// Must be called with the lock held (a read lock is enough).
+func (b *Buffer) scrollbackLine(i int) []Cell {
+	p := b.scrollbackPacked[i]
+	if p == nil {
+		return b.scrollback[i]
+	}
+
+	cache := &b.unpacked
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if line, ok := cache.lines[p]; ok {
+		return line
+	}
+	line := p.unpack()
+	if cache.lines == nil {
+		cache.lines = make(map[*packedLine][]Cell)
+	}
+	if len(cache.order) >= maxUnpackedCache {
+		delete(cache.lines, cache.order[0])
+		cache.order = cache.order[1:]
+	}
+	cache.lines[p] = line
+	cache.order = append(cache.order, p)
+	return line
+}
+
+// scrollbackLineLen returns the length of a scrollback line in cells
+// Must be called with the lock held (a read lock is enough).
+func (b *Buffer) scrollbackLineLen(i int) int {
+	if p := b.scrollbackPacked[i]; p != nil {
+		return p.Cells
+	}
+	return len(b.scrollback[i])
+}
+
+// packAgedScrollback packs the scrollback lines older than the newest
+// scrollbackUnpackedLines, newest first, until it reaches a packed line (lines age
+// in order, so the older ones already are)
+// Must be called with the lock held.
+func (b *Buffer) packAgedScrollback() {
+	for i := len(b.scrollback) - scrollbackUnpackedLines - 1; i >= 0; i-- {
+		if b.scrollbackPacked[i] != nil {
+			return
+		}
+		if p := packLine(b.scrollback[i]); p != nil {
+			b.scrollbackPacked[i] = p
+			b.scrollback[i] = nil
+		}
+	}
+}
+
+// clearUnpackedCache forgets the cached unpacked lines
+// Must be called with the lock held.
+func (b *Buffer) clearUnpackedCache() {
+	b.unpacked.mu.Lock()
+	defer b.unpacked.mu.Unlock()
+	b.unpacked.lines = nil
+	b.unpacked.order = nil
+}
@@ -0,0 +1,107 @@
+package purfecterm
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestPackLineRoundTrip(t *testing.T) {
+	red := StandardColor(1)
+	wide := func(ch rune) Cell {
+		c := EmptyCell()
+		c.Char, c.FlexWidth, c.CellWidth = ch, true, 2.0
+		return c
+	}
+	styled := func(ch rune, change func(*Cell)) Cell {
+		c := EmptyCell()
+		c.Char = ch
+		change(&c)
+		return c
+	}
+	cells := func(text string) []Cell {
+		var line []Cell
+		for _, ch := range text {
+			c := EmptyCell()
+			c.Char = ch
+			line = append(line, c)
+		}
+		return line
+	}
+
+	tests := []struct {
+		name string
+		line []Cell
+	}{
+		{"empty", nil},
+		{"plain", cells("hello, world")},
+		{"blank cells", []Cell{{}, EmptyCell(), {Char: 'x'}, {}}},
+		{"attributes", []Cell{
+			styled('b', func(c *Cell) { c.Bold = true }),
+			styled('r', func(c *Cell) { c.Foreground, c.Reverse = red, true }),
+			styled('u', func(c *Cell) {
+				c.Underline, c.UnderlineStyle = true, UnderlineCurly
+				c.UnderlineColor, c.HasUnderlineColor = red, true
+			}),
+			styled('l', func(c *Cell) { c.LinkID = 3 }),
+			styled('l', func(c *Cell) { c.LinkID = 3 }),
+			styled('g', func(c *Cell) { c.BGP, c.XFlip = 5, true }),
+		}},
+		{"wide characters", []Cell{wide('世'), wide('界'), {Char: 'x'}, wide('🐱')}},
+		{"combining marks", []Cell{
+			{Char: 'e', Combining: "\u0301"},
+			{Char: 'a'},
+			{Char: 'ש', Combining: "\u05B8\u05C1"},
+			{Char: 'n', Combining: "\u0303"},
+		}},
+		{"replacement character", cells("a\uFFFDb")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := packLine(tt.line)
+			if p == nil {
+				t.Fatal("line not packed")
+			}
+			got := p.unpack()
+			if len(got) != len(tt.line) || (len(got) > 0 && !reflect.DeepEqual(got, tt.line)) {
+				t.Errorf("unpacked\n%+v\nwant\n%+v", got, tt.line)
+			}
+		})
+	}
+
+	// A rune a string can't hold leaves the line unpacked
+	if p := packLine([]Cell{{Char: 'a'}, {Char: 0xD800}}); p != nil {
+		t.Error("packed a line holding a surrogate")
+	}
+}
+
+func TestPackAgedScrollback(t *testing.T) {
+	b := NewBuffer(20, 4, scrollbackUnpackedLines+100)
+	parser := NewParser(b)
+	parser.ParseString("\x1b[1;31mred\x1b[m é \x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\ ok")
+	b.mu.RLock()
+	first := append([]Cell(nil), b.screen[0]...)
+	b.mu.RUnlock()
+	if first[1].Foreground == first[4].Foreground || first[4].Combining == "" || first[6].LinkID == 0 {
+		t.Fatalf("first line lacks the attributes under test: %+v", first)
+	}
+
+	for i := 0; i < scrollbackUnpackedLines+10; i++ {
+		parser.ParseString(fmt.Sprintf("\r\nline %d", i))
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.scrollbackPacked[0] == nil {
+		t.Fatal("oldest scrollback line not packed")
+	}
+	if newest := len(b.scrollback) - 1; b.scrollbackPacked[newest] != nil {
+		t.Error("newest scrollback line packed")
+	}
+	if got := b.scrollbackLine(0); !reflect.DeepEqual(got, first) {
+		t.Errorf("packed line reads back as\n%+v\nwant\n%+v", got, first)
+	}
+	if got := b.scrollbackLineLen(0); got != len(first) {
+		t.Errorf("packed line length %d, want %d", got, len(first))
+	}
+}
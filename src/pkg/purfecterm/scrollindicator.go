@@ -0,0 +1,56 @@
+package purfecterm
+
+import "fmt"
+
+// Scroll indicator
+// While the view is scrolled back into the scrollback, the widgets show how far
+// back it is, and once more output arrives, a "N new lines" pill that jumps to the
+// bottom when clicked. NewOutput counts the lines that scrolled off the top of the
+// screen since the view left the bottom.
+
+// NewOutput counts the output written while the view is scrolled back
+// It is not safe for concurrent use; the widgets use it on the UI thread.
+type NewOutput struct {
+	back bool  // Whether the view was scrolled back at the last Update
+	mark int64 // GetLinesScrolledOff when the view left the bottom
+}
+
+// Update takes the view's effective scroll offset and the buffer's
+// GetLinesScrolledOff, returning how many lines of output were written since the
+// view left the bottom (0 while it is at the bottom)
+func (n *NewOutput) Update(offset int, scrolledOff int64) int {
+	if offset <= 0 {
+		n.back = false
+		return 0
+	}
+	if !n.back {
+		n.back = true
+		n.mark = scrolledOff
+	}
+	return int(max(scrolledOff-n.mark, 0))
+}
+
+// ScrollIndicatorText returns the indicator of how far back the view is, by its
+// effective scroll offset ("" at the bottom)
+func ScrollIndicatorText(offset int) string {
+	switch {
+	case offset <= 0:
+		return ""
+	case offset == 1:
+		return "1 line back"
+	default:
+		return fmt.Sprintf("%d lines back", offset)
+	}
+}
+
+// NewLinesText returns the text of the pill for n new lines ("" for none)
+func NewLinesText(n int) string {
+	switch {
+	case n <= 0:
+		return ""
+	case n == 1:
+		return "1 new line ↓"
+	default:
+		return fmt.Sprintf("%d new lines ↓", n)
+	}
+}
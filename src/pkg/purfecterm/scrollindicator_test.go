@@ -0,0 +1,51 @@
+package purfecterm
+
+import "testing"
+
+func TestNewOutput(t *testing.T) {
+	var n NewOutput
+	if got := n.Update(0, 100); got != 0 {
+		t.Errorf("at the bottom: got %d, want 0", got)
+	}
+	if got := n.Update(5, 100); got != 0 {
+		t.Errorf("just scrolled back: got %d, want 0", got)
+	}
+	if got := n.Update(5, 112); got != 12 {
+		t.Errorf("after 12 lines: got %d, want 12", got)
+	}
+	if got := n.Update(0, 115); got != 0 {
+		t.Errorf("back at the bottom: got %d, want 0", got)
+	}
+	if got := n.Update(3, 115); got != 0 {
+		t.Errorf("scrolled back again: got %d, want 0", got)
+	}
+}
+
+func TestNewOutputFromBuffer(t *testing.T) {
+	b := NewBuffer(10, 4, 100)
+	p := NewParser(b)
+	for i := 0; i < 30; i++ {
+		p.ParseString("line\r\n")
+	}
+	var n NewOutput
+	b.SetScrollOffset(10)
+	if n.Update(b.GetEffectiveScrollOffset(), b.GetLinesScrolledOff()) != 0 {
+		t.Fatal("new lines counted before any output")
+	}
+	p.ParseString("\r\n7\r\n8\r\n9")
+	if got := n.Update(b.GetEffectiveScrollOffset(), b.GetLinesScrolledOff()); got != 3 {
+		t.Errorf("new lines: got %d, want 3", got)
+	}
+}
+
+func TestScrollIndicatorText(t *testing.T) {
+	if got := ScrollIndicatorText(0); got != "" {
+		t.Errorf("at the bottom: got %q", got)
+	}
+	if got := ScrollIndicatorText(1); got != "1 line back" {
+		t.Errorf("one line: got %q", got)
+	}
+	if got := NewLinesText(40); got != "40 new lines ↓" {
+		t.Errorf("pill: got %q", got)
+	}
+}
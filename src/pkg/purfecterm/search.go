@@ -0,0 +1,274 @@
+package purfecterm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Scrollback search
+// Search finds every match of a regular expression in the scrollback and the logical
+// screen and keeps them as highlight state for the renderers, with one match current.
+// SearchNext moves the current match and ClearSearch removes the highlights. A match
+// can't span lines. The matches are a snapshot: output written afterwards isn't
+// searched until the next Search, though matches stay on their lines as they scroll.
+
+// maxSearchMatches caps how many matches a search keeps
+const maxSearchMatches = 10000
+
+// SearchDirection is which way a search moves from the view or the current match
+type SearchDirection int
+
+const (
+	SearchBackward SearchDirection = iota // Toward older output
+	SearchForward                         // Toward newer output
+)
+
+// SearchHighlight is how a cell is highlighted by the search
+type SearchHighlight int
+
+const (
+	SearchHighlightNone    SearchHighlight = iota
+	SearchHighlightMatch                   // Part of a match
+	SearchHighlightCurrent                 // Part of the current match
+)
+
+// SearchMatch is a match in buffer-absolute coordinates, as GetSelection uses
+// (line 0 is the oldest scrollback line)
+type SearchMatch struct {
+	Line     int
+	StartCol int // First column of the match
+	EndCol   int // Column after the match
+}
+
+// searchState holds the matches of the last search
+type searchState struct {
+	matches []SearchMatch // Lines as they were when searched
+	byLine  map[int][]int // Match indices by line
+	current int           // Index of the current match
+	dropped int64         // linesDropped when searched, to adjust lines since
+}
+
+// Search highlights every match of pattern (a regular expression) and makes the
+// nearest match in direction current, scrolling it into view. The search starts
+// from the view, and wraps around. An empty pattern clears the search.
+// Returns the matches in order and the index of the current one (-1 if none).
+func (b *Buffer) Search(pattern string, direction SearchDirection) ([]SearchMatch, int, error) {
+	if pattern == "" {
+		b.ClearSearch()
+		return nil, -1, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, -1, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	search := &searchState{byLine: map[int][]int{}, current: -1, dropped: b.linesDropped}
+	total := len(b.scrollback) + b.EffectiveRows()
+	for line := 0; line < total && len(search.matches) < maxSearchMatches; line++ {
+		text, cols := b.searchLineText(line)
+		for _, loc := range re.FindAllStringIndex(text, -1) {
+			if loc[0] == loc[1] {
+				continue // Empty matches have nothing to highlight
+			}
+			search.byLine[line] = append(search.byLine[line], len(search.matches))
+			search.matches = append(search.matches, SearchMatch{Line: line, StartCol: cols[loc[0]], EndCol: cols[loc[1]]})
+			if len(search.matches) >= maxSearchMatches {
+				break
+			}
+		}
+	}
+	b.search = search
+	if len(search.matches) > 0 {
+		top := b.screenToBufferY(0)
+		if direction == SearchBackward {
+			b.moveSearchCurrent(top+b.rows, direction)
+		} else {
+			b.moveSearchCurrent(top, direction)
+		}
+	}
+	b.markDirty()
+	return append([]SearchMatch(nil), search.matches...), search.current, nil
+}
+
+// SearchNext makes the next match in direction current, wrapping around, and
+// scrolls it into view. Returns false if there is no search with matches.
+func (b *Buffer) SearchNext(direction SearchDirection) (SearchMatch, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.search == nil || len(b.search.matches) == 0 {
+		return SearchMatch{}, false
+	}
+	count := len(b.search.matches)
+	if direction == SearchForward {
+		b.search.current = (b.search.current + 1) % count
+	} else {
+		b.search.current = (b.search.current + count - 1) % count
+	}
+	b.scrollToBufferY(b.searchMatch(b.search.current).Line)
+	b.markDirty()
+	return b.searchMatch(b.search.current), true
+}
+
+// ClearSearch removes the search highlights
+func (b *Buffer) ClearSearch() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.search != nil {
+		b.search = nil
+		b.markDirty()
+	}
+}
+
+// GetSearchMatches returns the matches of the last search and the index of the
+// current one (-1 if there are none)
+func (b *Buffer) GetSearchMatches() ([]SearchMatch, int) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.search == nil || len(b.search.matches) == 0 {
+		return nil, -1
+	}
+	matches := make([]SearchMatch, len(b.search.matches))
+	for i := range matches {
+		matches[i] = b.searchMatch(i)
+	}
+	return matches, b.search.current
+}
+
+// GetSearchHighlight returns how the cell at a screen row and logical column is
+// highlighted by the search (the column is as IsCellInSelection takes it)
+func (b *Buffer) GetSearchHighlight(x, screenY int) SearchHighlight {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.search == nil {
+		return SearchHighlightNone
+	}
+	line := b.screenToBufferY(screenY) + int(b.linesDropped-b.search.dropped)
+	for _, i := range b.search.byLine[line] {
+		match := b.search.matches[i]
+		if x >= match.StartCol && x < match.EndCol {
+			if i == b.search.current {
+				return SearchHighlightCurrent
+			}
+			return SearchHighlightMatch
+		}
+	}
+	return SearchHighlightNone
+}
+
+// searchLineText returns a buffer-absolute line as text, and the column each byte
+// of it starts in (with one extra entry for the end of the line)
+// Must be called with the lock held.
+func (b *Buffer) searchLineText(bufferY int) (string, []int) {
+	var line []Cell
+	if bufferY < len(b.scrollback) {
+		line = b.scrollbackLine(bufferY)
+	} else if y := bufferY - len(b.scrollback); y < len(b.screen) {
+		line = b.screen[y]
+	}
+	var text strings.Builder
+	var cols []int
+	for col, cell := range line {
+		s := cell.String()
+		if cell.Char == 0 {
+			s = " "
+		}
+		text.WriteString(s)
+		for range len(s) {
+			cols = append(cols, col)
+		}
+	}
+	cols = append(cols, len(line))
+	return text.String(), cols
+}
+
+// searchMatch returns match i with its line adjusted for lines dropped since the search
+// Must be called with the lock held.
+func (b *Buffer) searchMatch(i int) SearchMatch {
+	match := b.search.matches[i]
+	match.Line -= int(b.linesDropped - b.search.dropped)
+	return match
+}
+
+// moveSearchCurrent makes the last match before line (backward) or the first at or
+// after it (forward) current, wrapping around, and scrolls it into view
+// Must be called with the lock held.
+func (b *Buffer) moveSearchCurrent(line int, direction SearchDirection) {
+	matches := b.search.matches
+	shift := int(b.linesDropped - b.search.dropped)
+	var next int
+	if direction == SearchForward {
+		next = 0
+		for i, match := range matches {
+			if match.Line-shift >= line {
+				next = i
+				break
+			}
+		}
+	} else {
+		next = len(matches) - 1
+		for i := len(matches) - 1; i >= 0; i-- {
+			if matches[i].Line-shift < line {
+				next = i
+				break
+			}
+		}
+	}
+	b.search.current = next
+	b.scrollToBufferY(matches[next].Line - shift)
+}
+
+// scrollToBufferY scrolls a buffer-absolute line into view, centering it if it was
+// off screen
+// Must be called with the lock held.
+func (b *Buffer) scrollToBufferY(bufferY int) {
+	top := b.screenToBufferY(0)
+	if bufferY >= top && bufferY < top+b.rows {
+		return
+	}
+	logicalHiddenAbove := 0
+	if effectiveRows := b.EffectiveRows(); effectiveRows > b.rows {
+		logicalHiddenAbove = effectiveRows - b.rows
+	}
+	offset := len(b.scrollback) + logicalHiddenAbove + b.rows/2 - bufferY
+	if offset > logicalHiddenAbove {
+		// Past the magnetic zone the view lags the offset by the threshold
+		offset += b.getMagneticThreshold()
+	}
+	b.scrollOffset = max(0, min(offset, b.getMaxScrollOffsetInternal()))
+}
+
+// SearchPattern returns the regular expression the find bars search with: text as
+// it is if regex is set, or matched literally if not, ignoring case unless
+// matchCase is set
+func SearchPattern(text string, regex, matchCase bool) string {
+	if text == "" {
+		return ""
+	}
+	if !regex {
+		text = regexp.QuoteMeta(text)
+	}
+	if !matchCase {
+		text = "(?i)" + text
+	}
+	return text
+}
+
+// SearchStatus returns the find bars' match count for the result of a search:
+// the current match's number of the count, "No matches", or "Invalid pattern"
+func SearchStatus(count, current int, err error) string {
+	switch {
+	case err != nil:
+		return "Invalid pattern"
+	case count == 0:
+		return "No matches"
+	case count >= maxSearchMatches:
+		return fmt.Sprintf("%d of %d+", current+1, count)
+	default:
+		return fmt.Sprintf("%d of %d", current+1, count)
+	}
+}
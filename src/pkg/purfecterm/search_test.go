@@ -0,0 +1,47 @@
+package purfecterm
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSearchPattern(t *testing.T) {
+	tests := []struct {
+		text             string
+		regex, matchCase bool
+		want             string
+	}{
+		{"", false, false, ""},
+		{"a.b", false, true, `a\.b`},
+		{"a.b", true, true, "a.b"},
+		{"Foo", false, false, "(?i)Foo"},
+		{"f(o+)", true, false, "(?i)f(o+)"},
+	}
+	for _, tt := range tests {
+		if got := SearchPattern(tt.text, tt.regex, tt.matchCase); got != tt.want {
+			t.Errorf("SearchPattern(%q, %v, %v) = %q, want %q", tt.text, tt.regex, tt.matchCase, got, tt.want)
+		}
+	}
+}
+
+func TestSearchFindBar(t *testing.T) {
+	b := newGridTestBuffer("Foo foo\r\nf.o FOO")
+	matches, current, err := b.Search(SearchPattern("foo", false, false), SearchBackward)
+	if err != nil || len(matches) != 3 {
+		t.Fatalf("ignoring case: got %d matches, err %v; want 3", len(matches), err)
+	}
+	if got := SearchStatus(len(matches), current, err); got != "3 of 3" {
+		t.Errorf("status: got %q, want \"3 of 3\"", got)
+	}
+	matches, _, _ = b.Search(SearchPattern("f.o", false, true), SearchBackward)
+	if len(matches) != 1 || matches[0].StartCol != 0 {
+		t.Errorf("literal f.o: got %+v, want one match at column 0", matches)
+	}
+
+	if got := SearchStatus(0, -1, nil); got != "No matches" {
+		t.Errorf("no matches: got %q", got)
+	}
+	if got := SearchStatus(0, -1, errors.New("bad")); got != "Invalid pattern" {
+		t.Errorf("error: got %q", got)
+	}
+}
@@ -0,0 +1,242 @@
+package purfecterm
+
+import (
+	"image"
+	"image/color"
+)
+
+// Sixel graphics decoding
+// A sixel image arrives as a DCS sequence: ESC P P1;P2;P3 q DATA ESC \
+// DATA draws columns of six vertical pixels, one character per column:
+//   ? to ~      six pixels (character - '?', bit 0 at the top) in the current color
+//   !N c        repeat sixel character c N times
+//   #N          select color register N
+//   #N;U;X;Y;Z  define register N as HLS (U=1: hue, lightness, saturation) or
+//               RGB (U=2: red, green, blue, each 0-100), and select it
+//   "A;B;W;H    raster attributes: the image is W x H pixels (aspect A:B is ignored)
+//   $           return to the start of the current six-pixel band
+//   -           move to the start of the next band
+// P2 = 1 leaves unpainted pixels transparent; otherwise they take color register 0.
+// Pixels are square: the P1 aspect ratio is ignored, as modern terminals do.
+
+// maxSixelSize caps each dimension of a decoded sixel image, in pixels
+const maxSixelSize = 4096
+
+// sixelRegisters is the number of color registers
+const sixelRegisters = 256
+
+// sixelDefaultPalette is the VT340 default palette, as RGB percentages
+var sixelDefaultPalette = [16][3]int{
+	{0, 0, 0}, {20, 20, 80}, {80, 13, 13}, {20, 80, 20},
+	{80, 20, 80}, {20, 80, 80}, {80, 80, 20}, {53, 53, 53},
+	{26, 26, 26}, {33, 33, 60}, {60, 26, 26}, {33, 60, 33},
+	{60, 33, 60}, {33, 60, 60}, {60, 60, 33}, {80, 80, 80},
+}
+
+// sixelDecoder holds the state of a sixel image being drawn
+type sixelDecoder struct {
+	palette     [sixelRegisters]color.NRGBA
+	current     color.NRGBA
+	pixels      [][]color.NRGBA // Rows of painted pixels (alpha 0 = unpainted)
+	x, y        int             // Current column, and top row of the current band
+	width       int             // Widest row painted
+	rasterW     int             // Size from raster attributes (0 = not given)
+	rasterH     int
+	transparent bool
+}
+
+// DecodeSixel decodes the data of a DCS sixel sequence
+// params are the DCS parameters (P1, P2, P3) and data is everything after the 'q'.
+// Returns nil if the data has no size.
+func DecodeSixel(params []int, data []byte) *image.NRGBA {
+	d := &sixelDecoder{transparent: len(params) > 1 && params[1] == 1}
+	for i, rgb := range sixelDefaultPalette {
+		d.palette[i] = sixelPercentRGB(rgb[0], rgb[1], rgb[2])
+	}
+	for i := len(sixelDefaultPalette); i < sixelRegisters; i++ {
+		d.palette[i] = color.NRGBA{A: 255}
+	}
+	d.current = d.palette[0]
+
+	// readNumbers reads ;-separated decimal numbers starting at data[i]
+	readNumbers := func(i int) ([]int, int) {
+		nums := []int{0}
+		for ; i < len(data); i++ {
+			c := data[i]
+			switch {
+			case c >= '0' && c <= '9':
+				n := &nums[len(nums)-1]
+				if *n < 1<<20 {
+					*n = *n*10 + int(c-'0')
+				}
+			case c == ';':
+				nums = append(nums, 0)
+			default:
+				return nums, i
+			}
+		}
+		return nums, i
+	}
+
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case c >= '?' && c <= '~':
+			d.paint(c-'?', 1)
+			i++
+		case c == '!':
+			nums, next := readNumbers(i + 1)
+			i = next
+			if i < len(data) && data[i] >= '?' && data[i] <= '~' {
+				d.paint(data[i]-'?', nums[0])
+				i++
+			}
+		case c == '#':
+			nums, next := readNumbers(i + 1)
+			i = next
+			d.selectColor(nums)
+		case c == '"':
+			nums, next := readNumbers(i + 1)
+			i = next
+			if len(nums) >= 4 {
+				d.rasterW, d.rasterH = min(nums[2], maxSixelSize), min(nums[3], maxSixelSize)
+			}
+		case c == '$':
+			d.x = 0
+			i++
+		case c == '-':
+			d.x = 0
+			d.y += 6
+			i++
+		default:
+			// Whitespace and anything unexpected is ignored
+			i++
+		}
+	}
+	return d.image()
+}
+
+// paint draws a sixel count times at the current position and advances
+func (d *sixelDecoder) paint(bits byte, count int) {
+	if count < 1 {
+		count = 1
+	}
+	end := min(d.x+count, maxSixelSize)
+	if bits != 0 {
+		for bit := 0; bit < 6; bit++ {
+			if bits&(1<<bit) == 0 || d.y+bit >= maxSixelSize {
+				continue
+			}
+			row := d.row(d.y + bit)
+			for len(*row) < end {
+				*row = append(*row, color.NRGBA{})
+			}
+			for x := d.x; x < end; x++ {
+				(*row)[x] = d.current
+			}
+		}
+		d.width = max(d.width, end)
+	}
+	d.x = end
+}
+
+// row returns the pixel row y, adding empty rows as needed
+func (d *sixelDecoder) row(y int) *[]color.NRGBA {
+	for len(d.pixels) <= y {
+		d.pixels = append(d.pixels, nil)
+	}
+	return &d.pixels[y]
+}
+
+// selectColor handles # with its numbers: select a register, or define and select it
+func (d *sixelDecoder) selectColor(nums []int) {
+	reg := nums[0]
+	if reg < 0 || reg >= sixelRegisters {
+		reg = reg % sixelRegisters
+	}
+	if len(nums) >= 5 {
+		switch nums[1] {
+		case 1:
+			d.palette[reg] = sixelHLS(nums[2], nums[3], nums[4])
+		case 2:
+			d.palette[reg] = sixelPercentRGB(nums[2], nums[3], nums[4])
+		}
+	}
+	d.current = d.palette[reg]
+}
+
+// image builds the decoded image
+func (d *sixelDecoder) image() *image.NRGBA {
+	width := max(d.width, d.rasterW)
+	height := max(len(d.pixels), d.rasterH)
+	if width == 0 || height == 0 {
+		return nil
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	background := d.palette[0]
+	for y := 0; y < height; y++ {
+		var row []color.NRGBA
+		if y < len(d.pixels) {
+			row = d.pixels[y]
+		}
+		for x := 0; x < width; x++ {
+			var c color.NRGBA
+			if x < len(row) {
+				c = row[x]
+			}
+			if c.A == 0 && !d.transparent {
+				c = background
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// sixelPercentRGB converts RGB percentages (0-100) to a color
+func sixelPercentRGB(r, g, b int) color.NRGBA {
+	scale := func(v int) uint8 {
+		return uint8(min(max(v, 0), 100) * 255 / 100)
+	}
+	return color.NRGBA{R: scale(r), G: scale(g), B: scale(b), A: 255}
+}
+
+// sixelHLS converts a sixel HLS color to RGB
+// Sixel hues start at blue: 0 is blue, 120 is red and 240 is green.
+func sixelHLS(hue, lightness, saturation int) color.NRGBA {
+	h := float64((hue+240)%360) / 360
+	l := float64(min(max(lightness, 0), 100)) / 100
+	s := float64(min(max(saturation, 0), 100)) / 100
+	if s == 0 {
+		v := uint8(l * 255)
+		return color.NRGBA{R: v, G: v, B: v, A: 255}
+	}
+	var q float64
+	if l < 0.5 {
+		q = l * (1 + s)
+	} else {
+		q = l + s - l*s
+	}
+	p := 2*l - q
+	channel := func(t float64) uint8 {
+		if t < 0 {
+			t++
+		}
+		if t > 1 {
+			t--
+		}
+		var v float64
+		switch {
+		case t < 1.0/6:
+			v = p + (q-p)*6*t
+		case t < 1.0/2:
+			v = q
+		case t < 2.0/3:
+			v = p + (q-p)*(2.0/3-t)*6
+		default:
+			v = p
+		}
+		return uint8(v*255 + 0.5)
+	}
+	return color.NRGBA{R: channel(h + 1.0/3), G: channel(h), B: channel(h - 1.0/3), A: 255}
+}
@@ -0,0 +1,180 @@
+package purfecterm
+
+import (
+	"math"
+	"time"
+)
+
+// Smooth scrolling
+// The buffer scrolls by whole lines, but the widgets scroll by pixels: SmoothScroll
+// keeps the position in pixels back into the scrollback, and the widgets show it as
+// the buffer scrolled to the line at or above it (Lines) with the cells drawn that
+// many pixels higher (Shift). Mouse wheel notches glide to their position over a few
+// frames; touchpad and touch screen movements follow the fingers and, once they
+// lift, coast to a stop (kinetic scrolling). Whenever the buffer's scroll offset
+// changes by other means (typing, the scrollbar, the magnetic zone) the position is
+// taken from it again, with no shift.
+
+// Smooth scrolling tuning
+const (
+	smoothGlideRate    = 18.0 // Fraction of a glide covered per second, as an exponential rate
+	smoothFriction     = 4.0  // Kinetic slowdown, as an exponential rate per second
+	smoothMinVelocity  = 20.0 // Pixels per second below which coasting stops
+	smoothVelocityTime = 100 * time.Millisecond
+)
+
+// scrollSample is a touch movement, for estimating the velocity when the fingers lift
+type scrollSample struct {
+	at time.Time
+	dy float64
+}
+
+// SmoothScroll is a pixel scroll position and its animation. The zero value is at
+// the bottom with nothing moving; call Sync before using it.
+// It is not safe for concurrent use; the widgets use it on the UI thread.
+type SmoothScroll struct {
+	pos        float64 // Pixels back into the scrollback
+	cellHeight int
+	maxOffset  int // The buffer's largest scroll offset, in lines
+
+	glide    float64 // Pixels still to glide
+	velocity float64 // Coasting speed in pixels per second (positive is back into the scrollback)
+	samples  []scrollSample
+	lastStep time.Time
+}
+
+// Sync takes the buffer's scroll offset, largest offset and the cell height. If the
+// offset isn't the line shown for the position, the position jumps to the offset
+// and any animation stops.
+func (s *SmoothScroll) Sync(offset, maxOffset, cellHeight int) {
+	s.maxOffset = maxOffset
+	if cellHeight != s.cellHeight || s.cellHeight <= 0 || offset != s.Lines() {
+		s.cellHeight = max(cellHeight, 1)
+		s.pos = float64(offset * s.cellHeight)
+		s.Stop()
+	}
+	s.pos = s.clamp(s.pos)
+}
+
+// Lines returns the buffer scroll offset to show the position with
+func (s *SmoothScroll) Lines() int {
+	if s.cellHeight <= 0 {
+		return 0
+	}
+	// A hair's tolerance keeps float error from showing an extra line
+	return int(math.Ceil(s.pos/float64(s.cellHeight) - 1e-6))
+}
+
+// Shift returns how many pixels higher than usual the cells are drawn at the given
+// buffer scroll offset: 0 unless the offset is Lines
+func (s *SmoothScroll) Shift(offset int) int {
+	lines := s.Lines()
+	if offset != lines {
+		return 0
+	}
+	return int(math.Round(float64(lines*s.cellHeight) - s.pos))
+}
+
+// Glide scrolls by dy pixels (positive is back into the scrollback) over the next
+// few frames, as for a mouse wheel notch
+func (s *SmoothScroll) Glide(dy float64) {
+	s.velocity = 0
+	s.samples = nil
+	s.glide += dy
+}
+
+// Drag scrolls by dy pixels at once, as for a touchpad or touch screen movement,
+// remembering the movement for when the fingers lift
+func (s *SmoothScroll) Drag(dy float64, now time.Time) {
+	s.glide = 0
+	s.velocity = 0
+	s.pos = s.clamp(s.pos + dy)
+	s.samples = append(s.samples, scrollSample{at: now, dy: dy})
+	s.trimSamples(now)
+}
+
+// Release starts coasting at the speed of the last movements, as when the fingers lift
+func (s *SmoothScroll) Release(now time.Time) {
+	s.trimSamples(now)
+	if len(s.samples) > 0 {
+		var total float64
+		for _, sample := range s.samples {
+			total += sample.dy
+		}
+		elapsed := now.Sub(s.samples[0].at)
+		s.velocity = total / max(elapsed, 16*time.Millisecond).Seconds()
+	}
+	s.samples = nil
+	if math.Abs(s.velocity) < smoothMinVelocity {
+		s.velocity = 0
+	}
+	s.lastStep = now
+}
+
+// Stop ends any glide or coasting where it is
+func (s *SmoothScroll) Stop() {
+	s.glide = 0
+	s.velocity = 0
+	s.samples = nil
+}
+
+// Moving returns whether a glide or coast is under way, so the widget should keep
+// calling Step
+func (s *SmoothScroll) Moving() bool {
+	return s.glide != 0 || s.velocity != 0
+}
+
+// Step advances a glide or coast to now, returning whether it is still moving
+func (s *SmoothScroll) Step(now time.Time) bool {
+	if !s.Moving() {
+		s.lastStep = now
+		return false
+	}
+	dt := now.Sub(s.lastStep).Seconds()
+	if s.lastStep.IsZero() || dt <= 0 || dt > 0.1 {
+		// First frame, or the timer stalled: move as for one frame
+		dt = 1.0 / 60
+	}
+	s.lastStep = now
+
+	before := s.pos
+	if s.glide != 0 {
+		move := s.glide * (1 - math.Exp(-smoothGlideRate*dt))
+		if math.Abs(s.glide-move) < 0.5 {
+			move = s.glide
+		}
+		s.glide -= move
+		s.pos = s.clamp(s.pos + move)
+	}
+	if s.velocity != 0 {
+		s.pos = s.clamp(s.pos + s.velocity*dt)
+		s.velocity *= math.Exp(-smoothFriction * dt)
+		if math.Abs(s.velocity) < smoothMinVelocity {
+			s.velocity = 0
+		}
+	}
+	if s.pos == before && (s.pos == 0 || s.pos == s.maxPos()) {
+		// Ran into an end
+		s.Stop()
+	}
+	return s.Moving()
+}
+
+// trimSamples drops the movements too old to count toward the velocity
+func (s *SmoothScroll) trimSamples(now time.Time) {
+	i := 0
+	for i < len(s.samples) && now.Sub(s.samples[i].at) > smoothVelocityTime {
+		i++
+	}
+	s.samples = s.samples[i:]
+}
+
+// maxPos is the largest position, in pixels
+func (s *SmoothScroll) maxPos() float64 {
+	return float64(s.maxOffset * s.cellHeight)
+}
+
+// clamp keeps a position between the bottom and the top of the scrollback
+func (s *SmoothScroll) clamp(pos float64) float64 {
+	return math.Max(0, math.Min(pos, s.maxPos()))
+}
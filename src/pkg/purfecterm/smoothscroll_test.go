@@ -0,0 +1,103 @@
+package purfecterm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSmoothScrollDrag(t *testing.T) {
+	var s SmoothScroll
+	s.Sync(0, 50, 20)
+	now := time.Now()
+
+	s.Drag(5, now)
+	if s.Lines() != 1 || s.Shift(1) != 15 {
+		t.Errorf("after 5px: lines %d shift %d, want 1 and 15", s.Lines(), s.Shift(1))
+	}
+	if s.Shift(0) != 0 {
+		t.Errorf("shift at another offset: got %d, want 0", s.Shift(0))
+	}
+	s.Drag(35, now)
+	if s.Lines() != 2 || s.Shift(2) != 0 {
+		t.Errorf("after 40px: lines %d shift %d, want 2 and 0", s.Lines(), s.Shift(2))
+	}
+
+	// Clamped to the ends
+	s.Drag(-100, now)
+	if s.Lines() != 0 || s.Shift(0) != 0 {
+		t.Errorf("past the bottom: lines %d shift %d, want 0 and 0", s.Lines(), s.Shift(0))
+	}
+	s.Drag(5000, now)
+	if s.Lines() != 50 {
+		t.Errorf("past the top: lines %d, want 50", s.Lines())
+	}
+}
+
+func TestSmoothScrollSync(t *testing.T) {
+	var s SmoothScroll
+	s.Sync(3, 50, 20)
+	if s.Lines() != 3 || s.Shift(3) != 0 {
+		t.Fatalf("synced: lines %d shift %d, want 3 and 0", s.Lines(), s.Shift(3))
+	}
+	s.Drag(-10, time.Now())
+	s.Sync(3, 50, 20)
+	if s.Lines() != 3 || s.Shift(3) != 10 {
+		t.Errorf("same offset keeps the shift: lines %d shift %d, want 3 and 10", s.Lines(), s.Shift(3))
+	}
+	s.Sync(0, 50, 20)
+	if s.Lines() != 0 || s.Shift(0) != 0 {
+		t.Errorf("moved offset: lines %d shift %d, want 0 and 0", s.Lines(), s.Shift(0))
+	}
+}
+
+func TestSmoothScrollGlide(t *testing.T) {
+	var s SmoothScroll
+	s.Sync(0, 50, 20)
+	s.Glide(60)
+	now := time.Now()
+	steps := 0
+	for s.Step(now) {
+		now = now.Add(16 * time.Millisecond)
+		if steps++; steps > 200 {
+			t.Fatal("glide never finished")
+		}
+	}
+	if steps < 3 {
+		t.Errorf("glide took %d frames, want it spread over several", steps)
+	}
+	if s.Lines() != 3 || s.Shift(3) != 0 {
+		t.Errorf("after glide: lines %d shift %d, want 3 and 0", s.Lines(), s.Shift(3))
+	}
+}
+
+func TestSmoothScrollKinetic(t *testing.T) {
+	var s SmoothScroll
+	s.Sync(0, 1000, 20)
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		s.Drag(20, now)
+		now = now.Add(10 * time.Millisecond)
+	}
+	released := s.Lines()
+	s.Release(now)
+	if !s.Moving() {
+		t.Fatal("not coasting after a fast swipe")
+	}
+	steps := 0
+	for s.Step(now) {
+		now = now.Add(16 * time.Millisecond)
+		if steps++; steps > 1000 {
+			t.Fatal("coasting never stopped")
+		}
+	}
+	if s.Lines() <= released {
+		t.Errorf("coasting didn't move on: lines %d, released at %d", s.Lines(), released)
+	}
+
+	// A slow finish doesn't coast
+	s.Drag(1, now)
+	s.Release(now.Add(time.Second))
+	if s.Moving() {
+		t.Error("coasting after the fingers rested")
+	}
+}
@@ -0,0 +1,479 @@
+package purfecterm
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+)
+
+// Snapshots
+// SaveSnapshot writes the whole emulator state — screen, scrollback, cursor and saved
+// cursor, attributes, modes, margins, hyperlinks, images, palettes, glyphs, sprites and
+// splits — so RestoreSnapshot can resume exactly where it left off, in the same
+// buffer or another one (to move a session between windows). SaveScrollbackANS only
+// keeps what the text looks like. View state (scroll position, selection, search) and
+// the host's settings (scrollback size, preferred theme) aren't part of a snapshot.
+// Tab stops are fixed every 8 columns and the alternate screen isn't implemented, so
+// neither needs saving.
+//
+// The format is snapshotMagic followed by a gzipped gob of bufferSnapshot. Adding
+// fields to bufferSnapshot keeps older snapshots readable; other changes need a new
+// snapshotVersion.
+
+// snapshotMagic starts every snapshot
+const snapshotMagic = "PURFSNAP"
+
+// snapshotVersion is the format version written in snapshots
+const snapshotVersion = 1
+
+// ErrNotSnapshot is returned by RestoreSnapshot for data that isn't a snapshot
+var ErrNotSnapshot = errors.New("not a terminal snapshot")
+
+// IsSnapshot returns whether data (or its first few bytes) is a snapshot
+func IsSnapshot(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(snapshotMagic))
+}
+
+// bufferSnapshot is the saved state of a Buffer
+type bufferSnapshot struct {
+	Version int
+
+	LogicalCols, LogicalRows int
+
+	CursorX, CursorY int
+	CursorVisible    bool
+	CursorShape      int
+	CursorBlink      int
+	SavedCursorX     int
+	SavedCursorY     int
+	SavedOriginMode  bool
+
+	CurrentFg, CurrentBg     Color
+	CurrentBold              bool
+	CurrentItalic            bool
+	CurrentUnderline         bool
+	CurrentUnderlineStyle    UnderlineStyle
+	CurrentUnderlineColor    Color
+	CurrentHasUnderlineColor bool
+	CurrentReverse           bool
+	CurrentBlink             bool
+	CurrentStrikethrough     bool
+	CurrentOverline          bool
+	CurrentFlexWidth         bool
+	CurrentLink              int
+	CurrentBGP               int
+	CurrentXFlip             bool
+	CurrentYFlip             bool
+
+	BracketedPasteMode bool
+	MouseTracking      MouseTracking
+	MouseSGR           bool
+	AutoLinks          bool
+	FlexWidthMode      bool
+	VisualWidthWrap    bool
+	AmbiguousWidthMode AmbiguousWidthMode
+	AutoScrollDisabled bool
+	AutoWrapMode       bool
+	SmartWordWrap      bool
+	ScrollbackDisabled bool
+	DarkTheme          bool
+	ColumnMode132      bool
+	ColumnMode40       bool
+	LineDensity        int
+
+	MarginTop, MarginBottom int
+	MarginLeft, MarginRight int
+	LeftRightMarginMode     bool
+	OriginMode              bool
+	NoClearOnColumnChange   bool
+
+	Screen     []snapshotLine
+	ScreenInfo ScreenInfo
+	Scrollback []snapshotLine
+
+	Links      []snapshotLink
+	NextLinkID int
+
+	Images      []snapshotImage
+	NextImageID int
+
+	Palettes          map[int]*Palette
+	CustomGlyphs      map[rune]*CustomGlyph
+	Sprites           map[int]*Sprite
+	CropRects         map[int]*CropRectangle
+	SpriteUnitX       int
+	SpriteUnitY       int
+	WidthCrop         int
+	HeightCrop        int
+	ScreenSplits      map[int]*ScreenSplit
+	SplitContentWidth int
+}
+
+// snapshotLine is a line of the screen or scrollback, packed where possible
+type snapshotLine struct {
+	Packed *packedLine
+	Cells  []Cell // Used when the line couldn't be packed
+	Info   LineInfo
+}
+
+// snapshotLink is a hyperlink and its Cell.LinkID
+type snapshotLink struct {
+	LinkID int
+	ID     string
+	URI    string
+}
+
+// snapshotImage is an inline image, anchored to a line counted from the oldest
+// scrollback line
+type snapshotImage struct {
+	ID         int
+	Image      *image.NRGBA
+	Col        int
+	Cols, Rows int
+	Line       int64
+}
+
+// SaveSnapshot writes the buffer's complete state to w
+func (b *Buffer) SaveSnapshot(w io.Writer) error {
+	if _, err := io.WriteString(w, snapshotMagic); err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(w)
+	b.mu.RLock()
+	err := gob.NewEncoder(zw).Encode(b.snapshot()) // Shares the buffer's maps, so under the lock
+	b.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// RestoreSnapshot replaces the buffer's state with a snapshot read from r
+// The buffer keeps its physical size, scrollback size and callbacks; if the
+// snapshot can't be read, or is truncated or damaged, the buffer is left as it was.
+func (b *Buffer) RestoreSnapshot(r io.Reader) error {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(snapshotMagic))
+	if err != nil || !IsSnapshot(magic) {
+		return ErrNotSnapshot
+	}
+	br.Discard(len(snapshotMagic))
+	zr, err := gzip.NewReader(br)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	var snap bufferSnapshot
+	if err := gob.NewDecoder(zr).Decode(&snap); err != nil {
+		return fmt.Errorf("corrupt terminal snapshot: %w", err)
+	}
+	// Reading to the end checks the gzip trailer, so a snapshot cut off after the
+	// state itself is still refused
+	if _, err := io.Copy(io.Discard, zr); err != nil {
+		return fmt.Errorf("corrupt terminal snapshot: %w", err)
+	}
+	if snap.Version != snapshotVersion {
+		return fmt.Errorf("unsupported terminal snapshot version %d", snap.Version)
+	}
+	if err := snap.check(); err != nil {
+		return fmt.Errorf("corrupt terminal snapshot: %w", err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	themeChanged := b.darkTheme != snap.DarkTheme
+	b.restore(&snap)
+	b.markDirty()
+	b.notifyScaleChange()
+	if themeChanged {
+		b.notifyThemeChange()
+	}
+	return nil
+}
+
+// check returns an error for state that would break the buffer once restored, such
+// as a packed line whose parts disagree about its length
+func (snap *bufferSnapshot) check() error {
+	for i, line := range snap.Scrollback {
+		if line.Packed != nil && !line.Packed.valid() {
+			return fmt.Errorf("scrollback line %d is damaged", i)
+		}
+	}
+	for i, img := range snap.Images {
+		if img.Image == nil {
+			return fmt.Errorf("image %d has no pixels", i)
+		}
+	}
+	return nil
+}
+
+// snapshot returns the buffer's state
+// Must be called with the lock held (a read lock is enough).
+func (b *Buffer) snapshot() *bufferSnapshot {
+	snap := &bufferSnapshot{
+		Version:     snapshotVersion,
+		LogicalCols: b.logicalCols,
+		LogicalRows: b.logicalRows,
+
+		CursorX:         b.cursorX,
+		CursorY:         b.cursorY,
+		CursorVisible:   b.cursorVisible,
+		CursorShape:     b.cursorShape,
+		CursorBlink:     b.cursorBlink,
+		SavedCursorX:    b.savedCursorX,
+		SavedCursorY:    b.savedCursorY,
+		SavedOriginMode: b.savedOriginMode,
+
+		CurrentFg:                b.currentFg,
+		CurrentBg:                b.currentBg,
+		CurrentBold:              b.currentBold,
+		CurrentItalic:            b.currentItalic,
+		CurrentUnderline:         b.currentUnderline,
+		CurrentUnderlineStyle:    b.currentUnderlineStyle,
+		CurrentUnderlineColor:    b.currentUnderlineColor,
+		CurrentHasUnderlineColor: b.currentHasUnderlineColor,
+		CurrentReverse:           b.currentReverse,
+		CurrentBlink:             b.currentBlink,
+		CurrentStrikethrough:     b.currentStrikethrough,
+		CurrentOverline:          b.currentOverline,
+		CurrentFlexWidth:         b.currentFlexWidth,
+		CurrentLink:              b.currentLink,
+		CurrentBGP:               b.currentBGP,
+		CurrentXFlip:             b.currentXFlip,
+		CurrentYFlip:             b.currentYFlip,
+
+		BracketedPasteMode: b.bracketedPasteMode,
+		MouseTracking:      b.mouseTracking,
+		MouseSGR:           b.mouseSGR,
+		AutoLinks:          b.autoLinks,
+		FlexWidthMode:      b.flexWidthMode,
+		VisualWidthWrap:    b.visualWidthWrap,
+		AmbiguousWidthMode: b.ambiguousWidthMode,
+		AutoScrollDisabled: b.autoScrollDisabled,
+		AutoWrapMode:       b.autoWrapMode,
+		SmartWordWrap:      b.smartWordWrap,
+		ScrollbackDisabled: b.scrollbackDisabled,
+		DarkTheme:          b.darkTheme,
+		ColumnMode132:      b.columnMode132,
+		ColumnMode40:       b.columnMode40,
+		LineDensity:        b.lineDensity,
+
+		MarginTop:             b.marginTop,
+		MarginBottom:          b.marginBottom,
+		MarginLeft:            b.marginLeft,
+		MarginRight:           b.marginRight,
+		LeftRightMarginMode:   b.leftRightMarginMode,
+		OriginMode:            b.originMode,
+		NoClearOnColumnChange: b.noClearOnColumnChange,
+
+		ScreenInfo:  b.screenInfo,
+		NextLinkID:  b.nextLinkID,
+		NextImageID: b.nextImageID,
+
+		Palettes:          b.palettes,
+		CustomGlyphs:      b.customGlyphs,
+		Sprites:           b.sprites,
+		CropRects:         b.cropRects,
+		SpriteUnitX:       b.spriteUnitX,
+		SpriteUnitY:       b.spriteUnitY,
+		WidthCrop:         b.widthCrop,
+		HeightCrop:        b.heightCrop,
+		ScreenSplits:      b.screenSplits,
+		SplitContentWidth: b.splitContentWidth,
+	}
+
+	snap.Screen = make([]snapshotLine, len(b.screen))
+	for i, line := range b.screen {
+		snap.Screen[i] = snapshotLine{Cells: line, Info: b.lineInfos[i]}
+	}
+	snap.Scrollback = make([]snapshotLine, len(b.scrollback))
+	for i, line := range b.scrollback {
+		p := b.scrollbackPacked[i]
+		if p == nil {
+			p = packLine(line)
+		}
+		if p != nil {
+			line = nil
+		}
+		snap.Scrollback[i] = snapshotLine{Packed: p, Cells: line, Info: b.scrollbackInfo[i]}
+	}
+
+	for linkID, link := range b.links {
+		snap.Links = append(snap.Links, snapshotLink{LinkID: linkID, ID: link.id, URI: link.uri})
+	}
+	for _, img := range b.images {
+		snap.Images = append(snap.Images, snapshotImage{
+			ID:    img.ID,
+			Image: img.Image,
+			Col:   img.Col,
+			Cols:  img.Cols,
+			Rows:  img.Rows,
+			Line:  img.line - b.linesDropped,
+		})
+	}
+	return snap
+}
+
+// restore replaces the buffer's state with a snapshot, fitting it to the
+// buffer's physical size and scrollback size
+// Must be called with the lock held.
+func (b *Buffer) restore(snap *bufferSnapshot) {
+	b.logicalCols = snap.LogicalCols
+	b.logicalRows = snap.LogicalRows
+
+	b.cursorX = snap.CursorX
+	b.cursorY = snap.CursorY
+	b.cursorVisible = snap.CursorVisible
+	b.cursorShape = snap.CursorShape
+	b.cursorBlink = snap.CursorBlink
+	b.savedCursorX = snap.SavedCursorX
+	b.savedCursorY = snap.SavedCursorY
+	b.savedOriginMode = snap.SavedOriginMode
+
+	b.currentFg = snap.CurrentFg
+	b.currentBg = snap.CurrentBg
+	b.currentBold = snap.CurrentBold
+	b.currentItalic = snap.CurrentItalic
+	b.currentUnderline = snap.CurrentUnderline
+	b.currentUnderlineStyle = snap.CurrentUnderlineStyle
+	b.currentUnderlineColor = snap.CurrentUnderlineColor
+	b.currentHasUnderlineColor = snap.CurrentHasUnderlineColor
+	b.currentReverse = snap.CurrentReverse
+	b.currentBlink = snap.CurrentBlink
+	b.currentStrikethrough = snap.CurrentStrikethrough
+	b.currentOverline = snap.CurrentOverline
+	b.currentFlexWidth = snap.CurrentFlexWidth
+	b.currentLink = snap.CurrentLink
+	b.currentBGP = snap.CurrentBGP
+	b.currentXFlip = snap.CurrentXFlip
+	b.currentYFlip = snap.CurrentYFlip
+
+	b.bracketedPasteMode = snap.BracketedPasteMode
+	b.mouseTracking = snap.MouseTracking
+	b.mouseSGR = snap.MouseSGR
+	b.autoLinks = snap.AutoLinks
+	b.flexWidthMode = snap.FlexWidthMode
+	b.visualWidthWrap = snap.VisualWidthWrap
+	b.ambiguousWidthMode = snap.AmbiguousWidthMode
+	b.autoScrollDisabled = snap.AutoScrollDisabled
+	b.autoWrapMode = snap.AutoWrapMode
+	b.smartWordWrap = snap.SmartWordWrap
+	b.scrollbackDisabled = snap.ScrollbackDisabled
+	b.darkTheme = snap.DarkTheme
+	b.columnMode132 = snap.ColumnMode132
+	b.columnMode40 = snap.ColumnMode40
+	b.lineDensity = snap.LineDensity
+
+	b.marginTop = snap.MarginTop
+	b.marginBottom = snap.MarginBottom
+	b.marginLeft = snap.MarginLeft
+	b.marginRight = snap.MarginRight
+	b.leftRightMarginMode = snap.LeftRightMarginMode
+	b.originMode = snap.OriginMode
+	b.noClearOnColumnChange = snap.NoClearOnColumnChange
+
+	b.screen = make([][]Cell, len(snap.Screen))
+	b.lineInfos = make([]LineInfo, len(snap.Screen))
+	for i, line := range snap.Screen {
+		b.screen[i] = line.Cells
+		if b.screen[i] == nil {
+			b.screen[i] = b.makeEmptyLine()
+		}
+		b.lineInfos[i] = line.Info
+	}
+	b.screenInfo = snap.ScreenInfo
+
+	// The newest lines are kept unpacked, as pushLineToScrollback keeps them
+	b.scrollback = make([][]Cell, len(snap.Scrollback))
+	b.scrollbackInfo = make([]LineInfo, len(snap.Scrollback))
+	b.scrollbackPacked = make([]*packedLine, len(snap.Scrollback))
+	for i, line := range snap.Scrollback {
+		b.scrollbackInfo[i] = line.Info
+		switch {
+		case line.Packed == nil:
+			b.scrollback[i] = line.Cells
+		case i >= len(snap.Scrollback)-scrollbackUnpackedLines:
+			b.scrollback[i] = line.Packed.unpack()
+		default:
+			b.scrollbackPacked[i] = line.Packed
+		}
+	}
+	b.clearUnpackedCache()
+
+	b.links = make(map[int]hyperlink, len(snap.Links))
+	b.linkIDs = make(map[hyperlink]int)
+	for _, l := range snap.Links {
+		link := hyperlink{id: l.ID, uri: l.URI}
+		b.links[l.LinkID] = link
+		if link.id != "" {
+			b.linkIDs[link] = l.LinkID
+		}
+	}
+	b.nextLinkID = max(snap.NextLinkID, 1)
+
+	b.images = b.images[:0]
+	for _, img := range snap.Images {
+		b.images = append(b.images, &InlineImage{
+			ID:    img.ID,
+			Image: img.Image,
+			Col:   img.Col,
+			Cols:  img.Cols,
+			Rows:  img.Rows,
+			line:  b.linesDropped + img.Line,
+		})
+	}
+	b.nextImageID = max(snap.NextImageID, 1)
+
+	b.palettes = orEmpty(snap.Palettes)
+	b.customGlyphs = orEmpty(snap.CustomGlyphs)
+	b.sprites = orEmpty(snap.Sprites)
+	b.cropRects = orEmpty(snap.CropRects)
+	b.screenSplits = orEmpty(snap.ScreenSplits)
+	b.spriteUnitX = snap.SpriteUnitX
+	b.spriteUnitY = snap.SpriteUnitY
+	b.widthCrop = snap.WidthCrop
+	b.heightCrop = snap.HeightCrop
+	b.splitContentWidth = snap.SplitContentWidth
+
+	// Fit the scrollback to this buffer's size
+	dropped := 0
+	if b.scrollbackDisabled {
+		dropped = len(b.scrollback)
+	} else if len(b.scrollback) > b.maxScrollback {
+		dropped = len(b.scrollback) - b.maxScrollback
+	}
+	if dropped > 0 {
+		b.scrollback = b.scrollback[dropped:]
+		b.scrollbackInfo = b.scrollbackInfo[dropped:]
+		b.scrollbackPacked = b.scrollbackPacked[dropped:]
+		b.linesDropped += int64(dropped)
+		b.pruneImages()
+	}
+
+	// Fit the screen to this buffer's physical size, which may differ from the
+	// saved one
+	if b.logicalRows == 0 {
+		b.adjustScreenToRows(b.rows)
+	}
+	b.cursorX = max(0, min(b.cursorX, b.EffectiveCols()-1))
+	b.cursorY = max(0, min(b.cursorY, b.EffectiveRows()-1))
+
+	// View state starts fresh
+	b.scrollOffset = 0
+	b.horizOffset = 0
+	b.selectionActive = false
+	b.search = nil
+}
+
+// orEmpty returns m, or an empty map if m is nil (gob leaves empty maps nil)
+func orEmpty[K comparable, V any](m map[K]V) map[K]V {
+	if m == nil {
+		return make(map[K]V)
+	}
+	return m
+}
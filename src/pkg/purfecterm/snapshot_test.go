@@ -0,0 +1,148 @@
+package purfecterm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// snapshotTestBuffer returns a buffer with scrollback, attributes, a hyperlink and
+// the cursor partway along a line
+func snapshotTestBuffer(cols, rows int) *Buffer {
+	b := NewBuffer(cols, rows, 100)
+	p := NewParser(b)
+	p.ParseString("\x1b[1;31mfirst\x1b[m line\r\nsecond é\r\n")
+	p.ParseString("\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\\r\n")
+	p.ParseString("wide 世界\r\nlast\x1b[4m und")
+	return b
+}
+
+// bufferLines returns the cells and info of every scrollback and screen row
+func bufferLines(b *Buffer) ([][]Cell, []LineInfo) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	var lines [][]Cell
+	var infos []LineInfo
+	for y := 0; y < len(b.scrollback)+len(b.screen); y++ {
+		line, info := b.getLineByAbsoluteY(y)
+		lines = append(lines, append([]Cell(nil), line...))
+		infos = append(infos, info)
+	}
+	return lines, infos
+}
+
+func saveSnapshot(t *testing.T, b *Buffer) []byte {
+	t.Helper()
+	var data bytes.Buffer
+	if err := b.SaveSnapshot(&data); err != nil {
+		t.Fatal(err)
+	}
+	return data.Bytes()
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	saved := snapshotTestBuffer(12, 3)
+	data := saveSnapshot(t, saved)
+
+	b := NewBuffer(12, 3, 100)
+	if err := b.RestoreSnapshot(bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	wantLines, wantInfos := bufferLines(saved)
+	gotLines, gotInfos := bufferLines(b)
+	if !reflect.DeepEqual(gotLines, wantLines) || !reflect.DeepEqual(gotInfos, wantInfos) {
+		t.Errorf("restored rows %q, want %q", reflowRows(b), reflowRows(saved))
+	}
+	if x, y := b.GetCursor(); x != 8 || y != 2 {
+		t.Errorf("cursor: got (%d, %d), want (8, 2)", x, y)
+	}
+	if got := b.GetHyperlink(gotLines[2][0].LinkID); got != "https://example.com" {
+		t.Errorf("hyperlink: got %q", got)
+	}
+
+	// The current attributes carry over to what is written next
+	NewParser(b).ParseString("x")
+	if cell := b.GetCell(8, 2); !cell.Underline {
+		t.Error("underline not restored")
+	}
+}
+
+func TestSnapshotRestoreSmaller(t *testing.T) {
+	saved := snapshotTestBuffer(12, 4)
+	data := saveSnapshot(t, saved)
+
+	// The rows that don't fit move to the scrollback rather than being lost, and the
+	// cursor stays on its text
+	b := NewBuffer(10, 2, 100)
+	if err := b.RestoreSnapshot(bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := reflowRows(b), reflowRows(saved); !reflect.DeepEqual(got, want) {
+		t.Errorf("rows: got %q, want %q", got, want)
+	}
+	x, y := b.GetCursor()
+	if cell := b.GetCell(x-1, y); x != 8 || y != 1 || cell.Char != 'd' {
+		t.Errorf("cursor: got (%d, %d) after %q, want (8, 1) after 'd'", x, y, cell.Char)
+	}
+}
+
+func TestSnapshotCorrupt(t *testing.T) {
+	data := saveSnapshot(t, snapshotTestBuffer(12, 3))
+
+	// damaged encodes a snapshot of the test buffer after change damages it
+	damaged := func(change func(*bufferSnapshot)) []byte {
+		b := snapshotTestBuffer(12, 3)
+		b.mu.RLock()
+		snap := b.snapshot()
+		b.mu.RUnlock()
+		change(snap)
+		var out bytes.Buffer
+		out.WriteString(snapshotMagic)
+		zw := gzip.NewWriter(&out)
+		if err := gob.NewEncoder(zw).Encode(snap); err != nil {
+			t.Fatal(err)
+		}
+		zw.Close()
+		return out.Bytes()
+	}
+	flipped := append([]byte(nil), data...)
+	flipped[len(flipped)/2] ^= 0xFF
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"magic only", data[:len(snapshotMagic)]},
+		{"truncated in the header", data[:len(snapshotMagic)+4]},
+		{"truncated halfway", data[:len(data)/2]},
+		{"truncated trailer", data[:len(data)-4]},
+		{"missing last byte", data[:len(data)-1]},
+		{"flipped byte", flipped},
+		{"packed line too short", damaged(func(s *bufferSnapshot) { s.Scrollback[0].Packed.Cells++ })},
+		{"mark past the line", damaged(func(s *bufferSnapshot) {
+			p := s.Scrollback[0].Packed
+			p.Combining = append(p.Combining, packedMark{Col: p.Cells, Marks: "\u0301"})
+		})},
+		{"negative run", damaged(func(s *bufferSnapshot) { s.Scrollback[0].Packed.Runs[0].Count = -1 })},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBuffer(12, 3, 100)
+			NewParser(b).ParseString("kept")
+			err := b.RestoreSnapshot(bytes.NewReader(tt.data))
+			if err == nil {
+				t.Fatal("restored a damaged snapshot")
+			}
+			if len(tt.data) < len(snapshotMagic) && !errors.Is(err, ErrNotSnapshot) {
+				t.Errorf("got %v, want ErrNotSnapshot", err)
+			}
+			if got := reflowRows(b); !reflect.DeepEqual(got, []string{"kept"}) {
+				t.Errorf("buffer changed to %q", got)
+			}
+		})
+	}
+}
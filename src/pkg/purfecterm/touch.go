@@ -0,0 +1,53 @@
+package purfecterm
+
+import "math"
+
+// Touch gestures
+// On touch screens, two fingers dragged together scroll the scrollback, following
+// them as touchpads do (see smoothscroll.go), and spread apart or pinched together
+// they zoom the font (see zoom.go). Holding one finger still opens the context
+// menu, as a right click does. The widgets take the two-finger gestures from the
+// platform as the fingers' midpoint and how much their spread has scaled since
+// they went down, and TwoFingerGesture tells scrolling from zooming.
+
+// PinchThreshold is how far the fingers' spread must change, as a fraction, before
+// a two-finger gesture zooms rather than scrolls
+const PinchThreshold = 0.15
+
+// TwoFingerGesture follows a two-finger gesture from Begin to its end
+// It is not safe for concurrent use; the widgets use it on the UI thread.
+type TwoFingerGesture struct {
+	lastY     float64 // Midpoint at the last update
+	zoom      float64 // Zoom when pinching began
+	pinchBase float64 // Scale when pinching began, 0 while scrolling
+}
+
+// Begin starts a gesture with the fingers' midpoint at y and the font at zoom
+func (g *TwoFingerGesture) Begin(y, zoom float64) {
+	*g = TwoFingerGesture{lastY: y, zoom: zoom}
+}
+
+// Update takes the fingers' midpoint and the scale of their spread since Begin.
+// It returns how many pixels to scroll (positive moves the content down, back into
+// the scrollback) and the zoom to set, 0 to leave the zoom alone. Once the spread
+// has changed by PinchThreshold the gesture only zooms, from the zoom it had then
+// so it doesn't jump.
+func (g *TwoFingerGesture) Update(y, scale float64) (scroll, zoom float64) {
+	dy := y - g.lastY
+	g.lastY = y
+	if scale <= 0 || math.IsNaN(scale) {
+		return dy, 0
+	}
+	if g.pinchBase == 0 {
+		if math.Abs(scale-1) < PinchThreshold {
+			return dy, 0
+		}
+		g.pinchBase = scale
+	}
+	return 0, ClampZoom(g.zoom * scale / g.pinchBase)
+}
+
+// Pinching returns whether the gesture has become a pinch
+func (g *TwoFingerGesture) Pinching() bool {
+	return g.pinchBase != 0
+}
@@ -0,0 +1,41 @@
+package purfecterm
+
+import "testing"
+
+func TestTwoFingerGestureScrolls(t *testing.T) {
+	var g TwoFingerGesture
+	g.Begin(100, 1)
+	if scroll, zoom := g.Update(130, 1.05); scroll != 30 || zoom != 0 {
+		t.Errorf("Update(130, 1.05) = %v, %v, want 30, 0", scroll, zoom)
+	}
+	if scroll, zoom := g.Update(110, 0.9); scroll != -20 || zoom != 0 {
+		t.Errorf("Update(110, 0.9) = %v, %v, want -20, 0", scroll, zoom)
+	}
+	if g.Pinching() {
+		t.Error("Pinching() = true while the spread stayed within the threshold")
+	}
+}
+
+func TestTwoFingerGesturePinches(t *testing.T) {
+	var g TwoFingerGesture
+	g.Begin(100, 1.2)
+	// Crossing the threshold starts zooming from the zoom at Begin
+	if scroll, zoom := g.Update(104, 1.2); scroll != 0 || zoom != 1.2 {
+		t.Errorf("Update(104, 1.2) = %v, %v, want 0, 1.2", scroll, zoom)
+	}
+	if !g.Pinching() {
+		t.Error("Pinching() = false after the spread crossed the threshold")
+	}
+	if scroll, zoom := g.Update(150, 1.8); scroll != 0 || zoom != 1.8 {
+		t.Errorf("Update(150, 1.8) = %v, %v, want 0, 1.8", scroll, zoom)
+	}
+	if _, zoom := g.Update(150, 0.1); zoom != MinZoom {
+		t.Errorf("Update(150, 0.1) zoom = %v, want %v", zoom, MinZoom)
+	}
+
+	// A new gesture scrolls again
+	g.Begin(0, 1)
+	if scroll, zoom := g.Update(-10, 1); scroll != -10 || zoom != 0 {
+		t.Errorf("Update after Begin = %v, %v, want -10, 0", scroll, zoom)
+	}
+}
@@ -0,0 +1,37 @@
+package purfecterm
+
+import "math"
+
+// Zoom
+// Ctrl+wheel and Ctrl+Shift+plus/minus/0 (Cmd on macOS) zoom a terminal's font by
+// steps of ZoomStep, independently of the configured font size, which stays the
+// size at 100%. The widgets apply the zoom to the size given to SetFont.
+
+const (
+	MinZoom  = 0.5 // Smallest zoom factor
+	MaxZoom  = 3.0 // Largest zoom factor
+	ZoomStep = 0.1 // Zoom change per wheel click or key press
+
+	minZoomedFontSize = 4 // Smallest font size a zoom gives
+)
+
+// ClampZoom limits a zoom factor to MinZoom..MaxZoom, rounded to hundredths (1.0
+// for zero or less, as for an unset factor)
+func ClampZoom(zoom float64) float64 {
+	if zoom <= 0 || math.IsNaN(zoom) {
+		return 1.0
+	}
+	zoom = math.Round(zoom*100) / 100
+	return min(max(zoom, MinZoom), MaxZoom)
+}
+
+// StepZoom returns zoom changed by steps steps (negative to zoom out)
+func StepZoom(zoom float64, steps int) float64 {
+	return ClampZoom(ClampZoom(zoom) + float64(steps)*ZoomStep)
+}
+
+// ZoomFontSize returns the font size of size zoomed by zoom
+func ZoomFontSize(size int, zoom float64) int {
+	zoomed := int(math.Round(float64(size) * ClampZoom(zoom)))
+	return max(zoomed, min(size, minZoomedFontSize))
+}
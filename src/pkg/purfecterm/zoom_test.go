@@ -0,0 +1,59 @@
+package purfecterm
+
+import "testing"
+
+func TestStepZoom(t *testing.T) {
+	tests := []struct {
+		zoom  float64
+		steps int
+		want  float64
+	}{
+		{1.0, 1, 1.1},
+		{1.0, -1, 0.9},
+		{1.0, 3, 1.3},
+		{1.1, -1, 1.0},
+		{0.5, -1, MinZoom},
+		{2.95, 2, MaxZoom},
+		{0, 1, 1.1}, // Unset
+	}
+	for _, tt := range tests {
+		if got := StepZoom(tt.zoom, tt.steps); got != tt.want {
+			t.Errorf("StepZoom(%v, %d) = %v, want %v", tt.zoom, tt.steps, got, tt.want)
+		}
+	}
+}
+
+func TestStepZoomRepeated(t *testing.T) {
+	zoom := 1.0
+	for i := 0; i < 5; i++ {
+		zoom = StepZoom(zoom, 1)
+	}
+	for i := 0; i < 5; i++ {
+		zoom = StepZoom(zoom, -1)
+	}
+	if zoom != 1.0 {
+		t.Errorf("zoom after 5 steps in and out = %v, want 1", zoom)
+	}
+}
+
+func TestZoomFontSize(t *testing.T) {
+	tests := []struct {
+		size int
+		zoom float64
+		want int
+	}{
+		{12, 1.0, 12},
+		{12, 1.5, 18},
+		{12, 0.5, 6},
+		{14, 1.1, 15},
+		{6, 0.5, 4},  // Not below the minimum
+		{3, 0.5, 3},  // Already below it
+		{12, 0, 12},  // Unset
+		{12, 10, 36}, // Clamped to MaxZoom
+	}
+	for _, tt := range tests {
+		if got := ZoomFontSize(tt.size, tt.zoom); got != tt.want {
+			t.Errorf("ZoomFontSize(%d, %v) = %d, want %d", tt.size, tt.zoom, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package pawscript
+
+import (
+	"sort"
+)
+
+// PSLChangeKind says how a key differs between two PSL maps
+type PSLChangeKind int
+
+const (
+	PSLAdded PSLChangeKind = iota
+	PSLRemoved
+	PSLModified
+)
+
+// String returns the change kind as a word ("added", "removed" or "modified")
+func (k PSLChangeKind) String() string {
+	switch k {
+	case PSLAdded:
+		return "added"
+	case PSLRemoved:
+		return "removed"
+	default:
+		return "modified"
+	}
+}
+
+// PSLChange is one key-level difference found by DiffPSL
+// Old is nil for an added key and New is nil for a removed one.
+type PSLChange struct {
+	Path string // Dotted key path into nested maps (e.g. "term_colors.01_dark_blue")
+	Kind PSLChangeKind
+	Old  interface{}
+	New  interface{}
+}
+
+// PSLConflict is a key both sides of a MergePSL changed in different ways
+type PSLConflict struct {
+	Path   string
+	Base   interface{}
+	Ours   interface{}
+	Theirs interface{}
+}
+
+// DiffPSL lists the key-level differences between two PSL maps, sorted by path
+// Nested maps are compared key by key; any other value (including lists) is compared whole.
+// Values are equal when they serialize the same, so an int and an equal-valued int64 match.
+func DiffPSL(oldMap, newMap PSLMap) []PSLChange {
+	var changes []PSLChange
+	diffPSLMaps(oldMap, newMap, "", &changes)
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+func diffPSLMaps(oldMap, newMap PSLMap, prefix string, changes *[]PSLChange) {
+	for key, oldValue := range oldMap {
+		path := prefix + key
+		newValue, exists := newMap[key]
+		switch {
+		case !exists:
+			*changes = append(*changes, PSLChange{Path: path, Kind: PSLRemoved, Old: oldValue})
+		case pslEqual(oldValue, newValue):
+		default:
+			oldNested, oldIsMap := oldValue.(PSLMap)
+			newNested, newIsMap := newValue.(PSLMap)
+			if oldIsMap && newIsMap {
+				diffPSLMaps(oldNested, newNested, path+".", changes)
+			} else {
+				*changes = append(*changes, PSLChange{Path: path, Kind: PSLModified, Old: oldValue, New: newValue})
+			}
+		}
+	}
+	for key, newValue := range newMap {
+		if _, exists := oldMap[key]; !exists {
+			*changes = append(*changes, PSLChange{Path: prefix + key, Kind: PSLAdded, New: newValue})
+		}
+	}
+}
+
+// MergePSL combines two sets of changes made to the same base map
+// A key changed on only one side takes that side's value; a key both sides changed the
+// same way takes it once. Nested maps are merged key by key. When both sides changed a
+// key differently, ours is kept and the key is reported as a conflict. The inputs are
+// not modified.
+func MergePSL(base, ours, theirs PSLMap) (PSLMap, []PSLConflict) {
+	var conflicts []PSLConflict
+	merged := mergePSLMaps(base, ours, theirs, "", &conflicts)
+	sort.SliceStable(conflicts, func(i, j int) bool {
+		return conflicts[i].Path < conflicts[j].Path
+	})
+	return merged, conflicts
+}
+
+func mergePSLMaps(base, ours, theirs PSLMap, prefix string, conflicts *[]PSLConflict) PSLMap {
+	merged := PSLMap{}
+	keys := make(map[string]bool)
+	for _, m := range []PSLMap{base, ours, theirs} {
+		for key := range m {
+			keys[key] = true
+		}
+	}
+
+	for key := range keys {
+		baseValue, inBase := base[key]
+		ourValue, inOurs := ours[key]
+		theirValue, inTheirs := theirs[key]
+		same := func(aIn bool, a interface{}, bIn bool, b interface{}) bool {
+			return aIn == bIn && (!aIn || pslEqual(a, b))
+		}
+
+		var value interface{}
+		var keep bool
+		switch {
+		case same(inOurs, ourValue, inTheirs, theirValue):
+			value, keep = ourValue, inOurs
+		case same(inBase, baseValue, inOurs, ourValue):
+			value, keep = theirValue, inTheirs
+		case same(inBase, baseValue, inTheirs, theirValue):
+			value, keep = ourValue, inOurs
+		default:
+			ourNested, ourIsMap := ourValue.(PSLMap)
+			theirNested, theirIsMap := theirValue.(PSLMap)
+			if ourIsMap && theirIsMap {
+				baseNested, _ := baseValue.(PSLMap)
+				value, keep = mergePSLMaps(baseNested, ourNested, theirNested, prefix+key+".", conflicts), true
+			} else {
+				*conflicts = append(*conflicts, PSLConflict{Path: prefix + key, Base: baseValue, Ours: ourValue, Theirs: theirValue})
+				value, keep = ourValue, inOurs
+			}
+		}
+		if keep {
+			merged[key] = ClonePSLValue(value)
+		}
+	}
+	return merged
+}
+
+// ClonePSLValue deep-copies a PSL value, so nested maps and lists can be changed independently
+func ClonePSLValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case PSLMap:
+		clone := make(PSLMap, len(v))
+		for key, item := range v {
+			clone[key] = ClonePSLValue(item)
+		}
+		return clone
+	case PSLList:
+		clone := make(PSLList, len(v))
+		for i, item := range v {
+			clone[i] = ClonePSLValue(item)
+		}
+		return clone
+	default:
+		return value
+	}
+}
+
+// pslEqual reports whether two PSL values serialize the same
+func pslEqual(a, b interface{}) bool {
+	return SerializePSLList(PSLList{a}) == SerializePSLList(PSLList{b})
+}
@@ -0,0 +1,109 @@
+package pawscript
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// parsePSLMap parses PSL text for a test
+func parsePSLMap(t *testing.T, text string) PSLMap {
+	t.Helper()
+	m, err := ParsePSL(text)
+	if err != nil {
+		t.Fatalf("parse %s: %v", text, err)
+	}
+	return m
+}
+
+// pslValueText returns a value as PSL text, or "-" for none
+func pslValueText(value interface{}) string {
+	if value == nil {
+		return "-"
+	}
+	text := SerializePSLList(PSLList{value})
+	return strings.TrimSuffix(strings.TrimPrefix(text, "("), ")")
+}
+
+func TestDiffPSL(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     []string // "<kind> <path>: <old> -> <new>"
+	}{
+		{"same", `(a: 1, l: (1, 2))`, `(l: (1, 2), a: 1)`, nil},
+		{"added", `(a: 1)`, `(a: 1, b: "two")`, []string{`added b: - -> "two"`}},
+		{"removed", `(a: 1, b: 2)`, `(a: 1)`, []string{`removed b: 2 -> -`}},
+		{"changed", `(a: 1, b: x)`, `(a: 2, b: x)`, []string{`modified a: 1 -> 2`}},
+		{"changed type", `(a: 1)`, `(a: "1")`, []string{`modified a: 1 -> "1"`}},
+		{"nested", `(n: (x: 1, y: (z: 2), gone: 0))`, `(n: (x: 1, y: (z: 3), new: 4))`, []string{
+			`removed n.gone: 0 -> -`,
+			`added n.new: - -> 4`,
+			`modified n.y.z: 2 -> 3`,
+		}},
+		{"list changed whole", `(l: (1, 2, 3))`, `(l: (1, 2, 4))`, []string{`modified l: (1, 2, 3) -> (1, 2, 4)`}},
+		{"list of maps", `(l: ((a: 1), (b: 2)))`, `(l: ((a: 1), (b: 3)))`, []string{
+			`modified l: ((a: 1), (b: 2)) -> ((a: 1), (b: 3))`,
+		}},
+		{"map replaced by a value", `(n: (x: 1))`, `(n: 5)`, []string{`modified n: (x: 1) -> 5`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, c := range DiffPSL(parsePSLMap(t, tt.old), parsePSLMap(t, tt.new)) {
+				got = append(got, c.Kind.String()+" "+c.Path+": "+pslValueText(c.Old)+" -> "+pslValueText(c.New))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergePSL(t *testing.T) {
+	tests := []struct {
+		name                string
+		base, ours, theirs string
+		want                string
+		conflicts           []string // "<path>: <base> / <ours> / <theirs>"
+	}{
+		{"one side each", `(a: 1, b: 1)`, `(a: 2, b: 1)`, `(a: 1, b: 3)`, `(a: 2, b: 3)`, nil},
+		{"same change", `(a: 1)`, `(a: 2)`, `(a: 2)`, `(a: 2)`, nil},
+		{"added on both", `(a: 1)`, `(a: 1, b: 2)`, `(a: 1, c: 3)`, `(a: 1, b: 2, c: 3)`, nil},
+		{"removed on one side", `(a: 1, b: 2)`, `(a: 1)`, `(a: 1, b: 2)`, `(a: 1)`, nil},
+		{"nested", `(n: (x: 1, y: 1))`, `(n: (x: 2, y: 1))`, `(n: (x: 1, y: 3))`, `(n: (x: 2, y: 3))`, nil},
+		{"added map on both", `()`, `(n: (x: 1))`, `(n: (y: 2))`, `(n: (x: 1, y: 2))`, nil},
+		{"lists merge whole", `(l: (1, 2))`, `(l: (1, 2, 3))`, `(l: (1, 2))`, `(l: (1, 2, 3))`, nil},
+		{"conflict", `(a: 1, b: 1)`, `(a: 2, b: 1)`, `(a: 3, b: 4)`, `(a: 2, b: 4)`, []string{"a: 1 / 2 / 3"}},
+		{"list conflict", `(l: (1))`, `(l: (1, 2))`, `(l: (1, 3))`, `(l: (1, 2))`, []string{"l: (1) / (1, 2) / (1, 3)"}},
+		{"nested conflict", `(n: (x: 1))`, `(n: (x: 2))`, `(n: (x: 3))`, `(n: (x: 2))`, []string{"n.x: 1 / 2 / 3"}},
+		{"changed and removed", `(a: 1, b: 1)`, `(b: 1)`, `(a: 5, b: 1)`, `(b: 1)`, []string{"a: 1 / - / 5"}},
+		{"added differently", `()`, `(a: 1)`, `(a: 2)`, `(a: 1)`, []string{"a: - / 1 / 2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, ours, theirs := parsePSLMap(t, tt.base), parsePSLMap(t, tt.ours), parsePSLMap(t, tt.theirs)
+			oursText := SerializePSL(ours)
+
+			merged, conflicts := MergePSL(base, ours, theirs)
+			if got, want := SerializePSL(merged), SerializePSL(parsePSLMap(t, tt.want)); got != want {
+				t.Errorf("merged %s, want %s", got, want)
+			}
+			var got []string
+			for _, c := range conflicts {
+				got = append(got, c.Path+": "+pslValueText(c.Base)+" / "+pslValueText(c.Ours)+" / "+pslValueText(c.Theirs))
+			}
+			if !reflect.DeepEqual(got, tt.conflicts) {
+				t.Errorf("conflicts %q, want %q", got, tt.conflicts)
+			}
+
+			// The result shares nothing with the inputs
+			if n, ok := merged["n"].(PSLMap); ok {
+				n["x"] = "changed"
+			}
+			if SerializePSL(ours) != oursText {
+				t.Error("changing the merge changed ours")
+			}
+		})
+	}
+}
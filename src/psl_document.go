@@ -0,0 +1,418 @@
+package pawscript
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PSLDocument is a PSL map that remembers how its text was written
+// ParsePSL returns bare values, so serializing them again loses comments, key order and
+// hand formatting. A PSLDocument keeps the text of each entry along with the comments
+// and whitespace around it; Update rewrites only the entries whose values changed, so a
+// hand-edited config file keeps its annotations when a program saves it.
+type PSLDocument struct {
+	head    string // Text up to the opening parenthesis, plus the rest of its line
+	entries []*pslDocEntry
+	tail    string // Text from the closing parenthesis on
+}
+
+// pslDocEntry is one top-level entry of a PSLDocument
+type pslDocEntry struct {
+	key   string      // Name of a named entry; "" for a positional item, which is kept as written
+	value interface{} // Parsed value
+	lead  string      // Whitespace and comments before the entry
+	text  string      // The entry as written ("key: value")
+	trail string      // Whitespace and comment after the entry's comma, through the end of its line
+}
+
+// pslTokenKind classifies the spans of PSL text the document scanner works with
+type pslTokenKind int
+
+const (
+	pslTokSpace   pslTokenKind = iota // Spaces and tabs
+	pslTokNewline                     // A single \n
+	pslTokComment                     // Line comment (without its newline) or block comment
+	pslTokOpen                        // ( { [
+	pslTokClose                       // ) } ]
+	pslTokComma
+	pslTokText // Strings, escapes and anything else
+)
+
+type pslToken struct {
+	kind       pslTokenKind
+	start, end int
+}
+
+// scanPSLTokens splits PSL text into tokens, following the comment rules of Parser.RemoveComments
+func scanPSLTokens(src string) []pslToken {
+	var tokens []pslToken
+	add := func(kind pslTokenKind, start, end int) {
+		tokens = append(tokens, pslToken{kind, start, end})
+	}
+	isSpace := func(c byte) bool {
+		return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\v' || c == '\f'
+	}
+
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == '\n':
+			add(pslTokNewline, i, i+1)
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			j := i
+			for j < len(src) && (src[j] == ' ' || src[j] == '\t' || src[j] == '\r') {
+				j++
+			}
+			add(pslTokSpace, i, j)
+			i = j
+		case c == '\\':
+			end := min(i+2, len(src))
+			add(pslTokText, i, end)
+			i = end
+		case c == '"' || c == '\'':
+			j := i + 1
+			for j < len(src) && src[j] != c {
+				if src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			end := min(j+1, len(src))
+			add(pslTokText, i, end)
+			i = end
+		case c == '#' && i+1 < len(src) && (src[i+1] == '(' || src[i+1] == '{'):
+			if end := scanPSLBlockComment(src, i); end > 0 {
+				add(pslTokComment, i, end)
+				i = end
+				continue
+			}
+			add(pslTokText, i, i+1)
+			i++
+		case c == '#' && (i == 0 || isSpace(src[i-1])) &&
+			(i+1 >= len(src) || isSpace(src[i+1]) || src[i+1] == '!'):
+			j := i
+			for j < len(src) && src[j] != '\n' {
+				j++
+			}
+			add(pslTokComment, i, j)
+			i = j
+		case c == '(' || c == '{' || c == '[':
+			add(pslTokOpen, i, i+1)
+			i++
+		case c == ')' || c == '}' || c == ']':
+			add(pslTokClose, i, i+1)
+			i++
+		case c == ',':
+			add(pslTokComma, i, i+1)
+			i++
+		default:
+			j := i + 1
+			for j < len(src) && !strings.ContainsRune(" \t\r\n\\\"'#(){}[],", rune(src[j])) {
+				j++
+			}
+			add(pslTokText, i, j)
+			i = j
+		}
+	}
+	return tokens
+}
+
+// scanPSLBlockComment returns the end of a #( )# or #{ }# comment starting at i, or 0 if unclosed
+func scanPSLBlockComment(src string, i int) int {
+	open := src[i+1]
+	closer := byte(')')
+	if open == '{' {
+		closer = '}'
+	}
+	depth := 1
+	for j := i + 2; j < len(src); j++ {
+		switch {
+		case src[j] == '\\':
+			j++
+		case src[j] == '"':
+			for j++; j < len(src) && src[j] != '"'; j++ {
+				if src[j] == '\\' {
+					j++
+				}
+			}
+		case src[j] == '#' && j+1 < len(src) && src[j+1] == open:
+			depth++
+			j++
+		case src[j] == closer && j+1 < len(src) && src[j+1] == '#':
+			depth--
+			j++
+			if depth == 0 {
+				return j + 1
+			}
+		}
+	}
+	return 0
+}
+
+// ParsePSLDocument parses a PSL map, keeping its comments and layout
+func ParsePSLDocument(input string) (*PSLDocument, error) {
+	tokens := scanPSLTokens(input)
+
+	// The map starts at the first parenthesis; only whitespace and comments may precede it
+	open := -1
+	for i, tok := range tokens {
+		if tok.kind == pslTokOpen && input[tok.start] == '(' {
+			open = i
+			break
+		}
+		if tok.kind != pslTokSpace && tok.kind != pslTokNewline && tok.kind != pslTokComment {
+			break
+		}
+	}
+	if open < 0 {
+		if strings.TrimSpace(NewParser(input, "").RemoveComments(input)) == "" {
+			return &PSLDocument{head: input + "(", tail: ")\n"}, nil
+		}
+		return nil, fmt.Errorf("PSL must be enclosed in parentheses")
+	}
+
+	// Split the contents at top-level commas
+	var segments [][]pslToken
+	var current []pslToken
+	closeTok := -1
+	depth := 0
+	for i := open + 1; i < len(tokens) && closeTok < 0; i++ {
+		tok := tokens[i]
+		switch {
+		case tok.kind == pslTokOpen:
+			depth++
+		case tok.kind == pslTokClose && depth == 0:
+			closeTok = i
+			continue
+		case tok.kind == pslTokClose:
+			depth--
+		case tok.kind == pslTokComma && depth == 0:
+			segments = append(segments, current)
+			current = nil
+			continue
+		}
+		current = append(current, tok)
+	}
+	if closeTok < 0 {
+		return nil, fmt.Errorf("PSL is missing its closing parenthesis")
+	}
+	for _, tok := range tokens[closeTok+1:] {
+		if tok.kind != pslTokSpace && tok.kind != pslTokNewline && tok.kind != pslTokComment {
+			return nil, fmt.Errorf("unexpected text after the closing parenthesis")
+		}
+	}
+	segments = append(segments, current)
+
+	doc := &PSLDocument{}
+	headEnd := tokens[open].end
+	tailStart := tokens[closeTok].start
+
+	// lineRest returns how many leading tokens are just the rest of a line (through its newline)
+	lineRest := func(toks []pslToken) int {
+		for i, tok := range toks {
+			switch tok.kind {
+			case pslTokNewline:
+				return i + 1
+			case pslTokSpace, pslTokComment:
+				continue
+			}
+			return 0
+		}
+		return 0
+	}
+
+	var pending []pslToken // Leading tokens carried to the next entry
+	for si, seg := range segments {
+		last := si == len(segments)-1
+
+		// A comment after the opening parenthesis or a comma belongs to the line before
+		if n := lineRest(seg); n > 0 {
+			if si == 0 {
+				headEnd = seg[n-1].end
+			} else if len(doc.entries) > 0 {
+				prev := doc.entries[len(doc.entries)-1]
+				prev.trail = input[seg[0].start:seg[n-1].end]
+			}
+			seg = seg[n:]
+		}
+
+		first, lastSig := -1, -1
+		for i, tok := range seg {
+			if tok.kind != pslTokSpace && tok.kind != pslTokNewline && tok.kind != pslTokComment {
+				if first < 0 {
+					first = i
+				}
+				lastSig = i
+			}
+		}
+		if first < 0 {
+			// Nothing but whitespace and comments: an empty trailing segment, or a stray comma
+			pending = append(pending, seg...)
+			continue
+		}
+
+		lead := pending
+		pending = nil
+		lead = append(lead, seg[:first]...)
+		body := seg[first : lastSig+1]
+		after := seg[lastSig+1:]
+
+		entry := &pslDocEntry{text: input[body[0].start:body[len(body)-1].end]}
+		if len(lead) > 0 {
+			entry.lead = input[lead[0].start:lead[len(lead)-1].end]
+		}
+		if last {
+			// The rest of the final entry's line stays with it; anything after goes to the tail
+			n := lineRest(after)
+			if n == 0 && len(after) > 0 && after[len(after)-1].kind != pslTokNewline {
+				n = len(after) // The closing parenthesis is on the same line
+			}
+			if n > 0 {
+				entry.trail = input[after[0].start:after[n-1].end]
+				after = after[n:]
+			}
+			if len(after) > 0 {
+				tailStart = after[0].start
+			}
+		} else if len(after) > 0 {
+			entry.text = input[body[0].start:after[len(after)-1].end]
+		}
+
+		args, namedArgs := parseArguments(NewParser(entry.text, "").RemoveComments(entry.text))
+		for key, value := range namedArgs {
+			entry.key, entry.value = key, convertFromPawValue(value)
+		}
+		if len(args) > 0 && entry.key == "" {
+			entry.value = convertFromPawValue(args[0])
+		}
+		doc.entries = append(doc.entries, entry)
+	}
+	if len(pending) > 0 && len(doc.entries) == 0 {
+		headEnd = pending[len(pending)-1].end
+	} else if len(pending) > 0 {
+		tailStart = min(tailStart, pending[0].start)
+	}
+
+	doc.head = input[:headEnd]
+	doc.tail = input[tailStart:]
+	return doc, nil
+}
+
+// Map returns the named entries of the document
+func (d *PSLDocument) Map() PSLMap {
+	result := PSLMap{}
+	for _, entry := range d.entries {
+		if entry.key != "" {
+			result[entry.key] = entry.value
+		}
+	}
+	return result
+}
+
+// Update makes the document hold config
+// Entries whose values are unchanged keep their text; changed entries are rewritten in
+// place, entries missing from config are removed along with the comments before them,
+// and new keys are appended in sorted order, indented like the entry before them.
+// Positional items are left alone.
+func (d *PSLDocument) Update(config PSLMap) {
+	kept := d.entries[:0]
+	seen := make(map[string]bool)
+	for _, entry := range d.entries {
+		if entry.key == "" {
+			kept = append(kept, entry)
+			continue
+		}
+		value, exists := config[entry.key]
+		if !exists || seen[entry.key] {
+			continue
+		}
+		seen[entry.key] = true
+		if pslEntryText(entry.key, value) != pslEntryText(entry.key, entry.value) {
+			entry.text = pslEntryText(entry.key, value)
+			entry.value = value
+		}
+		kept = append(kept, entry)
+	}
+	d.entries = kept
+
+	var added []string
+	for key := range config {
+		if !seen[key] {
+			added = append(added, key)
+		}
+	}
+	sort.Strings(added)
+	for _, key := range added {
+		d.appendEntry(key, config[key])
+	}
+}
+
+// appendEntry adds an entry after the last one, following the document's layout
+func (d *PSLDocument) appendEntry(key string, value interface{}) {
+	entry := &pslDocEntry{key: key, value: value, text: pslEntryText(key, value)}
+	if len(d.entries) == 0 {
+		if !strings.HasSuffix(d.head, "\n") {
+			d.head += "\n"
+		}
+		entry.lead, entry.trail = "  ", "\n"
+		d.entries = append(d.entries, entry)
+		return
+	}
+
+	prev := d.entries[len(d.entries)-1]
+	indent := prev.lead[strings.LastIndex(prev.lead, "\n")+1:]
+	if strings.TrimSpace(indent) != "" {
+		indent = "  "
+	}
+	if strings.HasSuffix(prev.trail, "\n") {
+		// One entry per line
+		entry.lead, entry.trail = indent, "\n"
+	} else {
+		// All on one line: the space before the closing parenthesis moves to the new end
+		entry.lead = " "
+		if strings.TrimSpace(prev.trail) == "" {
+			entry.trail, prev.trail = prev.trail, ""
+		}
+	}
+	d.entries = append(d.entries, entry)
+}
+
+// pslEntryText formats a named entry in compact PSL
+func pslEntryText(key string, value interface{}) string {
+	text := SerializePSL(PSLMap{key: value})
+	return text[1 : len(text)-1]
+}
+
+// String returns the document's PSL text
+func (d *PSLDocument) String() string {
+	var sb strings.Builder
+	sb.WriteString(d.head)
+	for i, entry := range d.entries {
+		lead := entry.lead
+		if i == 0 && !strings.HasSuffix(d.head, "\n") {
+			lead = strings.TrimLeft(lead, " \t")
+		}
+		sb.WriteString(lead)
+		sb.WriteString(entry.text)
+		if i < len(d.entries)-1 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(entry.trail)
+	}
+	sb.WriteString(d.tail)
+	return sb.String()
+}
+
+// UpdatePSL rewrites PSL text so that it holds config, keeping its comments and the
+// layout of unchanged entries (see PSLDocument.Update)
+func UpdatePSL(original string, config PSLMap) (string, error) {
+	doc, err := ParsePSLDocument(original)
+	if err != nil {
+		return "", err
+	}
+	doc.Update(config)
+	return doc.String(), nil
+}
@@ -0,0 +1,99 @@
+package pawscript
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUpdatePSL(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		config   PSLMap
+		want     string
+	}{
+		{
+			"comments kept",
+			"# Settings\n(\n  # The look\n  theme: dark, # or light\n  size: 12,\n)\n",
+			PSLMap{"theme": "dark", "size": int64(14)},
+			"# Settings\n(\n  # The look\n  theme: dark, # or light\n  size: 14\n)\n",
+		},
+		{
+			"key added",
+			"(\n  a: 1,\n  b: 2\n)",
+			PSLMap{"a": int64(1), "b": int64(2), "d": int64(4), "c": int64(3)},
+			"(\n  a: 1,\n  b: 2,\n  c: 3,\n  d: 4\n)",
+		},
+		{
+			"key added on one line",
+			"(a: 1, b: 2)",
+			PSLMap{"a": int64(1), "b": int64(2), "c": int64(3)},
+			"(a: 1, b: 2, c: 3)",
+		},
+		{
+			"key added to empty map",
+			"()",
+			PSLMap{"a": int64(1)},
+			"(\n  a: 1\n)",
+		},
+		{
+			"key removed with its comment",
+			"(\n  a: 1,\n  # About b\n  b: 2,\n  c: 3\n)",
+			PSLMap{"a": int64(1), "c": int64(3)},
+			"(\n  a: 1,\n  c: 3\n)",
+		},
+		{
+			"nested map",
+			"(\n  window: (width: 800, height: 600), # main\n  font: mono\n)",
+			PSLMap{"window": PSLMap{"width": int64(1024), "height": int64(600)}, "font": "mono"},
+			"(\n  window: (height: 600, width: 1024), # main\n  font: mono\n)",
+		},
+		{
+			"nested map unchanged",
+			"(\n  window: ( width: 800,  height: 600 ),\n  font: mono\n)",
+			PSLMap{"window": PSLMap{"width": int64(800), "height": int64(600)}, "font": "serif"},
+			"(\n  window: ( width: 800,  height: 600 ),\n  font: \"serif\"\n)",
+		},
+		{
+			"strings with # , and )",
+			"(\n  color: \"#ff0000\", # red\n  list: \"a, b)\",\n  n: 1\n)",
+			PSLMap{"color": "#ff0000", "list": "a, b)", "n": int64(2)},
+			"(\n  color: \"#ff0000\", # red\n  list: \"a, b)\",\n  n: 2\n)",
+		},
+		{
+			"string with # changed",
+			"(\n  color: \"#ff0000\", # red\n  n: 1\n)",
+			PSLMap{"color": "#00ff00", "n": int64(1)},
+			"(\n  color: \"#00ff00\", # red\n  n: 1\n)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UpdatePSL(tt.original, tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+			parsed, err := ParsePSL(got)
+			if err != nil {
+				t.Fatalf("result doesn't parse: %v", err)
+			}
+			if !reflect.DeepEqual(PSLMap(parsed), tt.config) {
+				t.Errorf("result reads back as %v, want %v", parsed, tt.config)
+			}
+		})
+	}
+}
+
+func TestPSLDocumentMap(t *testing.T) {
+	doc, err := ParsePSLDocument("(\n  # Comment, with (parens)\n  a: \"x # y\",\n  b: (c: \"),\"),\n)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := PSLMap{"a": "x # y", "b": PSLMap{"c": "),"}}
+	if got := doc.Map(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
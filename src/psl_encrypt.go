@@ -0,0 +1,178 @@
+package pawscript
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Encrypted PSL sections
+// A section of a PSL file can be stored as (@encrypted: "<data>"), where the data is the
+// section's PSL text sealed with AES-256-GCM and base64 encoded. The section's key path
+// (such as "servers.prod") is sealed with it, so a block moved to another key no longer
+// opens. The encryption key is kept in a
+// SecretStore (the OS keychain by default), so only a user whose keychain grants access
+// can open the section. ParsePSL leaves encrypted sections as they are, and saving a
+// parsed map writes them back unchanged; ParsePSLWithSecrets or DecryptPSL opens them.
+
+const (
+	pslEncryptedKey = "@encrypted"
+	pslKeyService   = "pawscript-psl" // Shared by every script and host, unlike secret_get namespaces
+	pslKeyAccount   = "encryption-key"
+	pslKeySize      = 32
+)
+
+// pslEncryptionKey reads the PSL encryption key, creating one if asked and none exists
+func pslEncryptionKey(store SecretStore, create bool) ([]byte, error) {
+	if store == nil {
+		store = NewKeychainStore()
+	}
+	stored, err := store.Get(pslKeyService, pslKeyAccount)
+	if err == ErrSecretNotFound && create {
+		key := make([]byte, pslKeySize)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		if err := store.Set(pslKeyService, pslKeyAccount, hex.EncodeToString(key)); err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+	if err == ErrSecretNotFound {
+		return nil, fmt.Errorf("no PSL encryption key in the keychain: %w", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	key, err := hex.DecodeString(stored)
+	if err != nil || len(key) != pslKeySize {
+		return nil, fmt.Errorf("PSL encryption key in the keychain is malformed")
+	}
+	return key, nil
+}
+
+// pslCipher returns the AEAD for a PSL encryption key
+func pslCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptPSL seals a section into an encrypted block, ready to be stored under the key
+// path (dotted for nested keys, as in "servers.prod"); it only opens at that path.
+// A key is created in the store on first use; a nil store uses the OS keychain.
+func EncryptPSL(path string, section PSLMap, store SecretStore) (PSLMap, error) {
+	key, err := pslEncryptionKey(store, true)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := pslCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(SerializePSL(section)), []byte(path))
+	return PSLMap{pslEncryptedKey: base64.StdEncoding.EncodeToString(sealed)}, nil
+}
+
+// IsEncryptedPSL reports whether a value is an encrypted block
+func IsEncryptedPSL(value interface{}) bool {
+	_, ok := pslEncryptedData(value)
+	return ok
+}
+
+// pslEncryptedData returns the sealed data of an encrypted block
+func pslEncryptedData(value interface{}) (string, bool) {
+	m, ok := value.(PSLMap)
+	if !ok || len(m) != 1 {
+		return "", false
+	}
+	data, ok := m[pslEncryptedKey].(string)
+	return data, ok
+}
+
+// openPSLBlock decrypts the sealed data of an encrypted block stored at a key path
+func openPSLBlock(aead cipher.AEAD, path, data string) (PSLMap, error) {
+	sealed, err := base64.StdEncoding.DecodeString(data)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("encrypted block is corrupt")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, ciphertext, []byte(path))
+	if err != nil {
+		return nil, fmt.Errorf("encrypted block was sealed with a different key or for another key path, or is corrupt")
+	}
+	return ParsePSL(string(plain))
+}
+
+// DecryptPSL returns a copy of config with its encrypted blocks opened, at any depth
+// Blocks that can't be opened (no key, access denied, wrong key) stay encrypted in the
+// copy and are reported in the error, so the rest of the config is still usable. A nil
+// store uses the OS keychain, which is only consulted if config has encrypted blocks.
+// To keep secrets encrypted on disk, save the original map, not the copy.
+func DecryptPSL(config PSLMap, store SecretStore) (PSLMap, error) {
+	result := ClonePSLValue(config).(PSLMap)
+	var (
+		aead    cipher.AEAD
+		keyErr  error
+		loaded  bool
+		errs    []error
+		openAll func(m PSLMap, prefix string)
+	)
+	openAll = func(m PSLMap, prefix string) {
+		keys := make([]string, 0, len(m))
+		for key := range m {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			data, encrypted := pslEncryptedData(m[key])
+			if !encrypted {
+				if nested, ok := m[key].(PSLMap); ok {
+					openAll(nested, prefix+key+".")
+				}
+				continue
+			}
+			if !loaded {
+				loaded = true
+				var secret []byte
+				if secret, keyErr = pslEncryptionKey(store, false); keyErr == nil {
+					aead, keyErr = pslCipher(secret)
+				}
+			}
+			if keyErr != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", prefix+key, keyErr))
+				continue
+			}
+			section, err := openPSLBlock(aead, prefix+key, data)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", prefix+key, err))
+				continue
+			}
+			m[key] = section
+		}
+	}
+	openAll(result, "")
+	return result, errors.Join(errs...)
+}
+
+// ParsePSLWithSecrets parses PSL like ParsePSL, then opens its encrypted blocks
+// See DecryptPSL; a parse error returns a nil map, while blocks that can't be
+// opened return the parsed map (with those blocks still encrypted) and an error.
+func ParsePSLWithSecrets(input string, store SecretStore) (PSLMap, error) {
+	config, err := ParsePSL(input)
+	if err != nil {
+		return nil, err
+	}
+	return DecryptPSL(config, store)
+}
@@ -0,0 +1,113 @@
+package pawscript
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// memorySecretStore is a SecretStore kept in memory
+type memorySecretStore map[string]string
+
+func (m memorySecretStore) Get(service, key string) (string, error) {
+	value, ok := m[service+"/"+key]
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return value, nil
+}
+
+func (m memorySecretStore) Set(service, key, value string) error {
+	m[service+"/"+key] = value
+	return nil
+}
+
+func (m memorySecretStore) Delete(service, key string) error {
+	delete(m, service+"/"+key)
+	return nil
+}
+
+func TestEncryptPSLRoundTrip(t *testing.T) {
+	store := memorySecretStore{}
+	db := PSLMap{"user": "admin", "password": "hunter2", "port": int64(5432)}
+	prod := PSLMap{"token": "abc", "hosts": PSLList{"a", "b"}}
+
+	dbBlock, err := EncryptPSL("db", db, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prodBlock, err := EncryptPSL("servers.prod", prod, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(SerializePSL(dbBlock), "hunter2") {
+		t.Fatal("block holds the plain text")
+	}
+	config := PSLMap{"name": "app", "db": dbBlock, "servers": PSLMap{"prod": prodBlock}}
+
+	// Through PSL text, as a config file would be
+	opened, err := ParsePSLWithSecrets(SerializePSL(config), store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := PSLMap{"name": "app", "db": db, "servers": PSLMap{"prod": prod}}
+	if SerializePSL(opened) != SerializePSL(want) {
+		t.Errorf("opened %s, want %s", SerializePSL(opened), SerializePSL(want))
+	}
+	if !IsEncryptedPSL(config["db"]) {
+		t.Error("DecryptPSL changed the original map")
+	}
+}
+
+func TestDecryptPSLFailures(t *testing.T) {
+	store := memorySecretStore{}
+	block, err := EncryptPSL("db", PSLMap{"password": "hunter2"}, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherStore := memorySecretStore{}
+	if _, err := EncryptPSL("db", PSLMap{}, otherStore); err != nil {
+		t.Fatal(err)
+	}
+	tampered := ClonePSLValue(block).(PSLMap)
+	data := []byte(tampered[pslEncryptedKey].(string))
+	data[len(data)/2] ^= 1
+	tampered[pslEncryptedKey] = string(data)
+
+	tests := []struct {
+		name    string
+		config  PSLMap
+		store   SecretStore
+		wantErr error // Wrapped by the error, if set
+	}{
+		{"wrong key", PSLMap{"db": block}, otherStore, nil},
+		{"missing secret", PSLMap{"db": block}, memorySecretStore{}, ErrSecretNotFound},
+		{"moved to another key", PSLMap{"cache": block}, store, nil},
+		{"moved deeper", PSLMap{"old": PSLMap{"db": block}}, store, nil},
+		{"tampered", PSLMap{"db": tampered}, store, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config["plain"] = "visible"
+			opened, err := DecryptPSL(tt.config, tt.store)
+			if err == nil {
+				t.Fatal("opened a block that shouldn't open")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("got %v, want %v", err, tt.wantErr)
+			}
+			// The rest of the config is still usable, with the block left sealed
+			if opened["plain"] != "visible" || !reflect.DeepEqual(opened, tt.config) {
+				t.Errorf("got %s, want %s", SerializePSL(opened), SerializePSL(tt.config))
+			}
+		})
+	}
+
+	// Opening never creates a key
+	empty := memorySecretStore{}
+	_, _ = DecryptPSL(PSLMap{"db": block}, empty)
+	if len(empty) != 0 {
+		t.Error("DecryptPSL stored a key")
+	}
+}
@@ -0,0 +1,135 @@
+package pawscript
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Conversion between PSL and JSON text
+// A PSL list with only named items becomes a JSON object and one with only positional
+// items becomes an array. A list with both becomes an object holding its positional
+// items under "_children", the same layout the json command and list from: json use.
+
+// pslChildrenKey holds the positional items of a list that also has named items
+const pslChildrenKey = "_children"
+
+// PSLToJSON converts PSL text to JSON, keeping named and positional items
+// Comments are dropped. An empty list () becomes an empty array.
+func PSLToJSON(input string) (string, error) {
+	parser := NewParser(input, "")
+	input = strings.TrimSpace(parser.RemoveComments(input))
+	if !strings.HasPrefix(input, "(") || !strings.HasSuffix(input, ")") {
+		return "", fmt.Errorf("PSL must be enclosed in parentheses")
+	}
+	data, err := json.Marshal(pslJSONValue(ParenGroup(input[1 : len(input)-1])))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// pslJSONValue converts a parsed PSL value to a value encoding/json can marshal
+func pslJSONValue(value interface{}) interface{} {
+	group, ok := value.(ParenGroup)
+	if !ok {
+		return convertFromPawValue(value)
+	}
+	args, namedArgs := parseArguments(string(group))
+	items := make([]interface{}, len(args))
+	for i, arg := range args {
+		items[i] = pslJSONValue(arg)
+	}
+	if len(namedArgs) == 0 {
+		return items
+	}
+	obj := make(map[string]interface{}, len(namedArgs)+1)
+	for key, arg := range namedArgs {
+		obj[key] = pslJSONValue(arg)
+	}
+	if len(items) > 0 {
+		obj[pslChildrenKey] = items
+	}
+	return obj
+}
+
+// JSONToPSL converts a JSON object or array to PSL text
+// Whole numbers become ints and other numbers floats. An object's "_children" array
+// becomes the positional items of its list. Keys that aren't identifiers are quoted;
+// an empty key has no PSL form and is an error.
+func JSONToPSL(input string) (string, error) {
+	decoder := json.NewDecoder(strings.NewReader(input))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return "", err
+	}
+	if decoder.More() {
+		return "", fmt.Errorf("unexpected data after the JSON value")
+	}
+	converted, err := jsonPSLValue(value)
+	if err != nil {
+		return "", err
+	}
+	list, ok := converted.(StoredList)
+	if !ok {
+		return "", fmt.Errorf("only a JSON object or array can be converted to PSL")
+	}
+	return formatListForDisplay(list), nil
+}
+
+// jsonPSLValue converts a decoded JSON value to a PawScript value for serialization
+func jsonPSLValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n, nil
+		}
+		f, _ := v.Float64()
+		return f, nil
+	case string:
+		return QuotedString(v), nil
+	case []interface{}:
+		items, err := jsonPSLItems(v)
+		if err != nil {
+			return nil, err
+		}
+		return NewStoredListWithoutRefs(items), nil
+	case map[string]interface{}:
+		var items []interface{}
+		namedArgs := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			if key == "" {
+				return nil, fmt.Errorf("an empty object key can't be written in PSL")
+			}
+			if children, ok := item.([]interface{}); ok && key == pslChildrenKey {
+				converted, err := jsonPSLItems(children)
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, converted...)
+				continue
+			}
+			converted, err := jsonPSLValue(item)
+			if err != nil {
+				return nil, err
+			}
+			namedArgs[key] = converted
+		}
+		return NewStoredListWithNamed(items, namedArgs), nil
+	}
+	return value, nil // nil and bool carry over as they are
+}
+
+// jsonPSLItems converts the items of a decoded JSON array
+func jsonPSLItems(values []interface{}) ([]interface{}, error) {
+	items := make([]interface{}, len(values))
+	for i, item := range values {
+		converted, err := jsonPSLValue(item)
+		if err != nil {
+			return nil, err
+		}
+		items[i] = converted
+	}
+	return items, nil
+}
@@ -0,0 +1,51 @@
+package pawscript
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestJSONToPSLKeys(t *testing.T) {
+	tests := []struct {
+		json string
+		psl  string
+	}{
+		{`{"a":1}`, `(a: 1)`},
+		{`{"a b":1}`, `("a b": 1)`},
+		{`{"k:v":3}`, `("k:v": 3)`},
+		{`{"x)":"y"}`, `("x)": "y")`},
+		{`{"say \"hi\"":true}`, `("say \"hi\"": true)`},
+		{`{"2nd":2}`, `("2nd": 2)`},
+		{`{"1":"one"}`, `(1: "one")`},
+	}
+	for _, tt := range tests {
+		got, err := JSONToPSL(tt.json)
+		if err != nil {
+			t.Errorf("%s: %v", tt.json, err)
+			continue
+		}
+		if got != tt.psl {
+			t.Errorf("%s: got %s, want %s", tt.json, got, tt.psl)
+		}
+
+		// The keys read back as they were
+		back, err := PSLToJSON(got)
+		if err != nil {
+			t.Errorf("%s: reading back %s: %v", tt.json, got, err)
+			continue
+		}
+		var want, have interface{}
+		_ = json.Unmarshal([]byte(tt.json), &want)
+		_ = json.Unmarshal([]byte(back), &have)
+		if !reflect.DeepEqual(want, have) {
+			t.Errorf("%s: round trip gave %s", tt.json, back)
+		}
+	}
+}
+
+func TestJSONToPSLEmptyKey(t *testing.T) {
+	if _, err := JSONToPSL(`{"a":{"":1}}`); err == nil {
+		t.Error("expected an error for an empty key")
+	}
+}
@@ -0,0 +1,327 @@
+package pawscript
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PSL schema validation
+// A schema is itself PSL: each key of the schema names a key of the config, and its
+// value is a map of rules for that key:
+//
+//	type:     string, int, float, number, bool, map, list, nil or any, or one of
+//	          the text forms duration, color and size (see psl_typed.go);
+//	          a list of names accepts any of them (e.g. (string, nil))
+//	required: true if the key must be present
+//	min, max: range for numbers; length range for strings, lists and maps
+//	values:   list of the allowed values
+//	keys:     schema for the entries of a map value
+//	items:    rules for every list item, or every map entry not named in keys:
+//
+// Keys not mentioned by the schema are allowed. An empty nested () parses as "",
+// so "" is accepted as an empty map or list.
+
+// PSLSchemaError is one problem found by ValidatePSL
+type PSLSchemaError struct {
+	Path    string // Dotted key path, with list indexes in brackets (e.g. "window.size[1]")
+	Message string
+}
+
+func (e PSLSchemaError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// pslSchemaTypes are the type names a schema may use
+var pslSchemaTypes = map[string]bool{
+	"string": true, "int": true, "float": true, "number": true, "bool": true,
+	"map": true, "list": true, "nil": true, "any": true,
+	"duration": true, "color": true, "size": true,
+}
+
+// ValidatePSL checks a config against a schema and returns every problem found
+// Errors are sorted by path; nil means the config is valid. Mistakes in the schema
+// itself are reported as errors too, with messages starting "schema:".
+func ValidatePSL(config PSLMap, schema PSLMap) []PSLSchemaError {
+	var errs []PSLSchemaError
+	validatePSLKeys(config, schema, "", &errs)
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Path < errs[j].Path
+	})
+	return errs
+}
+
+// validatePSLKeys checks the entries of a map against a map of per-key rules
+func validatePSLKeys(config PSLMap, schema PSLMap, prefix string, errs *[]PSLSchemaError) {
+	for key, rulesValue := range schema {
+		path := prefix + key
+		rules, ok := pslSchemaMap(rulesValue)
+		if !ok {
+			*errs = append(*errs, PSLSchemaError{path, "schema: rules must be a map"})
+			continue
+		}
+		value, present := config[key]
+		if !present {
+			if required, _ := rules["required"].(bool); required {
+				*errs = append(*errs, PSLSchemaError{path, "is required"})
+			}
+			continue
+		}
+		validatePSLValue(value, rules, path, errs)
+	}
+}
+
+// validatePSLValue checks one value against its rules
+func validatePSLValue(value interface{}, rules PSLMap, path string, errs *[]PSLSchemaError) {
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, PSLSchemaError{path, fmt.Sprintf(format, args...)})
+	}
+
+	kind := pslValueKind(value)
+	types, err := pslSchemaTypeList(rules["type"])
+	if err != "" {
+		fail("schema: %s", err)
+		return
+	}
+	if len(types) > 0 {
+		matched := ""
+		for _, t := range types {
+			if pslKindMatches(kind, value, t) {
+				matched = t
+				break
+			}
+		}
+		if matched == "" {
+			if len(types) == 1 && kind == "string" {
+				if reason := pslTextFormError(types[0], value); reason != "" {
+					fail("%s", reason)
+					return
+				}
+			}
+			fail("expected %s, got %s", strings.Join(types, " or "), kind)
+			return
+		}
+		// "" standing in for an empty map or list has nothing more to check
+		if (matched == "map" || matched == "list") && kind == "string" {
+			return
+		}
+		// Neither do the text forms; min: and max: would only measure the text
+		if matched == "duration" || matched == "color" || matched == "size" {
+			return
+		}
+	}
+
+	if allowed, ok := rules["values"]; ok {
+		list, ok := allowed.(PSLList)
+		if !ok {
+			fail("schema: values must be a list")
+		} else if !pslListContains(list, value) {
+			options := make([]string, len(list))
+			for i, v := range list {
+				options[i] = fmt.Sprintf("%v", v)
+			}
+			fail("must be one of %s", strings.Join(options, ", "))
+		}
+	}
+
+	// min:/max: bound numbers by value and everything else by length
+	size, sizeWord, sized := 0.0, "", true
+	switch v := value.(type) {
+	case int64:
+		size = float64(v)
+	case float64:
+		size = v
+	case string:
+		size, sizeWord = float64(len([]rune(v))), "length "
+	case PSLList:
+		size, sizeWord = float64(len(v)), "length "
+	case PSLMap:
+		size, sizeWord = float64(len(v)), "size "
+	default:
+		sized = false
+	}
+	for _, bound := range []string{"min", "max"} {
+		limitValue, ok := rules[bound]
+		if !ok || !sized {
+			continue
+		}
+		limit, ok := pslNumber(limitValue)
+		if !ok {
+			fail("schema: %s must be a number", bound)
+			continue
+		}
+		if bound == "min" && size < limit {
+			fail("%smust be at least %v", sizeWord, limitValue)
+		} else if bound == "max" && size > limit {
+			fail("%smust be at most %v", sizeWord, limitValue)
+		}
+	}
+
+	var itemRules PSLMap
+	if itemsValue, ok := rules["items"]; ok {
+		if itemRules, ok = pslSchemaMap(itemsValue); !ok {
+			fail("schema: items must be a map of rules")
+			return
+		}
+	}
+
+	switch v := value.(type) {
+	case PSLMap:
+		var keySchema PSLMap
+		if keysValue, ok := rules["keys"]; ok {
+			if keySchema, ok = pslSchemaMap(keysValue); !ok {
+				fail("schema: keys must be a map")
+				return
+			}
+			validatePSLKeys(v, keySchema, path+".", errs)
+		}
+		if itemRules != nil {
+			for key, item := range v {
+				if _, named := keySchema[key]; !named {
+					validatePSLValue(item, itemRules, path+"."+key, errs)
+				}
+			}
+		}
+	case PSLList:
+		if itemRules != nil {
+			for i, item := range v {
+				validatePSLValue(item, itemRules, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	}
+}
+
+// pslValueKind names the PSL type of a parsed value
+func pslValueKind(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return "bool"
+	case int64, int:
+		return "int"
+	case float64:
+		return "float"
+	case string:
+		return "string"
+	case PSLMap:
+		return "map"
+	case PSLList:
+		return "list"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// pslKindMatches reports whether a value of the given kind satisfies a schema type
+func pslKindMatches(kind string, value interface{}, schemaType string) bool {
+	switch schemaType {
+	case "any":
+		return true
+	case "number":
+		return kind == "int" || kind == "float"
+	case "float":
+		return kind == "float" || kind == "int"
+	case "map", "list":
+		return kind == schemaType || value == ""
+	case "duration", "color", "size":
+		return pslTextFormError(schemaType, value) == ""
+	default:
+		return kind == schemaType
+	}
+}
+
+// pslTextFormError explains why a string isn't a valid duration, color or size
+func pslTextFormError(schemaType string, value interface{}) string {
+	var err error
+	switch schemaType {
+	case "duration":
+		_, err = ParsePSLDuration(value)
+	case "color":
+		_, err = ParsePSLColor(value)
+	case "size":
+		_, err = ParsePSLSize(value)
+	}
+	if err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// pslSchemaTypeList reads a type: rule as a list of type names
+func pslSchemaTypeList(value interface{}) ([]string, string) {
+	var names []string
+	switch v := value.(type) {
+	case nil:
+		return nil, ""
+	case string:
+		names = []string{v}
+	case PSLList:
+		for _, item := range v {
+			name, ok := item.(string)
+			if !ok {
+				// nil parses as a nil value rather than a name
+				if item != nil {
+					return nil, fmt.Sprintf("invalid type %v", item)
+				}
+				name = "nil"
+			}
+			names = append(names, name)
+		}
+	default:
+		return nil, fmt.Sprintf("invalid type %v", value)
+	}
+	for _, name := range names {
+		if !pslSchemaTypes[name] {
+			return nil, fmt.Sprintf("unknown type %q", name)
+		}
+	}
+	return names, ""
+}
+
+// pslSchemaMap reads a nested schema or rule set, allowing () for an empty one
+func pslSchemaMap(value interface{}) (PSLMap, bool) {
+	switch v := value.(type) {
+	case PSLMap:
+		return v, true
+	case string:
+		if v == "" {
+			return PSLMap{}, true
+		}
+	}
+	return nil, false
+}
+
+// pslNumber converts a parsed PSL number to float64
+func pslNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// pslListContains reports whether a list holds a value, comparing numbers by value
+func pslListContains(list PSLList, value interface{}) bool {
+	switch value.(type) {
+	case PSLMap, PSLList:
+		return false // Only plain values can be listed
+	}
+	n, isNumber := pslNumber(value)
+	for _, item := range list {
+		if m, ok := pslNumber(item); ok && isNumber {
+			if m == n {
+				return true
+			}
+			continue
+		}
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,257 @@
+package pawscript
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// PSLStreamParser reads PSL from a reader one item at a time
+// ParsePSL needs the whole text in memory and builds every value at once. The stream
+// parser instead scans for the commas between the items of the outermost list and
+// parses each item on its own, so memory use is bounded by the largest item rather
+// than the whole input. Values are the same ones ParsePSL produces.
+//
+// With Records set, the input is read as a sequence of top-level lists, such as a
+// log with one list per line, and each whole list is an item.
+type PSLStreamParser struct {
+	Records bool // Each top-level list is one item, instead of each item of a single list
+
+	r       *bufio.Reader
+	line    int
+	depth   int
+	started bool // A top-level list has been opened
+	done    bool
+	prev    byte // Last byte scanned, for the line-comment rule
+	item    strings.Builder
+}
+
+// NewPSLStreamParser creates a stream parser reading from r
+func NewPSLStreamParser(r io.Reader) *PSLStreamParser {
+	return &PSLStreamParser{r: bufio.NewReader(r), line: 1, prev: '\n'}
+}
+
+// ParsePSLStream calls onItem for each item of the PSL list read from r
+// key is the item's name, or "" for a positional item. An error from onItem stops the
+// parse and is returned.
+func ParsePSLStream(r io.Reader, onItem func(key string, value interface{}) error) error {
+	p := NewPSLStreamParser(r)
+	for {
+		key, value, err := p.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := onItem(key, value); err != nil {
+			return err
+		}
+	}
+}
+
+// Next returns the next item: its key ("" if positional) and value
+// Returns io.EOF after the last item.
+func (p *PSLStreamParser) Next() (string, interface{}, error) {
+	for {
+		text, err := p.scanItem()
+		if err != nil {
+			return "", nil, err
+		}
+		if p.Records {
+			return "", convertFromPawValue(ParenGroup(text)), nil
+		}
+		args, namedArgs := parseArguments(text)
+		for key, value := range namedArgs {
+			return key, convertFromPawValue(value), nil
+		}
+		if len(args) > 0 {
+			return "", convertFromPawValue(args[0]), nil
+		}
+		// An empty item, from a trailing or doubled comma
+	}
+}
+
+// scanItem reads the text of the next item
+// In Records mode the text is the contents of a top-level list.
+func (p *PSLStreamParser) scanItem() (string, error) {
+	if p.done {
+		return "", io.EOF
+	}
+	p.item.Reset()
+	itemDepth := 1 // Depth at which items are separated
+	if p.Records {
+		itemDepth = 0
+	}
+
+	for {
+		c, err := p.r.ReadByte()
+		if err == io.EOF {
+			p.done = true
+			if p.depth > 0 {
+				return "", p.errorf("PSL is missing its closing parenthesis")
+			}
+			return "", io.EOF
+		}
+		if err != nil {
+			return "", err
+		}
+		if c == '\n' {
+			p.line++
+		}
+		prev := p.prev
+		p.prev = c
+
+		switch {
+		case c == '\\':
+			if p.depth == 0 {
+				return "", p.errorf("PSL must be enclosed in parentheses")
+			}
+			p.item.WriteByte(c)
+			if next, err := p.r.ReadByte(); err == nil {
+				p.item.WriteByte(next)
+				p.prev = next
+			}
+			continue
+		case c == '"' || c == '\'':
+			if p.depth == 0 {
+				return "", p.errorf("PSL must be enclosed in parentheses")
+			}
+			if err := p.copyString(c); err != nil {
+				return "", err
+			}
+			continue
+		case c == '#':
+			next, _ := p.r.Peek(1)
+			if len(next) == 1 && (next[0] == '(' || next[0] == '{') {
+				if err := p.skipBlockComment(); err != nil {
+					return "", err
+				}
+				p.item.WriteByte(' ')
+				continue
+			}
+			if isPSLSpace(prev) && (len(next) == 0 || isPSLSpace(next[0]) || next[0] == '!') {
+				for len(next) == 1 && next[0] != '\n' {
+					_, _ = p.r.ReadByte()
+					next, _ = p.r.Peek(1)
+				}
+				continue
+			}
+		case c == '(' || c == '{' || c == '[':
+			p.depth++
+			if p.depth == 1 {
+				if c != '(' || (p.started && !p.Records) {
+					return "", p.errorf("unexpected %q outside the list", c)
+				}
+				p.started = true
+				if !p.Records {
+					continue
+				}
+			}
+		case c == ')' || c == '}' || c == ']':
+			if p.depth == 0 {
+				return "", p.errorf("unexpected %q", c)
+			}
+			p.depth--
+			if p.depth == 0 {
+				if p.Records {
+					text := p.item.String()
+					return text[1:], nil // Without the opening parenthesis
+				}
+				// The end of the outermost list ends its last item too
+				return p.item.String(), nil
+			}
+		case c == ',' && p.depth == itemDepth && !p.Records:
+			return p.item.String(), nil
+		}
+
+		if p.depth == 0 {
+			if !isPSLSpace(c) {
+				return "", p.errorf("unexpected %q outside the list", c)
+			}
+			continue
+		}
+		p.item.WriteByte(c)
+	}
+}
+
+// copyString copies a quoted string, whose opening quote has been read, into the item
+func (p *PSLStreamParser) copyString(quote byte) error {
+	p.item.WriteByte(quote)
+	for {
+		c, err := p.r.ReadByte()
+		if err != nil {
+			return p.errorf("unterminated string")
+		}
+		if c == '\n' {
+			p.line++
+		}
+		p.item.WriteByte(c)
+		switch c {
+		case '\\':
+			next, err := p.r.ReadByte()
+			if err != nil {
+				return p.errorf("unterminated string")
+			}
+			p.item.WriteByte(next)
+		case quote:
+			p.prev = c
+			return nil
+		}
+	}
+}
+
+// skipBlockComment skips a #( )# or #{ }# comment whose # has been read
+func (p *PSLStreamParser) skipBlockComment() error {
+	open, _ := p.r.ReadByte()
+	closer := byte(')')
+	if open == '{' {
+		closer = '}'
+	}
+	depth := 1
+	for depth > 0 {
+		c, err := p.r.ReadByte()
+		if err != nil {
+			return p.errorf("unterminated block comment")
+		}
+		next, _ := p.r.Peek(1)
+		switch {
+		case c == '\n':
+			p.line++
+		case c == '\\':
+			_, _ = p.r.ReadByte()
+		case c == '"':
+			for {
+				s, err := p.r.ReadByte()
+				if err != nil {
+					return p.errorf("unterminated block comment")
+				}
+				if s == '\\' {
+					_, _ = p.r.ReadByte()
+				} else if s == '"' {
+					break
+				} else if s == '\n' {
+					p.line++
+				}
+			}
+		case c == '#' && len(next) == 1 && next[0] == open:
+			_, _ = p.r.ReadByte()
+			depth++
+		case c == closer && len(next) == 1 && next[0] == '#':
+			_, _ = p.r.ReadByte()
+			depth--
+		}
+	}
+	p.prev = '#'
+	return nil
+}
+
+// errorf reports a problem at the current line
+func (p *PSLStreamParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+func isPSLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\v' || c == '\f'
+}
@@ -0,0 +1,152 @@
+package pawscript
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Typed accessors for PSL values written as text: durations, colors and sizes
+// Each Get method falls back to its default when the key is missing or its value
+// doesn't parse; the ParsePSL* functions report why a value was rejected.
+
+// PSLColor is an RGB color read from a "#RRGGBB" or "#RGB" value
+type PSLColor struct {
+	R, G, B uint8
+}
+
+// Hex returns the color in "#RRGGBB" form
+func (c PSLColor) Hex() string {
+	return fmt.Sprintf("#%02X%02X%02X", c.R, c.G, c.B)
+}
+
+// pslSizeUnits are the size suffixes ParsePSLSize accepts (all binary multiples)
+var pslSizeUnits = map[string]float64{
+	"":  1,
+	"b": 1,
+	"k": 1 << 10, "kb": 1 << 10, "kib": 1 << 10,
+	"m": 1 << 20, "mb": 1 << 20, "mib": 1 << 20,
+	"g": 1 << 30, "gb": 1 << 30, "gib": 1 << 30,
+	"t": 1 << 40, "tb": 1 << 40, "tib": 1 << 40,
+}
+
+// ParsePSLDuration reads a duration such as "1m30s", "250ms" or "2h"
+// A plain number is a count of seconds.
+func ParsePSLDuration(value interface{}) (time.Duration, error) {
+	if n, ok := pslNumber(value); ok {
+		return time.Duration(n * float64(time.Second)), nil
+	}
+	s, ok := value.(string)
+	if !ok {
+		return 0, fmt.Errorf("expected duration, got %s", pslValueKind(value))
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q (use e.g. 1m30s, 250ms)", s)
+	}
+	return d, nil
+}
+
+// ParsePSLColor reads a color written as "#RRGGBB" or "#RGB"
+func ParsePSLColor(value interface{}) (PSLColor, error) {
+	s, ok := value.(string)
+	if !ok {
+		return PSLColor{}, fmt.Errorf("expected color, got %s", pslValueKind(value))
+	}
+	hex := strings.TrimSpace(s)
+	if !strings.HasPrefix(hex, "#") || (len(hex) != 4 && len(hex) != 7) {
+		return PSLColor{}, fmt.Errorf("invalid color %q (use #RRGGBB or #RGB)", s)
+	}
+	n, err := strconv.ParseUint(hex[1:], 16, 32)
+	if err != nil {
+		return PSLColor{}, fmt.Errorf("invalid color %q (use #RRGGBB or #RGB)", s)
+	}
+	if len(hex) == 4 {
+		return PSLColor{uint8(n>>8&0xF) * 17, uint8(n>>4&0xF) * 17, uint8(n&0xF) * 17}, nil
+	}
+	return PSLColor{uint8(n >> 16), uint8(n >> 8), uint8(n)}, nil
+}
+
+// ParsePSLSize reads a byte count such as "2.5MB", "512K" or "1GiB"
+// Units are binary (1KB = 1024 bytes) and case-insensitive; a plain number is bytes.
+func ParsePSLSize(value interface{}) (int64, error) {
+	n, ok := pslNumber(value)
+	if !ok {
+		s, isString := value.(string)
+		if !isString {
+			return 0, fmt.Errorf("expected size, got %s", pslValueKind(value))
+		}
+		text := strings.TrimSpace(s)
+		split := strings.IndexFunc(text, func(r rune) bool {
+			return (r < '0' || r > '9') && r != '.'
+		})
+		if split < 0 {
+			split = len(text)
+		}
+		unit, known := pslSizeUnits[strings.ToLower(strings.TrimSpace(text[split:]))]
+		number, err := strconv.ParseFloat(text[:split], 64)
+		if !known || err != nil {
+			return 0, fmt.Errorf("invalid size %q (use e.g. 512K, 2.5MB, 1GiB)", s)
+		}
+		n = number * unit
+	}
+	if n < 0 || n > math.MaxInt64 {
+		return 0, fmt.Errorf("size %v out of range", value)
+	}
+	return int64(n), nil
+}
+
+// GetDuration returns a duration value from PSLMap, with default fallback
+func (m PSLMap) GetDuration(key string, defaultVal time.Duration) time.Duration {
+	if v, ok := m[key]; ok {
+		if d, err := ParsePSLDuration(v); err == nil {
+			return d
+		}
+	}
+	return defaultVal
+}
+
+// GetColor returns a color value from PSLMap, with default fallback
+func (m PSLMap) GetColor(key string, defaultVal PSLColor) PSLColor {
+	if v, ok := m[key]; ok {
+		if c, err := ParsePSLColor(v); err == nil {
+			return c
+		}
+	}
+	return defaultVal
+}
+
+// GetSize returns a size in bytes from PSLMap, with default fallback
+func (m PSLMap) GetSize(key string, defaultVal int64) int64 {
+	if v, ok := m[key]; ok {
+		if n, err := ParsePSLSize(v); err == nil {
+			return n
+		}
+	}
+	return defaultVal
+}
+
+// GetIntList returns the items of a list value as ints
+// ok is false if the key is missing, isn't a list, or holds anything but numbers.
+func (m PSLMap) GetIntList(key string) (ints []int, ok bool) {
+	var items []interface{}
+	switch v := m[key].(type) {
+	case PSLList:
+		items = v
+	case []interface{}:
+		items = v
+	default:
+		return nil, false
+	}
+	ints = make([]int, len(items))
+	for i, item := range items {
+		n, isNumber := pslNumber(item)
+		if !isNumber {
+			return nil, false
+		}
+		ints[i] = int(n)
+	}
+	return ints, true
+}
@@ -0,0 +1,103 @@
+package pawscript
+
+import (
+	"testing"
+	"time"
+)
+
+// typedTestMap holds one entry per case; a missing key uses the default
+func typedTestMap(t *testing.T, value string) PSLMap {
+	t.Helper()
+	if value == "" {
+		return PSLMap{}
+	}
+	return parsePSLMap(t, "(v: "+value+")")
+}
+
+func TestGetDuration(t *testing.T) {
+	const def = 7 * time.Second
+	tests := []struct {
+		name  string
+		value string // PSL text; empty for a missing key
+		want  time.Duration
+	}{
+		{"text", `"1m30s"`, 90 * time.Second},
+		{"milliseconds", `"250ms"`, 250 * time.Millisecond},
+		{"padded", `" 2h "`, 2 * time.Hour},
+		{"seconds", `5`, 5 * time.Second},
+		{"fractional seconds", `1.5`, 1500 * time.Millisecond},
+		{"missing", ``, def},
+		{"no unit", `"90"`, def},
+		{"unknown unit", `"3 days"`, def},
+		{"garbage", `"soon"`, def},
+		{"list", `(1, 2)`, def},
+		{"map", `(a: 1)`, def},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := typedTestMap(t, tt.value).GetDuration("v", def); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetColor(t *testing.T) {
+	def := PSLColor{1, 2, 3}
+	tests := []struct {
+		name  string
+		value string
+		want  PSLColor
+	}{
+		{"long", `"#FF8000"`, PSLColor{255, 128, 0}},
+		{"lower case", `"#0a0b0c"`, PSLColor{10, 11, 12}},
+		{"short", `"#F80"`, PSLColor{255, 136, 0}},
+		{"padded", `" #000 "`, PSLColor{}},
+		{"missing", ``, def},
+		{"no hash", `"FF8000"`, def},
+		{"wrong length", `"#FF80"`, def},
+		{"not hex", `"#GG0000"`, def},
+		{"sign", `"#+12345"`, def},
+		{"name", `"red"`, def},
+		{"number", `255`, def},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := typedTestMap(t, tt.value).GetColor("v", def); got != tt.want {
+				t.Errorf("got %s, want %s", got.Hex(), tt.want.Hex())
+			}
+		})
+	}
+}
+
+func TestGetSize(t *testing.T) {
+	const def = 42
+	tests := []struct {
+		name  string
+		value string
+		want  int64
+	}{
+		{"bytes", `512`, 512},
+		{"bytes suffix", `"100B"`, 100},
+		{"kilobytes", `"512K"`, 512 << 10},
+		{"fractional", `"2.5MB"`, 5 << 19},
+		{"binary suffix", `"1GiB"`, 1 << 30},
+		{"lower case", `"3tb"`, 3 << 40},
+		{"space before unit", `"4 kb"`, 4 << 10},
+		{"no unit", `"77"`, 77},
+		{"missing", ``, def},
+		{"unknown unit", `"5PB"`, def},
+		{"no number", `"MB"`, def},
+		{"two points", `"1.2.3K"`, def},
+		{"negative", `-5`, def},
+		{"too large", `"9999999T"`, def},
+		{"list", `(1)`, def},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := typedTestMap(t, tt.value).GetSize("v", def); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package pawscript
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// pslWatchSettle is how long a watched file must go unchanged before it is read,
+// so an editor's truncate-then-write save is seen once, complete
+const pslWatchSettle = 100 * time.Millisecond
+
+// Watch calls onChange whenever the PSL file at path comes to hold different settings
+// The file's directory is watched rather than the file, so saves that replace the file
+// (as many editors do) are followed. Contents that don't parse, such as a half-written
+// file, are skipped until they do. Changes are judged against the last version seen,
+// starting from m, so rewriting the same settings calls nothing. onChange runs on the
+// watcher's goroutine with a freshly parsed map; m itself is never modified.
+// Call stop to end the watch.
+func (m PSLMap) Watch(path string, onChange func(config PSLMap)) (stop func(), err error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := watcher.Add(filepath.Dir(absPath)); err != nil {
+		_ = watcher.Close()
+		return nil, err
+	}
+
+	last := ClonePSLValue(m).(PSLMap)
+	done := make(chan struct{})
+	go func() {
+		defer watcher.Close()
+		var settle <-chan time.Time
+		for {
+			select {
+			case <-done:
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) == absPath && event.Op != fsnotify.Chmod {
+					settle = time.After(pslWatchSettle)
+				}
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			case <-settle:
+				settle = nil
+				data, err := os.ReadFile(absPath)
+				if err != nil {
+					continue // Removed, or mid-replace; a later event will follow
+				}
+				config, err := ParsePSL(string(data))
+				if err != nil || len(DiffPSL(last, config)) == 0 {
+					continue
+				}
+				last = ClonePSLValue(config).(PSLMap)
+				onChange(config)
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }, nil
+}
@@ -0,0 +1,134 @@
+package pawscript
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Resource quotas
+// Quotas stop a runaway script from exhausting its host: the files it holds open,
+// the bytes it writes to files and the subprocesses it starts are counted per
+// interpreter. A command that would go over a quota fails (so scripts can handle it
+// with else) and logs an error wrapping ErrQuotaExceeded.
+
+// ResourceQuotas caps the resources an interpreter's scripts may use; 0 means no cap
+type ResourceQuotas struct {
+	MaxOpenFiles    int   // Files open at once
+	MaxBytesWritten int64 // Bytes written to files, in total
+	MaxProcesses    int   // Subprocesses started by exec, in total
+}
+
+// ErrQuotaExceeded is wrapped by every QuotaError
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// QuotaError reports which quota a command would have exceeded
+type QuotaError struct {
+	Resource string // "open files", "bytes written" or "processes"
+	Limit    int64
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%v: %s (limit %d)", ErrQuotaExceeded, e.Resource, e.Limit)
+}
+
+func (e *QuotaError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// quotaTracker counts resource use against the configured quotas
+type quotaTracker struct {
+	mu     sync.Mutex
+	limits ResourceQuotas
+	usage  ResourceQuotas // Current use, in the same fields as the limits
+}
+
+func newQuotaTracker(limits *ResourceQuotas) *quotaTracker {
+	q := &quotaTracker{}
+	q.setLimits(limits)
+	return q
+}
+
+func (q *quotaTracker) setLimits(limits *ResourceQuotas) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.limits = ResourceQuotas{}
+	if limits != nil {
+		q.limits = *limits
+	}
+}
+
+// openFile takes one open-file slot; call closeFile when the file is closed
+func (q *quotaTracker) openFile() error {
+	if q == nil {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.limits.MaxOpenFiles > 0 && q.usage.MaxOpenFiles >= q.limits.MaxOpenFiles {
+		return &QuotaError{"open files", int64(q.limits.MaxOpenFiles)}
+	}
+	q.usage.MaxOpenFiles++
+	return nil
+}
+
+func (q *quotaTracker) closeFile() {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.usage.MaxOpenFiles > 0 {
+		q.usage.MaxOpenFiles--
+	}
+}
+
+// write charges n bytes about to be written, refusing the whole write if it won't fit
+func (q *quotaTracker) write(n int64) error {
+	if q == nil {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.limits.MaxBytesWritten > 0 && q.usage.MaxBytesWritten+n > q.limits.MaxBytesWritten {
+		return &QuotaError{"bytes written", q.limits.MaxBytesWritten}
+	}
+	q.usage.MaxBytesWritten += n
+	return nil
+}
+
+// startProcess counts a subprocess about to be started
+func (q *quotaTracker) startProcess() error {
+	if q == nil {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.limits.MaxProcesses > 0 && q.usage.MaxProcesses >= q.limits.MaxProcesses {
+		return &QuotaError{"processes", int64(q.limits.MaxProcesses)}
+	}
+	q.usage.MaxProcesses++
+	return nil
+}
+
+// quotaWriter charges everything written through it to the bytes-written quota
+type quotaWriter struct {
+	w     io.Writer
+	quota *quotaTracker
+}
+
+func (qw quotaWriter) Write(p []byte) (int, error) {
+	if err := qw.quota.write(int64(len(p))); err != nil {
+		return 0, err
+	}
+	return qw.w.Write(p)
+}
+
+// QuotaUsage returns the resources scripts are using, in the fields of ResourceQuotas:
+// files open now, bytes written so far and subprocesses started so far
+func (ps *PawScript) QuotaUsage() ResourceQuotas {
+	ps.quotas.mu.Lock()
+	defer ps.quotas.mu.Unlock()
+	return ps.quotas.usage
+}
@@ -0,0 +1,79 @@
+package pawscript
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestQuotaExhaustion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "in.txt")
+	if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("open files", func(t *testing.T) {
+		ps := New(&Config{Quotas: &ResourceQuotas{MaxOpenFiles: 1}, Stderr: io.Discard})
+		ps.RegisterStandardLibrary([]string{})
+		open := `IMPORT files; file "` + filepath.ToSlash(path) + `"`
+		if result := ps.Execute(open); result != BoolStatus(true) {
+			t.Fatalf("first file: %v", result)
+		}
+		if result := ps.Execute(open); result != BoolStatus(false) {
+			t.Errorf("second file: got %v, want false", result)
+		}
+		if usage := ps.QuotaUsage(); usage.MaxOpenFiles != 1 {
+			t.Errorf("open files = %d, want 1", usage.MaxOpenFiles)
+		}
+	})
+
+	t.Run("bytes written", func(t *testing.T) {
+		quota := newQuotaTracker(&ResourceQuotas{MaxBytesWritten: 10})
+		file, err := os.Create(filepath.Join(t.TempDir(), "out.txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		stored := NewStoredFile(file, "out.txt", "w")
+		stored.quota = quota
+		defer stored.Close()
+
+		if err := stored.Write("123456"); err != nil {
+			t.Fatal(err)
+		}
+		// A write that doesn't fit is refused whole
+		err = stored.Write("12345")
+		var quotaErr *QuotaError
+		if !errors.As(err, &quotaErr) || !errors.Is(err, ErrQuotaExceeded) || quotaErr.Resource != "bytes written" {
+			t.Fatalf("got %v, want a bytes written QuotaError", err)
+		}
+		if err := stored.Write("1234"); err != nil {
+			t.Errorf("write filling the quota: %v", err)
+		}
+		if quota.usage.MaxBytesWritten != 10 {
+			t.Errorf("bytes written = %d, want 10", quota.usage.MaxBytesWritten)
+		}
+	})
+
+	t.Run("processes", func(t *testing.T) {
+		quota := newQuotaTracker(&ResourceQuotas{MaxProcesses: 2})
+		for i := 0; i < 2; i++ {
+			if err := quota.startProcess(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := quota.startProcess(); !errors.Is(err, ErrQuotaExceeded) {
+			t.Errorf("got %v, want ErrQuotaExceeded", err)
+		}
+	})
+
+	t.Run("no limit", func(t *testing.T) {
+		quota := newQuotaTracker(nil)
+		for i := 0; i < 100; i++ {
+			if err := quota.openFile(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+}
@@ -28,6 +28,15 @@ const (
 	replColorElide       = "\x1b[97;41m"
 )
 
+// Bracketed paste (mode 2004): while the prompt is up, the terminal wraps pasted
+// text in start/end markers so a multi-line paste isn't run line by line
+const (
+	replPasteModeOn  = "\x1b[?2004h"
+	replPasteModeOff = "\x1b[?2004l"
+	replPasteStart   = "\x1b[200~"
+	replPasteEnd     = "\x1b[201~"
+)
+
 // REPLConfig configures the REPL behavior
 type REPLConfig struct {
 	Debug        bool
@@ -35,6 +44,7 @@ type REPLConfig struct {
 	OptLevel     int
 	ShowBanner   bool              // Whether to show the startup banner
 	IOConfig     *IOChannelConfig  // Optional IO channels (for GUI terminals)
+	Dialogs      DialogHost        // Native dialogs for the gui:: commands (nil = no GUI)
 }
 
 // REPL provides an interactive Read-Eval-Print Loop for PawScript
@@ -64,6 +74,10 @@ type REPL struct {
 	// Readline-only mode support
 	readlineOnly    bool                   // When true, processInput returns input instead of executing
 	readlineChan    chan string            // Channel for returning completed input in readline-only mode
+	// Bracketed paste state
+	pasting      bool   // Between paste start and end markers
+	pasteBuf     []byte // Pasted bytes received so far
+	pendingInput []byte // Start of a paste marker split across HandleInput calls
 }
 
 // NewREPL creates a new REPL instance
@@ -89,6 +103,7 @@ func NewREPL(config REPLConfig, output func(string)) *REPL {
 		ContextLines:         2,
 		FileAccess:           fileAccess,
 		OptLevel:             OptimizationLevel(config.OptLevel),
+		Dialogs:              config.Dialogs,
 	})
 
 	// Register standard library with IO channels if provided
@@ -155,19 +170,24 @@ func (r *REPL) Start() {
 		r.output("PawScript Interactive Mode. Type 'exit' or 'quit' to leave.\r\n\r\n")
 	}
 
+	r.output(replPasteModeOn)
 	r.printPrompt()
 }
 
 // Stop ends the REPL session
 func (r *REPL) Stop() {
 	r.mu.Lock()
-	defer r.mu.Unlock()
+	wasRunning := r.running
 	if r.running {
 		r.running = false
 		close(r.quitChan)
 		// Save command history to file
 		saveReplHistory(r.history)
 	}
+	r.mu.Unlock()
+	if wasRunning {
+		r.output(replPasteModeOff)
+	}
 }
 
 // IsRunning returns whether the REPL is active
@@ -194,6 +214,7 @@ func (r *REPL) StartReadline() {
 	r.readlineOnly = true
 	r.readlineChan = make(chan string, 1)
 	r.mu.Unlock()
+	r.output(replPasteModeOn)
 	r.printPrompt()
 }
 
@@ -351,11 +372,42 @@ func (r *REPL) HandleInput(data []byte) bool {
 	}
 	r.mu.Unlock()
 
+	// Finish a paste marker that arrived split across reads
+	if len(r.pendingInput) > 0 {
+		data = append(r.pendingInput, data...)
+		r.pendingInput = nil
+	}
+
 	i := 0
 	for i < len(data) {
 		b := data[i]
 		i++
 
+		// Collect pasted text until the end marker
+		if r.pasting {
+			r.pasteBuf = append(r.pasteBuf, b)
+			if content, done := strings.CutSuffix(string(r.pasteBuf), replPasteEnd); done {
+				r.pasting = false
+				r.pasteBuf = nil
+				r.handlePaste(content)
+			}
+			continue
+		}
+
+		// Paste start marker (kept for the next call if it was cut off)
+		if b == 0x1b {
+			rest := string(data[i-1:])
+			if strings.HasPrefix(rest, replPasteStart) {
+				i += len(replPasteStart) - 1
+				r.pasting = true
+				continue
+			}
+			if len(rest) < len(replPasteStart) && strings.HasPrefix(replPasteStart, rest) {
+				r.pendingInput = append([]byte(nil), rest...)
+				break
+			}
+		}
+
 		// Handle escape sequences
 		if b == 0x1b && i < len(data) && data[i] == '[' {
 			escStart := i - 1 // Position of ESC
@@ -1203,25 +1255,7 @@ func (r *REPL) insertChar(ch rune) {
 }
 
 func (r *REPL) handleEnter() {
-	// If input was scrolled/elided, re-echo the full line before newline
-	inputWidth := r.getInputAreaWidth()
-	wasScrolled := r.scrollOffset > 0 || len(r.currentLine) > inputWidth
-
-	if wasScrolled && len(r.currentLine) > 0 {
-		// Move cursor back to start of input area (after prompt)
-		// Clear from cursor to end of line, then print full input
-		r.output("\r")          // Go to start of line
-		r.printPrompt()         // Re-print prompt
-		r.output("\x1b[K")      // Clear to end of line (CSI K)
-		r.output(replColorReset) // Reset to default color
-		// Print full input (this may wrap naturally)
-		r.output(string(r.currentLine))
-	}
-
-	r.output("\r\n")
-
-	// Reset scroll state for next input
-	r.scrollOffset = 0
+	r.finishDisplayLine()
 
 	// Flush output before potentially blocking execution
 	// This ensures the newline appears before async operations like msleep
@@ -1252,6 +1286,61 @@ func (r *REPL) handleEnter() {
 	}
 }
 
+// finishDisplayLine ends the input line on screen, moving to the next line
+// If the input was scrolled or elided, the full line is echoed first.
+func (r *REPL) finishDisplayLine() {
+	inputWidth := r.getInputAreaWidth()
+	wasScrolled := r.scrollOffset > 0 || len(r.currentLine) > inputWidth
+
+	if wasScrolled && len(r.currentLine) > 0 {
+		// Move cursor back to start of input area (after prompt)
+		// Clear from cursor to end of line, then print full input
+		r.output("\r")          // Go to start of line
+		r.printPrompt()         // Re-print prompt
+		r.output("\x1b[K")      // Clear to end of line (CSI K)
+		r.output(replColorReset) // Reset to default color
+		// Print full input (this may wrap naturally)
+		r.output(string(r.currentLine))
+	}
+
+	r.output("\r\n")
+
+	// Reset scroll state for next input
+	r.scrollOffset = 0
+}
+
+// handlePaste inserts bracketed-paste text at the cursor
+// Pasted lines become continuation lines of the current input, so nothing runs until
+// Enter submits the whole paste at once. A trailing newline in the paste is dropped.
+func (r *REPL) handlePaste(text string) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	text = strings.TrimSuffix(text, "\n")
+
+	tail := append([]rune(nil), r.currentLine[r.cursorPos:]...)
+	r.currentLine = r.currentLine[:r.cursorPos]
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			r.cursorPos = len(r.currentLine)
+			r.redrawLine()
+			r.finishDisplayLine()
+			r.lines = append(r.lines, string(r.currentLine))
+			r.currentLine = nil
+			r.printPrompt()
+		}
+		for _, ch := range line {
+			// Keep tabs; drop other control characters (including escapes)
+			if ch == '\t' || ch >= 32 && ch != 0x7f {
+				r.currentLine = append(r.currentLine, ch)
+			}
+		}
+	}
+	r.cursorPos = len(r.currentLine)
+	r.currentLine = append(r.currentLine, tail...)
+	r.inHistory = false
+	r.redrawLine()
+}
+
 func (r *REPL) processInput(input string) {
 	trimmed := strings.TrimSpace(input)
 
@@ -1271,6 +1360,8 @@ func (r *REPL) processInput(input string) {
 	r.mu.Unlock()
 
 	if readlineOnly {
+		// The caller runs the input; pastes go to it unbracketed until the next prompt
+		r.output(replPasteModeOff)
 		// Send to channel (non-blocking with select to avoid deadlock if nobody is reading)
 		select {
 		case r.readlineChan <- input:
@@ -1296,6 +1387,9 @@ func (r *REPL) processInput(input string) {
 	r.busy = true
 	r.mu.Unlock()
 
+	// The script gets pastes unbracketed, unless it turns the mode on itself
+	r.output(replPasteModeOff)
+
 	// Run execution in a goroutine so GUI remains responsive
 	go func() {
 		// Execute - blocks until complete (including async operations like msleep)
@@ -1314,6 +1408,7 @@ func (r *REPL) processInput(input string) {
 		r.mu.Unlock()
 
 		if running {
+			r.output(replPasteModeOn)
 			r.printPrompt()
 		}
 	}()
@@ -0,0 +1,128 @@
+package pawscript
+
+import (
+	"path/filepath"
+)
+
+// Sandbox changes between runs
+// Hosts that keep one interpreter for several scripts can loosen or tighten its
+// sandbox before each run with a SandboxDelta, instead of building a new interpreter.
+// The FileAccessConfig the host passed in is never modified; applying a delta swaps
+// in a changed copy. Change the sandbox between runs, not while a script is running.
+
+// ListDelta changes one FileAccessConfig list; the steps apply in field order
+type ListDelta struct {
+	Unrestrict bool     // Make the list nil, allowing everything (Set, Add and Remove are ignored)
+	Set        []string // Replace the list, if non-nil (an empty Set allows nothing)
+	Add        []string // Append entries not already present (ignored while the list is nil)
+	Remove     []string // Remove entries
+}
+
+// SandboxDelta describes changes to each list in a FileAccessConfig
+type SandboxDelta struct {
+	ReadRoots    ListDelta
+	WriteRoots   ListDelta
+	ExecRoots    ListDelta
+	AllowedHosts ListDelta
+	DeniedHosts  ListDelta
+}
+
+// Clone returns a deep copy of the config, keeping nil lists nil
+func (fa *FileAccessConfig) Clone() *FileAccessConfig {
+	if fa == nil {
+		return nil
+	}
+	clone := func(list []string) []string {
+		if list == nil {
+			return nil
+		}
+		return append([]string{}, list...)
+	}
+	return &FileAccessConfig{
+		ReadRoots:    clone(fa.ReadRoots),
+		WriteRoots:   clone(fa.WriteRoots),
+		ExecRoots:    clone(fa.ExecRoots),
+		AllowedHosts: clone(fa.AllowedHosts),
+		DeniedHosts:  clone(fa.DeniedHosts),
+	}
+}
+
+// Apply returns a copy of fa with the delta applied
+// A nil fa is the unrestricted sandbox: all of its lists start out nil.
+func (d SandboxDelta) Apply(fa *FileAccessConfig) *FileAccessConfig {
+	result := fa.Clone()
+	if result == nil {
+		result = &FileAccessConfig{}
+	}
+	sameRoot := func(a, b string) bool { return pathEquals(filepath.Clean(a), filepath.Clean(b)) }
+	sameHost := func(a, b string) bool { return a == b }
+	result.ReadRoots = d.ReadRoots.apply(result.ReadRoots, sameRoot)
+	result.WriteRoots = d.WriteRoots.apply(result.WriteRoots, sameRoot)
+	result.ExecRoots = d.ExecRoots.apply(result.ExecRoots, sameRoot)
+	result.AllowedHosts = d.AllowedHosts.apply(result.AllowedHosts, sameHost)
+	result.DeniedHosts = d.DeniedHosts.apply(result.DeniedHosts, sameHost)
+	return result
+}
+
+// apply changes one list, comparing entries with same
+func (d ListDelta) apply(list []string, same func(a, b string) bool) []string {
+	if d.Unrestrict {
+		return nil
+	}
+	if d.Set != nil {
+		list = append([]string{}, d.Set...)
+	}
+	if list == nil {
+		return nil
+	}
+	contains := func(entries []string, entry string) bool {
+		for _, e := range entries {
+			if same(e, entry) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, entry := range d.Add {
+		if !contains(list, entry) {
+			list = append(list, entry)
+		}
+	}
+	kept := []string{}
+	for _, entry := range list {
+		if !contains(d.Remove, entry) {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
+
+// FileAccess returns a copy of the interpreter's current sandbox (nil = unrestricted)
+func (ps *PawScript) FileAccess() *FileAccessConfig {
+	if ps.config == nil {
+		return nil
+	}
+	return ps.config.FileAccess.Clone()
+}
+
+// SetFileAccess replaces the interpreter's sandbox; nil removes all restrictions
+// The config is copied, so later changes to fa don't affect the interpreter.
+func (ps *PawScript) SetFileAccess(fa *FileAccessConfig) {
+	config := Config{}
+	if ps.config != nil {
+		config = *ps.config
+	}
+	config.FileAccess = fa.Clone()
+	ps.config = &config
+}
+
+// ApplySandboxDelta changes the interpreter's sandbox and returns the result
+func (ps *PawScript) ApplySandboxDelta(delta SandboxDelta) *FileAccessConfig {
+	var current *FileAccessConfig
+	if ps.config != nil {
+		current = ps.config.FileAccess
+	}
+	updated := delta.Apply(current)
+	ps.SetFileAccess(updated)
+	return updated.Clone()
+}
@@ -0,0 +1,122 @@
+package pawscript
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ErrSecretNotFound is returned by a SecretStore when no secret exists for a key
+var ErrSecretNotFound = errors.New("secret not found")
+
+// SecretStore is a backend for the secret_get/secret_set/secret_delete commands
+// The default implementation uses the OS keychain (macOS Keychain, libsecret,
+// or Windows Credential Manager). Hosts can supply their own via Config.SecretStore.
+type SecretStore interface {
+	Get(service, key string) (string, error)
+	Set(service, key, value string) error
+	Delete(service, key string) error
+}
+
+// NewKeychainStore returns a SecretStore backed by the operating system keychain
+func NewKeychainStore() SecretStore {
+	return keychainStore{}
+}
+
+// secretServiceName returns the keychain service name for a script namespace
+// Every script gets its own service so scripts cannot read each other's secrets
+func secretServiceName(namespace string) string {
+	if namespace == "" {
+		namespace = "default"
+	}
+	return "pawscript:" + namespace
+}
+
+// keychainValuePrefix marks a value stored hex-encoded by encodeKeychainValue
+const keychainValuePrefix = "pawscript-hex:"
+
+// encodeKeychainValue returns the form a value is stored in the macOS Keychain
+// security prints a password with non-printable bytes as hex, which can't be told
+// from a password that is hex, so values are stored hex-encoded behind a marker
+func encodeKeychainValue(value string) string {
+	return keychainValuePrefix + hex.EncodeToString([]byte(value))
+}
+
+// decodeKeychainValue returns the value stored by encodeKeychainValue
+// Values without the marker were stored by other tools and are returned as printed.
+func decodeKeychainValue(stored string) (string, error) {
+	encoded, ok := strings.CutPrefix(stored, keychainValuePrefix)
+	if !ok {
+		return stored, nil
+	}
+	value, err := hex.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("malformed keychain value: %w", err)
+	}
+	return string(value), nil
+}
+
+// runSecretTool runs a keychain helper program, feeding stdin and returning stdout less its final newline
+// The exit error is returned so callers can map "not found" exit codes
+func runSecretTool(stdin string, name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return "", &secretToolError{code: exitErr.ExitCode(), msg: msg}
+			}
+			return "", &secretToolError{code: exitErr.ExitCode(), msg: err.Error()}
+		}
+		return "", err
+	}
+	return trimToolNewline(string(out)), nil
+}
+
+// trimToolNewline removes the newline a helper prints after its output
+// Only one is removed: a stored value may itself end with newlines or spaces.
+func trimToolNewline(out string) string {
+	if trimmed, ok := strings.CutSuffix(out, "\n"); ok {
+		return strings.TrimSuffix(trimmed, "\r")
+	}
+	return out
+}
+
+// secretToolError records a failed keychain helper invocation
+type secretToolError struct {
+	code int
+	msg  string
+}
+
+func (e *secretToolError) Error() string {
+	return e.msg
+}
+
+// secretStore returns the configured secret backend, defaulting to the OS keychain
+func (ps *PawScript) secretStore() SecretStore {
+	if ps.config != nil && ps.config.SecretStore != nil {
+		return ps.config.SecretStore
+	}
+	return NewKeychainStore()
+}
+
+// secretService returns the keychain service name for this interpreter's scripts
+// Uses Config.SecretNamespace, falling back to the script directory
+func (ps *PawScript) secretService() string {
+	namespace := ""
+	if ps.config != nil {
+		namespace = ps.config.SecretNamespace
+		if namespace == "" {
+			namespace = ps.config.ScriptDir
+		}
+	}
+	return secretServiceName(namespace)
+}
@@ -0,0 +1,50 @@
+//go:build darwin
+
+package pawscript
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// keychainStore stores secrets in the macOS Keychain using the security tool
+type keychainStore struct{}
+
+// securityNotFound is the exit code security uses when an item doesn't exist
+const securityNotFound = 44
+
+// securityQuote quotes a value for security's interactive command parser
+func securityQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func (keychainStore) Get(service, key string) (string, error) {
+	out, err := runSecretTool("", "security", "find-generic-password", "-s", service, "-a", key, "-w")
+	var toolErr *secretToolError
+	if errors.As(err, &toolErr) && toolErr.code == securityNotFound {
+		return "", ErrSecretNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return decodeKeychainValue(out)
+}
+
+func (keychainStore) Set(service, key, value string) error {
+	// Pass the value hex-encoded over stdin (security -i) so it never appears in process args
+	cmd := fmt.Sprintf("add-generic-password -U -s %s -a %s -X %s\n",
+		securityQuote(service), securityQuote(key), hex.EncodeToString([]byte(encodeKeychainValue(value))))
+	_, err := runSecretTool(cmd, "security", "-i")
+	return err
+}
+
+func (keychainStore) Delete(service, key string) error {
+	_, err := runSecretTool("", "security", "delete-generic-password", "-s", service, "-a", key)
+	var toolErr *secretToolError
+	if errors.As(err, &toolErr) && toolErr.code == securityNotFound {
+		return ErrSecretNotFound
+	}
+	return err
+}
@@ -0,0 +1,16 @@
+//go:build js
+
+package pawscript
+
+import "fmt"
+
+// keychainStore is unavailable in the browser; hosts must supply Config.SecretStore
+type keychainStore struct{}
+
+var errNoKeychain = fmt.Errorf("no OS keychain available on this platform")
+
+func (keychainStore) Get(service, key string) (string, error) { return "", errNoKeychain }
+
+func (keychainStore) Set(service, key, value string) error { return errNoKeychain }
+
+func (keychainStore) Delete(service, key string) error { return errNoKeychain }
@@ -0,0 +1,59 @@
+package pawscript
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestKeychainValueRoundTrip(t *testing.T) {
+	values := []string{
+		"",
+		"hunter2",
+		"deadbeef", // Looks like hex
+		hex.EncodeToString([]byte{0x00, 0x01, 0xff}), // Hex of non-printable bytes
+		"\x00\x01\xff",
+		keychainValuePrefix + "zz",
+	}
+	for _, value := range values {
+		got, err := decodeKeychainValue(encodeKeychainValue(value))
+		if err != nil {
+			t.Errorf("%q: %v", value, err)
+			continue
+		}
+		if got != value {
+			t.Errorf("round trip of %q gave %q", value, got)
+		}
+	}
+}
+
+func TestKeychainValueUnmarked(t *testing.T) {
+	// Values stored by other tools come back as security printed them
+	got, err := decodeKeychainValue("000102")
+	if err != nil || got != "000102" {
+		t.Errorf("got %q, %v; want \"000102\"", got, err)
+	}
+	if _, err := decodeKeychainValue(keychainValuePrefix + "xyz"); err == nil {
+		t.Error("expected an error for a malformed marked value")
+	}
+}
+
+func TestTrimToolNewline(t *testing.T) {
+	tests := []struct {
+		out, want string
+	}{
+		{"hunter2\n", "hunter2"},
+		{"hunter2\r\n", "hunter2"},
+		{"hunter2", "hunter2"},
+		{"", ""},
+		{"\n", ""},
+		{"two lines\n\n", "two lines\n"},
+		{"ends in a space \n", "ends in a space "},
+		{" padded\t\n", " padded\t"},
+		{"carriage\r", "carriage\r"},
+	}
+	for _, tt := range tests {
+		if got := trimToolNewline(tt.out); got != tt.want {
+			t.Errorf("trimToolNewline(%q) = %q, want %q", tt.out, got, tt.want)
+		}
+	}
+}
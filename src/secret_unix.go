@@ -0,0 +1,54 @@
+//go:build !darwin && !windows && !js
+
+package pawscript
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+)
+
+// keychainStore stores secrets via libsecret using the secret-tool helper
+type keychainStore struct{}
+
+// checkSecretTool reports a clear error when libsecret's CLI isn't installed
+func checkSecretTool() error {
+	if _, err := exec.LookPath("secret-tool"); err != nil {
+		return fmt.Errorf("secret-tool not found (install libsecret-tools)")
+	}
+	return nil
+}
+
+func (keychainStore) Get(service, key string) (string, error) {
+	if err := checkSecretTool(); err != nil {
+		return "", err
+	}
+	out, err := runSecretTool("", "secret-tool", "lookup", "service", service, "account", key)
+	var toolErr *secretToolError
+	if errors.As(err, &toolErr) && toolErr.code == 1 {
+		return "", ErrSecretNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+func (keychainStore) Set(service, key, value string) error {
+	if err := checkSecretTool(); err != nil {
+		return err
+	}
+	// secret-tool reads the secret from stdin, keeping it out of process args
+	label := fmt.Sprintf("%s: %s", service, key)
+	_, err := runSecretTool(value, "secret-tool", "store", "--label="+label, "service", service, "account", key)
+	return err
+}
+
+func (s keychainStore) Delete(service, key string) error {
+	// secret-tool clear succeeds even when nothing matches, so check first
+	if _, err := s.Get(service, key); err != nil {
+		return err
+	}
+	_, err := runSecretTool("", "secret-tool", "clear", "service", service, "account", key)
+	return err
+}
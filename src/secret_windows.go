@@ -0,0 +1,108 @@
+//go:build windows
+
+package pawscript
+
+import (
+	"errors"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// keychainStore stores secrets in the Windows Credential Manager
+type keychainStore struct{}
+
+// credential mirrors the Win32 CREDENTIALW structure
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        windows.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+)
+
+var (
+	modAdvapi32    = windows.NewLazySystemDLL("advapi32.dll")
+	procCredReadW  = modAdvapi32.NewProc("CredReadW")
+	procCredWriteW = modAdvapi32.NewProc("CredWriteW")
+	procCredDelete = modAdvapi32.NewProc("CredDeleteW")
+	procCredFree   = modAdvapi32.NewProc("CredFree")
+)
+
+// credTarget builds the Credential Manager target name for a service and key
+func credTarget(service, key string) (*uint16, error) {
+	return windows.UTF16PtrFromString(service + ":" + key)
+}
+
+func (keychainStore) Get(service, key string) (string, error) {
+	target, err := credTarget(service, key)
+	if err != nil {
+		return "", err
+	}
+	var cred *credential
+	r, _, callErr := procCredReadW.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred)))
+	if r == 0 {
+		if errors.Is(callErr, windows.ERROR_NOT_FOUND) {
+			return "", ErrSecretNotFound
+		}
+		return "", callErr
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+
+	blob := unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)
+	return string(blob), nil
+}
+
+func (keychainStore) Set(service, key, value string) error {
+	target, err := credTarget(service, key)
+	if err != nil {
+		return err
+	}
+	userName, err := windows.UTF16PtrFromString(key)
+	if err != nil {
+		return err
+	}
+	blob := []byte(value)
+	cred := credential{
+		Type:               credTypeGeneric,
+		TargetName:         target,
+		UserName:           userName,
+		CredentialBlobSize: uint32(len(blob)),
+		Persist:            credPersistLocalMachine,
+	}
+	if len(blob) > 0 {
+		cred.CredentialBlob = &blob[0]
+	}
+	r, _, callErr := procCredWriteW.Call(uintptr(unsafe.Pointer(&cred)), 0)
+	if r == 0 {
+		return callErr
+	}
+	return nil
+}
+
+func (keychainStore) Delete(service, key string) error {
+	target, err := credTarget(service, key)
+	if err != nil {
+		return err
+	}
+	r, _, callErr := procCredDelete.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0)
+	if r == 0 {
+		if errors.Is(callErr, windows.ERROR_NOT_FOUND) {
+			return ErrSecretNotFound
+		}
+		return callErr
+	}
+	return nil
+}
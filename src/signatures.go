@@ -0,0 +1,137 @@
+package pawscript
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Signed scripts
+// With Config.RequireSignature set, ExecuteFile and include only run a script with a
+// detached signature beside it ("game.paw" is signed by "game.paw.sig") made by a
+// trusted key. Signatures and keys are ed25519, base64 encoded. Trusted public keys
+// are read from ~/.paw/trusted_keys, one per line, optionally followed by a comment;
+// blank lines and lines starting with # are ignored.
+
+// SignatureExt is appended to a script's filename to find its detached signature
+const SignatureExt = ".sig"
+
+// ErrUnsigned is wrapped by errors for scripts without a signature file
+var ErrUnsigned = errors.New("script is not signed")
+
+// ErrUntrustedSignature is wrapped by errors for signatures no trusted key made
+var ErrUntrustedSignature = errors.New("script signature is not from a trusted key")
+
+// DefaultTrustedKeysPath returns ~/.paw/trusted_keys
+func DefaultTrustedKeysPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".paw", "trusted_keys")
+}
+
+// LoadTrustedKeys reads a trusted keys file; a missing file has no keys
+func LoadTrustedKeys(path string) ([]ed25519.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var keys []ed25519.PublicKey
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		key, err := DecodePublicKey(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, lineNum, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, scanner.Err()
+}
+
+// EncodePublicKey formats a public key for a trusted keys file
+func EncodePublicKey(key ed25519.PublicKey) string {
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+// DecodePublicKey parses a public key written by EncodePublicKey
+func DecodePublicKey(text string) (ed25519.PublicKey, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(text))
+	if err != nil || len(data) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid ed25519 public key")
+	}
+	return ed25519.PublicKey(data), nil
+}
+
+// EncodeSigningKey formats a private key (as its seed) for saving to a key file
+func EncodeSigningKey(key ed25519.PrivateKey) string {
+	return base64.StdEncoding.EncodeToString(key.Seed())
+}
+
+// DecodeSigningKey parses a private key written by EncodeSigningKey
+func DecodeSigningKey(text string) (ed25519.PrivateKey, error) {
+	seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(text))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid ed25519 signing key")
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// SignScript returns the detached signature file contents for a script
+func SignScript(content []byte, key ed25519.PrivateKey) []byte {
+	return []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(key, content)) + "\n")
+}
+
+// VerifyScriptSignature checks a detached signature against the trusted keys
+func VerifyScriptSignature(content, signature []byte, keys []ed25519.PublicKey) error {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return fmt.Errorf("invalid script signature")
+	}
+	for _, key := range keys {
+		if ed25519.Verify(key, content, sig) {
+			return nil
+		}
+	}
+	return ErrUntrustedSignature
+}
+
+// verifyScriptFile checks a script's signature before it runs, if signatures are required
+func (ps *PawScript) verifyScriptFile(content, filename string) error {
+	if ps.config == nil || !ps.config.RequireSignature {
+		return nil
+	}
+	if filename == "" {
+		return fmt.Errorf("%w: no script file to find a signature beside", ErrUnsigned)
+	}
+	signature, err := os.ReadFile(filename + SignatureExt)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %s not found", ErrUnsigned, filepath.Base(filename)+SignatureExt)
+	}
+	if err != nil {
+		return err
+	}
+	keys := ps.config.TrustedKeys
+	if keys == nil {
+		if keys, err = LoadTrustedKeys(DefaultTrustedKeysPath()); err != nil {
+			return fmt.Errorf("trusted keys: %v", err)
+		}
+	}
+	if err := VerifyScriptSignature([]byte(content), signature, keys); err != nil {
+		return fmt.Errorf("%s: %w", filepath.Base(filename), err)
+	}
+	return nil
+}
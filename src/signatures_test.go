@@ -0,0 +1,56 @@
+package pawscript
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRequireSignature(t *testing.T) {
+	trustedPub, trustedKey, _ := ed25519.GenerateKey(nil)
+	_, otherKey, _ := ed25519.GenerateKey(nil)
+	script := "x: 1; true"
+
+	tests := []struct {
+		name      string
+		signature []byte // nil for no signature file
+		content   string
+		ok        bool
+		wantErr   error // Wrapped by the error when not ok, if set
+	}{
+		{"signed", SignScript([]byte(script), trustedKey), script, true, nil},
+		{"unsigned", nil, script, false, ErrUnsigned},
+		{"untrusted key", SignScript([]byte(script), otherKey), script, false, ErrUntrustedSignature},
+		{"changed after signing", SignScript([]byte(script), trustedKey), script + "; y: 2", false, ErrUntrustedSignature},
+		{"garbled signature", []byte("not base64!"), script, false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "script.paw")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if tt.signature != nil {
+				if err := os.WriteFile(path+SignatureExt, tt.signature, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			ps := New(&Config{RequireSignature: true, TrustedKeys: []ed25519.PublicKey{trustedPub}, Stderr: io.Discard})
+			ps.RegisterStandardLibrary([]string{})
+
+			err := ps.verifyScriptFile(tt.content, path)
+			if tt.ok && err != nil {
+				t.Fatalf("verify: %v", err)
+			}
+			if !tt.ok && (err == nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+				t.Fatalf("verify: got %v, want %v", err, tt.wantErr)
+			}
+			if got := ps.ExecuteFile(tt.content, path); got != BoolStatus(tt.ok) {
+				t.Errorf("ExecuteFile: got %v, want %v", got, tt.ok)
+			}
+		})
+	}
+}
@@ -225,6 +225,10 @@ func (s *ExecutionState) SetResult(value interface{}) {
 			// Files need special handling - register if not already stored
 			ref := s.executor.RegisterObject(v, ObjFile)
 			value = ref
+		case *StoredImage:
+			// Images are mutable and passed by reference like files
+			ref := s.executor.RegisterObject(v, ObjImage)
+			value = ref
 		case []interface{}:
 			// Convert raw slice to StoredList (this is OK for new list creation)
 			list := NewStoredListWithoutRefs(v)
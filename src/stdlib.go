@@ -5,6 +5,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"unicode"
 )
 
 // DisplayColorConfig holds ANSI color codes for colored output
@@ -171,6 +172,34 @@ func escapePSLString(s string, asciiOnly ...bool) string {
 	return sb.String()
 }
 
+// formatPSLKey formats a named item's key for PSL: bare when it is an identifier
+// or a number, quoted otherwise, so keys such as "a b" or "k:v" read back as they were
+func formatPSLKey(key string) string {
+	if isDigits(key) {
+		return key
+	}
+	for i, c := range key {
+		if c == '_' || unicode.IsLetter(c) || (i > 0 && unicode.IsDigit(c)) {
+			continue
+		}
+		return "\"" + escapePSLString(key) + "\""
+	}
+	return key
+}
+
+// isDigits reports whether s is a non-empty run of ASCII digits
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
 // escapeNonASCII escapes non-ASCII characters in a string using \uXXXX or \UXXXXXXXX.
 // This is used when utf8: false is specified in the string command.
 func escapeNonASCII(s string) string {
@@ -430,7 +459,7 @@ func formatListForDisplay(list StoredList, exec ...*Executor) string {
 			}
 
 			// Format as "key: value"
-			parts = append(parts, key+": "+valueStr)
+			parts = append(parts, formatPSLKey(key)+": "+valueStr)
 		}
 	}
 
@@ -542,7 +571,7 @@ func formatListForDisplayPretty(list StoredList, indent int) string {
 			default:
 				valueStr = fmt.Sprintf("%v", v)
 			}
-			parts = append(parts, key+": "+valueStr)
+			parts = append(parts, formatPSLKey(key)+": "+valueStr)
 		}
 	}
 
@@ -1052,9 +1081,16 @@ func (ps *PawScript) RegisterStandardLibraryWithIO(scriptArgs []string, ioConfig
 
 	// Register auxiliary libraries AFTER PopulateDefaultImports
 	// These are available via IMPORT but not auto-imported
-	ps.RegisterMathLib()    // math:: (trig functions, constants)
-	ps.RegisterFilesLib()   // files:: (file system operations)
-	ps.RegisterBitwiseLib() // bitwise:: (bitwise operations)
+	ps.RegisterMathLib()     // math:: (trig functions, constants)
+	ps.RegisterFilesLib()    // files:: (file system operations)
+	ps.RegisterBitwiseLib()  // bitwise:: (bitwise operations)
+	ps.RegisterI18nLib()     // i18n:: (locale formatting, message catalogs)
+	ps.RegisterCompressLib() // compress:: (gzip/zstd compression)
+	ps.RegisterXMLLib()      // xml:: (XML parsing, XPath-lite queries)
+	ps.RegisterAudioLib()    // audio:: (tones and WAV playback, -tags audio)
+	ps.RegisterImageLib()    // image:: (PNG/JPEG loading, pixels, resize, save)
+	ps.RegisterCanvasLib()   // canvas:: (drawing, half-block/sixel rendering)
+	ps.RegisterGUILib()      // gui:: (native dialogs, with Config.Dialogs)
 
 	// Populate IO module with native stdin/stdout/stderr/stdio channels
 	// Uses custom channels from ioConfig if provided
@@ -1390,6 +1426,8 @@ func getTypeName(val interface{}) string {
 		return "fiber"
 	case *StoredFile:
 		return "file"
+	case *StoredImage:
+		return "image"
 	case StoredList:
 		// Struct definitions are just lists with __size
 		return "list"
@@ -1428,6 +1466,8 @@ func getTypeName(val interface{}) string {
 			return "channel"
 		case ObjFile:
 			return "file"
+		case ObjImage:
+			return "image"
 		default:
 			return "object"
 		}
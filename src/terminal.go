@@ -22,11 +22,15 @@ type TerminalCapabilities struct {
 	SupportsANSI bool   // true if ANSI escape codes are supported
 	SupportsColor bool  // true if color output is supported
 	ColorDepth   int    // 0=none, 8=basic, 16=extended, 256=256color, 24=truecolor
+	SupportsSixel bool  // true if sixel graphics are supported
 
 	// Screen dimensions
 	Width  int // columns
 	Height int // rows
 
+	// Appearance
+	DarkTheme bool // true if the background is dark (scripts pick readable colors)
+
 	// Input capabilities
 	SupportsInput bool // true if this channel can receive input
 	EchoEnabled   bool // true if input should be echoed (duplex mode)
@@ -34,6 +38,9 @@ type TerminalCapabilities struct {
 
 	// Custom metadata (for host-provided channels)
 	Metadata map[string]interface{}
+
+	// system is true for the detected system terminal, whose size is re-queried on demand
+	system bool
 }
 
 // NewTerminalCapabilities creates a new capabilities struct with defaults
@@ -47,6 +54,7 @@ func NewTerminalCapabilities() *TerminalCapabilities {
 		ColorDepth:    0,
 		Width:         80,
 		Height:        24,
+		DarkTheme:     true,
 		SupportsInput: false,
 		EchoEnabled:   true,
 		LineMode:      true,
@@ -83,6 +91,7 @@ func DetectSystemTerminalCapabilities() *TerminalCapabilities {
 		// Normal terminal detection
 		caps.SupportsANSI = detectANSISupport(caps.TermType, caps.IsTerminal)
 		caps.SupportsColor, caps.ColorDepth = detectColorSupport(caps.TermType)
+		caps.SupportsSixel = detectSixelSupport(caps.TermType)
 
 		// Detect screen size
 		width, height, err := term.GetSize(int(os.Stdout.Fd()))
@@ -97,9 +106,27 @@ func DetectSystemTerminalCapabilities() *TerminalCapabilities {
 	caps.EchoEnabled = true
 	caps.LineMode = true
 
+	caps.DarkTheme = detectDarkTheme()
+	caps.system = true
+
 	return caps
 }
 
+// detectDarkTheme guesses whether the terminal background is dark
+// Uses COLORFGBG ("fg;bg", set by rxvt, Konsole, and others); assumes dark when unknown
+func detectDarkTheme() bool {
+	colorfgbg := os.Getenv("COLORFGBG")
+	if colorfgbg == "" {
+		return true
+	}
+	parts := strings.Split(colorfgbg, ";")
+	switch parts[len(parts)-1] {
+	case "7", "15":
+		return false
+	}
+	return true
+}
+
 // detectANSISupport checks if the terminal likely supports ANSI escape codes
 func detectANSISupport(termType string, isTerminal bool) bool {
 	if !isTerminal {
@@ -165,6 +192,23 @@ func detectANSISupportForRedirect(termType string) bool {
 	return true
 }
 
+// detectSixelSupport checks if the terminal is known to display sixel graphics
+// Terminals don't advertise sixel in TERM reliably, so TERM_PROGRAM is checked too
+func detectSixelSupport(termType string) bool {
+	termLower := strings.ToLower(termType)
+	for _, t := range []string{"sixel", "mlterm", "foot", "contour", "yaft"} {
+		if strings.Contains(termLower, t) {
+			return true
+		}
+	}
+
+	switch os.Getenv("TERM_PROGRAM") {
+	case "WezTerm", "mintty", "iTerm.app":
+		return true
+	}
+	return false
+}
+
 // detectColorSupport checks if terminal supports color and returns depth
 func detectColorSupport(termType string) (supportsColor bool, depth int) {
 	termLower := strings.ToLower(termType)
@@ -222,12 +266,15 @@ func (tc *TerminalCapabilities) Clone() *TerminalCapabilities {
 		SupportsANSI:  tc.SupportsANSI,
 		SupportsColor: tc.SupportsColor,
 		ColorDepth:    tc.ColorDepth,
+		SupportsSixel: tc.SupportsSixel,
 		Width:         tc.Width,
 		Height:        tc.Height,
+		DarkTheme:     tc.DarkTheme,
 		SupportsInput: tc.SupportsInput,
 		EchoEnabled:   tc.EchoEnabled,
 		LineMode:      tc.LineMode,
 		Metadata:      make(map[string]interface{}),
+		system:        tc.system,
 	}
 
 	for k, v := range tc.Metadata {
@@ -237,6 +284,30 @@ func (tc *TerminalCapabilities) Clone() *TerminalCapabilities {
 	return clone
 }
 
+// toStoredList returns a snapshot of the capabilities as a list of named values
+// Used by commands that report terminal capabilities to scripts
+func (tc *TerminalCapabilities) toStoredList() StoredList {
+	if tc == nil {
+		return NewStoredListWithNamed(nil, nil)
+	}
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+
+	return NewStoredListWithNamed(nil, map[string]interface{}{
+		"type":        QuotedString(tc.TermType),
+		"terminal":    tc.IsTerminal,
+		"redirected":  tc.IsRedirected,
+		"ansi":        tc.SupportsANSI,
+		"color":       tc.SupportsColor,
+		"color_depth": int64(tc.ColorDepth),
+		"sixel":       tc.SupportsSixel,
+		"width":       int64(tc.Width),
+		"height":      int64(tc.Height),
+		"dark":        tc.DarkTheme,
+		"input":       tc.SupportsInput,
+	})
+}
+
 // SetSize updates the terminal dimensions
 func (tc *TerminalCapabilities) SetSize(width, height int) {
 	tc.mu.Lock()
@@ -252,6 +323,41 @@ func (tc *TerminalCapabilities) GetSize() (width, height int) {
 	return tc.Width, tc.Height
 }
 
+// SetDarkTheme updates whether the terminal background is dark
+// GUI terminals call this when their theme changes
+func (tc *TerminalCapabilities) SetDarkTheme(dark bool) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.DarkTheme = dark
+}
+
+// IsDarkTheme returns whether the terminal background is dark
+func (tc *TerminalCapabilities) IsDarkTheme() bool {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	return tc.DarkTheme
+}
+
+// refreshSize re-queries the size of the system terminal so it tracks resizes
+// Host-provided capabilities are updated by their hosts and are left alone. The lock
+// SetSize takes is held throughout, so a concurrent SetSize isn't overwritten with an
+// older size.
+func (tc *TerminalCapabilities) refreshSize() {
+	if tc == nil {
+		return
+	}
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if !tc.system || tc.IsRedirected {
+		return
+	}
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err == nil && width > 0 && height > 0 {
+		tc.Width = width
+		tc.Height = height
+	}
+}
+
 // systemTerminalCaps is the singleton for system terminal capabilities
 var systemTerminalCaps *TerminalCapabilities
 var systemTerminalCapsOnce sync.Once
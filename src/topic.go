@@ -0,0 +1,144 @@
+package pawscript
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// TopicBus routes messages published on named topics to pattern subscriptions
+// Topic names are dot-separated segments ("events.ui.click"). In a pattern,
+// * matches exactly one segment and a final ** matches one or more remaining segments.
+// Each subscription is an ordinary channel, so it can be received from, selected on,
+// fanned out further with channel_subscribe, or given an overflow policy.
+type TopicBus struct {
+	mu   sync.Mutex
+	subs []*topicSubscription
+}
+
+type topicSubscription struct {
+	pattern string
+	ch      *StoredChannel
+}
+
+// NewTopicBus creates an empty topic bus
+func NewTopicBus() *TopicBus {
+	return &TopicBus{}
+}
+
+// validateTopic checks a topic name or pattern for empty segments and misplaced wildcards
+func validateTopic(name string, pattern bool) error {
+	if name == "" {
+		return fmt.Errorf("topic name is empty")
+	}
+	segments := strings.Split(name, ".")
+	for i, seg := range segments {
+		switch {
+		case seg == "":
+			return fmt.Errorf("topic %q has an empty segment", name)
+		case !pattern && strings.Contains(seg, "*"):
+			return fmt.Errorf("topic %q contains a wildcard; only subscriptions may use patterns", name)
+		case seg == "**" && i != len(segments)-1:
+			return fmt.Errorf("pattern %q: ** is only allowed as the last segment", name)
+		case seg != "*" && seg != "**" && strings.Contains(seg, "*"):
+			return fmt.Errorf("pattern %q: wildcards must be whole segments", name)
+		}
+	}
+	return nil
+}
+
+// MatchTopic reports whether a topic name matches a subscription pattern
+func MatchTopic(pattern, topic string) bool {
+	pSegs := strings.Split(pattern, ".")
+	tSegs := strings.Split(topic, ".")
+	for i, seg := range pSegs {
+		if seg == "**" {
+			return len(tSegs) > i
+		}
+		if i >= len(tSegs) || (seg != "*" && seg != tSegs[i]) {
+			return false
+		}
+	}
+	return len(pSegs) == len(tSegs)
+}
+
+// Subscribe creates a channel that receives messages published on topics matching pattern
+func (b *TopicBus) Subscribe(pattern string, bufferSize int) (*StoredChannel, error) {
+	if err := validateTopic(pattern, true); err != nil {
+		return nil, err
+	}
+	ch := NewStoredChannel(bufferSize)
+	b.mu.Lock()
+	b.subs = append(b.subs, &topicSubscription{pattern: pattern, ch: ch})
+	b.mu.Unlock()
+	return ch, nil
+}
+
+// Unsubscribe removes a subscription channel from the bus and closes it
+// Returns false if the channel was not a subscription
+func (b *TopicBus) Unsubscribe(ch *StoredChannel) bool {
+	b.mu.Lock()
+	found := false
+	for i, sub := range b.subs {
+		if sub.ch == ch {
+			b.subs = append(b.subs[:i], b.subs[i+1:]...)
+			found = true
+			break
+		}
+	}
+	b.mu.Unlock()
+	if found {
+		_ = ChannelClose(ch)
+	}
+	return found
+}
+
+// Patterns returns the patterns of the open subscriptions, in subscription order
+func (b *TopicBus) Patterns() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	patterns := make([]string, 0, len(b.subs))
+	for _, sub := range b.subs {
+		if ChannelIsOpened(sub.ch) {
+			patterns = append(patterns, sub.pattern)
+		}
+	}
+	return patterns
+}
+
+// Publish sends a message to every subscription matching topic and returns how many accepted it
+// message is called once per subscriber so each gets its own value (object references
+// must not be shared between receivers). Subscriptions closed by their owner are dropped.
+func (b *TopicBus) Publish(topic string, message func() interface{}) (int, error) {
+	if err := validateTopic(topic, false); err != nil {
+		return 0, err
+	}
+
+	// Collect matches under the lock, but send without it: a subscription with
+	// the block overflow policy may wait for its receiver
+	b.mu.Lock()
+	var targets []*StoredChannel
+	open := b.subs[:0]
+	for _, sub := range b.subs {
+		if !ChannelIsOpened(sub.ch) {
+			continue
+		}
+		open = append(open, sub)
+		if MatchTopic(sub.pattern, topic) {
+			targets = append(targets, sub.ch)
+		}
+	}
+	for i := len(open); i < len(b.subs); i++ {
+		b.subs[i] = nil
+	}
+	b.subs = open
+	b.mu.Unlock()
+
+	delivered := 0
+	for _, ch := range targets {
+		if ChannelSend(ch, message()) == nil {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
@@ -2,6 +2,7 @@ package pawscript
 
 import (
 	"context"
+	"crypto/ed25519"
 	"fmt"
 	"io"
 	"math/rand"
@@ -533,6 +534,9 @@ type FileAccessConfig struct {
 	ReadRoots  []string // Directories allowed for read access (empty = no access)
 	WriteRoots []string // Directories allowed for write access (empty = no access)
 	ExecRoots  []string // Directories allowed for exec command (empty = no access)
+
+	AllowedHosts []string // Host patterns scripts may connect to (nil = any host, empty = none)
+	DeniedHosts  []string // Host patterns refused even if allowed (see CheckHost)
 }
 
 // Config holds configuration for PawScript
@@ -551,12 +555,26 @@ type Config struct {
 	AllowMacros          bool
 	ShowErrorContext     bool
 	ContextLines         int
-	OptLevel             OptimizationLevel // AST caching level (default: OptimizeBasic)
-	Stdin                io.Reader         // Custom stdin reader (default: os.Stdin)
-	Stdout               io.Writer         // Custom stdout writer (default: os.Stdout)
-	Stderr               io.Writer         // Custom stderr writer (default: os.Stderr)
-	FileAccess           *FileAccessConfig // File system access control (nil = unrestricted)
-	ScriptDir            string            // Directory containing the script being executed
+	OptLevel             OptimizationLevel   // AST caching level (default: OptimizeBasic)
+	Stdin                io.Reader           // Custom stdin reader (default: os.Stdin)
+	Stdout               io.Writer           // Custom stdout writer (default: os.Stdout)
+	Stderr               io.Writer           // Custom stderr writer (default: os.Stderr)
+	FileAccess           *FileAccessConfig   // File system access control (nil = unrestricted)
+	Permissions          *PermissionProfile  // Command restrictions (nil = none)
+	Quotas               *ResourceQuotas     // Caps on open files, bytes written and processes (nil = none)
+	AuditLimit           int                 // Sandbox audit entries kept for AuditLog (0 = no audit log)
+	PermissionPrompt     PermissionPrompt    // Asked about file access outside the roots (nil = deny)
+	DryRun               bool                // Record file/exec/network access instead of performing it (see DryRunReport)
+	RequireSignature     bool                // Only run script files signed by a trusted key
+	TrustedKeys          []ed25519.PublicKey // Keys for RequireSignature (nil = read ~/.paw/trusted_keys)
+	EnvAllowlist         []string            // Environment variables scripts and exec can see (nil = all, see DefaultEnvAllowlist)
+	ExecHook             ExecHook            // Inspects, refuses or rewrites each exec (nil = none)
+	Debugger             *Debugger           // Pauses scripts at breakpoints and steps (nil = none)
+	Dialogs              DialogHost          // Native dialogs for the gui:: commands (nil = no GUI)
+	ScriptDir            string              // Directory containing the script being executed
+	Locale               string              // Locale for i18n formatting and catalogs (empty = detect from environment)
+	SecretNamespace      string              // Keychain namespace for secret_get/secret_set (empty = ScriptDir)
+	SecretStore          SecretStore         // Backend for secret commands (nil = OS keychain)
 }
 
 // DefaultConfig returns default configuration
@@ -724,6 +742,9 @@ type ChannelMessage struct {
 type StoredChannel struct {
 	mu              sync.RWMutex
 	BufferSize      int
+	Overflow        ChannelOverflow // What sends do when the buffer is full (default: error)
+	Stats           ChannelCounters // Message traffic counters (see ChannelGetStats)
+	journal         *channelJournal // Durable channels: log of queued messages (nil otherwise)
 	Messages        []ChannelMessage
 	Subscribers     map[int]*StoredChannel // Map of subscriber ID to subscriber endpoint
 	NextSubscriberID int
@@ -742,6 +763,7 @@ type StoredChannel struct {
 	NativeClose     func() error                    // Native close handler
 	NativeLen       func() int                      // Native length handler (for Go channel backing)
 	NativeFlush     func() error                    // Native flush handler (waits for pending output)
+	sendDone        <-chan struct{}                 // done of the ChannelSendUntil calling NativeSend
 	// Terminal capabilities associated with this channel
 	// Allows channels to report their own ANSI/color/size support
 	// If nil, system terminal capabilities are used as fallback
@@ -856,6 +878,7 @@ type StoredFile struct {
 	Path     string    // Original path used to open the file
 	Mode     string    // "r", "w", "a", "rw"
 	IsClosed bool
+	quota    *quotaTracker // Quotas charged for this file (nil = none)
 }
 
 // NewStoredFile creates a new file handle
@@ -876,6 +899,7 @@ func (f *StoredFile) Close() error {
 		return nil
 	}
 	f.IsClosed = true
+	f.quota.closeFile()
 	if f.File != nil {
 		return f.File.Close()
 	}
@@ -943,6 +967,9 @@ func (f *StoredFile) Write(s string) error {
 	if f.IsClosed || f.File == nil {
 		return fmt.Errorf("file is closed")
 	}
+	if err := f.quota.write(int64(len(s))); err != nil {
+		return err
+	}
 	_, err := f.File.WriteString(s)
 	return err
 }
@@ -1022,6 +1049,9 @@ func (f *StoredFile) WriteBytes(data []byte) error {
 	if f.IsClosed || f.File == nil {
 		return fmt.Errorf("file is closed")
 	}
+	if err := f.quota.write(int64(len(data))); err != nil {
+		return err
+	}
 	_, err := f.File.Write(data)
 	return err
 }